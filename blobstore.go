@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ServiceWeaver/weaver/internal/blob"
+)
+
+// BlobStore is a built-in component that stores blobs, like uploaded files or
+// images, in a flat namespace of slash-separated keys like "images/cat.png".
+//
+// The storage backend is selected in the BlobStore component's section of the
+// config file. For example, the following config stores blobs in an S3 bucket:
+//
+//	["github.com/ServiceWeaver/weaver/BlobStore"]
+//	backend = "s3"
+//	bucket = "my-bucket"
+//	region = "us-east-1"
+//
+// The supported backends are:
+//
+//   - "memory": blobs are held in memory. This is the default. Every replica
+//     of the BlobStore component has its own blobs, so the memory backend is
+//     only suitable for tests, like those run with weavertest or the
+//     simulator, and single process deployments.
+//   - "disk": blobs are stored as files in the local directory named by dir.
+//   - "s3": blobs are stored in the Amazon S3 bucket named by bucket, or in a
+//     bucket served by any S3-compatible API at endpoint.
+//   - "gcs": blobs are stored in the Google Cloud Storage bucket named by
+//     bucket, accessed with an HMAC key through the S3-compatible XML API.
+//
+// A component uses a BlobStore like any other component:
+//
+//	type server struct {
+//	    weaver.Implements[weaver.Main]
+//	    blobs weaver.Ref[weaver.BlobStore]
+//	}
+//
+//	func (s *server) upload(ctx context.Context, name string, data []byte) error {
+//	    return s.blobs.Get().Put(ctx, "uploads/"+name, data)
+//	}
+type BlobStore interface {
+	// Get returns the contents of the blob with the provided key, or an error
+	// that wraps ErrBlobNotFound if the blob doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put stores data in the blob with the provided key, replacing any
+	// previous contents.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Delete deletes the blob with the provided key. Deleting a blob that
+	// doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys of all blobs that start with the provided prefix,
+	// in lexicographic order.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// ErrBlobNotFound is returned by BlobStore.Get when a blob doesn't exist.
+var ErrBlobNotFound error = blob.ErrNotFound
+
+// blobStoreConfig configures the BlobStore component.
+type blobStoreConfig struct {
+	Backend         string `toml:"backend"`           // "memory", "disk", "s3", or "gcs"
+	Dir             string `toml:"dir"`               // directory of the disk backend
+	Bucket          string `toml:"bucket"`            // bucket of the s3 and gcs backends
+	Prefix          string `toml:"prefix"`            // prefix of object names in the bucket
+	Region          string `toml:"region"`            // region of the s3 backend
+	Endpoint        string `toml:"endpoint"`          // API endpoint of the s3 and gcs backends
+	AccessKeyEnv    string `toml:"access_key_env"`    // env var holding the access key
+	SecretKeyEnv    string `toml:"secret_key_env"`    // env var holding the secret key
+	SessionTokenEnv string `toml:"session_token_env"` // env var holding the s3 session token
+}
+
+// blobStore is the implementation of the BlobStore component.
+type blobStore struct {
+	Implements[BlobStore]
+	WithConfig[blobStoreConfig]
+	store blob.Store
+}
+
+var _ BlobStore = &blobStore{}
+
+// Init initializes the BlobStore component.
+func (b *blobStore) Init(context.Context) error {
+	store, err := newBlobBackend(b.Config())
+	if err != nil {
+		return fmt.Errorf("BlobStore: %w", err)
+	}
+	b.store = store
+	return nil
+}
+
+// newBlobBackend returns the storage backend selected by the provided config.
+func newBlobBackend(config *blobStoreConfig) (blob.Store, error) {
+	// env returns the value of the environment variable named by the
+	// provided config field, or by def if the field is empty.
+	env := func(name, def string) string {
+		if name == "" {
+			name = def
+		}
+		return os.Getenv(name)
+	}
+
+	switch config.Backend {
+	case "", "memory":
+		return blob.NewMemory(), nil
+	case "disk":
+		return blob.NewDisk(config.Dir)
+	case "s3":
+		region := config.Region
+		if region == "" {
+			region = os.Getenv("AWS_REGION")
+		}
+		return blob.NewS3(blob.S3Options{
+			Endpoint:     config.Endpoint,
+			Region:       region,
+			Bucket:       config.Bucket,
+			Prefix:       config.Prefix,
+			AccessKey:    env(config.AccessKeyEnv, "AWS_ACCESS_KEY_ID"),
+			SecretKey:    env(config.SecretKeyEnv, "AWS_SECRET_ACCESS_KEY"),
+			SessionToken: env(config.SessionTokenEnv, "AWS_SESSION_TOKEN"),
+		})
+	case "gcs":
+		return blob.NewGCS(blob.GCSOptions{
+			Endpoint: config.Endpoint,
+			Bucket:   config.Bucket,
+			Prefix:   config.Prefix,
+			AccessID: env(config.AccessKeyEnv, "GCS_HMAC_ACCESS_ID"),
+			Secret:   env(config.SecretKeyEnv, "GCS_HMAC_SECRET"),
+		})
+	default:
+		return nil, fmt.Errorf("unknown backend %q", config.Backend)
+	}
+}
+
+// Get implements the BlobStore interface.
+func (b *blobStore) Get(ctx context.Context, key string) ([]byte, error) {
+	return b.store.Get(ctx, key)
+}
+
+// Put implements the BlobStore interface.
+func (b *blobStore) Put(ctx context.Context, key string, data []byte) error {
+	return b.store.Put(ctx, key, data)
+}
+
+// Delete implements the BlobStore interface.
+func (b *blobStore) Delete(ctx context.Context, key string) error {
+	return b.store.Delete(ctx, key)
+}
+
+// List implements the BlobStore interface.
+func (b *blobStore) List(ctx context.Context, prefix string) ([]string, error) {
+	return b.store.List(ctx, prefix)
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBlobStore(t *testing.T) {
+	configs := map[string]string{
+		"memory": "",
+		"disk": fmt.Sprintf(`
+			["github.com/ServiceWeaver/weaver/BlobStore"]
+			backend = "disk"
+			dir = %q
+		`, t.TempDir()),
+	}
+
+	// We don't use the multiprocess runner because every replica of the
+	// BlobStore component has its own in-memory blobs.
+	for backend, config := range configs {
+		for _, runner := range []weavertest.Runner{weavertest.Local, weavertest.RPC} {
+			runner.Config = config
+			runner.Name = fmt.Sprintf("%s/%s", backend, runner.Name)
+			runner.Test(t, func(t *testing.T, blobs weaver.BlobStore) {
+				ctx := context.Background()
+				if _, err := blobs.Get(ctx, "missing"); !errors.Is(err, weaver.ErrBlobNotFound) {
+					t.Fatalf("Get(missing): got %v, want %v", err, weaver.ErrBlobNotFound)
+				}
+				for _, key := range []string{"a/1", "a/2", "b/1"} {
+					if err := blobs.Put(ctx, key, []byte(key)); err != nil {
+						t.Fatal(err)
+					}
+				}
+				got, err := blobs.Get(ctx, "a/2")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(got) != "a/2" {
+					t.Fatalf("Get(a/2): got %q, want %q", got, "a/2")
+				}
+				if err := blobs.Delete(ctx, "a/1"); err != nil {
+					t.Fatal(err)
+				}
+				keys, err := blobs.List(ctx, "a/")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff([]string{"a/2"}, keys); diff != "" {
+					t.Fatalf("List(a/) (-want +got):\n%s", diff)
+				}
+				if err := blobs.Put(ctx, "../escape", nil); err == nil {
+					t.Fatal("Put(../escape): unexpected success")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+)
+
+// Broadcast calls fn concurrently once for every replica of the component
+// referenced by ref, passing it a handle that sends all of its calls to that
+// replica, and combines the results of the calls that succeed with reduce.
+// Because a routed component's shards are spread across its replicas, a
+// broadcast to a routed component reaches every shard. For example, the
+// following code sums the number of entries cached by every replica of a
+// Cache component:
+//
+//	n, err := weaver.Broadcast(ctx, s.cache,
+//	    func(ctx context.Context, c Cache) (int, error) {
+//	        return c.Len(ctx)
+//	    },
+//	    func(x, y int) int { return x + y },
+//	)
+//
+// If some calls fail, Broadcast returns the reduced results of the calls
+// that succeeded, along with their errors joined together, as returned by
+// Wait. Each error is a *CallError whose Index identifies the failed replica
+// within the broadcast. If every call fails, the returned result is the zero
+// value of R.
+//
+// The set of replicas is the one known to the caller when Broadcast is
+// called; replicas that start later are not called, and replicas that stop
+// during the broadcast make their calls fail. A component that is
+// co-located with the caller, or that runs in a deployment without replicas,
+// has a single replica. Under the simulator (see package sim), every replica
+// is called, one at a time and in order, so that executions are
+// deterministic.
+func Broadcast[T, R any](ctx context.Context, ref Ref[T], fn func(context.Context, T) (R, error), reduce func(R, R) R) (R, error) {
+	var result R
+	handles := []any{ref.value}
+	if ref.replicas != nil {
+		var err error
+		handles, err = ref.replicas()
+		if err != nil {
+			return result, err
+		}
+	}
+	if len(handles) == 0 {
+		return result, fmt.Errorf("weaver.Broadcast: no replicas of %v", reflect.TypeOf((*T)(nil)).Elem())
+	}
+
+	calls := make([]*Call[R], len(handles))
+	futures := make([]Future, len(handles))
+	serial := weaver.SerialCalls(ctx)
+	for i, h := range handles {
+		h := h.(T)
+		call := func(ctx context.Context) (R, error) {
+			return fn(ctx, h)
+		}
+		if serial {
+			// The simulator runs one goroutine at a time, so the calls are
+			// made one at a time, in replica order.
+			calls[i] = &Call[R]{done: make(chan struct{})}
+			calls[i].value, calls[i].err = call(ctx)
+			close(calls[i].done)
+		} else {
+			calls[i] = Go(ctx, call)
+		}
+		futures[i] = calls[i]
+	}
+	err := Wait(ctx, futures...)
+
+	first := true
+	for _, c := range calls {
+		select {
+		case <-c.done:
+		default:
+			// ctx is done and the call hasn't finished.
+			continue
+		}
+		if c.err != nil {
+			continue
+		}
+		if first {
+			result, first = c.value, false
+		} else {
+			result = reduce(result, c.value)
+		}
+	}
+	return result, err
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestBroadcast(t *testing.T) {
+	// Every replica is an int, and the broadcast sums them up, failing on
+	// negative replicas.
+	fn := func(_ context.Context, x int) (int, error) {
+		if x < 0 {
+			return 0, fmt.Errorf("negative replica %d", x)
+		}
+		return x, nil
+	}
+	sum := func(x, y int) int { return x + y }
+
+	for _, test := range []struct {
+		name     string
+		replicas []any // nil if broadcasts aren't supported
+		want     int
+		failed   []int // indices of the failed replicas
+	}{
+		{"Unsupported", nil, 42, nil},
+		{"One", []any{1}, 1, nil},
+		{"Many", []any{1, 2, 3}, 6, nil},
+		{"SomeFail", []any{1, -2, 3, -4}, 4, []int{1, 3}},
+		{"AllFail", []any{-1, -2}, 0, []int{0, 1}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var x struct{ r Ref[int] }
+			var replicas func(reflect.Type) ([]any, error)
+			if test.replicas != nil {
+				replicas = func(reflect.Type) ([]any, error) { return test.replicas, nil }
+			}
+			if err := fillRefs(&x, getValue, replicas); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Broadcast(context.Background(), x.r, fn, sum)
+			if got != test.want {
+				t.Errorf("Broadcast: got %d, want %d", got, test.want)
+			}
+			var failed []int
+			for _, err := range unjoin(err) {
+				var callErr *CallError
+				if !errors.As(err, &callErr) {
+					t.Fatalf("Broadcast: unexpected error %v", err)
+				}
+				failed = append(failed, callErr.Index)
+			}
+			if !reflect.DeepEqual(failed, test.failed) {
+				t.Errorf("Broadcast: got failures %v, want %v", failed, test.failed)
+			}
+		})
+	}
+}
+
+func TestBroadcastNoReplicas(t *testing.T) {
+	var x struct{ r Ref[int] }
+	replicas := func(reflect.Type) ([]any, error) { return nil, nil }
+	if err := fillRefs(&x, getValue, replicas); err != nil {
+		t.Fatal(err)
+	}
+	fn := func(context.Context, int) (int, error) { return 0, nil }
+	sum := func(x, y int) int { return x + y }
+	if _, err := Broadcast(context.Background(), x.r, fn, sum); err == nil {
+		t.Fatal("Broadcast: unexpected success")
+	}
+}
+
+// unjoin returns the errors joined in err, if any.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
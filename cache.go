@@ -0,0 +1,301 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache is a built-in component that caches values by key, sharded across the
+// replicas of the application. It saves applications from hand-rolling a
+// memcache-like layer on top of their own routed components.
+//
+// Entries expire after a TTL, and every shard evicts its least recently used
+// entries once it holds more than a maximum number of entries. Both are
+// configured in the Cache component's section of the config file. For
+// example, the following config expires entries after five minutes and keeps
+// at most 100,000 entries per shard:
+//
+//	["github.com/ServiceWeaver/weaver/Cache"]
+//	ttl = "5m"
+//	max_entries = 100000
+//
+// By default, entries expire after ten minutes and shards hold at most 10,000
+// entries. Use ReadThrough to read a value from the cache, loading and caching
+// it on a miss:
+//
+//	type server struct {
+//	    weaver.Implements[weaver.Main]
+//	    cache weaver.Ref[weaver.Cache]
+//	}
+//
+//	func (s *server) profile(ctx context.Context, user string) ([]byte, error) {
+//	    return weaver.ReadThrough(ctx, s.cache.Get(), "profile/"+user, func(ctx context.Context) ([]byte, error) {
+//	        return s.db.Profile(ctx, user)
+//	    })
+//	}
+//
+// Calls for a key are routed to the same shard. When a key is invalidated,
+// the invalidation is broadcast to every shard (see Broadcast), so copies
+// left on the shards that owned the key before the routing of the shards
+// changed are dropped too.
+//
+// The cache is a best-effort cache: Get may miss on a key that was put, e.g.,
+// after it was evicted or after its shard restarted. A Put that races with an
+// Invalidate of the same key, e.g., a Put of a value loaded before the
+// underlying data changed, may leave a stale value in the cache for up to the
+// TTL.
+type Cache interface {
+	// Get returns the value cached for the provided key, and whether there
+	// is one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put caches the provided value for the provided key, replacing the
+	// value cached for it, if any.
+	Put(ctx context.Context, key string, value []byte) error
+
+	// Invalidate removes the value cached for the provided key, if any,
+	// from every shard.
+	Invalidate(ctx context.Context, key string) error
+}
+
+// ReadThrough returns the value cached for the provided key. On a miss, it
+// calls load and caches the value it returns. If the cache fails, the error
+// is returned; ReadThrough doesn't fall back to calling load, so that a cache
+// outage doesn't overload the source of the values.
+func ReadThrough(ctx context.Context, c Cache, key string, load func(context.Context) ([]byte, error)) ([]byte, error) {
+	value, ok, err := c.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return value, nil
+	}
+	value, err = load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.Put(ctx, key, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// cacheConfig configures the Cache component.
+type cacheConfig struct {
+	TTL        string `toml:"ttl"`         // entry lifetime (e.g., "5m"); defaults to 10m
+	MaxEntries int    `toml:"max_entries"` // entries per shard; defaults to 10,000
+}
+
+const (
+	// defaultCacheTTL is the default lifetime of Cache entries.
+	defaultCacheTTL = 10 * time.Minute
+
+	// defaultCacheMaxEntries is the default number of entries of a Cache
+	// shard.
+	defaultCacheMaxEntries = 10_000
+)
+
+// cache is the implementation of the Cache component.
+type cache struct {
+	Implements[Cache]
+	WithConfig[cacheConfig]
+	shards Ref[cacheShard]
+
+	ttl        time.Duration // parsed TTL
+	maxEntries int           // entries per shard
+}
+
+var _ Cache = &cache{}
+
+// Init initializes the Cache component.
+func (c *cache) Init(context.Context) error {
+	cfg := c.Config()
+	c.ttl = defaultCacheTTL
+	if cfg.TTL != "" {
+		ttl, err := time.ParseDuration(cfg.TTL)
+		if err != nil {
+			return fmt.Errorf("cache: invalid ttl %q: %w", cfg.TTL, err)
+		}
+		if ttl <= 0 {
+			return fmt.Errorf("cache: non-positive ttl %q", cfg.TTL)
+		}
+		c.ttl = ttl
+	}
+	c.maxEntries = defaultCacheMaxEntries
+	if cfg.MaxEntries < 0 {
+		return fmt.Errorf("cache: negative max_entries %d", cfg.MaxEntries)
+	}
+	if cfg.MaxEntries > 0 {
+		c.maxEntries = cfg.MaxEntries
+	}
+	return nil
+}
+
+// Get implements the Cache interface.
+func (c *cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	return c.shards.Get().Get(ctx, key)
+}
+
+// Put implements the Cache interface.
+func (c *cache) Put(ctx context.Context, key string, value []byte) error {
+	return c.shards.Get().Put(ctx, key, value, int64(c.ttl), c.maxEntries)
+}
+
+// Invalidate implements the Cache interface.
+func (c *cache) Invalidate(ctx context.Context, key string) error {
+	_, err := Broadcast(ctx, c.shards,
+		func(ctx context.Context, shard cacheShard) (struct{}, error) {
+			return struct{}{}, shard.Delete(ctx, key)
+		},
+		func(struct{}, struct{}) struct{} { return struct{}{} },
+	)
+	return err
+}
+
+// cacheShard stores a shard of the entries of the Cache component. Calls are
+// routed by key, so that every replica of the Cache component consults the
+// same cacheShard replica for a given key.
+type cacheShard interface {
+	// Get returns the unexpired value stored for the provided key, and
+	// whether there is one.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Put stores the provided value for the provided key for ttl
+	// nanoseconds, evicting the least recently used entries to keep at most
+	// maxEntries entries.
+	Put(ctx context.Context, key string, value []byte, ttl int64, maxEntries int) error
+
+	// Delete removes the value stored for the provided key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+// cacheStore is the implementation of the cacheShard component.
+type cacheStore struct {
+	Implements[cacheShard]
+	WithRouter[cacheRouter]
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // entries, by key
+	lru     *list.List               // *cacheEntry, most recently used first
+}
+
+// cacheEntry is an entry of a cacheShard.
+type cacheEntry struct {
+	key     string
+	value   []byte
+	expires int64 // expiration time, in unix nanos
+}
+
+var _ cacheShard = &cacheStore{}
+
+// Init initializes the cacheShard component.
+func (s *cacheStore) Init(context.Context) error {
+	s.entries = map[string]*list.Element{}
+	s.lru = list.New()
+	return nil
+}
+
+// Get implements the cacheShard interface.
+func (s *cacheStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	e := elem.Value.(*cacheEntry)
+	if time.Now().UnixNano() >= e.expires {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+		return nil, false, nil
+	}
+	s.lru.MoveToFront(elem)
+	return e.value, true, nil
+}
+
+// Put implements the cacheShard interface.
+func (s *cacheStore) Put(_ context.Context, key string, value []byte, ttl int64, maxEntries int) error {
+	if ttl <= 0 {
+		return fmt.Errorf("cache: non-positive ttl %d", ttl)
+	}
+	if maxEntries <= 0 {
+		return fmt.Errorf("cache: non-positive max entries %d", maxEntries)
+	}
+	expires := time.Now().UnixNano() + ttl
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		e := elem.Value.(*cacheEntry)
+		e.value = value
+		e.expires = expires
+		s.lru.MoveToFront(elem)
+	} else {
+		s.entries[key] = s.lru.PushFront(&cacheEntry{key: key, value: value, expires: expires})
+	}
+	for s.lru.Len() > maxEntries {
+		oldest := s.lru.Back()
+		s.lru.Remove(oldest)
+		delete(s.entries, oldest.Value.(*cacheEntry).key)
+	}
+	return nil
+}
+
+// Delete implements the cacheShard interface.
+func (s *cacheStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[key]; ok {
+		s.lru.Remove(elem)
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// Snapshot returns the keys stored in the shard, most recently used first.
+// The simulator records snapshots in the histories of its executions (see
+// sim.Snapshotter).
+func (s *cacheStore) Snapshot() any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := make([]string, 0, s.lru.Len())
+	for elem := s.lru.Front(); elem != nil; elem = elem.Next() {
+		keys = append(keys, elem.Value.(*cacheEntry).key)
+	}
+	return keys
+}
+
+// cacheRouter routes calls to the cacheShard component.
+type cacheRouter struct{}
+
+// Get routes calls to cacheShard.Get by key.
+func (cacheRouter) Get(_ context.Context, key string) string {
+	return key
+}
+
+// Put routes calls to cacheShard.Put by key.
+func (cacheRouter) Put(_ context.Context, key string, _ []byte, _ int64, _ int) string {
+	return key
+}
+
+// Delete routes calls to cacheShard.Delete by key.
+func (cacheRouter) Delete(_ context.Context, key string) string {
+	return key
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+)
+
+// cacheRunners are the runners used to test the Cache component. We don't use
+// the multiprocess runner because it replicates the cache shards, and a key's
+// calls may reach different replicas before routing information is available,
+// making hits approximate.
+var cacheRunners = []weavertest.Runner{weavertest.Local, weavertest.RPC}
+
+// get returns the value cached for the provided key, or "" on a miss.
+func get(t *testing.T, cache weaver.Cache, key string) string {
+	t.Helper()
+	value, ok, err := cache.Get(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		return ""
+	}
+	return string(value)
+}
+
+// put caches the provided value for the provided key.
+func put(t *testing.T, cache weaver.Cache, key, value string) {
+	t.Helper()
+	if err := cache.Put(context.Background(), key, []byte(value)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCache(t *testing.T) {
+	for _, runner := range cacheRunners {
+		runner.Test(t, func(t *testing.T, cache weaver.Cache) {
+			if got := get(t, cache, "a"); got != "" {
+				t.Fatalf("Get(a) before Put: got %q, want miss", got)
+			}
+			put(t, cache, "a", "1")
+			put(t, cache, "b", "2")
+			if got, want := get(t, cache, "a"), "1"; got != want {
+				t.Fatalf("Get(a): got %q, want %q", got, want)
+			}
+			put(t, cache, "a", "3")
+			if got, want := get(t, cache, "a"), "3"; got != want {
+				t.Fatalf("Get(a) after overwrite: got %q, want %q", got, want)
+			}
+
+			if err := cache.Invalidate(context.Background(), "a"); err != nil {
+				t.Fatal(err)
+			}
+			if got := get(t, cache, "a"); got != "" {
+				t.Fatalf("Get(a) after Invalidate: got %q, want miss", got)
+			}
+			if got, want := get(t, cache, "b"), "2"; got != want {
+				t.Fatalf("Get(b): got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	for _, runner := range cacheRunners {
+		runner.Config = `
+			["github.com/ServiceWeaver/weaver/Cache"]
+			max_entries = 2
+		`
+		runner.Test(t, func(t *testing.T, cache weaver.Cache) {
+			put(t, cache, "a", "1")
+			put(t, cache, "b", "2")
+			get(t, cache, "a") // a is now more recently used than b
+			put(t, cache, "c", "3")
+			if got := get(t, cache, "b"); got != "" {
+				t.Errorf("Get(b): got %q, want eviction", got)
+			}
+			for key, want := range map[string]string{"a": "1", "c": "3"} {
+				if got := get(t, cache, key); got != want {
+					t.Errorf("Get(%s): got %q, want %q", key, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	for _, runner := range cacheRunners {
+		runner.Config = `
+			["github.com/ServiceWeaver/weaver/Cache"]
+			ttl = "10ms"
+		`
+		runner.Test(t, func(t *testing.T, cache weaver.Cache) {
+			put(t, cache, "a", "1")
+			time.Sleep(20 * time.Millisecond)
+			if got := get(t, cache, "a"); got != "" {
+				t.Errorf("Get(a) after TTL: got %q, want miss", got)
+			}
+		})
+	}
+}
+
+func TestReadThrough(t *testing.T) {
+	for _, runner := range cacheRunners {
+		runner.Test(t, func(t *testing.T, cache weaver.Cache) {
+			ctx := context.Background()
+			loads := 0
+			load := func(context.Context) ([]byte, error) {
+				loads++
+				return []byte("loaded"), nil
+			}
+			for i := 0; i < 3; i++ {
+				value, err := weaver.ReadThrough(ctx, cache, "a", load)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got, want := string(value), "loaded"; got != want {
+					t.Fatalf("ReadThrough: got %q, want %q", got, want)
+				}
+			}
+			if loads != 1 {
+				t.Errorf("loads: got %d, want 1", loads)
+			}
+		})
+	}
+}
+
+func TestCacheInvalidConfig(t *testing.T) {
+	for _, config := range []string{
+		`ttl = "forever"`,
+		`ttl = "-1s"`,
+		`max_entries = -1`,
+	} {
+		rt := weaver.NewRuntime(weaver.RuntimeOptions{
+			Config: "[\"github.com/ServiceWeaver/weaver/Cache\"]\n" + config,
+			Quiet:  true,
+		})
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := rt.Start(ctx); err != nil {
+			cancel()
+			t.Fatal(err)
+		}
+		if _, err := weaver.Get[weaver.Cache](rt); err == nil {
+			t.Errorf("%s: unexpected success", config)
+		}
+		rt.Shutdown(ctx)
+		cancel()
+	}
+}
@@ -26,9 +26,12 @@ import (
 	"strings"
 
 	itool "github.com/ServiceWeaver/weaver/internal/tool"
+	"github.com/ServiceWeaver/weaver/internal/tool/analyze"
 	"github.com/ServiceWeaver/weaver/internal/tool/callgraph"
 	"github.com/ServiceWeaver/weaver/internal/tool/generate"
+	"github.com/ServiceWeaver/weaver/internal/tool/loadtest"
 	"github.com/ServiceWeaver/weaver/internal/tool/multi"
+	"github.com/ServiceWeaver/weaver/internal/tool/simdebug"
 	"github.com/ServiceWeaver/weaver/internal/tool/single"
 	"github.com/ServiceWeaver/weaver/internal/tool/ssh"
 	"github.com/ServiceWeaver/weaver/runtime/tool"
@@ -38,6 +41,9 @@ const usage = `USAGE
 
   weaver generate                 // weaver code generator
   weaver version                  // show weaver version
+  weaver analyze   <command> ...  // for analyzing deployed applications
+  weaver loadtest  <command> ...  // for load testing deployed applications
+  weaver sim       <command> ...  // for debugging and distributing simulations
   weaver single    <command> ...  // for single process deployments
   weaver multi     <command> ...  // for multiprocess deployments
   weaver ssh       <command> ...  // for multimachine deployments
@@ -49,8 +55,8 @@ DESCRIPTION
 
   Use the "weaver" command to deploy and manage Weaver applications.
 
-  The "weaver generate", "weaver version", "weaver single", "weaver multi", and
-  "weaver ssh" subcommands are baked in, but all other subcommands of the form
+  The "weaver generate", "weaver version", "weaver analyze", "weaver loadtest",
+  "weaver sim", "weaver single", "weaver multi", and "weaver ssh" subcommands are baked in, but all other subcommands of the form
   "weaver <deployer>" dispatch to a binary called "weaver-<deployer>".
   "weaver gke status", for example, dispatches to "weaver-gke status".
 `
@@ -66,15 +72,22 @@ func main() {
 
 	// Handle the internal deployers.
 	internals := map[string]map[string]*tool.Command{
-		"single": single.Commands,
-		"multi":  multi.Commands,
-		"ssh":    ssh.Commands,
+		"single":   single.Commands,
+		"multi":    multi.Commands,
+		"ssh":      ssh.Commands,
+		"analyze":  analyze.Commands,
+		"loadtest": loadtest.Commands,
+		"sim":      simdebug.Commands,
 	}
 
 	switch flag.Arg(0) {
 	case "generate":
 		generateFlags := flag.NewFlagSet("generate", flag.ExitOnError)
 		tags := generateFlags.String("tags", "", "Optional tags for the generate command")
+		clientSDK := generateFlags.String("client-sdk", "", "Optional directory in which to generate a client SDK")
+		public := generateFlags.String("public", "", "Optional comma-separated list of components to include in the client SDK")
+		strict := generateFlags.Bool("strict", false, "Fail on unreferenced or unreachable components and undeclared listeners")
+		configs := generateFlags.String("config", "", "Optional comma-separated list of config files checked by -strict")
 		generateFlags.Usage = func() {
 			fmt.Fprintln(os.Stderr, generate.Usage)
 		}
@@ -86,7 +99,14 @@ func main() {
 			// extra validation at some point.
 			buildTags = buildTags + "," + *tags
 		}
-		if err := generate.Generate(".", generateFlags.Args(), generate.Options{BuildTags: buildTags}); err != nil {
+		opts := generate.Options{BuildTags: buildTags, ClientSDK: *clientSDK, Strict: *strict}
+		if *public != "" {
+			opts.Public = strings.Split(*public, ",")
+		}
+		if *configs != "" {
+			opts.Configs = strings.Split(*configs, ",")
+		}
+		if err := generate.Generate(".", generateFlags.Args(), opts); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 			os.Exit(1)
 		}
@@ -128,7 +148,7 @@ Description:
 		fmt.Println(s)
 		return
 
-	case "single", "multi", "ssh":
+	case "single", "multi", "ssh", "analyze", "loadtest", "sim":
 		os.Args = os.Args[1:]
 		tool.Run("weaver "+flag.Arg(0), internals[flag.Arg(0)])
 		return
@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// Code classifies the errors returned by component method calls, like the
+// status codes of gRPC. Use CodeOf to get the code of an error, rather than
+// matching its message:
+//
+//	err := cache.Get(ctx, key)
+//	switch weaver.CodeOf(err) {
+//	case weaver.OK:
+//	    // The call succeeded.
+//	case weaver.Unavailable:
+//	    // The callee couldn't be reached. The call may be retried.
+//	case weaver.DeadlineExceeded, weaver.Canceled:
+//	    // ctx expired.
+//	default:
+//	    // ...
+//	}
+//
+// Errors raised by Service Weaver have a code: for example, a call that fails
+// because the callee's replicas are unreachable returns an error with code
+// Unavailable, and a call whose arguments or results can't be serialized
+// returns an error with code Internal. Application errors have a code if they
+// were created by Errorf, even if they are returned by a remote component.
+type Code int
+
+const (
+	OK                 Code = Code(codegen.OK)                 // no error
+	Canceled           Code = Code(codegen.Canceled)           // the caller's context was canceled
+	Unknown            Code = Code(codegen.Unknown)            // the error has no code
+	InvalidArgument    Code = Code(codegen.InvalidArgument)    // the arguments are invalid
+	DeadlineExceeded   Code = Code(codegen.DeadlineExceeded)   // the caller's deadline expired
+	NotFound           Code = Code(codegen.NotFound)           // an entity was not found
+	AlreadyExists      Code = Code(codegen.AlreadyExists)      // an entity already exists
+	PermissionDenied   Code = Code(codegen.PermissionDenied)   // the caller is not allowed
+	ResourceExhausted  Code = Code(codegen.ResourceExhausted)  // a resource or quota is exhausted
+	FailedPrecondition Code = Code(codegen.FailedPrecondition) // the system is in the wrong state
+	Aborted            Code = Code(codegen.Aborted)            // aborted, e.g., by a conflict
+	OutOfRange         Code = Code(codegen.OutOfRange)         // an argument is out of range
+	Unimplemented      Code = Code(codegen.Unimplemented)      // the method is not implemented
+	Internal           Code = Code(codegen.Internal)           // an invariant is broken
+	Unavailable        Code = Code(codegen.Unavailable)        // the callee is unavailable
+	DataLoss           Code = Code(codegen.DataLoss)           // data was lost or corrupted
+	Unauthenticated    Code = Code(codegen.Unauthenticated)    // the caller is not authenticated
+)
+
+// String returns the name of c, e.g., "Unavailable".
+func (c Code) String() string {
+	return codegen.Code(c).String()
+}
+
+// Retriable reports whether a call that failed with an error with code c may
+// succeed if it is retried as is. Only Unavailable is retriable. Service
+// Weaver retries the calls to retriable methods (see NotRetriable) that fail
+// with a retriable code.
+func (c Code) Retriable() bool {
+	return codegen.Code(c).Retriable()
+}
+
+// CodeOf returns the code of the provided error: OK if err is nil; the code of
+// the first error in err's tree that has one; Canceled or DeadlineExceeded if
+// err wraps context.Canceled or context.DeadlineExceeded; and Unknown
+// otherwise.
+func CodeOf(err error) Code {
+	return Code(codegen.CodeOf(err))
+}
+
+// Errorf returns an error with the provided code, formatted like fmt.Errorf.
+// The code is preserved when the error is returned by a remote component
+// method call:
+//
+//	func (c *cache) Get(ctx context.Context, key string) (string, error) {
+//	    if key == "" {
+//	        return "", weaver.Errorf(weaver.InvalidArgument, "empty key")
+//	    }
+//	    ...
+//	}
+func Errorf(code Code, format string, args ...any) error {
+	return codegen.Errorf(codegen.Code(code), format, args...)
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// ComponentInfo describes a component linked into the binary.
+type ComponentInfo struct {
+	Name      string       // full package-prefixed name, e.g., "example.com/mypkg/Cache"
+	Interface reflect.Type // component interface type
+	Impl      reflect.Type // implementation struct type
+	Methods   []MethodInfo // methods of the interface, sorted by name
+	Config    reflect.Type // config struct type T, if Impl embeds WithConfig[T]
+	Router    reflect.Type // router type T, if Impl embeds WithRouter[T]
+	Listeners []string     // names of the listeners of the implementation
+	Refs      []string     // full names of the components referenced by Impl with Ref fields, sorted
+}
+
+// MethodInfo describes a method of a component.
+type MethodInfo struct {
+	Name       string         // method name
+	Args       []reflect.Type // argument types, without the leading context.Context
+	Results    []reflect.Type // result types, without the trailing error
+	Retried    bool           // whether failed calls are retried; see NotRetriable
+	AtMostOnce bool           // whether calls are made at most once; see AtMostOnce
+	Routed     bool           // whether calls are routed, i.e., the router has the method
+	Directives []string       // //weaver: directives of the method, e.g., "weaver:timeout 2s"
+}
+
+// Components returns the components linked into the binary, sorted by name.
+// Components lets tools like documentation generators, admin UIs, and test
+// frameworks introspect an application's components, the schemas of their
+// methods, their configs, and how calls to them are routed, without running
+// the application. For example:
+//
+//	for _, c := range weaver.Components() {
+//	    fmt.Println(c.Name)
+//	    for _, m := range c.Methods {
+//	        fmt.Println("  ", m.Name, m.Args, m.Results)
+//	    }
+//	}
+//
+// The returned values are copies. Modifying them has no effect.
+func Components() []ComponentInfo {
+	regs := codegen.Registered()
+	infos := make([]ComponentInfo, 0, len(regs))
+	for _, reg := range regs {
+		infos = append(infos, componentInfo(reg))
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// componentInfo returns a description of the provided registered component.
+func componentInfo(reg *codegen.Registration) ComponentInfo {
+	info := ComponentInfo{
+		Name:      reg.Name,
+		Interface: reg.Iface,
+		Impl:      reg.Impl,
+		Listeners: append([]string{}, reg.Listeners...),
+	}
+
+	impl := reflect.New(reg.Impl).Interface()
+	if c, ok := impl.(interface{ getConfig() any }); ok {
+		info.Config = reflect.TypeOf(c.getConfig()).Elem()
+	}
+	if r, ok := impl.(interface{ routerType() reflect.Type }); ok {
+		info.Router = r.routerType()
+	}
+	for _, edge := range codegen.ExtractEdges([]byte(reg.RefData)) {
+		if edge[0] == reg.Name {
+			info.Refs = append(info.Refs, edge[1])
+		}
+	}
+	sort.Strings(info.Refs)
+
+	// The indices in reg.NoRetry and reg.AtMostOnce are indices of methods
+	// sorted by name, which is the order of reflect's interface methods.
+	noRetry := map[int]bool{}
+	for _, i := range reg.NoRetry {
+		noRetry[i] = true
+	}
+	atMostOnce := map[int]bool{}
+	for _, i := range reg.AtMostOnce {
+		atMostOnce[i] = true
+	}
+	for i := 0; i < reg.Iface.NumMethod(); i++ {
+		m := reg.Iface.Method(i)
+		method := MethodInfo{
+			Name:       m.Name,
+			Retried:    !noRetry[i] && !atMostOnce[i],
+			AtMostOnce: atMostOnce[i],
+		}
+		for j := 0; j < m.Type.NumIn(); j++ {
+			if t := m.Type.In(j); j > 0 || t != reflection.Type[context.Context]() {
+				method.Args = append(method.Args, t)
+			}
+		}
+		for j := 0; j < m.Type.NumOut()-1; j++ {
+			method.Results = append(method.Results, m.Type.Out(j))
+		}
+		if info.Router != nil {
+			_, method.Routed = info.Router.MethodByName(m.Name)
+		}
+		for _, d := range reg.Directives[m.Name] {
+			method.Directives = append(method.Directives, d.String())
+		}
+		info.Methods = append(info.Methods, method)
+	}
+	return info
+}
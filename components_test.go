@@ -0,0 +1,74 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestComponents(t *testing.T) {
+	components := weaver.Components()
+	if !sort.SliceIsSorted(components, func(i, j int) bool { return components[i].Name < components[j].Name }) {
+		t.Fatal("components not sorted by name")
+	}
+	byName := map[string]weaver.ComponentInfo{}
+	for _, c := range components {
+		byName[c.Name] = c
+	}
+
+	const quotaName = "github.com/ServiceWeaver/weaver/Quota"
+	const serverName = "github.com/ServiceWeaver/weaver/quotaServer"
+	quota, ok := byName[quotaName]
+	if !ok {
+		t.Fatalf("component %s not found", quotaName)
+	}
+	if quota.Interface != reflect.TypeOf((*weaver.Quota)(nil)).Elem() {
+		t.Errorf("Quota interface: got %v", quota.Interface)
+	}
+	if quota.Config == nil || quota.Config.Name() != "quotaConfig" {
+		t.Errorf("Quota config: got %v, want quotaConfig", quota.Config)
+	}
+	if quota.Router != nil {
+		t.Errorf("Quota router: got %v, want nil", quota.Router)
+	}
+	if diff := cmp.Diff([]string{serverName}, quota.Refs); diff != "" {
+		t.Errorf("Quota refs (-want +got):\n%s", diff)
+	}
+	str, integer := reflect.TypeOf(""), reflect.TypeOf(0)
+	want := []weaver.MethodInfo{{
+		Name:    "Acquire",
+		Args:    []reflect.Type{str, str, integer},
+		Results: []reflect.Type{reflect.TypeOf(false)},
+	}}
+	if diff := cmp.Diff(want, quota.Methods, cmp.Comparer(func(x, y reflect.Type) bool { return x == y })); diff != "" {
+		t.Errorf("Quota methods (-want +got):\n%s", diff)
+	}
+
+	server, ok := byName[serverName]
+	if !ok {
+		t.Fatalf("component %s not found", serverName)
+	}
+	if server.Router == nil || server.Router.Name() != "quotaRouter" {
+		t.Errorf("quotaServer router: got %v, want quotaRouter", server.Router)
+	}
+	if len(server.Methods) != 1 || !server.Methods[0].Routed {
+		t.Errorf("quotaServer methods: got %+v, want one routed method", server.Methods)
+	}
+}
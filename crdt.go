@@ -0,0 +1,555 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/uuid"
+)
+
+// CRDTState is the state of a state-based conflict-free replicated data type
+// (CRDT). Every replica of a component updates its own copy of the state, and
+// replicas merge each other's copies in the background. Because merging is
+// commutative, associative, and idempotent, replicas that have merged the
+// same updates hold the same state, no matter the order in which they merged
+// them or how many times.
+//
+// PNCounter, ORSet, and LWWMap are CRDTStates. You can implement your own.
+type CRDTState interface {
+	// Marshal encodes the state.
+	Marshal() ([]byte, error)
+
+	// Merge merges a state encoded by Marshal into the state. Merge must be
+	// commutative, associative, and idempotent.
+	Merge(data []byte) error
+}
+
+// CRDT replicates a CRDTState across the replicas of a component, with
+// eventual consistency: reads are served by the local copy of the state,
+// which reflects the local updates right away and the updates of other
+// replicas once it has gossiped with them. Use CRDTs for state where eventual
+// consistency suffices, like counters of events or sets of seen IDs, and a
+// ReplicatedStateMachine for state that must be strongly consistent.
+//
+// Replicas gossip by exchanging their states through a component method that
+// calls Exchange. For example, the following component counts hits across its
+// replicas:
+//
+//	type Hits interface {
+//	    Hit(context.Context) error
+//	    Count(context.Context) (int64, error)
+//	    Exchange(context.Context, []byte) ([]byte, error)
+//	}
+//
+//	type hits struct {
+//	    weaver.Implements[Hits]
+//	    self  weaver.Ref[Hits]
+//	    count *weaver.CRDT[*weaver.PNCounter]
+//	}
+//
+//	func (h *hits) Init(ctx context.Context) error {
+//	    h.count = weaver.NewCRDT(ctx, weaver.NewPNCounter)
+//	    weaver.GossipCRDT(h.count, h.self, time.Second, Hits.Exchange)
+//	    return nil
+//	}
+//
+//	func (h *hits) Shutdown(context.Context) error {
+//	    h.count.Close()
+//	    return nil
+//	}
+//
+//	func (h *hits) Hit(context.Context) error {
+//	    return h.count.Update(func(c *weaver.PNCounter) error {
+//	        c.Add(1)
+//	        return nil
+//	    })
+//	}
+//
+//	func (h *hits) Count(context.Context) (n int64, err error) {
+//	    err = h.count.Read(func(c *weaver.PNCounter) error {
+//	        n = c.Value()
+//	        return nil
+//	    })
+//	    return n, err
+//	}
+//
+//	func (h *hits) Exchange(_ context.Context, state []byte) ([]byte, error) {
+//	    return h.count.Exchange(state)
+//	}
+//
+// A CRDT is safe for concurrent use.
+type CRDT[T CRDTState] struct {
+	replica string
+
+	mu    sync.RWMutex
+	state T
+
+	ctx    context.Context // canceled by Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup // background gossip
+}
+
+// NewCRDT returns a CRDT with the state returned by newState. newState is
+// passed a unique ID of the replica, which states like PNCounter use to tell
+// updates of different replicas apart. The ID is the weavelet ID reported by
+// ReplicaInfo for ctx, or a random ID if ctx carries no replica information.
+func NewCRDT[T CRDTState](ctx context.Context, newState func(replica string) T) *CRDT[T] {
+	replica := uuid.NewString()
+	if r, ok := ReplicaInfo(ctx); ok && r.WeaveletID != "" {
+		replica = r.WeaveletID
+	}
+	gctx, cancel := context.WithCancel(context.Background())
+	return &CRDT[T]{
+		replica: replica,
+		state:   newState(replica),
+		ctx:     gctx,
+		cancel:  cancel,
+	}
+}
+
+// Close stops gossiping in the background, if GossipCRDT was called.
+func (c *CRDT[T]) Close() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Replica returns the ID of the replica passed to NewCRDT's newState.
+func (c *CRDT[T]) Replica() string {
+	return c.replica
+}
+
+// Update calls fn, which may modify the state, and returns its error.
+func (c *CRDT[T]) Update(fn func(T) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fn(c.state)
+}
+
+// Read calls fn, which may read the state but not modify it, and returns its
+// error.
+func (c *CRDT[T]) Read(fn func(T) error) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return fn(c.state)
+}
+
+// Exchange merges a peer's encoded state into the state, and returns the
+// encoded merged state for the peer to merge in turn. Components call it from
+// the method that replicas gossip through.
+func (c *CRDT[T]) Exchange(peer []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if peer != nil {
+		if err := c.state.Merge(peer); err != nil {
+			return nil, err
+		}
+	}
+	return c.state.Marshal()
+}
+
+// Gossip runs a round of anti-entropy with a single peer: it sends the state
+// to the peer with exchange, which calls the peer's Exchange, and merges the
+// peer's reply. Updates made while the round is in flight are kept.
+func (c *CRDT[T]) Gossip(ctx context.Context, exchange func(context.Context, []byte) ([]byte, error)) error {
+	c.mu.RLock()
+	state, err := c.state.Marshal()
+	c.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+	reply, err := exchange(ctx, state)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.state.Merge(reply)
+}
+
+// GossipCRDT makes c gossip with a random replica of the component referenced
+// by self every interval, until c is closed. exchange calls the method of the
+// component that calls Exchange, e.g., Hits.Exchange in the CRDT example.
+// Failed rounds are retried in the next interval.
+//
+// Under the simulator, which doesn't simulate background work, call Gossip
+// from a component method instead.
+func GossipCRDT[C any, T CRDTState](c *CRDT[T], self Ref[C], interval time.Duration, exchange func(C, context.Context, []byte) ([]byte, error)) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-c.ctx.Done():
+				return
+			}
+			peer := self.Get()
+			if self.replicas != nil {
+				handles, err := self.replicas()
+				if err != nil || len(handles) == 0 {
+					continue
+				}
+				peer = handles[rand.Intn(len(handles))].(C)
+			}
+			ctx, cancel := context.WithTimeout(c.ctx, interval)
+			// Errors are transient; the next round retries.
+			c.Gossip(ctx, func(ctx context.Context, state []byte) ([]byte, error) { //nolint:errcheck
+				return exchange(peer, ctx, state)
+			})
+			cancel()
+		}
+	}()
+}
+
+// PNCounter is a CRDTState that counts up and down. Every replica counts its
+// own increments and decrements, and the value of the counter is the sum of
+// the counts of every replica.
+type PNCounter struct {
+	replica string
+	counts  map[string]counterCounts // by replica
+}
+
+// counterCounts are the counts of a replica.
+type counterCounts struct {
+	inc uint64
+	dec uint64
+}
+
+var _ CRDTState = &PNCounter{}
+
+// NewPNCounter returns a zero PNCounter, updated by the provided replica.
+func NewPNCounter(replica string) *PNCounter {
+	return &PNCounter{replica: replica, counts: map[string]counterCounts{}}
+}
+
+// Add adds n, which may be negative, to the counter.
+func (c *PNCounter) Add(n int64) {
+	counts := c.counts[c.replica]
+	if n >= 0 {
+		counts.inc += uint64(n)
+	} else {
+		counts.dec += uint64(-n)
+	}
+	c.counts[c.replica] = counts
+}
+
+// Value returns the value of the counter.
+func (c *PNCounter) Value() int64 {
+	var v int64
+	for _, counts := range c.counts {
+		v += int64(counts.inc - counts.dec)
+	}
+	return v
+}
+
+// Marshal implements the CRDTState interface.
+func (c *PNCounter) Marshal() ([]byte, error) {
+	replicas := make([]string, 0, len(c.counts))
+	for r := range c.counts {
+		replicas = append(replicas, r)
+	}
+	sort.Strings(replicas)
+	enc := codegen.NewEncoder()
+	enc.Uint32(uint32(len(replicas)))
+	for _, r := range replicas {
+		enc.String(r)
+		enc.Uint64(c.counts[r].inc)
+		enc.Uint64(c.counts[r].dec)
+	}
+	return enc.Data(), nil
+}
+
+// Merge implements the CRDTState interface. It keeps the larger counts of
+// every replica, since counts only grow.
+func (c *PNCounter) Merge(data []byte) (err error) {
+	defer func() {
+		if e := codegen.CatchPanics(recover()); e != nil {
+			err = fmt.Errorf("weaver.PNCounter: merge: %w", e)
+		}
+	}()
+	dec := codegen.NewDecoder(data)
+	n := dec.Uint32()
+	merged := make(map[string]counterCounts, len(c.counts))
+	for r, counts := range c.counts {
+		merged[r] = counts
+	}
+	for i := uint32(0); i < n; i++ {
+		r := dec.String()
+		counts := counterCounts{inc: dec.Uint64(), dec: dec.Uint64()}
+		mine := merged[r]
+		merged[r] = counterCounts{inc: max(mine.inc, counts.inc), dec: max(mine.dec, counts.dec)}
+	}
+	c.counts = merged
+	return nil
+}
+
+// ORSet is a CRDTState that holds a set of elements, which must be encodable
+// with encoding/json. When one replica adds an element that another replica
+// concurrently removes, the addition wins.
+//
+// Every addition is tagged with a dot: the ID of the adding replica and the
+// number of additions it has made so far. Removing an element discards its
+// dots, and a replica remembers which dots it has seen, so that a merge can
+// tell an element removed by one replica from an element not yet added by
+// the other one.
+type ORSet[K comparable] struct {
+	replica string
+	elems   map[K]map[dot]bool // live dots, by element
+	clock   map[string]uint64  // number of additions seen, by replica
+}
+
+// dot identifies an addition to an ORSet.
+type dot struct {
+	Replica string
+	Seq     uint64
+}
+
+// setState is the encoding of an ORSet.
+type setState[K comparable] struct {
+	Clock map[string]uint64
+	Elems []setElem[K]
+}
+
+// setElem is the encoding of an element of an ORSet.
+type setElem[K comparable] struct {
+	Elem K
+	Dots []dot
+}
+
+var _ CRDTState = &ORSet[string]{}
+
+// NewORSet returns an empty Set, updated by the provided replica.
+func NewORSet[K comparable](replica string) *ORSet[K] {
+	return &ORSet[K]{replica: replica, elems: map[K]map[dot]bool{}, clock: map[string]uint64{}}
+}
+
+// Add adds an element to the set.
+func (s *ORSet[K]) Add(elem K) {
+	s.clock[s.replica]++
+	// The new dot supersedes the dots of earlier additions.
+	s.elems[elem] = map[dot]bool{{Replica: s.replica, Seq: s.clock[s.replica]}: true}
+}
+
+// Remove removes an element from the set.
+func (s *ORSet[K]) Remove(elem K) {
+	delete(s.elems, elem)
+}
+
+// Contains returns whether the set contains the provided element.
+func (s *ORSet[K]) Contains(elem K) bool {
+	_, ok := s.elems[elem]
+	return ok
+}
+
+// Len returns the number of elements in the set.
+func (s *ORSet[K]) Len() int {
+	return len(s.elems)
+}
+
+// Elements returns the elements of the set, in no particular order.
+func (s *ORSet[K]) Elements() []K {
+	elems := make([]K, 0, len(s.elems))
+	for e := range s.elems {
+		elems = append(elems, e)
+	}
+	return elems
+}
+
+// Marshal implements the CRDTState interface.
+func (s *ORSet[K]) Marshal() ([]byte, error) {
+	state := setState[K]{Clock: s.clock}
+	for e, dots := range s.elems {
+		elem := setElem[K]{Elem: e}
+		for d := range dots {
+			elem.Dots = append(elem.Dots, d)
+		}
+		state.Elems = append(state.Elems, elem)
+	}
+	return json.Marshal(state)
+}
+
+// Merge implements the CRDTState interface. A dot held by only one of the
+// sets survives unless the other set has seen it, in which case the other
+// set has removed the element.
+func (s *ORSet[K]) Merge(data []byte) error {
+	var other setState[K]
+	if err := json.Unmarshal(data, &other); err != nil {
+		return fmt.Errorf("weaver.ORSet: merge: %w", err)
+	}
+	theirs := map[K]map[dot]bool{}
+	for _, e := range other.Elems {
+		dots := map[dot]bool{}
+		for _, d := range e.Dots {
+			dots[d] = true
+		}
+		theirs[e.Elem] = dots
+	}
+	seen := func(clock map[string]uint64, d dot) bool {
+		return d.Seq <= clock[d.Replica]
+	}
+
+	merged := map[K]map[dot]bool{}
+	keep := func(e K, d dot) {
+		if merged[e] == nil {
+			merged[e] = map[dot]bool{}
+		}
+		merged[e][d] = true
+	}
+	for e, dots := range s.elems {
+		for d := range dots {
+			if theirs[e][d] || !seen(other.Clock, d) {
+				keep(e, d)
+			}
+		}
+	}
+	for e, dots := range theirs {
+		for d := range dots {
+			if !s.elems[e][d] && !seen(s.clock, d) {
+				keep(e, d)
+			}
+		}
+	}
+	s.elems = merged
+	for r, n := range other.Clock {
+		s.clock[r] = max(s.clock[r], n)
+	}
+	return nil
+}
+
+// LWWMap is a CRDTState that maps keys to values, which must be encodable with
+// encoding/json. Every write is stamped with a logical clock, and concurrent
+// writes to the same key are resolved in favor of the write with the later
+// stamp, ties broken by replica ID. Deleted keys leave behind tombstones, so
+// that deletions are merged like writes.
+type LWWMap[K comparable, V any] struct {
+	replica string
+	clock   uint64 // the latest stamp seen
+	entries map[K]mapEntry[V]
+}
+
+// mapEntry is the latest write to a key of an LWWMap.
+type mapEntry[V any] struct {
+	Value   V
+	Clock   uint64 // the write's stamp
+	Replica string // the writer
+	Deleted bool   // is the write a deletion?
+}
+
+// newer returns whether e is a later write than f.
+func (e mapEntry[V]) newer(f mapEntry[V]) bool {
+	if e.Clock != f.Clock {
+		return e.Clock > f.Clock
+	}
+	return e.Replica > f.Replica
+}
+
+// mapState is the encoding of an LWWMap.
+type mapState[K comparable, V any] struct {
+	Entries []mapStateEntry[K, V]
+}
+
+// mapStateEntry is the encoding of an entry of an LWWMap.
+type mapStateEntry[K comparable, V any] struct {
+	Key   K
+	Entry mapEntry[V]
+}
+
+var _ CRDTState = &LWWMap[string, int]{}
+
+// NewLWWMap returns an empty Map, updated by the provided replica.
+func NewLWWMap[K comparable, V any](replica string) *LWWMap[K, V] {
+	return &LWWMap[K, V]{replica: replica, entries: map[K]mapEntry[V]{}}
+}
+
+// Set maps a key to a value.
+func (m *LWWMap[K, V]) Set(key K, value V) {
+	m.clock++
+	m.entries[key] = mapEntry[V]{Value: value, Clock: m.clock, Replica: m.replica}
+}
+
+// Delete deletes a key.
+func (m *LWWMap[K, V]) Delete(key K) {
+	m.clock++
+	m.entries[key] = mapEntry[V]{Clock: m.clock, Replica: m.replica, Deleted: true}
+}
+
+// Get returns the value of a key, and whether the key is present.
+func (m *LWWMap[K, V]) Get(key K) (V, bool) {
+	e, ok := m.entries[key]
+	if !ok || e.Deleted {
+		var zero V
+		return zero, false
+	}
+	return e.Value, true
+}
+
+// Len returns the number of keys in the map.
+func (m *LWWMap[K, V]) Len() int {
+	n := 0
+	for _, e := range m.entries {
+		if !e.Deleted {
+			n++
+		}
+	}
+	return n
+}
+
+// Keys returns the keys of the map, in no particular order.
+func (m *LWWMap[K, V]) Keys() []K {
+	keys := make([]K, 0, len(m.entries))
+	for k, e := range m.entries {
+		if !e.Deleted {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Marshal implements the CRDTState interface.
+func (m *LWWMap[K, V]) Marshal() ([]byte, error) {
+	var state mapState[K, V]
+	for k, e := range m.entries {
+		state.Entries = append(state.Entries, mapStateEntry[K, V]{Key: k, Entry: e})
+	}
+	return json.Marshal(state)
+}
+
+// Merge implements the CRDTState interface. It keeps the later write to
+// every key.
+func (m *LWWMap[K, V]) Merge(data []byte) error {
+	var other mapState[K, V]
+	if err := json.Unmarshal(data, &other); err != nil {
+		return fmt.Errorf("weaver.LWWMap: merge: %w", err)
+	}
+	for _, e := range other.Entries {
+		if mine, ok := m.entries[e.Key]; !ok || e.Entry.newer(mine) {
+			m.entries[e.Key] = e.Entry
+		}
+		// Later writes of this replica must be stamped after every write
+		// it has seen.
+		m.clock = max(m.clock, e.Entry.Clock)
+	}
+	return nil
+}
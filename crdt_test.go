@@ -0,0 +1,214 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+// merge merges src into dst.
+func merge(t *testing.T, dst, src CRDTState) {
+	t.Helper()
+	data, err := src.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dst.Merge(data); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// converge runs random updates against replicas, merging random pairs of
+// replicas in between, and then merges every replica with every other one.
+func converge[T CRDTState](t *testing.T, replicas []T, update func(r *rand.Rand, state T)) {
+	t.Helper()
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		if r.Intn(4) == 0 {
+			merge(t, replicas[r.Intn(len(replicas))], replicas[r.Intn(len(replicas))])
+		} else {
+			update(r, replicas[r.Intn(len(replicas))])
+		}
+	}
+	for _, dst := range replicas {
+		for _, src := range replicas {
+			merge(t, dst, src)
+		}
+	}
+	// Merging again changes nothing.
+	for _, dst := range replicas {
+		for _, src := range replicas {
+			merge(t, dst, src)
+		}
+	}
+}
+
+func TestPNCounter(t *testing.T) {
+	var replicas []*PNCounter
+	for i := 0; i < 3; i++ {
+		replicas = append(replicas, NewPNCounter(fmt.Sprint(i)))
+	}
+	var want int64
+	converge(t, replicas, func(r *rand.Rand, c *PNCounter) {
+		n := int64(r.Intn(21) - 10)
+		c.Add(n)
+		want += n
+	})
+	for i, c := range replicas {
+		if got := c.Value(); got != want {
+			t.Errorf("replica %d: got %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestORSet(t *testing.T) {
+	var replicas []*ORSet[int]
+	for i := 0; i < 3; i++ {
+		replicas = append(replicas, NewORSet[int](fmt.Sprint(i)))
+	}
+	converge(t, replicas, func(r *rand.Rand, s *ORSet[int]) {
+		if r.Intn(2) == 0 {
+			s.Add(r.Intn(10))
+		} else {
+			s.Remove(r.Intn(10))
+		}
+	})
+	want := replicas[0].Elements()
+	sort.Ints(want)
+	for i, s := range replicas {
+		got := s.Elements()
+		sort.Ints(got)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("replica %d (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestORSetAddWins(t *testing.T) {
+	a, b := NewORSet[string]("a"), NewORSet[string]("b")
+	a.Add("x")
+	merge(t, b, a)
+
+	// a removes x while b concurrently adds it again.
+	a.Remove("x")
+	b.Add("x")
+	merge(t, a, b)
+	merge(t, b, a)
+	if !a.Contains("x") || !b.Contains("x") {
+		t.Fatalf("concurrent add lost: a=%v, b=%v", a.Elements(), b.Elements())
+	}
+
+	// A removal that observed every addition sticks.
+	a.Remove("x")
+	merge(t, b, a)
+	merge(t, a, b)
+	if a.Contains("x") || b.Contains("x") {
+		t.Fatalf("removal lost: a=%v, b=%v", a.Elements(), b.Elements())
+	}
+}
+
+func TestLWWMap(t *testing.T) {
+	var replicas []*LWWMap[string, int]
+	for i := 0; i < 3; i++ {
+		replicas = append(replicas, NewLWWMap[string, int](fmt.Sprint(i)))
+	}
+	converge(t, replicas, func(r *rand.Rand, m *LWWMap[string, int]) {
+		key := fmt.Sprint(r.Intn(10))
+		if r.Intn(3) == 0 {
+			m.Delete(key)
+		} else {
+			m.Set(key, r.Intn(100))
+		}
+	})
+	contents := func(m *LWWMap[string, int]) map[string]int {
+		c := map[string]int{}
+		for _, k := range m.Keys() {
+			c[k], _ = m.Get(k)
+		}
+		return c
+	}
+	want := contents(replicas[0])
+	for i, m := range replicas {
+		if diff := cmp.Diff(want, contents(m)); diff != "" {
+			t.Errorf("replica %d (-want +got):\n%s", i, diff)
+		}
+		if got := m.Len(); got != len(want) {
+			t.Errorf("replica %d: Len() = %d, want %d", i, got, len(want))
+		}
+	}
+}
+
+func TestLWWMapLaterWriteWins(t *testing.T) {
+	a, b := NewLWWMap[string, string]("a"), NewLWWMap[string, string]("b")
+	a.Set("k", "a1")
+	merge(t, b, a)
+	b.Set("k", "b1") // stamped after a1, which b has seen
+	merge(t, a, b)
+	if got, _ := a.Get("k"); got != "b1" {
+		t.Fatalf("Get: got %q, want %q", got, "b1")
+	}
+}
+
+func TestCRDTGossip(t *testing.T) {
+	ctx := context.Background()
+	var replicas []*CRDT[*PNCounter]
+	for i := 0; i < 3; i++ {
+		ctx := weaver.WithReplicaInfo(ctx, weaver.ReplicaInfo{WeaveletID: fmt.Sprint(i)})
+		replicas = append(replicas, NewCRDT(ctx, NewPNCounter))
+	}
+	for i, c := range replicas {
+		if got, want := c.Replica(), fmt.Sprint(i); got != want {
+			t.Fatalf("Replica: got %q, want %q", got, want)
+		}
+		if err := c.Update(func(c *PNCounter) error {
+			c.Add(int64(i + 1))
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Gossip in a ring, twice, so that every update reaches every replica.
+	for round := 0; round < 2; round++ {
+		for i, c := range replicas {
+			peer := replicas[(i+1)%len(replicas)]
+			exchange := func(_ context.Context, state []byte) ([]byte, error) {
+				return peer.Exchange(state)
+			}
+			if err := c.Gossip(ctx, exchange); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	for i, c := range replicas {
+		var got int64
+		if err := c.Read(func(c *PNCounter) error {
+			got = c.Value()
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if got != 6 {
+			t.Errorf("replica %d: got %d, want 6", i, got)
+		}
+	}
+}
@@ -72,6 +72,11 @@ func (*localDeployerControl) ExportListener(context.Context, *protos.ExportListe
 	return nil, fmt.Errorf("localDeployerControl.ExportListener not implemented")
 }
 
+// GetTopology implements the control.DeployerControl interface.
+func (*localDeployerControl) GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	return nil, fmt.Errorf("localDeployerControl.GetTopology not implemented")
+}
+
 // GetSelfCertificate implements the control.DeployerControl interface.
 func (*localDeployerControl) GetSelfCertificate(context.Context, *protos.GetSelfCertificateRequest) (*protos.GetSelfCertificateReply, error) {
 	return nil, fmt.Errorf("localDeployerControl.GetSelfCertificate not implemented")
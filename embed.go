@@ -0,0 +1,248 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// RuntimeOptions configures a Runtime.
+type RuntimeOptions struct {
+	// Config is the contents of a Service Weaver config file. If empty, the
+	// config file named by the SERVICEWEAVER_CONFIG environment variable, if
+	// any, is used instead.
+	Config string
+
+	// ConfigFilename is the name of the config file, used in error messages.
+	ConfigFilename string
+
+	// If true, the runtime does not print or log anything.
+	Quiet bool
+
+	// Components lists the full names of the components in the application
+	// (e.g., "example.com/app/Cache"). The application also includes every
+	// component they transitively reference. If empty, the application
+	// includes every registered component.
+	Components []string
+
+	// Registry holds the registered components. If nil, the global registry,
+	// which holds every component linked into the binary, is used.
+	Registry *codegen.Registry
+
+	// Providers are started runtimes that host components on behalf of this
+	// runtime. If a component in this runtime's application is hosted by a
+	// provider, calls to it are sent to the provider's instance, and its
+	// references are not included in the application. This lets, for
+	// example, an integration test run a caller application against a
+	// provider application in the same process.
+	Providers []*Runtime
+}
+
+// Runtime runs the components of a Service Weaver application inside an
+// existing Go program. Unlike Run, a Runtime doesn't take over the program's
+// main function and doesn't require a weaver.Main component, which lets
+// teams adopt components incrementally inside existing servers:
+//
+//	func main() {
+//	    ctx := context.Background()
+//	    rt := weaver.NewRuntime(weaver.RuntimeOptions{})
+//	    if err := rt.Start(ctx); err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    defer rt.Shutdown(ctx)
+//
+//	    cache, err := weaver.Get[Cache](rt)
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	    legacyServer := newLegacyServer(cache)
+//	    ...
+//	}
+//
+// A Runtime runs all components in the current process, like an application
+// run with "go run" or "weaver single deploy". Components are created when
+// they are first requested. Several runtimes can run in the same process, each
+// with its own instances of the components in its application (see
+// RuntimeOptions.Components). Unlike Run, a Runtime doesn't handle signals; the
+// embedding program should call Shutdown before it exits.
+type Runtime struct {
+	opts RuntimeOptions
+
+	mu     sync.Mutex
+	wlet   *weaver.SingleWeavelet // nil until started
+	hosted map[string]bool        // names of the components hosted by wlet
+	cancel context.CancelFunc     // stops serving status
+}
+
+// NewRuntime returns a new Runtime. Call Start to start it.
+func NewRuntime(opts RuntimeOptions) *Runtime {
+	return &Runtime{opts: opts}
+}
+
+// Start starts the runtime. The runtime runs until ctx is cancelled or
+// Shutdown is called.
+func (r *Runtime) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.wlet != nil {
+		return errors.New("weaver.Runtime: already started")
+	}
+
+	bootstrap, err := runtime.GetBootstrap(ctx)
+	if err != nil {
+		return err
+	}
+	if bootstrap.Exists() {
+		return errors.New("weaver.Runtime: cannot run under a deployer; use weaver.Run instead")
+	}
+
+	opts := weaver.SingleWeaveletOptions{
+		ConfigFilename: r.opts.ConfigFilename,
+		Config:         r.opts.Config,
+		Quiet:          r.opts.Quiet,
+		Embedded:       true,
+	}
+	if opts.Config == "" {
+		if err := readConfigEnv(&opts); err != nil {
+			return err
+		}
+	}
+
+	regs, fakes, hosted, err := r.registrations()
+	if err != nil {
+		return err
+	}
+	if err := validateRegistrations(regs); err != nil {
+		return err
+	}
+	opts.Fakes = fakes
+	ctx, cancel := context.WithCancel(ctx)
+	wlet, err := weaver.NewSingleWeavelet(ctx, regs, opts)
+	if err != nil {
+		cancel()
+		return err
+	}
+	go func() {
+		if err := wlet.ServeStatus(ctx); err != nil && ctx.Err() == nil && !r.opts.Quiet {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}()
+	r.wlet = wlet
+	r.hosted = hosted
+	r.cancel = cancel
+	return nil
+}
+
+// registrations returns the registrations of the components in r's
+// application, the components hosted by r's providers, keyed by interface
+// type, and the names of the components r hosts itself.
+func (r *Runtime) registrations() ([]*codegen.Registration, map[reflect.Type]any, map[string]bool, error) {
+	// Find the components hosted by the providers.
+	providers := map[string]*Runtime{}
+	for _, p := range r.opts.Providers {
+		if p == r {
+			return nil, nil, nil, errors.New("weaver.Runtime: runtime provides to itself")
+		}
+		p.mu.Lock()
+		hosted := p.hosted
+		p.mu.Unlock()
+		if hosted == nil {
+			return nil, nil, nil, errors.New("weaver.Runtime: provider not started")
+		}
+		for name := range hosted {
+			if _, ok := providers[name]; !ok {
+				providers[name] = p
+			}
+		}
+	}
+
+	// Gather the components in the application.
+	registry := r.opts.Registry
+	if registry == nil {
+		registry = codegen.Global()
+	}
+	names := r.opts.Components
+	if len(names) == 0 {
+		for _, reg := range registry.Registered() {
+			names = append(names, reg.Name)
+		}
+	}
+	isProvided := func(name string) bool {
+		_, ok := providers[name]
+		return ok
+	}
+	app, err := registry.Subset(names, isProvided)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("weaver.Runtime: %w", err)
+	}
+
+	regs := app.Registered()
+	fakes := map[reflect.Type]any{}
+	hosted := map[string]bool{}
+	for _, reg := range regs {
+		p, ok := providers[reg.Name]
+		if !ok {
+			hosted[reg.Name] = true
+			continue
+		}
+		c, err := p.wlet.GetIntf(reg.Iface)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		fakes[reg.Iface] = c
+	}
+	return regs, fakes, hosted, nil
+}
+
+// Shutdown calls the Shutdown method of every component that has one and
+// stops the runtime.
+func (r *Runtime) Shutdown(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.wlet == nil {
+		return errors.New("weaver.Runtime: not started")
+	}
+	r.cancel()
+	return r.wlet.Shutdown(ctx)
+}
+
+// Get returns the component with interface type T from the provided runtime,
+// creating it if needed.
+//
+// REQUIRES: r has been started.
+func Get[T any](r *Runtime) (T, error) {
+	var zero T
+	r.mu.Lock()
+	wlet := r.wlet
+	r.mu.Unlock()
+	if wlet == nil {
+		return zero, errors.New("weaver.Runtime: not started")
+	}
+	c, err := wlet.GetIntf(reflection.Type[T]())
+	if err != nil {
+		return zero, err
+	}
+	return c.(T), nil
+}
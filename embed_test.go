@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+func TestRuntime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	rt := weaver.NewRuntime(weaver.RuntimeOptions{
+		Config: `
+			["github.com/ServiceWeaver/weaver/Quota"]
+			limits = { requests = { limit = 1, period = "1h" } }
+		`,
+		Quiet: true,
+	})
+	if _, err := weaver.Get[weaver.Quota](rt); err == nil {
+		t.Fatal("Get: unexpected success before Start")
+	}
+	if err := rt.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Start(ctx); err == nil {
+		t.Fatal("Start: unexpected success when already started")
+	}
+
+	// Call a component from outside of any component.
+	quota, err := weaver.Get[weaver.Quota](rt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []bool{true, false} {
+		got, err := quota.Acquire(ctx, "requests", "key", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("Acquire: got %v, want %v", got, want)
+		}
+	}
+
+	if err := rt.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRuntimeProviders(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Run a provider application that hosts only the quota server, and two
+	// caller applications that host the Quota component.
+	const config = `
+		["github.com/ServiceWeaver/weaver/Quota"]
+		limits = { requests = { limit = 1, period = "1h", batch = 1 } }
+	`
+	start := func(opts weaver.RuntimeOptions) *weaver.Runtime {
+		t.Helper()
+		opts.Config = config
+		opts.Quiet = true
+		rt := weaver.NewRuntime(opts)
+		if err := rt.Start(ctx); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { rt.Shutdown(ctx) })
+		return rt
+	}
+	provider := start(weaver.RuntimeOptions{
+		Components: []string{"github.com/ServiceWeaver/weaver/quotaServer"},
+	})
+	callers := []*weaver.Runtime{
+		start(weaver.RuntimeOptions{
+			Components: []string{"github.com/ServiceWeaver/weaver/Quota"},
+			Providers:  []*weaver.Runtime{provider},
+		}),
+		start(weaver.RuntimeOptions{
+			Components: []string{"github.com/ServiceWeaver/weaver/Quota"},
+			Providers:  []*weaver.Runtime{provider},
+		}),
+	}
+
+	// The provider doesn't host components outside of its application.
+	if _, err := weaver.Get[weaver.Quota](provider); err == nil {
+		t.Fatal("Get: unexpected success for component outside of application")
+	}
+
+	// The callers have separate Quota components that share the provider's
+	// quota server, and therefore share limits.
+	for i, want := range []bool{true, false} {
+		quota, err := weaver.Get[weaver.Quota](callers[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := quota.Acquire(ctx, "requests", "key", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Fatalf("caller %d: Acquire: got %v, want %v", i, got, want)
+		}
+	}
+}
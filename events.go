@@ -0,0 +1,116 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// DeploymentEventKind is the kind of a DeploymentEvent.
+type DeploymentEventKind int
+
+const (
+	// VersionRollout means that a new version of the application is rolling
+	// out. The event's Version is the deployment id of the new version. Only
+	// deployers that perform rollouts report it.
+	VersionRollout DeploymentEventKind = iota + 1
+
+	// Draining means that the replica is about to be drained and stopped,
+	// e.g., because it received a SIGTERM. The replica stops once the
+	// subscribers have returned, so it is the time to flush buffers.
+	Draining
+
+	// ReplicasChanged means that replicas of a component called by the
+	// replica were added or removed. The event's Component is the full name
+	// of the component and Replicas holds the addresses of its replicas.
+	ReplicasChanged
+)
+
+// String returns the name of the event kind.
+func (k DeploymentEventKind) String() string {
+	switch k {
+	case VersionRollout:
+		return "VersionRollout"
+	case Draining:
+		return "Draining"
+	case ReplicasChanged:
+		return "ReplicasChanged"
+	default:
+		return fmt.Sprintf("DeploymentEventKind(%d)", int(k))
+	}
+}
+
+// DeploymentEvent is an event in the deployment of an application that
+// affects a replica. See SubscribeDeploymentEvents.
+type DeploymentEvent struct {
+	Kind      DeploymentEventKind
+	Version   string   // for VersionRollout events
+	Component string   // for ReplicasChanged events
+	Replicas  []string // for ReplicasChanged events
+}
+
+// SubscribeDeploymentEvents registers fn to be called with the deployment
+// events that affect the replica running the component that received ctx,
+// e.g., in its Init method:
+//
+//	func (b *buffer) Init(ctx context.Context) error {
+//	    _, err := weaver.SubscribeDeploymentEvents(ctx, func(ctx context.Context, e weaver.DeploymentEvent) {
+//	        if e.Kind == weaver.Draining {
+//	            b.flush(ctx)
+//	        }
+//	    })
+//	    return err
+//	}
+//
+// Events are delivered one at a time, to one subscriber at a time, and the
+// deployer waits for the subscribers to return, so fn should return promptly.
+// SubscribeDeploymentEvents returns a function that cancels the subscription.
+//
+// SubscribeDeploymentEvents returns an error if ctx was not passed by Service
+// Weaver (see ReplicaInfo). When running with "weaver single" or under the
+// simulator, there is no deployer, and no events are delivered.
+func SubscribeDeploymentEvents(ctx context.Context, fn func(context.Context, DeploymentEvent)) (func(), error) {
+	events, ok := weaver.EventsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("weaver.SubscribeDeploymentEvents: context not passed by Service Weaver")
+	}
+	if events == nil {
+		return func() {}, nil
+	}
+	return events.Subscribe(func(ctx context.Context, e *protos.DeploymentEvent) {
+		var kind DeploymentEventKind
+		switch e.Kind {
+		case protos.DeploymentEvent_ROLLOUT:
+			kind = VersionRollout
+		case protos.DeploymentEvent_DRAINING:
+			kind = Draining
+		case protos.DeploymentEvent_REPLICAS_CHANGED:
+			kind = ReplicasChanged
+		default:
+			// An event unknown to this version of Service Weaver.
+			return
+		}
+		fn(ctx, DeploymentEvent{
+			Kind:      kind,
+			Version:   e.Version,
+			Component: e.Component,
+			Replicas:  e.Replicas,
+		})
+	}), nil
+}
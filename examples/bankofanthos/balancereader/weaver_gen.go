@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*T)(nil)).Elem(),
 		Impl:  reflect.TypeOf(impl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return t_local_stub{impl: impl.(T), tracer: tracer, getBalanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T", Method: "GetBalance", Remote: false, Generated: true})}
+			return t_local_stub{impl: impl.(T), tracer: tracer, caller: codegen.Caller{Component: caller}, getBalanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T", Method: "GetBalance", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return t_client_stub{stub: stub, getBalanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T", Method: "GetBalance", Remote: true, Generated: true})}
@@ -30,7 +30,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return t_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e1e694e203e550a1",
 	})
 }
 
@@ -45,6 +48,7 @@ var _ weaver.Unrouted = (*impl)(nil)
 type t_local_stub struct {
 	impl              T
 	tracer            trace.Tracer
+	caller            codegen.Caller
 	getBalanceMetrics *codegen.MethodMetrics
 }
 
@@ -55,6 +59,10 @@ func (s t_local_stub) GetBalance(ctx context.Context, a0 string) (r0 int64, err
 	// Update metrics.
 	begin := s.getBalanceMetrics.Begin()
 	defer func() { s.getBalanceMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -113,7 +121,7 @@ func (s t_client_stub) GetBalance(ctx context.Context, a0 string) (r0 int64, err
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -124,6 +132,7 @@ func (s t_client_stub) GetBalance(ctx context.Context, a0 string) (r0 int64, err
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -140,10 +149,10 @@ func (s t_client_stub) GetBalance(ctx context.Context, a0 string) (r0 int64, err
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*T)(nil)).Elem(),
 		Impl:  reflect.TypeOf(impl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return t_local_stub{impl: impl.(T), tracer: tracer, addContactMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "AddContact", Remote: false, Generated: true}), getContactsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "GetContacts", Remote: false, Generated: true})}
+			return t_local_stub{impl: impl.(T), tracer: tracer, caller: codegen.Caller{Component: caller}, addContactMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "AddContact", Remote: false, Generated: true}), getContactsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "GetContacts", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return t_client_stub{stub: stub, addContactMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "AddContact", Remote: true, Generated: true}), getContactsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T", Method: "GetContacts", Remote: true, Generated: true})}
@@ -31,7 +31,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return t_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "2773ed253962420d",
 	})
 }
 
@@ -46,6 +49,7 @@ var _ weaver.Unrouted = (*impl)(nil)
 type t_local_stub struct {
 	impl               T
 	tracer             trace.Tracer
+	caller             codegen.Caller
 	addContactMetrics  *codegen.MethodMetrics
 	getContactsMetrics *codegen.MethodMetrics
 }
@@ -57,6 +61,10 @@ func (s t_local_stub) AddContact(ctx context.Context, a0 string, a1 Contact) (er
 	// Update metrics.
 	begin := s.addContactMetrics.Begin()
 	defer func() { s.addContactMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -77,6 +85,10 @@ func (s t_local_stub) GetContacts(ctx context.Context, a0 string) (r0 []Contact,
 	// Update metrics.
 	begin := s.getContactsMetrics.Begin()
 	defer func() { s.getContactsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -137,7 +149,7 @@ func (s t_client_stub) AddContact(ctx context.Context, a0 string, a1 Contact) (e
 	size := 0
 	size += (4 + len(a0))
 	size += serviceweaver_size_Contact_15811618(&a1)
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -149,6 +161,7 @@ func (s t_client_stub) AddContact(ctx context.Context, a0 string, a1 Contact) (e
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -193,7 +206,7 @@ func (s t_client_stub) GetContacts(ctx context.Context, a0 string) (r0 []Contact
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -204,6 +217,7 @@ func (s t_client_stub) GetContacts(ctx context.Context, a0 string) (r0 []Contact
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -212,6 +226,7 @@ func (s t_client_stub) GetContacts(ctx context.Context, a0 string) (r0 []Contact
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_Contact_d00a3378(dec)
 	err = dec.Error()
 	return
@@ -220,10 +235,10 @@ func (s t_client_stub) GetContacts(ctx context.Context, a0 string) (r0 []Contact
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -377,9 +392,11 @@ func serviceweaver_enc_slice_Contact_d00a3378(enc *codegen.Encoder, arg []Contac
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		(arg[i]).WeaverMarshal(enc)
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(arg[i]).WeaverMarshal(enc)
+		}
+	})
 }
 
 func serviceweaver_dec_slice_Contact_d00a3378(dec *codegen.Decoder) []Contact {
@@ -387,10 +404,13 @@ func serviceweaver_dec_slice_Contact_d00a3378(dec *codegen.Decoder) []Contact {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 17)
 	res := make([]Contact, n)
-	for i := 0; i < n; i++ {
-		(&res[i]).WeaverUnmarshal(dec)
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(&res[i]).WeaverUnmarshal(dec)
+		}
+	})
 	return res
 }
 
@@ -18,7 +18,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"bank"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer}
+			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer, caller: codegen.Caller{Component: caller}}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any { return main_client_stub{stub: stub} },
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
@@ -27,7 +27,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return main_reflect_stub{caller: caller}
 		},
-		RefData: "⟦583f439b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T⟧\n⟦01efa328:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T⟧\n⟦285db949:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T⟧\n⟦c236fa3b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T⟧\n⟦0906345d:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/userservice/T⟧\n⟦969790bc:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→bank⟧\n",
+		RefData:          "⟦583f439b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T⟧\n⟦01efa328:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T⟧\n⟦285db949:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T⟧\n⟦c236fa3b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T⟧\n⟦0906345d:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/bankofanthos/userservice/T⟧\n⟦969790bc:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→bank⟧\n⟦9f7a6327:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.homeHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T.GetBalance@handlers.go:125:18⟧\n⟦604e0b78:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.homeHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T.GetTransactions@handlers.go:129:21⟧\n⟦9529ff60:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.homeHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T.GetContacts@handlers.go:133:19⟧\n⟦b4e6c3d2:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.paymentHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T.AddContact@handlers.go:218:11⟧\n⟦7d47e9f3:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.paymentHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T.AddTransaction@handlers.go:242:8⟧\n⟦e5627d58:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.depositHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/contacts/T.AddContact@handlers.go:288:11⟧\n⟦2f71dd82:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.depositHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T.AddTransaction@handlers.go:334:8⟧\n⟦5307f352:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.loginPostHelper→github.com/ServiceWeaver/weaver/examples/bankofanthos/userservice/T.Login@handlers.go:440:16⟧\n⟦3179b705:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.signupPostHandler→github.com/ServiceWeaver/weaver/examples/bankofanthos/userservice/T.CreateUser@handlers.go:638:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e3b0c44298fc1c14",
 	})
 }
 
@@ -42,6 +45,7 @@ var _ weaver.Unrouted = (*server)(nil)
 type main_local_stub struct {
 	impl   weaver.Main
 	tracer trace.Tracer
+	caller codegen.Caller
 }
 
 // Check that main_local_stub implements the weaver.Main interface.
@@ -59,10 +63,10 @@ var _ weaver.Main = (*main_client_stub)(nil)
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*T)(nil)).Elem(),
 		Impl:  reflect.TypeOf(impl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return t_local_stub{impl: impl.(T), tracer: tracer, addTransactionMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T", Method: "AddTransaction", Remote: false, Generated: true})}
+			return t_local_stub{impl: impl.(T), tracer: tracer, caller: codegen.Caller{Component: caller}, addTransactionMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T", Method: "AddTransaction", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return t_client_stub{stub: stub, addTransactionMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T", Method: "AddTransaction", Remote: true, Generated: true})}
@@ -31,7 +31,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return t_reflect_stub{caller: caller}
 		},
-		RefData: "⟦7237a6f4:wEaVeReDgE:github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T⟧\n",
+		RefData:          "⟦7237a6f4:wEaVeReDgE:github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T⟧\n⟦3ecb78da:wEaVeRcAlL:github.com/ServiceWeaver/weaver/examples/bankofanthos/ledgerwriter/T.getAvailableBalance→github.com/ServiceWeaver/weaver/examples/bankofanthos/balancereader/T.GetBalance@ledgerwriter.go:60:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "789351dfa16104de",
 	})
 }
 
@@ -46,6 +49,7 @@ var _ weaver.Unrouted = (*impl)(nil)
 type t_local_stub struct {
 	impl                  T
 	tracer                trace.Tracer
+	caller                codegen.Caller
 	addTransactionMetrics *codegen.MethodMetrics
 }
 
@@ -56,6 +60,10 @@ func (s t_local_stub) AddTransaction(ctx context.Context, a0 string, a1 string,
 	// Update metrics.
 	begin := s.addTransactionMetrics.Begin()
 	defer func() { s.addTransactionMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -112,7 +120,7 @@ func (s t_client_stub) AddTransaction(ctx context.Context, a0 string, a1 string,
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.String(a0)
 	enc.String(a1)
 	(a2).WeaverMarshal(enc)
@@ -122,6 +130,7 @@ func (s t_client_stub) AddTransaction(ctx context.Context, a0 string, a1 string,
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -137,10 +146,10 @@ func (s t_client_stub) AddTransaction(ctx context.Context, a0 string, a1 string,
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
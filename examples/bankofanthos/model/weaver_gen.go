@@ -21,10 +21,10 @@ import (
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -70,7 +70,7 @@ func (x *Transaction) WeaverMarshal(enc *codegen.Encoder) {
 	enc.String(x.ToAccountNum)
 	enc.String(x.ToRoutingNum)
 	enc.Int64(x.Amount)
-	enc.EncodeBinaryMarshaler(&x.Timestamp)
+	enc.Time(x.Timestamp)
 }
 
 func (x *Transaction) WeaverUnmarshal(dec *codegen.Decoder) {
@@ -82,7 +82,7 @@ func (x *Transaction) WeaverUnmarshal(dec *codegen.Decoder) {
 	x.ToAccountNum = dec.String()
 	x.ToRoutingNum = dec.String()
 	x.Amount = dec.Int64()
-	dec.DecodeBinaryUnmarshaler(&x.Timestamp)
+	x.Timestamp = dec.Time()
 }
 
 var _ codegen.AutoMarshal = (*TransactionWithID)(nil)
@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*T)(nil)).Elem(),
 		Impl:  reflect.TypeOf(impl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return t_local_stub{impl: impl.(T), tracer: tracer, getTransactionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T", Method: "GetTransactions", Remote: false, Generated: true})}
+			return t_local_stub{impl: impl.(T), tracer: tracer, caller: codegen.Caller{Component: caller}, getTransactionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T", Method: "GetTransactions", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return t_client_stub{stub: stub, getTransactionsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/bankofanthos/transactionhistory/T", Method: "GetTransactions", Remote: true, Generated: true})}
@@ -31,7 +31,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return t_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "c29e221582ff9204",
 	})
 }
 
@@ -46,6 +49,7 @@ var _ weaver.Unrouted = (*impl)(nil)
 type t_local_stub struct {
 	impl                   T
 	tracer                 trace.Tracer
+	caller                 codegen.Caller
 	getTransactionsMetrics *codegen.MethodMetrics
 }
 
@@ -56,6 +60,10 @@ func (s t_local_stub) GetTransactions(ctx context.Context, a0 string) (r0 []mode
 	// Update metrics.
 	begin := s.getTransactionsMetrics.Begin()
 	defer func() { s.getTransactionsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -114,7 +122,7 @@ func (s t_client_stub) GetTransactions(ctx context.Context, a0 string) (r0 []mod
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -125,6 +133,7 @@ func (s t_client_stub) GetTransactions(ctx context.Context, a0 string) (r0 []mod
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -133,6 +142,7 @@ func (s t_client_stub) GetTransactions(ctx context.Context, a0 string) (r0 []mod
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_Transaction_d2a36fba(dec)
 	err = dec.Error()
 	return
@@ -141,10 +151,10 @@ func (s t_client_stub) GetTransactions(ctx context.Context, a0 string) (r0 []mod
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -228,9 +238,11 @@ func serviceweaver_enc_slice_Transaction_d2a36fba(enc *codegen.Encoder, arg []mo
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		(arg[i]).WeaverMarshal(enc)
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(arg[i]).WeaverMarshal(enc)
+		}
+	})
 }
 
 func serviceweaver_dec_slice_Transaction_d2a36fba(dec *codegen.Decoder) []model.Transaction {
@@ -238,9 +250,12 @@ func serviceweaver_dec_slice_Transaction_d2a36fba(dec *codegen.Decoder) []model.
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 24)
 	res := make([]model.Transaction, n)
-	for i := 0; i < n; i++ {
-		(&res[i]).WeaverUnmarshal(dec)
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(&res[i]).WeaverUnmarshal(dec)
+		}
+	})
 	return res
 }
@@ -21,7 +21,7 @@ func init() {
 		Iface: reflect.TypeOf((*ImageScaler)(nil)).Elem(),
 		Impl:  reflect.TypeOf(scaler{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return imageScaler_local_stub{impl: impl.(ImageScaler), tracer: tracer, scaleMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/ImageScaler", Method: "Scale", Remote: false, Generated: true})}
+			return imageScaler_local_stub{impl: impl.(ImageScaler), tracer: tracer, caller: codegen.Caller{Component: caller}, scaleMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/ImageScaler", Method: "Scale", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return imageScaler_client_stub{stub: stub, scaleMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/ImageScaler", Method: "Scale", Remote: true, Generated: true})}
@@ -32,14 +32,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return imageScaler_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "70d52f13815f1e28",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/examples/chat/LocalCache",
 		Iface: reflect.TypeOf((*LocalCache)(nil)).Elem(),
 		Impl:  reflect.TypeOf(localCache{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return localCache_local_stub{impl: impl.(LocalCache), tracer: tracer, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Get", Remote: false, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Put", Remote: false, Generated: true})}
+			return localCache_local_stub{impl: impl.(LocalCache), tracer: tracer, caller: codegen.Caller{Component: caller}, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Get", Remote: false, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Put", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return localCache_client_stub{stub: stub, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Get", Remote: true, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/LocalCache", Method: "Put", Remote: true, Generated: true})}
@@ -50,7 +53,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return localCache_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "7eb2af797553cda7",
 	})
 	codegen.Register(codegen.Registration{
 		Name:      "github.com/ServiceWeaver/weaver/Main",
@@ -58,7 +64,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"chat"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer}
+			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer, caller: codegen.Caller{Component: caller}}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any { return main_client_stub{stub: stub} },
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
@@ -67,7 +73,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return main_reflect_stub{caller: caller}
 		},
-		RefData: "⟦7e1a0aa0:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/SQLStore⟧\n⟦ae108c0d:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/ImageScaler⟧\n⟦c86a1d44:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/LocalCache⟧\n⟦7b9a3b0b:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→chat⟧\n",
+		RefData:          "⟦7e1a0aa0:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/SQLStore⟧\n⟦ae108c0d:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/ImageScaler⟧\n⟦c86a1d44:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/chat/LocalCache⟧\n⟦7b9a3b0b:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→chat⟧\n⟦0dd35144:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.generateFeed→github.com/ServiceWeaver/weaver/examples/chat/SQLStore.GetFeed@server.go:117:18⟧\n⟦5730b999:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.serveThumbnail→github.com/ServiceWeaver/weaver/examples/chat/LocalCache.Get@server.go:142:19⟧\n⟦a66c0867:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.serveThumbnail→github.com/ServiceWeaver/weaver/examples/chat/SQLStore.GetImage@server.go:151:14⟧\n⟦57941d55:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.serveThumbnail→github.com/ServiceWeaver/weaver/examples/chat/ImageScaler.Scale@server.go:157:16⟧\n⟦1ba5f2ad:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.serveThumbnail→github.com/ServiceWeaver/weaver/examples/chat/LocalCache.Put@server.go:163:6⟧\n⟦33cd8899:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.newThread→github.com/ServiceWeaver/weaver/examples/chat/SQLStore.CreateThread@server.go:184:11⟧\n⟦cf193bd4:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.newPost→github.com/ServiceWeaver/weaver/examples/chat/SQLStore.CreatePost@server.go:203:8⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e3b0c44298fc1c14",
 	})
 	codegen.Register(codegen.Registration{
 		Name:    "github.com/ServiceWeaver/weaver/examples/chat/SQLStore",
@@ -75,7 +84,7 @@ func init() {
 		Impl:    reflect.TypeOf(sqlStore{}),
 		NoRetry: []int{0, 1},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return sQLStore_local_stub{impl: impl.(SQLStore), tracer: tracer, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreatePost", Remote: false, Generated: true}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreateThread", Remote: false, Generated: true}), getFeedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetFeed", Remote: false, Generated: true}), getImageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetImage", Remote: false, Generated: true})}
+			return sQLStore_local_stub{impl: impl.(SQLStore), tracer: tracer, caller: codegen.Caller{Component: caller}, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreatePost", Remote: false, Generated: true}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreateThread", Remote: false, Generated: true}), getFeedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetFeed", Remote: false, Generated: true}), getImageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetImage", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return sQLStore_client_stub{stub: stub, createPostMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreatePost", Remote: true, Generated: true}), createThreadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "CreateThread", Remote: true, Generated: true}), getFeedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetFeed", Remote: true, Generated: true}), getImageMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/chat/SQLStore", Method: "GetImage", Remote: true, Generated: true})}
@@ -86,7 +95,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return sQLStore_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "d848c6c6630cf49d",
 	})
 }
 
@@ -107,6 +119,7 @@ var _ weaver.Unrouted = (*sqlStore)(nil)
 type imageScaler_local_stub struct {
 	impl         ImageScaler
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	scaleMetrics *codegen.MethodMetrics
 }
 
@@ -117,6 +130,10 @@ func (s imageScaler_local_stub) Scale(ctx context.Context, a0 []byte, a1 int, a2
 	// Update metrics.
 	begin := s.scaleMetrics.Begin()
 	defer func() { s.scaleMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -136,6 +153,7 @@ func (s imageScaler_local_stub) Scale(ctx context.Context, a0 []byte, a1 int, a2
 type localCache_local_stub struct {
 	impl       LocalCache
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	getMetrics *codegen.MethodMetrics
 	putMetrics *codegen.MethodMetrics
 }
@@ -147,6 +165,10 @@ func (s localCache_local_stub) Get(ctx context.Context, a0 string) (r0 string, e
 	// Update metrics.
 	begin := s.getMetrics.Begin()
 	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -167,6 +189,10 @@ func (s localCache_local_stub) Put(ctx context.Context, a0 string, a1 string) (e
 	// Update metrics.
 	begin := s.putMetrics.Begin()
 	defer func() { s.putMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -186,6 +212,7 @@ func (s localCache_local_stub) Put(ctx context.Context, a0 string, a1 string) (e
 type main_local_stub struct {
 	impl   weaver.Main
 	tracer trace.Tracer
+	caller codegen.Caller
 }
 
 // Check that main_local_stub implements the weaver.Main interface.
@@ -194,6 +221,7 @@ var _ weaver.Main = (*main_local_stub)(nil)
 type sQLStore_local_stub struct {
 	impl                SQLStore
 	tracer              trace.Tracer
+	caller              codegen.Caller
 	createPostMetrics   *codegen.MethodMetrics
 	createThreadMetrics *codegen.MethodMetrics
 	getFeedMetrics      *codegen.MethodMetrics
@@ -207,6 +235,10 @@ func (s sQLStore_local_stub) CreatePost(ctx context.Context, a0 string, a1 time.
 	// Update metrics.
 	begin := s.createPostMetrics.Begin()
 	defer func() { s.createPostMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -227,6 +259,10 @@ func (s sQLStore_local_stub) CreateThread(ctx context.Context, a0 string, a1 tim
 	// Update metrics.
 	begin := s.createThreadMetrics.Begin()
 	defer func() { s.createThreadMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -247,6 +283,10 @@ func (s sQLStore_local_stub) GetFeed(ctx context.Context, a0 string) (r0 []Threa
 	// Update metrics.
 	begin := s.getFeedMetrics.Begin()
 	defer func() { s.getFeedMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -267,6 +307,10 @@ func (s sQLStore_local_stub) GetImage(ctx context.Context, a0 string, a1 ImageID
 	// Update metrics.
 	begin := s.getImageMetrics.Begin()
 	defer func() { s.getImageMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -327,10 +371,11 @@ func (s imageScaler_client_stub) Scale(ctx context.Context, a0 []byte, a1 int, a
 	size += (4 + (len(a0) * 1))
 	size += 8
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	serviceweaver_enc_slice_byte_87461245(enc, a0)
 	enc.Int(a1)
 	enc.Int(a2)
@@ -340,6 +385,7 @@ func (s imageScaler_client_stub) Scale(ctx context.Context, a0 []byte, a1 int, a
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -348,6 +394,7 @@ func (s imageScaler_client_stub) Scale(ctx context.Context, a0 []byte, a1 int, a
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_byte_87461245(dec)
 	err = dec.Error()
 	return
@@ -394,7 +441,7 @@ func (s localCache_client_stub) Get(ctx context.Context, a0 string) (r0 string,
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -405,6 +452,7 @@ func (s localCache_client_stub) Get(ctx context.Context, a0 string) (r0 string,
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -451,7 +499,7 @@ func (s localCache_client_stub) Put(ctx context.Context, a0 string, a1 string) (
 	size := 0
 	size += (4 + len(a0))
 	size += (4 + len(a1))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -463,6 +511,7 @@ func (s localCache_client_stub) Put(ctx context.Context, a0 string, a1 string) (
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -523,9 +572,9 @@ func (s sQLStore_client_stub) CreatePost(ctx context.Context, a0 string, a1 time
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.String(a0)
-	enc.EncodeBinaryMarshaler(&a1)
+	enc.Time(a1)
 	enc.Int64((int64)(a2))
 	enc.String(a3)
 	var shardKey uint64
@@ -534,6 +583,7 @@ func (s sQLStore_client_stub) CreatePost(ctx context.Context, a0 string, a1 time
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -576,9 +626,10 @@ func (s sQLStore_client_stub) CreateThread(ctx context.Context, a0 string, a1 ti
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	enc.String(a0)
-	enc.EncodeBinaryMarshaler(&a1)
+	enc.Time(a1)
 	serviceweaver_enc_slice_string_4af10117(enc, a2)
 	enc.String(a3)
 	serviceweaver_enc_slice_byte_87461245(enc, a4)
@@ -588,6 +639,7 @@ func (s sQLStore_client_stub) CreateThread(ctx context.Context, a0 string, a1 ti
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -633,7 +685,7 @@ func (s sQLStore_client_stub) GetFeed(ctx context.Context, a0 string) (r0 []Thre
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -644,6 +696,7 @@ func (s sQLStore_client_stub) GetFeed(ctx context.Context, a0 string) (r0 []Thre
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -652,6 +705,7 @@ func (s sQLStore_client_stub) GetFeed(ctx context.Context, a0 string) (r0 []Thre
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_Thread_511e1469(dec)
 	err = dec.Error()
 	return
@@ -690,7 +744,7 @@ func (s sQLStore_client_stub) GetImage(ctx context.Context, a0 string, a1 ImageI
 	size := 0
 	size += (4 + len(a0))
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -702,6 +756,7 @@ func (s sQLStore_client_stub) GetImage(ctx context.Context, a0 string, a1 ImageI
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -710,6 +765,7 @@ func (s sQLStore_client_stub) GetImage(ctx context.Context, a0 string, a1 ImageI
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_byte_87461245(dec)
 	err = dec.Error()
 	return
@@ -718,10 +774,10 @@ func (s sQLStore_client_stub) GetImage(ctx context.Context, a0 string, a1 ImageI
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -911,7 +967,7 @@ func (s sQLStore_server_stub) createPost(ctx context.Context, args []byte) (res
 	var a0 string
 	a0 = dec.String()
 	var a1 time.Time
-	dec.DecodeBinaryUnmarshaler(&a1)
+	a1 = dec.Time()
 	var a2 ThreadID
 	*(*int64)(&a2) = dec.Int64()
 	var a3 string
@@ -941,7 +997,7 @@ func (s sQLStore_server_stub) createThread(ctx context.Context, args []byte) (re
 	var a0 string
 	a0 = dec.String()
 	var a1 time.Time
-	dec.DecodeBinaryUnmarshaler(&a1)
+	a1 = dec.Time()
 	var a2 []string
 	a2 = serviceweaver_dec_slice_string_4af10117(dec)
 	var a3 string
@@ -1099,7 +1155,7 @@ func (x *Post) WeaverMarshal(enc *codegen.Encoder) {
 	}
 	enc.Int64((int64)(x.ID))
 	enc.String(x.Creator)
-	enc.EncodeBinaryMarshaler(&x.When)
+	enc.Time(x.When)
 	enc.String(x.Text)
 	enc.Int64((int64)(x.ImageID))
 }
@@ -1110,7 +1166,7 @@ func (x *Post) WeaverUnmarshal(dec *codegen.Decoder) {
 	}
 	*(*int64)(&x.ID) = dec.Int64()
 	x.Creator = dec.String()
-	dec.DecodeBinaryUnmarshaler(&x.When)
+	x.When = dec.Time()
 	x.Text = dec.String()
 	*(*int64)(&x.ImageID) = dec.Int64()
 }
@@ -1147,9 +1203,11 @@ func serviceweaver_enc_slice_Post_29a9ee83(enc *codegen.Encoder, arg []Post) {
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		(arg[i]).WeaverMarshal(enc)
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(arg[i]).WeaverMarshal(enc)
+		}
+	})
 }
 
 func serviceweaver_dec_slice_Post_29a9ee83(dec *codegen.Decoder) []Post {
@@ -1157,10 +1215,13 @@ func serviceweaver_dec_slice_Post_29a9ee83(dec *codegen.Decoder) []Post {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 24)
 	res := make([]Post, n)
-	for i := 0; i < n; i++ {
-		(&res[i]).WeaverUnmarshal(dec)
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(&res[i]).WeaverUnmarshal(dec)
+		}
+	})
 	return res
 }
 
@@ -1172,9 +1233,14 @@ func serviceweaver_enc_slice_byte_87461245(enc *codegen.Encoder, arg []byte) {
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.Byte(arg[i])
+	if codegen.EncodeFixed(enc, arg) {
+		return
 	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Byte(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_byte_87461245(dec *codegen.Decoder) []byte {
@@ -1182,10 +1248,16 @@ func serviceweaver_dec_slice_byte_87461245(dec *codegen.Decoder) []byte {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 1)
 	res := make([]byte, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.Byte()
+	if codegen.DecodeFixed[byte](dec, res) {
+		return res
 	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Byte()
+		}
+	})
 	return res
 }
 
@@ -1195,9 +1267,11 @@ func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string)
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.String(arg[i])
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
@@ -1205,10 +1279,13 @@ func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 4)
 	res := make([]string, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.String()
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
 	return res
 }
 
@@ -1218,9 +1295,11 @@ func serviceweaver_enc_slice_Thread_511e1469(enc *codegen.Encoder, arg []Thread)
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		(arg[i]).WeaverMarshal(enc)
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(arg[i]).WeaverMarshal(enc)
+		}
+	})
 }
 
 func serviceweaver_dec_slice_Thread_511e1469(dec *codegen.Decoder) []Thread {
@@ -1228,9 +1307,12 @@ func serviceweaver_dec_slice_Thread_511e1469(dec *codegen.Decoder) []Thread {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 12)
 	res := make([]Thread, n)
-	for i := 0; i < n; i++ {
-		(&res[i]).WeaverUnmarshal(dec)
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(&res[i]).WeaverUnmarshal(dec)
+		}
+	})
 	return res
 }
@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*Even)(nil)).Elem(),
 		Impl:  reflect.TypeOf(even{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return even_local_stub{impl: impl.(Even), tracer: tracer, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Even", Method: "Do", Remote: false, Generated: true})}
+			return even_local_stub{impl: impl.(Even), tracer: tracer, caller: codegen.Caller{Component: caller}, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Even", Method: "Do", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return even_client_stub{stub: stub, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Even", Method: "Do", Remote: true, Generated: true})}
@@ -30,7 +30,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return even_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "0e168dc867f2e9dc",
 	})
 	codegen.Register(codegen.Registration{
 		Name:      "github.com/ServiceWeaver/weaver/Main",
@@ -38,7 +41,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"collatz"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer}
+			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer, caller: codegen.Caller{Component: caller}}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any { return main_client_stub{stub: stub} },
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
@@ -47,14 +50,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return main_reflect_stub{caller: caller}
 		},
-		RefData: "⟦f95ad2dd:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/collatz/Odd⟧\n⟦987c175b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/collatz/Even⟧\n⟦f3b62957:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→collatz⟧\n",
+		RefData:          "⟦f95ad2dd:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/collatz/Odd⟧\n⟦987c175b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/collatz/Even⟧\n⟦f3b62957:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→collatz⟧\n⟦b37e6342:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.handle→github.com/ServiceWeaver/weaver/examples/collatz/Even.Do@server.go:58:13⟧\n⟦3de60a7c:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.handle→github.com/ServiceWeaver/weaver/examples/collatz/Odd.Do@server.go:60:13⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e3b0c44298fc1c14",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/examples/collatz/Odd",
 		Iface: reflect.TypeOf((*Odd)(nil)).Elem(),
 		Impl:  reflect.TypeOf(odd{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return odd_local_stub{impl: impl.(Odd), tracer: tracer, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Odd", Method: "Do", Remote: false, Generated: true})}
+			return odd_local_stub{impl: impl.(Odd), tracer: tracer, caller: codegen.Caller{Component: caller}, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Odd", Method: "Do", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return odd_client_stub{stub: stub, doMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/collatz/Odd", Method: "Do", Remote: true, Generated: true})}
@@ -65,7 +71,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return odd_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "0e168dc867f2e9dc",
 	})
 }
 
@@ -84,6 +93,7 @@ var _ weaver.Unrouted = (*odd)(nil)
 type even_local_stub struct {
 	impl      Even
 	tracer    trace.Tracer
+	caller    codegen.Caller
 	doMetrics *codegen.MethodMetrics
 }
 
@@ -94,6 +104,10 @@ func (s even_local_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	// Update metrics.
 	begin := s.doMetrics.Begin()
 	defer func() { s.doMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -113,6 +127,7 @@ func (s even_local_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 type main_local_stub struct {
 	impl   weaver.Main
 	tracer trace.Tracer
+	caller codegen.Caller
 }
 
 // Check that main_local_stub implements the weaver.Main interface.
@@ -121,6 +136,7 @@ var _ weaver.Main = (*main_local_stub)(nil)
 type odd_local_stub struct {
 	impl      Odd
 	tracer    trace.Tracer
+	caller    codegen.Caller
 	doMetrics *codegen.MethodMetrics
 }
 
@@ -131,6 +147,10 @@ func (s odd_local_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	// Update metrics.
 	begin := s.doMetrics.Begin()
 	defer func() { s.doMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -189,7 +209,7 @@ func (s even_client_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -200,6 +220,7 @@ func (s even_client_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -260,7 +281,7 @@ func (s odd_client_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -271,6 +292,7 @@ func (s odd_client_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -287,10 +309,10 @@ func (s odd_client_stub) Do(ctx context.Context, a0 int) (r0 int, err error) {
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -20,7 +20,7 @@ func init() {
 		Impl:   reflect.TypeOf(factorer{}),
 		Routed: true,
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return factorer_local_stub{impl: impl.(Factorer), tracer: tracer, factorsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/factors/Factorer", Method: "Factors", Remote: false, Generated: true})}
+			return factorer_local_stub{impl: impl.(Factorer), tracer: tracer, caller: codegen.Caller{Component: caller}, factorsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/factors/Factorer", Method: "Factors", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return factorer_client_stub{stub: stub, factorsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/factors/Factorer", Method: "Factors", Remote: true, Generated: true})}
@@ -31,7 +31,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return factorer_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8b52d3997b6bc580",
 	})
 	codegen.Register(codegen.Registration{
 		Name:      "github.com/ServiceWeaver/weaver/Main",
@@ -39,7 +42,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"factors"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer}
+			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer, caller: codegen.Caller{Component: caller}}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any { return main_client_stub{stub: stub} },
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
@@ -48,7 +51,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return main_reflect_stub{caller: caller}
 		},
-		RefData: "⟦4724da9b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/factors/Factorer⟧\n⟦68699208:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→factors⟧\n",
+		RefData:          "⟦4724da9b:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/factors/Factorer⟧\n⟦68699208:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→factors⟧\n⟦7e4740a2:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.handleFactors→github.com/ServiceWeaver/weaver/examples/factors/Factorer.Factors@server.go:51:18⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e3b0c44298fc1c14",
 	})
 }
 
@@ -68,6 +74,7 @@ var _ func(_ context.Context, x int) int = (&router{}).Factors // routed
 type factorer_local_stub struct {
 	impl           Factorer
 	tracer         trace.Tracer
+	caller         codegen.Caller
 	factorsMetrics *codegen.MethodMetrics
 }
 
@@ -78,6 +85,10 @@ func (s factorer_local_stub) Factors(ctx context.Context, a0 int) (r0 []int, err
 	// Update metrics.
 	begin := s.factorsMetrics.Begin()
 	defer func() { s.factorsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -97,6 +108,7 @@ func (s factorer_local_stub) Factors(ctx context.Context, a0 int) (r0 []int, err
 type main_local_stub struct {
 	impl   weaver.Main
 	tracer trace.Tracer
+	caller codegen.Caller
 }
 
 // Check that main_local_stub implements the weaver.Main interface.
@@ -144,7 +156,7 @@ func (s factorer_client_stub) Factors(ctx context.Context, a0 int) (r0 []int, er
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -158,6 +170,7 @@ func (s factorer_client_stub) Factors(ctx context.Context, a0 int) (r0 []int, er
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -166,6 +179,7 @@ func (s factorer_client_stub) Factors(ctx context.Context, a0 int) (r0 []int, er
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_int_7c8c8866(dec)
 	err = dec.Error()
 	return
@@ -181,10 +195,10 @@ var _ weaver.Main = (*main_client_stub)(nil)
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -309,9 +323,14 @@ func serviceweaver_enc_slice_int_7c8c8866(enc *codegen.Encoder, arg []int) {
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.Int(arg[i])
+	if codegen.EncodeFixed(enc, arg) {
+		return
 	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Int(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_int_7c8c8866(dec *codegen.Decoder) []int {
@@ -319,9 +338,15 @@ func serviceweaver_dec_slice_int_7c8c8866(dec *codegen.Decoder) []int {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 8)
 	res := make([]int, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.Int()
+	if codegen.DecodeFixed[int](dec, res) {
+		return res
 	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Int()
+		}
+	})
 	return res
 }
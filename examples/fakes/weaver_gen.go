@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*Clock)(nil)).Elem(),
 		Impl:  reflect.TypeOf(clock{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return clock_local_stub{impl: impl.(Clock), tracer: tracer, unixMicroMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/fakes/Clock", Method: "UnixMicro", Remote: false, Generated: true})}
+			return clock_local_stub{impl: impl.(Clock), tracer: tracer, caller: codegen.Caller{Component: caller}, unixMicroMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/fakes/Clock", Method: "UnixMicro", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return clock_client_stub{stub: stub, unixMicroMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/fakes/Clock", Method: "UnixMicro", Remote: true, Generated: true})}
@@ -30,7 +30,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return clock_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "137df9d78da5e2f9",
 	})
 }
 
@@ -45,6 +48,7 @@ var _ weaver.Unrouted = (*clock)(nil)
 type clock_local_stub struct {
 	impl             Clock
 	tracer           trace.Tracer
+	caller           codegen.Caller
 	unixMicroMetrics *codegen.MethodMetrics
 }
 
@@ -55,6 +59,10 @@ func (s clock_local_stub) UnixMicro(ctx context.Context) (r0 int64, err error) {
 	// Update metrics.
 	begin := s.unixMicroMetrics.Begin()
 	defer func() { s.unixMicroMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -131,10 +139,10 @@ func (s clock_client_stub) UnixMicro(ctx context.Context) (r0 int64, err error)
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -20,7 +20,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"reverser"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer}
+			return main_local_stub{impl: impl.(weaver.Main), tracer: tracer, caller: codegen.Caller{Component: caller}}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any { return main_client_stub{stub: stub} },
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
@@ -29,14 +29,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return main_reflect_stub{caller: caller}
 		},
-		RefData: "⟦b78b74f4:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/reverser/Reverser⟧\n⟦7c420fb8:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→reverser⟧\n",
+		RefData:          "⟦b78b74f4:wEaVeReDgE:github.com/ServiceWeaver/weaver/Main→github.com/ServiceWeaver/weaver/examples/reverser/Reverser⟧\n⟦7c420fb8:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/Main→reverser⟧\n⟦3936fc1d:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Main.handleReverse→github.com/ServiceWeaver/weaver/examples/reverser/Reverser.Reverse@main.go:64:19⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e3b0c44298fc1c14",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/examples/reverser/Reverser",
 		Iface: reflect.TypeOf((*Reverser)(nil)).Elem(),
 		Impl:  reflect.TypeOf(reverser{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return reverser_local_stub{impl: impl.(Reverser), tracer: tracer, reverseMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/reverser/Reverser", Method: "Reverse", Remote: false, Generated: true})}
+			return reverser_local_stub{impl: impl.(Reverser), tracer: tracer, caller: codegen.Caller{Component: caller}, reverseMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/reverser/Reverser", Method: "Reverse", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return reverser_client_stub{stub: stub, reverseMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/examples/reverser/Reverser", Method: "Reverse", Remote: true, Generated: true})}
@@ -47,7 +50,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return reverser_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "7bc405768e547e52",
 	})
 }
 
@@ -64,6 +70,7 @@ var _ weaver.Unrouted = (*reverser)(nil)
 type main_local_stub struct {
 	impl   weaver.Main
 	tracer trace.Tracer
+	caller codegen.Caller
 }
 
 // Check that main_local_stub implements the weaver.Main interface.
@@ -72,6 +79,7 @@ var _ weaver.Main = (*main_local_stub)(nil)
 type reverser_local_stub struct {
 	impl           Reverser
 	tracer         trace.Tracer
+	caller         codegen.Caller
 	reverseMetrics *codegen.MethodMetrics
 }
 
@@ -82,6 +90,10 @@ func (s reverser_local_stub) Reverse(ctx context.Context, a0 string) (r0 string,
 	// Update metrics.
 	begin := s.reverseMetrics.Begin()
 	defer func() { s.reverseMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -147,7 +159,7 @@ func (s reverser_client_stub) Reverse(ctx context.Context, a0 string) (r0 string
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -158,6 +170,7 @@ func (s reverser_client_stub) Reverse(ctx context.Context, a0 string) (r0 string
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -174,10 +187,10 @@ func (s reverser_client_stub) Reverse(ctx context.Context, a0 string) (r0 string
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
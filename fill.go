@@ -81,7 +81,7 @@ func hasRefs(impl any) bool {
 }
 
 // See internal/weaver/types.go.
-func fillRefs(impl any, get func(reflect.Type) (any, error)) error {
+func fillRefs(impl any, get func(reflect.Type) (any, error), replicas func(reflect.Type) ([]any, error)) error {
 	p := reflect.ValueOf(impl)
 	if p.Kind() != reflect.Pointer {
 		return fmt.Errorf("FillRefs: %T not a pointer", impl)
@@ -97,7 +97,9 @@ func fillRefs(impl any, get func(reflect.Type) (any, error)) error {
 			continue
 		}
 		p := reflect.NewAt(f.Type(), f.Addr().UnsafePointer()).Interface()
-		x, ok := p.(interface{ setRef(any) })
+		x, ok := p.(interface {
+			setRef(any, func() ([]any, error))
+		})
 		if !ok {
 			continue
 		}
@@ -108,7 +110,12 @@ func fillRefs(impl any, get func(reflect.Type) (any, error)) error {
 		if err != nil {
 			return fmt.Errorf("FillRefs: setting field %v.%s: %w", s.Type(), s.Type().Field(i).Name, err)
 		}
-		x.setRef(component)
+		var replicasOf func() ([]any, error)
+		if replicas != nil {
+			t := valueField.Type()
+			replicasOf = func() ([]any, error) { return replicas(t) }
+		}
+		x.setRef(component, replicasOf)
 	}
 	return nil
 }
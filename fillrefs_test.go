@@ -42,7 +42,7 @@ func TestFillRefs(t *testing.T) {
 		a Ref[int]
 		b Ref[string]
 	}
-	if err := fillRefs(&x, getValue); err != nil {
+	if err := fillRefs(&x, getValue, nil); err != nil {
 		t.Fatal(err)
 	}
 	if x.a.Get() != 42 {
@@ -68,7 +68,7 @@ func TestFillRefsErrors(t *testing.T) {
 		{"unsupported-type", &badref{}, "unsupported"},
 	} {
 		t.Run(c.name, func(t *testing.T) {
-			err := fillRefs(c.impl, getValue)
+			err := fillRefs(c.impl, getValue, nil)
 			if err == nil || !strings.Contains(err.Error(), c.expect) {
 				t.Fatalf("unexpected error %v; expecting %s", err, c.expect)
 			}
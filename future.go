@@ -0,0 +1,182 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Call is the future result of a function, typically a component method
+// call, started with Go. It lets a caller fan out several calls concurrently
+// and join them later:
+//
+//	u := weaver.Go(ctx, func(ctx context.Context) (User, error) {
+//	    return users.Get(ctx, id)
+//	})
+//	o := weaver.Go(ctx, func(ctx context.Context) ([]Order, error) {
+//	    return orders.List(ctx, id)
+//	})
+//	if err := weaver.Wait(ctx, u, o); err != nil {
+//	    ...
+//	}
+//	user, _ := u.Get(ctx)
+//	list, _ := o.Get(ctx)
+type Call[T any] struct {
+	done  chan struct{} // closed when the call finishes
+	value T             // the call's result, once done
+	err   error         // the call's error, once done
+}
+
+// Future is the interface implemented by every Call[T], regardless of T. It
+// lets calls with different result types be waited on together (see Wait and
+// WaitAny).
+type Future interface {
+	// Done returns a channel that is closed when the call finishes.
+	Done() <-chan struct{}
+
+	// Err returns the error returned by the call. It returns nil if the call
+	// hasn't finished.
+	Err() error
+}
+
+var _ Future = &Call[int]{}
+
+// Go calls fn(ctx) in a new goroutine and returns its future result. Use a
+// context with a deadline to bound how long fn may run; cancelling ctx is
+// the only way to stop fn early.
+func Go[T any](ctx context.Context, fn func(context.Context) (T, error)) *Call[T] {
+	c := &Call[T]{done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		c.value, c.err = fn(ctx)
+	}()
+	return c
+}
+
+// Done implements the Future interface.
+func (c *Call[T]) Done() <-chan struct{} {
+	return c.done
+}
+
+// Err implements the Future interface.
+func (c *Call[T]) Err() error {
+	select {
+	case <-c.done:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+// Get waits for the call to finish and returns its result. If ctx is done
+// first, Get returns ctx's error, and the call keeps running.
+func (c *Call[T]) Get(ctx context.Context) (T, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// CallError is an error returned by one of the calls passed to Wait.
+type CallError struct {
+	Index int   // index of the failed call in the arguments of Wait
+	Err   error // the error returned by the call
+}
+
+// Error implements the error interface.
+func (e *CallError) Error() string {
+	return fmt.Sprintf("call %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error returned by the call.
+func (e *CallError) Unwrap() error {
+	return e.Err
+}
+
+// Wait waits for all of the provided calls to finish and returns the errors
+// of the calls that failed, if any, joined together (see errors.Join). Each
+// error is a *CallError that records the index of the failed call, so the
+// results of the calls that succeeded can still be used:
+//
+//	err := weaver.Wait(ctx, a, b, c)
+//	var callErr *weaver.CallError
+//	if errors.As(err, &callErr) {
+//	    // The call at index callErr.Index failed.
+//	}
+//
+// If ctx is done before all calls finish, Wait returns ctx's error, joined
+// with the errors of the calls that failed so far. Calls that haven't
+// finished keep running; check Done or Err to see which ones did.
+func Wait(ctx context.Context, calls ...Future) error {
+	var errs []error
+	collect := func() {
+		for i, c := range calls {
+			if err := c.Err(); err != nil {
+				errs = append(errs, &CallError{Index: i, Err: err})
+			}
+		}
+	}
+	for _, c := range calls {
+		select {
+		case <-c.Done():
+		case <-ctx.Done():
+			collect()
+			return errors.Join(append([]error{ctx.Err()}, errs...)...)
+		}
+	}
+	collect()
+	return errors.Join(errs...)
+}
+
+// WaitAny waits for one of the provided calls to finish and returns its
+// index. If ctx is done first, WaitAny returns -1 and ctx's error. WaitAny
+// panics if no calls are provided.
+func WaitAny(ctx context.Context, calls ...Future) (int, error) {
+	if len(calls) == 0 {
+		panic("weaver.WaitAny: no calls")
+	}
+	// Check for calls that already finished, so that the earliest of them
+	// is returned, and fan in otherwise.
+	for i, c := range calls {
+		select {
+		case <-c.Done():
+			return i, nil
+		default:
+		}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	first := make(chan int, len(calls))
+	for i, c := range calls {
+		go func(i int, c Future) {
+			select {
+			case <-c.Done():
+				first <- i
+			case <-ctx.Done():
+			}
+		}(i, c)
+	}
+	select {
+	case i := <-first:
+		return i, nil
+	case <-ctx.Done():
+		return -1, ctx.Err()
+	}
+}
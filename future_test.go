@@ -0,0 +1,127 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+func TestGoGet(t *testing.T) {
+	ctx := context.Background()
+	c := weaver.Go(ctx, func(context.Context) (string, error) { return "ok", nil })
+	got, err := c.Get(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ok"; got != want {
+		t.Fatalf("Get: got %q, want %q", got, want)
+	}
+}
+
+func TestGetDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	c := weaver.Go(context.Background(), func(context.Context) (int, error) {
+		<-release
+		return 42, nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.Get(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Get: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if err := c.Err(); err != nil {
+		t.Fatalf("Err of unfinished call: got %v, want nil", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	ctx := context.Background()
+	boom := errors.New("boom")
+	a := weaver.Go(ctx, func(context.Context) (int, error) { return 1, nil })
+	b := weaver.Go(ctx, func(context.Context) (string, error) { return "", boom })
+	c := weaver.Go(ctx, func(context.Context) (bool, error) { return true, nil })
+
+	err := weaver.Wait(ctx, a, b, c)
+	if !errors.Is(err, boom) {
+		t.Fatalf("Wait: got %v, want %v", err, boom)
+	}
+	var callErr *weaver.CallError
+	if !errors.As(err, &callErr) || callErr.Index != 1 {
+		t.Fatalf("Wait: got %v, want error for call 1", err)
+	}
+
+	// The results of the successful calls are still available.
+	if got, err := a.Get(ctx); err != nil || got != 1 {
+		t.Fatalf("a.Get: got %v, %v, want 1, nil", got, err)
+	}
+	if got, err := c.Get(ctx); err != nil || !got {
+		t.Fatalf("c.Get: got %v, %v, want true, nil", got, err)
+	}
+
+	// No errors.
+	if err := weaver.Wait(ctx, a, c); err != nil {
+		t.Fatalf("Wait: got %v, want nil", err)
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	boom := errors.New("boom")
+	failed := weaver.Go(context.Background(), func(context.Context) (int, error) { return 0, boom })
+	<-failed.Done()
+	slow := weaver.Go(context.Background(), func(context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := weaver.Wait(ctx, failed, slow)
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, boom) {
+		t.Fatalf("Wait: got %v, want %v and %v", err, context.DeadlineExceeded, boom)
+	}
+}
+
+func TestWaitAny(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	ctx := context.Background()
+	slow := weaver.Go(ctx, func(context.Context) (int, error) {
+		<-release
+		return 0, nil
+	})
+	fast := weaver.Go(ctx, func(context.Context) (string, error) { return "fast", nil })
+	i, err := weaver.WaitAny(ctx, slow, fast)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if i != 1 {
+		t.Fatalf("WaitAny: got %d, want 1", i)
+	}
+
+	// Time out.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if i, err := weaver.WaitAny(ctx, slow); i != -1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("WaitAny: got %d, %v, want -1, %v", i, err, context.DeadlineExceeded)
+	}
+}
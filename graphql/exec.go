@@ -0,0 +1,559 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// executor executes a single operation.
+type executor struct {
+	g    *Gateway
+	doc  *document
+	vars map[string]any
+
+	mu     sync.Mutex
+	errors []Error
+}
+
+// fail records an error for the field at the provided path.
+func (e *executor) fail(path []any, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, Error{Message: err.Error(), Path: slices.Clone(path)})
+}
+
+// object is a GraphQL response object. Unlike a map, it remembers the order
+// of its fields.
+type object struct {
+	keys   []string
+	values []any
+}
+
+// set sets the value of the provided field.
+func (o *object) set(key string, value any) {
+	if i := slices.Index(o.keys, key); i >= 0 {
+		o.values[i] = value
+		return
+	}
+	o.keys = append(o.keys, key)
+	o.values = append(o.values, value)
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (o *object) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		k, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(k)
+		b.WriteByte(':')
+		b.Write(v)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// operation executes the provided operation.
+func (e *executor) operation(ctx context.Context, op *operationDef) *object {
+	resolvers, typename := e.g.queries, "Query"
+	if op.kind == "mutation" {
+		resolvers, typename = e.g.mutations, "Mutation"
+	}
+	fields, err := e.collect(op.selections, map[string]bool{})
+	if err != nil {
+		e.fail(nil, err)
+		return nil
+	}
+
+	data := &object{}
+	for _, f := range fields {
+		data.set(f.key(), nil)
+	}
+
+	// resolve resolves a single top-level field.
+	var mu sync.Mutex
+	resolve := func(ctx context.Context, f *selection) {
+		path := []any{f.key()}
+		var value any
+		if f.name == "__typename" {
+			value = typename
+		} else if r, ok := resolvers[f.name]; !ok {
+			e.fail(path, fmt.Errorf("%s has no field %q", strings.ToLower(typename), f.name))
+		} else if args, err := e.args(f, r); err != nil {
+			e.fail(path, err)
+		} else if out, err := call(r.fn, append([]reflect.Value{reflect.ValueOf(ctx)}, args...)); err != nil {
+			e.fail(path, err)
+		} else {
+			value = e.complete(ctx, f, []reflect.Value{out}, [][]any{path})[0]
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		data.set(f.key(), value)
+	}
+
+	if op.kind == "mutation" {
+		// Mutations are executed serially.
+		for _, f := range fields {
+			resolve(ctx, f)
+		}
+		return data
+	}
+	calls := make([]weaver.Future, len(fields))
+	for i, f := range fields {
+		f := f
+		calls[i] = weaver.Go(ctx, func(ctx context.Context) (struct{}, error) {
+			resolve(ctx, f)
+			return struct{}{}, nil
+		})
+	}
+	weaver.Wait(ctx, calls...)
+	return data
+}
+
+// collect returns the fields of a selection set, expanding fragments and
+// merging the sub-selections of fields with the same response key.
+func (e *executor) collect(sels []*selection, visiting map[string]bool) ([]*selection, error) {
+	var fields []*selection
+	byKey := map[string]*selection{}
+	var add func([]*selection) error
+	add = func(sels []*selection) error {
+		for _, s := range sels {
+			switch {
+			case s.isInline:
+				if err := add(s.fragment); err != nil {
+					return err
+				}
+			case s.spread != "":
+				f, ok := e.doc.fragments[s.spread]
+				if !ok {
+					return fmt.Errorf("unknown fragment %q", s.spread)
+				}
+				if visiting[s.spread] {
+					return fmt.Errorf("fragment %q spreads itself", s.spread)
+				}
+				visiting[s.spread] = true
+				err := add(f.selections)
+				delete(visiting, s.spread)
+				if err != nil {
+					return err
+				}
+			default:
+				if prev, ok := byKey[s.key()]; ok {
+					if prev.name != s.name {
+						return fmt.Errorf("fields %q and %q both use response key %q", prev.name, s.name, s.key())
+					}
+					merged := *prev
+					merged.selections = append(slices.Clone(prev.selections), s.selections...)
+					*prev = merged
+					continue
+				}
+				f := *s
+				byKey[s.key()] = &f
+				fields = append(fields, &f)
+			}
+		}
+		return nil
+	}
+	return fields, add(sels)
+}
+
+// complete completes the values of field f, one value for every parent of
+// the field, returning their JSON values. paths holds the path of every value.
+func (e *executor) complete(ctx context.Context, f *selection, values []reflect.Value, paths [][]any) []any {
+	results := make([]any, len(values))
+
+	// Flatten lists, remembering where every element belongs.
+	type item struct {
+		value reflect.Value
+		path  []any
+		store func(any)
+	}
+	var items []item
+	var flatten func(v reflect.Value, path []any, store func(any))
+	flatten = func(v reflect.Value, path []any, store func(any)) {
+		for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+			if v.IsNil() {
+				store(nil)
+				return
+			}
+			v = v.Elem()
+		}
+		switch {
+		case !v.IsValid():
+			store(nil)
+		case (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8:
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				store(nil)
+				return
+			}
+			list := make([]any, v.Len())
+			store(list)
+			for i := 0; i < v.Len(); i++ {
+				i := i
+				flatten(v.Index(i), append(slices.Clone(path), i), func(x any) { list[i] = x })
+			}
+		case isObject(v.Type()):
+			if f.selections == nil {
+				e.fail(path, fmt.Errorf("field %q of type %v must have a selection of subfields", f.name, v.Type()))
+				store(nil)
+				return
+			}
+			items = append(items, item{v, path, store})
+		default:
+			if f.selections != nil {
+				e.fail(path, fmt.Errorf("field %q of type %v must not have a selection of subfields", f.name, v.Type()))
+				store(nil)
+				return
+			}
+			store(v.Interface())
+		}
+	}
+	for i, v := range values {
+		i := i
+		flatten(v, paths[i], func(x any) { results[i] = x })
+	}
+	if len(items) == 0 {
+		return results
+	}
+
+	// Resolve the selected fields of all objects at once.
+	objs := make([]reflect.Value, len(items))
+	objPaths := make([][]any, len(items))
+	for i, it := range items {
+		objs[i], objPaths[i] = it.value, it.path
+	}
+	for i, obj := range e.selectFields(ctx, f.selections, objs, objPaths) {
+		items[i].store(obj)
+	}
+	return results
+}
+
+// selectFields resolves the provided selections on every provided object.
+func (e *executor) selectFields(ctx context.Context, sels []*selection, objs []reflect.Value, paths [][]any) []*object {
+	results := make([]*object, len(objs))
+	fields, err := e.collect(sels, map[string]bool{})
+	if err != nil {
+		for _, path := range paths {
+			e.fail(path, err)
+		}
+		return results
+	}
+	for i := range results {
+		results[i] = &object{}
+		for _, f := range fields {
+			results[i].set(f.key(), nil)
+		}
+	}
+
+	for _, f := range fields {
+		fieldPaths := make([][]any, len(objs))
+		for i := range objs {
+			fieldPaths[i] = append(slices.Clone(paths[i]), f.key())
+		}
+		values := e.resolve(ctx, f, objs, fieldPaths)
+
+		// Complete the values of the field that resolved successfully.
+		var ok []int
+		for i, v := range values {
+			if v.IsValid() {
+				ok = append(ok, i)
+			}
+		}
+		okValues := make([]reflect.Value, len(ok))
+		okPaths := make([][]any, len(ok))
+		for j, i := range ok {
+			okValues[j], okPaths[j] = values[i], fieldPaths[i]
+		}
+		for j, x := range e.complete(ctx, f, okValues, okPaths) {
+			results[ok[j]].set(f.key(), x)
+		}
+	}
+	return results
+}
+
+// invalid is the zero reflect.Value, used as the value of fields that failed
+// to resolve.
+var invalid reflect.Value
+
+// resolve resolves field f on every provided object. The returned value of a
+// field that fails to resolve is the zero reflect.Value. A null field is
+// returned as a nil interface value.
+func (e *executor) resolve(ctx context.Context, f *selection, objs []reflect.Value, paths [][]any) []reflect.Value {
+	values := make([]reflect.Value, len(objs))
+	null := reflect.ValueOf((*any)(nil)).Elem()
+	if f.name == "__typename" {
+		for i, obj := range objs {
+			values[i] = reflect.ValueOf(obj.Type().Name())
+		}
+		return values
+	}
+
+	// Group the objects by type, since objects of different types (e.g., in
+	// a list of interface values) may resolve the field differently.
+	byType := map[reflect.Type][]int{}
+	var types []reflect.Type
+	for i, obj := range objs {
+		if _, ok := byType[obj.Type()]; !ok {
+			types = append(types, obj.Type())
+		}
+		byType[obj.Type()] = append(byType[obj.Type()], i)
+	}
+	for _, t := range types {
+		indices := byType[t]
+		r, ok := e.g.fields[t][f.name]
+		if !ok {
+			// Read a struct field or map entry.
+			for _, i := range indices {
+				v, err := e.g.field(objs[i], f.name)
+				if err != nil {
+					e.fail(paths[i], err)
+					continue
+				}
+				if !v.IsValid() {
+					v = null
+				}
+				values[i] = v
+			}
+			continue
+		}
+
+		args, err := e.args(f, r)
+		if err != nil {
+			for _, i := range indices {
+				e.fail(paths[i], err)
+			}
+			continue
+		}
+		parentType := r.fn.Type().In(1)
+		if r.batch {
+			// Call the batch resolver once with all of the objects.
+			parents := reflect.MakeSlice(parentType, len(indices), len(indices))
+			for j, i := range indices {
+				parents.Index(j).Set(parentValue(objs[i], parentType.Elem()))
+			}
+			in := append([]reflect.Value{reflect.ValueOf(ctx), parents}, args...)
+			out, err := call(r.fn, in)
+			if err == nil && out.Len() != len(indices) {
+				err = fmt.Errorf("batch resolver for field %q returned %d results for %d objects", f.name, out.Len(), len(indices))
+			}
+			for j, i := range indices {
+				if err != nil {
+					e.fail(paths[i], err)
+					continue
+				}
+				values[i] = out.Index(j)
+			}
+			continue
+		}
+
+		// Call the resolver concurrently for every object.
+		calls := make([]*weaver.Call[reflect.Value], len(indices))
+		futures := make([]weaver.Future, len(indices))
+		for j, i := range indices {
+			obj := objs[i]
+			calls[j] = weaver.Go(ctx, func(ctx context.Context) (reflect.Value, error) {
+				in := append([]reflect.Value{reflect.ValueOf(ctx), parentValue(obj, parentType)}, args...)
+				return call(r.fn, in)
+			})
+			futures[j] = calls[j]
+		}
+		weaver.Wait(ctx, futures...)
+		for j, i := range indices {
+			v, err := calls[j].Get(ctx)
+			if err != nil {
+				e.fail(paths[i], err)
+				continue
+			}
+			values[i] = v
+		}
+	}
+	return values
+}
+
+// args returns the arguments of field f to pass to resolver r.
+func (e *executor) args(f *selection, r *resolver) ([]reflect.Value, error) {
+	for _, arg := range f.args {
+		if !slices.Contains(r.args, arg.name) {
+			return nil, fmt.Errorf("field %q has no argument %q", f.name, arg.name)
+		}
+	}
+	values := make([]reflect.Value, len(r.args))
+	for i, name := range r.args {
+		ptr := reflect.New(r.argTypes[i])
+		values[i] = ptr.Elem()
+		j := slices.IndexFunc(f.args, func(a argument) bool { return a.name == name })
+		if j < 0 {
+			continue
+		}
+		x, err := e.value(f.args[j].value)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(x)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", name, err)
+		}
+		if err := json.Unmarshal(data, ptr.Interface()); err != nil {
+			return nil, fmt.Errorf("argument %q: %w", name, err)
+		}
+	}
+	return values, nil
+}
+
+// value substitutes variables in an argument value.
+func (e *executor) value(x any) (any, error) {
+	switch x := x.(type) {
+	case variable:
+		return e.vars[string(x)], nil
+	case enumValue:
+		return string(x), nil
+	case []any:
+		list := make([]any, len(x))
+		for i, elem := range x {
+			v, err := e.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case map[string]any:
+		obj := make(map[string]any, len(x))
+		for k, elem := range x {
+			v, err := e.value(elem)
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = v
+		}
+		return obj, nil
+	default:
+		return x, nil
+	}
+}
+
+// call calls fn, which returns a result and an error.
+func call(fn reflect.Value, in []reflect.Value) (reflect.Value, error) {
+	out := fn.Call(in)
+	if err, _ := out[1].Interface().(error); err != nil {
+		return invalid, err
+	}
+	return out[0], nil
+}
+
+// parentValue converts an object into the parent argument type of a
+// resolver, which is either the type of the object or a pointer to it.
+func parentValue(obj reflect.Value, t reflect.Type) reflect.Value {
+	if obj.Type() == t {
+		return obj
+	}
+	if t.Kind() == reflect.Pointer && t.Elem() == obj.Type() {
+		if obj.CanAddr() {
+			return obj.Addr()
+		}
+		ptr := reflect.New(obj.Type())
+		ptr.Elem().Set(obj)
+		return ptr
+	}
+	return obj.Convert(t)
+}
+
+// isObject returns whether values of the provided type are GraphQL objects.
+func isObject(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map && t.Key().Kind() == reflect.String
+}
+
+// field returns the value of the named field of the provided object.
+func (g *Gateway) field(obj reflect.Value, name string) (reflect.Value, error) {
+	if obj.Kind() == reflect.Map {
+		v := obj.MapIndex(reflect.ValueOf(name).Convert(obj.Type().Key()))
+		return v, nil
+	}
+	index, ok := g.structFields(obj.Type())[name]
+	if !ok {
+		return invalid, fmt.Errorf("type %v has no field %q", obj.Type(), name)
+	}
+	v, err := obj.FieldByIndexErr(index)
+	if err != nil {
+		// A nil embedded pointer.
+		return invalid, nil
+	}
+	return v, nil
+}
+
+// structFields returns the indices of the GraphQL fields of the provided
+// struct type, by field name.
+func (g *Gateway) structFields(t reflect.Type) map[string][]int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if fields, ok := g.structs[t]; ok {
+		return fields
+	}
+	fields := map[string][]int{}
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous && f.Type.Kind() == reflect.Struct {
+			continue
+		}
+		name := lowerCamel(f.Name)
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			name = tag
+		}
+		fields[name] = f.Index
+	}
+	g.structs[t] = fields
+	return fields
+}
+
+// lowerCamel lower-cases the first word of the provided Go name, e.g.,
+// "ID" becomes "id", "UserName" becomes "userName", and "URLPath" becomes
+// "urlPath".
+func lowerCamel(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		// Keep the start of the next word, as in "URLPath".
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
@@ -0,0 +1,322 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package graphql serves selected component methods as a GraphQL API.
+//
+// A Gateway maps the top-level fields of GraphQL queries and mutations to
+// functions, typically thin wrappers around component methods, and resolves
+// the fields of the returned values from their struct fields, map entries, or
+// registered field resolvers:
+//
+//	g := graphql.New()
+//	g.Query("user", func(ctx context.Context, id int) (User, error) {
+//	    return s.users.Get().GetUser(ctx, id)
+//	}, "id")
+//	g.Mutation("rename", s.users.Get().Rename, "id", "name")
+//	g.BatchField("posts", func(ctx context.Context, users []User, limit int) ([][]Post, error) {
+//	    return s.posts.Get().PostsByUsers(ctx, ids(users), limit)
+//	}, "limit")
+//	return http.Serve(s.lis, g)
+//
+// With the gateway above, the query
+//
+//	{
+//	  user(id: 1) {
+//	    name
+//	    friends { name posts(limit: 3) { title } }
+//	  }
+//	}
+//
+// calls the "user" function once, reads the user's name and friends from the
+// User struct, and then calls the "posts" batch resolver once with all of the
+// friends, rather than once per friend. Fields are resolved breadth first, so
+// a batch resolver is called at most once per field of a query, no matter how
+// many objects the field is selected on. This avoids the N+1 calls that a
+// per-object resolver would make, which is particularly important when every
+// call is a remote component method call.
+//
+// Per-object field resolvers registered with Field are called concurrently
+// using weaver.Go, as are the top-level fields of a query. The top-level
+// fields of a mutation are resolved one after another, in order.
+//
+// A Go value is exposed as a GraphQL object if it is a struct or a map with
+// string keys. Struct fields are named by their json tag, if any, or by their
+// Go name with a lower-case first word (e.g., ID is "id" and UserName is
+// "userName"). Slices and arrays are exposed as lists, and all other values
+// as scalars, encoded as JSON. Fields are nullable: if a resolver returns an
+// error, the field is null and the error is reported with the field's path.
+//
+// The gateway parses queries, mutations, variables, aliases, fragments, and
+// inline fragments. It doesn't check queries against a GraphQL type system,
+// so type conditions of fragments are ignored, and it doesn't support
+// subscriptions, directives, or introspection other than __typename.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Gateway is an http.Handler that serves GraphQL queries and mutations by
+// calling registered resolvers. Register all resolvers before serving
+// requests.
+type Gateway struct {
+	queries   map[string]*resolver
+	mutations map[string]*resolver
+	fields    map[reflect.Type]map[string]*resolver // resolvers, by parent type
+
+	mu      sync.Mutex
+	structs map[reflect.Type]map[string][]int // struct field indices, by type
+}
+
+// New returns a new Gateway with no resolvers.
+func New() *Gateway {
+	return &Gateway{
+		queries:   map[string]*resolver{},
+		mutations: map[string]*resolver{},
+		fields:    map[reflect.Type]map[string]*resolver{},
+		structs:   map[reflect.Type]map[string][]int{},
+	}
+}
+
+// resolver resolves a field by calling a function.
+type resolver struct {
+	fn       reflect.Value  // the function
+	args     []string       // names of the GraphQL arguments passed to fn
+	argTypes []reflect.Type // types of the GraphQL arguments passed to fn
+	batch    bool           // does fn resolve a slice of parents at once?
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// Query registers a top-level query field. fn must have type
+//
+//	func(context.Context, A1, ..., An) (R, error)
+//
+// where A1, ..., An are the types of the field's arguments, named args. A
+// method value of a component, like users.Get().GetUser, is a valid fn.
+// Arguments are decoded from their GraphQL values as if from JSON.
+func (g *Gateway) Query(name string, fn any, args ...string) error {
+	return g.register(g.queries, "query", name, fn, false, args)
+}
+
+// Mutation registers a top-level mutation field. See Query for the
+// requirements on fn.
+func (g *Gateway) Mutation(name string, fn any, args ...string) error {
+	return g.register(g.mutations, "mutation", name, fn, false, args)
+}
+
+// Field registers a resolver for field name of objects of type T. fn must have
+// type
+//
+//	func(context.Context, T, A1, ..., An) (R, error)
+//
+// where A1, ..., An are the types of the field's arguments, named args. The
+// resolver is also used for objects of type *T. It takes precedence over a
+// struct field or map entry of the same name.
+func (g *Gateway) Field(name string, fn any, args ...string) error {
+	return g.register(nil, "field", name, fn, false, args)
+}
+
+// BatchField registers a batch resolver for field name of objects of type T.
+// fn must have type
+//
+//	func(context.Context, []T, A1, ..., An) ([]R, error)
+//
+// and return one result for every provided object, in order. See Field for
+// details.
+func (g *Gateway) BatchField(name string, fn any, args ...string) error {
+	return g.register(nil, "batch field", name, fn, true, args)
+}
+
+// register registers a resolver. If resolvers is nil, fn resolves a field of
+// the type of its second argument.
+func (g *Gateway) register(resolvers map[string]*resolver, kind, name string, fn any, batch bool, args []string) error {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return fmt.Errorf("%s %q: got %T, want a function", kind, name, fn)
+	}
+	t := v.Type()
+	skip := 1 // context.Context
+	if resolvers == nil {
+		skip = 2 // context.Context and parent
+	}
+	if t.NumIn() != skip+len(args) {
+		return fmt.Errorf("%s %q: function has %d arguments, want %d", kind, name, t.NumIn(), skip+len(args))
+	}
+	if t.In(0) != contextType {
+		return fmt.Errorf("%s %q: first argument has type %v, want context.Context", kind, name, t.In(0))
+	}
+	if t.NumOut() != 2 || t.Out(1) != errorType {
+		return fmt.Errorf("%s %q: function must return (R, error)", kind, name)
+	}
+
+	r := &resolver{fn: v, args: args, batch: batch}
+	for i := range args {
+		r.argTypes = append(r.argTypes, t.In(skip+i))
+	}
+	if resolvers == nil {
+		parent := t.In(1)
+		if batch {
+			if parent.Kind() != reflect.Slice {
+				return fmt.Errorf("%s %q: second argument has type %v, want a slice", kind, name, parent)
+			}
+			if t.Out(0).Kind() != reflect.Slice {
+				return fmt.Errorf("%s %q: result has type %v, want a slice", kind, name, t.Out(0))
+			}
+			parent = parent.Elem()
+		}
+		for parent.Kind() == reflect.Pointer {
+			parent = parent.Elem()
+		}
+		if g.fields[parent] == nil {
+			g.fields[parent] = map[string]*resolver{}
+		}
+		resolvers = g.fields[parent]
+	}
+	if _, ok := resolvers[name]; ok {
+		return fmt.Errorf("%s %q: already registered", kind, name)
+	}
+	resolvers[name] = r
+	return nil
+}
+
+// Request is a GraphQL request.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response is a GraphQL response.
+type Response struct {
+	Data   any     `json:"data"`
+	Errors []Error `json:"errors,omitempty"`
+}
+
+// Error is an error in a GraphQL response.
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"` // field names and list indices
+}
+
+// Execute executes the provided request.
+func (g *Gateway) Execute(ctx context.Context, req Request) *Response {
+	doc, op, err := prepare(req)
+	if err != nil {
+		return &Response{Errors: []Error{{Message: err.Error()}}}
+	}
+	return g.execute(ctx, doc, op, req.Variables)
+}
+
+// prepare parses the provided request and returns the operation to execute.
+func prepare(req Request) (*document, *operationDef, error) {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.OperationName == "" {
+		if len(doc.operations) > 1 {
+			return nil, nil, fmt.Errorf("operationName is required for documents with multiple operations")
+		}
+		return doc, doc.operations[0], nil
+	}
+	for _, op := range doc.operations {
+		if op.name == req.OperationName {
+			return doc, op, nil
+		}
+	}
+	return nil, nil, fmt.Errorf("operation %q not found", req.OperationName)
+}
+
+// execute executes the provided operation with the provided variables.
+func (g *Gateway) execute(ctx context.Context, doc *document, op *operationDef, variables map[string]any) *Response {
+	vars := map[string]any{}
+	for _, v := range op.variables {
+		if x, ok := variables[v.name]; ok {
+			vars[v.name] = x
+		} else if v.hasDefault {
+			vars[v.name] = v.def
+		}
+	}
+	e := &executor{g: g, doc: doc, vars: vars}
+	data := e.operation(ctx, op)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+// ServeHTTP implements the http.Handler interface. It accepts GET requests
+// with query, operationName, and variables URL parameters, and POST requests
+// with a JSON encoded Request body. Mutations must be sent with POST.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	switch r.Method {
+	case http.MethodGet:
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if vars := q.Get("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid variables: %w", err))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestSize)).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	doc, op, err := prepare(req)
+	if err != nil {
+		writeError(w, http.StatusOK, err)
+		return
+	}
+	if r.Method == http.MethodGet && op.kind == "mutation" {
+		w.Header().Set("Allow", "POST")
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("mutations must be sent with POST"))
+		return
+	}
+	writeJSON(w, http.StatusOK, g.execute(r.Context(), doc, op, req.Variables))
+}
+
+// writeError writes a response with the provided error and no data.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &Response{Errors: []Error{{Message: err.Error()}}})
+}
+
+// maxRequestSize is the maximum size of a request body, in bytes.
+const maxRequestSize = 1 << 20
+
+// writeJSON writes v as a JSON response with the provided status code.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(&Response{Errors: []Error{{Message: fmt.Sprintf("encode response: %v", err)}}})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
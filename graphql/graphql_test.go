@@ -0,0 +1,343 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/graphql"
+	"github.com/google/go-cmp/cmp"
+)
+
+type User struct {
+	ID        int
+	Name      string
+	FriendIDs []int  `json:"-"`
+	Email     string `json:"mail"`
+}
+
+type Post struct {
+	Title string
+	Likes int
+}
+
+// backend is a fake backend with users and their posts. It counts the calls
+// made to it.
+type backend struct {
+	mu    sync.Mutex
+	users map[int]User
+	posts map[int][]Post
+
+	userCalls  atomic.Int32
+	postsCalls atomic.Int32
+}
+
+func newBackend() *backend {
+	return &backend{
+		users: map[int]User{
+			1: {ID: 1, Name: "ada", FriendIDs: []int{2, 3}, Email: "ada@example.com"},
+			2: {ID: 2, Name: "bob", FriendIDs: []int{1}},
+			3: {ID: 3, Name: "cy", FriendIDs: []int{1, 2}},
+		},
+		posts: map[int][]Post{
+			1: {{"hello", 3}},
+			2: {{"hi", 1}, {"bye", 2}},
+			3: {},
+		},
+	}
+}
+
+func (b *backend) GetUser(_ context.Context, id int) (User, error) {
+	b.userCalls.Add(1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %d not found", id)
+	}
+	return u, nil
+}
+
+func (b *backend) GetUsers(_ context.Context, ids []int) ([]User, error) {
+	b.userCalls.Add(1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	users := make([]User, len(ids))
+	for i, id := range ids {
+		users[i] = b.users[id]
+	}
+	return users, nil
+}
+
+func (b *backend) PostsByUsers(_ context.Context, ids []int, limit int) ([][]Post, error) {
+	b.postsCalls.Add(1)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	posts := make([][]Post, len(ids))
+	for i, id := range ids {
+		posts[i] = b.posts[id]
+		if limit > 0 && len(posts[i]) > limit {
+			posts[i] = posts[i][:limit]
+		}
+	}
+	return posts, nil
+}
+
+func (b *backend) Rename(_ context.Context, id int, name string) (User, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u, ok := b.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %d not found", id)
+	}
+	u.Name = name
+	b.users[id] = u
+	return u, nil
+}
+
+// gateway returns a gateway over the provided backend.
+func gateway(t *testing.T, b *backend) *graphql.Gateway {
+	t.Helper()
+	g := graphql.New()
+	for _, err := range []error{
+		g.Query("user", b.GetUser, "id"),
+		g.Mutation("rename", b.Rename, "id", "name"),
+		g.BatchField("friends", func(ctx context.Context, users []User) ([][]User, error) {
+			var ids []int
+			for _, u := range users {
+				ids = append(ids, u.FriendIDs...)
+			}
+			friends, err := b.GetUsers(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make([][]User, len(users))
+			for i, u := range users {
+				result[i], friends = friends[:len(u.FriendIDs)], friends[len(u.FriendIDs):]
+			}
+			return result, nil
+		}),
+		g.BatchField("posts", func(ctx context.Context, users []User, limit int) ([][]Post, error) {
+			ids := make([]int, len(users))
+			for i, u := range users {
+				ids[i] = u.ID
+			}
+			return b.PostsByUsers(ctx, ids, limit)
+		}, "limit"),
+		g.Field("shout", func(_ context.Context, u *User, suffix string) (string, error) {
+			return strings.ToUpper(u.Name) + suffix, nil
+		}, "suffix"),
+	} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return g
+}
+
+// execute executes a request and returns the JSON encoded response.
+func execute(t *testing.T, g *graphql.Gateway, query string, vars map[string]any) string {
+	t.Helper()
+	resp := g.Execute(context.Background(), graphql.Request{Query: query, Variables: vars})
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestQuery(t *testing.T) {
+	b := newBackend()
+	g := gateway(t, b)
+	const query = `
+		query Friends($id: Int!, $limit: Int = 1) {
+			user(id: $id) {
+				__typename
+				name
+				mail
+				friends {
+					id
+					name
+					posts(limit: $limit) { title }
+					friends { name posts { title likes } }
+				}
+			}
+		}`
+	got := execute(t, g, query, map[string]any{"id": 1})
+	const want = `{"data":{"user":{"__typename":"User","name":"ada","mail":"ada@example.com","friends":[` +
+		`{"id":2,"name":"bob","posts":[{"title":"hi"}],"friends":[{"name":"ada","posts":[{"title":"hello","likes":3}]}]},` +
+		`{"id":3,"name":"cy","posts":[],"friends":[{"name":"ada","posts":[{"title":"hello","likes":3}]},{"name":"bob","posts":[{"title":"hi","likes":1},{"title":"bye","likes":2}]}]}]}}}`
+	if got != want {
+		t.Fatalf("response:\ngot  %s\nwant %s", got, want)
+	}
+
+	// The batch resolvers are called once per field, not once per object.
+	if got, want := b.userCalls.Load(), int32(3); got != want {
+		t.Errorf("user calls: got %d, want %d", got, want)
+	}
+	if got, want := b.postsCalls.Load(), int32(2); got != want {
+		t.Errorf("posts calls: got %d, want %d", got, want)
+	}
+}
+
+func TestFragmentsAndAliases(t *testing.T) {
+	g := gateway(t, newBackend())
+	const query = `
+		{
+			a: user(id: 1) { ...names }
+			b: user(id: 2) { ... on User { loud: shout(suffix: "!") } name }
+		}
+		fragment names on User { name friends { name } }`
+	got := execute(t, g, query, nil)
+	const want = `{"data":{"a":{"name":"ada","friends":[{"name":"bob"},{"name":"cy"}]},"b":{"loud":"BOB!","name":"bob"}}}`
+	if got != want {
+		t.Fatalf("response:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestMutation(t *testing.T) {
+	g := gateway(t, newBackend())
+	const query = `
+		mutation {
+			first: rename(id: 2, name: "rob") { name }
+			second: rename(id: 2, name: "robert") { name }
+		}`
+	got := execute(t, g, query, nil)
+	const want = `{"data":{"first":{"name":"rob"},"second":{"name":"robert"}}}`
+	if got != want {
+		t.Fatalf("response:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	g := gateway(t, newBackend())
+	for _, test := range []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"ResolverError", `{ ok: user(id: 1) { name } missing: user(id: 9) { name } }`,
+			`{"data":{"ok":{"name":"ada"},"missing":null},"errors":[{"message":"user 9 not found","path":["missing"]}]}`},
+		{"UnknownField", `{ user(id: 1) { name age } }`,
+			`{"data":{"user":{"name":"ada","age":null}},"errors":[{"message":"type graphql_test.User has no field \"age\"","path":["user","age"]}]}`},
+		{"UnknownArgument", `{ user(id: 1, name: "x") { name } }`,
+			`{"data":{"user":null},"errors":[{"message":"field \"user\" has no argument \"name\"","path":["user"]}]}`},
+		{"UnknownQuery", `{ group { name } }`,
+			`{"data":{"group":null},"errors":[{"message":"query has no field \"group\"","path":["group"]}]}`},
+		{"MissingSelection", `{ user(id: 1) }`,
+			`{"data":{"user":null},"errors":[{"message":"field \"user\" of type graphql_test.User must have a selection of subfields","path":["user"]}]}`},
+		{"ScalarSelection", `{ user(id: 1) { name { length } } }`,
+			`{"data":{"user":{"name":null}},"errors":[{"message":"field \"name\" of type string must not have a selection of subfields","path":["user","name"]}]}`},
+		{"ParseError", `{ user(id: 1) { name }`,
+			`{"data":null,"errors":[{"message":"line 1: unterminated selection set"}]}`},
+		{"Directive", `{ user(id: 1) @skip(if: true) { name } }`,
+			`{"data":null,"errors":[{"message":"line 1: directives are not supported"}]}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := execute(t, g, test.query, nil); got != test.want {
+				t.Fatalf("response:\ngot  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterErrors(t *testing.T) {
+	g := graphql.New()
+	for _, test := range []struct {
+		name string
+		err  error
+	}{
+		{"NotAFunction", g.Query("a", 42)},
+		{"NoContext", g.Query("b", func(int) (int, error) { return 0, nil }, "x")},
+		{"Arity", g.Query("c", func(context.Context, int) (int, error) { return 0, nil })},
+		{"NoError", g.Query("d", func(context.Context) int { return 0 })},
+		{"BatchNotSlice", g.BatchField("e", func(context.Context, User) ([]int, error) { return nil, nil })},
+	} {
+		if test.err == nil {
+			t.Errorf("%s: unexpected success", test.name)
+		}
+	}
+	if err := g.Query("f", func(context.Context) (int, error) { return 0, nil }); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Query("f", func(context.Context) (int, error) { return 0, nil }); err == nil {
+		t.Error("duplicate query: unexpected success")
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	server := httptest.NewServer(gateway(t, newBackend()))
+	defer server.Close()
+
+	do := func(t *testing.T, method string, body string, query url.Values) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, server.URL+"?"+query.Encode(), strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var got graphql.Response
+		if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := json.Marshal(got)
+		return resp.StatusCode, string(data)
+	}
+
+	for _, test := range []struct {
+		name       string
+		method     string
+		body       string
+		query      url.Values
+		wantStatus int
+		want       string
+	}{
+		{"Post", "POST", `{"query": "query($id: Int) { user(id: $id) { name } }", "variables": {"id": 2}}`, nil,
+			200, `{"data":{"user":{"name":"bob"}}}`},
+		{"Get", "GET", "", url.Values{"query": {"{ user(id: 3) { name } }"}},
+			200, `{"data":{"user":{"name":"cy"}}}`},
+		{"OperationName", "POST", `{"query": "query A { user(id: 1) { name } } query B { user(id: 2) { name } }", "operationName": "B"}`, nil,
+			200, `{"data":{"user":{"name":"bob"}}}`},
+		{"GetMutation", "GET", "", url.Values{"query": {`mutation { rename(id: 1, name: "x") { name } }`}},
+			405, `{"data":null,"errors":[{"message":"mutations must be sent with POST"}]}`},
+		{"BadBody", "POST", `{`, nil,
+			400, `{"data":null,"errors":[{"message":"invalid request: unexpected EOF"}]}`},
+		{"BadMethod", "PUT", "", nil,
+			405, `{"data":null,"errors":[{"message":"method PUT not allowed"}]}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			status, got := do(t, test.method, test.body, test.query)
+			if status != test.wantStatus {
+				t.Errorf("status: got %d, want %d", status, test.wantStatus)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("response (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,603 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// document is a parsed GraphQL document.
+type document struct {
+	operations []*operationDef
+	fragments  map[string]*fragmentDef
+}
+
+// operationDef is a query or mutation.
+type operationDef struct {
+	kind       string // "query" or "mutation"
+	name       string
+	variables  []variableDef
+	selections []*selection
+}
+
+// variableDef declares a variable of an operation.
+type variableDef struct {
+	name       string
+	def        any // default value
+	hasDefault bool
+}
+
+// fragmentDef is a named fragment.
+type fragmentDef struct {
+	name       string
+	selections []*selection
+}
+
+// selection is a field, a fragment spread, or an inline fragment.
+type selection struct {
+	// Fields.
+	alias      string
+	name       string
+	args       []argument
+	selections []*selection
+
+	// Fragment spreads and inline fragments. An inline fragment has no name
+	// and its selections in fragment.
+	spread   string
+	fragment []*selection
+	isInline bool
+}
+
+// key returns the response key of a field.
+func (s *selection) key() string {
+	if s.alias != "" {
+		return s.alias
+	}
+	return s.name
+}
+
+// argument is a field argument.
+type argument struct {
+	name  string
+	value any
+}
+
+// variable is a reference to a variable in an argument value.
+type variable string
+
+// enumValue is an enum value in an argument value.
+type enumValue string
+
+// parse parses a GraphQL document.
+func parse(src string) (*document, error) {
+	p := &parser{lex: lexer{src: strings.TrimPrefix(src, "\ufeff")}}
+	p.next()
+	doc := &document{fragments: map[string]*fragmentDef{}}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.tok.is(tokPunct, "{"):
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operationDef{kind: "query", selections: sels})
+		case p.tok.is(tokName, "query"), p.tok.is(tokName, "mutation"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.is(tokName, "fragment"):
+			f, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[f.name]; ok {
+				return nil, fmt.Errorf("duplicate fragment %q", f.name)
+			}
+			doc.fragments[f.name] = f
+		case p.tok.is(tokName, "subscription"):
+			return nil, p.errorf("subscriptions are not supported")
+		default:
+			return nil, p.errorf("unexpected %s", p.tok)
+		}
+	}
+	if p.err != nil {
+		return nil, p.err
+	}
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("no operations")
+	}
+	return doc, nil
+}
+
+// parser is a recursive descent GraphQL parser.
+type parser struct {
+	lex lexer
+	tok token
+	err error // first lexing error
+}
+
+// next advances to the next token.
+func (p *parser) next() {
+	tok, err := p.lex.next()
+	if err != nil && p.err == nil {
+		p.err = err
+	}
+	p.tok = tok
+}
+
+// errorf returns an error at the current token.
+func (p *parser) errorf(format string, args ...any) error {
+	if p.err != nil {
+		return p.err
+	}
+	return fmt.Errorf("line %d: %s", p.tok.line, fmt.Sprintf(format, args...))
+}
+
+// expect consumes the provided punctuator.
+func (p *parser) expect(punct string) error {
+	if !p.tok.is(tokPunct, punct) {
+		return p.errorf("got %s, want %q", p.tok, punct)
+	}
+	p.next()
+	return nil
+}
+
+// name consumes a name.
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", p.errorf("got %s, want a name", p.tok)
+	}
+	name := p.tok.text
+	p.next()
+	return name, nil
+}
+
+// operation parses an operation definition.
+func (p *parser) operation() (*operationDef, error) {
+	op := &operationDef{kind: p.tok.text}
+	p.next()
+	if p.tok.kind == tokName {
+		op.name = p.tok.text
+		p.next()
+	}
+	if p.tok.is(tokPunct, "(") {
+		p.next()
+		for !p.tok.is(tokPunct, ")") {
+			if err := p.expect("$"); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			if err := p.typeRef(); err != nil {
+				return nil, err
+			}
+			v := variableDef{name: name}
+			if p.tok.is(tokPunct, "=") {
+				p.next()
+				if v.def, err = p.value(true); err != nil {
+					return nil, err
+				}
+				v.hasDefault = true
+			}
+			op.variables = append(op.variables, v)
+		}
+		p.next()
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.selections = sels
+	return op, nil
+}
+
+// typeRef parses and discards a type reference, like "[ID!]!".
+func (p *parser) typeRef() error {
+	if p.tok.is(tokPunct, "[") {
+		p.next()
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if _, err := p.name(); err != nil {
+		return err
+	}
+	if p.tok.is(tokPunct, "!") {
+		p.next()
+	}
+	return nil
+}
+
+// directives rejects directives, which are not supported.
+func (p *parser) directives() error {
+	if p.tok.is(tokPunct, "@") {
+		return p.errorf("directives are not supported")
+	}
+	return nil
+}
+
+// fragment parses a fragment definition.
+func (p *parser) fragment() (*fragmentDef, error) {
+	p.next()
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if !p.tok.is(tokName, "on") {
+		return nil, p.errorf("got %s, want \"on\"", p.tok)
+	}
+	p.next()
+	if _, err := p.name(); err != nil {
+		return nil, err
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	sels, err := p.selectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &fragmentDef{name: name, selections: sels}, nil
+}
+
+// selectionSet parses a selection set.
+func (p *parser) selectionSet() ([]*selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var sels []*selection
+	for !p.tok.is(tokPunct, "}") {
+		if p.tok.kind == tokEOF {
+			return nil, p.errorf("unterminated selection set")
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+	p.next()
+	if len(sels) == 0 {
+		return nil, p.errorf("empty selection set")
+	}
+	return sels, nil
+}
+
+// selection parses a field, fragment spread, or inline fragment.
+func (p *parser) selection() (*selection, error) {
+	if p.tok.is(tokPunct, "...") {
+		p.next()
+		if p.tok.kind == tokName && p.tok.text != "on" {
+			name := p.tok.text
+			p.next()
+			return &selection{spread: name}, p.directives()
+		}
+		if p.tok.is(tokName, "on") {
+			p.next()
+			if _, err := p.name(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.directives(); err != nil {
+			return nil, err
+		}
+		sels, err := p.selectionSet()
+		if err != nil {
+			return nil, err
+		}
+		return &selection{fragment: sels, isInline: true}, nil
+	}
+
+	sel := &selection{}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.is(tokPunct, ":") {
+		p.next()
+		sel.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	sel.name = name
+	if p.tok.is(tokPunct, "(") {
+		p.next()
+		for !p.tok.is(tokPunct, ")") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.value(false)
+			if err != nil {
+				return nil, err
+			}
+			sel.args = append(sel.args, argument{name: name, value: value})
+		}
+		p.next()
+	}
+	if err := p.directives(); err != nil {
+		return nil, err
+	}
+	if p.tok.is(tokPunct, "{") {
+		if sel.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return sel, nil
+}
+
+// value parses a value. If constant is true, variables are not allowed.
+func (p *parser) value(constant bool) (any, error) {
+	tok := p.tok
+	switch {
+	case tok.is(tokPunct, "$"):
+		if constant {
+			return nil, p.errorf("variables are not allowed in constant values")
+		}
+		p.next()
+		name, err := p.name()
+		return variable(name), err
+	case tok.kind == tokInt:
+		p.next()
+		n, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid integer %s", tok.line, tok.text)
+		}
+		return n, nil
+	case tok.kind == tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid float %s", tok.line, tok.text)
+		}
+		return f, nil
+	case tok.kind == tokString:
+		p.next()
+		return tok.text, nil
+	case tok.is(tokName, "true"), tok.is(tokName, "false"):
+		p.next()
+		return tok.text == "true", nil
+	case tok.is(tokName, "null"):
+		p.next()
+		return nil, nil
+	case tok.kind == tokName:
+		p.next()
+		return enumValue(tok.text), nil
+	case tok.is(tokPunct, "["):
+		p.next()
+		list := []any{}
+		for !p.tok.is(tokPunct, "]") {
+			if p.tok.kind == tokEOF {
+				return nil, p.errorf("unterminated list")
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		p.next()
+		return list, nil
+	case tok.is(tokPunct, "{"):
+		p.next()
+		obj := map[string]any{}
+		for !p.tok.is(tokPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			v, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			obj[name] = v
+		}
+		p.next()
+		return obj, nil
+	default:
+		return nil, p.errorf("got %s, want a value", tok)
+	}
+}
+
+// tokenKind is the kind of a token.
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokPunct
+	tokName
+	tokInt
+	tokFloat
+	tokString
+)
+
+// token is a lexical token.
+type token struct {
+	kind tokenKind
+	text string // for strings, the unescaped value
+	line int
+}
+
+// is returns whether the token has the provided kind and text.
+func (t token) is(kind tokenKind, text string) bool {
+	return t.kind == kind && t.text == text
+}
+
+// String implements the fmt.Stringer interface.
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of document"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// lexer splits a GraphQL document into tokens.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+// next returns the next token.
+func (l *lexer) next() (token, error) {
+	if l.line == 0 {
+		l.line = 1
+	}
+	// Skip whitespace, commas, and comments.
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		if c == '\n' {
+			l.line++
+		}
+		if c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',' {
+			l.pos++
+			continue
+		}
+		if c == '#' {
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				l.pos++
+			}
+			continue
+		}
+		break
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, line: l.line}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	switch {
+	case strings.HasPrefix(l.src[l.pos:], "..."):
+		l.pos += 3
+		return token{kind: tokPunct, text: "...", line: l.line}, nil
+	case strings.IndexByte("!$():=@[]{|}", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, text: string(c), line: l.line}, nil
+	case c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		for l.pos < len(l.src) && isNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, text: l.src[start:l.pos], line: l.line}, nil
+	case c == '-' || '0' <= c && c <= '9':
+		kind := tokInt
+		l.pos++
+		for l.pos < len(l.src) {
+			c := l.src[l.pos]
+			if c == '.' || c == 'e' || c == 'E' || (c == '+' || c == '-') && kind == tokFloat {
+				kind = tokFloat
+			} else if !('0' <= c && c <= '9') {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: kind, text: l.src[start:l.pos], line: l.line}, nil
+	case c == '"':
+		return l.string()
+	default:
+		r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+		return token{kind: tokEOF, line: l.line}, fmt.Errorf("line %d: unexpected character %q", l.line, r)
+	}
+}
+
+// string lexes a string value.
+func (l *lexer) string() (token, error) {
+	line := l.line
+	if strings.HasPrefix(l.src[l.pos:], `"""`) {
+		end := strings.Index(l.src[l.pos+3:], `"""`)
+		if end < 0 {
+			return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: unterminated block string", line)
+		}
+		text := l.src[l.pos+3 : l.pos+3+end]
+		l.line += strings.Count(text, "\n")
+		l.pos += end + 6
+		return token{kind: tokString, text: text, line: line}, nil
+	}
+	var b strings.Builder
+	for i := l.pos + 1; i < len(l.src); i++ {
+		switch c := l.src[i]; c {
+		case '"':
+			l.pos = i + 1
+			return token{kind: tokString, text: b.String(), line: line}, nil
+		case '\n':
+			return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: unterminated string", line)
+		case '\\':
+			if i+1 >= len(l.src) {
+				return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i++
+			switch e := l.src[i]; e {
+			case '"', '\\', '/':
+				b.WriteByte(e)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				if i+4 >= len(l.src) {
+					return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: invalid unicode escape", line)
+				}
+				n, err := strconv.ParseUint(l.src[i+1:i+5], 16, 32)
+				if err != nil {
+					return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: invalid unicode escape", line)
+				}
+				b.WriteRune(rune(n))
+				i += 4
+			default:
+				return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: invalid escape \\%c", line, e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return token{kind: tokEOF, line: line}, fmt.Errorf("line %d: unterminated string", line)
+}
+
+// isNameChar returns whether c may appear in a name.
+func isNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/ServiceWeaver/weaver/internal/handoff"
+)
+
+// HandOffFile hands off an open file under the provided name, to be taken by
+// TakeFile. Ownership of the file passes to Service Weaver: the caller should
+// not use or close f afterwards.
+//
+// Files are handed off between the components that run in the same process,
+// i.e., colocated components. A file that is handed off but not taken when its
+// process exits is handed off to the process that replaces it, if the deployer
+// replaces processes (e.g., "weaver multi deploy" restarts the processes of
+// isolated components) and the platform supports it (it doesn't on Windows).
+// This lets a proxy, for example, hand off its listener and the connections it
+// accepted to its next incarnation, without dropping a connection:
+//
+//	// Before exiting.
+//	weaver.HandOffListener("proxy", lis)
+//
+//	// After restarting.
+//	lis, err := weaver.TakeListener(ctx, "proxy")
+//
+// A file taken shortly before its process exits may be handed off to the
+// replacement process as well, so take files with names that are unique to an
+// incarnation, or be ready to take a file twice.
+func HandOffFile(name string, f *os.File) error {
+	return handoff.Default().Put(name, f)
+}
+
+// TakeFile waits until a file is handed off under the provided name (see
+// HandOffFile), and returns it. The caller takes ownership of the file.
+// Every handed off file is returned by at most one call to TakeFile.
+func TakeFile(ctx context.Context, name string) (*os.File, error) {
+	return handoff.Default().Take(ctx, name)
+}
+
+// HandOffListener hands off a listener under the provided name, to be taken
+// by TakeListener. The listener must be a *net.TCPListener or a
+// *net.UnixListener. HandOffListener closes lis, but the underlying socket
+// stays open, and keeps queueing incoming connections, until the listener
+// returned by TakeListener is closed. See HandOffFile for details.
+func HandOffListener(name string, lis net.Listener) error {
+	f, err := fileOf(lis)
+	if err != nil {
+		return err
+	}
+	if err := HandOffFile(name, f); err != nil {
+		f.Close()
+		return err
+	}
+	return lis.Close()
+}
+
+// TakeListener waits until a listener is handed off under the provided name
+// (see HandOffListener), and returns it.
+func TakeListener(ctx context.Context, name string) (net.Listener, error) {
+	f, err := TakeFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return net.FileListener(f)
+}
+
+// HandOffConn hands off a connection under the provided name, to be taken by
+// TakeConn. The connection must be a *net.TCPConn or a *net.UnixConn.
+// HandOffConn closes conn, but the underlying socket stays open until the
+// connection returned by TakeConn is closed. Data that was read from conn but
+// not consumed, e.g., because conn is wrapped in a bufio.Reader, is not handed
+// off. See HandOffFile for details.
+func HandOffConn(name string, conn net.Conn) error {
+	f, err := fileOf(conn)
+	if err != nil {
+		return err
+	}
+	if err := HandOffFile(name, f); err != nil {
+		f.Close()
+		return err
+	}
+	return conn.Close()
+}
+
+// TakeConn waits until a connection is handed off under the provided name
+// (see HandOffConn), and returns it.
+func TakeConn(ctx context.Context, name string) (net.Conn, error) {
+	f, err := TakeFile(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return net.FileConn(f)
+}
+
+// fileOf returns a duplicate of the file underlying the provided listener or
+// connection.
+func fileOf(x any) (*os.File, error) {
+	filer, ok := x.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, fmt.Errorf("cannot hand off a %T", x)
+	}
+	return filer.File()
+}
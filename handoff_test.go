@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+func TestHandOffListener(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("listeners can't be converted to files on Windows")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	lis := listen(t)
+	addr := lis.Addr().String()
+	if err := weaver.HandOffListener("TestHandOffListener", lis); err != nil {
+		t.Fatal(err)
+	}
+
+	// Connections made while the listener is handed off are queued.
+	client, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	lis, err = weaver.TakeListener(ctx, "TestHandOffListener")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	server, err := lis.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hand off the accepted connection too.
+	if err := weaver.HandOffConn("TestHandOffListener/conn", server); err != nil {
+		t.Fatal(err)
+	}
+	server, err = weaver.TakeConn(ctx, "TestHandOffListener/conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	if _, err := client.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	got := make([]byte, 5)
+	if _, err := io.ReadFull(server, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("read: got %q, want %q", got, "hello")
+	}
+}
@@ -0,0 +1,342 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+const cart = "example.com/shop/Cart"
+
+// methodMetrics returns the cumulative method metrics of a method of the cart
+// component, with the provided number of calls, errors, and calls of every
+// latency bucket.
+func methodMetrics(method string, calls, errors float64, latencies ...uint64) []*metrics.MetricSnapshot {
+	labels := map[string]string{"caller": "main", "component": cart, "method": method}
+	counts := make([]uint64, len(imetrics.GeneratedBuckets)+1)
+	copy(counts, latencies)
+	return []*metrics.MetricSnapshot{
+		{Name: imetrics.MethodCountsName, Labels: labels, Value: calls},
+		{Name: imetrics.MethodErrorsName, Labels: labels, Value: errors},
+		{Name: imetrics.MethodLatenciesName, Labels: labels, Bounds: imetrics.GeneratedBuckets, Counts: counts},
+	}
+}
+
+func parse(t *testing.T, config string) *Config {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseConfig(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestParseConfig(t *testing.T) {
+	got := parse(t, `
+[alerts]
+webhook = "http://example.com/hook"
+interval = "5s"
+
+[[alerts.rules]]
+name = "cart errors"
+kind = "error_rate"
+component = "example.com/shop/Cart"
+threshold = 0.05
+for = "2m"
+min_calls = 10
+
+[[alerts.rules]]
+name = "down"
+kind = "replica_down"
+severity = "critical"
+`)
+	want := &Config{
+		Interval: 5 * time.Second,
+		Webhook:  "http://example.com/hook",
+		Rules: []Rule{
+			{Name: "cart errors", Kind: ErrorRate, Component: cart, Threshold: 0.05, Window: time.Minute, MinCalls: 10, For: 2 * time.Minute, Severity: "error"},
+			{Name: "down", Kind: ReplicaDown, Window: time.Minute, Severity: "critical"},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ParseConfig (-want +got):\n%s", diff)
+	}
+
+	if got := parse(t, ""); got != nil {
+		t.Fatalf("ParseConfig: got %v, want nil", got)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"NoDestination", `[[alerts.rules]]
+name = "x"
+kind = "error_rate"`, "without a webhook"},
+		{"BadKind", `webhook = "http://x"
+[[alerts.rules]]
+name = "x"
+kind = "cpu"`, `invalid kind "cpu"`},
+		{"Duplicate", `webhook = "http://x"
+[[alerts.rules]]
+name = "x"
+kind = "error_rate"
+[[alerts.rules]]
+name = "x"
+kind = "error_rate"`, "duplicate name"},
+		{"MethodWithoutComponent", `webhook = "http://x"
+[[alerts.rules]]
+name = "x"
+kind = "error_rate"
+method = "Add"`, "method set without a component"},
+		{"UnknownKey", `webhook = "http://x"
+[[alerts.rules]]
+name = "x"
+kind = "error_rate"
+treshold = 1`, "unknown keys"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			app, err := runtime.ParseConfig("weaver.toml", "[alerts]\n"+test.config, codegen.ComponentConfigValidator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ParseConfig(app)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+// statuses returns the rules and statuses of the provided alerts.
+func statuses(alerts []*Alert) []string {
+	var s []string
+	for _, a := range alerts {
+		s = append(s, a.Rule+" "+a.Status)
+	}
+	return s
+}
+
+func TestErrorRate(t *testing.T) {
+	config := &Config{Webhook: "http://x", Rules: []Rule{
+		{Name: "errors", Kind: ErrorRate, Component: "shop.Cart", Threshold: 0.1, MinCalls: 10, For: 30 * time.Second},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(config, "shop", "1234", nil)
+	start := time.Now()
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	for _, step := range []struct {
+		at            int
+		calls, errors float64
+		want          []string
+	}{
+		{0, 0, 0, nil},
+		{15, 100, 1, nil},  // 1% errors
+		{30, 200, 50, nil}, // 25% errors, pending
+		{45, 200, 50, nil}, // still pending
+		{60, 300, 150, []string{"errors firing"}}, // held for 30 seconds
+		{75, 305, 150, nil},                       // still firing over the one minute window
+		{135, 1000, 150, []string{"errors resolved"}},
+	} {
+		got := e.Evaluate(at(step.at), methodMetrics("Add", step.calls, step.errors), nil)
+		if diff := cmp.Diff(step.want, statuses(got)); diff != "" {
+			t.Fatalf("at %ds (-want +got):\n%s", step.at, diff)
+		}
+		if len(got) > 0 && got[0].Status == Firing {
+			a := got[0]
+			if a.Component != cart || a.App != "shop" || a.DeploymentId != "1234" {
+				t.Fatalf("at %ds: bad alert %+v", step.at, a)
+			}
+			if want := "[shop] errors: shop.Cart: error rate"; !strings.HasPrefix(a.Summary, want) {
+				t.Fatalf("at %ds: summary %q, want prefix %q", step.at, a.Summary, want)
+			}
+		}
+	}
+}
+
+func TestMinCalls(t *testing.T) {
+	config := &Config{Webhook: "http://x", Rules: []Rule{
+		{Name: "errors", Kind: ErrorRate, Threshold: 0.1, MinCalls: 10},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(config, "shop", "1234", nil)
+	now := time.Now()
+	e.Evaluate(now, methodMetrics("Add", 0, 0), nil)
+	if got := e.Evaluate(now.Add(time.Second), methodMetrics("Add", 5, 5), nil); len(got) != 0 {
+		t.Fatalf("unexpected alerts %v", statuses(got))
+	}
+}
+
+func TestP99Latency(t *testing.T) {
+	config := &Config{Webhook: "http://x", Rules: []Rule{
+		{Name: "slow", Kind: P99Latency, Component: cart, Method: "Add", Threshold: 100},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(config, "shop", "1234", nil)
+	now := time.Now()
+	fast := func(n uint64) []*metrics.MetricSnapshot {
+		// The bucket at index 10 holds latencies between 1ms and 2ms.
+		counts := make([]uint64, 11)
+		counts[10] = n
+		return methodMetrics("Add", float64(n), 0, counts...)
+	}
+	e.Evaluate(now, fast(0), nil)
+	if got := e.Evaluate(now.Add(time.Second), fast(100), nil); len(got) != 0 {
+		t.Fatalf("unexpected alerts %v", statuses(got))
+	}
+
+	// The bucket at index 18 holds latencies between 500ms and 1s.
+	counts := make([]uint64, 19)
+	counts[10] = 100
+	counts[18] = 10
+	got := e.Evaluate(now.Add(2*time.Second), methodMetrics("Add", 110, 0, counts...), nil)
+	if diff := cmp.Diff([]string{"slow firing"}, statuses(got)); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+	if got[0].Value < 500 || got[0].Value > 1000 || got[0].Method != "Add" {
+		t.Fatalf("bad alert %+v", got[0])
+	}
+
+	// Calls to other methods are ignored.
+	other := append(methodMetrics("Remove", 1000, 0, counts...), methodMetrics("Add", 110, 0, counts...)...)
+	if got := e.Evaluate(now.Add(3*time.Second), other, nil); len(got) != 0 {
+		t.Fatalf("unexpected alerts %v", statuses(got))
+	}
+}
+
+func TestReplicaDown(t *testing.T) {
+	config := &Config{Webhook: "http://x", Rules: []Rule{
+		{Name: "down", Kind: ReplicaDown, Component: "shop.Cart"},
+	}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	e := NewEngine(config, "shop", "1234", nil)
+	now := time.Now()
+	groups := func(ready int) []Group {
+		return []Group{
+			{Name: cart, Components: []string{cart}, Replicas: 2, Ready: ready},
+			{Name: "main", Components: []string{"main"}, Replicas: 2, Ready: 0},
+		}
+	}
+	for i, step := range []struct {
+		ready int
+		want  []string
+	}{
+		{2, nil},
+		{1, []string{"down firing"}},
+		{0, nil},
+		{2, []string{"down resolved"}},
+	} {
+		got := e.Evaluate(now.Add(time.Duration(i)*time.Second), nil, groups(step.ready))
+		if diff := cmp.Diff(step.want, statuses(got)); diff != "" {
+			t.Fatalf("step %d (-want +got):\n%s", i, diff)
+		}
+		if len(got) > 0 && got[0].Group != cart {
+			t.Fatalf("step %d: bad alert %+v", i, got[0])
+		}
+	}
+}
+
+func TestNotify(t *testing.T) {
+	var mu sync.Mutex
+	received := map[string]map[string]any{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		var body map[string]any
+		if err := json.Unmarshal(data, &body); err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received[r.URL.Path] = body
+	}))
+	defer server.Close()
+	defer func(url string) { pagerDutyURL = url }(pagerDutyURL)
+	pagerDutyURL = server.URL + "/pagerduty"
+
+	n := &notifier{config: &Config{
+		Webhook:             server.URL + "/webhook",
+		SlackWebhook:        server.URL + "/slack",
+		PagerDutyRoutingKey: "key",
+	}}
+	alert := &Alert{
+		Rule:         "errors",
+		Kind:         ErrorRate,
+		Status:       Firing,
+		Severity:     "critical",
+		App:          "shop",
+		DeploymentId: "1234",
+		Component:    cart,
+		Method:       "Add",
+		Summary:      "[shop] errors: shop.Cart.Add: error rate 50.0% > 10.0%",
+	}
+	if err := n.notify(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := received["/webhook"]["component"]; got != cart {
+		t.Errorf("webhook component: got %v, want %q", got, cart)
+	}
+	if got, _ := received["/slack"]["text"].(string); !strings.Contains(got, alert.Summary) || !strings.Contains(got, cart) {
+		t.Errorf("slack text: got %q", got)
+	}
+	pd := received["/pagerduty"]
+	if pd["routing_key"] != "key" || pd["event_action"] != "trigger" || pd["dedup_key"] != "shop/1234/errors/"+cart+"/Add/" {
+		t.Errorf("pagerduty event: got %v", pd)
+	}
+	if payload, _ := pd["payload"].(map[string]any); payload["severity"] != "critical" || payload["component"] != cart {
+		t.Errorf("pagerduty payload: got %v", payload)
+	}
+
+	// A failing destination returns an error.
+	n.config.Webhook = server.URL + "/missing"
+	if err := n.notify(context.Background(), alert); err == nil {
+		t.Fatal("unexpected success")
+	}
+}
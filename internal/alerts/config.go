@@ -0,0 +1,192 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alerts evaluates alerting rules over the metrics of a deployment
+// and sends notifications when alerts fire and resolve.
+//
+// Rules are defined in the [alerts] section of a config file:
+//
+//	[alerts]
+//	slack_webhook = "https://hooks.slack.com/services/..."
+//
+//	[[alerts.rules]]
+//	name = "cart errors"
+//	kind = "error_rate"
+//	component = "example.com/shop/Cart"
+//	threshold = 0.05
+//	for = "2m"
+//
+// The multiprocess and SSH deployers evaluate the rules periodically. This
+// provides basic alerting for deployments without a full monitoring stack.
+package alerts
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/alerts"
+	shortConfigKey = "alerts"
+)
+
+// Rule kinds.
+const (
+	// ErrorRate rules fire when the fraction of calls to a component or
+	// method that fail exceeds the threshold, e.g., 0.05.
+	ErrorRate = "error_rate"
+
+	// P99Latency rules fire when the 99th percentile latency of the calls to
+	// a component or method exceeds the threshold, in milliseconds.
+	P99Latency = "p99_latency"
+
+	// ReplicaDown rules fire when the number of replicas of a colocation
+	// group that aren't ready exceeds the threshold, which defaults to zero.
+	ReplicaDown = "replica_down"
+)
+
+// Defaults.
+const (
+	defaultInterval = 15 * time.Second
+	defaultWindow   = time.Minute
+)
+
+// Config configures alerting.
+type Config struct {
+	// Interval is how often rules are evaluated. Defaults to 15 seconds.
+	Interval time.Duration `toml:"interval"`
+
+	// Webhook is a URL to which alerts are POSTed as JSON.
+	Webhook string `toml:"webhook"`
+
+	// SlackWebhook is the URL of a Slack incoming webhook.
+	SlackWebhook string `toml:"slack_webhook"`
+
+	// PagerDutyRoutingKey is the integration key of a PagerDuty service.
+	// Alerts are sent with the PagerDuty Events API v2.
+	PagerDutyRoutingKey string `toml:"pagerduty_routing_key"`
+
+	// Rules are the alerting rules.
+	Rules []Rule `toml:"rules"`
+}
+
+// Rule is an alerting rule.
+type Rule struct {
+	// Name is the unique name of the rule.
+	Name string `toml:"name"`
+
+	// Kind is ErrorRate, P99Latency, or ReplicaDown.
+	Kind string `toml:"kind"`
+
+	// Component restricts the rule to the provided component, by full or
+	// short name. For ReplicaDown rules, it restricts the rule to the
+	// colocation group hosting the component. An empty component matches
+	// every component. Every matching component or group is evaluated, and
+	// alerts, separately.
+	Component string `toml:"component"`
+
+	// Method restricts the rule to a method of Component. If empty, calls to
+	// all the methods of a component are aggregated.
+	Method string `toml:"method"`
+
+	// Threshold is the value above which the rule fires. See the rule kinds.
+	Threshold float64 `toml:"threshold"`
+
+	// Window is the period over which error rates and latencies are
+	// computed. Defaults to one minute.
+	Window time.Duration `toml:"window"`
+
+	// MinCalls is the minimum number of calls in a window for an ErrorRate
+	// or P99Latency rule to fire, so that a single failed call to an idle
+	// component doesn't fire an alert.
+	MinCalls int `toml:"min_calls"`
+
+	// For is how long the rule's condition must hold before the rule fires.
+	// Defaults to zero, i.e., the rule fires as soon as its condition holds.
+	For time.Duration `toml:"for"`
+
+	// Severity is the severity reported to PagerDuty: "critical", "error",
+	// "warning", or "info". Defaults to "error".
+	Severity string `toml:"severity"`
+}
+
+// ParseConfig parses the alerting config in the provided app config. It
+// returns nil if the app config doesn't configure alerts.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	if len(config.Rules) == 0 {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// Validate validates and fills in the defaults of a config. It is called by
+// runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	if c.Interval < 0 {
+		return fmt.Errorf("negative interval %v", c.Interval)
+	}
+	if c.Interval == 0 {
+		c.Interval = defaultInterval
+	}
+	if len(c.Rules) > 0 && c.Webhook == "" && c.SlackWebhook == "" && c.PagerDutyRoutingKey == "" {
+		return fmt.Errorf("alerting rules without a webhook, slack_webhook, or pagerduty_routing_key")
+	}
+	names := map[string]bool{}
+	for i := range c.Rules {
+		r := &c.Rules[i]
+		if r.Name == "" {
+			return fmt.Errorf("rule %d: missing name", i)
+		}
+		if names[r.Name] {
+			return fmt.Errorf("rule %q: duplicate name", r.Name)
+		}
+		names[r.Name] = true
+		switch r.Kind {
+		case ErrorRate, P99Latency:
+		case ReplicaDown:
+			if r.Method != "" {
+				return fmt.Errorf("rule %q: method set for a %s rule", r.Name, r.Kind)
+			}
+		default:
+			return fmt.Errorf("rule %q: invalid kind %q; must be %q, %q, or %q", r.Name, r.Kind, ErrorRate, P99Latency, ReplicaDown)
+		}
+		if r.Method != "" && r.Component == "" {
+			return fmt.Errorf("rule %q: method set without a component", r.Name)
+		}
+		if r.Threshold < 0 {
+			return fmt.Errorf("rule %q: negative threshold %v", r.Name, r.Threshold)
+		}
+		if r.Window < 0 || r.For < 0 || r.MinCalls < 0 {
+			return fmt.Errorf("rule %q: negative window, for, or min_calls", r.Name)
+		}
+		if r.Window == 0 {
+			r.Window = defaultWindow
+		}
+		switch r.Severity {
+		case "":
+			r.Severity = "error"
+		case "critical", "error", "warning", "info":
+		default:
+			return fmt.Errorf("rule %q: invalid severity %q", r.Name, r.Severity)
+		}
+	}
+	return nil
+}
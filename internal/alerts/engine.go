@@ -0,0 +1,336 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+)
+
+// Alert statuses.
+const (
+	Firing   = "firing"
+	Resolved = "resolved"
+)
+
+// An Alert is a notification that a rule started or stopped firing.
+type Alert struct {
+	Rule         string    `json:"rule"`
+	Kind         string    `json:"kind"`
+	Status       string    `json:"status"` // Firing or Resolved
+	Severity     string    `json:"severity"`
+	Time         time.Time `json:"time"`
+	App          string    `json:"app"`
+	DeploymentId string    `json:"deployment_id"`
+	Component    string    `json:"component,omitempty"` // full component name
+	Method       string    `json:"method,omitempty"`
+	Group        string    `json:"group,omitempty"` // colocation group
+	Value        float64   `json:"value"`
+	Threshold    float64   `json:"threshold"`
+	Summary      string    `json:"summary"`
+}
+
+// Group is the state of a colocation group, as reported by a deployer.
+type Group struct {
+	Name       string   // group name
+	Components []string // components hosted by the group
+	Replicas   int      // desired number of replicas
+	Ready      int      // number of replicas that receive traffic
+}
+
+// An Engine evaluates alerting rules. It is safe for concurrent use.
+type Engine struct {
+	config       *Config
+	app          string
+	deploymentId string
+	logger       *slog.Logger
+	notify       func(context.Context, *Alert) error // sends notifications
+
+	mu      sync.Mutex
+	samples []sample               // previous samples, oldest first
+	states  map[subject]*ruleState // rule states, by subject
+}
+
+// subject identifies what a rule is evaluated over: a component, a method of
+// a component, or a colocation group.
+type subject struct {
+	rule      string
+	component string
+	method    string
+	group     string
+}
+
+// ruleState is the state of a rule for a subject.
+type ruleState struct {
+	pendingSince time.Time // when the condition started holding
+	firing       bool      // has the alert fired?
+}
+
+// sample is a set of cumulative method counts taken at a point in time.
+type sample struct {
+	at      time.Time
+	methods map[imetrics.MethodKey]*imetrics.MethodCounts // by component and method
+}
+
+// NewEngine returns an engine that evaluates the rules of the provided config
+// for the provided deployment.
+func NewEngine(config *Config, app, deploymentId string, logger *slog.Logger) *Engine {
+	n := &notifier{config: config, app: app, deploymentId: deploymentId}
+	return &Engine{
+		config:       config,
+		app:          app,
+		deploymentId: deploymentId,
+		logger:       logger,
+		notify:       n.notify,
+		states:       map[subject]*ruleState{},
+	}
+}
+
+// Run evaluates the rules every config.Interval, until ctx is canceled, and
+// sends notifications for the alerts that fire and resolve. collect returns
+// the metric snapshots of every process of the deployment and the state of
+// its colocation groups.
+func (e *Engine) Run(ctx context.Context, collect func() ([]*metrics.MetricSnapshot, []Group)) {
+	ticker := time.NewTicker(e.config.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			snapshots, groups := collect()
+			for _, alert := range e.Evaluate(time.Now(), snapshots, groups) {
+				if alert.Status == Firing {
+					e.logger.Warn("Alert firing", "rule", alert.Rule, "summary", alert.Summary)
+				} else {
+					e.logger.Info("Alert resolved", "rule", alert.Rule, "summary", alert.Summary)
+				}
+				if err := e.notify(ctx, alert); err != nil {
+					e.logger.Error("Cannot send alert notification", "err", err, "rule", alert.Rule)
+				}
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Evaluate evaluates the rules against the provided metric snapshots and
+// groups, taken at the provided time, and returns the alerts that started or
+// stopped firing. Error rates and latencies are computed against previous
+// snapshots, so rules over them can't fire on the first evaluation.
+func (e *Engine) Evaluate(now time.Time, snapshots []*metrics.MetricSnapshot, groups []Group) []*Alert {
+	cur := sample{at: now, methods: imetrics.AggregateMethods(snapshots, byMethod)}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Evaluate the conditions of every rule for every subject.
+	holds := map[subject]float64{}
+	evaluated := map[subject]bool{}
+	for _, rule := range e.config.Rules {
+		switch rule.Kind {
+		case ErrorRate, P99Latency:
+			prev := e.previous(now, rule.Window)
+			if prev == nil {
+				continue
+			}
+			for s, v := range e.evalMethods(rule, prev, cur) {
+				evaluated[s] = true
+				if v > rule.Threshold {
+					holds[s] = v
+				}
+			}
+		case ReplicaDown:
+			for _, g := range groups {
+				if rule.Component != "" && !slices.ContainsFunc(g.Components, func(c string) bool { return matches(rule.Component, c) }) {
+					continue
+				}
+				s := subject{rule: rule.Name, group: g.Name}
+				evaluated[s] = true
+				if down := float64(g.Replicas - g.Ready); down > rule.Threshold {
+					holds[s] = down
+				}
+			}
+		}
+	}
+
+	// Update the states of the rules.
+	rules := map[string]Rule{}
+	for _, rule := range e.config.Rules {
+		rules[rule.Name] = rule
+	}
+	var alerts []*Alert
+	for s, v := range holds {
+		rule := rules[s.rule]
+		state, ok := e.states[s]
+		if !ok {
+			state = &ruleState{pendingSince: now}
+			e.states[s] = state
+		}
+		if !state.firing && now.Sub(state.pendingSince) >= rule.For {
+			state.firing = true
+			alerts = append(alerts, e.alert(rule, s, Firing, now, v))
+		}
+	}
+	for s, state := range e.states {
+		if _, ok := holds[s]; ok {
+			continue
+		}
+		if !evaluated[s] && e.previous(now, rules[s.rule].Window) == nil && rules[s.rule].Kind != ReplicaDown {
+			// Not enough data to evaluate the rule; keep its state.
+			continue
+		}
+		delete(e.states, s)
+		if state.firing {
+			alerts = append(alerts, e.alert(rules[s.rule], s, Resolved, now, 0))
+		}
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		a, b := alerts[i], alerts[j]
+		if a.Rule != b.Rule {
+			return a.Rule < b.Rule
+		}
+		return a.Component+a.Method+a.Group < b.Component+b.Method+b.Group
+	})
+
+	// Record the sample, retaining the samples needed by the longest window.
+	var window time.Duration
+	for _, rule := range e.config.Rules {
+		window = max(window, rule.Window)
+	}
+	base := 0
+	for i, s := range e.samples {
+		if now.Sub(s.at) >= window {
+			base = i
+		}
+	}
+	e.samples = append(e.samples[base:], cur)
+	return alerts
+}
+
+// previous returns the latest previous sample that is at least a window old,
+// or the oldest previous sample if none is, or nil if there is no previous
+// sample.
+//
+// REQUIRES: e.mu is held.
+func (e *Engine) previous(now time.Time, window time.Duration) *sample {
+	var prev *sample
+	for i := range e.samples {
+		s := &e.samples[i]
+		if !s.at.Before(now) {
+			break
+		}
+		if prev == nil || now.Sub(s.at) >= window {
+			prev = s
+		}
+	}
+	return prev
+}
+
+// evalMethods returns the value of an ErrorRate or P99Latency rule for every
+// matching subject with enough calls between prev and cur.
+func (e *Engine) evalMethods(rule Rule, prev *sample, cur sample) map[subject]float64 {
+	// Compute the deltas of the matching methods, by subject.
+	deltas := map[subject]*imetrics.MethodCounts{}
+	for k, c := range cur.methods {
+		if rule.Component != "" && !matches(rule.Component, k.Component) {
+			continue
+		}
+		if rule.Method != "" && rule.Method != k.Method {
+			continue
+		}
+		s := subject{rule: rule.Name, component: k.Component, method: rule.Method}
+		d, ok := deltas[s]
+		if !ok {
+			d = &imetrics.MethodCounts{}
+			deltas[s] = d
+		}
+		d.Add(c.Sub(prev.methods[k]))
+	}
+
+	values := map[subject]float64{}
+	for s, d := range deltas {
+		if d.Calls == 0 || d.Calls < float64(rule.MinCalls) {
+			continue
+		}
+		switch rule.Kind {
+		case ErrorRate:
+			values[s] = d.Errors / d.Calls
+		case P99Latency:
+			values[s] = imetrics.HistogramPercentile(d.Bounds, d.Latencies, 0.99) / 1000
+		}
+	}
+	return values
+}
+
+// alert returns an alert for the provided rule and subject.
+func (e *Engine) alert(rule Rule, s subject, status string, now time.Time, value float64) *Alert {
+	a := &Alert{
+		Rule:         rule.Name,
+		Kind:         rule.Kind,
+		Status:       status,
+		Severity:     rule.Severity,
+		Time:         now,
+		App:          e.app,
+		DeploymentId: e.deploymentId,
+		Component:    s.component,
+		Method:       s.method,
+		Group:        s.group,
+		Value:        value,
+		Threshold:    rule.Threshold,
+	}
+	target := logging.ShortenComponent(s.component)
+	if s.method != "" {
+		target += "." + s.method
+	}
+	if s.group != "" {
+		target = "group " + logging.ShortenComponent(s.group)
+	}
+	var condition string
+	switch rule.Kind {
+	case ErrorRate:
+		condition = fmt.Sprintf("error rate %.1f%% > %.1f%%", 100*value, 100*rule.Threshold)
+	case P99Latency:
+		condition = fmt.Sprintf("p99 latency %.1fms > %gms", value, rule.Threshold)
+	case ReplicaDown:
+		condition = fmt.Sprintf("%d replicas not ready", int(value))
+	}
+	if status == Firing {
+		a.Summary = fmt.Sprintf("[%s] %s: %s: %s", e.app, rule.Name, target, condition)
+	} else {
+		a.Summary = fmt.Sprintf("[%s] %s: %s: resolved", e.app, rule.Name, target)
+	}
+	return a
+}
+
+// matches returns whether a rule's component matches the provided full
+// component name.
+func matches(ruleComponent, component string) bool {
+	return ruleComponent == component || ruleComponent == logging.ShortenComponent(component)
+}
+
+// byMethod projects a method key to its component and method, to aggregate
+// method metrics across callers.
+func byMethod(k imetrics.MethodKey) imetrics.MethodKey {
+	return imetrics.MethodKey{Component: k.Component, Method: k.Method}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// pagerDutyURL is the endpoint of the PagerDuty Events API v2. It is a
+// variable so that tests can replace it.
+var pagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+
+// notifyTimeout bounds how long sending a notification may take.
+const notifyTimeout = 10 * time.Second
+
+// notifier sends alerts to the destinations in a config.
+type notifier struct {
+	config       *Config
+	app          string
+	deploymentId string
+}
+
+// notify sends the provided alert to every configured destination.
+func (n *notifier) notify(ctx context.Context, a *Alert) error {
+	ctx, cancel := context.WithTimeout(ctx, notifyTimeout)
+	defer cancel()
+	var errs []error
+	if n.config.Webhook != "" {
+		errs = append(errs, post(ctx, n.config.Webhook, a))
+	}
+	if n.config.SlackWebhook != "" {
+		errs = append(errs, post(ctx, n.config.SlackWebhook, slackMessage(a)))
+	}
+	if n.config.PagerDutyRoutingKey != "" {
+		errs = append(errs, post(ctx, pagerDutyURL, n.pagerDutyEvent(a)))
+	}
+	return errors.Join(errs...)
+}
+
+// slackMessage returns the Slack message for the provided alert.
+func slackMessage(a *Alert) any {
+	icon := ":rotating_light:"
+	if a.Status == Resolved {
+		icon = ":white_check_mark:"
+	}
+	var details []string
+	if a.Component != "" {
+		details = append(details, fmt.Sprintf("component: `%s`", a.Component))
+	}
+	if a.Method != "" {
+		details = append(details, fmt.Sprintf("method: `%s`", a.Method))
+	}
+	if a.Group != "" {
+		details = append(details, fmt.Sprintf("group: `%s`", a.Group))
+	}
+	details = append(details, fmt.Sprintf("deployment: `%s`", a.DeploymentId))
+	return map[string]string{
+		"text": fmt.Sprintf("%s %s\n%s", icon, a.Summary, strings.Join(details, ", ")),
+	}
+}
+
+// pagerDutyEvent returns the PagerDuty event for the provided alert. Firing
+// and resolved alerts of the same rule and subject share a dedup key, so that
+// PagerDuty resolves the incident it opened.
+func (n *notifier) pagerDutyEvent(a *Alert) any {
+	action := "trigger"
+	if a.Status == Resolved {
+		action = "resolve"
+	}
+	type payload struct {
+		Summary       string `json:"summary"`
+		Source        string `json:"source"`
+		Severity      string `json:"severity"`
+		Timestamp     string `json:"timestamp"`
+		Component     string `json:"component,omitempty"`
+		Group         string `json:"group,omitempty"`
+		Class         string `json:"class"`
+		CustomDetails *Alert `json:"custom_details"`
+	}
+	type event struct {
+		RoutingKey  string   `json:"routing_key"`
+		EventAction string   `json:"event_action"`
+		DedupKey    string   `json:"dedup_key"`
+		Payload     *payload `json:"payload,omitempty"`
+	}
+	e := &event{
+		RoutingKey:  n.config.PagerDutyRoutingKey,
+		EventAction: action,
+		DedupKey:    strings.Join([]string{a.App, a.DeploymentId, a.Rule, a.Component, a.Method, a.Group}, "/"),
+	}
+	if action == "trigger" {
+		e.Payload = &payload{
+			Summary:       a.Summary,
+			Source:        fmt.Sprintf("%s/%s", a.App, a.DeploymentId),
+			Severity:      a.Severity,
+			Timestamp:     a.Time.Format(time.RFC3339),
+			Component:     a.Component,
+			Group:         a.Group,
+			Class:         a.Kind,
+			CustomDetails: a,
+		}
+	}
+	return e
+}
+
+// post POSTs the provided value as JSON to the provided URL.
+func post(ctx context.Context, url string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("alert webhook %q: %s", url, resp.Status)
+	}
+	return nil
+}
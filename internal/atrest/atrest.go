@@ -0,0 +1,102 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package atrest encrypts data that Service Weaver deployers persist to local
+// storage, like log files and deployment registrations.
+//
+// Encryption is enabled by setting the SERVICEWEAVER_ENCRYPTION_KEY_FILE
+// environment variable to the name of a file that holds a hex-encoded 16, 24,
+// or 32 byte AES key. Such a file is typically provisioned by a secrets
+// manager. Data is encrypted with AES-GCM, using a fresh random nonce for
+// every sealed message.
+package atrest
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+)
+
+// KeyFileEnvVar is the environment variable that names the key file.
+const KeyFileEnvVar = "SERVICEWEAVER_ENCRYPTION_KEY_FILE"
+
+// Cipher encrypts and decrypts data. A nil *Cipher is valid and leaves data
+// unencrypted.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New returns a Cipher that uses the provided AES key.
+func New(key []byte) (*Cipher, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// FromEnv returns a Cipher that uses the key in the file named by the
+// KeyFileEnvVar environment variable. If the variable is not set, FromEnv
+// returns nil and data is not encrypted.
+func FromEnv() (*Cipher, error) {
+	filename := os.Getenv(KeyFileEnvVar)
+	if filename == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: read key file: %w", err)
+	}
+	key, err := hex.DecodeString(string(bytes.TrimSpace(data)))
+	if err != nil {
+		return nil, fmt.Errorf("atrest: key file %q: key is not hex-encoded: %w", filename, err)
+	}
+	return New(key)
+}
+
+// Seal encrypts and authenticates plaintext. Use Open to decrypt it.
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("atrest: generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts and authenticates data returned by Seal.
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if c == nil {
+		return data, nil
+	}
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("atrest: decrypt: data too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("atrest: decrypt: wrong key or data not encrypted: %w", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package atrest
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	c, err := New(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("hello")
+	sealed, err := c.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(sealed, plaintext) {
+		t.Fatalf("sealed data %q contains plaintext", sealed)
+	}
+	got, err := c.Open(sealed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Fatalf("Open: got %q, want %q", got, plaintext)
+	}
+
+	// Tampered data and data sealed with a different key fail to open.
+	sealed[len(sealed)-1] ^= 1
+	if _, err := c.Open(sealed); err == nil {
+		t.Fatal("Open of tampered data: unexpected success")
+	}
+	other, err := New(bytes.Repeat([]byte{2}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err = other.Seal(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Open(sealed); err == nil {
+		t.Fatal("Open with wrong key: unexpected success")
+	}
+}
+
+func TestNilCipher(t *testing.T) {
+	var c *Cipher
+	sealed, err := c.Seal([]byte("hello"))
+	if err != nil || string(sealed) != "hello" {
+		t.Fatalf("Seal: got (%q, %v), want (\"hello\", nil)", sealed, err)
+	}
+	opened, err := c.Open(sealed)
+	if err != nil || string(opened) != "hello" {
+		t.Fatalf("Open: got (%q, %v), want (\"hello\", nil)", opened, err)
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv(KeyFileEnvVar, "")
+	if c, err := FromEnv(); c != nil || err != nil {
+		t.Fatalf("FromEnv without key file: got (%v, %v), want (nil, nil)", c, err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(filename, []byte("000102030405060708090a0b0c0d0e0f\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(KeyFileEnvVar, filename)
+	if c, err := FromEnv(); c == nil || err != nil {
+		t.Fatalf("FromEnv: got (%v, %v), want non-nil cipher", c, err)
+	}
+
+	if err := os.WriteFile(filename, []byte("not hex"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("FromEnv with bad key: unexpected success")
+	}
+}
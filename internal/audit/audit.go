@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit writes and verifies tamper-evident audit logs.
+//
+// An audit log records the calls made to audited component methods. Every
+// record includes the calling component, a hash of the call's arguments, the
+// time of the call, and whether the call succeeded. Records are chained
+// together: every record includes the hash of the record before it, and its
+// own hash covers the previous hash. Modifying, removing, or reordering a
+// record therefore breaks the chain, which Verify detects.
+//
+// Audit logs are written independently of application and system logs.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Result codes.
+const (
+	OK    = "OK"    // the method returned a nil error
+	Error = "ERROR" // the method returned a non-nil error
+)
+
+// Record is an audit record for a single call to an audited method.
+type Record struct {
+	Time       time.Time `json:"time"`
+	App        string    `json:"app"`
+	Deployment string    `json:"deployment"`
+	Weavelet   string    `json:"weavelet"`
+	Caller     string    `json:"caller"`    // full name of the calling component
+	Component  string    `json:"component"` // full name of the called component
+	Method     string    `json:"method"`    // called method
+	ArgsHash   string    `json:"args_hash"` // see HashArgs
+	Result     string    `json:"result"`    // OK or Error
+	PrevHash   string    `json:"prev_hash"` // hash of the previous record, or "" for the first
+	Hash       string    `json:"hash"`      // hash of this record, see Log.Append
+}
+
+// Log is an append-only audit log. It is safe for concurrent use.
+type Log struct {
+	mu   sync.Mutex
+	w    io.Writer
+	prev string // hash of the last appended record
+}
+
+// NewLog returns a log that writes records, one JSON object per line, to w.
+func NewLog(w io.Writer) *Log {
+	return &Log{w: w}
+}
+
+// Open returns a log that appends to the file "audit-<weavelet>.jsonl" in the
+// provided directory, creating the directory if needed, along with the name of
+// the file.
+func Open(dir, weavelet string) (*Log, string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, "", err
+	}
+	filename := filepath.Join(dir, fmt.Sprintf("audit-%s.jsonl", weavelet))
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, "", err
+	}
+	return NewLog(f), filename, nil
+}
+
+// Append chains the provided record to the previously appended records, by
+// setting its PrevHash and Hash, and writes it to the log.
+func (l *Log) Append(r *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r.PrevHash = l.prev
+	h, err := hash(r)
+	if err != nil {
+		return err
+	}
+	r.Hash = h
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	if _, err := l.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	l.prev = h
+	return nil
+}
+
+// Verify reads the records written to a log and checks that they form an
+// unbroken chain. It returns the number of verified records.
+func Verify(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	prev := ""
+	n := 0
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		if record.PrevHash != prev {
+			return n, fmt.Errorf("record %d: previous hash %q, want %q", n, record.PrevHash, prev)
+		}
+		want, err := hash(&record)
+		if err != nil {
+			return n, fmt.Errorf("record %d: %w", n, err)
+		}
+		if record.Hash != want {
+			return n, fmt.Errorf("record %d: hash %q, want %q", n, record.Hash, want)
+		}
+		prev = record.Hash
+		n++
+	}
+	return n, scanner.Err()
+}
+
+// HashArgs returns a hex-encoded SHA-256 hash of the provided method
+// arguments. Arguments are hashed rather than recorded, so that audit logs
+// don't contain sensitive data, but a known set of arguments can still be
+// matched against a record.
+func HashArgs(args []any) string {
+	h := sha256.New()
+	for _, arg := range args {
+		data, err := json.Marshal(arg)
+		if err != nil {
+			// Not every argument type can be marshaled to JSON.
+			data = []byte(fmt.Sprintf("%#v", arg))
+		}
+		fmt.Fprintf(h, "%d:", len(data))
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hash returns the hex-encoded SHA-256 hash of the provided record, ignoring
+// its Hash field.
+func hash(r *Record) (string, error) {
+	r2 := *r
+	r2.Time = r2.Time.UTC()
+	r2.Hash = ""
+	data, err := json.Marshal(&r2)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
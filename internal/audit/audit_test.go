@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// appendRecords appends n records to l.
+func appendRecords(t *testing.T, l *Log, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		r := &Record{
+			Time:      time.Now(),
+			Caller:    "pkg/Caller",
+			Component: "pkg/Foo",
+			Method:    "Bar",
+			ArgsHash:  HashArgs([]any{i, "x"}),
+			Result:    OK,
+		}
+		if err := l.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	var b bytes.Buffer
+	appendRecords(t, NewLog(&b), 3)
+	n, err := Verify(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("Verify: got %d records, want 3", n)
+	}
+}
+
+func TestVerifyTampered(t *testing.T) {
+	var b bytes.Buffer
+	appendRecords(t, NewLog(&b), 3)
+	lines := strings.SplitAfter(b.String(), "\n")
+
+	for _, test := range []struct {
+		name  string
+		lines []string
+	}{
+		{"Modified", []string{lines[0], strings.Replace(lines[1], `"OK"`, `"ERROR"`, 1), lines[2]}},
+		{"Removed", []string{lines[0], lines[2]}},
+		{"Reordered", []string{lines[1], lines[0], lines[2]}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Verify(strings.NewReader(strings.Join(test.lines, ""))); err == nil {
+				t.Fatal("Verify: unexpected success")
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	l, filename, err := Open(t.TempDir(), "weavelet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	appendRecords(t, l, 2)
+	f, err := os.Open(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if n, err := Verify(f); err != nil || n != 2 {
+		t.Fatalf("Verify: got (%d, %v), want (2, nil)", n, err)
+	}
+}
+
+func TestHashArgs(t *testing.T) {
+	if HashArgs([]any{"a", "bc"}) == HashArgs([]any{"ab", "c"}) {
+		t.Fatal("HashArgs: different arguments have the same hash")
+	}
+	if HashArgs([]any{1, "x"}) != HashArgs([]any{1, "x"}) {
+		t.Fatal("HashArgs: same arguments have different hashes")
+	}
+}
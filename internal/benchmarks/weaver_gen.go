@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*Ping1)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping1{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping1_local_stub{impl: impl.(Ping1), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingS", Remote: false, Generated: true})}
+			return ping1_local_stub{impl: impl.(Ping1), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping1_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1", Method: "PingS", Remote: true, Generated: true})}
@@ -31,14 +31,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping1_reflect_stub{caller: caller}
 		},
-		RefData: "⟦544443c5:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2⟧\n",
+		RefData:          "⟦544443c5:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping1→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10",
 		Iface: reflect.TypeOf((*Ping10)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping10{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping10_local_stub{impl: impl.(Ping10), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingS", Remote: false, Generated: true})}
+			return ping10_local_stub{impl: impl.(Ping10), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping10_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10", Method: "PingS", Remote: true, Generated: true})}
@@ -49,14 +52,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping10_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2",
 		Iface: reflect.TypeOf((*Ping2)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping2{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping2_local_stub{impl: impl.(Ping2), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingS", Remote: false, Generated: true})}
+			return ping2_local_stub{impl: impl.(Ping2), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping2_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2", Method: "PingS", Remote: true, Generated: true})}
@@ -67,14 +73,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping2_reflect_stub{caller: caller}
 		},
-		RefData: "⟦b42b173c:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3⟧\n",
+		RefData:          "⟦b42b173c:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping2→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3",
 		Iface: reflect.TypeOf((*Ping3)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping3{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping3_local_stub{impl: impl.(Ping3), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingS", Remote: false, Generated: true})}
+			return ping3_local_stub{impl: impl.(Ping3), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping3_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3", Method: "PingS", Remote: true, Generated: true})}
@@ -85,14 +94,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping3_reflect_stub{caller: caller}
 		},
-		RefData: "⟦8c498b47:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4⟧\n",
+		RefData:          "⟦8c498b47:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping3→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4",
 		Iface: reflect.TypeOf((*Ping4)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping4{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping4_local_stub{impl: impl.(Ping4), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingS", Remote: false, Generated: true})}
+			return ping4_local_stub{impl: impl.(Ping4), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping4_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4", Method: "PingS", Remote: true, Generated: true})}
@@ -103,14 +115,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping4_reflect_stub{caller: caller}
 		},
-		RefData: "⟦90669915:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5⟧\n",
+		RefData:          "⟦90669915:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping4→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5",
 		Iface: reflect.TypeOf((*Ping5)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping5{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping5_local_stub{impl: impl.(Ping5), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingS", Remote: false, Generated: true})}
+			return ping5_local_stub{impl: impl.(Ping5), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping5_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5", Method: "PingS", Remote: true, Generated: true})}
@@ -121,14 +136,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping5_reflect_stub{caller: caller}
 		},
-		RefData: "⟦a38d1914:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6⟧\n",
+		RefData:          "⟦a38d1914:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping5→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6",
 		Iface: reflect.TypeOf((*Ping6)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping6{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping6_local_stub{impl: impl.(Ping6), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingS", Remote: false, Generated: true})}
+			return ping6_local_stub{impl: impl.(Ping6), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping6_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6", Method: "PingS", Remote: true, Generated: true})}
@@ -139,14 +157,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping6_reflect_stub{caller: caller}
 		},
-		RefData: "⟦ebf8b6d3:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7⟧\n",
+		RefData:          "⟦ebf8b6d3:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping6→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7",
 		Iface: reflect.TypeOf((*Ping7)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping7{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping7_local_stub{impl: impl.(Ping7), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingS", Remote: false, Generated: true})}
+			return ping7_local_stub{impl: impl.(Ping7), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping7_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7", Method: "PingS", Remote: true, Generated: true})}
@@ -157,14 +178,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping7_reflect_stub{caller: caller}
 		},
-		RefData: "⟦88d68418:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8⟧\n",
+		RefData:          "⟦88d68418:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping7→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8",
 		Iface: reflect.TypeOf((*Ping8)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping8{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping8_local_stub{impl: impl.(Ping8), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingS", Remote: false, Generated: true})}
+			return ping8_local_stub{impl: impl.(Ping8), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping8_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8", Method: "PingS", Remote: true, Generated: true})}
@@ -175,14 +199,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping8_reflect_stub{caller: caller}
 		},
-		RefData: "⟦ed98271d:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9⟧\n",
+		RefData:          "⟦ed98271d:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping8→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9",
 		Iface: reflect.TypeOf((*Ping9)(nil)).Elem(),
 		Impl:  reflect.TypeOf(ping9{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return ping9_local_stub{impl: impl.(Ping9), tracer: tracer, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingS", Remote: false, Generated: true})}
+			return ping9_local_stub{impl: impl.(Ping9), tracer: tracer, caller: codegen.Caller{Component: caller}, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingC", Remote: false, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingS", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return ping9_client_stub{stub: stub, pingCMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingC", Remote: true, Generated: true}), pingSMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9", Method: "PingS", Remote: true, Generated: true})}
@@ -193,7 +220,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return ping9_reflect_stub{caller: caller}
 		},
-		RefData: "⟦5ceb96a7:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10⟧\n",
+		RefData:          "⟦5ceb96a7:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/benchmarks/Ping9→github.com/ServiceWeaver/weaver/internal/benchmarks/Ping10⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8c6b83eb1e16bb12",
 	})
 }
 
@@ -226,6 +256,7 @@ var _ weaver.Unrouted = (*ping9)(nil)
 type ping1_local_stub struct {
 	impl         Ping1
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -237,6 +268,10 @@ func (s ping1_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -257,6 +292,10 @@ func (s ping1_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -276,6 +315,7 @@ func (s ping1_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping10_local_stub struct {
 	impl         Ping10
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -287,6 +327,10 @@ func (s ping10_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -307,6 +351,10 @@ func (s ping10_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -326,6 +374,7 @@ func (s ping10_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 type ping2_local_stub struct {
 	impl         Ping2
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -337,6 +386,10 @@ func (s ping2_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -357,6 +410,10 @@ func (s ping2_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -376,6 +433,7 @@ func (s ping2_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping3_local_stub struct {
 	impl         Ping3
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -387,6 +445,10 @@ func (s ping3_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -407,6 +469,10 @@ func (s ping3_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -426,6 +492,7 @@ func (s ping3_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping4_local_stub struct {
 	impl         Ping4
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -437,6 +504,10 @@ func (s ping4_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -457,6 +528,10 @@ func (s ping4_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -476,6 +551,7 @@ func (s ping4_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping5_local_stub struct {
 	impl         Ping5
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -487,6 +563,10 @@ func (s ping5_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -507,6 +587,10 @@ func (s ping5_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -526,6 +610,7 @@ func (s ping5_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping6_local_stub struct {
 	impl         Ping6
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -537,6 +622,10 @@ func (s ping6_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -557,6 +646,10 @@ func (s ping6_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -576,6 +669,7 @@ func (s ping6_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping7_local_stub struct {
 	impl         Ping7
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -587,6 +681,10 @@ func (s ping7_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -607,6 +705,10 @@ func (s ping7_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -626,6 +728,7 @@ func (s ping7_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping8_local_stub struct {
 	impl         Ping8
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -637,6 +740,10 @@ func (s ping8_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -657,6 +764,10 @@ func (s ping8_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -676,6 +787,7 @@ func (s ping8_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 type ping9_local_stub struct {
 	impl         Ping9
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	pingCMetrics *codegen.MethodMetrics
 	pingSMetrics *codegen.MethodMetrics
 }
@@ -687,6 +799,10 @@ func (s ping9_local_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingCMetrics.Begin()
 	defer func() { s.pingCMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -707,6 +823,10 @@ func (s ping9_local_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 pa
 	// Update metrics.
 	begin := s.pingSMetrics.Begin()
 	defer func() { s.pingSMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -767,10 +887,11 @@ func (s ping1_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -779,6 +900,7 @@ func (s ping1_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -787,6 +909,7 @@ func (s ping1_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -822,7 +945,8 @@ func (s ping1_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -831,6 +955,7 @@ func (s ping1_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -839,6 +964,7 @@ func (s ping1_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -886,10 +1012,11 @@ func (s ping10_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -898,6 +1025,7 @@ func (s ping10_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -906,6 +1034,7 @@ func (s ping10_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -941,7 +1070,8 @@ func (s ping10_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -950,6 +1080,7 @@ func (s ping10_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -958,6 +1089,7 @@ func (s ping10_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1005,10 +1137,11 @@ func (s ping2_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1017,6 +1150,7 @@ func (s ping2_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1025,6 +1159,7 @@ func (s ping2_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1060,7 +1195,8 @@ func (s ping2_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1069,6 +1205,7 @@ func (s ping2_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1077,6 +1214,7 @@ func (s ping2_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1124,10 +1262,11 @@ func (s ping3_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1136,6 +1275,7 @@ func (s ping3_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1144,6 +1284,7 @@ func (s ping3_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1179,7 +1320,8 @@ func (s ping3_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1188,6 +1330,7 @@ func (s ping3_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1196,6 +1339,7 @@ func (s ping3_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1243,10 +1387,11 @@ func (s ping4_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1255,6 +1400,7 @@ func (s ping4_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1263,6 +1409,7 @@ func (s ping4_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1298,7 +1445,8 @@ func (s ping4_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1307,6 +1455,7 @@ func (s ping4_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1315,6 +1464,7 @@ func (s ping4_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1362,10 +1512,11 @@ func (s ping5_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1374,6 +1525,7 @@ func (s ping5_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1382,6 +1534,7 @@ func (s ping5_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1417,7 +1570,8 @@ func (s ping5_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1426,6 +1580,7 @@ func (s ping5_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1434,6 +1589,7 @@ func (s ping5_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1481,10 +1637,11 @@ func (s ping6_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1493,6 +1650,7 @@ func (s ping6_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1501,6 +1659,7 @@ func (s ping6_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1536,7 +1695,8 @@ func (s ping6_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1545,6 +1705,7 @@ func (s ping6_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1553,6 +1714,7 @@ func (s ping6_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1600,10 +1762,11 @@ func (s ping7_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1612,6 +1775,7 @@ func (s ping7_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1620,6 +1784,7 @@ func (s ping7_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1655,7 +1820,8 @@ func (s ping7_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1664,6 +1830,7 @@ func (s ping7_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1672,6 +1839,7 @@ func (s ping7_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1719,10 +1887,11 @@ func (s ping8_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1731,6 +1900,7 @@ func (s ping8_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1739,6 +1909,7 @@ func (s ping8_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1774,7 +1945,8 @@ func (s ping8_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1783,6 +1955,7 @@ func (s ping8_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1791,6 +1964,7 @@ func (s ping8_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1838,10 +2012,11 @@ func (s ping9_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	size := 0
 	size += serviceweaver_size_payloadC_7e82696e(&a0)
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1850,6 +2025,7 @@ func (s ping9_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1858,6 +2034,7 @@ func (s ping9_client_stub) PingC(ctx context.Context, a0 payloadC, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1893,7 +2070,8 @@ func (s ping9_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	(a0).WeaverMarshal(enc)
 	enc.Int(a1)
 	var shardKey uint64
@@ -1902,6 +2080,7 @@ func (s ping9_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -1910,6 +2089,7 @@ func (s ping9_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -1918,10 +2098,10 @@ func (s ping9_client_stub) PingS(ctx context.Context, a0 payloadS, a1 int) (r0 p
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -2886,9 +3066,14 @@ func serviceweaver_enc_slice_int64_a8f7f092(enc *codegen.Encoder, arg []int64) {
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.Int64(arg[i])
+	if codegen.EncodeFixed(enc, arg) {
+		return
 	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Int64(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_int64_a8f7f092(dec *codegen.Decoder) []int64 {
@@ -2896,10 +3081,16 @@ func serviceweaver_dec_slice_int64_a8f7f092(dec *codegen.Decoder) []int64 {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 8)
 	res := make([]int64, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.Int64()
+	if codegen.DecodeFixed[int64](dec, res) {
+		return res
 	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Int64()
+		}
+	})
 	return res
 }
 
@@ -3039,9 +3230,11 @@ func serviceweaver_enc_slice_bool_c791c3b0(enc *codegen.Encoder, arg []bool) {
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.Bool(arg[i])
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Bool(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_bool_c791c3b0(dec *codegen.Decoder) []bool {
@@ -3049,10 +3242,13 @@ func serviceweaver_dec_slice_bool_c791c3b0(dec *codegen.Decoder) []bool {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 1)
 	res := make([]bool, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.Bool()
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Bool()
+		}
+	})
 	return res
 }
 
@@ -3138,9 +3334,11 @@ func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string)
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.String(arg[i])
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
@@ -3148,10 +3346,13 @@ func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 4)
 	res := make([]string, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.String()
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
 	return res
 }
 
@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blob implements the storage backends of the weaver.BlobStore
+// component: an in-memory store, a local disk store, and S3 and Google Cloud
+// Storage stores.
+//
+// The S3 and Google Cloud Storage stores talk to the S3 REST API directly,
+// signing requests with AWS Signature Version 4. Google Cloud Storage is
+// accessed through its S3-compatible XML API using HMAC keys.
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// ErrNotFound is returned when a blob doesn't exist.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a flat namespace of blobs, keyed by slash-separated names like
+// "images/cat.png".
+type Store interface {
+	// Get returns the contents of the blob with the provided key, or an error
+	// that wraps ErrNotFound if the blob doesn't exist.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put stores data in the blob with the provided key, replacing any
+	// previous contents.
+	Put(ctx context.Context, key string, data []byte) error
+
+	// Delete deletes the blob with the provided key. Deleting a blob that
+	// doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns the keys of all blobs that start with the provided prefix,
+	// in lexicographic order.
+	List(ctx context.Context, prefix string) ([]string, error)
+}
+
+// CheckKey returns an error if the provided key is not a valid blob key. A
+// valid key is a non-empty, valid UTF-8 sequence of slash-separated segments,
+// none of which is empty, ".", or "..".
+func CheckKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("empty blob key")
+	}
+	if !utf8.ValidString(key) {
+		return fmt.Errorf("blob key %q is not valid UTF-8", key)
+	}
+	if strings.ContainsRune(key, 0) {
+		return fmt.Errorf("blob key %q contains a NUL byte", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		switch segment {
+		case "":
+			return fmt.Errorf("blob key %q has an empty segment", key)
+		case ".", "..":
+			return fmt.Errorf("blob key %q has a %q segment", key, segment)
+		}
+	}
+	return nil
+}
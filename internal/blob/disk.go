@@ -0,0 +1,133 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Disk is a Store that holds blobs as files in a local directory. The blob
+// with key k is stored in the file dir/blobs/k. Blobs are first written to a
+// temporary file in dir/tmp and then atomically renamed into place, so readers
+// never observe partially written blobs.
+type Disk struct {
+	blobs string // directory of blobs
+	tmp   string // directory of partially written blobs
+}
+
+var _ Store = &Disk{}
+
+// NewDisk returns a Store that holds blobs in the provided directory,
+// creating the directory if needed.
+func NewDisk(dir string) (*Disk, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("empty blob directory")
+	}
+	d := &Disk{
+		blobs: filepath.Join(dir, "blobs"),
+		tmp:   filepath.Join(dir, "tmp"),
+	}
+	for _, dir := range []string{d.blobs, d.tmp} {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Get implements the Store interface.
+func (d *Disk) Get(_ context.Context, key string) ([]byte, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(d.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("get %q: %w", key, ErrNotFound)
+	}
+	return data, err
+}
+
+// Put implements the Store interface.
+func (d *Disk) Put(_ context.Context, key string, data []byte) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	dst := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(d.tmp, "blob*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), dst)
+}
+
+// Delete implements the Store interface.
+func (d *Disk) Delete(_ context.Context, key string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(d.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List implements the Store interface.
+func (d *Disk) List(_ context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	err := filepath.WalkDir(d.blobs, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(d.blobs, path)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+// path returns the path of the file that holds the blob with the provided key.
+func (d *Disk) path(key string) string {
+	return filepath.Join(d.blobs, filepath.FromSlash(key))
+}
@@ -0,0 +1,363 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blob
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3Options configures an S3 store.
+type S3Options struct {
+	Endpoint     string       // base URL of the S3 API (e.g., "https://s3.us-east-1.amazonaws.com")
+	Region       string       // region used to sign requests (e.g., "us-east-1")
+	Bucket       string       // bucket name
+	Prefix       string       // prefix prepended to every blob key
+	AccessKey    string       // access key id
+	SecretKey    string       // secret access key
+	SessionToken string       // optional session token for temporary credentials
+	Client       *http.Client // if nil, http.DefaultClient is used
+
+	// Now returns the current time and is used to sign requests. If nil,
+	// time.Now is used.
+	Now func() time.Time
+}
+
+// S3 is a Store that holds blobs as objects in an S3 bucket, or in any bucket
+// served by an S3-compatible API. Buckets are addressed path-style, i.e.,
+// object k in bucket b has URL endpoint/b/k.
+type S3 struct {
+	opts S3Options
+}
+
+var _ Store = &S3{}
+
+// NewS3 returns a Store backed by an S3 bucket.
+func NewS3(opts S3Options) (*S3, error) {
+	if opts.Bucket == "" {
+		return nil, fmt.Errorf("empty bucket name")
+	}
+	if opts.Region == "" {
+		return nil, fmt.Errorf("empty region")
+	}
+	if opts.Endpoint == "" {
+		opts.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", opts.Region)
+	}
+	if opts.AccessKey == "" || opts.SecretKey == "" {
+		return nil, fmt.Errorf("missing credentials for bucket %q", opts.Bucket)
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	opts.Endpoint = strings.TrimSuffix(opts.Endpoint, "/")
+	return &S3{opts: opts}, nil
+}
+
+// GCSOptions configures a Google Cloud Storage store.
+type GCSOptions struct {
+	Bucket   string       // bucket name
+	Prefix   string       // prefix prepended to every blob key
+	AccessID string       // HMAC key access id
+	Secret   string       // HMAC key secret
+	Endpoint string       // if empty, "https://storage.googleapis.com" is used
+	Client   *http.Client // if nil, http.DefaultClient is used
+	Now      func() time.Time
+}
+
+// NewGCS returns a Store backed by a Google Cloud Storage bucket. The bucket is
+// accessed through the Cloud Storage XML API, which is compatible with S3,
+// using an HMAC key [1].
+//
+// [1]: https://cloud.google.com/storage/docs/authentication/hmackeys
+func NewGCS(opts GCSOptions) (*S3, error) {
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return NewS3(S3Options{
+		Endpoint:  endpoint,
+		Region:    "auto",
+		Bucket:    opts.Bucket,
+		Prefix:    opts.Prefix,
+		AccessKey: opts.AccessID,
+		SecretKey: opts.Secret,
+		Client:    opts.Client,
+		Now:       opts.Now,
+	})
+}
+
+// Get implements the Store interface.
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := CheckKey(key); err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.opts.Prefix+key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get %q: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("get %q: %w", key, ErrNotFound)
+	default:
+		return nil, fmt.Errorf("get %q: %w", key, responseError(resp))
+	}
+}
+
+// Put implements the Store interface.
+func (s *S3) Put(ctx context.Context, key string, data []byte) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPut, s.opts.Prefix+key, nil, data)
+	if err != nil {
+		return fmt.Errorf("put %q: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("put %q: %w", key, responseError(resp))
+	}
+	return nil
+}
+
+// Delete implements the Store interface.
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := CheckKey(key); err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, s.opts.Prefix+key, nil, nil)
+	if err != nil {
+		return fmt.Errorf("delete %q: %w", key, err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		return fmt.Errorf("delete %q: %w", key, responseError(resp))
+	}
+}
+
+// listResult is the subset of an S3 ListObjectsV2 response that we use.
+type listResult struct {
+	Contents []struct {
+		Key string
+	}
+	IsTruncated           bool
+	NextContinuationToken string
+}
+
+// List implements the Store interface.
+func (s *S3) List(ctx context.Context, prefix string) ([]string, error) {
+	keys := []string{}
+	token := ""
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", s.opts.Prefix+prefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("list %q: %w", prefix, err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := responseError(resp)
+			resp.Body.Close()
+			return nil, fmt.Errorf("list %q: %w", prefix, err)
+		}
+		var result listResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list %q: %w", prefix, err)
+		}
+		for _, c := range result.Contents {
+			keys = append(keys, strings.TrimPrefix(c.Key, s.opts.Prefix))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// do issues a signed request for the provided object. If object is empty, the
+// request is issued for the bucket.
+func (s *S3) do(ctx context.Context, method, object string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s.opts.Bucket
+	if object != "" {
+		path += "/" + object
+	}
+	u, err := url.Parse(s.opts.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(u.EscapedPath(), "/")
+	u.Path = strings.TrimSuffix(u.Path, "/") + path
+	u.RawPath = base + escapePath(path)
+	u.RawQuery = canonicalQuery(query)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.opts.SessionToken)
+	}
+	sign(req, body, s.opts.AccessKey, s.opts.SecretKey, s.opts.Region, s.opts.Now())
+	return s.opts.Client.Do(req)
+}
+
+// responseError returns an error describing an unsuccessful response.
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	var e struct {
+		Code    string
+		Message string
+	}
+	if xml.Unmarshal(body, &e) == nil && e.Code != "" {
+		return fmt.Errorf("%s: %s: %s", resp.Status, e.Code, e.Message)
+	}
+	return fmt.Errorf("%s", resp.Status)
+}
+
+// sign signs the provided request with AWS Signature Version 4 [1], signing
+// the Host header and every X-Amz-* and Range header.
+//
+// [1]: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_aws-signing.html
+func sign(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	const service = "s3"
+	now = now.UTC()
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	payload := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", timestamp)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+
+	// Compute the canonical headers.
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "range" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Compute the signature.
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+	scope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data using the provided key.
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath URI-encodes every segment of the provided slash-separated path,
+// as required by AWS Signature Version 4.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query string for the provided values, with keys
+// sorted and keys and values URI-encoded, as required by AWS Signature
+// Version 4.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			parts = append(parts, escape(key)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escape URI-encodes every byte of s except the unreserved characters
+// A-Z, a-z, 0-9, '-', '.', '_', and '~'.
+func escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
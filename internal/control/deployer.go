@@ -51,6 +51,10 @@ type DeployerControl interface {
 	// traffic to the provided address.
 	ExportListener(context.Context, *protos.ExportListenerRequest) (*protos.ExportListenerReply, error)
 
+	// GetTopology returns the live component graph of the deployment,
+	// assembled from the method metrics of its weavelets.
+	GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error)
+
 	// GetSelfCertificate returns the certificate and the private key the
 	// weavelet should use for network connection establishment. The weavelet
 	// will issue this request each time it establishes a connection with
@@ -52,4 +52,12 @@ type WeaveletControl interface {
 
 	// GetProfile gets a profile from the weavelet.
 	GetProfile(context.Context, *protos.GetProfileRequest) (*protos.GetProfileReply, error)
+
+	// GetFlightRecord fetches the component method calls recently recorded by
+	// the weavelet's flight recorder.
+	GetFlightRecord(context.Context, *protos.GetFlightRecordRequest) (*protos.GetFlightRecordReply, error)
+
+	// NotifyDeploymentEvent notifies the weavelet of a deployment event. It
+	// returns once the weavelet's components have handled the event.
+	NotifyDeploymentEvent(context.Context, *protos.NotifyDeploymentEventRequest) (*protos.NotifyDeploymentEventReply, error)
 }
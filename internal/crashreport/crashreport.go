@@ -0,0 +1,133 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package crashreport captures information about weavelet crashes.
+//
+// When a component method or a component's Init method panics, the weavelet
+// writes a crash report that records the panic, its stack, the component
+// method calls that were in progress, and the calls recently recorded by the
+// flight recorder. This lets operators tell which component and which call
+// caused a crash.
+package crashreport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/flightrecorder"
+)
+
+// Report is a crash report.
+type Report struct {
+	Time        time.Time              `json:"time"`
+	App         string                 `json:"app"`
+	Deployment  string                 `json:"deployment"`
+	Weavelet    string                 `json:"weavelet"`
+	Component   string                 `json:"component"` // full name of the crashing component
+	Method      string                 `json:"method"`    // crashing method, or "Init"
+	Panic       string                 `json:"panic"`     // panic value
+	Stack       string                 `json:"stack"`     // stack of the panicking goroutine
+	Versions    map[string]string      `json:"versions"`  // see Versions
+	ActiveCalls []flightrecorder.Call  `json:"active_calls"`
+	RecentCalls []flightrecorder.Event `json:"recent_calls"`
+}
+
+// New returns a crash report for a panic with value r, raised by the provided
+// component method. The report includes the calls recorded by the default
+// flight recorder. stack should be the stack of the panicking goroutine, as
+// returned by debug.Stack when called from a deferred function.
+func New(app, deployment, weavelet, component, method string, r any, stack []byte) *Report {
+	return &Report{
+		Time:        time.Now(),
+		App:         app,
+		Deployment:  deployment,
+		Weavelet:    weavelet,
+		Component:   component,
+		Method:      method,
+		Panic:       fmt.Sprint(r),
+		Stack:       string(stack),
+		Versions:    Versions(deployment),
+		ActiveCalls: flightrecorder.Default.Active(),
+		RecentCalls: flightrecorder.Default.Snapshot(),
+	}
+}
+
+// Versions returns the versions of the code running in this process: the
+// deployment id, the Go version, the version of the main module, and the
+// version of the weaver module.
+func Versions(deployment string) map[string]string {
+	versions := map[string]string{
+		"deployment": deployment,
+		"go":         runtime.Version(),
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return versions
+	}
+	versions[info.Main.Path] = info.Main.Version
+	for _, dep := range info.Deps {
+		if dep.Path == "github.com/ServiceWeaver/weaver" {
+			versions[dep.Path] = dep.Version
+		}
+	}
+	return versions
+}
+
+// Write writes the report as a JSON file in the provided directory, creating
+// the directory if needed, and returns the name of the file.
+func Write(dir string, r *Report) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("crash-%s-%d.json", r.Weavelet, r.Time.UnixNano())
+	filename := filepath.Join(dir, name)
+	if err := os.WriteFile(filename, data, 0600); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// Post sends the report as JSON to the provided webhook URL.
+func Post(ctx context.Context, url string, r *Report) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("crash report webhook %q: %s", url, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package crashreport
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWrite(t *testing.T) {
+	r := New("app", "deployment", "weavelet", "pkg/Foo", "Bar", "boom", []byte("stack"))
+	filename, err := Write(t.TempDir(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Report
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(r, &got); diff != "" {
+		t.Fatalf("report (-want +got):\n%s", diff)
+	}
+}
+
+func TestPost(t *testing.T) {
+	got := make(chan Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		var r Report
+		if err := json.Unmarshal(data, &r); err != nil {
+			t.Error(err)
+		}
+		got <- r
+	}))
+	defer server.Close()
+
+	r := New("app", "deployment", "weavelet", "pkg/Foo", "Bar", "boom", nil)
+	if err := Post(context.Background(), server.URL, r); err != nil {
+		t.Fatal(err)
+	}
+	if r := <-got; r.Component != "pkg/Foo" || r.Method != "Bar" || r.Panic != "boom" {
+		t.Fatalf("bad report: %+v", r)
+	}
+}
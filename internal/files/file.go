@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 // Writer writes a sequence of bytes to a file. In case of errors, the old file
@@ -100,3 +101,42 @@ func (w *Writer) Cleanup() {
 	w.tmp = nil
 	os.Remove(w.tmpName)
 }
+
+// WriteFileSync atomically replaces the named file with data. Unlike with a
+// Writer, the replacement is durable when WriteFileSync returns: the file and
+// its directory are synced to disk.
+func WriteFileSync(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	tmp, err := os.CreateTemp(dir, filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if runtime.GOOS == "windows" {
+		// Windows doesn't support syncing directories.
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flightrecorder implements an always-on, low-overhead recorder of
+// recent component method calls.
+//
+// A flight recorder keeps the most recent method calls in a bounded ring
+// buffer. It can be dumped on demand (e.g., through a deployer's status
+// server) or when a weavelet crashes, to help with post-hoc incident analysis
+// without having to enable full tracing.
+package flightrecorder
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCapacity is the maximum number of calls recorded by the default
+	// flight recorder.
+	DefaultCapacity = 4096
+
+	// DefaultWindow is the period of time covered by the default flight
+	// recorder.
+	DefaultWindow = 30 * time.Second
+)
+
+// Default is the process-wide flight recorder that records every component
+// method call.
+var Default = New(DefaultCapacity, DefaultWindow)
+
+// Event records a single completed component method call.
+type Event struct {
+	Time      time.Time     // time the call ended
+	Caller    string        // full calling component name
+	Component string        // full callee component name
+	Method    string        // callee method name
+	Latency   time.Duration // call latency
+	Failed    bool          // did the call return an error?
+	Remote    bool          // was the call remote?
+}
+
+// Call is a component method call that is in progress.
+type Call struct {
+	Start     time.Time // time the call started
+	Caller    string    // full calling component name
+	Component string    // full callee component name
+	Method    string    // callee method name
+	Remote    bool      // is the call remote?
+}
+
+// Recorder records the last calls that completed within a window of time, up
+// to a maximum number of calls, along with the calls that are in progress. It
+// is safe for concurrent use.
+type Recorder struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	events []Event         // ring buffer
+	next   int             // index in events of the next event to write
+	full   bool            // has the ring buffer wrapped around?
+	active map[uint64]Call // in-progress calls, keyed by id
+	nextID uint64          // id of the next call passed to Begin
+}
+
+// New returns a new recorder that records at most capacity calls completed
+// within the last window of time.
+func New(capacity int, window time.Duration) *Recorder {
+	if capacity <= 0 {
+		panic("flightrecorder: non-positive capacity")
+	}
+	return &Recorder{
+		window: window,
+		events: make([]Event, capacity),
+		active: map[uint64]Call{},
+	}
+}
+
+// Begin records that the provided call has started and returns an id that
+// must be passed to End when the call finishes.
+func (r *Recorder) Begin(c Call) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	r.active[r.nextID] = c
+	return r.nextID
+}
+
+// End records that the call with the provided id, as returned by Begin, has
+// finished, and records e.
+func (r *Recorder) End(id uint64, e Event) {
+	r.mu.Lock()
+	delete(r.active, id)
+	r.mu.Unlock()
+	r.Record(e)
+}
+
+// Active returns the calls that are in progress, from oldest to newest.
+func (r *Recorder) Active() []Call {
+	r.mu.Lock()
+	calls := make([]Call, 0, len(r.active))
+	for _, c := range r.active {
+		calls = append(calls, c)
+	}
+	r.mu.Unlock()
+	slices.SortFunc(calls, func(a, b Call) int { return a.Start.Compare(b.Start) })
+	return calls
+}
+
+// Record records the provided call, overwriting the oldest recorded call if
+// the recorder is full.
+func (r *Recorder) Record(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events[r.next] = e
+	r.next++
+	if r.next == len(r.events) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Snapshot returns the recorded calls that completed within the recorder's
+// window of time, from oldest to newest.
+func (r *Recorder) Snapshot() []Event {
+	return r.snapshot(time.Now())
+}
+
+func (r *Recorder) snapshot(now time.Time) []Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ordered []Event
+	if r.full {
+		ordered = append(ordered, r.events[r.next:]...)
+	}
+	ordered = append(ordered, r.events[:r.next]...)
+
+	cutoff := now.Add(-r.window)
+	for i, e := range ordered {
+		if !e.Time.Before(cutoff) {
+			return ordered[i:]
+		}
+	}
+	return nil
+}
+
+// Reset discards all recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clear(r.events)
+	clear(r.active)
+	r.next = 0
+	r.full = false
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flightrecorder
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnapshotCapacity(t *testing.T) {
+	now := time.Now()
+	r := New(3, time.Hour)
+	for i := 0; i < 5; i++ {
+		r.Record(Event{Time: now, Method: fmt.Sprint(i)})
+	}
+	var got []string
+	for _, e := range r.snapshot(now) {
+		got = append(got, e.Method)
+	}
+	if diff := cmp.Diff([]string{"2", "3", "4"}, got); diff != "" {
+		t.Fatalf("snapshot (-want +got):\n%s", diff)
+	}
+}
+
+func TestSnapshotWindow(t *testing.T) {
+	now := time.Now()
+	r := New(10, time.Minute)
+	for i, age := range []time.Duration{time.Hour, 2 * time.Minute, 30 * time.Second, 0} {
+		r.Record(Event{Time: now.Add(-age), Method: fmt.Sprint(i)})
+	}
+	var got []string
+	for _, e := range r.snapshot(now) {
+		got = append(got, e.Method)
+	}
+	if diff := cmp.Diff([]string{"2", "3"}, got); diff != "" {
+		t.Fatalf("snapshot (-want +got):\n%s", diff)
+	}
+}
+
+func TestReset(t *testing.T) {
+	r := New(10, time.Minute)
+	r.Record(Event{Time: time.Now()})
+	r.Reset()
+	if got := r.Snapshot(); len(got) != 0 {
+		t.Fatalf("Snapshot after Reset: got %v, want empty", got)
+	}
+}
+
+func TestActive(t *testing.T) {
+	now := time.Now()
+	r := New(10, time.Minute)
+	a := r.Begin(Call{Start: now, Method: "a"})
+	r.Begin(Call{Start: now.Add(time.Second), Method: "b"})
+	r.Begin(Call{Start: now.Add(-time.Second), Method: "c"})
+	r.End(a, Event{Time: now, Method: "a"})
+
+	var active []string
+	for _, c := range r.Active() {
+		active = append(active, c.Method)
+	}
+	if diff := cmp.Diff([]string{"c", "b"}, active); diff != "" {
+		t.Fatalf("Active (-want +got):\n%s", diff)
+	}
+	if got := r.Snapshot(); len(got) != 1 || got[0].Method != "a" {
+		t.Fatalf("Snapshot: got %v, want [a]", got)
+	}
+}
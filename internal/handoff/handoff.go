@@ -0,0 +1,252 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package handoff hands off open files, like listeners and connections,
+// between the components of a process, and from a weavelet to the weavelet
+// that replaces it.
+//
+// Every process has a table of named files (see Default). A component hands
+// off a file by putting it in the table, and another component takes it out.
+// If the weavelet runs in a process started by an envelope, every file put in
+// the table is also sent to the envelope, over a Unix socket inherited by the
+// weavelet, and every file taken out of the table is dropped by the envelope.
+// When the weavelet exits, the envelope holds the files that were handed off
+// but not taken (see Parent.Files), and a deployer can hand them off to the
+// weavelet that replaces it: they are inherited by the new process, and put in
+// its table.
+//
+// A file taken by a component shortly before its process exits may still be
+// handed off to the replacement process, so a file may be taken twice.
+package handoff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+)
+
+// EnvKey is the environment variable that describes the files handed off to a
+// weavelet by its envelope.
+const EnvKey = "SERVICEWEAVER_HANDOFF"
+
+// env is the JSON encoded value of EnvKey.
+type env struct {
+	Socket int            // the socket connected to the envelope
+	Files  map[string]int // the inherited files, by name
+}
+
+// Message operations.
+const (
+	opPut  byte = 'P' // a file was put in the table
+	opTake byte = 'T' // a file was taken out of the table
+)
+
+// Table is a table of files handed off between components. It is safe for
+// concurrent use.
+type Table struct {
+	mu     sync.Mutex
+	files  map[string]*os.File // handed off files, by name
+	put    chan struct{}       // closed when a file is put in the table
+	parent *net.UnixConn       // connection to the envelope, or nil
+}
+
+// Default returns the table of the process. If the process was started by an
+// envelope, the table holds the files handed off to the process, and is
+// connected to the envelope.
+var Default = sync.OnceValue(func() *Table {
+	t, err := fromEnv(os.Getenv(EnvKey))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read the handed off files: %v\n", err)
+		return newTable(nil, nil)
+	}
+	os.Unsetenv(EnvKey)
+	return t
+})
+
+// newTable returns a table that holds the provided files and is connected to
+// the envelope by the provided connection, if not nil.
+func newTable(parent *net.UnixConn, files map[string]*os.File) *Table {
+	if files == nil {
+		files = map[string]*os.File{}
+	}
+	return &Table{files: files, put: make(chan struct{}), parent: parent}
+}
+
+// fromEnv returns a table that holds the inherited files described by the
+// provided value of EnvKey, if not empty.
+func fromEnv(value string) (*Table, error) {
+	if value == "" {
+		return newTable(nil, nil), nil
+	}
+	var e env
+	if err := json.Unmarshal([]byte(value), &e); err != nil {
+		return nil, fmt.Errorf("invalid %s %q: %w", EnvKey, value, err)
+	}
+	files := map[string]*os.File{}
+	for name, fd := range e.Files {
+		files[name] = inherit(fd, name)
+	}
+	socket := inherit(e.Socket, "handoff")
+	defer socket.Close()
+	conn, err := net.FileConn(socket)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the envelope: %w", err)
+	}
+	return newTable(conn.(*net.UnixConn), files), nil
+}
+
+// Put hands off the provided file under the provided name. The table takes
+// ownership of the file.
+func (t *Table) Put(name string, f *os.File) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.files[name]; ok {
+		return fmt.Errorf("file %q already handed off", name)
+	}
+	if t.parent != nil {
+		// Send the file to the envelope while holding t.mu, so that the
+		// envelope observes puts and takes in order.
+		if err := send(t.parent, opPut, name, f); err != nil {
+			return fmt.Errorf("cannot hand off file %q to the envelope: %w", name, err)
+		}
+	}
+	t.files[name] = f
+	close(t.put)
+	t.put = make(chan struct{})
+	return nil
+}
+
+// Take waits until a file is handed off under the provided name, and takes it
+// out of the table. The caller takes ownership of the file.
+func (t *Table) Take(ctx context.Context, name string) (*os.File, error) {
+	for {
+		t.mu.Lock()
+		if f, ok := t.files[name]; ok {
+			delete(t.files, name)
+			if t.parent != nil {
+				// If the envelope misses the take, the file is handed off to
+				// the replacement process as well, which is harmless.
+				send(t.parent, opTake, name, nil)
+			}
+			t.mu.Unlock()
+			return f, nil
+		}
+		put := t.put
+		t.mu.Unlock()
+
+		select {
+		case <-put:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Parent holds the files that a weavelet hands off to its envelope. It is
+// safe for concurrent use.
+type Parent struct {
+	conn    *net.UnixConn       // the envelope's end of the socket
+	child   *os.File            // the weavelet's end of the socket
+	inherit map[string]*os.File // files handed off to the weavelet
+	done    chan struct{}       // closed when the weavelet closes its end
+
+	mu    sync.Mutex
+	files map[string]*os.File // files handed off by the weavelet, by name
+}
+
+// NewParent returns a Parent that hands off the provided files to a new
+// weavelet. The Parent takes ownership of the files.
+func NewParent(files map[string]*os.File) (*Parent, error) {
+	conn, child, err := socketpair()
+	if err != nil {
+		for _, f := range files {
+			f.Close()
+		}
+		return nil, err
+	}
+	p := &Parent{
+		conn:    conn,
+		child:   child,
+		inherit: files,
+		done:    make(chan struct{}),
+		files:   map[string]*os.File{},
+	}
+	// The envelope holds on to the files until the weavelet takes them, in
+	// case the weavelet exits before it does.
+	for name, f := range files {
+		p.files[name] = f
+	}
+	go p.serve()
+	return p, nil
+}
+
+// Inherit returns the files that the weavelet's process inherits, and the
+// value of EnvKey that describes them. The first file is inherited as file
+// descriptor first, the second as first+1, and so on.
+func (p *Parent) Inherit(first int) ([]*os.File, string, error) {
+	e := env{Socket: first, Files: map[string]int{}}
+	files := []*os.File{p.child}
+	for name, f := range p.inherit {
+		e.Files[name] = first + len(files)
+		files = append(files, f)
+	}
+	value, err := json.Marshal(e)
+	if err != nil {
+		return nil, "", err
+	}
+	return files, string(value), nil
+}
+
+// Started is called after the weavelet's process has started, or failed to
+// start. It closes the envelope's copy of the weavelet's end of the socket.
+func (p *Parent) Started() {
+	p.child.Close()
+}
+
+// Files waits until the weavelet exits, and returns the files that it handed
+// off but didn't take. The caller takes ownership of the files. Files should
+// be called at most once.
+func (p *Parent) Files() map[string]*os.File {
+	<-p.done
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	files := p.files
+	p.files = nil
+	return files
+}
+
+// serve receives the files handed off by the weavelet until the weavelet
+// closes its end of the socket.
+func (p *Parent) serve() {
+	defer close(p.done)
+	defer p.conn.Close()
+	for {
+		op, name, f, err := recv(p.conn)
+		if err != nil {
+			return
+		}
+		p.mu.Lock()
+		if old, ok := p.files[name]; ok {
+			old.Close()
+			delete(p.files, name)
+		}
+		if op == opPut && f != nil {
+			p.files[name] = f
+		}
+		p.mu.Unlock()
+	}
+}
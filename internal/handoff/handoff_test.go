@@ -0,0 +1,141 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handoff
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/exp/maps"
+)
+
+// tempFile returns a new file with the provided contents.
+func tempFile(t *testing.T, contents string) *os.File {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(contents); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// contents returns the contents of the provided file.
+func contents(t *testing.T, f *os.File) string {
+	t.Helper()
+	b := make([]byte, 100)
+	n, err := f.ReadAt(b, 0)
+	if err != nil && n == 0 {
+		t.Fatal(err)
+	}
+	return string(b[:n])
+}
+
+func TestTable(t *testing.T) {
+	ctx := context.Background()
+	table := newTable(nil, nil)
+
+	// Take waits for the file to be put.
+	taken := make(chan *os.File)
+	go func() {
+		f, err := table.Take(ctx, "a")
+		if err != nil {
+			t.Error(err)
+		}
+		taken <- f
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := table.Put("a", tempFile(t, "hello")); err != nil {
+		t.Fatal(err)
+	}
+	f := <-taken
+	defer f.Close()
+	if got := contents(t, f); got != "hello" {
+		t.Fatalf("contents: got %q, want %q", got, "hello")
+	}
+
+	// A name can't be put twice, until it is taken.
+	if err := table.Put("b", tempFile(t, "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put("b", tempFile(t, "")); err == nil {
+		t.Fatal("Put: unexpected success")
+	}
+
+	// Take stops waiting when ctx is done.
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := table.Take(ctx, "a"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Take: got %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestParent(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("handing off files to an envelope is not supported on Windows")
+	}
+	ctx := context.Background()
+
+	// Hand off a file to a weavelet, which we simulate in process by
+	// connecting a table to the weavelet's end of the socket.
+	p, err := NewParent(map[string]*os.File{"inherited": tempFile(t, "inherited")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := p.Inherit(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.FileConn(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.Started()
+	table := newTable(conn.(*net.UnixConn), map[string]*os.File{"inherited": files[1]})
+
+	// The weavelet takes the inherited file, and hands off two new files,
+	// one of which it takes back.
+	if _, err := table.Take(ctx, "inherited"); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put("a", tempFile(t, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Put("b", tempFile(t, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := table.Take(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The weavelet exits. The envelope has the file handed off but not taken.
+	conn.Close()
+	handedOff := p.Files()
+	if diff := cmp.Diff([]string{"a"}, maps.Keys(handedOff)); diff != "" {
+		t.Fatalf("Files (-want +got):\n%s", diff)
+	}
+	if got := contents(t, handedOff["a"]); got != "a" {
+		t.Fatalf("contents: got %q, want %q", got, "a")
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package handoff
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+)
+
+// socketpair returns a pair of connected Unix stream sockets: a connection for
+// the envelope, and a file for the weavelet to inherit.
+func socketpair() (*net.UnixConn, *os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("socketpair: %w", err)
+	}
+	syscall.CloseOnExec(fds[0])
+	syscall.CloseOnExec(fds[1])
+	parent := os.NewFile(uintptr(fds[0]), "handoff")
+	defer parent.Close()
+	conn, err := net.FileConn(parent)
+	if err != nil {
+		syscall.Close(fds[1])
+		return nil, nil, err
+	}
+	return conn.(*net.UnixConn), os.NewFile(uintptr(fds[1]), "handoff"), nil
+}
+
+// inherit returns the inherited file with the provided file descriptor. The
+// file is not inherited by the processes that the weavelet starts.
+func inherit(fd int, name string) *os.File {
+	syscall.CloseOnExec(fd)
+	return os.NewFile(uintptr(fd), name)
+}
+
+// send sends a message, and the provided file if not nil. A message is an
+// operation, followed by the length of the name and the name. The file is
+// attached to the first byte of the message.
+func send(conn *net.UnixConn, op byte, name string, f *os.File) error {
+	msg := []byte{op}
+	msg = binary.BigEndian.AppendUint16(msg, uint16(len(name)))
+	msg = append(msg, name...)
+	if f == nil {
+		_, err := conn.Write(msg)
+		return err
+	}
+	raw, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var werr error
+	if err := raw.Control(func(fd uintptr) {
+		_, _, werr = conn.WriteMsgUnix(msg, syscall.UnixRights(int(fd)), nil)
+	}); err != nil {
+		return err
+	}
+	return werr
+}
+
+// recv receives a message, and the file attached to it, if any.
+func recv(conn *net.UnixConn) (byte, string, *os.File, error) {
+	// Read the header, and the file attached to it.
+	var hdr [3]byte
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(hdr[:], oob)
+	if err != nil {
+		return 0, "", nil, err
+	}
+	var f *os.File
+	if oobn > 0 {
+		msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			return 0, "", nil, err
+		}
+		for _, msg := range msgs {
+			fds, err := syscall.ParseUnixRights(&msg)
+			if err != nil {
+				continue
+			}
+			for _, fd := range fds {
+				if f == nil {
+					syscall.CloseOnExec(fd)
+					f = os.NewFile(uintptr(fd), "handoff")
+				} else {
+					syscall.Close(fd)
+				}
+			}
+		}
+	}
+	closeFile := func() {
+		if f != nil {
+			f.Close()
+		}
+	}
+	if _, err := io.ReadFull(conn, hdr[n:]); err != nil {
+		closeFile()
+		return 0, "", nil, err
+	}
+
+	// Read the name.
+	name := make([]byte, binary.BigEndian.Uint16(hdr[1:]))
+	if _, err := io.ReadFull(conn, name); err != nil {
+		closeFile()
+		return 0, "", nil, err
+	}
+	return hdr[0], string(name), f, nil
+}
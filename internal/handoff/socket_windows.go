@@ -0,0 +1,41 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handoff
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// errUnsupported is returned when handing off files to an envelope, which
+// isn't supported on Windows.
+var errUnsupported = errors.New("handing off files to an envelope is not supported on Windows")
+
+func socketpair() (*net.UnixConn, *os.File, error) {
+	return nil, nil, errUnsupported
+}
+
+func inherit(fd int, name string) *os.File {
+	return os.NewFile(uintptr(fd), name)
+}
+
+func send(*net.UnixConn, byte, string, *os.File) error {
+	return errUnsupported
+}
+
+func recv(*net.UnixConn) (byte, string, *os.File, error) {
+	return 0, "", nil, errUnsupported
+}
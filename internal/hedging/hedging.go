@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hedging configures the hedging of remote calls.
+//
+// A hedged call is a duplicate of a call that is sent when the original call
+// hasn't returned after a delay; the first reply wins, and the other call is
+// cancelled. Hedging cuts the tail latency of a method, at the cost of extra
+// work. The [hedging] section of a config file sets the delay after which the
+// calls to a method are hedged:
+//
+//	[hedging]
+//	budget = 10
+//	methods."github.com/example/app/Cache.Get" = "20ms"
+//
+// Every request hedges at most budget calls, across every component it
+// reaches. The budget is propagated with the calls a request makes: every call
+// takes half of the budget its caller has left, and hedged calls don't hedge.
+// A request is identified by its trace, if it is traced, and by the call that
+// started it otherwise.
+package hedging
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/hedging"
+	shortConfigKey = "hedging"
+)
+
+// DefaultBudget is the number of calls a request hedges if the config doesn't
+// set a budget.
+const DefaultBudget = 10
+
+// traceTTL is how long the budget of a trace is kept after it was created.
+const traceTTL = time.Minute
+
+// Config configures the hedging of calls.
+type Config struct {
+	// Budget is the largest number of calls that a request hedges. If zero,
+	// DefaultBudget.
+	Budget int `toml:"budget"`
+
+	// Methods is the delay after which the calls to every hedged method are
+	// hedged, keyed by "<full component name>.<method name>", e.g., "20ms".
+	Methods map[string]string `toml:"methods"`
+
+	delays map[string]time.Duration // parsed Methods
+}
+
+// ParseConfig parses the hedging config in the provided config sections.
+func ParseConfig(sections map[string]string) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	if c.Budget < 0 {
+		return fmt.Errorf("invalid budget %d: must be non-negative", c.Budget)
+	}
+	c.delays = map[string]time.Duration{}
+	for method, delay := range c.Methods {
+		if !strings.Contains(method, ".") {
+			return fmt.Errorf("invalid method %q: want <component>.<method>", method)
+		}
+		d, err := time.ParseDuration(delay)
+		if err != nil {
+			return fmt.Errorf("method %q: invalid delay %q: %w", method, delay, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("method %q: invalid delay %q: must be positive", method, delay)
+		}
+		c.delays[method] = d
+	}
+	return nil
+}
+
+// Delay returns the delay after which the calls to the provided method of the
+// provided component are hedged, or zero if they aren't.
+func (c *Config) Delay(component, method string) time.Duration {
+	return c.delays[component+"."+method]
+}
+
+// Hedger decides which calls are hedged, and hands out the hedging budgets of
+// requests. It is safe for concurrent use.
+type Hedger struct {
+	config *Config
+	budget int // the budget of a request
+
+	mu     sync.Mutex
+	traces map[trace.TraceID]*traceBudget
+	swept  time.Time // when traces was last swept
+}
+
+// traceBudget is the budget of a trace.
+type traceBudget struct {
+	budget  *codegen.HedgingBudget
+	created time.Time
+}
+
+// NewHedger returns a hedger for the provided config.
+func NewHedger(config *Config) *Hedger {
+	budget := config.Budget
+	if budget == 0 {
+		budget = DefaultBudget
+	}
+	return &Hedger{config: config, budget: budget, traces: map[trace.TraceID]*traceBudget{}}
+}
+
+// Delay returns the delay after which the calls to the provided method of the
+// provided component are hedged, or zero if they aren't.
+func (h *Hedger) Delay(component, method string) time.Duration {
+	if h == nil {
+		return 0
+	}
+	return h.config.Delay(component, method)
+}
+
+// Budget returns the hedging budget of the request that ctx belongs to: the
+// budget in ctx if any, or else the budget of the trace in ctx, or else a new
+// budget.
+func (h *Hedger) Budget(ctx context.Context) *codegen.HedgingBudget {
+	if budget := codegen.HedgingBudgetFromContext(ctx); budget != nil {
+		return budget
+	}
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return codegen.NewHedgingBudget(h.budget)
+	}
+
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if now.Sub(h.swept) > traceTTL {
+		for id, t := range h.traces {
+			if now.Sub(t.created) > traceTTL {
+				delete(h.traces, id)
+			}
+		}
+		h.swept = now
+	}
+	t, ok := h.traces[sc.TraceID()]
+	if !ok {
+		t = &traceBudget{budget: codegen.NewHedgingBudget(h.budget), created: now}
+		h.traces[sc.TraceID()] = t
+	}
+	return t.budget
+}
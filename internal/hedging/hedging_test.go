@@ -0,0 +1,115 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hedging
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func parse(t *testing.T, config string) (*Config, error) {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseConfig(app.Sections)
+}
+
+func TestDelay(t *testing.T) {
+	config, err := parse(t, `
+[hedging]
+methods."github.com/example/app/Cache.Get" = "20ms"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHedger(config)
+	if got, want := h.Delay("github.com/example/app/Cache", "Get"), 20*time.Millisecond; got != want {
+		t.Errorf("Delay(Cache.Get): got %v, want %v", got, want)
+	}
+	if got := h.Delay("github.com/example/app/Cache", "Put"); got != 0 {
+		t.Errorf("Delay(Cache.Put): got %v, want 0", got)
+	}
+	var nilHedger *Hedger
+	if got := nilHedger.Delay("github.com/example/app/Cache", "Get"); got != 0 {
+		t.Errorf("nil Delay(Cache.Get): got %v, want 0", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"NegativeBudget", "budget = -1", "must be non-negative"},
+		{"NoMethod", `methods.Cache = "20ms"`, "want <component>.<method>"},
+		{"BadDelay", `methods."Cache.Get" = "soon"`, "invalid delay"},
+		{"ZeroDelay", `methods."Cache.Get" = "0s"`, "must be positive"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse(t, "[hedging]\n"+test.config)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestBudget(t *testing.T) {
+	h := NewHedger(&Config{Budget: 3})
+	ctx := context.Background()
+
+	// Untraced requests get a budget of their own.
+	if h.Budget(ctx) == h.Budget(ctx) {
+		t.Error("Budget: untraced requests share a budget")
+	}
+
+	// Traced requests share the budget of their trace.
+	traced := trace.ContextWithSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1},
+		SpanID:  trace.SpanID{1},
+	}))
+	budget := h.Budget(traced)
+	if h.Budget(traced) != budget {
+		t.Error("Budget: traced request has more than one budget")
+	}
+
+	// A budget in the context wins.
+	want := codegen.NewHedgingBudget(1)
+	if got := h.Budget(codegen.WithHedgingBudget(traced, want)); got != want {
+		t.Error("Budget: ignored the budget in the context")
+	}
+
+	// Splitting a budget doesn't create hedged calls.
+	child := budget.Split()
+	if got := budget.Remaining() + child.Remaining(); got != 3 {
+		t.Errorf("Remaining after Split: got %d, want 3", got)
+	}
+	taken := 0
+	for _, b := range []*codegen.HedgingBudget{budget, child, child.Split()} {
+		for b.Take() {
+			taken++
+		}
+	}
+	if taken != 3 {
+		t.Errorf("Take: took %d hedged calls, want 3", taken)
+	}
+}
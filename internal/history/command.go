@@ -0,0 +1,216 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/colors"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+)
+
+// Command returns a "history" subcommand that prints the events stored in the
+// provided database file. tool is the name of the command-line tool the
+// returned subcommand runs as (e.g., "weaver multi").
+func Command(toolName, fname string) *tool.Command {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	app := flags.String("app", "", "Only show events of this application")
+	deployment := flags.String("deployment", "", "Only show events of this deployment (id or id prefix)")
+	kinds := flags.String("kind", "", "Only show events of these comma-separated kinds")
+	since := flags.String("since", "", "Only show events at or after this time")
+	until := flags.String("until", "", "Only show events before this time")
+	limit := flags.Int("limit", 0, "Only show the most recent events")
+	format := flags.String("format", "pretty", "Output format (pretty or json)")
+
+	const help = `Usage:
+  {{.Tool}} history [flags]
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  "{{.Tool}} history" prints the events recorded by deployments, oldest
+  first: deployments starting and stopping, replicas starting, becoming
+  ready, stopping, crashing, and being killed or moved, and changes to the
+  number of replicas of colocation groups. Events are kept for 30 days.
+
+  --since and --until accept an RFC 3339 timestamp (e.g.,
+  "2024-03-01T14:03:00Z"), a local date and time (e.g., "2024-03-01 14:03"),
+  a local time of day today (e.g., "14:03"), or a duration, which is
+  interpreted relative to now (e.g., "2h" means two hours ago).
+
+  Event kinds: {{.Kinds}}.
+
+Examples:
+  # Show all events.
+  {{.Tool}} history
+
+  # Show the events between 14:00 and 14:10 today.
+  {{.Tool}} history --since=14:00 --until=14:10
+
+  # Show the crashes of the last hour of the "todo" app.
+  {{.Tool}} history --app=todo --kind=ReplicaCrashed,ReplicaKilled --since=1h`
+	var kindNames []string
+	for _, k := range Kinds {
+		kindNames = append(kindNames, string(k))
+	}
+	var b strings.Builder
+	t := template.Must(template.New(toolName).Parse(help))
+	content := struct{ Tool, Flags, Kinds string }{toolName, tool.FlagsHelp(flags), strings.Join(kindNames, ", ")}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "history",
+		Flags:       flags,
+		Description: "Show the history of Service Weaver deployments",
+		Help:        b.String(),
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) > 0 {
+				return fmt.Errorf("too many arguments")
+			}
+			if *format != "pretty" && *format != "json" {
+				return fmt.Errorf("invalid format %q; must be %q or %q", *format, "pretty", "json")
+			}
+			q := Query{App: *app, DeploymentId: *deployment, Limit: *limit}
+			if *kinds != "" {
+				for _, k := range strings.Split(*kinds, ",") {
+					if !slices.Contains(Kinds, Kind(k)) {
+						return fmt.Errorf("invalid kind %q", k)
+					}
+					q.Kinds = append(q.Kinds, Kind(k))
+				}
+			}
+			now := time.Now()
+			var err error
+			if q.Since, err = ParseTime(*since, now); err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			if q.Until, err = ParseTime(*until, now); err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+
+			if _, err := os.Stat(fname); os.IsNotExist(err) {
+				// Nothing has been deployed yet.
+				return nil
+			}
+			db, err := OpenDB(ctx, fname)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+			events, err := db.Query(ctx, q)
+			if err != nil {
+				return err
+			}
+			if *format == "json" {
+				return formatJSON(os.Stdout, events)
+			}
+			formatPretty(os.Stdout, events)
+			return nil
+		},
+	}
+}
+
+// ParseTime parses a time passed to the --since or --until flags, relative to
+// now. See Command for the accepted formats. The empty string parses to the
+// zero time.
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if t, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+			y, m, d := now.Date()
+			return time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, now.Location()), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q as a time or duration", s)
+}
+
+// formatPretty pretty-prints the provided events.
+func formatPretty(w io.Writer, events []Event) {
+	title := []colors.Text{{{S: "HISTORY", Bold: true}}}
+	t := colors.NewTabularizer(w, title, colors.NoDim)
+	defer t.Flush()
+	t.Row("TIME", "APP", "DEPLOYMENT", "KIND", "GROUP", "REPLICA", "WEAVELET ID", "DETAIL")
+	for _, e := range events {
+		replica, weavelet := "", e.WeaveletId
+		if e.Replica >= 0 {
+			replica = fmt.Sprint(e.Replica)
+		}
+		if len(weavelet) > 8 {
+			weavelet = weavelet[:8]
+		}
+		t.Row(e.Time.Format("2006-01-02 15:04:05.000"), e.App, logging.Shorten(e.DeploymentId), string(e.Kind), logging.ShortenComponent(e.Group), replica, weavelet, e.Detail)
+	}
+}
+
+// formatJSON prints the provided events as JSON, one per line.
+func formatJSON(w io.Writer, events []Event) error {
+	type jsonEvent struct {
+		Time         string `json:"time"`
+		App          string `json:"app"`
+		DeploymentId string `json:"deployment_id"`
+		Kind         string `json:"kind"`
+		Group        string `json:"group,omitempty"`
+		Replica      *int   `json:"replica,omitempty"`
+		WeaveletId   string `json:"weavelet_id,omitempty"`
+		Detail       string `json:"detail,omitempty"`
+	}
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		j := jsonEvent{
+			Time:         e.Time.Format(time.RFC3339Nano),
+			App:          e.App,
+			DeploymentId: e.DeploymentId,
+			Kind:         string(e.Kind),
+			Group:        e.Group,
+			WeaveletId:   e.WeaveletId,
+			Detail:       e.Detail,
+		}
+		if e.Replica >= 0 {
+			replica := e.Replica
+			j.Replica = &replica
+		}
+		if err := enc.Encode(j); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history stores the timeline of the events of a deployment, like
+// replicas starting, stopping, and crashing, so that changes to a deployment
+// can be inspected after the fact, e.g., with "weaver multi history".
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/sqlitedb"
+)
+
+// Kind is the kind of an event.
+type Kind string
+
+// Event kinds.
+const (
+	DeploymentStarted Kind = "DeploymentStarted" // the deployment started
+	DeploymentStopped Kind = "DeploymentStopped" // the deployment stopped
+	DeploymentResumed Kind = "DeploymentResumed" // a standby manager took over the deployment
+	GroupScaled       Kind = "GroupScaled"       // the desired number of replicas of a group changed
+	ReplicaStarted    Kind = "ReplicaStarted"    // a replica started
+	ReplicaReady      Kind = "ReplicaReady"      // traffic is routed to a replica
+	ReplicaNotReady   Kind = "ReplicaNotReady"   // a replica is still not ready after a timeout
+	ReplicaStopped    Kind = "ReplicaStopped"    // a replica stopped
+	ReplicaCrashed    Kind = "ReplicaCrashed"    // a replica failed unexpectedly
+	ReplicaKilled     Kind = "ReplicaKilled"     // a replica exceeded its resource limits
+	ReplicaMoved      Kind = "ReplicaMoved"      // a replica was removed to be placed elsewhere
+	ReplicaPromoted   Kind = "ReplicaPromoted"   // traffic is routed to a standby replica
+)
+
+// Kinds holds all event kinds.
+var Kinds = []Kind{
+	DeploymentStarted,
+	DeploymentStopped,
+	DeploymentResumed,
+	GroupScaled,
+	ReplicaStarted,
+	ReplicaReady,
+	ReplicaNotReady,
+	ReplicaStopped,
+	ReplicaCrashed,
+	ReplicaKilled,
+	ReplicaMoved,
+	ReplicaPromoted,
+}
+
+// Event is an event in the history of a deployment.
+type Event struct {
+	Time         time.Time
+	App          string
+	DeploymentId string
+	Kind         Kind
+	Group        string // colocation group, if any
+	Replica      int    // replica index, or -1 if the event isn't about a replica
+	WeaveletId   string // weavelet id, if known
+	Detail       string // human-readable details, e.g., the error of a crash
+}
+
+// Query selects events. A zero field matches every event.
+type Query struct {
+	App          string
+	DeploymentId string    // a deployment id, or a prefix of one
+	Kinds        []Kind    // matches events of any of the kinds
+	Since        time.Time // matches events at or after Since
+	Until        time.Time // matches events before Until
+	Limit        int       // returns at most the Limit most recent events
+}
+
+// DB is a database of events, stored on the local file system.
+type DB struct {
+	db *sql.DB
+}
+
+// OpenDB opens the event database persisted in the provided file. If the file
+// doesn't exist, OpenDB creates it.
+func OpenDB(ctx context.Context, fname string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(fname), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := sqlitedb.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("open history db %q: %w", fname, err)
+	}
+	d := &DB{db: db}
+
+	const initDB = `
+CREATE TABLE IF NOT EXISTS events (
+	time_unix_us INTEGER NOT NULL,
+	app TEXT NOT NULL,
+	deployment_id TEXT NOT NULL,
+	kind TEXT NOT NULL,
+	group_name TEXT NOT NULL,
+	replica INTEGER NOT NULL,
+	weavelet_id TEXT NOT NULL,
+	detail TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS events_by_time ON events (time_unix_us);
+
+-- Garbage-collect events older than 30 days.
+CREATE TRIGGER IF NOT EXISTS expire_events AFTER INSERT ON events
+BEGIN
+	DELETE FROM events
+	WHERE time_unix_us < (1000000 * unixepoch('now', '-30 days'));
+END;
+`
+	if _, err := sqlitedb.Exec(ctx, d.db, initDB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open history db %q: %w", fname, err)
+	}
+	return d, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Record stores the provided event in the database.
+func (d *DB) Record(ctx context.Context, e Event) error {
+	const stmt = `INSERT INTO events VALUES (?,?,?,?,?,?,?,?)`
+	_, err := sqlitedb.Exec(ctx, d.db, stmt, e.Time.UnixMicro(), e.App, e.DeploymentId, string(e.Kind), e.Group, e.Replica, e.WeaveletId, e.Detail)
+	return err
+}
+
+// Query returns the events that match the provided query, oldest first.
+func (d *DB) Query(ctx context.Context, q Query) ([]Event, error) {
+	var conds []string
+	var args []any
+	if q.App != "" {
+		conds = append(conds, "app = ?")
+		args = append(args, q.App)
+	}
+	if q.DeploymentId != "" {
+		conds = append(conds, "substr(deployment_id, 1, ?) = ?")
+		args = append(args, len(q.DeploymentId), q.DeploymentId)
+	}
+	if len(q.Kinds) > 0 {
+		conds = append(conds, "kind IN ("+strings.TrimSuffix(strings.Repeat("?,", len(q.Kinds)), ",")+")")
+		for _, k := range q.Kinds {
+			args = append(args, string(k))
+		}
+	}
+	if !q.Since.IsZero() {
+		conds = append(conds, "time_unix_us >= ?")
+		args = append(args, q.Since.UnixMicro())
+	}
+	if !q.Until.IsZero() {
+		conds = append(conds, "time_unix_us < ?")
+		args = append(args, q.Until.UnixMicro())
+	}
+	query := "SELECT * FROM events"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	// Select the most recent events, and return them oldest first.
+	query += " ORDER BY time_unix_us DESC, rowid DESC"
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := sqlitedb.Query(ctx, d.db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		var e Event
+		var micros int64
+		var kind string
+		if err := rows.Scan(&micros, &e.App, &e.DeploymentId, &kind, &e.Group, &e.Replica, &e.WeaveletId, &e.Detail); err != nil {
+			return nil, err
+		}
+		e.Time = time.UnixMicro(micros)
+		e.Kind = Kind(kind)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+		events[i], events[j] = events[j], events[i]
+	}
+	return events, nil
+}
+
+// Recorder records the events of a single deployment. A nil *Recorder
+// records nothing. Errors are logged rather than returned, since a deployment
+// shouldn't fail because its history can't be recorded.
+type Recorder struct {
+	db           *DB
+	app          string
+	deploymentId string
+	logger       *slog.Logger
+}
+
+// NewRecorder returns a recorder that stores the events of the provided
+// deployment in db.
+func NewRecorder(db *DB, app, deploymentId string, logger *slog.Logger) *Recorder {
+	return &Recorder{db: db, app: app, deploymentId: deploymentId, logger: logger}
+}
+
+// Deployment records an event about the deployment as a whole.
+func (r *Recorder) Deployment(kind Kind, detail string) {
+	r.record(Event{Kind: kind, Replica: -1, Detail: detail})
+}
+
+// Group records an event about a colocation group.
+func (r *Recorder) Group(kind Kind, group, detail string) {
+	r.record(Event{Kind: kind, Group: group, Replica: -1, Detail: detail})
+}
+
+// Replica records an event about a replica of a colocation group.
+func (r *Recorder) Replica(kind Kind, group string, replica int, weaveletId, detail string) {
+	r.record(Event{Kind: kind, Group: group, Replica: replica, WeaveletId: weaveletId, Detail: detail})
+}
+
+func (r *Recorder) record(e Event) {
+	if r == nil {
+		return
+	}
+	e.Time = time.Now()
+	e.App = r.app
+	e.DeploymentId = r.deploymentId
+	// Record the event even if the deployment is stopping.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.db.Record(ctx, e); err != nil {
+		r.logger.Error("Cannot record deployment event", "err", err, "kind", e.Kind)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuery(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenDB(ctx, filepath.Join(t.TempDir(), "history.DB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Current time, rounded to a whole number of microseconds.
+	now := time.UnixMicro(time.Now().UnixMicro())
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+	events := []Event{
+		{Time: at(0), App: "todo", DeploymentId: "aaaa-1", Kind: DeploymentStarted, Replica: -1},
+		{Time: at(1), App: "todo", DeploymentId: "aaaa-1", Kind: ReplicaStarted, Group: "main", Replica: 0, WeaveletId: "w1"},
+		{Time: at(2), App: "chat", DeploymentId: "bbbb-2", Kind: ReplicaStarted, Group: "main", Replica: 0, WeaveletId: "w2"},
+		{Time: at(3), App: "todo", DeploymentId: "aaaa-1", Kind: ReplicaCrashed, Group: "main", Replica: 0, WeaveletId: "w1", Detail: "exit status 2"},
+		{Time: at(4), App: "todo", DeploymentId: "aaaa-1", Kind: DeploymentStopped, Replica: -1},
+	}
+	for _, e := range events {
+		if err := db.Record(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		name  string
+		query Query
+		want  []Event
+	}{
+		{"All", Query{}, events},
+		{"App", Query{App: "chat"}, events[2:3]},
+		{"DeploymentPrefix", Query{DeploymentId: "aaaa"}, []Event{events[0], events[1], events[3], events[4]}},
+		{"Kinds", Query{Kinds: []Kind{ReplicaCrashed, DeploymentStopped}}, events[3:]},
+		{"Since", Query{Since: at(3)}, events[3:]},
+		{"Until", Query{Until: at(2)}, events[:2]},
+		{"Range", Query{Since: at(1), Until: at(3)}, events[1:3]},
+		{"Limit", Query{Limit: 2}, events[3:]},
+		{"Empty", Query{App: "missing"}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := db.Query(ctx, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("Query (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenDB(ctx, filepath.Join(t.TempDir(), "history.DB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	r := NewRecorder(db, "todo", "aaaa-1", nil)
+	r.Deployment(DeploymentStarted, "todo.bin")
+	r.Group(GroupScaled, "main", "2 replicas")
+	r.Replica(ReplicaReady, "main", 1, "w1", "localhost:1234")
+	var nilRecorder *Recorder
+	nilRecorder.Deployment(DeploymentStopped, "")
+
+	got, err := db.Query(ctx, Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		got[i].Time = time.Time{}
+	}
+	want := []Event{
+		{App: "todo", DeploymentId: "aaaa-1", Kind: DeploymentStarted, Replica: -1, Detail: "todo.bin"},
+		{App: "todo", DeploymentId: "aaaa-1", Kind: GroupScaled, Group: "main", Replica: -1, Detail: "2 replicas"},
+		{App: "todo", DeploymentId: "aaaa-1", Kind: ReplicaReady, Group: "main", Replica: 1, WeaveletId: "w1", Detail: "localhost:1234"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("events (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	loc := time.FixedZone("test", -7*60*60)
+	now := time.Date(2024, 3, 1, 15, 30, 0, 0, loc)
+	for _, test := range []struct {
+		s    string
+		want time.Time
+	}{
+		{"", time.Time{}},
+		{"2024-02-29T14:03:00Z", time.Date(2024, 2, 29, 14, 3, 0, 0, time.UTC)},
+		{"2024-02-29 14:03", time.Date(2024, 2, 29, 14, 3, 0, 0, loc)},
+		{"2024-02-29 14:03:05", time.Date(2024, 2, 29, 14, 3, 5, 0, loc)},
+		{"2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, loc)},
+		{"14:03", time.Date(2024, 3, 1, 14, 3, 0, 0, loc)},
+		{"14:03:05", time.Date(2024, 3, 1, 14, 3, 5, 0, loc)},
+		{"2h", time.Date(2024, 3, 1, 13, 30, 0, 0, loc)},
+	} {
+		got, err := ParseTime(test.s, now)
+		if err != nil {
+			t.Errorf("ParseTime(%q): %v", test.s, err)
+			continue
+		}
+		if !got.Equal(test.want) {
+			t.Errorf("ParseTime(%q): got %v, want %v", test.s, got, test.want)
+		}
+	}
+	for _, s := range []string{"yesterday", "-2h", "25:00"} {
+		if _, err := ParseTime(s, now); err == nil {
+			t.Errorf("ParseTime(%q): unexpected success", s)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package isolation configures isolated components.
+//
+// A panic in a component crashes the process that hosts it, along with every
+// component colocated with it, and a component that leaks memory starves its
+// neighbors. An isolated component always runs in a process of its own, even
+// if it is listed in a colocation group, and the deployer restarts the process
+// when it crashes, backing off if it keeps crashing. Isolated components are
+// listed in the [isolation] section of a config file:
+//
+//	[isolation]
+//	components = ["github.com/example/app/Parser"]
+//
+// An isolated component is in a colocation group by itself, named after the
+// component, so its resource limits can be set like those of any other group.
+package isolation
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/isolation"
+	shortConfigKey = "isolation"
+)
+
+const (
+	// minDelay and maxDelay bound the delay before restarting a crashed
+	// process of an isolated component.
+	minDelay = 100 * time.Millisecond
+	maxDelay = 30 * time.Second
+
+	// A process that crashes after running for stableUptime isn't considered
+	// to be crash looping, and is restarted right away.
+	stableUptime = time.Minute
+)
+
+// Config configures isolated components.
+type Config struct {
+	// Components are the full names of the isolated components.
+	Components []string `toml:"components"`
+}
+
+// ParseConfig parses the isolation config in the provided app config.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	seen := map[string]bool{}
+	for _, component := range c.Components {
+		if component == runtime.Main {
+			// Restarting the main component would run main again.
+			return fmt.Errorf("isolating %s is not supported", runtime.Main)
+		}
+		if seen[component] {
+			return fmt.Errorf("component %q isolated multiple times", component)
+		}
+		seen[component] = true
+	}
+	return nil
+}
+
+// Isolated returns whether the provided component is isolated.
+func (c *Config) Isolated(component string) bool {
+	return slices.Contains(c.Components, component)
+}
+
+// Apply returns a copy of the provided app config in which the isolated
+// components are removed from the colocation groups they are listed in. The
+// provided app config is not modified.
+func (c *Config) Apply(app *protos.AppConfig) *protos.AppConfig {
+	if len(c.Components) == 0 {
+		return app
+	}
+	isolated := proto.Clone(app).(*protos.AppConfig)
+	for _, group := range isolated.Colocate {
+		group.Components = slices.DeleteFunc(group.Components, c.Isolated)
+	}
+	return isolated
+}
+
+// Restarts paces the restarts of the crashed processes of an isolated
+// component. The zero value is ready to use. Restarts is not safe for
+// concurrent use.
+type Restarts struct {
+	crashes int // the number of recent crashes
+}
+
+// Crashed records that a process that ran for the provided duration crashed,
+// and returns how long to wait before restarting it. The delay doubles with
+// every crash of a process that ran for less than a minute, up to 30 seconds.
+func (r *Restarts) Crashed(uptime time.Duration) time.Duration {
+	if uptime >= stableUptime {
+		r.crashes = 0
+		return 0
+	}
+	delay := maxDelay
+	if r.crashes < 16 {
+		delay = min(minDelay<<r.crashes, maxDelay)
+	}
+	r.crashes++
+	return delay
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package isolation
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func parse(t *testing.T, config string) *protos.AppConfig {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestApply(t *testing.T) {
+	app := parse(t, `
+[serviceweaver]
+colocate = [["a/A", "a/B", "a/C"], ["a/D", "a/E"]]
+
+[isolation]
+components = ["a/A", "a/E"]
+`)
+	config, err := ParseConfig(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	isolated := config.Apply(app)
+	var got [][]string
+	for _, group := range isolated.Colocate {
+		got = append(got, group.Components)
+	}
+	want := [][]string{{"a/B", "a/C"}, {"a/D"}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Colocate (-want +got):\n%s", diff)
+	}
+
+	// The app config isn't modified.
+	if diff := cmp.Diff([]string{"a/A", "a/B", "a/C"}, app.Colocate[0].Components); diff != "" {
+		t.Fatalf("app colocate (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"Main", `components = ["github.com/ServiceWeaver/weaver/Main"]`, "not supported"},
+		{"Duplicate", `components = ["a/A", "a/A"]`, "isolated multiple times"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParseConfig(parse(t, "[isolation]\n"+test.config))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRestarts(t *testing.T) {
+	var r Restarts
+	var got []time.Duration
+	for i := 0; i < 12; i++ {
+		got = append(got, r.Crashed(time.Second))
+	}
+	want := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		1600 * time.Millisecond,
+		3200 * time.Millisecond,
+		6400 * time.Millisecond,
+		12800 * time.Millisecond,
+		25600 * time.Millisecond,
+		30 * time.Second,
+		30 * time.Second,
+		30 * time.Second,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("delays (-want +got):\n%s", diff)
+	}
+
+	// A process that ran for a while is restarted right away, and resets the
+	// backoff.
+	if got := r.Crashed(time.Hour); got != 0 {
+		t.Fatalf("Crashed(1h): got %v, want 0", got)
+	}
+	if got := r.Crashed(time.Second); got != 100*time.Millisecond {
+		t.Fatalf("Crashed(1s): got %v, want 100ms", got)
+	}
+}
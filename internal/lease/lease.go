@@ -0,0 +1,211 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lease stores leases in a local database, so that one of many
+// processes on a machine can manage a deployment, with the others standing by
+// to take over if it dies. A lease also stores the state its holder needs to
+// resume the deployment.
+package lease
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/sqlitedb"
+)
+
+// ErrLost is returned when renewing or updating a lease that is no longer held
+// by the caller, e.g., because it expired and another process acquired it.
+var ErrLost = errors.New("lease lost")
+
+// Lease is a lease on a key, e.g., a deployment id.
+type Lease struct {
+	Key    string
+	Owner  string    // unique id of the holder
+	Pid    int       // process id of the holder
+	Expiry time.Time // when the lease expires, unless renewed
+	State  []byte    // the holder's state
+}
+
+// Expired returns whether the lease is expired at the provided time.
+func (l Lease) Expired(now time.Time) bool {
+	return !now.Before(l.Expiry)
+}
+
+// DB is a database of leases, stored on the local file system.
+type DB struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// OpenDB opens the lease database persisted in the provided file. If the file
+// doesn't exist, OpenDB creates it.
+func OpenDB(ctx context.Context, fname string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(fname), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := sqlitedb.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("open lease db %q: %w", fname, err)
+	}
+	d := &DB{db: db, now: time.Now}
+
+	const initDB = `
+CREATE TABLE IF NOT EXISTS leases (
+	key TEXT NOT NULL PRIMARY KEY,
+	owner TEXT NOT NULL,
+	pid INTEGER NOT NULL,
+	expiry_unix_us INTEGER NOT NULL,
+	state BLOB NOT NULL
+);
+`
+	if _, err := sqlitedb.Exec(ctx, d.db, initDB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open lease db %q: %w", fname, err)
+	}
+	return d, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Create creates a lease on the provided key, held by the provided owner for
+// the provided duration, with the provided state. It returns an error if a
+// lease on the key already exists.
+func (d *DB) Create(ctx context.Context, key, owner string, ttl time.Duration, state []byte) error {
+	const stmt = `INSERT INTO leases VALUES (?,?,?,?,?)`
+	expiry := d.now().Add(ttl).UnixMicro()
+	if _, err := sqlitedb.Exec(ctx, d.db, stmt, key, owner, os.Getpid(), expiry, nonNil(state)); err != nil {
+		return fmt.Errorf("create lease %q: %w", key, err)
+	}
+	return nil
+}
+
+// Acquire acquires the lease on the provided key for the provided owner and
+// duration, if the lease is expired or already held by the owner. It returns
+// whether the owner holds the lease, which is false if there is no lease on the
+// key, e.g., because its holder released it.
+func (d *DB) Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	const stmt = `
+UPDATE leases SET owner = ?, pid = ?, expiry_unix_us = ?
+WHERE key = ? AND (owner = ? OR expiry_unix_us <= ?)`
+	now := d.now()
+	res, err := sqlitedb.Exec(ctx, d.db, stmt, owner, os.Getpid(), now.Add(ttl).UnixMicro(), key, owner, now.UnixMicro())
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %q: %w", key, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("acquire lease %q: %w", key, err)
+	}
+	return n == 1, nil
+}
+
+// Renew extends the lease on the provided key, held by the provided owner, by
+// the provided duration. It returns ErrLost if the owner doesn't hold the
+// lease.
+//
+// Note that an owner still holds an expired lease until another owner
+// acquires it.
+func (d *DB) Renew(ctx context.Context, key, owner string, ttl time.Duration) error {
+	const stmt = `UPDATE leases SET expiry_unix_us = ? WHERE key = ? AND owner = ?`
+	return d.update(ctx, "renew", key, stmt, d.now().Add(ttl).UnixMicro(), key, owner)
+}
+
+// Store stores the provided state in the lease on the provided key, held by
+// the provided owner. It returns ErrLost if the owner doesn't hold the lease.
+func (d *DB) Store(ctx context.Context, key, owner string, state []byte) error {
+	const stmt = `UPDATE leases SET state = ? WHERE key = ? AND owner = ?`
+	return d.update(ctx, "store", key, stmt, nonNil(state), key, owner)
+}
+
+// Release deletes the lease on the provided key, held by the provided owner,
+// along with its state. It returns ErrLost if the owner doesn't hold the
+// lease.
+func (d *DB) Release(ctx context.Context, key, owner string) error {
+	const stmt = `DELETE FROM leases WHERE key = ? AND owner = ?`
+	return d.update(ctx, "release", key, stmt, key, owner)
+}
+
+// update executes the provided statement, which updates the lease on the
+// provided key if it is held by a given owner.
+func (d *DB) update(ctx context.Context, op, key, stmt string, args ...any) error {
+	res, err := sqlitedb.Exec(ctx, d.db, stmt, args...)
+	if err != nil {
+		return fmt.Errorf("%s lease %q: %w", op, key, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s lease %q: %w", op, key, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%s lease %q: %w", op, key, ErrLost)
+	}
+	return nil
+}
+
+// Get returns the lease on the provided key, and whether it exists.
+func (d *DB) Get(ctx context.Context, key string) (Lease, bool, error) {
+	leases, err := d.query(ctx, "WHERE key = ?", key)
+	if err != nil || len(leases) == 0 {
+		return Lease{}, false, err
+	}
+	return leases[0], true, nil
+}
+
+// List returns the leases whose keys start with the provided prefix, ordered
+// by key.
+func (d *DB) List(ctx context.Context, prefix string) ([]Lease, error) {
+	return d.query(ctx, "WHERE substr(key, 1, ?) = ? ORDER BY key", len(prefix), prefix)
+}
+
+// query returns the leases selected by the provided clause.
+func (d *DB) query(ctx context.Context, clause string, args ...any) ([]Lease, error) {
+	rows, err := sqlitedb.Query(ctx, d.db, "SELECT * FROM leases "+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query leases: %w", err)
+	}
+	defer rows.Close()
+	var leases []Lease
+	for rows.Next() {
+		var l Lease
+		var micros int64
+		if err := rows.Scan(&l.Key, &l.Owner, &l.Pid, &micros, &l.State); err != nil {
+			return nil, fmt.Errorf("query leases: %w", err)
+		}
+		l.Expiry = time.UnixMicro(micros)
+		leases = append(leases, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query leases: %w", err)
+	}
+	return leases, nil
+}
+
+// nonNil returns state, or an empty slice if state is nil, since the state
+// column can't be NULL.
+func nonNil(state []byte) []byte {
+	if state == nil {
+		return []byte{}
+	}
+	return state
+}
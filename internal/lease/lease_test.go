@@ -0,0 +1,125 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lease
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLease(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenDB(ctx, filepath.Join(t.TempDir(), "lease.DB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// Control the clock.
+	now := time.UnixMicro(time.Now().UnixMicro())
+	db.now = func() time.Time { return now }
+	const ttl = 5 * time.Second
+
+	// The primary creates the lease.
+	if err := db.Create(ctx, "dep", "primary", ttl, []byte("v1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(ctx, "dep", "other", ttl, nil); err == nil {
+		t.Fatal("Create of an existing lease: unexpected success")
+	}
+
+	// The standby can't acquire a held lease.
+	if ok, err := db.Acquire(ctx, "dep", "standby", ttl); err != nil || ok {
+		t.Fatalf("Acquire of a held lease: got %t, %v; want false, nil", ok, err)
+	}
+
+	// The primary renews the lease and stores its state.
+	now = now.Add(ttl - time.Second)
+	if err := db.Renew(ctx, "dep", "primary", ttl); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Store(ctx, "dep", "primary", []byte("v2")); err != nil {
+		t.Fatal(err)
+	}
+	l, ok, err := db.Get(ctx, "dep")
+	if err != nil || !ok {
+		t.Fatalf("Get: got %t, %v; want true, nil", ok, err)
+	}
+	if l.Owner != "primary" || string(l.State) != "v2" || !l.Expiry.Equal(now.Add(ttl)) || l.Expired(now) {
+		t.Fatalf("Get: got %+v", l)
+	}
+
+	// The primary dies, and the standby acquires the expired lease, which
+	// keeps the primary's state.
+	now = now.Add(ttl)
+	if ok, err := db.Acquire(ctx, "dep", "standby", ttl); err != nil || !ok {
+		t.Fatalf("Acquire of an expired lease: got %t, %v; want true, nil", ok, err)
+	}
+	if l, _, _ := db.Get(ctx, "dep"); l.Owner != "standby" || string(l.State) != "v2" {
+		t.Fatalf("Get after Acquire: got %+v", l)
+	}
+
+	// The primary has lost the lease.
+	for name, err := range map[string]error{
+		"Renew":   db.Renew(ctx, "dep", "primary", ttl),
+		"Store":   db.Store(ctx, "dep", "primary", nil),
+		"Release": db.Release(ctx, "dep", "primary"),
+	} {
+		if !errors.Is(err, ErrLost) {
+			t.Errorf("%s by the old owner: got %v, want %v", name, err, ErrLost)
+		}
+	}
+
+	// The new primary releases the lease, which can't be acquired anymore.
+	if err := db.Release(ctx, "dep", "standby"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := db.Get(ctx, "dep"); err != nil || ok {
+		t.Fatalf("Get after Release: got %t, %v; want false, nil", ok, err)
+	}
+	now = now.Add(time.Hour)
+	if ok, err := db.Acquire(ctx, "dep", "other", ttl); err != nil || ok {
+		t.Fatalf("Acquire of a released lease: got %t, %v; want false, nil", ok, err)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	db, err := OpenDB(ctx, filepath.Join(t.TempDir(), "lease.DB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, key := range []string{"bbbb-2", "aaaa-2", "aaaa-1"} {
+		if err := db.Create(ctx, key, "owner", time.Minute, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leases, err := db.List(ctx, "aaaa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, l := range leases {
+		keys = append(keys, l.Key)
+	}
+	if len(keys) != 2 || keys[0] != "aaaa-1" || keys[1] != "aaaa-2" {
+		t.Fatalf("List(aaaa): got %v, want [aaaa-1 aaaa-2]", keys)
+	}
+}
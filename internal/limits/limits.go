@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package limits confines weavelets to CPU and memory limits.
+//
+// A [Group] confines the processes added to it to a set of [Limits]. On Linux,
+// a Group is a cgroup v2 created below the cgroup of the calling process, so
+// the calling process's cgroup must have the cpu and memory controllers
+// delegated to it. On Windows, a Group is a job object. Resource limits are
+// not supported on other platforms.
+//
+// When a process in a Group exceeds the Group's memory limit, the process is
+// killed (on Linux by the kernel's OOM killer, on Windows by failing its
+// allocations) and [Group.Exceeded] starts returning true, which lets a
+// deployer tell that a weavelet exited because it ran out of memory and
+// restart it.
+package limits
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
+)
+
+// Limits are the resource limits of a process.
+type Limits struct {
+	CPUs   float64 // maximum number of CPUs, e.g., 0.5; zero means no limit
+	Memory int64   // maximum memory in bytes; zero means no limit
+}
+
+// IsZero returns true if l doesn't limit anything.
+func (l Limits) IsZero() bool {
+	return l.CPUs == 0 && l.Memory == 0
+}
+
+// Validate returns an error if l is invalid.
+func (l Limits) Validate() error {
+	if l.CPUs < 0 {
+		return fmt.Errorf("negative cpus %v", l.CPUs)
+	}
+	if l.Memory < 0 {
+		return fmt.Errorf("negative memory %d", l.Memory)
+	}
+	return nil
+}
+
+// String returns a human-readable description of l, e.g., "1.5 CPUs, 512.0 MiB".
+func (l Limits) String() string {
+	switch {
+	case l.CPUs == 0 && l.Memory == 0:
+		return "no limits"
+	case l.Memory == 0:
+		return fmt.Sprintf("%v CPUs", l.CPUs)
+	case l.CPUs == 0:
+		return payloads.FormatSize(float64(l.Memory))
+	default:
+		return fmt.Sprintf("%v CPUs, %s", l.CPUs, payloads.FormatSize(float64(l.Memory)))
+	}
+}
+
+// Self returns the CPU and memory limits of the calling process, e.g., the
+// limits of the container it runs in. On Linux, these are the tightest limits
+// of the process's cgroup and its ancestors. On other platforms, Self returns
+// no limits.
+func Self() (Limits, error) {
+	return self()
+}
+
+// invalidName matches the characters that are not allowed in a group name.
+var invalidName = regexp.MustCompile(`[^a-zA-Z0-9_.-]+`)
+
+// New returns a new Group that confines the processes added to it to the
+// provided limits. The name identifies the group to the operating system
+// (e.g., as the name of a cgroup) and should be unique among the groups of
+// the calling process. Characters that aren't valid in a group name are
+// replaced with underscores.
+//
+// The returned Group should be closed once all of its processes have exited.
+func New(name string, l Limits) (*Group, error) {
+	if err := l.Validate(); err != nil {
+		return nil, err
+	}
+	return newGroup(invalidName.ReplaceAllString(name, "_"), l)
+}
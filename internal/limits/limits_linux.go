@@ -0,0 +1,274 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limits
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted.
+const cgroupRoot = "/sys/fs/cgroup"
+
+// cpuPeriod is the cpu.max period, in microseconds.
+const cpuPeriod = 100_000
+
+// leaf is the name of the cgroup to which the calling process moves itself if
+// its own cgroup can't delegate controllers to its children, because a cgroup
+// that contains processes can't do so.
+const leaf = "weaver"
+
+// Group is a cgroup v2 that confines its processes to a set of limits.
+type Group struct {
+	dir string // cgroup directory
+}
+
+func newGroup(name string, l Limits) (*Group, error) {
+	parent, err := selfCgroup()
+	if err != nil {
+		return nil, err
+	}
+	if err := enableControllers(parent, l); err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(parent, name)
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return nil, fmt.Errorf("create cgroup %q: %w", dir, err)
+	}
+	g := &Group{dir: dir}
+	if l.CPUs > 0 {
+		quota := int64(l.CPUs * cpuPeriod)
+		if err := g.write("cpu.max", fmt.Sprintf("%d %d", quota, cpuPeriod)); err != nil {
+			return nil, errors.Join(err, g.Close())
+		}
+	}
+	if l.Memory > 0 {
+		if err := g.write("memory.max", strconv.FormatInt(l.Memory, 10)); err != nil {
+			return nil, errors.Join(err, g.Close())
+		}
+		// Disable swap, so that a process that exceeds the memory limit is
+		// killed rather than slowed down. memory.swap.max doesn't exist if
+		// swap accounting is disabled, in which case there is nothing to do.
+		if err := g.write("memory.swap.max", "0"); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, errors.Join(err, g.Close())
+		}
+	}
+	return g, nil
+}
+
+// selfCgroup returns the directory of the cgroup of the calling process.
+func selfCgroup() (string, error) {
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers")); err != nil {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s: %w", cgroupRoot, err)
+	}
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", fmt.Errorf("read cgroup: %w", err)
+	}
+	// In the unified hierarchy, /proc/self/cgroup has a line of the form
+	// "0::/path/to/cgroup".
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			dir := filepath.Join(cgroupRoot, path)
+			if filepath.Base(dir) == leaf {
+				// The calling process has already moved itself to a leaf.
+				dir = filepath.Dir(dir)
+			}
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("cgroup v2 path not found in /proc/self/cgroup")
+}
+
+func self() (Limits, error) {
+	dir, err := selfCgroup()
+	if err != nil {
+		return Limits{}, err
+	}
+	// The limits of a cgroup also confine its descendants, so walk up to the
+	// root and keep the tightest limits.
+	var l Limits
+	for ; strings.HasPrefix(dir, cgroupRoot+"/"); dir = filepath.Dir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+			cpus, err := parseCPUMax(string(data))
+			if err != nil {
+				return Limits{}, err
+			}
+			if cpus > 0 && (l.CPUs == 0 || cpus < l.CPUs) {
+				l.CPUs = cpus
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return Limits{}, err
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "memory.max")); err == nil {
+			memory, err := parseMemoryMax(string(data))
+			if err != nil {
+				return Limits{}, err
+			}
+			if memory > 0 && (l.Memory == 0 || memory < l.Memory) {
+				l.Memory = memory
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return Limits{}, err
+		}
+	}
+	return l, nil
+}
+
+// parseCPUMax parses the contents of a cpu.max file, e.g., "50000 100000",
+// and returns the number of CPUs it allows, or zero if it allows all of them.
+func parseCPUMax(data string) (float64, error) {
+	quota, period, ok := strings.Cut(strings.TrimSpace(data), " ")
+	if !ok {
+		return 0, fmt.Errorf("parse cpu.max %q: missing period", data)
+	}
+	if quota == "max" {
+		return 0, nil
+	}
+	q, err := strconv.ParseInt(quota, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse cpu.max %q: %w", data, err)
+	}
+	p, err := strconv.ParseInt(period, 10, 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("parse cpu.max %q: invalid period", data)
+	}
+	return float64(q) / float64(p), nil
+}
+
+// parseMemoryMax parses the contents of a memory.max file, e.g., "536870912",
+// and returns the number of bytes it allows, or zero if it doesn't limit
+// memory.
+func parseMemoryMax(data string) (int64, error) {
+	data = strings.TrimSpace(data)
+	if data == "max" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(data, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse memory.max %q: %w", data, err)
+	}
+	return n, nil
+}
+
+// enableControllers enables the controllers needed to enforce the provided
+// limits in the children of the provided cgroup.
+func enableControllers(dir string, l Limits) error {
+	var needed []string
+	if l.CPUs > 0 {
+		needed = append(needed, "cpu")
+	}
+	if l.Memory > 0 {
+		needed = append(needed, "memory")
+	}
+	file := filepath.Join(dir, "cgroup.subtree_control")
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read %q: %w", file, err)
+	}
+	enabled := strings.Fields(string(data))
+	for _, c := range needed {
+		if slices.Contains(enabled, c) {
+			continue
+		}
+		err := os.WriteFile(file, []byte("+"+c), 0o644)
+		if errors.Is(err, syscall.EBUSY) {
+			// The cgroup contains processes, including the calling process.
+			// Move the calling process to a leaf cgroup and try again.
+			if err := moveToLeaf(dir); err != nil {
+				return err
+			}
+			err = os.WriteFile(file, []byte("+"+c), 0o644)
+		}
+		if err != nil {
+			return fmt.Errorf("enable the %s controller in cgroup %q (is the cgroup delegated to this user?): %w", c, dir, err)
+		}
+	}
+	return nil
+}
+
+// moveToLeaf moves the calling process from the provided cgroup to a child
+// leaf cgroup.
+func moveToLeaf(dir string) error {
+	dir = filepath.Join(dir, leaf)
+	if err := os.Mkdir(dir, 0o755); err != nil && !errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("create cgroup %q: %w", dir, err)
+	}
+	file := filepath.Join(dir, "cgroup.procs")
+	if err := os.WriteFile(file, []byte(strconv.Itoa(os.Getpid())), 0o644); err != nil {
+		return fmt.Errorf("move to cgroup %q: %w", dir, err)
+	}
+	return nil
+}
+
+// Add places the process with the provided pid in the group.
+func (g *Group) Add(pid int) error {
+	return g.write("cgroup.procs", strconv.Itoa(pid))
+}
+
+// Exceeded returns true if a process in the group was killed because the
+// group exceeded its memory limit.
+func (g *Group) Exceeded() (bool, error) {
+	f, err := os.Open(filepath.Join(g.dir, "memory.events"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// The memory controller is not enabled.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	// memory.events contains lines of the form "oom_kill 1".
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := bytes.Cut(scanner.Bytes(), []byte(" "))
+		if !ok || string(key) != "oom_kill" {
+			continue
+		}
+		n, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("parse memory.events: %w", err)
+		}
+		return n > 0, nil
+	}
+	return false, scanner.Err()
+}
+
+// Close removes the group. All processes in the group must have exited.
+func (g *Group) Close() error {
+	if err := os.Remove(g.dir); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("remove cgroup %q: %w", g.dir, err)
+	}
+	return nil
+}
+
+// write writes the provided value to the provided cgroup interface file.
+func (g *Group) write(file, value string) error {
+	path := filepath.Join(g.dir, file)
+	if err := os.WriteFile(path, []byte(value), 0o644); err != nil {
+		return fmt.Errorf("write %q: %w", path, err)
+	}
+	return nil
+}
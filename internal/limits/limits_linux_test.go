@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limits
+
+import "testing"
+
+func TestParseCPUMax(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want float64
+	}{
+		{"max 100000\n", 0},
+		{"50000 100000\n", 0.5},
+		{"200000 100000", 2},
+	} {
+		got, err := parseCPUMax(test.data)
+		if err != nil {
+			t.Errorf("parseCPUMax(%q): %v", test.data, err)
+		} else if got != test.want {
+			t.Errorf("parseCPUMax(%q): got %v, want %v", test.data, got, test.want)
+		}
+	}
+	for _, data := range []string{"", "100000", "x 100000", "100000 0"} {
+		if _, err := parseCPUMax(data); err == nil {
+			t.Errorf("parseCPUMax(%q): unexpected success", data)
+		}
+	}
+}
+
+func TestParseMemoryMax(t *testing.T) {
+	for _, test := range []struct {
+		data string
+		want int64
+	}{
+		{"max\n", 0},
+		{"536870912\n", 512 << 20},
+	} {
+		got, err := parseMemoryMax(test.data)
+		if err != nil {
+			t.Errorf("parseMemoryMax(%q): %v", test.data, err)
+		} else if got != test.want {
+			t.Errorf("parseMemoryMax(%q): got %v, want %v", test.data, got, test.want)
+		}
+	}
+	if _, err := parseMemoryMax("lots"); err == nil {
+		t.Error(`parseMemoryMax("lots"): unexpected success`)
+	}
+}
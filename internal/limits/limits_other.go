@@ -0,0 +1,41 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux && !windows
+
+package limits
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Group is not supported on this platform.
+type Group struct{}
+
+func newGroup(string, Limits) (*Group, error) {
+	return nil, fmt.Errorf("resource limits are not supported on %s", runtime.GOOS)
+}
+
+func self() (Limits, error) { return Limits{}, nil }
+
+// Add places the process with the provided pid in the group.
+func (g *Group) Add(int) error { return nil }
+
+// Exceeded returns true if a process in the group was killed because the
+// group exceeded its memory limit.
+func (g *Group) Exceeded() (bool, error) { return false, nil }
+
+// Close releases the group.
+func (g *Group) Close() error { return nil }
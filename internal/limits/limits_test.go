@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limits
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+)
+
+// allocateKey is the environment variable that tells the test binary to act
+// as a process that allocates memory until it is killed.
+const allocateKey = "LIMITS_TEST_ALLOCATE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(allocateKey) != "" {
+		allocate()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// allocate waits for a byte on stdin and then allocates memory indefinitely.
+func allocate() {
+	if _, err := os.Stdin.Read(make([]byte, 1)); err != nil {
+		panic(err)
+	}
+	var chunks [][]byte
+	for {
+		chunk := make([]byte, 1<<20)
+		for i := range chunk {
+			chunk[i] = 1 // touch every page
+		}
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, test := range []struct {
+		limits Limits
+		want   string
+	}{
+		{Limits{}, "no limits"},
+		{Limits{CPUs: 1.5}, "1.5 CPUs"},
+		{Limits{Memory: 512 << 20}, "512.0 MiB"},
+		{Limits{CPUs: 2, Memory: 1 << 30}, "2 CPUs, 1.0 GiB"},
+	} {
+		if got := test.limits.String(); got != test.want {
+			t.Errorf("%#v.String(): got %q, want %q", test.limits, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		limits Limits
+		valid  bool
+	}{
+		{Limits{}, true},
+		{Limits{CPUs: 0.5, Memory: 1 << 20}, true},
+		{Limits{CPUs: -1}, false},
+		{Limits{Memory: -1}, false},
+	} {
+		err := test.limits.Validate()
+		if test.valid && err != nil {
+			t.Errorf("%#v.Validate(): unexpected error: %v", test.limits, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%#v.Validate(): unexpected success", test.limits)
+		}
+	}
+}
+
+func TestExceeded(t *testing.T) {
+	g, err := New("weaver-limits-test-"+t.Name(), Limits{Memory: 64 << 20})
+	if err != nil {
+		t.Skipf("resource limits unavailable: %v", err)
+	}
+	defer g.Close()
+
+	// Start a process that allocates memory once it's in the group.
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), allocateKey+"=1")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Add(cmd.Process.Pid); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		t.Fatal(err)
+	}
+	if exceeded, err := g.Exceeded(); err != nil || exceeded {
+		t.Fatalf("Exceeded() before allocating: got %v, %v; want false, nil", exceeded, err)
+	}
+	if _, err := stdin.Write([]byte("\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The process should be killed for exceeding the memory limit.
+	if err := cmd.Wait(); err == nil {
+		t.Fatal("process exited successfully; want it to be killed")
+	}
+	if exceeded, err := g.Exceeded(); err != nil || !exceeded {
+		t.Fatalf("Exceeded() after allocating: got %v, %v; want true, nil", exceeded, err)
+	}
+}
+
+func TestSelf(t *testing.T) {
+	l, err := Self()
+	if err != nil {
+		t.Skipf("cgroup limits unavailable: %v", err)
+	}
+	if err := l.Validate(); err != nil {
+		t.Fatalf("Self(): got invalid limits %v: %v", l, err)
+	}
+}
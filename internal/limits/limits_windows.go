@@ -0,0 +1,151 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package limits
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// The following definitions are missing from golang.org/x/sys/windows.
+const (
+	jobObjectCPURateControlEnable  = 0x1
+	jobObjectCPURateControlHardCap = 0x4
+	jobObjectMsgJobMemoryLimit     = 10
+)
+
+// jobObjectCPURateControlInformation is JOBOBJECT_CPU_RATE_CONTROL_INFORMATION.
+type jobObjectCPURateControlInformation struct {
+	ControlFlags uint32
+	CPURate      uint32 // in hundredths of a percent of all CPUs
+}
+
+// jobObjectAssociateCompletionPort is JOBOBJECT_ASSOCIATE_COMPLETION_PORT.
+type jobObjectAssociateCompletionPort struct {
+	CompletionKey  uintptr
+	CompletionPort windows.Handle
+}
+
+// Group is a job object that confines its processes to a set of limits.
+type Group struct {
+	job      windows.Handle
+	port     windows.Handle // receives the job's notifications
+	exceeded atomic.Bool    // has the job exceeded its memory limit?
+}
+
+// self returns no limits; the limits of the job object containing the calling
+// process, if any, aren't inspected.
+func self() (Limits, error) { return Limits{}, nil }
+
+func newGroup(name string, l Limits) (*Group, error) {
+	utf16Name, err := windows.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	job, err := windows.CreateJobObject(nil, utf16Name)
+	if err != nil {
+		return nil, fmt.Errorf("create job object %q: %w", name, err)
+	}
+	g := &Group{job: job}
+
+	// Kill the processes in the job when the job is closed, and set the
+	// memory limit.
+	var info windows.JOBOBJECT_EXTENDED_LIMIT_INFORMATION
+	info.BasicLimitInformation.LimitFlags = windows.JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE
+	if l.Memory > 0 {
+		info.BasicLimitInformation.LimitFlags |= windows.JOB_OBJECT_LIMIT_JOB_MEMORY
+		info.JobMemoryLimit = uintptr(l.Memory)
+	}
+	if _, err := windows.SetInformationJobObject(job, windows.JobObjectExtendedLimitInformation, uintptr(unsafe.Pointer(&info)), uint32(unsafe.Sizeof(info))); err != nil {
+		g.Close()
+		return nil, fmt.Errorf("set job object limits: %w", err)
+	}
+
+	// Set the CPU limit, as a fraction of all CPUs.
+	if l.CPUs > 0 {
+		rate := jobObjectCPURateControlInformation{
+			ControlFlags: jobObjectCPURateControlEnable | jobObjectCPURateControlHardCap,
+			CPURate:      uint32(min(10000, max(1, l.CPUs/float64(runtime.NumCPU())*10000))),
+		}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectCpuRateControlInformation, uintptr(unsafe.Pointer(&rate)), uint32(unsafe.Sizeof(rate))); err != nil {
+			g.Close()
+			return nil, fmt.Errorf("set job object CPU rate: %w", err)
+		}
+	}
+
+	// Receive a notification when the job exceeds its memory limit.
+	if l.Memory > 0 {
+		port, err := windows.CreateIoCompletionPort(windows.InvalidHandle, 0, 0, 1)
+		if err != nil {
+			g.Close()
+			return nil, fmt.Errorf("create completion port: %w", err)
+		}
+		g.port = port
+		assoc := jobObjectAssociateCompletionPort{CompletionKey: uintptr(job), CompletionPort: port}
+		if _, err := windows.SetInformationJobObject(job, windows.JobObjectAssociateCompletionPortInformation, uintptr(unsafe.Pointer(&assoc)), uint32(unsafe.Sizeof(assoc))); err != nil {
+			g.Close()
+			return nil, fmt.Errorf("associate completion port: %w", err)
+		}
+		go g.watch()
+	}
+	return g, nil
+}
+
+// watch records the job's memory limit notifications. It returns when the
+// completion port is closed.
+func (g *Group) watch() {
+	for {
+		var msg uint32
+		var key uintptr
+		var overlapped *windows.Overlapped
+		if err := windows.GetQueuedCompletionStatus(g.port, &msg, &key, &overlapped, windows.INFINITE); err != nil {
+			return
+		}
+		if msg == jobObjectMsgJobMemoryLimit {
+			g.exceeded.Store(true)
+		}
+	}
+}
+
+// Add places the process with the provided pid in the group.
+func (g *Group) Add(pid int) error {
+	process, err := windows.OpenProcess(windows.PROCESS_SET_QUOTA|windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("open process %d: %w", pid, err)
+	}
+	defer windows.CloseHandle(process)
+	if err := windows.AssignProcessToJobObject(g.job, process); err != nil {
+		return fmt.Errorf("assign process %d to job object: %w", pid, err)
+	}
+	return nil
+}
+
+// Exceeded returns true if a process in the group was killed because the
+// group exceeded its memory limit.
+func (g *Group) Exceeded() (bool, error) {
+	return g.exceeded.Load(), nil
+}
+
+// Close releases the group, killing any processes still in it.
+func (g *Group) Close() error {
+	if g.port != 0 {
+		windows.CloseHandle(g.port)
+	}
+	return windows.CloseHandle(g.job)
+}
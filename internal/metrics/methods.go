@@ -0,0 +1,151 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+)
+
+// MethodKey identifies the calls made by a caller component to a component
+// method. Aggregations may leave fields empty to sum over them.
+type MethodKey struct {
+	Caller    string
+	Component string
+	Method    string
+}
+
+// MethodCounts holds cumulative method metrics.
+type MethodCounts struct {
+	Calls        float64
+	Errors       float64
+	LatencySum   float64   // sum of call latencies, in microseconds
+	LatencyCount float64   // number of call latencies
+	Bounds       []float64 // latency histogram bounds, in microseconds
+	Latencies    []float64 // latency histogram counts
+}
+
+// AggregateMethods sums the method metrics in the provided snapshots by the
+// key that by returns for every metric's caller, component, and method. The
+// metrics of calls from or to system components (see IsSystem), and metrics
+// without a component, are skipped.
+func AggregateMethods(snapshots []*metrics.MetricSnapshot, by func(MethodKey) MethodKey) map[MethodKey]*MethodCounts {
+	methods := map[MethodKey]*MethodCounts{}
+	for _, m := range snapshots {
+		switch m.Name {
+		case MethodCountsName, MethodErrorsName, MethodLatenciesName:
+		default:
+			continue
+		}
+		caller, component := m.Labels["caller"], m.Labels["component"]
+		if component == "" || IsSystem(caller) || IsSystem(component) {
+			continue
+		}
+		k := by(MethodKey{Caller: caller, Component: component, Method: m.Labels["method"]})
+		c, ok := methods[k]
+		if !ok {
+			c = &MethodCounts{}
+			methods[k] = c
+		}
+		switch m.Name {
+		case MethodCountsName:
+			c.Calls += m.Value
+		case MethodErrorsName:
+			c.Errors += m.Value
+		case MethodLatenciesName:
+			c.LatencySum += m.Value
+			if c.Latencies == nil {
+				c.Bounds = m.Bounds
+				c.Latencies = make([]float64, len(m.Counts))
+			}
+			for _, n := range m.Counts {
+				c.LatencyCount += float64(n)
+			}
+			if len(m.Counts) != len(c.Latencies) {
+				// Histograms with different bounds can't be summed.
+				continue
+			}
+			for i, n := range m.Counts {
+				c.Latencies[i] += float64(n)
+			}
+		}
+	}
+	return methods
+}
+
+// Sub returns the increase of the counts from prev to c. prev may be nil. If
+// prev's latency histogram has different bounds, it is treated as empty.
+func (c *MethodCounts) Sub(prev *MethodCounts) *MethodCounts {
+	if prev == nil {
+		prev = &MethodCounts{}
+	}
+	d := &MethodCounts{
+		Calls:        Delta(c.Calls, prev.Calls),
+		Errors:       Delta(c.Errors, prev.Errors),
+		LatencySum:   Delta(c.LatencySum, prev.LatencySum),
+		LatencyCount: Delta(c.LatencyCount, prev.LatencyCount),
+		Bounds:       c.Bounds,
+	}
+	if c.Latencies != nil {
+		d.Latencies = make([]float64, len(c.Latencies))
+		for i, n := range c.Latencies {
+			var pn float64
+			if len(prev.Latencies) == len(c.Latencies) {
+				pn = prev.Latencies[i]
+			}
+			d.Latencies[i] = Delta(n, pn)
+		}
+	}
+	return d
+}
+
+// Add adds the provided counts to c. If other's latency histogram has
+// different bounds than c's, it is not added.
+func (c *MethodCounts) Add(other *MethodCounts) {
+	c.Calls += other.Calls
+	c.Errors += other.Errors
+	c.LatencySum += other.LatencySum
+	c.LatencyCount += other.LatencyCount
+	if other.Latencies == nil {
+		return
+	}
+	if c.Latencies == nil {
+		c.Bounds = other.Bounds
+		c.Latencies = make([]float64, len(other.Latencies))
+	}
+	if len(other.Latencies) != len(c.Latencies) {
+		return
+	}
+	for i, n := range other.Latencies {
+		c.Latencies[i] += n
+	}
+}
+
+// Delta returns the increase of a cumulative counter from prev to cur. A
+// counter that decreased, e.g., because the process that exported it
+// restarted, is treated as if it restarted from zero.
+func Delta(cur, prev float64) float64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
+
+// IsSystem returns whether the named component is a runtime-internal
+// component, i.e., the weavelet or deployer control component. Calls to and
+// from system components are usually excluded from method metrics reports.
+func IsSystem(component string) bool {
+	return component == control.WeaveletPath || component == control.DeployerPath
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+// methodSnapshots returns the method metric snapshots of the provided calls
+// from caller to component.method, as exported by one process.
+func methodSnapshots(caller, component, method string, calls, errors float64, latencies ...uint64) []*metrics.MetricSnapshot {
+	labels := map[string]string{"caller": caller, "component": component, "method": method}
+	return []*metrics.MetricSnapshot{
+		{Name: MethodCountsName, Labels: labels, Value: calls},
+		{Name: MethodErrorsName, Labels: labels, Value: errors},
+		{Name: MethodLatenciesName, Labels: labels, Value: 10 * calls, Bounds: []float64{10}, Counts: latencies},
+	}
+}
+
+func TestAggregateMethods(t *testing.T) {
+	var ms []*metrics.MetricSnapshot
+	ms = append(ms, methodSnapshots("main", "a/B", "M", 4, 1, 3, 1)...)
+	ms = append(ms, methodSnapshots("main", "a/B", "M", 2, 0, 2, 0)...) // another process
+	ms = append(ms, methodSnapshots("a/C", "a/B", "N", 1, 1, 0, 1)...)
+	ms = append(ms, methodSnapshots("main", "a/B", "M", 1, 0, 1)...) // different bounds
+	ms = append(ms, methodSnapshots(control.DeployerPath, control.WeaveletPath, "M", 9, 9, 9, 0)...)
+	ms = append(ms, methodSnapshots("main", "", "M", 9, 9, 9, 0)...)
+	ms = append(ms, &metrics.MetricSnapshot{Name: "other", Labels: map[string]string{"component": "a/B"}, Value: 9})
+
+	byMethod := func(k MethodKey) MethodKey { return MethodKey{Component: k.Component, Method: k.Method} }
+	got := AggregateMethods(ms, byMethod)
+	want := map[MethodKey]*MethodCounts{
+		{Component: "a/B", Method: "M"}: {Calls: 7, Errors: 1, LatencySum: 70, LatencyCount: 7, Bounds: []float64{10}, Latencies: []float64{5, 1}},
+		{Component: "a/B", Method: "N"}: {Calls: 1, Errors: 1, LatencySum: 10, LatencyCount: 1, Bounds: []float64{10}, Latencies: []float64{0, 1}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("AggregateMethods (-want +got):\n%s", diff)
+	}
+}
+
+func TestMethodCountsSub(t *testing.T) {
+	cur := &MethodCounts{Calls: 10, Errors: 2, LatencySum: 100, LatencyCount: 10, Bounds: []float64{10}, Latencies: []float64{8, 2}}
+	for _, test := range []struct {
+		name string
+		prev *MethodCounts
+		want *MethodCounts
+	}{
+		{"Nil", nil, cur},
+		{"Increase", &MethodCounts{Calls: 4, Errors: 1, LatencySum: 40, LatencyCount: 4, Bounds: []float64{10}, Latencies: []float64{3, 1}},
+			&MethodCounts{Calls: 6, Errors: 1, LatencySum: 60, LatencyCount: 6, Bounds: []float64{10}, Latencies: []float64{5, 1}}},
+		{"Restart", &MethodCounts{Calls: 20, Errors: 1, LatencySum: 200, LatencyCount: 20, Bounds: []float64{10}, Latencies: []float64{10, 10}},
+			&MethodCounts{Calls: 10, Errors: 1, LatencySum: 100, LatencyCount: 10, Bounds: []float64{10}, Latencies: []float64{8, 2}}},
+		{"DifferentBounds", &MethodCounts{Latencies: []float64{1}}, cur},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if diff := cmp.Diff(test.want, cur.Sub(test.prev)); diff != "" {
+				t.Fatalf("Sub (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMethodCountsAdd(t *testing.T) {
+	var c MethodCounts
+	c.Add(&MethodCounts{Calls: 1, Errors: 1, Bounds: []float64{10}, Latencies: []float64{1, 0}})
+	c.Add(&MethodCounts{Calls: 2, Bounds: []float64{10}, Latencies: []float64{1, 1}})
+	c.Add(&MethodCounts{Calls: 4, Latencies: []float64{4}}) // different bounds
+	want := MethodCounts{Calls: 7, Errors: 1, Bounds: []float64{10}, Latencies: []float64{2, 1}}
+	if diff := cmp.Diff(want, c); diff != "" {
+		t.Fatalf("Add (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"cmp"
+	"math"
+)
+
+// HistogramPercentile returns an estimate of the pth percentile, with
+// 0 < p <= 1, of the values in the histogram with the provided bounds and
+// counts, interpolating linearly within the bucket that holds the percentile.
+// counts[i] is the number of values in [bounds[i-1], bounds[i]), and the last
+// count is the number of values larger than every bound. HistogramPercentile
+// returns 0 if the histogram is empty.
+func HistogramPercentile[T uint64 | float64](bounds []float64, counts []T, p float64) float64 {
+	var total float64
+	for _, c := range counts {
+		total += float64(c)
+	}
+	rank := p * total
+	var seen float64
+	for i, c := range counts {
+		if c == 0 || seen+float64(c) < rank {
+			seen += float64(c)
+			continue
+		}
+		lo := 0.0
+		if i > 0 {
+			lo = bounds[i-1]
+		}
+		if i == len(bounds) {
+			// The percentile is larger than every bound.
+			return lo
+		}
+		return lo + (bounds[i]-lo)*(rank-seen)/float64(c)
+	}
+	return 0
+}
+
+// NearestRank returns the pth percentile, with 0 < p <= 1, of the provided
+// sorted values, using the nearest-rank method. It returns the zero value if
+// there are no values.
+func NearestRank[T cmp.Ordered](sorted []T, p float64) T {
+	if len(sorted) == 0 {
+		var zero T
+		return zero
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(rank, 0), len(sorted)-1)]
+}
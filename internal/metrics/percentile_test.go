@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	bounds := []float64{10, 20, 40}
+	for _, test := range []struct {
+		name   string
+		counts []uint64
+		p      float64
+		want   float64
+	}{
+		{"Empty", []uint64{0, 0, 0, 0}, 0.5, 0},
+		{"FirstBucket", []uint64{10, 0, 0, 0}, 0.5, 5},
+		{"Interpolated", []uint64{5, 10, 0, 0}, 0.5, 12.5},
+		{"LastBound", []uint64{0, 0, 4, 0}, 1, 40},
+		{"Overflow", []uint64{1, 0, 0, 9}, 0.99, 40},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := HistogramPercentile(bounds, test.counts, test.p); got != test.want {
+				t.Errorf("uint64 counts: got %v, want %v", got, test.want)
+			}
+			floats := make([]float64, len(test.counts))
+			for i, c := range test.counts {
+				floats[i] = float64(c)
+			}
+			if got := HistogramPercentile(bounds, floats, test.p); got != test.want {
+				t.Errorf("float64 counts: got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNearestRank(t *testing.T) {
+	sorted := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	for _, test := range []struct {
+		p    float64
+		want time.Duration
+	}{
+		{0.01, 1},
+		{0.5, 5},
+		{0.55, 6},
+		{0.99, 10},
+		{1, 10},
+	} {
+		if got := NearestRank(sorted, test.p); got != test.want {
+			t.Errorf("NearestRank(%v): got %v, want %v", test.p, got, test.want)
+		}
+	}
+	if got := NearestRank([]time.Duration(nil), 0.5); got != 0 {
+		t.Errorf("NearestRank(nil): got %v, want 0", got)
+	}
+}
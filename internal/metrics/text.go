@@ -0,0 +1,196 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// A Sample is a metric sample in the Prometheus text format [1], like the
+// samples produced by runtime/prometheus.
+//
+// [1]: https://github.com/prometheus/docs/blob/main/content/docs/instrumenting/exposition_formats.md#text-based-format
+type Sample struct {
+	Name   string            // sample name, including any suffix (e.g., "_sum")
+	Labels map[string]string // sample labels
+	Value  float64           // sample value
+}
+
+// ParseSamples parses the samples in r, which holds metrics in the Prometheus
+// text format. Comments are ignored.
+func ParseSamples(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sample, err := parseSample(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineno, err)
+		}
+		samples = append(samples, sample)
+	}
+	return samples, scanner.Err()
+}
+
+// parseSample parses a sample line of the form
+// `name{label="value",...} value [timestamp]`.
+func parseSample(line string) (Sample, error) {
+	end := strings.IndexAny(line, "{ ")
+	if end <= 0 {
+		return Sample{}, fmt.Errorf("malformed sample %q", line)
+	}
+	name, rest := line[:end], line[end:]
+
+	labels := map[string]string{}
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " ,")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.Index(rest, `="`)
+			if eq <= 0 {
+				return Sample{}, fmt.Errorf("malformed labels in %q", line)
+			}
+			key := strings.TrimSpace(rest[:eq])
+			rest = rest[eq+2:]
+			var value strings.Builder
+			closed := false
+			for i := 0; i < len(rest); i++ {
+				c := rest[i]
+				if c == '\\' && i+1 < len(rest) {
+					i++
+					switch rest[i] {
+					case 'n':
+						value.WriteByte('\n')
+					default:
+						value.WriteByte(rest[i])
+					}
+					continue
+				}
+				if c == '"' {
+					rest = rest[i+1:]
+					closed = true
+					break
+				}
+				value.WriteByte(c)
+			}
+			if !closed {
+				return Sample{}, fmt.Errorf("unterminated label value in %q", line)
+			}
+			labels[key] = value.String()
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return Sample{}, fmt.Errorf("missing value in %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Sample{}, fmt.Errorf("bad value in %q: %w", line, err)
+	}
+	return Sample{Name: name, Labels: labels, Value: value}, nil
+}
+
+// Histograms reconstructs the histograms named name from their "_bucket" and
+// "_sum" samples, like the samples produced by runtime/prometheus for
+// histogram metrics. Samples with the same labels, other than the "le" label
+// of the buckets, belong to the same histogram.
+func Histograms(samples []Sample, name string) ([]*metrics.MetricSnapshot, error) {
+	type bucket struct{ le, cumulative float64 }
+	type histogram struct {
+		labels  map[string]string
+		sum     float64
+		buckets []bucket
+	}
+	byLabels := map[string]*histogram{}
+	var keys []string
+	for _, s := range samples {
+		if s.Name != name+"_bucket" && s.Name != name+"_sum" {
+			continue
+		}
+		labels := map[string]string{}
+		var pairs []string
+		for k, v := range s.Labels {
+			if k == "le" {
+				continue
+			}
+			labels[k] = v
+			pairs = append(pairs, strconv.Quote(k)+"="+strconv.Quote(v))
+		}
+		sort.Strings(pairs)
+		key := strings.Join(pairs, ",")
+		h, ok := byLabels[key]
+		if !ok {
+			h = &histogram{labels: labels}
+			byLabels[key] = h
+			keys = append(keys, key)
+		}
+		if s.Name == name+"_sum" {
+			h.sum = s.Value
+			continue
+		}
+		le, err := strconv.ParseFloat(s.Labels["le"], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s: bad bucket bound %q: %w", name, s.Labels["le"], err)
+		}
+		h.buckets = append(h.buckets, bucket{le, s.Value})
+	}
+
+	var snapshots []*metrics.MetricSnapshot
+	for _, key := range keys {
+		h := byLabels[key]
+		sort.Slice(h.buckets, func(i, j int) bool { return h.buckets[i].le < h.buckets[j].le })
+		m := &metrics.MetricSnapshot{
+			Name:   name,
+			Type:   protos.MetricType_HISTOGRAM,
+			Labels: h.labels,
+			Value:  h.sum,
+		}
+		prev := 0.0
+		for _, b := range h.buckets {
+			if b.cumulative < prev {
+				return nil, fmt.Errorf("%s: decreasing cumulative bucket counts", name)
+			}
+			if !math.IsInf(b.le, 1) {
+				m.Bounds = append(m.Bounds, b.le)
+			}
+			m.Counts = append(m.Counts, uint64(b.cumulative-prev))
+			prev = b.cumulative
+		}
+		if len(m.Counts) == len(m.Bounds) {
+			// There is no +Inf bucket.
+			m.Counts = append(m.Counts, 0)
+		}
+		snapshots = append(snapshots, m)
+	}
+	return snapshots, nil
+}
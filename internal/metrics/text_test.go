@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestParseSamples(t *testing.T) {
+	const text = `# HELP m A metric.
+# TYPE m counter
+m 1
+
+m_sum{a="x\"y",b="1\\2"} 42.5 1700000000
+m_bucket{le="+Inf"} 3
+`
+	got, err := ParseSamples(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sample{
+		{Name: "m", Labels: map[string]string{}, Value: 1},
+		{Name: "m_sum", Labels: map[string]string{"a": `x"y`, "b": `1\2`}, Value: 42.5},
+		{Name: "m_bucket", Labels: map[string]string{"le": "+Inf"}, Value: 3},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ParseSamples (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSamplesErrors(t *testing.T) {
+	for _, bad := range []string{`{a="b"} 1`, `m{a="b} 1`, `m{a} 1`, `m{a="b"}`, `m{a="b"} x`} {
+		if _, err := ParseSamples(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseSamples(%q): unexpected success", bad)
+		}
+	}
+}
+
+func TestHistograms(t *testing.T) {
+	const text = `# TYPE c counter
+c{component="A"} 1
+
+# TYPE h histogram
+h_bucket{component="A",le="10"} 1
+h_bucket{component="A",le="100"} 1
+h_bucket{component="A",le="1000"} 6
+h_bucket{component="A",le="+Inf"} 8
+h_sum{component="A"} 1234
+h_count{component="A"} 8
+
+h_bucket{component="B",le="10"} 1
+h_bucket{component="B",le="100"} 1
+h_bucket{component="B",le="1000"} 1
+h_bucket{component="B",le="+Inf"} 1
+h_sum{component="B"} 5
+h_count{component="B"} 1
+`
+	samples, err := ParseSamples(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Histograms(samples, "h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*metrics.MetricSnapshot{
+		{
+			Name:   "h",
+			Type:   protos.MetricType_HISTOGRAM,
+			Labels: map[string]string{"component": "A"},
+			Value:  1234,
+			Bounds: []float64{10, 100, 1000},
+			Counts: []uint64{1, 0, 5, 2},
+		},
+		{
+			Name:   "h",
+			Type:   protos.MetricType_HISTOGRAM,
+			Labels: map[string]string{"component": "B"},
+			Value:  5,
+			Bounds: []float64{10, 100, 1000},
+			Counts: []uint64{1, 0, 0, 0},
+		},
+	}
+	sortByComponent := cmpopts.SortSlices(func(x, y *metrics.MetricSnapshot) bool {
+		return x.Labels["component"] < y.Labels["component"]
+	})
+	if diff := cmp.Diff(want, got, sortByComponent); diff != "" {
+		t.Fatalf("Histograms (-want +got):\n%s", diff)
+	}
+
+	// Cumulative bucket counts can't decrease.
+	const bad = `h_bucket{le="10"} 2
+h_bucket{le="100"} 1
+`
+	samples, err = ParseSamples(strings.NewReader(bad))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Histograms(samples, "h"); err == nil {
+		t.Fatal("Histograms with decreasing counts: unexpected success")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package call
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// # Blobs
+//
+// Large call arguments and results are transferred out of band, as blobs, to
+// avoid delaying the other calls multiplexed on a connection while they are
+// written and read (head-of-line blocking).
+//
+// A client that wants to send an argument larger than ClientOptions.BlobThreshold
+// dials a new network connection, a side channel, to the server and sends the
+// argument in a putBlobMessage. The server stores it in its blob store and
+// replies with a blobAckMessage. The client then sends a requestBlobMessage
+// with the id of the blob, rather than the argument, over the shared
+// connection, and the server takes the argument out of its blob store.
+//
+// Conversely, a server that wants to send a result larger than
+// ServerOptions.BlobThreshold stores it in its blob store and sends a
+// responseBlobMessage with the id of the blob over the shared connection. The
+// client then dials a side channel to the server and fetches the result with
+// a getBlobMessage, to which the server replies with a blobDataMessage.
+//
+// Blobs are only used if both sides of a connection speak blobVersion or
+// later. Blobs that are never taken out of a blob store (e.g., because the
+// call was cancelled) expire after blobTTL.
+
+// blobTTL is how long a blob stays in a blob store if it isn't taken out.
+const blobTTL = time.Minute
+
+// blobID identifies a blob.
+type blobID [16]byte
+
+// newBlobID returns a new random blob id.
+func newBlobID() blobID {
+	var id blobID
+	if _, err := rand.Read(id[:]); err != nil {
+		panic(fmt.Errorf("new blob id: %w", err))
+	}
+	return id
+}
+
+// blobStore stores blobs until they are taken out or expire.
+type blobStore struct {
+	mu    sync.Mutex
+	blobs map[blobID]blob
+}
+
+// blob is a blob in a blob store.
+type blob struct {
+	data    []byte
+	expires time.Time
+}
+
+// blobs is the blob store of all servers in this process. Servers share a
+// blob store because a side channel may not reach the server state that
+// handles the call (e.g., when using ServeOn).
+var blobs = &blobStore{blobs: map[blobID]blob{}}
+
+// put stores a blob and drops expired blobs.
+func (s *blobStore) put(id blobID, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, b := range s.blobs {
+		if now.After(b.expires) {
+			delete(s.blobs, id)
+		}
+	}
+	s.blobs[id] = blob{data: data, expires: now.Add(blobTTL)}
+}
+
+// take removes and returns a blob.
+func (s *blobStore) take(id blobID) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.blobs[id]
+	if !ok || time.Now().After(b.expires) {
+		delete(s.blobs, id)
+		return nil, false
+	}
+	delete(s.blobs, id)
+	return b.data, true
+}
+
+// putBlob sends data to the server over a side channel and returns the id of
+// the resulting blob.
+func (c *clientConnection) putBlob(ctx context.Context, data []byte) (blobID, error) {
+	id := newBlobID()
+	if _, err := c.exchangeBlob(ctx, putBlobMessage, id, data); err != nil {
+		return blobID{}, err
+	}
+	return id, nil
+}
+
+// getBlob fetches the provided blob from the server over a side channel.
+func (c *clientConnection) getBlob(ctx context.Context, id blobID) ([]byte, error) {
+	return c.exchangeBlob(ctx, getBlobMessage, id, nil)
+}
+
+// exchangeBlob dials a side channel to the server, sends a message of type mt
+// for the provided blob, and returns the payload of the server's reply.
+func (c *clientConnection) exchangeBlob(ctx context.Context, mt messageType, id blobID, data []byte) ([]byte, error) {
+	nc, err := c.endpoint.Dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer nc.Close()
+	stop := context.AfterFunc(ctx, func() { nc.Close() })
+	defer stop()
+
+	var wlock sync.Mutex
+	buf := bufio.NewReader(nc)
+	mine := localSettings(nc, c.rc.opts.MaxMessageSize)
+	exchange := func() ([]byte, error) {
+		if err := writeVersion(nc, &wlock, mine); err != nil {
+			return nil, err
+		}
+		vmt, vid, vmsg, err := readMessage(buf, mine.maxMessage)
+		if err != nil {
+			return nil, err
+		}
+		if vmt != versionMessage {
+			return nil, fmt.Errorf("wrong message type %d, expecting %d", vmt, versionMessage)
+		}
+		s, err := getVersion(vid, vmsg, mine)
+		if err != nil {
+			return nil, err
+		}
+		if s.version < blobVersion {
+			return nil, fmt.Errorf("server version %d does not support blobs", s.version)
+		}
+		if c.rc.opts.RequireEncryption && !encrypted(mine, s) {
+			return nil, fmt.Errorf("connection to %s is not encrypted", c.endpoint.Address())
+		}
+		if size := uint64(len(id) + len(data)); size > s.maxMessage {
+			return nil, fmt.Errorf("blob of %d bytes exceeds the server's limit of %d bytes", size, s.maxMessage)
+		}
+
+		wmt := mt | checksummed(c.rc.opts.Checksum, s)
+		if err := writeMessage(nc, &wlock, wmt, 0, id[:], data, c.rc.opts.WriteFlattenLimit); err != nil {
+			return nil, err
+		}
+		rmt, _, reply, err := readMessage(buf, mine.maxMessage)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case mt == putBlobMessage && rmt == blobAckMessage:
+			return nil, nil
+		case mt == getBlobMessage && rmt == blobDataMessage:
+			return reply, nil
+		case rmt == responseError:
+			if err, ok := decodeError(reply); ok {
+				return nil, err
+			}
+			return nil, fmt.Errorf("could not decode error")
+		default:
+			return nil, fmt.Errorf("invalid blob reply %d", rmt)
+		}
+	}
+	reply, err := exchange()
+	if err != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return reply, err
+}
+
+// serveBlob handles a putBlobMessage or getBlobMessage received by the server.
+func (c *serverConnection) serveBlob(mt messageType, id uint64, msg []byte) error {
+	if len(msg) < len(blobID{}) {
+		return fmt.Errorf("missing blob id")
+	}
+	var bid blobID
+	copy(bid[:], msg)
+	if mt == putBlobMessage {
+		blobs.put(bid, msg[len(bid):])
+		return writeMessage(c.c, &c.wlock, blobAckMessage, id, nil, nil, c.opts.WriteFlattenLimit)
+	}
+	data, ok := blobs.take(bid)
+	if !ok {
+		err := encodeError(fmt.Errorf("%w: blob not found", CommunicationError))
+		return writeMessage(c.c, &c.wlock, responseError, id, nil, err, c.opts.WriteFlattenLimit)
+	}
+	c.mu.Lock()
+	settings := c.settings
+	c.mu.Unlock()
+	if size := uint64(len(data)); size > settings.maxMessage {
+		err := encodeError(fmt.Errorf("blob of %d bytes exceeds the client's limit of %d bytes", size, settings.maxMessage))
+		return writeMessage(c.c, &c.wlock, responseError, id, nil, err, c.opts.WriteFlattenLimit)
+	}
+	mt = blobDataMessage | checksummed(c.opts.Checksum, settings)
+	return writeMessage(c.c, &c.wlock, mt, id, nil, data, c.opts.WriteFlattenLimit)
+}
@@ -74,6 +74,7 @@ import (
 	"io"
 	"log/slog"
 	"net"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -94,6 +95,10 @@ type Connection interface {
 	// return an error. All future invocations of Call fail and return an error
 	// immediately. Close can be called more than once.
 	Close()
+
+	// Replicas returns the addresses of the replicas that calls can
+	// currently be sent to, in sorted order.
+	Replicas() []string
 }
 
 // Listener allows the server to accept RPCs.
@@ -177,7 +182,7 @@ type clientConnection struct {
 	inBalancer     bool             // Is c registered with the balancer?
 	c              net.Conn         // Active network connection, or nil
 	cbuf           *bufio.Reader    // Buffered reader wrapped around c
-	version        version          // Version number to use for connection
+	settings       settings         // Settings negotiated for connection
 	calls          map[uint64]*call // In-progress calls
 	lastID         uint64           // Last assigned request ID for a call
 }
@@ -191,8 +196,10 @@ type call struct {
 
 	// Fields below are accessed across goroutines, but their access is
 	// synchronized via doneSignal, i.e., it is never concurrent.
-	err      error
-	response []byte
+	err       error
+	serverErr bool // is err an error returned by the server?
+	response  []byte
+	blob      *blobID // if not nil, the response is in this blob at the server
 
 	// Is the call done?
 	// This field is accessed across goroutines using atomics.
@@ -208,7 +215,7 @@ type serverConnection struct {
 	wlock       sync.Mutex    // Guards writes to c
 	mu          sync.Mutex
 	closed      bool              // has c been closed?
-	version     version           // Version number to use for connection
+	settings    settings          // Settings negotiated for connection
 	cancelFuncs map[uint64]func() // Cancellation functions for in-progress calls
 }
 
@@ -269,7 +276,7 @@ func (ss *serverState) serveConnection(ctx context.Context, conn net.Conn, hmap
 		opts:        ss.opts,
 		c:           conn,
 		cbuf:        bufio.NewReader(conn),
-		version:     initialVersion, // Updated when we hear from client
+		settings:    settings{version: initialVersion}, // Updated when we hear from client
 		cancelFuncs: map[uint64]func(){},
 	}
 	ss.register(c)
@@ -365,14 +372,32 @@ func (rc *reconnectingConnection) Close() {
 	rc.resolverDone.Wait()
 }
 
+// Replicas implements the Connection interface.
+func (rc *reconnectingConnection) Replicas() []string {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	var addrs []string
+	for addr, c := range rc.conns {
+		if c.state != missing && c.state != draining {
+			addrs = append(addrs, addr)
+		}
+	}
+	slices.Sort(addrs)
+	return addrs
+}
+
 // Call makes an RPC over connection c, retrying it on network errors if retries are allowed.
 func (rc *reconnectingConnection) Call(ctx context.Context, h MethodKey, arg []byte, opts CallOptions) ([]byte, error) {
-	if !opts.Retry {
+	if !opts.Retry || opts.AtMostOnce {
 		return rc.callOnce(ctx, h, arg, opts)
 	}
 	for r := retry.Begin(); r.Continue(ctx); {
 		response, err := rc.callOnce(ctx, h, arg, opts)
-		if errors.Is(err, Unreachable) || errors.Is(err, CommunicationError) {
+		if errors.Is(err, Unreachable) && opts.Replica != "" {
+			// The target replica is gone.
+			return nil, err
+		}
+		if codegen.CodeOf(err).Retriable() {
 			continue
 		}
 		return response, err
@@ -409,21 +434,54 @@ func (rc *reconnectingConnection) callOnce(ctx context.Context, h MethodKey, arg
 	rpc.doneSignal = make(chan struct{})
 
 	// TODO: Arrange to obey deadline in any reconnection done inside startCall.
-	conn, nc, err := rc.startCall(ctx, rpc, opts)
+	conn, nc, settings, err := rc.startCall(ctx, rpc, opts)
 	if err != nil {
 		return nil, err
 	}
-	if err := writeMessage(nc, &conn.wlock, requestMessage, rpc.id, hdrSlice, arg, rc.opts.WriteFlattenLimit); err != nil {
+
+	// maybeCommitted marks an error of a call that may have reached the
+	// server, if the call is at-most-once.
+	maybeCommitted := func(err error) error {
+		if !opts.AtMostOnce {
+			return err
+		}
+		return fmt.Errorf("%w: %w", MaybeCommitted, err)
+	}
+
+	// Send large arguments out of band.
+	mt, payload := requestMessage, arg
+	if rc.opts.BlobThreshold > 0 && len(arg) > rc.opts.BlobThreshold && settings.version >= blobVersion {
+		id, err := conn.putBlob(ctx, arg)
+		if err != nil {
+			conn.endCall(rpc)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: send argument: %s", CommunicationError, err)
+		}
+		mt, payload = requestBlobMessage, id[:]
+	} else if rc.opts.CompressThreshold > 0 && len(arg) > rc.opts.CompressThreshold && settings.features&featureFlate != 0 {
+		// Compress large arguments, if the server supports it.
+		mt, payload = requestMessage|compressedFlag, compress(arg)
+	}
+	if size := uint64(len(hdrSlice) + len(payload)); size > settings.maxMessage {
+		conn.endCall(rpc)
+		return nil, codegen.Errorf(codegen.ResourceExhausted, "request of %d bytes exceeds the server's limit of %d bytes", size, settings.maxMessage)
+	}
+	mt |= checksummed(rc.opts.Checksum, settings)
+
+	if err := writeMessage(nc, &conn.wlock, mt, rpc.id, hdrSlice, payload, rc.opts.WriteFlattenLimit); err != nil {
 		conn.shutdown("client send request", err)
 		conn.endCall(rpc)
-		return nil, fmt.Errorf("%w: %s", CommunicationError, err)
+		// The request may have been partially written.
+		return nil, maybeCommitted(fmt.Errorf("%w: %s", CommunicationError, err))
 	}
 
 	if rc.opts.OptimisticSpinDuration > 0 {
 		// Optimistically spin, waiting for the results.
 		for start := time.Now(); time.Since(start) < rc.opts.OptimisticSpinDuration; {
 			if atomic.LoadUint32(&rpc.done) > 0 {
-				return rpc.response, rpc.err
+				return conn.result(ctx, rpc, maybeCommitted)
 			}
 		}
 	}
@@ -443,12 +501,32 @@ func (rc *reconnectingConnection) callOnce(ctx context.Context, h MethodKey, arg
 				}
 			}
 
-			return nil, ctx.Err()
+			return nil, maybeCommitted(ctx.Err())
 		}
 	} else {
 		<-rpc.doneSignal
 	}
-	return rpc.response, rpc.err
+	return conn.result(ctx, rpc, maybeCommitted)
+}
+
+// result returns the result of a done call, fetching it from the server if
+// it was sent out of band. Errors not returned by the server are passed
+// through maybeCommitted.
+func (c *clientConnection) result(ctx context.Context, rpc *call, maybeCommitted func(error) error) ([]byte, error) {
+	if rpc.err != nil && !rpc.serverErr {
+		return nil, maybeCommitted(rpc.err)
+	}
+	if rpc.err != nil || rpc.blob == nil {
+		return rpc.response, rpc.err
+	}
+	response, err := c.getBlob(ctx, *rpc.blob)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, maybeCommitted(ctx.Err())
+		}
+		return nil, maybeCommitted(fmt.Errorf("%w: fetch result: %s", CommunicationError, err))
+	}
+	return response, nil
 }
 
 // watchResolver watches for updates to the set of endpoints. When a new set of
@@ -525,39 +603,57 @@ func (rc *reconnectingConnection) updateEndpoints(ctx context.Context, endpoints
 	return nil
 }
 
-// startCall registers a new in-progress call.
+// startCall registers a new in-progress call. It returns the connection on
+// which the call was registered, its network connection, and the settings
+// negotiated for it.
 // REQUIRES: rc.mu is not held.
-func (rc *reconnectingConnection) startCall(ctx context.Context, rpc *call, opts CallOptions) (*clientConnection, net.Conn, error) {
+func (rc *reconnectingConnection) startCall(ctx context.Context, rpc *call, opts CallOptions) (*clientConnection, net.Conn, settings, error) {
 	for r := retry.Begin(); r.Continue(ctx); {
 		rc.mu.Lock()
 		if rc.closed {
 			rc.mu.Unlock()
-			return nil, nil, fmt.Errorf("Call on closed Connection")
+			return nil, nil, settings{}, fmt.Errorf("Call on closed Connection")
 		}
 
-		replica, ok := rc.opts.Balancer.Pick(opts)
-		if !ok {
-			rc.mu.Unlock()
-			continue
+		var replica ReplicaConnection
+		if opts.Replica != "" {
+			c, ok := rc.conns[opts.Replica]
+			if !ok || c.state == draining {
+				rc.mu.Unlock()
+				return nil, nil, settings{}, fmt.Errorf("%w: replica %s not found", Unreachable, opts.Replica)
+			}
+			if !c.inBalancer {
+				// Wait for the connection to become usable.
+				rc.mu.Unlock()
+				continue
+			}
+			replica = c
+		} else {
+			var ok bool
+			replica, ok = rc.opts.Balancer.Pick(opts)
+			if !ok {
+				rc.mu.Unlock()
+				continue
+			}
 		}
 
 		c, ok := replica.(*clientConnection)
 		if !ok {
 			rc.mu.Unlock()
-			return nil, nil, fmt.Errorf("internal error: wrong connection type %#v returned by load balancer", replica)
+			return nil, nil, settings{}, fmt.Errorf("internal error: wrong connection type %#v returned by load balancer", replica)
 		}
 
 		c.lastID++
 		rpc.id = c.lastID
 		c.calls[rpc.id] = rpc
 		c.callstart()
-		nc := c.c
+		nc, settings := c.c, c.settings
 		rc.mu.Unlock()
 
-		return c, nc, nil
+		return c, nc, settings, nil
 	}
 
-	return nil, nil, ctx.Err()
+	return nil, nil, settings{}, ctx.Err()
 }
 
 func (c *clientConnection) Address() string {
@@ -801,7 +897,8 @@ func (c *clientConnection) connectOnce(ctx context.Context) bool {
 	c.loggedShutdown = false
 	c.connected()
 
-	// Handshake to get the peer version and verify that it is live.
+	// Handshake to negotiate the connection settings and verify that the peer
+	// is live.
 	if err := c.exchangeVersions(); err != nil {
 		c.fail("handshake", err)
 		return false
@@ -816,64 +913,89 @@ func (c *clientConnection) connectOnce(ctx context.Context) bool {
 	return true
 }
 
-// exchangeVersions sends client version to server and waits for the server version.
+// exchangeVersions sends client settings to server, waits for the server
+// settings, and negotiates the settings to use on the connection.
 func (c *clientConnection) exchangeVersions() error {
-	nc, buf := c.c, c.cbuf
+	nc, buf, opts := c.c, c.cbuf, c.rc.opts
 
 	// Do not hold mutex while reading from the network.
 	c.rc.mu.Unlock()
 	defer c.rc.mu.Lock()
 
-	if err := writeVersion(nc, &c.wlock); err != nil {
+	mine := localSettings(nc, opts.MaxMessageSize)
+	if err := writeVersion(nc, &c.wlock, mine); err != nil {
 		return err
 	}
-	mt, id, msg, err := readMessage(buf)
+	mt, id, msg, err := readMessage(buf, mine.maxMessage)
 	if err != nil {
 		return err
 	}
 	if mt != versionMessage {
 		return fmt.Errorf("wrong message type %d, expecting %d", mt, versionMessage)
 	}
-	v, err := getVersion(id, msg)
+	s, err := getVersion(id, msg, mine)
 	if err != nil {
 		return err
 	}
-	c.version = v
+	if opts.RequireEncryption && !encrypted(mine, s) {
+		return fmt.Errorf("connection to %s is not encrypted", c.endpoint.Address())
+	}
+	c.rc.mu.Lock()
+	c.settings = s
+	c.rc.mu.Unlock()
 	return nil
 }
 
 // readAndProcessMessage reads and handles one message sent from the server.
 func (c *clientConnection) readAndProcessMessage() error {
 	buf := c.cbuf
+	maxSize := uint64(c.rc.opts.MaxMessageSize)
 
 	// Do not hold mutex while reading from the network.
 	c.rc.mu.Unlock()
 	defer c.rc.mu.Lock()
 
-	mt, id, msg, err := readMessage(buf)
+	mt, id, msg, err := readMessage(buf, maxSize)
 	if err != nil {
 		return err
 	}
+	compressed := mt&compressedFlag != 0
+	mt &^= compressedFlag
 	switch mt {
 	case versionMessage:
-		_, err := getVersion(id, msg)
+		_, err := getVersion(id, msg, settings{})
 		if err != nil {
 			return err
 		}
 		// Ignore versions sent after initial hand-shake
-	case responseMessage, responseError:
+	case responseMessage, responseError, responseBlobMessage:
 		rpc := c.findAndEndCall(id)
 		if rpc == nil {
 			return nil // May have been canceled
 		}
-		if mt == responseError {
+		switch mt {
+		case responseError:
 			if err, ok := decodeError(msg); ok {
 				rpc.err = err
+				rpc.serverErr = true
 			} else {
 				rpc.err = fmt.Errorf("%w: could not decode error", CommunicationError)
 			}
-		} else {
+		case responseBlobMessage:
+			var blob blobID
+			if len(msg) != len(blob) {
+				rpc.err = fmt.Errorf("%w: bad blob id", CommunicationError)
+			} else {
+				copy(blob[:], msg)
+				rpc.blob = &blob
+			}
+		default:
 			rpc.response = msg
+			if compressed {
+				if rpc.response, err = decompress(msg, maxSize); err != nil {
+					rpc.err = fmt.Errorf("%w: decompress result: %s", CommunicationError, err)
+				}
+			}
 		}
 		atomic.StoreUint32(&rpc.done, 1)
 		close(rpc.doneSignal)
@@ -885,50 +1007,65 @@ func (c *clientConnection) readAndProcessMessage() error {
 
 // readRequests runs on the server side reading messages sent over a connection by the client.
 func (c *serverConnection) readRequests(ctx context.Context, hmap *HandlerMap, onDone func()) {
+	mine := localSettings(c.c, c.opts.MaxMessageSize)
 	for ctx.Err() == nil {
-		mt, id, msg, err := readMessage(c.cbuf)
+		mt, id, msg, err := readMessage(c.cbuf, mine.maxMessage)
 		if err != nil {
 			c.shutdown("server read", err)
 			onDone()
 			return
 		}
 
+		compressed := mt&compressedFlag != 0
+		mt &^= compressedFlag
 		switch mt {
 		case versionMessage:
-			v, err := getVersion(id, msg)
+			s, err := getVersion(id, msg, mine)
 			if err != nil {
 				c.shutdown("server read version", err)
 				onDone()
 				return
 			}
+			if c.opts.RequireEncryption && !encrypted(mine, s) {
+				c.shutdown("server handshake", fmt.Errorf("connection from %s is not encrypted", c.c.RemoteAddr()))
+				onDone()
+				return
+			}
 			c.mu.Lock()
-			c.version = v
+			c.settings = s
 			c.mu.Unlock()
 
-			// Respond with my version.
-			if err := writeVersion(c.c, &c.wlock); err != nil {
+			// Respond with my settings.
+			if err := writeVersion(c.c, &c.wlock, mine); err != nil {
 				c.shutdown("server send version", err)
 				onDone()
 				return
 			}
-		case requestMessage:
+		case requestMessage, requestBlobMessage:
+			blob := mt == requestBlobMessage
 			if c.opts.InlineHandlerDuration > 0 {
 				// Run the handler inline. If it doesn't return in the specified
 				// time period, launch another goroutine to read incoming requests.
 				t := time.AfterFunc(c.opts.InlineHandlerDuration, func() {
 					c.readRequests(ctx, hmap, onDone)
 				})
-				c.runHandler(hmap, id, msg)
+				c.runHandler(hmap, id, msg, blob, compressed)
 				if !t.Stop() {
 					// Another goroutine is reading incoming requests: bail out.
 					return
 				}
 			} else {
 				// Run the handler in a separate goroutine.
-				go c.runHandler(hmap, id, msg)
+				go c.runHandler(hmap, id, msg, blob, compressed)
 			}
 		case cancelMessage:
 			c.endRequest(id)
+		case putBlobMessage, getBlobMessage:
+			if err := c.serveBlob(mt, id, msg); err != nil {
+				c.shutdown("server blob", err)
+				onDone()
+				return
+			}
 		default:
 			c.shutdown("server read", fmt.Errorf("invalid request type %d", mt))
 			onDone()
@@ -941,7 +1078,9 @@ func (c *serverConnection) readRequests(ctx context.Context, hmap *HandlerMap, o
 
 // runHandler runs an application specified RPC handler at the server side.
 // The result (or error) from the handler is sent back to the client over c.
-func (c *serverConnection) runHandler(hmap *HandlerMap, id uint64, msg []byte) {
+// If blob is true, the request's argument is the blob whose id follows the
+// request header. If compressed is true, the request's argument is compressed.
+func (c *serverConnection) runHandler(hmap *HandlerMap, id uint64, msg []byte, blob, compressed bool) {
 	msgLen := uint32(len(msg))
 	if msgLen < hdrLenLen {
 		c.shutdown("server handler", fmt.Errorf("missing request header length"))
@@ -995,9 +1134,27 @@ func (c *serverConnection) runHandler(hmap *HandlerMap, id uint64, msg []byte) {
 	payload := msg[hdrEndOffset:]
 	var err error
 	var result []byte
+	if blob {
+		var bid blobID
+		if len(payload) != len(bid) {
+			c.shutdown("server handler", fmt.Errorf("bad blob id"))
+			return
+		}
+		copy(bid[:], payload)
+		var ok bool
+		if payload, ok = blobs.take(bid); !ok {
+			err = fmt.Errorf("%w: argument blob not found", CommunicationError)
+		}
+	} else if compressed {
+		if payload, err = decompress(payload, uint64(c.opts.MaxMessageSize)); err != nil {
+			err = fmt.Errorf("%w: decompress argument: %s", CommunicationError, err)
+		}
+	}
 	fn, ok := hmap.handlers[hkey]
-	if !ok {
-		err = fmt.Errorf("internal error: unknown function")
+	if err != nil {
+		// The argument is missing or corrupted.
+	} else if !ok {
+		err = codegen.Errorf(codegen.Unimplemented, "internal error: unknown function")
 	} else {
 		if err := c.startRequest(id, cancelFunc); err != nil {
 			logError(c.opts.Logger, "handle "+hmap.names[hkey], err)
@@ -1008,13 +1165,31 @@ func (c *serverConnection) runHandler(hmap *HandlerMap, id uint64, msg []byte) {
 		result, err = fn(ctx, payload)
 	}
 
+	c.mu.Lock()
+	settings := c.settings
+	c.mu.Unlock()
+
 	mt := responseMessage
+	if err == nil && c.opts.BlobThreshold > 0 && len(result) > c.opts.BlobThreshold && settings.version >= blobVersion {
+		// Send large results out of band, if the client supports it.
+		bid := newBlobID()
+		blobs.put(bid, result)
+		mt, result = responseBlobMessage, bid[:]
+	} else if err == nil && c.opts.CompressThreshold > 0 && len(result) > c.opts.CompressThreshold && settings.features&featureFlate != 0 {
+		// Compress large results, if the client supports it.
+		mt, result = responseMessage|compressedFlag, compress(result)
+	}
+	if err == nil && uint64(len(result)) > settings.maxMessage {
+		// The client would reject the result.
+		err = codegen.Errorf(codegen.ResourceExhausted, "result of %d bytes exceeds the client's limit of %d bytes", len(result), settings.maxMessage)
+	}
 	if err != nil {
 		mt = responseError
 		result = encodeError(err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 	}
+	mt |= checksummed(c.opts.Checksum, settings)
 
 	if err := writeMessage(c.c, &c.wlock, mt, id, nil, result, c.opts.WriteFlattenLimit); err != nil {
 		c.shutdown("server write "+hmap.names[hkey], err)
@@ -1070,6 +1245,18 @@ func encodeHeader(ctx context.Context, h MethodKey, micros int64) []byte {
 	// Send context metadata in the header.
 	writeContextMetadata(ctx, enc)
 
+	// Send the caller in the header.
+	writeCaller(ctx, enc)
+
+	// Send the idempotency key in the header.
+	writeIdempotencyKey(ctx, enc)
+
+	// Send the scheduling information in the header.
+	writeScheduling(ctx, enc)
+
+	// Send the hedging budget in the header.
+	writeHedging(ctx, enc)
+
 	return enc.Data()
 }
 
@@ -1089,6 +1276,18 @@ func decodeHeader(hdr []byte) (context.Context, MethodKey, int64, *trace.SpanCon
 
 	// Extract metadata context information if any.
 	ctx := readContextMetadata(context.Background(), dec)
+
+	// Extract the caller, if any.
+	ctx = readCaller(ctx, dec)
+
+	// Extract the idempotency key, if any.
+	ctx = readIdempotencyKey(ctx, dec)
+
+	// Extract the scheduling information, if any.
+	ctx = readScheduling(ctx, dec)
+
+	// Extract the hedging budget, if any.
+	ctx = readHedging(ctx, dec)
 	return ctx, hkey, micros, sc
 }
 
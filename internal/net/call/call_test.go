@@ -27,6 +27,7 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -695,6 +696,221 @@ func TestSingleTCPServer(t *testing.T) {
 	}
 }
 
+// TestBlobs tests that large arguments and results are transferred out of
+// band and that small ones are not affected.
+func TestBlobs(t *testing.T) {
+	const threshold = 1 << 10
+	ctx := context.Background()
+	endpoints := startServers(ctx, call.ServerOptions{Logger: logger(t), BlobThreshold: threshold})
+	for _, protocol := range []string{"tcp", "mtls"} {
+		for _, clientThreshold := range []int{threshold, -1} {
+			name := fmt.Sprintf("%s/%d", protocol, clientThreshold)
+			t.Run(name, func(t *testing.T) {
+				opts := call.ClientOptions{Logger: logger(t), BlobThreshold: clientThreshold}
+				client, err := call.Connect(ctx, call.NewConstantResolver(endpoints[protocol]), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer client.Close()
+				for _, size := range []int{0, threshold, threshold + 1, 10 << 20} {
+					arg := bytes.Repeat([]byte{'x'}, size)
+					result, err := client.Call(ctx, echoKey, arg, call.CallOptions{})
+					if err != nil {
+						t.Fatalf("Call(%d bytes): %v", size, err)
+					}
+					if !bytes.Equal(result, arg) {
+						t.Fatalf("Call(%d bytes): got %d bytes, want %d", size, len(result), size)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestCompression tests that large arguments and results are compressed when
+// both peers support it and that compression is transparent to callers.
+func TestCompression(t *testing.T) {
+	const threshold = 1 << 10
+	ctx := context.Background()
+	endpoints := startServers(ctx, call.ServerOptions{Logger: logger(t), CompressThreshold: threshold})
+	for _, protocol := range []string{"tcp", "mtls"} {
+		for _, clientThreshold := range []int{threshold, 0} {
+			name := fmt.Sprintf("%s/%d", protocol, clientThreshold)
+			t.Run(name, func(t *testing.T) {
+				opts := call.ClientOptions{Logger: logger(t), CompressThreshold: clientThreshold}
+				client, err := call.Connect(ctx, call.NewConstantResolver(endpoints[protocol]), opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer client.Close()
+				for _, size := range []int{0, threshold, threshold + 1, 1 << 20} {
+					arg := bytes.Repeat([]byte("compressible"), size/12)
+					result, err := client.Call(ctx, echoKey, arg, call.CallOptions{})
+					if err != nil {
+						t.Fatalf("Call(%d bytes): %v", len(arg), err)
+					}
+					if !bytes.Equal(result, arg) {
+						t.Fatalf("Call(%d bytes): got %d bytes, want %d", len(arg), len(result), len(arg))
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestMaxMessageSize tests that arguments and results larger than the limit
+// advertised by the peer are rejected before they are sent.
+func TestMaxMessageSize(t *testing.T) {
+	const limit = 4 << 10
+	ctx := context.Background()
+	endpoints := startServers(ctx, call.ServerOptions{Logger: logger(t), BlobThreshold: -1, MaxMessageSize: limit})
+	opts := call.ClientOptions{Logger: logger(t), BlobThreshold: -1, MaxMessageSize: limit}
+	client, err := call.Connect(ctx, call.NewConstantResolver(endpoints["tcp"]), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Small arguments and results are accepted.
+	arg := bytes.Repeat([]byte{'x'}, limit/2)
+	if _, err := client.Call(ctx, echoKey, arg, call.CallOptions{}); err != nil {
+		t.Fatalf("Call(%d bytes): %v", len(arg), err)
+	}
+
+	// Large arguments are rejected by the client.
+	arg = bytes.Repeat([]byte{'x'}, 2*limit)
+	if _, err := client.Call(ctx, echoKey, arg, call.CallOptions{}); err == nil || !strings.Contains(err.Error(), "exceeds the server's limit") {
+		t.Fatalf("Call(%d bytes): got %v, want exceeds the server's limit error", len(arg), err)
+	}
+
+	// Large results are rejected by the server.
+	_, err = runAtServer(ctx, client, call.CallOptions{}, func(context.Context) ([]byte, error) {
+		return bytes.Repeat([]byte{'x'}, 2*limit), nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "exceeds the client's limit") {
+		t.Fatalf("large result: got %v, want exceeds the client's limit error", err)
+	}
+}
+
+// TestRequireEncryption tests that clients that require encryption only
+// connect to servers over encrypted connections.
+func TestRequireEncryption(t *testing.T) {
+	ctx := context.Background()
+	endpoints := startServers(ctx, call.ServerOptions{Logger: logger(t)})
+	for _, test := range []struct {
+		protocol string
+		ok       bool
+	}{
+		{"tcp", false},
+		{"mtls", true},
+	} {
+		t.Run(test.protocol, func(t *testing.T) {
+			opts := call.ClientOptions{Logger: logger(t), RequireEncryption: true}
+			client, err := call.Connect(ctx, call.NewConstantResolver(endpoints[test.protocol]), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+			ctx, cancel := context.WithTimeout(ctx, delaySlop)
+			defer cancel()
+			_, err = client.Call(ctx, echoKey, []byte("hello"), call.CallOptions{})
+			if got := err == nil; got != test.ok {
+				t.Fatalf("Call: got err %v, want success %t", err, test.ok)
+			}
+		})
+	}
+}
+
+// corruptingConn is a net.Conn that flips a bit in the first message written
+// to it that contains marker.
+type corruptingConn struct {
+	net.Conn
+	marker    []byte
+	corrupted *atomic.Bool
+}
+
+func (c corruptingConn) Write(b []byte) (int, error) {
+	if bytes.Contains(b, c.marker) && c.corrupted.CompareAndSwap(false, true) {
+		b = slices.Clone(b)
+		b[len(b)-1] ^= 1
+	}
+	return c.Conn.Write(b)
+}
+
+// corruptingEndpoint is a pipe-based endpoint, like pipeEndpoint, whose client
+// or server side corrupts the first message that contains marker.
+type corruptingEndpoint struct {
+	t         testing.TB
+	checksum  bool // enable checksums at the server
+	server    bool // corrupt a message sent by the server
+	marker    []byte
+	corrupted atomic.Bool
+}
+
+func (c *corruptingEndpoint) Dial(context.Context) (net.Conn, error) {
+	client, server := pipe(c.t)
+	if c.server {
+		server = corruptingConn{server, c.marker, &c.corrupted}
+	} else {
+		client = corruptingConn{client, c.marker, &c.corrupted}
+	}
+	opts := call.ServerOptions{Logger: logger(c.t), Checksum: c.checksum}
+	call.ServeOn(context.Background(), server, handlers, opts)
+	return client, nil
+}
+
+func (c *corruptingEndpoint) Address() string {
+	return "corrupting://"
+}
+
+// TestChecksum tests that corrupted messages are detected when checksums are
+// enabled, and that the affected calls are retried if they allow it.
+func TestChecksum(t *testing.T) {
+	ctx := context.Background()
+	arg := []byte("corrupt me")
+	for _, test := range []struct {
+		name     string
+		checksum bool
+		server   bool
+		retry    bool
+	}{
+		{"request/retry", true, false, true},
+		{"request/noretry", true, false, false},
+		{"result/retry", true, true, true},
+		{"result/noretry", true, true, false},
+		{"nochecksum", false, false, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			endpoint := &corruptingEndpoint{t: t, checksum: test.checksum, server: test.server, marker: arg}
+			opts := call.ClientOptions{Logger: logger(t), Checksum: test.checksum}
+			client, err := call.Connect(ctx, call.NewConstantResolver(endpoint), opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			result, err := client.Call(ctx, echoKey, arg, call.CallOptions{Retry: test.retry})
+			switch {
+			case !endpoint.corrupted.Load():
+				t.Fatal("no message was corrupted")
+			case !test.checksum:
+				// The corruption goes unnoticed.
+				if err != nil || bytes.Equal(result, arg) {
+					t.Fatalf("Call: got (%q, %v), want corrupted result", result, err)
+				}
+			case test.retry:
+				if err != nil || !bytes.Equal(result, arg) {
+					t.Fatalf("Call: got (%q, %v), want (%q, nil)", result, err, arg)
+				}
+			default:
+				if !errors.Is(err, call.CommunicationError) {
+					t.Fatalf("Call: got %v, want CommunicationError", err)
+				}
+			}
+		})
+	}
+}
+
 // TestTracePropagation tests that the trace context is propagated across an RPC
 func TestTracePropagation(t *testing.T) {
 	ct := startTest(t)
@@ -764,6 +980,51 @@ func TestMultipleEndpoints(t *testing.T) {
 	}
 }
 
+// TestReplica tests that calls with CallOptions.Replica are sent to the
+// provided replica.
+func TestReplica(t *testing.T) {
+	for name, maker := range resolverMakers {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			endpoints := servers(t, 3)
+			options := call.ClientOptions{Logger: logger(t)}
+			client, err := call.Connect(ctx, maker(endpoints...), options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer client.Close()
+
+			var want []string
+			for _, e := range endpoints {
+				want = append(want, e.Address())
+			}
+			slices.Sort(want)
+			if got := client.Replicas(); !slices.Equal(got, want) {
+				t.Fatalf("Replicas() = %v; expecting %v", got, want)
+			}
+
+			for i, e := range endpoints {
+				for j := 0; j < 10; j++ {
+					opts := call.CallOptions{Retry: true, Replica: e.Address()}
+					result, err := client.Call(ctx, whoKey, []byte{}, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if got, want := string(result), strconv.Itoa(i); got != want {
+						t.Fatalf("call to %s handled by %s, expecting %s", e.Address(), got, want)
+					}
+				}
+			}
+
+			// Calls to unknown replicas fail without being retried.
+			opts := call.CallOptions{Retry: true, Replica: "unknown"}
+			if _, err := client.Call(ctx, whoKey, []byte{}, opts); !errors.Is(err, call.Unreachable) {
+				t.Fatalf("call to unknown replica: got %v, want %v", err, call.Unreachable)
+			}
+		})
+	}
+}
+
 // TestChangingEndpoints tests that RPC calls succeed across endpoint changes.
 func TestChangingEndpoints(t *testing.T) {
 	n := 3
@@ -1515,6 +1776,82 @@ func TestNoRetry(t *testing.T) {
 	}
 }
 
+func TestAtMostOnce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	// connect returns a client connected to a new server, and the client's
+	// network connection.
+	connect := func(t *testing.T) (call.Connection, net.Conn) {
+		c, s := pipe(t)
+		call.ServeOn(ctx, s, handlers, call.ServerOptions{Logger: logger(t)})
+		endpoint := &connEndpoint{"server", c}
+		client, err := call.Connect(ctx, call.NewConstantResolver(endpoint), call.ClientOptions{Logger: logger(t)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { client.Close() })
+		return client, c
+	}
+
+	t.Run("BrokenConnection", func(t *testing.T) {
+		// Break the connection while the call is running.
+		for _, atMostOnce := range []bool{true, false} {
+			client, c := connect(t)
+			started := make(chan struct{})
+			go func() {
+				<-started
+				c.Close()
+			}()
+			opts := call.CallOptions{AtMostOnce: atMostOnce}
+			_, err := runAtServer(ctx, client, opts, func(ctx context.Context) ([]byte, error) {
+				close(started)
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+			if !errors.Is(err, call.CommunicationError) {
+				t.Fatalf("at-most-once %v: got %v, want %v", atMostOnce, err, call.CommunicationError)
+			}
+			if got := errors.Is(err, call.MaybeCommitted); got != atMostOnce {
+				t.Fatalf("at-most-once %v: got %v, want maybe committed %v", atMostOnce, err, atMostOnce)
+			}
+		}
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		// Errors returned by the server are neither retried nor ambiguous,
+		// even if they are communication errors.
+		client, _ := connect(t)
+		var calls atomic.Int32
+		opts := call.CallOptions{AtMostOnce: true, Retry: true}
+		_, err := runAtServer(ctx, client, opts, func(context.Context) ([]byte, error) {
+			calls.Add(1)
+			return nil, call.CommunicationError
+		})
+		if !errors.Is(err, call.CommunicationError) || errors.Is(err, call.MaybeCommitted) {
+			t.Fatalf("got %v, want %v", err, call.CommunicationError)
+		}
+		if got, want := calls.Load(), int32(1); got != want {
+			t.Fatalf("got %d calls, want %d", got, want)
+		}
+	})
+
+	t.Run("Cancelled", func(t *testing.T) {
+		// A call cancelled after it was sent may have executed.
+		client, _ := connect(t)
+		ctx, cancel := context.WithCancel(ctx)
+		opts := call.CallOptions{AtMostOnce: true}
+		_, err := runAtServer(ctx, client, opts, func(context.Context) ([]byte, error) {
+			cancel()
+			time.Sleep(shortDelay)
+			return nil, nil
+		})
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, call.MaybeCommitted) {
+			t.Fatalf("got %v, want %v and %v", err, context.Canceled, call.MaybeCommitted)
+		}
+	})
+}
+
 func TestRetry(t *testing.T) {
 	ct := startTest(t)
 	client := ct.connect(call.NewConstantResolver(ct.startTCPServer()))
@@ -32,6 +32,11 @@ const (
 	// server is unreachable. Check for it via errors.Is(call.Unreachable).
 	Unreachable
 
+	// MaybeCommitted is the type of the error returned by a call made with
+	// CallOptions.AtMostOnce when the call fails after it may have reached
+	// the server. Check for it via errors.Is(call.MaybeCommitted).
+	MaybeCommitted
+
 	// TODO: Decide what error most applications will want to check for. We may
 	// need to combine CommunicationError and Unreachable. We may also want to
 	// make errors.Is(CommunicationError) return true for both types of errors.
@@ -45,11 +50,24 @@ func (e transportError) Error() string {
 		return "communication error"
 	case Unreachable:
 		return "unreachable"
+	case MaybeCommitted:
+		return "maybe committed"
 	default:
 		return fmt.Sprintf("unknown error %d", e)
 	}
 }
 
+// ErrorCode returns the code of e. See codegen.CodeOf.
+func (e transportError) ErrorCode() codegen.Code {
+	switch e {
+	case CommunicationError, Unreachable:
+		return codegen.Unavailable
+	default:
+		// A call that may have executed can't safely be retried as is.
+		return codegen.Unknown
+	}
+}
+
 func encodeError(err error) []byte {
 	// TODO(sanjay): There is a tiny risk that encoding the error will fail if
 	// we end up generating a string whose length does not fit in four bytes.
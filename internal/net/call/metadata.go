@@ -52,3 +52,96 @@ func readContextMetadata(ctx context.Context, dec *codegen.Decoder) context.Cont
 	}
 	return metadata.NewContext(ctx, res)
 }
+
+// writeCaller serializes the caller recorded in ctx (if any) into enc.
+func writeCaller(ctx context.Context, enc *codegen.Encoder) {
+	c, found := codegen.CallerFromContext(ctx)
+	if !found {
+		enc.Bool(false)
+		return
+	}
+	enc.Bool(true)
+	enc.String(c.Component)
+	enc.String(c.Version)
+}
+
+// writeIdempotencyKey serializes the idempotency key recorded in ctx (if any)
+// into enc.
+func writeIdempotencyKey(ctx context.Context, enc *codegen.Encoder) {
+	key, found := codegen.IdempotencyKeyFromContext(ctx)
+	if !found {
+		enc.Bool(false)
+		return
+	}
+	enc.Bool(true)
+	enc.String(key)
+}
+
+// readIdempotencyKey returns a context that carries the idempotency key (if
+// any) stored in dec.
+func readIdempotencyKey(ctx context.Context, dec *codegen.Decoder) context.Context {
+	if !dec.Bool() {
+		return ctx
+	}
+	return codegen.WithIdempotencyKey(ctx, dec.String())
+}
+
+// writeScheduling serializes the call priority and the weavelets that admitted
+// the call recorded in ctx (if any) into enc.
+func writeScheduling(ctx context.Context, enc *codegen.Encoder) {
+	priority, found := codegen.PriorityFromContext(ctx)
+	enc.Bool(found)
+	if found {
+		enc.Int(priority)
+	}
+	admitted := codegen.AdmittedFromContext(ctx)
+	enc.Len(len(admitted))
+	for _, weavelet := range admitted {
+		enc.String(weavelet)
+	}
+}
+
+// readScheduling returns a context that carries the call priority and the
+// weavelets that admitted the call (if any) stored in dec.
+func readScheduling(ctx context.Context, dec *codegen.Decoder) context.Context {
+	if dec.Bool() {
+		ctx = codegen.WithPriority(ctx, dec.Int())
+	}
+	n := dec.Len()
+	for i := 0; i < n; i++ {
+		ctx = codegen.WithAdmitted(ctx, dec.String())
+	}
+	return ctx
+}
+
+// writeHedging serializes the hedging budget recorded in ctx (if any) into
+// enc.
+func writeHedging(ctx context.Context, enc *codegen.Encoder) {
+	budget := codegen.HedgingBudgetFromContext(ctx)
+	if budget == nil {
+		enc.Bool(false)
+		return
+	}
+	enc.Bool(true)
+	enc.Int(budget.Remaining())
+}
+
+// readHedging returns a context that carries the hedging budget (if any)
+// stored in dec.
+func readHedging(ctx context.Context, dec *codegen.Decoder) context.Context {
+	if !dec.Bool() {
+		return ctx
+	}
+	return codegen.WithHedgingBudget(ctx, codegen.NewHedgingBudget(dec.Int()))
+}
+
+// readCaller returns a context that records the caller (if any) stored in dec.
+func readCaller(ctx context.Context, dec *codegen.Decoder) context.Context {
+	if !dec.Bool() {
+		return ctx
+	}
+	var c codegen.Caller
+	c.Component = dec.String()
+	c.Version = dec.String()
+	return codegen.WithCaller(ctx, c)
+}
@@ -15,11 +15,18 @@
 package call
 
 import (
+	"bytes"
+	"compress/flate"
+	"crypto/tls"
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"net"
 	"sync"
+
+	"github.com/ServiceWeaver/weaver/metrics"
 )
 
 // messageType identifies a type of message sent across the wire.
@@ -31,8 +38,24 @@ const (
 	responseMessage
 	responseError
 	cancelMessage
+	requestBlobMessage  // request whose argument is a blob
+	responseBlobMessage // response whose result is a blob
+	putBlobMessage      // stores a blob at the server
+	blobAckMessage      // acknowledges a putBlobMessage
+	getBlobMessage      // fetches a blob from the server
+	blobDataMessage     // replies to a getBlobMessage
+
+	// compressedFlag is set in the type of a requestMessage or a
+	// responseMessage whose payload is compressed with DEFLATE. It is only
+	// set if the peer supports featureFlate.
+	compressedFlag messageType = 0x80
+
+	// checksumFlag is set in the type of a message whose payload is followed
+	// by a checksum of the message. It is only set if the peer supports
+	// featureChecksum.
+	checksumFlag messageType = 0x40
+
 	// Other types to add?
-	// - chunked request/response messages?
 	// - health check
 	// - server status info
 )
@@ -41,13 +64,59 @@ const (
 type version uint32
 
 const (
-	initialVersion version = iota
+	initialVersion  version = iota
+	blobVersion             // adds blob messages
+	featuresVersion         // adds feature negotiation to the handshake
+)
+
+const currentVersion = featuresVersion
+
+// feature is a set of optional protocol features, negotiated per connection.
+// A feature is used on a connection only if both peers support it.
+type feature uint64
+
+const (
+	featureFlate     feature = 1 << iota // accepts payloads compressed with DEFLATE
+	featureEncrypted                     // the connection is encrypted (e.g., TLS)
+	featureChecksum                      // accepts checksummed messages
 )
 
-const currentVersion = initialVersion
+// defaultMaxMessageSize is the largest message accepted by peers that don't
+// advertise a limit during the handshake.
+const defaultMaxMessageSize = 100 << 20
+
+// settings holds the per-connection settings exchanged during the handshake.
+// Every peer sends its own settings in its versionMessage, and the settings
+// used on the connection are negotiated from the settings of both peers.
+type settings struct {
+	version    version // protocol version
+	features   feature // supported features
+	maxMessage uint64  // largest message accepted, in bytes
+}
 
 const hdrLenLen = uint32(4) // size of the header length included in each message
 
+// checksumLen is the size of the checksum that follows the payload of a
+// message whose type has checksumFlag set.
+const checksumLen = 4
+
+// crcTable is the table used to compute message checksums.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// errCorrupted is returned by readMessage for a message whose checksum
+// doesn't match its contents.
+var errCorrupted = errors.New("corrupted message")
+
+// corruptedLabels are the labels of the corrupted messages metric.
+type corruptedLabels struct {
+	Generated bool `weaver:"serviceweaver_generated"` // always true
+}
+
+var corruptedMessages = metrics.NewCounterMap[corruptedLabels](
+	"serviceweaver_call_corrupted_messages",
+	"Number of messages received with a checksum that doesn't match their contents",
+).Get(corruptedLabels{Generated: true})
+
 // # Message formats
 //
 // All messages have the following format:
@@ -60,6 +129,9 @@ const hdrLenLen = uint32(4) // size of the header length included in each messag
 //
 // versionMessage: this is the first message sent on a connection by both sides.
 //    version  [4]byte
+//    -- the remaining fields are sent only at featuresVersion or above --
+//    features [8]byte  -- bitmask of the features supported by the sender
+//    maxMsg   [8]byte  -- largest message accepted by the sender
 //
 // requestMessage:
 //    headerLen         [4]byte         -- length of the encoded header
@@ -79,11 +151,44 @@ const hdrLenLen = uint32(4) // size of the header length included in each messag
 // responseMessage:
 //    payload holds call result serialization
 //
+// A requestMessage or responseMessage whose type has compressedFlag set holds
+// the DEFLATE compressed call argument or result serialization.
+//
+// The payload of a message of any type whose type has checksumFlag set is
+// followed by a checksum, and length includes the size of the checksum:
+//    checksum  [4]byte   -- CRC-32 (Castagnoli) of the id, type, length, and
+//                           payload of the message
+//
+// A receiver that detects a checksum mismatch can't trust any part of the
+// message, including its id, so it closes the connection. The calls in
+// progress on the connection fail with a CommunicationError, and are retried
+// if they allow it.
+//
 // responseError:
 //    payload holds error serialization
 //
 // cancelMessage:
 //    payload is empty
+//
+// requestBlobMessage:
+//    like requestMessage, but the payload after the header is a 16 byte blob
+//    id, and the call argument is the blob with that id
+//
+// responseBlobMessage:
+//    payload holds the 16 byte id of the blob that holds the call result
+//
+// putBlobMessage: sent by a client over a side channel (see blob.go)
+//    id       [16]byte   -- blob id
+//    data                -- blob contents
+//
+// blobAckMessage:
+//    payload is empty
+//
+// getBlobMessage: sent by a client over a side channel (see blob.go)
+//    id       [16]byte   -- blob id
+//
+// blobDataMessage:
+//    payload holds the blob contents
 
 // writeMessage formats and sends a message over w.
 //
@@ -101,20 +206,29 @@ func writeMessage(w io.Writer, wlock *sync.Mutex, mt messageType, id uint64, ext
 	return writeFlat(w, wlock, mt, id, extraHdr, payload)
 }
 
-// writeChunked writes the header, extra header, and the payload into w using
-// three different w.Write() calls.
+// writeChunked writes the header, extra header, the payload, and the checksum,
+// if any, into w using up to four different w.Write() calls.
 func writeChunked(w io.Writer, wlock *sync.Mutex, mt messageType, id uint64, extraHdr []byte, payload []byte) error {
-	// We use an iovec with up to three entries.
-	var vec [3][]byte
+	// We use an iovec with up to four entries.
+	var vec [4][]byte
 
-	nh, np := len(extraHdr), len(payload)
+	nh, np, nc := len(extraHdr), len(payload), 0
+	if mt&checksumFlag != 0 {
+		nc = checksumLen
+	}
 	var hdr [16]byte
 	binary.LittleEndian.PutUint64(hdr[0:], id)
-	binary.LittleEndian.PutUint64(hdr[8:], uint64(mt)|(uint64(nh+np)<<8))
+	binary.LittleEndian.PutUint64(hdr[8:], uint64(mt)|(uint64(nh+np+nc)<<8))
 
 	vec[0] = hdr[:]
 	vec[1] = extraHdr
 	vec[2] = payload
+	if nc > 0 {
+		crc := crc32.Checksum(hdr[:], crcTable)
+		crc = crc32.Update(crc, crcTable, extraHdr)
+		crc = crc32.Update(crc, crcTable, payload)
+		vec[3] = binary.LittleEndian.AppendUint32(nil, crc)
+	}
 	buf := net.Buffers(vec[:])
 
 	// buf.WriteTo is not guaranteed to write the entire contents of buf
@@ -123,22 +237,30 @@ func writeChunked(w io.Writer, wlock *sync.Mutex, mt messageType, id uint64, ext
 	wlock.Lock()
 	defer wlock.Unlock()
 	n, err := buf.WriteTo(w)
-	if err == nil && n != 16+int64(nh)+int64(np) {
+	if err == nil && n != 16+int64(nh)+int64(np)+int64(nc) {
 		err = fmt.Errorf("partial write")
 	}
 	return err
 }
 
-// writeFlat concatenates the header, extra header, and the payload into
-// a single flat byte slice, and writes it into w using a single w.Write() call.
+// writeFlat concatenates the header, extra header, the payload, and the
+// checksum, if any, into a single flat byte slice, and writes it into w using a
+// single w.Write() call.
 func writeFlat(w io.Writer, wlock *sync.Mutex, mt messageType, id uint64, extraHdr []byte, payload []byte) error {
-	nh, np := len(extraHdr), len(payload)
-	data := make([]byte, 16+nh+np)
+	nh, np, nc := len(extraHdr), len(payload), 0
+	if mt&checksumFlag != 0 {
+		nc = checksumLen
+	}
+	data := make([]byte, 16+nh+np+nc)
 	binary.LittleEndian.PutUint64(data[0:], id)
-	val := uint64(mt) | (uint64(nh+np) << 8)
+	val := uint64(mt) | (uint64(nh+np+nc) << 8)
 	binary.LittleEndian.PutUint64(data[8:], val)
 	copy(data[16:], extraHdr)
 	copy(data[16+nh:], payload)
+	if nc > 0 {
+		n := 16 + nh + np
+		binary.LittleEndian.PutUint32(data[n:], crc32.Checksum(data[:n], crcTable))
+	}
 
 	// Write while holding the lock, since we don't know if the underlying
 	// io.Write is atomic.
@@ -153,8 +275,11 @@ func writeFlat(w io.Writer, wlock *sync.Mutex, mt messageType, id uint64, extraH
 	return err
 }
 
-// readMessage reads, parses, and returns the next message from r.
-func readMessage(r io.Reader) (messageType, uint64, []byte, error) {
+// readMessage reads, parses, and returns the next message from r. Messages
+// larger than maxSize bytes are rejected. If maxSize is zero, a default limit
+// is used. If the message has a checksum, readMessage verifies it, returning an
+// error that wraps errCorrupted on a mismatch, and strips it from the message.
+func readMessage(r io.Reader, maxSize uint64) (messageType, uint64, []byte, error) {
 	// Read the header.
 	const headerSize = 16
 	var hdr [headerSize]byte
@@ -167,7 +292,9 @@ func readMessage(r io.Reader) (messageType, uint64, []byte, error) {
 	w2 := binary.LittleEndian.Uint64(hdr[8:])
 	mt := messageType(w2 & 0xff)
 	dataLen := w2 >> 8
-	const maxSize = 100 << 20
+	if maxSize == 0 {
+		maxSize = defaultMaxMessageSize
+	}
 	if dataLen > maxSize {
 		return 0, 0, nil, fmt.Errorf("overly large message length %d", dataLen)
 	}
@@ -177,31 +304,123 @@ func readMessage(r io.Reader) (messageType, uint64, []byte, error) {
 	if _, err := io.ReadFull(r, msg); err != nil {
 		return 0, 0, nil, err
 	}
+
+	// Verify the checksum.
+	if mt&checksumFlag != 0 {
+		if len(msg) < checksumLen {
+			corruptedMessages.Inc()
+			return 0, 0, nil, fmt.Errorf("%w: missing checksum", errCorrupted)
+		}
+		n := len(msg) - checksumLen
+		crc := crc32.Update(crc32.Checksum(hdr[:], crcTable), crcTable, msg[:n])
+		if got := binary.LittleEndian.Uint32(msg[n:]); got != crc {
+			corruptedMessages.Inc()
+			return 0, 0, nil, fmt.Errorf("%w: got checksum %#x, want %#x", errCorrupted, got, crc)
+		}
+		mt, msg = mt&^checksumFlag, msg[:n]
+	}
 	return mt, id, msg, nil
 }
 
-// writeVersion sends my version number to the peer.
-func writeVersion(w io.Writer, wlock *sync.Mutex) error {
-	var msg [4]byte
-	binary.LittleEndian.PutUint32(msg[:], uint32(currentVersion))
+// localSettings returns my settings for the provided connection.
+func localSettings(nc net.Conn, maxMessage int) settings {
+	s := settings{
+		version:    currentVersion,
+		features:   featureFlate | featureChecksum,
+		maxMessage: uint64(maxMessage),
+	}
+	if _, ok := nc.(*tls.Conn); ok {
+		s.features |= featureEncrypted
+	}
+	return s
+}
+
+// writeVersion sends my settings to the peer.
+func writeVersion(w io.Writer, wlock *sync.Mutex, mine settings) error {
+	var msg [20]byte
+	binary.LittleEndian.PutUint32(msg[0:], uint32(currentVersion))
+	binary.LittleEndian.PutUint64(msg[4:], uint64(mine.features))
+	binary.LittleEndian.PutUint64(msg[12:], mine.maxMessage)
 	return writeFlat(w, wlock, versionMessage, 0, nil, msg[:])
 }
 
-// getVersion extracts the version number sent by the peer and picks the
-// appropriate version number to use for communicating with the peer.
-func getVersion(id uint64, msg []byte) (version, error) {
+// getVersion extracts the settings sent by the peer and negotiates the
+// settings to use for communicating with the peer: the minimum of the peer
+// and my version numbers, the features supported by both of us, and the
+// largest message accepted by the peer.
+func getVersion(id uint64, msg []byte, mine settings) (settings, error) {
 	if id != 0 {
-		return 0, fmt.Errorf("invalid ID %d in handshake", id)
+		return settings{}, fmt.Errorf("invalid ID %d in handshake", id)
 	}
 	// Allow messages longer than needed so that future updates can send more info.
 	if len(msg) < 4 {
-		return 0, fmt.Errorf("bad version message length %d, must be >= 4", len(msg))
+		return settings{}, fmt.Errorf("bad version message length %d, must be >= 4", len(msg))
 	}
 	v := binary.LittleEndian.Uint32(msg)
 
 	// We use the minimum of the peer and my version numbers.
+	peer := settings{version: currentVersion, maxMessage: defaultMaxMessageSize}
 	if v < uint32(currentVersion) {
-		return version(v), nil
+		peer.version = version(v)
+	}
+	if peer.version < featuresVersion {
+		// The peer doesn't support any features.
+		return peer, nil
+	}
+	if len(msg) < 20 {
+		return settings{}, fmt.Errorf("bad version message length %d, must be >= 20", len(msg))
+	}
+	peer.features = feature(binary.LittleEndian.Uint64(msg[4:])) & mine.features
+	if n := binary.LittleEndian.Uint64(msg[12:]); n > 0 {
+		peer.maxMessage = n
+	}
+	return peer, nil
+}
+
+// encrypted returns whether a connection is encrypted at both ends, given my
+// settings and the settings negotiated with the peer. Peers that predate
+// feature negotiation can't report whether their end is encrypted, so only my
+// end is checked for them.
+func encrypted(mine, negotiated settings) bool {
+	if mine.features&featureEncrypted == 0 {
+		return false
+	}
+	return negotiated.version < featuresVersion || negotiated.features&featureEncrypted != 0
+}
+
+// checksummed returns checksumFlag if messages sent to the peer should have a
+// checksum, given whether checksums are enabled locally and the settings
+// negotiated with the peer, or zero otherwise.
+func checksummed(enabled bool, negotiated settings) messageType {
+	if enabled && negotiated.features&featureChecksum != 0 {
+		return checksumFlag
+	}
+	return 0
+}
+
+// compress returns the DEFLATE compressed data.
+func compress(data []byte) []byte {
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		panic(err) // unreachable: the compression level is valid
+	}
+	w.Write(data) // writes to a bytes.Buffer never fail
+	w.Close()
+	return buf.Bytes()
+}
+
+// decompress returns the decompressed DEFLATE compressed data. It fails if
+// the decompressed data is larger than maxSize bytes.
+func decompress(data []byte, maxSize uint64) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(out)) > maxSize {
+		return nil, fmt.Errorf("decompressed payload exceeds %d bytes", maxSize)
 	}
-	return currentVersion, nil
+	return out, nil
 }
@@ -16,9 +16,12 @@ package call
 
 import (
 	"bytes"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/rand"
 	"net"
+	"slices"
 	"sync"
 	"testing"
 	"time"
@@ -60,7 +63,7 @@ func TestConcurrentWrites(t *testing.T) {
 
 	reader := func() error {
 		for i := 0; i < numWriters*numWrites; i++ {
-			mt, id, payload, err := readMessage(server)
+			mt, id, payload, err := readMessage(server, 0)
 			if err != nil {
 				return err
 			}
@@ -115,7 +118,7 @@ func BenchmarkReadWrite(b *testing.B) {
 					done := make(chan bool)
 					go func() {
 						for n := 0; n < numIters; n++ {
-							if _, _, _, err := readMessage(in); err != nil {
+							if _, _, _, err := readMessage(in, 0); err != nil {
 								panic(fmt.Sprint(err))
 							}
 						}
@@ -148,3 +151,113 @@ func sizeString(s int) string {
 	}
 	return fmt.Sprint(s)
 }
+
+func TestNegotiateSettings(t *testing.T) {
+	mine := settings{version: currentVersion, features: featureFlate, maxMessage: 1 << 20}
+	for _, test := range []struct {
+		name string
+		peer []byte // the peer's version message
+		want settings
+	}{
+		{
+			name: "blobVersion",
+			peer: []byte{byte(blobVersion), 0, 0, 0},
+			want: settings{version: blobVersion, maxMessage: defaultMaxMessageSize},
+		},
+		{
+			name: "featuresVersion",
+			peer: versionMessageOf(settings{version: featuresVersion, features: featureFlate | featureEncrypted, maxMessage: 4096}),
+			want: settings{version: featuresVersion, features: featureFlate, maxMessage: 4096},
+		},
+		{
+			name: "no limit",
+			peer: versionMessageOf(settings{version: featuresVersion}),
+			want: settings{version: featuresVersion, maxMessage: defaultMaxMessageSize},
+		},
+		{
+			name: "newer version",
+			peer: append(versionMessageOf(settings{version: currentVersion + 1, features: featureFlate, maxMessage: 4096}), 42),
+			want: settings{version: currentVersion, features: featureFlate, maxMessage: 4096},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := getVersion(0, test.peer, mine)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Fatalf("getVersion: got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// versionMessageOf returns the payload of the versionMessage that holds the
+// provided settings.
+func versionMessageOf(s settings) []byte {
+	msg := make([]byte, 20)
+	binary.LittleEndian.PutUint32(msg[0:], uint32(s.version))
+	binary.LittleEndian.PutUint64(msg[4:], uint64(s.features))
+	binary.LittleEndian.PutUint64(msg[12:], s.maxMessage)
+	return msg
+}
+
+func TestCompress(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible"), 1000)
+	compressed := compress(data)
+	if len(compressed) >= len(data) {
+		t.Fatalf("compress: got %d bytes, want fewer than %d", len(compressed), len(data))
+	}
+	got, err := decompress(compressed, uint64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("decompress: got %d bytes, want %d", len(got), len(data))
+	}
+	if _, err := decompress(compressed, uint64(len(data)-1)); err == nil {
+		t.Fatal("decompress: unexpected success for data over the limit")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	extraHdr := []byte{1, 2, 3, 4}
+	payload := bytes.Repeat([]byte{5}, 100)
+	for _, flatten := range []bool{true, false} {
+		write := writeChunked
+		if flatten {
+			write = writeFlat
+		}
+		for _, corrupt := range []int{-1, 0, 8, 16, 120} {
+			name := fmt.Sprintf("flatten=%t/corrupt=%d", flatten, corrupt)
+			t.Run(name, func(t *testing.T) {
+				var buf bytes.Buffer
+				var wlock sync.Mutex
+				if err := write(&buf, &wlock, requestMessage|compressedFlag|checksumFlag, 42, extraHdr, payload); err != nil {
+					t.Fatal(err)
+				}
+				data := buf.Bytes()
+				if corrupt >= 0 {
+					data[corrupt] ^= 0x10
+				}
+
+				mt, id, msg, err := readMessage(bytes.NewReader(data), 0)
+				if corrupt >= 0 {
+					if !errors.Is(err, errCorrupted) {
+						t.Fatalf("readMessage: got %v, want %v", err, errCorrupted)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mt != requestMessage|compressedFlag || id != 42 {
+					t.Fatalf("readMessage: got (%d, %d), want (%d, 42)", mt, id, requestMessage|compressedFlag)
+				}
+				if want := append(slices.Clone(extraHdr), payload...); !bytes.Equal(msg, want) {
+					t.Fatalf("readMessage: got %v, want %v", msg, want)
+				}
+			})
+		}
+	}
+}
@@ -26,6 +26,7 @@ import (
 const (
 	defaultWriteFlattenLimit     = 4 << 10
 	defaultInlineHandlerDuration = 20 * time.Microsecond
+	defaultBlobThreshold         = 4 << 20
 )
 
 // ClientOptions are the options to configure an RPC client.
@@ -44,6 +45,32 @@ type ClientOptions struct {
 	// buffer before being written on the connection. If zero, an appropriate
 	// value is picked automatically. If negative, no flattening is done.
 	WriteFlattenLimit int
+
+	// Call arguments larger than this many bytes are sent out of band, over
+	// a separate network connection, to avoid delaying other calls on the
+	// shared connection. If zero, an appropriate value is picked
+	// automatically. If negative, arguments are never sent out of band.
+	BlobThreshold int
+
+	// Call arguments larger than this many bytes are compressed, if the
+	// server supports compression. If zero or negative, arguments are never
+	// compressed.
+	CompressThreshold int
+
+	// The largest message, in bytes, accepted from a server. The limit is
+	// sent to the server when connecting, so that the server rejects larger
+	// results rather than sending them. If zero, an appropriate value is
+	// picked automatically.
+	MaxMessageSize int
+
+	// If true, connections to servers fail unless both ends of the connection
+	// are encrypted (e.g., with TLS).
+	RequireEncryption bool
+	// If true, messages sent to servers that support checksums carry a
+	// checksum of their contents. A server that receives a corrupted message
+	// closes the connection, failing the calls in progress on it with a
+	// CommunicationError.
+	Checksum bool
 }
 
 // ServerOption are the options to configure an RPC server.
@@ -65,20 +92,57 @@ type ServerOptions struct {
 	// buffer before being written on the connection. If zero, an appropriate
 	// value is picked automatically. If negative, no flattening is done.
 	WriteFlattenLimit int
+
+	// Call results larger than this many bytes are sent out of band, over a
+	// separate network connection, to avoid delaying other calls on the
+	// shared connection. If zero, an appropriate value is picked
+	// automatically. If negative, results are never sent out of band.
+	BlobThreshold int
+
+	// Call results larger than this many bytes are compressed, if the client
+	// supports compression. If zero or negative, results are never
+	// compressed.
+	CompressThreshold int
+
+	// The largest message, in bytes, accepted from a client. The limit is
+	// sent to the client when it connects, so that the client rejects larger
+	// arguments rather than sending them. If zero, an appropriate value is
+	// picked automatically.
+	MaxMessageSize int
+
+	// If true, connections from clients fail unless both ends of the
+	// connection are encrypted (e.g., with TLS).
+	RequireEncryption bool
+	// If true, messages sent to clients that support checksums carry a
+	// checksum of their contents. A client that receives a corrupted message
+	// closes the connection, failing the calls in progress on it with a
+	// CommunicationError.
+	Checksum bool
 }
 
 // CallOptions are call-specific options.
 type CallOptions struct {
-	// Retry indicates whether or not calls that failed due to communication
-	// errors should be retried.
+	// Retry indicates whether or not calls that failed with a retriable error
+	// code (see codegen.Code.Retriable), e.g., due to communication errors,
+	// should be retried.
 	Retry bool
 
+	// AtMostOnce indicates that the call must not be retried and that calls
+	// that fail after they may have reached the server should return an error
+	// that wraps MaybeCommitted. If AtMostOnce is true, Retry is ignored.
+	AtMostOnce bool
+
 	// ShardKey, if not 0, is the shard key that a Balancer can use to route a
 	// call. A Balancer can always choose to ignore the ShardKey.
 	//
 	// TODO(mwhittaker): Figure out a way to have 0 be a valid shard key. Could
 	// change to *uint64 for example.
 	ShardKey uint64
+
+	// Replica, if not empty, is the address of the replica the call is sent
+	// to. The Balancer is bypassed. If the replica is unknown, the call fails
+	// with an error that wraps Unreachable and is not retried.
+	Replica string
 }
 
 // withDefaults returns a copy of the ClientOptions with zero values replaced
@@ -93,6 +157,12 @@ func (c ClientOptions) withDefaults() ClientOptions {
 	if c.WriteFlattenLimit == 0 {
 		c.WriteFlattenLimit = defaultWriteFlattenLimit
 	}
+	if c.BlobThreshold == 0 {
+		c.BlobThreshold = defaultBlobThreshold
+	}
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = defaultMaxMessageSize
+	}
 	return c
 }
 
@@ -111,5 +181,11 @@ func (s ServerOptions) withDefaults() ServerOptions {
 	if s.WriteFlattenLimit == 0 {
 		s.WriteFlattenLimit = defaultWriteFlattenLimit
 	}
+	if s.BlobThreshold == 0 {
+		s.BlobThreshold = defaultBlobThreshold
+	}
+	if s.MaxMessageSize <= 0 {
+		s.MaxMessageSize = defaultMaxMessageSize
+	}
 	return s
 }
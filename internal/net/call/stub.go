@@ -16,34 +16,66 @@ package call
 
 import (
 	"context"
+	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/hedging"
+	"github.com/ServiceWeaver/weaver/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// hedgeLabels are the labels of the hedged calls metric.
+type hedgeLabels struct {
+	Component string // full callee component name
+	Method    string // callee component method's name
+	Outcome   string // "win", "loss", or "denied"
+	Generated bool   `weaver:"serviceweaver_generated"` // always true
+}
+
+var hedgeCounts = metrics.NewCounterMap[hedgeLabels](
+	"serviceweaver_call_hedges",
+	"Number of calls that were due to be hedged, by whether the hedged call won, lost, or was denied by the hedging budget",
+)
+
 // stub holds information about a client stub to the remote component.
 type stub struct {
-	conn          Connection   // connection to talk to the remote component
-	methods       []stubMethod // per method info
-	tracer        trace.Tracer // component tracer
-	injectRetries int          // Number of artificial retries per retriable call
+	conn          Connection      // connection to talk to the remote component
+	methods       []stubMethod    // per method info
+	tracer        trace.Tracer    // component tracer
+	injectRetries int             // Number of artificial retries per retriable call
+	hedger        *hedging.Hedger // hedges calls, or nil
 }
 
 type stubMethod struct {
-	key   MethodKey // key for remote component method
-	retry bool      // Whether or not the method should be retred
+	key        MethodKey     // key for remote component method
+	retry      bool          // Whether or not the method should be retred
+	atMostOnce bool          // Whether or not the method is at-most-once
+	hedgeDelay time.Duration // Delay after which calls are hedged, or zero
+	hedge      hedgeLabels   // Labels of the hedged calls metric
 }
 
 var _ codegen.Stub = &stub{}
 
 // NewStub creates a client-side stub of the type matching reg. Calls on the stub are sent on
-// conn to the component with the specified name.
-func NewStub(name string, reg *codegen.Registration, conn Connection, tracer trace.Tracer, injectRetries int) codegen.Stub {
+// conn to the component with the specified name. If hedger is not nil, calls
+// are hedged as configured by hedger.
+func NewStub(name string, reg *codegen.Registration, conn Connection, tracer trace.Tracer, injectRetries int, hedger *hedging.Hedger) codegen.Stub {
+	methods := makeStubMethods(name, reg)
+	for i := range methods {
+		if !methods[i].retry {
+			// Only retriable methods can safely run twice.
+			continue
+		}
+		method := reg.Iface.Method(i).Name
+		methods[i].hedgeDelay = hedger.Delay(name, method)
+		methods[i].hedge = hedgeLabels{Component: name, Method: method, Generated: true}
+	}
 	return &stub{
 		conn:          conn,
-		methods:       makeStubMethods(name, reg),
+		methods:       methods,
 		tracer:        tracer,
 		injectRetries: injectRetries,
+		hedger:        hedger,
 	}
 }
 
@@ -55,21 +87,135 @@ func (s *stub) Tracer() trace.Tracer {
 // Run implements the codegen.Stub interface.
 func (s *stub) Run(ctx context.Context, method int, args []byte, shardKey uint64) (result []byte, err error) {
 	m := s.methods[method]
+	replica := replicaFromContext(ctx)
+	retry := m.retry
+	if _, ok := codegen.IdempotencyKeyFromContext(ctx); ok && !m.atMostOnce && (replica != "" || shardKey != 0) {
+		// The callee deduplicates calls with an idempotency key, so it is
+		// safe to retry them, even if the method is not retriable. Calls are
+		// deduplicated per replica, so this only holds if the retry reaches
+		// the same replica, i.e., if the call is pinned to a replica or
+		// routed by its shard key.
+		retry = true
+	}
 	opts := CallOptions{
-		Retry:    m.retry,
-		ShardKey: shardKey,
+		Retry:      retry,
+		AtMostOnce: m.atMostOnce,
+		ShardKey:   shardKey,
+		Replica:    replica,
+	}
+
+	// Split the callee's hedging budget off the caller's, so that the calls
+	// made on behalf of a request share its budget.
+	var budget *codegen.HedgingBudget
+	if s.hedger != nil {
+		budget = s.hedger.Budget(ctx)
+	} else {
+		budget = codegen.HedgingBudgetFromContext(ctx)
 	}
+	callCtx := ctx
+	if budget != nil {
+		callCtx = codegen.WithHedgingBudget(ctx, budget.Split())
+	}
+
 	n := 1
-	if m.retry {
+	if retry {
 		n += s.injectRetries
 	}
 	for i := 0; i < n; i++ {
-		result, err = s.conn.Call(ctx, m.key, args, opts)
+		if m.hedgeDelay > 0 && opts.Replica == "" {
+			result, err = s.hedge(ctx, callCtx, m, args, opts, budget)
+		} else {
+			result, err = s.conn.Call(callCtx, m.key, args, opts)
+		}
 		// No backoff since these retries are fake ones injected for testing.
 	}
 	return
 }
 
+// hedge makes a call with the provided call context and, if it hasn't
+// returned after the method's hedge delay and the hedging budget allows it, a
+// hedged call with ctx and an empty budget. It returns the first successful
+// reply, once it has cancelled the other call and waited for it to return,
+// since the other call may still be reading args, which the caller is free to
+// reuse once hedge returns.
+func (s *stub) hedge(ctx, callCtx context.Context, m stubMethod, args []byte, opts CallOptions, budget *codegen.HedgingBudget) ([]byte, error) {
+	type reply struct {
+		result []byte
+		err    error
+		hedged bool
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	callCtx, cancelCall := context.WithCancel(callCtx)
+	defer cancelCall()
+	replies := make(chan reply, 2)
+	go func() {
+		result, err := s.conn.Call(callCtx, m.key, args, opts)
+		replies <- reply{result, err, false}
+	}()
+
+	timer := time.NewTimer(m.hedgeDelay)
+	defer timer.Stop()
+	select {
+	case r := <-replies:
+		return r.result, r.err
+	case <-timer.C:
+	}
+
+	labels := m.hedge
+	if !budget.Take() {
+		labels.Outcome = "denied"
+		hedgeCounts.Get(labels).Inc()
+		r := <-replies
+		return r.result, r.err
+	}
+	go func() {
+		ctx := codegen.WithHedgingBudget(ctx, codegen.NewHedgingBudget(0))
+		result, err := s.conn.Call(ctx, m.key, args, opts)
+		replies <- reply{result, err, true}
+	}()
+
+	// Return the first successful reply, or the last failed one.
+	r := <-replies
+	if r.err != nil {
+		r = <-replies
+	} else {
+		cancel()
+		cancelCall()
+		<-replies
+	}
+	labels.Outcome = "loss"
+	if r.hedged {
+		labels.Outcome = "win"
+	}
+	hedgeCounts.Get(labels).Inc()
+	return r.result, r.err
+}
+
+// Replicas returns the addresses of the replicas of the remote component that
+// calls can currently be sent to. See WithReplica.
+func (s *stub) Replicas() []string {
+	return s.conn.Replicas()
+}
+
+// replicaKey is the context key used to store the target replica of a call.
+type replicaKey struct{}
+
+// WithReplica returns a context that sends the calls made through a stub
+// returned by NewStub to the replica with the provided address, bypassing the
+// load balancer. Only the stub reads the replica; it is not propagated to the
+// callee.
+func WithReplica(ctx context.Context, addr string) context.Context {
+	return context.WithValue(ctx, replicaKey{}, addr)
+}
+
+// replicaFromContext returns the replica stored in ctx by WithReplica, or ""
+// if there is none.
+func replicaFromContext(ctx context.Context) string {
+	addr, _ := ctx.Value(replicaKey{}).(string)
+	return addr
+}
+
 // makeStubMethods returns a slice of stub methods for the component methods of reg.
 func makeStubMethods(fullName string, reg *codegen.Registration) []stubMethod {
 	// Construct method info slice.
@@ -83,5 +229,9 @@ func makeStubMethods(fullName string, reg *codegen.Registration) []stubMethod {
 	for _, m := range reg.NoRetry {
 		methods[m].retry = false
 	}
+	for _, m := range reg.AtMostOnce {
+		methods[m].retry = false
+		methods[m].atMostOnce = true
+	}
 	return methods
 }
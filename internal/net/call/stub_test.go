@@ -15,13 +15,18 @@
 package call
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/hedging"
 	"github.com/ServiceWeaver/weaver/internal/reflection"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/google/go-cmp/cmp"
@@ -39,6 +44,8 @@ func (c *localClient) Call(ctx context.Context, _ MethodKey, args []byte, _ Call
 
 func (c *localClient) Close() {}
 
+func (c *localClient) Replicas() []string { return nil }
+
 func TestCall(t *testing.T) {
 	type testCase struct {
 		fn      interface{}
@@ -253,6 +260,83 @@ func TestStubMethodRetries(t *testing.T) {
 	}
 }
 
+func TestStubMethodAtMostOnce(t *testing.T) {
+	reg := &codegen.Registration{
+		Name: "TestInterface",
+		Iface: reflection.Type[interface {
+			A()
+			B()
+			C()
+		}](),
+		NoRetry:    []int{1},
+		AtMostOnce: []int{2},
+	}
+	want := []stubMethod{
+		{key: MakeMethodKey(reg.Name, "A"), retry: true},
+		{key: MakeMethodKey(reg.Name, "B")},
+		{key: MakeMethodKey(reg.Name, "C"), atMostOnce: true},
+	}
+	got := makeStubMethods(reg.Name, reg)
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(stubMethod{})); diff != "" {
+		t.Errorf("stub methods (-want,+got):\n%s\n", diff)
+	}
+}
+
+// optionsConnection is a Connection that records the options of every call.
+type optionsConnection struct {
+	opts []CallOptions
+}
+
+var _ Connection = &optionsConnection{}
+
+func (c *optionsConnection) Call(_ context.Context, _ MethodKey, _ []byte, opts CallOptions) ([]byte, error) {
+	c.opts = append(c.opts, opts)
+	return nil, nil
+}
+
+func (c *optionsConnection) Close() {}
+
+func (c *optionsConnection) Replicas() []string { return nil }
+
+func TestStubIdempotencyKeyRetries(t *testing.T) {
+	reg := &codegen.Registration{
+		Name: "TestInterface",
+		Iface: reflection.Type[interface {
+			Retriable()
+			NotRetriable()
+			AtMostOnce()
+		}](),
+		NoRetry:    []int{1, 2},
+		AtMostOnce: []int{2},
+	}
+	keyed := codegen.WithIdempotencyKey(context.Background(), "key")
+	for _, test := range []struct {
+		name     string
+		ctx      context.Context
+		method   int
+		shardKey uint64
+		want     bool
+	}{
+		{"Retriable", context.Background(), 0, 0, true},
+		{"NotRetriable", context.Background(), 1, 0, false},
+		{"Unrouted", keyed, 1, 0, false},
+		{"Routed", keyed, 1, 42, true},
+		{"Pinned", WithReplica(keyed, "tcp://replica"), 1, 0, true},
+		{"AtMostOnce", keyed, 2, 42, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			conn := &optionsConnection{}
+			stub := NewStub(reg.Name, reg, conn, nil, 0, nil)
+			if _, err := stub.Run(test.ctx, test.method, nil, test.shardKey); err != nil {
+				t.Fatal(err)
+			}
+			if got := conn.opts[0].Retry; got != test.want {
+				t.Errorf("Retry: got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
 // convertCallPanicToError catches and returns errors detected during fn's execution.
 func convertCallPanicToError(fn func() error) (err error) {
 	defer func() {
@@ -334,3 +418,118 @@ func weaverDecode(d *codegen.Decoder, output interface{}) interface{} {
 		panic(fmt.Errorf("Unable to decode type %v with Service Weaver decoder\n", x))
 	}
 }
+
+// slowConnection is a Connection whose first call blocks until it is
+// cancelled, and whose other calls return immediately.
+type slowConnection struct {
+	mu      sync.Mutex
+	calls   int
+	budgets []int    // hedging budget of every call
+	args    [][]byte // arguments of every call, as read when it returns
+}
+
+var _ Connection = &slowConnection{}
+
+func (c *slowConnection) Call(ctx context.Context, _ MethodKey, args []byte, _ CallOptions) ([]byte, error) {
+	c.mu.Lock()
+	c.calls++
+	first := c.calls == 1
+	c.budgets = append(c.budgets, codegen.HedgingBudgetFromContext(ctx).Remaining())
+	c.mu.Unlock()
+	if first {
+		<-ctx.Done()
+	}
+
+	// Read the arguments as a call does when it sends them, or retries.
+	c.mu.Lock()
+	c.args = append(c.args, slices.Clone(args))
+	c.mu.Unlock()
+	if first {
+		return nil, ctx.Err()
+	}
+	return []byte("ok"), nil
+}
+
+func (c *slowConnection) Close() {}
+
+func (c *slowConnection) Replicas() []string { return nil }
+
+func TestStubHedging(t *testing.T) {
+	reg := &codegen.Registration{
+		Name:  "TestInterface",
+		Iface: reflection.Type[interface{ A() }](),
+	}
+	config := &hedging.Config{Methods: map[string]string{"TestInterface.A": "1ms"}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	conn := &slowConnection{}
+	s := NewStub(reg.Name, reg, conn, nil, 0, hedging.NewHedger(config))
+
+	// The first call is hedged, and the hedged call wins. The callee gets half
+	// of the budget, and the hedged call gets none.
+	budget := codegen.NewHedgingBudget(4)
+	ctx := codegen.WithHedgingBudget(context.Background(), budget)
+	if result, err := s.Run(ctx, 0, nil, 0); err != nil || string(result) != "ok" {
+		t.Fatalf("Run: got %q, %v, want %q, nil", result, err, "ok")
+	}
+	if diff := cmp.Diff([]int{2, 0}, conn.budgets); diff != "" {
+		t.Fatalf("budgets (-want +got):\n%s", diff)
+	}
+	if got := budget.Remaining(); got != 1 {
+		t.Fatalf("Remaining: got %d, want 1", got)
+	}
+
+	// Once the budget is exhausted, calls are not hedged.
+	budget.Take()
+	conn.calls, conn.budgets = 0, nil
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	if _, err := s.Run(ctx, 0, nil, 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Run: got %v, want context.DeadlineExceeded", err)
+	}
+	if got := conn.calls; got != 1 {
+		t.Fatalf("calls: got %d, want 1", got)
+	}
+}
+
+func TestStubHedgingReleasesArgs(t *testing.T) {
+	// Generated stubs release the encoder of a call's arguments as soon as
+	// Run returns, so a hedged call must not read its arguments afterwards,
+	// even if it lost. Run this test with -race.
+	reg := &codegen.Registration{
+		Name:  "TestInterface",
+		Iface: reflection.Type[interface{ A() }](),
+	}
+	config := &hedging.Config{Methods: map[string]string{"TestInterface.A": "1ms"}}
+	if err := config.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	conn := &slowConnection{}
+	s := NewStub(reg.Name, reg, conn, nil, 0, hedging.NewHedger(config))
+
+	// Encode enough arguments to take a buffer from the pool.
+	enc := codegen.NewPooledEncoder()
+	enc.String(strings.Repeat("x", 4096))
+	args := enc.Data()
+	want := slices.Clone(args)
+	ctx := codegen.WithHedgingBudget(context.Background(), codegen.NewHedgingBudget(4))
+	if result, err := s.Run(ctx, 0, args, 0); err != nil || string(result) != "ok" {
+		t.Fatalf("Run: got %q, %v, want %q, nil", result, err, "ok")
+	}
+
+	// Reuse the buffer, as the next call would.
+	clear(args)
+	enc.Release()
+
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if got := len(conn.args); got != 2 {
+		t.Fatalf("calls: got %d, want 2", got)
+	}
+	for i, got := range conn.args {
+		if !bytes.Equal(got, want) {
+			t.Errorf("call %d read corrupted arguments", i)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package notify implements the providers and templates of the weaver.Notifier
+// component.
+//
+// A provider delivers rendered messages over a channel like email, SMS, or a
+// webhook. Providers are selected and configured by name, using a flat map of
+// string settings taken from the config file. For example, the settings
+//
+//	{"provider": "smtp", "addr": "smtp.example.com:587", "from": "noreply@example.com"}
+//
+// configure a provider that sends email through an SMTP server.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Message is a rendered notification.
+type Message struct {
+	To      []string // recipients (e.g., email addresses, phone numbers)
+	Subject string   // subject, if supported by the channel
+	Body    string   // body
+}
+
+// Provider delivers messages over a channel.
+type Provider interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// providers maps provider names to provider constructors.
+var providers = map[string]func(settings) (Provider, error){
+	"smtp":    newSMTP,
+	"twilio":  newTwilio,
+	"webhook": newWebhook,
+}
+
+// New returns the provider configured by the provided settings. The
+// "provider" setting names the provider, and the remaining settings configure
+// it.
+func New(config map[string]string) (Provider, error) {
+	name := config["provider"]
+	if name == "" {
+		return nil, fmt.Errorf("missing provider")
+	}
+	newProvider, ok := providers[name]
+	if !ok {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown provider %q; want one of %s", name, strings.Join(names, ", "))
+	}
+	return newProvider(settings{provider: name, values: config})
+}
+
+// settings are the settings of a provider.
+type settings struct {
+	provider string
+	values   map[string]string
+}
+
+// get returns the provided setting, or def if it isn't set.
+func (s settings) get(name, def string) string {
+	if v, ok := s.values[name]; ok {
+		return v
+	}
+	return def
+}
+
+// required returns the provided setting, or an error if it isn't set.
+func (s settings) required(name string) (string, error) {
+	v := s.values[name]
+	if v == "" {
+		return "", fmt.Errorf("%s provider: missing %q setting", s.provider, name)
+	}
+	return v, nil
+}
+
+// secret returns the value of the environment variable named by the provided
+// setting, or by def if the setting isn't set. Secrets are read from the
+// environment, rather than the config file, so that they can be provisioned
+// by a secrets manager.
+func (s settings) secret(name, def string) string {
+	return os.Getenv(s.get(name, def))
+}
+
+// Template is a pair of text/template templates for a message's subject and
+// body.
+type Template struct {
+	subject *template.Template
+	body    *template.Template
+}
+
+// ParseTemplate parses the provided subject and body templates. Templates
+// fail to execute if they reference missing data.
+func ParseTemplate(name, subject, body string) (*Template, error) {
+	s, err := template.New(name + ".subject").Option("missingkey=error").Parse(subject)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %w", name, err)
+	}
+	b, err := template.New(name + ".body").Option("missingkey=error").Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("template %q: %w", name, err)
+	}
+	return &Template{subject: s, body: b}, nil
+}
+
+// Render renders the template's subject and body with the provided data.
+func (t *Template) Render(data map[string]string) (subject, body string, err error) {
+	var s, b bytes.Buffer
+	if err := t.subject.Execute(&s, data); err != nil {
+		return "", "", err
+	}
+	if err := t.body.Execute(&b, data); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate("welcome", "Welcome, {{.name}}!", "Your code is {{.code}}.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, body, err := tmpl.Render(map[string]string{"name": "Ada", "code": "42"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Welcome, Ada!"; subject != want {
+		t.Errorf("subject: got %q, want %q", subject, want)
+	}
+	if want := "Your code is 42."; body != want {
+		t.Errorf("body: got %q, want %q", body, want)
+	}
+
+	// Missing data is an error.
+	if _, _, err := tmpl.Render(map[string]string{"name": "Ada"}); err == nil {
+		t.Error("Render: unexpected success with missing data")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, config := range []map[string]string{
+		{},
+		{"provider": "pigeon"},
+		{"provider": "smtp", "from": "a@example.com"},
+		{"provider": "smtp", "addr": "no-port", "from": "a@example.com"},
+		{"provider": "webhook"},
+		{"provider": "twilio", "from": "+15550100", "account_sid_env": "NOTIFY_TEST_UNSET"},
+	} {
+		if _, err := New(config); err == nil {
+			t.Errorf("New(%v): unexpected success", config)
+		}
+	}
+}
+
+func TestSMTP(t *testing.T) {
+	p, err := New(map[string]string{
+		"provider": "smtp",
+		"addr":     "smtp.example.com:587",
+		"from":     "noreply@example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got string
+	p.(*smtpProvider).sendMail = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+		got = string(msg)
+		return nil
+	}
+	msg := Message{
+		To:      []string{"ada@example.com"},
+		Subject: "Hi\r\nBcc: eve@example.com",
+		Body:    "line 1\nline 2",
+	}
+	if err := p.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"From: noreply@example.com\r\n",
+		"To: ada@example.com\r\n",
+		"Subject: Hi  Bcc: eve@example.com\r\n",
+		"\r\n\r\nline 1\r\nline 2",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("message %q does not contain %q", got, want)
+		}
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	t.Setenv("NOTIFY_TEST_TOKEN", "secret")
+	type payload struct {
+		To      []string `json:"to"`
+		Subject string   `json:"subject"`
+		Body    string   `json:"body"`
+	}
+	var got payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	p, err := New(map[string]string{
+		"provider":  "webhook",
+		"url":       server.URL,
+		"token_env": "NOTIFY_TEST_TOKEN",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Send(context.Background(), Message{To: []string{"ops"}, Subject: "s", Body: "b"}); err != nil {
+		t.Fatal(err)
+	}
+	want := payload{To: []string{"ops"}, Subject: "s", Body: "b"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("payload (-want +got):\n%s", diff)
+	}
+}
+
+func TestTwilio(t *testing.T) {
+	t.Setenv("TWILIO_ACCOUNT_SID", "AC123")
+	t.Setenv("TWILIO_AUTH_TOKEN", "token")
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "AC123" || pass != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/2010-04-01/Accounts/AC123/Messages.json" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		got = append(got, r.FormValue("From")+"->"+r.FormValue("To")+": "+r.FormValue("Body"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	p, err := New(map[string]string{"provider": "twilio", "from": "+15550100", "url": server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{To: []string{"+15550101", "+15550102"}, Body: "hi"}
+	if err := p.Send(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"+15550100->+15550101: hi", "+15550100->+15550102: hi"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("messages (-want +got):\n%s", diff)
+	}
+}
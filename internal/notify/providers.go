@@ -0,0 +1,203 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strings"
+)
+
+// smtpProvider sends email through an SMTP server. Settings:
+//
+//   - addr: address of the SMTP server (e.g., "smtp.example.com:587").
+//   - from: sender address.
+//   - username_env: env var holding the SMTP username (default SMTP_USERNAME).
+//   - password_env: env var holding the SMTP password (default SMTP_PASSWORD).
+//
+// If no username is set, mail is sent without authentication.
+type smtpProvider struct {
+	addr     string
+	from     string
+	auth     smtp.Auth
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+func newSMTP(s settings) (Provider, error) {
+	addr, err := s.required("addr")
+	if err != nil {
+		return nil, err
+	}
+	from, err := s.required("from")
+	if err != nil {
+		return nil, err
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("smtp provider: invalid addr %q: %w", addr, err)
+	}
+	p := &smtpProvider{addr: addr, from: from, sendMail: smtp.SendMail}
+	if username := s.secret("username_env", "SMTP_USERNAME"); username != "" {
+		p.auth = smtp.PlainAuth("", username, s.secret("password_env", "SMTP_PASSWORD"), host)
+	}
+	return p, nil
+}
+
+// Send implements the Provider interface.
+func (p *smtpProvider) Send(_ context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("smtp provider: no recipients")
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", header(p.from))
+	fmt.Fprintf(&b, "To: %s\r\n", header(strings.Join(msg.To, ", ")))
+	fmt.Fprintf(&b, "Subject: %s\r\n", header(msg.Subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return p.sendMail(p.addr, p.auth, p.from, msg.To, []byte(b.String()))
+}
+
+// header returns v with line breaks replaced by spaces, so that v can't inject
+// additional email headers.
+func header(v string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(v)
+}
+
+// twilioProvider sends SMS messages through the Twilio Messages API, one
+// message per recipient. Settings:
+//
+//   - from: sender phone number.
+//   - account_sid_env: env var holding the account SID (default TWILIO_ACCOUNT_SID).
+//   - auth_token_env: env var holding the auth token (default TWILIO_AUTH_TOKEN).
+//   - url: base URL of the API (default "https://api.twilio.com").
+type twilioProvider struct {
+	url    string
+	from   string
+	sid    string
+	token  string
+	client *http.Client
+}
+
+func newTwilio(s settings) (Provider, error) {
+	from, err := s.required("from")
+	if err != nil {
+		return nil, err
+	}
+	p := &twilioProvider{
+		url:    strings.TrimSuffix(s.get("url", "https://api.twilio.com"), "/"),
+		from:   from,
+		sid:    s.secret("account_sid_env", "TWILIO_ACCOUNT_SID"),
+		token:  s.secret("auth_token_env", "TWILIO_AUTH_TOKEN"),
+		client: http.DefaultClient,
+	}
+	if p.sid == "" || p.token == "" {
+		return nil, fmt.Errorf("twilio provider: missing credentials")
+	}
+	return p, nil
+}
+
+// Send implements the Provider interface.
+func (p *twilioProvider) Send(ctx context.Context, msg Message) error {
+	if len(msg.To) == 0 {
+		return fmt.Errorf("twilio provider: no recipients")
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", p.url, url.PathEscape(p.sid))
+	for _, to := range msg.To {
+		form := url.Values{"From": {p.from}, "To": {to}, "Body": {msg.Body}}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(p.sid, p.token)
+		if err := do(p.client, req); err != nil {
+			return fmt.Errorf("twilio provider: send to %q: %w", to, err)
+		}
+	}
+	return nil
+}
+
+// webhookProvider posts messages as JSON to a URL. Settings:
+//
+//   - url: URL to post messages to.
+//   - token_env: env var holding a bearer token to authorize requests with.
+//     If unset, requests are not authorized.
+//
+// The posted JSON object has fields "to", "subject", and "body".
+type webhookProvider struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newWebhook(s settings) (Provider, error) {
+	u, err := s.required("url")
+	if err != nil {
+		return nil, err
+	}
+	p := &webhookProvider{url: u, client: http.DefaultClient}
+	if s.get("token_env", "") != "" {
+		p.token = s.secret("token_env", "")
+	}
+	return p, nil
+}
+
+// Send implements the Provider interface.
+func (p *webhookProvider) Send(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(struct {
+		To      []string `json:"to"`
+		Subject string   `json:"subject,omitempty"`
+		Body    string   `json:"body"`
+	}{msg.To, msg.Subject, msg.Body})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	if err := do(p.client, req); err != nil {
+		return fmt.Errorf("webhook provider: %w", err)
+	}
+	return nil
+}
+
+// do issues the provided request, returning an error if it fails or if the
+// response status isn't 2xx.
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+	return nil
+}
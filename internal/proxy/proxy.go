@@ -20,6 +20,7 @@ import (
 	"math/rand"
 	"net/http"
 	"net/http/httputil"
+	"slices"
 	"sync"
 )
 
@@ -43,14 +44,20 @@ func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	p.reverse.ServeHTTP(w, r)
 }
 
-// AddBackend adds a backend to the proxy. Note that backends cannot be
-// removed.
+// AddBackend adds a backend to the proxy.
 func (p *Proxy) AddBackend(backend string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 	p.backends = append(p.backends, backend)
 }
 
+// RemoveBackend removes a backend from the proxy, if present.
+func (p *Proxy) RemoveBackend(backend string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.backends = slices.DeleteFunc(p.backends, func(b string) bool { return b == backend })
+}
+
 // director implements a ReverseProxy.Director function [1].
 //
 // [1]: https://pkg.go.dev/net/http/httputil#ReverseProxy
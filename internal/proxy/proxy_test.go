@@ -161,3 +161,47 @@ func TestProxyConcurrentAddBackend(t *testing.T) {
 		t.Fatalf("unexpected response body got: %s", string(b))
 	}
 }
+
+// TestProxyRemoveBackend verifies that the proxy stops forwarding requests to
+// a backend once the backend is removed.
+func TestProxyRemoveBackend(t *testing.T) {
+	// Create two backend servers.
+	newBackend := func(response string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(response))
+		}))
+		t.Cleanup(server.Close)
+		u, err := url.Parse(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u.Host
+	}
+	removed := newBackend("removed")
+	kept := newBackend("kept")
+
+	// Create a proxy with both backends, and then remove one of them.
+	proxy := NewProxy(slog.Default())
+	proxy.AddBackend(removed)
+	proxy.AddBackend(kept)
+	proxy.RemoveBackend(removed)
+
+	frontend := httptest.NewServer(proxy)
+	defer frontend.Close()
+
+	// Every request should be forwarded to the remaining backend.
+	for i := 0; i < 10; i++ {
+		res, err := http.Get(frontend.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := string(b), "kept"; got != want {
+			t.Fatalf("got body %q; expected %q", got, want)
+		}
+	}
+}
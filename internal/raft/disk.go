@@ -0,0 +1,338 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/uuid"
+)
+
+// A DiskStorage directory holds the following files:
+//
+//	LOCK      locked by the process using the directory
+//	snapshot  the latest snapshot, in a single record
+//	log       the hard state and entries, appended as records
+//
+// A record is a 4 byte length, a 4 byte CRC-32C checksum, and a payload. The
+// first byte of a payload is its kind. The log starts with a recBase record
+// with the index and term of the snapshot it follows. A torn record at the end
+// of the log, left by a crash, is discarded.
+const (
+	lockFile     = "LOCK"
+	snapshotFile = "snapshot"
+	logFile      = "log"
+)
+
+// Record kinds.
+const (
+	recHardState byte = iota + 1
+	recEntries
+	recBase
+	recSnapshot
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// errLocked is returned by lock if another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+// DiskStorage is a Storage that persists its state to files in a directory.
+type DiskStorage struct {
+	dir  string
+	lock *os.File
+
+	mu  sync.Mutex
+	log *os.File // opened for appending
+}
+
+var _ Storage = &DiskStorage{}
+
+// OpenDisk opens the storage of a node in a subdirectory of dir, which holds
+// the storage of every node of a cluster that runs on the machine. It reuses
+// the subdirectory of a node that no running process uses, or creates one for
+// a new node. It returns the node's ID, which is the name of the
+// subdirectory.
+func OpenDisk(dir string) (string, *DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", nil, err
+	}
+	dirs, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		s, err := openDisk(filepath.Join(dir, d.Name()))
+		if errors.Is(err, errLocked) {
+			continue
+		}
+		if err != nil {
+			return "", nil, err
+		}
+		return d.Name(), s, nil
+	}
+
+	id := uuid.NewString()
+	if err := os.Mkdir(filepath.Join(dir, id), 0700); err != nil {
+		return "", nil, err
+	}
+	s, err := openDisk(filepath.Join(dir, id))
+	if err != nil {
+		return "", nil, err
+	}
+	return id, s, nil
+}
+
+// openDisk locks and opens the storage in the provided directory.
+func openDisk(dir string) (*DiskStorage, error) {
+	l, err := lock(filepath.Join(dir, lockFile))
+	if err != nil {
+		return nil, err
+	}
+	log, err := os.OpenFile(filepath.Join(dir, logFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		l.Close()
+		return nil, err
+	}
+	return &DiskStorage{dir: dir, lock: l, log: log}, nil
+}
+
+// Close closes the storage and releases its directory.
+func (d *DiskStorage) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	err := d.log.Close()
+	if lerr := d.lock.Close(); err == nil {
+		err = lerr
+	}
+	return err
+}
+
+// Load implements the Storage interface.
+func (d *DiskStorage) Load() (HardState, Snapshot, []Entry, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.read()
+}
+
+// SaveHardState implements the Storage interface.
+func (d *DiskStorage) SaveHardState(hs HardState) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.appendRecord(hardStateRecord(hs))
+}
+
+// Append implements the Storage interface.
+func (d *DiskStorage) Append(entries []Entry) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.appendRecord(entriesRecord(entries))
+}
+
+// SaveSnapshot implements the Storage interface.
+func (d *DiskStorage) SaveSnapshot(s Snapshot) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hs, snap, entries, err := d.read()
+	if err != nil {
+		return err
+	}
+	if s.Index <= snap.Index {
+		return nil
+	}
+	entries = compactEntries(snap, entries, s)
+
+	// Write the snapshot, then rewrite the log to follow it. If the process
+	// crashes in between, read discards the entries the snapshot covers.
+	enc := codegen.NewEncoder()
+	enc.Byte(recSnapshot)
+	encodeSnapshot(enc, s)
+	if err := d.replace(snapshotFile, frame(enc.Data())); err != nil {
+		return err
+	}
+	enc = codegen.NewEncoder()
+	enc.Byte(recBase)
+	enc.Uint64(s.Index)
+	enc.Uint64(s.Term)
+	data := frame(enc.Data())
+	data = append(data, frame(hardStateRecord(hs))...)
+	if len(entries) > 0 {
+		data = append(data, frame(entriesRecord(entries))...)
+	}
+	if err := d.replace(logFile, data); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(filepath.Join(d.dir, logFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	d.log.Close()
+	d.log = log
+	return nil
+}
+
+// read reads the persisted state.
+//
+// REQUIRES: d.mu is held.
+func (d *DiskStorage) read() (hs HardState, snap Snapshot, entries []Entry, err error) {
+	defer func() {
+		if e := codegen.CatchPanics(recover()); e != nil {
+			err = fmt.Errorf("raft: corrupt storage in %s: %w", d.dir, e)
+		}
+	}()
+
+	// Read the snapshot.
+	records, _, err := readRecords(filepath.Join(d.dir, snapshotFile))
+	if err != nil {
+		return HardState{}, Snapshot{}, nil, err
+	}
+	if len(records) > 0 {
+		dec := codegen.NewDecoder(records[0])
+		if kind := dec.Byte(); kind != recSnapshot {
+			return HardState{}, Snapshot{}, nil, fmt.Errorf("raft: corrupt snapshot in %s: record kind %d", d.dir, kind)
+		}
+		snap = decodeSnapshot(dec)
+	}
+
+	// Replay the log.
+	records, valid, err := readRecords(filepath.Join(d.dir, logFile))
+	if err != nil {
+		return HardState{}, Snapshot{}, nil, err
+	}
+	var base Snapshot
+	for _, r := range records {
+		dec := codegen.NewDecoder(r)
+		switch kind := dec.Byte(); kind {
+		case recBase:
+			base = Snapshot{Index: dec.Uint64(), Term: dec.Uint64()}
+		case recHardState:
+			hs = HardState{Term: dec.Uint64(), Vote: dec.String()}
+		case recEntries:
+			entries = appendEntries(base, entries, decodeEntries(dec))
+		default:
+			return HardState{}, Snapshot{}, nil, fmt.Errorf("raft: corrupt log in %s: record kind %d", d.dir, kind)
+		}
+	}
+	if snap.Index > base.Index {
+		entries = compactEntries(base, entries, snap)
+	}
+
+	// Discard a torn record at the end of the log.
+	if err := os.Truncate(filepath.Join(d.dir, logFile), valid); err != nil {
+		return HardState{}, Snapshot{}, nil, err
+	}
+	return hs, snap, entries, nil
+}
+
+// appendRecord appends a record to the log and syncs it.
+//
+// REQUIRES: d.mu is held.
+func (d *DiskStorage) appendRecord(payload []byte) error {
+	if _, err := d.log.Write(frame(payload)); err != nil {
+		return err
+	}
+	return d.log.Sync()
+}
+
+// replace atomically replaces the named file with the provided data.
+func (d *DiskStorage) replace(name string, data []byte) error {
+	tmp := filepath.Join(d.dir, name+".tmp")
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, filepath.Join(d.dir, name)); err != nil {
+		return err
+	}
+	return syncDir(d.dir)
+}
+
+// hardStateRecord returns the payload of a recHardState record.
+func hardStateRecord(hs HardState) []byte {
+	enc := codegen.NewEncoder()
+	enc.Byte(recHardState)
+	enc.Uint64(hs.Term)
+	enc.String(hs.Vote)
+	return enc.Data()
+}
+
+// entriesRecord returns the payload of a recEntries record.
+func entriesRecord(entries []Entry) []byte {
+	enc := codegen.NewEncoder()
+	enc.Byte(recEntries)
+	encodeEntries(enc, entries)
+	return enc.Data()
+}
+
+// frame returns a record with the provided payload.
+func frame(payload []byte) []byte {
+	data := make([]byte, 8, 8+len(payload))
+	binary.LittleEndian.PutUint32(data, uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[4:], crc32.Checksum(payload, castagnoli))
+	return append(data, payload...)
+}
+
+// readRecords returns the payloads of the valid records at the start of the
+// named file, and the length of the valid prefix of the file. A missing file
+// has no records.
+func readRecords(filename string) ([][]byte, int64, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var records [][]byte
+	var valid int64
+	for {
+		if len(data) < 8 {
+			return records, valid, nil
+		}
+		n := binary.LittleEndian.Uint32(data)
+		sum := binary.LittleEndian.Uint32(data[4:])
+		if uint64(len(data)-8) < uint64(n) {
+			return records, valid, nil
+		}
+		payload := data[8 : 8+n]
+		if crc32.Checksum(payload, castagnoli) != sum {
+			return records, valid, nil
+		}
+		records = append(records, payload)
+		data = data[8+n:]
+		valid += 8 + int64(n)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"fmt"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// EncodeMessages encodes messages for transmission to another node.
+func EncodeMessages(msgs []Message) []byte {
+	enc := codegen.NewEncoder()
+	enc.Uint32(uint32(len(msgs)))
+	for _, m := range msgs {
+		enc.Uint8(uint8(m.Type))
+		enc.String(m.From)
+		enc.String(m.To)
+		enc.Uint64(m.Term)
+		enc.Uint64(m.LogTerm)
+		enc.Uint64(m.Index)
+		enc.Uint64(m.Commit)
+		encodeEntries(enc, m.Entries)
+		encodeSnapshot(enc, m.Snapshot)
+		enc.Bool(m.Reject)
+		enc.Uint64(m.Context)
+	}
+	return enc.Data()
+}
+
+// DecodeMessages decodes messages encoded by EncodeMessages.
+func DecodeMessages(data []byte) (msgs []Message, err error) {
+	defer func() {
+		if e := codegen.CatchPanics(recover()); e != nil {
+			err = fmt.Errorf("raft: decode messages: %w", e)
+		}
+	}()
+	dec := codegen.NewDecoder(data)
+	n := dec.Uint32()
+	for i := uint32(0); i < n; i++ {
+		var m Message
+		m.Type = MessageType(dec.Uint8())
+		m.From = dec.String()
+		m.To = dec.String()
+		m.Term = dec.Uint64()
+		m.LogTerm = dec.Uint64()
+		m.Index = dec.Uint64()
+		m.Commit = dec.Uint64()
+		m.Entries = decodeEntries(dec)
+		m.Snapshot = decodeSnapshot(dec)
+		m.Reject = dec.Bool()
+		m.Context = dec.Uint64()
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func encodeEntries(enc *codegen.Encoder, entries []Entry) {
+	enc.Uint32(uint32(len(entries)))
+	for _, e := range entries {
+		enc.Uint64(e.Term)
+		enc.Uint64(e.Index)
+		enc.Uint8(uint8(e.Type))
+		enc.Bytes(e.Data)
+	}
+}
+
+func decodeEntries(dec *codegen.Decoder) []Entry {
+	n := dec.Uint32()
+	if n == 0 {
+		return nil
+	}
+	entries := make([]Entry, n)
+	for i := range entries {
+		entries[i].Term = dec.Uint64()
+		entries[i].Index = dec.Uint64()
+		entries[i].Type = EntryType(dec.Uint8())
+		entries[i].Data = dec.Bytes()
+	}
+	return entries
+}
+
+func encodeSnapshot(enc *codegen.Encoder, s Snapshot) {
+	enc.Uint64(s.Index)
+	enc.Uint64(s.Term)
+	enc.Bytes(s.Data)
+}
+
+func decodeSnapshot(dec *codegen.Decoder) Snapshot {
+	return Snapshot{Index: dec.Uint64(), Term: dec.Uint64(), Data: dec.Bytes()}
+}
@@ -0,0 +1,33 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package raft
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// lock returns an error. Disk storage is not supported on this platform.
+func lock(string) (*os.File, error) {
+	return nil, fmt.Errorf("raft: disk storage is not supported on %s", runtime.GOOS)
+}
+
+// syncDir is a no-op.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,862 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package raft implements the Raft consensus algorithm [1], which replicates
+// a log of entries across a cluster of nodes.
+//
+// A Node is a deterministic state machine with no goroutines, timers, or
+// network connections of its own. Its driver feeds it clock ticks (Tick),
+// messages from other nodes (Step), and requests (Propose, ReadIndex), and
+// drains its output (Ready): the messages to send to other nodes, the
+// committed entries to apply, and the reads that are safe to serve. A Node
+// persists its state to a Storage before it produces any output that depends
+// on it.
+//
+// The members of a cluster are the node itself and the peers passed to
+// SetPeers. Decisions require a majority of the larger of the number of
+// members and the configured cluster size, so that nodes that haven't
+// discovered each other yet can't form two majorities.
+//
+// [1]: https://raft.github.io/raft.pdf
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ErrNotLeader is returned by requests that only the leader can serve.
+var ErrNotLeader = errors.New("raft: not the leader")
+
+// EntryType is the type of a log entry.
+type EntryType uint8
+
+const (
+	EntryNormal EntryType = iota // an entry proposed with Propose
+	EntryNoop                    // an empty entry appended by a new leader
+)
+
+// Entry is a log entry.
+type Entry struct {
+	Term  uint64
+	Index uint64
+	Type  EntryType
+	Data  []byte
+}
+
+// Snapshot is a snapshot of the state that results from applying the log up
+// to and including the entry at Index, whose term is Term.
+type Snapshot struct {
+	Index uint64
+	Term  uint64
+	Data  []byte
+}
+
+// HardState is the state a node persists besides its log.
+type HardState struct {
+	Term uint64 // the latest term the node has seen
+	Vote string // the candidate the node voted for in Term, if any
+}
+
+// MessageType is the type of a message.
+type MessageType uint8
+
+const (
+	MsgVote          MessageType = iota + 1 // a candidate requests a vote
+	MsgVoteResp                             // a node grants or rejects a vote
+	MsgApp                                  // the leader appends entries
+	MsgAppResp                              // a follower acknowledges or rejects entries
+	MsgSnap                                 // the leader installs a snapshot
+	MsgHeartbeat                            // the leader asserts its leadership
+	MsgHeartbeatResp                        // a follower acknowledges a heartbeat
+)
+
+// Message is a message between nodes.
+type Message struct {
+	Type MessageType
+	From string
+	To   string
+	Term uint64
+
+	// LogTerm and Index are the term and index of a candidate's last entry
+	// in a MsgVote, and of the entry that precedes Entries in a MsgApp. In a
+	// MsgAppResp, Index is the index of the follower's last entry that
+	// matches the leader's log or, if Reject is true, a hint of where the
+	// logs may match.
+	LogTerm uint64
+	Index   uint64
+
+	// Commit is the leader's commit index in a MsgApp or MsgHeartbeat.
+	Commit uint64
+
+	Entries  []Entry  // the entries of a MsgApp
+	Snapshot Snapshot // the snapshot of a MsgSnap
+	Reject   bool     // is a MsgVoteResp or MsgAppResp a rejection?
+	Context  uint64   // the read round of a MsgHeartbeat or MsgHeartbeatResp
+}
+
+// ReadState is a read requested with ReadIndex. The read may be served once
+// the entries up to and including Index are applied, unless Dropped is true.
+type ReadState struct {
+	ID      uint64
+	Index   uint64
+	Dropped bool // did the node lose its leadership before confirming the read?
+}
+
+// Ready is the output of a node.
+type Ready struct {
+	// Messages are the messages to send to other nodes.
+	Messages []Message
+
+	// Snapshot, if not nil, is a snapshot to restore before applying
+	// CommittedEntries.
+	Snapshot *Snapshot
+
+	// CommittedEntries are the committed entries to apply, in order.
+	CommittedEntries []Entry
+
+	// ReadStates are the reads that may be served.
+	ReadStates []ReadState
+}
+
+// Config configures a node.
+type Config struct {
+	// ID is the unique ID of the node.
+	ID string
+
+	// ClusterSize is the minimum number of members of the cluster used to
+	// compute majorities.
+	ClusterSize int
+
+	// ElectionTicks is the number of ticks without hearing from a leader
+	// after which a follower starts an election. The actual timeout is
+	// randomized between ElectionTicks and twice ElectionTicks. Defaults
+	// to 10.
+	ElectionTicks int
+
+	// HeartbeatTicks is the number of ticks between heartbeats sent by the
+	// leader. Defaults to 1.
+	HeartbeatTicks int
+
+	// MaxEntries is the maximum number of entries in a MsgApp. Defaults to
+	// 256.
+	MaxEntries int
+
+	// Storage persists the node's state.
+	Storage Storage
+
+	// Rand randomizes election timeouts. Defaults to a source seeded with
+	// the current time.
+	Rand *rand.Rand
+}
+
+// role is the role of a node.
+type role int
+
+const (
+	follower role = iota
+	candidate
+	leader
+)
+
+// progress is the leader's view of a follower.
+type progress struct {
+	match   uint64 // index of the follower's last entry known to match
+	next    uint64 // index of the next entry to send to the follower
+	pending uint64 // index of a snapshot in flight to the follower, or 0
+	active  bool   // heard from the follower since the last quorum check?
+	read    uint64 // latest read round acknowledged by the follower
+}
+
+// pendingRead is a read the leader has yet to confirm.
+type pendingRead struct {
+	id    uint64 // the read's ID
+	index uint64 // the commit index when the read was requested
+	round uint64 // the heartbeat round that confirms the read
+}
+
+// Node is a Raft node. A Node is not safe for concurrent use.
+type Node struct {
+	id             string
+	clusterSize    int
+	electionTicks  int
+	heartbeatTicks int
+	maxEntries     int
+	storage        Storage
+	rand           *rand.Rand
+
+	peers  []string // the other members of the cluster, sorted
+	role   role
+	term   uint64
+	vote   string
+	leader string // the leader of term, if known
+
+	snap    Snapshot // the latest snapshot, covering the entries up to snap.Index
+	entries []Entry  // the entries that follow snap.Index
+	commit  uint64   // index of the last committed entry
+	applied uint64   // index of the last entry returned by Ready
+
+	electionElapsed  int // ticks since the election timer was reset
+	heartbeatElapsed int // ticks since the leader's last heartbeat
+	timeout          int // randomized election timeout, in ticks
+
+	votes    map[string]bool      // candidate: votes received, granted or not
+	progress map[string]*progress // leader: progress of every peer
+	round    uint64               // leader: the latest read round
+	reads    []pendingRead        // leader: reads waiting for their round
+	waiting  []uint64             // leader: reads waiting for a commit in term
+
+	msgs       []Message
+	restore    *Snapshot
+	readStates []ReadState
+}
+
+// New returns a node that recovers its state from the provided config's
+// storage.
+func New(config Config) (*Node, error) {
+	if config.ID == "" {
+		return nil, fmt.Errorf("raft: missing node ID")
+	}
+	if config.Storage == nil {
+		return nil, fmt.Errorf("raft: missing storage")
+	}
+	if config.ElectionTicks <= 0 {
+		config.ElectionTicks = 10
+	}
+	if config.HeartbeatTicks <= 0 {
+		config.HeartbeatTicks = 1
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 256
+	}
+	if config.Rand == nil {
+		config.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	hs, snap, entries, err := config.Storage.Load()
+	if err != nil {
+		return nil, err
+	}
+	n := &Node{
+		id:             config.ID,
+		clusterSize:    config.ClusterSize,
+		electionTicks:  config.ElectionTicks,
+		heartbeatTicks: config.HeartbeatTicks,
+		maxEntries:     config.MaxEntries,
+		storage:        config.Storage,
+		rand:           config.Rand,
+		term:           hs.Term,
+		vote:           hs.Vote,
+		snap:           snap,
+		entries:        entries,
+		commit:         snap.Index,
+		applied:        snap.Index,
+	}
+	if snap.Index > 0 {
+		n.restore = &snap
+	}
+	n.resetElection()
+	return n, nil
+}
+
+// ID returns the ID of the node.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Term returns the node's current term.
+func (n *Node) Term() uint64 {
+	return n.term
+}
+
+// Leader returns the ID of the leader of the node's current term, or "" if
+// the leader is unknown.
+func (n *Node) Leader() string {
+	return n.leader
+}
+
+// IsLeader returns whether the node is the leader.
+func (n *Node) IsLeader() bool {
+	return n.role == leader
+}
+
+// SetPeers sets the other members of the cluster.
+func (n *Node) SetPeers(peers []string) {
+	peers = slices.DeleteFunc(slices.Clone(peers), func(p string) bool { return p == n.id })
+	sort.Strings(peers)
+	n.peers = slices.Compact(peers)
+	if n.role != leader {
+		return
+	}
+	for _, p := range n.peers {
+		if _, ok := n.progress[p]; !ok {
+			n.progress[p] = &progress{next: n.lastIndex() + 1}
+			n.sendAppend(p)
+		}
+	}
+	for p := range n.progress {
+		if !slices.Contains(n.peers, p) {
+			delete(n.progress, p)
+		}
+	}
+	n.maybeCommit()
+	n.checkReads()
+}
+
+// Tick advances the node's clock by one tick.
+func (n *Node) Tick() error {
+	n.electionElapsed++
+	if n.role != leader {
+		if n.electionElapsed >= n.timeout {
+			return n.Campaign()
+		}
+		return nil
+	}
+
+	n.heartbeatElapsed++
+	if n.heartbeatElapsed >= n.heartbeatTicks {
+		n.heartbeatElapsed = 0
+		n.broadcastHeartbeat()
+	}
+	if n.electionElapsed >= n.electionTicks {
+		n.electionElapsed = 0
+		if !n.checkQuorum() {
+			// A leader that can't reach a majority steps down, so that it
+			// stops serving stale reads.
+			return n.becomeFollower(n.term, "")
+		}
+	}
+	return nil
+}
+
+// Campaign starts an election. A node that is the only member of its cluster
+// becomes the leader immediately.
+func (n *Node) Campaign() error {
+	if n.role == leader {
+		return nil
+	}
+	if err := n.setHardState(n.term+1, n.id); err != nil {
+		return err
+	}
+	n.role = candidate
+	n.leader = ""
+	n.votes = map[string]bool{n.id: true}
+	n.resetElection()
+	if n.count(n.votes, true) >= n.quorum() {
+		return n.becomeLeader()
+	}
+	for _, p := range n.peers {
+		n.send(Message{Type: MsgVote, To: p, Index: n.lastIndex(), LogTerm: n.lastTerm()})
+	}
+	return nil
+}
+
+// Propose appends an entry with the provided data to the log. It returns the
+// index and term of the entry, which is committed only if the entry at index
+// is still from term when it is applied. Propose returns ErrNotLeader if the
+// node isn't the leader.
+func (n *Node) Propose(data []byte) (uint64, uint64, error) {
+	if n.role != leader {
+		return 0, 0, ErrNotLeader
+	}
+	if err := n.append(Entry{Type: EntryNormal, Data: data}); err != nil {
+		return 0, 0, err
+	}
+	return n.lastIndex(), n.term, nil
+}
+
+// ReadIndex requests a linearizable read with the provided ID. Once the leader
+// confirms that it is still the leader, a ReadState for the read is returned by
+// Ready. ReadIndex returns ErrNotLeader if the node isn't the leader.
+func (n *Node) ReadIndex(id uint64) error {
+	if n.role != leader {
+		return ErrNotLeader
+	}
+	if t, _ := n.termAt(n.commit); t != n.term {
+		// Until the leader commits an entry in its term, it doesn't know
+		// which entries are committed.
+		n.waiting = append(n.waiting, id)
+		return nil
+	}
+	n.startReads([]uint64{id})
+	return nil
+}
+
+// Compact replaces the applied entries up to and including index with a
+// snapshot of the state that results from applying them.
+func (n *Node) Compact(index uint64, data []byte) error {
+	if index <= n.snap.Index {
+		return nil
+	}
+	if index > n.applied {
+		return fmt.Errorf("raft: compact %d beyond applied index %d", index, n.applied)
+	}
+	t, _ := n.termAt(index)
+	s := Snapshot{Index: index, Term: t, Data: data}
+	if err := n.storage.SaveSnapshot(s); err != nil {
+		return err
+	}
+	n.entries = slices.Clone(n.entries[index-n.snap.Index:])
+	n.snap = s
+	return nil
+}
+
+// Ready returns and clears the node's output. The committed entries it
+// returns are considered applied.
+func (n *Node) Ready() Ready {
+	rd := Ready{Messages: n.msgs, Snapshot: n.restore, ReadStates: n.readStates}
+	if n.commit > n.applied {
+		rd.CommittedEntries = n.slice(n.applied+1, n.commit)
+		n.applied = n.commit
+	}
+	n.msgs, n.restore, n.readStates = nil, nil, nil
+	return rd
+}
+
+// Step processes a message from another node.
+func (n *Node) Step(m Message) error {
+	fromLeader := m.Type == MsgApp || m.Type == MsgHeartbeat || m.Type == MsgSnap
+	switch {
+	case m.Term > n.term:
+		lead := ""
+		if fromLeader {
+			lead = m.From
+		}
+		if err := n.becomeFollower(m.Term, lead); err != nil {
+			return err
+		}
+	case m.Term < n.term:
+		// Tell a stale leader or candidate about the current term.
+		switch {
+		case fromLeader:
+			n.send(Message{Type: MsgAppResp, To: m.From, Reject: true})
+		case m.Type == MsgVote:
+			n.send(Message{Type: MsgVoteResp, To: m.From, Reject: true})
+		}
+		return nil
+	}
+
+	switch m.Type {
+	case MsgVote:
+		return n.handleVote(m)
+	case MsgVoteResp:
+		return n.handleVoteResp(m)
+	case MsgApp, MsgHeartbeat, MsgSnap:
+		if n.role == leader {
+			// Two leaders of the same term can't exist.
+			return nil
+		}
+		if n.role == candidate || n.leader != m.From {
+			if err := n.becomeFollower(n.term, m.From); err != nil {
+				return err
+			}
+		}
+		n.electionElapsed = 0
+		switch m.Type {
+		case MsgApp:
+			return n.handleAppend(m)
+		case MsgHeartbeat:
+			n.handleHeartbeat(m)
+			return nil
+		default:
+			return n.handleSnapshot(m)
+		}
+	case MsgAppResp:
+		if n.role == leader {
+			n.handleAppendResp(m)
+		}
+	case MsgHeartbeatResp:
+		if n.role == leader {
+			n.handleHeartbeatResp(m)
+		}
+	}
+	return nil
+}
+
+// quorum returns the number of members that form a majority.
+func (n *Node) quorum() int {
+	return max(n.clusterSize, len(n.peers)+1)/2 + 1
+}
+
+// count returns the number of votes with the provided value.
+func (n *Node) count(votes map[string]bool, value bool) int {
+	c := 0
+	for _, v := range votes {
+		if v == value {
+			c++
+		}
+	}
+	return c
+}
+
+// lastIndex returns the index of the last entry in the log.
+func (n *Node) lastIndex() uint64 {
+	return n.snap.Index + uint64(len(n.entries))
+}
+
+// lastTerm returns the term of the last entry in the log.
+func (n *Node) lastTerm() uint64 {
+	t, _ := n.termAt(n.lastIndex())
+	return t
+}
+
+// termAt returns the term of the entry at the provided index, or false if the
+// entry doesn't exist or was compacted.
+func (n *Node) termAt(i uint64) (uint64, bool) {
+	switch {
+	case i == n.snap.Index:
+		return n.snap.Term, true
+	case i < n.snap.Index || i > n.lastIndex():
+		return 0, false
+	}
+	return n.entries[i-n.snap.Index-1].Term, true
+}
+
+// slice returns a copy of the entries from lo to hi, inclusive.
+//
+// REQUIRES: snap.Index < lo and hi <= lastIndex().
+func (n *Node) slice(lo, hi uint64) []Entry {
+	if lo > hi {
+		return nil
+	}
+	return slices.Clone(n.entries[lo-n.snap.Index-1 : hi-n.snap.Index])
+}
+
+// send queues a message from the node in its current term.
+func (n *Node) send(m Message) {
+	m.From = n.id
+	m.Term = n.term
+	n.msgs = append(n.msgs, m)
+}
+
+// setHardState persists and sets the node's term and vote.
+func (n *Node) setHardState(term uint64, vote string) error {
+	if term == n.term && vote == n.vote {
+		return nil
+	}
+	if err := n.storage.SaveHardState(HardState{Term: term, Vote: vote}); err != nil {
+		return err
+	}
+	n.term, n.vote = term, vote
+	return nil
+}
+
+// resetElection resets and re-randomizes the election timer.
+func (n *Node) resetElection() {
+	n.electionElapsed = 0
+	n.timeout = n.electionTicks + n.rand.Intn(n.electionTicks)
+}
+
+// becomeFollower makes the node a follower of the provided leader in the
+// provided term.
+func (n *Node) becomeFollower(term uint64, lead string) error {
+	if term > n.term {
+		if err := n.setHardState(term, ""); err != nil {
+			return err
+		}
+	}
+	if n.role == leader {
+		// Reads the leader hasn't confirmed may be stale.
+		for _, id := range n.waiting {
+			n.readStates = append(n.readStates, ReadState{ID: id, Dropped: true})
+		}
+		for _, r := range n.reads {
+			n.readStates = append(n.readStates, ReadState{ID: r.id, Dropped: true})
+		}
+		n.waiting, n.reads, n.progress = nil, nil, nil
+	}
+	n.role = follower
+	n.leader = lead
+	n.votes = nil
+	n.resetElection()
+	return nil
+}
+
+// becomeLeader makes a candidate the leader of its term.
+func (n *Node) becomeLeader() error {
+	n.role = leader
+	n.leader = n.id
+	n.votes = nil
+	n.electionElapsed = 0
+	n.heartbeatElapsed = 0
+	n.progress = map[string]*progress{}
+	for _, p := range n.peers {
+		n.progress[p] = &progress{next: n.lastIndex() + 1}
+	}
+
+	// Commit an entry in the new term, which commits the entries of
+	// previous terms as well.
+	return n.append(Entry{Type: EntryNoop})
+}
+
+// append appends an entry to the leader's log and replicates it.
+func (n *Node) append(e Entry) error {
+	e.Term = n.term
+	e.Index = n.lastIndex() + 1
+	if err := n.storage.Append([]Entry{e}); err != nil {
+		return err
+	}
+	n.entries = append(n.entries, e)
+	n.maybeCommit()
+	for _, p := range n.peers {
+		n.sendAppend(p)
+	}
+	return nil
+}
+
+// sendAppend sends the entries a follower is missing.
+func (n *Node) sendAppend(p string) {
+	pr := n.progress[p]
+	if pr.pending != 0 {
+		// Wait for the follower to install the snapshot.
+		return
+	}
+	if pr.next <= n.snap.Index {
+		// The follower needs entries that were compacted.
+		pr.pending = n.snap.Index
+		n.send(Message{Type: MsgSnap, To: p, Snapshot: n.snap})
+		return
+	}
+	prev := pr.next - 1
+	prevTerm, _ := n.termAt(prev)
+	hi := min(n.lastIndex(), prev+uint64(n.maxEntries))
+	n.send(Message{
+		Type:    MsgApp,
+		To:      p,
+		Index:   prev,
+		LogTerm: prevTerm,
+		Entries: n.slice(prev+1, hi),
+		Commit:  n.commit,
+	})
+}
+
+// broadcastHeartbeat sends a heartbeat to every follower.
+func (n *Node) broadcastHeartbeat() {
+	for _, p := range n.peers {
+		pr := n.progress[p]
+		n.send(Message{Type: MsgHeartbeat, To: p, Commit: min(pr.match, n.commit), Context: n.round})
+	}
+}
+
+// checkQuorum returns whether the leader heard from a majority since the last
+// check, and resets the check. Snapshots in flight since the last check are
+// considered lost, and are sent again.
+func (n *Node) checkQuorum() bool {
+	active := 1
+	for _, pr := range n.progress {
+		if pr.active {
+			active++
+		}
+		pr.active = false
+		pr.pending = 0
+	}
+	return active >= n.quorum()
+}
+
+// maybeCommit advances the leader's commit index to the last entry of its
+// term replicated on a majority.
+func (n *Node) maybeCommit() {
+	matches := []uint64{n.lastIndex()}
+	for _, p := range n.peers {
+		matches = append(matches, n.progress[p].match)
+	}
+	q := n.quorum()
+	if len(matches) < q {
+		return
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i] > matches[j] })
+	index := matches[q-1]
+	if index <= n.commit {
+		return
+	}
+	if t, _ := n.termAt(index); t != n.term {
+		// Entries of previous terms are only committed indirectly.
+		return
+	}
+	n.commit = index
+	if len(n.waiting) > 0 {
+		ids := n.waiting
+		n.waiting = nil
+		n.startReads(ids)
+	}
+}
+
+// startReads starts a read round that confirms the provided reads.
+func (n *Node) startReads(ids []uint64) {
+	n.round++
+	for _, id := range ids {
+		n.reads = append(n.reads, pendingRead{id: id, index: n.commit, round: n.round})
+	}
+	n.broadcastHeartbeat()
+	n.checkReads()
+}
+
+// checkReads returns the reads confirmed by a majority.
+func (n *Node) checkReads() {
+	q := n.quorum()
+	i := 0
+	for ; i < len(n.reads); i++ {
+		r := n.reads[i]
+		acks := 1
+		for _, pr := range n.progress {
+			if pr.read >= r.round {
+				acks++
+			}
+		}
+		if acks < q {
+			break
+		}
+		n.readStates = append(n.readStates, ReadState{ID: r.id, Index: r.index})
+	}
+	n.reads = n.reads[i:]
+}
+
+// handleVote handles a MsgVote.
+func (n *Node) handleVote(m Message) error {
+	upToDate := m.LogTerm > n.lastTerm() || (m.LogTerm == n.lastTerm() && m.Index >= n.lastIndex())
+	grant := (n.vote == "" || n.vote == m.From) && upToDate
+	if grant {
+		if err := n.setHardState(n.term, m.From); err != nil {
+			return err
+		}
+		n.electionElapsed = 0
+	}
+	n.send(Message{Type: MsgVoteResp, To: m.From, Reject: !grant})
+	return nil
+}
+
+// handleVoteResp handles a MsgVoteResp.
+func (n *Node) handleVoteResp(m Message) error {
+	if n.role != candidate {
+		return nil
+	}
+	n.votes[m.From] = !m.Reject
+	switch q := n.quorum(); {
+	case n.count(n.votes, true) >= q:
+		return n.becomeLeader()
+	case n.count(n.votes, false) >= q:
+		return n.becomeFollower(n.term, "")
+	}
+	return nil
+}
+
+// handleAppend handles a MsgApp.
+func (n *Node) handleAppend(m Message) error {
+	if m.Index < n.commit {
+		n.send(Message{Type: MsgAppResp, To: m.From, Index: n.commit})
+		return nil
+	}
+	if t, ok := n.termAt(m.Index); !ok || t != m.LogTerm {
+		hint := min(m.Index-1, n.lastIndex())
+		n.send(Message{Type: MsgAppResp, To: m.From, Index: hint, Reject: true})
+		return nil
+	}
+
+	// Skip the entries the log already has, and replace the rest.
+	entries := m.Entries
+	for len(entries) > 0 {
+		if t, ok := n.termAt(entries[0].Index); !ok || t != entries[0].Term {
+			break
+		}
+		entries = entries[1:]
+	}
+	if len(entries) > 0 {
+		if err := n.storage.Append(entries); err != nil {
+			return err
+		}
+		n.entries = append(n.entries[:entries[0].Index-n.snap.Index-1], entries...)
+	}
+
+	last := m.Index + uint64(len(m.Entries))
+	if c := min(m.Commit, last); c > n.commit {
+		n.commit = c
+	}
+	n.send(Message{Type: MsgAppResp, To: m.From, Index: last})
+	return nil
+}
+
+// handleHeartbeat handles a MsgHeartbeat.
+func (n *Node) handleHeartbeat(m Message) {
+	// The leader sends a commit index no larger than the index of the last
+	// entry it knows matches, so every entry up to it is committed.
+	if m.Commit > n.commit && m.Commit <= n.lastIndex() {
+		n.commit = m.Commit
+	}
+	n.send(Message{Type: MsgHeartbeatResp, To: m.From, Context: m.Context})
+}
+
+// handleSnapshot handles a MsgSnap.
+func (n *Node) handleSnapshot(m Message) error {
+	s := m.Snapshot
+	if s.Index <= n.commit {
+		n.send(Message{Type: MsgAppResp, To: m.From, Index: n.commit})
+		return nil
+	}
+	if err := n.storage.SaveSnapshot(s); err != nil {
+		return err
+	}
+	if t, ok := n.termAt(s.Index); ok && t == s.Term {
+		// Keep the entries that follow the snapshot.
+		n.entries = slices.Clone(n.entries[s.Index-n.snap.Index:])
+	} else {
+		n.entries = nil
+	}
+	n.snap = s
+	n.commit, n.applied = s.Index, s.Index
+	n.restore = &s
+	n.send(Message{Type: MsgAppResp, To: m.From, Index: s.Index})
+	return nil
+}
+
+// handleAppendResp handles a MsgAppResp.
+func (n *Node) handleAppendResp(m Message) {
+	pr, ok := n.progress[m.From]
+	if !ok {
+		return
+	}
+	pr.active = true
+	pr.pending = 0
+	if m.Reject {
+		pr.next = max(min(pr.next-1, m.Index+1), pr.match+1)
+		n.sendAppend(m.From)
+		return
+	}
+	if m.Index > pr.match {
+		pr.match = m.Index
+		n.maybeCommit()
+	}
+	pr.next = pr.match + 1
+	if pr.next <= n.lastIndex() {
+		n.sendAppend(m.From)
+	}
+}
+
+// handleHeartbeatResp handles a MsgHeartbeatResp.
+func (n *Node) handleHeartbeatResp(m Message) {
+	pr, ok := n.progress[m.From]
+	if !ok {
+		return
+	}
+	pr.active = true
+	if m.Context > pr.read {
+		pr.read = m.Context
+		n.checkReads()
+	}
+	if pr.match < n.lastIndex() {
+		n.sendAppend(m.From)
+	}
+}
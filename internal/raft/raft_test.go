@@ -0,0 +1,427 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// cluster is a cluster of nodes connected by a simulated network. Every node
+// applies entries to a state that is the list of the entries' data.
+type cluster struct {
+	t        *testing.T
+	ids      []string
+	nodes    map[string]*Node
+	storages map[string]*MemoryStorage
+	states   map[string][]string    // applied state, by node
+	reads    map[string][]ReadState // returned reads, by node
+	down     map[string]bool        // nodes that send and receive nothing
+	queue    []Message              // messages in flight
+}
+
+func newCluster(t *testing.T, size int) *cluster {
+	t.Helper()
+	c := &cluster{
+		t:        t,
+		nodes:    map[string]*Node{},
+		storages: map[string]*MemoryStorage{},
+		states:   map[string][]string{},
+		reads:    map[string][]ReadState{},
+		down:     map[string]bool{},
+	}
+	for i := 0; i < size; i++ {
+		c.ids = append(c.ids, fmt.Sprintf("n%d", i))
+	}
+	for i, id := range c.ids {
+		c.storages[id] = NewMemoryStorage()
+		c.start(id, int64(i))
+	}
+	return c
+}
+
+// start starts or restarts a node from its storage.
+func (c *cluster) start(id string, seed int64) {
+	c.t.Helper()
+	n, err := New(Config{
+		ID:          id,
+		ClusterSize: len(c.ids),
+		Storage:     c.storages[id],
+		Rand:        rand.New(rand.NewSource(seed)),
+	})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	n.SetPeers(c.ids)
+	c.nodes[id] = n
+	c.states[id] = nil
+}
+
+// process applies the output of every node and queues its messages.
+func (c *cluster) process() {
+	for _, id := range c.ids {
+		rd := c.nodes[id].Ready()
+		if rd.Snapshot != nil {
+			c.states[id] = nil
+			if len(rd.Snapshot.Data) > 0 {
+				c.states[id] = strings.Split(string(rd.Snapshot.Data), ",")
+			}
+		}
+		for _, e := range rd.CommittedEntries {
+			if e.Type == EntryNormal {
+				c.states[id] = append(c.states[id], string(e.Data))
+			}
+		}
+		c.reads[id] = append(c.reads[id], rd.ReadStates...)
+		if !c.down[id] {
+			c.queue = append(c.queue, rd.Messages...)
+		}
+	}
+}
+
+// deliver delivers messages until the network is quiet.
+func (c *cluster) deliver() {
+	c.t.Helper()
+	for c.process(); len(c.queue) > 0; c.process() {
+		queue := c.queue
+		c.queue = nil
+		for _, m := range queue {
+			if c.down[m.To] {
+				continue
+			}
+			if err := c.nodes[m.To].Step(m); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+	}
+}
+
+// tick ticks every node that isn't down and delivers the resulting messages.
+func (c *cluster) tick(n int) {
+	c.t.Helper()
+	for i := 0; i < n; i++ {
+		for _, id := range c.ids {
+			if c.down[id] {
+				continue
+			}
+			if err := c.nodes[id].Tick(); err != nil {
+				c.t.Fatal(err)
+			}
+		}
+		c.deliver()
+	}
+}
+
+// leader ticks until a node that isn't down is the leader, and returns it.
+func (c *cluster) leader() string {
+	c.t.Helper()
+	for i := 0; i < 100; i++ {
+		for _, id := range c.ids {
+			if !c.down[id] && c.nodes[id].IsLeader() {
+				return id
+			}
+		}
+		c.tick(1)
+	}
+	c.t.Fatal("no leader elected")
+	return ""
+}
+
+// propose proposes data on the provided node.
+func (c *cluster) propose(id, data string) {
+	c.t.Helper()
+	if _, _, err := c.nodes[id].Propose([]byte(data)); err != nil {
+		c.t.Fatal(err)
+	}
+	c.deliver()
+}
+
+// checkLeaders checks that no term has two leaders.
+func (c *cluster) checkLeaders() {
+	c.t.Helper()
+	leaders := map[uint64]string{}
+	for _, id := range c.ids {
+		n := c.nodes[id]
+		if !n.IsLeader() {
+			continue
+		}
+		if other, ok := leaders[n.Term()]; ok {
+			c.t.Fatalf("term %d has two leaders: %s and %s", n.Term(), other, id)
+		}
+		leaders[n.Term()] = id
+	}
+}
+
+func TestSingleNode(t *testing.T) {
+	c := newCluster(t, 1)
+	n := c.nodes["n0"]
+	if err := n.Campaign(); err != nil {
+		t.Fatal(err)
+	}
+	if !n.IsLeader() {
+		t.Fatal("single node isn't the leader after campaigning")
+	}
+
+	// Entries commit and reads are confirmed without any messages.
+	c.propose("n0", "a")
+	c.propose("n0", "b")
+	if err := n.ReadIndex(1); err != nil {
+		t.Fatal(err)
+	}
+	c.process()
+	if diff := cmp.Diff([]string{"a", "b"}, c.states["n0"]); diff != "" {
+		t.Fatalf("state (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]ReadState{{ID: 1, Index: 3}}, c.reads["n0"]); diff != "" {
+		t.Fatalf("reads (-want +got):\n%s", diff)
+	}
+}
+
+func TestReplication(t *testing.T) {
+	c := newCluster(t, 3)
+	l := c.leader()
+	c.checkLeaders()
+	c.propose(l, "a")
+	c.propose(l, "b")
+	c.tick(1) // heartbeats propagate the commit index
+	for _, id := range c.ids {
+		if diff := cmp.Diff([]string{"a", "b"}, c.states[id]); diff != "" {
+			t.Fatalf("%s state (-want +got):\n%s", id, diff)
+		}
+	}
+
+	// Followers don't accept proposals.
+	for _, id := range c.ids {
+		if id == l {
+			continue
+		}
+		if _, _, err := c.nodes[id].Propose([]byte("c")); !errors.Is(err, ErrNotLeader) {
+			t.Fatalf("%s: Propose: got %v, want ErrNotLeader", id, err)
+		}
+		if got := c.nodes[id].Leader(); got != l {
+			t.Fatalf("%s: Leader: got %q, want %q", id, got, l)
+		}
+	}
+}
+
+func TestLeaderFailure(t *testing.T) {
+	c := newCluster(t, 3)
+	old := c.leader()
+	c.propose(old, "a")
+
+	// The old leader appends an entry it can't commit.
+	c.down[old] = true
+	if _, _, err := c.nodes[old].Propose([]byte("lost")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The others elect a new leader, which keeps the committed entry.
+	l := c.leader()
+	if l == old {
+		t.Fatal("isolated leader still the leader")
+	}
+	c.checkLeaders()
+	c.propose(l, "b")
+
+	// The old leader rejoins, and its uncommitted entry is replaced.
+	c.down[old] = false
+	c.tick(3)
+	c.checkLeaders()
+	for _, id := range c.ids {
+		if diff := cmp.Diff([]string{"a", "b"}, c.states[id]); diff != "" {
+			t.Fatalf("%s state (-want +got):\n%s", id, diff)
+		}
+	}
+}
+
+func TestIsolatedLeaderStepsDown(t *testing.T) {
+	c := newCluster(t, 3)
+	l := c.leader()
+	for _, id := range c.ids {
+		if id != l {
+			c.down[id] = true
+		}
+	}
+	if err := c.nodes[l].ReadIndex(7); err != nil {
+		t.Fatal(err)
+	}
+	c.tick(20)
+	if c.nodes[l].IsLeader() {
+		t.Fatal("leader that can't reach a majority didn't step down")
+	}
+	if diff := cmp.Diff([]ReadState{{ID: 7, Dropped: true}}, c.reads[l]); diff != "" {
+		t.Fatalf("reads (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadIndex(t *testing.T) {
+	c := newCluster(t, 3)
+	l := c.leader()
+	c.propose(l, "a")
+	if err := c.nodes[l].ReadIndex(1); err != nil {
+		t.Fatal(err)
+	}
+	c.deliver()
+	if diff := cmp.Diff([]ReadState{{ID: 1, Index: 2}}, c.reads[l]); diff != "" {
+		t.Fatalf("reads (-want +got):\n%s", diff)
+	}
+	for _, id := range c.ids {
+		if id != l {
+			if err := c.nodes[id].ReadIndex(2); !errors.Is(err, ErrNotLeader) {
+				t.Fatalf("%s: ReadIndex: got %v, want ErrNotLeader", id, err)
+			}
+		}
+	}
+}
+
+func TestSnapshotCatchUp(t *testing.T) {
+	c := newCluster(t, 3)
+	l := c.leader()
+	var lagging string
+	for _, id := range c.ids {
+		if id != l {
+			lagging = id
+			break
+		}
+	}
+	c.down[lagging] = true
+	for _, data := range []string{"a", "b", "c"} {
+		c.propose(l, data)
+	}
+
+	// Compact the leader's log, so that the lagging follower can only
+	// catch up with a snapshot.
+	n := c.nodes[l]
+	if err := n.Compact(n.applied, []byte(strings.Join(c.states[l], ","))); err != nil {
+		t.Fatal(err)
+	}
+	c.propose(l, "d")
+
+	c.down[lagging] = false
+	c.tick(20)
+	if diff := cmp.Diff([]string{"a", "b", "c", "d"}, c.states[lagging]); diff != "" {
+		t.Fatalf("state (-want +got):\n%s", diff)
+	}
+}
+
+func TestClusterSize(t *testing.T) {
+	// Two nodes of a cluster of three that haven't discovered each other
+	// can't elect a leader on their own.
+	for _, id := range []string{"a", "b"} {
+		n, err := New(Config{ID: id, ClusterSize: 3, Storage: NewMemoryStorage()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100; i++ {
+			if err := n.Tick(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if n.IsLeader() {
+			t.Fatalf("%s: became the leader on its own", id)
+		}
+	}
+}
+
+func TestRestart(t *testing.T) {
+	c := newCluster(t, 3)
+	l := c.leader()
+	c.propose(l, "a")
+	c.propose(l, "b")
+	c.tick(1)
+
+	// Restart every node from its storage.
+	for i, id := range c.ids {
+		c.start(id, int64(i+10))
+	}
+	l = c.leader()
+	c.propose(l, "c")
+	c.tick(1)
+	for _, id := range c.ids {
+		if diff := cmp.Diff([]string{"a", "b", "c"}, c.states[id]); diff != "" {
+			t.Fatalf("%s state (-want +got):\n%s", id, diff)
+		}
+	}
+}
+
+func TestEncodeMessages(t *testing.T) {
+	msgs := []Message{
+		{Type: MsgVote, From: "a", To: "b", Term: 3, LogTerm: 2, Index: 7},
+		{
+			Type:    MsgApp,
+			From:    "b",
+			To:      "c",
+			Term:    4,
+			Index:   8,
+			Commit:  6,
+			Entries: []Entry{{Term: 4, Index: 9, Data: []byte("x")}, {Term: 4, Index: 10, Type: EntryNoop}},
+		},
+		{Type: MsgSnap, Snapshot: Snapshot{Index: 5, Term: 1, Data: []byte("s")}},
+		{Type: MsgHeartbeatResp, Reject: true, Context: 12},
+	}
+	got, err := DecodeMessages(EncodeMessages(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(msgs, got); diff != "" {
+		t.Fatalf("DecodeMessages (-want +got):\n%s", diff)
+	}
+	if _, err := DecodeMessages([]byte{1, 2}); err == nil {
+		t.Fatal("DecodeMessages: unexpected success for truncated data")
+	}
+}
+
+func TestRandomFailures(t *testing.T) {
+	// Crash and revive random minorities of a cluster while proposing, and
+	// check that every node applies a prefix of the same entries.
+	r := rand.New(rand.NewSource(0))
+	c := newCluster(t, 5)
+	proposed := 0
+	for step := 0; step < 300; step++ {
+		if step%20 == 0 {
+			clear(c.down)
+			for i := 0; i < r.Intn(3); i++ {
+				c.down[c.ids[r.Intn(len(c.ids))]] = true
+			}
+		}
+		for _, id := range c.ids {
+			if c.nodes[id].IsLeader() && r.Intn(2) == 0 {
+				proposed++
+				if _, _, err := c.nodes[id].Propose([]byte(fmt.Sprint(proposed))); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		c.tick(1)
+		c.checkLeaders()
+	}
+
+	clear(c.down)
+	c.tick(50)
+	want := c.states[c.leader()]
+	if len(want) == 0 {
+		t.Fatal("no entries committed")
+	}
+	for _, id := range c.ids {
+		if diff := cmp.Diff(want, c.states[id]); diff != "" {
+			t.Fatalf("%s state (-want +got):\n%s", id, diff)
+		}
+	}
+}
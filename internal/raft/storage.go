@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"slices"
+	"sync"
+)
+
+// Storage persists the state of a node. A node calls Storage methods before
+// it acts on the state it persists, so a Storage must not return until the
+// state is durable.
+type Storage interface {
+	// Load returns the persisted state, or zero values if nothing has been
+	// persisted. The returned entries follow the returned snapshot.
+	Load() (HardState, Snapshot, []Entry, error)
+
+	// SaveHardState persists the provided hard state.
+	SaveHardState(HardState) error
+
+	// Append persists the provided consecutive entries, replacing every
+	// persisted entry with an index at or beyond the index of the first
+	// one.
+	Append([]Entry) error
+
+	// SaveSnapshot persists the provided snapshot and discards the entries
+	// it covers. If the persisted entry at the snapshot's index has a
+	// different term, or doesn't exist, every persisted entry is discarded.
+	SaveSnapshot(Snapshot) error
+}
+
+// MemoryStorage is a Storage that holds its state in memory.
+type MemoryStorage struct {
+	mu      sync.Mutex
+	hs      HardState
+	snap    Snapshot
+	entries []Entry
+}
+
+var _ Storage = &MemoryStorage{}
+
+// NewMemoryStorage returns an empty MemoryStorage.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{}
+}
+
+// Load implements the Storage interface.
+func (m *MemoryStorage) Load() (HardState, Snapshot, []Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.hs, m.snap, slices.Clone(m.entries), nil
+}
+
+// SaveHardState implements the Storage interface.
+func (m *MemoryStorage) SaveHardState(hs HardState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hs = hs
+	return nil
+}
+
+// Append implements the Storage interface.
+func (m *MemoryStorage) Append(entries []Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.entries = appendEntries(m.snap, m.entries, entries)
+	return nil
+}
+
+// SaveSnapshot implements the Storage interface.
+func (m *MemoryStorage) SaveSnapshot(s Snapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if s.Index <= m.snap.Index {
+		return nil
+	}
+	m.entries = compactEntries(m.snap, m.entries, s)
+	m.snap = s
+	return nil
+}
+
+// appendEntries appends entries to the entries that follow snap, replacing
+// the entries with an index at or beyond the index of the first new entry.
+func appendEntries(snap Snapshot, entries, added []Entry) []Entry {
+	if len(added) == 0 {
+		return entries
+	}
+	first := added[0].Index
+	if first <= snap.Index {
+		// Skip the entries the snapshot covers.
+		if added[len(added)-1].Index <= snap.Index {
+			return entries
+		}
+		added = added[snap.Index-first+1:]
+		first = snap.Index + 1
+	}
+	keep := min(uint64(len(entries)), first-snap.Index-1)
+	return append(slices.Clip(entries[:keep]), added...)
+}
+
+// compactEntries returns the entries that follow the new snapshot s, given
+// the entries that follow the old snapshot snap.
+//
+// REQUIRES: s.Index > snap.Index.
+func compactEntries(snap Snapshot, entries []Entry, s Snapshot) []Entry {
+	i := s.Index - snap.Index // position of the entry that follows s
+	if i > uint64(len(entries)) || entries[i-1].Term != s.Term {
+		return nil
+	}
+	return slices.Clone(entries[i:])
+}
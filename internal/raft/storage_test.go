@@ -0,0 +1,174 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package raft
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func entry(term, index uint64, data string) Entry {
+	return Entry{Term: term, Index: index, Data: []byte(data)}
+}
+
+// testStorage writes to a storage and checks what it loads.
+func testStorage(t *testing.T, s Storage, reopen func() Storage) {
+	t.Helper()
+	check := func(wantHS HardState, wantSnap Snapshot, wantEntries []Entry) {
+		t.Helper()
+		hs, snap, entries, err := reopen().Load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(wantHS, hs); diff != "" {
+			t.Fatalf("hard state (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(wantSnap, snap); diff != "" {
+			t.Fatalf("snapshot (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(wantEntries, entries); diff != "" {
+			t.Fatalf("entries (-want +got):\n%s", diff)
+		}
+	}
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check(HardState{}, Snapshot{}, nil)
+
+	hs := HardState{Term: 2, Vote: "a"}
+	must(s.SaveHardState(hs))
+	must(s.Append([]Entry{entry(1, 1, "a"), entry(1, 2, "b"), entry(2, 3, "c")}))
+	check(hs, Snapshot{}, []Entry{entry(1, 1, "a"), entry(1, 2, "b"), entry(2, 3, "c")})
+
+	// Conflicting entries replace the entries that follow them.
+	must(s.Append([]Entry{entry(3, 3, "x"), entry(3, 4, "y")}))
+	check(hs, Snapshot{}, []Entry{entry(1, 1, "a"), entry(1, 2, "b"), entry(3, 3, "x"), entry(3, 4, "y")})
+
+	// A snapshot discards the entries it covers.
+	snap := Snapshot{Index: 2, Term: 1, Data: []byte("ab")}
+	must(s.SaveSnapshot(snap))
+	check(hs, snap, []Entry{entry(3, 3, "x"), entry(3, 4, "y")})
+	must(s.Append([]Entry{entry(3, 5, "z")}))
+	check(hs, snap, []Entry{entry(3, 3, "x"), entry(3, 4, "y"), entry(3, 5, "z")})
+
+	// An older snapshot is ignored.
+	must(s.SaveSnapshot(Snapshot{Index: 1, Term: 1}))
+	check(hs, snap, []Entry{entry(3, 3, "x"), entry(3, 4, "y"), entry(3, 5, "z")})
+
+	// A snapshot that doesn't match the entries discards all of them.
+	snap = Snapshot{Index: 4, Term: 4, Data: []byte("other")}
+	must(s.SaveSnapshot(snap))
+	check(hs, snap, nil)
+}
+
+func TestMemoryStorage(t *testing.T) {
+	s := NewMemoryStorage()
+	testStorage(t, s, func() Storage { return s })
+}
+
+func TestDiskStorage(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("reopening a locked directory isn't supported on windows")
+	}
+	dir := t.TempDir()
+	_, s, err := OpenDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStorage(t, s, func() Storage {
+		// Read the files from scratch, as a restarted process would.
+		return &DiskStorage{dir: s.dir}
+	})
+}
+
+func TestDiskStorageTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	_, s, err := OpenDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append([]Entry{entry(1, 1, "a")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of appending a record.
+	f, err := os.OpenFile(filepath.Join(s.dir, logFile), os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(frame(entriesRecord([]Entry{entry(1, 2, "b")}))[:10]); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// The torn record is discarded, and later records are readable.
+	_, _, entries, err := s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Entry{entry(1, 1, "a")}, entries); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+	if err := s.Append([]Entry{entry(1, 2, "c")}); err != nil {
+		t.Fatal(err)
+	}
+	_, _, entries, err = s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Entry{entry(1, 1, "a"), entry(1, 2, "c")}, entries); diff != "" {
+		t.Fatalf("entries (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenDisk(t *testing.T) {
+	dir := t.TempDir()
+	id1, s1, err := OpenDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in use isn't reused.
+	id2, s2, err := OpenDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id1 == id2 {
+		t.Fatalf("OpenDisk: reused directory %s in use", id1)
+	}
+
+	// A released directory is reused.
+	if err := s1.Close(); err != nil {
+		t.Fatal(err)
+	}
+	id3, s3, err := OpenDisk(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id3 != id1 {
+		t.Fatalf("OpenDisk: got %s, want released directory %s", id3, id1)
+	}
+	s2.Close()
+	s3.Close()
+}
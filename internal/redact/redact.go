@@ -0,0 +1,221 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact formats values with their sensitive data masked.
+//
+// A value's sensitive data is masked if the value implements weaver.Redactor,
+// in which case it is formatted as the result of its Redact method, or if it
+// is a struct with fields tagged `weaver:"redact"`, in which case those fields
+// are formatted as Mask. Redaction applies recursively to the elements of
+// structs, pointers, slices, arrays, and maps. Types with String or Error
+// methods that don't implement weaver.Redactor are formatted by those methods.
+package redact
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces the values of redacted struct fields.
+const Mask = "REDACTED"
+
+// redactor is identical to weaver.Redactor.
+type redactor interface {
+	Redact() any
+}
+
+var (
+	redactorType = reflect.TypeOf((*redactor)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// String returns x formatted like fmt.Sprint(x) but with its sensitive data
+// masked. Values without sensitive data are formatted exactly like
+// fmt.Sprint.
+func String(x any) string {
+	if x == nil {
+		return fmt.Sprint(x)
+	}
+	var b strings.Builder
+	format(&b, reflect.ValueOf(x), true)
+	return b.String()
+}
+
+// Sensitive returns whether x may contain sensitive data that String would
+// mask.
+func Sensitive(x any) bool {
+	return x != nil && sensitive(reflect.TypeOf(x))
+}
+
+// format writes v, formatted with its sensitive data masked, to b. top is
+// true if v is the value passed to String.
+func format(b *strings.Builder, v reflect.Value, top bool) {
+	if !v.IsValid() {
+		b.WriteString("<nil>")
+		return
+	}
+	t := v.Type()
+	if !sensitive(t) {
+		fmt.Fprint(b, printable(v))
+		return
+	}
+	if t.Implements(redactorType) {
+		switch {
+		case !v.CanInterface():
+			// Redact can't be called on unexported struct fields.
+			b.WriteString(Mask)
+		case t.Kind() == reflect.Pointer && v.IsNil():
+			b.WriteString("<nil>")
+		default:
+			fmt.Fprint(b, v.Interface().(redactor).Redact())
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			b.WriteString("<nil>")
+			return
+		}
+		if !top {
+			// Like fmt, only print the pointed-to value of top-level
+			// pointers.
+			fmt.Fprintf(b, "%#x", v.Pointer())
+			return
+		}
+		b.WriteString("&")
+		format(b, v.Elem(), false)
+
+	case reflect.Struct:
+		b.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			if redacted(t.Field(i)) {
+				b.WriteString(Mask)
+				continue
+			}
+			format(b, v.Field(i), false)
+		}
+		b.WriteString("}")
+
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && v.IsNil() {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteString("[")
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			format(b, v.Index(i), false)
+		}
+		b.WriteString("]")
+
+	case reflect.Map:
+		// Like fmt, sort map entries by key.
+		type entry struct{ k, v string }
+		entries := make([]entry, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			var k, e strings.Builder
+			format(&k, iter.Key(), false)
+			format(&e, iter.Value(), false)
+			entries = append(entries, entry{k.String(), e.String()})
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].k < entries[j].k })
+		b.WriteString("map[")
+		for i, e := range entries {
+			if i > 0 {
+				b.WriteString(" ")
+			}
+			fmt.Fprintf(b, "%s:%s", e.k, e.v)
+		}
+		b.WriteString("]")
+
+	default:
+		fmt.Fprint(b, printable(v))
+	}
+}
+
+// printable returns a value that fmt formats the same way as v. Values of
+// unexported struct fields can't be converted to interfaces, but fmt formats
+// a reflect.Value as the value it holds.
+func printable(v reflect.Value) any {
+	if v.CanInterface() {
+		return v.Interface()
+	}
+	return v
+}
+
+// redacted returns whether the provided struct field is tagged
+// `weaver:"redact"`.
+func redacted(f reflect.StructField) bool {
+	return f.Tag.Get("weaver") == "redact"
+}
+
+// cache caches the results of sensitive, by type.
+var cache sync.Map // map[reflect.Type]bool
+
+// sensitive returns whether values of type t may contain sensitive data.
+func sensitive(t reflect.Type) bool {
+	if s, ok := cache.Load(t); ok {
+		return s.(bool)
+	}
+	s := sensitiveType(t, map[reflect.Type]bool{})
+	cache.Store(t, s)
+	return s
+}
+
+// sensitiveType returns whether values of type t may contain sensitive data.
+// visiting holds the types being visited, to handle recursive types.
+//
+// Types with String or Error methods control their own formatting, so they are
+// not considered sensitive unless they also implement redactor. Neither are
+// interface types, since only the dynamic types of top-level values are
+// inspected.
+func sensitiveType(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if t.Implements(redactorType) {
+		return true
+	}
+	if t.Implements(stringerType) || t.Implements(errorType) {
+		return false
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array:
+		return sensitiveType(t.Elem(), visiting)
+	case reflect.Map:
+		return sensitiveType(t.Key(), visiting) || sensitiveType(t.Elem(), visiting)
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if redacted(f) || sensitiveType(f.Type, visiting) {
+				return true
+			}
+		}
+	}
+	return false
+}
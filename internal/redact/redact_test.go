@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"fmt"
+	"testing"
+)
+
+type card struct {
+	Holder string
+	Number string `weaver:"redact"`
+}
+
+type password string
+
+func (password) Redact() any { return "***" }
+
+type login struct {
+	User     string
+	Password password
+}
+
+type account struct {
+	Cards  []card
+	Logins map[string]login
+	secret password
+}
+
+type stringer struct {
+	Secret string `weaver:"redact"`
+}
+
+func (stringer) String() string { return "stringer" }
+
+func TestString(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		x    any
+		want string
+	}{
+		{"Nil", nil, "<nil>"},
+		{"Int", 42, "42"},
+		{"PlainStruct", struct{ A, B int }{1, 2}, "{1 2}"},
+		{"TaggedField", card{"alice", "4111"}, "{alice REDACTED}"},
+		{"Pointer", &card{"alice", "4111"}, "&{alice REDACTED}"},
+		{"Redactor", password("hunter2"), "***"},
+		{"NestedRedactor", login{"alice", "hunter2"}, "{alice ***}"},
+		{"Slice", []card{{"alice", "1"}, {"bob", "2"}}, "[{alice REDACTED} {bob REDACTED}]"},
+		{"Map", map[string]password{"b": "x", "a": "y"}, "map[a:*** b:***]"},
+		{
+			"Nested",
+			account{
+				Cards:  []card{{"alice", "1"}},
+				Logins: map[string]login{"web": {"alice", "x"}},
+				secret: "y",
+			},
+			"{[{alice REDACTED}] map[web:{alice ***}] REDACTED}",
+		},
+		{"Stringer", stringer{"x"}, "stringer"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := String(test.x); got != test.want {
+				t.Fatalf("String(%#v): got %q, want %q", test.x, got, test.want)
+			}
+		})
+	}
+}
+
+func TestStringMatchesFmt(t *testing.T) {
+	// Values without sensitive data are formatted exactly like fmt.Sprint.
+	for _, x := range []any{
+		1, "a", []int{1, 2}, map[string]int{"a": 1}, struct{ a []string }{[]string{"x"}}, fmt.Errorf("err"),
+	} {
+		if got, want := String(x), fmt.Sprint(x); got != want {
+			t.Errorf("String(%#v): got %q, want %q", x, got, want)
+		}
+	}
+}
+
+func TestSensitive(t *testing.T) {
+	for _, test := range []struct {
+		x    any
+		want bool
+	}{
+		{nil, false},
+		{42, false},
+		{card{}, true},
+		{[]login{}, true},
+		{account{}, true},
+		{stringer{}, false},
+		{map[string]int{}, false},
+	} {
+		if got := Sensitive(test.x); got != test.want {
+			t.Errorf("Sensitive(%#v): got %t, want %t", test.x, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package resources configures the machine resources that components require.
+//
+// Some components only run on particular machines: a component that runs
+// inference needs a GPU, a component that maps a large table needs huge pages,
+// and a component that talks to a device needs the device. The resources that
+// the machines hosting a component must have are set in the [resources]
+// section of a config file:
+//
+//	[resources.components."github.com/example/app/Inference"]
+//	gpus = 1
+//	devices = ["/dev/nvidia0"]
+//	hugepages = 1073741824
+//	numa_node = 0
+//
+// Deployers place the replicas of a component only on machines that have the
+// required resources. The resources of a machine are described by labels (see
+// Satisfies).
+package resources
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/resources"
+	shortConfigKey = "resources"
+)
+
+// Labels that describe the resources of a machine.
+const (
+	GPUsLabel      = "gpus"       // number of GPUs, e.g., gpus=2
+	DevicesLabel   = "devices"    // comma separated devices, e.g., devices=/dev/nvidia0,/dev/nvidia1
+	HugepagesLabel = "hugepages"  // bytes of huge pages, e.g., hugepages=4294967296
+	NUMANodesLabel = "numa_nodes" // comma separated NUMA nodes, e.g., numa_nodes=0,1
+)
+
+// Config configures the resources that components require.
+type Config struct {
+	// Components are the resources that every component requires, keyed by
+	// full component name.
+	Components map[string]*Requirements `toml:"components"`
+}
+
+// Requirements are the resources that a machine must have to host a
+// component.
+type Requirements struct {
+	GPUs      int      `toml:"gpus"`      // minimum number of GPUs
+	Devices   []string `toml:"devices"`   // devices, e.g., "/dev/fuse"
+	Hugepages int64    `toml:"hugepages"` // minimum bytes of huge pages
+	NUMANode  *int     `toml:"numa_node"` // NUMA node, if any
+}
+
+// ParseConfig parses the resources config in the provided app config.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	for component, r := range c.Components {
+		if err := r.validate(); err != nil {
+			return fmt.Errorf("component %q: %w", component, err)
+		}
+	}
+	return nil
+}
+
+// validate validates the requirements.
+func (r *Requirements) validate() error {
+	if r.GPUs < 0 {
+		return fmt.Errorf("invalid gpus %d: must be non-negative", r.GPUs)
+	}
+	if r.Hugepages < 0 {
+		return fmt.Errorf("invalid hugepages %d: must be non-negative", r.Hugepages)
+	}
+	if r.NUMANode != nil && *r.NUMANode < 0 {
+		return fmt.Errorf("invalid numa_node %d: must be non-negative", *r.NUMANode)
+	}
+	for _, device := range r.Devices {
+		if !filepath.IsAbs(device) || strings.Contains(device, ",") {
+			return fmt.Errorf("invalid device %q: want an absolute path without commas", device)
+		}
+	}
+	return nil
+}
+
+// Groups returns the resources that every colocation group requires, keyed by
+// group name. A group requires the resources of all of its components.
+// groupOf returns the name of the colocation group of a component, or false
+// if there is no such component.
+func (c *Config) Groups(groupOf func(component string) (string, bool)) (map[string]*Requirements, error) {
+	groups := map[string]*Requirements{}
+	for component, r := range c.Components {
+		group, ok := groupOf(component)
+		if !ok {
+			return nil, fmt.Errorf("resources specified for unknown component %q", component)
+		}
+		g, ok := groups[group]
+		if !ok {
+			g = &Requirements{}
+			groups[group] = g
+		}
+		g.GPUs = max(g.GPUs, r.GPUs)
+		g.Hugepages = max(g.Hugepages, r.Hugepages)
+		for _, device := range r.Devices {
+			if !slices.Contains(g.Devices, device) {
+				g.Devices = append(g.Devices, device)
+			}
+		}
+		if r.NUMANode != nil {
+			if g.NUMANode != nil && *g.NUMANode != *r.NUMANode {
+				return nil, fmt.Errorf("colocation group %q requires NUMA nodes %d and %d", group, *g.NUMANode, *r.NUMANode)
+			}
+			g.NUMANode = r.NUMANode
+		}
+	}
+	for _, g := range groups {
+		slices.Sort(g.Devices)
+	}
+	return groups, nil
+}
+
+// Satisfies returns true if a machine with the provided labels has the
+// required resources. A machine has the number of GPUs, the devices, the bytes
+// of huge pages, and the NUMA nodes listed in its gpus, devices, hugepages, and
+// numa_nodes labels respectively, and none if a label is missing. Nil
+// requirements are satisfied by every machine.
+func (r *Requirements) Satisfies(labels map[string]string) bool {
+	if r == nil {
+		return true
+	}
+	if r.GPUs > 0 && intLabel(labels, GPUsLabel) < int64(r.GPUs) {
+		return false
+	}
+	if r.Hugepages > 0 && intLabel(labels, HugepagesLabel) < r.Hugepages {
+		return false
+	}
+	devices := listLabel(labels, DevicesLabel)
+	for _, device := range r.Devices {
+		if !slices.Contains(devices, device) {
+			return false
+		}
+	}
+	if r.NUMANode != nil && !slices.Contains(listLabel(labels, NUMANodesLabel), strconv.Itoa(*r.NUMANode)) {
+		return false
+	}
+	return true
+}
+
+// String returns a human readable description of the requirements, e.g.,
+// "gpus=1 hugepages=1073741824".
+func (r *Requirements) String() string {
+	var parts []string
+	if r.GPUs > 0 {
+		parts = append(parts, fmt.Sprintf("%s=%d", GPUsLabel, r.GPUs))
+	}
+	if len(r.Devices) > 0 {
+		parts = append(parts, fmt.Sprintf("%s=%s", DevicesLabel, strings.Join(r.Devices, ",")))
+	}
+	if r.Hugepages > 0 {
+		parts = append(parts, fmt.Sprintf("%s=%d", HugepagesLabel, r.Hugepages))
+	}
+	if r.NUMANode != nil {
+		parts = append(parts, fmt.Sprintf("numa_node=%d", *r.NUMANode))
+	}
+	return strings.Join(parts, " ")
+}
+
+// intLabel returns the value of the provided integer label, or 0 if the label
+// is missing or invalid.
+func intLabel(labels map[string]string, key string) int64 {
+	n, err := strconv.ParseInt(labels[key], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// listLabel returns the comma separated values of the provided label.
+func listLabel(labels map[string]string, key string) []string {
+	value := labels[key]
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/go-cmp/cmp"
+)
+
+func parse(t *testing.T, config string) (*Config, error) {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseConfig(app)
+}
+
+func TestGroups(t *testing.T) {
+	config, err := parse(t, `
+[resources.components."a/A"]
+gpus = 1
+devices = ["/dev/b", "/dev/a"]
+
+[resources.components."a/B"]
+gpus = 2
+hugepages = 1024
+devices = ["/dev/a"]
+numa_node = 1
+
+[resources.components."a/C"]
+numa_node = 0
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	colocation := map[string]string{"a/A": "a/A", "a/B": "a/A", "a/C": "a/C"}
+	groupOf := func(component string) (string, bool) {
+		group, ok := colocation[component]
+		return group, ok
+	}
+	got, err := config.Groups(groupOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	one, zero := 1, 0
+	want := map[string]*Requirements{
+		"a/A": {GPUs: 2, Hugepages: 1024, Devices: []string{"/dev/a", "/dev/b"}, NUMANode: &one},
+		"a/C": {NUMANode: &zero},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Groups (-want +got):\n%s", diff)
+	}
+
+	// Colocated components can't require different NUMA nodes.
+	colocation["a/C"] = "a/A"
+	if _, err := config.Groups(groupOf); err == nil || !strings.Contains(err.Error(), "NUMA nodes") {
+		t.Fatalf("Groups: got %v, want NUMA nodes error", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"NegativeGPUs", "gpus = -1", "invalid gpus"},
+		{"NegativeHugepages", "hugepages = -1", "invalid hugepages"},
+		{"NegativeNUMANode", "numa_node = -1", "invalid numa_node"},
+		{"RelativeDevice", `devices = ["nvidia0"]`, "invalid device"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse(t, "[resources.components.\"a/A\"]\n"+test.config)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	one := 1
+	gpu := &Requirements{GPUs: 2, Devices: []string{"/dev/nvidia0"}}
+	numa := &Requirements{Hugepages: 2048, NUMANode: &one}
+	for _, test := range []struct {
+		name         string
+		requirements *Requirements
+		labels       map[string]string
+		want         bool
+	}{
+		{"None", nil, map[string]string{}, true},
+		{"GPU", gpu, map[string]string{"gpus": "4", "devices": "/dev/nvidia0,/dev/nvidia1"}, true},
+		{"TooFewGPUs", gpu, map[string]string{"gpus": "1", "devices": "/dev/nvidia0"}, false},
+		{"MissingDevice", gpu, map[string]string{"gpus": "2", "devices": "/dev/nvidia1"}, false},
+		{"InvalidGPUs", gpu, map[string]string{"gpus": "many", "devices": "/dev/nvidia0"}, false},
+		{"NUMA", numa, map[string]string{"hugepages": "4096", "numa_nodes": "0,1"}, true},
+		{"WrongNUMANode", numa, map[string]string{"hugepages": "4096", "numa_nodes": "0"}, false},
+		{"TooFewHugepages", numa, map[string]string{"hugepages": "1024", "numa_nodes": "1"}, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.requirements.Satisfies(test.labels); got != test.want {
+				t.Fatalf("Satisfies(%v): got %t, want %t", test.labels, got, test.want)
+			}
+		})
+	}
+}
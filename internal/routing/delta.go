@@ -0,0 +1,122 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"slices"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/proto"
+)
+
+// Delta returns a routing info that, when applied to old by Apply, results in
+// new. Both old and new must be versioned. If new has the same version as
+// old, the returned delta only renews the lease of old. Note that a delta
+// can't remove an assignment.
+func Delta(old, new *protos.RoutingInfo) *protos.RoutingInfo {
+	delta := &protos.RoutingInfo{
+		Component:   new.Component,
+		Local:       new.Local,
+		Version:     new.Version,
+		BaseVersion: old.Version,
+		LeaseNanos:  new.LeaseNanos,
+	}
+	if new.Version == old.Version {
+		return delta
+	}
+	for _, replica := range new.Replicas {
+		if !slices.Contains(old.Replicas, replica) {
+			delta.Replicas = append(delta.Replicas, replica)
+		}
+	}
+	for _, replica := range old.Replicas {
+		if !slices.Contains(new.Replicas, replica) {
+			delta.RemovedReplicas = append(delta.RemovedReplicas, replica)
+		}
+	}
+	for replica, locality := range new.Localities {
+		if !proto.Equal(locality, old.Localities[replica]) {
+			if delta.Localities == nil {
+				delta.Localities = map[string]*protos.Locality{}
+			}
+			delta.Localities[replica] = locality
+		}
+	}
+	if !proto.Equal(old.Assignment, new.Assignment) {
+		delta.Assignment = new.Assignment
+	}
+	return delta
+}
+
+// Apply applies the provided update, either a full routing info or a delta
+// returned by Delta, to the routing info cur, which may be nil, and returns
+// the resulting routing info. Apply doesn't modify cur or update.
+//
+// If update is older than cur, Apply returns cur. If update is a delta
+// against a version other than cur's, Apply returns an error with code
+// FailedPrecondition (see codegen.CodeOf), and the sender should send the full
+// routing info instead.
+func Apply(cur, update *protos.RoutingInfo) (*protos.RoutingInfo, error) {
+	if cur != nil && update.Version != 0 && update.Version < cur.Version {
+		// The update is outdated, e.g., because it was reordered with a
+		// later one.
+		return cur, nil
+	}
+	if update.BaseVersion == 0 {
+		// A full routing info.
+		return update, nil
+	}
+	if cur == nil || cur.Version != update.BaseVersion {
+		var version uint64
+		if cur != nil {
+			version = cur.Version
+		}
+		return nil, codegen.Errorf(codegen.FailedPrecondition, "routing info delta for %q against version %d, have version %d", update.Component, update.BaseVersion, version)
+	}
+	if update.Version == update.BaseVersion {
+		// A lease renewal.
+		return cur, nil
+	}
+
+	next := &protos.RoutingInfo{
+		Component:  cur.Component,
+		Local:      cur.Local,
+		Assignment: cur.Assignment,
+		Localities: maps.Clone(cur.Localities),
+		Version:    update.Version,
+		LeaseNanos: update.LeaseNanos,
+	}
+	for _, replica := range cur.Replicas {
+		if !slices.Contains(update.RemovedReplicas, replica) {
+			next.Replicas = append(next.Replicas, replica)
+		}
+	}
+	next.Replicas = append(next.Replicas, update.Replicas...)
+	for _, replica := range update.RemovedReplicas {
+		delete(next.Localities, replica)
+	}
+	for replica, locality := range update.Localities {
+		if next.Localities == nil {
+			next.Localities = map[string]*protos.Locality{}
+		}
+		next.Localities[replica] = locality
+	}
+	if update.Assignment != nil {
+		next.Assignment = update.Assignment
+	}
+	return next, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestDeltaApply(t *testing.T) {
+	old := &protos.RoutingInfo{
+		Component:  "a",
+		Replicas:   []string{"x", "y"},
+		Localities: map[string]*protos.Locality{"x": {Zone: "1"}, "y": {Zone: "2"}},
+		Assignment: EqualSlices([]string{"x", "y"}),
+		Version:    1,
+	}
+	new := &protos.RoutingInfo{
+		Component:  "a",
+		Replicas:   []string{"y", "z"},
+		Localities: map[string]*protos.Locality{"y": {Zone: "2"}, "z": {Zone: "3"}},
+		Assignment: EqualSlices([]string{"y", "z"}),
+		Version:    2,
+		LeaseNanos: 10,
+	}
+
+	delta := Delta(old, new)
+	want := &protos.RoutingInfo{
+		Component:       "a",
+		Replicas:        []string{"z"},
+		RemovedReplicas: []string{"x"},
+		Localities:      map[string]*protos.Locality{"z": {Zone: "3"}},
+		Assignment:      new.Assignment,
+		Version:         2,
+		BaseVersion:     1,
+		LeaseNanos:      10,
+	}
+	if diff := cmp.Diff(want, delta, protocmp.Transform()); diff != "" {
+		t.Fatalf("Delta (-want +got):\n%s", diff)
+	}
+
+	got, err := Apply(old, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(new, got, protocmp.Transform()); diff != "" {
+		t.Fatalf("Apply (-want +got):\n%s", diff)
+	}
+}
+
+func TestApply(t *testing.T) {
+	cur := &protos.RoutingInfo{Component: "a", Replicas: []string{"x"}, Version: 2}
+	for _, test := range []struct {
+		name   string
+		cur    *protos.RoutingInfo
+		update *protos.RoutingInfo
+		want   *protos.RoutingInfo
+	}{
+		{"Snapshot", cur, &protos.RoutingInfo{Component: "a", Version: 3}, &protos.RoutingInfo{Component: "a", Version: 3}},
+		{"Unversioned", cur, &protos.RoutingInfo{Component: "a"}, &protos.RoutingInfo{Component: "a"}},
+		{"Outdated", cur, &protos.RoutingInfo{Component: "a", Version: 1}, cur},
+		{"Renewal", cur, &protos.RoutingInfo{Component: "a", Version: 2, BaseVersion: 2}, cur},
+		{"First", nil, cur, cur},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Apply(test.cur, test.update)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+				t.Fatalf("Apply (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	// A delta against another version is rejected.
+	for _, base := range []*protos.RoutingInfo{nil, cur} {
+		_, err := Apply(base, &protos.RoutingInfo{Component: "a", Version: 4, BaseVersion: 3})
+		if got := codegen.CodeOf(err); got != codegen.FailedPrecondition {
+			t.Errorf("Apply(%v): got code %v, want FailedPrecondition", base, got)
+		}
+	}
+}
+
+func TestSender(t *testing.T) {
+	// The receiver applies the updates it receives, and can forget its
+	// routing info, e.g., when it restarts.
+	var received []*protos.RoutingInfo
+	var cur *protos.RoutingInfo
+	s := NewSender(func(update *protos.RoutingInfo) error {
+		received = append(received, update)
+		next, err := Apply(cur, update)
+		if err != nil {
+			return err
+		}
+		cur = next
+		return nil
+	}, time.Second)
+
+	// kinds returns the kind of every received update, and clears them.
+	kinds := func() []string {
+		var got []string
+		for _, update := range received {
+			switch {
+			case update.BaseVersion == 0:
+				got = append(got, "snapshot")
+			case update.BaseVersion == update.Version:
+				got = append(got, "renewal")
+			default:
+				got = append(got, "delta")
+			}
+		}
+		received = nil
+		return got
+	}
+
+	for _, step := range []struct {
+		name string
+		do   func() error
+		want []string
+	}{
+		{"First", func() error { return s.Send(&protos.RoutingInfo{Component: "a", Replicas: []string{"x"}}) }, []string{"snapshot"}},
+		{"Change", func() error { return s.Send(&protos.RoutingInfo{Component: "a", Replicas: []string{"x", "y"}}) }, []string{"delta"}},
+		{"Renew", s.Renew, []string{"renewal"}},
+		{"Restart", func() error {
+			cur = nil
+			return s.Send(&protos.RoutingInfo{Component: "a", Replicas: []string{"y"}})
+		}, []string{"delta", "snapshot"}},
+	} {
+		if err := step.do(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if diff := cmp.Diff(step.want, kinds()); diff != "" {
+			t.Fatalf("%s: updates (-want +got):\n%s", step.name, diff)
+		}
+	}
+	want := &protos.RoutingInfo{Component: "a", Replicas: []string{"y"}, Version: 3, LeaseNanos: int64(time.Second)}
+	if diff := cmp.Diff(want, cur, protocmp.Transform()); diff != "" {
+		t.Fatalf("routing info (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routing
+
+import (
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"google.golang.org/protobuf/proto"
+)
+
+// Sender sends the routing info of a component to a receiver, e.g., a
+// weavelet. It versions the routing info, sends deltas against the routing
+// info the receiver last acknowledged, and renews the lease of the routing
+// info. A Sender is not safe for concurrent use.
+type Sender struct {
+	send    func(*protos.RoutingInfo) error // sends an update to the receiver
+	lease   time.Duration                   // the lease of the routing info, or 0
+	version uint64                          // the version of cur
+	cur     *protos.RoutingInfo             // the latest routing info, or nil
+	last    *protos.RoutingInfo             // the last acknowledged routing info, or nil
+}
+
+// NewSender returns a Sender that sends updates with the provided function,
+// with the provided lease (zero for none).
+func NewSender(send func(*protos.RoutingInfo) error, lease time.Duration) *Sender {
+	return &Sender{send: send, lease: lease}
+}
+
+// Send sends the provided routing info to the receiver, as a delta if the
+// receiver acknowledged an earlier one.
+func (s *Sender) Send(info *protos.RoutingInfo) error {
+	s.version++
+	s.cur = proto.Clone(info).(*protos.RoutingInfo)
+	s.cur.Version = s.version
+	s.cur.LeaseNanos = int64(s.lease)
+	return s.flush()
+}
+
+// Renew renews the lease of the latest routing info, resending it if the
+// receiver didn't acknowledge it.
+func (s *Sender) Renew() error {
+	if s.cur == nil || s.lease == 0 {
+		return nil
+	}
+	return s.flush()
+}
+
+// flush sends the latest routing info to the receiver, as a delta against the
+// last acknowledged routing info, if any. If the receiver rejects the delta,
+// flush sends the full routing info instead.
+func (s *Sender) flush() error {
+	var err error
+	if s.last != nil {
+		err = s.send(Delta(s.last, s.cur))
+	}
+	if s.last == nil || codegen.CodeOf(err) == codegen.FailedPrecondition {
+		// The receiver has no routing info to apply a delta to, e.g., because
+		// it restarted, or missed an update.
+		err = s.send(s.cur)
+	}
+	if err != nil {
+		s.last = nil
+		return err
+	}
+	s.last = s.cur
+	return nil
+}
@@ -0,0 +1,253 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scheduling schedules the execution of the calls received by a
+// weavelet.
+//
+// By default, a weavelet runs every call it receives as soon as it arrives.
+// Under load, low-priority calls then compete for CPU with high-priority ones,
+// and the tail latency of every method grows. The [scheduling] section of a
+// config file bounds the number of calls a weavelet executes at a time; the
+// other calls wait in a queue, ordered by priority and then by deadline:
+//
+//	[scheduling]
+//	max_concurrent_calls = 64
+//	priorities."github.com/example/app/Cache.Get" = "high"
+//	priorities."github.com/example/app/Indexer.Reindex" = "low"
+//
+// The priority of a call is the priority set by the caller with
+// weaver.WithPriority, or else the priority of the method in the config, or
+// else normal. Calls without a deadline are ordered after calls with one.
+package scheduling
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/scheduling"
+	shortConfigKey = "scheduling"
+)
+
+// Call priorities. They match the values of weaver.Priority.
+const (
+	Low    = -1
+	Normal = 0
+	High   = 1
+)
+
+// priorities maps the priority names accepted in a config to priorities.
+var priorities = map[string]int{"low": Low, "normal": Normal, "high": High}
+
+// Config configures the scheduling of calls.
+type Config struct {
+	// MaxConcurrentCalls is the largest number of calls that a weavelet
+	// executes at a time. If zero, calls are executed as soon as they
+	// arrive.
+	MaxConcurrentCalls int `toml:"max_concurrent_calls"`
+
+	// Priorities is the priority of the calls to every method, keyed by
+	// "<full component name>.<method name>": "low", "normal", or "high".
+	Priorities map[string]string `toml:"priorities"`
+}
+
+// ParseConfig parses the scheduling config in the provided config sections.
+func ParseConfig(sections map[string]string) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	if c.MaxConcurrentCalls < 0 {
+		return fmt.Errorf("invalid max_concurrent_calls %d: must be non-negative", c.MaxConcurrentCalls)
+	}
+	for method, priority := range c.Priorities {
+		if !strings.Contains(method, ".") {
+			return fmt.Errorf("invalid method %q: want <component>.<method>", method)
+		}
+		if _, ok := priorities[priority]; !ok {
+			return fmt.Errorf("method %q: invalid priority %q: want low, normal, or high", method, priority)
+		}
+	}
+	return nil
+}
+
+// Priority returns the configured priority of the calls to the provided
+// method of the provided component.
+func (c *Config) Priority(component, method string) int {
+	if p, ok := c.Priorities[component+"."+method]; ok {
+		return priorities[p]
+	}
+	return Normal
+}
+
+// Scheduler admits calls for execution, at most a bounded number at a time, in
+// order of priority and then of deadline. Calls with the same priority and
+// deadline are admitted in the order they arrive. A Scheduler is safe for
+// concurrent use.
+type Scheduler struct {
+	limit int // the maximum number of running calls, or 0 for no limit
+
+	mu      sync.Mutex
+	running int       // the number of admitted calls that haven't finished
+	waiting waitQueue // calls waiting to be admitted
+	seq     uint64    // the sequence number of the next waiting call
+}
+
+// NewScheduler returns a scheduler that admits at most limit calls at a time,
+// or any number if limit is zero.
+func NewScheduler(limit int) *Scheduler {
+	return &Scheduler{limit: limit}
+}
+
+// Acquire waits until a call with the provided priority and deadline (zero if
+// none) is admitted, and returns a function to call when the call finishes. It
+// returns ctx.Err() if ctx is done first.
+func (s *Scheduler) Acquire(ctx context.Context, priority int, deadline time.Time) (func(), error) {
+	s.mu.Lock()
+	if s.limit == 0 || (s.running < s.limit && len(s.waiting) == 0) {
+		s.running++
+		s.mu.Unlock()
+		return s.release, nil
+	}
+	w := &waiter{
+		priority: priority,
+		deadline: deadline,
+		seq:      s.seq,
+		admitted: make(chan struct{}),
+	}
+	s.seq++
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.admitted:
+		return s.release, nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.index < 0 {
+			// The call was admitted while ctx became done. Give the slot
+			// to another call.
+			s.releaseLocked()
+		} else {
+			heap.Remove(&s.waiting, w.index)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Bypass records that a call runs without waiting to be admitted, e.g.,
+// because it was made by an admitted call, and returns a function to call when
+// the call finishes. The call counts against the limit of running calls.
+func (s *Scheduler) Bypass() func() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.running++
+	return s.release
+}
+
+// Running returns the number of admitted calls that haven't finished, and the
+// number of calls waiting to be admitted.
+func (s *Scheduler) Running() (running, waiting int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running, len(s.waiting)
+}
+
+// release records that an admitted call finished, and admits waiting calls.
+func (s *Scheduler) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+// releaseLocked records that an admitted call finished, and admits waiting
+// calls.
+//
+// REQUIRES: s.mu is held.
+func (s *Scheduler) releaseLocked() {
+	s.running--
+	for s.running < s.limit && len(s.waiting) > 0 {
+		w := heap.Pop(&s.waiting).(*waiter)
+		s.running++
+		close(w.admitted)
+	}
+}
+
+// waiter is a call waiting to be admitted.
+type waiter struct {
+	priority int
+	deadline time.Time // zero if none
+	seq      uint64
+	admitted chan struct{} // closed when the call is admitted
+	index    int           // index in the waitQueue, or -1 once popped
+}
+
+// before returns whether w should be admitted before x.
+func (w *waiter) before(x *waiter) bool {
+	if w.priority != x.priority {
+		return w.priority > x.priority
+	}
+	if !w.deadline.Equal(x.deadline) {
+		switch {
+		case w.deadline.IsZero():
+			return false
+		case x.deadline.IsZero():
+			return true
+		default:
+			return w.deadline.Before(x.deadline)
+		}
+	}
+	return w.seq < x.seq
+}
+
+// waitQueue is a heap of waiting calls, implementing heap.Interface.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int           { return len(q) }
+func (q waitQueue) Less(i, j int) bool { return q[i].before(q[j]) }
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	w.index = -1
+	*q = old[:n-1]
+	return w
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scheduling
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/go-cmp/cmp"
+)
+
+func parse(t *testing.T, config string) (*Config, error) {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseConfig(app.Sections)
+}
+
+func TestPriority(t *testing.T) {
+	config, err := parse(t, `
+[scheduling]
+max_concurrent_calls = 8
+priorities."github.com/example/app/Cache.Get" = "high"
+priorities."github.com/example/app/Cache.Scan" = "low"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.MaxConcurrentCalls != 8 {
+		t.Fatalf("MaxConcurrentCalls: got %d, want 8", config.MaxConcurrentCalls)
+	}
+	for _, test := range []struct {
+		method string
+		want   int
+	}{
+		{"Get", High},
+		{"Scan", Low},
+		{"Put", Normal},
+	} {
+		if got := config.Priority("github.com/example/app/Cache", test.method); got != test.want {
+			t.Errorf("Priority(%q): got %d, want %d", test.method, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"NegativeLimit", "max_concurrent_calls = -1", "must be non-negative"},
+		{"NoMethod", `priorities.Cache = "high"`, "want <component>.<method>"},
+		{"BadPriority", `priorities."Cache.Get" = "urgent"`, "invalid priority"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse(t, "[scheduling]\n"+test.config)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestSchedulerOrder(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(1)
+	release, err := s.Acquire(ctx, Normal, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Queue calls while the only slot is taken, and record the order in which
+	// they are admitted.
+	now := time.Now()
+	waiting := []struct {
+		name     string
+		priority int
+		deadline time.Time
+	}{
+		{"low", Low, now},
+		{"normal-no-deadline", Normal, time.Time{}},
+		{"normal-late", Normal, now.Add(time.Hour)},
+		{"normal-early", Normal, now.Add(time.Minute)},
+		{"high", High, time.Time{}},
+		{"normal-early-2", Normal, now.Add(time.Minute)},
+	}
+	admitted := make(chan string, len(waiting))
+	for i, w := range waiting {
+		w := w
+		go func() {
+			release, err := s.Acquire(ctx, w.priority, w.deadline)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			admitted <- w.name
+			release()
+		}()
+		// Wait for the call to be queued, so that calls arrive in order.
+		for {
+			if _, n := s.Running(); n == i+1 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	release()
+	var got []string
+	for range waiting {
+		got = append(got, <-admitted)
+	}
+	want := []string{"high", "normal-early", "normal-early-2", "normal-late", "normal-no-deadline", "low"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("admission order (-want +got):\n%s", diff)
+	}
+	if running, waiting := s.Running(); running != 0 || waiting != 0 {
+		t.Fatalf("Running: got (%d, %d), want (0, 0)", running, waiting)
+	}
+}
+
+func TestSchedulerLimit(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(2)
+	var releases []func()
+	for i := 0; i < 2; i++ {
+		release, err := s.Acquire(ctx, Normal, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		releases = append(releases, release)
+	}
+
+	// A third call waits until a running call finishes.
+	admitted := make(chan func())
+	go func() {
+		release, err := s.Acquire(ctx, High, time.Time{})
+		if err != nil {
+			t.Error(err)
+		}
+		admitted <- release
+	}()
+	select {
+	case <-admitted:
+		t.Fatal("call admitted beyond the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	releases[0]()
+	(<-admitted)()
+	releases[1]()
+	if running, waiting := s.Running(); running != 0 || waiting != 0 {
+		t.Fatalf("Running: got (%d, %d), want (0, 0)", running, waiting)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := NewScheduler(1)
+	release, err := s.Acquire(context.Background(), Normal, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A canceled call stops waiting, and is removed from the queue.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, High, time.Time{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire: got %v, want context.DeadlineExceeded", err)
+	}
+	if running, waiting := s.Running(); running != 1 || waiting != 0 {
+		t.Fatalf("Running: got (%d, %d), want (1, 0)", running, waiting)
+	}
+	release()
+}
+
+func TestSchedulerBypass(t *testing.T) {
+	ctx := context.Background()
+	s := NewScheduler(1)
+	release, err := s.Acquire(ctx, Normal, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A bypassing call runs beyond the limit, and holds back waiting calls
+	// until it finishes.
+	bypass := s.Bypass()
+	release()
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, Normal, time.Time{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Acquire: got %v, want context.DeadlineExceeded", err)
+	}
+	bypass()
+	if running, waiting := s.Running(); running != 0 || waiting != 0 {
+		t.Fatalf("Running: got (%d, %d), want (0, 0)", running, waiting)
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simfarm implements a coordinator that distributes simulations
+// across many workers, and the client workers use to talk to it.
+//
+// A farm explores a single sweep of executions (see the sim package). The
+// coordinator leases consecutive ranges of the sweep to workers, along with
+// mutation candidates: variations of the inputs of failing executions that
+// were previously reported. Workers run the leased executions and report back
+// the number of executions they ran, the coverage they observed, and the
+// failures they found. The coordinator deduplicates failures by fingerprint
+// and aggregates coverage across all workers.
+//
+// Workers and the coordinator communicate using JSON over HTTP:
+//
+//	POST /lease   LeaseRequest -> Lease
+//	POST /report  Report       -> {}
+//	GET  /status                  Status
+//
+// Leases are not tracked. If a worker dies while running a lease, the lease's
+// executions are not run again.
+package simfarm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Params are the hyperparameters of an execution.
+type Params struct {
+	Seed        int64   `json:"seed"`
+	NumReplicas int     `json:"num_replicas"`
+	NumOps      int     `json:"num_ops"`
+	FailureRate float64 `json:"failure_rate"`
+	YieldRate   float64 `json:"yield_rate"`
+}
+
+// LeaseRequest is a request for a lease.
+type LeaseRequest struct {
+	Worker string `json:"worker"` // worker name
+}
+
+// Lease is a batch of executions leased to a worker: Count consecutive
+// executions of the sweep with seed Seed, starting at execution Start, and a
+// set of mutation candidates.
+type Lease struct {
+	ID         int64    `json:"id"`
+	Seed       int64    `json:"seed"`
+	Start      int64    `json:"start"`
+	Count      int64    `json:"count"`
+	Candidates []Params `json:"candidates"`
+}
+
+// Failure is a failing execution.
+type Failure struct {
+	Fingerprint string `json:"fingerprint"` // identifies failures caused by the same bug
+	Error       string `json:"error"`       // error returned by the failing op
+	Params      Params `json:"params"`      // inputs of the failing execution
+	History     string `json:"history"`     // history, in the format of sim.WriteHistory
+}
+
+// Report is a report of the results of a lease.
+type Report struct {
+	Worker     string           `json:"worker"`
+	Lease      int64            `json:"lease"`
+	Executions int64            `json:"executions"` // executions run
+	Ops        int64            `json:"ops"`        // ops run
+	Coverage   map[string]int64 `json:"coverage"`   // coverage counts, by key
+	Failures   []Failure        `json:"failures"`
+}
+
+// FailureSummary summarizes the failures that share a fingerprint.
+type FailureSummary struct {
+	Failure           // the first failure reported with this fingerprint
+	Count   int64     `json:"count"`   // number of failures reported
+	Workers []string  `json:"workers"` // workers that reported the failure
+	First   time.Time `json:"first"`   // when the failure was first reported
+}
+
+// Status is the status of a farm.
+type Status struct {
+	Seed       int64            `json:"seed"`
+	Leases     int64            `json:"leases"`     // leases issued
+	Workers    []string         `json:"workers"`    // workers that requested leases
+	Executions int64            `json:"executions"` // executions reported
+	Ops        int64            `json:"ops"`        // ops reported
+	Coverage   map[string]int64 `json:"coverage"`   // aggregate coverage
+	Failures   []FailureSummary `json:"failures"`   // unique failures, in the order they were found
+}
+
+// Coordinator coordinates a farm of workers. A Coordinator is an
+// http.Handler that serves the farm protocol.
+type Coordinator struct {
+	seed       int64 // seed of the sweep
+	leaseSize  int64 // number of sweep executions per lease
+	candidates int   // maximum number of mutation candidates per lease
+	mux        *http.ServeMux
+
+	mu         sync.Mutex
+	rand       *rand.Rand
+	next       int64 // next unleased execution of the sweep
+	leases     int64
+	workers    map[string]struct{}
+	executions int64
+	ops        int64
+	coverage   map[string]int64
+	failures   map[string]*FailureSummary // by fingerprint
+	order      []string                   // fingerprints, in the order found
+}
+
+var _ http.Handler = &Coordinator{}
+
+// NewCoordinator returns a coordinator for the sweep with the provided seed.
+// Every lease contains leaseSize executions of the sweep and up to candidates
+// mutation candidates.
+func NewCoordinator(seed int64, leaseSize, candidates int) (*Coordinator, error) {
+	if leaseSize <= 0 {
+		return nil, fmt.Errorf("lease size (%d) <= 0", leaseSize)
+	}
+	if candidates < 0 {
+		return nil, fmt.Errorf("candidates (%d) < 0", candidates)
+	}
+	c := &Coordinator{
+		seed:       seed,
+		leaseSize:  int64(leaseSize),
+		candidates: candidates,
+		mux:        http.NewServeMux(),
+		rand:       rand.New(rand.NewSource(seed)),
+		workers:    map[string]struct{}{},
+		coverage:   map[string]int64{},
+		failures:   map[string]*FailureSummary{},
+	}
+	c.mux.HandleFunc("/lease", post(c.Lease))
+	c.mux.HandleFunc("/report", post(func(r Report) (struct{}, error) {
+		return struct{}{}, c.Report(r)
+	}))
+	c.mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(c.Status())
+	})
+	return c, nil
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (c *Coordinator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mux.ServeHTTP(w, r)
+}
+
+// Lease leases the next range of the sweep to a worker.
+func (c *Coordinator) Lease(req LeaseRequest) (Lease, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.leases++
+	c.workers[req.Worker] = struct{}{}
+	lease := Lease{ID: c.leases, Seed: c.seed, Start: c.next, Count: c.leaseSize}
+	c.next += c.leaseSize
+	if len(c.order) > 0 {
+		for i := 0; i < c.candidates; i++ {
+			f := c.failures[c.order[c.rand.Intn(len(c.order))]]
+			lease.Candidates = append(lease.Candidates, mutate(c.rand, f.Params))
+		}
+	}
+	return lease, nil
+}
+
+// Report records the results of a lease.
+func (c *Coordinator) Report(r Report) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.executions += r.Executions
+	c.ops += r.Ops
+	for k, v := range r.Coverage {
+		c.coverage[k] += v
+	}
+	for _, f := range r.Failures {
+		summary, ok := c.failures[f.Fingerprint]
+		if !ok {
+			summary = &FailureSummary{Failure: f, First: time.Now()}
+			c.failures[f.Fingerprint] = summary
+			c.order = append(c.order, f.Fingerprint)
+		}
+		summary.Count++
+		if !contains(summary.Workers, r.Worker) {
+			summary.Workers = append(summary.Workers, r.Worker)
+		}
+	}
+	return nil
+}
+
+// Status returns the status of the farm.
+func (c *Coordinator) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status := Status{
+		Seed:       c.seed,
+		Leases:     c.leases,
+		Executions: c.executions,
+		Ops:        c.ops,
+		Coverage:   make(map[string]int64, len(c.coverage)),
+	}
+	for w := range c.workers {
+		status.Workers = append(status.Workers, w)
+	}
+	sort.Strings(status.Workers)
+	for k, v := range c.coverage {
+		status.Coverage[k] = v
+	}
+	for _, fp := range c.order {
+		summary := *c.failures[fp]
+		summary.Workers = append([]string(nil), summary.Workers...)
+		status.Failures = append(status.Failures, summary)
+	}
+	return status
+}
+
+// mutate returns a variation of the provided params, with a new seed and
+// with at most one other hyperparameter nudged.
+func mutate(r *rand.Rand, p Params) Params {
+	p.Seed = r.Int63()
+	switch r.Intn(4) {
+	case 0:
+		p.NumOps = max(1, p.NumOps+r.Intn(3)-1)
+	case 1:
+		p.NumReplicas = max(1, p.NumReplicas+r.Intn(3)-1)
+	case 2:
+		p.FailureRate = min(1, max(0, p.FailureRate+(r.Float64()-0.5)/10))
+	case 3:
+		p.YieldRate = min(1, max(0, p.YieldRate+(r.Float64()-0.5)/5))
+	}
+	return p
+}
+
+func contains(xs []string, x string) bool {
+	for _, y := range xs {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+// post returns an HTTP handler that decodes a JSON request of type Req,
+// passes it to f, and encodes the result as JSON.
+func post[Req, Resp any](f func(Req) (Resp, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req Req
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp, err := f(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+// Client is a client of a coordinator.
+type Client struct {
+	Addr string // address of the coordinator, e.g. "http://localhost:9000"
+}
+
+// Lease requests a lease from the coordinator.
+func (c Client) Lease(ctx context.Context, worker string) (Lease, error) {
+	var lease Lease
+	err := c.call(ctx, "/lease", LeaseRequest{Worker: worker}, &lease)
+	return lease, err
+}
+
+// Report reports the results of a lease to the coordinator.
+func (c Client) Report(ctx context.Context, r Report) error {
+	return c.call(ctx, "/report", r, &struct{}{})
+}
+
+// call issues a POST request to the coordinator.
+func (c Client) call(ctx context.Context, path string, req, resp any) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Addr+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("simfarm %s: %w", path, err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		var b bytes.Buffer
+		b.ReadFrom(httpResp.Body)
+		return fmt.Errorf("simfarm %s: %s: %s", path, httpResp.Status, bytes.TrimSpace(b.Bytes()))
+	}
+	return json.NewDecoder(httpResp.Body).Decode(resp)
+}
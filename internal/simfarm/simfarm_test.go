@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simfarm
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCoordinator(t *testing.T) {
+	c, err := NewCoordinator(42, 100, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(c)
+	defer server.Close()
+	client := Client{Addr: server.URL}
+	ctx := context.Background()
+
+	// Leases are consecutive ranges of the sweep. Without failures, there
+	// are no candidates.
+	a, err := client.Lease(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := client.Lease(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(Lease{ID: 1, Seed: 42, Start: 0, Count: 100}, a); diff != "" {
+		t.Fatalf("lease a (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(Lease{ID: 2, Seed: 42, Start: 100, Count: 100}, b); diff != "" {
+		t.Fatalf("lease b (-want +got):\n%s", diff)
+	}
+
+	// Failures are deduplicated by fingerprint and coverage is summed.
+	failure := Failure{Fingerprint: "f", Error: "boom", Params: Params{Seed: 1, NumReplicas: 2, NumOps: 3}}
+	for _, r := range []Report{
+		{Worker: "a", Lease: 1, Executions: 100, Ops: 300, Coverage: map[string]int64{"op Foo": 2}, Failures: []Failure{failure}},
+		{Worker: "b", Lease: 2, Executions: 100, Ops: 200, Coverage: map[string]int64{"op Foo": 1, "op Bar": 1}, Failures: []Failure{failure}},
+	} {
+		if err := client.Report(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := c.Status()
+	if len(status.Failures) != 1 {
+		t.Fatalf("failures: got %v, want 1", status.Failures)
+	}
+	got := status.Failures[0]
+	if got.Count != 2 || !cmp.Equal(got.Workers, []string{"a", "b"}) || got.Failure != failure {
+		t.Fatalf("failure: got %+v, want 2 reports of %+v from a and b", got, failure)
+	}
+	if diff := cmp.Diff(map[string]int64{"op Foo": 3, "op Bar": 1}, status.Coverage); diff != "" {
+		t.Fatalf("coverage (-want +got):\n%s", diff)
+	}
+	if status.Executions != 200 || status.Ops != 500 {
+		t.Fatalf("got %d executions and %d ops, want 200 and 500", status.Executions, status.Ops)
+	}
+
+	// Later leases include mutations of the failure.
+	lease, err := client.Lease(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lease.Candidates) != 3 {
+		t.Fatalf("candidates: got %v, want 3", lease.Candidates)
+	}
+	for _, p := range lease.Candidates {
+		if p.NumOps < 1 || p.NumReplicas < 1 || p.FailureRate < 0 || p.FailureRate > 1 || p.YieldRate < 0 || p.YieldRate > 1 {
+			t.Errorf("invalid candidate %+v", p)
+		}
+	}
+}
+
+func TestNewCoordinatorErrors(t *testing.T) {
+	if _, err := NewCoordinator(0, 0, 1); err == nil {
+		t.Error("NewCoordinator(lease size 0): unexpected success")
+	}
+	if _, err := NewCoordinator(0, 1, -1); err == nil {
+		t.Error("NewCoordinator(candidates -1): unexpected success")
+	}
+}
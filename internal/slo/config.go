@@ -0,0 +1,173 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package slo tracks the compliance of an application with its service level
+// objectives (SLOs), and the error budgets that remain.
+//
+// Objectives are defined in the [slo] section of a config file:
+//
+//	[slo]
+//	period = "720h"
+//	block_deploys = true
+//
+//	[[slo.objectives]]
+//	name = "cart availability"
+//	kind = "availability"
+//	component = "example.com/shop/Cart"
+//	target = 0.999
+//
+//	[[slo.objectives]]
+//	name = "checkout latency"
+//	kind = "latency"
+//	component = "example.com/shop/Cart"
+//	method = "Checkout"
+//	latency = "250ms"
+//	target = 0.99
+//
+// The multiprocess and SSH deployers count the calls that meet and miss every
+// objective, persist the counts across deployments, and export the compliance
+// and remaining error budget of every objective as metrics. If block_deploys
+// is set, new deployments of an application are refused while the error
+// budget of any of its objectives is exhausted.
+package slo
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/slo"
+	shortConfigKey = "slo"
+)
+
+// Objective kinds.
+const (
+	// Availability objectives are met by calls that succeed.
+	Availability = "availability"
+
+	// Latency objectives are met by calls that complete within the
+	// objective's latency.
+	Latency = "latency"
+)
+
+const (
+	// defaultPeriod is the default period over which compliance and error
+	// budgets are computed.
+	defaultPeriod = 30 * 24 * time.Hour
+
+	// maxPeriod is the longest period for which counts are retained.
+	maxPeriod = 90 * 24 * time.Hour
+)
+
+// Config configures service level objectives.
+type Config struct {
+	// Period is the rolling period over which compliance and error budgets
+	// are computed. Defaults to 30 days, and can be at most 90 days.
+	Period time.Duration `toml:"period"`
+
+	// BlockDeploys, if true, makes deployers refuse to deploy an application
+	// while the error budget of any of its objectives is exhausted.
+	BlockDeploys bool `toml:"block_deploys"`
+
+	// Objectives are the service level objectives.
+	Objectives []Objective `toml:"objectives"`
+}
+
+// Objective is a service level objective for a component or one of its
+// methods.
+type Objective struct {
+	// Name is the unique name of the objective.
+	Name string `toml:"name"`
+
+	// Kind is Availability or Latency.
+	Kind string `toml:"kind"`
+
+	// Component is the component the objective is for, by full or short
+	// name.
+	Component string `toml:"component"`
+
+	// Method restricts the objective to a method of Component. If empty,
+	// the objective covers the calls to all the methods of the component.
+	Method string `toml:"method"`
+
+	// Target is the fraction of calls that must meet the objective, e.g.,
+	// 0.999. The error budget of the objective is the remaining fraction.
+	Target float64 `toml:"target"`
+
+	// Latency is the latency within which calls must complete to meet a
+	// Latency objective. Latencies are measured with histograms, so Latency
+	// is rounded down to the nearest histogram bucket bound.
+	Latency time.Duration `toml:"latency"`
+}
+
+// ParseConfig parses the SLO config in the provided app config. It returns
+// nil if the app config doesn't define any objectives.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	if len(config.Objectives) == 0 {
+		return nil, nil
+	}
+	return config, nil
+}
+
+// Validate validates and fills in the defaults of a config. It is called by
+// runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	if c.Period < 0 {
+		return fmt.Errorf("negative period %v", c.Period)
+	}
+	if c.Period == 0 {
+		c.Period = defaultPeriod
+	}
+	if c.Period > maxPeriod {
+		return fmt.Errorf("period %v longer than %v", c.Period, maxPeriod)
+	}
+	names := map[string]bool{}
+	for i := range c.Objectives {
+		o := &c.Objectives[i]
+		if o.Name == "" {
+			return fmt.Errorf("objective %d: missing name", i)
+		}
+		if names[o.Name] {
+			return fmt.Errorf("objective %q: duplicate name", o.Name)
+		}
+		names[o.Name] = true
+		switch o.Kind {
+		case Availability:
+			if o.Latency != 0 {
+				return fmt.Errorf("objective %q: latency set for an %s objective", o.Name, o.Kind)
+			}
+		case Latency:
+			if o.Latency <= 0 {
+				return fmt.Errorf("objective %q: missing latency", o.Name)
+			}
+		default:
+			return fmt.Errorf("objective %q: invalid kind %q; must be %q or %q", o.Name, o.Kind, Availability, Latency)
+		}
+		if o.Component == "" {
+			return fmt.Errorf("objective %q: missing component", o.Name)
+		}
+		if o.Target <= 0 || o.Target > 1 {
+			return fmt.Errorf("objective %q: target %v not in (0, 1]", o.Name, o.Target)
+		}
+	}
+	return nil
+}
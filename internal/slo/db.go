@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/sqlitedb"
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+)
+
+// DB is a database of the number of calls that met and missed objectives,
+// stored on the local file system. Counts are kept per application and
+// objective in hourly buckets, so that they outlive individual deployments.
+type DB struct {
+	db *sql.DB
+}
+
+// OpenDB opens the database persisted in the provided file. If the file
+// doesn't exist, OpenDB creates it.
+func OpenDB(ctx context.Context, fname string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(fname), 0700); err != nil {
+		return nil, err
+	}
+
+	db, err := sqlitedb.Open(fname)
+	if err != nil {
+		return nil, fmt.Errorf("open slo db %q: %w", fname, err)
+	}
+	d := &DB{db: db}
+
+	const initDB = `
+CREATE TABLE IF NOT EXISTS counts (
+	app TEXT NOT NULL,
+	objective TEXT NOT NULL,
+	hour_unix INTEGER NOT NULL,
+	total REAL NOT NULL,
+	bad REAL NOT NULL,
+	PRIMARY KEY (app, objective, hour_unix)
+);
+
+-- Garbage-collect counts older than the longest period, 90 days.
+CREATE TRIGGER IF NOT EXISTS expire_counts AFTER INSERT ON counts
+BEGIN
+	DELETE FROM counts WHERE hour_unix < unixepoch('now', '-90 days');
+END;
+`
+	if _, err := sqlitedb.Exec(ctx, d.db, initDB); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open slo db %q: %w", fname, err)
+	}
+	return d, nil
+}
+
+// Close closes the database.
+func (d *DB) Close() error {
+	return d.db.Close()
+}
+
+// Record adds the provided number of calls, and of calls that missed the
+// objective, to the counts of an objective at the provided time.
+func (d *DB) Record(ctx context.Context, app, objective string, t time.Time, total, bad float64) error {
+	const stmt = `
+INSERT INTO counts VALUES (?,?,?,?,?)
+ON CONFLICT (app, objective, hour_unix) DO UPDATE
+SET total = total + excluded.total, bad = bad + excluded.bad
+`
+	_, err := sqlitedb.Exec(ctx, d.db, stmt, app, objective, t.Truncate(time.Hour).Unix(), total, bad)
+	return err
+}
+
+// Counts returns the number of calls, and of calls that missed the objective,
+// recorded for an objective at or after the provided time. The time is
+// rounded down to the hour.
+func (d *DB) Counts(ctx context.Context, app, objective string, since time.Time) (total, bad float64, err error) {
+	const query = `
+SELECT COALESCE(SUM(total), 0), COALESCE(SUM(bad), 0)
+FROM counts
+WHERE app = ? AND objective = ? AND hour_unix >= ?
+`
+	for r := retry.Begin(); r.Continue(ctx); {
+		err = d.db.QueryRowContext(ctx, query, app, objective, since.Truncate(time.Hour).Unix()).Scan(&total, &bad)
+		if sqlitedb.IsLocked(err) {
+			continue
+		}
+		return total, bad, err
+	}
+	return 0, 0, ctx.Err()
+}
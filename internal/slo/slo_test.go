@@ -0,0 +1,214 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slo
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	app  = "shop"
+	cart = "example.com/shop/Cart"
+
+	fastBucket = 10 // latencies below 2ms
+	slowBucket = 17 // latencies between 200ms and 500ms
+)
+
+// methodMetrics returns the cumulative method metrics of a method of the cart
+// component, with the provided number of calls, errors, and fast and slow
+// calls.
+func methodMetrics(method string, calls, errors float64, fast, slow uint64) []*metrics.MetricSnapshot {
+	labels := map[string]string{"caller": "main", "component": cart, "method": method}
+	counts := make([]uint64, len(imetrics.GeneratedBuckets)+1)
+	counts[fastBucket] = fast
+	counts[slowBucket] = slow
+	return []*metrics.MetricSnapshot{
+		{Name: imetrics.MethodCountsName, Labels: labels, Value: calls},
+		{Name: imetrics.MethodErrorsName, Labels: labels, Value: errors},
+		{Name: imetrics.MethodLatenciesName, Labels: labels, Bounds: imetrics.GeneratedBuckets, Counts: counts},
+	}
+}
+
+func parse(t *testing.T, config string) *Config {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := ParseConfig(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func openDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := OpenDB(context.Background(), filepath.Join(t.TempDir(), "slo.DB"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+const testConfig = `
+[slo]
+block_deploys = true
+
+[[slo.objectives]]
+name = "availability"
+kind = "availability"
+component = "shop.Cart"
+target = 0.9
+
+[[slo.objectives]]
+name = "checkout latency"
+kind = "latency"
+component = "example.com/shop/Cart"
+method = "Checkout"
+latency = "200ms"
+target = 0.5
+`
+
+func TestParseConfig(t *testing.T) {
+	got := parse(t, testConfig)
+	want := &Config{
+		Period:       30 * 24 * time.Hour,
+		BlockDeploys: true,
+		Objectives: []Objective{
+			{Name: "availability", Kind: Availability, Component: "shop.Cart", Target: 0.9},
+			{Name: "checkout latency", Kind: Latency, Component: cart, Method: "Checkout", Latency: 200 * time.Millisecond, Target: 0.5},
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ParseConfig (-want +got):\n%s", diff)
+	}
+
+	if got := parse(t, ""); got != nil {
+		t.Fatalf("ParseConfig: got %v, want nil", got)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"LongPeriod", `period = "2400h"`, "longer than"},
+		{"BadKind", `[[slo.objectives]]
+name = "x"
+kind = "throughput"
+component = "shop.Cart"
+target = 0.9`, `invalid kind "throughput"`},
+		{"NoLatency", `[[slo.objectives]]
+name = "x"
+kind = "latency"
+component = "shop.Cart"
+target = 0.9`, "missing latency"},
+		{"NoComponent", `[[slo.objectives]]
+name = "x"
+kind = "availability"
+target = 0.9`, "missing component"},
+		{"BadTarget", `[[slo.objectives]]
+name = "x"
+kind = "availability"
+component = "shop.Cart"
+target = 99.9`, "not in (0, 1]"},
+		{"Duplicate", `[[slo.objectives]]
+name = "x"
+kind = "availability"
+component = "shop.Cart"
+target = 0.9
+[[slo.objectives]]
+name = "x"
+kind = "availability"
+component = "shop.Cart"
+target = 0.9`, "duplicate name"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			app, err := runtime.ParseConfig("weaver.toml", "[slo]\n"+test.config, codegen.ComponentConfigValidator)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = ParseConfig(app)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestTracker(t *testing.T) {
+	ctx := context.Background()
+	config := parse(t, testConfig)
+	db := openDB(t)
+	now := time.Now()
+
+	// Checkout: 100 calls, 4 failed, 80 fast. Add: 100 calls, 2 failed.
+	tracker := NewTracker(config, app, db, slog.Default())
+	snapshots := append(methodMetrics("Checkout", 100, 4, 80, 20), methodMetrics("Add", 100, 2, 100, 0)...)
+	if err := tracker.Update(ctx, now, snapshots); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checkout: 100 more calls, 14 failed, 20 fast. The Add process
+	// restarted and served 10 calls.
+	snapshots = append(methodMetrics("Checkout", 200, 18, 100, 100), methodMetrics("Add", 10, 0, 10, 0)...)
+	if err := tracker.Update(ctx, now.Add(time.Minute), snapshots); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := Statuses(ctx, db, config, app, now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	approx := cmp.Comparer(func(x, y float64) bool { return math.Abs(x-y) < 1e-9 })
+	want := []Status{
+		// 20 failed calls out of 310; the budget is 31 calls.
+		{Objective: config.Objectives[0], Total: 310, Bad: 20, Compliance: 1 - 20.0/310, BudgetRemaining: 1 - 20.0/31},
+		// 100 slow calls out of 200; the budget is 100 calls.
+		{Objective: config.Objectives[1], Total: 200, Bad: 100, Compliance: 0.5, BudgetRemaining: 0},
+	}
+	if diff := cmp.Diff(want, statuses, approx); diff != "" {
+		t.Fatalf("Statuses (-want +got):\n%s", diff)
+	}
+
+	// The latency objective's budget is exhausted.
+	err = CheckBudgets(ctx, db, config, app, now.Add(time.Minute))
+	if err == nil || !strings.Contains(err.Error(), "checkout latency") || strings.Contains(err.Error(), "availability:") {
+		t.Fatalf("CheckBudgets: got %v, want error about checkout latency", err)
+	}
+
+	// Counts expire after the period.
+	if err := CheckBudgets(ctx, db, config, app, now.Add(config.Period+2*time.Hour)); err != nil {
+		t.Fatalf("CheckBudgets after period: %v", err)
+	}
+	config.BlockDeploys = false
+	if err := CheckBudgets(ctx, db, config, app, now); err != nil {
+		t.Fatalf("CheckBudgets without block_deploys: %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package slo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	rmetrics "github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// updateInterval is how often a Tracker records counts.
+const updateInterval = time.Minute
+
+var (
+	complianceGauge = metrics.NewGaugeMap[objectiveLabels](
+		"serviceweaver_slo_compliance",
+		"Fraction of the calls covered by a service level objective that met it, over the objective's period",
+	)
+	budgetRemainingGauge = metrics.NewGaugeMap[objectiveLabels](
+		"serviceweaver_slo_error_budget_remaining",
+		"Fraction of the error budget of a service level objective that remains, over the objective's period",
+	)
+)
+
+type objectiveLabels struct {
+	App       string // application name
+	Objective string // objective name
+	Component string // component, as written in the config
+	Method    string // method, or empty for all methods
+}
+
+// Status is the status of an objective over its period.
+type Status struct {
+	Objective       Objective
+	Total           float64 // calls covered by the objective
+	Bad             float64 // calls that missed the objective
+	Compliance      float64 // fraction of calls that met the objective, or 1 if there were none
+	BudgetRemaining float64 // fraction of the error budget left; negative if overspent
+}
+
+// Exhausted returns whether the error budget of the objective is exhausted.
+func (s Status) Exhausted() bool {
+	return s.BudgetRemaining <= 0
+}
+
+// Statuses returns the status, at the provided time, of every objective of
+// the provided application.
+func Statuses(ctx context.Context, db *DB, config *Config, app string, now time.Time) ([]Status, error) {
+	statuses := make([]Status, 0, len(config.Objectives))
+	for _, o := range config.Objectives {
+		total, bad, err := db.Counts(ctx, app, o.Name, now.Add(-config.Period))
+		if err != nil {
+			return nil, err
+		}
+		s := Status{Objective: o, Total: total, Bad: bad, Compliance: 1, BudgetRemaining: 1}
+		if total > 0 {
+			s.Compliance = 1 - bad/total
+			if budget := (1 - o.Target) * total; budget > 0 {
+				s.BudgetRemaining = 1 - bad/budget
+			} else if bad > 0 {
+				s.BudgetRemaining = 0
+			}
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses, nil
+}
+
+// CheckBudgets returns an error if config.BlockDeploys is set and the error
+// budget of any objective of the provided application is exhausted.
+func CheckBudgets(ctx context.Context, db *DB, config *Config, app string, now time.Time) error {
+	if !config.BlockDeploys {
+		return nil
+	}
+	statuses, err := Statuses(ctx, db, config, app, now)
+	if err != nil {
+		return err
+	}
+	var exhausted []string
+	for _, s := range statuses {
+		if s.Exhausted() {
+			exhausted = append(exhausted, fmt.Sprintf("  %s: %.3f%% of calls met the objective; the target is %.3f%%",
+				s.Objective.Name, 100*s.Compliance, 100*s.Objective.Target))
+		}
+	}
+	if len(exhausted) > 0 {
+		return fmt.Errorf("deploys of %s are blocked because the error budgets of these objectives are exhausted:\n%s",
+			app, strings.Join(exhausted, "\n"))
+	}
+	return nil
+}
+
+// A Tracker records, for every objective of an application, the number of
+// calls that met and missed the objective, and exports the status of every
+// objective as metrics. It is safe for concurrent use.
+type Tracker struct {
+	config *Config
+	app    string
+	db     *DB
+	logger *slog.Logger
+
+	mu   sync.Mutex
+	prev map[imetrics.MethodKey]*imetrics.MethodCounts // cumulative counts of the previous update
+}
+
+// NewTracker returns a tracker for the objectives of the provided
+// application that records counts in db.
+func NewTracker(config *Config, app string, db *DB, logger *slog.Logger) *Tracker {
+	return &Tracker{
+		config: config,
+		app:    app,
+		db:     db,
+		logger: logger,
+		prev:   map[imetrics.MethodKey]*imetrics.MethodCounts{},
+	}
+}
+
+// Run updates the tracker every minute, until ctx is canceled. collect
+// returns the metric snapshots of every process of the deployment. The
+// counters in the snapshots must start at zero when the tracker is created,
+// as they do in a new deployment.
+func (t *Tracker) Run(ctx context.Context, collect func() []*rmetrics.MetricSnapshot) {
+	if err := t.export(ctx, time.Now()); err != nil {
+		t.logger.Error("Cannot export SLO metrics", "err", err)
+	}
+	ticker := time.NewTicker(updateInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.Update(ctx, time.Now(), collect()); err != nil {
+				t.logger.Error("Cannot update SLOs", "err", err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Update records the calls made since the previous update, given the
+// provided metric snapshots taken at the provided time, and exports the
+// status of every objective.
+func (t *Tracker) Update(ctx context.Context, now time.Time, snapshots []*rmetrics.MetricSnapshot) error {
+	cur := imetrics.AggregateMethods(snapshots, byMethod)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prev := t.prev
+	t.prev = cur
+	for _, o := range t.config.Objectives {
+		total, bad := measure(o, prev, cur)
+		if total == 0 {
+			continue
+		}
+		if err := t.db.Record(ctx, t.app, o.Name, now, total, bad); err != nil {
+			return err
+		}
+	}
+	return t.export(ctx, now)
+}
+
+// export exports the status of every objective as metrics.
+func (t *Tracker) export(ctx context.Context, now time.Time) error {
+	statuses, err := Statuses(ctx, t.db, t.config, t.app, now)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		labels := objectiveLabels{
+			App:       t.app,
+			Objective: s.Objective.Name,
+			Component: s.Objective.Component,
+			Method:    s.Objective.Method,
+		}
+		complianceGauge.Get(labels).Set(s.Compliance)
+		budgetRemainingGauge.Get(labels).Set(s.BudgetRemaining)
+	}
+	return nil
+}
+
+// measure returns the number of calls covered by an objective between prev
+// and cur, and the number of them that missed the objective.
+func measure(o Objective, prev, cur map[imetrics.MethodKey]*imetrics.MethodCounts) (total, bad float64) {
+	threshold := float64(o.Latency.Microseconds())
+	for k, c := range cur {
+		if o.Component != k.Component && o.Component != logging.ShortenComponent(k.Component) {
+			continue
+		}
+		if o.Method != "" && o.Method != k.Method {
+			continue
+		}
+		d := c.Sub(prev[k])
+		switch o.Kind {
+		case Availability:
+			total += d.Calls
+			bad += d.Errors
+		case Latency:
+			for i, n := range d.Latencies {
+				total += n
+				// Bucket i holds latencies below d.Bounds[i], or above every
+				// bound if i == len(d.Bounds).
+				if i == len(d.Bounds) || d.Bounds[i] > threshold {
+					bad += n
+				}
+			}
+		}
+	}
+	return total, bad
+}
+
+// byMethod projects a method key to its component and method, to aggregate
+// method metrics across callers.
+func byMethod(k imetrics.MethodKey) imetrics.MethodKey {
+	return imetrics.MethodKey{Component: k.Component, Method: k.Method}
+}
+
+// CheckDeploy returns an error if the provided application shouldn't be
+// deployed because its config sets block_deploys and the error budget of any
+// of its objectives, as recorded in the provided database file, is exhausted.
+func CheckDeploy(ctx context.Context, app *protos.AppConfig, fname string) error {
+	config, err := ParseConfig(app)
+	if err != nil || config == nil || !config.BlockDeploys {
+		return err
+	}
+	db, err := OpenDB(ctx, fname)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return CheckBudgets(ctx, db, config, app.Name, time.Now())
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlitedb contains helpers for the sqlite databases that deployers
+// keep on the local file system, like the trace and deployment history
+// databases. These databases may be opened by multiple processes at once, so
+// statements are retried as long as the database is locked by another process.
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Open opens the sqlite database persisted in the provided file. If the file
+// doesn't exist, it is created when the database is first used.
+func Open(fname string) (*sql.DB, error) {
+	// The DB may be opened by multiple writers. Turn on appropriate
+	// concurrency control options. See:
+	//   https://www.sqlite.org/pragma.html#pragma_locking_mode
+	//   https://www.sqlite.org/pragma.html#pragma_busy_timeout
+	const params = "?_locking_mode=NORMAL&_busy_timeout=10000"
+	db, err := sql.Open("sqlite", fname+params)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	return db, nil
+}
+
+// Exec executes a statement, retrying as long as the database is locked.
+func Exec(ctx context.Context, db *sql.DB, query string, args ...any) (sql.Result, error) {
+	// Keep retrying as long as we are getting the "locked" error.
+	for r := retry.Begin(); r.Continue(ctx); {
+		res, err := db.ExecContext(ctx, query, args...)
+		if IsLocked(err) {
+			continue
+		}
+		return res, err
+	}
+	return nil, ctx.Err()
+}
+
+// Query executes a query, retrying as long as the database is locked.
+func Query(ctx context.Context, db *sql.DB, query string, args ...any) (*sql.Rows, error) {
+	// Keep retrying as long as we are getting the "locked" error.
+	for r := retry.Begin(); r.Continue(ctx); {
+		rows, err := db.QueryContext(ctx, query, args...)
+		if IsLocked(err) {
+			continue
+		}
+		return rows, err
+	}
+	return nil, ctx.Err()
+}
+
+// IsLocked returns whether the error is a "database is locked" error.
+func IsLocked(err error) bool {
+	sqlError := &sqlite.Error{}
+	ok := errors.As(err, &sqlError)
+	return ok && (sqlError.Code() == sqlite3.SQLITE_BUSY || sqlError.Code() == sqlite3.SQLITE_LOCKED)
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlitedb
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecQuery(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	if _, err := Exec(ctx, db, "CREATE TABLE t (x INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 3; i++ {
+		if _, err := Exec(ctx, db, "INSERT INTO t VALUES (?)", i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rows, err := Query(ctx, db, "SELECT SUM(x) FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var sum int
+	if !rows.Next() {
+		t.Fatal("no rows")
+	}
+	if err := rows.Scan(&sum); err != nil {
+		t.Fatal(err)
+	}
+	if sum != 6 {
+		t.Fatalf("sum: got %d, want 6", sum)
+	}
+}
+
+func TestExecRetriesWhileLocked(t *testing.T) {
+	ctx := context.Background()
+	fname := filepath.Join(t.TempDir(), "test.db")
+	db, err := Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := Exec(ctx, db, "CREATE TABLE t (x INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lock the database from another connection that doesn't wait for locks.
+	other, err := sql.Open("sqlite", fname+"?_busy_timeout=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	other.SetMaxOpenConns(1)
+	if _, err := other.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatal(err)
+	}
+	_, err = other.ExecContext(ctx, "INSERT INTO t VALUES (1)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Statements from db fail with a "locked" error while the lock is held,
+	// and succeed once it is released.
+	timeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	if _, err := Exec(timeout, db, "INSERT INTO t VALUES (2)"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Exec while locked: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, err := other.ExecContext(ctx, "COMMIT"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Exec(ctx, db, "INSERT INTO t VALUES (2)"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsLocked(t *testing.T) {
+	for _, err := range []error{nil, errors.New("locked"), context.Canceled} {
+		if IsLocked(err) {
+			t.Errorf("IsLocked(%v): unexpected true", err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package standby configures warm standby replicas.
+//
+// A standby replica of a colocation group runs the group's components and
+// receives routing information and health checks like any other replica, but
+// no traffic. When an active replica of the group fails, a healthy standby is
+// promoted in its place: traffic is routed to it immediately, without waiting
+// for a new process to start and construct its components. This matters most
+// for routed components, whose replicas hold state that is slow to rebuild.
+//
+// The number of standby replicas of the colocation group of every component is
+// set in the [standby] section of a config file:
+//
+//	[standby]
+//	replicas."github.com/example/app/Cache" = 1
+package standby
+
+import (
+	"fmt"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/standby"
+	shortConfigKey = "standby"
+)
+
+// maxReplicas is the maximum number of standby replicas of a colocation group.
+const maxReplicas = 8
+
+// Config configures standby replicas.
+type Config struct {
+	// Replicas is the number of standby replicas of the colocation group of
+	// every component, keyed by full component name. If several components
+	// of a group are listed, the group gets the largest number of replicas.
+	Replicas map[string]int `toml:"replicas"`
+}
+
+// ParseConfig parses the standby config in the provided app config.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	for component, n := range c.Replicas {
+		if component == runtime.Main {
+			// A standby replica of the main component would run main.
+			return fmt.Errorf("standby replicas of %s are not supported", runtime.Main)
+		}
+		if n < 0 || n > maxReplicas {
+			return fmt.Errorf("component %q: %d standby replicas not in [0, %d]", component, n, maxReplicas)
+		}
+	}
+	return nil
+}
+
+// Groups returns the number of standby replicas of every colocation group,
+// keyed by group name. groupOf returns the name of the colocation group of a
+// component, or false if there is no such component.
+func (c *Config) Groups(groupOf func(component string) (string, bool)) (map[string]int, error) {
+	groups := map[string]int{}
+	for component, n := range c.Replicas {
+		group, ok := groupOf(component)
+		if !ok {
+			return nil, fmt.Errorf("standby replicas specified for unknown component %q", component)
+		}
+		groups[group] = max(groups[group], n)
+	}
+	return groups, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package standby
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/go-cmp/cmp"
+)
+
+func parse(t *testing.T, config string) (*Config, error) {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ParseConfig(app)
+}
+
+func TestGroups(t *testing.T) {
+	config, err := parse(t, `
+[standby]
+replicas."example.com/app/Cache" = 1
+replicas."example.com/app/Index" = 2
+replicas."example.com/app/Store" = 1
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Cache and Index are colocated.
+	groupOf := func(component string) (string, bool) {
+		switch component {
+		case "example.com/app/Cache", "example.com/app/Index":
+			return "example.com/app/Cache", true
+		case "example.com/app/Store":
+			return component, true
+		}
+		return "", false
+	}
+	got, err := config.Groups(groupOf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"example.com/app/Cache": 2, "example.com/app/Store": 1}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Groups (-want +got):\n%s", diff)
+	}
+
+	config.Replicas["example.com/app/Unknown"] = 1
+	if _, err := config.Groups(groupOf); err == nil || !strings.Contains(err.Error(), "unknown component") {
+		t.Fatalf("Groups: got %v, want error about unknown component", err)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"Negative", `replicas."example.com/app/Cache" = -1`, "not in [0, 8]"},
+		{"TooMany", `replicas."example.com/app/Cache" = 9`, "not in [0, 8]"},
+		{"Main", `replicas."github.com/ServiceWeaver/weaver/Main" = 1`, "not supported"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := parse(t, "[standby]\n"+test.config)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
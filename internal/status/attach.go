@@ -0,0 +1,200 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/colors"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+)
+
+// attachStatusInterval is how often an attached CLI checks the status of the
+// deployment.
+const attachStatusInterval = 2 * time.Second
+
+var (
+	attachFlags  = flag.NewFlagSet("attach", flag.ContinueOnError)
+	attachSystem = attachFlags.Bool("system", false, "Show system internal logs")
+)
+
+// AttachCommand returns an "attach" subcommand that attaches to a running
+// deployment registered with the provided registry: it shows the deployment's
+// status, and then follows the deployment's logs, read from the provided
+// source, and changes to its status, until the deployment stops. Detaching
+// leaves the deployment running.
+func AttachCommand(toolName string, registry func(context.Context) (*Registry, error), source func(context.Context) (logging.Source, error)) *tool.Command {
+	const help = `Usage:
+  {{.Tool}} attach [--system] <deployment>
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  '{{.Tool}} attach <deployment>' attaches to a running deployment, e.g., one
+  deployed in the background or by another terminal. It shows the status of
+  the deployment, and then follows its logs, and shows its status again
+  whenever it changes, until the deployment stops. Interrupting '{{.Tool}}
+  attach' (e.g., with Ctrl-C) detaches from the deployment, which keeps
+  running.
+
+  <deployment> is the id of the deployment, or a uniquely identifying prefix
+  of it, which can be found using '{{.Tool}} status'.`
+	var b strings.Builder
+	t := template.Must(template.New(toolName).Parse(help))
+	content := struct{ Tool, Flags string }{toolName, tool.FlagsHelp(attachFlags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "attach",
+		Description: "Attach to a running Service Weaver application",
+		Help:        b.String(),
+		Flags:       attachFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) != 1 || args[0] == "" {
+				return fmt.Errorf("usage: %s attach [--system] <deployment>", toolName)
+			}
+			r, err := registry(ctx)
+			if err != nil {
+				return fmt.Errorf("create registry: %w", err)
+			}
+			reg, err := lookup(ctx, r, args[0])
+			if err != nil {
+				return err
+			}
+			a := &attacher{registry: r, reg: reg}
+			return a.attach(ctx, source)
+		},
+	}
+}
+
+// An attacher follows a running deployment.
+type attacher struct {
+	registry *Registry
+	reg      Registration
+
+	mu   sync.Mutex // guards writes to stdout
+	last string     // the key of the last status shown; see showStatus
+}
+
+// attach follows the deployment until it stops.
+func (a *attacher) attach(ctx context.Context, source func(context.Context) (logging.Source, error)) error {
+	// Show the deployment's status.
+	status, err := NewClient(a.reg.Addr).Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Print(a.reg.Rolodex())
+	a.showStatus(status)
+
+	// Follow the deployment's logs, starting now.
+	src, err := source(ctx)
+	if err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`full_version == %q && time >= timestamp(%q)`, a.reg.DeploymentId, time.Now().Format(time.RFC3339))
+	if !*attachSystem {
+		query += ` && !("serviceweaver/system" in attrs)`
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	logs, err := src.Query(ctx, query, true)
+	if err != nil {
+		return err
+	}
+	defer logs.Close()
+
+	// Watch the deployment's status until it stops.
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		a.watch(ctx)
+		cancel()
+	}()
+
+	pp := logging.NewPrettyPrinter(colors.Enabled())
+	for {
+		entry, err := logs.Read(ctx)
+		if ctx.Err() != nil {
+			<-stopped
+			return nil
+		} else if err != nil {
+			return err
+		}
+		a.mu.Lock()
+		fmt.Println(pp.Format(entry))
+		a.mu.Unlock()
+	}
+}
+
+// watch shows the status of the deployment whenever it changes, until the
+// deployment stops or ctx is done.
+func (a *attacher) watch(ctx context.Context) {
+	ticker := time.NewTicker(attachStatusInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		status, err := NewClient(a.reg.Addr).Status(ctx)
+		if err != nil && ctx.Err() == nil {
+			// The deployment may have a new status server, e.g., because
+			// another manager took it over.
+			reg, err := a.registry.Get(ctx, a.reg.DeploymentId)
+			if err != nil {
+				a.mu.Lock()
+				fmt.Printf("Deployment %s stopped\n", a.reg.DeploymentId)
+				a.mu.Unlock()
+				return
+			}
+			a.reg = reg
+			continue
+		} else if err != nil {
+			return
+		}
+		a.showStatus(status)
+	}
+}
+
+// showStatus shows the provided status of the deployment, unless its
+// components, listeners, and resource limit events haven't changed since it
+// was last shown.
+func (a *attacher) showStatus(status *Status) {
+	statuses := []*Status{status}
+	var key strings.Builder
+	formatComponents(&key, statuses)
+	formatListeners(&key, statuses)
+	fmt.Fprint(&key, len(status.LimitEvents))
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if key.String() != a.last {
+		fmt.Print(format(statuses))
+		a.last = key.String()
+	}
+}
@@ -75,3 +75,27 @@ func (c *Client) Profile(ctx context.Context, req *protos.GetProfileRequest) (*p
 	})
 	return reply, err
 }
+
+// FlightRecord implements the Server interface.
+func (c *Client) FlightRecord(ctx context.Context) (*protos.GetFlightRecordReply, error) {
+	reply := &protos.GetFlightRecordReply{}
+	err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + c.addr,
+		URLPath: flightEndpoint,
+		Reply:   reply,
+	})
+	return reply, err
+}
+
+// Topology implements the Server interface.
+func (c *Client) Topology(ctx context.Context) (*protos.GetTopologyReply, error) {
+	reply := &protos.GetTopologyReply{}
+	err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    "http://" + c.addr,
+		URLPath: topologyEndpoint,
+		Reply:   reply,
+	})
+	return reply, err
+}
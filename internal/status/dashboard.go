@@ -28,8 +28,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/ServiceWeaver/weaver/internal/control"
-	metrics2 "github.com/ServiceWeaver/weaver/internal/metrics"
 	"github.com/ServiceWeaver/weaver/internal/traceio"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/metrics"
@@ -39,6 +37,7 @@ import (
 	dtool "github.com/ServiceWeaver/weaver/runtime/tool"
 	"github.com/ServiceWeaver/weaver/runtime/traces"
 	"github.com/pkg/browser"
+	"golang.org/x/exp/maps"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -70,12 +69,32 @@ var (
 			return strings.Join(s, ", ")
 
 		},
+		"locjoin": func(replicas []*Replica) string {
+			s := make([]string, len(replicas))
+			for i, x := range replicas {
+				s[i] = x.Location
+			}
+			return strings.Join(s, ", ")
+		},
+		"located": located,
+		"ready": func(replicas []*Replica) string {
+			ready := 0
+			for _, x := range replicas {
+				if x.Ready {
+					ready++
+				}
+			}
+			return fmt.Sprintf("%d/%d", ready, len(replicas))
+		},
 		"age": func(t *timestamppb.Timestamp) string {
 			return time.Since(t.AsTime()).Truncate(time.Second).String()
 		},
 		"dec": func(x int) int {
 			return x - 1
 		},
+		"ms": func(micros float64) float64 {
+			return micros / 1000
+		},
 	}).Parse(deploymentHTML))
 
 	//go:embed templates/traces.html
@@ -227,8 +246,8 @@ func (d *dashboard) handleDeployment(w http.ResponseWriter, r *http.Request) {
 		})
 	}
 
-	// Fetch metrics.
-	metrics, err := client.Metrics(r.Context())
+	// Fetch the live component graph.
+	topology, err := client.Topology(r.Context())
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -238,12 +257,16 @@ func (d *dashboard) handleDeployment(w http.ResponseWriter, r *http.Request) {
 	content := struct {
 		*Status
 		Tool     string
-		Traffic  []edge
+		Nodes    []string
+		Topology []topologyEdge
+		Window   time.Duration
 		Commands []Command
 	}{
 		Status:   status,
 		Tool:     d.spec.Tool,
-		Traffic:  computeTraffic(status, metrics.Metrics),
+		Nodes:    topologyNodes(status, topology.Topology),
+		Topology: topologyEdges(topology.Topology),
+		Window:   time.Duration(topology.Topology.GetWindowNanos()).Round(time.Second),
 		Commands: d.spec.Commands(id),
 	}
 	if err := deploymentTemplate.Execute(w, content); err != nil {
@@ -251,50 +274,43 @@ func (d *dashboard) handleDeployment(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// An edge represents an edge in a traffic graph. If a component s calls n
-// methods on component t, then an edge is formed from s to t with weight v.
-type edge struct {
-	Source string // calling component
-	Target string // callee component
-	Value  int    // number of method calls
+// A topologyEdge is an edge in the live component graph, as displayed on the
+// deployment page.
+type topologyEdge struct {
+	*protos.TopologyEdge
+	Value float64 // edge weight: the call rate, or the number of calls if there is no rate
+	Label string  // edge label
 }
 
-// computeTraffic calculates cross-component traffic.
-func computeTraffic(status *Status, metrics []*protos.MetricSnapshot) []edge {
-	// Aggregate traffic by component.
-	type pair struct {
-		caller    string
-		component string
+// topologyNodes returns the sorted names of the components in the provided
+// deployment status and component graph.
+func topologyNodes(status *Status, topology *protos.Topology) []string {
+	nodes := map[string]bool{}
+	for _, c := range status.Components {
+		nodes[c.Name] = true
 	}
-	isSystemComponentFn := func(name string) bool {
-		return name == control.WeaveletPath || name == control.DeployerPath
-	}
-	byPair := map[pair]int{}
-	for _, metric := range metrics {
-		if metric.Name != metrics2.MethodCountsName {
-			continue
-		}
-		caller := metric.Labels["caller"]
-		component := metric.Labels["component"]
-		if isSystemComponentFn(caller) || isSystemComponentFn(component) {
-			// Don't display traffic information for the internal system components.
-			continue
-		}
-		call := pair{
-			caller:    caller,
-			component: component,
-		}
-		byPair[call] += int(metric.Value)
+	for _, c := range topology.GetComponents() {
+		nodes[c] = true
 	}
+	names := maps.Keys(nodes)
+	sort.Strings(names)
+	return names
+}
 
-	// Massage data into graph format.
-	var edges []edge
-	for call, value := range byPair {
-		edges = append(edges, edge{
-			Source: call.caller,
-			Target: call.component,
-			Value:  value,
-		})
+// topologyEdges returns the edges of the provided component graph, as
+// displayed on the deployment page.
+func topologyEdges(topology *protos.Topology) []topologyEdge {
+	var edges []topologyEdge
+	for _, e := range topology.GetEdges() {
+		edge := topologyEdge{TopologyEdge: e}
+		if topology.WindowNanos > 0 {
+			edge.Value = e.CallsPerSecond
+			edge.Label = fmt.Sprintf("%.2f/s", e.CallsPerSecond)
+		} else {
+			edge.Value = float64(e.Calls)
+			edge.Label = fmt.Sprint(e.Calls)
+		}
+		edges = append(edges, edge)
 	}
 	return edges
 }
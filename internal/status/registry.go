@@ -26,6 +26,7 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/ServiceWeaver/weaver/internal/atrest"
 	"github.com/ServiceWeaver/weaver/internal/files"
 	"github.com/ServiceWeaver/weaver/runtime/colors"
 )
@@ -43,6 +44,10 @@ type Registry struct {
 	// TODO(mwhittaker): Store as protos instead of JSON?
 	dir string
 
+	// cipher encrypts registration files if at-rest encryption is enabled
+	// (see the atrest package).
+	cipher *atrest.Cipher
+
 	// newClient returns a new status client that curls the provided address.
 	// It is a field of Registry to enable dependency injection in
 	// registry_test.go.
@@ -111,8 +116,12 @@ func NewRegistry(_ context.Context, dir string) (*Registry, error) {
 	if err = os.MkdirAll(dir, 0750); err != nil {
 		return nil, fmt.Errorf("registry: make dir %q: %w", dir, err)
 	}
+	cipher, err := atrest.FromEnv()
+	if err != nil {
+		return nil, fmt.Errorf("registry: %w", err)
+	}
 	newClient := func(addr string) Server { return NewClient(addr) }
-	return &Registry{dir, newClient}, nil
+	return &Registry{dir, cipher, newClient}, nil
 }
 
 // Register adds a registration to the registry.
@@ -121,6 +130,10 @@ func (r *Registry) Register(ctx context.Context, reg Registration) error {
 	if err != nil {
 		return fmt.Errorf("registry: encode %v: %w", reg, err)
 	}
+	bytes, err = r.cipher.Seal(bytes)
+	if err != nil {
+		return fmt.Errorf("registry: encrypt %v: %w", reg, err)
+	}
 	filename := fmt.Sprintf("%s.registration.json", reg.DeploymentId)
 	filename = filepath.Join(r.dir, filename)
 	w := files.NewWriter(filename)
@@ -167,6 +180,33 @@ func (r *Registry) Get(ctx context.Context, deploymentId string) (Registration,
 	return Registration{}, fmt.Errorf("registry: deployment %q not found", deploymentId)
 }
 
+// lookup returns the active Registration whose deployment id has the provided
+// prefix. If the prefix is ambiguous, lookup prints the matching deployment ids
+// to stderr and returns an error.
+func lookup(ctx context.Context, r *Registry, prefix string) (Registration, error) {
+	regs, err := r.List(ctx)
+	if err != nil {
+		return Registration{}, fmt.Errorf("get registrations: %w", err)
+	}
+	var candidates []Registration
+	for _, reg := range regs {
+		if strings.HasPrefix(reg.DeploymentId, prefix) {
+			candidates = append(candidates, reg)
+		}
+	}
+	if len(candidates) == 0 {
+		return Registration{}, fmt.Errorf("no deployment with prefix %q found", prefix)
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintf(os.Stderr, "The deployment id prefix %q is ambiguous. Expand the prefix to identify one of the following deployments:\n", prefix)
+		for _, candidate := range candidates {
+			fmt.Fprintf(os.Stderr, "  - %s\n", candidate.DeploymentId)
+		}
+		return Registration{}, fmt.Errorf("multiple deployments with prefix %q found", prefix)
+	}
+	return candidates[0], nil
+}
+
 // List returns all active Registrations.
 func (r *Registry) List(ctx context.Context) ([]Registration, error) {
 	regs, err := r.list()
@@ -215,6 +255,10 @@ func (r *Registry) list() ([]Registration, error) {
 		if err != nil {
 			return nil, fmt.Errorf("registry: read file %q: %w", filename, err)
 		}
+		bytes, err = r.cipher.Open(bytes)
+		if err != nil {
+			return nil, fmt.Errorf("registry: decrypt file %q: %w", filename, err)
+		}
 		var reg Registration
 		if err := json.Unmarshal(bytes, &reg); err != nil {
 			return nil, fmt.Errorf("registry: decode file %q: %w", filename, err)
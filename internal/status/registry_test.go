@@ -17,9 +17,13 @@ package status
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"testing"
 
+	"github.com/ServiceWeaver/weaver/internal/atrest"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/testing/protocmp"
@@ -46,6 +50,16 @@ func (f fakeClient) Profile(context.Context, *protos.GetProfileRequest) (*protos
 	return nil, fmt.Errorf("unimplemented")
 }
 
+// FlightRecord implements the Server interface.
+func (f fakeClient) FlightRecord(context.Context) (*protos.GetFlightRecordReply, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
+// Topology implements the Server interface.
+func (f fakeClient) Topology(context.Context) (*protos.GetTopologyReply, error) {
+	return nil, fmt.Errorf("unimplemented")
+}
+
 func TestRegister(t *testing.T) {
 	// Create the registry.
 	ctx := context.Background()
@@ -77,6 +91,44 @@ func TestRegister(t *testing.T) {
 	}
 }
 
+func TestRegisterEncrypted(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("000102030405060708090a0b0c0d0e0f"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(atrest.KeyFileEnvVar, keyFile)
+
+	// Create the registry and register a deployment.
+	ctx := context.Background()
+	dir := t.TempDir()
+	registry, err := NewRegistry(ctx, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := Registration{"0", "todo", "localhost:0"}
+	if err := registry.Register(ctx, reg); err != nil {
+		t.Fatal(err)
+	}
+
+	// The registration file is encrypted.
+	data, err := os.ReadFile(filepath.Join(dir, "0.registration.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "todo") {
+		t.Fatal("registration file contains plaintext")
+	}
+
+	// List the deployments.
+	got, err := registry.list()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]Registration{reg}, got); diff != "" {
+		t.Fatalf("List (-want +got):\n%s", diff)
+	}
+}
+
 func TestUnregister(t *testing.T) {
 	// Create the registry.
 	ctx := context.Background()
@@ -31,6 +31,8 @@ const (
 	metricsEndpoint    = "/debug/serviceweaver/metrics"
 	prometheusEndpoint = "/debug/serviceweaver/prometheus"
 	profileEndpoint    = "/debug/serviceweaver/profile"
+	flightEndpoint     = "/debug/serviceweaver/flightrecord"
+	topologyEndpoint   = "/debug/serviceweaver/topology"
 )
 
 // A Server returns information about a Service Weaver deployment.
@@ -43,6 +45,14 @@ type Server interface {
 
 	// Profile returns a profile of the deployment.
 	Profile(context.Context, *protos.GetProfileRequest) (*protos.GetProfileReply, error)
+
+	// FlightRecord returns the component method calls recently recorded by
+	// the flight recorders of the deployment's weavelets.
+	FlightRecord(context.Context) (*protos.GetFlightRecordReply, error)
+
+	// Topology returns the live component graph of the deployment, with
+	// call rates.
+	Topology(context.Context) (*protos.GetTopologyReply, error)
 }
 
 // RegisterServer registers a Server's methods with the provided mux under the
@@ -51,6 +61,8 @@ func RegisterServer(mux *http.ServeMux, server Server, logger *slog.Logger) {
 	mux.Handle(statusEndpoint, protomsg.HandlerThunk(logger, server.Status))
 	mux.Handle(metricsEndpoint, protomsg.HandlerThunk(logger, server.Metrics))
 	mux.Handle(profileEndpoint, protomsg.HandlerFunc(logger, server.Profile))
+	mux.Handle(flightEndpoint, protomsg.HandlerThunk(logger, server.FlightRecord))
+	mux.Handle(topologyEndpoint, protomsg.HandlerThunk(logger, server.Topology))
 	mux.HandleFunc(prometheusEndpoint, func(w http.ResponseWriter, r *http.Request) {
 		ms, err := server.Metrics(r.Context())
 		if err != nil {
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -80,6 +81,7 @@ func format(statuses []*Status) string {
 	formatDeployments(&b, statuses)
 	formatComponents(&b, statuses)
 	formatListeners(&b, statuses)
+	formatLimitEvents(&b, statuses)
 	return b.String()
 }
 
@@ -111,7 +113,16 @@ func formatComponents(w io.Writer, statuses []*Status) {
 	title := []colors.Text{{{S: "COMPONENTS", Bold: true}}}
 	t := colors.NewTabularizer(w, title, colors.PrefixDim)
 	defer t.Flush()
-	t.Row("APP", "DEPLOYMENT", "COMPONENT", "REPLICA PIDS", "WEAVELET IDS")
+	// Show the locations of the replicas only if the deployer places replicas
+	// on locations (e.g., the SSH deployer).
+	showLocations := slices.ContainsFunc(statuses, func(status *Status) bool {
+		return located(status.Components)
+	})
+	if showLocations {
+		t.Row("APP", "DEPLOYMENT", "COMPONENT", "REPLICA PIDS", "WEAVELET IDS", "LOCATIONS", "READY")
+	} else {
+		t.Row("APP", "DEPLOYMENT", "COMPONENT", "REPLICA PIDS", "WEAVELET IDS", "READY")
+	}
 	for _, status := range statuses {
 		sort.Slice(status.Components, func(i, j int) bool {
 			return status.Components[i].Name < status.Components[j].Name
@@ -124,15 +135,34 @@ func formatComponents(w io.Writer, statuses []*Status) {
 			})
 			pids := make([]string, len(component.Replicas))
 			weaveletIds := make([]string, len(component.Replicas))
+			locations := make([]string, len(component.Replicas))
+			ready := 0
 			for i, replica := range component.Replicas {
 				pids[i] = fmt.Sprint(replica.Pid)
 				weaveletIds[i] = replica.WeaveletId[0:8]
+				locations[i] = replica.Location
+				if replica.Ready {
+					ready++
+				}
+			}
+			readiness := fmt.Sprintf("%d/%d", ready, len(component.Replicas))
+			if showLocations {
+				t.Row(status.App, prefix, c, strings.Join(pids, ", "), strings.Join(weaveletIds, ", "), strings.Join(locations, ", "), readiness)
+			} else {
+				t.Row(status.App, prefix, c, strings.Join(pids, ", "), strings.Join(weaveletIds, ", "), readiness)
 			}
-			t.Row(status.App, prefix, c, strings.Join(pids, ", "), strings.Join(weaveletIds, ", "))
 		}
 	}
 }
 
+// located returns whether any replica of the provided components runs on a
+// known location.
+func located(components []*Component) bool {
+	return slices.ContainsFunc(components, func(c *Component) bool {
+		return slices.ContainsFunc(c.Replicas, func(r *Replica) bool { return r.Location != "" })
+	})
+}
+
 // formatDeployments pretty-prints the set of listeners.
 func formatListeners(w io.Writer, statuses []*Status) {
 	title := []colors.Text{{{S: "LISTENERS", Bold: true}}}
@@ -149,3 +179,23 @@ func formatListeners(w io.Writer, statuses []*Status) {
 		}
 	}
 }
+
+// formatLimitEvents pretty-prints the replicas that were killed for exceeding
+// their resource limits, if any.
+func formatLimitEvents(w io.Writer, statuses []*Status) {
+	if !slices.ContainsFunc(statuses, func(s *Status) bool { return len(s.LimitEvents) > 0 }) {
+		return
+	}
+	title := []colors.Text{{{S: "RESOURCE LIMIT EVENTS", Bold: true}}}
+	t := colors.NewTabularizer(w, title, colors.PrefixDim)
+	defer t.Flush()
+	t.Row("APP", "DEPLOYMENT", "GROUP", "REPLICA", "WEAVELET ID", "AGE", "REASON")
+	for _, status := range statuses {
+		for _, event := range status.LimitEvents {
+			prefix, _ := formatId(status.DeploymentId)
+			g := logging.ShortenComponent(event.Group)
+			age := time.Since(event.Time.AsTime()).Truncate(time.Second)
+			t.Row(status.App, prefix, g, event.Replica, event.WeaveletId[0:8], age, event.Reason)
+		}
+	}
+}
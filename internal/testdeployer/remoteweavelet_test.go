@@ -308,6 +308,11 @@ func (d *deployer) HandleTraceSpans(context.Context, *protos.TraceSpans) error {
 	return nil
 }
 
+// GetTopology implements the EnvelopeHandler interface.
+func (d *deployer) GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	return &protos.GetTopologyReply{Topology: &protos.Topology{}}, nil
+}
+
 // GetSelfCertificate implements the EnvelopeHandler interface.
 func (d *deployer) GetSelfCertificate(context.Context, *protos.GetSelfCertificateRequest) (*protos.GetSelfCertificateReply, error) {
 	d.t.Fatal("unimplemented")
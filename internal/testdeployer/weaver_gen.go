@@ -20,7 +20,7 @@ func init() {
 		Impl:      reflect.TypeOf(aimpl{}),
 		Listeners: []string{"lis"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return a_local_stub{impl: impl.(a), tracer: tracer, aMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/a", Method: "A", Remote: false, Generated: true})}
+			return a_local_stub{impl: impl.(a), tracer: tracer, caller: codegen.Caller{Component: caller}, aMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/a", Method: "A", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return a_client_stub{stub: stub, aMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/a", Method: "A", Remote: true, Generated: true})}
@@ -31,14 +31,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return a_reflect_stub{caller: caller}
 		},
-		RefData: "⟦d473cf51:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/testdeployer/a→github.com/ServiceWeaver/weaver/internal/testdeployer/b⟧\n⟦83f71f4e:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/testdeployer/a→lis⟧\n",
+		RefData:          "⟦d473cf51:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/testdeployer/a→github.com/ServiceWeaver/weaver/internal/testdeployer/b⟧\n⟦83f71f4e:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/testdeployer/a→lis⟧\n⟦26838ccd:wEaVeRcAlL:github.com/ServiceWeaver/weaver/internal/testdeployer/a.A→github.com/ServiceWeaver/weaver/internal/testdeployer/b.B@components.go:68:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "a85d558e5d60534a",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/testdeployer/b",
 		Iface: reflect.TypeOf((*b)(nil)).Elem(),
 		Impl:  reflect.TypeOf(bimpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return b_local_stub{impl: impl.(b), tracer: tracer, bMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/b", Method: "B", Remote: false, Generated: true})}
+			return b_local_stub{impl: impl.(b), tracer: tracer, caller: codegen.Caller{Component: caller}, bMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/b", Method: "B", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return b_client_stub{stub: stub, bMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/b", Method: "B", Remote: true, Generated: true})}
@@ -49,14 +52,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return b_reflect_stub{caller: caller}
 		},
-		RefData: "⟦54fc5958:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/testdeployer/b→github.com/ServiceWeaver/weaver/internal/testdeployer/c⟧\n",
+		RefData:          "⟦54fc5958:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/testdeployer/b→github.com/ServiceWeaver/weaver/internal/testdeployer/c⟧\n⟦97d50f24:wEaVeRcAlL:github.com/ServiceWeaver/weaver/internal/testdeployer/b.B→github.com/ServiceWeaver/weaver/internal/testdeployer/c.C@components.go:73:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "7741132fa69d4140",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/testdeployer/c",
 		Iface: reflect.TypeOf((*c)(nil)).Elem(),
 		Impl:  reflect.TypeOf(cimpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return c_local_stub{impl: impl.(c), tracer: tracer, cMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/c", Method: "C", Remote: false, Generated: true})}
+			return c_local_stub{impl: impl.(c), tracer: tracer, caller: codegen.Caller{Component: caller}, cMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/c", Method: "C", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return c_client_stub{stub: stub, cMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/c", Method: "C", Remote: true, Generated: true})}
@@ -67,14 +73,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return c_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "15b01df98e62f8e2",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/internal/testdeployer/d",
 		Iface: reflect.TypeOf((*d)(nil)).Elem(),
 		Impl:  reflect.TypeOf(dimpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return d_local_stub{impl: impl.(d), tracer: tracer, dMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/d", Method: "D", Remote: false, Generated: true})}
+			return d_local_stub{impl: impl.(d), tracer: tracer, caller: codegen.Caller{Component: caller}, dMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/d", Method: "D", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return d_client_stub{stub: stub, dMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/testdeployer/d", Method: "D", Remote: true, Generated: true})}
@@ -85,7 +94,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return d_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "c3df639705079723",
 	})
 }
 
@@ -106,6 +118,7 @@ var _ weaver.Unrouted = (*dimpl)(nil)
 type a_local_stub struct {
 	impl     a
 	tracer   trace.Tracer
+	caller   codegen.Caller
 	aMetrics *codegen.MethodMetrics
 }
 
@@ -116,6 +129,10 @@ func (s a_local_stub) A(ctx context.Context, a0 int) (r0 int, err error) {
 	// Update metrics.
 	begin := s.aMetrics.Begin()
 	defer func() { s.aMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -135,6 +152,7 @@ func (s a_local_stub) A(ctx context.Context, a0 int) (r0 int, err error) {
 type b_local_stub struct {
 	impl     b
 	tracer   trace.Tracer
+	caller   codegen.Caller
 	bMetrics *codegen.MethodMetrics
 }
 
@@ -145,6 +163,10 @@ func (s b_local_stub) B(ctx context.Context, a0 int) (r0 int, err error) {
 	// Update metrics.
 	begin := s.bMetrics.Begin()
 	defer func() { s.bMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -164,6 +186,7 @@ func (s b_local_stub) B(ctx context.Context, a0 int) (r0 int, err error) {
 type c_local_stub struct {
 	impl     c
 	tracer   trace.Tracer
+	caller   codegen.Caller
 	cMetrics *codegen.MethodMetrics
 }
 
@@ -174,6 +197,10 @@ func (s c_local_stub) C(ctx context.Context, a0 int) (r0 int, err error) {
 	// Update metrics.
 	begin := s.cMetrics.Begin()
 	defer func() { s.cMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -193,6 +220,7 @@ func (s c_local_stub) C(ctx context.Context, a0 int) (r0 int, err error) {
 type d_local_stub struct {
 	impl     d
 	tracer   trace.Tracer
+	caller   codegen.Caller
 	dMetrics *codegen.MethodMetrics
 }
 
@@ -203,6 +231,10 @@ func (s d_local_stub) D(ctx context.Context) (r0 string, err error) {
 	// Update metrics.
 	begin := s.dMetrics.Begin()
 	defer func() { s.dMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -261,7 +293,7 @@ func (s a_client_stub) A(ctx context.Context, a0 int) (r0 int, err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -272,6 +304,7 @@ func (s a_client_stub) A(ctx context.Context, a0 int) (r0 int, err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -325,7 +358,7 @@ func (s b_client_stub) B(ctx context.Context, a0 int) (r0 int, err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -336,6 +369,7 @@ func (s b_client_stub) B(ctx context.Context, a0 int) (r0 int, err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -389,7 +423,7 @@ func (s c_client_stub) C(ctx context.Context, a0 int) (r0 int, err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -400,6 +434,7 @@ func (s c_client_stub) C(ctx context.Context, a0 int) (r0 int, err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -471,10 +506,10 @@ func (s d_client_stub) D(ctx context.Context) (r0 string, err error) {
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -0,0 +1,212 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package analyze implements the "weaver analyze" subcommands, which analyze
+// the behavior of deployed Service Weaver applications.
+package analyze
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+)
+
+var (
+	colocateFlags        = flag.NewFlagSet("colocate", flag.ContinueOnError)
+	colocateCallOverhead = colocateFlags.Int("call_overhead", 512, "Network cost of a remote call, in bytes, in addition to its payload")
+	colocateMaxGroupSize = colocateFlags.Int("max_group_size", 0, "Maximum number of components in a colocation group; 0 means unlimited")
+	colocateMinShare     = colocateFlags.Float64("min_share", 0.05, "Minimum share of the total network cost that colocating two groups must save")
+
+	payloadsFlags   = flag.NewFlagSet("payloads", flag.ContinueOnError)
+	payloadsMaxMean = payloadsFlags.Int("max_mean", int(payloads.DefaultThresholds.Mean), "Flag methods whose mean argument or result size exceeds this many bytes; 0 disables the check")
+	payloadsMaxP99  = payloadsFlags.Int("max_p99", int(payloads.DefaultThresholds.P99), "Flag methods whose 99th percentile argument or result size exceeds this many bytes; 0 disables the check")
+
+	// Commands holds the "weaver analyze" subcommands.
+	Commands = map[string]*tool.Command{
+		"colocate": colocateCommand(),
+		"payloads": payloadsCommand(),
+	}
+)
+
+func colocateCommand() *tool.Command {
+	const help = `Usage:
+  weaver analyze colocate [options] <metrics>...
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  'weaver analyze colocate' recommends how to colocate the components of an
+  application to minimize the network cost of the calls between them. It
+  prints the recommendation as a TOML snippet that can be pasted into the
+  application's config file.
+
+  Every <metrics> argument is a file or an http(s) URL holding the metrics of
+  one or more processes of the application in the Prometheus text format,
+  like the metrics served by the "/debug/serviceweaver/prometheus" endpoint
+  of a deployment's status server. The recommendation is based on the number
+  of calls between every pair of components and the average size of their
+  requests and replies, which is measured on remote calls only.
+
+  Starting with every component in its own process, the command repeatedly
+  colocates the two groups of components with the highest network cost
+  between them, until no merge saves at least --min_share of the total cost or
+  every merge would exceed --max_group_size components.
+
+Examples:
+  # Recommend a colocation using the metrics of a multiprocess deployment.
+  weaver analyze colocate http://127.0.0.1:43087/debug/serviceweaver/prometheus
+
+  # Recommend groups of at most three components using saved metrics.
+  weaver analyze colocate --max_group_size=3 metrics1.txt metrics2.txt`
+	var b strings.Builder
+	t := template.Must(template.New("colocate").Parse(help))
+	content := struct{ Flags string }{tool.FlagsHelp(colocateFlags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "colocate",
+		Description: "Recommend a colocation of components based on their traffic",
+		Help:        b.String(),
+		Flags:       colocateFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("usage: weaver analyze colocate [options] <metrics>...")
+			}
+			if *colocateMinShare < 0 || *colocateMinShare > 1 {
+				return fmt.Errorf("invalid --min_share %v; want a value between 0 and 1", *colocateMinShare)
+			}
+			t := newTraffic()
+			for _, arg := range args {
+				if err := readSource(ctx, arg, t.readMetrics); err != nil {
+					return fmt.Errorf("read metrics from %q: %w", arg, err)
+				}
+			}
+			if len(t.edges) == 0 {
+				return fmt.Errorf("no calls between components found in the provided metrics")
+			}
+			opts := colocateOptions{
+				callOverhead: float64(*colocateCallOverhead),
+				maxGroupSize: *colocateMaxGroupSize,
+				minShare:     *colocateMinShare,
+			}
+			writeColocation(os.Stdout, t, recommendColocation(t, opts), opts)
+			return nil
+		},
+	}
+}
+
+func payloadsCommand() *tool.Command {
+	const help = `Usage:
+  weaver analyze payloads [options] <metrics>...
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  'weaver analyze payloads' reports the distribution of the sizes of the
+  serialized arguments and results of every component method, and flags the
+  methods whose mean or 99th percentile sizes exceed --max_mean or --max_p99
+  bytes, suggesting pagination or streaming for them.
+
+  Every <metrics> argument is a file or an http(s) URL holding the metrics of
+  one or more processes of the application in the Prometheus text format,
+  like the metrics served by the "/debug/serviceweaver/prometheus" endpoint
+  of a deployment's status server. Sizes are only recorded for remote calls,
+  so methods that are only called locally are not reported. Percentiles are
+  estimated from histograms with buckets that grow by 2x to 2.5x.
+
+Examples:
+  # Report the payload sizes of a multiprocess deployment.
+  weaver analyze payloads http://127.0.0.1:43087/debug/serviceweaver/prometheus
+
+  # Flag methods with payloads above 64 KiB at the 99th percentile.
+  weaver analyze payloads --max_p99=65536 metrics.txt`
+	var b strings.Builder
+	t := template.Must(template.New("payloads").Parse(help))
+	content := struct{ Flags string }{tool.FlagsHelp(payloadsFlags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "payloads",
+		Description: "Report the sizes of method arguments and results",
+		Help:        b.String(),
+		Flags:       payloadsFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("usage: weaver analyze payloads [options] <metrics>...")
+			}
+			var snapshots []*metrics.MetricSnapshot
+			for _, arg := range args {
+				if err := readSource(ctx, arg, readHistograms(&snapshots)); err != nil {
+					return fmt.Errorf("read metrics from %q: %w", arg, err)
+				}
+			}
+			methods, err := payloads.Sizes(snapshots)
+			if err != nil {
+				return err
+			}
+			if len(methods) == 0 {
+				return fmt.Errorf("no remote calls found in the provided metrics")
+			}
+			thresholds := payloads.Thresholds{
+				Mean: float64(*payloadsMaxMean),
+				P99:  float64(*payloadsMaxP99),
+			}
+			writePayloads(os.Stdout, methods, thresholds)
+			return nil
+		},
+	}
+}
+
+// readSource passes the contents of the provided file or http(s) URL to read.
+func readSource(ctx context.Context, source string, read func(io.Reader) error) error {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		f, err := os.Open(source)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return read(f)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return read(resp.Body)
+}
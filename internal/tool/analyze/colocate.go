@@ -0,0 +1,253 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+)
+
+// An edge holds the traffic sent by one component to another.
+type edge struct {
+	caller      string  // calling component
+	callee      string  // called component
+	calls       float64 // number of method calls
+	remoteCalls float64 // number of remote method calls
+	bytes       float64 // bytes in the requests and replies of remote calls
+}
+
+// payload returns the average number of bytes in the request and reply of a
+// call, estimated from the remote calls. It returns 0 if the caller never
+// called the callee remotely.
+func (e *edge) payload() float64 {
+	if e.remoteCalls == 0 {
+		return 0
+	}
+	return e.bytes / e.remoteCalls
+}
+
+// traffic holds the traffic between every pair of components.
+type traffic struct {
+	edges map[[2]string]*edge // keyed by [caller, callee]
+}
+
+func newTraffic() *traffic {
+	return &traffic{edges: map[[2]string]*edge{}}
+}
+
+// edge returns the edge from caller to callee, creating it if needed.
+func (t *traffic) edge(caller, callee string) *edge {
+	key := [2]string{caller, callee}
+	e, ok := t.edges[key]
+	if !ok {
+		e = &edge{caller: caller, callee: callee}
+		t.edges[key] = e
+	}
+	return e
+}
+
+// components returns the sorted names of the components in t.
+func (t *traffic) components() []string {
+	set := map[string]bool{}
+	for _, e := range t.edges {
+		set[e.caller] = true
+		set[e.callee] = true
+	}
+	var names []string
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readMetrics reads the Service Weaver method metrics, in the Prometheus text
+// format, from r into t. Metrics exported by different processes, or at
+// different times, may be read into the same traffic.
+func (t *traffic) readMetrics(r io.Reader) error {
+	samples, err := imetrics.ParseSamples(r)
+	if err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		caller, callee := sample.Labels["caller"], sample.Labels["component"]
+		if caller == "" || callee == "" || caller == callee || imetrics.IsSystem(caller) || imetrics.IsSystem(callee) {
+			continue
+		}
+		switch sample.Name {
+		case imetrics.MethodCountsName:
+			t.edge(caller, callee).calls += sample.Value
+		case imetrics.MethodBytesRequestName + "_count":
+			t.edge(caller, callee).remoteCalls += sample.Value
+		case imetrics.MethodBytesRequestName + "_sum", imetrics.MethodBytesReplyName + "_sum":
+			t.edge(caller, callee).bytes += sample.Value
+		}
+	}
+	return nil
+}
+
+// colocateOptions configures recommendColocation.
+type colocateOptions struct {
+	callOverhead float64 // network cost of a remote call, in bytes, in addition to its payload
+	maxGroupSize int     // maximum number of components in a group; 0 means unlimited
+	minShare     float64 // minimum share of the total cost a merge must save
+}
+
+// cost returns the estimated network cost, in bytes, of the calls on e if they
+// were remote.
+func (e *edge) cost(opts colocateOptions) float64 {
+	return e.calls * (opts.callOverhead + e.payload())
+}
+
+// recommendColocation returns a colocation grouping of the components in t
+// that reduces the network cost of the calls between them. Every component
+// appears in exactly one group.
+//
+// Starting with every component in its own group, it repeatedly merges the
+// two groups with the highest cost of the calls between them, as long as the
+// merged group isn't too big and the merge saves at least opts.minShare of the
+// total cost.
+func recommendColocation(t *traffic, opts colocateOptions) [][]string {
+	names := t.components()
+	groupOf := map[string]int{}
+	groups := make([][]string, len(names))
+	for i, name := range names {
+		groupOf[name] = i
+		groups[i] = []string{name}
+	}
+
+	total := 0.0
+	for _, e := range t.edges {
+		total += e.cost(opts)
+	}
+
+	for {
+		// Compute the cost of the calls between every pair of groups.
+		between := map[[2]int]float64{}
+		for _, e := range t.edges {
+			i, j := groupOf[e.caller], groupOf[e.callee]
+			if i == j {
+				continue
+			}
+			between[[2]int{min(i, j), max(i, j)}] += e.cost(opts)
+		}
+
+		// Pick the most expensive pair that can be merged. Ties are broken by
+		// group index, for determinism.
+		best, bestCost := [2]int{-1, -1}, 0.0
+		for pair, cost := range between {
+			if opts.maxGroupSize > 0 && len(groups[pair[0]])+len(groups[pair[1]]) > opts.maxGroupSize {
+				continue
+			}
+			if cost < opts.minShare*total {
+				continue
+			}
+			if cost > bestCost || (cost == bestCost && (pair[0] < best[0] || (pair[0] == best[0] && pair[1] < best[1]))) {
+				best, bestCost = pair, cost
+			}
+		}
+		if best[0] < 0 {
+			break
+		}
+
+		// Merge the second group into the first.
+		for _, name := range groups[best[1]] {
+			groupOf[name] = best[0]
+		}
+		groups[best[0]] = append(groups[best[0]], groups[best[1]]...)
+		groups[best[1]] = nil
+	}
+
+	var result [][]string
+	for _, group := range groups {
+		if len(group) > 0 {
+			sort.Strings(group)
+			result = append(result, group)
+		}
+	}
+	return result
+}
+
+// remoteCost returns the estimated network cost, in bytes, of the calls in t
+// between components in different groups.
+func remoteCost(t *traffic, groups [][]string, opts colocateOptions) float64 {
+	groupOf := map[string]int{}
+	for i, group := range groups {
+		for _, name := range group {
+			groupOf[name] = i
+		}
+	}
+	cost := 0.0
+	for _, e := range t.edges {
+		if groupOf[e.caller] != groupOf[e.callee] {
+			cost += e.cost(opts)
+		}
+	}
+	return cost
+}
+
+// writeColocation writes the provided grouping to w as a TOML snippet that can
+// be pasted into a config file. Singleton groups are omitted.
+func writeColocation(w io.Writer, t *traffic, groups [][]string, opts colocateOptions) {
+	var singletons [][]string
+	for _, name := range t.components() {
+		singletons = append(singletons, []string{name})
+	}
+	before := remoteCost(t, singletons, opts)
+	after := remoteCost(t, groups, opts)
+	fmt.Fprintf(w, "# Estimated network cost with every component in its own process: %s.\n", formatBytes(before))
+	if before > 0 {
+		fmt.Fprintf(w, "# Estimated network cost with the colocation below: %s (%.1f%% less).\n", formatBytes(after), 100*(before-after)/before)
+	}
+
+	var colocated [][]string
+	for _, group := range groups {
+		if len(group) > 1 {
+			colocated = append(colocated, group)
+		}
+	}
+	if len(colocated) == 0 {
+		fmt.Fprintln(w, "# No colocation recommended.")
+		return
+	}
+	fmt.Fprintln(w, "[serviceweaver]")
+	fmt.Fprintln(w, "colocate = [")
+	for _, group := range colocated {
+		quoted := slices.Clone(group)
+		for i, name := range quoted {
+			quoted[i] = strconv.Quote(name)
+		}
+		fmt.Fprintf(w, "  [%s],\n", strings.Join(quoted, ", "))
+	}
+	fmt.Fprintln(w, "]")
+}
+
+// formatBytes formats n bytes in human readable form (e.g., "1.5 MB").
+func formatBytes(n float64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%.0f B", n)
+	}
+	exp := int(math.Log(n) / math.Log(unit))
+	exp = min(exp, 6)
+	return fmt.Sprintf("%.1f %cB", n/math.Pow(unit, float64(exp)), "kMGTPE"[exp-1])
+}
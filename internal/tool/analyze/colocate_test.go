@@ -0,0 +1,156 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/control"
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/prometheus"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+// call returns the metrics recorded for n calls from caller to callee. If
+// payload is positive, the calls are remote and every request and reply
+// holds payload bytes.
+func call(caller, callee string, n, payload float64) []*metrics.MetricSnapshot {
+	labels := map[string]string{
+		"caller":    caller,
+		"component": callee,
+		"method":    "M",
+		"remote":    "false",
+	}
+	ms := []*metrics.MetricSnapshot{
+		{Name: imetrics.MethodCountsName, Type: protos.MetricType_COUNTER, Labels: labels, Value: n},
+	}
+	if payload > 0 {
+		labels["remote"] = "true"
+		for _, name := range []string{imetrics.MethodBytesRequestName, imetrics.MethodBytesReplyName} {
+			ms = append(ms, &metrics.MetricSnapshot{
+				Name:   name,
+				Type:   protos.MetricType_HISTOGRAM,
+				Labels: labels,
+				Value:  n * payload,
+				Bounds: []float64{10000},
+				Counts: []uint64{uint64(n), 0},
+			})
+		}
+	}
+	return ms
+}
+
+// testTraffic returns the traffic of an application in which A calls B a lot
+// with big payloads, B calls C a fair amount, and A and C call D rarely.
+func testTraffic(t *testing.T) *traffic {
+	t.Helper()
+	var ms []*metrics.MetricSnapshot
+	ms = append(ms, call("A", "B", 1000, 1000)...)
+	ms = append(ms, call("B", "C", 500, 250)...)
+	ms = append(ms, call("A", "D", 10, 50)...)
+	ms = append(ms, call("C", "D", 5, 0)...)
+	ms = append(ms, call(control.DeployerPath, "A", 100, 100)...)
+	var b bytes.Buffer
+	prometheus.TranslateMetricsToPrometheusTextFormat(&b, ms, "localhost:0", "/metrics")
+
+	traffic := newTraffic()
+	if err := traffic.readMetrics(&b); err != nil {
+		t.Fatal(err)
+	}
+	return traffic
+}
+
+func TestReadMetrics(t *testing.T) {
+	traffic := testTraffic(t)
+	for _, test := range []struct {
+		caller, callee string
+		calls, payload float64
+	}{
+		{"A", "B", 1000, 2000},
+		{"B", "C", 500, 500},
+		{"A", "D", 10, 100},
+		{"C", "D", 5, 0},
+	} {
+		e, ok := traffic.edges[[2]string{test.caller, test.callee}]
+		if !ok {
+			t.Errorf("%s -> %s: edge not found", test.caller, test.callee)
+			continue
+		}
+		if e.calls != test.calls || e.payload() != test.payload {
+			t.Errorf("%s -> %s: got %v calls of %v bytes, want %v calls of %v bytes", test.caller, test.callee, e.calls, e.payload(), test.calls, test.payload)
+		}
+	}
+	if got, want := len(traffic.edges), 4; got != want {
+		t.Errorf("got %d edges, want %d", got, want)
+	}
+}
+
+func TestRecommendColocation(t *testing.T) {
+	traffic := testTraffic(t)
+	for _, test := range []struct {
+		name string
+		opts colocateOptions
+		want [][]string
+	}{
+		{
+			name: "Default",
+			opts: colocateOptions{callOverhead: 512, minShare: 0.05},
+			want: [][]string{{"A", "B", "C"}, {"D"}},
+		},
+		{
+			name: "MaxGroupSize",
+			opts: colocateOptions{callOverhead: 512, maxGroupSize: 2, minShare: 0.05},
+			want: [][]string{{"A", "B"}, {"C"}, {"D"}},
+		},
+		{
+			name: "NoMinShare",
+			opts: colocateOptions{callOverhead: 512},
+			want: [][]string{{"A", "B", "C", "D"}},
+		},
+		{
+			name: "HighMinShare",
+			opts: colocateOptions{callOverhead: 512, minShare: 0.9},
+			want: [][]string{{"A"}, {"B"}, {"C"}, {"D"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := recommendColocation(traffic, test.opts)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("recommendColocation (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWriteColocation(t *testing.T) {
+	traffic := testTraffic(t)
+	opts := colocateOptions{callOverhead: 512, minShare: 0.05}
+	var b strings.Builder
+	writeColocation(&b, traffic, recommendColocation(traffic, opts), opts)
+	const want = `# Estimated network cost with every component in its own process: 3.0 MB.
+# Estimated network cost with the colocation below: 8.7 kB (99.7% less).
+[serviceweaver]
+colocate = [
+  ["A", "B", "C"],
+]
+`
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Fatalf("writeColocation (-want +got):\n%s", diff)
+	}
+}
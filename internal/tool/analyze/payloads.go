@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"fmt"
+	"io"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/colors"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
+)
+
+// readHistograms returns a function that reads the payload size histograms in
+// metrics in the Prometheus text format and appends them to snapshots.
+func readHistograms(snapshots *[]*metrics.MetricSnapshot) func(io.Reader) error {
+	return func(r io.Reader) error {
+		samples, err := imetrics.ParseSamples(r)
+		if err != nil {
+			return err
+		}
+		for _, name := range []string{imetrics.MethodBytesRequestName, imetrics.MethodBytesReplyName} {
+			hs, err := imetrics.Histograms(samples, name)
+			if err != nil {
+				return err
+			}
+			*snapshots = append(*snapshots, hs...)
+		}
+		return nil
+	}
+}
+
+// writePayloads pretty-prints the payload sizes of the provided methods,
+// followed by the methods whose payloads exceed the provided thresholds.
+func writePayloads(w io.Writer, methods []payloads.Method, t payloads.Thresholds) {
+	size := payloads.FormatSize
+	table := colors.NewTabularizer(w, []colors.Text{{{S: "PAYLOAD SIZES", Bold: true}}}, colors.NoDim)
+	table.Row("COMPONENT", "METHOD", "CALLS", "ARGS MEAN", "ARGS P99", "ARGS MAX", "RESULTS MEAN", "RESULTS P99", "RESULTS MAX")
+	for _, m := range methods {
+		table.Row(m.Component, m.Method, fmt.Sprint(m.Calls),
+			size(m.Args.Mean), size(m.Args.P99), size(m.Args.Max),
+			size(m.Results.Mean), size(m.Results.P99), size(m.Results.Max))
+	}
+	table.Flush()
+
+	findings := payloads.Check(methods, t)
+	if len(findings) == 0 {
+		fmt.Fprintln(w, "No method has payloads above the thresholds.")
+		return
+	}
+	fmt.Fprintln(w)
+	for _, f := range findings {
+		what := "arguments"
+		if f.Results {
+			what = "results"
+		}
+		fmt.Fprintf(w, "%s.%s: %s %s.\n  Suggestion: %s.\n", f.Method.Component, f.Method.Method, what, f.Reason, f.Suggestion)
+	}
+}
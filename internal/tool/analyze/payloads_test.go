@@ -0,0 +1,92 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package analyze
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
+	"github.com/ServiceWeaver/weaver/runtime/prometheus"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+func TestPayloads(t *testing.T) {
+	// List is called 10 times with small arguments and big results. Get is
+	// called 100 times with small arguments and results.
+	histogram := func(name, method string, sum float64, counts ...uint64) *metrics.MetricSnapshot {
+		return &metrics.MetricSnapshot{
+			Name: name,
+			Type: protos.MetricType_HISTOGRAM,
+			Labels: map[string]string{
+				"caller":    "main",
+				"component": "example.com/app/Store",
+				"method":    method,
+				"remote":    "true",
+			},
+			Value:  sum,
+			Bounds: imetrics.GeneratedBuckets,
+			Counts: counts,
+		}
+	}
+	buckets := func(i int, n uint64) []uint64 {
+		counts := make([]uint64, len(imetrics.GeneratedBuckets)+1)
+		counts[i] = n
+		return counts
+	}
+	ms := []*metrics.MetricSnapshot{
+		histogram(imetrics.MethodBytesRequestName, "List", 10*20, buckets(4, 10)...),       // 20 B
+		histogram(imetrics.MethodBytesReplyName, "List", 10*4_000_000, buckets(20, 10)...), // 4 MB
+		histogram(imetrics.MethodBytesRequestName, "Get", 100*20, buckets(4, 100)...),
+		histogram(imetrics.MethodBytesReplyName, "Get", 100*20, buckets(4, 100)...),
+	}
+	var text bytes.Buffer
+	prometheus.TranslateMetricsToPrometheusTextFormat(&text, ms, "localhost", "/metrics")
+
+	var snapshots []*metrics.MetricSnapshot
+	if err := readHistograms(&snapshots)(&text); err != nil {
+		t.Fatal(err)
+	}
+	methods, err := payloads.Sizes(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(methods) != 2 || methods[0].Method != "Get" || methods[1].Method != "List" {
+		t.Fatalf("unexpected methods %v", methods)
+	}
+	if got, want := methods[1].Results.Mean, 4_000_000.0; got != want {
+		t.Errorf("List results mean: got %v, want %v", got, want)
+	}
+
+	var b strings.Builder
+	writePayloads(&b, methods, payloads.DefaultThresholds)
+	out := b.String()
+	for _, want := range []string{
+		"example.com/app/Store",
+		"3.8 MiB", // mean List results
+		"example.com/app/Store.List: results p99 of 4.7 MiB exceeds 1.0 MiB.",
+		"pagination",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Store.Get:") || strings.Contains(out, "List: arguments") {
+		t.Errorf("unexpected findings:\n%s", out)
+	}
+}
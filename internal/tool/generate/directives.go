@@ -0,0 +1,182 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"errors"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"golang.org/x/tools/go/packages"
+)
+
+// directivePrefix is the prefix of a comment directive on a component
+// interface method. See codegen.Directive.
+const directivePrefix = "//weaver:"
+
+// findDirectives records the comment directives on the methods of the
+// component interfaces declared in the provided file. For example,
+// findDirectives records a timeout directive on method Get below.
+//
+//	type Cache interface {
+//	    //weaver:timeout 2s
+//	    Get(context.Context, string) (string, error)
+//	}
+func findDirectives(pkg *packages.Package, f *ast.File, components map[string]*component) error {
+	byIntf := map[types.Object]*component{}
+	for _, c := range components {
+		byIntf[c.intf.Obj()] = c
+	}
+
+	var errs []error
+	for _, decl := range f.Decls {
+		gendecl, ok := decl.(*ast.GenDecl)
+		if !ok || gendecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gendecl.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			comp, ok := byIntf[pkg.TypesInfo.Defs[ts.Name]]
+			if !ok {
+				continue
+			}
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+			for _, field := range it.Methods.List {
+				if len(field.Names) != 1 || field.Doc == nil {
+					// Embedded interfaces don't have directives.
+					continue
+				}
+				m, ok := pkg.TypesInfo.Defs[field.Names[0]].(*types.Func)
+				if !ok {
+					continue
+				}
+				for _, c := range field.Doc.List {
+					if !strings.HasPrefix(c.Text, directivePrefix) {
+						continue
+					}
+					name, args, _ := strings.Cut(strings.TrimPrefix(c.Text, directivePrefix), " ")
+					d := codegen.Directive{Name: name, Args: strings.TrimSpace(args)}
+					if err := validateDirective(m, d, comp.directives[m.Name()]); err != nil {
+						errs = append(errs, errorf(pkg.Fset, c.Pos(), "invalid directive %q on method %s.%s: %w", c.Text, comp.intfName(), m.Name(), err))
+						continue
+					}
+					if comp.directives == nil {
+						comp.directives = map[string][]codegen.Directive{}
+					}
+					comp.directives[m.Name()] = append(comp.directives[m.Name()], d)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateDirective returns an error if the provided directive on the
+// provided method is invalid, given the method's other directives.
+func validateDirective(m *types.Func, d codegen.Directive, others []codegen.Directive) error {
+	for _, other := range others {
+		if other.Name == d.Name {
+			return errors.New("duplicate directive")
+		}
+	}
+	switch d.Name {
+	case "timeout":
+		timeout, err := time.ParseDuration(d.Args)
+		if err != nil {
+			return err
+		}
+		if timeout <= 0 {
+			return errors.New("timeout must be positive")
+		}
+	case "idempotent":
+		if d.Args != "" {
+			return errors.New("idempotent takes no arguments")
+		}
+	case "unpaginated":
+		if d.Args != "" {
+			return errors.New("unpaginated takes no arguments")
+		}
+	case "route":
+		key, ok := d.Arg("key")
+		if !ok || len(strings.Fields(d.Args)) != 1 {
+			return errors.New("route takes a single key=<name> argument")
+		}
+		if !hasRouteKey(m.Type().(*types.Signature), key) {
+			return errors.New("route key must name an argument or a field of a struct argument")
+		}
+	default:
+		return errors.New("unknown directive; supported directives are timeout, idempotent, route, and unpaginated")
+	}
+	return nil
+}
+
+// hasRouteKey returns whether the provided name is the name of an argument of
+// the provided signature, other than the context, or the name of a field of a
+// struct, or pointer to struct, argument.
+func hasRouteKey(sig *types.Signature, name string) bool {
+	for i := 1; i < sig.Params().Len(); i++ {
+		arg := sig.Params().At(i)
+		if arg.Name() == name {
+			return true
+		}
+		t := arg.Type()
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		s, ok := t.Underlying().(*types.Struct)
+		if !ok {
+			continue
+		}
+		for j := 0; j < s.NumFields(); j++ {
+			if s.Field(j).Name() == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkDirectives returns an error if the directives of the provided
+// components conflict with the components' other method attributes.
+func checkDirectives(fset *token.FileSet, components map[string]*component) error {
+	var errs []error
+	for _, comp := range components {
+		methods := make([]string, 0, len(comp.directives))
+		for method := range comp.directives {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			for _, d := range comp.directives[method] {
+				if _, ok := comp.atMostOnce[method]; ok && d.Name == "idempotent" {
+					errs = append(errs, errorf(fset, comp.intf.Obj().Pos(),
+						"method %s.%s is both idempotent and weaver.AtMostOnce", comp.intfName(), method))
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
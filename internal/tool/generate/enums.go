@@ -0,0 +1,199 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"errors"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// enumDirective is the comment directive that marks a type as an enum.
+const enumDirective = directivePrefix + "enum"
+
+// findEnums returns the enum types declared in the provided file and their
+// constants. An enum type is a named integer or string type marked with a
+// //weaver:enum directive, e.g.,
+//
+//	//weaver:enum
+//	type Color int
+//
+//	const (
+//	    Red Color = iota
+//	    Green
+//	    Blue
+//	)
+//
+// The constants of an enum type are the package-level constants of the type,
+// in declaration order. Constants with the same value as an earlier constant
+// are omitted.
+func findEnums(pkg *packages.Package, f *ast.File) (map[*types.Named][]*types.Const, error) {
+	enums := map[*types.Named][]*types.Const{}
+	var errs []error
+	for _, decl := range f.Decls {
+		gendecl, ok := decl.(*ast.GenDecl)
+		if !ok || gendecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range gendecl.Specs {
+			typespec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			doc := typespec.Doc
+			if doc == nil && len(gendecl.Specs) == 1 {
+				doc = gendecl.Doc
+			}
+			if !hasEnumDirective(doc) {
+				continue
+			}
+
+			n, ok := pkg.TypesInfo.Defs[typespec.Name].Type().(*types.Named)
+			if !ok || typespec.Assign.IsValid() {
+				errs = append(errs, errorf(pkg.Fset, spec.Pos(), "enum %s cannot be a type alias", typespec.Name.Name))
+				continue
+			}
+			if n.TypeParams() != nil {
+				errs = append(errs, errorf(pkg.Fset, spec.Pos(), "enum %v cannot be generic", formatType(pkg, n)))
+				continue
+			}
+			b, ok := n.Underlying().(*types.Basic)
+			if !ok || b.Info()&(types.IsInteger|types.IsString) == 0 {
+				errs = append(errs, errorf(pkg.Fset, spec.Pos(), "enum %v must have an integer or string underlying type", formatType(pkg, n)))
+				continue
+			}
+			consts := enumConstants(pkg.Types, n)
+			if len(consts) == 0 {
+				errs = append(errs, errorf(pkg.Fset, spec.Pos(), "enum %v declares no constants", formatType(pkg, n)))
+				continue
+			}
+			enums[n] = consts
+		}
+	}
+	return enums, errors.Join(errs...)
+}
+
+// hasEnumDirective returns whether the provided doc comment contains a
+// //weaver:enum directive.
+func hasEnumDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == enumDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// enumConstants returns the package-level constants of the provided enum type,
+// in declaration order, omitting constants with the same value as an earlier
+// constant.
+func enumConstants(pkg *types.Package, t *types.Named) []*types.Const {
+	var consts []*types.Const
+	scope := pkg.Scope()
+	for _, name := range scope.Names() {
+		c, ok := scope.Lookup(name).(*types.Const)
+		if ok && types.Identical(c.Type(), t) {
+			consts = append(consts, c)
+		}
+	}
+	sort.Slice(consts, func(i, j int) bool { return consts[i].Pos() < consts[j].Pos() })
+
+	var unique []*types.Const
+	for _, c := range consts {
+		duplicate := false
+		for _, u := range unique {
+			if constant.Compare(c.Val(), token.EQL, u.Val()) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			unique = append(unique, c)
+		}
+	}
+	return unique
+}
+
+// generateEnumMethods generates WeaverMarshal and WeaverUnmarshal methods for
+// the enum types declared in the package. An enum value is encoded like a
+// value of the enum's underlying type, so the wire representation of a value
+// doesn't depend on the order or number of the enum's constants. Decoding a
+// value that isn't one of the enum's constants fails.
+func (g *generator) generateEnumMethods(p printFn) {
+	if g.tset.enums.Len() == 0 {
+		return
+	}
+	p(``)
+	p(`// Enum implementations.`)
+
+	// Sort the types so the generated methods appear in deterministic order.
+	sorted := g.tset.enums.Keys()
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].String() < sorted[j].String()
+	})
+
+	ts := g.tset.genTypeString
+	fmt := g.tset.importPackage("fmt", "fmt")
+	for _, t := range sorted {
+		name := t.(*types.Named).Obj().Name()
+		under := t.Underlying()
+		consts := g.tset.enums.At(t).([]*types.Const)
+		names := make([]string, len(consts))
+		for i, c := range consts {
+			names[i] = c.Name()
+		}
+
+		// Generate AutoMarshal assertion. The __is_ check fails to build if
+		// the underlying type of the enum changes and "weaver generate" isn't
+		// re-run. Likewise, WeaverUnmarshal fails to build if a constant is
+		// removed.
+		p(``)
+		p(`var _ %s = (*%s)(nil)`, g.codegen().qualify("AutoMarshal"), ts(t))
+		p(`type __is_%s[T ~%s] struct{}`, name, ts(under))
+		p(`var _ __is_%s[%s]`, name, ts(t))
+
+		// Generate WeaverMarshal method.
+		p(``)
+		p(`func (x *%s) WeaverMarshal(enc *%s) {`, ts(t), g.codegen().qualify("Encoder"))
+		p(`	if x == nil {`)
+		p(`		panic(%s("%s.WeaverMarshal: nil receiver"))`, fmt.qualify("Errorf"), ts(t))
+		p(`	}`)
+		p(`	%s`, g.encode("enc", "("+ts(under)+")(*x)", under))
+		p(`}`)
+
+		// Generate WeaverUnmarshal method.
+		p(``)
+		p(`func (x *%s) WeaverUnmarshal(dec *%s) {`, ts(t), g.codegen().qualify("Decoder"))
+		p(`	if x == nil {`)
+		p(`		panic(%s("%s.WeaverUnmarshal: nil receiver"))`, fmt.qualify("Errorf"), ts(t))
+		p(`	}`)
+		p(`	%s`, g.decode("dec", "(*"+ts(under)+")(x)", under))
+		p(`	switch *x {`)
+		p(`	case %s:`, strings.Join(names, ", "))
+		p(`	default:`)
+		p(`		dec.UnknownEnum(%q, *x)`, ts(t))
+		p(`	}`)
+		p(`}`)
+	}
+}
@@ -22,7 +22,7 @@ func init() {
 		Routed:    true,
 		Listeners: []string{"lis2", "renamed_listener"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return a_local_stub{impl: impl.(A), tracer: tracer, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M1", Remote: false, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M2", Remote: false, Generated: true})}
+			return a_local_stub{impl: impl.(A), tracer: tracer, caller: codegen.Caller{Component: caller}, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M1", Remote: false, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M2", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return a_client_stub{stub: stub, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M1", Remote: true, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/A", Method: "M2", Remote: true, Generated: true})}
@@ -33,7 +33,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return a_reflect_stub{caller: caller}
 		},
-		RefData: "⟦627f661b:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/tool/generate/example/A→github.com/ServiceWeaver/weaver/internal/tool/generate/example/B⟧\n⟦26168bd7:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/tool/generate/example/A→lis2,renamed_listener⟧\n",
+		RefData:          "⟦627f661b:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/tool/generate/example/A→github.com/ServiceWeaver/weaver/internal/tool/generate/example/B⟧\n⟦26168bd7:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/tool/generate/example/A→lis2,renamed_listener⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "c5d3ced9856512f7",
 	})
 	codegen.Register(codegen.Registration{
 		Name:      "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B",
@@ -42,7 +45,7 @@ func init() {
 		Routed:    true,
 		Listeners: []string{"lis2", "renamed_listener"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return b_local_stub{impl: impl.(B), tracer: tracer, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M1", Remote: false, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M2", Remote: false, Generated: true})}
+			return b_local_stub{impl: impl.(B), tracer: tracer, caller: codegen.Caller{Component: caller}, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M1", Remote: false, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M2", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return b_client_stub{stub: stub, m1Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M1", Remote: true, Generated: true}), m2Metrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/internal/tool/generate/example/B", Method: "M2", Remote: true, Generated: true})}
@@ -53,7 +56,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return b_reflect_stub{caller: caller}
 		},
-		RefData: "⟦6971bce2:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/tool/generate/example/B→github.com/ServiceWeaver/weaver/internal/tool/generate/example/A⟧\n⟦c9c43570:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/tool/generate/example/B→lis2,renamed_listener⟧\n",
+		RefData:          "⟦6971bce2:wEaVeReDgE:github.com/ServiceWeaver/weaver/internal/tool/generate/example/B→github.com/ServiceWeaver/weaver/internal/tool/generate/example/A⟧\n⟦c9c43570:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/internal/tool/generate/example/B→lis2,renamed_listener⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "c5d3ced9856512f7",
 	})
 }
 
@@ -77,6 +83,7 @@ var _ func(context.Context, int, string, bool, [10]int, []string, map[bool]int,
 type a_local_stub struct {
 	impl      A
 	tracer    trace.Tracer
+	caller    codegen.Caller
 	m1Metrics *codegen.MethodMetrics
 	m2Metrics *codegen.MethodMetrics
 }
@@ -88,6 +95,10 @@ func (s a_local_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [10
 	// Update metrics.
 	begin := s.m1Metrics.Begin()
 	defer func() { s.m1Metrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -108,6 +119,10 @@ func (s a_local_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [10
 	// Update metrics.
 	begin := s.m2Metrics.Begin()
 	defer func() { s.m2Metrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -127,6 +142,7 @@ func (s a_local_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [10
 type b_local_stub struct {
 	impl      B
 	tracer    trace.Tracer
+	caller    codegen.Caller
 	m1Metrics *codegen.MethodMetrics
 	m2Metrics *codegen.MethodMetrics
 }
@@ -138,6 +154,10 @@ func (s b_local_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [10
 	// Update metrics.
 	begin := s.m1Metrics.Begin()
 	defer func() { s.m1Metrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -158,6 +178,10 @@ func (s b_local_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [10
 	// Update metrics.
 	begin := s.m2Metrics.Begin()
 	defer func() { s.m2Metrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -215,7 +239,8 @@ func (s a_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	enc.Int(a0)
 	enc.String(a1)
 	enc.Bool(a2)
@@ -232,6 +257,7 @@ func (s a_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -240,6 +266,7 @@ func (s a_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -275,7 +302,8 @@ func (s a_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	enc.Int(a0)
 	enc.String(a1)
 	enc.Bool(a2)
@@ -292,6 +320,7 @@ func (s a_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -300,6 +329,7 @@ func (s a_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -344,7 +374,8 @@ func (s b_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	enc.Int(a0)
 	enc.String(a1)
 	enc.Bool(a2)
@@ -361,6 +392,7 @@ func (s b_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -369,6 +401,7 @@ func (s b_client_stub) M1(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -404,7 +437,8 @@ func (s b_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	}()
 
 	// Encode arguments.
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
 	enc.Int(a0)
 	enc.String(a1)
 	enc.Bool(a2)
@@ -421,6 +455,7 @@ func (s b_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -429,6 +464,7 @@ func (s b_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	(&r0).WeaverUnmarshal(dec)
 	err = dec.Error()
 	return
@@ -437,10 +473,10 @@ func (s b_client_stub) M2(ctx context.Context, a0 int, a1 string, a2 bool, a3 [1
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -749,9 +785,11 @@ func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string)
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.String(arg[i])
-	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
@@ -759,10 +797,13 @@ func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 4)
 	res := make([]string, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.String()
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
 	return res
 }
 
@@ -772,10 +813,14 @@ func serviceweaver_enc_map_bool_int_acb668fa(enc *codegen.Encoder, arg map[bool]
 		return
 	}
 	enc.Len(len(arg))
+	m := enc.Map()
 	for k, v := range arg {
+		m.Key()
 		enc.Bool(k)
+		m.Value()
 		enc.Int(v)
 	}
+	m.End()
 }
 
 func serviceweaver_dec_map_bool_int_acb668fa(dec *codegen.Decoder) map[bool]int {
@@ -783,14 +828,17 @@ func serviceweaver_dec_map_bool_int_acb668fa(dec *codegen.Decoder) map[bool]int
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 9)
 	res := make(map[bool]int, n)
 	var k bool
 	var v int
-	for i := 0; i < n; i++ {
-		k = dec.Bool()
-		v = dec.Int()
-		res[k] = v
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k = dec.Bool()
+			v = dec.Int()
+			res[k] = v
+		}
+	})
 	return res
 }
 
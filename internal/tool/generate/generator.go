@@ -54,7 +54,7 @@ const (
 	Usage = `Generate code for a Service Weaver application.
 
 Usage:
-  weaver generate [-tags taglist] [packages]
+  weaver generate [-tags taglist] [-strict [-config files]] [-client-sdk dir [-public components]] [packages]
 
 Description:
   "weaver generate" generates code for the Service Weaver applications in the
@@ -78,6 +78,24 @@ Description:
 
   and then use the normal "go generate" command.
 
+  If -strict is provided, "weaver generate" fails without generating any code
+  if a component is never referenced by a weaver.Ref, if a component is
+  unreachable from the weaver.Main implementation, if a config file
+  configures a listener that no component declares, or if a list method (a
+  method whose name starts with "List") returns a slice or a map rather than a
+  weaver.Page and has no //weaver:unpaginated directive. Only the components
+  in the provided packages are considered. Use -config to list the config
+  files to check; by default, the weaver.toml file in every package's
+  directory is checked, if it exists.
+
+  If -client-sdk is provided, "weaver generate" also writes a standalone Go
+  module to the provided directory with typed clients for the generated
+  components. Other Go programs can use the module to call the components of a
+  deployed application without linking the application's main package. By
+  default, the module includes all exported components. Use -public to list the
+  full names of the components to include instead. Run "go mod tidy" in the
+  directory after generating the module.
+
 Examples:
   # Generate code for the package in the current directory.
   weaver generate
@@ -97,13 +115,40 @@ Examples:
 
   # Generate code for all files that have a "//go:build good,prod" line at the
   top of the file.
-  weaver generate -tags good,prod`
+  weaver generate -tags good,prod
+
+  # Generate code for all packages, checking for unused components and for
+  # listeners in prod.toml that no component declares.
+  weaver generate -strict -config prod.toml ./...
+
+  # Generate code for all packages and a client SDK in the ./sdk directory
+  # for the example.com/app/cache/Cache component.
+  weaver generate -client-sdk ./sdk -public example.com/app/cache/Cache ./...`
 )
 
 // Options controls the operation of Generate.
 type Options struct {
 	Warn      func(error) // If non-nil, use the specified function to report warnings
 	BuildTags string
+
+	// If non-empty, a Go module with typed clients for the generated
+	// components is written to ClientSDK (see runtime/client).
+	ClientSDK string
+
+	// The full names of the components included in the client SDK. If empty,
+	// all exported components are included.
+	Public []string
+
+	// If true, Generate fails without generating any code if a component is
+	// never referenced by a weaver.Ref, if a component is unreachable from
+	// weaver.Main, or if a config file configures a listener that no
+	// component declares.
+	Strict bool
+
+	// The config files whose listeners are checked in strict mode. If empty,
+	// the weaver.toml file in the directory of every package is checked, if
+	// it exists.
+	Configs []string
 }
 
 // Generate generates Service Weaver code for the specified packages.
@@ -115,7 +160,7 @@ func Generate(dir string, pkgs []string, opt Options) error {
 	}
 	fset := token.NewFileSet()
 	cfg := &packages.Config{
-		Mode:      packages.NeedName | packages.NeedSyntax | packages.NeedImports | packages.NeedTypes | packages.NeedTypesInfo,
+		Mode:      packages.NeedName | packages.NeedSyntax | packages.NeedImports | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedModule,
 		Dir:       dir,
 		Fset:      fset,
 		ParseFile: parseNonWeaverGenFile,
@@ -129,6 +174,8 @@ func Generate(dir string, pkgs []string, opt Options) error {
 	}
 
 	var automarshals typeutil.Map
+	var generators []*generator
+	var components []*component
 	var errs []error
 	for _, pkg := range pkgList {
 		g, err := newGenerator(opt, pkg, fset, &automarshals)
@@ -136,11 +183,29 @@ func Generate(dir string, pkgs []string, opt Options) error {
 			errs = append(errs, err)
 			continue
 		}
+		generators = append(generators, g)
+		components = append(components, g.components...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if opt.Strict {
+		if err := checkStrict(fset, pkgList, components, opt.Configs); err != nil {
+			return err
+		}
+	}
+	for _, g := range generators {
 		if err := g.generate(); err != nil {
 			errs = append(errs, err)
 		}
 	}
-	return errors.Join(errs...)
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	if opt.ClientSDK != "" {
+		return generateClientSDK(opt.ClientSDK, opt.Public, pkgList, components)
+	}
+	return nil
 }
 
 // parseNonWeaverGenFile parses a Go file, except for weaver_gen.go files whose
@@ -209,6 +274,17 @@ func newGenerator(opt Options, pkg *packages.Package, fset *token.FileSet, autom
 		for _, t := range ts {
 			tset.automarshalCandidates.Set(t, struct{}{})
 		}
+
+		// Enums implement AutoMarshal with generated methods.
+		enums, err := findEnums(pkg, file)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for t, consts := range enums {
+			tset.enums.Set(t, consts)
+			tset.automarshals.Set(t, struct{}{})
+		}
 	}
 	if err := errors.Join(errs...); err != nil {
 		return nil, err
@@ -272,6 +348,16 @@ func newGenerator(opt Options, pkg *packages.Package, fset *token.FileSet, autom
 		if err := findMethodAttributes(pkg, file, components); err != nil {
 			errs = append(errs, err)
 		}
+		if err := findDirectives(pkg, file, components); err != nil {
+			errs = append(errs, err)
+		}
+		findCalls(pkg, file, components)
+	}
+	if err := checkDirectives(fset, components); err != nil {
+		errs = append(errs, err)
+	}
+	if err := checkWatches(fset, components); err != nil {
+		errs = append(errs, err)
 	}
 
 	if err := errors.Join(errs...); err != nil {
@@ -322,6 +408,7 @@ func findComponents(opt Options, pkg *packages.Package, f *ast.File, tset *typeS
 func findMethodAttributes(pkg *packages.Package, f *ast.File, components map[string]*component) error {
 	// Look for declarations of the form:
 	//	var _ weaver.NotRetriable = Component.Method
+	//	var _ weaver.AtMostOnce = Component.Method
 	var errs []error
 	for _, decl := range f.Decls {
 		gendecl, ok := decl.(*ast.GenDecl)
@@ -338,14 +425,27 @@ func findMethodAttributes(pkg *packages.Package, f *ast.File, components map[str
 				continue
 			}
 			t := typeAndValue.Type
-			if !isWeaverNotRetriable(t) {
+			var attr string
+			switch {
+			case isWeaverNotRetriable(t):
+				attr = "weaver.NonRetriable"
+			case isWeaverAtMostOnce(t):
+				attr = "weaver.AtMostOnce"
+			default:
 				continue
 			}
 			for _, val := range valspec.Values {
 				// We allow non-blank vars for uniformity.
 				comp, method, ok := findComponentMethod(pkg, components, val)
 				if !ok {
-					errs = append(errs, errorf(pkg.Fset, valspec.Pos(), "weaver.NonRetriable should only be assigned a value that identifies a method of a component implemented by this package"))
+					errs = append(errs, errorf(pkg.Fset, valspec.Pos(), "%s should only be assigned a value that identifies a method of a component implemented by this package", attr))
+					continue
+				}
+				if isWeaverAtMostOnce(t) {
+					if comp.atMostOnce == nil {
+						comp.atMostOnce = map[string]struct{}{}
+					}
+					comp.atMostOnce[method] = struct{}{}
 					continue
 				}
 				if comp.noretry == nil {
@@ -358,6 +458,75 @@ func findMethodAttributes(pkg *packages.Package, f *ast.File, components map[str
 	return errors.Join(errs...)
 }
 
+// findCalls records the component method calls made by the methods of the
+// component implementations in the provided file. For example, findCalls
+// records a call from a.Foo to B.Bar in the following code:
+//
+//	type a struct {
+//	    weaver.Implements[A]
+//	    b weaver.Ref[B]
+//	}
+//
+//	func (a *a) Foo(ctx context.Context) error {
+//	    return a.b.Get().Bar(ctx)
+//	}
+//
+// Only calls on values whose static type is a component interface referenced
+// by the implementation with a weaver.Ref are recorded.
+func findCalls(pkg *packages.Package, f *ast.File, components map[string]*component) {
+	byImpl := map[types.Object]*component{}
+	for _, c := range components {
+		byImpl[c.impl.Obj()] = c
+	}
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Body == nil {
+			continue
+		}
+		recv := pkg.TypesInfo.TypeOf(fn.Recv.List[0].Type)
+		if ptr, ok := recv.(*types.Pointer); ok {
+			recv = ptr.Elem()
+		}
+		named, ok := recv.(*types.Named)
+		if !ok {
+			continue
+		}
+		comp, ok := byImpl[named.Obj()]
+		if !ok {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			selection, ok := pkg.TypesInfo.Selections[sel]
+			if !ok || selection.Kind() != types.MethodVal {
+				return true
+			}
+			for _, ref := range comp.refs {
+				if !types.Identical(selection.Recv(), ref) {
+					continue
+				}
+				pos := pkg.Fset.Position(call.Pos())
+				comp.calls = append(comp.calls, codegen.MethodCall{
+					Caller:       comp.fullIntfName(),
+					CallerMethod: fn.Name.Name,
+					Callee:       fullName(ref),
+					CalleeMethod: sel.Sel.Name,
+					Position:     fmt.Sprintf("%s:%d:%d", filepath.Base(pos.Filename), pos.Line, pos.Column),
+				})
+				break
+			}
+			return true
+		})
+	}
+}
+
 // findComponentMethod returns the component and method if val is an expression of
 // the form C.M where C is a component listed in components and C has a method named M.
 func findComponentMethod(pkg *packages.Package, components map[string]*component, val ast.Expr) (*component, string, bool) {
@@ -645,6 +814,31 @@ func extractComponent(opt Options, pkg *packages.Package, file *ast.File, tset *
 	return comp, nil
 }
 
+// checkWatches returns an error if a method of the provided components that
+// returns a weaver.WatchBatch isn't routed by a router. A weaver.Publisher
+// keeps its changes in the memory of a single replica, and can't resume a
+// watch from a token returned by another replica, so every call of a watch
+// must reach the same replica. A route directive isn't enough, since it
+// doesn't route calls.
+func checkWatches(fset *token.FileSet, components map[string]*component) error {
+	var errs []error
+	for _, comp := range components {
+		for _, m := range comp.methods() {
+			results := m.Type().(*types.Signature).Results()
+			if results.Len() == 0 || !isWeaverWatchBatch(results.At(0).Type()) {
+				continue
+			}
+			if comp.routedMethods[m.Name()] {
+				continue
+			}
+			errs = append(errs, errorf(fset, m.Pos(),
+				"method %s.%s returns a weaver.WatchBatch but isn't routed. Route it with a router (see weaver.WithRouter), so that every call of a watch reaches the replica that publishes the changes it watches.",
+				comp.intfName(), m.Name()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // getListenerNamesFromStructField extracts listener names from the given
 // weaver.Listener field in the component implementation struct.
 func getListenerNamesFromStructField(pkg *packages.Package, f *ast.Field) ([]string, error) {
@@ -690,15 +884,20 @@ func getListenerNamesFromStructField(pkg *packages.Package, f *ast.Field) ([]str
 //	}
 //	type router struct{}
 type component struct {
-	intf          *types.Named        // component interface
-	impl          *types.Named        // component implementation
-	router        *types.Named        // router, or nil if there is no router
-	routingKey    types.Type          // routing key, or nil if there is no router
-	routedMethods map[string]bool     // the set of methods with a routing function
-	isMain        bool                // intf is weaver.Main
-	refs          []*types.Named      // List of T where a weaver.Ref[T] field is in impl struct
-	listeners     []string            // Names of listener fields declared in impl struct
-	noretry       map[string]struct{} // Methods that should not be retried
+	intf          *types.Named         // component interface
+	impl          *types.Named         // component implementation
+	router        *types.Named         // router, or nil if there is no router
+	routingKey    types.Type           // routing key, or nil if there is no router
+	routedMethods map[string]bool      // the set of methods with a routing function
+	isMain        bool                 // intf is weaver.Main
+	refs          []*types.Named       // List of T where a weaver.Ref[T] field is in impl struct
+	listeners     []string             // Names of listener fields declared in impl struct
+	calls         []codegen.MethodCall // Component method calls made by impl methods
+	noretry       map[string]struct{}  // Methods that should not be retried
+	atMostOnce    map[string]struct{}  // Methods that should be called at most once
+
+	// Method directives, by method name. See codegen.Directive.
+	directives map[string][]codegen.Directive
 }
 
 func fullName(t *types.Named) string {
@@ -912,7 +1111,7 @@ type printFn func(format string, args ...interface{})
 
 // TODO(mwhittaker): Have generate return an error.
 func (g *generator) generate() error {
-	if len(g.components)+g.tset.automarshalCandidates.Len() == 0 {
+	if len(g.components)+g.tset.automarshalCandidates.Len()+g.tset.enums.Len() == 0 {
 		// There's nothing to generate.
 		return nil
 	}
@@ -928,7 +1127,9 @@ func (g *generator) generate() error {
 		fn := func(format string, args ...interface{}) {
 			fmt.Fprintln(&body, fmt.Sprintf(format, args...))
 		}
-		g.generateRegisteredComponents(fn)
+		if err := g.generateRegisteredComponents(fn); err != nil {
+			return err
+		}
 		g.generateInstanceChecks(fn)
 		g.generateRouterChecks(fn)
 		g.generateLocalStubs(fn)
@@ -939,6 +1140,7 @@ func (g *generator) generate() error {
 		g.generateServerStubs(fn)
 		g.generateReflectStubs(fn)
 		g.generateAutoMarshalMethods(fn)
+		g.generateEnumMethods(fn)
 		g.generateRouterMethods(fn)
 		g.generateEncDecMethods(fn)
 
@@ -1175,9 +1377,13 @@ func (g *generator) generateRouterChecks(p printFn) {
 }
 
 // generateRegisteredComponents generates code that registers the components with Service Weaver.
-func (g *generator) generateRegisteredComponents(p printFn) {
+func (g *generator) generateRegisteredComponents(p printFn) error {
 	if len(g.components) == 0 {
-		return
+		return nil
+	}
+	selfVersion, err := tool.SelfVersion()
+	if err != nil {
+		return fmt.Errorf("read self version: %w", err)
 	}
 
 	g.tset.importPackage("context", "context")
@@ -1201,13 +1407,13 @@ func (g *generator) generateRegisteredComponents(p printFn) {
 
 		// E.g.,
 		//   func(impl any, caller string, tracer trace.Tracer) any {
-		//       return foo_local_stub{impl: impl.(Foo), tracer: tracer, ...}
+		//       return foo_local_stub{impl: impl.(Foo), tracer: tracer, caller: codegen.Caller{Component: caller}, ...}
 		//   }
 		b.Reset()
 		for _, m := range comp.methods() {
 			emitMetricInitializer(m, false)
 		}
-		localStubFn := fmt.Sprintf(`func(impl any, caller string, tracer %v) any { return %s_local_stub{impl: impl.(%s), tracer: tracer%s } }`, g.trace().qualify("Tracer"), notExported(name), g.componentRef(comp), b.String())
+		localStubFn := fmt.Sprintf(`func(impl any, caller string, tracer %v) any { return %s_local_stub{impl: impl.(%s), tracer: tracer, caller: %s{Component: caller}%s } }`, g.trace().qualify("Tracer"), notExported(name), g.componentRef(comp), g.codegen().qualify("Caller"), b.String())
 
 		// E.g.,
 		//   func(stub *codegen.Stub, caller string) any {
@@ -1245,6 +1451,9 @@ func (g *generator) generateRegisteredComponents(p printFn) {
 		if len(comp.listeners) > 0 {
 			refData.WriteString(codegen.MakeListenersString(myName, comp.listeners))
 		}
+		for _, call := range comp.calls {
+			refData.WriteString(codegen.MakeCallString(call))
+		}
 
 		// E.g.,
 		//	weaver.Register(weaver.Registration{
@@ -1269,25 +1478,45 @@ func (g *generator) generateRegisteredComponents(p printFn) {
 			p(`		Listeners: []string{%s},`, strings.Join(listeners, ", "))
 		}
 		if len(comp.noretry) > 0 {
-			p(`		NoRetry: []int{%s},`, noRetryString(comp))
+			p(`		NoRetry: []int{%s},`, methodIndices(comp, comp.noretry))
+		}
+		if len(comp.atMostOnce) > 0 {
+			p(`		AtMostOnce: []int{%s},`, methodIndices(comp, comp.atMostOnce))
+		}
+		if len(comp.directives) > 0 {
+			p(`		Directives: map[string][]%s{`, g.codegen().qualify("Directive"))
+			for _, m := range comp.methods() {
+				var ds []string
+				for _, d := range comp.directives[m.Name()] {
+					ds = append(ds, fmt.Sprintf(`{Name: %q, Args: %q}`, d.Name, d.Args))
+				}
+				if len(ds) > 0 {
+					p(`			%q: {%s},`, m.Name(), strings.Join(ds, ", "))
+				}
+			}
+			p(`		},`)
 		}
 		p(`		LocalStubFn: %s,`, localStubFn)
 		p(`		ClientStubFn: %s,`, clientStubFn)
 		p(`		ServerStubFn: %s,`, serverStubFn)
 		p(`		ReflectStubFn: %s,`, reflectStubFn)
 		p(`		RefData: %s,`, strconv.Quote(refData.String()))
+		p(`		GeneratorVersion: %q,`, selfVersion)
+		p(`		CodegenVersion: %q,`, version.CodegenVersion)
+		p(`		IfaceHash: %q,`, interfaceHash(comp.intf))
 		p(`	})`)
 	}
 	p(`}`)
+	return nil
 }
 
-// noRetryString generates a string of the form "i_1, i_2, ... i_n" where the
-// individual elements are the indices of methods in comp.retry that should not
-// be retried.
-func noRetryString(comp *component) string {
-	list := make([]int, 0, len(comp.noretry))
+// methodIndices generates a string of the form "i_1, i_2, ... i_n" where the
+// individual elements are the indices of the methods of comp in the provided
+// set (e.g., comp.noretry).
+func methodIndices(comp *component, set map[string]struct{}) string {
+	list := make([]int, 0, len(set))
 	for i, m := range comp.methods() {
-		if _, ok := comp.noretry[m.Name()]; ok {
+		if _, ok := set[m.Name()]; ok {
 			list = append(list, i)
 		}
 	}
@@ -1312,6 +1541,7 @@ func (g *generator) generateLocalStubs(p printFn) {
 		p(`type %s struct{`, stub)
 		p(`	impl %s`, g.componentRef(comp))
 		p(`	tracer %s`, g.trace().qualify("Tracer"))
+		p(`	caller %s`, g.codegen().qualify("Caller"))
 		for _, m := range comp.methods() {
 			p(`	%sMetrics *%s`, notExported(m.Name()), g.codegen().qualify("MethodMetrics"))
 		}
@@ -1331,6 +1561,12 @@ func (g *generator) generateLocalStubs(p printFn) {
 			p(`	begin := s.%sMetrics.Begin()`, notExported(m.Name()))
 			p(`	defer func() { s.%sMetrics.End(begin, err != nil, 0, 0) }()`, notExported(m.Name()))
 
+			// Record the caller.
+			p(``)
+			p(`	// Record the caller.`)
+			p(`	ctx = %s(ctx, s.caller)`, g.codegen().qualify("WithCaller"))
+			p(``)
+
 			// Create a child span iff tracing is enabled in ctx.
 			p(`	span := %s(ctx)`, g.trace().qualify("SpanFromContext"))
 			p(`	if span.SpanContext().IsValid() {`)
@@ -1452,7 +1688,7 @@ func (g *generator) generateClientStubs(p printFn) {
 						at := mt.Params().At(i).Type()
 						p("	size += %s", g.size(fmt.Sprintf("a%d", i-1), at))
 					}
-					p("	enc := %s", g.codegen().qualify("NewEncoder()"))
+					p("	enc := %s", g.codegen().qualify("NewPooledEncoder()"))
 					p("	enc.Reset(size)")
 					preallocated = true
 				}
@@ -1464,7 +1700,13 @@ func (g *generator) generateClientStubs(p printFn) {
 				p(``)
 				p(`	// Encode arguments.`)
 				if !preallocated {
-					p("	enc := %s", g.codegen().qualify("NewEncoder()"))
+					p("	enc := %s", g.codegen().qualify("NewPooledEncoder()"))
+				}
+				for i := 1; i < mt.Params().Len(); i++ {
+					if g.hasCollection(mt.Params().At(i).Type()) {
+						p("	enc.SetProgress(%s(ctx))", g.codegen().qualify("ProgressFromContext"))
+						break
+					}
 				}
 			}
 			for i := 1; i < mt.Params().Len(); i++ { // Skip initial context.Context
@@ -1499,6 +1741,10 @@ func (g *generator) generateClientStubs(p printFn) {
 			}
 			p(`	var results []byte`)
 			p(`	results, err = s.stub.Run(ctx, %d, %s, shardKey)`, methodIndex[m.Name()], data)
+			if mt.Params().Len() > 1 {
+				// The stub doesn't use the arguments after Run returns.
+				p(`	enc.Release()`)
+			}
 			p(`	replyBytes = len(results)`)
 			p(`	if err != nil {`)
 			p(`		err = %s(%s, err)`, g.errorsPackage().qualify("Join"), g.weaver().qualify("RemoteCallError"))
@@ -1510,6 +1756,12 @@ func (g *generator) generateClientStubs(p printFn) {
 			p(``)
 			p(`	// Decode the results.`)
 			p(`	dec := %s(results)`, g.codegen().qualify("NewDecoder"))
+			for i := 0; i < mt.Results().Len()-1; i++ {
+				if g.hasCollection(mt.Results().At(i).Type()) {
+					p(`	dec.SetProgress(%s(ctx))`, g.codegen().qualify("ProgressFromContext"))
+					break
+				}
+			}
 			for i := 0; i < mt.Results().Len()-1; i++ { // Skip final error
 				rt := mt.Results().At(i).Type()
 				res := fmt.Sprintf("r%d", i)
@@ -1634,6 +1886,198 @@ func (g *generator) isWeaverEncoded(t types.Type) bool {
 	}
 }
 
+// hasCollection returns whether a value of type t may contain a slice or a
+// map, which are encoded in chunks if they are large (see
+// codegen.Encoder.Chunks).
+//
+// REQUIRES: t is serializable.
+func (g *generator) hasCollection(t types.Type) bool {
+	var visited typeutil.Map
+	var f func(t types.Type) bool
+	f = func(t types.Type) bool {
+		t = unalias(t)
+		if visited.At(t) != nil {
+			// Recursive types, like type List struct { Next *List }.
+			return false
+		}
+		visited.Set(t, true)
+		if nativeCodec(t) != "" || g.tset.isProto(t) || g.tset.hasMarshalBinary(t) {
+			return false
+		}
+		if _, ok := t.Underlying().(*types.Interface); ok {
+			// The concrete value may contain anything.
+			return true
+		}
+
+		switch x := t.(type) {
+		case *types.Slice, *types.Map:
+			return true
+		case *types.Pointer:
+			return f(x.Elem())
+		case *types.Array:
+			return f(x.Elem())
+		case *types.Struct:
+			for i := 0; i < x.NumFields(); i++ {
+				if f(x.Field(i).Type()) {
+					return true
+				}
+			}
+			return false
+		case *types.Named:
+			return f(x.Underlying())
+		default:
+			return false
+		}
+	}
+	return f(t)
+}
+
+// minEncodedSize returns a lower bound on the number of bytes a value of type t
+// is encoded in, or zero if it doesn't know one, e.g., for types that are
+// serialized by hand. Generated decoders use it to check the length of a
+// collection before allocating it (see codegen.Decoder.CheckLen).
+//
+// REQUIRES: t is serializable.
+func (g *generator) minEncodedSize(t types.Type) int {
+	t = unalias(t)
+	if nativeCodec(t) != "" || g.tset.isProto(t) || g.tset.hasMarshalBinary(t) {
+		return 0
+	}
+
+	switch x := t.(type) {
+	case *types.Basic:
+		switch x.Kind() {
+		case types.Bool, types.Int8, types.Uint8:
+			return 1
+		case types.Int16, types.Uint16:
+			return 2
+		case types.Int32, types.Uint32, types.Float32:
+			return 4
+		case types.Int, types.Uint, types.Int64, types.Uint64, types.Float64, types.Complex64:
+			return 8
+		case types.Complex128:
+			return 16
+		case types.String:
+			return 4 // the length
+		default:
+			return 0
+		}
+
+	case *types.Pointer:
+		return 1 // whether the pointer is nil
+
+	case *types.Slice, *types.Map:
+		return 4 // the length
+
+	case *types.Array:
+		return int(x.Len()) * g.minEncodedSize(x.Elem())
+
+	case *types.Struct:
+		size := 0
+		for i := 0; i < x.NumFields(); i++ {
+			size += g.minEncodedSize(x.Field(i).Type())
+		}
+		return size
+
+	case *types.Named:
+		if g.tset.enums.At(x) != nil {
+			return 0
+		}
+		if g.tset.automarshals.At(x) != nil {
+			// See hasFixedLayout.
+			s, ok := x.Underlying().(*types.Struct)
+			if !ok {
+				return 0
+			}
+			size := 0
+			for i := 0; i < s.NumFields(); i++ {
+				if f := s.Field(i); !isWeaverAutoMarshal(f.Type()) {
+					size += g.minEncodedSize(f.Type())
+				}
+			}
+			return size
+		}
+		if g.tset.implementsAutoMarshal(x) {
+			// The WeaverMarshal method may be written by hand.
+			return 0
+		}
+		return g.minEncodedSize(x.Underlying())
+
+	default:
+		return 0
+	}
+}
+
+// hasFixedLayout returns whether the serialization of a value of type t is
+// the concatenation of the serializations of its numeric fields or elements.
+// If so, values of type t may be laid out in memory exactly as they are
+// serialized, and slices of t can be encoded and decoded with a single copy.
+// Whether they are laid out this way depends on the target machine, so
+// codegen.EncodeFixed and codegen.DecodeFixed check at runtime.
+//
+// REQUIRES: t is serializable.
+func (g *generator) hasFixedLayout(t types.Type) bool {
+	if nativeCodec(t) != "" || g.tset.isProto(t) || g.tset.hasMarshalBinary(t) {
+		return false
+	}
+
+	switch x := t.(type) {
+	case *types.Basic:
+		// Booleans are excluded because decoding them requires validation.
+		switch x.Kind() {
+		case types.Int, types.Int8, types.Int16, types.Int32, types.Int64,
+			types.Uint, types.Uint8, types.Uint16, types.Uint32, types.Uint64,
+			types.Float32, types.Float64,
+			types.Complex64, types.Complex128:
+			return true
+		default:
+			return false
+		}
+
+	case *types.Array:
+		return g.hasFixedLayout(x.Elem())
+
+	case *types.Struct:
+		for i := 0; i < x.NumFields(); i++ {
+			if !g.hasFixedLayout(x.Field(i).Type()) {
+				return false
+			}
+		}
+		return true
+
+	case *types.Named:
+		if g.tset.enums.At(x) != nil {
+			// Decoding an enum requires validation.
+			return false
+		}
+		if g.tset.automarshals.At(x) != nil {
+			// The generated WeaverMarshal method of a struct encodes every
+			// field, except for the embedded weaver.AutoMarshal, which is
+			// empty.
+			s, ok := x.Underlying().(*types.Struct)
+			if !ok {
+				// An enum declared in another package.
+				return false
+			}
+			for i := 0; i < s.NumFields(); i++ {
+				f := s.Field(i)
+				if !isWeaverAutoMarshal(f.Type()) && !g.hasFixedLayout(f.Type()) {
+					return false
+				}
+			}
+			return true
+		}
+		if g.tset.implementsAutoMarshal(x) {
+			// The WeaverMarshal method may be written by hand.
+			return false
+		}
+		return g.hasFixedLayout(x.Underlying())
+
+	default:
+		return false
+	}
+}
+
 // size returns a go expression that evaluates to the size of the provided
 // expression e of the provided type t.
 //
@@ -2194,6 +2638,13 @@ func (g *generator) encode(stub, e string, t types.Type) string {
 		return fmt.Sprintf("serviceweaver_enc_%s", sanitize(t))
 	}
 
+	if m := nativeCodec(t); m != "" {
+		return fmt.Sprintf("%s.%s(%s)", stub, m, e)
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return fmt.Sprintf("%s.Concrete(%s)", stub, e)
+	}
+
 	// Let enc(stub, e: t) be the statement that encodes e into stub. [t] is
 	// shorthand for sanitize(t). under(t) is the underlying type of t.
 	//
@@ -2203,6 +2654,8 @@ func (g *generator) encode(stub, e string, t types.Type) string {
 	// enc(stub, e: []t) = serviceweaver_enc_[[]t](&stub, e)
 	// enc(stub, e: map[k]v) = serviceweaver_enc_[map[k]v](&stub, e)
 	// enc(stub, e: struct{...}) = serviceweaver_enc_[struct{...}](&stub, &e)
+	// enc(stub, e: time.Time) = stub.Time(e)                  // likewise for netip.Addr, netip.Prefix, *big.Int
+	// enc(stub, e: t) = stub.Concrete(e)                      // under(t) = interface{...}
 	// enc(stub, e: type t u) = stub.EncodeProto(&e)           // t implements proto.Message
 	// enc(stub, e: type t u) = (e).WeaverMarshal(stub)         // t implements AutoMarshal
 	// enc(stub, e: type t u) = stub.EncodeBinaryMarshaler(&e) // t implements BinaryMarshaler
@@ -2266,6 +2719,13 @@ func (g *generator) decode(stub, v string, t types.Type) string {
 		return fmt.Sprintf("serviceweaver_dec_%s", sanitize(t))
 	}
 
+	if m := nativeCodec(t); m != "" {
+		return fmt.Sprintf("%s = %s.%s()", deref(v), stub, m)
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		return fmt.Sprintf("%s = %s[%s](%s)", deref(v), g.codegen().qualify("DecodeConcrete"), g.tset.genTypeString(t), stub)
+	}
+
 	// Let dec(stub, v: t) be the statement that decodes a value of type t from
 	// stub into v of type *t. [t] is shorthand for sanitize(t). under(t) is
 	// the underlying type of t.
@@ -2276,6 +2736,8 @@ func (g *generator) decode(stub, v string, t types.Type) string {
 	// dec(stub, v: []t) = v := *v = serviceweaver_dec_[[]t](stub)
 	// dec(stub, v: map[k]v) = *v := serviceweaver_dec_[map[k]v](stub)
 	// dec(stub, v: struct{...}) = serviceweaver_dec_[struct{...}](stub, &v)
+	// dec(stub, v: time.Time) = *v = stub.Time()                // likewise for netip.Addr, netip.Prefix, *big.Int
+	// dec(stub, v: t) = *v = codegen.DecodeConcrete[t](stub)  // under(t) = interface{...}
 	// dec(stub, v: type t u) = stub.DecodeProto(v)             // t implements proto.Message
 	// dec(stub, v: type t u) = (v).WeaverUnmarshal(stub)        // t implements AutoMarshal
 	// dec(stub, v: type t u) = stub.DecodeBinaryUnmarshaler(v) // t implements BinaryUnmarshaler
@@ -2368,6 +2830,18 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 	}
 	g.generated.Set(t, true)
 
+	if nativeCodec(t) != "" {
+		// Natively supported types (e.g., time.Time) don't need encoding or
+		// decoding methods. Instead, we call methods directly on a
+		// codegen.Encoder or codegen.Decoder (e.g., enc.Time(x)).
+		return
+	}
+	if _, ok := t.Underlying().(*types.Interface); ok {
+		// Interfaces are encoded with enc.Concrete(x) and decoded with
+		// codegen.DecodeConcrete[T](dec).
+		return
+	}
+
 	ts := g.tset.genTypeString
 	switch x := t.(type) {
 	case *types.Basic:
@@ -2436,9 +2910,16 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 		p(`		return`)
 		p(`	}`)
 		p(`	enc.Len(len(arg))`)
-		p(`	for i := 0; i < len(arg); i++ {`)
-		p(`		%s`, g.encode("enc", "arg[i]", x.Elem()))
-		p(`	}`)
+		if g.hasFixedLayout(x.Elem()) {
+			p(`	if %s(enc, arg) {`, g.codegen().qualify("EncodeFixed"))
+			p(`		return`)
+			p(`	}`)
+		}
+		p(`	enc.Chunks(len(arg), func(lo, hi int) {`)
+		p(`		for i := lo; i < hi; i++ {`)
+		p(`			%s`, g.encode("enc", "arg[i]", x.Elem()))
+		p(`		}`)
+		p(`	})`)
 		p(`}`)
 
 		p(``)
@@ -2447,10 +2928,20 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 		p(`	if n == -1 {`)
 		p(`		return nil`)
 		p(`	}`)
+		if size := g.minEncodedSize(x.Elem()); size > 0 {
+			p(`	dec.CheckLen(n, %d)`, size)
+		}
 		p(`	res := make(%s, n)`, ts(x))
-		p(`	for i := 0; i < n; i++ {`)
-		p(`		%s`, g.decode("dec", "&res[i]", x.Elem()))
-		p(`	}`)
+		if g.hasFixedLayout(x.Elem()) {
+			p(`	if %s[%s](dec, res) {`, g.codegen().qualify("DecodeFixed"), ts(x.Elem()))
+			p(`		return res`)
+			p(`	}`)
+		}
+		p(`	dec.Chunks(n, func(lo, hi int) {`)
+		p(`		for i := lo; i < hi; i++ {`)
+		p(`			%s`, g.decode("dec", "&res[i]", x.Elem()))
+		p(`		}`)
+		p(`	})`)
 		p(`	return res`)
 		p(`}`)
 
@@ -2465,10 +2956,14 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 		p(`		return`)
 		p(`	}`)
 		p(`	enc.Len(len(arg))`)
+		p(`	m := enc.Map()`)
 		p(`	for k, v := range arg {`)
+		p(`		m.Key()`)
 		p(`		%s`, g.encode("enc", "k", x.Key()))
+		p(`		m.Value()`)
 		p(`		%s`, g.encode("enc", "v", x.Elem()))
 		p(`	}`)
+		p(`	m.End()`)
 		p(`}`)
 
 		p(``)
@@ -2477,14 +2972,19 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 		p(`	if n == -1 {`)
 		p(`		return nil`)
 		p(`	}`)
+		if size := g.minEncodedSize(x.Key()) + g.minEncodedSize(x.Elem()); size > 0 {
+			p(`	dec.CheckLen(n, %d)`, size)
+		}
 		p(`	res := make(%s, n)`, ts(x))
 		p(`	var k %s`, ts(x.Key()))
 		p(`	var v %s`, ts(x.Elem()))
-		p(`	for i := 0; i < n; i++ {`)
-		p(`		%s`, g.decode("dec", "&k", x.Key()))
-		p(`		%s`, g.decode("dec", "&v", x.Elem()))
-		p(`		res[k] = v`)
-		p(`	}`)
+		p(`	dec.Chunks(n, func(lo, hi int) {`)
+		p(`		for i := lo; i < hi; i++ {`)
+		p(`			%s`, g.decode("dec", "&k", x.Key()))
+		p(`			%s`, g.decode("dec", "&v", x.Elem()))
+		p(`			res[k] = v`)
+		p(`		}`)
+		p(`	})`)
 		p(`	return res`)
 		p(`}`)
 
@@ -2501,6 +3001,35 @@ func (g *generator) generateEncDecMethodsFor(p printFn, t types.Type) {
 			// enc.EncodeProto(x), dec.DecodeBinaryUnmarshaler(x)).
 			return
 		}
+		if isWeaverGenericStruct(x) {
+			// weaver.Page[T], WatchBatch[T], and WatchChange[T] are generic
+			// structs, so they can't embed weaver.AutoMarshal. Instead, we
+			// generate encoding and decoding methods for every
+			// instantiation.
+			s := x.Underlying().(*types.Struct)
+			for i := 0; i < s.NumFields(); i++ {
+				g.generateEncDecMethodsFor(p, s.Field(i).Type())
+			}
+
+			// Note that arg is never nil.
+			p(``)
+			p(`func serviceweaver_enc_%s(enc *%s, arg *%s) {`, sanitize(x), g.codegen().qualify("Encoder"), ts(x))
+			for i := 0; i < s.NumFields(); i++ {
+				f := s.Field(i)
+				p(`	%s`, g.encode("enc", "arg."+f.Name(), f.Type()))
+			}
+			p(`}`)
+
+			// Note that res is never nil.
+			p(``)
+			p(`func serviceweaver_dec_%s(dec *%s, res *%s) {`, sanitize(x), g.codegen().qualify("Decoder"), ts(x))
+			for i := 0; i < s.NumFields(); i++ {
+				f := s.Field(i)
+				p(`	%s`, g.decode("dec", "&res."+f.Name(), f.Type()))
+			}
+			p(`}`)
+			return
+		}
 		// If a named type t is not a struct, e.g. `type t int`, then we
 		// encode and decode values of type by casting it to its underlying
 		// type (e.g., enc.Int(int(x)) where x has type t).
@@ -2566,7 +3095,7 @@ func formatType(currentPackage *packages.Package, t types.Type) string {
 func sanitize(t types.Type) string {
 	var sanitize func(types.Type) string
 	sanitize = func(t types.Type) string {
-		switch x := t.(type) {
+		switch x := unalias(t).(type) {
 		case *types.Pointer:
 			return fmt.Sprintf("ptr_%s", sanitize(x.Elem()))
 
@@ -2611,6 +3140,10 @@ func sanitize(t types.Type) string {
 			// The hash suffix below will ensure the names are unique.
 			return "struct"
 
+		case *types.Interface:
+			// As with structs, the hash suffix below ensures uniqueness.
+			return "interface"
+
 		case *types.Basic:
 			switch x.Kind() {
 			case types.Bool,
@@ -2640,7 +3173,7 @@ func sanitize(t types.Type) string {
 // int bool`, then TypeString returns "int" for both the named type int and the
 // primitive type int.
 func uniqueName(t types.Type) string {
-	switch x := t.(type) {
+	switch x := unalias(t).(type) {
 	case *types.Pointer:
 		return fmt.Sprintf("*%s", uniqueName(x.Elem()))
 
@@ -2690,6 +3223,11 @@ func uniqueName(t types.Type) string {
 		}
 		return fmt.Sprintf("struct{%s}", strings.Join(fields, "; "))
 
+	case *types.Interface:
+		// Qualify every type by its full package path, so that the name is
+		// unique.
+		return types.TypeString(x, func(pkg *types.Package) string { return pkg.Path() })
+
 	case *types.Basic:
 		switch x.Kind() {
 		case types.Bool,
@@ -2701,7 +3239,6 @@ func uniqueName(t types.Type) string {
 			return x.Name()
 		}
 	}
-	// TODO(mwhittaker): What about Struct and Interface literals?
 	panic(fmt.Sprintf("unsupported type %v (%T)", t, t))
 }
 
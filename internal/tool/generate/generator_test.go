@@ -344,6 +344,98 @@ func TestGeneratorErrors(t *testing.T) {
 	}
 }
 
+func TestClientSDK(t *testing.T) {
+	// Test plan: Run "weaver generate -client-sdk" on a module with a
+	// component in a library package, and check that the client SDK builds.
+	tmp := t.TempDir()
+	save := func(f, data string) {
+		if err := os.MkdirAll(filepath.Dir(filepath.Join(tmp, f)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmp, f), []byte(data), 0644); err != nil {
+			t.Fatalf("error writing %s: %v", f, err)
+		}
+	}
+	save("go.mod", goModFile)
+	save("cache/cache.go", `package cache
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type Cache interface {
+	Get(context.Context, string) (string, error)
+}
+
+type cache struct {
+	weaver.Implements[Cache]
+}
+
+func (*cache) Get(context.Context, string) (string, error) { return "", nil }
+
+type hidden interface {
+	Get(context.Context) error
+}
+
+type hiddenImpl struct {
+	weaver.Implements[hidden]
+}
+
+func (*hiddenImpl) Get(context.Context) error { return nil }
+`)
+	run := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Dir = dir
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+	run(tmp, "go", "mod", "tidy")
+
+	// Run "weaver generate".
+	sdk := filepath.Join(tmp, "sdk")
+	opt := Options{
+		Warn:      func(err error) { t.Log(err) },
+		BuildTags: "ignoreWeaverGen",
+		ClientSDK: sdk,
+	}
+	if err := Generate(tmp, []string{"./..."}, opt); err != nil {
+		t.Fatal(err)
+	}
+	code, err := os.ReadFile(filepath.Join(sdk, sdkCodeFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "func (c *Client) Cache(ctx context.Context) (cache.Cache, error)"; !strings.Contains(string(code), want) {
+		t.Errorf("client SDK does not contain %q:\n%s", want, code)
+	}
+	if strings.Contains(string(code), "hidden") {
+		t.Errorf("client SDK unexpectedly contains unexported component:\n%s", code)
+	}
+	gomod, err := os.ReadFile(filepath.Join(sdk, sdkGoModFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "module foo/sdk"; !strings.Contains(string(gomod), want) {
+		t.Errorf("client SDK go.mod does not contain %q:\n%s", want, gomod)
+	}
+
+	// Check that the client SDK builds.
+	run(sdk, "go", "mod", "tidy")
+	run(sdk, "go", "build", ".")
+
+	// Unexported components can't be chosen.
+	opt.Public = []string{"foo/cache/hidden"}
+	if err := Generate(tmp, []string{"./..."}, opt); err == nil {
+		t.Fatal("Generate: unexpected success for unexported component")
+	}
+}
+
 func TestSanitize(t *testing.T) {
 	// Test plan: Check that sanitize returns the expected sanitized name for
 	// various types. Also check that sanitize is injective; i.e. every type
@@ -502,7 +594,7 @@ func TestExampleVersion(t *testing.T) {
 	got := fmt.Sprintf("%x", h.Sum(nil))
 
 	// If weaver_gen.go has changed, the codegen version may need updating.
-	const want = "fdc14ac425413ea0f80ea42d3c8d1bc555d57c511135b5639720f233668f4fe7"
+	const want = "e23cca5d640645e98116ebae09423704c77fbb3b46a72f53be67423d9c949074"
 	if got != want {
 		t.Fatalf(`Unexpected SHA-256 hash of examples/weaver_gen.go: got %s, want %s. If this change is meaningful, REMEMBER TO UPDATE THE CODEGEN VERSION in runtime/version/version.go.`, got, want)
 	}
@@ -0,0 +1,109 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"fmt"
+	"go/types"
+	"sort"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// interfaceHash returns the hash of the provided component interface. It
+// returns the same hash as codegen.InterfaceHash returns for the corresponding
+// reflect.Type.
+func interfaceHash(intf *types.Named) string {
+	underlying := intf.Underlying().(*types.Interface)
+	methods := make([]string, underlying.NumMethods())
+	for i := range methods {
+		m := underlying.Method(i)
+		methods[i] = m.Name() + signatureString(m.Type().(*types.Signature)) + "\n"
+	}
+	sort.Strings(methods)
+	return codegen.HashInterfaceString(strings.Join(methods, ""))
+}
+
+// typeString returns the string representation of a type used to compute
+// interface hashes. See runtime/codegen/ifacehash.go for details.
+func typeString(t types.Type) string {
+	switch x := unalias(t).(type) {
+	case *types.Named:
+		if x.Obj().Pkg() == nil {
+			return x.Obj().Name()
+		}
+		return x.Obj().Pkg().Path() + "." + x.Obj().Name()
+	case *types.Basic:
+		// Resolve byte and rune.
+		return types.Typ[x.Kind()].Name()
+	case *types.Pointer:
+		return "*" + typeString(x.Elem())
+	case *types.Slice:
+		return "[]" + typeString(x.Elem())
+	case *types.Array:
+		return fmt.Sprintf("[%d]%s", x.Len(), typeString(x.Elem()))
+	case *types.Map:
+		return fmt.Sprintf("map[%s]%s", typeString(x.Key()), typeString(x.Elem()))
+	case *types.Chan:
+		switch x.Dir() {
+		case types.RecvOnly:
+			return "<-chan " + typeString(x.Elem())
+		case types.SendOnly:
+			return "chan<- " + typeString(x.Elem())
+		default:
+			return "chan " + typeString(x.Elem())
+		}
+	case *types.Signature:
+		return "func" + signatureString(x)
+	case *types.Struct:
+		fields := make([]string, x.NumFields())
+		for i := range fields {
+			f := x.Field(i)
+			fields[i] = f.Name() + " " + typeString(f.Type())
+		}
+		return "struct{" + strings.Join(fields, ";") + "}"
+	case *types.Interface:
+		methods := make([]string, x.NumMethods())
+		for i := range methods {
+			m := x.Method(i)
+			methods[i] = m.Name() + signatureString(m.Type().(*types.Signature))
+		}
+		sort.Strings(methods)
+		return "interface{" + strings.Join(methods, ";") + "}"
+	default:
+		// Type parameters and tuples don't appear in component interfaces.
+		return t.String()
+	}
+}
+
+// signatureString returns the string representation of the signature of the
+// provided function type, e.g., "(int,...string)(error)".
+func signatureString(sig *types.Signature) string {
+	args := make([]string, sig.Params().Len())
+	for i := range args {
+		t := sig.Params().At(i).Type()
+		if sig.Variadic() && i == len(args)-1 {
+			args[i] = "..." + typeString(t.(*types.Slice).Elem())
+		} else {
+			args[i] = typeString(t)
+		}
+	}
+	results := make([]string, sig.Results().Len())
+	for i := range results {
+		results[i] = typeString(sig.Results().At(i).Type())
+	}
+	return "(" + strings.Join(args, ",") + ")(" + strings.Join(results, ",") + ")"
+}
@@ -0,0 +1,299 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/internal/files"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/tools/go/packages"
+)
+
+const (
+	// The names of the files in a generated client SDK.
+	sdkCodeFile  = "weaver_client_gen.go"
+	sdkGoModFile = "go.mod"
+
+	// The import path of the package used by generated client SDKs.
+	clientPackagePath = "github.com/ServiceWeaver/weaver/runtime/client"
+)
+
+// generateClientSDK writes a Go module to dir that contains typed clients for
+// the provided components. If public is non-empty, only the components with
+// the provided full names are included.
+func generateClientSDK(dir string, public []string, pkgs []*packages.Package, components []*component) error {
+	// Select the components to include.
+	byName := map[string]*component{}
+	for _, c := range components {
+		if !c.isMain {
+			byName[fullName(c.intf)] = c
+		}
+	}
+	// importable returns whether c can be used outside of its package.
+	importable := func(c *component) bool {
+		return token.IsExported(c.intfName()) && c.intf.Obj().Pkg().Name() != "main"
+	}
+	var selected []*component
+	if len(public) == 0 {
+		for _, c := range byName {
+			if importable(c) {
+				selected = append(selected, c)
+			}
+		}
+	} else {
+		for _, name := range public {
+			c, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("client SDK: component %q not found", name)
+			}
+			if !importable(c) {
+				return fmt.Errorf("client SDK: component %q is unexported or in a main package", name)
+			}
+			selected = append(selected, c)
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("client SDK: no exported components outside of main packages found")
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		return fullName(selected[i].intf) < fullName(selected[j].intf)
+	})
+
+	// Find the module that contains the components.
+	var mod *packages.Module
+	for _, pkg := range pkgs {
+		if pkg.Module != nil {
+			mod = pkg.Module
+			break
+		}
+	}
+	if mod == nil {
+		return fmt.Errorf("client SDK: components are not in a module")
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	code, err := sdkCode(filepath.Base(dir), selected)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(filepath.Join(dir, sdkCodeFile), code); err != nil {
+		return err
+	}
+	gomod, err := sdkGoMod(dir, mod)
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(dir, sdkGoModFile), gomod)
+}
+
+// sdkCode returns the formatted contents of a client SDK's Go file.
+func sdkCode(dirname string, components []*component) ([]byte, error) {
+	// Assign a unique name to every imported package.
+	imports := map[string]string{} // package path -> package name
+	used := map[string]bool{"context": true, "weaverclient": true}
+	for _, c := range components {
+		path := c.intf.Obj().Pkg().Path()
+		if _, ok := imports[path]; ok {
+			continue
+		}
+		name := c.intf.Obj().Pkg().Name()
+		for i := 1; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", c.intf.Obj().Pkg().Name(), i)
+		}
+		imports[path] = name
+		used[name] = true
+	}
+
+	// Every component gets a method named after its interface.
+	methods := map[string]string{"Close": ""}
+	for _, c := range components {
+		if other, ok := methods[c.intfName()]; ok {
+			return nil, fmt.Errorf("client SDK: components %s and %s have the same name; use -public to pick one", other, fullName(c.intf))
+		}
+		methods[c.intfName()] = fullName(c.intf)
+	}
+
+	var b bytes.Buffer
+	p := func(format string, args ...any) {
+		fmt.Fprintf(&b, format, args...)
+		b.WriteByte('\n')
+	}
+	p(`// Code generated by "weaver generate". DO NOT EDIT.`)
+	p(``)
+	p(`// Package %s contains typed clients for the components of a Service Weaver`, sdkPackageName(dirname))
+	p(`// application. Run "go mod tidy" in this directory after generating it.`)
+	p(`package %s`, sdkPackageName(dirname))
+	p(``)
+	p(`import (`)
+	p(`	"context"`)
+	p(``)
+	p(`	weaverclient %q`, clientPackagePath)
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		p(`	%s %q`, imports[path], path)
+	}
+	p(`)`)
+	p(``)
+	p(`// Client calls the components of a deployed Service Weaver application.`)
+	p(`type Client struct {`)
+	p(`	conn *weaverclient.Conn`)
+	p(`}`)
+	p(``)
+	p(`// Dial returns a Client connected to the weavelets at the provided addresses.`)
+	p(`func Dial(ctx context.Context, opts weaverclient.Options) (*Client, error) {`)
+	p(`	conn, err := weaverclient.Dial(ctx, opts)`)
+	p(`	if err != nil {`)
+	p(`		return nil, err`)
+	p(`	}`)
+	p(`	return &Client{conn: conn}, nil`)
+	p(`}`)
+	p(``)
+	p(`// Close closes the client's connection.`)
+	p(`func (c *Client) Close() {`)
+	p(`	c.conn.Close()`)
+	p(`}`)
+	for _, c := range components {
+		typ := imports[c.intf.Obj().Pkg().Path()] + "." + c.intfName()
+		p(``)
+		p(`// %s returns a client for the %s component.`, c.intfName(), fullName(c.intf))
+		p(`func (c *Client) %s(ctx context.Context) (%s, error) {`, c.intfName(), typ)
+		p(`	return weaverclient.Get[%s](ctx, c.conn)`, typ)
+		p(`}`)
+	}
+	return format.Source(b.Bytes())
+}
+
+// sdkPackageName returns the name of a client SDK package in the provided
+// directory.
+func sdkPackageName(dirname string) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r - 'A' + 'a'
+		}
+		return -1
+	}, dirname)
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "sdk" + name
+	}
+	return name
+}
+
+// sdkGoMod returns the contents of the go.mod file of a client SDK in dir that
+// depends on the provided module. The SDK's module path is derived from the
+// provided module's path if dir is inside the module, and is dir's base name
+// otherwise. The module's replace directives are copied into the SDK, since
+// they are otherwise ignored.
+func sdkGoMod(dir string, mod *packages.Module) ([]byte, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Base(dir)
+	if rel, err := filepath.Rel(mod.Dir, dir); err == nil && !strings.HasPrefix(rel, "..") {
+		path = mod.Path + "/" + filepath.ToSlash(rel)
+	}
+
+	f := &modfile.File{}
+	if err := f.AddModuleStmt(path); err != nil {
+		return nil, err
+	}
+	goVersion := mod.GoVersion
+	if goVersion == "" {
+		goVersion = "1.21"
+	}
+	if err := f.AddGoStmt(goVersion); err != nil {
+		return nil, err
+	}
+	if err := f.AddRequire(mod.Path, "v0.0.0"); err != nil {
+		return nil, err
+	}
+	rel, err := filepath.Rel(dir, mod.Dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.AddReplace(mod.Path, "", localModulePath(rel), ""); err != nil {
+		return nil, err
+	}
+
+	// Copy the module's own replace directives.
+	if mod.GoMod != "" {
+		data, err := os.ReadFile(mod.GoMod)
+		if err != nil {
+			return nil, err
+		}
+		modFile, err := modfile.Parse(mod.GoMod, data, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range modFile.Replace {
+			newPath := r.New.Path
+			if r.New.Version == "" && !filepath.IsAbs(newPath) {
+				// A local replacement relative to the module's directory.
+				rel, err := filepath.Rel(dir, filepath.Join(mod.Dir, newPath))
+				if err != nil {
+					return nil, err
+				}
+				newPath = localModulePath(rel)
+			}
+			if err := f.AddReplace(r.Old.Path, r.Old.Version, newPath, r.New.Version); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	data, err := f.Format()
+	if err != nil {
+		return nil, err
+	}
+	header := []byte("// Code generated by \"weaver generate\". DO NOT EDIT.\n\n")
+	return append(header, data...), nil
+}
+
+// localModulePath returns a replace directive path for the provided relative
+// directory. Relative paths in replace directives must start with ./ or ../.
+func localModulePath(rel string) string {
+	rel = filepath.ToSlash(rel)
+	if strings.HasPrefix(rel, "../") || rel == ".." {
+		return rel
+	}
+	return "./" + rel
+}
+
+// writeFile atomically writes data to the provided file.
+func writeFile(filename string, data []byte) error {
+	dst := files.NewWriter(filename)
+	defer dst.Cleanup()
+	if _, err := dst.Write(data); err != nil {
+		return err
+	}
+	return dst.Close()
+}
@@ -0,0 +1,228 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"golang.org/x/tools/go/packages"
+)
+
+// configFile is the name of the config file that "weaver generate -strict"
+// checks in the directory of every package, if no config files are provided.
+const configFile = "weaver.toml"
+
+// checkStrict performs the checks of "weaver generate -strict" on the
+// provided components, found in the provided packages. It returns an error if
+//
+//   - a component other than weaver.Main is never referenced by a weaver.Ref;
+//   - weaver.Main is implemented, but a component is unreachable from it; or
+//   - a config file configures a listener that no component declares; or
+//   - a list method, i.e., a method whose name starts with "List", returns a
+//     slice or map rather than a weaver.Page, unless it has a
+//     //weaver:unpaginated directive.
+//
+// Config files are read from the provided paths or, if there are none, from
+// the weaver.toml file in the directory of every package, if it exists.
+//
+// Only the provided components are considered. A component referenced only by
+// components in other packages is reported as unreferenced.
+func checkStrict(fset *token.FileSet, pkgs []*packages.Package, components []*component, configs []string) error {
+	var errs []error
+	byName := map[string]*component{}
+	for _, c := range components {
+		byName[c.fullIntfName()] = c
+	}
+	components = slices.Clone(components)
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].fullIntfName() < components[j].fullIntfName()
+	})
+
+	// Check that every component is referenced.
+	referenced := map[string]bool{}
+	for _, c := range components {
+		for _, ref := range c.refs {
+			referenced[fullName(ref)] = true
+		}
+	}
+	for _, c := range components {
+		if !c.isMain && !referenced[c.fullIntfName()] {
+			errs = append(errs, errorf(fset, c.impl.Obj().Pos(),
+				"component %s is never referenced by a weaver.Ref", c.fullIntfName()))
+		}
+	}
+
+	// Check that every referenced component is reachable from weaver.Main.
+	var main *component
+	for _, c := range components {
+		if c.isMain {
+			main = c
+		}
+	}
+	if main != nil {
+		reachable := map[string]bool{}
+		var visit func(c *component)
+		visit = func(c *component) {
+			if reachable[c.fullIntfName()] {
+				return
+			}
+			reachable[c.fullIntfName()] = true
+			for _, ref := range c.refs {
+				if next, ok := byName[fullName(ref)]; ok {
+					visit(next)
+				}
+			}
+		}
+		visit(main)
+		for _, c := range components {
+			if referenced[c.fullIntfName()] && !reachable[c.fullIntfName()] {
+				errs = append(errs, errorf(fset, c.impl.Obj().Pos(),
+					"component %s is unreachable from weaver.Main", c.fullIntfName()))
+			}
+		}
+	}
+
+	// Check that list methods are paginated.
+	for _, c := range components {
+		for _, m := range c.methods() {
+			if !isUnpaginatedList(m) {
+				continue
+			}
+			if _, ok := findDirective(c.directives[m.Name()], "unpaginated"); ok {
+				continue
+			}
+			errs = append(errs, errorf(fset, m.Pos(),
+				"list method %s.%s returns all of its results at once. Return a weaver.Page instead, or add a //weaver:unpaginated directive.",
+				c.intfName(), m.Name()))
+		}
+	}
+
+	// Check that configured listeners are declared.
+	declared := map[string]bool{}
+	for _, c := range components {
+		for _, lis := range c.listeners {
+			declared[lis] = true
+		}
+	}
+	if len(configs) == 0 {
+		for _, pkg := range pkgs {
+			if len(pkg.Syntax) == 0 {
+				continue
+			}
+			dir := filepath.Dir(fset.Position(pkg.Syntax[0].Package).Filename)
+			filename := filepath.Join(dir, configFile)
+			if _, err := os.Stat(filename); err == nil {
+				configs = append(configs, filename)
+			}
+		}
+	}
+	for _, filename := range configs {
+		listeners, err := configuredListeners(filename, byName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, lis := range listeners {
+			if !declared[lis.name] {
+				errs = append(errs, fmt.Errorf("%s: section %q configures listener %q, which is not declared by any component",
+					filename, lis.section, lis.name))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// configuredListener is a listener configured in a config file.
+type configuredListener struct {
+	section string // config section, e.g., "multi"
+	name    string // listener name
+}
+
+// configuredListeners returns the listeners configured in the "listeners"
+// tables of the sections of the provided config file, sorted by section and
+// name. Component config sections, whose keys are the names of components in
+// the provided map, are skipped.
+func configuredListeners(filename string, components map[string]*component) ([]configuredListener, error) {
+	var sections map[string]any
+	if _, err := toml.DecodeFile(filename, &sections); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", filename, err)
+	}
+	var listeners []configuredListener
+	for key, section := range sections {
+		if _, ok := components[key]; ok {
+			continue
+		}
+		table, ok := section.(map[string]any)
+		if !ok {
+			continue
+		}
+		lis, ok := table["listeners"].(map[string]any)
+		if !ok {
+			continue
+		}
+		for name := range lis {
+			listeners = append(listeners, configuredListener{key, name})
+		}
+	}
+	sort.Slice(listeners, func(i, j int) bool {
+		if listeners[i].section != listeners[j].section {
+			return listeners[i].section < listeners[j].section
+		}
+		return listeners[i].name < listeners[j].name
+	})
+	return listeners, nil
+}
+
+// isUnpaginatedList returns whether the provided component method is a list
+// method, i.e., its name starts with "List", that returns a slice or a map
+// rather than a weaver.Page.
+func isUnpaginatedList(m *types.Func) bool {
+	if !strings.HasPrefix(m.Name(), "List") {
+		return false
+	}
+	results := m.Type().(*types.Signature).Results()
+	unpaginated := false
+	for i := 0; i < results.Len(); i++ {
+		t := results.At(i).Type()
+		if isWeaverPage(t) {
+			return false
+		}
+		switch t.Underlying().(type) {
+		case *types.Slice, *types.Map:
+			unpaginated = true
+		}
+	}
+	return unpaginated
+}
+
+// findDirective returns the directive with the provided name, if any.
+func findDirective(directives []codegen.Directive, name string) (codegen.Directive, bool) {
+	for _, d := range directives {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return codegen.Directive{}, false
+}
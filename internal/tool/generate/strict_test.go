@@ -0,0 +1,182 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// strictPreamble is the start of every program in TestStrict.
+const strictPreamble = `package main
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type A interface{ F(context.Context) error }
+type B interface{ F(context.Context) error }
+
+func (a) F(context.Context) error { return nil }
+func (b) F(context.Context) error { return nil }
+`
+
+func TestStrict(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		src    string
+		config string
+		want   []string // expected error substrings, or none for success
+	}{
+		{
+			name: "Reachable",
+			src: `
+type app struct {
+	weaver.Implements[weaver.Main]
+	a   weaver.Ref[A]
+	lis weaver.Listener
+}
+type a struct {
+	weaver.Implements[A]
+	b weaver.Ref[B]
+}
+type b struct{ weaver.Implements[B] }
+`,
+			config: "[multi]\nlisteners.lis = {address = \"localhost:9000\"}\n",
+		},
+		{
+			name: "Unreferenced",
+			src: `
+type app struct {
+	weaver.Implements[weaver.Main]
+	a weaver.Ref[A]
+}
+type a struct{ weaver.Implements[A] }
+type b struct{ weaver.Implements[B] }
+`,
+			want: []string{"component main/B is never referenced by a weaver.Ref"},
+		},
+		{
+			name: "Unreachable",
+			src: `
+type app struct{ weaver.Implements[weaver.Main] }
+type a struct {
+	weaver.Implements[A]
+	b weaver.Ref[B]
+}
+type b struct {
+	weaver.Implements[B]
+	a weaver.Ref[A]
+}
+`,
+			want: []string{
+				"component main/A is unreachable from weaver.Main",
+				"component main/B is unreachable from weaver.Main",
+			},
+		},
+		{
+			name: "UndeclaredListener",
+			src: `
+type app struct {
+	weaver.Implements[weaver.Main]
+	a   weaver.Ref[A]
+	b   weaver.Ref[B]
+	lis weaver.Listener
+}
+type a struct{ weaver.Implements[A] }
+type b struct{ weaver.Implements[B] }
+`,
+			config: "[single]\nlisteners.lis = {address = \"localhost:9000\"}\nlisteners.typo = {address = \"localhost:9001\"}\n",
+			want:   []string{`section "single" configures listener "typo", which is not declared by any component`},
+		},
+		{
+			name: "UnpaginatedList",
+			src: `
+type L interface {
+	ListAll(context.Context) ([]string, error)
+	ListPage(context.Context, weaver.PageRequest) (weaver.Page[string], error)
+	//weaver:unpaginated
+	ListSmall(context.Context) (map[string]int, error)
+}
+type app struct {
+	weaver.Implements[weaver.Main]
+	a weaver.Ref[A]
+	b weaver.Ref[B]
+	l weaver.Ref[L]
+}
+type a struct{ weaver.Implements[A] }
+type b struct{ weaver.Implements[B] }
+type l struct{ weaver.Implements[L] }
+
+func (l) ListAll(context.Context) ([]string, error) { return nil, nil }
+func (l) ListPage(context.Context, weaver.PageRequest) (weaver.Page[string], error) {
+	return weaver.Page[string]{}, nil
+}
+func (l) ListSmall(context.Context) (map[string]int, error) { return nil, nil }
+`,
+			want: []string{"list method L.ListAll returns all of its results at once"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			tmp := t.TempDir()
+			save := func(f, data string) {
+				if err := os.WriteFile(filepath.Join(tmp, f), []byte(data), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			save("main.go", strictPreamble+test.src+"\nfunc main() {}\n")
+			save("go.mod", strings.Replace(goModFile, `module "foo"`, `module "main"`, 1))
+			if test.config != "" {
+				save(configFile, test.config)
+			}
+			tidy := exec.Command("go", "mod", "tidy")
+			tidy.Dir = tmp
+			tidy.Stdout = os.Stdout
+			tidy.Stderr = os.Stderr
+			if err := tidy.Run(); err != nil {
+				t.Fatalf("go mod tidy: %v", err)
+			}
+
+			opt := Options{
+				Warn:      func(err error) { t.Log(err) },
+				BuildTags: "ignoreWeaverGen",
+				Strict:    true,
+			}
+			err := Generate(tmp, []string{tmp}, opt)
+			if len(test.want) == 0 {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("unexpectedly succeeded (want errors %q)", test.want)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("bad error: want %q, got: %v", want, err)
+				}
+			}
+			if _, err := os.Stat(filepath.Join(tmp, generatedCodeFile)); err == nil {
+				t.Errorf("%s generated despite strict errors", generatedCodeFile)
+			}
+		})
+	}
+}
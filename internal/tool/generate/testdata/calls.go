@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// wEaVeRcAlL:foo/A.Foo→foo/B.Bar@calls.go:55:9
+// wEaVeRcAlL:foo/A.helper→foo/B.Baz@calls.go:60:2
+// wEaVeRcAlL:foo/A.helper→foo/B.Bar@calls.go:62:10
+
+// UNEXPECTED
+// wEaVeRcAlL:foo/B.
+
+// Package foo contains components that call each other.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type A interface {
+	Foo(context.Context) error
+}
+
+type B interface {
+	Bar(context.Context) error
+	Baz(context.Context) error
+}
+
+type a struct {
+	weaver.Implements[A]
+	b weaver.Ref[B]
+}
+
+type b struct {
+	weaver.Implements[B]
+}
+
+type notAComponent struct{}
+
+func (notAComponent) Bar(context.Context) error { return nil }
+
+func (a *a) Foo(ctx context.Context) error {
+	return a.b.Get().Bar(ctx)
+}
+
+func (a *a) helper(ctx context.Context) error {
+	b := a.b.Get()
+	b.Baz(ctx)
+	f := func() error {
+		return b.Bar(ctx)
+	}
+	return f()
+}
+
+func (*b) Bar(ctx context.Context) error {
+	return notAComponent{}.Bar(ctx)
+}
+
+func (*b) Baz(context.Context) error { return nil }
@@ -0,0 +1,56 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// Directives: map[string][]codegen.Directive{
+// "Get": {{Name: "timeout", Args: "2s"}, {Name: "idempotent", Args: ""}},
+// "Put": {{Name: "route", Args: "key=UserID"}},
+
+// UNEXPECTED
+// "Delete": {{
+
+// Package foo contains a component with method directives.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type Request struct {
+	weaver.AutoMarshal
+	UserID string
+	Value  string
+}
+
+type foo interface {
+	// Get returns the value of a key.
+	//
+	//weaver:timeout 2s
+	//weaver:idempotent
+	Get(ctx context.Context, key string) (string, error)
+
+	//weaver:route key=UserID
+	Put(context.Context, Request) error
+
+	// Delete is not a weaver:idempotent method.
+	Delete(context.Context, string) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (*impl) Get(context.Context, string) (string, error) { return "", nil }
+func (*impl) Put(context.Context, Request) error          { return nil }
+func (*impl) Delete(context.Context, string) error        { return nil }
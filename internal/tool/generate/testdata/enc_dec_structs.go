@@ -37,8 +37,8 @@
 // func (x *W) WeaverUnmarshal(dec *codegen.Decoder)
 // func (x *W) WeaverMarshal(enc *codegen.Encoder)
 // func (x *W) WeaverUnmarshal(dec *codegen.Decoder)
-// EncodeBinaryMarshaler
-// DecodeBinaryUnmarshaler
+// enc.Time(x.When)
+// x.When = dec.Time()
 
 // UNEXPECTED
 // Preallocate
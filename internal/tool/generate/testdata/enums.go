@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// func (x *Color) WeaverUnmarshal(dec *codegen.Decoder) {
+// *(*int)(x) = dec.Int()
+// case Red, Green, Blue:
+// dec.UnknownEnum("Color", *x)
+// *(*string)(x) = dec.String()
+// case Small, Large:
+// (a0).WeaverMarshal(enc)
+// (&res[i]).WeaverUnmarshal(dec)
+
+// UNEXPECTED
+// case Red, Green, Blue, Crimson:
+// func (x *notAnEnum) WeaverMarshal
+
+// Package foo contains enum types used by a component.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// Color is a color.
+//
+//weaver:enum
+type Color int
+
+const (
+	Red Color = iota
+	Green
+	Blue
+
+	// Crimson is an alias of Red.
+	Crimson = Red
+)
+
+type (
+	//weaver:enum
+	Size string
+
+	notAnEnum int
+)
+
+const (
+	Small Size = "small"
+	Large Size = "large"
+)
+
+const zero notAnEnum = 0
+
+type foo interface {
+	Paint(context.Context, Color, []Size, notAnEnum) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (*impl) Paint(context.Context, Color, []Size, notAnEnum) error { return nil }
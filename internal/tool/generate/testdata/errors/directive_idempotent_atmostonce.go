@@ -0,0 +1,33 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ERROR: is both idempotent and weaver.AtMostOnce
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type foo interface {
+	//weaver:idempotent
+	Get(context.Context, string) (string, error)
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (*impl) Get(context.Context, string) (string, error) { return "", nil }
+
+var _ weaver.AtMostOnce = foo.Get
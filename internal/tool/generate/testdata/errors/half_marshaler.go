@@ -0,0 +1,36 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// ERROR: type has a WeaverMarshal method but no WeaverUnmarshal method
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// celsius serializes itself, but can't deserialize itself.
+type celsius float64
+
+func (c *celsius) WeaverMarshal(enc *codegen.Encoder) { enc.Float64(float64(*c)) }
+
+type foo interface {
+	Set(context.Context, celsius) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) Set(context.Context, celsius) error { return nil }
@@ -0,0 +1,35 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+
+// ERROR: method foo.WatchChanges returns a weaver.WatchBatch but isn't routed
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// A route directive records a routing key, but doesn't route calls.
+type foo interface {
+	//weaver:route key=prefix
+	WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[string], error)
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) WatchChanges(context.Context, string, weaver.WatchToken) (weaver.WatchBatch[string], error) {
+	return weaver.WatchBatch[string]{}, nil
+}
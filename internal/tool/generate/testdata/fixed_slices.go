@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// codegen.EncodeFixed(enc, arg)
+// codegen.DecodeFixed[int64](dec, res)
+// codegen.DecodeFixed[float64](dec, res)
+// codegen.DecodeFixed[Celsius](dec, res)
+// codegen.DecodeFixed[[3]float32](dec, res)
+// codegen.DecodeFixed[Point](dec, res)
+
+// UNEXPECTED
+// codegen.DecodeFixed[bool]
+// codegen.DecodeFixed[string]
+// codegen.DecodeFixed[Color]
+// codegen.DecodeFixed[Labeled]
+
+// Slices of fixed layout types.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type Celsius float64
+
+type Point struct {
+	weaver.AutoMarshal
+	X, Y, Z float64
+}
+
+//weaver:enum
+type Color int
+
+const (
+	Red Color = iota
+	Green
+)
+
+type Labeled struct {
+	weaver.AutoMarshal
+	P     Point
+	Valid bool
+}
+
+type foo interface {
+	A(context.Context, []int64, []float64, []Celsius, [][3]float32) error
+	B(context.Context, []Point) ([]Point, error)
+	C(context.Context, []bool, []string, []Color, []Labeled) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (l *impl) A(context.Context, []int64, []float64, []Celsius, [][3]float32) error {
+	return nil
+}
+
+func (l *impl) B(context.Context, []Point) ([]Point, error) {
+	return nil, nil
+}
+
+func (l *impl) C(context.Context, []bool, []string, []Color, []Labeled) error {
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// CodegenVersion:
+// IfaceHash:        "a7fa453629ed1ea2",
+
+// Package foo contains a component whose interface hash must match the one
+// computed by codegen.InterfaceHash for the equivalent reflect.Type.
+package foo
+
+import (
+	"context"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type Store interface {
+	Put(context.Context, map[string]*time.Duration, ...[2]rune) error
+	Get(context.Context, string) ([]byte, error)
+}
+
+type store struct {
+	weaver.Implements[Store]
+}
+
+func (*store) Get(context.Context, string) ([]byte, error) {
+	return nil, nil
+}
+
+func (*store) Put(context.Context, map[string]*time.Duration, ...[2]rune) error {
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// enc.Concrete(a0)
+// a0 = codegen.DecodeConcrete[Shape](dec)
+// enc.Concrete(x.Shape)
+// x.Shape = codegen.DecodeConcrete[Shape](dec)
+// enc.Concrete(arg[i])
+// res[i] = codegen.DecodeConcrete[any](dec)
+// r0 = codegen.DecodeConcrete[interface{ Area() float64 }](dec)
+
+// UNEXPECTED
+// func serviceweaver_enc_Shape
+// func serviceweaver_dec_Shape
+
+// Methods with interface-typed arguments, results, and fields.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	weaver.AutoMarshal
+	R float64
+}
+
+func (c Circle) Area() float64 { return 3 * c.R * c.R }
+
+type Drawing struct {
+	weaver.AutoMarshal
+	Shape Shape
+}
+
+func init() {
+	codegen.RegisterConcrete[Circle]()
+}
+
+type foo interface {
+	Area(context.Context, Shape) (float64, error)
+	Draw(context.Context, Drawing, []any) (interface{ Area() float64 }, error)
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) Area(_ context.Context, s Shape) (float64, error) { return s.Area(), nil }
+
+func (impl) Draw(_ context.Context, d Drawing, _ []any) (interface{ Area() float64 }, error) {
+	return d.Shape, nil
+}
@@ -21,6 +21,8 @@
 // serviceweaver_enc_map_int_bool
 // serviceweaver_dec_map_array_10_int_int
 // serviceweaver_enc_map_Y_map_string_slice_X
+// enc.SetProgress(codegen.ProgressFromContext(ctx))
+// dec.Chunks(n, func(lo, hi int) {
 
 // UNEXPECTED
 // Preallocate
@@ -20,6 +20,9 @@
 // serviceweaver_dec_slice_X
 // serviceweaver_dec_slice_int
 // serviceweaver_dec_slice_string
+// enc.Chunks(len(arg), func(lo, hi int) {
+// dec.Chunks(n, func(lo, hi int) {
+// dec.SetProgress(codegen.ProgressFromContext(ctx))
 // serviceweaver_enc_slice_map_int_string
 // serviceweaver_enc_slice_slice_X
 // serviceweaver_enc_ptr_string
@@ -17,16 +17,19 @@
 // type foo_client_stub struct
 // type foo_server_stub struct
 // A(ctx context.Context, a0 string, a1 int, a2 Bar, a3 Other) (err error)
+// enc := codegen.NewPooledEncoder()
 // s.stub.Run(ctx, 0, enc.Data(), shardKey)
+// enc.Release()
 // enc.String(a0)
 // enc.Int(a1)
 // func (x *Bar) WeaverMarshal(enc *codegen.Encoder)
 // func (x *Bar) WeaverUnmarshal(dec *codegen.Decoder)
-// EncodeBinaryMarshaler
+// enc.Time(x.T)
 // impl{}
 
 // UNEXPECTED
 // c.Args.Encode(a3)
+// SetProgress
 // Preallocate
 
 // Multiple args.
@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// enc.Time(a0)
+// enc.Int64((int64)(a1))
+// enc.Addr(a2)
+// enc.Prefix(a3)
+// enc.BigInt(a4)
+// *(*int64)(&a1) = dec.Int64()
+// a0 = dec.Time()
+// a2 = dec.Addr()
+// a3 = dec.Prefix()
+// a4 = dec.BigInt()
+// r0 = dec.Time()
+// enc.Time(arg[i])
+// res[i] = dec.Time()
+// enc.BigInt(x.N)
+// x.N = dec.BigInt()
+
+// UNEXPECTED
+// EncodeBinaryMarshaler
+// DecodeBinaryUnmarshaler
+// func serviceweaver_enc_ptr_Int
+// func serviceweaver_dec_ptr_Int
+
+// Methods with standard library types that are serialized natively.
+package foo
+
+import (
+	"context"
+	"math/big"
+	"net/netip"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type bar struct {
+	weaver.AutoMarshal
+	N *big.Int
+}
+
+type foo interface {
+	A(context.Context, time.Time, time.Duration, netip.Addr, netip.Prefix, *big.Int) (time.Time, error)
+	B(context.Context, []time.Time, bar) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) A(context.Context, time.Time, time.Duration, netip.Addr, netip.Prefix, *big.Int) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (impl) B(context.Context, []time.Time, bar) error { return nil }
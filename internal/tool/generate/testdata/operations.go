@@ -0,0 +1,49 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// (r0).WeaverMarshal(enc)
+// (&r0).WeaverUnmarshal(dec)
+
+// UNEXPECTED
+// named structs are not serializable
+
+// Methods that start long-running operations.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type foo interface {
+	Export(ctx context.Context, table string) (weaver.Operation[int], error)
+	OperationStatus(ctx context.Context, id string) (weaver.OperationStatus, error)
+	CancelOperation(ctx context.Context, id string) error
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) Export(context.Context, string) (weaver.Operation[int], error) {
+	return weaver.Operation[int]{}, nil
+}
+
+func (impl) OperationStatus(context.Context, string) (weaver.OperationStatus, error) {
+	return weaver.OperationStatus{}, nil
+}
+
+func (impl) CancelOperation(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// (a0).WeaverMarshal(enc)
+// (&a0).WeaverUnmarshal(dec)
+// func serviceweaver_enc_Page_User_
+// func serviceweaver_dec_Page_User_
+// func serviceweaver_enc_Page_string_
+// enc.String((string)(arg.Next))
+// *(*string)(&res.Next) = dec.String()
+// arg.Items)
+// res.Items = serviceweaver_dec_slice_User_
+
+// UNEXPECTED
+// named structs are not serializable
+
+// Methods that return weaver.Page.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type User struct {
+	weaver.AutoMarshal
+	ID   string
+	Name string
+}
+
+type foo interface {
+	ListUsers(context.Context, weaver.PageRequest) (weaver.Page[User], error)
+	ListNames(context.Context, weaver.PageRequest) (weaver.Page[string], error)
+}
+
+type impl struct{ weaver.Implements[foo] }
+
+func (impl) ListUsers(context.Context, weaver.PageRequest) (weaver.Page[User], error) {
+	return weaver.Page[User]{}, nil
+}
+
+func (impl) ListNames(context.Context, weaver.PageRequest) (weaver.Page[string], error) {
+	return weaver.Page[string]{}, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// EXPECTED
+// func serviceweaver_enc_WatchBatch_string_
+// func serviceweaver_enc_WatchChange_string_
+// func serviceweaver_enc_WatchBatch_Point_
+// enc.String((string)(arg.Token))
+// serviceweaver_enc_slice_WatchChange_string_
+// (arg.Value).WeaverMarshal(enc)
+
+// UNEXPECTED
+// named structs are not serializable
+
+// Methods that publish changes to watchers.
+package foo
+
+import (
+	"context"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+type Point struct {
+	weaver.AutoMarshal
+	X, Y int
+}
+
+type foo interface {
+	Get(ctx context.Context, id string) (string, weaver.WatchToken, error)
+	WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[string], error)
+}
+
+type impl struct {
+	weaver.Implements[foo]
+	weaver.WithRouter[fooRouter]
+}
+
+func (impl) Get(context.Context, string) (string, weaver.WatchToken, error) {
+	return "", "", nil
+}
+
+func (impl) WatchChanges(context.Context, string, weaver.WatchToken) (weaver.WatchBatch[string], error) {
+	return weaver.WatchBatch[string]{}, nil
+}
+
+type Points interface {
+	WatchPoints(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[Point], error)
+}
+
+type points struct {
+	weaver.Implements[Points]
+	weaver.WithRouter[router]
+}
+
+func (points) WatchPoints(context.Context, string, weaver.WatchToken) (weaver.WatchBatch[Point], error) {
+	return weaver.WatchBatch[Point]{}, nil
+}
+
+type router struct{}
+
+func (router) WatchPoints(_ context.Context, prefix string, _ weaver.WatchToken) string {
+	return prefix
+}
+
+type fooRouter struct{}
+
+func (fooRouter) Get(_ context.Context, id string) string {
+	return id
+}
+
+func (fooRouter) WatchChanges(_ context.Context, prefix string, _ weaver.WatchToken) string {
+	return prefix
+}
@@ -36,6 +36,7 @@ type typeSet struct {
 
 	automarshals          *typeutil.Map // types that implement AutoMarshal
 	automarshalCandidates *typeutil.Map // types that declare themselves AutoMarshal
+	enums                 *typeutil.Map // enum types, mapped to their []*types.Const
 
 	// If checked[t] != nil, then checked[t] is the cached result of calling
 	// check(pkg, t, string[]{}). Otherwise, if checked[t] == nil, then t has
@@ -99,6 +100,7 @@ func newTypeSet(pkg *packages.Package, automarshals, automarshalCandidates *type
 		importedByName:        map[string]importPkg{},
 		automarshals:          automarshals,
 		automarshalCandidates: automarshalCandidates,
+		enums:                 &typeutil.Map{},
 	}
 }
 
@@ -258,6 +260,7 @@ func (tset *typeSet) checkSerializable(t types.Type) []error {
 			lineage = append(lineage, pathAndType{path, t})
 			defer func() { lineage = lineage[:len(lineage)-1] }()
 		}
+		t = unalias(t)
 
 		// Return early if we've already checked this type.
 		if result := tset.checked.At(t); result != nil {
@@ -289,12 +292,41 @@ func (tset *typeSet) checkSerializable(t types.Type) []error {
 			// No need to check if x is an unexported type from another package
 			// since the Go compiler takes care of that.
 
-			// Check if the type implements one of the marshaler interfaces.
-			if tset.isProto(x) || tset.automarshals.At(t) != nil || tset.implementsAutoMarshal(x) || tset.hasMarshalBinary(x) {
+			if isError(x) {
+				addError(fmt.Errorf("errors are only serializable as the final result of a method"))
+				tset.checked.Set(t, false)
+				break
+			}
+
+			// Check if the type is serialized natively or implements one of
+			// the marshaler interfaces.
+			if nativeCodec(x) != "" || tset.isProto(x) || tset.automarshals.At(t) != nil || tset.implementsAutoMarshal(x) || tset.hasMarshalBinary(x) {
 				tset.checked.Set(t, true)
 				break
 			}
 
+			// Reject types with only half of a custom marshaler.
+			if err := tset.checkMarshalerPair(x); err != nil {
+				addError(err)
+				tset.checked.Set(t, false)
+				break
+			}
+
+			// A weaver.Page[T], WatchBatch[T], or WatchChange[T] is
+			// serializable if its fields are. Its encoding and decoding
+			// methods are generated for every instantiation.
+			if isWeaverGenericStruct(x) {
+				s := x.Underlying().(*types.Struct)
+				serializable := true
+				for i := 0; i < s.NumFields(); i++ {
+					f := s.Field(i)
+					b := check(f.Type(), path+"."+f.Name(), true)
+					serializable = serializable && b
+				}
+				tset.checked.Set(t, serializable)
+				break
+			}
+
 			// If the underlying type is not a struct, then we simply recurse
 			// on the underlying type.
 			s, ok := x.Underlying().(*types.Struct)
@@ -329,10 +361,10 @@ func (tset *typeSet) checkSerializable(t types.Type) []error {
 			tset.checked.Set(t, serializable)
 
 		case *types.Interface:
-			// TODO(sanjay): Support types.Interface only if we can figure out
-			// a way to instantiate the type.
-			addError(fmt.Errorf("serialization of interfaces not currently supported"))
-			tset.checked.Set(t, false)
+			// An interface value is encoded with a tag identifying its
+			// concrete type. Concrete types are registered at run time with
+			// codegen.RegisterConcrete, so we can't check them here.
+			tset.checked.Set(t, true)
 
 		case *types.Struct:
 			addError(fmt.Errorf("struct literals are not serializable"))
@@ -365,6 +397,10 @@ func (tset *typeSet) checkSerializable(t types.Type) []error {
 			tset.checked.Set(t, check(x.Elem(), path+"[0]", true))
 
 		case *types.Pointer:
+			if nativeCodec(x) != "" {
+				tset.checked.Set(t, true)
+				break
+			}
 			tset.checked.Set(t, check(x.Elem(), "(*"+path+")", true))
 
 		case *types.Map:
@@ -674,6 +710,28 @@ func (tset *typeSet) implementsAutoMarshal(t types.Type) bool {
 	return isWeaverMarshal(t, marshal) && isWeaverUnmarshal(t, unmarshal)
 }
 
+// checkMarshalerPair returns an error if the provided type implements exactly
+// one of the codegen.Marshaler and codegen.Unmarshaler interfaces. Such a type
+// was almost certainly meant to have a custom marshaler, so silently
+// serializing its underlying type instead would be surprising.
+func (tset *typeSet) checkMarshalerPair(t types.Type) error {
+	has := func(name string, valid func(types.Type, *types.Func) bool) bool {
+		obj, _, _ := types.LookupFieldOrMethod(t, true, tset.pkg.Types, name)
+		m, ok := obj.(*types.Func)
+		return ok && valid(t, m)
+	}
+	marshal := has("WeaverMarshal", isWeaverMarshal)
+	unmarshal := has("WeaverUnmarshal", isWeaverUnmarshal)
+	switch {
+	case marshal && !unmarshal:
+		return fmt.Errorf("type has a WeaverMarshal method but no WeaverUnmarshal method. Implement both codegen.Marshaler and codegen.Unmarshaler.")
+	case unmarshal && !marshal:
+		return fmt.Errorf("type has a WeaverUnmarshal method but no WeaverMarshal method. Implement both codegen.Marshaler and codegen.Unmarshaler.")
+	default:
+		return nil
+	}
+}
+
 // isWeaverMarshal returns true if m is WeaverMarshal(*codegen.Encoder).
 func isWeaverMarshal(t types.Type, m *types.Func) bool {
 	if m.Name() != "WeaverMarshal" {
@@ -756,6 +814,38 @@ func isDecoderPtr(t types.Type) bool {
 	return n.Obj().Pkg() != nil && n.Obj().Pkg().Path() == path && n.Obj().Name() == "Decoder"
 }
 
+// nativeCodec returns the name of the codegen.Encoder and codegen.Decoder
+// methods that serialize the provided standard library type (e.g., "Time" for
+// time.Time), or the empty string if the type isn't serialized natively.
+func nativeCodec(t types.Type) string {
+	if p, ok := t.(*types.Pointer); ok {
+		if isNamed(p.Elem(), "math/big", "Int") {
+			return "BigInt"
+		}
+		return ""
+	}
+	switch {
+	case isNamed(t, "time", "Time"):
+		return "Time"
+	case isNamed(t, "net/netip", "Addr"):
+		return "Addr"
+	case isNamed(t, "net/netip", "Prefix"):
+		return "Prefix"
+	default:
+		return ""
+	}
+}
+
+// isNamed returns whether t is the named type pkg.name.
+func isNamed(t types.Type, pkg, name string) bool {
+	n, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := n.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
 // hasMarshalBinary returns whether the provided type is a concrete type that
 // implements the encoding.BinaryMarshaler and binary.BinaryUnmarshaler
 // interfaces.
@@ -889,10 +979,34 @@ func isWeaverAutoMarshal(t types.Type) bool {
 	return isWeaverType(t, "AutoMarshal", 0)
 }
 
+func isWeaverPage(t types.Type) bool {
+	return isWeaverType(t, "Page", 1)
+}
+
+func isWeaverWatchBatch(t types.Type) bool {
+	return isWeaverType(t, "WatchBatch", 1)
+}
+
+// isWeaverGenericStruct returns whether t is an instantiation of one of the
+// generic structs of the weaver package that the code generator serializes.
+// Generic structs can't embed weaver.AutoMarshal, so their encoding and
+// decoding methods are generated for every instantiation.
+func isWeaverGenericStruct(t types.Type) bool {
+	return isWeaverPage(t) || isWeaverWatchBatch(t) || isWeaverType(t, "WatchChange", 1)
+}
+
+func isWeaverPageRequest(t types.Type) bool {
+	return isWeaverType(t, "PageRequest", 0)
+}
+
 func isWeaverNotRetriable(t types.Type) bool {
 	return isWeaverType(t, "NotRetriable", 0)
 }
 
+func isWeaverAtMostOnce(t types.Type) bool {
+	return isWeaverType(t, "AtMostOnce", 0)
+}
+
 func isString(t types.Type) bool {
 	b, ok := t.(*types.Basic)
 	return ok && b.Kind() == types.String
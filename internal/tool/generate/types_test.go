@@ -82,6 +82,14 @@ func (t *target) MarshalBinary() ([]byte, error) { return nil, nil }
 func (t *target) UnmarshalBinary([]byte) error { return nil }
 `, ""},
 
+		{"interface", `
+type target interface{
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary([]byte) error
+}
+`, ""},
+		{"any", "type target []any", ""},
+
 		// Non-serializable types:
 		{"function", "type target func()", "not a serializable type"},
 		{"chan", "type target chan int", "not a serializable type"},
@@ -99,12 +107,7 @@ type target struct{
 }
 func (t *target) UnmarshalBinary([]byte) error { return nil }
 `, "not serializable"},
-		{"interface", `
-type target interface{
-	MarshalBinary() ([]byte, error)
-	UnmarshalBinary([]byte) error
-}
-`, "not currently supported"},
+		{"error", "type target []error", "errors are only serializable"},
 		{"simple recursive", `
 type target *target
 `, "not currently supported"},
@@ -0,0 +1,229 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A stage is a step of a load schedule. During a stage, the load level ramps
+// linearly from the level at the end of the previous stage (or zero, for the
+// first stage) to target. The load level is a request rate, in requests per
+// second, for open loop load and a number of concurrent clients for closed loop
+// load.
+type stage struct {
+	duration time.Duration
+	target   float64
+}
+
+// A schedule is a sequence of stages.
+type schedule []stage
+
+// constant returns a schedule that holds the provided level for d.
+func constant(level float64, d time.Duration) schedule {
+	return schedule{{0, level}, {d, level}}
+}
+
+// parseSchedule parses a schedule of the form "10s:100,1m:100,10s:0", a
+// comma-separated list of stages written as <duration>:<target>.
+func parseSchedule(s string) (schedule, error) {
+	var sched schedule
+	for _, part := range strings.Split(s, ",") {
+		d, t, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return nil, fmt.Errorf("stage %q: want <duration>:<target>", part)
+		}
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return nil, fmt.Errorf("stage %q: %w", part, err)
+		}
+		target, err := strconv.ParseFloat(t, 64)
+		if err != nil {
+			return nil, fmt.Errorf("stage %q: %w", part, err)
+		}
+		if duration < 0 || target < 0 {
+			return nil, fmt.Errorf("stage %q: negative duration or target", part)
+		}
+		sched = append(sched, stage{duration, target})
+	}
+	return sched, nil
+}
+
+// duration returns the total duration of the schedule.
+func (s schedule) duration() time.Duration {
+	var total time.Duration
+	for _, st := range s {
+		total += st.duration
+	}
+	return total
+}
+
+// level returns the load level at time t into the schedule.
+func (s schedule) level(t time.Duration) float64 {
+	from := 0.0
+	for _, st := range s {
+		if t < st.duration {
+			return from + (st.target-from)*float64(t)/float64(st.duration)
+		}
+		t -= st.duration
+		from = st.target
+	}
+	return from
+}
+
+// loadOptions configure the generation of load.
+type loadOptions struct {
+	open     bool          // open loop if true, closed loop otherwise
+	schedule schedule      // load schedule
+	method   string        // HTTP method
+	body     string        // HTTP request body
+	timeout  time.Duration // per-request timeout
+}
+
+// generate sends requests to the provided URLs, in round robin order,
+// following opts, and returns the latencies and errors observed for every URL.
+func generate(ctx context.Context, client *http.Client, urls []string, opts loadOptions) []*recorder {
+	recorders := make([]*recorder, len(urls))
+	for i, url := range urls {
+		recorders[i] = &recorder{name: url}
+	}
+
+	var mu sync.Mutex
+	next := 0
+	send := func(ctx context.Context) {
+		mu.Lock()
+		i := next
+		next = (next + 1) % len(urls)
+		mu.Unlock()
+		if latency, failed, ok := request(ctx, client, urls[i], opts); ok {
+			recorders[i].record(latency, failed)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.schedule.duration())
+	defer cancel()
+	var wg sync.WaitGroup
+	if opts.open {
+		openLoop(ctx, &wg, opts.schedule, send)
+	} else {
+		closedLoop(ctx, &wg, opts.schedule, send)
+	}
+	wg.Wait()
+	return recorders
+}
+
+// openLoop calls send at the rate dictated by sched, without waiting for
+// previous calls to finish, until ctx is done.
+func openLoop(ctx context.Context, wg *sync.WaitGroup, sched schedule, send func(context.Context)) {
+	start := time.Now()
+	for {
+		// Wait until the next request is due. If the rate is zero, check
+		// again in a bit.
+		wait := 10 * time.Millisecond
+		if rate := sched.level(time.Since(start)); rate > 0 {
+			wait = time.Duration(float64(time.Second) / rate)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		if sched.level(time.Since(start)) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(ctx)
+		}()
+	}
+}
+
+// closedLoop runs the number of clients dictated by sched, each of which
+// repeatedly calls send, until ctx is done.
+func closedLoop(ctx context.Context, wg *sync.WaitGroup, sched schedule, send func(context.Context)) {
+	start := time.Now()
+	var stops []chan struct{}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		// Adjust the number of clients.
+		n := int(math.Round(sched.level(time.Since(start))))
+		for len(stops) < n {
+			stop := make(chan struct{})
+			stops = append(stops, stop)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-stop:
+						return
+					default:
+						send(ctx)
+					}
+				}
+			}()
+		}
+		for len(stops) > n {
+			close(stops[len(stops)-1])
+			stops = stops[:len(stops)-1]
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// request sends a single request and returns its latency and whether it
+// failed. It returns false if the request was cut short because ctx is done,
+// in which case the request shouldn't be recorded.
+func request(ctx context.Context, client *http.Client, url string, opts loadOptions) (time.Duration, bool, bool) {
+	reqCtx, cancel := context.WithTimeout(ctx, opts.timeout)
+	defer cancel()
+	var body io.Reader
+	if opts.body != "" {
+		body = strings.NewReader(opts.body)
+	}
+	req, err := http.NewRequestWithContext(reqCtx, opts.method, url, body)
+	if err != nil {
+		return 0, true, true
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	latency := time.Since(start)
+	if ctx.Err() != nil {
+		return 0, false, false
+	}
+	failed := err != nil || resp.StatusCode >= 400
+	return latency, failed, true
+}
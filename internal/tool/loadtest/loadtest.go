@@ -0,0 +1,251 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadtest implements the "weaver loadtest" subcommands, which send
+// load to deployed Service Weaver applications and report the latencies and
+// error rates they observe.
+package loadtest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+)
+
+var (
+	runFlags       = flag.NewFlagSet("run", flag.ContinueOnError)
+	runMode        = runFlags.String("mode", "closed", `Load mode: "open" sends requests at --rate, "closed" runs --concurrency clients`)
+	runRate        = runFlags.Float64("rate", 10, "Requests per second, in open mode")
+	runConcurrency = runFlags.Int("concurrency", 1, "Number of concurrent clients, in closed mode")
+	runDuration    = runFlags.Duration("duration", 30*time.Second, "Duration of the test, if --ramp is not set")
+	runRamp        = runFlags.String("ramp", "", `Load schedule, like "10s:100,1m:100,10s:0"; overrides --rate, --concurrency, and --duration`)
+	runMethod      = runFlags.String("method", http.MethodGet, "HTTP method of the requests")
+	runBody        = runFlags.String("body", "", "HTTP body of the requests")
+	runTimeout     = runFlags.Duration("timeout", 10*time.Second, "Per-request timeout")
+	runMetrics     = runFlags.String("metrics", "", "URL of the deployment's Prometheus metrics, used to report per-component latencies")
+	runOut         = runFlags.String("out", "", "If non-empty, the file to which the report is written as JSON")
+
+	// Commands holds the "weaver loadtest" subcommands.
+	Commands = map[string]*tool.Command{
+		"run":     runCommand(),
+		"compare": compareCommand(),
+	}
+)
+
+func runCommand() *tool.Command {
+	const help = `Usage:
+  weaver loadtest run [options] <url>...
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  'weaver loadtest run' sends HTTP requests to the provided URLs, typically
+  served by the listeners of a deployed application, in round robin order. It
+  reports the throughput, error rate, and latency distribution observed for
+  every URL. A response with a status code of 400 or above is an error.
+
+  In closed mode, --concurrency clients send requests back to back. In open
+  mode, requests are sent at --rate requests per second, regardless of how
+  long previous requests take. --ramp replaces the constant load level with a
+  schedule of stages, written as <duration>:<target>, during which the load
+  level (a rate in open mode, a number of clients in closed mode) ramps
+  linearly from the previous target to the stage's target.
+
+  If --metrics is set to the Prometheus endpoint of the deployment's status
+  server (e.g., "/debug/serviceweaver/prometheus"), the report also includes
+  the number of calls, errors, and the latency distribution of every component
+  method during the test, estimated from the deployment's metrics.
+
+  With --out, the report is also written as JSON, which can be compared with
+  other reports using 'weaver loadtest compare'.
+
+Examples:
+  # Run 8 clients for a minute.
+  weaver loadtest run --concurrency=8 --duration=1m http://localhost:9000/
+
+  # Ramp up to 200 requests per second, hold, and ramp down.
+  weaver loadtest run --mode=open --ramp=30s:200,2m:200,30s:0 \
+    --metrics=http://127.0.0.1:43087/debug/serviceweaver/prometheus \
+    --out=report.json http://localhost:9000/`
+	var b strings.Builder
+	t := template.Must(template.New("run").Parse(help))
+	content := struct{ Flags string }{tool.FlagsHelp(runFlags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "run",
+		Description: "Send load to an application and report latencies",
+		Help:        b.String(),
+		Flags:       runFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) == 0 {
+				return fmt.Errorf("usage: weaver loadtest run [options] <url>...")
+			}
+			opts := loadOptions{method: *runMethod, body: *runBody, timeout: *runTimeout}
+			switch *runMode {
+			case "open":
+				opts.open = true
+				opts.schedule = constant(*runRate, *runDuration)
+			case "closed":
+				opts.schedule = constant(float64(*runConcurrency), *runDuration)
+			default:
+				return fmt.Errorf("invalid --mode %q; want open or closed", *runMode)
+			}
+			if *runRamp != "" {
+				sched, err := parseSchedule(*runRamp)
+				if err != nil {
+					return fmt.Errorf("invalid --ramp: %w", err)
+				}
+				opts.schedule = sched
+			}
+			if opts.schedule.duration() <= 0 {
+				return fmt.Errorf("empty load schedule")
+			}
+			return run(ctx, args, opts)
+		},
+	}
+}
+
+// run runs a load test and prints its report.
+func run(ctx context.Context, urls []string, opts loadOptions) error {
+	client := &http.Client{}
+	var before map[methodKey]*methodMetrics
+	if *runMetrics != "" {
+		var err error
+		if before, err = fetchMethodMetrics(ctx, client, *runMetrics); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	recorders := generate(ctx, client, urls, opts)
+	duration := time.Since(start)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	r := &report{Start: start, Duration: duration, Mode: "closed"}
+	if opts.open {
+		r.Mode = "open"
+	}
+	for _, rec := range recorders {
+		r.Targets = append(r.Targets, rec.stats(duration))
+	}
+	if *runMetrics != "" {
+		after, err := fetchMethodMetrics(ctx, client, *runMetrics)
+		if err != nil {
+			return err
+		}
+		r.Components = componentStats(before, after, duration)
+	}
+
+	formatReport(os.Stdout, r)
+	if *runOut != "" {
+		bytes, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(*runOut, bytes, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchMethodMetrics fetches the method metrics served at url.
+func fetchMethodMetrics(ctx context.Context, client *http.Client, url string) (map[methodKey]*methodMetrics, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch metrics: unexpected status %s", resp.Status)
+	}
+	return readMethodMetrics(resp.Body)
+}
+
+func compareCommand() *tool.Command {
+	return &tool.Command{
+		Name:        "compare",
+		Description: "Compare two load test reports",
+		Help: `Usage:
+  weaver loadtest compare <baseline.json> <report.json>
+
+Flags:
+  -h, --help	Print this help message.
+
+Description:
+  'weaver loadtest compare' compares two reports written by
+  'weaver loadtest run --out', printing the change in throughput, error rate,
+  and median and 99th percentile latency of every target and component
+  method.`,
+		Flags: flag.NewFlagSet("compare", flag.ContinueOnError),
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return fmt.Errorf("usage: weaver loadtest compare <baseline.json> <report.json>")
+			}
+			base, err := readReport(args[0])
+			if err != nil {
+				return err
+			}
+			next, err := readReport(args[1])
+			if err != nil {
+				return err
+			}
+			formatComparison(os.Stdout, base, next)
+			return nil
+		},
+	}
+}
+
+// readReport reads a JSON report from the provided file.
+func readReport(filename string) (*report, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return decodeReport(f)
+}
+
+// decodeReport decodes a JSON report.
+func decodeReport(r io.Reader) (*report, error) {
+	var rep report
+	if err := json.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("decode report: %w", err)
+	}
+	if rep.Mode == "" {
+		return nil, errors.New("decode report: not a load test report")
+	}
+	return &rep, nil
+}
@@ -0,0 +1,229 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/prometheus"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseSchedule(t *testing.T) {
+	got, err := parseSchedule("10s:100, 1m:100,10s:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := schedule{{10 * time.Second, 100}, {time.Minute, 100}, {10 * time.Second, 0}}
+	if diff := cmp.Diff(want, got, cmp.AllowUnexported(stage{})); diff != "" {
+		t.Fatalf("parseSchedule (-want +got):\n%s", diff)
+	}
+	if got, want := got.duration(), 80*time.Second; got != want {
+		t.Errorf("duration: got %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"", "10s", "10s:x", "x:10", "-1s:10", "1s:-10"} {
+		if _, err := parseSchedule(bad); err == nil {
+			t.Errorf("parseSchedule(%q): unexpected success", bad)
+		}
+	}
+}
+
+func TestScheduleLevel(t *testing.T) {
+	sched := schedule{{10 * time.Second, 100}, {time.Minute, 100}, {10 * time.Second, 0}}
+	for _, test := range []struct {
+		t    time.Duration
+		want float64
+	}{
+		{0, 0},
+		{5 * time.Second, 50},
+		{10 * time.Second, 100},
+		{40 * time.Second, 100},
+		{75 * time.Second, 50},
+		{80 * time.Second, 0},
+		{time.Hour, 0},
+	} {
+		if got := sched.level(test.t); got != test.want {
+			t.Errorf("level(%v): got %v, want %v", test.t, got, test.want)
+		}
+	}
+	if got, want := constant(7, time.Second).level(0), 7.0; got != want {
+		t.Errorf("constant level: got %v, want %v", got, want)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ok.Close()
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "oops", http.StatusInternalServerError)
+	}))
+	defer bad.Close()
+
+	for _, open := range []bool{true, false} {
+		opts := loadOptions{
+			open:    open,
+			method:  http.MethodGet,
+			timeout: time.Second,
+		}
+		if open {
+			opts.schedule = constant(200, 500*time.Millisecond)
+		} else {
+			opts.schedule = constant(2, 500*time.Millisecond)
+		}
+		recorders := generate(context.Background(), ok.Client(), []string{ok.URL, bad.URL}, opts)
+		okStats := recorders[0].stats(time.Second)
+		badStats := recorders[1].stats(time.Second)
+		if okStats.Requests == 0 || badStats.Requests == 0 {
+			t.Fatalf("open=%v: no requests recorded: %d, %d", open, okStats.Requests, badStats.Requests)
+		}
+		if okStats.Errors != 0 {
+			t.Errorf("open=%v: got %d errors, want 0", open, okStats.Errors)
+		}
+		if badStats.Errors != badStats.Requests {
+			t.Errorf("open=%v: got %d errors, want %d", open, badStats.Errors, badStats.Requests)
+		}
+		var total int64
+		for _, b := range okStats.Histogram {
+			total += b.Count
+		}
+		if total != okStats.Requests {
+			t.Errorf("open=%v: histogram holds %d requests, want %d", open, total, okStats.Requests)
+		}
+	}
+}
+
+func TestRecorderStats(t *testing.T) {
+	r := &recorder{name: "target"}
+	for i := 1; i <= 100; i++ {
+		r.record(time.Duration(i)*time.Millisecond, i%10 == 0)
+	}
+	got := r.stats(10 * time.Second)
+	if got.Requests != 100 || got.Errors != 10 || got.Throughput != 10 {
+		t.Errorf("got %d requests, %d errors, %v/s; want 100, 10, 10/s", got.Requests, got.Errors, got.Throughput)
+	}
+	if got.P50 != 50*time.Millisecond || got.P90 != 90*time.Millisecond || got.P99 != 99*time.Millisecond {
+		t.Errorf("got percentiles %v, %v, %v; want 50ms, 90ms, 99ms", got.P50, got.P90, got.P99)
+	}
+	if got.Mean != 50500*time.Microsecond {
+		t.Errorf("got mean %v, want 50.5ms", got.Mean)
+	}
+}
+
+// methodMetricsText returns the metrics text recorded for n calls to method M
+// of component C, errors of which failed, with latencies in the provided
+// histogram buckets.
+func methodMetricsText(n, errors float64, sum float64, counts []uint64) string {
+	labels := map[string]string{"caller": "main", "component": "C", "method": "M", "remote": "true"}
+	ms := []*metrics.MetricSnapshot{
+		{Name: imetrics.MethodCountsName, Type: protos.MetricType_COUNTER, Labels: labels, Value: n},
+		{Name: imetrics.MethodErrorsName, Type: protos.MetricType_COUNTER, Labels: labels, Value: errors},
+		{
+			Name:   imetrics.MethodLatenciesName,
+			Type:   protos.MetricType_HISTOGRAM,
+			Labels: labels,
+			Value:  sum,
+			Bounds: []float64{1000, 10000},
+			Counts: counts,
+		},
+	}
+	var b bytes.Buffer
+	prometheus.TranslateMetricsToPrometheusTextFormat(&b, ms, "localhost", "/metrics")
+	return b.String()
+}
+
+func TestComponentStats(t *testing.T) {
+	before, err := readMethodMetrics(strings.NewReader(methodMetricsText(10, 1, 5000, []uint64{10, 0, 0})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := readMethodMetrics(strings.NewReader(methodMetricsText(110, 6, 505000, []uint64{50, 40, 20})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := componentStats(before, after, 10*time.Second)
+	want := []stats{{
+		Name:       "C.M",
+		Requests:   100,
+		Errors:     5,
+		Throughput: 10,
+		Mean:       5 * time.Millisecond,
+		P50:        10 * time.Millisecond,
+		P90:        10 * time.Millisecond,
+		P99:        10 * time.Millisecond,
+		Histogram: []bucket{
+			{time.Millisecond, 40},
+			{10 * time.Millisecond, 40},
+			{0, 20},
+		},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("componentStats (-want +got):\n%s", diff)
+	}
+}
+
+func TestFormatReport(t *testing.T) {
+	r := &report{
+		Mode:       "closed",
+		Targets:    []stats{{Name: "http://localhost:9000/", Requests: 1234, Errors: 12, Throughput: 41.1, P99: 4200 * time.Microsecond}},
+		Components: []stats{{Name: "C.M", Requests: 2468, Throughput: 82.2}},
+	}
+	var b bytes.Buffer
+	formatReport(&b, r)
+	for _, want := range []string{"TARGETS", "http://localhost:9000/", "1234", "0.97%", "41.1/s", "4.2ms", "COMPONENTS", "C.M", "2468"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("report does not contain %q:\n%s", want, b.String())
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := &report{Mode: "closed", Targets: []stats{{Name: "/a", Requests: 100, Throughput: 10, P50: time.Millisecond, P99: 4 * time.Millisecond}}}
+	next := &report{Mode: "closed", Targets: []stats{{Name: "/a", Requests: 100, Errors: 1, Throughput: 12, P50: 2 * time.Millisecond, P99: 4 * time.Millisecond}}}
+
+	// Round trip the reports through JSON, like "weaver loadtest run --out".
+	roundTrip := func(r *report) *report {
+		bytes, err := json.Marshal(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := decodeReport(strings.NewReader(string(bytes)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	var b bytes.Buffer
+	formatComparison(&b, roundTrip(base), roundTrip(next))
+	for _, want := range []string{"/a", "+20.0%", "0.00% → 1.00%", "1ms → 2ms (+100.0%)", "+0.0%"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("comparison does not contain %q:\n%s", want, b.String())
+		}
+	}
+
+	if _, err := decodeReport(strings.NewReader(`{}`)); err == nil {
+		t.Error("decodeReport of an empty object: unexpected success")
+	}
+}
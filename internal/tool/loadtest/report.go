@@ -0,0 +1,324 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/colors"
+)
+
+// A report summarizes a load test run. Reports are written and read as JSON,
+// so that runs can be compared.
+type report struct {
+	Start      time.Time
+	Duration   time.Duration
+	Mode       string  // "open" or "closed"
+	Targets    []stats // one per target URL
+	Components []stats // one per component method, if metrics were provided
+}
+
+// stats summarizes the requests sent to a target, or the calls to a component
+// method.
+type stats struct {
+	Name       string        // target URL, or <component>.<method>
+	Requests   int64         // number of requests or calls
+	Errors     int64         // number of failed requests or calls
+	Throughput float64       // requests or calls per second
+	Mean       time.Duration // mean latency
+	P50        time.Duration // median latency
+	P90        time.Duration // 90th percentile latency
+	P99        time.Duration // 99th percentile latency
+	Histogram  []bucket      // latency histogram
+}
+
+// A bucket is a histogram bucket. It counts the latencies larger than the
+// upper bound of the previous bucket and no larger than its own. The upper
+// bound of the last bucket is infinite, and is represented as zero.
+type bucket struct {
+	UpperBound time.Duration
+	Count      int64
+}
+
+// bounds are the upper bounds of the buckets of target latency histograms.
+var bounds = []time.Duration{
+	100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond,
+	10 * time.Millisecond, 25 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 250 * time.Millisecond, 500 * time.Millisecond,
+	time.Second, 2500 * time.Millisecond, 5 * time.Second, 10 * time.Second,
+}
+
+// A recorder records the latencies and errors of the requests sent to a
+// target. It is safe for concurrent use by multiple goroutines.
+type recorder struct {
+	name string
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	errors    int64
+}
+
+// record records a request.
+func (r *recorder) record(latency time.Duration, failed bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies = append(r.latencies, latency)
+	if failed {
+		r.errors++
+	}
+}
+
+// stats returns the stats of the requests recorded during a run of duration d.
+func (r *recorder) stats(d time.Duration) stats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := stats{Name: r.name, Requests: int64(len(r.latencies)), Errors: r.errors}
+	if len(r.latencies) == 0 {
+		return s
+	}
+	sorted := append([]time.Duration(nil), r.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, l := range sorted {
+		total += l
+	}
+	s.Throughput = float64(len(sorted)) / d.Seconds()
+	s.Mean = total / time.Duration(len(sorted))
+	s.P50 = imetrics.NearestRank(sorted, 0.5)
+	s.P90 = imetrics.NearestRank(sorted, 0.9)
+	s.P99 = imetrics.NearestRank(sorted, 0.99)
+
+	s.Histogram = make([]bucket, len(bounds)+1)
+	for i, bound := range bounds {
+		s.Histogram[i].UpperBound = bound
+	}
+	for _, l := range sorted {
+		i := sort.Search(len(bounds), func(i int) bool { return l <= bounds[i] })
+		s.Histogram[i].Count++
+	}
+	return s
+}
+
+// methodKey identifies a component method.
+type methodKey struct {
+	component string
+	method    string
+}
+
+// methodMetrics holds the cumulative metrics of a component method,
+// aggregated across callers and processes.
+type methodMetrics struct {
+	calls   float64
+	errors  float64
+	sum     float64             // sum of latencies, in microseconds
+	buckets map[float64]float64 // cumulative bucket counts, by upper bound in microseconds
+}
+
+// readMethodMetrics reads the method metrics in r, which holds metrics in the
+// Prometheus text format.
+func readMethodMetrics(r io.Reader) (map[methodKey]*methodMetrics, error) {
+	samples, err := imetrics.ParseSamples(r)
+	if err != nil {
+		return nil, err
+	}
+	ms := map[methodKey]*methodMetrics{}
+	for _, sample := range samples {
+		key := methodKey{sample.Labels["component"], sample.Labels["method"]}
+		if key.component == "" || imetrics.IsSystem(key.component) {
+			continue
+		}
+		m, ok := ms[key]
+		if !ok {
+			m = &methodMetrics{buckets: map[float64]float64{}}
+			ms[key] = m
+		}
+		switch sample.Name {
+		case imetrics.MethodCountsName:
+			m.calls += sample.Value
+		case imetrics.MethodErrorsName:
+			m.errors += sample.Value
+		case imetrics.MethodLatenciesName + "_sum":
+			m.sum += sample.Value
+		case imetrics.MethodLatenciesName + "_bucket":
+			le, err := strconv.ParseFloat(sample.Labels["le"], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bad bucket bound %q: %w", sample.Labels["le"], err)
+			}
+			m.buckets[le] += sample.Value
+		}
+	}
+	return ms, nil
+}
+
+// componentStats returns the stats of the component method calls made between
+// the provided snapshots of method metrics, taken d apart.
+func componentStats(before, after map[methodKey]*methodMetrics, d time.Duration) []stats {
+	var result []stats
+	for key, a := range after {
+		b, ok := before[key]
+		if !ok {
+			b = &methodMetrics{buckets: map[float64]float64{}}
+		}
+		calls := a.calls - b.calls
+		if calls <= 0 {
+			continue
+		}
+		s := stats{
+			Name:       key.component + "." + key.method,
+			Requests:   int64(calls),
+			Errors:     int64(a.errors - b.errors),
+			Throughput: calls / d.Seconds(),
+			Mean:       micros((a.sum - b.sum) / calls),
+		}
+
+		// Compute the histogram and estimate percentiles from it. The
+		// estimate of a percentile is the upper bound of the bucket that
+		// holds it.
+		var les []float64
+		for le := range a.buckets {
+			les = append(les, le)
+		}
+		sort.Float64s(les)
+		prev := 0.0
+		for _, le := range les {
+			cumulative := a.buckets[le] - b.buckets[le]
+			var upper time.Duration
+			if !math.IsInf(le, +1) {
+				upper = micros(le)
+			}
+			s.Histogram = append(s.Histogram, bucket{upper, int64(cumulative - prev)})
+			prev = cumulative
+		}
+		percentile := func(p float64) time.Duration {
+			rank := math.Ceil(p * calls)
+			for i, le := range les {
+				if a.buckets[le]-b.buckets[le] >= rank {
+					if math.IsInf(le, +1) && i > 0 {
+						return micros(les[i-1])
+					}
+					return micros(le)
+				}
+			}
+			return 0
+		}
+		s.P50, s.P90, s.P99 = percentile(0.5), percentile(0.9), percentile(0.99)
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// micros returns the duration of x microseconds.
+func micros(x float64) time.Duration {
+	return time.Duration(x * float64(time.Microsecond))
+}
+
+// errorRate returns the percentage of failed requests.
+func (s stats) errorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return 100 * float64(s.Errors) / float64(s.Requests)
+}
+
+// formatReport pretty-prints a report.
+func formatReport(w io.Writer, r *report) {
+	format := func(title string, ss []stats) {
+		t := colors.NewTabularizer(w, []colors.Text{{{S: title, Bold: true}}}, colors.NoDim)
+		defer t.Flush()
+		t.Row("NAME", "REQUESTS", "ERRORS", "THROUGHPUT", "MEAN", "P50", "P90", "P99")
+		for _, s := range ss {
+			t.Row(s.Name, fmt.Sprint(s.Requests), fmt.Sprintf("%.2f%%", s.errorRate()), fmt.Sprintf("%.1f/s", s.Throughput),
+				round(s.Mean), round(s.P50), round(s.P90), round(s.P99))
+		}
+	}
+	format("TARGETS", r.Targets)
+	if len(r.Components) > 0 {
+		format("COMPONENTS", r.Components)
+	}
+}
+
+// formatComparison pretty-prints a comparison of a baseline report and a new
+// report.
+func formatComparison(w io.Writer, base, next *report) {
+	format := func(title string, base, next []stats) {
+		byName := map[string][2]*stats{}
+		var names []string
+		for i := range base {
+			pair := byName[base[i].Name]
+			pair[0] = &base[i]
+			byName[base[i].Name] = pair
+			names = append(names, base[i].Name)
+		}
+		for i := range next {
+			pair, ok := byName[next[i].Name]
+			pair[1] = &next[i]
+			byName[next[i].Name] = pair
+			if !ok {
+				names = append(names, next[i].Name)
+			}
+		}
+		sort.Strings(names)
+
+		t := colors.NewTabularizer(w, []colors.Text{{{S: title, Bold: true}}}, colors.NoDim)
+		defer t.Flush()
+		t.Row("NAME", "THROUGHPUT", "ERRORS", "P50", "P99")
+		for _, name := range names {
+			b, n := byName[name][0], byName[name][1]
+			if b == nil || n == nil {
+				t.Row(name, "missing in a report", "", "", "")
+				continue
+			}
+			t.Row(name,
+				fmt.Sprintf("%.1f/s → %.1f/s (%s)", b.Throughput, n.Throughput, change(b.Throughput, n.Throughput)),
+				fmt.Sprintf("%.2f%% → %.2f%%", b.errorRate(), n.errorRate()),
+				fmt.Sprintf("%v → %v (%s)", round(b.P50), round(n.P50), change(float64(b.P50), float64(n.P50))),
+				fmt.Sprintf("%v → %v (%s)", round(b.P99), round(n.P99), change(float64(b.P99), float64(n.P99))),
+			)
+		}
+	}
+	format("TARGETS", base.Targets, next.Targets)
+	if len(base.Components) > 0 || len(next.Components) > 0 {
+		format("COMPONENTS", base.Components, next.Components)
+	}
+}
+
+// change returns the relative change from a to b, as a percentage.
+func change(a, b float64) string {
+	if a == 0 {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", 100*(b-a)/a)
+}
+
+// round rounds d to a readable precision.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	default:
+		return d.Round(time.Microsecond)
+	}
+}
@@ -23,13 +23,18 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 
+	"github.com/ServiceWeaver/weaver/internal/history"
+	"github.com/ServiceWeaver/weaver/internal/lease"
+	"github.com/ServiceWeaver/weaver/internal/slo"
 	"github.com/ServiceWeaver/weaver/internal/status"
 	itool "github.com/ServiceWeaver/weaver/internal/tool"
 	"github.com/ServiceWeaver/weaver/internal/tool/config"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/bin"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/ServiceWeaver/weaver/runtime/retry"
 	"github.com/ServiceWeaver/weaver/runtime/tool"
 	"github.com/ServiceWeaver/weaver/runtime/version"
@@ -41,13 +46,19 @@ const (
 	shortConfigKey = "multi"
 )
 
-var deployCmd = tool.Command{
-	Name:        "deploy",
-	Description: "Deploy a Service Weaver app",
-	Help:        "Usage:\n  weaver multi deploy <configfile>",
-	Flags:       flag.NewFlagSet("deploy", flag.ContinueOnError),
-	Fn:          deploy,
-}
+var (
+	deployFlags  = flag.NewFlagSet("deploy", flag.ContinueOnError)
+	deployForce  = deployFlags.Bool("force", false, "Deploy even if the error budget of a service level objective is exhausted")
+	deployDetach = deployFlags.Bool("detach", false, "Run the deployment in the background, detached from the terminal")
+
+	deployCmd = tool.Command{
+		Name:        "deploy",
+		Description: "Deploy a Service Weaver app",
+		Help:        "Usage:\n  weaver multi deploy [--force] [--detach] <configfile>",
+		Flags:       deployFlags,
+		Fn:          deploy,
+	}
+)
 
 // deploy deploys an application on the local machine using a multiprocess
 // deployer. Note that each component is deployed as a separate OS process.
@@ -84,24 +95,73 @@ func deploy(ctx context.Context, args []string) error {
 	multiConfig.App = appConfig
 
 	// Check version compatibility.
+	if err := checkVersion(appConfig); err != nil {
+		return err
+	}
+
+	// Refuse to deploy while the error budgets of the app's service level
+	// objectives are exhausted.
+	if !*deployForce {
+		if err := slo.CheckDeploy(ctx, appConfig, sloFile); err != nil {
+			return fmt.Errorf("%w\nPass --force to deploy anyway", err)
+		}
+	}
+
+	deploymentId := uuid.New().String()
+	if id := os.Getenv(deploymentIdKey); id != "" {
+		// We are the detached process of a 'weaver multi deploy --detach'.
+		deploymentId = id
+	}
+	if *deployDetach {
+		var flags []string
+		if *deployForce {
+			flags = append(flags, "--force")
+		}
+		return detach(ctx, deploymentId, flags, configFile)
+	}
+
+	// Lease the deployment, so that standby managers can take it over if
+	// this one dies.
+	leases, err := lease.OpenDB(ctx, leaseFile)
+	if err != nil {
+		return fmt.Errorf("open lease database: %w", err)
+	}
+	defer leases.Close()
+	owner := uuid.New().String()
+	state, err := newManagerState(multiConfig)
+	if err != nil {
+		return err
+	}
+	if err := leases.Create(ctx, deploymentId, owner, managerLease, state.encode()); err != nil {
+		return err
+	}
+	return run(ctx, deploymentId, multiConfig, leases, owner, state, "")
+}
+
+// checkVersion checks that the app's binary was built with a version of the
+// weaver module compatible with the 'weaver multi' binary.
+func checkVersion(appConfig *protos.AppConfig) error {
 	versions, err := bin.ReadVersions(appConfig.Binary)
 	if err != nil {
 		return fmt.Errorf("read versions: %w", err)
 	}
-	if versions.DeployerVersion != version.DeployerVersion {
-		// Try to relativize the binary, defaulting to the absolute path if
-		// there are any errors..
-		binary := appConfig.Binary
-		if cwd, err := os.Getwd(); err == nil {
-			if rel, err := filepath.Rel(cwd, appConfig.Binary); err == nil {
-				binary = rel
-			}
-		}
-		selfVersion, err := itool.SelfVersion()
-		if err != nil {
-			return fmt.Errorf("read self version: %w", err)
+	if versions.DeployerVersion == version.DeployerVersion {
+		return nil
+	}
+
+	// Try to relativize the binary, defaulting to the absolute path if
+	// there are any errors..
+	binary := appConfig.Binary
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, appConfig.Binary); err == nil {
+			binary = rel
 		}
-		return fmt.Errorf(`
+	}
+	selfVersion, err := itool.SelfVersion()
+	if err != nil {
+		return fmt.Errorf("read self version: %w", err)
+	}
+	return fmt.Errorf(`
 ERROR: The binary you're trying to deploy (%q) was built with
 github.com/ServiceWeaver/weaver module version %s. However, the 'weaver
 multi' binary you're using was built with weaver module version %s.
@@ -115,8 +175,27 @@ updating the 'weaver multi' command by running the following.
 
 Then, re-build your code and re-run 'weaver multi deploy'. If the problem
 persists, please file an issue at https://github.com/ServiceWeaver/weaver/issues.`,
-			binary, versions.ModuleVersion, selfVersion)
+		binary, versions.ModuleVersion, selfVersion)
+}
+
+// run runs the provided deployment while holding its lease, until the
+// deployment stops or the lease is lost. resumed describes the manager the
+// deployment was taken over from, if any.
+func run(ctx context.Context, deploymentId string, multiConfig *MultiConfig, leases *lease.DB, owner string, state *managerState, resumed string) error {
+	appConfig := multiConfig.App
+
+	// Release the lease, and thus stop standby managers, when the deployment
+	// stops. A manager that lost its lease leaves the deployment to the
+	// manager that took it over.
+	var releaseOnce sync.Once
+	held := true
+	release := func() {
+		releaseOnce.Do(func() {
+			held = leases.Release(context.Background(), deploymentId, owner) == nil
+		})
 	}
+	defer release()
+	runtime.OnExitSignal(release)
 
 	// Make temporary directory.
 	tmpDir, err := runtime.NewTempDir()
@@ -125,13 +204,28 @@ persists, please file an issue at https://github.com/ServiceWeaver/weaver/issues
 	}
 	defer os.RemoveAll(tmpDir)
 	runtime.OnExitSignal(func() { os.RemoveAll(tmpDir) })
+	state.TmpDir = tmpDir
 
 	// Create the deployer.
-	deploymentId := uuid.New().String()
 	d, err := newDeployer(ctx, deploymentId, multiConfig, tmpDir)
 	if err != nil {
 		return fmt.Errorf("create deployer: %w", err)
 	}
+	if resumed != "" {
+		d.history.Deployment(history.DeploymentResumed, resumed)
+	} else {
+		d.history.Deployment(history.DeploymentStarted, appConfig.Binary)
+	}
+	var once sync.Once
+	stopped := func(detail string) {
+		once.Do(func() { d.history.Deployment(history.DeploymentStopped, detail) })
+	}
+	runtime.OnExitSignal(func() { stopped("interrupted") })
+	runtime.OnExitSignal(func() { d.shutdown(shutdownTimeout) })
+	d.running.Go(func() error {
+		d.holdLease(leases, owner, state)
+		return nil
+	})
 
 	// Run a status server.
 	lis, err := net.Listen("tcp", "localhost:0")
@@ -175,11 +269,21 @@ persists, please file an issue at https://github.com/ServiceWeaver/weaver/issues
 	if err := registry.Register(ctx, reg); err != nil {
 		return fmt.Errorf("register deployment: %w", err)
 	}
-	unregister := func() { registry.Unregister(ctx, deploymentId) }
+	unregister := func() {
+		release()
+		if held {
+			// The manager that took over the deployment registered it.
+			registry.Unregister(ctx, deploymentId)
+		}
+	}
 	defer unregister()
 	runtime.OnExitSignal(unregister)
 
-	return d.wait()
+	err = d.wait()
+	if !errors.Is(err, lease.ErrLost) {
+		stopped(fmt.Sprint(err))
+	}
+	return err
 }
 
 // defaultRegistry returns a registry in defaultRegistryDir().
@@ -15,6 +15,7 @@
 package multi
 
 import (
+	"cmp"
 	"context"
 	"crypto"
 	"crypto/x509"
@@ -24,16 +25,25 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/alerts"
+	"github.com/ServiceWeaver/weaver/internal/history"
+	"github.com/ServiceWeaver/weaver/internal/isolation"
+	"github.com/ServiceWeaver/weaver/internal/limits"
 	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
 	"github.com/ServiceWeaver/weaver/internal/proxy"
 	"github.com/ServiceWeaver/weaver/internal/routing"
+	"github.com/ServiceWeaver/weaver/internal/slo"
+	"github.com/ServiceWeaver/weaver/internal/standby"
 	"github.com/ServiceWeaver/weaver/internal/status"
 	"github.com/ServiceWeaver/weaver/internal/tool/certs"
+	"github.com/ServiceWeaver/weaver/internal/tuning"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/bin"
 	"github.com/ServiceWeaver/weaver/runtime/colors"
@@ -41,8 +51,11 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/graph"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
 	"github.com/ServiceWeaver/weaver/runtime/profiling"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"github.com/ServiceWeaver/weaver/runtime/topology"
 	"github.com/ServiceWeaver/weaver/runtime/traces"
 	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
@@ -53,6 +66,25 @@ import (
 // The default number of times a component is replicated.
 const defaultReplication = 2
 
+// readinessTimeout is how long the deployer waits for a replica's components
+// to become ready before reporting that the replica is not ready. The deployer
+// keeps waiting afterwards, and doesn't route traffic to the replica until it
+// is ready.
+const readinessTimeout = time.Minute
+
+// standbyCheckInterval is how often the deployer checks the health of standby
+// replicas.
+const standbyCheckInterval = time.Second
+
+// shutdownTimeout bounds how long the deployer waits for its weavelets to exit
+// when it is interrupted.
+const shutdownTimeout = 5 * time.Second
+
+// routingLease is the lease of the routing info the deployer sends to
+// weavelets. The deployer renews the lease every third of it, and weavelets
+// keep routing with the routing info whose lease expired, but mark it stale.
+const routingLease = 10 * time.Second
+
 // A deployer manages an application deployment.
 type deployer struct {
 	ctx          context.Context
@@ -68,28 +100,45 @@ type deployer struct {
 	logsDB       *logging.FileStore
 	printer      *logging.PrettyPrinter
 	traceDB      *traces.DB
+	history      *history.Recorder
 
 	// statsProcessor tracks and computes stats to be rendered on the /statusz page.
 	statsProcessor *imetrics.StatsProcessor
 
-	mu      sync.Mutex            // guards the following
-	err     error                 // error that stopped the babysitter
-	groups  map[string]*group     // groups, by component name
-	proxies map[string]*proxyInfo // proxies, by listener name
+	// topology computes the live component graph from collected metrics.
+	topology *topology.Tracker
+
+	mu          sync.Mutex            // guards the following
+	err         error                 // error that stopped the babysitter
+	groups      map[string]*group     // groups, by component name
+	proxies     map[string]*proxyInfo // proxies, by listener name
+	limitEvents []*status.LimitEvent  // replicas killed for exceeding limits
 }
 
 // A group contains information about a co-location group.
 type group struct {
-	name        string                          // group name
-	envelopes   []*envelope.Envelope            // envelopes, one per weavelet
-	replicas    []*status.Replica               // stores replica info such as pid, weavelet id
-	started     map[string]bool                 // started components
-	addresses   map[string]bool                 // weavelet addresses
-	assignments map[string]*protos.Assignment   // assignment, by component
-	subscribers map[string][]*envelope.Envelope // routing info subscribers, by component
-	callable    []string                        // callable components for group
-	certPEM     []byte                          // group certificate
-	keyPEM      []byte                          // group private key
+	name        string                        // group name
+	envelopes   []*envelope.Envelope          // envelopes, one per weavelet
+	handlers    []*handler                    // handlers, one per weavelet
+	replicas    []*status.Replica             // stores replica info such as pid, weavelet id
+	started     map[string]bool               // started components
+	addresses   map[string]bool               // weavelet addresses
+	assignments map[string]*protos.Assignment // assignment, by component
+	subscribers map[string][]*subscriber      // routing info subscribers, by component
+	callable    []string                      // callable components for group
+	certPEM     []byte                        // group certificate
+	keyPEM      []byte                        // group private key
+	limits      limits.Limits                 // resource limits of every weavelet
+	app         *protos.AppConfig             // app config, with the group's Go runtime tuning
+	standby     int                           // number of standby replicas
+	isolated    bool                          // does the group host an isolated component?
+	restarts    isolation.Restarts            // paces the restarts of an isolated group
+}
+
+// A subscriber is a weavelet subscribed to the routing info of a component.
+type subscriber struct {
+	envelope *envelope.Envelope // the weavelet's envelope
+	sender   *routing.Sender    // sends routing info deltas to the weavelet
 }
 
 // A proxyInfo contains information about a proxy.
@@ -104,7 +153,11 @@ type handler struct {
 	*deployer
 	g          *group
 	envelope   *envelope.Envelope
-	subscribed map[string]bool // routing info subscriptions, by component
+	subscribed map[string]bool   // routing info subscriptions, by component
+	exported   map[string]string // exported listener addresses, by listener
+	standby    bool              // is the weavelet a standby replica?
+	warm       bool              // are the components of the standby replica healthy?
+	started    time.Time         // when the weavelet was started
 }
 
 var _ envelope.EnvelopeHandler = &handler{}
@@ -142,6 +195,31 @@ func newDeployer(ctx context.Context, deploymentId string, config *MultiConfig,
 		return nil, fmt.Errorf("cannot open Perfetto database: %w", err)
 	}
 
+	// Parse the alerting rules.
+	alertsConfig, err := alerts.ParseConfig(config.App)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the history recorder.
+	historyDB, err := history.OpenDB(ctx, historyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open history database: %w", err)
+	}
+
+	// Parse the service level objectives.
+	sloConfig, err := slo.ParseConfig(config.App)
+	if err != nil {
+		return nil, err
+	}
+	var sloDB *slo.DB
+	if sloConfig != nil {
+		sloDB, err = slo.OpenDB(ctx, sloFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open SLO database: %w", err)
+		}
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	d := &deployer{
 		ctx:            ctx,
@@ -153,7 +231,9 @@ func newDeployer(ctx context.Context, deploymentId string, config *MultiConfig,
 		logsDB:         logsDB,
 		printer:        printer,
 		traceDB:        traceDB,
+		history:        history.NewRecorder(historyDB, config.App.Name, deploymentId, logger),
 		statsProcessor: imetrics.NewStatsProcessor(),
+		topology:       topology.NewTracker(topology.DefaultWindow),
 		deploymentId:   deploymentId,
 		config:         config,
 		started:        time.Now(),
@@ -165,13 +245,51 @@ func newDeployer(ctx context.Context, deploymentId string, config *MultiConfig,
 		return nil, err
 	}
 
+	// Start a goroutine that evaluates the alerting rules.
+	if alertsConfig != nil {
+		engine := alerts.NewEngine(alertsConfig, config.App.Name, deploymentId, logger)
+		d.running.Go(func() error {
+			engine.Run(d.ctx, func() ([]*metrics.MetricSnapshot, []alerts.Group) {
+				return d.readMetrics(), d.alertGroups()
+			})
+			return nil
+		})
+	}
+
+	// Start a goroutine that tracks the service level objectives.
+	if sloConfig != nil {
+		tracker := slo.NewTracker(sloConfig, config.App.Name, sloDB, logger)
+		d.running.Go(func() error {
+			tracker.Run(d.ctx, d.readMetrics)
+			return nil
+		})
+	}
+
 	// Start a goroutine that collects metrics.
 	d.running.Go(func() error {
-		err := d.statsProcessor.CollectMetrics(d.ctx, d.readMetrics)
+		err := d.statsProcessor.CollectMetrics(d.ctx, func() []*metrics.MetricSnapshot {
+			ms := d.readMetrics()
+			d.topology.Record(time.Now(), ms)
+			return ms
+		})
 		d.stop(err)
 		return err
 	})
 
+	// Start a goroutine that checks the health of standby replicas.
+	if slices.ContainsFunc(maps.Values(d.groups), func(g *group) bool { return g.standby > 0 }) {
+		d.running.Go(func() error {
+			d.checkStandbys()
+			return nil
+		})
+	}
+
+	// Start a goroutine that renews the leases of routing info.
+	d.running.Go(func() error {
+		d.renewRoutingLeases()
+		return nil
+	})
+
 	// Start a goroutine that watches for context cancelation.
 	d.running.Go(func() error {
 		<-d.ctx.Done()
@@ -198,6 +316,13 @@ func newDeployer(ctx context.Context, deploymentId string, config *MultiConfig,
 // components are placed in their own co-location group. We use the first
 // listed component as the co-location group name.
 func (d *deployer) computeGroups() error {
+	// Remove isolated components from their colocation groups.
+	isolationConfig, err := isolation.ParseConfig(d.config.App)
+	if err != nil {
+		return err
+	}
+	d.config.App = isolationConfig.Apply(d.config.App)
+
 	groups := map[string]*group{}
 	ensureGroup := func(component string) (*group, error) {
 		if g, ok := groups[component]; ok {
@@ -221,7 +346,7 @@ func (d *deployer) computeGroups() error {
 			started:     map[string]bool{},
 			addresses:   map[string]bool{},
 			assignments: map[string]*protos.Assignment{},
-			subscribers: map[string][]*envelope.Envelope{},
+			subscribers: map[string][]*subscriber{},
 			certPEM:     certPEM,
 			keyPEM:      keyPEM,
 		}
@@ -261,6 +386,67 @@ func (d *deployer) computeGroups() error {
 		srcGroup.callable = append(srcGroup.callable, dst)
 	})
 
+	// Attach resource limits to the groups.
+	for name, l := range d.config.Limits {
+		g, ok := groups[name]
+		if !ok || g.name != name {
+			return fmt.Errorf("limits specified for unknown colocation group %q", name)
+		}
+		g.limits = limits.Limits{CPUs: l.Cpus, Memory: l.Memory}
+		if err := g.limits.Validate(); err != nil {
+			return fmt.Errorf("invalid limits for colocation group %q: %w", name, err)
+		}
+	}
+
+	// Tune the Go runtime of the groups' weavelets.
+	tuningConfig, err := tuning.ParseConfig(d.config.App)
+	if err != nil {
+		return err
+	}
+	for name := range tuningConfig.Groups {
+		if g, ok := groups[name]; !ok || g.name != name {
+			return fmt.Errorf("tuning specified for unknown colocation group %q", name)
+		}
+	}
+	for name, g := range groups {
+		if g.name == name {
+			g.app = tuningConfig.Apply(d.config.App, g.name, g.limits)
+		}
+	}
+
+	// Mark the groups of isolated components.
+	for _, component := range isolationConfig.Components {
+		g, ok := groups[component]
+		if !ok {
+			return fmt.Errorf("isolation specified for unknown component %q", component)
+		}
+		g.isolated = true
+	}
+
+	// Attach the number of standby replicas to the groups.
+	standbyConfig, err := standby.ParseConfig(d.config.App)
+	if err != nil {
+		return err
+	}
+	standbys, err := standbyConfig.Groups(func(component string) (string, bool) {
+		g, ok := groups[component]
+		if !ok {
+			return "", false
+		}
+		return g.name, true
+	})
+	if err != nil {
+		return err
+	}
+	for name, n := range standbys {
+		g := groups[name]
+		if g == groups[runtime.Main] {
+			// A standby replica of the main component would run main.
+			return fmt.Errorf("standby replicas of colocation group %q, which hosts %s, are not supported", name, runtime.Main)
+		}
+		g.standby = n
+	}
+
 	d.groups = groups
 	return nil
 }
@@ -291,6 +477,25 @@ func (d *deployer) stop(err error) {
 	d.ctxCancel()
 }
 
+// shutdown stops the deployer, and waits until its weavelets exit, or until
+// the provided timeout elapses. Weavelets aren't necessarily interrupted
+// along with the deployer, e.g., if the deployer is detached from the
+// terminal and is terminated on its own.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) shutdown(timeout time.Duration) {
+	d.stop(fmt.Errorf("interrupted"))
+	done := make(chan struct{})
+	go func() {
+		d.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+	}
+}
+
 // routing returns the RoutingInfo for the provided component.
 //
 // REQUIRES: d.mu is held.
@@ -313,57 +518,486 @@ func (d *deployer) startColocationGroup(g *group) error {
 	if d.err != nil {
 		return d.err
 	}
-	if len(g.envelopes) == defaultReplication {
+	if len(g.envelopes) == defaultReplication+g.standby {
 		// Already started.
 		return nil
 	}
 
+	detail := fmt.Sprintf("%d replicas", defaultReplication)
+	if g.standby > 0 {
+		detail += fmt.Sprintf(", %d standby", g.standby)
+	}
+	d.history.Group(history.GroupScaled, g.name, detail)
+	for r := 0; r < defaultReplication+g.standby; r++ {
+		if err := d.startReplica(g, r, r >= defaultReplication, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startReplica starts the weavelet with the provided replica index in the
+// provided colocation group, replacing the replica's previous weavelet, if
+// any. A standby replica runs the group's components but doesn't receive
+// traffic until it is promoted. The provided files, if any, are handed off to
+// the weavelet.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) startReplica(g *group, r int, standby bool, files map[string]*os.File) error {
+	// Start the weavelet and capture its logs, traces, and metrics.
 	components := maps.Keys(g.started)
-	for r := 0; r < defaultReplication; r++ {
-		// Start the weavelet and capture its logs, traces, and metrics.
-		info := &protos.WeaveletArgs{
-			App:             d.config.App.Name,
-			DeploymentId:    d.deploymentId,
-			Id:              uuid.New().String(),
-			RunMain:         g.started[runtime.Main],
-			Mtls:            d.config.Mtls,
-			InternalAddress: "localhost:0",
-		}
-		e, err := envelope.NewEnvelope(d.ctx, info, d.config.App, envelope.Options{
-			Logger: d.logger,
-		})
+	info := &protos.WeaveletArgs{
+		App:             d.config.App.Name,
+		DeploymentId:    d.deploymentId,
+		Id:              uuid.New().String(),
+		RunMain:         g.started[runtime.Main],
+		Mtls:            d.config.Mtls,
+		InternalAddress: "localhost:0",
+		Group:           g.name,
+		ReplicaIndex:    int32(r),
+
+		CrashReportDir:     filepath.Join(logDir, "crashes"),
+		CrashReportWebhook: d.config.CrashReportWebhook,
+		AuditDir:           filepath.Join(logDir, "audit"),
+	}
+	e, err := envelope.NewEnvelope(d.ctx, info, g.app, envelope.Options{
+		Logger: d.logger,
+		Files:  files,
+	})
+	if err != nil {
+		return err
+	}
+	pid, ok := e.Pid()
+	if !ok {
+		panic("multi deployer child must be a real process")
+	}
+
+	// Confine the weavelet to the group's resource limits.
+	var lg *limits.Group
+	if !g.limits.IsZero() {
+		lg, err = limits.New("weaver-"+info.Id, g.limits)
 		if err != nil {
-			return err
+			return fmt.Errorf("cannot enforce the resource limits of colocation group %q: %w", g.name, err)
+		}
+		if err := lg.Add(pid); err != nil {
+			return fmt.Errorf("cannot enforce the resource limits of colocation group %q: %w", g.name, err)
 		}
+	}
+
+	h := &handler{
+		deployer:   d,
+		g:          g,
+		subscribed: map[string]bool{},
+		exported:   map[string]string{},
+		envelope:   e,
+		standby:    standby,
+		started:    time.Now(),
+	}
 
-		h := &handler{
-			deployer:   d,
-			g:          g,
-			subscribed: map[string]bool{},
-			envelope:   e,
+	d.running.Go(func() error {
+		err := e.Serve(h)
+		if lg != nil {
+			restarted, rerr := d.restartIfExceeded(h, r, lg)
+			if restarted {
+				return nil
+			}
+			if rerr != nil {
+				err = rerr
+			}
+		}
+		if d.ctx.Err() != nil {
+			d.history.Replica(history.ReplicaStopped, g.name, r, info.Id, "")
+		} else {
+			d.history.Replica(history.ReplicaCrashed, g.name, r, info.Id, fmt.Sprint(err))
+			replaced, rerr := d.replaceCrashed(h, r)
+			if replaced {
+				return nil
+			}
+			if rerr != nil {
+				err = rerr
+			}
 		}
+		d.stop(err)
+		return err
+	})
 
-		d.running.Go(func() error {
-			err := e.Serve(h)
+	// Add replica info to group
+	replica := &status.Replica{Pid: int64(pid), WeaveletId: info.Id}
+	if r < len(g.envelopes) {
+		g.replicas[r] = replica
+		g.envelopes[r] = e
+		g.handlers[r] = h
+	} else {
+		g.replicas = append(g.replicas, replica)
+		g.envelopes = append(g.envelopes, e)
+		g.handlers = append(g.handlers, h)
+	}
+	detail := fmt.Sprintf("pid %d", pid)
+	if standby {
+		detail += ", standby"
+	}
+	d.history.Replica(history.ReplicaStarted, g.name, r, info.Id, detail)
+	if err := e.UpdateComponents(components); err != nil {
+		return err
+	}
+
+	// Register the replica, and thus route traffic to it, once its
+	// components are ready.
+	d.running.Go(func() error {
+		if !d.waitUntilReady(g, r, info.Id, e, components) {
+			// The deployer is stopping.
+			return nil
+		}
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if !slices.Contains(g.envelopes, e) {
+			// The replica was replaced before it became ready.
+			return nil
+		}
+		if h.standby || g.addresses[e.WeaveletAddress()] {
+			// The replica is a standby, which doesn't receive traffic, or a
+			// standby that was promoted before it became ready.
+			return nil
+		}
+		err := d.registerReplica(g, e.WeaveletAddress())
+		if err != nil {
 			d.stop(err)
 			return err
-		})
-		pid, ok := e.Pid()
-		if !ok {
-			panic("multi deployer child must be a real process")
 		}
-		// Add replica info to group
-		g.replicas = append(g.replicas, &status.Replica{Pid: int64(pid), WeaveletId: info.Id})
-		// Register replica
-		if err := d.registerReplica(g, e.WeaveletAddress()); err != nil {
-			return err
+		d.history.Replica(history.ReplicaReady, g.name, r, info.Id, e.WeaveletAddress())
+		return nil
+	})
+	return nil
+}
+
+// restartIfExceeded is called when a weavelet confined to the provided
+// resource limits exits. If the weavelet was killed for exceeding its memory
+// limit, restartIfExceeded records the event, replaces the weavelet, and
+// returns true.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) restartIfExceeded(h *handler, r int, lg *limits.Group) (bool, error) {
+	exceeded, err := lg.Exceeded()
+	if cerr := lg.Close(); cerr != nil {
+		d.logger.Error("Cannot release resource limits", "err", cerr, "group", h.g.name)
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot check the resource limits of colocation group %q: %w", h.g.name, err)
+	}
+	if !exceeded {
+		return false, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		// The deployer is stopping.
+		return false, nil
+	}
+	weaveletId := h.g.replicas[r].WeaveletId
+	reason := fmt.Sprintf("memory limit of %s exceeded", payloads.FormatSize(float64(h.g.limits.Memory)))
+	d.logger.Error("Replica killed for exceeding its resource limits; restarting", "group", h.g.name, "replica", r, "weavelet", weaveletId, "reason", reason)
+	d.limitEvents = append(d.limitEvents, &status.LimitEvent{
+		Time:       timestamppb.Now(),
+		Group:      h.g.name,
+		Replica:    int32(r),
+		WeaveletId: weaveletId,
+		Reason:     reason,
+	})
+	d.history.Replica(history.ReplicaKilled, h.g.name, r, weaveletId, reason)
+	return d.replaceReplica(h, r, true)
+}
+
+// replaceCrashed is called when the weavelet with the provided replica index,
+// managed by the provided handler, crashes. It replaces the weavelet, if it
+// can be replaced without restarting an active replica or if it hosts an
+// isolated component, and returns true if it did.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) replaceCrashed(h *handler, r int) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.err != nil {
+		// The deployer is stopping.
+		return false, nil
+	}
+	g := h.g
+	if !g.isolated {
+		if h.standby && !h.warm {
+			// A standby that crashes before its components are healthy
+			// would likely crash again if restarted.
+			return false, nil
 		}
-		if err := e.UpdateComponents(components); err != nil {
-			return err
+		return d.replaceReplica(h, r, false)
+	}
+	if _, promotable := g.promotable(); !h.standby && promotable {
+		return d.replaceReplica(h, r, false)
+	}
+
+	// Restart the weavelet of the isolated component, backing off if it keeps
+	// crashing. Stop routing traffic to it in the meantime.
+	if err := d.removeReplica(h); err != nil {
+		return false, err
+	}
+	delay := g.restarts.Crashed(time.Since(h.started))
+	d.logger.Error("Isolated component crashed; restarting", "group", g.name, "replica", r, "weavelet", g.replicas[r].WeaveletId, "delay", delay)
+	d.mu.Unlock()
+	select {
+	case <-time.After(delay):
+	case <-d.ctx.Done():
+	}
+	d.mu.Lock()
+	if d.err != nil || d.ctx.Err() != nil {
+		// The deployer is stopping.
+		return false, nil
+	}
+	return d.replaceReplica(h, r, true)
+}
+
+// replaceReplica replaces the failed weavelet with the provided replica index,
+// managed by the provided handler, and returns true if it did. A failed
+// standby replica is replaced by a new standby. A failed active replica is
+// replaced by a healthy standby replica of its group, if any, which is
+// replaced by a new standby. Otherwise, if restart is true, a failed active
+// replica is replaced by a new active replica.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) replaceReplica(h *handler, r int, restart bool) (bool, error) {
+	g := h.g
+	s, promotable := g.promotable()
+	if !h.standby && !promotable && !restart {
+		return false, nil
+	}
+	if err := d.removeReplica(h); err != nil {
+		return false, err
+	}
+	// Hand off the files that the failed weavelet handed off, but that
+	// weren't taken, to its replacement.
+	files := h.envelope.HandedOff()
+	if !h.standby && promotable {
+		if err := d.promote(g, s); err != nil {
+			return false, err
 		}
-		g.envelopes = append(g.envelopes, e)
+		return true, d.startReplica(g, r, true, files)
 	}
-	return nil
+	return true, d.startReplica(g, r, h.standby, files)
+}
+
+// promotable returns the index of a healthy standby replica of g, if any.
+//
+// REQUIRES: d.mu is held.
+func (g *group) promotable() (int, bool) {
+	for r, h := range g.handlers {
+		if h.standby && h.warm {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// promote routes traffic to the standby replica of g with the provided index.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) promote(g *group, r int) error {
+	h := g.handlers[r]
+	h.standby = false
+	addr := h.envelope.WeaveletAddress()
+	d.logger.Info("Promoting standby replica", "group", g.name, "replica", r, "weavelet", g.replicas[r].WeaveletId)
+	d.history.Replica(history.ReplicaPromoted, g.name, r, g.replicas[r].WeaveletId, addr)
+	for listener, exported := range h.exported {
+		if p, ok := d.proxies[listener]; ok {
+			p.proxy.AddBackend(exported)
+		}
+	}
+	return d.registerReplica(g, addr)
+}
+
+// checkStandbys periodically checks whether the components of every standby
+// replica are healthy, and thus whether the standby can be promoted, until
+// the deployer is stopped.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) checkStandbys() {
+	ticker := time.NewTicker(standbyCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+
+		d.mu.Lock()
+		standbys := map[*handler][]string{}
+		for name, g := range d.groups {
+			if g.name != name {
+				continue
+			}
+			for _, h := range g.handlers {
+				if h.standby {
+					standbys[h] = maps.Keys(g.started)
+				}
+			}
+		}
+		d.mu.Unlock()
+
+		for h, components := range standbys {
+			healthy := h.envelope.GetHealth().HealthyComponents
+			warm := !slices.ContainsFunc(components, func(c string) bool { return !slices.Contains(healthy, c) })
+			d.mu.Lock()
+			if h.standby && h.warm != warm {
+				h.warm = warm
+				if warm {
+					d.logger.Debug("Standby replica ready", "group", h.g.name, "weavelet", h.envelope.WeaveletAddress())
+				} else {
+					d.logger.Warn("Standby replica unhealthy", "group", h.g.name, "weavelet", h.envelope.WeaveletAddress())
+				}
+			}
+			d.mu.Unlock()
+		}
+	}
+}
+
+// renewRoutingLeases periodically renews the leases of the routing info sent
+// to weavelets, until the deployer's context is canceled.
+func (d *deployer) renewRoutingLeases() {
+	ticker := time.NewTicker(routingLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+
+		d.mu.Lock()
+		for name, g := range d.groups {
+			if g.name != name {
+				continue
+			}
+			for component, subs := range g.subscribers {
+				for _, sub := range subs {
+					if err := sub.sender.Renew(); err != nil {
+						d.logger.Debug("Renew routing lease", "component", component, "weavelet", sub.envelope.WeaveletAddress(), "err", err)
+					}
+				}
+			}
+		}
+		d.mu.Unlock()
+	}
+}
+
+// removeReplica stops routing traffic to the weavelet managed by the provided
+// handler.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) removeReplica(h *handler) error {
+	for listener, addr := range h.exported {
+		if p, ok := d.proxies[listener]; ok {
+			p.proxy.RemoveBackend(addr)
+		}
+	}
+	for _, g := range d.groups {
+		for component, subs := range g.subscribers {
+			g.subscribers[component] = slices.DeleteFunc(subs, func(s *subscriber) bool {
+				return s.envelope == h.envelope
+			})
+		}
+	}
+	addr := h.envelope.WeaveletAddress()
+	if !h.g.addresses[addr] {
+		// The replica was never registered.
+		return nil
+	}
+	delete(h.g.addresses, addr)
+	return d.updateRouting(h.g)
+}
+
+// waitUntilReady blocks until the provided components are ready on the
+// weavelet managed by the provided envelope. Every readinessTimeout that the
+// components are not ready, waitUntilReady logs a warning and records a
+// ReplicaNotReady event. It returns false if the deployer stops first.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) waitUntilReady(g *group, replica int, weaveletId string, e *envelope.Envelope, components []string) bool {
+	// notReady returns the components that are not ready yet.
+	notReady := func() []string {
+		healthy := e.GetHealth().HealthyComponents
+		var unready []string
+		for _, c := range components {
+			if !slices.Contains(healthy, c) {
+				unready = append(unready, logging.ShortenComponent(c))
+			}
+		}
+		return unready
+	}
+	for waited := readinessTimeout; ; waited += readinessTimeout {
+		ctx, cancel := context.WithTimeout(d.ctx, readinessTimeout)
+		for r := retry.Begin(); r.Continue(ctx); {
+			if len(notReady()) == 0 {
+				cancel()
+				return true
+			}
+		}
+		cancel()
+		if d.ctx.Err() != nil {
+			return false
+		}
+		unready := notReady()
+		if len(unready) == 0 {
+			return true
+		}
+		d.logger.Warn("Replica not ready; not routing traffic to it", "group", g.name, "replica", replica, "weavelet", e.WeaveletAddress(), "waited", waited, "components", unready)
+		d.history.Replica(history.ReplicaNotReady, g.name, replica, weaveletId, fmt.Sprintf("not ready after %v: %s", waited, strings.Join(unready, ", ")))
+	}
+}
+
+// alertGroups returns the state of the started colocation groups, for
+// alerting.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) alertGroups() []alerts.Group {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	seen := map[*group]bool{}
+	var groups []alerts.Group
+	for _, g := range d.groups {
+		if seen[g] || len(g.envelopes) == 0 {
+			continue
+		}
+		seen[g] = true
+		groups = append(groups, alerts.Group{
+			Name:       g.name,
+			Components: maps.Keys(g.started),
+			Replicas:   defaultReplication,
+			Ready:      len(g.addresses),
+		})
+	}
+	return groups
+}
+
+// readiness returns, for every weavelet, the set of components that are
+// ready on the weavelet.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) readiness() map[string]map[string]bool {
+	d.mu.Lock()
+	envelopes := map[string]*envelope.Envelope{}
+	for _, group := range d.groups {
+		for i, e := range group.envelopes {
+			envelopes[group.replicas[i].WeaveletId] = e
+		}
+	}
+	d.mu.Unlock()
+
+	ready := map[string]map[string]bool{}
+	for id, e := range envelopes {
+		ready[id] = map[string]bool{}
+		for _, c := range e.GetHealth().HealthyComponents {
+			ready[id][c] = true
+		}
+	}
+	return ready
 }
 
 func (d *deployer) startMain() error {
@@ -401,8 +1035,30 @@ func (h *handler) subscribeTo(req *protos.ActivateComponentRequest) error {
 	}
 
 	// Route remotely.
-	target.subscribers[req.Component] = append(target.subscribers[req.Component], h.envelope)
-	return h.envelope.UpdateRoutingInfo(target.routing(req.Component))
+	sub := &subscriber{
+		envelope: h.envelope,
+		sender:   routing.NewSender(h.envelope.UpdateRoutingInfo, routingLease),
+	}
+	target.subscribers[req.Component] = append(target.subscribers[req.Component], sub)
+	return sub.sender.Send(target.routing(req.Component))
+}
+
+// ExportListener implements the control.DeployerControl interface.
+func (h *handler) ExportListener(_ context.Context, req *protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// A standby replica doesn't receive traffic until it is promoted.
+	reply, err := h.exportListener(req, !h.standby)
+	if err != nil || reply.Error != "" {
+		return reply, err
+	}
+
+	// Remember the exported address, so that the proxy can stop forwarding
+	// traffic to it if the weavelet is restarted, or start forwarding traffic
+	// to it if the weavelet is a standby that is promoted.
+	h.exported[req.Listener] = req.Address
+	return reply, nil
 }
 
 // GetSelfCertificate implements the control.DeployerControl interface.
@@ -498,7 +1154,7 @@ func (d *deployer) activateComponent(req *protos.ActivateComponentRequest) error
 		// Notify the subscribers.
 		routing := target.routing(req.Component)
 		for _, sub := range target.subscribers[req.Component] {
-			if err := sub.UpdateRoutingInfo(routing); err != nil {
+			if err := sub.sender.Send(routing); err != nil {
 				return err
 			}
 		}
@@ -517,6 +1173,14 @@ func (d *deployer) registerReplica(g *group, replicaAddr string) error {
 		return nil
 	}
 	g.addresses[replicaAddr] = true
+	return d.updateRouting(g)
+}
+
+// updateRouting updates the assignments of the components in the provided
+// group to match the group's replicas, and notifies the subscribers.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) updateRouting(g *group) error {
 	// Update all assignments.
 	replicas := maps.Keys(g.addresses)
 	for component, assignment := range g.assignments {
@@ -529,7 +1193,7 @@ func (d *deployer) registerReplica(g *group, replicaAddr string) error {
 	for component := range g.started {
 		routing := g.routing(component)
 		for _, sub := range g.subscribers[component] {
-			if err := sub.UpdateRoutingInfo(routing); err != nil {
+			if err := sub.sender.Send(routing); err != nil {
 				return err
 			}
 		}
@@ -556,14 +1220,17 @@ func (d *deployer) GetListenerAddress(context.Context, *protos.GetListenerAddres
 	return &protos.GetListenerAddressReply{Address: "localhost:0"}, nil
 }
 
-// ExportListener implements the control.DeployerControl interface.
-func (d *deployer) ExportListener(_ context.Context, req *protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
-	d.mu.Lock()
-	defer d.mu.Unlock()
-
+// exportListener returns the address of the proxy of the listener exported
+// by the provided request, creating the proxy if needed. If backend is true,
+// the proxy forwards traffic to the listener.
+//
+// REQUIRES: d.mu is held.
+func (d *deployer) exportListener(req *protos.ExportListenerRequest, backend bool) (*protos.ExportListenerReply, error) {
 	// Update the proxy.
 	if p, ok := d.proxies[req.Listener]; ok {
-		p.proxy.AddBackend(req.Address)
+		if backend {
+			p.proxy.AddBackend(req.Address)
+		}
 		return &protos.ExportListenerReply{ProxyAddress: p.addr}, nil
 	}
 
@@ -585,7 +1252,9 @@ func (d *deployer) ExportListener(_ context.Context, req *protos.ExportListenerR
 	addr := lis.Addr().String() // actual proxy address
 	d.logger.Info("Proxy listening", "address", addr)
 	proxy := proxy.NewProxy(d.logger)
-	proxy.AddBackend(req.Address)
+	if backend {
+		proxy.AddBackend(req.Address)
+	}
 	d.proxies[req.Listener] = &proxyInfo{
 		listener: req.Listener,
 		proxy:    proxy,
@@ -634,8 +1303,46 @@ func (d *deployer) Profile(_ context.Context, req *protos.GetProfileRequest) (*p
 	return profile, nil
 }
 
+// FlightRecord implements the status.Server interface.
+func (d *deployer) FlightRecord(context.Context) (*protos.GetFlightRecordReply, error) {
+	d.mu.Lock()
+	var envelopes []*envelope.Envelope
+	for _, group := range d.groups {
+		envelopes = append(envelopes, group.envelopes...)
+	}
+	d.mu.Unlock()
+
+	reply := &protos.GetFlightRecordReply{}
+	for _, e := range envelopes {
+		events, err := e.GetFlightRecord()
+		if err != nil {
+			d.logger.Error("Failed to get flight record", "weavelet", e.WeaveletAddress(), "err", err)
+			continue
+		}
+		reply.Events = append(reply.Events, events...)
+	}
+	slices.SortStableFunc(reply.Events, func(a, b *protos.CallEvent) int {
+		return cmp.Compare(a.TimeMicros, b.TimeMicros)
+	})
+	return reply, nil
+}
+
+// Topology implements the status.Server interface.
+func (d *deployer) Topology(context.Context) (*protos.GetTopologyReply, error) {
+	return &protos.GetTopologyReply{Topology: d.topology.Record(time.Now(), d.readMetrics())}, nil
+}
+
+// GetTopology implements the envelope.EnvelopeHandler interface.
+func (d *deployer) GetTopology(ctx context.Context, _ *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	return d.Topology(ctx)
+}
+
 // Status implements the status.Server interface.
 func (d *deployer) Status(context.Context) (*status.Status, error) {
+	// Fetch readiness before acquiring the lock, since it requires calls to
+	// the weavelets.
+	ready := d.readiness()
+
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
@@ -643,9 +1350,13 @@ func (d *deployer) Status(context.Context) (*status.Status, error) {
 	var components []*status.Component
 	for _, group := range d.groups {
 		for component := range group.started {
-			c := &status.Component{
-				Name:     component,
-				Replicas: group.replicas,
+			c := &status.Component{Name: component}
+			for _, r := range group.replicas {
+				c.Replicas = append(c.Replicas, &status.Replica{
+					Pid:        r.Pid,
+					WeaveletId: r.WeaveletId,
+					Ready:      ready[r.WeaveletId][component],
+				})
 			}
 			components = append(components, c)
 
@@ -695,6 +1406,7 @@ func (d *deployer) Status(context.Context) (*status.Status, error) {
 		Components:     components,
 		Listeners:      listeners,
 		Config:         d.config.App,
+		LimitEvents:    d.limitEvents,
 	}, nil
 }
 
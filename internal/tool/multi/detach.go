@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+)
+
+// deploymentIdKey is the name of the env variable that contains the id of the
+// deployment run by a detached 'weaver multi deploy' process.
+const deploymentIdKey = "SERVICEWEAVER_MULTI_DEPLOYMENT_ID"
+
+// detachTimeout bounds how long 'weaver multi deploy --detach' waits for the
+// detached deployment to start.
+const detachTimeout = time.Minute
+
+// detach runs 'weaver multi deploy' with the provided flags and config file
+// in a new process, detached from the terminal, that runs the deployment with
+// the provided id. detach returns once the deployment has started, leaving it
+// running.
+func detach(ctx context.Context, deploymentId string, flags []string, configFile string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+
+	// Write the output of the detached process to a file, since there is no
+	// terminal to write it to.
+	outFile := filepath.Join(dataDir, "deploy", deploymentId+".log")
+	if err := os.MkdirAll(filepath.Dir(outFile), 0700); err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+	out, err := os.OpenFile(outFile, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+	defer out.Close()
+
+	args := append([]string{"multi", "deploy"}, flags...)
+	cmd := exec.Command(exe, append(args, configFile)...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", deploymentIdKey, deploymentId))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	setDetached(cmd)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("detach: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	// Wait for the deployment to register itself.
+	registry, err := defaultRegistry(ctx)
+	if err != nil {
+		return fmt.Errorf("create registry: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, detachTimeout)
+	defer cancel()
+	for r := retry.Begin(); r.Continue(ctx); {
+		select {
+		case err := <-exited:
+			return fmt.Errorf("deployment %s exited: %v; see %s", deploymentId, err, outFile)
+		default:
+		}
+		reg, err := registry.Get(ctx, deploymentId)
+		if err != nil {
+			continue
+		}
+		fmt.Fprint(os.Stderr, reg.Rolodex())
+		fmt.Fprintf(os.Stderr, `The deployment is running in the background, as process %d.
+Its output is written to %s.
+
+To attach to the deployment, run:
+    weaver multi attach %s
+To stop the deployment, terminate process %d.
+`, cmd.Process.Pid, outFile, logging.Shorten(deploymentId), cmd.Process.Pid)
+		return nil
+	}
+	return fmt.Errorf("deployment %s didn't start within %v; see %s", deploymentId, detachTimeout, outFile)
+}
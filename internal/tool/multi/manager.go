@@ -0,0 +1,251 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/lease"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/proto"
+)
+
+// managerLease is the lease of the manager of a deployment, i.e., the
+// 'weaver multi deploy' process. The manager renews its lease every third of
+// it, and a standby manager takes over the deployment once it expires.
+const managerLease = 5 * time.Second
+
+// orphanWindow bounds how long after the lease of a manager expired a standby
+// manager kills the processes the manager left behind. Past it, the processes
+// are presumed gone, and their process ids may have been reused.
+const orphanWindow = time.Minute
+
+var standbyCmd = tool.Command{
+	Name:        "standby",
+	Description: "Stand by to take over a deployment",
+	Help: `Usage:
+  weaver multi standby <deployment id>
+
+Flags:
+  -h, --help	Print this help message.
+
+"weaver multi standby" runs a hot standby manager for a deployment started
+with "weaver multi deploy". If the manager of the deployment dies, the standby
+restarts the deployment's processes and manages the deployment from then on,
+under the same deployment id. The standby exits when the deployment stops.
+The deployment id may be a prefix of the id.`,
+	Flags: flag.NewFlagSet("standby", flag.ContinueOnError),
+	Fn:    runStandby,
+}
+
+// managerState is the state of a deployment that its manager stores in its
+// lease, so that a standby manager can take it over.
+type managerState struct {
+	Config []byte `json:"config"`  // the deployment's MultiConfig, proto-encoded
+	TmpDir string `json:"tmp_dir"` // the manager's temporary directory
+	Pids   []int  `json:"pids"`    // process ids of the manager's weavelets
+}
+
+// newManagerState returns the initial state of a deployment with the provided
+// config.
+func newManagerState(config *MultiConfig) (*managerState, error) {
+	bytes, err := proto.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return &managerState{Config: bytes}, nil
+}
+
+// decodeManagerState decodes a state encoded by managerState.encode.
+func decodeManagerState(data []byte) (*managerState, *MultiConfig, error) {
+	var state managerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, nil, fmt.Errorf("decode manager state: %w", err)
+	}
+	config := &MultiConfig{}
+	if err := proto.Unmarshal(state.Config, config); err != nil {
+		return nil, nil, fmt.Errorf("decode manager state: %w", err)
+	}
+	return &state, config, nil
+}
+
+// encode encodes the state.
+func (s *managerState) encode() []byte {
+	// Marshaling a managerState never fails.
+	bytes, _ := json.Marshal(s)
+	return bytes
+}
+
+// holdLease renews the lease of the deployment's manager, and stores the
+// deployment's state in it, until the deployer stops. If the lease is lost,
+// e.g., because the manager stalled and a standby manager took over, the
+// deployer is stopped.
+func (d *deployer) holdLease(leases *lease.DB, owner string, state *managerState) {
+	ticker := time.NewTicker(managerLease / 3)
+	defer ticker.Stop()
+	var stored []byte
+	for {
+		err := leases.Renew(d.ctx, d.deploymentId, owner, managerLease)
+		if err == nil {
+			state.Pids = d.pids()
+			if data := state.encode(); !bytes.Equal(data, stored) {
+				if err = leases.Store(d.ctx, d.deploymentId, owner, data); err == nil {
+					stored = data
+				}
+			}
+		}
+		if errors.Is(err, lease.ErrLost) {
+			d.stop(fmt.Errorf("a standby manager took over the deployment: %w", err))
+			return
+		} else if err != nil && d.ctx.Err() == nil {
+			d.logger.Error("Cannot renew the manager's lease", "err", err)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-d.ctx.Done():
+			return
+		}
+	}
+}
+
+// pids returns the process ids of the deployment's weavelets.
+//
+// REQUIRES: d.mu is NOT held.
+func (d *deployer) pids() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var pids []int
+	for name, g := range d.groups {
+		if g.name != name {
+			continue
+		}
+		for _, r := range g.replicas {
+			pids = append(pids, int(r.Pid))
+		}
+	}
+	slices.Sort(pids)
+	return pids
+}
+
+// runStandby runs a standby manager for a deployment, taking it over if its
+// manager dies.
+func runStandby(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no deployment id provided")
+	}
+	if len(args) > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+
+	leases, err := lease.OpenDB(ctx, leaseFile)
+	if err != nil {
+		return fmt.Errorf("open lease database: %w", err)
+	}
+	defer leases.Close()
+	matches, err := leases.List(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("deployment %q not found", args[0])
+	case 1:
+	default:
+		return fmt.Errorf("deployment id %q is ambiguous", args[0])
+	}
+	deploymentId := matches[0].Key
+	_, config, err := decodeManagerState(matches[0].State)
+	if err != nil {
+		return err
+	}
+	if err := checkVersion(config.App); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Standing by for deployment %s of app %q, managed by pid %d\n", deploymentId, config.App.Name, matches[0].Pid)
+	owner := uuid.New().String()
+	ticker := time.NewTicker(managerLease / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		l, ok, err := leases.Get(ctx, deploymentId)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Deployment %s stopped\n", deploymentId)
+			return nil
+		}
+		now := time.Now()
+		if !l.Expired(now) {
+			continue
+		}
+		old := l
+		if acquired, err := leases.Acquire(ctx, deploymentId, owner, managerLease); err != nil {
+			return err
+		} else if !acquired {
+			// Another standby manager took over the deployment.
+			continue
+		}
+
+		// Read the state again, now that the old manager can't update it.
+		l, _, err = leases.Get(ctx, deploymentId)
+		if err != nil {
+			return err
+		}
+		state, config, err := decodeManagerState(l.State)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "The manager of deployment %s (pid %d) is unresponsive; taking over\n", deploymentId, old.Pid)
+		if now.Sub(old.Expiry) < orphanWindow {
+			killOrphans(old.Pid, state.Pids)
+		}
+		if state.TmpDir != "" {
+			os.RemoveAll(state.TmpDir)
+		}
+		state.Pids = nil
+		return run(ctx, deploymentId, config, leases, owner, state, fmt.Sprintf("took over from manager pid %d", old.Pid))
+	}
+}
+
+// killOrphans kills the provided manager and weavelets of a deployment that
+// was taken over, if they are still running. A manager that stalled rather
+// than died would otherwise keep serving the deployment's listeners.
+func killOrphans(manager int, weavelets []int) {
+	for _, pid := range append([]int{manager}, weavelets...) {
+		if pid == os.Getpid() {
+			continue
+		}
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	}
+}
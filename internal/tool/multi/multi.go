@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/ServiceWeaver/weaver/internal/history"
 	"github.com/ServiceWeaver/weaver/internal/must"
 	"github.com/ServiceWeaver/weaver/internal/status"
 	itool "github.com/ServiceWeaver/weaver/internal/tool"
@@ -33,6 +34,9 @@ var (
 	dataDir      = filepath.Join(must.Must(runtime.DataDir()), "multi")
 	registryDir  = filepath.Join(dataDir, "registry")
 	perfettoFile = filepath.Join(dataDir, "traces.DB")
+	historyFile  = filepath.Join(dataDir, "history.DB")
+	sloFile      = filepath.Join(dataDir, "slo.DB")
+	leaseFile    = filepath.Join(dataDir, "lease.DB")
 
 	dashboardSpec = &status.DashboardSpec{
 		Tool:         "weaver multi",
@@ -50,12 +54,13 @@ var (
 
 	purgeSpec = &tool.PurgeSpec{
 		Tool:  "weaver multi",
-		Kill:  "weaver multi (dashboard|deploy|logs|profile)",
+		Kill:  "weaver multi (dashboard|deploy|standby|attach|logs|profile)",
 		Paths: []string{logDir, dataDir},
 	}
 
 	Commands = map[string]*tool.Command{
-		"deploy": &deployCmd,
+		"deploy":  &deployCmd,
+		"standby": &standbyCmd,
 		"logs": tool.LogsCmd(&tool.LogsSpec{
 			Tool: "weaver multi",
 			Source: func(context.Context) (logging.Source, error) {
@@ -64,9 +69,13 @@ var (
 		}),
 		"dashboard": status.DashboardCommand(dashboardSpec),
 		"status":    status.StatusCommand("weaver multi", defaultRegistry),
-		"metrics":   status.MetricsCommand("weaver multi", defaultRegistry),
-		"profile":   status.ProfileCommand("weaver multi", defaultRegistry),
-		"purge":     tool.PurgeCmd(purgeSpec),
-		"version":   itool.VersionCmd("weaver multi"),
+		"attach": status.AttachCommand("weaver multi", defaultRegistry, func(context.Context) (logging.Source, error) {
+			return logging.FileSource(logDir), nil
+		}),
+		"metrics": status.MetricsCommand("weaver multi", defaultRegistry),
+		"profile": status.ProfileCommand("weaver multi", defaultRegistry),
+		"history": history.Command("weaver multi", historyFile),
+		"purge":   tool.PurgeCmd(purgeSpec),
+		"version": itool.VersionCmd("weaver multi"),
 	}
 )
@@ -14,8 +14,8 @@
 
 // Code generated by protoc-gen-go. DO NOT EDIT.
 // versions:
-// 	protoc-gen-go v1.26.0
-// 	protoc        v3.21.12
+// 	protoc-gen-go v1.34.1
+// 	protoc        v3.19.6
 // source: internal/tool/multi/multi.proto
 
 package multi
@@ -48,6 +48,13 @@ type MultiConfig struct {
 	// one another?
 	Mtls      bool                                    `protobuf:"varint,2,opt,name=mtls,proto3" json:"mtls,omitempty"`
 	Listeners map[string]*MultiConfig_ListenerOptions `protobuf:"bytes,3,rep,name=listeners,proto3" json:"listeners,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// URL to which weavelets POST a JSON crash report when one of their
+	// components panics. Crash reports are always written to the deployer's
+	// logs directory.
+	CrashReportWebhook string `protobuf:"bytes,4,opt,name=crash_report_webhook,json=crashReportWebhook,proto3" json:"crash_report_webhook,omitempty"`
+	// Resource limits, keyed by colocation group name. A colocation group is
+	// named after its first component.
+	Limits map[string]*MultiConfig_GroupLimits `protobuf:"bytes,5,rep,name=limits,proto3" json:"limits,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (x *MultiConfig) Reset() {
@@ -103,6 +110,20 @@ func (x *MultiConfig) GetListeners() map[string]*MultiConfig_ListenerOptions {
 	return nil
 }
 
+func (x *MultiConfig) GetCrashReportWebhook() string {
+	if x != nil {
+		return x.CrashReportWebhook
+	}
+	return ""
+}
+
+func (x *MultiConfig) GetLimits() map[string]*MultiConfig_GroupLimits {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
 // Options for the application listeners, keyed by listener name.
 // If a listener isn't specified in the map, default options will be used.
 type MultiConfig_ListenerOptions struct {
@@ -154,6 +175,67 @@ func (x *MultiConfig_ListenerOptions) GetAddress() string {
 	return ""
 }
 
+// Resource limits of the weavelets in a colocation group. On Linux, limits
+// are enforced with cgroups v2; on Windows, with job objects. A weavelet
+// that exceeds its memory limit is killed and restarted.
+type MultiConfig_GroupLimits struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Maximum number of CPUs each weavelet may use, e.g., 0.5. Zero means no
+	// limit.
+	Cpus float64 `protobuf:"fixed64,1,opt,name=cpus,proto3" json:"cpus,omitempty"`
+	// Maximum memory, in bytes, each weavelet may use. Zero means no limit.
+	Memory int64 `protobuf:"varint,2,opt,name=memory,proto3" json:"memory,omitempty"`
+}
+
+func (x *MultiConfig_GroupLimits) Reset() {
+	*x = MultiConfig_GroupLimits{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_internal_tool_multi_multi_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *MultiConfig_GroupLimits) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiConfig_GroupLimits) ProtoMessage() {}
+
+func (x *MultiConfig_GroupLimits) ProtoReflect() protoreflect.Message {
+	mi := &file_internal_tool_multi_multi_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiConfig_GroupLimits.ProtoReflect.Descriptor instead.
+func (*MultiConfig_GroupLimits) Descriptor() ([]byte, []int) {
+	return file_internal_tool_multi_multi_proto_rawDescGZIP(), []int{0, 2}
+}
+
+func (x *MultiConfig_GroupLimits) GetCpus() float64 {
+	if x != nil {
+		return x.Cpus
+	}
+	return 0
+}
+
+func (x *MultiConfig_GroupLimits) GetMemory() int64 {
+	if x != nil {
+		return x.Memory
+	}
+	return 0
+}
+
 var File_internal_tool_multi_multi_proto protoreflect.FileDescriptor
 
 var file_internal_tool_multi_multi_proto_rawDesc = []byte{
@@ -161,7 +243,7 @@ var file_internal_tool_multi_multi_proto_rawDesc = []byte{
 	0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2f, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2e, 0x70, 0x72, 0x6f, 0x74,
 	0x6f, 0x12, 0x05, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x1a, 0x1b, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
 	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e,
-	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x97, 0x02, 0x0a, 0x0b, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x43,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x97, 0x04, 0x0a, 0x0b, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x43,
 	0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x24, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01,
 	0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x70, 0x70,
 	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x6d,
@@ -170,15 +252,31 @@ var file_internal_tool_multi_multi_proto_rawDesc = []byte{
 	0x28, 0x0b, 0x32, 0x21, 0x2e, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69,
 	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73,
 	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x09, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73,
-	0x1a, 0x2b, 0x0a, 0x0f, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x4f, 0x70, 0x74, 0x69,
-	0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x60, 0x0a,
-	0x0e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
-	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
-	0x79, 0x12, 0x38, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x22, 0x2e, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x43, 0x6f,
-	0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x4f, 0x70, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
+	0x12, 0x30, 0x0a, 0x14, 0x63, 0x72, 0x61, 0x73, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74,
+	0x5f, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12,
+	0x63, 0x72, 0x61, 0x73, 0x68, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x57, 0x65, 0x62, 0x68, 0x6f,
+	0x6f, 0x6b, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x18, 0x05, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69,
+	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x06, 0x6c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x1a, 0x2b, 0x0a, 0x0f, 0x4c, 0x69,
+	0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a,
+	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x1a, 0x60, 0x0a, 0x0e, 0x4c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x65, 0x72, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x38, 0x0a, 0x05, 0x76,
+	0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x22, 0x2e, 0x6d, 0x75, 0x6c,
+	0x74, 0x69, 0x2e, 0x4d, 0x75, 0x6c, 0x74, 0x69, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x4f, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x05,
+	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x39, 0x0a, 0x0b, 0x47, 0x72, 0x6f,
+	0x75, 0x70, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x70, 0x75, 0x73,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x63, 0x70, 0x75, 0x73, 0x12, 0x16, 0x0a, 0x06,
+	0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x06, 0x6d, 0x65,
+	0x6d, 0x6f, 0x72, 0x79, 0x1a, 0x59, 0x0a, 0x0b, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x73, 0x45, 0x6e,
+	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x34, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x6d, 0x75, 0x6c, 0x74, 0x69, 0x2e, 0x4d, 0x75, 0x6c,
+	0x74, 0x69, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x4c, 0x69,
+	0x6d, 0x69, 0x74, 0x73, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x42,
 	0x35, 0x5a, 0x33, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x65,
 	0x72, 0x76, 0x69, 0x63, 0x65, 0x57, 0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x77, 0x65, 0x61, 0x76,
 	0x65, 0x72, 0x2f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x74, 0x6f, 0x6f, 0x6c,
@@ -197,22 +295,26 @@ func file_internal_tool_multi_multi_proto_rawDescGZIP() []byte {
 	return file_internal_tool_multi_multi_proto_rawDescData
 }
 
-var file_internal_tool_multi_multi_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_internal_tool_multi_multi_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
 var file_internal_tool_multi_multi_proto_goTypes = []interface{}{
 	(*MultiConfig)(nil),                 // 0: multi.MultiConfig
 	(*MultiConfig_ListenerOptions)(nil), // 1: multi.MultiConfig.ListenerOptions
 	nil,                                 // 2: multi.MultiConfig.ListenersEntry
-	(*protos.AppConfig)(nil),            // 3: runtime.AppConfig
+	(*MultiConfig_GroupLimits)(nil),     // 3: multi.MultiConfig.GroupLimits
+	nil,                                 // 4: multi.MultiConfig.LimitsEntry
+	(*protos.AppConfig)(nil),            // 5: runtime.AppConfig
 }
 var file_internal_tool_multi_multi_proto_depIdxs = []int32{
-	3, // 0: multi.MultiConfig.app:type_name -> runtime.AppConfig
+	5, // 0: multi.MultiConfig.app:type_name -> runtime.AppConfig
 	2, // 1: multi.MultiConfig.listeners:type_name -> multi.MultiConfig.ListenersEntry
-	1, // 2: multi.MultiConfig.ListenersEntry.value:type_name -> multi.MultiConfig.ListenerOptions
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	4, // 2: multi.MultiConfig.limits:type_name -> multi.MultiConfig.LimitsEntry
+	1, // 3: multi.MultiConfig.ListenersEntry.value:type_name -> multi.MultiConfig.ListenerOptions
+	3, // 4: multi.MultiConfig.LimitsEntry.value:type_name -> multi.MultiConfig.GroupLimits
+	5, // [5:5] is the sub-list for method output_type
+	5, // [5:5] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
 }
 
 func init() { file_internal_tool_multi_multi_proto_init() }
@@ -245,6 +347,18 @@ func file_internal_tool_multi_multi_proto_init() {
 				return nil
 			}
 		}
+		file_internal_tool_multi_multi_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*MultiConfig_GroupLimits); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -252,7 +366,7 @@ func file_internal_tool_multi_multi_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: file_internal_tool_multi_multi_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   3,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
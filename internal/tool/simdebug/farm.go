@@ -0,0 +1,189 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simdebug
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/simfarm"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+)
+
+var (
+	farmFlags      = flag.NewFlagSet("farm", flag.ContinueOnError)
+	farmAddress    = farmFlags.String("address", ":9000", "Address on which the coordinator listens")
+	farmSeed       = farmFlags.Int64("seed", 0, "Seed of the sweep of executions; if 0, a time-based seed is used")
+	farmLeaseSize  = farmFlags.Int("lease_size", 1000, "Number of sweep executions leased to a worker at a time")
+	farmCandidates = farmFlags.Int("candidates", 100, "Maximum number of mutation candidates per lease")
+	farmInterval   = farmFlags.Duration("interval", 10*time.Second, "How often the status of the farm is printed")
+	farmOut        = farmFlags.String("out", "", "If non-empty, the directory to which the history of every unique failure is written")
+)
+
+func farmCommand() *tool.Command {
+	const help = `Usage:
+  weaver sim farm [options]
+
+Flags:
+  -h, --help	Print this help message.
+{{.Flags}}
+
+Description:
+  'weaver sim farm' runs a coordinator that distributes a simulation across
+  many workers. The coordinator leases consecutive ranges of a sweep of
+  executions to workers, along with mutation candidates: variations of the
+  inputs of failing executions that workers have reported.
+
+  A worker is a simulator whose sim.Options.Farm field, or the WEAVER_SIM_FARM
+  environment variable, holds the address of the coordinator. Workers run the
+  executions they lease, report the failures they find, and keep going until
+  the duration passed to Simulator.Run elapses.
+
+  The coordinator deduplicates failures by a fingerprint of their histories
+  and aggregates the coverage of ops and component methods reported by all
+  workers. It periodically prints the status of the farm, which is also served
+  as JSON at /status. With --out, the history of every unique failure is
+  written to the provided directory and can be inspected with
+  'weaver sim debug'.
+
+Examples:
+  # Start a coordinator.
+  weaver sim farm --address=:9000 --seed=42 --out=/tmp/farm
+
+  # On every worker machine, join the farm.
+  WEAVER_SIM_FARM=http://coordinator:9000 go test -run=TestBank -timeout=0`
+	var b strings.Builder
+	t := template.Must(template.New("farm").Parse(help))
+	content := struct{ Flags string }{tool.FlagsHelp(farmFlags)}
+	if err := t.Execute(&b, content); err != nil {
+		panic(err)
+	}
+
+	return &tool.Command{
+		Name:        "farm",
+		Description: "Distribute a simulation across many workers",
+		Help:        b.String(),
+		Flags:       farmFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) != 0 {
+				return fmt.Errorf("usage: weaver sim farm [options]")
+			}
+			seed := *farmSeed
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+			c, err := simfarm.NewCoordinator(seed, *farmLeaseSize, *farmCandidates)
+			if err != nil {
+				return err
+			}
+			return serveFarm(ctx, c, *farmAddress, *farmInterval, *farmOut)
+		},
+	}
+}
+
+// serveFarm serves the provided coordinator on the provided address until
+// the context is cancelled, printing its status every interval.
+func serveFarm(ctx context.Context, c *simfarm.Coordinator, address string, interval time.Duration, out string) error {
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Handler: c}
+	errs := make(chan error, 1)
+	go func() { errs <- server.Serve(lis) }()
+	defer server.Close()
+	fmt.Printf("Coordinating a farm with seed %d on %s.\n", c.Status().Seed, lis.Addr())
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	written := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errs:
+			if errors.Is(err, http.ErrServerClosed) {
+				return nil
+			}
+			return err
+		case <-ticker.C:
+			status := c.Status()
+			printStatus(os.Stdout, status)
+			if out != "" {
+				if err := writeFailures(out, status, written); err != nil {
+					fmt.Fprintf(os.Stderr, "write failures: %v\n", err)
+				}
+			}
+		}
+	}
+}
+
+// printStatus prints the status of a farm.
+func printStatus(w io.Writer, status simfarm.Status) {
+	fmt.Fprintf(w, "%s: %d workers, %d leases, %d executions, %d ops, %d coverage keys, %d unique failures\n",
+		time.Now().Format(time.TimeOnly), len(status.Workers), status.Leases, status.Executions, status.Ops, len(status.Coverage), len(status.Failures))
+
+	// Print the least covered keys, which are often the most interesting.
+	keys := make([]string, 0, len(status.Coverage))
+	for k := range status.Coverage {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if status.Coverage[keys[i]] != status.Coverage[keys[j]] {
+			return status.Coverage[keys[i]] < status.Coverage[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > 5 {
+		keys = keys[:5]
+	}
+	for _, k := range keys {
+		fmt.Fprintf(w, "  rarely covered: %-40s %d\n", k, status.Coverage[k])
+	}
+	for _, f := range status.Failures {
+		fmt.Fprintf(w, "  failure %s: %d reports from %d workers: %s\n", f.Fingerprint, f.Count, len(f.Workers), f.Error)
+	}
+}
+
+// writeFailures writes the history of every failure in status that is not in
+// written to dir, and adds it to written.
+func writeFailures(dir string, status simfarm.Status, written map[string]bool) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, f := range status.Failures {
+		if written[f.Fingerprint] {
+			continue
+		}
+		filename := filepath.Join(dir, f.Fingerprint+".history")
+		if err := os.WriteFile(filename, []byte(f.History), 0644); err != nil {
+			return err
+		}
+		written[f.Fingerprint] = true
+		fmt.Printf("  history of failure %s written to %s\n", f.Fingerprint, filename)
+	}
+	return nil
+}
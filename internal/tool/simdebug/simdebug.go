@@ -0,0 +1,308 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package simdebug implements the "weaver sim" subcommands, which inspect the
+// histories of simulations run by the sim package and distribute simulations
+// across many workers.
+package simdebug
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/tool"
+	"github.com/ServiceWeaver/weaver/sim"
+)
+
+var (
+	debugFlags = flag.NewFlagSet("debug", flag.ContinueOnError)
+
+	// Commands holds the "weaver sim" subcommands.
+	Commands = map[string]*tool.Command{
+		"debug": debugCommand(),
+		"farm":  farmCommand(),
+	}
+)
+
+func debugCommand() *tool.Command {
+	return &tool.Command{
+		Name:        "debug",
+		Description: "Step through a simulation history",
+		Help: `Usage:
+  weaver sim debug <history file>
+
+Flags:
+  -h, --help	Print this help message.
+
+Description:
+  'weaver sim debug' is an interactive debugger for the history of a failed
+  simulation. When a simulation fails, the simulator writes the history of the
+  failing execution to a file and logs its name.
+
+  The debugger steps forward and backward through the events of the history,
+  shows the state of every component replica at the current event, and jumps
+  to the first invariant violation. Replica state is only available for
+  components that implement sim.Snapshotter. Type 'help' at the prompt for a
+  list of commands.`,
+		Flags: debugFlags,
+		Fn: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("usage: weaver sim debug <history file>")
+			}
+			f, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			history, err := sim.ReadHistory(f)
+			if err != nil {
+				return fmt.Errorf("read %s: %w", args[0], err)
+			}
+			d := &debugger{history: history, out: os.Stdout}
+			return d.repl(os.Stdin)
+		},
+	}
+}
+
+const replHelp = `Commands:
+  next [n]     (n)  Step forward n events (default 1).
+  prev [n]     (p)  Step backward n events (default 1).
+  goto <i>     (g)  Jump to just after event i.
+  violation    (v)  Jump to the first invariant violation or panic.
+  state        (s)  Show the latest state of every replica.
+  list [n]     (l)  List the n events around the current one (default 10).
+  help         (h)  Print this help message.
+  quit         (q)  Exit the debugger.
+An empty line repeats the previous command.`
+
+// debugger steps through a history. The debugger's position is the number of
+// events that have happened. The current event is the last event that
+// happened.
+type debugger struct {
+	history []sim.Event
+	pos     int       // in the range [0, len(history)]
+	out     io.Writer // where output is written
+}
+
+// repl runs the debugger, reading commands from in until it is exhausted or
+// a quit command is read.
+func (d *debugger) repl(in io.Reader) error {
+	fmt.Fprintf(d.out, "Loaded %d events. Type 'help' for a list of commands.\n", len(d.history))
+	scanner := bufio.NewScanner(in)
+	var last string
+	for {
+		fmt.Fprint(d.out, "(sim) ")
+		if !scanner.Scan() {
+			fmt.Fprintln(d.out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			line = last
+		}
+		last = line
+		if line == "" {
+			continue
+		}
+		if quit := d.exec(line); quit {
+			return nil
+		}
+	}
+}
+
+// exec executes a single command and returns whether the debugger should
+// exit.
+func (d *debugger) exec(line string) bool {
+	fields := strings.Fields(line)
+	cmd, args := fields[0], fields[1:]
+
+	// count parses an optional count argument.
+	count := func(def int) (int, bool) {
+		if len(args) == 0 {
+			return def, true
+		}
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 0 {
+			fmt.Fprintf(d.out, "invalid count %q\n", args[0])
+			return 0, false
+		}
+		return n, true
+	}
+
+	switch cmd {
+	case "next", "n":
+		if n, ok := count(1); ok {
+			for i := 0; i < n && d.pos < len(d.history); i++ {
+				d.pos++
+				d.print(d.pos - 1)
+			}
+			if d.pos == len(d.history) {
+				fmt.Fprintln(d.out, "End of history.")
+			}
+		}
+	case "prev", "p":
+		if n, ok := count(1); ok {
+			d.jump(d.pos - n)
+		}
+	case "goto", "g":
+		if len(args) != 1 {
+			fmt.Fprintln(d.out, "usage: goto <i>")
+			break
+		}
+		if i, ok := count(0); ok {
+			d.jump(i + 1)
+		}
+	case "violation", "v":
+		i := violation(d.history)
+		if i == -1 {
+			fmt.Fprintln(d.out, "No invariant violations found.")
+			break
+		}
+		d.jump(i + 1)
+	case "state", "s":
+		d.state()
+	case "list", "l":
+		if n, ok := count(10); ok {
+			d.list(n)
+		}
+	case "help", "h":
+		fmt.Fprintln(d.out, replHelp)
+	case "quit", "q":
+		return true
+	default:
+		fmt.Fprintf(d.out, "unknown command %q. Type 'help' for a list of commands.\n", cmd)
+	}
+	return false
+}
+
+// jump moves the debugger to the provided position and prints the current
+// event.
+func (d *debugger) jump(pos int) {
+	d.pos = max(0, min(pos, len(d.history)))
+	if d.pos == 0 {
+		fmt.Fprintln(d.out, "Start of history.")
+		return
+	}
+	d.print(d.pos - 1)
+}
+
+// print prints the i-th event.
+func (d *debugger) print(i int) {
+	fmt.Fprintf(d.out, "%4d  %s\n", i, describe(d.history[i]))
+}
+
+// list prints the n events around the current event.
+func (d *debugger) list(n int) {
+	current := d.pos - 1
+	start := max(0, current-n/2)
+	end := min(len(d.history), start+n)
+	for i := start; i < end; i++ {
+		marker := " "
+		if i == current {
+			marker = ">"
+		}
+		fmt.Fprintf(d.out, "%s%4d  %s\n", marker, i, describe(d.history[i]))
+	}
+}
+
+// state prints the latest snapshot of every replica as of the current event.
+func (d *debugger) state() {
+	type replica struct {
+		component string
+		replica   int
+	}
+	latest := map[replica]sim.EventSnapshot{}
+	for _, event := range d.history[:d.pos] {
+		if s, ok := event.(sim.EventSnapshot); ok {
+			latest[replica{s.Component, s.Replica}] = s
+		}
+	}
+	if len(latest) == 0 {
+		fmt.Fprintln(d.out, "No replica state recorded yet. Components must implement sim.Snapshotter to record state.")
+		return
+	}
+	replicas := make([]replica, 0, len(latest))
+	for r := range latest {
+		replicas = append(replicas, r)
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		if replicas[i].component != replicas[j].component {
+			return replicas[i].component < replicas[j].component
+		}
+		return replicas[i].replica < replicas[j].replica
+	})
+	for _, r := range replicas {
+		s := latest[r]
+		fmt.Fprintf(d.out, "%s %d (as of [%d:%d]): %s\n", logging.ShortenComponent(r.component), r.replica, s.TraceID, s.SpanID, s.State)
+	}
+}
+
+// violation returns the index of the first invariant violation or panic in a
+// history, or -1 if there is none.
+func violation(history []sim.Event) int {
+	for i, event := range history {
+		switch x := event.(type) {
+		case sim.EventOpFinish:
+			if x.Error != "<nil>" {
+				return i
+			}
+		case sim.EventPanic:
+			return i
+		}
+	}
+	return -1
+}
+
+// describe returns a one-line description of an event.
+func describe(event sim.Event) string {
+	shorten := logging.ShortenComponent
+	commas := func(xs []string) string { return strings.Join(xs, ", ") }
+	switch x := event.(type) {
+	case sim.EventOpStart:
+		return fmt.Sprintf("[%d:%d] op %d: %s(%s)", x.TraceID, x.SpanID, x.TraceID, x.Name, commas(x.Args))
+	case sim.EventOpFinish:
+		if x.Result != "" {
+			return fmt.Sprintf("[%d:%d] op %d returns %s, %s", x.TraceID, x.SpanID, x.TraceID, x.Result, x.Error)
+		}
+		return fmt.Sprintf("[%d:%d] op %d returns %s", x.TraceID, x.SpanID, x.TraceID, x.Error)
+	case sim.EventCall:
+		return fmt.Sprintf("[%d:%d] %s %d calls %s.%s(%s)", x.TraceID, x.SpanID, shorten(x.Caller), x.Replica, shorten(x.Component), x.Method, commas(x.Args))
+	case sim.EventDeliverCall:
+		return fmt.Sprintf("[%d:%d] call delivered to %s %d", x.TraceID, x.SpanID, shorten(x.Component), x.Replica)
+	case sim.EventReturn:
+		return fmt.Sprintf("[%d:%d] %s %d returns %s", x.TraceID, x.SpanID, shorten(x.Component), x.Replica, commas(x.Returns))
+	case sim.EventDeliverReturn:
+		return fmt.Sprintf("[%d:%d] return delivered", x.TraceID, x.SpanID)
+	case sim.EventDeliverError:
+		return fmt.Sprintf("[%d:%d] call failed with RemoteCallError", x.TraceID, x.SpanID)
+	case sim.EventPanic:
+		return fmt.Sprintf("[%d:%d] %s %d panics: %s", x.TraceID, x.SpanID, shorten(x.Panicker), x.Replica, x.Error)
+	case sim.EventSnapshot:
+		return fmt.Sprintf("[%d:%d] %s %d state: %s", x.TraceID, x.SpanID, shorten(x.Component), x.Replica, x.State)
+	case sim.EventUpgrade:
+		return fmt.Sprintf("%s %d upgraded", shorten(x.Component), x.Replica)
+	case sim.EventUpdateConfig:
+		return fmt.Sprintf("config of %s %d updated: %s", shorten(x.Component), x.Replica, strings.Join(strings.Fields(x.Config), " "))
+	default:
+		return fmt.Sprintf("%+v", event)
+	}
+}
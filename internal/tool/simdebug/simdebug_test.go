@@ -0,0 +1,135 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simdebug
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/sim"
+)
+
+var history = []sim.Event{
+	sim.EventOpStart{TraceID: 1, SpanID: 1, Name: "Deposit", Args: []string{"alice", "10"}},
+	sim.EventCall{TraceID: 1, SpanID: 2, Parent: 1, Caller: "op", Replica: 1, Component: "bank/Store", Method: "Add", Args: []string{"alice", "10"}},
+	sim.EventDeliverCall{TraceID: 1, SpanID: 2, Component: "bank/Store", Replica: 0},
+	sim.EventReturn{TraceID: 1, SpanID: 2, Component: "bank/Store", Replica: 0, Returns: []string{"10", "<nil>"}},
+	sim.EventSnapshot{TraceID: 1, SpanID: 2, Component: "bank/Store", Replica: 0, State: "map[alice:10]"},
+	sim.EventDeliverReturn{TraceID: 1, SpanID: 2},
+	sim.EventOpFinish{TraceID: 1, SpanID: 1, Error: "<nil>"},
+	sim.EventOpStart{TraceID: 2, SpanID: 3, Name: "Withdraw", Args: []string{"alice", "20"}},
+	sim.EventOpFinish{TraceID: 2, SpanID: 3, Error: "negative balance"},
+}
+
+// run runs the debugger on history with the provided commands and returns
+// its output.
+func run(t *testing.T, commands ...string) string {
+	t.Helper()
+	var out strings.Builder
+	d := &debugger{history: history, out: &out}
+	if err := d.repl(strings.NewReader(strings.Join(commands, "\n") + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestDebugger(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		commands []string
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "Next",
+			commands: []string{"next", "n 2"},
+			want:     []string{"0  [1:1] op 1: Deposit(alice, 10)", "1  [1:2] op 1 calls bank.Store.Add(alice, 10)", "2  [1:2] call delivered to bank.Store 0"},
+		},
+		{
+			name:     "RepeatLast",
+			commands: []string{"n", "", ""},
+			want:     []string{"2  [1:2] call delivered to bank.Store 0"},
+		},
+		{
+			name:     "EndOfHistory",
+			commands: []string{"n 100"},
+			want:     []string{"8  [2:3] op 2 returns negative balance", "End of history."},
+		},
+		{
+			name:     "Prev",
+			commands: []string{"n 3", "prev 2"},
+			want:     []string{"0  [1:1] op 1: Deposit"},
+		},
+		{
+			name:     "StartOfHistory",
+			commands: []string{"n", "p 5"},
+			want:     []string{"Start of history."},
+		},
+		{
+			name:     "Goto",
+			commands: []string{"goto 3"},
+			want:     []string{"3  [1:2] bank.Store 0 returns 10, <nil>"},
+		},
+		{
+			name:     "Violation",
+			commands: []string{"violation"},
+			want:     []string{"8  [2:3] op 2 returns negative balance"},
+		},
+		{
+			name:     "State",
+			commands: []string{"g 5", "state"},
+			want:     []string{"bank.Store 0 (as of [1:2]): map[alice:10]"},
+		},
+		{
+			name:     "NoState",
+			commands: []string{"g 2", "state"},
+			want:     []string{"No replica state recorded yet."},
+		},
+		{
+			name:     "List",
+			commands: []string{"g 4", "list 3"},
+			want:     []string{" 3  [1:2] bank.Store 0 returns", ">   4  [1:2] bank.Store 0 state", " 5  [1:2] return delivered"},
+		},
+		{
+			name:     "Quit",
+			commands: []string{"quit", "next"},
+			notWant:  []string{"Deposit"},
+		},
+		{
+			name:     "Unknown",
+			commands: []string{"jump"},
+			want:     []string{`unknown command "jump"`},
+		},
+		{
+			name:     "InvalidCount",
+			commands: []string{"next x"},
+			want:     []string{`invalid count "x"`},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			out := run(t, test.commands...)
+			for _, want := range test.want {
+				if !strings.Contains(out, want) {
+					t.Errorf("output missing %q:\n%s", want, out)
+				}
+			}
+			for _, notWant := range test.notWant {
+				if strings.Contains(out, notWant) {
+					t.Errorf("output unexpectedly contains %q:\n%s", notWant, out)
+				}
+			}
+		})
+	}
+}
@@ -31,6 +31,7 @@ var (
 	dataDir      = filepath.Join(must.Must(runtime.DataDir()), "single")
 	RegistryDir  = filepath.Join(dataDir, "registry")
 	PerfettoFile = filepath.Join(dataDir, "traces.DB")
+	AuditDir     = filepath.Join(dataDir, "audit")
 
 	dashboardSpec = &status.DashboardSpec{
 		Tool:         "weaver single",
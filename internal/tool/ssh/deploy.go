@@ -15,7 +15,6 @@
 package ssh
 
 import (
-	"bufio"
 	"context"
 	"errors"
 	"flag"
@@ -26,15 +25,21 @@ import (
 	"os/signal"
 	"os/user"
 	"path/filepath"
+	"slices"
 	"strings"
 	"syscall"
 
 	"github.com/google/uuid"
-	"golang.org/x/exp/maps"
 
+	"github.com/ServiceWeaver/weaver/internal/isolation"
+	"github.com/ServiceWeaver/weaver/internal/limits"
+	"github.com/ServiceWeaver/weaver/internal/resources"
+	"github.com/ServiceWeaver/weaver/internal/slo"
+	"github.com/ServiceWeaver/weaver/internal/standby"
 	itool "github.com/ServiceWeaver/weaver/internal/tool"
 	"github.com/ServiceWeaver/weaver/internal/tool/config"
 	"github.com/ServiceWeaver/weaver/internal/tool/ssh/impl"
+	"github.com/ServiceWeaver/weaver/internal/tuning"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/bin"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
@@ -49,13 +54,18 @@ const (
 	shortConfigKey = "ssh"
 )
 
-var deployCmd = tool.Command{
-	Name:        "deploy",
-	Description: "Deploy a Service Weaver app",
-	Help:        "Usage:\n  weaver ssh deploy <configfile>",
-	Flags:       flag.NewFlagSet("deploy", flag.ContinueOnError),
-	Fn:          deploy,
-}
+var (
+	deployFlags = flag.NewFlagSet("deploy", flag.ContinueOnError)
+	deployForce = deployFlags.Bool("force", false, "Deploy even if the error budget of a service level objective is exhausted")
+
+	deployCmd = tool.Command{
+		Name:        "deploy",
+		Description: "Deploy a Service Weaver app",
+		Help:        "Usage:\n  weaver ssh deploy [--force] <configfile>",
+		Flags:       deployFlags,
+		Fn:          deploy,
+	}
+)
 
 // deploy deploys an application on a cluster of machines using an SSH deployer.
 // Note that each component is deployed as a separate OS process.
@@ -127,20 +137,35 @@ persists, please file an issue at https://github.com/ServiceWeaver/weaver/issues
 			binary, versions.ModuleVersion, selfVersion)
 	}
 
+	// Refuse to deploy while the error budgets of the app's service level
+	// objectives are exhausted.
+	if !*deployForce {
+		if err := slo.CheckDeploy(ctx, app, impl.SloFile); err != nil {
+			return fmt.Errorf("%w\nPass --force to deploy anyway", err)
+		}
+	}
+
 	// Retrieve the list of locations to deploy.
-	locs, err := getLocations(config)
+	locations, err := getLocations(config)
 	if err != nil {
 		return err
 	}
+	if err := validateGroups(config, locations); err != nil {
+		return err
+	}
+	locs := make([]string, len(locations))
+	for i, loc := range locations {
+		locs[i] = loc.Addr
+	}
 
 	// Copy the binaries to each location.
-	locations, err := copyBinaries(locs, app.Binary, config.DepId)
+	dirs, err := copyBinaries(locs, app.Binary, config.DepId)
 	if err != nil {
 		return err
 	}
 
 	// Run the manager.
-	stopFn, err := impl.RunManager(ctx, config, locations)
+	stopFn, err := impl.RunManager(ctx, config, dirs)
 	if err != nil {
 		return fmt.Errorf("cannot instantiate the manager: %w", err)
 	}
@@ -179,6 +204,101 @@ persists, please file an issue at https://github.com/ServiceWeaver/weaver/issues
 	}
 }
 
+// validateGroups checks that the resource limits, placement constraints, and
+// Go runtime tuning in the provided config are valid and keyed by the names of
+// the application's colocation groups, and that every group's placement
+// constraints and resource requirements are satisfied by at least one of the
+// provided locations.
+func validateGroups(config *impl.SshConfig, locations []*impl.Location) error {
+	tuningConfig, err := tuning.ParseConfig(config.App)
+	if err != nil {
+		return err
+	}
+	standbyConfig, err := standby.ParseConfig(config.App)
+	if err != nil {
+		return err
+	}
+	if len(standbyConfig.Replicas) > 0 {
+		return fmt.Errorf("standby replicas are not supported by the SSH deployer")
+	}
+	isolationConfig, err := isolation.ParseConfig(config.App)
+	if err != nil {
+		return err
+	}
+	if len(isolationConfig.Components) > 0 {
+		return fmt.Errorf("isolated components are not supported by the SSH deployer")
+	}
+	resourcesConfig, err := resources.ParseConfig(config.App)
+	if err != nil {
+		return err
+	}
+	if len(config.Limits) == 0 && len(config.Placement) == 0 && len(tuningConfig.Groups) == 0 && len(resourcesConfig.Components) == 0 {
+		return nil
+	}
+
+	// A colocation group is named after its first component. Components that
+	// aren't colocated are in a group by themselves.
+	components, _, err := bin.ReadComponentGraph(config.App.Binary)
+	if err != nil {
+		return fmt.Errorf("cannot read the components from the application binary: %w", err)
+	}
+	groups := map[string]bool{}
+	for _, c := range components {
+		groups[c] = true
+	}
+	colocation := map[string]string{}
+	for _, group := range config.App.Colocate {
+		for i := 1; i < len(group.Components); i++ {
+			delete(groups, group.Components[i])
+			colocation[group.Components[i]] = group.Components[0]
+		}
+	}
+
+	for name, l := range config.Limits {
+		if !groups[name] {
+			return fmt.Errorf("limits specified for unknown colocation group %q", name)
+		}
+		if err := (limits.Limits{CPUs: l.Cpus, Memory: l.Memory}).Validate(); err != nil {
+			return fmt.Errorf("invalid limits for colocation group %q: %w", name, err)
+		}
+	}
+	for name := range tuningConfig.Groups {
+		if !groups[name] {
+			return fmt.Errorf("tuning specified for unknown colocation group %q", name)
+		}
+	}
+	for name, placement := range config.Placement {
+		if !groups[name] {
+			return fmt.Errorf("placement specified for unknown colocation group %q", name)
+		}
+		if !slices.ContainsFunc(locations, func(loc *impl.Location) bool {
+			return impl.Satisfies(loc.Labels, placement)
+		}) {
+			return fmt.Errorf("no location satisfies the placement constraints of colocation group %q", name)
+		}
+	}
+	requirements, err := resourcesConfig.Groups(func(component string) (string, bool) {
+		if !slices.Contains(components, component) {
+			return "", false
+		}
+		if group, ok := colocation[component]; ok {
+			return group, true
+		}
+		return component, true
+	})
+	if err != nil {
+		return err
+	}
+	for name, r := range requirements {
+		if !slices.ContainsFunc(locations, func(loc *impl.Location) bool {
+			return impl.Satisfies(loc.Labels, config.Placement[name]) && r.Satisfies(loc.Labels)
+		}) {
+			return fmt.Errorf("no location has the resources required by colocation group %q (%v)", name, r)
+		}
+	}
+	return nil
+}
+
 // copyBinaries copies the tool and the application binary
 // to the given set of locations. It produces a map which
 // returns the paths to the directories where the binaries
@@ -224,33 +344,23 @@ func terminateDeployment(locs []string, depId string) error {
 	return nil
 }
 
-// getLocations returns the list of locations at which to deploy the application.
-func getLocations(config *impl.SshConfig) ([]string, error) {
+// getLocations returns the list of locations at which to deploy the
+// application. It also makes the path of the locations file in the provided
+// config absolute, so that the manager can re-read the file.
+func getLocations(config *impl.SshConfig) ([]*impl.Location, error) {
 	file, err := getAbsoluteFilePath(config.Locations)
 	if err != nil {
 		return nil, err
 	}
-	readFile, err := os.Open(file)
+	config.Locations = file
+	locations, err := impl.ReadLocations(file)
 	if err != nil {
-		return nil, fmt.Errorf("unable to open locations file: %w", err)
-	}
-	defer readFile.Close()
-
-	locations := map[string]bool{}
-	fileScanner := bufio.NewScanner(readFile)
-	fileScanner.Split(bufio.ScanLines)
-	for fileScanner.Scan() {
-		loc := fileScanner.Text()
-		if _, ok := locations[loc]; ok {
-			return nil, fmt.Errorf("duplicate locations in the locations file")
-		}
-		locations[loc] = true
+		return nil, err
 	}
-
 	if len(locations) == 0 {
 		return nil, fmt.Errorf("no locations to deploy using the ssh deployer")
 	}
-	return maps.Keys(locations), nil
+	return locations, nil
 }
 
 // getAbsoluteFilePath returns the absolute path for a file.
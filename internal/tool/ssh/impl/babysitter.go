@@ -16,6 +16,7 @@ package impl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -23,14 +24,20 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/limits"
 	"github.com/ServiceWeaver/weaver/internal/proto"
+	"github.com/ServiceWeaver/weaver/internal/status"
+	"github.com/ServiceWeaver/weaver/internal/tuning"
 	"github.com/ServiceWeaver/weaver/runtime/envelope"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/payloads"
 	"github.com/ServiceWeaver/weaver/runtime/protomsg"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/ServiceWeaver/weaver/runtime/retry"
 	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // babysitter starts and manages weavelets belonging to a single colocation
@@ -44,6 +51,7 @@ type babysitter struct {
 
 	mu                  sync.Mutex
 	watchingRoutingInfo map[string]bool
+	exported            map[string]string // exported listener addresses, by listener
 }
 
 var _ envelope.EnvelopeHandler = &babysitter{}
@@ -62,7 +70,34 @@ func RunBabysitter(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("cannot create log storage: %w", err)
 	}
-	logSaver := fs.Add
+
+	// Run the weavelet, restarting it whenever it is killed for exceeding its
+	// resource limits. Stop when the manager re-places the replica on another
+	// location.
+	babysitterCtx, stop := context.WithCancel(ctx)
+	defer stop()
+	for {
+		restart, err := runWeavelet(babysitterCtx, stop, info, fs.Add)
+		if restart {
+			continue
+		}
+		if babysitterCtx.Err() != nil && ctx.Err() == nil {
+			// The manager stopped the babysitter.
+			return nil
+		}
+		return err
+	}
+}
+
+// runWeavelet starts a weavelet and manages it until it exits. It returns
+// true if the weavelet was killed for exceeding its resource limits and
+// should be restarted. It calls stop if the manager asks the babysitter to
+// stop.
+func runWeavelet(ctx context.Context, stop func(), info *BabysitterInfo, logSaver func(*protos.LogEntry)) (bool, error) {
+	// Stop watching for components, routing info, and metrics once the
+	// weavelet exits.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	id := uuid.New().String()
 	b := &babysitter{
@@ -87,6 +122,7 @@ func RunBabysitter(ctx context.Context) error {
 			})
 		},
 		watchingRoutingInfo: map[string]bool{},
+		exported:            map[string]string{},
 	}
 
 	// Start the envelope.
@@ -95,12 +131,21 @@ func RunBabysitter(ctx context.Context) error {
 		DeploymentId: info.DepId,
 		Id:           id,
 		RunMain:      info.RunMain,
+		Group:        info.Group,
+		ReplicaIndex: info.ReplicaId,
+		Locality:     info.Locality,
+	}
+	tuningConfig, err := tuning.ParseConfig(info.App)
+	if err != nil {
+		return false, err
 	}
-	e, err := envelope.NewEnvelope(ctx, wlet, info.App, envelope.Options{
+	groupLimits := limits.Limits{CPUs: info.Limits.GetCpus(), Memory: info.Limits.GetMemory()}
+	app := tuningConfig.Apply(info.App, info.Group, groupLimits)
+	e, err := envelope.NewEnvelope(ctx, wlet, app, envelope.Options{
 		Logger: b.logger,
 	})
 	if err != nil {
-		return err
+		return false, err
 	}
 	b.envelope = e
 
@@ -108,12 +153,71 @@ func RunBabysitter(ctx context.Context) error {
 	if !ok {
 		panic("ssh deployer child must be a real process")
 	}
+
+	// Confine the weavelet to the group's resource limits.
+	var lg *limits.Group
+	if !groupLimits.IsZero() {
+		lg, err = limits.New("weaver-"+id, groupLimits)
+		if err != nil {
+			return false, fmt.Errorf("cannot enforce the resource limits of colocation group %q: %w", info.Group, err)
+		}
+		if err := lg.Add(pid); err != nil {
+			return false, errors.Join(fmt.Errorf("cannot enforce the resource limits of colocation group %q: %w", info.Group, err), lg.Close())
+		}
+	}
+
 	if err := b.registerReplica(e.WeaveletAddress(), pid, id); err != nil {
-		return err
+		return false, err
 	}
+	go b.sendHeartbeats(stop)
 	c := metricsCollector{logger: b.logger, envelope: e, info: info}
 	go c.run(ctx)
-	return e.Serve(b)
+	err = e.Serve(b)
+	if lg == nil {
+		return false, err
+	}
+	return b.exceeded(lg, id, err)
+}
+
+// exceeded is called when the weavelet with the provided id, confined to
+// resource limits, exits. If the weavelet was killed for exceeding its memory limit, exceeded notifies
+// the manager and returns true.
+func (b *babysitter) exceeded(lg *limits.Group, id string, err error) (bool, error) {
+	exceeded, eerr := lg.Exceeded()
+	if cerr := lg.Close(); cerr != nil {
+		b.logger.Error("Cannot release resource limits", "err", cerr)
+	}
+	if eerr != nil {
+		return false, errors.Join(err, fmt.Errorf("cannot check resource limits: %w", eerr))
+	}
+	if !exceeded {
+		return false, err
+	}
+
+	reason := fmt.Sprintf("memory limit of %s exceeded", payloads.FormatSize(float64(b.info.Limits.GetMemory())))
+	b.logger.Error("Replica killed for exceeding its resource limits; restarting", "group", b.info.Group, "replica", b.info.ReplicaId, "reason", reason)
+	b.mu.Lock()
+	listeners := maps.Clone(b.exported)
+	b.mu.Unlock()
+	if err := protomsg.Call(b.ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    b.info.ManagerAddr,
+		URLPath: limitExceededURL,
+		Request: &LimitExceeded{
+			Event: &status.LimitEvent{
+				Time:       timestamppb.Now(),
+				Group:      b.info.Group,
+				Replica:    b.info.ReplicaId,
+				WeaveletId: id,
+				Reason:     reason,
+			},
+			Address:   b.envelope.WeaveletAddress(),
+			Listeners: listeners,
+		},
+	}); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 type metricsCollector struct {
@@ -158,6 +262,44 @@ func (m *metricsCollector) run(ctx context.Context) {
 	}
 }
 
+// sendHeartbeats periodically sends heartbeats to the manager, until the
+// babysitter's context is canceled. It calls stop if the manager replies that
+// the babysitter should stop.
+func (b *babysitter) sendHeartbeats(stop func()) {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.mu.Lock()
+			listeners := maps.Clone(b.exported)
+			b.mu.Unlock()
+			reply := &HeartbeatReply{}
+			if err := protomsg.Call(b.ctx, protomsg.CallArgs{
+				Client:  http.DefaultClient,
+				Addr:    b.info.ManagerAddr,
+				URLPath: heartbeatURL,
+				Request: &Heartbeat{
+					Group:     b.info.Group,
+					ReplicaId: b.info.ReplicaId,
+					Listeners: listeners,
+				},
+				Reply: reply,
+			}); err != nil {
+				b.logger.Error("Cannot send heartbeat", "err", err)
+				continue
+			}
+			if reply.Stop {
+				b.logger.Info("Replica re-placed on another location; stopping", "group", b.info.Group, "replica", b.info.ReplicaId)
+				stop()
+				return
+			}
+		case <-b.ctx.Done():
+			return
+		}
+	}
+}
+
 // ActivateComponent implements the protos.EnvelopeHandler interface.
 func (b *babysitter) ActivateComponent(_ context.Context, req *protos.ActivateComponentRequest) (*protos.ActivateComponentReply, error) {
 	if err := protomsg.Call(b.ctx, protomsg.CallArgs{
@@ -190,6 +332,7 @@ func (b *babysitter) registerReplica(replicaAddr string, pid int, weaveletId str
 			Address:    replicaAddr,
 			Pid:        int64(pid),
 			WeaveletId: weaveletId,
+			ReplicaId:  b.info.ReplicaId,
 		},
 	}); err != nil {
 		return err
@@ -220,6 +363,11 @@ func (b *babysitter) ExportListener(_ context.Context, req *protos.ExportListene
 	}); err != nil {
 		return nil, err
 	}
+	if reply.Error == "" {
+		b.mu.Lock()
+		b.exported[req.Listener] = req.Address
+		b.mu.Unlock()
+	}
 	return reply, nil
 }
 
@@ -331,6 +479,20 @@ func (b *babysitter) LogBatch(_ context.Context, req *protos.LogEntryBatch) erro
 	return nil
 }
 
+// GetTopology implements the protos.EnvelopeHandler interface.
+func (b *babysitter) GetTopology(ctx context.Context, _ *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	reply := &protos.GetTopologyReply{}
+	if err := protomsg.Call(ctx, protomsg.CallArgs{
+		Client:  http.DefaultClient,
+		Addr:    b.info.ManagerAddr,
+		URLPath: getTopologyURL,
+		Reply:   reply,
+	}); err != nil {
+		return nil, err
+	}
+	return reply, nil
+}
+
 // HandleTraceSpans implements the protos.EnvelopeHandler interface.
 func (b *babysitter) HandleTraceSpans(_ context.Context, spans *protos.TraceSpans) error {
 	return b.exportTraces(spans)
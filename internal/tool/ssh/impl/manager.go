@@ -24,13 +24,18 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/alerts"
+	"github.com/ServiceWeaver/weaver/internal/history"
 	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
 	"github.com/ServiceWeaver/weaver/internal/must"
+	"github.com/ServiceWeaver/weaver/internal/resources"
 	"github.com/ServiceWeaver/weaver/internal/routing"
+	"github.com/ServiceWeaver/weaver/internal/slo"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
@@ -45,6 +50,7 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/protomsg"
 	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"github.com/ServiceWeaver/weaver/runtime/topology"
 	"github.com/google/uuid"
 )
 
@@ -58,10 +64,21 @@ const (
 	recvLogEntryURL         = "/manager/recv_log_entry"
 	recvTraceSpansURL       = "/manager/recv_trace_spans"
 	recvMetricsURL          = "/manager/recv_metrics"
+	getTopologyURL          = "/manager/get_topology"
+	limitExceededURL        = "/manager/limit_exceeded"
+	heartbeatURL            = "/manager/heartbeat"
 
 	// babysitterInfoKey is the name of the env variable that contains deployment
 	// information for a babysitter deployed using SSH.
 	babysitterInfoKey = "SERVICEWEAVER_BABYSITTER_INFO"
+
+	// heartbeatInterval is how often babysitters send heartbeats to the
+	// manager, and how often the manager checks the placement of replicas.
+	heartbeatInterval = 5 * time.Second
+
+	// heartbeatTimeout is how long the manager waits for a heartbeat from a
+	// babysitter before it considers the babysitter's location lost.
+	heartbeatTimeout = 30 * time.Second
 )
 
 var (
@@ -72,6 +89,8 @@ var (
 	dataDir      = filepath.Join(must.Must(runtime.DataDir()), "ssh")
 	registryDir  = filepath.Join(dataDir, "registry")
 	PerfettoFile = filepath.Join(dataDir, "traces.DB")
+	HistoryFile  = filepath.Join(dataDir, "history.DB")
+	SloFile      = filepath.Join(dataDir, "slo.DB")
 )
 
 // manager manages an application version deployment across a set of locations,
@@ -111,26 +130,56 @@ type manager struct {
 	// statsProcessor tracks and computes stats to be rendered on the /statusz page.
 	statsProcessor *imetrics.StatsProcessor
 
+	// topology computes the live component graph from collected metrics.
+	topology *topology.Tracker
+
+	// history records the events of the deployment.
+	history *history.Recorder
+
 	// colocation maps a component to the name of its colocation group. If a
 	// component is missing in the map, then it is in a colocation group by
 	// itself.
 	colocation map[string]string
 
-	mu      sync.Mutex                                    // guards following structures, but not contents
-	groups  map[string]*group                             // groups, by group name
-	proxies map[string]*proxyInfo                         // proxies, by listener name
-	metrics map[groupReplicaInfo][]*protos.MetricSnapshot // latest metrics, by group name and replica id
+	// requirements are the resources that the locations of every colocation
+	// group must have, by group name.
+	requirements map[string]*resources.Requirements
+
+	mu           sync.Mutex                                    // guards following structures, but not contents
+	groups       map[string]*group                             // groups, by group name
+	proxies      map[string]*proxyInfo                         // proxies, by listener name
+	metrics      map[groupReplicaInfo][]*protos.MetricSnapshot // latest metrics, by group name and replica id
+	limitEvents  []*status.LimitEvent                          // replicas killed for exceeding limits
+	labels       map[string]map[string]string                  // labels of the listed locations, by location
+	lost         map[string]bool                               // locations that stopped sending heartbeats
+	locationsMod time.Time                                     // modification time of the locations file
 }
 
 type group struct {
 	name       string
 	components *versioned.Versioned[map[string]bool] // started components
 
-	mu        sync.Mutex                                           // guards the following
-	started   bool                                                 // has this group been started?
-	addresses map[string]bool                                      // weavelet addresses
-	routings  map[string]*versioned.Versioned[*protos.RoutingInfo] // routing info, by component
-	replicas  []*status.Replica                                    // stores replica info such as pid, weavelet id
+	mu         sync.Mutex                                           // guards the following
+	started    bool                                                 // has this group been started?
+	runMain    bool                                                 // does this group run main?
+	want       int                                                  // desired number of replicas
+	nextId     int32                                                // id of the next replica
+	placements map[int32]*placement                                 // running replicas, by replica id
+	unplaced   bool                                                 // are some replicas missing a location?
+	addresses  map[string]bool                                      // weavelet addresses
+	localities map[string]*protos.Locality                          // weavelet localities, by address
+	routings   map[string]*versioned.Versioned[*protos.RoutingInfo] // routing info, by component
+	replicas   []*status.Replica                                    // stores replica info such as pid, weavelet id
+}
+
+// A placement is a replica of a colocation group placed on a location.
+type placement struct {
+	location   string            // location of the replica
+	heartbeat  time.Time         // when the last heartbeat was received
+	address    string            // weavelet address, once registered
+	weaveletId string            // weavelet id, once registered
+	listeners  map[string]string // exported listener addresses, by listener
+	locality   *protos.Locality  // region and zone of the location
 }
 
 type proxyInfo struct {
@@ -175,6 +224,45 @@ func RunManager(ctx context.Context, config *SshConfig, locations map[string]str
 		return traceDB.Store(ctx, app.Name, config.DepId, spans)
 	}
 
+	// Parse the alerting rules.
+	alertsConfig, err := alerts.ParseConfig(app)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the history recorder.
+	historyDB, err := history.OpenDB(ctx, HistoryFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open history database: %w", err)
+	}
+
+	// Parse the service level objectives.
+	sloConfig, err := slo.ParseConfig(app)
+	if err != nil {
+		return nil, err
+	}
+	var sloDB *slo.DB
+	if sloConfig != nil {
+		sloDB, err = slo.OpenDB(ctx, SloFile)
+		if err != nil {
+			return nil, fmt.Errorf("cannot open SLO database: %w", err)
+		}
+	}
+
+	// Read the labels of the locations.
+	info, err := os.Stat(config.Locations)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open locations file: %w", err)
+	}
+	locs, err := ReadLocations(config.Locations)
+	if err != nil {
+		return nil, err
+	}
+	labels := map[string]map[string]string{}
+	for _, loc := range locs {
+		labels[loc.Addr] = loc.Labels
+	}
+
 	// Form co-location.
 	colocation := map[string]string{}
 	for _, group := range app.Colocate {
@@ -183,6 +271,21 @@ func RunManager(ctx context.Context, config *SshConfig, locations map[string]str
 		}
 	}
 
+	// Compute the resources that every group requires.
+	resourcesConfig, err := resources.ParseConfig(app)
+	if err != nil {
+		return nil, err
+	}
+	requirements, err := resourcesConfig.Groups(func(component string) (string, bool) {
+		if group, ok := colocation[component]; ok {
+			return group, true
+		}
+		return component, true
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Create the manager.
 	m := &manager{
 		ctx:            ctx,
@@ -192,33 +295,36 @@ func RunManager(ctx context.Context, config *SshConfig, locations map[string]str
 		logSaver:       logSaver,
 		traceSaver:     traceSaver,
 		statsProcessor: imetrics.NewStatsProcessor(),
+		topology:       topology.NewTracker(topology.DefaultWindow),
+		history:        history.NewRecorder(historyDB, app.Name, config.DepId, logger),
 		started:        time.Now(),
 		colocation:     colocation,
+		requirements:   requirements,
 		groups:         map[string]*group{},
 		proxies:        map[string]*proxyInfo{},
 		metrics:        map[groupReplicaInfo][]*protos.MetricSnapshot{},
+		labels:         labels,
+		lost:           map[string]bool{},
+		locationsMod:   info.ModTime(),
 	}
 
 	// Run the manager.
+	m.history.Deployment(history.DeploymentStarted, app.Binary)
 	go func() {
 		if err := m.run(); err != nil {
 			m.logger.Error("Unable to run the manager", "err", err)
 		}
 	}()
 
+	// Re-place the replicas of lost and drained locations.
+	go m.maintainPlacements()
+
 	// Run the stats collector.
 	go func() {
 		err := m.statsProcessor.CollectMetrics(
 			m.ctx, func() []*metrics.MetricSnapshot {
-
-				m.mu.Lock()
-				defer m.mu.Unlock()
-				var result []*metrics.MetricSnapshot
-				for _, ms := range m.metrics {
-					for _, m := range ms {
-						result = append(result, metrics.UnProto(m))
-					}
-				}
+				result := m.readMetrics()
+				m.topology.Record(time.Now(), result)
 				return result
 			})
 		if err != nil {
@@ -226,7 +332,22 @@ func RunManager(ctx context.Context, config *SshConfig, locations map[string]str
 		}
 	}()
 
+	// Evaluate the alerting rules.
+	if alertsConfig != nil {
+		engine := alerts.NewEngine(alertsConfig, app.Name, config.DepId, logger)
+		go engine.Run(m.ctx, func() ([]*metrics.MetricSnapshot, []alerts.Group) {
+			return m.readMetrics(), m.alertGroups()
+		})
+	}
+
+	// Track the service level objectives.
+	if sloConfig != nil {
+		tracker := slo.NewTracker(sloConfig, app.Name, sloDB, logger)
+		go tracker.Run(m.ctx, m.readMetrics)
+	}
+
 	return func() error {
+		m.history.Deployment(history.DeploymentStopped, "terminated")
 		return m.registry.Unregister(m.ctx, config.DepId)
 	}, nil
 }
@@ -296,6 +417,9 @@ func (m *manager) addHTTPHandlers(mux *http.ServeMux) {
 	mux.HandleFunc(recvLogEntryURL, protomsg.HandlerDo(m.logger, m.handleLogEntry))
 	mux.HandleFunc(recvTraceSpansURL, protomsg.HandlerDo(m.logger, m.handleTraceSpans))
 	mux.HandleFunc(recvMetricsURL, protomsg.HandlerDo(m.logger, m.handleRecvMetrics))
+	mux.HandleFunc(getTopologyURL, protomsg.HandlerThunk(m.logger, m.Topology))
+	mux.HandleFunc(limitExceededURL, protomsg.HandlerDo(m.logger, m.limitExceeded))
+	mux.HandleFunc(heartbeatURL, protomsg.HandlerFunc(m.logger, m.heartbeat))
 }
 
 // registerStatusPages registers the status pages with the provided mux.
@@ -314,10 +438,13 @@ func (m *manager) Status(ctx context.Context) (*status.Status, error) {
 		g.components.Lock()
 		cs := maps.Keys(g.components.Val)
 		g.components.Unlock()
+		g.mu.Lock()
+		replicas := slices.Clone(g.replicas)
+		g.mu.Unlock()
 		for _, component := range cs {
 			c := &status.Component{
 				Name:     component,
-				Replicas: g.replicas,
+				Replicas: replicas,
 			}
 			components = append(components, c)
 
@@ -369,6 +496,7 @@ func (m *manager) Status(ctx context.Context) (*status.Status, error) {
 		Components:     components,
 		Listeners:      listeners,
 		Config:         app,
+		LimitEvents:    m.limitEvents,
 	}, nil
 }
 
@@ -388,6 +516,56 @@ func (m *manager) Profile(context.Context, *protos.GetProfileRequest) (*protos.G
 	return nil, nil
 }
 
+// FlightRecord implements the status.Server interface.
+func (m *manager) FlightRecord(context.Context) (*protos.GetFlightRecordReply, error) {
+	return nil, nil
+}
+
+// Topology implements the status.Server interface.
+func (m *manager) Topology(context.Context) (*protos.GetTopologyReply, error) {
+	return &protos.GetTopologyReply{Topology: m.topology.Record(time.Now(), m.readMetrics())}, nil
+}
+
+// readMetrics returns the latest metrics received from the babysitters.
+func (m *manager) readMetrics() []*metrics.MetricSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var result []*metrics.MetricSnapshot
+	for _, ms := range m.metrics {
+		for _, m := range ms {
+			result = append(result, metrics.UnProto(m))
+		}
+	}
+	// Include the manager's own metrics, e.g., the status of the service
+	// level objectives.
+	return append(result, metrics.Snapshot()...)
+}
+
+// alertGroups returns the state of the started colocation groups, for
+// alerting.
+//
+// REQUIRES: m.mu is NOT held.
+func (m *manager) alertGroups() []alerts.Group {
+	var groups []alerts.Group
+	for _, g := range m.allGroups() {
+		g.components.Lock()
+		components := maps.Keys(g.components.Val)
+		g.components.Unlock()
+
+		g.mu.Lock()
+		if g.started {
+			groups = append(groups, alerts.Group{
+				Name:       g.name,
+				Components: components,
+				Replicas:   g.want,
+				Ready:      len(g.addresses),
+			})
+		}
+		g.mu.Unlock()
+	}
+	return groups
+}
+
 // group returns the named co-location group.
 //
 // REQUIRES: m.mu is not held.
@@ -404,7 +582,9 @@ func (m *manager) group(component string) *group {
 	if !ok {
 		g = &group{
 			name:       name,
+			placements: map[int32]*placement{},
 			addresses:  map[string]bool{},
+			localities: map[string]*protos.Locality{},
 			components: versioned.Version(map[string]bool{}),
 			routings:   map[string]*versioned.Versioned[*protos.RoutingInfo]{},
 			replicas:   []*status.Replica{},
@@ -423,6 +603,16 @@ func (g *group) allAddresses() []string {
 	return maps.Keys(g.addresses) // creates a new slice.
 }
 
+// allLocalities returns a copy of the localities of all current addresses in
+// the group.
+//
+// REQUIRES: g.mu is NOT held.
+func (g *group) allLocalities() map[string]*protos.Locality {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return maps.Clone(g.localities)
+}
+
 // routing returns the RoutingInfo for the provided component.
 //
 // REQUIRES: g.mu is NOT held.
@@ -460,32 +650,103 @@ func (m *manager) registerReplica(_ context.Context, req *ReplicaToRegister) err
 	g := m.group(req.Group)
 
 	// Update addresses and pids.
-	record := func() bool {
+	record := func() (bool, error) {
 		g.mu.Lock()
 		defer g.mu.Unlock()
+		p, ok := g.placements[req.ReplicaId]
+		if !ok {
+			return false, fmt.Errorf("replica %d of group %q has been re-placed", req.ReplicaId, req.Group)
+		}
 		if g.addresses[req.Address] {
 			// Replica already registered.
-			return true
+			return true, nil
 		}
+		p.address = req.Address
+		p.weaveletId = req.WeaveletId
 		g.addresses[req.Address] = true
-		g.replicas = append(g.replicas, &status.Replica{Pid: req.Pid, WeaveletId: req.WeaveletId})
-		return false
+		if p.locality != nil {
+			g.localities[req.Address] = p.locality
+		}
+		g.replicas = append(g.replicas, &status.Replica{Pid: req.Pid, WeaveletId: req.WeaveletId, Location: p.location})
+		return false, nil
 	}
-	if record() {
-		return nil
+	if registered, err := record(); registered || err != nil {
+		return err
+	}
+	m.history.Replica(history.ReplicaReady, req.Group, int(req.ReplicaId), req.WeaveletId, req.Address)
+	g.updateRouting()
+	return nil
+}
+
+// heartbeat handles a heartbeat from a babysitter. It tells the babysitter to
+// stop if its replica has been re-placed on another location.
+func (m *manager) heartbeat(_ context.Context, req *Heartbeat) (*HeartbeatReply, error) {
+	g := m.group(req.Group)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	p, ok := g.placements[req.ReplicaId]
+	if !ok {
+		return &HeartbeatReply{Stop: true}, nil
+	}
+	p.heartbeat = time.Now()
+	p.listeners = req.Listeners
+	return &HeartbeatReply{}, nil
+}
+
+// limitExceeded handles a replica that was killed for exceeding its resource
+// limits. It stops routing traffic to the replica. The babysitter restarts the
+// replica, which then registers itself again.
+func (m *manager) limitExceeded(_ context.Context, req *LimitExceeded) error {
+	event := req.Event
+	m.logger.Error("Replica killed for exceeding its resource limits; restarting", "group", event.Group, "replica", event.Replica, "weavelet", event.WeaveletId, "reason", event.Reason)
+	m.history.Replica(history.ReplicaKilled, event.Group, int(event.Replica), event.WeaveletId, event.Reason)
+
+	g := m.group(event.Group)
+	remove := func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		g.replicas = slices.DeleteFunc(g.replicas, func(r *status.Replica) bool {
+			return r.WeaveletId == event.WeaveletId
+		})
+		if !g.addresses[req.Address] {
+			// Replica never registered.
+			return false
+		}
+		delete(g.addresses, req.Address)
+		delete(g.localities, req.Address)
+		return true
+	}
+	if remove() {
+		g.updateRouting()
 	}
 
-	// Update routing.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for listener, addr := range req.Listeners {
+		if p, ok := m.proxies[listener]; ok {
+			p.proxy.RemoveBackend(addr)
+		}
+	}
+	m.limitEvents = append(m.limitEvents, event)
+	return nil
+}
+
+// updateRouting updates the routing info of the components in the group to
+// match the group's replicas.
+//
+// REQUIRES: g.mu is NOT held.
+func (g *group) updateRouting() {
 	replicas := g.allAddresses()
+	localities := g.allLocalities()
 	for _, routing := range g.routings {
 		routing.Lock()
 		routing.Val.Replicas = replicas
+		routing.Val.Localities = localities
 		if routing.Val.Assignment != nil {
 			routing.Val.Assignment = routingAlgo(routing.Val.Assignment, replicas)
 		}
 		routing.Unlock()
 	}
-	return nil
 }
 
 func (m *manager) exportListener(_ context.Context, req *protos.ExportListenerRequest) (*protos.ExportListenerReply, error) {
@@ -551,11 +812,13 @@ func (m *manager) startComponent(ctx context.Context, req *protos.ActivateCompon
 	// Update the routing info.
 	routing := g.routing(req.Component)
 	addresses := g.allAddresses()
+	localities := g.allLocalities()
 	update := func() {
 		routing.Lock()
 		defer routing.Unlock()
 
 		routing.Val.Replicas = addresses
+		routing.Val.Localities = localities
 		if req.Routed {
 			routing.Val.Assignment = routingAlgo(&protos.Assignment{}, routing.Val.Replicas)
 		}
@@ -575,30 +838,240 @@ func (m *manager) startColocationGroup(g *group, runMain bool) error {
 		return nil
 	}
 	g.started = true
+	g.runMain = runMain
 
-	// Start the colocation group. Right now, the number of replicas for each
-	// colocation group is equal to the number of locations.
+	// Start the colocation group. By default, a colocation group is
+	// replicated on every location that satisfies its placement constraints.
 	//
 	// TODO(rgrandl): Implement some smarter logic to determine the number of
 	// replicas for each group.
-	replicaId := 0
+	eligible := m.eligible(g.name)
+	if len(eligible) == 0 {
+		return fmt.Errorf("no location satisfies the placement constraints of colocation group %q", g.name)
+	}
+	g.want = len(eligible)
+	m.history.Group(history.GroupScaled, g.name, fmt.Sprintf("%d replicas", g.want))
+	for _, loc := range eligible {
+		if err := m.place(g, loc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// place starts a new replica of the provided group on the provided location.
+//
+// REQUIRES: g.mu is held.
+func (m *manager) place(g *group, loc string) error {
+	id := g.nextId
+	g.nextId++
+	m.mu.Lock()
+	locality := localityOf(m.labels[loc])
+	m.mu.Unlock()
+	info := &BabysitterInfo{
+		ManagerAddr: m.mgrAddress,
+		App:         m.config.App,
+		DepId:       m.config.DepId,
+		Group:       g.name,
+		ReplicaId:   id,
+		LogDir:      LogDir,
+		RunMain:     g.runMain,
+		Limits:      m.config.Limits[g.name],
+		Locality:    locality,
+	}
+	if err := m.startBabysitter(loc, info); err != nil {
+		return fmt.Errorf("unable to start babysitter for group %s at location %s: %w\n", g.name, loc, err)
+	}
+	g.placements[id] = &placement{location: loc, heartbeat: time.Now(), locality: locality}
+	m.logger.Info("Started babysitter", "location", loc, "colocation group", g.name, "replica", id)
+	m.history.Replica(history.ReplicaStarted, g.name, int(id), "", "location "+loc)
+	return nil
+}
+
+// eligible returns the locations on which the replicas of the provided group
+// may be placed, sorted by name.
+//
+// REQUIRES: m.mu is NOT held.
+func (m *manager) eligible(group string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var locs []string
 	for loc := range m.locations {
-		info := &BabysitterInfo{
-			ManagerAddr: m.mgrAddress,
-			App:         m.config.App,
-			DepId:       m.config.DepId,
-			Group:       g.name,
-			ReplicaId:   int32(replicaId),
-			LogDir:      LogDir,
-			RunMain:     runMain,
+		if m.isEligible(group, loc) {
+			locs = append(locs, loc)
 		}
-		if err := m.startBabysitter(loc, info); err != nil {
-			return fmt.Errorf("unable to start babysitter for group %s at location %s: %w\n", g.name, loc, err)
+	}
+	slices.Sort(locs)
+	return locs
+}
+
+// isEligible returns true if a replica of the provided group may be placed on
+// the provided location. A location is eligible if it is listed in the
+// locations file, hasn't stopped sending heartbeats, isn't drained, satisfies
+// the group's placement constraints, and has the resources the group requires.
+//
+// REQUIRES: m.mu is held.
+func (m *manager) isEligible(group, loc string) bool {
+	labels, ok := m.labels[loc]
+	if !ok || m.lost[loc] {
+		return false
+	}
+	return Satisfies(labels, m.config.Placement[group]) && m.requirements[group].Satisfies(labels)
+}
+
+// maintainPlacements periodically re-reads the locations file and re-places
+// the replicas running on locations that were drained, removed from the
+// locations file, or stopped sending heartbeats.
+func (m *manager) maintainPlacements() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.reloadLocations()
+			for _, g := range m.allGroups() {
+				m.maintainGroup(g)
+			}
+		case <-m.ctx.Done():
+			return
 		}
-		m.logger.Info("Started babysitter", "location", loc, "colocation group", g.name)
-		replicaId++
 	}
-	return nil
+}
+
+// reloadLocations re-reads the labels of the locations from the locations
+// file, if the file has changed.
+//
+// REQUIRES: m.mu is NOT held.
+func (m *manager) reloadLocations() {
+	info, err := os.Stat(m.config.Locations)
+	if err != nil {
+		m.logger.Error("Cannot read the locations file", "err", err)
+		return
+	}
+	m.mu.Lock()
+	changed := !info.ModTime().Equal(m.locationsMod)
+	m.mu.Unlock()
+	if !changed {
+		return
+	}
+
+	locs, err := ReadLocations(m.config.Locations)
+	if err != nil {
+		m.logger.Error("Cannot read the locations file", "err", err)
+		return
+	}
+	labels := map[string]map[string]string{}
+	for _, loc := range locs {
+		if _, ok := m.locations[loc.Addr]; !ok {
+			m.logger.Warn("Ignoring location added to a running deployment", "location", loc.Addr)
+			continue
+		}
+		labels[loc.Addr] = loc.Labels
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.labels = labels
+	m.locationsMod = info.ModTime()
+}
+
+// maintainGroup re-places the replicas of the provided group that run on
+// locations that are no longer eligible, and places new replicas until the
+// group has the desired number of replicas.
+//
+// REQUIRES: g.mu is NOT held.
+func (m *manager) maintainGroup(g *group) {
+	g.mu.Lock()
+	if !g.started {
+		g.mu.Unlock()
+		return
+	}
+
+	// Remove the replicas on lost and ineligible locations.
+	removed := map[int32]*placement{}
+	for id, p := range g.placements {
+		lost := time.Since(p.heartbeat) > heartbeatTimeout
+		m.mu.Lock()
+		if lost {
+			m.lost[p.location] = true
+		}
+		eligible := m.isEligible(g.name, p.location)
+		m.mu.Unlock()
+		switch {
+		case lost:
+			m.logger.Error("Location stopped sending heartbeats; re-placing replica", "location", p.location, "colocation group", g.name, "replica", id)
+			m.history.Replica(history.ReplicaCrashed, g.name, int(id), p.weaveletId, fmt.Sprintf("location %s stopped sending heartbeats", p.location))
+		case !eligible:
+			m.logger.Info("Location no longer eligible; re-placing replica", "location", p.location, "colocation group", g.name, "replica", id)
+			m.history.Replica(history.ReplicaMoved, g.name, int(id), p.weaveletId, fmt.Sprintf("location %s no longer eligible", p.location))
+		default:
+			continue
+		}
+		removed[id] = p
+		delete(g.placements, id)
+		if p.address != "" {
+			delete(g.addresses, p.address)
+			delete(g.localities, p.address)
+		}
+		g.replicas = slices.DeleteFunc(g.replicas, func(r *status.Replica) bool {
+			return r.WeaveletId == p.weaveletId
+		})
+	}
+
+	// Place new replicas on the least loaded eligible locations.
+	for len(g.placements) < g.want {
+		loc, ok := m.leastLoaded(g)
+		if !ok {
+			if !g.unplaced {
+				m.logger.Error("No eligible location for replica", "colocation group", g.name, "replicas", len(g.placements), "want", g.want)
+			}
+			g.unplaced = true
+			break
+		}
+		if err := m.place(g, loc); err != nil {
+			m.logger.Error("Cannot place replica", "err", err, "colocation group", g.name)
+			break
+		}
+	}
+	if len(g.placements) == g.want {
+		g.unplaced = false
+	}
+	g.mu.Unlock()
+
+	if len(removed) == 0 {
+		return
+	}
+
+	// Stop routing traffic to the removed replicas.
+	g.updateRouting()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, p := range removed {
+		for listener, addr := range p.listeners {
+			if proxy, ok := m.proxies[listener]; ok {
+				proxy.proxy.RemoveBackend(addr)
+			}
+		}
+		delete(m.metrics, groupReplicaInfo{name: g.name, id: id})
+	}
+}
+
+// leastLoaded returns the eligible location that runs the fewest replicas of
+// the provided group, or false if no location is eligible.
+//
+// REQUIRES: g.mu is held.
+func (m *manager) leastLoaded(g *group) (string, bool) {
+	load := map[string]int{}
+	for _, p := range g.placements {
+		load[p.location]++
+	}
+	var best string
+	for _, loc := range m.eligible(g.name) {
+		if best == "" || load[loc] < load[best] {
+			best = loc
+		}
+	}
+	return best, best != ""
 }
 
 func (m *manager) handleLogEntry(_ context.Context, entry *protos.LogEntry) error {
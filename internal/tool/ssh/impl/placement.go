@@ -0,0 +1,122 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	// drainLabel is the label that marks a location as drained. No replicas
+	// are placed on a drained location, and the replicas running on it are
+	// re-placed on other locations.
+	drainLabel = "drain"
+
+	// regionLabel and zoneLabel are the labels that specify the region and
+	// zone of a location. Calls between components prefer replicas in the
+	// caller's zone, then in the caller's region.
+	regionLabel = "region"
+	zoneLabel   = "zone"
+)
+
+// A Location is a machine listed in the locations file.
+type Location struct {
+	Addr   string            // ssh address, e.g., "10.100.12.31"
+	Labels map[string]string // labels, e.g., {"preemptible": "true"}
+}
+
+// ReadLocations reads the locations file. See ParseLocations for the format.
+func ReadLocations(file string) ([]*Location, error) {
+	contents, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open locations file: %w", err)
+	}
+	return ParseLocations(string(contents))
+}
+
+// ParseLocations parses the contents of a locations file. Every line lists
+// the ssh address of a location, optionally followed by whitespace separated
+// key=value labels. Empty lines and lines that start with '#' are ignored.
+// For example:
+//
+//	# On-demand machines.
+//	10.100.12.31 zone=us-east1-b
+//	10.100.12.32 zone=us-east1-c
+//
+//	# Spot machines.
+//	10.100.12.33 zone=us-east1-b preemptible=true
+func ParseLocations(contents string) ([]*Location, error) {
+	var locations []*Location
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(contents))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		loc := &Location{Addr: fields[0], Labels: map[string]string{}}
+		if seen[loc.Addr] {
+			return nil, fmt.Errorf("duplicate location %q in the locations file", loc.Addr)
+		}
+		seen[loc.Addr] = true
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok || key == "" {
+				return nil, fmt.Errorf("invalid label %q for location %q: want key=value", field, loc.Addr)
+			}
+			loc.Labels[key] = value
+		}
+		locations = append(locations, loc)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Satisfies returns true if a location with the provided labels satisfies
+// the provided placement constraints. A nil placement is satisfied by every
+// location that isn't drained.
+func Satisfies(labels map[string]string, placement *SshConfig_Placement) bool {
+	if labels[drainLabel] == "true" {
+		return false
+	}
+	for key, value := range placement.GetRequire() {
+		if labels[key] != value {
+			return false
+		}
+	}
+	for key, value := range placement.GetAvoid() {
+		if labels[key] == value {
+			return false
+		}
+	}
+	return true
+}
+
+// localityOf returns the locality of a location with the provided labels, or
+// nil if the labels specify neither a region nor a zone.
+func localityOf(labels map[string]string) *protos.Locality {
+	region, zone := labels[regionLabel], labels[zoneLabel]
+	if region == "" && zone == "" {
+		return nil
+	}
+	return &protos.Locality{Region: region, Zone: zone}
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package impl
+
+import (
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestParseLocations(t *testing.T) {
+	const contents = `
+# On-demand machines.
+10.100.12.31 zone=us-east1-b
+10.100.12.32
+
+# Spot machines.
+10.100.12.33   zone=us-east1-b preemptible=true
+`
+	got, err := ParseLocations(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Location{
+		{Addr: "10.100.12.31", Labels: map[string]string{"zone": "us-east1-b"}},
+		{Addr: "10.100.12.32", Labels: map[string]string{}},
+		{Addr: "10.100.12.33", Labels: map[string]string{"zone": "us-east1-b", "preemptible": "true"}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ParseLocations (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseLocationsErrors(t *testing.T) {
+	for _, test := range []struct{ name, contents string }{
+		{"duplicate", "10.100.12.31\n10.100.12.31"},
+		{"missing value", "10.100.12.31 preemptible"},
+		{"missing key", "10.100.12.31 =true"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseLocations(test.contents); err == nil {
+				t.Fatalf("ParseLocations(%q): unexpected success", test.contents)
+			}
+		})
+	}
+}
+
+func TestSatisfies(t *testing.T) {
+	avoidPreemptible := &SshConfig_Placement{Avoid: map[string]string{"preemptible": "true"}}
+	requireZone := &SshConfig_Placement{Require: map[string]string{"zone": "b"}}
+	for _, test := range []struct {
+		name      string
+		labels    map[string]string
+		placement *SshConfig_Placement
+		want      bool
+	}{
+		{"no constraints", map[string]string{}, nil, true},
+		{"drained", map[string]string{"drain": "true"}, nil, false},
+		{"on-demand", map[string]string{}, avoidPreemptible, true},
+		{"preemptible", map[string]string{"preemptible": "true"}, avoidPreemptible, false},
+		{"required zone", map[string]string{"zone": "b"}, requireZone, true},
+		{"other zone", map[string]string{"zone": "c"}, requireZone, false},
+		{"no zone", map[string]string{}, requireZone, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Satisfies(test.labels, test.placement); got != test.want {
+				t.Fatalf("Satisfies(%v, %v): got %t, want %t", test.labels, test.placement, got, test.want)
+			}
+		})
+	}
+}
+
+func TestLocalityOf(t *testing.T) {
+	for _, test := range []struct {
+		labels map[string]string
+		want   *protos.Locality
+	}{
+		{map[string]string{}, nil},
+		{map[string]string{"preemptible": "true"}, nil},
+		{map[string]string{"zone": "b"}, &protos.Locality{Zone: "b"}},
+		{map[string]string{"region": "r", "zone": "b"}, &protos.Locality{Region: "r", Zone: "b"}},
+	} {
+		got := localityOf(test.labels)
+		if diff := cmp.Diff(test.want, got, protocmp.Transform()); diff != "" {
+			t.Errorf("localityOf(%v) (-want +got):\n%s", test.labels, diff)
+		}
+	}
+}
@@ -15,8 +15,10 @@
 package ssh
 
 import (
+	"github.com/ServiceWeaver/weaver/internal/history"
 	"github.com/ServiceWeaver/weaver/internal/status"
 	itool "github.com/ServiceWeaver/weaver/internal/tool"
+	"github.com/ServiceWeaver/weaver/internal/tool/ssh/impl"
 	"github.com/ServiceWeaver/weaver/runtime/tool"
 )
 
@@ -25,6 +27,7 @@ var (
 		"deploy":    &deployCmd,
 		"logs":      tool.LogsCmd(&logsSpec),
 		"dashboard": status.DashboardCommand(dashboardSpec),
+		"history":   history.Command("weaver ssh", impl.HistoryFile),
 		"version":   itool.VersionCmd("weaver ssh"),
 
 		// Hidden commands.
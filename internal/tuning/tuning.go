@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tuning tunes the Go runtime of the weavelets of every colocation
+// group.
+//
+// By default, the Go runtime sizes itself for the whole machine: GOMAXPROCS
+// is the number of CPUs of the machine, and the garbage collector ignores how
+// much memory the process may use. A weavelet confined to a fraction of the
+// machine then runs more threads than it has CPUs to run them on, and is
+// killed for exceeding its memory limit before the garbage collector notices
+// memory is tight. The [tuning] section of a config file sets GOMAXPROCS, GOGC,
+// and GOMEMLIMIT for the weavelets of every colocation group:
+//
+//	[tuning.groups."github.com/example/app/Cache"]
+//	gomaxprocs = 2
+//	gogc = 200
+//	gomemlimit = 805306368
+//
+// Settings that aren't set explicitly are derived from the CPU and memory
+// limits of the group or, for a group without limits, from the limits of the
+// cgroup the deployer runs in: GOMAXPROCS is the number of CPUs rounded up,
+// and GOMEMLIMIT is 90% of the memory limit, leaving room for memory not
+// managed by the garbage collector.
+package tuning
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/internal/limits"
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/protomsg"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+const (
+	configKey      = "github.com/ServiceWeaver/weaver/tuning"
+	shortConfigKey = "tuning"
+)
+
+// memoryLimitFraction is the fraction of a memory limit that a derived
+// GOMEMLIMIT allows the Go runtime to use.
+const memoryLimitFraction = 0.9
+
+// Config configures the Go runtime of every colocation group.
+type Config struct {
+	// Groups are the runtime settings of colocation groups, keyed by group
+	// name. A colocation group is named after its first component.
+	Groups map[string]Settings `toml:"groups"`
+}
+
+// Settings are the Go runtime settings of the weavelets of a colocation
+// group. A zero value means the setting is derived from the group's limits,
+// or left to the Go runtime's default if there are no limits.
+type Settings struct {
+	// GOMAXPROCS is the maximum number of CPUs executing Go code at once.
+	GOMAXPROCS int `toml:"gomaxprocs"`
+
+	// GOGC is the garbage collection target percentage. A negative value
+	// turns off the garbage collector, except when GOMEMLIMIT is reached.
+	GOGC int `toml:"gogc"`
+
+	// GOMEMLIMIT is the soft memory limit of the Go runtime, in bytes.
+	GOMEMLIMIT int64 `toml:"gomemlimit"`
+}
+
+// ParseConfig parses the tuning config in the provided app config.
+func ParseConfig(app *protos.AppConfig) (*Config, error) {
+	config := &Config{}
+	if err := runtime.ParseConfigSection(configKey, shortConfigKey, app.Sections, config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Validate validates a config. It is called by runtime.ParseConfigSection.
+func (c *Config) Validate() error {
+	for name, s := range c.Groups {
+		if s.GOMAXPROCS < 0 {
+			return fmt.Errorf("colocation group %q: negative gomaxprocs %d", name, s.GOMAXPROCS)
+		}
+		if s.GOMEMLIMIT < 0 {
+			return fmt.Errorf("colocation group %q: negative gomemlimit %d", name, s.GOMEMLIMIT)
+		}
+	}
+	return nil
+}
+
+// Settings returns the runtime settings of the weavelets of the provided
+// colocation group, whose weavelets are confined to the provided limits.
+func (c *Config) Settings(group string, l limits.Limits) Settings {
+	s := c.Groups[group]
+	if s.GOMAXPROCS == 0 && l.CPUs > 0 {
+		s.GOMAXPROCS = max(1, int(math.Ceil(l.CPUs)))
+	}
+	if s.GOMEMLIMIT == 0 && l.Memory > 0 {
+		s.GOMEMLIMIT = int64(memoryLimitFraction * float64(l.Memory))
+	}
+	return s
+}
+
+// Env returns the environment variables that apply s to a Go program.
+func (s Settings) Env() []string {
+	var env []string
+	if s.GOMAXPROCS > 0 {
+		env = append(env, "GOMAXPROCS="+strconv.Itoa(s.GOMAXPROCS))
+	}
+	switch {
+	case s.GOGC > 0:
+		env = append(env, "GOGC="+strconv.Itoa(s.GOGC))
+	case s.GOGC < 0:
+		env = append(env, "GOGC=off")
+	}
+	if s.GOMEMLIMIT > 0 {
+		env = append(env, "GOMEMLIMIT="+strconv.FormatInt(s.GOMEMLIMIT, 10))
+	}
+	return env
+}
+
+// Apply returns a copy of the provided app config whose environment tunes
+// the Go runtime of the weavelets of the provided colocation group, confined
+// to the provided limits. If the group has no limits, the limits of the
+// calling process are used instead. Variables already set in the app
+// config's environment are left untouched.
+func (c *Config) Apply(app *protos.AppConfig, group string, l limits.Limits) *protos.AppConfig {
+	if self, err := limits.Self(); err == nil {
+		// A group's limits are nested in the calling process's limits.
+		if l.CPUs == 0 || (self.CPUs > 0 && self.CPUs < l.CPUs) {
+			l.CPUs = self.CPUs
+		}
+		if l.Memory == 0 || (self.Memory > 0 && self.Memory < l.Memory) {
+			l.Memory = self.Memory
+		}
+	}
+	env := filterEnv(c.Settings(group, l).Env(), app.Env)
+	if len(env) == 0 {
+		return app
+	}
+	tuned := protomsg.Clone(app)
+	tuned.Env = append(tuned.Env, env...)
+	return tuned
+}
+
+// filterEnv returns the variables in env that aren't set in existing.
+func filterEnv(env, existing []string) []string {
+	set := map[string]bool{}
+	for _, kv := range existing {
+		k, _, _ := strings.Cut(kv, "=")
+		set[k] = true
+	}
+	var filtered []string
+	for _, kv := range env {
+		if k, _, _ := strings.Cut(kv, "="); !set[k] {
+			filtered = append(filtered, kv)
+		}
+	}
+	return filtered
+}
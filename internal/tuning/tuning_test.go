@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tuning
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/limits"
+	"github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	cache     = "github.com/example/app/Cache"
+	mainGroup = "github.com/ServiceWeaver/weaver/Main"
+)
+
+func parse(t *testing.T, config string) *protos.AppConfig {
+	t.Helper()
+	app, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return app
+}
+
+func TestSettings(t *testing.T) {
+	config, err := ParseConfig(parse(t, `
+[tuning.groups."github.com/example/app/Cache"]
+gomaxprocs = 4
+gogc = -1
+
+[tuning.groups."github.com/ServiceWeaver/weaver/Main"]
+gogc = 200
+gomemlimit = 1000
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		group  string
+		limits limits.Limits
+		want   []string
+	}{
+		{"Explicit", cache, limits.Limits{}, []string{"GOMAXPROCS=4", "GOGC=off"}},
+		{"Derived", cache, limits.Limits{CPUs: 0.5, Memory: 1000}, []string{"GOMAXPROCS=4", "GOGC=off", "GOMEMLIMIT=900"}},
+		{"ExplicitMemory", mainGroup, limits.Limits{CPUs: 2.5, Memory: 5000}, []string{"GOMAXPROCS=3", "GOGC=200", "GOMEMLIMIT=1000"}},
+		{"Unconfigured", "other", limits.Limits{CPUs: 0.5}, []string{"GOMAXPROCS=1"}},
+		{"Untuned", "other", limits.Limits{}, nil},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got := config.Settings(test.group, test.limits).Env()
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("Env (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, test := range []struct {
+		name, config, want string
+	}{
+		{"NegativeGOMAXPROCS", "gomaxprocs = -1", "negative gomaxprocs"},
+		{"NegativeGOMEMLIMIT", "gomemlimit = -1", "negative gomemlimit"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			app := parse(t, "[tuning.groups.x]\n"+test.config)
+			_, err := ParseConfig(app)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ParseConfig: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	app := parse(t, `
+[serviceweaver]
+env = ["GOGC=50"]
+
+[tuning.groups."github.com/example/app/Cache"]
+gomaxprocs = 4
+gogc = 200
+`)
+	config, err := ParseConfig(app)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tuned := config.Apply(app, cache, limits.Limits{})
+
+	// GOGC is set in the app config, so it isn't overridden.
+	if !slices.Contains(tuned.Env, "GOMAXPROCS=4") || slices.Contains(tuned.Env, "GOGC=200") {
+		t.Fatalf("Apply: got env %v, want GOMAXPROCS=4 and not GOGC=200", tuned.Env)
+	}
+
+	// The app config isn't modified.
+	if diff := cmp.Diff([]string{"GOGC=50"}, app.Env); diff != "" {
+		t.Fatalf("app env (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/audit"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// auditor records calls to audited component methods in an audit log.
+type auditor struct {
+	app        string          // application name
+	deployment string          // deployment id
+	weavelet   string          // weavelet id
+	dir        string          // directory of the audit log
+	methods    map[string]bool // audited methods, as "<component>.<method>"
+	components map[string]bool // components with at least one audited method
+	syslogger  *slog.Logger    // logger for audit log failures
+
+	mu  sync.Mutex
+	log *audit.Log // opened on the first record
+}
+
+// newAuditor returns an auditor for the provided audited methods, each of the
+// form "<full component name>.<method name>", that writes to an audit log in
+// dir. If dir is empty or there are no audited methods, newAuditor returns
+// nil, and no audit records are written.
+func newAuditor(app, deployment, weavelet, dir string, methods []string, syslogger *slog.Logger) *auditor {
+	if dir == "" || len(methods) == 0 {
+		return nil
+	}
+	a := &auditor{
+		app:        app,
+		deployment: deployment,
+		weavelet:   weavelet,
+		dir:        dir,
+		methods:    map[string]bool{},
+		components: map[string]bool{},
+		syslogger:  syslogger,
+	}
+	for _, method := range methods {
+		a.methods[method] = true
+		a.components[method[:strings.LastIndex(method, ".")]] = true
+	}
+	return a
+}
+
+// wrap returns an implementation of the component interface of the provided
+// registration that forwards calls to stub and records calls to audited
+// methods. If the component has no audited methods, wrap returns stub.
+func (a *auditor) wrap(reg *codegen.Registration, requester string, stub any) any {
+	if a == nil || !a.components[reg.Name] {
+		return stub
+	}
+	v := reflect.ValueOf(stub)
+	call := func(method string, ctx context.Context, args []any, returns []any) error {
+		m := v.MethodByName(method)
+		if !m.IsValid() {
+			return fmt.Errorf("component %q has no method %q", reg.Name, method)
+		}
+		start := time.Now()
+		err := invoke(m, ctx, args, returns)
+		if a.methods[reg.Name+"."+method] {
+			a.record(start, requester, reg.Name, method, args, err)
+		}
+		return err
+	}
+	return reg.ReflectStubFn(call)
+}
+
+// record appends a record of a call to the audit log. Failures are logged,
+// but don't fail the call.
+func (a *auditor) record(start time.Time, caller, component, method string, args []any, err error) {
+	result := audit.OK
+	if err != nil {
+		result = audit.Error
+	}
+	r := &audit.Record{
+		Time:       start,
+		App:        a.app,
+		Deployment: a.deployment,
+		Weavelet:   a.weavelet,
+		Caller:     caller,
+		Component:  component,
+		Method:     method,
+		ArgsHash:   audit.HashArgs(args),
+		Result:     result,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.log == nil {
+		log, _, err := audit.Open(a.dir, a.weavelet)
+		if err != nil {
+			a.syslogger.Error("Failed to open audit log", "dir", a.dir, "err", err)
+			return
+		}
+		a.log = log
+	}
+	if err := a.log.Append(r); err != nil {
+		a.syslogger.Error("Failed to write audit record", "component", component, "method", method, "err", err)
+	}
+}
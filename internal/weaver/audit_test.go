@@ -0,0 +1,111 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/audit"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// account is a component interface with an audited method.
+type account interface {
+	Withdraw(context.Context, int) error
+	Balance(context.Context) (int, error)
+}
+
+// accountImpl is an implementation of account.
+type accountImpl struct {
+	balance int
+}
+
+func (a *accountImpl) Withdraw(_ context.Context, amount int) error {
+	if amount > a.balance {
+		return fmt.Errorf("insufficient funds")
+	}
+	a.balance -= amount
+	return nil
+}
+
+func (a *accountImpl) Balance(context.Context) (int, error) {
+	return a.balance, nil
+}
+
+// accountReflectStub is an account that forwards calls to a call function.
+type accountReflectStub struct {
+	call func(string, context.Context, []any, []any) error
+}
+
+func (s accountReflectStub) Withdraw(ctx context.Context, amount int) error {
+	return s.call("Withdraw", ctx, []any{amount}, nil)
+}
+
+func (s accountReflectStub) Balance(ctx context.Context) (int, error) {
+	var balance int
+	err := s.call("Balance", ctx, nil, []any{&balance})
+	return balance, err
+}
+
+func TestAuditor(t *testing.T) {
+	reg := &codegen.Registration{
+		Name: "pkg/Account",
+		ReflectStubFn: func(call func(string, context.Context, []any, []any) error) any {
+			return accountReflectStub{call: call}
+		},
+	}
+	dir := t.TempDir()
+	syslogger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	a := newAuditor("app", "deployment", "weavelet", dir, []string{"pkg/Account.Withdraw"}, syslogger)
+	acct := a.wrap(reg, "pkg/Caller", &accountImpl{balance: 10}).(account)
+
+	ctx := context.Background()
+	if err := acct.Withdraw(ctx, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := acct.Withdraw(ctx, 50); err == nil {
+		t.Fatal("Withdraw: unexpected success")
+	}
+	if balance, err := acct.Balance(ctx); err != nil || balance != 5 {
+		t.Fatalf("Balance: got (%d, %v), want (5, nil)", balance, err)
+	}
+
+	// Only the two calls to Withdraw are audited.
+	f, err := os.Open(filepath.Join(dir, "audit-weavelet.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	n, err := audit.Verify(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("got %d audit records, want 2", n)
+	}
+}
+
+func TestAuditorDisabled(t *testing.T) {
+	a := newAuditor("app", "deployment", "weavelet", "", []string{"pkg/Account.Withdraw"}, nil)
+	impl := &accountImpl{}
+	if got := a.wrap(&codegen.Registration{Name: "pkg/Account"}, "pkg/Caller", impl); got != impl {
+		t.Fatalf("wrap: got %v, want unwrapped stub", got)
+	}
+}
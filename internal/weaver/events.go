@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// Events delivers deployment events to the components of a weavelet that
+// subscribe to them.
+type Events struct {
+	mu   sync.Mutex
+	next int                                                    // id of the next subscriber
+	subs map[int]func(context.Context, *protos.DeploymentEvent) // subscribers, by id
+}
+
+// newEvents returns a new Events with no subscribers.
+func newEvents() *Events {
+	return &Events{subs: map[int]func(context.Context, *protos.DeploymentEvent){}}
+}
+
+// Subscribe registers fn to be called with every future deployment event. It
+// returns a function that cancels the subscription.
+func (e *Events) Subscribe(fn func(context.Context, *protos.DeploymentEvent)) func() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	id := e.next
+	e.next++
+	e.subs[id] = fn
+	return func() {
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		delete(e.subs, id)
+	}
+}
+
+// publish calls every subscriber with the provided event, one at a time and
+// in the order in which they subscribed. It returns when all of them have
+// returned.
+func (e *Events) publish(ctx context.Context, event *protos.DeploymentEvent) {
+	e.mu.Lock()
+	ids := make([]int, 0, len(e.subs))
+	for id := range e.subs {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	subs := make([]func(context.Context, *protos.DeploymentEvent), len(ids))
+	for i, id := range ids {
+		subs[i] = e.subs[id]
+	}
+	e.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(ctx, event)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEvents(t *testing.T) {
+	ctx := context.Background()
+	events := newEvents()
+	var got []string
+	record := func(name string) func(context.Context, *protos.DeploymentEvent) {
+		return func(_ context.Context, e *protos.DeploymentEvent) {
+			got = append(got, name+":"+e.Kind.String())
+		}
+	}
+	events.Subscribe(record("a"))
+	cancel := events.Subscribe(record("b"))
+	events.Subscribe(record("c"))
+
+	// Subscribers are called in the order in which they subscribed.
+	events.publish(ctx, &protos.DeploymentEvent{Kind: protos.DeploymentEvent_ROLLOUT})
+	want := []string{"a:ROLLOUT", "b:ROLLOUT", "c:ROLLOUT"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("events (-want +got):\n%s", diff)
+	}
+
+	// Cancelled subscribers are not called.
+	got = nil
+	cancel()
+	events.publish(ctx, &protos.DeploymentEvent{Kind: protos.DeploymentEvent_DRAINING})
+	want = []string{"a:DRAINING", "c:DRAINING"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("events (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"github.com/ServiceWeaver/weaver/internal/flightrecorder"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// flightRecord returns the calls recorded by this process's flight recorder,
+// attributed to the provided weavelet.
+func flightRecord(weavelet string) []*protos.CallEvent {
+	snapshot := flightrecorder.Default.Snapshot()
+	events := make([]*protos.CallEvent, len(snapshot))
+	for i, e := range snapshot {
+		events[i] = &protos.CallEvent{
+			TimeMicros:    e.Time.UnixMicro(),
+			Caller:        e.Caller,
+			Component:     e.Component,
+			Method:        e.Method,
+			LatencyMicros: e.Latency.Microseconds(),
+			Failed:        e.Failed,
+			Remote:        e.Remote,
+			Weavelet:      weavelet,
+		}
+	}
+	return events
+}
@@ -0,0 +1,335 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	grpcreflection "google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// healthWatchInterval is how often a gRPC health Watch call re-checks the
+// status of the watched service.
+const healthWatchInterval = time.Second
+
+// serveGRPC serves the standard gRPC health checking and reflection services
+// on the provided listener until ctx is cancelled.
+//
+// The health service reports the status of the weavelet as a whole under the
+// empty service name, and the status of every component hosted by the
+// weavelet under the component's full name. The reflection service describes
+// the hosted components as gRPC services, with one rpc per component method.
+// These services exist only to be described; the component methods cannot be
+// called over gRPC.
+func (w *RemoteWeavelet) serveGRPC(ctx context.Context, lis net.Listener) error {
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, &healthServer{w: w})
+	opts := grpcreflection.ServerOptions{
+		Services:           &componentServices{w: w, server: server},
+		DescriptorResolver: w.componentFiles(),
+		ExtensionResolver:  protoregistry.GlobalTypes,
+	}
+	reflectionv1.RegisterServerReflectionServer(server, grpcreflection.NewServerV1(opts))
+	reflectionv1alpha.RegisterServerReflectionServer(server, grpcreflection.NewServer(opts))
+
+	go func() {
+		<-ctx.Done()
+		server.Stop()
+	}()
+	if err := server.Serve(lis); err != nil && ctx.Err() == nil {
+		w.syslogger.Error("gRPC server failed", "err", err)
+		return err
+	}
+	return nil
+}
+
+// healthStatus returns the health of the provided service, which is either
+// the empty string, for the weavelet as a whole, or the full name of a
+// component hosted by the weavelet.
+func (w *RemoteWeavelet) healthStatus(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	if service != "" {
+		w.hostedMu.Lock()
+		hosted := w.hosted[service]
+		w.hostedMu.Unlock()
+		c, ok := w.componentsByName[service]
+		if !ok || !hosted {
+			return 0, status.Errorf(codes.NotFound, "unknown service %q", service)
+		}
+		if !w.ready(ctx, c) {
+			return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+		}
+	}
+	if w.draining.Load() {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, nil
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil
+}
+
+// healthServer implements the gRPC health checking service for a weavelet.
+type healthServer struct {
+	grpc_health_v1.UnimplementedHealthServer
+	w *RemoteWeavelet
+}
+
+// Check implements the grpc_health_v1.HealthServer interface.
+func (h *healthServer) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	s, err := h.w.healthStatus(ctx, req.Service)
+	if err != nil {
+		return nil, err
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: s}, nil
+}
+
+// Watch implements the grpc_health_v1.HealthServer interface.
+func (h *healthServer) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		s, err := h.w.healthStatus(ctx, req.Service)
+		if err != nil {
+			// Per the health checking protocol, an unknown service is
+			// reported as such, rather than failing the call, because it may
+			// be hosted later.
+			s = grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+		}
+		if s != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: s}); err != nil {
+				return err
+			}
+			last = s
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// componentServices is a reflection.ServiceInfoProvider that returns the
+// services registered with a gRPC server along with a service for every
+// component hosted by a weavelet.
+type componentServices struct {
+	w      *RemoteWeavelet
+	server *grpc.Server
+}
+
+// GetServiceInfo implements the reflection.ServiceInfoProvider interface.
+func (s *componentServices) GetServiceInfo() map[string]grpc.ServiceInfo {
+	info := s.server.GetServiceInfo()
+	s.w.hostedMu.Lock()
+	defer s.w.hostedMu.Unlock()
+	for name := range s.w.hosted {
+		c, ok := s.w.componentsByName[name]
+		if !ok {
+			continue
+		}
+		var methods []grpc.MethodInfo
+		for i := 0; i < c.reg.Iface.NumMethod(); i++ {
+			methods = append(methods, grpc.MethodInfo{Name: c.reg.Iface.Method(i).Name})
+		}
+		info[grpcServiceName(name)] = grpc.ServiceInfo{
+			Methods:  methods,
+			Metadata: grpcFileName(name),
+		}
+	}
+	return info
+}
+
+// componentFiles returns a resolver for the proto descriptors of the weavelet's
+// components, falling back to the globally registered descriptors.
+func (w *RemoteWeavelet) componentFiles() protodesc.Resolver {
+	files := &protoregistry.Files{}
+	for name, c := range w.componentsByName {
+		fd, err := protodesc.NewFile(componentDescriptor(name, c.reg.Iface), files)
+		if err == nil {
+			err = files.RegisterFile(fd)
+		}
+		if err != nil {
+			// Reflection is best effort. A component that can't be described
+			// is simply omitted.
+			w.syslogger.Debug("Cannot describe component", "component", logging.ShortenComponent(name), "err", err)
+		}
+	}
+	return fileResolver{files}
+}
+
+// fileResolver is a protodesc.Resolver that looks up descriptors first in a
+// local registry and then in protoregistry.GlobalFiles.
+type fileResolver struct {
+	local *protoregistry.Files
+}
+
+// FindFileByPath implements the protodesc.Resolver interface.
+func (r fileResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.local.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+// FindDescriptorByName implements the protodesc.Resolver interface.
+func (r fileResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.local.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+// componentDescriptor returns a proto file describing the provided component
+// as a gRPC service. Every method M of the component becomes an rpc that takes
+// an MRequest message, with one field for every argument after the context,
+// and returns an MReply message, with one field for every result before the
+// error. Arguments and results of basic Go types are described with the
+// corresponding proto scalar types; all others are described as bytes.
+func componentDescriptor(component string, iface reflect.Type) *descriptorpb.FileDescriptorProto {
+	pkg, svc := splitServiceName(grpcServiceName(component))
+	fd := &descriptorpb.FileDescriptorProto{
+		Name:    proto.String(grpcFileName(component)),
+		Package: proto.String(pkg),
+		Syntax:  proto.String("proto3"),
+	}
+	service := &descriptorpb.ServiceDescriptorProto{Name: proto.String(svc)}
+	for i := 0; i < iface.NumMethod(); i++ {
+		m := iface.Method(i)
+		req := &descriptorpb.DescriptorProto{Name: proto.String(m.Name + "Request")}
+		for j := 1; j < m.Type.NumIn(); j++ {
+			req.Field = append(req.Field, fieldDescriptor(fmt.Sprintf("arg%d", j-1), j, m.Type.In(j)))
+		}
+		reply := &descriptorpb.DescriptorProto{Name: proto.String(m.Name + "Reply")}
+		for j := 0; j < m.Type.NumOut()-1; j++ {
+			reply.Field = append(reply.Field, fieldDescriptor(fmt.Sprintf("result%d", j), j+1, m.Type.Out(j)))
+		}
+		fd.MessageType = append(fd.MessageType, req, reply)
+		service.Method = append(service.Method, &descriptorpb.MethodDescriptorProto{
+			Name:       proto.String(m.Name),
+			InputType:  proto.String("." + pkg + "." + req.GetName()),
+			OutputType: proto.String("." + pkg + "." + reply.GetName()),
+		})
+	}
+	fd.Service = []*descriptorpb.ServiceDescriptorProto{service}
+	return fd
+}
+
+// fieldDescriptor returns a descriptor for a message field that holds a value
+// of the provided type.
+func fieldDescriptor(name string, number int, t reflect.Type) *descriptorpb.FieldDescriptorProto {
+	label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	if t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		if _, ok := scalarType(t.Elem()); ok {
+			label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+			t = t.Elem()
+		}
+	}
+	typ, ok := scalarType(t)
+	if !ok {
+		typ = descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	}
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(int32(number)),
+		Label:  label.Enum(),
+		Type:   typ.Enum(),
+	}
+}
+
+// scalarType returns the proto scalar type that corresponds to the provided Go
+// type, if any.
+func scalarType(t reflect.Type) (descriptorpb.FieldDescriptorProto_Type, bool) {
+	switch t.Kind() {
+	case reflect.Bool:
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL, true
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT32, true
+	case reflect.Int, reflect.Int64:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64, true
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return descriptorpb.FieldDescriptorProto_TYPE_UINT32, true
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return descriptorpb.FieldDescriptorProto_TYPE_UINT64, true
+	case reflect.Float32:
+		return descriptorpb.FieldDescriptorProto_TYPE_FLOAT, true
+	case reflect.Float64:
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, true
+	case reflect.String:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return descriptorpb.FieldDescriptorProto_TYPE_BYTES, true
+		}
+	}
+	return 0, false
+}
+
+// grpcServiceName returns the fully qualified gRPC service name of the
+// provided component. For example, the component
+// "github.com/example/app/Cache" is described by the service
+// "github.com.example.app.Cache".
+func grpcServiceName(component string) string {
+	parts := strings.FieldsFunc(component, func(r rune) bool { return r == '/' || r == '.' })
+	for i, part := range parts {
+		var b strings.Builder
+		for j, r := range part {
+			switch {
+			case r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z':
+				b.WriteRune(r)
+			case '0' <= r && r <= '9':
+				if j == 0 {
+					b.WriteByte('_')
+				}
+				b.WriteRune(r)
+			default:
+				b.WriteByte('_')
+			}
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitServiceName splits a fully qualified gRPC service name into its
+// package and its unqualified name.
+func splitServiceName(name string) (string, string) {
+	i := strings.LastIndex(name, ".")
+	if i < 0 {
+		return "", name
+	}
+	return name[:i], name[i+1:]
+}
+
+// grpcFileName returns the name of the proto file that describes the provided
+// component.
+func grpcFileName(component string) string {
+	return component + ".proto"
+}
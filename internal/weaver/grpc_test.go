@@ -0,0 +1,209 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// grpcCache is a component interface described by the reflection service.
+type grpcCache interface {
+	Get(context.Context, string) ([]byte, bool, error)
+	Put(context.Context, string, []byte, []int32, struct{ X int }) error
+}
+
+// grpcTestWeavelet starts serving gRPC for a weavelet that hosts a single
+// grpcCache component, and returns the weavelet, the component, and a client
+// connection to the gRPC server.
+func grpcTestWeavelet(t *testing.T) (*RemoteWeavelet, *component, *grpc.ClientConn) {
+	t.Helper()
+	const name = "github.com/example/my-app/Cache"
+	c := &component{reg: &codegen.Registration{
+		Name:  name,
+		Iface: reflect.TypeOf((*grpcCache)(nil)).Elem(),
+	}}
+	w := &RemoteWeavelet{
+		syslogger:        slog.New(slog.NewTextHandler(os.Stderr, nil)),
+		componentsByName: map[string]*component{name: c},
+		hosted:           map[string]bool{name: true},
+	}
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- w.serveGRPC(ctx, lis) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	})
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return w, c, conn
+}
+
+func TestGRPCHealth(t *testing.T) {
+	ctx := context.Background()
+	w, c, conn := grpcTestWeavelet(t)
+	client := grpc_health_v1.NewHealthClient(conn)
+
+	check := func(service string, want grpc_health_v1.HealthCheckResponse_ServingStatus) {
+		t.Helper()
+		reply, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("Check(%q): %v", service, err)
+		}
+		if reply.Status != want {
+			t.Fatalf("Check(%q): got %v, want %v", service, reply.Status, want)
+		}
+	}
+
+	check("", grpc_health_v1.HealthCheckResponse_SERVING)
+	check(c.reg.Name, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	c.ready.Store(true)
+	check(c.reg.Name, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	_, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "github.com/example/my-app/Other"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("Check(unknown): got %v, want NotFound", err)
+	}
+
+	w.draining.Store(true)
+	check("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+	check(c.reg.Name, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	// Watch reports the current status right away.
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply.Status != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("Watch: got %v, want NOT_SERVING", reply.Status)
+	}
+}
+
+func TestGRPCReflection(t *testing.T) {
+	ctx := context.Background()
+	_, _, conn := grpcTestWeavelet(t)
+	stream, err := reflectionv1.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roundTrip := func(req *reflectionv1.ServerReflectionRequest) *reflectionv1.ServerReflectionResponse {
+		t.Helper()
+		if err := stream.Send(req); err != nil {
+			t.Fatal(err)
+		}
+		reply, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e := reply.GetErrorResponse(); e != nil {
+			t.Fatalf("reflection error: %v", e.ErrorMessage)
+		}
+		return reply
+	}
+
+	// List the services.
+	const service = "github.com.example.my_app.Cache"
+	reply := roundTrip(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{},
+	})
+	var services []string
+	for _, s := range reply.GetListServicesResponse().Service {
+		services = append(services, s.Name)
+	}
+	for _, want := range []string{service, "grpc.health.v1.Health", "grpc.reflection.v1.ServerReflection"} {
+		if !slices.Contains(services, want) {
+			t.Errorf("services: got %v, missing %q", services, want)
+		}
+	}
+
+	// Describe the component.
+	reply = roundTrip(&reflectionv1.ServerReflectionRequest{
+		MessageRequest: &reflectionv1.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service},
+	})
+	files := reply.GetFileDescriptorResponse().FileDescriptorProto
+	if len(files) != 1 {
+		t.Fatalf("got %d files, want 1", len(files))
+	}
+	fdp := &descriptorpb.FileDescriptorProto{}
+	if err := proto.Unmarshal(files[0], fdp); err != nil {
+		t.Fatal(err)
+	}
+	fd, err := protodesc.NewFile(fdp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svc := fd.Services().ByName("Cache")
+	if svc == nil {
+		t.Fatalf("service Cache not found in %v", fd.Path())
+	}
+	put := svc.Methods().ByName("Put")
+	if put == nil {
+		t.Fatal("method Put not found")
+	}
+	for _, want := range []struct {
+		field    string
+		kind     string
+		repeated bool
+	}{
+		{"arg0", "string", false},
+		{"arg1", "bytes", false},
+		{"arg2", "int32", true},
+		{"arg3", "bytes", false},
+	} {
+		f := put.Input().Fields().ByName(protoreflect.Name(want.field))
+		if f == nil {
+			t.Fatalf("PutRequest.%s not found", want.field)
+		}
+		if got := f.Kind().String(); got != want.kind || f.IsList() != want.repeated {
+			t.Errorf("PutRequest.%s: got %s (repeated %t), want %s (repeated %t)", want.field, got, f.IsList(), want.kind, want.repeated)
+		}
+	}
+	if got := svc.Methods().ByName("Get").Output().Fields().Len(); got != 2 {
+		t.Errorf("GetReply: got %d fields, want 2", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+)
+
+// defaultIdempotencyWindow is how long a deduper remembers the result of a
+// call if the config doesn't specify an idempotency window.
+const defaultIdempotencyWindow = 10 * time.Minute
+
+// deduper deduplicates the remote calls a weavelet handles that carry the
+// same idempotency key (see weaver.WithIdempotencyKey).
+type deduper struct {
+	window time.Duration // how long results are remembered
+
+	mu        sync.Mutex
+	calls     map[dedupKey]*dedupCall
+	lastSweep time.Time // when expired calls were last dropped
+}
+
+// dedupKey identifies a set of duplicate calls.
+type dedupKey struct {
+	component string // full component name
+	method    string // method name
+	key       string // idempotency key
+}
+
+// dedupCall is a running or finished call.
+type dedupCall struct {
+	args    string        // CanonicalHash of the call's arguments
+	done    chan struct{} // closed when the call finishes
+	result  []byte        // the call's result, once done
+	expires time.Time     // when the result is forgotten, once done
+}
+
+// newDeduper returns a deduper that remembers results for the provided
+// window, or for defaultIdempotencyWindow if the window is not positive.
+func newDeduper(window time.Duration) *deduper {
+	if window <= 0 {
+		window = defaultIdempotencyWindow
+	}
+	return &deduper{window: window, calls: map[dedupKey]*dedupCall{}}
+}
+
+// do returns fn(), unless ctx carries an idempotency key and a call to the
+// provided method with the same key already succeeded within the window, in
+// which case do returns the result of that call. If such a call is running,
+// do waits for it to finish. args are the encoded arguments of the call. A
+// call whose arguments differ from those of the earlier call with the same
+// key fails, since the key was likely reused by mistake.
+func (d *deduper) do(ctx context.Context, component, method string, args []byte, fn func() ([]byte, error)) ([]byte, error) {
+	key, ok := codegen.IdempotencyKeyFromContext(ctx)
+	if !ok {
+		return fn()
+	}
+	k := dedupKey{component: component, method: method, key: key}
+	hash := codegen.CanonicalHash(args)
+	for {
+		d.mu.Lock()
+		now := time.Now()
+		d.sweep(now)
+		c, ok := d.calls[k]
+		if !ok || (!c.expires.IsZero() && now.After(c.expires)) {
+			c = &dedupCall{args: hash, done: make(chan struct{})}
+			d.calls[k] = c
+			d.mu.Unlock()
+			return d.run(ctx, k, c, fn)
+		}
+		d.mu.Unlock()
+		if c.args != hash {
+			return nil, fmt.Errorf("idempotency key %q of %s.%s was reused with different arguments", key, logging.ShortenComponent(component), method)
+		}
+
+		select {
+		case <-c.done:
+			if !c.expires.IsZero() {
+				return c.result, nil
+			}
+			// The call failed and was forgotten. Try again.
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// run runs fn as the provided call. The call is forgotten if it fails or if
+// ctx is cancelled while it runs, since the method may have returned early.
+func (d *deduper) run(ctx context.Context, k dedupKey, c *dedupCall, fn func() ([]byte, error)) ([]byte, error) {
+	succeeded := false
+	defer func() {
+		// Note that fn may panic, in which case the call is forgotten.
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if succeeded {
+			c.expires = time.Now().Add(d.window)
+		} else {
+			delete(d.calls, k)
+		}
+		close(c.done)
+	}()
+	result, err := fn()
+	if err == nil && ctx.Err() == nil {
+		c.result = result
+		succeeded = true
+	}
+	return result, err
+}
+
+// sweep drops expired calls, at most once per window.
+//
+// REQUIRES: d.mu is held.
+func (d *deduper) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.window {
+		return
+	}
+	d.lastSweep = now
+	for k, c := range d.calls {
+		if !c.expires.IsZero() && now.After(c.expires) {
+			delete(d.calls, k)
+		}
+	}
+}
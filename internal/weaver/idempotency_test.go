@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+func TestDeduper(t *testing.T) {
+	d := newDeduper(time.Minute)
+	var calls int
+	do := func(ctx context.Context, method string) string {
+		t.Helper()
+		result, err := d.do(ctx, "C", method, nil, func() ([]byte, error) {
+			calls++
+			return []byte(fmt.Sprint(calls)), nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(result)
+	}
+
+	ctx := context.Background()
+	a := codegen.WithIdempotencyKey(ctx, "a")
+	b := codegen.WithIdempotencyKey(ctx, "b")
+	for _, test := range []struct {
+		name   string
+		ctx    context.Context
+		method string
+		want   string
+	}{
+		{"NoKey", ctx, "M", "1"},
+		{"NoKeyAgain", ctx, "M", "2"},
+		{"A", a, "M", "3"},
+		{"ADuplicate", a, "M", "3"},
+		{"B", b, "M", "4"},
+		{"AOtherMethod", a, "N", "5"},
+		{"AOtherMethodDuplicate", a, "N", "5"},
+		{"ADuplicateAgain", a, "M", "3"},
+	} {
+		if got := do(test.ctx, test.method); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestDeduperForgetsFailures(t *testing.T) {
+	d := newDeduper(time.Minute)
+	ctx := codegen.WithIdempotencyKey(context.Background(), "k")
+	boom := errors.New("boom")
+	if _, err := d.do(ctx, "C", "M", nil, func() ([]byte, error) { return nil, boom }); !errors.Is(err, boom) {
+		t.Fatalf("do: got %v, want %v", err, boom)
+	}
+
+	// Calls whose context is cancelled are also forgotten, even if they
+	// succeed.
+	cancelled, cancel := context.WithCancel(ctx)
+	if _, err := d.do(cancelled, "C", "M", nil, func() ([]byte, error) {
+		cancel()
+		return []byte("cancelled"), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := d.do(ctx, "C", "M", nil, func() ([]byte, error) { return []byte("ok"), nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(result), "ok"; got != want {
+		t.Fatalf("do: got %q, want %q", got, want)
+	}
+}
+
+func TestDeduperRejectsReusedKeys(t *testing.T) {
+	d := newDeduper(time.Minute)
+	ctx := codegen.WithIdempotencyKey(context.Background(), "k")
+	fn := func() ([]byte, error) { return []byte("ok"), nil }
+	if _, err := d.do(ctx, "C", "M", []byte("args"), fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.do(ctx, "C", "M", []byte("args"), fn); err != nil {
+		t.Fatalf("duplicate call: %v", err)
+	}
+	if _, err := d.do(ctx, "C", "M", []byte("other args"), fn); err == nil {
+		t.Fatal("call with reused key and different arguments: unexpected success")
+	}
+}
+
+func TestDeduperExpires(t *testing.T) {
+	d := newDeduper(time.Millisecond)
+	ctx := codegen.WithIdempotencyKey(context.Background(), "k")
+	var calls int
+	fn := func() ([]byte, error) {
+		calls++
+		return nil, nil
+	}
+	d.do(ctx, "C", "M", nil, fn)
+	time.Sleep(10 * time.Millisecond)
+	d.do(ctx, "C", "M", nil, fn)
+	if got, want := calls, 2; got != want {
+		t.Fatalf("calls: got %d, want %d", got, want)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if got, want := len(d.calls), 1; got != want {
+		t.Fatalf("remembered calls: got %d, want %d", got, want)
+	}
+}
+
+func TestDeduperConcurrentCalls(t *testing.T) {
+	// Concurrent duplicate calls wait for the first one to finish.
+	d := newDeduper(time.Minute)
+	ctx := codegen.WithIdempotencyKey(context.Background(), "k")
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fn := func() ([]byte, error) {
+		calls.Add(1)
+		<-release
+		return []byte("ok"), nil
+	}
+
+	const n = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := d.do(ctx, "C", "M", nil, fn)
+			if err == nil && string(result) != "ok" {
+				err = fmt.Errorf("got %q, want %q", result, "ok")
+			}
+			errs <- err
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	if got, want := calls.Load(), int32(1); got != want {
+		t.Fatalf("calls: got %d, want %d", got, want)
+	}
+}
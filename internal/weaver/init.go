@@ -0,0 +1,171 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+)
+
+// initTimeout returns the maximum duration of the named component's Init
+// method, given the default timeout and the per-component overrides, both in
+// nanoseconds. A zero duration means that Init is not bounded.
+func initTimeout(component string, def int64, overrides map[string]int64) time.Duration {
+	if timeout, ok := overrides[component]; ok {
+		return time.Duration(timeout)
+	}
+	return time.Duration(def)
+}
+
+// initTracker tracks the components that are currently being constructed,
+// so that an initialization that deadlocks or times out can be attributed to
+// the components involved.
+type initTracker struct {
+	mu           sync.Mutex
+	constructing map[string]time.Time // construction start times, by component
+}
+
+// start records that the named component is being constructed.
+func (t *initTracker) start(component string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.constructing == nil {
+		t.constructing = map[string]time.Time{}
+	}
+	t.constructing[component] = time.Now()
+}
+
+// done records that the named component is no longer being constructed.
+func (t *initTracker) done(component string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.constructing, component)
+}
+
+// run runs the provided Init method of the named component. If timeout is
+// positive, the context passed to init is canceled after timeout and run
+// returns an error without waiting for init to return.
+func (t *initTracker) run(ctx context.Context, component string, timeout time.Duration, init func(context.Context) error) error {
+	if timeout <= 0 {
+		if err := init(ctx); err != nil {
+			return fmt.Errorf("component %q initialization failed: %w", component, err)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() { errs <- init(ctx) }()
+	select {
+	case err := <-errs:
+		if err == nil {
+			return nil
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			break
+		}
+		return fmt.Errorf("component %q initialization failed: %w", component, err)
+	case <-ctx.Done():
+	}
+	err := fmt.Errorf("component %q initialization timed out after %v", component, timeout)
+	if others := t.others(component); len(others) > 0 {
+		err = fmt.Errorf("%w; components still initializing: %s", err, strings.Join(others, ", "))
+	}
+	return err
+}
+
+// others returns the names of the components, other than the provided one,
+// that are being constructed, along with how long they have been constructed
+// for.
+func (t *initTracker) others(component string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var others []string
+	for name, start := range t.constructing {
+		if name == component {
+			continue
+		}
+		elapsed := time.Since(start).Round(time.Millisecond)
+		others = append(others, fmt.Sprintf("%s (%v)", logging.ShortenComponent(name), elapsed))
+	}
+	slices.Sort(others)
+	return others
+}
+
+// cycle returns the dependency cycle that would deadlock if the requester,
+// which is being constructed, waited for the target to be constructed. The
+// cycle is returned as a list of component names that starts and ends with
+// the requester. If there is no such cycle, cycle returns nil.
+//
+// A cycle exists if the target is being constructed and, following the
+// provided dependencies through components that are being constructed, the
+// target (transitively) depends on the requester.
+func (t *initTracker) cycle(requester, target string, deps map[string][]string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.constructing[requester]; !ok {
+		return nil
+	}
+	seen := map[string]bool{}
+	var visit func(string) []string
+	visit = func(name string) []string {
+		if name == requester {
+			return []string{name}
+		}
+		if _, ok := t.constructing[name]; !ok || seen[name] {
+			return nil
+		}
+		seen[name] = true
+		for _, dep := range deps[name] {
+			if path := visit(dep); path != nil {
+				return append([]string{name}, path...)
+			}
+		}
+		return nil
+	}
+	if path := visit(target); path != nil {
+		return append([]string{requester}, path...)
+	}
+	return nil
+}
+
+// componentDeps returns the components that every registered component
+// holds weaver.Refs to, by component name.
+func componentDeps(regs []*codegen.Registration) map[string][]string {
+	deps := map[string][]string{}
+	for _, reg := range regs {
+		for _, edge := range codegen.ExtractEdges([]byte(reg.RefData)) {
+			deps[edge[0]] = append(deps[edge[0]], edge[1])
+		}
+	}
+	return deps
+}
+
+// cycleError returns an error describing the provided dependency cycle.
+func cycleError(cycle []string) error {
+	names := make([]string, len(cycle))
+	for i, name := range cycle {
+		names[i] = logging.ShortenComponent(name)
+	}
+	return fmt.Errorf("component dependency cycle: %s", strings.Join(names, " -> "))
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInitTimeout(t *testing.T) {
+	overrides := map[string]int64{"b": int64(time.Minute), "c": 0}
+	for _, test := range []struct {
+		component string
+		want      time.Duration
+	}{
+		{"a", time.Second},
+		{"b", time.Minute},
+		{"c", 0},
+	} {
+		if got := initTimeout(test.component, int64(time.Second), overrides); got != test.want {
+			t.Errorf("initTimeout(%q): got %v, want %v", test.component, got, test.want)
+		}
+	}
+}
+
+func TestInitRun(t *testing.T) {
+	ctx := context.Background()
+	var tracker initTracker
+
+	// Successful and failing Init methods.
+	if err := tracker.run(ctx, "a", time.Minute, func(context.Context) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+	boom := errors.New("boom")
+	err := tracker.run(ctx, "a", 0, func(context.Context) error { return boom })
+	if !errors.Is(err, boom) {
+		t.Fatalf("run: got %v, want %v", err, boom)
+	}
+
+	// An Init method that ignores its context.
+	tracker.start("a")
+	tracker.start("b")
+	hang := make(chan struct{})
+	defer close(hang)
+	err = tracker.run(ctx, "a", 10*time.Millisecond, func(context.Context) error {
+		<-hang
+		return nil
+	})
+	if err == nil {
+		t.Fatal("run: unexpected success")
+	}
+	for _, want := range []string{`"a" initialization timed out`, "still initializing: b"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("run: error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestInitCycle(t *testing.T) {
+	deps := map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"a", "d"},
+	}
+	var tracker initTracker
+	tracker.start("a")
+	tracker.start("b")
+
+	// c is not being constructed, so b can wait for it.
+	if cycle := tracker.cycle("b", "c", deps); cycle != nil {
+		t.Fatalf("cycle(b, c): unexpected cycle %v", cycle)
+	}
+
+	// c is being constructed and depends on a, which waits for b.
+	tracker.start("c")
+	want := []string{"c", "a", "b", "c"}
+	if diff := cmp.Diff(want, tracker.cycle("c", "a", deps)); diff != "" {
+		t.Fatalf("cycle(c, a) (-want +got):\n%s", diff)
+	}
+
+	// Nobody waits for d.
+	tracker.start("d")
+	if cycle := tracker.cycle("c", "d", deps); cycle != nil {
+		t.Fatalf("cycle(c, d): unexpected cycle %v", cycle)
+	}
+}
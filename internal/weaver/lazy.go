@@ -0,0 +1,151 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// lazyLabels are the labels of the firstCallLatency metric.
+type lazyLabels struct {
+	Component string // full component name
+}
+
+// firstCallLatency records the latency of the first method call to every
+// lazily constructed component, which includes the time it takes to construct
+// and initialize the component.
+var firstCallLatency = metrics.NewHistogramMap[lazyLabels](
+	"serviceweaver_system_lazy_first_call_latency_micros",
+	"Duration, in microseconds, of the first method call to a lazily constructed component, including its construction",
+	imetrics.GeneratedBuckets,
+)
+
+// firstCalls records the latency of the first method call to every lazily
+// constructed component.
+type firstCalls struct {
+	mu   sync.Mutex
+	seen map[string]bool // components whose first call has been recorded
+}
+
+// record records the latency of a call to the named component that started at
+// the provided time, if it is the first call to the component.
+func (f *firstCalls) record(component string, start time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.seen[component] {
+		return
+	}
+	if f.seen == nil {
+		f.seen = map[string]bool{}
+	}
+	f.seen[component] = true
+	latency := time.Since(start)
+	firstCallLatency.Get(lazyLabels{Component: component}).Put(float64(latency.Microseconds()))
+}
+
+// lazyStub returns an implementation of the component interface of the
+// provided registration that does not construct the component until its first
+// method call. On every call, the component is obtained by calling get, and
+// the call is executed against the component's local stub.
+func lazyStub(reg *codegen.Registration, requester string, tracer trace.Tracer, first *firstCalls, get func() (any, error)) any {
+	var mu sync.Mutex
+	var stub reflect.Value // local stub, once the component is constructed
+	call := func(method string, ctx context.Context, args []any, returns []any) error {
+		start := time.Now()
+		mu.Lock()
+		if !stub.IsValid() {
+			impl, err := get()
+			if err != nil {
+				mu.Unlock()
+				return err
+			}
+			stub = reflect.ValueOf(reg.LocalStubFn(impl, requester, tracer))
+		}
+		m := stub.MethodByName(method)
+		mu.Unlock()
+		if !m.IsValid() {
+			return fmt.Errorf("component %q has no method %q", reg.Name, method)
+		}
+
+		err := invoke(m, ctx, args, returns)
+		first.record(reg.Name, start)
+		return err
+	}
+	return reg.ReflectStubFn(call)
+}
+
+// selfStub returns an implementation of the component interface of the
+// provided registration for a component's reference to itself. The reference
+// is filled while the component is being constructed, before the component can
+// serve calls, so the stub obtains the component interface by calling get on
+// its first method call instead, and retries on later calls if get fails.
+func selfStub(reg *codegen.Registration, get func() (any, error)) any {
+	var mu sync.Mutex
+	var stub reflect.Value // component interface, once obtained
+	call := func(method string, ctx context.Context, args []any, returns []any) error {
+		mu.Lock()
+		if !stub.IsValid() {
+			intf, err := get()
+			if err != nil {
+				mu.Unlock()
+				return err
+			}
+			stub = reflect.ValueOf(intf)
+		}
+		m := stub.MethodByName(method)
+		mu.Unlock()
+		if !m.IsValid() {
+			return fmt.Errorf("component %q has no method %q", reg.Name, method)
+		}
+		return invoke(m, ctx, args, returns)
+	}
+	return reg.ReflectStubFn(call)
+}
+
+// invoke calls method m of a component stub with the provided context and
+// arguments, as passed to the call function of a codegen.Registration's
+// ReflectStubFn, and populates returns with the method's results.
+func invoke(m reflect.Value, ctx context.Context, args []any, returns []any) error {
+	// Call the method. Nil arguments of interface type are replaced by zero
+	// values, since reflect.ValueOf(nil) is not a valid argument.
+	in := make([]reflect.Value, 1+len(args))
+	in[0] = reflect.ValueOf(ctx)
+	for i, arg := range args {
+		in[i+1] = reflect.ValueOf(arg)
+		if !in[i+1].IsValid() {
+			in[i+1] = reflect.Zero(m.Type().In(i + 1))
+		}
+	}
+	out := m.Call(in)
+
+	// Populate return values. Note that returns[i] has static type any but
+	// dynamic type *T for some T, and out[i] has dynamic type T.
+	for i := range returns {
+		reflect.ValueOf(returns[i]).Elem().Set(out[i])
+	}
+	if err := out[len(out)-1].Interface(); err != nil {
+		return err.(error)
+	}
+	return nil
+}
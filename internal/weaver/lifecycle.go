@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"slices"
+	"sync"
+)
+
+// Copy of the LifecycleObserver interface in the main weaver package.
+type LifecycleObserver interface {
+	Constructed(component string, impl any)
+	Initialized(component string, impl any, err error)
+	Draining(component string, impl any)
+	ShutDown(component string, impl any, err error)
+}
+
+// observers holds the lifecycle observers of the process. Observers are
+// process-wide, rather than per weavelet, so that they also observe the
+// weavelets created by frameworks like weavertest.
+var observers struct {
+	mu   sync.Mutex
+	next int                       // id of the next observer
+	all  map[int]LifecycleObserver // observers, by id
+}
+
+// AddLifecycleObserver registers o to be notified of the lifecycle of every
+// component constructed by a weavelet in this process. It returns a function
+// that unregisters o.
+func AddLifecycleObserver(o LifecycleObserver) func() {
+	observers.mu.Lock()
+	defer observers.mu.Unlock()
+	if observers.all == nil {
+		observers.all = map[int]LifecycleObserver{}
+	}
+	id := observers.next
+	observers.next++
+	observers.all[id] = o
+	return func() {
+		observers.mu.Lock()
+		defer observers.mu.Unlock()
+		delete(observers.all, id)
+	}
+}
+
+// notify calls fn with every registered observer, one at a time and in the
+// order in which they were registered. The observers are called without
+// holding any lock, so they may register or unregister observers.
+func notify(fn func(LifecycleObserver)) {
+	observers.mu.Lock()
+	ids := make([]int, 0, len(observers.all))
+	for id := range observers.all {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	all := make([]LifecycleObserver, len(ids))
+	for i, id := range ids {
+		all[i] = observers.all[id]
+	}
+	observers.mu.Unlock()
+
+	for _, o := range all {
+		fn(o)
+	}
+}
@@ -18,57 +18,136 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sync"
+	"time"
 
+	"github.com/ServiceWeaver/weaver/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 )
 
+// droppedLogEntries counts the number of log entries that were dropped because
+// the log buffer was full.
+var droppedLogEntries = metrics.NewCounter(
+	"serviceweaver_system_log_entries_dropped",
+	"Number of log entries dropped because the weavelet log buffer was full",
+)
+
 // remoteLogger collects log entries into batches and sends these batches to a
 // specified function.
+//
+// Log entries are stored in a bounded ring buffer. The buffer starts empty and
+// grows on demand up to a fixed capacity. If the buffer is full, the oldest
+// entry is dropped to make room for the new one, so a slow log consumer never
+// blocks the goroutine that is logging. Whenever the previous batch has been
+// sent, all buffered entries are moved into the next batch.
 type remoteLogger struct {
-	c        chan *protos.LogEntry
 	fallback io.Writer              // Fallback destination when dst() returns an error
 	pp       *logging.PrettyPrinter // Used when sending to dst fails
+	ready    chan struct{}          // Signaled when entries are buffered
+	capacity int                    // Maximum number of buffered entries
+
+	mu      sync.Mutex
+	buf     []*protos.LogEntry // ring buffer of pending entries; len(buf) <= capacity
+	start   int                // index of the oldest entry in buf
+	n       int                // number of entries in buf
+	dropped int                // number of entries dropped since the last batch
 }
 
-const logBufferCount = 1000
+const logBufferCount = 1 << 14
 
 func newRemoteLogger(fallback io.Writer) *remoteLogger {
+	return newRemoteLoggerWithCapacity(fallback, logBufferCount)
+}
+
+func newRemoteLoggerWithCapacity(fallback io.Writer, capacity int) *remoteLogger {
 	rl := &remoteLogger{
-		c:        make(chan *protos.LogEntry, logBufferCount),
 		fallback: fallback,
 		pp:       logging.NewPrettyPrinter(false),
+		ready:    make(chan struct{}, 1),
+		capacity: capacity,
 	}
 	return rl
 }
 
+// minLogBufferCount is the initial size of a remoteLogger's buffer.
+const minLogBufferCount = 64
+
+// log buffers the provided entry. It never blocks. If the buffer is full, the
+// oldest buffered entry is dropped.
 func (rl *remoteLogger) log(entry *protos.LogEntry) {
-	// TODO(sanjay): Drop if too many entries are buffered?
-	rl.c <- entry
+	rl.mu.Lock()
+	if rl.n == len(rl.buf) && len(rl.buf) < rl.capacity {
+		rl.grow()
+	}
+	if rl.n == len(rl.buf) {
+		rl.buf[rl.start] = nil
+		rl.start = (rl.start + 1) % len(rl.buf)
+		rl.n--
+		rl.dropped++
+		droppedLogEntries.Inc()
+	}
+	rl.buf[(rl.start+rl.n)%len(rl.buf)] = entry
+	rl.n++
+	rl.mu.Unlock()
+
+	select {
+	case rl.ready <- struct{}{}:
+	default:
+	}
+}
+
+// grow enlarges the buffer, preserving the order of the buffered entries.
+//
+// REQUIRES: rl.mu is held.
+func (rl *remoteLogger) grow() {
+	size := min(max(2*len(rl.buf), minLogBufferCount), rl.capacity)
+	buf := make([]*protos.LogEntry, size)
+	for i := 0; i < rl.n; i++ {
+		buf[i] = rl.buf[(rl.start+i)%len(rl.buf)]
+	}
+	rl.buf, rl.start = buf, 0
 }
 
-// run collects log entries passed to log() and, and passes theme to dst. At
-// most one call to dst is outstanding at a time. Log entries that arrive while
-// a call is in progress are buffered and sent in the next call.
+// drain appends all buffered entries to batch and empties the buffer. If any
+// entries were dropped since the last call to drain, an entry recording the
+// number of dropped entries is appended first.
+func (rl *remoteLogger) drain(batch *protos.LogEntryBatch) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.dropped > 0 {
+		batch.Entries = append(batch.Entries, &protos.LogEntry{
+			Component:  "weavelet",
+			TimeMicros: time.Now().UnixMicro(),
+			Level:      "WARN",
+			Line:       -1,
+			Msg:        fmt.Sprintf("dropped %d log entries because the log buffer was full", rl.dropped),
+			Attrs:      []string{logging.SystemAttributeKey, ""},
+		})
+		rl.dropped = 0
+	}
+	for i := 0; i < rl.n; i++ {
+		j := (rl.start + i) % len(rl.buf)
+		batch.Entries = append(batch.Entries, rl.buf[j])
+		rl.buf[j] = nil
+	}
+	rl.start, rl.n = 0, 0
+}
+
+// run collects log entries passed to log() and passes them to dst. At most
+// one call to dst is outstanding at a time. Log entries that arrive while a
+// call is in progress are buffered and sent in the next call.
 func (rl *remoteLogger) run(ctx context.Context, dst func(context.Context, *protos.LogEntryBatch) error) {
 	batch := &protos.LogEntryBatch{}
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case e := <-rl.c:
-			// Batch together all available entries.
-			batch.Entries = append(batch.Entries, e)
-		readloop:
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case e := <-rl.c:
-					batch.Entries = append(batch.Entries, e)
-				default:
-					break readloop
-				}
+		case <-rl.ready:
+			// Pull together all available entries.
+			rl.drain(batch)
+			if len(batch.Entries) == 0 {
+				continue
 			}
 
 			// Send this batch
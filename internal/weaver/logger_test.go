@@ -108,3 +108,61 @@ func (c collector) Write(data []byte) (int, error) {
 	c <- string(data)
 	return len(data), nil
 }
+
+func TestLoggerDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Log more entries than fit in the buffer while the consumer is blocked.
+	// Logging must not block and the oldest entries must be dropped.
+	const capacity = 10
+	rl := newRemoteLoggerWithCapacity(os.Stderr, capacity)
+	for i := 0; i < 3*capacity; i++ {
+		rl.log(&protos.LogEntry{Msg: fmt.Sprint(i)})
+	}
+
+	got := make(chan []string, 1)
+	go rl.run(ctx, func(ctx context.Context, batch *protos.LogEntryBatch) error {
+		var msgs []string
+		for _, e := range batch.Entries {
+			msgs = append(msgs, e.Msg)
+		}
+		got <- msgs
+		return nil
+	})
+
+	want := []string{fmt.Sprintf("dropped %d log entries because the log buffer was full", 2*capacity)}
+	for i := 2 * capacity; i < 3*capacity; i++ {
+		want = append(want, fmt.Sprint(i))
+	}
+	if diff := cmp.Diff(want, <-got); diff != "" {
+		t.Errorf("log delivery error: (-want,+got):\n%s\n", diff)
+	}
+}
+
+func TestLoggerGrow(t *testing.T) {
+	// The buffer should only grow as entries are logged, and never beyond
+	// its capacity.
+	const capacity = 1000
+	rl := newRemoteLoggerWithCapacity(os.Stderr, capacity)
+	if got := len(rl.buf); got != 0 {
+		t.Fatalf("initial buffer size: got %d, want 0", got)
+	}
+	for i := 0; i < 2*capacity; i++ {
+		rl.log(&protos.LogEntry{Msg: fmt.Sprint(i)})
+		if got := len(rl.buf); got > capacity {
+			t.Fatalf("buffer size: got %d, want <= %d", got, capacity)
+		}
+	}
+
+	batch := &protos.LogEntryBatch{}
+	rl.drain(batch)
+	if got, want := len(batch.Entries), capacity+1; got != want {
+		t.Fatalf("drained entries: got %d, want %d", got, want)
+	}
+	for i, e := range batch.Entries[1:] {
+		if got, want := e.Msg, fmt.Sprint(capacity+i); got != want {
+			t.Fatalf("entry %d: got %q, want %q", i, got, want)
+		}
+	}
+}
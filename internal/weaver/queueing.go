@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"math"
+	goruntime "runtime"
+	rtmetrics "runtime/metrics"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/metrics"
+)
+
+// The server-side queueing metrics of a component replica. A call received by
+// a replica is pending until it starts executing, e.g., while the component
+// is constructed, while the component isn't ready, or while an identical call
+// with the same idempotency key executes. Comparing the wait and latency on
+// the server with the latency observed by the caller separates slow handlers
+// from queueing in the replica and delays in the transport.
+
+// serverComponentLabels are the labels of the per-component queueing metrics.
+type serverComponentLabels struct {
+	Component string // full component name
+	Generated bool   `weaver:"serviceweaver_generated"` // always true
+}
+
+// serverMethodLabels are the labels of the per-method queueing metrics.
+type serverMethodLabels struct {
+	Component string // full component name
+	Method    string // component method's name
+	Generated bool   `weaver:"serviceweaver_generated"` // always true
+}
+
+// processLabels are the labels of the goroutine saturation metrics.
+type processLabels struct {
+	Generated bool `weaver:"serviceweaver_generated"` // always true
+}
+
+var (
+	serverPendingCalls = metrics.NewGaugeMap[serverComponentLabels](
+		"serviceweaver_server_pending_calls",
+		"Number of calls received by a component replica that haven't started executing",
+	)
+	serverActiveCalls = metrics.NewGaugeMap[serverComponentLabels](
+		"serviceweaver_server_active_calls",
+		"Number of calls executing in a component replica",
+	)
+	serverWaitMicros = metrics.NewHistogramMap[serverMethodLabels](
+		"serviceweaver_server_wait_micros",
+		"Duration, in microseconds, that calls received by a component replica wait before they start executing",
+		imetrics.GeneratedBuckets,
+	)
+	serverLatencyMicros = metrics.NewHistogramMap[serverMethodLabels](
+		"serviceweaver_server_latency_micros",
+		"Duration, in microseconds, of the execution of calls in a component replica",
+		imetrics.GeneratedBuckets,
+	)
+	goroutines = metrics.NewGaugeMap[processLabels](
+		"serviceweaver_goroutines",
+		"Number of goroutines in a weavelet",
+	).Get(processLabels{Generated: true})
+	schedulerLatencyMicros = metrics.NewGaugeMap[processLabels](
+		"serviceweaver_scheduler_latency_p99_micros",
+		"99th percentile, in microseconds, of the time goroutines in a weavelet were runnable before running, since metrics were last exported",
+	).Get(processLabels{Generated: true})
+)
+
+// methodQueue records the queueing metrics of a component method.
+type methodQueue struct {
+	pending *metrics.Gauge
+	active  *metrics.Gauge
+	wait    *metrics.Histogram
+	latency *metrics.Histogram
+}
+
+// newMethodQueue returns the methodQueue of the provided component method.
+func newMethodQueue(component, method string) *methodQueue {
+	cl := serverComponentLabels{Component: component, Generated: true}
+	ml := serverMethodLabels{Component: component, Method: method, Generated: true}
+	return &methodQueue{
+		pending: serverPendingCalls.Get(cl),
+		active:  serverActiveCalls.Get(cl),
+		wait:    serverWaitMicros.Get(ml),
+		latency: serverLatencyMicros.Get(ml),
+	}
+}
+
+// queuedCall is a call received by a component replica.
+type queuedCall struct {
+	q        *methodQueue
+	received time.Time
+	started  time.Time // zero if the call hasn't started executing
+}
+
+// receive records the receipt of a call, which is pending until start is
+// called. finish must be called when the call is done, whether it started or
+// not.
+func (q *methodQueue) receive() queuedCall {
+	q.pending.Add(1)
+	return queuedCall{q: q, received: time.Now()}
+}
+
+// start records that the call started executing.
+func (c *queuedCall) start() {
+	c.started = time.Now()
+	c.q.pending.Sub(1)
+	c.q.active.Add(1)
+	c.q.wait.Put(float64(c.started.Sub(c.received).Microseconds()))
+}
+
+// finish records that the call is done.
+func (c *queuedCall) finish() {
+	if c.started.IsZero() {
+		// The call failed, or its result was deduplicated, before it started.
+		c.q.pending.Sub(1)
+		return
+	}
+	c.q.active.Sub(1)
+	c.q.latency.Put(float64(time.Since(c.started).Microseconds()))
+}
+
+// schedulerSampler samples goroutine saturation metrics: the number of
+// goroutines, and the time goroutines spend runnable before they run, which
+// grows when there are more runnable goroutines than processors.
+type schedulerSampler struct {
+	mu      sync.Mutex
+	samples []rtmetrics.Sample
+	prev    []uint64 // scheduler latency counts of the previous sample
+}
+
+// schedLatencies is the runtime/metrics histogram of the time goroutines
+// spend runnable before running.
+const schedLatencies = "/sched/latencies:seconds"
+
+// sample updates the goroutine saturation metrics.
+func (s *schedulerSampler) sample() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.samples == nil {
+		s.samples = []rtmetrics.Sample{{Name: schedLatencies}}
+	}
+	goroutines.Set(float64(goruntime.NumGoroutine()))
+	rtmetrics.Read(s.samples)
+	if s.samples[0].Value.Kind() != rtmetrics.KindFloat64Histogram {
+		// Not supported by this version of Go.
+		return
+	}
+	h := s.samples[0].Value.Float64Histogram()
+	delta := make([]uint64, len(h.Counts))
+	var total uint64
+	for i, n := range h.Counts {
+		delta[i] = n
+		if len(s.prev) == len(h.Counts) {
+			delta[i] -= s.prev[i]
+		}
+		total += delta[i]
+	}
+	s.prev = append(s.prev[:0], h.Counts...)
+	if total == 0 {
+		schedulerLatencyMicros.Set(0)
+		return
+	}
+
+	// Report the upper bound of the bucket holding the 99th percentile, or
+	// its lower bound if the bucket is unbounded. h.Buckets[i] and
+	// h.Buckets[i+1] are the bounds of bucket i.
+	rank := uint64(math.Ceil(0.99 * float64(total)))
+	var seen uint64
+	for i, n := range delta {
+		seen += n
+		if seen < rank {
+			continue
+		}
+		bound := h.Buckets[i+1]
+		if math.IsInf(bound, 1) {
+			bound = h.Buckets[i]
+		}
+		schedulerLatencyMicros.Set(bound * 1e6)
+		return
+	}
+}
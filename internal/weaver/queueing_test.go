@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+)
+
+// metricValue returns the value of the named metric with the provided
+// component label, and the number of values put in it if it is a histogram.
+func metricValue(t *testing.T, name, component string) (float64, uint64) {
+	t.Helper()
+	for _, m := range metrics.Snapshot() {
+		if m.Name != name || m.Labels["component"] != component {
+			continue
+		}
+		var n uint64
+		for _, c := range m.Counts {
+			n += c
+		}
+		return m.Value, n
+	}
+	t.Fatalf("metric %s{component=%q} not found", name, component)
+	return 0, 0
+}
+
+func TestMethodQueue(t *testing.T) {
+	const component = "example.com/queueing/TestMethodQueue"
+	q := newMethodQueue(component, "Get")
+
+	// Receive two calls and start one of them.
+	a := q.receive()
+	b := q.receive()
+	time.Sleep(time.Millisecond)
+	a.start()
+	if got, _ := metricValue(t, "serviceweaver_server_pending_calls", component); got != 1 {
+		t.Errorf("pending calls: got %v, want 1", got)
+	}
+	if got, _ := metricValue(t, "serviceweaver_server_active_calls", component); got != 1 {
+		t.Errorf("active calls: got %v, want 1", got)
+	}
+	if sum, n := metricValue(t, "serviceweaver_server_wait_micros", component); n != 1 || sum < 1000 {
+		t.Errorf("wait: got %d waits totaling %vus, want 1 wait of at least 1000us", n, sum)
+	}
+
+	// Finish both calls, one of which never started.
+	a.finish()
+	b.finish()
+	if got, _ := metricValue(t, "serviceweaver_server_pending_calls", component); got != 0 {
+		t.Errorf("pending calls: got %v, want 0", got)
+	}
+	if got, _ := metricValue(t, "serviceweaver_server_active_calls", component); got != 0 {
+		t.Errorf("active calls: got %v, want 0", got)
+	}
+	if _, n := metricValue(t, "serviceweaver_server_latency_micros", component); n != 1 {
+		t.Errorf("latency: got %d calls, want 1", n)
+	}
+}
+
+func TestSchedulerSampler(t *testing.T) {
+	var s schedulerSampler
+	s.sample()
+	s.sample()
+	if got, _ := metricValue(t, "serviceweaver_goroutines", ""); got < 1 {
+		t.Errorf("goroutines: got %v, want at least 1", got)
+	}
+	if got, _ := metricValue(t, "serviceweaver_scheduler_latency_p99_micros", ""); got < 0 {
+		t.Errorf("scheduler latency: got %v, want non-negative", got)
+	}
+}
@@ -25,15 +25,21 @@ import (
 	"os"
 	"os/signal"
 	"reflect"
+	"runtime/debug"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/ServiceWeaver/weaver/internal/config"
 	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/internal/crashreport"
+	"github.com/ServiceWeaver/weaver/internal/hedging"
 	"github.com/ServiceWeaver/weaver/internal/net/call"
 	"github.com/ServiceWeaver/weaver/internal/register"
+	"github.com/ServiceWeaver/weaver/internal/scheduling"
 	"github.com/ServiceWeaver/weaver/internal/traceio"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
@@ -52,6 +58,9 @@ import (
 // clients to check if a component is ready.
 const readyMethodName = "ready"
 
+// crashReportTimeout bounds the time spent posting a crash report.
+const crashReportTimeout = 5 * time.Second
+
 // RemoteWeaveletOptions configure a RemoteWeavelet.
 type RemoteWeaveletOptions struct {
 	Fakes         map[reflect.Type]any // component fakes, by component interface type
@@ -72,11 +81,14 @@ type RemoteWeavelet struct {
 	dialAddr   string                  // Address dialed by other components
 	id         string                  // unique id for this weavelet
 	weaverInfo *WeaverInfo             // application runtime information
+	replica    *replicaContext         // information about this replica
+	events     *Events                 // deployment events
 	deployer   control.DeployerControl // component to control deployer
 	logDst     *remoteLogger           // for writing log entries
 	syslogger  *slog.Logger            // system logger
 	tracer     trace.Tracer            // tracer used by all components
 	metrics    metrics.Exporter        // helper for sending metrics to envelope
+	scheduler  schedulerSampler        // samples goroutine saturation metrics
 
 	// state to synchronize with envelope initiated initialization handshake.
 	initMu     sync.Mutex
@@ -84,7 +96,21 @@ type RemoteWeavelet struct {
 	initDone   chan struct{}
 
 	// Ready to use by the time initDone is closed.
-	sectionConfig map[string]string
+	sectionConfig         map[string]string
+	initTimeout           int64                 // default Init timeout, in nanoseconds
+	componentInitTimeouts map[string]int64      // per-component Init timeouts
+	lazy                  map[string]bool       // lazily constructed components
+	auditor               *auditor              // records calls to audited methods
+	largePayloadBytes     int                   // threshold for out of band payloads
+	compressPayloadBytes  int                   // threshold for compressed payloads
+	maxMessageBytes       int                   // largest message accepted
+	checksumPayloads      bool                  // send checksummed messages
+	deduper               *deduper              // deduplicates calls with idempotency keys
+	atMostOnce            map[string][]string   // configured at-most-once methods, by component
+	grpcAddress           string                // address of the gRPC server, if any
+	scheduling            *scheduling.Config    // call scheduling config
+	calls                 *scheduling.Scheduler // admits calls, or nil if unbounded
+	hedger                *hedging.Hedger       // hedges calls, or nil if none are hedged
 
 	// channel that is closed when deployer is ready.
 	deployerReady chan struct{}
@@ -93,9 +119,16 @@ type RemoteWeavelet struct {
 	componentsByIntf map[reflect.Type]*component // component interface type -> component
 	componentsByImpl map[reflect.Type]*component // component impl type -> component
 	redirects        map[string]redirect         // component redirects
+	deps             map[string][]string         // component dependencies
+	inits            initTracker                 // components being constructed
+	firstCalls       firstCalls                  // first calls to lazy components
 
 	lismu     sync.Mutex           // guards listeners
 	listeners map[string]*listener // listeners, by name
+
+	hostedMu sync.Mutex      // guards hosted
+	hosted   map[string]bool // components hosted by this weavelet, by name
+	draining atomic.Bool     // true once the weavelet starts shutting down
 }
 
 var _ control.WeaveletControl = (*RemoteWeavelet)(nil)
@@ -118,11 +151,14 @@ type component struct {
 	implInit   sync.Once      // used to initialize impl, severStub
 	implErr    error          // non-nil if impl creation fails
 	implReady  atomic.Bool    // true only after impl creation succeeds
+	warm       atomic.Bool    // true only after impl is warmed up (see warmup)
+	ready      atomic.Bool    // true only after impl is ready (see ready)
 	impl       any            // instance of component implementation
 	serverStub codegen.Server // handles remote calls from other processes
 
 	resolver *routingResolver // client resolver
 	balancer *routingBalancer // client balancer
+	routing  *routingCache    // latest routing info
 
 	stubInit sync.Once    // used to initialize stub
 	stubErr  error        // non-nil if stub creation fails
@@ -171,6 +207,19 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 		dialAddr = fmt.Sprintf("mtls://%s", dialAddr)
 	}
 
+	events := newEvents()
+	replica := &replicaContext{
+		info: ReplicaInfo{
+			Index:      int(args.ReplicaIndex),
+			WeaveletID: args.Id,
+			Group:      args.Group,
+			Version:    args.DeploymentId,
+			Region:     args.Locality.GetRegion(),
+			Zone:       args.Locality.GetZone(),
+		},
+		events: events,
+	}
+	ctx = withReplica(ctx, replica)
 	servers, ctx := errgroup.WithContext(ctx)
 	w := &RemoteWeavelet{
 		ctx:              ctx,
@@ -179,6 +228,8 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 		args:             args,
 		dialAddr:         dialAddr,
 		weaverInfo:       &WeaverInfo{DeploymentID: args.DeploymentId},
+		replica:          replica,
+		events:           events,
 		logDst:           newRemoteLogger(os.Stderr),
 		initDone:         make(chan struct{}),
 		deployerReady:    make(chan struct{}),
@@ -186,8 +237,11 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 		componentsByIntf: map[reflect.Type]*component{},
 		componentsByImpl: map[reflect.Type]*component{},
 		redirects:        map[string]redirect{},
+		deps:             componentDeps(regs),
 		listeners:        map[string]*listener{},
+		hosted:           map[string]bool{},
 	}
+	replica.topology = w.getTopology
 
 	info := bootstrap.Args
 	controlSocket, err := net.Listen("unix", info.ControlSocket)
@@ -203,6 +257,18 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 	exporter := traceio.NewWriter(w.sendTraceSpans)
 	w.tracer = tracer(exporter, info.App, info.DeploymentId, info.Id)
 
+	// The control components are provided by the runtime rather than the
+	// application, so include them even if regs holds only a subset of the
+	// registered components.
+	for _, name := range []string{control.DeployerPath, control.WeaveletPath} {
+		if slices.ContainsFunc(regs, func(reg *codegen.Registration) bool { return reg.Name == name }) {
+			continue
+		}
+		if reg, ok := codegen.Find(name); ok {
+			regs = append(regs, reg)
+		}
+	}
+
 	// Initialize the component structs.
 	for _, reg := range regs {
 		reg := reg
@@ -233,7 +299,8 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 
 		// Initialize the resolver and balancer.
 		c.resolver = newRoutingResolver()
-		c.balancer = newRoutingBalancer(c.clientTLS)
+		c.balancer = newRoutingBalancer(reg.Name, c.clientTLS, info.Locality)
+		c.routing = &routingCache{component: reg.Name}
 	}
 
 	// Process all redirects.
@@ -265,6 +332,12 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 		})
 	})
 
+	// Watch the leases of the routing info.
+	servers.Go(func() error {
+		w.watchRoutingLeases(ctx)
+		return nil
+	})
+
 	// Wait for initialization handshake to complete so we have full config info.
 	select {
 	case <-ctx.Done():
@@ -278,8 +351,13 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 	servers.Go(func() error {
 		server := &server{Listener: lis, wlet: w}
 		opts := call.ServerOptions{
-			Logger: w.syslogger,
-			Tracer: w.tracer,
+			Logger:            w.syslogger,
+			Tracer:            w.tracer,
+			BlobThreshold:     w.largePayloadBytes,
+			CompressThreshold: w.compressPayloadBytes,
+			MaxMessageSize:    w.maxMessageBytes,
+			Checksum:          w.checksumPayloads,
+			RequireEncryption: w.args.Mtls,
 		}
 		if err := call.Serve(w.ctx, server, opts); err != nil {
 			w.syslogger.Error("RPC server failed", "err", err)
@@ -288,22 +366,49 @@ func NewRemoteWeavelet(ctx context.Context, regs []*codegen.Registration, bootst
 		return nil
 	})
 
+	// Serve the gRPC health checking and reflection services, if configured.
+	if w.grpcAddress != "" {
+		glis, err := net.Listen("tcp", w.grpcAddress)
+		if err != nil {
+			return nil, fmt.Errorf("gRPC listen on %q: %w", w.grpcAddress, err)
+		}
+		w.syslogger.Info("Serving gRPC health and reflection", "address", glis.Addr())
+		servers.Go(func() error {
+			return w.serveGRPC(ctx, glis)
+		})
+	}
+
 	// Start a signal handler to detect when the process is killed. This isn't
 	// perfect, as we can't catch a SIGKILL, but it's good in the common case.
 	done := make(chan os.Signal, 1)
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-done
+		w.draining.Store(true)
+		w.events.publish(ctx, &protos.DeploymentEvent{Kind: protos.DeploymentEvent_DRAINING})
+		// Fakes are not constructed by the weavelet, so they are not
+		// reported to lifecycle observers.
+		var ready []*component
 		for _, c := range w.componentsByName {
 			if !c.implReady.Load() {
 				continue
 			}
+			ready = append(ready, c)
+			if _, fake := w.opts.Fakes[c.reg.Iface]; !fake {
+				notify(func(o LifecycleObserver) { o.Draining(c.reg.Name, c.impl) })
+			}
+		}
+		for _, c := range ready {
 			// Call Shutdown method if available.
+			var err error
 			if i, ok := c.impl.(interface{ Shutdown(context.Context) error }); ok {
-				if err := i.Shutdown(ctx); err != nil {
+				if err = i.Shutdown(ctx); err != nil {
 					w.syslogger.Error("Component shutdown failed", "component", c.reg.Name, "err", err)
 				}
 			}
+			if _, fake := w.opts.Fakes[c.reg.Iface]; !fake {
+				notify(func(o LifecycleObserver) { o.ShutDown(c.reg.Name, c.impl, err) })
+			}
 		}
 		os.Exit(1)
 	}()
@@ -317,7 +422,43 @@ func (w *RemoteWeavelet) InitWeavelet(ctx context.Context, req *protos.InitWeave
 	w.initMu.Lock()
 	defer w.initMu.Unlock()
 	if !w.initCalled {
+		cfg, err := scheduling.ParseConfig(req.Sections)
+		if err != nil {
+			return nil, err
+		}
+		w.scheduling = cfg
+		if cfg.MaxConcurrentCalls > 0 {
+			w.calls = scheduling.NewScheduler(cfg.MaxConcurrentCalls)
+		}
+		hedgingConfig, err := hedging.ParseConfig(req.Sections)
+		if err != nil {
+			return nil, err
+		}
+		if len(hedgingConfig.Methods) > 0 {
+			w.hedger = hedging.NewHedger(hedgingConfig)
+		}
 		w.sectionConfig = req.Sections
+		w.initTimeout = req.InitTimeoutNanos
+		w.componentInitTimeouts = req.ComponentInitTimeoutNanos
+		w.lazy = map[string]bool{}
+		for _, component := range req.LazyComponents {
+			w.lazy[component] = true
+		}
+		w.auditor = newAuditor(w.args.App, w.args.DeploymentId, w.args.Id, w.args.AuditDir, req.AuditedMethods, w.syslogger)
+		w.largePayloadBytes = int(req.LargePayloadBytes)
+		w.compressPayloadBytes = int(req.CompressPayloadBytes)
+		w.maxMessageBytes = int(req.MaxMessageBytes)
+		w.checksumPayloads = req.ChecksumPayloads
+		w.deduper = newDeduper(time.Duration(req.IdempotencyWindowNanos))
+		w.grpcAddress = req.GrpcAddress
+		w.atMostOnce = map[string][]string{}
+		for _, method := range req.AtMostOnceMethods {
+			i := strings.LastIndex(method, ".")
+			if i < 0 {
+				continue
+			}
+			w.atMostOnce[method[:i]] = append(w.atMostOnce[method[:i]], method[i+1:])
+		}
 		w.initCalled = true
 		close(w.initDone)
 	}
@@ -332,6 +473,12 @@ func (w *RemoteWeavelet) InitWeavelet(ctx context.Context, req *protos.InitWeave
 	}, nil
 }
 
+// WithReplica returns a context that carries information about the replica
+// that the weavelet runs, like the contexts passed to its components.
+func (w *RemoteWeavelet) WithReplica(ctx context.Context) context.Context {
+	return withReplica(ctx, w.replica)
+}
+
 // Wait waits for the RemoteWeavelet to fully shut down after its context has
 // been cancelled.
 func (w *RemoteWeavelet) Wait() error {
@@ -350,7 +497,15 @@ func (w *RemoteWeavelet) getIntf(t reflect.Type, requester string) (any, error)
 	if !ok {
 		return nil, fmt.Errorf("component of type %v was not registered; maybe you forgot to run weaver generate", t)
 	}
+	if c.reg.Name == requester {
+		return selfStub(c.reg, func() (any, error) { return w.intf(c, requester) }), nil
+	}
+	return w.intf(c, requester)
+}
 
+// intf returns the component interface of the provided component, for use by
+// the requester.
+func (w *RemoteWeavelet) intf(c *component, requester string) (any, error) {
 	if r, ok := w.redirects[c.reg.Name]; ok {
 		return w.redirect(requester, c, r.target, r.address)
 	}
@@ -382,11 +537,20 @@ func (w *RemoteWeavelet) getIntf(t reflect.Type, requester string) (any, error)
 
 	// Return a local stub.
 	if c.local.Read() {
+		if w.lazy[c.reg.Name] {
+			stub := lazyStub(c.reg, requester, w.tracer, &w.firstCalls, func() (any, error) {
+				return w.GetImpl(c.reg.Impl)
+			})
+			return w.auditor.wrap(c.reg, requester, stub), nil
+		}
+		if cycle := w.inits.cycle(requester, c.reg.Name, w.deps); cycle != nil {
+			return nil, cycleError(cycle)
+		}
 		impl, err := w.GetImpl(c.reg.Impl)
 		if err != nil {
 			return nil, err
 		}
-		return c.reg.LocalStubFn(impl, requester, w.tracer), nil
+		return w.auditor.wrap(c.reg, requester, c.reg.LocalStubFn(impl, requester, w.tracer)), nil
 	}
 
 	// Return a remote stub.
@@ -394,7 +558,42 @@ func (w *RemoteWeavelet) getIntf(t reflect.Type, requester string) (any, error)
 	if err != nil {
 		return nil, err
 	}
-	return c.reg.ClientStubFn(stub, requester), nil
+	stub = callerStub{Stub: stub, caller: codegen.Caller{Component: requester, Version: w.args.DeploymentId}}
+	return w.auditor.wrap(c.reg, requester, c.reg.ClientStubFn(stub, requester)), nil
+}
+
+// getReplicas returns one handle per replica of the component with the
+// provided interface type. Each handle sends all of its calls to its
+// replica. Local and redirected components have a single handle.
+func (w *RemoteWeavelet) getReplicas(t reflect.Type, requester string) ([]any, error) {
+	c, ok := w.componentsByIntf[t]
+	if !ok {
+		return nil, fmt.Errorf("component of type %v was not registered; maybe you forgot to run weaver generate", t)
+	}
+	// Replicas are requested once the requester is constructed, so unlike
+	// getIntf, a component's replicas of itself are resolved right away.
+	intf, err := w.intf(c, requester)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := w.redirects[c.reg.Name]; ok || c.local.Read() {
+		return []any{intf}, nil
+	}
+	stub, err := w.getStub(c)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := stub.(interface{ Replicas() []string })
+	if !ok {
+		return []any{intf}, nil
+	}
+	caller := codegen.Caller{Component: requester, Version: w.args.DeploymentId}
+	var handles []any
+	for _, addr := range r.Replicas() {
+		stub := replicaStub{Stub: callerStub{Stub: stub, caller: caller}, addr: addr}
+		handles = append(handles, w.auditor.wrap(c.reg, requester, c.reg.ClientStubFn(stub, requester)))
+	}
+	return handles, nil
 }
 
 // redirect creates a component interface for c that redirects calls to the
@@ -413,7 +612,10 @@ func (w *RemoteWeavelet) redirect(requester string, c *component, target, addres
 		}
 		resolver := call.NewConstantResolver(endpoint)
 		// TODO(sanjay): Pass retry info from the target component.
-		c.stub, c.stubErr = w.makeStub(target, c.reg, resolver, nil, false)
+		//
+		// Redirected calls are sent to the deployer over a local Unix
+		// socket, which isn't encrypted, even if mTLS is enabled.
+		c.stub, c.stubErr = w.makeStub(target, c.reg, resolver, nil, false, false)
 	})
 	if c.stubErr != nil {
 		return nil, c.stubErr
@@ -431,12 +633,22 @@ func (w *RemoteWeavelet) GetImpl(t reflect.Type) (any, error) {
 	c.implInit.Do(func() {
 		name := logging.ShortenComponent(c.reg.Name)
 		w.syslogger.Debug("Constructing", "component", name)
+		w.inits.start(c.reg.Name)
 		c.impl, c.implErr = w.createComponent(w.ctx, c.reg)
+		w.inits.done(c.reg.Name)
 		if c.implErr != nil {
 			w.syslogger.Error("Failed to construct", "component", name, "err", c.implErr)
 			return
 		} else {
 			w.syslogger.Debug("Constructed", "component", name)
+			if _, ok := c.impl.(interface{ Warmup(context.Context) error }); ok && !w.lazy[c.reg.Name] {
+				go w.warmup(c)
+			} else {
+				// There is nothing to warm up, or the component is lazy
+				// and is constructed by a method call that is waiting for
+				// it, so there is no point in deferring the warmup.
+				w.warmup(c)
+			}
 			c.implReady.Store(true)
 		}
 
@@ -485,6 +697,8 @@ func (w *RemoteWeavelet) createComponent(ctx context.Context, reg *codegen.Regis
 	// Fill ref fields.
 	if err := FillRefs(obj, func(t reflect.Type) (any, error) {
 		return w.getIntf(t, reg.Name)
+	}, func(t reflect.Type) ([]any, error) {
+		return w.getReplicas(t, reg.Name)
 	}); err != nil {
 		return nil, err
 	}
@@ -500,31 +714,70 @@ func (w *RemoteWeavelet) createComponent(ctx context.Context, reg *codegen.Regis
 		return nil, err
 	}
 
+	notify(func(o LifecycleObserver) { o.Constructed(reg.Name, obj) })
+
 	// Call Init if available.
 	if i, ok := obj.(interface{ Init(context.Context) error }); ok {
-		if err := i.Init(ctx); err != nil {
-			return nil, fmt.Errorf("component %q initialization failed: %w", reg.Name, err)
+		timeout := initTimeout(reg.Name, w.initTimeout, w.componentInitTimeouts)
+		if err := w.inits.run(ctx, reg.Name, timeout, func(ctx context.Context) error {
+			defer w.reportCrash(reg.Name, "Init")
+			return i.Init(ctx)
+		}); err != nil {
+			notify(func(o LifecycleObserver) { o.Initialized(reg.Name, obj, err) })
+			return nil, err
 		}
 	}
+	notify(func(o LifecycleObserver) { o.Initialized(reg.Name, obj, nil) })
 	return obj, nil
 }
 
 // getStub returns a component's client stub, initializing it if necessary.
 func (w *RemoteWeavelet) getStub(c *component) (codegen.Stub, error) {
 	c.stubInit.Do(func() {
-		c.stub, c.stubErr = w.makeStub(c.reg.Name, c.reg, c.resolver, c.balancer, true)
+		c.stub, c.stubErr = w.makeStub(c.reg.Name, c.reg, c.resolver, c.balancer, true, w.args.Mtls)
 	})
 	return c.stub, c.stubErr
 }
 
-// makeStub makes a new stub with the provided resolver and balancer.
-func (w *RemoteWeavelet) makeStub(fullName string, reg *codegen.Registration, resolver call.Resolver, balancer call.Balancer, wait bool) (codegen.Stub, error) {
+// callerStub is a codegen.Stub that records the calling component in the
+// context of every call, so that the callee can identify it.
+type callerStub struct {
+	codegen.Stub
+	caller codegen.Caller
+}
+
+// Run implements the codegen.Stub interface.
+func (s callerStub) Run(ctx context.Context, method int, args []byte, shardKey uint64) ([]byte, error) {
+	return s.Stub.Run(codegen.WithCaller(ctx, s.caller), method, args, shardKey)
+}
+
+// replicaStub is a codegen.Stub that sends every call to the replica with
+// the provided address.
+type replicaStub struct {
+	codegen.Stub
+	addr string
+}
+
+// Run implements the codegen.Stub interface.
+func (s replicaStub) Run(ctx context.Context, method int, args []byte, shardKey uint64) ([]byte, error) {
+	return s.Stub.Run(call.WithReplica(ctx, s.addr), method, args, shardKey)
+}
+
+// makeStub makes a new stub with the provided resolver and balancer. If
+// encrypted is true, the stub only connects to replicas over encrypted
+// connections.
+func (w *RemoteWeavelet) makeStub(fullName string, reg *codegen.Registration, resolver call.Resolver, balancer call.Balancer, wait, encrypted bool) (codegen.Stub, error) {
 	// Create the client connection.
 	name := logging.ShortenComponent(fullName)
 	w.syslogger.Debug("Connecting to remote", "component", name)
 	opts := call.ClientOptions{
-		Balancer: balancer,
-		Logger:   w.syslogger,
+		Balancer:          balancer,
+		Logger:            w.syslogger,
+		BlobThreshold:     w.largePayloadBytes,
+		CompressThreshold: w.compressPayloadBytes,
+		MaxMessageSize:    w.maxMessageBytes,
+		Checksum:          w.checksumPayloads,
+		RequireEncryption: encrypted,
 	}
 	conn, err := call.Connect(w.ctx, resolver, opts)
 	if err != nil {
@@ -538,7 +791,25 @@ func (w *RemoteWeavelet) makeStub(fullName string, reg *codegen.Registration, re
 		}
 	}
 	w.syslogger.Debug("Connected to remote", "component", name)
-	return call.NewStub(fullName, reg, conn, w.tracer, w.opts.InjectRetries), nil
+	reg = withAtMostOnce(reg, w.atMostOnce[fullName])
+	return call.NewStub(fullName, reg, conn, w.tracer, w.opts.InjectRetries, w.hedger), nil
+}
+
+// withAtMostOnce returns a copy of reg in which the provided methods are
+// at-most-once, in addition to the methods that are already at-most-once.
+// Unknown methods are ignored.
+func withAtMostOnce(reg *codegen.Registration, methods []string) *codegen.Registration {
+	if len(methods) == 0 {
+		return reg
+	}
+	r := *reg
+	r.AtMostOnce = slices.Clone(reg.AtMostOnce)
+	for _, method := range methods {
+		if m, ok := reg.Iface.MethodByName(method); ok && !slices.Contains(r.AtMostOnce, m.Index) {
+			r.AtMostOnce = append(r.AtMostOnce, m.Index)
+		}
+	}
+	return &r
 }
 
 // GetLoad implements controller interface.
@@ -573,6 +844,9 @@ func (w *RemoteWeavelet) UpdateComponents(_ context.Context, req *protos.UpdateC
 			continue
 		}
 		components = append(components, c)
+		w.hostedMu.Lock()
+		w.hosted[component] = true
+		w.hostedMu.Unlock()
 	}
 
 	// Create components in a separate goroutine. A component's Init function
@@ -585,6 +859,11 @@ func (w *RemoteWeavelet) UpdateComponents(_ context.Context, req *protos.UpdateC
 	for i, c := range components {
 		i := i
 		c := c
+		if w.lazy[c.reg.Name] {
+			// Lazy components are constructed on their first method call.
+			w.syslogger.Debug("Deferring construction", "component", shortened[i])
+			continue
+		}
 		go func() {
 			w.syslogger.Debug("Updating", "components", shortened[i])
 			if _, err := w.GetImpl(c.reg.Impl); err != nil {
@@ -644,12 +923,32 @@ func (w *RemoteWeavelet) UpdateRoutingInfo(_ context.Context, req *protos.Update
 		return
 	}
 
+	// Apply the update to the cached routing info. An update may be a delta
+	// or a lease renewal, in which case info becomes the full routing info.
+	info, changed, err := c.routing.apply(req.RoutingInfo, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return &protos.UpdateRoutingInfoReply{}, nil
+	}
+
+	// Update the replica localities before the resolver, so that the balancer
+	// knows the locality of new replicas when it receives their connections.
+	c.balancer.updateLocalities(info.Localities)
+
 	// Update resolver.
 	endpoints, err := parseEndpoints(info.Replicas, c.clientTLS)
 	if err != nil {
 		return nil, err
 	}
-	c.resolver.update(endpoints)
+	if c.resolver.update(endpoints) {
+		w.events.publish(w.ctx, &protos.DeploymentEvent{
+			Kind:      protos.DeploymentEvent_REPLICAS_CHANGED,
+			Component: info.Component,
+			Replicas:  info.Replicas,
+		})
+	}
 
 	// Update balancer.
 	if info.Assignment != nil {
@@ -664,26 +963,67 @@ func (w *RemoteWeavelet) UpdateRoutingInfo(_ context.Context, req *protos.Update
 	return &protos.UpdateRoutingInfoReply{}, nil
 }
 
+// watchRoutingLeases periodically records the staleness of the routing info
+// of every remote component, until ctx is done. While the lease of the routing
+// info of a component is expired, e.g., because the deployer is briefly
+// unreachable, the weavelet keeps routing calls with the stale routing info,
+// but stops requiring routed calls to reach a replica of their slice.
+func (w *RemoteWeavelet) watchRoutingLeases(ctx context.Context) {
+	ticker := time.NewTicker(routingLeaseCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			for name, c := range w.componentsByName {
+				if c.routing == nil {
+					continue
+				}
+				staleness := c.routing.staleness(now)
+				routingStaleness.Get(routingLabels{Component: name}).Set(staleness.Seconds())
+				c.balancer.setStale(staleness > 0)
+			}
+		}
+	}
+}
+
 // GetHealth implements controller.GetHealth.
-func (w *RemoteWeavelet) GetHealth(context.Context, *protos.GetHealthRequest) (*protos.GetHealthReply, error) {
+func (w *RemoteWeavelet) GetHealth(ctx context.Context, _ *protos.GetHealthRequest) (*protos.GetHealthReply, error) {
 	// Get the health status for all components. For now, we consider a component
-	// healthy iff it has been successfully initialized. In the future, we will
-	// maintain a real-time health for each component.
+	// healthy iff it is ready (see ready). In the future, we will maintain a
+	// real-time health for each component.
 	reply := &protos.GetHealthReply{Status: protos.HealthStatus_HEALTHY}
 	for cname, c := range w.componentsByName {
-		if c.implReady.Load() {
+		if w.ready(ctx, c) {
 			reply.HealthyComponents = append(reply.HealthyComponents, cname)
 		}
 	}
 	return reply, nil
 }
 
+// getTopology returns the live component graph of the deployment, as
+// reported by the deployer.
+func (w *RemoteWeavelet) getTopology(ctx context.Context) (*protos.Topology, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-w.deployerReady:
+	}
+	reply, err := w.deployer.GetTopology(ctx, &protos.GetTopologyRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Topology, nil
+}
+
 // GetMetrics implements controller.GetMetrics.
 func (w *RemoteWeavelet) GetMetrics(context.Context, *protos.GetMetricsRequest) (*protos.GetMetricsReply, error) {
 	// TODO(sanjay): The protocol is currently brittle; if we ever lose a set of
 	// updates, they will be lost forever. Fix by versioning the "last" map in
 	// metrics.Exporter. The reader echoes back the version of the last set of
 	// updates it read. If the echoed version does not match, send everything.
+	w.scheduler.sample()
 	updates := w.metrics.Export()
 
 	// Add weavelet labels to the metrics.
@@ -708,6 +1048,20 @@ func (w *RemoteWeavelet) GetProfile(ctx context.Context, req *protos.GetProfileR
 	return &protos.GetProfileReply{Data: data}, nil
 }
 
+// GetFlightRecord implements controller.GetFlightRecord.
+func (w *RemoteWeavelet) GetFlightRecord(context.Context, *protos.GetFlightRecordRequest) (*protos.GetFlightRecordReply, error) {
+	return &protos.GetFlightRecordReply{Events: flightRecord(w.Info().Id)}, nil
+}
+
+// NotifyDeploymentEvent implements controller.NotifyDeploymentEvent.
+func (w *RemoteWeavelet) NotifyDeploymentEvent(ctx context.Context, req *protos.NotifyDeploymentEventRequest) (*protos.NotifyDeploymentEventReply, error) {
+	if req.Event == nil {
+		return nil, fmt.Errorf("nil DeploymentEvent")
+	}
+	w.events.publish(w.WithReplica(ctx), req.Event)
+	return &protos.NotifyDeploymentEventReply{}, nil
+}
+
 // Info returns the WeaveletArgs received from the envelope.
 func (w *RemoteWeavelet) Info() *protos.WeaveletArgs {
 	return w.args
@@ -732,7 +1086,14 @@ func (w *RemoteWeavelet) getComponent(name string) (*component, error) {
 func (w *RemoteWeavelet) addHandlers(handlers *call.HandlerMap, c *component) {
 	for i, n := 0, c.reg.Iface.NumMethod(); i < n; i++ {
 		mname := c.reg.Iface.Method(i).Name
+		queue := newMethodQueue(c.reg.Name, mname)
 		handler := func(ctx context.Context, args []byte) (res []byte, err error) {
+			defer w.reportCrash(c.reg.Name, mname)
+			ctx = withReplica(ctx, w.replica)
+			start := time.Now()
+			qc := queue.receive()
+			defer qc.finish()
+
 			// This handler is supposed to invoke the method named mname on the
 			// local component. However, it is possible that the component has
 			// not yet been started. w.GetImpl will start the component if it
@@ -741,8 +1102,25 @@ func (w *RemoteWeavelet) addHandlers(handlers *call.HandlerMap, c *component) {
 			if _, err := w.GetImpl(c.reg.Impl); err != nil {
 				return nil, err
 			}
+			// Reject calls until the component is ready, so that clients
+			// retry them on other replicas.
+			if !w.ready(ctx, c) {
+				return nil, call.Unreachable
+			}
 			fn := c.serverStub.GetStubFn(mname)
-			return fn(ctx, args)
+			res, err = w.deduper.do(ctx, c.reg.Name, mname, args, func() ([]byte, error) {
+				ctx, release, err := w.admit(ctx, c.reg.Name, mname)
+				if err != nil {
+					return nil, err
+				}
+				defer release()
+				qc.start()
+				return fn(ctx, args)
+			})
+			if w.lazy[c.reg.Name] {
+				w.firstCalls.record(c.reg.Name, start)
+			}
+			return res, err
 		}
 		handlers.Set(c.reg.Name, mname, handler)
 	}
@@ -750,14 +1128,120 @@ func (w *RemoteWeavelet) addHandlers(handlers *call.HandlerMap, c *component) {
 	// Add the special "component is ready" method handler, which is used by
 	// the clients to wait for the component to be ready before receiving traffic
 	// (see waitUntilReady).
-	handlers.Set(c.reg.Name, readyMethodName, func(context.Context, []byte) ([]byte, error) {
-		if c.implReady.Load() {
+	handlers.Set(c.reg.Name, readyMethodName, func(ctx context.Context, _ []byte) ([]byte, error) {
+		if w.ready(ctx, c) {
 			return nil, nil
 		}
 		return nil, call.Unreachable
 	})
 }
 
+// admit waits until the call scheduler admits a call to the provided method,
+// and returns the context to run the call with and a function to call when the
+// call finishes. A call made, directly or not, by a call that this weavelet
+// admitted runs right away: it would otherwise wait for a slot held by its
+// caller, which may be all of them.
+func (w *RemoteWeavelet) admit(ctx context.Context, component, method string) (context.Context, func(), error) {
+	if w.calls == nil {
+		return ctx, func() {}, nil
+	}
+	if slices.Contains(codegen.AdmittedFromContext(ctx), w.id) {
+		return ctx, w.calls.Bypass(), nil
+	}
+	priority, ok := codegen.PriorityFromContext(ctx)
+	if !ok {
+		priority = w.scheduling.Priority(component, method)
+	}
+	deadline, _ := ctx.Deadline()
+	release, err := w.calls.Acquire(ctx, priority, deadline)
+	if err != nil {
+		return nil, nil, err
+	}
+	return codegen.WithAdmitted(ctx, w.id), release, nil
+}
+
+// warmup calls the Warmup(context.Context) error method of the provided
+// component's implementation, if it has one, and then marks the component as
+// warm. A component does not receive traffic until it is warm (see ready), so
+// Warmup can populate caches or establish connections before the first call
+// arrives. Warmup is a best-effort hint: if it fails, the failure is logged and
+// the component is still marked warm.
+func (w *RemoteWeavelet) warmup(c *component) {
+	defer c.warm.Store(true)
+	wm, ok := c.impl.(interface{ Warmup(context.Context) error })
+	if !ok {
+		return
+	}
+	name := logging.ShortenComponent(c.reg.Name)
+	w.syslogger.Debug("Warming up", "component", name)
+	start := time.Now()
+	if err := func() error {
+		defer w.reportCrash(c.reg.Name, "Warmup")
+		return wm.Warmup(w.ctx)
+	}(); err != nil {
+		w.syslogger.Warn("Failed to warm up", "component", name, "err", err)
+		return
+	}
+	w.syslogger.Debug("Warmed up", "component", name, "duration", time.Since(start))
+}
+
+// ready returns whether the provided component is ready to receive traffic. A
+// component is ready once it has been successfully initialized and warmed
+// up and, if its implementation has a Ready(context.Context) error method,
+// once a call to Ready succeeds. Once a component is ready, it stays ready. A lazy component
+// that has not been constructed yet is also ready.
+func (w *RemoteWeavelet) ready(ctx context.Context, c *component) bool {
+	if c.ready.Load() {
+		return true
+	}
+	if !c.implReady.Load() {
+		// A lazy component is constructed by its first method call, so it
+		// must accept calls before it has been constructed.
+		return w.lazy[c.reg.Name]
+	}
+	if !c.warm.Load() {
+		return false
+	}
+	if r, ok := c.impl.(interface{ Ready(context.Context) error }); ok {
+		if err := r.Ready(ctx); err != nil {
+			w.syslogger.Debug("Not ready", "component", logging.ShortenComponent(c.reg.Name), "err", err)
+			return false
+		}
+	}
+	c.ready.Store(true)
+	return true
+}
+
+// reportCrash writes a crash report, attributed to the provided component
+// method, if the calling goroutine is panicking. It then resumes panicking.
+// reportCrash must be called directly by a deferred function.
+func (w *RemoteWeavelet) reportCrash(component, method string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if w.args.CrashReportDir == "" && w.args.CrashReportWebhook == "" {
+		panic(r)
+	}
+
+	report := crashreport.New(w.args.App, w.args.DeploymentId, w.args.Id, component, method, r, debug.Stack())
+	if dir := w.args.CrashReportDir; dir != "" {
+		if filename, err := crashreport.Write(dir, report); err != nil {
+			w.syslogger.Error("Failed to write crash report", "component", component, "method", method, "err", err)
+		} else {
+			fmt.Fprintf(os.Stderr, "crash report written to %s\n", filename)
+		}
+	}
+	if url := w.args.CrashReportWebhook; url != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), crashReportTimeout)
+		defer cancel()
+		if err := crashreport.Post(ctx, url, report); err != nil {
+			w.syslogger.Error("Failed to post crash report", "component", component, "method", method, "err", err)
+		}
+	}
+	panic(r)
+}
+
 // repeatedly repeatedly executes f until it succeeds or until ctx is cancelled.
 func (w *RemoteWeavelet) repeatedly(ctx context.Context, errMsg string, f func() error) error {
 	for r := retry.Begin(); r.Continue(ctx); {
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+// readyImpl is a component implementation with a Ready method.
+type readyImpl struct {
+	ready bool
+}
+
+func (r *readyImpl) Ready(context.Context) error {
+	if !r.ready {
+		return fmt.Errorf("not ready")
+	}
+	return nil
+}
+
+func TestReady(t *testing.T) {
+	ctx := context.Background()
+	w := &RemoteWeavelet{syslogger: slog.New(slog.NewTextHandler(os.Stderr, nil))}
+	impl := &readyImpl{}
+	c := &component{reg: &codegen.Registration{Name: "Foo"}, impl: impl}
+
+	// Not initialized.
+	if w.ready(ctx, c) {
+		t.Fatal("uninitialized component is ready")
+	}
+
+	// Initialized, but not warmed up.
+	c.implReady.Store(true)
+	if w.ready(ctx, c) {
+		t.Fatal("cold component is ready")
+	}
+
+	// Warmed up, but Ready fails.
+	c.warm.Store(true)
+	if w.ready(ctx, c) {
+		t.Fatal("component with failing Ready is ready")
+	}
+
+	// Ready succeeds.
+	impl.ready = true
+	if !w.ready(ctx, c) {
+		t.Fatal("component with succeeding Ready is not ready")
+	}
+
+	// Once ready, a component stays ready.
+	impl.ready = false
+	if !w.ready(ctx, c) {
+		t.Fatal("ready component became not ready")
+	}
+}
+
+// warmupImpl is a component implementation with a Warmup method.
+type warmupImpl struct {
+	warmedUp bool
+	err      error
+}
+
+func (w *warmupImpl) Warmup(context.Context) error {
+	w.warmedUp = true
+	return w.err
+}
+
+func TestWarmup(t *testing.T) {
+	w := &RemoteWeavelet{
+		ctx:       context.Background(),
+		syslogger: slog.New(slog.NewTextHandler(os.Stderr, nil)),
+	}
+	for _, test := range []struct {
+		name string
+		impl any
+	}{
+		{"NoWarmup", &readyImpl{}},
+		{"Warmup", &warmupImpl{}},
+		{"FailingWarmup", &warmupImpl{err: fmt.Errorf("cache unavailable")}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			c := &component{reg: &codegen.Registration{Name: "Foo"}, impl: test.impl}
+			w.warmup(c)
+			if !c.warm.Load() {
+				t.Fatal("component is not warm after warmup")
+			}
+			if impl, ok := test.impl.(*warmupImpl); ok && !impl.warmedUp {
+				t.Fatal("Warmup not called")
+			}
+		})
+	}
+}
+
+func TestWithAtMostOnce(t *testing.T) {
+	reg := &codegen.Registration{
+		Name: "Foo",
+		Iface: reflection.Type[interface {
+			A()
+			B()
+			C()
+		}](),
+		AtMostOnce: []int{0},
+	}
+	got := withAtMostOnce(reg, []string{"A", "C", "Missing"})
+	if diff := cmp.Diff([]int{0, 2}, got.AtMostOnce); diff != "" {
+		t.Errorf("at-most-once methods (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]int{0}, reg.AtMostOnce); diff != "" {
+		t.Errorf("original registration modified (-want +got):\n%s", diff)
+	}
+	if got := withAtMostOnce(reg, nil); got != reg {
+		t.Errorf("withAtMostOnce(nil) copied the registration")
+	}
+}
+
+func TestNotifyDeploymentEvent(t *testing.T) {
+	ctx := context.Background()
+	events := newEvents()
+	w := &RemoteWeavelet{
+		replica: &replicaContext{info: ReplicaInfo{Index: 1}, events: events},
+		events:  events,
+	}
+
+	// Subscribe like a component would, with a context from the weavelet.
+	subscribed, ok := EventsFromContext(w.WithReplica(ctx))
+	if !ok || subscribed != events {
+		t.Fatal("missing events in context")
+	}
+	var got []*protos.DeploymentEvent
+	subscribed.Subscribe(func(ctx context.Context, e *protos.DeploymentEvent) {
+		if info, ok := ReplicaInfoFromContext(ctx); !ok || info.Index != 1 {
+			t.Errorf("ReplicaInfoFromContext: got %v, %t", info, ok)
+		}
+		got = append(got, e)
+	})
+
+	event := &protos.DeploymentEvent{Kind: protos.DeploymentEvent_ROLLOUT, Version: "v2"}
+	if _, err := w.NotifyDeploymentEvent(ctx, &protos.NotifyDeploymentEventRequest{Event: event}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != event {
+		t.Fatalf("events: got %v, want [%v]", got, event)
+	}
+	if _, err := w.NotifyDeploymentEvent(ctx, &protos.NotifyDeploymentEventRequest{}); err == nil {
+		t.Fatal("unexpected success for nil event")
+	}
+}
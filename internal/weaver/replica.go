@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// replicaKey is the context key used to store a replicaContext.
+type replicaKey struct{}
+
+// replicaContext is the information about a replica stored in the contexts
+// passed to the components it runs.
+type replicaContext struct {
+	info   ReplicaInfo
+	events *Events // nil if the replica doesn't deliver deployment events
+
+	// topology returns the live component graph of the deployment. It is nil
+	// if the replica can't report the graph.
+	topology func(context.Context) (*protos.Topology, error)
+}
+
+// WithReplicaInfo returns a context that carries the provided information
+// about the replica running the components that receive the context.
+func WithReplicaInfo(ctx context.Context, info ReplicaInfo) context.Context {
+	return withReplica(ctx, &replicaContext{info: info})
+}
+
+// withReplica returns a context that carries the provided replica context.
+func withReplica(ctx context.Context, r *replicaContext) context.Context {
+	return context.WithValue(ctx, replicaKey{}, r)
+}
+
+// ReplicaInfoFromContext returns the replica information stored in ctx, if
+// any.
+func ReplicaInfoFromContext(ctx context.Context) (ReplicaInfo, bool) {
+	r, ok := ctx.Value(replicaKey{}).(*replicaContext)
+	if !ok {
+		return ReplicaInfo{}, false
+	}
+	return r.info, true
+}
+
+// EventsFromContext returns the deployment events of the replica stored in
+// ctx, if any. The returned Events is nil if the replica doesn't deliver
+// deployment events.
+func EventsFromContext(ctx context.Context) (*Events, bool) {
+	r, ok := ctx.Value(replicaKey{}).(*replicaContext)
+	if !ok {
+		return nil, false
+	}
+	return r.events, true
+}
+
+// TopologyFromContext returns the live component graph of the deployment
+// running the replica stored in ctx.
+func TopologyFromContext(ctx context.Context) (*protos.Topology, error) {
+	r, ok := ctx.Value(replicaKey{}).(*replicaContext)
+	if !ok || r.topology == nil {
+		return nil, fmt.Errorf("component topology not available")
+	}
+	return r.topology(ctx)
+}
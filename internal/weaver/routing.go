@@ -18,18 +18,54 @@ import (
 	"context"
 	"crypto/tls"
 	"math/rand"
+	"slices"
 	"sync"
+	"sync/atomic"
 
 	"github.com/ServiceWeaver/weaver/internal/cond"
 	"github.com/ServiceWeaver/weaver/internal/net/call"
+	"github.com/ServiceWeaver/weaver/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/uuid"
 )
 
-// routingBalancer balances requests according to a routing assignment.
+// localityLabels are the labels of the cross-locality call metrics.
+type localityLabels struct {
+	Component string // full callee component name
+	From      string // the caller's region or zone
+	To        string // the callee's region or zone
+}
+
+var (
+	// crossRegionCalls counts calls sent to a replica in another region.
+	crossRegionCalls = metrics.NewCounterMap[localityLabels](
+		"serviceweaver_system_cross_region_call_count",
+		"Count of Service Weaver component method calls sent to a replica in another region",
+	)
+
+	// crossZoneCalls counts calls sent to a replica in another zone, but not
+	// in another region.
+	crossZoneCalls = metrics.NewCounterMap[localityLabels](
+		"serviceweaver_system_cross_zone_call_count",
+		"Count of Service Weaver component method calls sent to a replica in another zone, but not in another region",
+	)
+)
+
+// Proximity of a replica to the caller, from closest to farthest.
+const (
+	sameZone = iota
+	sameRegion
+	elsewhere
+)
+
+// routingBalancer balances requests according to a routing assignment. It
+// prefers replicas in the caller's zone, then in the caller's region.
 type routingBalancer struct {
-	balancer  call.Balancer // balancer to use for non-routed calls
-	tlsConfig *tls.Config   // tls config to use; may be nil.
+	balancer  call.Balancer    // balancer to use for non-routed calls
+	tlsConfig *tls.Config      // tls config to use; may be nil.
+	component string           // name of the component being called
+	locality  *protos.Locality // locality of the caller; may be nil
+	stale     atomic.Bool      // is the lease of the assignment expired?
 
 	mu         sync.RWMutex
 	assignment *protos.Assignment
@@ -39,15 +75,31 @@ type routingBalancer struct {
 	// connection per address.
 	// Guarded by mu.
 	conns map[string]call.ReplicaConnection
+
+	// Map from address to replica locality, and balancers over the
+	// connections in the caller's zone and region. The balancers are nil if
+	// the caller's locality is unknown.
+	// Guarded by mu.
+	localities map[string]*protos.Locality
+	zone       call.Balancer
+	region     call.Balancer
 }
 
-// newRoutingBalancer returns a new routingBalancer.
-func newRoutingBalancer(tlsConfig *tls.Config) *routingBalancer {
-	return &routingBalancer{
+// newRoutingBalancer returns a new routingBalancer for calls to the provided
+// component, made from the provided locality.
+func newRoutingBalancer(component string, tlsConfig *tls.Config, locality *protos.Locality) *routingBalancer {
+	rb := &routingBalancer{
 		balancer:  call.RoundRobin(),
 		tlsConfig: tlsConfig,
+		component: component,
+		locality:  locality,
 		conns:     map[string]call.ReplicaConnection{},
 	}
+	if locality.GetRegion() != "" || locality.GetZone() != "" {
+		rb.zone = call.RoundRobin()
+		rb.region = call.RoundRobin()
+	}
+	return rb
 }
 
 // Add adds c to the set of connections we are balancing across.
@@ -57,6 +109,7 @@ func (rb *routingBalancer) Add(c call.ReplicaConnection) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 	rb.conns[c.Address()] = c
+	rb.addLocal(c)
 }
 
 // Remove removes c from the set of connections we are balancing across.
@@ -66,6 +119,43 @@ func (rb *routingBalancer) Remove(c call.ReplicaConnection) {
 	rb.mu.Lock()
 	defer rb.mu.Unlock()
 	delete(rb.conns, c.Address())
+	if rb.zone != nil {
+		rb.zone.Remove(c)
+		rb.region.Remove(c)
+	}
+}
+
+// addLocal adds c to the balancer of its proximity, if any.
+//
+// REQUIRES: rb.mu is held.
+func (rb *routingBalancer) addLocal(c call.ReplicaConnection) {
+	if rb.zone == nil {
+		return
+	}
+	switch rb.proximity(c.Address()) {
+	case sameZone:
+		rb.zone.Add(c)
+	case sameRegion:
+		rb.region.Add(c)
+	}
+}
+
+// proximity returns the proximity to the caller of the replica with the
+// provided address.
+//
+// REQUIRES: rb.mu is held.
+func (rb *routingBalancer) proximity(addr string) int {
+	l, ok := rb.localities[addr]
+	if !ok {
+		return elsewhere
+	}
+	if l.Zone != "" && l.Zone == rb.locality.GetZone() {
+		return sameZone
+	}
+	if l.Region != "" && l.Region == rb.locality.GetRegion() {
+		return sameRegion
+	}
+	return elsewhere
 }
 
 // update updates the balancer with the provided assignment
@@ -81,12 +171,44 @@ func (rb *routingBalancer) update(assignment *protos.Assignment) {
 	rb.index = index
 }
 
+// setStale records whether the lease of the balancer's assignment is expired.
+// While it is, routed calls whose slice has no reachable replica are sent to
+// any replica instead, since the assignment may be outdated.
+func (rb *routingBalancer) setStale(stale bool) {
+	rb.stale.Store(stale)
+}
+
+// updateLocalities updates the balancer with the provided replica
+// localities, keyed by replica address.
+func (rb *routingBalancer) updateLocalities(localities map[string]*protos.Locality) {
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	rb.localities = localities
+	if rb.zone == nil {
+		return
+	}
+	rb.zone = call.RoundRobin()
+	rb.region = call.RoundRobin()
+	for _, c := range rb.conns {
+		rb.addLocal(c)
+	}
+}
+
 // Pick implements the call.Balancer interface.
 func (rb *routingBalancer) Pick(opts call.CallOptions) (call.ReplicaConnection, bool) {
+	c, ok := rb.pick(opts)
+	if ok {
+		rb.record(c)
+	}
+	return c, ok
+}
+
+// pick picks a connection, without recording any metrics.
+func (rb *routingBalancer) pick(opts call.CallOptions) (call.ReplicaConnection, bool) {
 	if opts.ShardKey == 0 {
 		// If the method we're calling is not sharded (which is guaranteed to
 		// be true for nonsharded components), then the shard key is 0.
-		return rb.balancer.Pick(opts)
+		return rb.pickLocal(opts)
 	}
 
 	// Grab the current assignment. It's possible that the current assignment
@@ -100,31 +222,79 @@ func (rb *routingBalancer) Pick(opts call.CallOptions) (call.ReplicaConnection,
 	if assignment == nil {
 		// There is no assignment. This is possible if we haven't received an
 		// assignment from the assigner yet.
-		return rb.balancer.Pick(opts)
+		return rb.pickLocal(opts)
 	}
 
 	slice, ok := index.find(opts.ShardKey)
 	if !ok {
 		// TODO(mwhittaker): Shouldn't this be impossible. Understand better
 		// when this happens.
-		return rb.balancer.Pick(opts)
+		return rb.pickLocal(opts)
 	}
 
-	// Search for an available ReplicConnection starting at a random offset.
+	// Search for the closest available ReplicConnection starting at a random
+	// offset.
 	// TODO(sanjay):Precompute the set of available ReplicaConnections per slice.
 	offset := rand.Intn(len(slice.replicas))
 	rb.mu.RLock()
-	defer rb.mu.RUnlock()
-	for i, n := 0, len(slice.replicas); i < n; i++ {
+	var closest call.ReplicaConnection
+	proximity := elsewhere + 1
+	for i, n := 0, len(slice.replicas); i < n && proximity > sameZone; i++ {
 		offset++
 		if offset == n {
 			offset = 0
 		}
-		if c, ok := rb.conns[slice.replicas[offset]]; ok {
+		addr := slice.replicas[offset]
+		c, ok := rb.conns[addr]
+		if !ok {
+			continue
+		}
+		if p := rb.proximity(addr); p < proximity {
+			closest, proximity = c, p
+		}
+	}
+	rb.mu.RUnlock()
+	if closest == nil && rb.stale.Load() {
+		return rb.pickLocal(opts)
+	}
+	return closest, closest != nil
+}
+
+// pickLocal picks a connection in the caller's zone, if any, then in the
+// caller's region, if any, and then any connection.
+func (rb *routingBalancer) pickLocal(opts call.CallOptions) (call.ReplicaConnection, bool) {
+	rb.mu.RLock()
+	zone, region := rb.zone, rb.region
+	rb.mu.RUnlock()
+	if zone != nil {
+		if c, ok := zone.Pick(opts); ok {
+			return c, true
+		}
+		if c, ok := region.Pick(opts); ok {
 			return c, true
 		}
 	}
-	return nil, false
+	return rb.balancer.Pick(opts)
+}
+
+// record records a call sent to c in the cross-locality call metrics.
+func (rb *routingBalancer) record(c call.ReplicaConnection) {
+	if rb.locality == nil {
+		return
+	}
+	rb.mu.RLock()
+	l, ok := rb.localities[c.Address()]
+	rb.mu.RUnlock()
+	if !ok {
+		return
+	}
+	from, to := rb.locality, l
+	switch {
+	case from.Region != "" && to.Region != "" && from.Region != to.Region:
+		crossRegionCalls.Get(localityLabels{rb.component, from.Region, to.Region}).Inc()
+	case from.Zone != "" && to.Zone != "" && from.Zone != to.Zone:
+		crossZoneCalls.Get(localityLabels{rb.component, from.Zone, to.Zone}).Inc()
+	}
 }
 
 // routingResolver is a dummy resolver that returns whatever endpoints are
@@ -148,13 +318,30 @@ func newRoutingResolver() *routingResolver {
 // IsConstant implements the call.Resolver interface.
 func (rr *routingResolver) IsConstant() bool { return false }
 
-// update updates the resolver with the provided endpoints.
-func (rr *routingResolver) update(endpoints []call.Endpoint) {
+// update updates the resolver with the provided endpoints. It returns whether
+// the addresses of the endpoints changed.
+func (rr *routingResolver) update(endpoints []call.Endpoint) bool {
 	rr.m.Lock()
 	defer rr.m.Unlock()
+	changed := !sameAddresses(rr.endpoints, endpoints)
 	rr.version = &call.Version{Opaque: uuid.New().String()}
 	rr.endpoints = endpoints
 	rr.changed.Broadcast()
+	return changed
+}
+
+// sameAddresses returns whether the provided endpoints have the same set of
+// addresses.
+func sameAddresses(x, y []call.Endpoint) bool {
+	addrs := func(endpoints []call.Endpoint) []string {
+		var s []string
+		for _, e := range endpoints {
+			s = append(s, e.Address())
+		}
+		slices.Sort(s)
+		return slices.Compact(s)
+	}
+	return slices.Equal(addrs(x), addrs(y))
 }
 
 // Resolve implements the call.Resolver interface.
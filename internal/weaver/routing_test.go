@@ -107,6 +107,49 @@ func TestRoutingBalancer(t *testing.T) {
 	}
 }
 
+// TestRoutingBalancerLocality tests that a routingBalancer prefers replicas in
+// the caller's zone, then in the caller's region.
+func TestRoutingBalancerLocality(t *testing.T) {
+	rb := newRoutingBalancer("component", nil, &protos.Locality{Region: "r1", Zone: "r1-a"})
+	rb.Add(fakeConn("same-zone"))
+	rb.Add(fakeConn("same-region"))
+	rb.Add(fakeConn("elsewhere"))
+	rb.updateLocalities(map[string]*protos.Locality{
+		"same-zone":   {Region: "r1", Zone: "r1-a"},
+		"same-region": {Region: "r1", Zone: "r1-b"},
+		"elsewhere":   {Region: "r2", Zone: "r2-a"},
+	})
+	rb.update(&protos.Assignment{
+		Slices: []*protos.Assignment_Slice{
+			{Start: 0, Replicas: []string{"elsewhere", "same-region", "same-zone"}},
+		},
+	})
+
+	for _, test := range []struct {
+		remove string // connection to remove before picking
+		want   string
+	}{
+		{"", "same-zone"},
+		{"same-zone", "same-region"},
+		{"same-region", "elsewhere"},
+	} {
+		if test.remove != "" {
+			rb.Remove(fakeConn(test.remove))
+		}
+		for _, shardKey := range []uint64{0, 42} {
+			for i := 0; i < 3; i++ {
+				got, ok := rb.Pick(call.CallOptions{ShardKey: shardKey})
+				if !ok {
+					t.Fatalf("rb.Pick(%d): did not find replica", shardKey)
+				}
+				if got.Address() != test.want {
+					t.Fatalf("rb.Pick(%d): got %s, want %s", shardKey, got.Address(), test.want)
+				}
+			}
+		}
+	}
+}
+
 // TestRoutingResolverInitialResolve tests that the first Resolve invocation on
 // a routingResolver returns a nil set of endpoints but a non-nil version.
 func TestRoutingResolverInitialResolve(t *testing.T) {
@@ -156,3 +199,21 @@ func TestRoutingResolverUpdate(t *testing.T) {
 		t.Fatalf("endpoints (-want +got):\n%s", diff)
 	}
 }
+
+func TestRoutingResolverUpdateChanged(t *testing.T) {
+	r := newRoutingResolver()
+	for _, test := range []struct {
+		endpoints []call.Endpoint
+		want      bool
+	}{
+		{[]call.Endpoint{nilEndpoint{"a"}, nilEndpoint{"b"}}, true},
+		{[]call.Endpoint{nilEndpoint{"b"}, nilEndpoint{"a"}}, false},
+		{[]call.Endpoint{nilEndpoint{"a"}}, true},
+		{nil, true},
+		{nil, false},
+	} {
+		if got := r.update(test.endpoints); got != test.want {
+			t.Errorf("update(%v): got %t, want %t", test.endpoints, got, test.want)
+		}
+	}
+}
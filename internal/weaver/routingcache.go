@@ -0,0 +1,114 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/routing"
+	"github.com/ServiceWeaver/weaver/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// routingLabels are the labels of the routing staleness metric.
+type routingLabels struct {
+	Component string // full callee component name
+}
+
+// routingUpdateLabels are the labels of the routing update metric.
+type routingUpdateLabels struct {
+	Component string // full callee component name
+	Kind      string // "snapshot", "delta", "renewal", "outdated", or "rejected"
+}
+
+var (
+	// routingStaleness measures how long ago the lease of the routing info of
+	// a component expired.
+	routingStaleness = metrics.NewGaugeMap[routingLabels](
+		"serviceweaver_system_routing_staleness_seconds",
+		"Time, in seconds, since the lease of the routing info of a Service Weaver component expired, or 0 if it hasn't",
+	)
+
+	// routingUpdates counts the routing info updates received, by kind.
+	routingUpdates = metrics.NewCounterMap[routingUpdateLabels](
+		"serviceweaver_system_routing_update_count",
+		"Count of routing info updates received for a Service Weaver component, by kind",
+	)
+)
+
+// routingLeaseCheckInterval is how often a weavelet checks the leases of its
+// routing info.
+const routingLeaseCheckInterval = time.Second
+
+// routingCache caches the routing info of a remote component, and tracks its
+// lease. It is safe for concurrent use.
+type routingCache struct {
+	component string
+
+	mu     sync.Mutex
+	info   *protos.RoutingInfo // the latest routing info, or nil
+	expiry time.Time           // when the lease of info expires, or zero if never
+}
+
+// apply applies the provided update to the cached routing info (see
+// routing.Apply), renews its lease, and returns the resulting routing info, and
+// whether it changed.
+func (rc *routingCache) apply(update *protos.RoutingInfo, now time.Time) (*protos.RoutingInfo, bool, error) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	labels := routingUpdateLabels{Component: rc.component}
+	if rc.info != nil && update.Version != 0 && update.Version < rc.info.Version {
+		labels.Kind = "outdated"
+		routingUpdates.Get(labels).Inc()
+		return rc.info, false, nil
+	}
+	next, err := routing.Apply(rc.info, update)
+	switch {
+	case err != nil:
+		labels.Kind = "rejected"
+	case update.BaseVersion == 0:
+		labels.Kind = "snapshot"
+	case update.BaseVersion == update.Version:
+		labels.Kind = "renewal"
+	default:
+		labels.Kind = "delta"
+	}
+	routingUpdates.Get(labels).Inc()
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A snapshot always replaces the cached routing info, even if the sender
+	// reuses the same message.
+	changed := next != rc.info || update.BaseVersion == 0
+	rc.info = next
+	rc.expiry = time.Time{}
+	if update.LeaseNanos > 0 {
+		rc.expiry = now.Add(time.Duration(update.LeaseNanos))
+	}
+	return next, changed, nil
+}
+
+// staleness returns how long ago the lease of the cached routing info
+// expired, or zero if it hasn't.
+func (rc *routingCache) staleness(now time.Time) time.Duration {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if rc.expiry.IsZero() || now.Before(rc.expiry) {
+		return 0
+	}
+	return now.Sub(rc.expiry)
+}
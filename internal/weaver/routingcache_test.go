@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/routing"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRoutingCache(t *testing.T) {
+	rc := &routingCache{component: "c"}
+	now := time.Now()
+	lease := 10 * time.Second
+
+	// A snapshot.
+	v1 := &protos.RoutingInfo{Component: "c", Replicas: []string{"a", "b"}, Version: 1, LeaseNanos: int64(lease)}
+	info, changed, err := rc.apply(v1, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || !cmp.Equal(info.Replicas, v1.Replicas) {
+		t.Fatalf("apply(v1): got %v, %t; want %v, true", info.Replicas, changed, v1.Replicas)
+	}
+
+	// A delta.
+	v2 := &protos.RoutingInfo{Component: "c", Replicas: []string{"b", "c"}, Version: 2, LeaseNanos: int64(lease)}
+	info, changed, err = rc.apply(routing.Delta(v1, v2), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"b", "c"}; !changed || !cmp.Equal(info.Replicas, want) {
+		t.Fatalf("apply(delta): got %v, %t; want %v, true", info.Replicas, changed, want)
+	}
+
+	// The lease expires, and a renewal renews it without changing anything.
+	later := now.Add(lease + time.Second)
+	if got, want := rc.staleness(later), time.Second; got != want {
+		t.Fatalf("staleness: got %v, want %v", got, want)
+	}
+	if _, changed, err = rc.apply(routing.Delta(v2, v2), later); err != nil || changed {
+		t.Fatalf("apply(renewal): got %t, %v; want false, nil", changed, err)
+	}
+	if got := rc.staleness(later); got != 0 {
+		t.Fatalf("staleness after renewal: got %v, want 0", got)
+	}
+
+	// Outdated updates are ignored, and deltas against another version are
+	// rejected.
+	if _, changed, err = rc.apply(v1, later); err != nil || changed {
+		t.Fatalf("apply(v1 again): got %t, %v; want false, nil", changed, err)
+	}
+	v4 := &protos.RoutingInfo{Component: "c", Version: 4}
+	if _, _, err := rc.apply(routing.Delta(&protos.RoutingInfo{Version: 3}, v4), later); err == nil {
+		t.Fatal("apply(delta against v3): unexpected success")
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import "context"
+
+// serialKey is the context key used to mark contexts whose calls must be made
+// one at a time.
+type serialKey struct{}
+
+// WithSerialCalls returns a context that tells the APIs that fan out
+// component method calls, like weaver.Broadcast, to make the calls one at a
+// time, in a deterministic order. The simulator, which runs a single
+// goroutine of an execution at a time, passes such contexts to the ops and
+// component methods it runs.
+func WithSerialCalls(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serialKey{}, true)
+}
+
+// SerialCalls returns whether ctx was returned by WithSerialCalls.
+func SerialCalls(ctx context.Context) bool {
+	serial, _ := ctx.Value(serialKey{}).(bool)
+	return serial
+}
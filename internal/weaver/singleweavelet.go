@@ -16,6 +16,7 @@ package weaver
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net"
@@ -41,9 +42,11 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"github.com/ServiceWeaver/weaver/runtime/topology"
 	"github.com/ServiceWeaver/weaver/runtime/traces"
 	"github.com/google/uuid"
 	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -53,6 +56,7 @@ type SingleWeaveletOptions struct {
 	Config         string               // TOML config contents
 	Fakes          map[reflect.Type]any // component fakes, by component interface type
 	Quiet          bool                 // if true, do not print or log anything
+	Embedded       bool                 // if true, do not handle signals (see weaver.Runtime)
 }
 
 // SingleWeavelet is a weavelet that runs all components locally in a single
@@ -65,6 +69,9 @@ type SingleWeavelet struct {
 	regsByName map[string]*codegen.Registration       // registrations by component name
 	regsByIntf map[reflect.Type]*codegen.Registration // registrations by component interface type
 	regsByImpl map[reflect.Type]*codegen.Registration // registrations by component implementation type
+	deps       map[string][]string                    // component dependencies, by component name
+	lazy       map[string]bool                        // lazily constructed components
+	auditor    *auditor                               // records calls to audited methods
 
 	// Options, config, and metadata.
 	opts         SingleWeaveletOptions // options
@@ -72,17 +79,30 @@ type SingleWeavelet struct {
 	deploymentId string                // globally unique deployment id
 	id           string                // globally unique weavelet id
 	weaverInfo   *WeaverInfo           // application's runtime information
+	replica      *replicaContext       // information about the only replica
 	createdAt    time.Time             // time at which the weavelet was created
 
 	// Logging, tracing, and metrics.
 	pp     *logging.PrettyPrinter   // pretty printer for logger
 	tracer trace.Tracer             // tracer used by all components
 	stats  *imetrics.StatsProcessor // metrics aggregator
+	topo   *topology.Tracker        // live component graph
 
 	// Components and listeners.
-	mu         sync.Mutex              // guards the following fields
-	components map[string]any          // components, by name
-	listeners  map[string]net.Listener // listeners, by name
+	mu         sync.Mutex                   // guards the following fields
+	components map[string]any               // components, by name
+	warm       map[string]bool              // components that are warmed up
+	pending    map[string]*pendingComponent // components being created, by name
+	inits      initTracker                  // components being created
+	firstCalls firstCalls                   // first calls to lazy components
+	listeners  map[string]net.Listener      // listeners, by name
+}
+
+// pendingComponent is a component that is being created.
+type pendingComponent struct {
+	done chan struct{} // closed when the component has been created
+	impl any           // the component, valid once done is closed
+	err  error         // the creation error, valid once done is closed
 }
 
 // NewSingleWeavelet returns a new SingleWeavelet that hosts the components
@@ -120,6 +140,10 @@ func NewSingleWeavelet(ctx context.Context, regs []*codegen.Registration, opts S
 		regsByIntf[reg.Iface] = reg
 		regsByImpl[reg.Impl] = reg
 	}
+	lazy := map[string]bool{}
+	for _, component := range config.App.LazyComponents {
+		lazy[component] = true
+	}
 
 	// Print rolodex card.
 	//
@@ -132,24 +156,54 @@ func NewSingleWeavelet(ctx context.Context, regs []*codegen.Registration, opts S
 		fmt.Fprint(os.Stderr, reg.Rolodex())
 	}
 
+	// All components run in a single replica of a single group.
+	// There is no deployer, so there are no deployment events.
+	replica := &replicaContext{
+		info: ReplicaInfo{
+			WeaveletID: id,
+			Group:      runtime.Main,
+			Version:    deploymentId,
+		},
+		events: newEvents(),
+	}
 	w := &SingleWeavelet{
-		ctx:          ctx,
+		ctx:          withReplica(ctx, replica),
 		regs:         regs,
 		regsByName:   regsByName,
 		regsByIntf:   regsByIntf,
 		regsByImpl:   regsByImpl,
+		deps:         componentDeps(regs),
+		lazy:         lazy,
 		opts:         opts,
 		config:       config,
 		deploymentId: deploymentId,
 		id:           id,
 		weaverInfo:   &WeaverInfo{DeploymentID: id},
+		replica:      replica,
 		createdAt:    time.Now(),
 		pp:           logging.NewPrettyPrinter(colors.Enabled()),
 		tracer:       tracer,
 		stats:        imetrics.NewStatsProcessor(),
+		topo:         topology.NewTracker(topology.DefaultWindow),
 		components:   map[string]any{},
+		warm:         map[string]bool{},
+		pending:      map[string]*pendingComponent{},
 		listeners:    map[string]net.Listener{},
 	}
+	replica.topology = func(ctx context.Context) (*protos.Topology, error) {
+		reply, err := w.Topology(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return reply.Topology, nil
+	}
+	w.auditor = newAuditor(config.App.Name, deploymentId, id, single.AuditDir, config.App.AuditedMethods, w.logger("weavelet"))
+
+	// An embedded weavelet leaves signal handling to the program that embeds
+	// it, which calls Shutdown instead.
+	if opts.Embedded {
+		return w, nil
+	}
 
 	// Start a signal handler to detect when the process is killed. This isn't
 	// perfect, as we can't catch a SIGKILL, but it's good in the common case.
@@ -157,16 +211,8 @@ func NewSingleWeavelet(ctx context.Context, regs []*codegen.Registration, opts S
 	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-done
-
-		w.mu.Lock()
-		defer w.mu.Unlock()
-		for c, impl := range w.components {
-			// Call Shutdown method if available.
-			if i, ok := impl.(interface{ Shutdown(context.Context) error }); ok {
-				if err := i.Shutdown(ctx); err != nil {
-					fmt.Printf("Component %s failed to shutdown: %v\n", c, err)
-				}
-			}
+		for _, err := range w.shutdown(ctx) {
+			fmt.Println(err)
 		}
 		os.Exit(1)
 	}()
@@ -174,6 +220,33 @@ func NewSingleWeavelet(ctx context.Context, regs []*codegen.Registration, opts S
 	return w, nil
 }
 
+// Shutdown calls the Shutdown method of every component that has one.
+func (w *SingleWeavelet) Shutdown(ctx context.Context) error {
+	return errors.Join(w.shutdown(ctx)...)
+}
+
+// shutdown calls the Shutdown method of every component that has one and
+// returns the errors.
+func (w *SingleWeavelet) shutdown(ctx context.Context) []error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for c, impl := range w.components {
+		notify(func(o LifecycleObserver) { o.Draining(c, impl) })
+	}
+	var errs []error
+	for c, impl := range w.components {
+		// Call Shutdown method if available.
+		var err error
+		if i, ok := impl.(interface{ Shutdown(context.Context) error }); ok {
+			if err = i.Shutdown(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("component %s failed to shutdown: %w", c, err))
+			}
+		}
+		notify(func(o LifecycleObserver) { o.ShutDown(c, impl, err) })
+	}
+	return errs
+}
+
 // parseSingleConfig parses the "[single]" section of a config file.
 func parseSingleConfig(regs []*codegen.Registration, filename, contents string) (*single.SingleConfig, error) {
 	// Parse the config file, if one is given.
@@ -222,61 +295,128 @@ func singleTracer(ctx context.Context, app, deploymentId, id string) (trace.Trac
 
 // GetIntf implements the Weavelet interface.
 func (w *SingleWeavelet) GetIntf(t reflect.Type) (any, error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
 	return w.getIntf(t, "root")
 }
 
+// WithReplica returns a context that carries information about the replica
+// that the weavelet runs, like the contexts passed to its components.
+func (w *SingleWeavelet) WithReplica(ctx context.Context) context.Context {
+	return withReplica(ctx, w.replica)
+}
+
 // GetImpl implements the Weavelet interface.
 func (w *SingleWeavelet) GetImpl(t reflect.Type) (any, error) {
-	w.mu.Lock()
-	defer w.mu.Unlock()
 	return w.getImpl(t)
 }
 
 // getIntf returns the component with the provided interface type. The returned
 // value has type t.
-//
-// REQUIRES: w.mu is held.
 func (w *SingleWeavelet) getIntf(t reflect.Type, requester string) (any, error) {
 	reg, ok := w.regsByIntf[t]
 	if !ok {
 		return nil, fmt.Errorf("component %v not found; maybe you forgot to run weaver generate", t)
 	}
-	c, err := w.get(reg)
+	if reg.Name == requester {
+		return selfStub(reg, func() (any, error) { return w.intf(reg, requester) }), nil
+	}
+	return w.intf(reg, requester)
+}
+
+// intf returns the component interface of the component with the provided
+// registration, for use by the requester.
+func (w *SingleWeavelet) intf(reg *codegen.Registration, requester string) (any, error) {
+	if w.lazy[reg.Name] {
+		stub := lazyStub(reg, requester, w.tracer, &w.firstCalls, func() (any, error) {
+			return w.get(reg, requester)
+		})
+		return w.auditor.wrap(reg, requester, stub), nil
+	}
+	c, err := w.get(reg, requester)
 	if err != nil {
 		return nil, err
 	}
-	return reg.LocalStubFn(c, requester, w.tracer), nil
+	return w.auditor.wrap(reg, requester, reg.LocalStubFn(c, requester, w.tracer)), nil
 }
 
 // getImpl returns the component with the provided implementation type. The
 // returned value has type t.
-//
-// REQUIRES: w.mu is held.
 func (w *SingleWeavelet) getImpl(t reflect.Type) (any, error) {
 	reg, ok := w.regsByImpl[t]
 	if !ok {
 		return nil, fmt.Errorf("component implementation %v not found; maybe you forgot to run weaver generate", t)
 	}
-	return w.get(reg)
+	return w.get(reg, "root")
 }
 
 // get returns the component with the provided registration, creating it if
-// necessary.
-//
-// REQUIRES: w.mu is held.
-func (w *SingleWeavelet) get(reg *codegen.Registration) (any, error) {
+// necessary. requester is the name of the component that needs it.
+func (w *SingleWeavelet) get(reg *codegen.Registration, requester string) (any, error) {
+	w.mu.Lock()
 	if c, ok := w.components[reg.Name]; ok {
 		// The component has already been created.
+		w.mu.Unlock()
 		return c, nil
 	}
 
 	if fake, ok := w.opts.Fakes[reg.Iface]; ok {
 		// We have a fake registered for this component.
+		w.mu.Unlock()
 		return fake, nil
 	}
 
+	if cycle := w.inits.cycle(requester, reg.Name, w.deps); cycle != nil {
+		// Waiting for the component would deadlock.
+		w.mu.Unlock()
+		return nil, cycleError(cycle)
+	}
+
+	if p, ok := w.pending[reg.Name]; ok {
+		// The component is being created by someone else.
+		w.mu.Unlock()
+		<-p.done
+		return p.impl, p.err
+	}
+
+	p := &pendingComponent{done: make(chan struct{})}
+	w.pending[reg.Name] = p
+	w.inits.start(reg.Name)
+	w.mu.Unlock()
+
+	p.impl, p.err = w.create(reg)
+
+	w.mu.Lock()
+	w.inits.done(reg.Name)
+	delete(w.pending, reg.Name)
+	if p.err == nil {
+		w.components[reg.Name] = p.impl
+	}
+	w.mu.Unlock()
+	close(p.done)
+	return p.impl, p.err
+}
+
+// create creates the component with the provided registration. The
+// components it depends on are created first, in parallel, so that
+// independent components are initialized concurrently while every component
+// is initialized after its dependencies. Lazy dependencies are instead created
+// on their first method call.
+func (w *SingleWeavelet) create(reg *codegen.Registration) (any, error) {
+	// Create dependencies.
+	var group errgroup.Group
+	for _, dep := range w.deps[reg.Name] {
+		depReg, ok := w.regsByName[dep]
+		if !ok || w.lazy[dep] || dep == reg.Name {
+			continue
+		}
+		group.Go(func() error {
+			_, err := w.get(depReg, reg.Name)
+			return err
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
 	// Create the component implementation.
 	v := reflect.New(reg.Impl)
 	obj := v.Interface()
@@ -301,29 +441,61 @@ func (w *SingleWeavelet) get(reg *codegen.Registration) (any, error) {
 	// Fill ref fields.
 	if err := FillRefs(obj, func(t reflect.Type) (any, error) {
 		return w.getIntf(t, reg.Name)
-	}); err != nil {
+	}, nil); err != nil {
 		return nil, err
 	}
 
 	// Fill listener fields.
 	if err := FillListeners(obj, func(name string) (net.Listener, string, error) {
+		w.mu.Lock()
+		defer w.mu.Unlock()
 		lis, err := w.listener(name)
 		return lis, "", err
 	}); err != nil {
 		return nil, err
 	}
 
+	notify(func(o LifecycleObserver) { o.Constructed(reg.Name, obj) })
+
 	// Call Init if available.
 	if i, ok := obj.(interface{ Init(context.Context) error }); ok {
-		if err := i.Init(w.ctx); err != nil {
-			return nil, fmt.Errorf("component %q initialization failed: %w", reg.Name, err)
+		app := w.config.App
+		timeout := initTimeout(reg.Name, app.InitTimeoutNanos, app.ComponentInitTimeoutNanos)
+		if err := w.inits.run(w.ctx, reg.Name, timeout, i.Init); err != nil {
+			notify(func(o LifecycleObserver) { o.Initialized(reg.Name, obj, err) })
+			return nil, err
 		}
 	}
+	notify(func(o LifecycleObserver) { o.Initialized(reg.Name, obj, nil) })
 
-	w.components[reg.Name] = obj
+	// Warm up the component. There is no point in deferring the warmup if
+	// there is nothing to warm up, or if the component is lazy and is created
+	// by a method call that is waiting for it.
+	if _, ok := obj.(interface{ Warmup(context.Context) error }); ok && !w.lazy[reg.Name] {
+		go w.warmup(reg.Name, obj)
+	} else {
+		w.warmup(reg.Name, obj)
+	}
 	return obj, nil
 }
 
+// warmup calls the Warmup(context.Context) error method of the provided
+// component implementation, if it has one, and then marks the component as
+// warm. Warmup is a best-effort hint: if it fails, the failure is logged and
+// the component is still marked warm.
+func (w *SingleWeavelet) warmup(component string, impl any) {
+	defer func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.warm[component] = true
+	}()
+	if wm, ok := impl.(interface{ Warmup(context.Context) error }); ok {
+		if err := wm.Warmup(w.ctx); err != nil {
+			w.logger(component).Warn("Failed to warm up", "err", err)
+		}
+	}
+}
+
 // listener returns the listener with the provided name.
 //
 // REQUIRES: w.mu is held.
@@ -378,15 +550,22 @@ func (w *SingleWeavelet) ServeStatus(ctx context.Context) error {
 
 	// Launch the stats processor.
 	go func() {
-		err := w.stats.CollectMetrics(ctx, metrics.Snapshot)
+		err := w.stats.CollectMetrics(ctx, func() []*metrics.MetricSnapshot {
+			ms := metrics.Snapshot()
+			w.topo.Record(time.Now(), ms)
+			return ms
+		})
 		if err != nil {
 			noopLogger.Error("metric collection stopped with error", "err", err)
 		}
 	}()
 
-	// Start a signal handler to detect when the process is killed.
+	// Start a signal handler to detect when the process is killed. Embedded
+	// weavelets instead unregister when ctx is cancelled.
 	done := make(chan os.Signal, 1)
-	signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
+	if !w.opts.Embedded {
+		signal.Notify(done, syscall.SIGINT, syscall.SIGTERM)
+	}
 
 	// Spawn the status server.
 	mux := http.NewServeMux()
@@ -445,18 +624,22 @@ func (w *SingleWeavelet) ServeStatus(ctx context.Context) error {
 }
 
 // Status implements the status.Server interface.
-func (w *SingleWeavelet) Status(context.Context) (*status.Status, error) {
+func (w *SingleWeavelet) Status(ctx context.Context) (*status.Status, error) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
 	pid := int64(os.Getpid())
 	stats := w.stats.GetStatsStatusz()
 	var components []*status.Component
-	for component := range w.components {
+	for component, impl := range w.components {
 		c := &status.Component{
 			Name:     component,
 			Replicas: []*status.Replica{},
 		}
-		c.Replicas = append(c.Replicas, &status.Replica{Pid: pid, WeaveletId: w.id})
+		ready := w.warm[component]
+		if r, ok := impl.(interface{ Ready(context.Context) error }); ok && ready {
+			ready = r.Ready(ctx) == nil
+		}
+		c.Replicas = append(c.Replicas, &status.Replica{Pid: pid, WeaveletId: w.id, Ready: ready})
 		components = append(components, c)
 
 		// TODO(mwhittaker): Unify with ui package and remove duplication.
@@ -529,6 +712,16 @@ func (w *SingleWeavelet) Profile(ctx context.Context, req *protos.GetProfileRequ
 	return &protos.GetProfileReply{Data: data}, err
 }
 
+// FlightRecord implements the status.Server interface.
+func (w *SingleWeavelet) FlightRecord(context.Context) (*protos.GetFlightRecordReply, error) {
+	return &protos.GetFlightRecordReply{Events: flightRecord(w.id)}, nil
+}
+
+// Topology implements the status.Server interface.
+func (w *SingleWeavelet) Topology(context.Context) (*protos.GetTopologyReply, error) {
+	return &protos.GetTopologyReply{Topology: w.topo.Record(time.Now(), metrics.Snapshot())}, nil
+}
+
 // serveHTTP serves HTTP traffic on the provided listener using the provided
 // handler. The server is shut down when then provided context is cancelled.
 func serveHTTP(ctx context.Context, lis net.Listener, handler http.Handler) error {
@@ -39,7 +39,10 @@ var (
 	//   - impl should be a pointer to the implementation struct
 	//   - get should be a function that returns the component of interface
 	//     type T when passed the reflect.Type for T.
-	FillRefs func(impl any, get func(reflect.Type) (any, error)) error
+	//   - replicas, if not nil, should be a function that returns one handle
+	//     per replica of the component of interface type T, as needed by
+	//     weaver.Broadcast. If nil, broadcasts use the handle returned by get.
+	FillRefs func(impl any, get func(reflect.Type) (any, error), replicas func(reflect.Type) ([]any, error)) error
 
 	// HasListeners returns whether the provided component implementation has
 	// weaver.Listener fields.
@@ -66,3 +69,13 @@ type WeaverInfo struct {
 	// Unique identifier for the application deployment.
 	DeploymentID string
 }
+
+// Copy of the Replica struct in the main weaver package.
+type ReplicaInfo struct {
+	Index      int
+	WeaveletID string
+	Group      string
+	Version    string
+	Region     string
+	Zone       string
+}
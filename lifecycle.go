@@ -0,0 +1,52 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import "github.com/ServiceWeaver/weaver/internal/weaver"
+
+// LifecycleObserver is notified of the lifecycle of the components that the
+// weavelets of a process construct. Every method receives the full name of
+// the component, e.g., "example.com/mypkg/Cache", and a pointer to its
+// implementation struct. A component goes through the following steps:
+//
+//   - Constructed: the implementation struct has been allocated and its
+//     config, logger, Ref, and Listener fields have been filled, but its
+//     Init method has not yet been called.
+//   - Initialized: the Init method, if any, has returned err. If err is not
+//     nil, the component is discarded, and no further methods are called
+//     for it.
+//   - Draining: the weavelet is shutting down. Draining is called for every
+//     initialized component before any of them is shut down.
+//   - ShutDown: the Shutdown method, if any, has returned err.
+//
+// Fakes (see weavertest.Fake) are not constructed by weavelets and are not
+// reported. Observer methods may be called concurrently, for different
+// components, and should return quickly.
+type LifecycleObserver interface {
+	Constructed(component string, impl any)
+	Initialized(component string, impl any, err error)
+	Draining(component string, impl any)
+	ShutDown(component string, impl any, err error)
+}
+
+// AddLifecycleObserver registers o to be notified of the lifecycle of every
+// component subsequently constructed by a weavelet in this process, including
+// the weavelets created by weaver.Run, weaver.NewRuntime, and weavertest. It
+// returns a function that unregisters o. A test can, for example, record the
+// implementations reported to Constructed and check that every one of them is
+// reported to ShutDown, to detect leaked components.
+func AddLifecycleObserver(o LifecycleObserver) func() {
+	return weaver.AddLifecycleObserver(o)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorder is a LifecycleObserver that records the lifecycle steps of every
+// component.
+type recorder struct {
+	mu    sync.Mutex
+	steps map[string][]string // steps, by component
+	live  map[any]string      // constructed and not shut down impls
+}
+
+func newRecorder() *recorder {
+	return &recorder{steps: map[string][]string{}, live: map[any]string{}}
+}
+
+func (r *recorder) record(component, step string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.steps[component] = append(r.steps[component], step)
+}
+
+func (r *recorder) Constructed(component string, impl any) {
+	r.record(component, "constructed")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.live[impl] = component
+}
+
+func (r *recorder) Initialized(component string, impl any, err error) {
+	r.record(component, "initialized")
+	if err != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.live, impl)
+	}
+}
+
+func (r *recorder) Draining(component string, impl any) {
+	r.record(component, "draining")
+}
+
+func (r *recorder) ShutDown(component string, impl any, err error) {
+	r.record(component, "shut down")
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.live, impl)
+}
+
+func TestLifecycleObserver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := newRecorder()
+	remove := weaver.AddLifecycleObserver(r)
+	defer remove()
+
+	rt := weaver.NewRuntime(weaver.RuntimeOptions{
+		Config: `
+			["github.com/ServiceWeaver/weaver/Quota"]
+			limits = { requests = { limit = 1, period = "1h" } }
+		`,
+		Quiet: true,
+	})
+	if err := rt.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := weaver.Get[weaver.Quota](rt); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	want := []string{"constructed", "initialized", "draining", "shut down"}
+	if diff := cmp.Diff(want, r.steps["github.com/ServiceWeaver/weaver/Quota"]); diff != "" {
+		t.Errorf("Quota steps (-want +got):\n%s", diff)
+	}
+	for impl, component := range r.live {
+		t.Errorf("component %s (%p) was not shut down", component, impl)
+	}
+}
+
+func TestRemoveLifecycleObserver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r := newRecorder()
+	remove := weaver.AddLifecycleObserver(r)
+	remove()
+
+	rt := weaver.NewRuntime(weaver.RuntimeOptions{Quiet: true})
+	if err := rt.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := weaver.Get[weaver.Quota](rt); err != nil {
+		t.Fatal(err)
+	}
+	if err := rt.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.steps) != 0 {
+		t.Errorf("removed observer got steps %v", r.steps)
+	}
+}
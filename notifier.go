@@ -0,0 +1,148 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ServiceWeaver/weaver/internal/notify"
+)
+
+// Notifier is a built-in component that sends notifications, like emails, SMS
+// messages, and webhook calls.
+//
+// Notifications are sent over channels, which are named and configured in the
+// Notifier component's section of the config file. Every channel has a
+// provider that delivers its notifications. Notifications may be rendered
+// from named text/template templates. For example:
+//
+//	["github.com/ServiceWeaver/weaver/Notifier"]
+//	channels.email = { provider = "smtp", addr = "smtp.example.com:587", from = "noreply@example.com" }
+//	channels.sms = { provider = "twilio", from = "+15550100" }
+//	channels.ops = { provider = "webhook", url = "https://hooks.example.com/ops" }
+//	templates.welcome = { subject = "Welcome, {{.name}}!", body = "Hi {{.name}}, thanks for signing up." }
+//
+// A component sends a notification by calling Notify:
+//
+//	err := s.notifier.Get().Notify(ctx, weaver.Notification{
+//	    Channel:  "email",
+//	    To:       []string{user.Email},
+//	    Template: "welcome",
+//	    Data:     map[string]string{"name": user.Name},
+//	})
+//
+// In tests, use a weavertest.FakeNotifier to capture notifications instead of
+// sending them.
+type Notifier interface {
+	// Notify renders and sends the provided notification.
+	Notify(ctx context.Context, n Notification) error
+}
+
+// Notify is not idempotent: retrying it may send a notification twice.
+var _ NotRetriable = Notifier.Notify
+
+// Notification is a notification sent by a Notifier.
+type Notification struct {
+	AutoMarshal
+
+	// Channel is the name of the configured channel to send the notification
+	// over.
+	Channel string
+
+	// To lists the recipients of the notification, like email addresses or
+	// phone numbers. Webhook channels include the recipients in the payload
+	// they post.
+	To []string
+
+	// Template is the name of the configured template to render the subject
+	// and body of the notification from, using Data. If Template is empty,
+	// Subject and Body are sent as is.
+	Template string
+	Data     map[string]string
+
+	Subject string
+	Body    string
+}
+
+// notifierConfig configures the Notifier component.
+type notifierConfig struct {
+	// Channels maps channel names to provider settings. The "provider"
+	// setting selects the provider, and the remaining settings configure it.
+	Channels  map[string]map[string]string `toml:"channels"`
+	Templates map[string]notifierTemplate  `toml:"templates"`
+}
+
+// notifierTemplate is a template for the subject and body of a notification.
+type notifierTemplate struct {
+	Subject string `toml:"subject"`
+	Body    string `toml:"body"`
+}
+
+// notifier is the implementation of the Notifier component.
+type notifier struct {
+	Implements[Notifier]
+	WithConfig[notifierConfig]
+
+	channels  map[string]notify.Provider
+	templates map[string]*notify.Template
+}
+
+var _ Notifier = &notifier{}
+
+// Init initializes the Notifier component.
+func (n *notifier) Init(context.Context) error {
+	n.channels = map[string]notify.Provider{}
+	for name, settings := range n.Config().Channels {
+		provider, err := notify.New(settings)
+		if err != nil {
+			return fmt.Errorf("Notifier: channel %q: %w", name, err)
+		}
+		n.channels[name] = provider
+	}
+	n.templates = map[string]*notify.Template{}
+	for name, t := range n.Config().Templates {
+		tmpl, err := notify.ParseTemplate(name, t.Subject, t.Body)
+		if err != nil {
+			return fmt.Errorf("Notifier: %w", err)
+		}
+		n.templates[name] = tmpl
+	}
+	return nil
+}
+
+// Notify implements the Notifier interface.
+func (n *notifier) Notify(ctx context.Context, note Notification) error {
+	provider, ok := n.channels[note.Channel]
+	if !ok {
+		return fmt.Errorf("notification channel %q not configured", note.Channel)
+	}
+	msg := notify.Message{To: note.To, Subject: note.Subject, Body: note.Body}
+	if note.Template != "" {
+		tmpl, ok := n.templates[note.Template]
+		if !ok {
+			return fmt.Errorf("notification template %q not configured", note.Template)
+		}
+		subject, body, err := tmpl.Render(note.Data)
+		if err != nil {
+			return fmt.Errorf("notification template %q: %w", note.Template, err)
+		}
+		msg.Subject, msg.Body = subject, body
+	}
+	if err := provider.Send(ctx, msg); err != nil {
+		return fmt.Errorf("notification channel %q: %w", note.Channel, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNotifier(t *testing.T) {
+	type payload struct {
+		To      []string `json:"to"`
+		Subject string   `json:"subject"`
+		Body    string   `json:"body"`
+	}
+	var mu sync.Mutex
+	var got []payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p payload
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, p)
+	}))
+	defer server.Close()
+
+	config := fmt.Sprintf(`
+		["github.com/ServiceWeaver/weaver/Notifier"]
+		channels.hook = { provider = "webhook", url = %q }
+		templates.welcome = { subject = "Welcome, {{.name}}!", body = "Hi {{.name}}." }
+	`, server.URL)
+	for _, runner := range weavertest.AllRunners() {
+		runner.Config = config
+		runner.Test(t, func(t *testing.T, notifier weaver.Notifier) {
+			mu.Lock()
+			got = nil
+			mu.Unlock()
+
+			ctx := context.Background()
+			notes := []weaver.Notification{
+				{Channel: "hook", To: []string{"ada"}, Template: "welcome", Data: map[string]string{"name": "Ada"}},
+				{Channel: "hook", To: []string{"ops"}, Subject: "Alert", Body: "Disk full."},
+			}
+			for _, n := range notes {
+				if err := notifier.Notify(ctx, n); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := []payload{
+				{To: []string{"ada"}, Subject: "Welcome, Ada!", Body: "Hi Ada."},
+				{To: []string{"ops"}, Subject: "Alert", Body: "Disk full."},
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Fatalf("payloads (-want +got):\n%s", diff)
+			}
+
+			// Unknown channels and templates and missing template data are
+			// errors.
+			for _, n := range []weaver.Notification{
+				{Channel: "carrier-pigeon", Body: "coo"},
+				{Channel: "hook", Template: "goodbye"},
+				{Channel: "hook", Template: "welcome"},
+			} {
+				if err := notifier.Notify(ctx, n); err == nil {
+					t.Errorf("Notify(%+v): unexpected success", n)
+				}
+			}
+		})
+	}
+}
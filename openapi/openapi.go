@@ -0,0 +1,553 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi serves an OpenAPI 3 spec by calling the methods of a
+// Service Weaver component.
+//
+// Bind maps every operation in a spec to a component method, decodes and
+// validates requests against the spec, calls the method, and encodes its
+// result as the JSON response. Bind fails if the spec and the component
+// interface disagree, e.g., if an operation has no corresponding method or a
+// parameter's type doesn't match, so an application's HTTP API can't silently
+// drift from its components.
+//
+// An operation with operationId "getUser" is bound to method GetUser, or to
+// the method named by the operation's "x-weaver-method" extension. The method
+// receives a context.Context, followed by one argument per parameter of the
+// operation, followed by the decoded request body if the operation has one.
+// Parameters are ordered as in the spec, with the path item's parameters
+// before the operation's. For example, the operation
+//
+//	"/users/{id}": {
+//	  "put": {
+//	    "operationId": "updateUser",
+//	    "parameters": [
+//	      {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}},
+//	      {"name": "notify", "in": "query", "schema": {"type": "boolean"}}
+//	    ],
+//	    "requestBody": {"content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}},
+//	    "responses": {"200": {"description": "the updated user"}}
+//	  }
+//	}
+//
+// is bound to the method
+//
+//	UpdateUser(ctx context.Context, id int, notify bool, user User) (User, error)
+//
+// A method returns either an error, or a result and an error. A result is
+// encoded as JSON with the lowest 2xx status code listed in the operation's
+// responses, or 200 if none is listed. A method that returns only an error
+// replies with 204 No Content unless the operation lists a 2xx status code.
+// Invalid requests receive a 400 Bad Request and errors returned by methods
+// a 500 Internal Server Error, both with a JSON body of the form
+// {"error": "<message>"}.
+//
+// Use Bind to serve a spec on a weaver.Listener:
+//
+//	type server struct {
+//	    weaver.Implements[weaver.Main]
+//	    users weaver.Ref[Users]
+//	    api   weaver.Listener
+//	}
+//
+//	//go:embed openapi.json
+//	var spec []byte
+//
+//	func serve(ctx context.Context, s *server) error {
+//	    handler, err := openapi.Bind(spec, s.users.Get())
+//	    if err != nil {
+//	        return err
+//	    }
+//	    return http.Serve(s.api, handler)
+//	}
+//
+// Parameters may be path, query, header, or cookie parameters with a string,
+// integer, number, or boolean schema. Request bodies must be JSON. Schemas are
+// validated for their type, enum, nullable, properties, required,
+// additionalProperties, items, minimum, maximum, minLength, maxLength,
+// minItems, maxItems, and pattern keywords; other keywords are ignored.
+// References to schemas, parameters, and request bodies in the spec's
+// components are supported.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxBodySize is the maximum size of a request body, in bytes.
+const maxBodySize = 10 << 20
+
+// Bind returns an HTTP handler that serves the operations of the provided
+// OpenAPI 3 spec, in JSON, by calling the methods of impl. impl is typically
+// a component, as returned by weaver.Ref.Get. Bind returns an error if an
+// operation can't be bound to a method of impl.
+func Bind(spec []byte, impl any) (http.Handler, error) {
+	s, err := parseSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+	h := &handler{v: &validator{spec: s, patterns: map[string]*regexp.Regexp{}}}
+	seen := map[*schema]bool{}
+	for _, sc := range s.Components.Schemas {
+		if err := h.v.compile(sc, seen); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bind operations in a deterministic order, so errors are deterministic.
+	paths := make([]string, 0, len(s.Paths))
+	for path := range s.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	impls := reflect.ValueOf(impl)
+	for _, path := range paths {
+		item := s.Paths[path]
+		ops := item.operations()
+		methods := make([]string, 0, len(ops))
+		for method := range ops {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			r, err := h.bind(impls, path, method, item, ops[method])
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", method, path, err)
+			}
+			h.routes = append(h.routes, r)
+		}
+	}
+
+	// Prefer routes with more literal segments, so that "/users/me" takes
+	// precedence over "/users/{id}".
+	sort.SliceStable(h.routes, func(i, j int) bool {
+		return h.routes[i].literals > h.routes[j].literals
+	})
+	return h, nil
+}
+
+// handler is the http.Handler returned by Bind.
+type handler struct {
+	v      *validator
+	routes []*route
+}
+
+// route is an operation bound to a method.
+type route struct {
+	method    string        // HTTP method
+	segments  []string      // path segments; "{name}" segments are parameters
+	literals  int           // number of literal segments
+	fn        reflect.Value // bound method
+	params    []*parameter  // parameters, in argument order
+	body      *schema       // request body schema, or nil if no body
+	bodyType  reflect.Type  // request body argument type
+	required  bool          // is the request body required?
+	hasResult bool          // does the method return a result?
+	status    int           // status code of successful responses
+}
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// bind binds the provided operation to a method of impl.
+func (h *handler) bind(impl reflect.Value, path, method string, item *pathItem, op *operation) (*route, error) {
+	name := op.Method
+	if name == "" {
+		if op.OperationID == "" {
+			return nil, fmt.Errorf("missing operationId")
+		}
+		r, n := utf8.DecodeRuneInString(op.OperationID)
+		name = string(unicode.ToUpper(r)) + op.OperationID[n:]
+	}
+	if !impl.IsValid() {
+		return nil, fmt.Errorf("nil implementation has no method %s", name)
+	}
+	fn := impl.MethodByName(name)
+	if !fn.IsValid() {
+		return nil, fmt.Errorf("%v has no method %s", impl.Type(), name)
+	}
+	t := fn.Type()
+
+	r := &route{method: method, fn: fn}
+	for _, segment := range strings.Split(strings.Trim(path, "/"), "/") {
+		r.segments = append(r.segments, segment)
+		if !isParam(segment) {
+			r.literals++
+		}
+	}
+
+	// Collect the parameters, letting operation parameters override path item
+	// parameters with the same name and location.
+	var params []*parameter
+	for _, list := range [][]*parameter{item.Parameters, op.Parameters} {
+		for _, p := range list {
+			p, err := h.v.spec.parameter(p)
+			if err != nil {
+				return nil, err
+			}
+			params = slices.DeleteFunc(params, func(q *parameter) bool {
+				return q.Name == p.Name && q.In == p.In
+			})
+			params = append(params, p)
+		}
+	}
+	for _, p := range params {
+		switch p.In {
+		case "path":
+			if !slices.Contains(r.segments, "{"+p.Name+"}") {
+				return nil, fmt.Errorf("path parameter %q not in path", p.Name)
+			}
+		case "query", "header", "cookie":
+		default:
+			return nil, fmt.Errorf("parameter %q: unsupported location %q", p.Name, p.In)
+		}
+		if err := h.v.compile(p.Schema, map[*schema]bool{}); err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", p.Name, err)
+		}
+	}
+	for _, segment := range r.segments {
+		if isParam(segment) && !slices.ContainsFunc(params, func(p *parameter) bool {
+			return p.In == "path" && "{"+p.Name+"}" == segment
+		}) {
+			return nil, fmt.Errorf("path parameter %s not declared", segment)
+		}
+	}
+	r.params = params
+
+	// Check the method's arguments.
+	want := 1 + len(params)
+	if op.RequestBody != nil {
+		want++
+	}
+	if t.NumIn() != want {
+		return nil, fmt.Errorf("method %s has %d arguments, want %d (a context.Context, %d parameters, and %d request bodies)", name, t.NumIn(), want, len(params), want-1-len(params))
+	}
+	if t.In(0) != contextType {
+		return nil, fmt.Errorf("method %s: first argument has type %v, want context.Context", name, t.In(0))
+	}
+	for i, p := range params {
+		sc, err := h.v.spec.schema(p.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", p.Name, err)
+		}
+		if sc == nil {
+			return nil, fmt.Errorf("parameter %q: missing schema", p.Name)
+		}
+		if !compatible(sc.Type, t.In(1+i).Kind()) {
+			return nil, fmt.Errorf("method %s: parameter %q has type %v, incompatible with schema type %q", name, p.Name, t.In(1+i), sc.Type)
+		}
+	}
+	if op.RequestBody != nil {
+		body, err := h.v.spec.requestBody(op.RequestBody)
+		if err != nil {
+			return nil, err
+		}
+		content, ok := body.Content["application/json"]
+		if !ok {
+			return nil, fmt.Errorf("request body has no application/json content")
+		}
+		if err := h.v.compile(content.Schema, map[*schema]bool{}); err != nil {
+			return nil, fmt.Errorf("request body: %w", err)
+		}
+		r.body = content.Schema
+		if r.body == nil {
+			r.body = &schema{}
+		}
+		r.bodyType = t.In(t.NumIn() - 1)
+		r.required = body.Required
+	}
+
+	// Check the method's results.
+	switch {
+	case t.NumOut() == 1 && t.Out(0) == errorType:
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		r.hasResult = true
+	default:
+		return nil, fmt.Errorf("method %s must return (error) or (T, error)", name)
+	}
+
+	// Pick the status code of successful responses.
+	r.status = http.StatusOK
+	if !r.hasResult {
+		r.status = http.StatusNoContent
+	}
+	codes := []int{}
+	for code := range op.Responses {
+		if n, err := strconv.Atoi(code); err == nil && n >= 200 && n < 300 {
+			codes = append(codes, n)
+		}
+	}
+	if len(codes) > 0 {
+		sort.Ints(codes)
+		r.status = codes[0]
+	}
+	return r, nil
+}
+
+// isParam returns whether the provided path segment is a parameter.
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// compatible returns whether a Go value of the provided kind can hold a value
+// of the provided schema type.
+func compatible(schemaType string, kind reflect.Kind) bool {
+	switch schemaType {
+	case "string":
+		return kind == reflect.String
+	case "integer":
+		switch kind {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return true
+		}
+	case "number":
+		return kind == reflect.Float32 || kind == reflect.Float64
+	case "boolean":
+		return kind == reflect.Bool
+	}
+	return false
+}
+
+// ServeHTTP implements the http.Handler interface.
+func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := strings.Split(strings.Trim(r.URL.EscapedPath(), "/"), "/")
+	var allowed []string
+	for _, rt := range h.routes {
+		vars, ok := match(rt.segments, segments)
+		if !ok {
+			continue
+		}
+		if rt.method != r.Method {
+			allowed = append(allowed, rt.method)
+			continue
+		}
+		h.serve(w, r, rt, vars)
+		return
+	}
+	if len(allowed) > 0 {
+		sort.Strings(allowed)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	writeError(w, http.StatusNotFound, fmt.Errorf("path %s not found", r.URL.Path))
+}
+
+// match matches the provided route segments against the segments of a
+// request path, returning the values of path parameters.
+func match(route, path []string) (map[string]string, bool) {
+	if len(route) != len(path) {
+		return nil, false
+	}
+	vars := map[string]string{}
+	for i, segment := range route {
+		if isParam(segment) {
+			value, err := url.PathUnescape(path[i])
+			if err != nil || value == "" {
+				return nil, false
+			}
+			vars[strings.Trim(segment, "{}")] = value
+			continue
+		}
+		if segment != path[i] {
+			return nil, false
+		}
+	}
+	return vars, true
+}
+
+// serve serves a request with the provided route.
+func (h *handler) serve(w http.ResponseWriter, r *http.Request, rt *route, vars map[string]string) {
+	t := rt.fn.Type()
+	args := make([]reflect.Value, 0, t.NumIn())
+	args = append(args, reflect.ValueOf(r.Context()))
+
+	// Decode and validate parameters.
+	query := r.URL.Query()
+	for i, p := range rt.params {
+		var raw string
+		var ok bool
+		switch p.In {
+		case "path":
+			raw, ok = vars[p.Name]
+		case "query":
+			ok = query.Has(p.Name)
+			raw = query.Get(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+			ok = len(r.Header.Values(p.Name)) > 0
+		case "cookie":
+			if c, err := r.Cookie(p.Name); err == nil {
+				raw, ok = c.Value, true
+			}
+		}
+		arg := reflect.New(t.In(1 + i)).Elem()
+		if !ok {
+			if p.Required {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("missing required %s parameter %q", p.In, p.Name))
+				return
+			}
+			args = append(args, arg)
+			continue
+		}
+		if err := h.decodeParam(p, raw, arg); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		args = append(args, arg)
+	}
+
+	// Decode and validate the request body.
+	if rt.body != nil {
+		arg, err := h.decodeBody(w, r, rt)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		args = append(args, arg)
+	}
+
+	// Call the method and encode the result.
+	results := rt.fn.Call(args)
+	if err, _ := results[len(results)-1].Interface().(error); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !rt.hasResult {
+		w.WriteHeader(rt.status)
+		return
+	}
+	data, err := json.Marshal(results[0].Interface())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("encode response: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(rt.status)
+	w.Write(data)
+}
+
+// decodeParam decodes and validates the raw value of the provided parameter,
+// storing it in arg.
+func (h *handler) decodeParam(p *parameter, raw string, arg reflect.Value) error {
+	sc, err := h.v.spec.schema(p.Schema)
+	if err != nil {
+		return err
+	}
+	// Convert the raw value into a JSON value, so we can validate it.
+	var x any
+	switch sc.Type {
+	case "string":
+		x = raw
+	case "integer", "number":
+		x = json.Number(raw)
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: %q is not a boolean", p.In, p.Name, raw)
+		}
+		x = b
+	}
+	if err := h.v.validate(fmt.Sprintf("%s parameter %q", p.In, p.Name), sc, x); err != nil {
+		return err
+	}
+
+	switch arg.Kind() {
+	case reflect.String:
+		arg.SetString(raw)
+	case reflect.Bool:
+		arg.SetBool(x.(bool))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, arg.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: %w", p.In, p.Name, err)
+		}
+		arg.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, arg.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: %w", p.In, p.Name, err)
+		}
+		arg.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, arg.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%s parameter %q: %w", p.In, p.Name, err)
+		}
+		arg.SetFloat(f)
+	}
+	return nil
+}
+
+// decodeBody decodes and validates the body of the provided request.
+func (h *handler) decodeBody(w http.ResponseWriter, r *http.Request, rt *route) (reflect.Value, error) {
+	arg := reflect.New(rt.bodyType)
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		return arg, fmt.Errorf("read request body: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if rt.required {
+			return arg, fmt.Errorf("missing required request body")
+		}
+		return arg.Elem(), nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x any
+	if err := dec.Decode(&x); err != nil {
+		return arg, fmt.Errorf("decode request body: %w", err)
+	}
+	if dec.More() {
+		return arg, fmt.Errorf("decode request body: unexpected data after JSON value")
+	}
+	if err := h.v.validate("request body", rt.body, x); err != nil {
+		return arg, err
+	}
+	if err := json.Unmarshal(data, arg.Interface()); err != nil {
+		return arg, fmt.Errorf("decode request body: %w", err)
+	}
+	return arg.Elem(), nil
+}
+
+// writeError writes an error response with the provided status code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	var maxBytes *http.MaxBytesError
+	if errors.As(err, &maxBytes) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	data, _ := json.Marshal(map[string]string{"error": err.Error()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/openapi"
+)
+
+const spec = `{
+  "openapi": "3.0.3",
+  "info": {"title": "users", "version": "1"},
+  "paths": {
+    "/users": {
+      "post": {
+        "operationId": "createUser",
+        "requestBody": {"$ref": "#/components/requestBodies/User"},
+        "responses": {"201": {"description": "created"}}
+      }
+    },
+    "/users/me": {
+      "get": {
+        "x-weaver-method": "Me",
+        "parameters": [{"name": "X-User", "in": "header", "required": true, "schema": {"type": "string"}}],
+        "responses": {"200": {"description": "ok"}}
+      }
+    },
+    "/users/{id}": {
+      "parameters": [{"$ref": "#/components/parameters/ID"}],
+      "get": {
+        "operationId": "getUser",
+        "parameters": [{"name": "verbose", "in": "query", "schema": {"type": "boolean"}}],
+        "responses": {"200": {"description": "ok"}}
+      },
+      "delete": {
+        "operationId": "deleteUser",
+        "responses": {"default": {"description": "error"}}
+      }
+    }
+  },
+  "components": {
+    "parameters": {
+      "ID": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer", "minimum": 1}}
+    },
+    "requestBodies": {
+      "User": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/User"}}}}
+    },
+    "schemas": {
+      "User": {
+        "type": "object",
+        "required": ["name"],
+        "additionalProperties": false,
+        "properties": {
+          "id": {"type": "integer"},
+          "name": {"type": "string", "minLength": 1, "pattern": "^[a-z]+$"},
+          "role": {"type": "string", "enum": ["admin", "member"]},
+          "tags": {"type": "array", "maxItems": 2, "items": {"type": "string"}}
+        }
+      }
+    }
+  }
+}`
+
+type User struct {
+	ID   int      `json:"id"`
+	Name string   `json:"name"`
+	Role string   `json:"role,omitempty"`
+	Tags []string `json:"tags,omitempty"`
+}
+
+// users implements the operations in spec.
+type users struct {
+	mu    sync.Mutex
+	users map[int]User
+}
+
+func (u *users) CreateUser(_ context.Context, user User) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	user.ID = len(u.users) + 1
+	u.users[user.ID] = user
+	return user, nil
+}
+
+func (u *users) Me(_ context.Context, name string) (User, error) {
+	return User{Name: name}, nil
+}
+
+func (u *users) GetUser(_ context.Context, id int, verbose bool) (User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	user, ok := u.users[id]
+	if !ok {
+		return User{}, fmt.Errorf("user %d not found", id)
+	}
+	if !verbose {
+		user.Tags = nil
+	}
+	return user, nil
+}
+
+func (u *users) DeleteUser(_ context.Context, id int) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.users, id)
+	return nil
+}
+
+func TestBind(t *testing.T) {
+	handler, err := openapi.Bind([]byte(spec), &users{users: map[int]User{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	for _, test := range []struct {
+		name       string
+		method     string
+		path       string
+		header     string
+		body       string
+		wantStatus int
+		wantBody   string // substring of the response body
+	}{
+		{"Create", "POST", "/users", "", `{"name": "ada", "tags": ["a"]}`, 201, `{"id":1,"name":"ada","tags":["a"]}`},
+		{"Get", "GET", "/users/1", "", "", 200, `{"id":1,"name":"ada"}`},
+		{"GetVerbose", "GET", "/users/1?verbose=true", "", "", 200, `{"id":1,"name":"ada","tags":["a"]}`},
+		{"Me", "GET", "/users/me", "bob", "", 200, `{"id":0,"name":"bob"}`},
+		{"Delete", "DELETE", "/users/1", "", "", 204, ""},
+		{"GetDeleted", "GET", "/users/1", "", "", 500, `user 1 not found`},
+		{"MissingBody", "POST", "/users", "", "", 400, `missing required request body`},
+		{"MissingProperty", "POST", "/users", "", `{}`, 400, `missing required property \"name\"`},
+		{"ExtraProperty", "POST", "/users", "", `{"name": "ada", "age": 3}`, 400, `unexpected property \"age\"`},
+		{"BadPattern", "POST", "/users", "", `{"name": "Ada"}`, 400, `does not match`},
+		{"BadEnum", "POST", "/users", "", `{"name": "ada", "role": "owner"}`, 400, `is not one of`},
+		{"TooManyItems", "POST", "/users", "", `{"name": "ada", "tags": ["a", "b", "c"]}`, 400, `3 items is greater than 2`},
+		{"WrongType", "POST", "/users", "", `{"name": 7}`, 400, `got number, want string`},
+		{"BadJSON", "POST", "/users", "", `{`, 400, `decode request body`},
+		{"BadInteger", "GET", "/users/one", "", "", 400, `is not an integer`},
+		{"BelowMinimum", "GET", "/users/0", "", "", 400, `is less than 1`},
+		{"BadBoolean", "GET", "/users/1?verbose=maybe", "", "", 400, `is not a boolean`},
+		{"MissingHeader", "GET", "/users/me", "", "", 400, `missing required header parameter`},
+		{"NotFound", "GET", "/groups", "", "", 404, `not found`},
+		{"NotAllowed", "PUT", "/users/1", "", "", 405, `not allowed`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, server.URL+test.path, strings.NewReader(test.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.header != "" {
+				req.Header.Set("X-User", test.header)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != test.wantStatus {
+				t.Fatalf("status: got %d, want %d (body %s)", resp.StatusCode, test.wantStatus, body)
+			}
+			if !strings.Contains(string(body), test.wantBody) {
+				t.Fatalf("body: got %s, want it to contain %s", body, test.wantBody)
+			}
+			if resp.StatusCode >= 400 {
+				var e struct{ Error string }
+				if err := json.Unmarshal(body, &e); err != nil || e.Error == "" {
+					t.Fatalf("error body %s is not of the form {\"error\": ...}", body)
+				}
+			}
+		})
+	}
+}
+
+// mismatched has methods that don't match spec.
+type mismatched struct{}
+
+func (mismatched) CreateUser(context.Context, User) (User, error)      { return User{}, nil }
+func (mismatched) Me(context.Context, string) (User, error)            { return User{}, nil }
+func (mismatched) GetUser(context.Context, string, bool) (User, error) { return User{}, nil }
+func (mismatched) DeleteUser(context.Context, int) error               { return nil }
+
+func TestBindErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		spec string
+		impl any
+		want string
+	}{
+		{"MissingMethod", spec, struct{}{}, "has no method"},
+		{"ParameterType", spec, mismatched{}, `parameter "id" has type string`},
+		{"Version", `{"openapi": "2.0"}`, &users{}, "unsupported OpenAPI version"},
+		{"Arity", `{"openapi": "3.0.0", "paths": {"/me": {"get": {"x-weaver-method": "DeleteUser"}}}}`, &users{}, "has 2 arguments, want 1"},
+		{"UndeclaredPathParameter", `{"openapi": "3.0.0", "paths": {"/users/{id}": {"delete": {"x-weaver-method": "CreateUser", "requestBody": {"content": {"application/json": {}}}}}}}`, &users{}, "path parameter {id} not declared"},
+		{"BadReference", `{"openapi": "3.0.0", "paths": {"/users": {"post": {"operationId": "createUser", "requestBody": {"$ref": "#/components/requestBodies/Nope"}}}}}`, &users{}, "unresolved reference"},
+		{"NoOperationID", `{"openapi": "3.0.0", "paths": {"/users": {"get": {}}}}`, &users{}, "missing operationId"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := openapi.Bind([]byte(test.spec), test.impl)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("Bind: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
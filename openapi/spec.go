@@ -0,0 +1,197 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// spec is the subset of an OpenAPI 3 document that we use.
+type spec struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*pathItem `json:"paths"`
+	Components struct {
+		Schemas       map[string]*schema      `json:"schemas"`
+		Parameters    map[string]*parameter   `json:"parameters"`
+		RequestBodies map[string]*requestBody `json:"requestBodies"`
+	} `json:"components"`
+}
+
+// pathItem describes the operations available on a single path.
+type pathItem struct {
+	Get        *operation   `json:"get"`
+	Put        *operation   `json:"put"`
+	Post       *operation   `json:"post"`
+	Delete     *operation   `json:"delete"`
+	Patch      *operation   `json:"patch"`
+	Parameters []*parameter `json:"parameters"`
+}
+
+// operations returns the operations of the path item, keyed by HTTP method.
+func (p *pathItem) operations() map[string]*operation {
+	ops := map[string]*operation{}
+	for method, op := range map[string]*operation{
+		"GET":    p.Get,
+		"PUT":    p.Put,
+		"POST":   p.Post,
+		"DELETE": p.Delete,
+		"PATCH":  p.Patch,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// operation describes a single API operation on a path.
+type operation struct {
+	OperationID string                     `json:"operationId"`
+	Method      string                     `json:"x-weaver-method"`
+	Parameters  []*parameter               `json:"parameters"`
+	RequestBody *requestBody               `json:"requestBody"`
+	Responses   map[string]json.RawMessage `json:"responses"`
+}
+
+// parameter describes a single operation parameter.
+type parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"` // "path", "query", "header", or "cookie"
+	Required bool    `json:"required"`
+	Schema   *schema `json:"schema"`
+}
+
+// requestBody describes a request body.
+type requestBody struct {
+	Ref      string `json:"$ref"`
+	Required bool   `json:"required"`
+	Content  map[string]struct {
+		Schema *schema `json:"schema"`
+	} `json:"content"`
+}
+
+// schema is the subset of a JSON schema that we validate.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Nullable             bool               `json:"nullable"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"-"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+	Pattern              string             `json:"pattern"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. It records
+// additionalProperties only if it is a boolean, ignoring schema values.
+func (s *schema) UnmarshalJSON(data []byte) error {
+	type plain schema
+	var v struct {
+		plain
+		AdditionalProperties json.RawMessage `json:"additionalProperties"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = schema(v.plain)
+	var b bool
+	if json.Unmarshal(v.AdditionalProperties, &b) == nil {
+		s.AdditionalProperties = &b
+	}
+	return nil
+}
+
+// parseSpec parses an OpenAPI 3 document in JSON.
+func parseSpec(data []byte) (*spec, error) {
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parse spec: %w", err)
+	}
+	if !strings.HasPrefix(s.OpenAPI, "3.") {
+		return nil, fmt.Errorf("unsupported OpenAPI version %q; want 3.x", s.OpenAPI)
+	}
+	return &s, nil
+}
+
+// refName returns the name of the component referenced by ref, which must
+// have the form "#/components/<kind>/<name>".
+func refName(ref, kind string) (string, error) {
+	prefix := "#/components/" + kind + "/"
+	if !strings.HasPrefix(ref, prefix) {
+		return "", fmt.Errorf("unsupported reference %q; want %s<name>", ref, prefix)
+	}
+	return strings.TrimPrefix(ref, prefix), nil
+}
+
+// parameter resolves a possibly referenced parameter.
+func (s *spec) parameter(p *parameter) (*parameter, error) {
+	if p.Ref == "" {
+		return p, nil
+	}
+	name, err := refName(p.Ref, "parameters")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := s.Components.Parameters[name]
+	if !ok || resolved.Ref != "" {
+		return nil, fmt.Errorf("unresolved reference %q", p.Ref)
+	}
+	return resolved, nil
+}
+
+// requestBody resolves a possibly referenced request body.
+func (s *spec) requestBody(b *requestBody) (*requestBody, error) {
+	if b.Ref == "" {
+		return b, nil
+	}
+	name, err := refName(b.Ref, "requestBodies")
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := s.Components.RequestBodies[name]
+	if !ok || resolved.Ref != "" {
+		return nil, fmt.Errorf("unresolved reference %q", b.Ref)
+	}
+	return resolved, nil
+}
+
+// schema resolves a possibly referenced schema.
+func (s *spec) schema(sc *schema) (*schema, error) {
+	for seen := 0; sc != nil && sc.Ref != ""; seen++ {
+		if seen > len(s.Components.Schemas) {
+			return nil, fmt.Errorf("cyclic reference %q", sc.Ref)
+		}
+		name, err := refName(sc.Ref, "schemas")
+		if err != nil {
+			return nil, err
+		}
+		resolved, ok := s.Components.Schemas[name]
+		if !ok {
+			return nil, fmt.Errorf("unresolved reference %q", sc.Ref)
+		}
+		sc = resolved
+	}
+	return sc, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// validator validates JSON values, as decoded by a json.Decoder with UseNumber,
+// against schemas.
+type validator struct {
+	spec     *spec
+	patterns map[string]*regexp.Regexp // compiled patterns
+}
+
+// compile compiles the patterns of the provided schema and of the schemas it
+// references, checking that every reference can be resolved.
+func (v *validator) compile(sc *schema, seen map[*schema]bool) error {
+	sc, err := v.spec.schema(sc)
+	if err != nil || sc == nil || seen[sc] {
+		return err
+	}
+	seen[sc] = true
+	if sc.Pattern != "" {
+		re, err := regexp.Compile(sc.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", sc.Pattern, err)
+		}
+		v.patterns[sc.Pattern] = re
+	}
+	for _, prop := range sc.Properties {
+		if err := v.compile(prop, seen); err != nil {
+			return err
+		}
+	}
+	return v.compile(sc.Items, seen)
+}
+
+// validate returns an error if x doesn't conform to the provided schema. path
+// names x in error messages.
+func (v *validator) validate(path string, sc *schema, x any) error {
+	sc, err := v.spec.schema(sc)
+	if err != nil {
+		return err
+	}
+	if sc == nil {
+		return nil
+	}
+	if x == nil {
+		if sc.Nullable || sc.Type == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: got null, want %s", path, sc.Type)
+	}
+	if len(sc.Enum) > 0 && !inEnum(sc.Enum, x) {
+		return fmt.Errorf("%s: %v is not one of %v", path, x, sc.Enum)
+	}
+
+	switch sc.Type {
+	case "":
+		return nil
+
+	case "string":
+		s, ok := x.(string)
+		if !ok {
+			return fmt.Errorf("%s: got %s, want string", path, typeName(x))
+		}
+		n := utf8.RuneCountInString(s)
+		if sc.MinLength != nil && n < *sc.MinLength {
+			return fmt.Errorf("%s: length %d is less than %d", path, n, *sc.MinLength)
+		}
+		if sc.MaxLength != nil && n > *sc.MaxLength {
+			return fmt.Errorf("%s: length %d is greater than %d", path, n, *sc.MaxLength)
+		}
+		if sc.Pattern != "" && !v.patterns[sc.Pattern].MatchString(s) {
+			return fmt.Errorf("%s: %q does not match %q", path, s, sc.Pattern)
+		}
+		return nil
+
+	case "integer", "number":
+		n, ok := x.(json.Number)
+		if !ok {
+			return fmt.Errorf("%s: got %s, want %s", path, typeName(x), sc.Type)
+		}
+		if sc.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				return fmt.Errorf("%s: %s is not an integer", path, n)
+			}
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return fmt.Errorf("%s: %s is not a number", path, n)
+		}
+		if sc.Minimum != nil && f < *sc.Minimum {
+			return fmt.Errorf("%s: %s is less than %v", path, n, *sc.Minimum)
+		}
+		if sc.Maximum != nil && f > *sc.Maximum {
+			return fmt.Errorf("%s: %s is greater than %v", path, n, *sc.Maximum)
+		}
+		return nil
+
+	case "boolean":
+		if _, ok := x.(bool); !ok {
+			return fmt.Errorf("%s: got %s, want boolean", path, typeName(x))
+		}
+		return nil
+
+	case "array":
+		xs, ok := x.([]any)
+		if !ok {
+			return fmt.Errorf("%s: got %s, want array", path, typeName(x))
+		}
+		if sc.MinItems != nil && len(xs) < *sc.MinItems {
+			return fmt.Errorf("%s: %d items is less than %d", path, len(xs), *sc.MinItems)
+		}
+		if sc.MaxItems != nil && len(xs) > *sc.MaxItems {
+			return fmt.Errorf("%s: %d items is greater than %d", path, len(xs), *sc.MaxItems)
+		}
+		for i, item := range xs {
+			if err := v.validate(fmt.Sprintf("%s[%d]", path, i), sc.Items, item); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "object":
+		m, ok := x.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%s: got %s, want object", path, typeName(x))
+		}
+		for _, name := range sc.Required {
+			if _, ok := m[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		// Validate properties in sorted order, so errors are deterministic.
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := sc.Properties[name]
+			if !ok {
+				if sc.AdditionalProperties != nil && !*sc.AdditionalProperties {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+				continue
+			}
+			if err := v.validate(path+"."+name, prop, m[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("%s: unsupported schema type %q", path, sc.Type)
+	}
+}
+
+// inEnum returns whether x is one of the provided enum values.
+func inEnum(enum []any, x any) bool {
+	for _, e := range enum {
+		if n, ok := x.(json.Number); ok {
+			if f, ok := e.(float64); ok {
+				if g, err := n.Float64(); err == nil && f == g {
+					return true
+				}
+			}
+			continue
+		}
+		if reflect.DeepEqual(e, x) {
+			return true
+		}
+	}
+	return false
+}
+
+// typeName returns the JSON type name of a decoded JSON value.
+func typeName(x any) string {
+	switch x.(type) {
+	case string:
+		return "string"
+	case json.Number:
+		return "number"
+	case bool:
+		return "boolean"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return strings.ToLower(fmt.Sprintf("%T", x))
+	}
+}
@@ -0,0 +1,546 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/google/uuid"
+)
+
+const (
+	// operationPrefix prefixes the keys of the records an OperationManager
+	// stores in its log.
+	operationPrefix = "operations/"
+
+	// operationHeartbeat is how often the replica running an operation
+	// updates its record, and checks whether it was cancelled.
+	operationHeartbeat = 10 * time.Second
+
+	// operationAbandonment is how long after its last update a running
+	// operation, which isn't running on the replica asked for its status, is
+	// considered abandoned, e.g., because the replica running it restarted.
+	operationAbandonment = 3 * operationHeartbeat
+
+	// maxOperationPoll is the longest Operation.Wait waits between two polls
+	// of an operation's status.
+	maxOperationPoll = time.Second
+)
+
+var (
+	// ErrOperationNotFound is returned when an operation doesn't exist.
+	ErrOperationNotFound = errors.New("operation not found")
+
+	// ErrOperationCancelled is returned by Operation.Wait when an operation
+	// was cancelled.
+	ErrOperationCancelled = errors.New("operation cancelled")
+
+	// ErrOperationAbandoned is the error of an operation that stopped
+	// running before it finished, e.g., because the replica running it
+	// restarted.
+	ErrOperationAbandoned = errors.New("operation abandoned")
+)
+
+// OperationState is the state of a long-running operation.
+type OperationState int
+
+const (
+	OperationRunning   OperationState = iota // running
+	OperationSucceeded                       // finished with a result
+	OperationFailed                          // finished with an error
+	OperationCancelled                       // cancelled before it finished
+)
+
+// String implements the fmt.Stringer interface.
+func (s OperationState) String() string {
+	switch s {
+	case OperationRunning:
+		return "running"
+	case OperationSucceeded:
+		return "succeeded"
+	case OperationFailed:
+		return "failed"
+	case OperationCancelled:
+		return "cancelled"
+	default:
+		return fmt.Sprintf("OperationState(%d)", int(s))
+	}
+}
+
+// OperationStatus is the status of a long-running operation.
+type OperationStatus struct {
+	ID       string         // the operation's id
+	State    OperationState // the operation's state
+	Progress float64        // the fraction of the operation done, in [0, 1]
+	Message  string         // the last progress message, if any
+	Result   []byte         // the JSON encoded result, if succeeded
+	Error    string         // the error, if failed
+}
+
+// Done returns whether the operation has finished, successfully or not.
+func (s OperationStatus) Done() bool {
+	return s.State != OperationRunning
+}
+
+// OperationStatus and Operation are serialized by hand, rather than by
+// embedding AutoMarshal, because "weaver generate" doesn't read the code it
+// generated for the weaver package when generating code for the packages that
+// use them.
+var (
+	_ codegen.AutoMarshal = (*OperationStatus)(nil)
+	_ codegen.AutoMarshal = (*Operation[int])(nil)
+)
+
+// WeaverMarshal implements the codegen.AutoMarshal interface.
+func (s *OperationStatus) WeaverMarshal(enc *codegen.Encoder) {
+	enc.String(s.ID)
+	enc.Int(int(s.State))
+	enc.Float64(s.Progress)
+	enc.String(s.Message)
+	enc.Bytes(s.Result)
+	enc.String(s.Error)
+}
+
+// WeaverUnmarshal implements the codegen.AutoMarshal interface.
+func (s *OperationStatus) WeaverUnmarshal(dec *codegen.Decoder) {
+	s.ID = dec.String()
+	s.State = OperationState(dec.Int())
+	s.Progress = dec.Float64()
+	s.Message = dec.String()
+	s.Result = dec.Bytes()
+	s.Error = dec.String()
+}
+
+// Operations is implemented by components that run long-running operations.
+// A component that starts long-running operations declares the two methods
+// below in its interface, and implements them with an OperationManager, so
+// that callers can poll, wait for, and cancel the operations it returns:
+//
+//	type Exporter interface {
+//	    Export(ctx context.Context, table string) (weaver.Operation[int], error)
+//	    OperationStatus(ctx context.Context, id string) (weaver.OperationStatus, error)
+//	    CancelOperation(ctx context.Context, id string) error
+//	}
+type Operations interface {
+	// OperationStatus returns the status of the operation with the provided
+	// id, or an error that wraps ErrOperationNotFound if it doesn't exist.
+	OperationStatus(ctx context.Context, id string) (OperationStatus, error)
+
+	// CancelOperation requests the cancellation of the operation with the
+	// provided id. Cancelling an operation that has finished does nothing.
+	CancelOperation(ctx context.Context, id string) error
+}
+
+// Operation is a handle to a long-running operation, started with
+// StartOperation, whose result is a T. A method that starts long-running
+// work returns an Operation, rather than waiting for the work to finish, and
+// callers poll, wait for, or cancel the operation through the Operations
+// methods of the component that started it:
+//
+//	op, err := exporter.Export(ctx, "users")
+//	if err != nil {
+//	    return err
+//	}
+//	rows, err := op.Wait(ctx, exporter)
+//
+// An Operation is serializable, and can be stored and passed around. Its
+// result must be serializable with encoding/json.
+type Operation[T any] struct {
+	ID string // the operation's id
+}
+
+// WeaverMarshal implements the codegen.AutoMarshal interface.
+func (o *Operation[T]) WeaverMarshal(enc *codegen.Encoder) {
+	enc.String(o.ID)
+}
+
+// WeaverUnmarshal implements the codegen.AutoMarshal interface.
+func (o *Operation[T]) WeaverUnmarshal(dec *codegen.Decoder) {
+	o.ID = dec.String()
+}
+
+// Status returns the status of the operation.
+func (o Operation[T]) Status(ctx context.Context, ops Operations) (OperationStatus, error) {
+	return ops.OperationStatus(ctx, o.ID)
+}
+
+// Cancel requests the cancellation of the operation. The operation's
+// function is cancelled, and the operation finishes in the
+// OperationCancelled state, unless it finishes first.
+func (o Operation[T]) Cancel(ctx context.Context, ops Operations) error {
+	return ops.CancelOperation(ctx, o.ID)
+}
+
+// Wait waits for the operation to finish and returns its result. It returns
+// an error that wraps ErrOperationCancelled if the operation was cancelled,
+// and the operation's error if it failed. Wait polls the status of the
+// operation, waiting longer and longer between polls, up to a second.
+func (o Operation[T]) Wait(ctx context.Context, ops Operations) (T, error) {
+	var zero T
+	poll := 10 * time.Millisecond
+	for {
+		status, err := o.Status(ctx, ops)
+		if err != nil {
+			return zero, err
+		}
+		if status.Done() {
+			return o.Result(status)
+		}
+		select {
+		case <-ctx.Done():
+			return zero, ctx.Err()
+		case <-time.After(poll):
+		}
+		poll = min(2*poll, maxOperationPoll)
+	}
+}
+
+// Result returns the result of the operation, given its status. It returns an
+// error if the operation hasn't finished, was cancelled, or failed.
+func (o Operation[T]) Result(status OperationStatus) (T, error) {
+	var result T
+	switch status.State {
+	case OperationRunning:
+		return result, fmt.Errorf("operation %s: still running", o.ID)
+	case OperationCancelled:
+		return result, fmt.Errorf("operation %s: %w", o.ID, ErrOperationCancelled)
+	case OperationFailed:
+		if status.Error == ErrOperationAbandoned.Error() {
+			return result, fmt.Errorf("operation %s: %w", o.ID, ErrOperationAbandoned)
+		}
+		return result, fmt.Errorf("operation %s: %s", o.ID, status.Error)
+	}
+	if err := json.Unmarshal(status.Result, &result); err != nil {
+		return result, fmt.Errorf("operation %s: decode result: %w", o.ID, err)
+	}
+	return result, nil
+}
+
+// OperationLog stores the records of the operations of an OperationManager.
+// Every BlobStore is an OperationLog, so a component can persist its
+// operations in the BlobStore component, and the status and result of an
+// operation then survive restarts of the replica that ran it. The records of
+// a log shared by the replicas of a component, like the BlobStore component
+// with the "disk", "s3", or "gcs" backends, are visible to every replica, so
+// that any replica can report the status of any operation.
+type OperationLog interface {
+	// Get returns the record stored with the provided key, or an error that
+	// wraps ErrBlobNotFound if there is none.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Put stores the record with the provided key, replacing the record
+	// stored previously, if any.
+	Put(ctx context.Context, key string, record []byte) error
+}
+
+var _ OperationLog = BlobStore(nil)
+
+// memOperationLog is an OperationLog that stores records in memory.
+type memOperationLog struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+// NewMemOperationLog returns an OperationLog that stores records in memory.
+// The records are lost when the process exits, and are only visible to the
+// replica that stores them, so use it for testing, or for components that run
+// in a single process.
+func NewMemOperationLog() OperationLog {
+	return &memOperationLog{records: map[string][]byte{}}
+}
+
+// Get implements the OperationLog interface.
+func (m *memOperationLog) Get(_ context.Context, key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record, ok := m.records[key]
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", key, ErrBlobNotFound)
+	}
+	return append([]byte(nil), record...), nil
+}
+
+// Put implements the OperationLog interface.
+func (m *memOperationLog) Put(_ context.Context, key string, record []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[key] = append([]byte(nil), record...)
+	return nil
+}
+
+// operationRecord is the record of an operation stored by an
+// OperationManager.
+type operationRecord struct {
+	State    OperationState
+	Progress float64
+	Message  string
+	Result   json.RawMessage `json:",omitempty"`
+	Error    string          `json:",omitempty"`
+	Updated  time.Time       // when the record was last stored
+}
+
+// OperationManager runs the long-running operations of a component, records
+// their status in an OperationLog, and implements the Operations interface.
+// For example:
+//
+//	type exporter struct {
+//	    weaver.Implements[Exporter]
+//	    blobs weaver.Ref[weaver.BlobStore]
+//	    ops   *weaver.OperationManager
+//	}
+//
+//	func (e *exporter) Init(context.Context) error {
+//	    e.ops = weaver.NewOperationManager(e.blobs.Get())
+//	    return nil
+//	}
+//
+//	func (e *exporter) Export(ctx context.Context, table string) (weaver.Operation[int], error) {
+//	    return weaver.StartOperation(ctx, e.ops, func(ctx context.Context, p *weaver.Progress) (int, error) {
+//	        return e.export(ctx, table, p)
+//	    })
+//	}
+//
+//	func (e *exporter) OperationStatus(ctx context.Context, id string) (weaver.OperationStatus, error) {
+//	    return e.ops.OperationStatus(ctx, id)
+//	}
+//
+//	func (e *exporter) CancelOperation(ctx context.Context, id string) error {
+//	    return e.ops.CancelOperation(ctx, id)
+//	}
+//
+// An operation runs on the replica that started it. While it runs, the
+// replica updates its record every few seconds. If the replica stops, e.g.,
+// because it restarted, the operation is reported as failed, with the error
+// of ErrOperationAbandoned, once its record is stale, rather than as running
+// forever. Operations are not restarted.
+type OperationManager struct {
+	log OperationLog
+
+	mu      sync.Mutex
+	running map[string]*Progress // running operations, by id
+}
+
+var _ Operations = &OperationManager{}
+
+// NewOperationManager returns a new OperationManager that records operations
+// in the provided log.
+func NewOperationManager(log OperationLog) *OperationManager {
+	return &OperationManager{log: log, running: map[string]*Progress{}}
+}
+
+// Progress reports the progress of a running operation. It is passed to the
+// function run by StartOperation.
+type Progress struct {
+	m      *OperationManager
+	id     string
+	cancel context.CancelFunc // cancels the operation's function
+
+	mu        sync.Mutex
+	fraction  float64
+	message   string
+	cancelled bool // was the operation cancelled?
+}
+
+// Report records that the provided fraction, in [0, 1], of the operation is
+// done, along with an optional human readable message, like "exported 1200
+// of 5000 rows". Report also checks whether the operation was cancelled on
+// another replica, in which case it cancels the operation's context.
+// Progress is reported on a best effort basis: if the log fails to store
+// the report, the next report, or heartbeat, stores it.
+func (p *Progress) Report(ctx context.Context, fraction float64, message string) {
+	p.mu.Lock()
+	p.fraction = max(0, min(1, fraction))
+	p.message = message
+	p.mu.Unlock()
+	p.update(ctx)
+}
+
+// record returns the record of the running operation.
+func (p *Progress) record() operationRecord {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return operationRecord{State: OperationRunning, Progress: p.fraction, Message: p.message}
+}
+
+// update stores the record of the running operation, and cancels it if its
+// cancellation was requested on another replica.
+func (p *Progress) update(ctx context.Context) {
+	if _, err := p.m.log.Get(ctx, cancelKey(p.id)); err == nil {
+		p.markCancelled()
+	}
+	p.m.store(ctx, p.id, p.record())
+}
+
+// markCancelled cancels the running operation.
+func (p *Progress) markCancelled() {
+	p.mu.Lock()
+	p.cancelled = true
+	p.mu.Unlock()
+	p.cancel()
+}
+
+// StartOperation starts running fn in a new goroutine, as a long-running
+// operation managed by m, and returns a handle to the operation. fn's context
+// is not cancelled when ctx is; it is cancelled when the operation is.
+func StartOperation[T any](ctx context.Context, m *OperationManager, fn func(context.Context, *Progress) (T, error)) (Operation[T], error) {
+	id := uuid.NewString()
+	if err := m.store(ctx, id, operationRecord{State: OperationRunning}); err != nil {
+		return Operation[T]{}, fmt.Errorf("start operation: %w", err)
+	}
+
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	p := &Progress{m: m, id: id, cancel: cancel}
+	m.mu.Lock()
+	m.running[id] = p
+	m.mu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		// Keep the record fresh, so that the operation isn't considered
+		// abandoned while fn runs.
+		done := make(chan struct{})
+		var heartbeat sync.WaitGroup
+		heartbeat.Add(1)
+		go func() {
+			defer heartbeat.Done()
+			ticker := time.NewTicker(operationHeartbeat)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+					p.update(runCtx)
+				}
+			}
+		}()
+		value, err := fn(runCtx, p)
+		close(done)
+		heartbeat.Wait() // don't let a heartbeat overwrite the final record
+
+		record := p.record()
+		p.mu.Lock()
+		cancelled := p.cancelled
+		p.mu.Unlock()
+		switch {
+		case cancelled:
+			record.State = OperationCancelled
+		case err != nil:
+			record.State = OperationFailed
+			record.Error = err.Error()
+		default:
+			result, err := json.Marshal(value)
+			if err != nil {
+				record.State = OperationFailed
+				record.Error = fmt.Sprintf("encode result: %v", err)
+				break
+			}
+			record.State = OperationSucceeded
+			record.Progress = 1
+			record.Result = result
+		}
+		// If the record can't be stored, the operation is eventually
+		// reported as abandoned.
+		m.store(context.WithoutCancel(ctx), id, record)
+
+		m.mu.Lock()
+		delete(m.running, id)
+		m.mu.Unlock()
+	}()
+	return Operation[T]{ID: id}, nil
+}
+
+// OperationStatus implements the Operations interface.
+func (m *OperationManager) OperationStatus(ctx context.Context, id string) (OperationStatus, error) {
+	record, err := m.load(ctx, id)
+	if err != nil {
+		return OperationStatus{}, err
+	}
+	m.mu.Lock()
+	_, running := m.running[id]
+	m.mu.Unlock()
+	if record.State == OperationRunning && !running && time.Since(record.Updated) > operationAbandonment {
+		record.State = OperationFailed
+		record.Error = ErrOperationAbandoned.Error()
+	}
+	return OperationStatus{
+		ID:       id,
+		State:    record.State,
+		Progress: record.Progress,
+		Message:  record.Message,
+		Result:   record.Result,
+		Error:    record.Error,
+	}, nil
+}
+
+// CancelOperation implements the Operations interface.
+func (m *OperationManager) CancelOperation(ctx context.Context, id string) error {
+	record, err := m.load(ctx, id)
+	if err != nil {
+		return err
+	}
+	if record.State != OperationRunning {
+		return nil
+	}
+	m.mu.Lock()
+	p, running := m.running[id]
+	m.mu.Unlock()
+	if running {
+		p.markCancelled()
+		return nil
+	}
+	// The operation runs on another replica, which cancels it the next time
+	// it updates the operation's record.
+	return m.log.Put(ctx, cancelKey(id), []byte{})
+}
+
+// load loads the record of the operation with the provided id.
+func (m *OperationManager) load(ctx context.Context, id string) (operationRecord, error) {
+	data, err := m.log.Get(ctx, operationPrefix+id)
+	if errors.Is(err, ErrBlobNotFound) {
+		return operationRecord{}, fmt.Errorf("operation %s: %w", id, ErrOperationNotFound)
+	}
+	if err != nil {
+		return operationRecord{}, err
+	}
+	var record operationRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return operationRecord{}, fmt.Errorf("operation %s: corrupt record: %w", id, err)
+	}
+	return record, nil
+}
+
+// store stores the record of the operation with the provided id.
+func (m *OperationManager) store(ctx context.Context, id string, record operationRecord) error {
+	record.Updated = time.Now()
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return m.log.Put(ctx, operationPrefix+id, data)
+}
+
+// cancelKey returns the key of the record that requests the cancellation of
+// the operation with the provided id.
+func cancelKey(id string) string {
+	return operationPrefix + id + ".cancel"
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+func TestOperationWait(t *testing.T) {
+	ctx := context.Background()
+	m := NewOperationManager(NewMemOperationLog())
+	proceed := make(chan struct{})
+	op, err := StartOperation(ctx, m, func(ctx context.Context, p *Progress) ([]string, error) {
+		p.Report(ctx, 0.5, "halfway")
+		<-proceed
+		return []string{"a", "b"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Wait for the progress report.
+	for {
+		status, err := op.Status(ctx, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status.Message == "halfway" {
+			if status.Done() || status.Progress != 0.5 {
+				t.Fatalf("Status: got %+v, want running and halfway", status)
+			}
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	close(proceed)
+	got, err := op.Wait(ctx, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("Wait: got %v, want [a b]", got)
+	}
+	status, err := op.Status(ctx, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != OperationSucceeded || status.Progress != 1 {
+		t.Fatalf("Status: got %+v, want succeeded", status)
+	}
+}
+
+func TestOperationFails(t *testing.T) {
+	ctx := context.Background()
+	m := NewOperationManager(NewMemOperationLog())
+	op, err := StartOperation(ctx, m, func(context.Context, *Progress) (int, error) {
+		return 0, errors.New("disk full")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.Wait(ctx, m); err == nil || err.Error() != "operation "+op.ID+": disk full" {
+		t.Fatalf("Wait: got %v, want disk full", err)
+	}
+}
+
+func TestOperationCancel(t *testing.T) {
+	ctx := context.Background()
+	m := NewOperationManager(NewMemOperationLog())
+	op, err := StartOperation(ctx, m, func(ctx context.Context, _ *Progress) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := op.Cancel(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.Wait(ctx, m); !errors.Is(err, ErrOperationCancelled) {
+		t.Fatalf("Wait: got %v, want ErrOperationCancelled", err)
+	}
+
+	// Cancelling a finished operation does nothing.
+	if err := op.Cancel(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Operation[int]{ID: "missing"}).Cancel(ctx, m); !errors.Is(err, ErrOperationNotFound) {
+		t.Fatalf("Cancel(missing): got %v, want ErrOperationNotFound", err)
+	}
+}
+
+func TestOperationSharedLog(t *testing.T) {
+	// Two managers sharing a log behave like two replicas of a component
+	// whose operations are persisted in the BlobStore component.
+	ctx := context.Background()
+	log := NewMemOperationLog()
+	a, b := NewOperationManager(log), NewOperationManager(log)
+	op, err := StartOperation(ctx, a, func(ctx context.Context, p *Progress) (int, error) {
+		for i := 0; ; i++ {
+			p.Report(ctx, 0, "working")
+			select {
+			case <-ctx.Done():
+				return i, ctx.Err()
+			case <-time.After(time.Millisecond):
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// b cancels the operation running on a, which notices when it next
+	// reports its progress.
+	if err := op.Cancel(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := op.Wait(ctx, b); !errors.Is(err, ErrOperationCancelled) {
+		t.Fatalf("Wait: got %v, want ErrOperationCancelled", err)
+	}
+
+	// The status outlives the manager that ran the operation.
+	c := NewOperationManager(log)
+	if status, err := op.Status(ctx, c); err != nil || status.State != OperationCancelled {
+		t.Fatalf("Status: got %+v, %v, want cancelled", status, err)
+	}
+}
+
+func TestOperationAbandoned(t *testing.T) {
+	// Store the record of an operation last updated long ago by a replica
+	// that has since restarted.
+	ctx := context.Background()
+	m := NewOperationManager(NewMemOperationLog())
+	op := Operation[int]{ID: "stale"}
+	data, err := json.Marshal(operationRecord{State: OperationRunning, Updated: time.Now().Add(-2 * operationAbandonment)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.log.Put(ctx, operationPrefix+op.ID, data); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := op.Wait(ctx, m); !errors.Is(err, ErrOperationAbandoned) {
+		t.Fatalf("Wait: got %v, want ErrOperationAbandoned", err)
+	}
+}
+
+func TestOperationRoundTrip(t *testing.T) {
+	want := OperationStatus{ID: "id", State: OperationSucceeded, Progress: 1, Message: "done", Result: []byte("42")}
+	enc := codegen.NewEncoder()
+	want.WeaverMarshal(enc)
+	var got OperationStatus
+	got.WeaverUnmarshal(codegen.NewDecoder(enc.Data()))
+	if got.ID != want.ID || got.State != want.State || got.Progress != want.Progress || got.Message != want.Message || string(got.Result) != string(want.Result) {
+		t.Errorf("round trip: got %+v, want %+v", got, want)
+	}
+}
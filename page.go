@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// cursorVersion prefixes the cursors returned by NewCursor. If the encoding
+// of cursors ever changes, the version lets Position reject, or convert,
+// cursors handed out by older versions of an application.
+const cursorVersion = "v1."
+
+const (
+	// defaultPageSize is the page size used when neither the caller nor the
+	// config picks one.
+	defaultPageSize = 100
+
+	// defaultMaxPageSize is the largest page size allowed when the config
+	// doesn't set one.
+	defaultMaxPageSize = 1000
+)
+
+// Cursor is an opaque position in a list, returned in a Page to let callers
+// request the next page. The empty cursor denotes the start of a list.
+//
+// A cursor encodes the position of the last item of a page, like a primary
+// key, rather than an offset, so that items inserted or removed while a
+// caller pages through a list don't shift the items of the following pages.
+// Callers should treat cursors as opaque strings; only the component that
+// returned a cursor should interpret it, with Position.
+type Cursor string
+
+// NewCursor returns a cursor positioned at the provided position, typically
+// the key of the last item of a page.
+func NewCursor(position string) Cursor {
+	return Cursor(cursorVersion + base64.RawURLEncoding.EncodeToString([]byte(position)))
+}
+
+// Position returns the position passed to NewCursor to create c, or the empty
+// string if c is empty. It returns an error if c wasn't returned by
+// NewCursor, e.g., if a caller altered it.
+func (c Cursor) Position() (string, error) {
+	if c == "" {
+		return "", nil
+	}
+	encoded, ok := strings.CutPrefix(string(c), cursorVersion)
+	if !ok {
+		return "", fmt.Errorf("malformed cursor %q", string(c))
+	}
+	position, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed cursor %q: %w", string(c), err)
+	}
+	return string(position), nil
+}
+
+// PageRequest requests a page of a list. It is the conventional argument of
+// list methods. For example:
+//
+//	type Users interface {
+//	    List(ctx context.Context, req weaver.PageRequest) (weaver.Page[User], error)
+//	}
+type PageRequest struct {
+	// Cursor is the cursor of the requested page: the empty cursor for the
+	// first page, or the Next cursor of the previous page.
+	Cursor Cursor
+
+	// Size is the requested number of items. Zero requests the default page
+	// size. A component may return fewer items (see PageConfig).
+	Size int
+}
+
+// PageRequest is serialized by hand, rather than by embedding AutoMarshal,
+// because "weaver generate" doesn't read the code it generated for the weaver
+// package when generating code for the packages that use PageRequest.
+var _ codegen.AutoMarshal = (*PageRequest)(nil)
+
+// WeaverMarshal implements the codegen.AutoMarshal interface.
+func (r *PageRequest) WeaverMarshal(enc *codegen.Encoder) {
+	enc.String(string(r.Cursor))
+	enc.Int(r.Size)
+}
+
+// WeaverUnmarshal implements the codegen.AutoMarshal interface.
+func (r *PageRequest) WeaverUnmarshal(dec *codegen.Decoder) {
+	r.Cursor = Cursor(dec.String())
+	r.Size = dec.Int()
+}
+
+// Page is a page of a list, returned by list methods. It holds at most the
+// requested number of items, and the cursor of the next page, if any.
+//
+// The code generator serializes Page[T] for every serializable type T, so
+// that list methods can return it.
+type Page[T any] struct {
+	Items []T    // the items of the page
+	Next  Cursor // the cursor of the next page, or "" for the last page
+}
+
+// Last returns whether p is the last page of its list.
+func (p Page[T]) Last() bool {
+	return p.Next == ""
+}
+
+// PageConfig configures the page sizes of the list methods of a component.
+// Embed it in a component's config to read it from the component's section
+// of the config file:
+//
+//	type config struct {
+//	    weaver.PageConfig
+//	}
+//
+//	type users struct {
+//	    weaver.Implements[Users]
+//	    weaver.WithConfig[config]
+//	}
+//
+// For example, the following config returns 50 items per page by default, and
+// at most 200 items per page:
+//
+//	["example.com/users/Users"]
+//	default_page_size = 50
+//	max_page_size = 200
+type PageConfig struct {
+	DefaultPageSize int `toml:"default_page_size"` // defaults to 100
+	MaxPageSize     int `toml:"max_page_size"`     // defaults to 1000
+}
+
+// Validate returns an error if the page sizes are invalid.
+func (c PageConfig) Validate() error {
+	if c.DefaultPageSize < 0 {
+		return fmt.Errorf("negative default_page_size %d", c.DefaultPageSize)
+	}
+	if c.MaxPageSize < 0 {
+		return fmt.Errorf("negative max_page_size %d", c.MaxPageSize)
+	}
+	if c.MaxPageSize > 0 && c.DefaultPageSize > c.MaxPageSize {
+		return fmt.Errorf("default_page_size %d larger than max_page_size %d", c.DefaultPageSize, c.MaxPageSize)
+	}
+	return nil
+}
+
+// PageSize returns the number of items to return for a page request that
+// requests the provided number of items: the default page size if requested
+// isn't positive, capped at the maximum page size.
+func (c PageConfig) PageSize(requested int) int {
+	max := c.MaxPageSize
+	if max <= 0 {
+		max = defaultMaxPageSize
+	}
+	size := requested
+	if size <= 0 {
+		size = c.DefaultPageSize
+	}
+	if size <= 0 {
+		size = defaultPageSize
+	}
+	if size > max {
+		size = max
+	}
+	return size
+}
+
+// Paginate returns the page of the provided items requested by req. The
+// items must be sorted by key, and keys must be unique. The returned page
+// holds the items whose keys follow the position of req's cursor, and its
+// cursor is positioned at the key of its last item. For example:
+//
+//	func (u *users) List(ctx context.Context, req weaver.PageRequest) (weaver.Page[User], error) {
+//	    all := u.sortedUsers()
+//	    return weaver.Paginate(all, req, u.Config().PageConfig, func(u User) string { return u.ID })
+//	}
+//
+// Paginate is convenient for small lists held in memory. Larger lists should
+// be paginated by their stores, e.g., with a query that selects the items
+// following Cursor.Position, ordered and limited to PageConfig.PageSize items.
+func Paginate[T any](items []T, req PageRequest, cfg PageConfig, key func(T) string) (Page[T], error) {
+	after, err := req.Cursor.Position()
+	if err != nil {
+		return Page[T]{}, err
+	}
+	start := 0
+	if req.Cursor != "" {
+		start = sort.Search(len(items), func(i int) bool { return key(items[i]) > after })
+	}
+	end := start + cfg.PageSize(req.Size)
+	if end >= len(items) {
+		return Page[T]{Items: items[start:]}, nil
+	}
+	return Page[T]{Items: items[start:end], Next: NewCursor(key(items[end-1]))}, nil
+}
+
+// AllPages calls list repeatedly to read every page of a list, starting at
+// the first page, and returns the items of all of the pages. Every call
+// requests pages of the provided size.
+func AllPages[T any](ctx context.Context, size int, list func(context.Context, PageRequest) (Page[T], error)) ([]T, error) {
+	var items []T
+	req := PageRequest{Size: size}
+	for {
+		page, err := list(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		if page.Last() {
+			return items, nil
+		}
+		if page.Next == req.Cursor {
+			return nil, fmt.Errorf("list returned cursor %q twice", string(page.Next))
+		}
+		req.Cursor = page.Next
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+func TestCursor(t *testing.T) {
+	for _, position := range []string{"", "a", "user/42", "\x00\xff"} {
+		c := weaver.NewCursor(position)
+		got, err := c.Position()
+		if err != nil {
+			t.Fatalf("NewCursor(%q).Position(): %v", position, err)
+		}
+		if got != position {
+			t.Errorf("NewCursor(%q).Position(): got %q", position, got)
+		}
+	}
+
+	// Cursors are stable across versions of an application.
+	if got, want := weaver.NewCursor("user/42"), weaver.Cursor("v1.dXNlci80Mg"); got != want {
+		t.Errorf("NewCursor: got %q, want %q", got, want)
+	}
+
+	for _, c := range []weaver.Cursor{"user/42", "v1.!!", "v2.dXNlci80Mg"} {
+		if _, err := c.Position(); err == nil {
+			t.Errorf("Cursor(%q).Position(): unexpected success", c)
+		}
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	for _, test := range []struct {
+		cfg       weaver.PageConfig
+		requested int
+		want      int
+	}{
+		{weaver.PageConfig{}, 0, 100},
+		{weaver.PageConfig{}, 10, 10},
+		{weaver.PageConfig{}, 5000, 1000},
+		{weaver.PageConfig{DefaultPageSize: 50}, 0, 50},
+		{weaver.PageConfig{DefaultPageSize: 50}, -1, 50},
+		{weaver.PageConfig{DefaultPageSize: 50, MaxPageSize: 200}, 300, 200},
+		{weaver.PageConfig{MaxPageSize: 20}, 0, 20},
+	} {
+		if got := test.cfg.PageSize(test.requested); got != test.want {
+			t.Errorf("%+v.PageSize(%d): got %d, want %d", test.cfg, test.requested, got, test.want)
+		}
+	}
+}
+
+func TestPageConfigValidate(t *testing.T) {
+	for _, cfg := range []weaver.PageConfig{
+		{DefaultPageSize: -1},
+		{MaxPageSize: -1},
+		{DefaultPageSize: 20, MaxPageSize: 10},
+	} {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%+v.Validate(): unexpected success", cfg)
+		}
+	}
+	if err := (weaver.PageConfig{DefaultPageSize: 10, MaxPageSize: 20}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	ctx := context.Background()
+	items := []string{"a", "b", "c", "d", "e"}
+	list := func(_ context.Context, req weaver.PageRequest) (weaver.Page[string], error) {
+		return weaver.Paginate(items, req, weaver.PageConfig{}, func(s string) string { return s })
+	}
+
+	first, err := list(ctx, weaver.PageRequest{Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := first.Items, []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Fatalf("first page: got %v, want %v", got, want)
+	}
+	if first.Last() {
+		t.Fatal("first page: unexpected last page")
+	}
+
+	// Inserting an item before the cursor doesn't shift the next page.
+	items = []string{"a", "aa", "b", "c", "d", "e"}
+	second, err := list(ctx, weaver.PageRequest{Cursor: first.Next, Size: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := second.Items, []string{"c", "d"}; !slices.Equal(got, want) {
+		t.Fatalf("second page: got %v, want %v", got, want)
+	}
+
+	all, err := weaver.AllPages(ctx, 4, list)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(all, items) {
+		t.Fatalf("AllPages: got %v, want %v", all, items)
+	}
+
+	if _, err := list(ctx, weaver.PageRequest{Cursor: "bogus"}); err == nil {
+		t.Error("Paginate with bogus cursor: unexpected success")
+	}
+}
+
+func TestAllPagesRepeatedCursor(t *testing.T) {
+	list := func(context.Context, weaver.PageRequest) (weaver.Page[int], error) {
+		return weaver.Page[int]{Items: []int{1}, Next: weaver.NewCursor("x")}, nil
+	}
+	if _, err := weaver.AllPages(context.Background(), 1, list); err == nil {
+		t.Error("AllPages: unexpected success")
+	}
+}
+
+func TestPageRequestRoundTrip(t *testing.T) {
+	want := weaver.PageRequest{Cursor: weaver.NewCursor("user/42"), Size: 25}
+	enc := codegen.NewEncoder()
+	want.WeaverMarshal(enc)
+	var got weaver.PageRequest
+	got.WeaverUnmarshal(codegen.NewDecoder(enc.Data()))
+	if got != want {
+		t.Errorf("round trip: got %+v, want %+v", got, want)
+	}
+}
+
+func ExamplePaginate() {
+	users := []string{"ada", "alan", "grace", "ken"}
+	req := weaver.PageRequest{Size: 3}
+	for {
+		page, err := weaver.Paginate(users, req, weaver.PageConfig{}, func(u string) string { return u })
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(page.Items)
+		if page.Last() {
+			break
+		}
+		req.Cursor = page.Next
+	}
+	// Output:
+	// [ada alan grace]
+	// [ken]
+}
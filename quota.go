@@ -0,0 +1,310 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Quota is a built-in component that enforces global limits, like a maximum
+// number of requests per API key per day, across all the components of an
+// application.
+//
+// Limits are named and configured in the Quota component's section of the
+// config file. For example, the following config allows 10,000 requests per
+// day:
+//
+//	["github.com/ServiceWeaver/weaver/Quota"]
+//	limits = { requests = { limit = 10000, period = "24h" } }
+//
+// A component consults a limit by calling Acquire with the limit's name and
+// the key being limited:
+//
+//	type server struct {
+//	    weaver.Implements[weaver.Main]
+//	    quota weaver.Ref[weaver.Quota]
+//	}
+//
+//	func (s *server) handle(ctx context.Context, apiKey string) error {
+//	    ok, err := s.quota.Get().Acquire(ctx, "requests", apiKey, 1)
+//	    if err != nil {
+//	        return err
+//	    }
+//	    if !ok {
+//	        return fmt.Errorf("quota exceeded for %q", apiKey)
+//	    }
+//	    ...
+//	}
+//
+// Usage is counted by a quota server, with calls for a given key routed to the
+// same quota server replica. Every replica of the Quota component caches a
+// batch of units per key and refills it asynchronously, so most calls to
+// Acquire don't contact the quota server at all. Colocate the Quota component
+// with the components that use it to make Acquire a local method call.
+type Quota interface {
+	// Acquire consumes n units of the limit with the provided name for the
+	// provided key, returning false if fewer than n units remain in the
+	// current period. Acquire returns an error if the limit isn't configured.
+	Acquire(ctx context.Context, name, key string, n int) (bool, error)
+}
+
+// Acquire and Grant are not idempotent: retrying them may consume units twice.
+var (
+	_ NotRetriable = Quota.Acquire
+	_ NotRetriable = quotaServer.Grant
+)
+
+// quotaConfig configures the Quota component.
+type quotaConfig struct {
+	Limits map[string]quotaLimit `toml:"limits"`
+}
+
+// quotaLimit is a limit on the number of units consumed per period.
+type quotaLimit struct {
+	Limit  int    `toml:"limit"`  // units available per period
+	Period string `toml:"period"` // period length (e.g., "1h", "24h")
+	Batch  int    `toml:"batch"`  // units cached per key; defaults to 1% of limit
+}
+
+// quotaRefillTimeout bounds asynchronous refills of a Quota replica's cache.
+const quotaRefillTimeout = 10 * time.Second
+
+// quota is the implementation of the Quota component.
+type quota struct {
+	Implements[Quota]
+	WithConfig[quotaConfig]
+	server Ref[quotaServer]
+
+	periods map[string]time.Duration // parsed limit periods, by limit name
+
+	mu      sync.Mutex
+	buckets map[quotaKey]*quotaBucket
+}
+
+// quotaKey identifies the usage of a limit by a single key.
+type quotaKey struct {
+	name string // limit name
+	key  string // limited key (e.g., an API key)
+}
+
+// quotaBucket holds the units a Quota replica has cached for a single key.
+type quotaBucket struct {
+	tokens    int   // cached units
+	end       int64 // end of the period the tokens belong to, in unix nanos
+	exhausted bool  // has the limit been reached for the period?
+	refilling bool  // is an asynchronous refill in progress?
+}
+
+var _ Quota = &quota{}
+
+// Init initializes the Quota component.
+func (q *quota) Init(context.Context) error {
+	q.periods = map[string]time.Duration{}
+	q.buckets = map[quotaKey]*quotaBucket{}
+	for name, limit := range q.Config().Limits {
+		period, err := time.ParseDuration(limit.Period)
+		if err != nil {
+			return fmt.Errorf("quota %q: invalid period %q: %w", name, limit.Period, err)
+		}
+		if period <= 0 {
+			return fmt.Errorf("quota %q: non-positive period %q", name, limit.Period)
+		}
+		if limit.Limit <= 0 {
+			return fmt.Errorf("quota %q: non-positive limit %d", name, limit.Limit)
+		}
+		if limit.Batch < 0 || limit.Batch > limit.Limit {
+			return fmt.Errorf("quota %q: batch %d not in range [0, %d]", name, limit.Batch, limit.Limit)
+		}
+		q.periods[name] = period
+	}
+	return nil
+}
+
+// Acquire implements the Quota interface.
+func (q *quota) Acquire(ctx context.Context, name, key string, n int) (bool, error) {
+	limit, ok := q.Config().Limits[name]
+	if !ok {
+		return false, fmt.Errorf("quota %q not configured", name)
+	}
+	if n < 0 {
+		return false, fmt.Errorf("quota %q: negative units %d", name, n)
+	}
+	if n > limit.Limit {
+		return false, nil
+	}
+	batch := limit.Batch
+	if batch == 0 {
+		batch = max(1, limit.Limit/100)
+	}
+
+	// Try to serve the request from the cache.
+	k := quotaKey{name: name, key: key}
+	q.mu.Lock()
+	b := q.bucket(k)
+	if b.tokens >= n {
+		b.tokens -= n
+		if b.tokens < batch && !b.exhausted && !b.refilling {
+			b.refilling = true
+			go q.refill(k, limit, batch)
+		}
+		q.mu.Unlock()
+		return true, nil
+	}
+	if b.exhausted {
+		q.mu.Unlock()
+		return false, nil
+	}
+	q.mu.Unlock()
+
+	// Fetch enough units from the quota server for this request and a batch.
+	want := n + batch
+	granted, end, err := q.server.Get().Grant(ctx, name, key, limit.Limit, int64(q.periods[name]), want)
+	if err != nil {
+		return false, err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	b = q.bucket(k)
+	q.deposit(b, granted, want, end)
+	if b.tokens < n {
+		return false, nil
+	}
+	b.tokens -= n
+	return true, nil
+}
+
+// refill asynchronously fetches a batch of units for the provided key.
+func (q *quota) refill(k quotaKey, limit quotaLimit, batch int) {
+	ctx, cancel := context.WithTimeout(context.Background(), quotaRefillTimeout)
+	defer cancel()
+	granted, end, err := q.server.Get().Grant(ctx, k.name, k.key, limit.Limit, int64(q.periods[k.name]), batch)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	b := q.bucket(k)
+	b.refilling = false
+	if err != nil {
+		q.Logger(ctx).Error("quota refill", "quota", k.name, "err", err)
+		return
+	}
+	q.deposit(b, granted, batch, end)
+}
+
+// bucket returns the bucket for the provided key, discarding any units cached
+// for a period that has ended. REQUIRES: q.mu is held.
+func (q *quota) bucket(k quotaKey) *quotaBucket {
+	b, ok := q.buckets[k]
+	if !ok || time.Now().UnixNano() >= b.end {
+		b = &quotaBucket{}
+		q.buckets[k] = b
+	}
+	return b
+}
+
+// deposit adds granted units, out of the wanted units, for the period ending
+// at end to the provided bucket. REQUIRES: q.mu is held.
+func (q *quota) deposit(b *quotaBucket, granted, want int, end int64) {
+	switch {
+	case end < b.end:
+		// The units belong to a period that has already ended.
+		return
+	case end > b.end:
+		*b = quotaBucket{end: end, refilling: b.refilling}
+	}
+	b.tokens += granted
+	if granted < want {
+		b.exhausted = true
+	}
+}
+
+// quotaServer counts the usage of Quota limits. Calls are routed by limit name
+// and key, so that every replica of the Quota component consults the same
+// quotaServer replica for a given key.
+type quotaServer interface {
+	// Grant consumes up to n units of the provided limit for the provided
+	// key in the current period, which is aligned to a multiple of period
+	// nanoseconds. It returns the number of units granted and the end of the
+	// current period in unix nanos.
+	Grant(ctx context.Context, name, key string, limit int, period int64, n int) (int, int64, error)
+}
+
+// quotaSweepInterval is how often a quotaServer discards usage of ended periods.
+const quotaSweepInterval = time.Minute
+
+// quotaCounter is the implementation of the quotaServer component.
+type quotaCounter struct {
+	Implements[quotaServer]
+	WithRouter[quotaRouter]
+
+	mu    sync.Mutex
+	usage map[quotaKey]*quotaUsage
+	swept int64 // last time usage was swept, in unix nanos
+}
+
+// quotaUsage is the usage of a limit by a single key in a single period.
+type quotaUsage struct {
+	end  int64 // end of the period, in unix nanos
+	used int   // units granted in the period
+}
+
+var _ quotaServer = &quotaCounter{}
+
+// Init initializes the quotaServer component.
+func (c *quotaCounter) Init(context.Context) error {
+	c.usage = map[quotaKey]*quotaUsage{}
+	c.swept = time.Now().UnixNano()
+	return nil
+}
+
+// Grant implements the quotaServer interface.
+func (c *quotaCounter) Grant(_ context.Context, name, key string, limit int, period int64, n int) (int, int64, error) {
+	if period <= 0 {
+		return 0, 0, fmt.Errorf("quota %q: non-positive period %d", name, period)
+	}
+	now := time.Now().UnixNano()
+	end := now - now%period + period
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if now-c.swept >= int64(quotaSweepInterval) {
+		for k, u := range c.usage {
+			if u.end <= now {
+				delete(c.usage, k)
+			}
+		}
+		c.swept = now
+	}
+
+	k := quotaKey{name: name, key: key}
+	u, ok := c.usage[k]
+	if !ok || u.end != end {
+		u = &quotaUsage{end: end}
+		c.usage[k] = u
+	}
+	granted := max(0, min(n, limit-u.used))
+	u.used += granted
+	return granted, end, nil
+}
+
+// quotaRouter routes calls to the quotaServer component.
+type quotaRouter struct{}
+
+// Grant routes calls to quotaServer.Grant by limit name and key.
+func (quotaRouter) Grant(_ context.Context, name, key string, _ int, _ int64, _ int) string {
+	return name + "/" + key
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+)
+
+func TestQuota(t *testing.T) {
+	const config = `
+		["github.com/ServiceWeaver/weaver/Quota"]
+		limits = { requests = { limit = 10, period = "24h", batch = 3 } }
+	`
+
+	// We don't use the multiprocess runner because it replicates the quota
+	// server, and a key's calls may reach different replicas before routing
+	// information is available, making the limit approximate.
+	for _, runner := range []weavertest.Runner{weavertest.Local, weavertest.RPC} {
+		runner.Config = config
+		runner.Test(t, func(t *testing.T, quota weaver.Quota) {
+			ctx := context.Background()
+			acquire := func(key string, n int) bool {
+				t.Helper()
+				ok, err := quota.Acquire(ctx, "requests", key, n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return ok
+			}
+
+			// Consume the entire limit for key a.
+			for i := 0; i < 10; i++ {
+				if !acquire("a", 1) {
+					t.Fatalf("Acquire #%d: quota unexpectedly exceeded", i)
+				}
+			}
+			if acquire("a", 1) {
+				t.Fatal("Acquire: quota unexpectedly available")
+			}
+
+			// Key b has its own limit.
+			if !acquire("b", 7) {
+				t.Fatal("Acquire(7): quota unexpectedly exceeded")
+			}
+			if acquire("b", 4) {
+				t.Fatal("Acquire(4): quota unexpectedly available")
+			}
+			if acquire("b", 11) {
+				t.Fatal("Acquire(11): more than the limit unexpectedly available")
+			}
+
+			// Unknown limits are an error.
+			if _, err := quota.Acquire(ctx, "unknown", "a", 1); err == nil {
+				t.Fatal("Acquire: unexpected success for unknown limit")
+			}
+		})
+	}
+}
@@ -0,0 +1,503 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReplicationLog is a built-in component that replicates the state of
+// leader-replicated components. Every replica of a leader-replicated component
+// holds a copy of the component's state, which changes only by applying
+// writes. Writes are appended to a named log, which is led by a single
+// replica of the ReplicationLog component: the replica that the log's calls
+// are routed to. Every replica of the leader-replicated component
+// follows the log, applying its writes in order, so that reads can be served
+// by any replica.
+//
+// A leader-replicated component wraps its state in a [Replicated], which
+// ships writes to the log leader and applies the log locally. Writes can be
+// issued by any replica, and reads are served locally, by whichever replica
+// the read was balanced to, with bounded staleness:
+//
+//	type kv struct {
+//	    weaver.Implements[KV]
+//	    log   weaver.Ref[weaver.ReplicationLog]
+//	    state *kvState // implements weaver.ReplicatedState
+//	    r     *weaver.Replicated
+//	}
+//
+//	func (s *kv) Init(context.Context) error {
+//	    s.state = newKVState()
+//	    s.r = weaver.NewReplicated("kv", s.log.Get(), s.state)
+//	    return nil
+//	}
+//
+//	func (s *kv) Put(ctx context.Context, key, value string) error {
+//	    _, err := s.r.Write(ctx, encodePut(key, value))
+//	    return err
+//	}
+//
+//	func (s *kv) Get(ctx context.Context, key string) (value string, err error) {
+//	    // Serve the read from this replica if it synced with the leader in
+//	    // the last second.
+//	    err = s.r.Read(ctx, time.Second, func() error {
+//	        value = s.state.m[key]
+//	        return nil
+//	    })
+//	    return value, err
+//	}
+//
+// The log leader keeps the log in memory. When the leader changes, e.g.,
+// because its replica failed, the new leader starts a new epoch of the log.
+// Before it accepts writes, it waits briefly for checkpoints from the
+// followers and adopts the most recent one, so that writes applied by any
+// follower survive the failover. Followers that are behind the adopted
+// checkpoint restore it. Long logs are compacted the same way: the leader asks
+// its followers for a checkpoint and discards the writes the checkpoint
+// covers. Writes that no follower applied before the leader failed are lost.
+type ReplicationLog interface {
+	// Append appends a write to the named log. It returns the epoch of the
+	// log and the index of the write in the epoch.
+	Append(ctx context.Context, name string, entry ReplicationEntry) (string, uint64, error)
+
+	// Fetch returns the writes of the named log that follow the write at
+	// the provided index, for a follower that has applied the writes of the
+	// provided epoch up to that index.
+	Fetch(ctx context.Context, name, epoch string, index uint64) (ReplicationBatch, error)
+
+	// Checkpoint offers the log a snapshot of the state that results from
+	// applying the writes of the provided epoch up to the provided index.
+	Checkpoint(ctx context.Context, name, epoch string, index uint64, snapshot []byte) error
+}
+
+// Append is not idempotent: retrying it may apply a write twice.
+var _ NotRetriable = ReplicationLog.Append
+
+// ReplicationEntry is a write in a ReplicationLog.
+type ReplicationEntry struct {
+	AutoMarshal
+	ID uint64 // random ID that identifies the write to its writer
+	Op []byte // the write, as passed to ReplicatedState.Apply
+}
+
+// ReplicationBatch is a batch of writes fetched from a ReplicationLog.
+type ReplicationBatch struct {
+	AutoMarshal
+
+	// Epoch is the epoch of the log.
+	Epoch string
+
+	// Recovering is true if the log is waiting for checkpoints after a
+	// leader change. The batch holds no writes.
+	Recovering bool
+
+	// Reset is true if the follower must restore Snapshot, which covers the
+	// writes of Epoch up to Base, before applying Entries. A nil Snapshot is
+	// the initial state.
+	Reset    bool
+	Snapshot []byte
+	Base     uint64
+
+	// Entries are the writes that follow the fetched index, or Base if
+	// Reset is true.
+	Entries []ReplicationEntry
+
+	// Last is the index of the last write in the log.
+	Last uint64
+
+	// Compact is true if the log asks for a checkpoint, so that it can
+	// discard the writes the checkpoint covers.
+	Compact bool
+}
+
+const (
+	// replicationRecovery is how long a new log leader waits for
+	// checkpoints before it accepts writes.
+	replicationRecovery = time.Second
+
+	// replicationMaxBatch is the maximum number of writes fetched at once.
+	replicationMaxBatch = 1000
+
+	// replicationCompactAfter is the number of writes a log leader retains
+	// before it asks for a checkpoint.
+	replicationCompactAfter = 10000
+
+	// replicatedSyncInterval is how often a Replicated syncs with the log
+	// leader in the background.
+	replicatedSyncInterval = 100 * time.Millisecond
+
+	// replicatedRecoveryPoll is how often a Replicated polls a recovering log
+	// leader.
+	replicatedRecoveryPoll = 50 * time.Millisecond
+)
+
+// replicationLog is the implementation of the ReplicationLog component.
+type replicationLog struct {
+	Implements[ReplicationLog]
+	WithRouter[replicationRouter]
+
+	recovery time.Duration // how long new logs wait for checkpoints
+
+	mu   sync.Mutex
+	logs map[string]*replicaLog // by log name
+}
+
+// replicaLog is a log led by a replicationLog replica.
+type replicaLog struct {
+	epoch     string
+	recovered time.Time          // when the log stops waiting for checkpoints
+	base      uint64             // index of the last write covered by snapshot
+	snapshot  []byte             // snapshot of the state at base, or nil
+	entries   []ReplicationEntry // the writes that follow base
+}
+
+var _ ReplicationLog = &replicationLog{}
+
+// Init initializes the ReplicationLog component.
+func (l *replicationLog) Init(context.Context) error {
+	l.recovery = replicationRecovery
+	l.logs = map[string]*replicaLog{}
+	return nil
+}
+
+// log returns the named log, starting a new epoch if the log doesn't exist.
+// REQUIRES: l.mu is held.
+func (l *replicationLog) log(name string) *replicaLog {
+	r, ok := l.logs[name]
+	if !ok {
+		r = &replicaLog{epoch: uuid.NewString(), recovered: time.Now().Add(l.recovery)}
+		l.logs[name] = r
+	}
+	return r
+}
+
+// Append implements the ReplicationLog interface.
+func (l *replicationLog) Append(ctx context.Context, name string, entry ReplicationEntry) (string, uint64, error) {
+	for {
+		l.mu.Lock()
+		r := l.log(name)
+		wait := time.Until(r.recovered)
+		if wait <= 0 {
+			r.entries = append(r.entries, entry)
+			defer l.mu.Unlock()
+			return r.epoch, r.base + uint64(len(r.entries)), nil
+		}
+		l.mu.Unlock()
+
+		// Wait for the log to recover.
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return "", 0, ctx.Err()
+		}
+	}
+}
+
+// Fetch implements the ReplicationLog interface.
+func (l *replicationLog) Fetch(_ context.Context, name, epoch string, index uint64) (ReplicationBatch, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.log(name)
+	if time.Now().Before(r.recovered) {
+		return ReplicationBatch{Epoch: r.epoch, Recovering: true}, nil
+	}
+
+	last := r.base + uint64(len(r.entries))
+	b := ReplicationBatch{Epoch: r.epoch, Last: last, Compact: len(r.entries) > replicationCompactAfter}
+	fresh := epoch == "" && index == 0
+	if (epoch != r.epoch && !fresh) || index < r.base || index > last {
+		// The follower's state is from another epoch, or predates the
+		// writes the log retains.
+		b.Reset, b.Snapshot, b.Base = true, r.snapshot, r.base
+		index = r.base
+	}
+	entries := r.entries[index-r.base:]
+	if len(entries) > replicationMaxBatch {
+		entries = entries[:replicationMaxBatch]
+	}
+	b.Entries = append([]ReplicationEntry(nil), entries...)
+	return b, nil
+}
+
+// Checkpoint implements the ReplicationLog interface.
+func (l *replicationLog) Checkpoint(_ context.Context, name, epoch string, index uint64, snapshot []byte) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	r := l.log(name)
+	if time.Now().Before(r.recovered) {
+		// Adopt the most recent checkpoint of the previous leader's epoch.
+		// Writes are only accepted once the log recovers, so the log holds
+		// no writes yet.
+		if index > r.base {
+			r.base, r.snapshot = index, snapshot
+		}
+		return nil
+	}
+	if epoch != r.epoch || index <= r.base || index > r.base+uint64(len(r.entries)) {
+		// The checkpoint is stale.
+		return nil
+	}
+	r.entries = append([]ReplicationEntry(nil), r.entries[index-r.base:]...)
+	r.base, r.snapshot = index, snapshot
+	return nil
+}
+
+// replicationRouter routes calls to the ReplicationLog component.
+type replicationRouter struct{}
+
+// Append routes calls to ReplicationLog.Append by log name.
+func (replicationRouter) Append(_ context.Context, name string, _ ReplicationEntry) string {
+	return name
+}
+
+// Fetch routes calls to ReplicationLog.Fetch by log name.
+func (replicationRouter) Fetch(_ context.Context, name, _ string, _ uint64) string {
+	return name
+}
+
+// Checkpoint routes calls to ReplicationLog.Checkpoint by log name.
+func (replicationRouter) Checkpoint(_ context.Context, name, _ string, _ uint64, _ []byte) string {
+	return name
+}
+
+// ReplicatedState is the state of a leader-replicated component. It must be
+// deterministic: applying the same writes to the same state must produce the
+// same state on every replica.
+type ReplicatedState interface {
+	// Apply applies a write to the state and returns the write's result.
+	Apply(op []byte) ([]byte, error)
+
+	// Snapshot returns a snapshot of the state.
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the state with a snapshot returned by Snapshot, or
+	// with the initial state if the snapshot is nil.
+	Restore(snapshot []byte) error
+}
+
+// Replicated replicates a ReplicatedState across the replicas of a
+// component by following a log of a ReplicationLog. See ReplicationLog for
+// details. A Replicated is safe for concurrent use.
+type Replicated struct {
+	name  string
+	log   ReplicationLog
+	state ReplicatedState
+
+	syncMu  sync.Mutex // serializes syncs with the log leader
+	offered string     // epoch of the last recovery checkpoint offered
+
+	mu      sync.RWMutex // guards state and the following
+	epoch   string       // epoch of the log the state follows
+	applied uint64       // index of the last write applied to the state
+	synced  time.Time    // start of the last sync that caught up with the leader
+	waiters map[uint64]chan replicatedResult
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// replicatedResult is the result of applying a write.
+type replicatedResult struct {
+	result []byte
+	err    error
+}
+
+// NewReplicated returns a Replicated that replicates the provided state by
+// following the named log. The Replicated syncs with the log leader in the
+// background until it is closed.
+func NewReplicated(name string, log ReplicationLog, state ReplicatedState) *Replicated {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Replicated{
+		name:    name,
+		log:     log,
+		state:   state,
+		waiters: map[uint64]chan replicatedResult{},
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	go r.syncPeriodically(ctx)
+	return r
+}
+
+// Close stops syncing with the log leader in the background.
+func (r *Replicated) Close() {
+	r.cancel()
+	<-r.done
+}
+
+// syncPeriodically syncs with the log leader until ctx is canceled.
+func (r *Replicated) syncPeriodically(ctx context.Context) {
+	defer close(r.done)
+	ticker := time.NewTicker(replicatedSyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			// Errors are returned by the next Read or Write that syncs.
+			r.sync(ctx) //nolint:errcheck
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Write applies a write to the replicated state. The write is appended to the
+// log by its leader and applied by every replica. Write returns once the
+// write is applied locally, with the result of applying it.
+func (r *Replicated) Write(ctx context.Context, op []byte) ([]byte, error) {
+	id := rand.Uint64()
+	ch := make(chan replicatedResult, 1)
+	r.mu.Lock()
+	r.waiters[id] = ch
+	r.mu.Unlock()
+	defer func() {
+		r.mu.Lock()
+		delete(r.waiters, id)
+		r.mu.Unlock()
+	}()
+
+	epoch, index, err := r.log.Append(ctx, r.name, ReplicationEntry{ID: id, Op: op})
+	if err != nil {
+		return nil, err
+	}
+	if err := r.sync(ctx); err != nil {
+		return nil, err
+	}
+	select {
+	case res := <-ch:
+		return res.result, res.err
+	default:
+		// The write was covered by a snapshot before it was applied
+		// locally, or lost in a leader failover.
+		return nil, fmt.Errorf("weaver.Replicated %q: result of write %d of epoch %s unavailable", r.name, index, epoch)
+	}
+}
+
+// Read calls fn, which may read the replicated state but not modify it, once
+// the state reflects every write applied by the log leader at most
+// maxStaleness ago. Writes are not applied while fn runs.
+func (r *Replicated) Read(ctx context.Context, maxStaleness time.Duration, fn func() error) error {
+	r.mu.RLock()
+	fresh := time.Since(r.synced) <= maxStaleness
+	r.mu.RUnlock()
+	if !fresh {
+		if err := r.sync(ctx); err != nil {
+			return err
+		}
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return fn()
+}
+
+// sync applies the writes of the log until the state has caught up with the
+// log leader.
+func (r *Replicated) sync(ctx context.Context) error {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+	for {
+		start := time.Now()
+		r.mu.RLock()
+		epoch, applied := r.epoch, r.applied
+		r.mu.RUnlock()
+		b, err := r.log.Fetch(ctx, r.name, epoch, applied)
+		if err != nil {
+			return err
+		}
+
+		if b.Recovering {
+			// The log has a new leader. Offer it a checkpoint, and wait for
+			// it to recover.
+			if applied > 0 && r.offered != b.Epoch {
+				if err := r.checkpoint(ctx, epoch, applied); err != nil {
+					return err
+				}
+				r.offered = b.Epoch
+			}
+			timer := time.NewTimer(replicatedRecoveryPoll)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			continue
+		}
+
+		if err := r.apply(b); err != nil {
+			return err
+		}
+		r.mu.Lock()
+		caughtUp := r.applied >= b.Last
+		if caughtUp {
+			r.synced = start
+		}
+		epoch, applied = r.epoch, r.applied
+		r.mu.Unlock()
+		if caughtUp {
+			if b.Compact {
+				return r.checkpoint(ctx, epoch, applied)
+			}
+			return nil
+		}
+	}
+}
+
+// apply applies a batch of writes to the state.
+func (r *Replicated) apply(b ReplicationBatch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if b.Reset {
+		if err := r.state.Restore(b.Snapshot); err != nil {
+			return fmt.Errorf("weaver.Replicated %q: restore: %w", r.name, err)
+		}
+		r.applied = b.Base
+	}
+	r.epoch = b.Epoch
+	for _, e := range b.Entries {
+		result, err := r.state.Apply(e.Op)
+		r.applied++
+		if ch, ok := r.waiters[e.ID]; ok {
+			ch <- replicatedResult{result: result, err: err}
+		}
+	}
+	return nil
+}
+
+// checkpoint offers the log leader a snapshot of the state, which reflects
+// the writes of the provided epoch up to the provided index.
+func (r *Replicated) checkpoint(ctx context.Context, epoch string, index uint64) error {
+	r.mu.RLock()
+	if r.epoch != epoch || r.applied != index {
+		// The state changed since the caller read the epoch and index.
+		r.mu.RUnlock()
+		return nil
+	}
+	snapshot, err := r.state.Snapshot()
+	r.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("weaver.Replicated %q: snapshot: %w", r.name, err)
+	}
+	return r.log.Checkpoint(ctx, r.name, epoch, index, snapshot)
+}
@@ -0,0 +1,201 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// counter is a ReplicatedState that sums the integers written to it.
+type counter struct {
+	n int
+}
+
+var _ ReplicatedState = &counter{}
+
+func (c *counter) Apply(op []byte) ([]byte, error) {
+	delta, err := strconv.Atoi(string(op))
+	if err != nil {
+		return nil, err
+	}
+	c.n += delta
+	return []byte(strconv.Itoa(c.n)), nil
+}
+
+func (c *counter) Snapshot() ([]byte, error) {
+	return []byte(strconv.Itoa(c.n)), nil
+}
+
+func (c *counter) Restore(snapshot []byte) error {
+	if snapshot == nil {
+		c.n = 0
+		return nil
+	}
+	n, err := strconv.Atoi(string(snapshot))
+	c.n = n
+	return err
+}
+
+// switchableLog is a ReplicationLog whose leader can be replaced, simulating
+// a leader failover.
+type switchableLog struct {
+	mu     sync.Mutex
+	leader *replicationLog
+}
+
+func newLeader(t *testing.T, recovery time.Duration) *replicationLog {
+	t.Helper()
+	l := &replicationLog{}
+	if err := l.Init(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	l.recovery = recovery
+	return l
+}
+
+func (s *switchableLog) get() *replicationLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.leader
+}
+
+func (s *switchableLog) Append(ctx context.Context, name string, entry ReplicationEntry) (string, uint64, error) {
+	return s.get().Append(ctx, name, entry)
+}
+
+func (s *switchableLog) Fetch(ctx context.Context, name, epoch string, index uint64) (ReplicationBatch, error) {
+	return s.get().Fetch(ctx, name, epoch, index)
+}
+
+func (s *switchableLog) Checkpoint(ctx context.Context, name, epoch string, index uint64, snapshot []byte) error {
+	return s.get().Checkpoint(ctx, name, epoch, index, snapshot)
+}
+
+func write(t *testing.T, r *Replicated, delta int) int {
+	t.Helper()
+	result, err := r.Write(context.Background(), []byte(strconv.Itoa(delta)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := strconv.Atoi(string(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func read(t *testing.T, r *Replicated, c *counter, maxStaleness time.Duration) int {
+	t.Helper()
+	var n int
+	if err := r.Read(context.Background(), maxStaleness, func() error {
+		n = c.n
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestReplicatedReadsFollowWrites(t *testing.T) {
+	log := &switchableLog{leader: newLeader(t, 0)}
+	a, b := &counter{}, &counter{}
+	ra, rb := NewReplicated("counter", log, a), NewReplicated("counter", log, b)
+	defer ra.Close()
+	defer rb.Close()
+
+	if got, want := write(t, ra, 2), 2; got != want {
+		t.Fatalf("Write: got %d, want %d", got, want)
+	}
+	if got, want := write(t, rb, 3), 5; got != want {
+		t.Fatalf("Write: got %d, want %d", got, want)
+	}
+
+	// A write is visible to reads on the replica that wrote it.
+	if got, want := read(t, rb, b, time.Hour), 5; got != want {
+		t.Fatalf("Read: got %d, want %d", got, want)
+	}
+	// A read with no staleness allowed syncs with the leader first.
+	if got, want := read(t, ra, a, 0), 5; got != want {
+		t.Fatalf("Read: got %d, want %d", got, want)
+	}
+}
+
+func TestReplicatedLeaderFailover(t *testing.T) {
+	log := &switchableLog{leader: newLeader(t, 0)}
+	a, b := &counter{}, &counter{}
+	ra, rb := NewReplicated("counter", log, a), NewReplicated("counter", log, b)
+	defer ra.Close()
+	defer rb.Close()
+	write(t, ra, 2)
+	write(t, ra, 3)
+
+	// Replace the leader. The new leader recovers the state from the
+	// followers' checkpoints before it accepts writes.
+	log.mu.Lock()
+	log.leader = newLeader(t, 500*time.Millisecond)
+	log.mu.Unlock()
+	if got, want := write(t, rb, 4), 9; got != want {
+		t.Fatalf("Write: got %d, want %d", got, want)
+	}
+	if got, want := read(t, ra, a, 0), 9; got != want {
+		t.Fatalf("Read: got %d, want %d", got, want)
+	}
+
+	// A replica that joins after the failover restores the checkpoint.
+	c := &counter{}
+	rc := NewReplicated("counter", log, c)
+	defer rc.Close()
+	if got, want := read(t, rc, c, 0), 9; got != want {
+		t.Fatalf("Read: got %d, want %d", got, want)
+	}
+}
+
+func TestReplicatedCompaction(t *testing.T) {
+	leader := newLeader(t, 0)
+	log := &switchableLog{leader: leader}
+	a := &counter{}
+	ra := NewReplicated("counter", log, a)
+	defer ra.Close()
+
+	ctx := context.Background()
+	for i := 0; i < replicationCompactAfter+1; i++ {
+		if _, _, err := leader.Append(ctx, "counter", ReplicationEntry{Op: []byte("1")}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Catching up with a long log checkpoints the state, and the leader
+	// discards the writes the checkpoint covers.
+	if got, want := read(t, ra, a, 0), replicationCompactAfter+1; got != want {
+		t.Fatalf("Read: got %d, want %d", got, want)
+	}
+	leader.mu.Lock()
+	retained := len(leader.logs["counter"].entries)
+	leader.mu.Unlock()
+	if retained != 0 {
+		t.Fatalf("leader retains %d writes, want 0", retained)
+	}
+
+	// A new replica restores the checkpoint.
+	b := &counter{}
+	rb := NewReplicated("counter", log, b)
+	defer rb.Close()
+	if got, want := write(t, rb, 1), replicationCompactAfter+2; got != want {
+		t.Fatalf("Write: got %d, want %d", got, want)
+	}
+}
@@ -45,7 +45,7 @@ func init() {
 	// the value of version.DeployerVersion. If the string is not a
 	// constant---if we try to use fmt.Sprintf, for example---it will not be
 	// embedded in a Service Weaver binary.
-	versionData = "⟦wEaVeRvErSiOn:deployer=v0.24.0⟧"
+	versionData = "⟦wEaVeRvErSiOn:deployer=v0.27.0⟧"
 }
 
 // rodata returns the read-only data section of the provided binary.
@@ -210,3 +210,13 @@ func extractDeployerVersion(data []byte) (version.SemVer, error) {
 	}
 	return v, nil
 }
+
+// ReadMethodCalls reads the component method calls found by "weaver generate"
+// in the specified binary.
+func ReadMethodCalls(file string) ([]codegen.MethodCall, error) {
+	data, err := rodata(file)
+	if err != nil {
+		return nil, err
+	}
+	return codegen.ExtractCalls(data), nil
+}
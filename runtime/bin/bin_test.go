@@ -55,7 +55,14 @@ func TestReadComponentGraph(t *testing.T) {
 			pkg := "github.com/ServiceWeaver/weaver/runtime/bin/testprogram"
 			main := "github.com/ServiceWeaver/weaver/Main"
 			wantComponents := []string{
+				// The built-in Cache, Quota, and ReplicatedStateMachine
+				// components are linked into every binary.
+				"github.com/ServiceWeaver/weaver/Cache",
 				main,
+				"github.com/ServiceWeaver/weaver/Quota",
+				"github.com/ServiceWeaver/weaver/ReplicatedStateMachine",
+				"github.com/ServiceWeaver/weaver/cacheShard",
+				"github.com/ServiceWeaver/weaver/quotaServer",
 				fmt.Sprintf("%s/A", pkg),
 				fmt.Sprintf("%s/B", pkg),
 				fmt.Sprintf("%s/C", pkg),
@@ -70,7 +77,7 @@ func TestReadComponentGraph(t *testing.T) {
 				nodes = append(nodes, n)
 			})
 			slices.Sort(nodes)
-			if diff := cmp.Diff([]graph.Node{0, 1, 2, 3}, nodes); diff != "" {
+			if diff := cmp.Diff([]graph.Node{0, 1, 2, 3, 4, 5, 6, 7, 8}, nodes); diff != "" {
 				t.Fatalf("unexpected nodes: (-want +got): %s", diff)
 			}
 
@@ -87,9 +94,12 @@ func TestReadComponentGraph(t *testing.T) {
 				return x.Src < y.Src
 			})
 			want := []graph.Edge{
-				{Src: 0, Dst: 1},
-				{Src: 1, Dst: 2},
-				{Src: 1, Dst: 3}}
+				{Src: 0, Dst: 4},
+				{Src: 1, Dst: 6},
+				{Src: 2, Dst: 5},
+				{Src: 3, Dst: 3}, // replicas of a state machine call each other
+				{Src: 6, Dst: 7},
+				{Src: 6, Dst: 8}}
 			if diff := cmp.Diff(want, edges); diff != "" {
 				t.Fatalf("unexpected edges: (-want +got): %s", diff)
 			}
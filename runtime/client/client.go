@@ -0,0 +1,150 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package client lets Go programs that are not part of a Service Weaver
+// application call the components of a deployed application over the Service
+// Weaver wire protocol.
+//
+// Most programs use the typed clients emitted by "weaver generate -client-sdk"
+// rather than using this package directly.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/ServiceWeaver/weaver/internal/net/call"
+	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"go.opentelemetry.io/otel"
+)
+
+// readyMethodName is the name of the method weavelets serve to report that a
+// component is ready to receive traffic.
+const readyMethodName = "ready"
+
+// Options configures a connection to a deployed application.
+type Options struct {
+	// Addresses of the weavelets hosting the components to call (e.g.,
+	// "tcp://10.0.0.1:9000" or "mtls://10.0.0.1:9000"). Calls are balanced
+	// across the addresses.
+	Addresses []string
+
+	// TLSConfig is used to connect to "mtls://" addresses.
+	TLSConfig *tls.Config
+
+	// Caller is reported to components as the name of the calling component
+	// (see weaver.CallerIdentity). Defaults to "client".
+	Caller string
+
+	// Logger. Defaults to a logger that logs to stderr.
+	Logger *slog.Logger
+}
+
+// Conn is a connection to a deployed application.
+type Conn struct {
+	conn   call.Connection
+	caller string
+}
+
+// Dial returns a connection to the weavelets at the provided addresses.
+func Dial(ctx context.Context, opts Options) (*Conn, error) {
+	if len(opts.Addresses) == 0 {
+		return nil, errors.New("client.Dial: no addresses")
+	}
+	var endpoints []call.Endpoint
+	for _, addr := range opts.Addresses {
+		const mtlsPrefix = "mtls://"
+		ep, err := call.ParseNetEndpoint(strings.TrimPrefix(addr, mtlsPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(addr, mtlsPrefix) {
+			endpoints = append(endpoints, ep)
+			continue
+		}
+		if opts.TLSConfig == nil {
+			return nil, fmt.Errorf("client.Dial: %q requires a TLS config", addr)
+		}
+		endpoints = append(endpoints, call.MTLS(opts.TLSConfig, ep))
+	}
+	conn, err := call.Connect(ctx, call.NewConstantResolver(endpoints...), call.ClientOptions{Logger: opts.Logger})
+	if err != nil {
+		return nil, err
+	}
+	caller := opts.Caller
+	if caller == "" {
+		caller = "client"
+	}
+	return &Conn{conn: conn, caller: caller}, nil
+}
+
+// Close closes the connection. Pending calls are cancelled.
+func (c *Conn) Close() {
+	c.conn.Close()
+}
+
+// Get returns a client for the component with interface type T, blocking
+// until the component is ready to receive traffic. The package that defines T
+// must be linked into the program, so that T's generated stubs are registered.
+func Get[T any](ctx context.Context, c *Conn) (T, error) {
+	var zero T
+	name := reflection.ComponentName[T]()
+	reg, ok := codegen.Find(name)
+	if !ok {
+		return zero, fmt.Errorf("component %s not found; did you run weaver generate?", name)
+	}
+	if err := waitUntilReady(ctx, c.conn, name); err != nil {
+		return zero, fmt.Errorf("component %s not ready: %w", name, err)
+	}
+	tracer := otel.GetTracerProvider().Tracer("github.com/ServiceWeaver/weaver/serviceweaver")
+	stub := callerStub{
+		Stub:   call.NewStub(name, reg, c.conn, tracer, 0, nil),
+		caller: codegen.Caller{Component: c.caller},
+	}
+	client, ok := reg.ClientStubFn(stub, c.caller).(T)
+	if !ok {
+		return zero, fmt.Errorf("client stub for %s does not implement %v", name, reflection.Type[T]())
+	}
+	return client, nil
+}
+
+// callerStub is a codegen.Stub that records the client as the caller of every
+// call.
+type callerStub struct {
+	codegen.Stub
+	caller codegen.Caller
+}
+
+// Run implements the codegen.Stub interface.
+func (s callerStub) Run(ctx context.Context, method int, args []byte, shardKey uint64) ([]byte, error) {
+	return s.Stub.Run(codegen.WithCaller(ctx, s.caller), method, args, shardKey)
+}
+
+// waitUntilReady blocks until a call to the "ready" method of the provided
+// component succeeds.
+func waitUntilReady(ctx context.Context, conn call.Connection, name string) error {
+	for r := retry.Begin(); r.Continue(ctx); {
+		_, err := conn.Call(ctx, call.MakeMethodKey(name, readyMethodName), nil, call.CallOptions{})
+		if err == nil || !errors.Is(err, call.Unreachable) {
+			return err
+		}
+	}
+	return ctx.Err()
+}
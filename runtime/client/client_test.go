@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/internal/net/call"
+	"github.com/ServiceWeaver/weaver/runtime/client"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// fakeQuota is a fake implementation of the weaver.Quota component that
+// records the callers of Acquire.
+type fakeQuota struct {
+	calls chan codegen.Caller
+}
+
+func (q *fakeQuota) Acquire(ctx context.Context, name, key string, n int) (bool, error) {
+	caller, _ := codegen.CallerFromContext(ctx)
+	q.calls <- caller
+	return n <= 1, nil
+}
+
+// listener is a call.Listener that serves a fixed set of handlers.
+type listener struct {
+	net.Listener
+	hm *call.HandlerMap
+}
+
+func (l listener) Accept() (net.Conn, *call.HandlerMap, error) {
+	conn, err := l.Listener.Accept()
+	return conn, l.hm, err
+}
+
+func TestGet(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve weaver.Quota, as a weavelet would.
+	const name = "github.com/ServiceWeaver/weaver/Quota"
+	reg, ok := codegen.Find(name)
+	if !ok {
+		t.Fatalf("component %s not registered", name)
+	}
+	quota := &fakeQuota{calls: make(chan codegen.Caller, 1)}
+	server := reg.ServerStubFn(quota, func(uint64, float64) {})
+	hm := call.NewHandlerMap()
+	hm.Set(name, "Acquire", server.GetStubFn("Acquire"))
+	hm.Set(name, "ready", func(context.Context, []byte) ([]byte, error) { return nil, nil })
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go call.Serve(ctx, listener{lis, hm}, call.ServerOptions{})
+
+	// Call weaver.Quota with a client.
+	conn, err := client.Dial(ctx, client.Options{
+		Addresses: []string{"tcp://" + lis.Addr().String()},
+		Caller:    "tool",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c, err := client.Get[weaver.Quota](ctx, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err = c.Acquire(ctx, "requests", "key", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("Acquire: got false, want true")
+	}
+	if got, want := <-quota.calls, (codegen.Caller{Component: "tool"}); got != want {
+		t.Fatalf("caller: got %v, want %v", got, want)
+	}
+}
+
+func TestDialNoAddresses(t *testing.T) {
+	if _, err := client.Dial(context.Background(), client.Options{}); err == nil {
+		t.Fatal("Dial: unexpected success")
+	}
+}
@@ -20,13 +20,31 @@ import (
 	"sync"
 )
 
-// AutoMarshal is the interface implemented by structs with weaver.AutoMarshal
-// declarations.
-type AutoMarshal interface {
+// Marshaler is the interface implemented by types that serialize themselves
+// into an Encoder.
+//
+// A type T whose pointer type *T implements both Marshaler and Unmarshaler is
+// serializable, even if T is declared in another package. This allows
+// libraries to make their types usable in component method signatures without
+// weaver.AutoMarshal. WeaverUnmarshal must decode exactly the bytes encoded by
+// WeaverMarshal.
+type Marshaler interface {
 	WeaverMarshal(enc *Encoder)
+}
+
+// Unmarshaler is the interface implemented by types that deserialize
+// themselves from a Decoder. See Marshaler.
+type Unmarshaler interface {
 	WeaverUnmarshal(dec *Decoder)
 }
 
+// AutoMarshal is the interface implemented by structs with weaver.AutoMarshal
+// declarations and by other types with custom marshalers.
+type AutoMarshal interface {
+	Marshaler
+	Unmarshaler
+}
+
 // Table of registered serialized types.
 var (
 	typesMu  sync.Mutex
@@ -41,14 +59,38 @@ var (
 // error structs that embed weaver.AutoMarshal.
 func RegisterSerializable[T AutoMarshal]() {
 	var value T
-	t := reflect.TypeOf(value)
+	registerType(reflect.TypeOf(value))
+}
+
+// RegisterConcrete records type T as a concrete type that may be stored in an
+// interface-typed method argument, method result, or field. Values of type T
+// and *T stored in an interface are encoded with a tag identifying their type,
+// and decoded as values of the same type. *T must implement AutoMarshal, e.g.,
+// T may be a struct that embeds weaver.AutoMarshal. Calls to RegisterConcrete
+// typically appear in init functions:
+//
+//	func init() {
+//	    codegen.RegisterConcrete[Circle]()
+//	    codegen.RegisterConcrete[Square]()
+//	}
+func RegisterConcrete[T any, _ interface {
+	*T
+	AutoMarshal
+}]() {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	registerType(t)
+	registerType(reflect.PointerTo(t))
+}
+
+// registerType records t as serializable.
+func registerType(t reflect.Type) {
 	typesMu.Lock()
 	defer typesMu.Unlock()
 	if types == nil {
 		types = map[string]reflect.Type{}
 		typeKeys = map[reflect.Type]string{}
 	}
-	key := typeKey(value)
+	key := typeKeyOf(t)
 	if existing, ok := types[key]; ok {
 		if existing == t {
 			return
@@ -62,8 +104,11 @@ func RegisterSerializable[T AutoMarshal]() {
 // typeKey returns the key to use to identify the type of value.
 // The returned key is stable across processes.
 func typeKey(value any) string {
-	t := reflect.TypeOf(value)
+	return typeKeyOf(reflect.TypeOf(value))
+}
 
+// typeKeyOf returns the key to use to identify type t.
+func typeKeyOf(t reflect.Type) string {
 	// Embed the package path into the result since the short package name
 	// may not be unique. Note: if the registered type is a pointer, it
 	// will have an empty PkgPath, so we use the package from the pointed
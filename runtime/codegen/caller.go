@@ -0,0 +1,39 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import "context"
+
+// Caller identifies the caller of a component method.
+type Caller struct {
+	Component string // full name of the calling component
+	Version   string // deployment id of the caller, or "" for local calls
+}
+
+// callerKey is the context key for a Caller.
+type callerKey struct{}
+
+// WithCaller returns a context that records c as the caller of a component
+// method. Generated local stubs and the RPC transport call WithCaller before
+// invoking a component method.
+func WithCaller(ctx context.Context, c Caller) context.Context {
+	return context.WithValue(ctx, callerKey{}, c)
+}
+
+// CallerFromContext returns the caller recorded in ctx by WithCaller, if any.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	c, ok := ctx.Value(callerKey{}).(Caller)
+	return c, ok
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Component method calls found by "weaver generate" are embedded in the
+// generated binary as specially formatted strings, like component graph edges
+// (see MakeEdgeString) and listeners (see MakeListenersString).
+//
+// Each call is represented by a string fragment that looks like:
+// ⟦checksum:wEaVeRcAlL:caller.method→callee.method@position⟧
+//
+// checksum is the first 8 bytes of the hex encoding of the SHA-256 of the
+// string "wEaVeRcAlL:caller.method→callee.method@position"; caller and callee
+// are fully qualified component type names; and position is the position of
+// the call in the caller's package, e.g., "server.go:42:9".
+
+// MethodCall is a statically known call from a method of one component to a
+// method of another, found by "weaver generate".
+type MethodCall struct {
+	// Fully qualified type name of the calling component, e.g.,
+	// github.com/ServiceWeaver/weaver/Main.
+	Caller string
+
+	// The method of the calling component's implementation that contains the
+	// call. This may be an unexported helper method.
+	CallerMethod string
+
+	// Fully qualified type name of the called component.
+	Callee string
+
+	// The called method.
+	CalleeMethod string
+
+	// The position of the call, as "file:line:column", where file is relative
+	// to the directory of the caller's package.
+	Position string
+}
+
+func (c MethodCall) String() string {
+	return fmt.Sprintf("%s.%s→%s.%s@%s", c.Caller, c.CallerMethod, c.Callee, c.CalleeMethod, c.Position)
+}
+
+// MakeCallString returns a string that should be emitted into generated code
+// to represent the provided call.
+func MakeCallString(c MethodCall) string {
+	return fmt.Sprintf("⟦%s:wEaVeRcAlL:%s⟧\n", checksumCall(c.String()), c)
+}
+
+// ExtractCalls returns the calls encoded using MakeCallString() in data,
+// sorted by caller, callee, and position.
+func ExtractCalls(data []byte) []MethodCall {
+	var results []MethodCall
+	re := regexp.MustCompile(`⟦([0-9a-fA-F]+):wEaVeRcAlL:([a-zA-Z0-9\-.~_/]*?)\.([\p{L}\p{Nd}_]+)→([a-zA-Z0-9\-.~_/]*?)\.([\p{L}\p{Nd}_]+)@([a-zA-Z0-9\-.~_/:]+)⟧`)
+	for _, m := range re.FindAllSubmatch(data, -1) {
+		if len(m) != 7 {
+			continue
+		}
+		c := MethodCall{
+			Caller:       string(m[2]),
+			CallerMethod: string(m[3]),
+			Callee:       string(m[4]),
+			CalleeMethod: string(m[5]),
+			Position:     string(m[6]),
+		}
+		if string(m[1]) != checksumCall(c.String()) {
+			continue
+		}
+		results = append(results, c)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		if a.Callee != b.Callee {
+			return a.Callee < b.Callee
+		}
+		if a.Position != b.Position {
+			return lessPosition(a.Position, b.Position)
+		}
+		return a.String() < b.String()
+	})
+	return results
+}
+
+// lessPosition returns whether the "file:line:column" position a comes before
+// position b.
+func lessPosition(a, b string) bool {
+	fa, la, ca := splitPosition(a)
+	fb, lb, cb := splitPosition(b)
+	if fa != fb {
+		return fa < fb
+	}
+	if la != lb {
+		return la < lb
+	}
+	return ca < cb
+}
+
+// splitPosition splits a "file:line:column" position into its parts.
+func splitPosition(pos string) (string, int, int) {
+	file, rest, _ := strings.Cut(pos, ":")
+	line, column, _ := strings.Cut(rest, ":")
+	l, _ := strconv.Atoi(line)
+	c, _ := strconv.Atoi(column)
+	return file, l, c
+}
+
+func checksumCall(call string) string {
+	str := "wEaVeRcAlL:" + call
+	sum := sha256.Sum256([]byte(str))
+	return fmt.Sprintf("%0x", sum)[:8]
+}
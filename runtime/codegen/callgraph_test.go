@@ -0,0 +1,38 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+func TestMethodCalls(t *testing.T) {
+	b2c := codegen.MethodCall{Caller: "x/b", CallerMethod: "Get", Callee: "x/c", CalleeMethod: "Put", Position: "b.go:3:1"}
+	a2b10 := codegen.MethodCall{Caller: "x/a", CallerMethod: "helper", Callee: "x/b", CalleeMethod: "Get", Position: "a.go:10:2"}
+	a2b9 := codegen.MethodCall{Caller: "x/a", CallerMethod: "Run", Callee: "x/b", CalleeMethod: "Get", Position: "a.go:9:14"}
+	corrupt := strings.Replace(codegen.MakeCallString(a2b9), "Run", "Walk", 1)
+	data := codegen.MakeCallString(b2c) + codegen.MakeCallString(a2b10) + corrupt + codegen.MakeCallString(a2b9)
+	t.Log(data)
+
+	got := codegen.ExtractCalls([]byte(data))
+	want := []codegen.MethodCall{a2b9, a2b10, b2c}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("ExtractCalls: expecting %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"encoding"
+	"reflect"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	protoMessageType    = reflect.TypeOf((*proto.Message)(nil)).Elem()
+	autoMarshalType     = reflect.TypeOf((*AutoMarshal)(nil)).Elem()
+	binaryMarshalerType = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+)
+
+// EncodeValue encodes an arbitrary value into enc, using reflection. Values
+// are encoded like generated code encodes them: protobufs, AutoMarshal types,
+// and encoding.BinaryMarshalers with their marshalers, maps with their entries
+// sorted by key (see CanonicalHash), and structs field by field, including
+// unexported fields. The values of unexported fields are always encoded field
+// by field, since their marshalers can't be called. Unlike generated code,
+// EncodeValue prefixes the values of interfaces, including value itself, with
+// their dynamic type, so that, for example, int32(1) and int64(1) encode
+// differently.
+//
+// The encoding is meant to be hashed, e.g., with CanonicalHash, and can't be
+// decoded. EncodeValue returns an error if value contains a channel, a
+// function, or a cycle.
+func EncodeValue(enc *Encoder, value any) (err error) {
+	defer func() { err = CatchPanics(recover()) }()
+	e := valueEncoder{enc: enc, visiting: map[visit]bool{}}
+	e.encode(reflect.ValueOf(&value).Elem())
+	return nil
+}
+
+// valueEncoder encodes values using reflection. See EncodeValue.
+type valueEncoder struct {
+	enc      *Encoder
+	visiting map[visit]bool // pointers and maps being encoded
+}
+
+// visit is a pointer or map being encoded.
+type visit struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// encode encodes v.
+func (e *valueEncoder) encode(v reflect.Value) {
+	t := v.Type()
+	if v.CanInterface() && t.Kind() != reflect.Interface {
+		switch {
+		case t.Kind() == reflect.Pointer && hasMarshaler(t):
+			if v.IsNil() {
+				e.enc.Bool(false)
+				return
+			}
+			e.enc.Bool(true)
+			e.marshal(v)
+			return
+		case t.Kind() != reflect.Pointer && (hasMarshaler(t) || hasMarshaler(reflect.PointerTo(t))):
+			// Marshal a pointer to a copy of v, since marshalers often have
+			// pointer receivers.
+			p := reflect.New(t)
+			p.Elem().Set(v)
+			e.marshal(p)
+			return
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		e.enc.Bool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.enc.Int64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.enc.Uint64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		e.enc.Float64(v.Float())
+	case reflect.Complex64, reflect.Complex128:
+		e.enc.Complex128(v.Complex())
+	case reflect.String:
+		e.enc.String(v.String())
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			e.encode(v.Index(i))
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			e.enc.Len(-1)
+			return
+		}
+		e.enc.Len(v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e.encode(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			e.enc.Len(-1)
+			return
+		}
+		defer e.enter(v)()
+		e.enc.Len(v.Len())
+		m := e.enc.Map()
+		for it := v.MapRange(); it.Next(); {
+			m.Key()
+			e.encode(it.Key())
+			m.Value()
+			e.encode(it.Value())
+		}
+		m.End()
+	case reflect.Pointer:
+		if v.IsNil() {
+			e.enc.Bool(false)
+			return
+		}
+		defer e.enter(v)()
+		e.enc.Bool(true)
+		e.encode(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			e.encode(v.Field(i))
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			e.enc.String("")
+			return
+		}
+		e.enc.String(v.Elem().Type().String())
+		e.encode(v.Elem())
+	default:
+		panic(makeEncodeError("cannot encode value of type %v", t))
+	}
+}
+
+// hasMarshaler returns whether values of type t encode themselves.
+func hasMarshaler(t reflect.Type) bool {
+	return t.Implements(protoMessageType) || t.Implements(autoMarshalType) || t.Implements(binaryMarshalerType)
+}
+
+// marshal encodes v with its marshaler.
+//
+// REQUIRES: v is a non-nil pointer and hasMarshaler(v.Type()).
+func (e *valueEncoder) marshal(v reflect.Value) {
+	switch x := v.Interface().(type) {
+	case proto.Message:
+		e.enc.EncodeProto(x)
+	case AutoMarshal:
+		x.WeaverMarshal(e.enc)
+	case encoding.BinaryMarshaler:
+		e.enc.EncodeBinaryMarshaler(x)
+	}
+}
+
+// enter records that the provided pointer or map is being encoded, and
+// returns a function that records that it no longer is. enter panics if v is
+// already being encoded, i.e., if v contains itself.
+func (e *valueEncoder) enter(v reflect.Value) func() {
+	k := visit{ptr: v.Pointer(), typ: v.Type()}
+	if e.visiting[k] {
+		panic(makeEncodeError("cannot encode cyclic value of type %v", v.Type()))
+	}
+	e.visiting[k] = true
+	return func() { delete(e.visiting, k) }
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"testing"
+	"time"
+)
+
+type canonicalPair struct {
+	Name   string
+	secret int
+	Tags   map[string][]int
+	Next   *canonicalPair
+	Any    any
+}
+
+// hashValue returns the CanonicalHash of the EncodeValue encoding of v.
+func hashValue(t *testing.T, v any) string {
+	t.Helper()
+	enc := NewEncoder()
+	if err := EncodeValue(enc, v); err != nil {
+		t.Fatalf("EncodeValue(%v): %v", v, err)
+	}
+	return CanonicalHash(enc.Data())
+}
+
+func TestEncodeValueDeterministic(t *testing.T) {
+	v := func() canonicalPair {
+		tags := map[string][]int{}
+		for i := 0; i < 100; i++ {
+			tags[string(rune('a'+i%26))+string(rune('a'+i/26))] = []int{i, i * i}
+		}
+		return canonicalPair{Name: "x", Tags: tags, Next: &canonicalPair{Name: "y"}, Any: time.Unix(1, 0).UTC()}
+	}
+	want := hashValue(t, v())
+	for i := 0; i < 10; i++ {
+		if got := hashValue(t, v()); got != want {
+			t.Fatalf("hash: got %s, want %s", got, want)
+		}
+	}
+}
+
+func TestEncodeValueDistinct(t *testing.T) {
+	// Every value must hash differently from every other.
+	chained := canonicalPair{Next: &canonicalPair{Name: "z"}}
+	values := []any{
+		nil,
+		0,
+		int32(0),
+		int64(0),
+		"",
+		[]int(nil),
+		[]int{},
+		map[string]int{},
+		map[string]int{"a": 1},
+		map[string]int{"a": 2},
+		(*canonicalPair)(nil),
+		&canonicalPair{},
+		canonicalPair{},
+		canonicalPair{secret: 1},
+		canonicalPair{Any: 1},
+		canonicalPair{Any: int8(1)},
+		chained,
+		time.Unix(1, 0).UTC(),
+		time.Unix(2, 0).UTC(),
+	}
+	seen := map[string]int{}
+	for i, v := range values {
+		h := hashValue(t, v)
+		if j, ok := seen[h]; ok {
+			t.Errorf("values %d (%#v) and %d (%#v) have the same hash", j, values[j], i, v)
+		}
+		seen[h] = i
+	}
+}
+
+func TestEncodeValueErrors(t *testing.T) {
+	cyclic := &canonicalPair{}
+	cyclic.Next = cyclic
+	for _, v := range []any{
+		make(chan int),
+		func() {},
+		cyclic,
+		canonicalPair{Any: func() {}},
+	} {
+		if err := EncodeValue(NewEncoder(), v); err == nil {
+			t.Errorf("EncodeValue(%T): unexpected success", v)
+		}
+	}
+}
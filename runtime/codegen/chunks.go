@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+)
+
+// # Chunked collections
+//
+// Large slices and maps are encoded and decoded in chunks of chunkLen
+// elements (the last chunk may be shorter), so that encoders and decoders can
+// report their progress, one chunk at a time, to the ProgressFunc registered
+// with SetProgress. Chunking doesn't change how a collection is encoded: a
+// chunked collection is encoded as its length followed by its elements, like
+// any other collection. Slices of types with a wire layout (see EncodeFixed)
+// are encoded and decoded with a single copy, and their progress is reported
+// once.
+
+// chunkLen is the number of elements in a chunk of a chunked collection.
+const chunkLen = 1 << 12
+
+// ProgressFunc reports the progress of encoding or decoding a chunked
+// collection: done of its total elements have been encoded or decoded.
+type ProgressFunc func(done, total int)
+
+// progressKey is the context key for a ProgressFunc.
+type progressKey struct{}
+
+// WithProgress returns a context that carries the provided progress function.
+// See weaver.WithProgress.
+func WithProgress(ctx context.Context, progress ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, progress)
+}
+
+// ProgressFromContext returns the progress function recorded in ctx by
+// WithProgress, or nil if there is none.
+func ProgressFromContext(ctx context.Context) ProgressFunc {
+	progress, _ := ctx.Value(progressKey{}).(ProgressFunc)
+	return progress
+}
+
+// SetProgress registers a function that is called after every chunk of a
+// chunked collection is encoded. A nil function disables progress reports.
+func (e *Encoder) SetProgress(progress ProgressFunc) {
+	e.progress = progress
+}
+
+// SetProgress registers a function that is called after every chunk of a
+// chunked collection is decoded. A nil function disables progress reports.
+func (d *Decoder) SetProgress(progress ProgressFunc) {
+	d.progress = progress
+}
+
+// Chunks encodes the elements of a collection of n elements, whose length has
+// already been encoded, by calling encode(lo, hi) to encode the elements with
+// indices in [lo, hi), once per chunk.
+//
+// NOTE that this method should be called only in the generated code.
+func (e *Encoder) Chunks(n int, encode func(lo, hi int)) {
+	if n <= chunkLen {
+		encode(0, n)
+		return
+	}
+	for lo := 0; lo < n; lo += chunkLen {
+		hi := min(lo+chunkLen, n)
+		encode(lo, hi)
+		if e.progress != nil {
+			e.progress(hi, n)
+		}
+	}
+}
+
+// Chunks decodes the elements of a collection of n elements encoded by
+// Encoder.Chunks, by calling decode(lo, hi) to decode the elements with indices
+// in [lo, hi), once per chunk.
+//
+// NOTE that this method should be called only in the generated code.
+func (d *Decoder) Chunks(n int, decode func(lo, hi int)) {
+	if n <= chunkLen {
+		decode(0, n)
+		return
+	}
+	for lo := 0; lo < n; lo += chunkLen {
+		hi := min(lo+chunkLen, n)
+		decode(lo, hi)
+		if d.progress != nil {
+			d.progress(hi, n)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// encodeStrings encodes s the way generated code encodes a []string.
+func encodeStrings(enc *Encoder, s []string) {
+	enc.Len(len(s))
+	enc.Chunks(len(s), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(s[i])
+		}
+	})
+}
+
+// decodeStrings decodes a []string encoded by encodeStrings.
+func decodeStrings(dec *Decoder) []string {
+	n := dec.Len()
+	res := make([]string, n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
+	return res
+}
+
+// encodeIntMap encodes m the way generated code encodes a map[int]int.
+func encodeIntMap(enc *Encoder, m map[int]int) {
+	enc.Len(len(m))
+	entries := enc.Map()
+	for k, v := range m {
+		entries.Key()
+		enc.Int(k)
+		entries.Value()
+		enc.Int(v)
+	}
+	entries.End()
+}
+
+// decodeIntMap decodes a map[int]int encoded by encodeIntMap.
+func decodeIntMap(dec *Decoder) map[int]int {
+	n := dec.Len()
+	res := make(map[int]int, n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k := dec.Int()
+			res[k] = dec.Int()
+		}
+	})
+	return res
+}
+
+// progressRecorder records the calls to a ProgressFunc.
+type progressRecorder [][2]int
+
+func (r *progressRecorder) progress(done, total int) {
+	*r = append(*r, [2]int{done, total})
+}
+
+func TestChunks(t *testing.T) {
+	for _, n := range []int{0, 1, chunkLen, chunkLen + 1, 3*chunkLen + 17} {
+		want := make([]string, n)
+		for i := range want {
+			want[i] = string(rune('a' + i%26))
+		}
+
+		enc := NewEncoder()
+		var encoded progressRecorder
+		enc.SetProgress(encoded.progress)
+		encodeStrings(enc, want)
+
+		// Chunking doesn't change the encoding.
+		if got, size := len(enc.Data()), 4+5*n; got != size {
+			t.Errorf("n=%d: encoded size: got %d, want %d", n, got, size)
+		}
+
+		dec := NewDecoder(enc.Data())
+		var decoded progressRecorder
+		dec.SetProgress(decoded.progress)
+		got := decodeStrings(dec)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("n=%d: (-want +got):\n%s", n, diff)
+		}
+		if !dec.Empty() {
+			t.Errorf("n=%d: leftover bytes", n)
+		}
+
+		// Progress is reported for every chunk of a chunked slice.
+		var progress progressRecorder
+		if n > chunkLen {
+			for done := chunkLen; done < n; done += chunkLen {
+				progress = append(progress, [2]int{done, n})
+			}
+			progress = append(progress, [2]int{n, n})
+		}
+		if diff := cmp.Diff(progress, encoded); diff != "" {
+			t.Errorf("n=%d: encoder progress (-want +got):\n%s", n, diff)
+		}
+		if diff := cmp.Diff(progress, decoded); diff != "" {
+			t.Errorf("n=%d: decoder progress (-want +got):\n%s", n, diff)
+		}
+	}
+}
+
+func TestChunkedMap(t *testing.T) {
+	for _, n := range []int{10, 2*chunkLen + 1} {
+		want := map[int]int{}
+		for i := 0; i < n; i++ {
+			want[i] = i * i
+		}
+		enc := NewEncoder()
+		var encoded progressRecorder
+		enc.SetProgress(encoded.progress)
+		encodeIntMap(enc, want)
+		if n > chunkLen {
+			if diff := cmp.Diff(progressRecorder{{n, n}}, encoded); diff != "" {
+				t.Errorf("n=%d: encoder progress (-want +got):\n%s", n, diff)
+			}
+		}
+
+		// Chunked maps are still encoded canonically.
+		again := NewEncoder()
+		encodeIntMap(again, want)
+		if diff := cmp.Diff(enc.Data(), again.Data()); diff != "" {
+			t.Errorf("n=%d: non-canonical encoding (-want +got):\n%s", n, diff)
+		}
+
+		dec := NewDecoder(enc.Data())
+		got := decodeIntMap(dec)
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("n=%d: (-want +got):\n%s", n, diff)
+		}
+		if !dec.Empty() {
+			t.Errorf("n=%d: leftover bytes", n)
+		}
+	}
+}
+
+func TestProgressFromContext(t *testing.T) {
+	ctx := context.Background()
+	if ProgressFromContext(ctx) != nil {
+		t.Fatal("unexpected progress function")
+	}
+	var r progressRecorder
+	ProgressFromContext(WithProgress(ctx, r.progress))(1, 2)
+	if diff := cmp.Diff(progressRecorder{{1, 2}}, r); diff != "" {
+		t.Fatalf("(-want +got):\n%s", diff)
+	}
+}
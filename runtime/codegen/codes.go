@@ -0,0 +1,138 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Code classifies the errors returned by component method calls. The codes
+// mirror the status codes of gRPC. See weaver.Code.
+type Code int
+
+const (
+	OK Code = iota
+	Canceled
+	Unknown
+	InvalidArgument
+	DeadlineExceeded
+	NotFound
+	AlreadyExists
+	PermissionDenied
+	ResourceExhausted
+	FailedPrecondition
+	Aborted
+	OutOfRange
+	Unimplemented
+	Internal
+	Unavailable
+	DataLoss
+	Unauthenticated
+)
+
+// codeNames holds the name of every code.
+var codeNames = [...]string{
+	OK:                 "OK",
+	Canceled:           "Canceled",
+	Unknown:            "Unknown",
+	InvalidArgument:    "InvalidArgument",
+	DeadlineExceeded:   "DeadlineExceeded",
+	NotFound:           "NotFound",
+	AlreadyExists:      "AlreadyExists",
+	PermissionDenied:   "PermissionDenied",
+	ResourceExhausted:  "ResourceExhausted",
+	FailedPrecondition: "FailedPrecondition",
+	Aborted:            "Aborted",
+	OutOfRange:         "OutOfRange",
+	Unimplemented:      "Unimplemented",
+	Internal:           "Internal",
+	Unavailable:        "Unavailable",
+	DataLoss:           "DataLoss",
+	Unauthenticated:    "Unauthenticated",
+}
+
+// String implements the fmt.Stringer interface.
+func (c Code) String() string {
+	if c < 0 || int(c) >= len(codeNames) {
+		return fmt.Sprintf("Code(%d)", int(c))
+	}
+	return codeNames[c]
+}
+
+// Retriable reports whether a call that failed with an error with code c may
+// succeed if it is retried as is.
+func (c Code) Retriable() bool {
+	return c == Unavailable
+}
+
+// coder is implemented by errors that carry a code.
+type coder interface {
+	ErrorCode() Code
+}
+
+// CodeOf returns the code of the provided error: OK if err is nil, the code
+// of the first error in err's tree that carries one, Canceled or
+// DeadlineExceeded for context errors, and Unknown otherwise.
+func CodeOf(err error) Code {
+	if err == nil {
+		return OK
+	}
+	var c coder
+	if errors.As(err, &c) {
+		return c.ErrorCode()
+	}
+	switch {
+	case errors.Is(err, context.Canceled):
+		return Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return DeadlineExceeded
+	default:
+		return Unknown
+	}
+}
+
+// codedError is an error with a code.
+type codedError struct {
+	code Code
+	err  error
+}
+
+var _ coder = &codedError{}
+
+// Errorf returns an error with the provided code, formatted like fmt.Errorf.
+// See weaver.Errorf.
+func Errorf(code Code, format string, args ...any) error {
+	return &codedError{code: code, err: fmt.Errorf(format, args...)}
+}
+
+// Error implements the error interface.
+func (e *codedError) Error() string { return e.err.Error() }
+
+// Unwrap returns the errors wrapped by e.
+func (e *codedError) Unwrap() []error {
+	switch u := e.err.(type) {
+	case interface{ Unwrap() error }:
+		return []error{u.Unwrap()}
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	default:
+		return nil
+	}
+}
+
+// ErrorCode returns the code of e.
+func (e *codedError) ErrorCode() Code { return e.code }
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestCodeOf(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		err  error
+		want Code
+	}{
+		{"Nil", nil, OK},
+		{"Plain", io.EOF, Unknown},
+		{"Coded", Errorf(NotFound, "no key %q", "k"), NotFound},
+		{"Wrapped", fmt.Errorf("get: %w", Errorf(InvalidArgument, "empty key")), InvalidArgument},
+		{"Joined", errors.Join(io.EOF, Errorf(Aborted, "conflict")), Aborted},
+		{"Canceled", fmt.Errorf("get: %w", context.Canceled), Canceled},
+		{"DeadlineExceeded", context.DeadlineExceeded, DeadlineExceeded},
+		{"Decoder", decoderError{io.EOF}, Internal},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := CodeOf(test.err); got != test.want {
+				t.Fatalf("CodeOf(%v): got %v, want %v", test.err, got, test.want)
+			}
+		})
+	}
+}
+
+func TestCodeRoundTrip(t *testing.T) {
+	// The code of an error survives encoding, and so does the identity of
+	// the errors it wraps.
+	err := Errorf(Unavailable, "lookup: %w", io.EOF)
+	enc := NewEncoder()
+	enc.Error(err)
+	got := NewDecoder(enc.Data()).Error()
+	if code := CodeOf(got); code != Unavailable {
+		t.Errorf("CodeOf: got %v, want %v", code, Unavailable)
+	}
+	if !errors.Is(got, io.EOF) {
+		t.Errorf("errors.Is(%v, io.EOF): got false, want true", got)
+	}
+}
+
+func TestCodeString(t *testing.T) {
+	if got, want := Unavailable.String(), "Unavailable"; got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+	if got, want := Code(100).String(), "Code(100)"; got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"reflect"
+)
+
+// Concrete encodes value, the contents of an interface, as a tag identifying
+// its concrete type followed by the value itself. A nil value is encoded as an
+// empty tag. The concrete type of value must be registered with
+// RegisterConcrete.
+func (e *Encoder) Concrete(value any) {
+	if value == nil {
+		e.String("")
+		return
+	}
+	t := reflect.TypeOf(value)
+	typesMu.Lock()
+	key, ok := typeKeys[t]
+	typesMu.Unlock()
+	if !ok {
+		panic(makeEncodeError("unable to encode %v; type not registered with codegen.RegisterConcrete", t))
+	}
+	var am AutoMarshal
+	if t.Kind() == reflect.Pointer {
+		if reflect.ValueOf(value).IsNil() {
+			panic(makeEncodeError("unable to encode nil %v", t))
+		}
+		am, ok = value.(AutoMarshal)
+	} else {
+		am, ok = pointerTo(value).(AutoMarshal)
+	}
+	if !ok {
+		panic(makeEncodeError("unable to encode %v; type is not serializable", t))
+	}
+	e.String(key)
+	am.WeaverMarshal(e)
+}
+
+// Concrete decodes a value encoded by Encoder.Concrete.
+func (d *Decoder) Concrete() any {
+	key := d.String()
+	if key == "" {
+		return nil
+	}
+	typesMu.Lock()
+	t, ok := types[key]
+	typesMu.Unlock()
+	if !ok {
+		panic(makeDecodeError("unable to decode value of unregistered type %q", key))
+	}
+
+	// Allocate space for the value.
+	var ptr reflect.Value
+	if t.Kind() == reflect.Pointer {
+		ptr = reflect.New(t.Elem())
+	} else {
+		ptr = reflect.New(t)
+	}
+	am, ok := ptr.Interface().(AutoMarshal)
+	if !ok {
+		panic(makeDecodeError("unable to decode value of non-serializable type %v", t))
+	}
+	am.WeaverUnmarshal(d)
+
+	if t.Kind() == reflect.Pointer {
+		return ptr.Interface()
+	}
+	return ptr.Elem().Interface()
+}
+
+// DecodeConcrete decodes a value encoded by Encoder.Concrete and returns it as
+// a value of interface type I. It fails to decode a value whose concrete type
+// doesn't implement I.
+func DecodeConcrete[I any](d *Decoder) I {
+	var result I
+	value := d.Concrete()
+	if value == nil {
+		return result
+	}
+	result, ok := value.(I)
+	if !ok {
+		panic(makeDecodeError("unable to decode %T; type does not implement %v", value, reflect.TypeOf((*I)(nil)).Elem()))
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+// shape, circle, and square are used by TestConcrete.
+type shape interface {
+	area() float64
+}
+
+type circle struct{ r float64 }
+type square struct{ s float64 }
+type unregistered struct{}
+
+func (c circle) area() float64                     { return 3 * c.r * c.r }
+func (c *circle) WeaverMarshal(enc *Encoder)       { enc.Float64(c.r) }
+func (c *circle) WeaverUnmarshal(dec *Decoder)     { c.r = dec.Float64() }
+func (s *square) area() float64                    { return s.s * s.s }
+func (s *square) WeaverMarshal(enc *Encoder)       { enc.Float64(s.s) }
+func (s *square) WeaverUnmarshal(dec *Decoder)     { s.s = dec.Float64() }
+func (unregistered) area() float64                 { return 0 }
+func (*unregistered) WeaverMarshal(enc *Encoder)   {}
+func (*unregistered) WeaverUnmarshal(dec *Decoder) {}
+
+func init() {
+	RegisterConcrete[circle]()
+	RegisterConcrete[square]()
+}
+
+func TestConcrete(t *testing.T) {
+	for _, want := range []shape{nil, circle{1}, &circle{2}, &square{3}} {
+		enc := NewEncoder()
+		enc.Concrete(want)
+		dec := NewDecoder(enc.Data())
+		got := DecodeConcrete[shape](dec)
+		if want == nil {
+			if got != nil {
+				t.Errorf("got %v, want nil", got)
+			}
+			continue
+		}
+		if got == nil || got.area() != want.area() {
+			t.Errorf("got %#v, want %#v", got, want)
+		}
+		if got, want := typeKey(got), typeKey(want); got != want {
+			t.Errorf("got type %s, want %s", got, want)
+		}
+	}
+}
+
+func TestConcreteErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{"Unregistered", func() {
+			NewEncoder().Concrete(unregistered{})
+		}, "not registered"},
+		{"NilPointer", func() {
+			NewEncoder().Concrete((*circle)(nil))
+		}, "unable to encode nil"},
+		{"UnknownTag", func() {
+			enc := NewEncoder()
+			enc.String("unknown")
+			NewDecoder(enc.Data()).Concrete()
+		}, "unregistered type"},
+		{"WrongInterface", func() {
+			enc := NewEncoder()
+			enc.Concrete(circle{1})
+			DecodeConcrete[interface{ perimeter() float64 }](NewDecoder(enc.Data()))
+		}, "does not implement"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := convertCallPanicToError(test.fn)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
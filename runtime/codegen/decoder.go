@@ -41,14 +41,19 @@ func (d decoderError) Unwrap() error {
 	return d.err
 }
 
+func (d decoderError) ErrorCode() Code {
+	return Internal
+}
+
 // Decoder deserializes data from a byte slice data in the expected results.
 type Decoder struct {
-	data []byte
+	data     []byte
+	progress ProgressFunc // Reports the progress of chunked collections, if not nil.
 }
 
 // NewDecoder instantiates a new Decoder for a given byte slice.
 func NewDecoder(data []byte) *Decoder {
-	return &Decoder{data}
+	return &Decoder{data: data}
 }
 
 // Empty returns true iff all bytes in d have been consumed.
@@ -213,6 +218,26 @@ func (d *Decoder) Len() int {
 	return n
 }
 
+// CheckLen panics if fewer than n * size bytes remain to be decoded, where n
+// is the length of a collection and size is a lower bound on the number of
+// bytes its elements are encoded in. Call it before allocating the
+// collection, as a corrupted length may be large.
+//
+// NOTE that this method should be called only in the generated code.
+func (d *Decoder) CheckLen(n, size int) {
+	if size > 0 && n > len(d.data)/size {
+		panic(makeDecodeError("unable to decode %d elements of at least %d bytes; only %d bytes remain", n, size, len(d.data)))
+	}
+}
+
+// UnknownEnum panics with a decoding error reporting that value is not one of
+// the declared constants of the provided enum type.
+//
+// NOTE that this method should be called only in the generated code.
+func (d *Decoder) UnknownEnum(typ string, value any) {
+	panic(makeDecodeError("unable to decode %s; unknown value %v", typ, value))
+}
+
 // Error decodes an error. We construct an instance of a special error value
 // that provides Is and Unwrap support.
 func (d *Decoder) Error() error {
@@ -240,6 +265,11 @@ func (d *Decoder) Error() error {
 			msg := d.String()
 			f := d.String()
 			list = append(list, decodedError{msg, f})
+		} else if tag == codedEmulatedError {
+			msg := d.String()
+			f := d.String()
+			code := Code(d.Int())
+			list = append(list, codedDecodedError{decodedError{msg, f}, code})
 		} else {
 			panic(fmt.Sprintf("invalid error list tag %d", tag))
 		}
@@ -303,6 +333,15 @@ func (e decodedError) Is(target error) bool {
 	return e.fmt == fmtError(target)
 }
 
+// codedDecodedError is a decodedError that carries a code.
+type codedDecodedError struct {
+	decodedError
+	code Code
+}
+
+// ErrorCode returns the code of e.
+func (e codedDecodedError) ErrorCode() Code { return e.code }
+
 // fmtError serializes an error value including its type info using fmt.Sprintf.
 func fmtError(v error) string {
 	// Include package and type info explicitly since %#v uses a shortened path.
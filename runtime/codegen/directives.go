@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"strings"
+	"time"
+)
+
+// Directive is a comment directive on a component interface method, e.g.,
+//
+//	type Cache interface {
+//	    //weaver:timeout 2s
+//	    Get(context.Context, string) (string, error)
+//	}
+//
+// "weaver generate" validates the directives it knows about and records the
+// directives of every method in Registration.Directives, so that runtime
+// policies can be declared next to the methods they govern. The directives
+// are:
+//
+//   - //weaver:timeout <duration>: calls to the method should time out after
+//     the provided duration, e.g., "2s".
+//   - //weaver:idempotent: the method can safely be executed more than once
+//     for the same call.
+//   - //weaver:route key=<name>: calls to the method are routed by the
+//     provided argument, or field of a struct argument.
+//   - //weaver:unpaginated: the method is a list method that deliberately
+//     returns all of its results at once, rather than a weaver.Page.
+type Directive struct {
+	Name string // e.g., "timeout"
+	Args string // e.g., "2s", or "" if there are no arguments
+}
+
+// String returns the directive as written in code, without the leading "//".
+func (d Directive) String() string {
+	if d.Args == "" {
+		return "weaver:" + d.Name
+	}
+	return "weaver:" + d.Name + " " + d.Args
+}
+
+// Arg returns the value of the provided "key=value" argument of the
+// directive, if present.
+func (d Directive) Arg(key string) (string, bool) {
+	for _, arg := range strings.Fields(d.Args) {
+		if k, v, ok := strings.Cut(arg, "="); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Directive returns the directive with the provided name on the provided
+// method, if any.
+func (r *Registration) Directive(method, name string) (Directive, bool) {
+	for _, d := range r.Directives[method] {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Directive{}, false
+}
+
+// Timeout returns the duration of the //weaver:timeout directive on the
+// provided method, if any.
+func (r *Registration) Timeout(method string) (time.Duration, bool) {
+	d, ok := r.Directive(method, "timeout")
+	if !ok {
+		return 0, false
+	}
+	timeout, err := time.ParseDuration(d.Args)
+	if err != nil || timeout <= 0 {
+		// "weaver generate" rejects invalid timeouts.
+		return 0, false
+	}
+	return timeout, true
+}
+
+// Idempotent returns whether the provided method has a //weaver:idempotent
+// directive.
+func (r *Registration) Idempotent(method string) bool {
+	_, ok := r.Directive(method, "idempotent")
+	return ok
+}
+
+// RouteKey returns the name of the argument, or struct argument field, named
+// by the //weaver:route directive on the provided method, if any.
+func (r *Registration) RouteKey(method string) (string, bool) {
+	d, ok := r.Directive(method, "route")
+	if !ok {
+		return "", false
+	}
+	return d.Arg("key")
+}
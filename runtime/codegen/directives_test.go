@@ -0,0 +1,55 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+func TestDirectives(t *testing.T) {
+	reg := &codegen.Registration{
+		Directives: map[string][]codegen.Directive{
+			"Get": {{Name: "timeout", Args: "2s"}, {Name: "idempotent"}},
+			"Put": {{Name: "route", Args: "key=UserID"}},
+		},
+	}
+
+	if got, ok := reg.Timeout("Get"); !ok || got != 2*time.Second {
+		t.Errorf("Timeout(Get): got %v, %v; want 2s, true", got, ok)
+	}
+	if got, ok := reg.Timeout("Put"); ok {
+		t.Errorf("Timeout(Put): got %v, true; want false", got)
+	}
+	if !reg.Idempotent("Get") {
+		t.Error("Idempotent(Get): got false, want true")
+	}
+	if reg.Idempotent("Put") {
+		t.Error("Idempotent(Put): got true, want false")
+	}
+	if got, ok := reg.RouteKey("Put"); !ok || got != "UserID" {
+		t.Errorf("RouteKey(Put): got %q, %v; want UserID, true", got, ok)
+	}
+	if got, ok := reg.RouteKey("Missing"); ok {
+		t.Errorf("RouteKey(Missing): got %q, true; want false", got)
+	}
+
+	d, _ := reg.Directive("Put", "route")
+	if got, want := d.String(), "weaver:route key=UserID"; got != want {
+		t.Errorf("String: got %q, want %q", got, want)
+	}
+}
@@ -15,10 +15,12 @@
 package codegen
 
 import (
+	"bytes"
 	"encoding"
 	"encoding/binary"
 	"fmt"
 	"math"
+	"slices"
 
 	"google.golang.org/protobuf/proto"
 )
@@ -39,10 +41,17 @@ func (e encoderError) Unwrap() error {
 	return e.err
 }
 
+func (e encoderError) ErrorCode() Code {
+	return Internal
+}
+
 // Encoder serializes data in a byte slice data.
 type Encoder struct {
-	data  []byte    // Contains the serialized arguments.
-	space [100]byte // Prellocated buffer to avoid allocations for small size arguments.
+	data   []byte    // Contains the serialized arguments.
+	space  [100]byte // Prellocated buffer to avoid allocations for small size arguments.
+	pooled bool      // Are buffers taken from a buffer pool? See NewPooledEncoder.
+
+	progress ProgressFunc // Reports the progress of chunked collections, if not nil.
 }
 
 func NewEncoder() *Encoder {
@@ -59,6 +68,9 @@ func (e *Encoder) Reset(n int) {
 	// NewCaller.
 	if n <= cap(e.data) {
 		e.data = e.data[:0]
+	} else if e.pooled {
+		putBuffer(e.data)
+		e.data = getBuffer(n)
 	} else {
 		e.data = make([]byte, 0, n)
 	}
@@ -70,8 +82,9 @@ func makeEncodeError(format string, args ...interface{}) encoderError {
 }
 
 // EncodeProto serializes value into a byte slice using proto serialization.
+// Map fields are serialized deterministically, sorted by key.
 func (e *Encoder) EncodeProto(value proto.Message) {
-	enc, err := proto.Marshal(value)
+	enc, err := proto.MarshalOptions{Deterministic: true}.Marshal(value)
 	if err != nil {
 		panic(makeEncodeError("error encoding to proto %T: %w", value, err))
 	}
@@ -100,6 +113,11 @@ func (e *Encoder) Grow(bytesNeeded int) []byte {
 	n := len(e.data)
 	if cap(e.data)-n >= bytesNeeded {
 		e.data = e.data[:n+bytesNeeded] // Grow in place (common case)
+	} else if e.pooled {
+		// Move to a pooled buffer at least twice as large.
+		data := append(getBuffer(max(2*cap(e.data), n+bytesNeeded)), e.data...)
+		putBuffer(e.data)
+		e.data = data[:n+bytesNeeded]
 	} else {
 		// Create a new larger slice.
 		e.data = append(e.data, make([]byte, bytesNeeded)...)
@@ -249,6 +267,96 @@ func (e *Encoder) Len(l int) {
 	e.Int32(int32(l))
 }
 
+// MapEncoder encodes the entries of a map in a canonical order. Go randomizes
+// map iteration order, so encoding entries in iteration order would produce
+// different bytes for equal maps. Instead, a MapEncoder records the location
+// of every entry written to the underlying Encoder and, when End is called,
+// rearranges the entries so that they are sorted by their encoded keys.
+//
+// Usage:
+//
+//	m := enc.Map()
+//	for k, v := range arg {
+//	    m.Key()
+//	    // Encode k.
+//	    m.Value()
+//	    // Encode v.
+//	}
+//	m.End()
+//
+// NOTE that this type should be used only in the generated code.
+type MapEncoder struct {
+	enc     *Encoder
+	entries []mapEntry
+}
+
+// mapEntry is the location of an encoded map entry in an Encoder's data.
+type mapEntry struct {
+	start int // offset of the encoded key
+	mid   int // offset of the encoded value
+	end   int // offset one past the end of the encoded value
+}
+
+// Map returns a MapEncoder that encodes map entries into e.
+func (e *Encoder) Map() *MapEncoder {
+	return &MapEncoder{enc: e}
+}
+
+// Key marks the start of a new map entry. The entry's key should be encoded
+// immediately afterwards.
+func (m *MapEncoder) Key() {
+	m.finish()
+	n := len(m.enc.data)
+	m.entries = append(m.entries, mapEntry{start: n, mid: -1, end: -1})
+}
+
+// Value marks the end of the current entry's key. The entry's value should be
+// encoded immediately afterwards.
+func (m *MapEncoder) Value() {
+	m.entries[len(m.entries)-1].mid = len(m.enc.data)
+}
+
+// End sorts the encoded entries by their encoded keys. If there are more than
+// chunkLen entries, End also reports that all of them have been encoded to the
+// encoder's ProgressFunc, if any.
+func (m *MapEncoder) End() {
+	m.finish()
+	n := len(m.entries)
+	if n > chunkLen && m.enc.progress != nil {
+		defer m.enc.progress(n, n)
+	}
+	if n < 2 {
+		return
+	}
+	data := m.enc.data
+	slices.SortFunc(m.entries, func(a, b mapEntry) int {
+		return bytes.Compare(data[a.start:a.mid], data[b.start:b.mid])
+	})
+	start := m.entries[0].start
+	for _, entry := range m.entries {
+		start = min(start, entry.start)
+	}
+	sorted := make([]byte, 0, len(data)-start)
+	for _, entry := range m.entries {
+		sorted = append(sorted, data[entry.start:entry.end]...)
+	}
+	copy(data[start:], sorted)
+}
+
+// finish records the end of the current entry, if any.
+func (m *MapEncoder) finish() {
+	if len(m.entries) == 0 {
+		return
+	}
+	last := &m.entries[len(m.entries)-1]
+	if last.mid == -1 {
+		panic(makeEncodeError("map entry key has no value"))
+	}
+	if last.end == -1 {
+		last.end = len(m.enc.data)
+	}
+}
+
 // Error encoding
 //
 // An error can be composed of a tree of errors (see the errors package)
@@ -264,11 +372,15 @@ func (e *Encoder) Len(l int) {
 // registered as serializable.
 //
 // <emulatedError,message,fmtError> for unregistered error types.
+//
+// <codedEmulatedError,message,fmtError,code> for unregistered error types
+// that carry a code (see CodeOf).
 const (
 	endOfErrors        uint8 = 0
 	serializedErrorVal uint8 = 1
 	serializedErrorPtr uint8 = 2
 	emulatedError      uint8 = 3
+	codedEmulatedError uint8 = 4
 )
 
 // Error encodes an arg of type error. We save enough type information
@@ -312,10 +424,19 @@ func (e *Encoder) Error(err error) {
 		}
 
 		// Send the error message and the representation of err so we
-		// can do plain comparisons at the other end.
-		e.Uint8(emulatedError)
+		// can do plain comparisons at the other end, and its code, if any,
+		// so that the other end can classify it.
+		c, coded := err.(coder)
+		if coded {
+			e.Uint8(codedEmulatedError)
+		} else {
+			e.Uint8(emulatedError)
+		}
 		e.String(err.Error())
 		e.String(fmtError(err))
+		if coded {
+			e.Int(int(c.ErrorCode()))
+		}
 
 		switch u := err.(type) {
 		case interface{ Unwrap() error }:
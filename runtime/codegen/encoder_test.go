@@ -17,6 +17,7 @@ package codegen
 import (
 	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"os"
 	"reflect"
@@ -139,7 +140,7 @@ func TestErrorDecUnableToRead(t *testing.T) {
 		enc := newEncoder()
 		enc.Int(12345)
 
-		dec := Decoder{enc.data}
+		dec := Decoder{data: enc.data}
 		dec.Int()
 		dec.Bool()
 	})
@@ -156,7 +157,7 @@ func TestErrorUnableToDecBool(t *testing.T) {
 		enc := newEncoder()
 		enc.Int(123)
 
-		dec := Decoder{enc.data}
+		dec := Decoder{data: enc.data}
 		dec.Bool()
 	})
 	if !strings.Contains(err.Error(), "unable to decode bool") {
@@ -172,7 +173,7 @@ func TestErrorUnableToDecBytes(t *testing.T) {
 		enc := newEncoder()
 		enc.Int(-10)
 
-		dec := Decoder{enc.data}
+		dec := Decoder{data: enc.data}
 		dec.Bytes()
 	})
 	if !strings.Contains(err.Error(), "unable to decode bytes; expected length") {
@@ -180,6 +181,31 @@ func TestErrorUnableToDecBytes(t *testing.T) {
 	}
 }
 
+// TestErrorCheckLen checks that CheckLen rejects lengths that exceed the
+// number of elements the remaining bytes can hold.
+func TestErrorCheckLen(t *testing.T) {
+	for _, test := range []struct {
+		n, size int
+		ok      bool
+	}{
+		{2, 8, true},
+		{3, 8, false},
+		{math.MaxInt32, 1, false},
+		{math.MaxInt32, 0, true}, // no lower bound
+	} {
+		err := convertCallPanicToError(func() {
+			dec := NewDecoder(make([]byte, 16))
+			dec.CheckLen(test.n, test.size)
+		})
+		if test.ok && err != nil {
+			t.Errorf("CheckLen(%d, %d): %v", test.n, test.size, err)
+		}
+		if !test.ok && (err == nil || !strings.Contains(err.Error(), "unable to decode")) {
+			t.Errorf("CheckLen(%d, %d): got %v, want decode error", test.n, test.size, err)
+		}
+	}
+}
+
 // Some custom error types. There are manually made serializable since we do
 // not want this package to depend on the code generator.
 
@@ -293,6 +319,77 @@ func TestCyclicError(t *testing.T) {
 	}
 }
 
+// encodeMap encodes m the way generated code encodes a map[string]map[int]bool.
+func encodeMap(enc *Encoder, m map[string]map[int]bool) {
+	enc.Len(len(m))
+	outer := enc.Map()
+	for k, v := range m {
+		outer.Key()
+		enc.String(k)
+		outer.Value()
+		enc.Len(len(v))
+		inner := enc.Map()
+		for k, v := range v {
+			inner.Key()
+			enc.Int(k)
+			inner.Value()
+			enc.Bool(v)
+		}
+		inner.End()
+	}
+	outer.End()
+}
+
+func TestMapEncoderCanonical(t *testing.T) {
+	// Build equal maps with different insertion orders. Go randomizes map
+	// iteration, so encoding entries in iteration order would almost
+	// certainly produce different bytes.
+	build := func(keys []int) map[string]map[int]bool {
+		m := map[string]map[int]bool{}
+		for _, k := range keys {
+			key := strconv.Itoa(k % 10)
+			if m[key] == nil {
+				m[key] = map[int]bool{}
+			}
+			m[key][k] = k%2 == 0
+		}
+		return m
+	}
+	keys := make([]int, 200)
+	for i := range keys {
+		keys[i] = i
+	}
+	want := newEncoder()
+	encodeMap(&want, build(keys))
+	for i := 0; i < 10; i++ {
+		rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		got := newEncoder()
+		encodeMap(&got, build(keys))
+		if diff := cmp.Diff(want.data, got.data); diff != "" {
+			t.Fatalf("non-canonical map encoding (-want,+got):\n%s", diff)
+		}
+	}
+
+	// Check that the sorted encoding still decodes to the original map.
+	dec := Decoder{data: want.data}
+	got := map[string]map[int]bool{}
+	for n := dec.Len(); n > 0; n-- {
+		k := dec.String()
+		v := map[int]bool{}
+		for m := dec.Len(); m > 0; m-- {
+			k := dec.Int()
+			v[k] = dec.Bool()
+		}
+		got[k] = v
+	}
+	if !dec.Empty() {
+		t.Fatalf("leftover bytes in decoder")
+	}
+	if diff := cmp.Diff(build(keys), got); diff != "" {
+		t.Fatalf("map: (-want,+got):\n%s", diff)
+	}
+}
+
 // encode serializes args using the encoder enc.
 func encode(enc *Encoder, args []interface{}) {
 	for _, elem := range args {
@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"reflect"
+	"sync"
+	"unsafe"
+)
+
+// Slices of numbers (e.g., []float64 feature vectors) are common in data
+// heavy applications, and encoding them one element at a time is slow. On a
+// little-endian machine, most numeric types, and arrays and padding-free
+// structs of them, are laid out in memory exactly as they are serialized. We
+// say these types have a wire layout. A slice of a type with a wire layout can
+// be encoded and decoded with a single copy.
+//
+// Booleans don't have a wire layout, even though they are a single byte,
+// because decoding a boolean requires checking that the byte is 0 or 1. Ints
+// and uints only have a wire layout on 64-bit machines, since they are always
+// serialized as 8 bytes.
+
+// littleEndian is true if the machine is little-endian.
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// wireLayouts caches the result of hasWireLayout. It maps a reflect.Type to a
+// bool.
+var wireLayouts sync.Map
+
+// hasWireLayout returns whether values of type t are laid out in memory
+// exactly as they are serialized.
+func hasWireLayout(t reflect.Type) bool {
+	if ok, found := wireLayouts.Load(t); found {
+		return ok.(bool)
+	}
+	ok := littleEndian && wireSize(t) >= 0
+	wireLayouts.Store(t, ok)
+	return ok
+}
+
+// wireSize returns the size of the serialization of a value of type t, if t
+// has a wire layout, or -1 otherwise.
+func wireSize(t reflect.Type) int {
+	size := -1
+	switch t.Kind() {
+	case reflect.Int8, reflect.Uint8:
+		size = 1
+	case reflect.Int16, reflect.Uint16:
+		size = 2
+	case reflect.Int32, reflect.Uint32, reflect.Float32:
+		size = 4
+	case reflect.Int, reflect.Uint, reflect.Int64, reflect.Uint64, reflect.Float64, reflect.Complex64:
+		size = 8
+	case reflect.Complex128:
+		size = 16
+	case reflect.Array:
+		if n := wireSize(t.Elem()); n >= 0 {
+			size = t.Len() * n
+		}
+	case reflect.Struct:
+		// Every field must start where the serialization of the previous
+		// field ends. Otherwise, there is padding between the fields.
+		size = 0
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			n := wireSize(f.Type)
+			if n < 0 || f.Offset != uintptr(size) {
+				return -1
+			}
+			size += n
+		}
+	}
+	if size != int(t.Size()) {
+		// Either t is not a fixed size type, or it is laid out differently in
+		// memory (e.g., a 4 byte int, or a struct with trailing padding).
+		return -1
+	}
+	return size
+}
+
+// bytesOf returns the memory backing the elements of s.
+func bytesOf[T any](s []T) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	size := len(s) * int(unsafe.Sizeof(s[0]))
+	return unsafe.Slice((*byte)(unsafe.Pointer(unsafe.SliceData(s))), size)
+}
+
+// EncodeFixed encodes the elements of arg, but not its length, with a single
+// copy, if values of type T are laid out in memory exactly as they are
+// serialized. It returns whether it encoded arg. If it returns false, the
+// caller must encode the elements of arg one at a time. If arg is large enough
+// to be chunked (see Encoder.Chunks), EncodeFixed reports its progress once.
+//
+// NOTE that this function should be called only in the generated code, and
+// only for types T whose serialization is the concatenation of their fields
+// or elements.
+func EncodeFixed[T any](e *Encoder, arg []T) bool {
+	if !hasWireLayout(reflect.TypeOf((*T)(nil)).Elem()) {
+		return false
+	}
+	b := bytesOf(arg)
+	copy(e.Grow(len(b)), b)
+	if n := len(arg); n > chunkLen && e.progress != nil {
+		e.progress(n, n)
+	}
+	return true
+}
+
+// DecodeFixed decodes len(res) values of type T encoded by EncodeFixed, or
+// encoded one at a time, into res with a single copy, if values of type T are
+// laid out in memory exactly as they are serialized. It returns whether it
+// decoded the values. If it returns false, the caller must decode the values
+// one at a time. Like EncodeFixed, DecodeFixed reports the progress of large
+// slices once.
+//
+// NOTE that this function should be called only in the generated code; see
+// EncodeFixed.
+func DecodeFixed[T any](d *Decoder, res []T) bool {
+	if !hasWireLayout(reflect.TypeOf((*T)(nil)).Elem()) {
+		return false
+	}
+	b := bytesOf(res)
+	copy(b, d.Read(len(b)))
+	if n := len(res); n > chunkLen && d.progress != nil {
+		d.progress(n, n)
+	}
+	return true
+}
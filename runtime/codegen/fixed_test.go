@@ -0,0 +1,282 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// packed is a struct without padding.
+type packed struct {
+	A int64
+	B [2]int32
+	C float64
+	D complex64
+}
+
+// encodePacked encodes p, one field at a time.
+func encodePacked(enc *Encoder, p packed) {
+	enc.Int64(p.A)
+	enc.Int32(p.B[0])
+	enc.Int32(p.B[1])
+	enc.Float64(p.C)
+	enc.Complex64(p.D)
+}
+
+// decodePacked decodes a packed, one field at a time.
+func decodePacked(dec *Decoder) packed {
+	var p packed
+	p.A = dec.Int64()
+	p.B[0] = dec.Int32()
+	p.B[1] = dec.Int32()
+	p.C = dec.Float64()
+	p.D = dec.Complex64()
+	return p
+}
+
+func TestHasWireLayout(t *testing.T) {
+	type padded struct {
+		A int8
+		B int64
+	}
+	type trailing struct {
+		A int64
+		B int8
+	}
+	type empty struct{}
+	type celsius float64
+
+	for _, test := range []struct {
+		value any
+		want  bool
+	}{
+		{int8(0), true},
+		{uint16(0), true},
+		{int32(0), true},
+		{int64(0), true},
+		{float32(0), true},
+		{float64(0), true},
+		{complex64(0), true},
+		{complex128(0), true},
+		{int(0), unsafe.Sizeof(int(0)) == 8},
+		{uint(0), unsafe.Sizeof(uint(0)) == 8},
+		{celsius(0), true},
+		{[3]int16{}, true},
+		{packed{}, true},
+		{empty{}, true},
+		{[2]packed{}, true},
+		{false, false},
+		{"", false},
+		{[]int64{}, false},
+		{&packed{}, false},
+		{padded{}, false},
+		{trailing{}, false},
+		{[2]padded{}, false},
+		{struct{ A [2]bool }{}, false},
+	} {
+		want := test.want && littleEndian
+		if got := hasWireLayout(reflect.TypeOf(test.value)); got != want {
+			t.Errorf("hasWireLayout(%T): got %t, want %t", test.value, got, want)
+		}
+	}
+}
+
+// checkFixed checks that EncodeFixed and DecodeFixed agree with encode and
+// decode, which encode and decode a single value.
+func checkFixed[T any](t *testing.T, values []T, encode func(*Encoder, T), decode func(*Decoder) T) {
+	t.Helper()
+
+	// Encode the values one at a time.
+	want := NewEncoder()
+	for _, v := range values {
+		encode(want, v)
+	}
+
+	// Encode the values in bulk.
+	got := NewEncoder()
+	if !EncodeFixed(got, values) {
+		t.Fatalf("EncodeFixed(%T): unexpected false", values)
+	}
+	if !bytes.Equal(got.Data(), want.Data()) {
+		t.Fatalf("EncodeFixed(%v): got %v, want %v", values, got.Data(), want.Data())
+	}
+
+	// Decode the values in bulk, and re-encode them one at a time.
+	decoded := make([]T, len(values))
+	if !DecodeFixed(NewDecoder(want.Data()), decoded) {
+		t.Fatalf("DecodeFixed(%T): unexpected false", values)
+	}
+	reencoded := NewEncoder()
+	for _, v := range decoded {
+		encode(reencoded, v)
+	}
+	if !bytes.Equal(reencoded.Data(), want.Data()) {
+		t.Fatalf("DecodeFixed(%v): got %v, want %v", want.Data(), decoded, values)
+	}
+
+	// Decode the values one at a time, and re-encode them in bulk.
+	dec := NewDecoder(want.Data())
+	var elems []T
+	for range values {
+		elems = append(elems, decode(dec))
+	}
+	reencoded = NewEncoder()
+	EncodeFixed(reencoded, elems)
+	if !bytes.Equal(reencoded.Data(), want.Data()) {
+		t.Fatalf("DecodeFixed(%v): got %v, want %v", want.Data(), elems, values)
+	}
+}
+
+func TestFixed(t *testing.T) {
+	if !littleEndian {
+		t.Skip("no types have a wire layout on big-endian machines")
+	}
+	checkFixed(t, []int64{0, 1, -1, math.MinInt64, math.MaxInt64}, (*Encoder).Int64, (*Decoder).Int64)
+	checkFixed(t, []float64{0, -1.5, math.Inf(1), math.NaN(), math.SmallestNonzeroFloat64}, (*Encoder).Float64, (*Decoder).Float64)
+	checkFixed(t, []uint16{0, 1, math.MaxUint16}, (*Encoder).Uint16, (*Decoder).Uint16)
+	checkFixed(t, []complex128{0, complex(1, -2)}, (*Encoder).Complex128, (*Decoder).Complex128)
+	checkFixed(t, []packed{{}, {A: 1, B: [2]int32{2, 3}, C: 4.5, D: complex(6, 7)}}, encodePacked, decodePacked)
+	checkFixed(t, []int64{}, (*Encoder).Int64, (*Decoder).Int64)
+}
+
+func TestFixedNoWireLayout(t *testing.T) {
+	enc := NewEncoder()
+	if EncodeFixed(enc, []bool{true, false}) {
+		t.Error("EncodeFixed([]bool): unexpected true")
+	}
+	if len(enc.Data()) != 0 {
+		t.Errorf("EncodeFixed([]bool): unexpectedly encoded %v", enc.Data())
+	}
+	if DecodeFixed(NewDecoder([]byte{1, 0}), make([]bool, 2)) {
+		t.Error("DecodeFixed([]bool): unexpected true")
+	}
+}
+
+func TestFixedProgress(t *testing.T) {
+	if !littleEndian {
+		t.Skip("no types have a wire layout on big-endian machines")
+	}
+	for _, n := range []int{chunkLen, 2*chunkLen + 1} {
+		// Large slices report their progress once.
+		var want progressRecorder
+		if n > chunkLen {
+			want = progressRecorder{{n, n}}
+		}
+
+		enc := NewEncoder()
+		var encoded progressRecorder
+		enc.SetProgress(encoded.progress)
+		EncodeFixed(enc, make([]int64, n))
+		if diff := cmp.Diff(want, encoded); diff != "" {
+			t.Errorf("n=%d: encoder progress (-want +got):\n%s", n, diff)
+		}
+
+		dec := NewDecoder(enc.Data())
+		var decoded progressRecorder
+		dec.SetProgress(decoded.progress)
+		DecodeFixed(dec, make([]int64, n))
+		if diff := cmp.Diff(want, decoded); diff != "" {
+			t.Errorf("n=%d: decoder progress (-want +got):\n%s", n, diff)
+		}
+	}
+}
+
+func TestDecodeFixedTooShort(t *testing.T) {
+	if !littleEndian {
+		t.Skip("no types have a wire layout on big-endian machines")
+	}
+	err := func() (err error) {
+		defer func() { err = CatchPanics(recover()) }()
+		DecodeFixed(NewDecoder(make([]byte, 15)), make([]int64, 2))
+		return nil
+	}()
+	if err == nil {
+		t.Error("DecodeFixed: unexpected success")
+	}
+}
+
+// FuzzFixedInt64 checks that EncodeFixed and DecodeFixed agree with
+// Encoder.Int64 and Decoder.Int64.
+func FuzzFixedInt64(f *testing.F) {
+	if !littleEndian {
+		f.Skip("no types have a wire layout on big-endian machines")
+	}
+	f.Add([]byte{})
+	f.Add([]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16})
+	f.Fuzz(func(t *testing.T, b []byte) {
+		var values []int64
+		for ; len(b) >= 8; b = b[8:] {
+			values = append(values, int64(binary.BigEndian.Uint64(b)))
+		}
+		checkFixed(t, values, (*Encoder).Int64, (*Decoder).Int64)
+	})
+}
+
+// FuzzFixedFloat64 checks that EncodeFixed and DecodeFixed agree with
+// Encoder.Float64 and Decoder.Float64.
+func FuzzFixedFloat64(f *testing.F) {
+	if !littleEndian {
+		f.Skip("no types have a wire layout on big-endian machines")
+	}
+	f.Add(0.0, 1.0, -1.0)
+	f.Add(math.Inf(1), math.Inf(-1), math.NaN())
+	f.Fuzz(func(t *testing.T, x, y, z float64) {
+		checkFixed(t, []float64{x, y, z}, (*Encoder).Float64, (*Decoder).Float64)
+	})
+}
+
+// FuzzFixedStruct checks that EncodeFixed and DecodeFixed agree with encoding
+// and decoding a struct one field at a time.
+func FuzzFixedStruct(f *testing.F) {
+	if !littleEndian {
+		f.Skip("no types have a wire layout on big-endian machines")
+	}
+	f.Add(int64(0), int32(0), int32(0), 0.0, float32(0), float32(0))
+	f.Add(int64(math.MinInt64), int32(-1), int32(math.MaxInt32), math.NaN(), float32(1.5), float32(-2.5))
+	f.Fuzz(func(t *testing.T, a int64, b0, b1 int32, c float64, re, im float32) {
+		p := packed{A: a, B: [2]int32{b0, b1}, C: c, D: complex(re, im)}
+		checkFixed(t, []packed{p, {}, p}, encodePacked, decodePacked)
+	})
+}
+
+func BenchmarkFixed(b *testing.B) {
+	values := make([]float64, 1024)
+	for i := range values {
+		values[i] = float64(i)
+	}
+	b.Run("Loop", func(b *testing.B) {
+		enc := NewEncoder()
+		for i := 0; i < b.N; i++ {
+			enc.Reset(0)
+			for _, v := range values {
+				enc.Float64(v)
+			}
+		}
+	})
+	b.Run("Fixed", func(b *testing.B) {
+		enc := NewEncoder()
+		for i := 0; i < b.N; i++ {
+			enc.Reset(0)
+			EncodeFixed(enc, values)
+		}
+	})
+}
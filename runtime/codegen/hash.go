@@ -17,6 +17,7 @@ package codegen
 import (
 	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"math"
 )
 
@@ -91,3 +92,14 @@ func (h *Hasher) WriteUint32(v uint32) { h.enc.Uint32(v) }
 
 // WriteUint64 adds a uint64 to the hasher.
 func (h *Hasher) WriteUint64(v uint64) { h.enc.Uint64(v) }
+
+// CanonicalHash returns the hex-encoded SHA-256 hash of an encoded payload.
+//
+// Generated encoders, and EncodeValue, write map entries sorted by key, so
+// equal values always encode to the same bytes, in every process and on every
+// run. As a result, CanonicalHash can be used to compare payloads, e.g., to
+// check that calls with the same idempotency key have the same arguments.
+func CanonicalHash(payload []byte) string {
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
@@ -45,3 +45,33 @@ func TestHash(t *testing.T) {
 		t.Errorf("unstable hash value %016x (expecting %016x)", a, expected)
 	}
 }
+
+func TestCanonicalHash(t *testing.T) {
+	encode := func(m map[string]int) []byte {
+		enc := NewEncoder()
+		enc.Len(len(m))
+		entries := enc.Map()
+		for k, v := range m {
+			entries.Key()
+			enc.String(k)
+			entries.Value()
+			enc.Int(v)
+		}
+		entries.End()
+		return enc.Data()
+	}
+	m := map[string]int{}
+	for i := 0; i < 100; i++ {
+		m[string(rune('a'+i%26))+string(rune('a'+i/26))] = i
+	}
+	want := CanonicalHash(encode(m))
+	for i := 0; i < 10; i++ {
+		if got := CanonicalHash(encode(m)); got != want {
+			t.Fatalf("CanonicalHash: got %s, want %s", got, want)
+		}
+	}
+	m["zz"] = 0
+	if got := CanonicalHash(encode(m)); got == want {
+		t.Fatalf("CanonicalHash: unchanged hash %s for different payload", got)
+	}
+}
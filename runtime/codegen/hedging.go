@@ -0,0 +1,79 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+	"sync"
+)
+
+// hedgingBudgetKey is the context key for a hedging budget.
+type hedgingBudgetKey struct{}
+
+// HedgingBudget is the number of hedged calls that a request may still make,
+// across every component it reaches. It is safe for concurrent use.
+type HedgingBudget struct {
+	mu     sync.Mutex
+	tokens int
+}
+
+// NewHedgingBudget returns a budget of n hedged calls.
+func NewHedgingBudget(n int) *HedgingBudget {
+	return &HedgingBudget{tokens: max(n, 0)}
+}
+
+// Take takes one hedged call out of the budget, and reports whether the budget
+// wasn't exhausted.
+func (b *HedgingBudget) Take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens == 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Split moves half of the remaining budget, rounded up, into a new budget, and
+// returns it. The budget of a call is split off the budget of its caller, so
+// that the hedged calls of a request never exceed its initial budget, however
+// deep its call graph.
+func (b *HedgingBudget) Split() *HedgingBudget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := (b.tokens + 1) / 2
+	b.tokens -= n
+	return &HedgingBudget{tokens: n}
+}
+
+// Remaining returns the number of hedged calls left in the budget.
+func (b *HedgingBudget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
+
+// WithHedgingBudget returns a context that carries the provided hedging
+// budget. See weaver.WithHedgingBudget.
+func WithHedgingBudget(ctx context.Context, budget *HedgingBudget) context.Context {
+	return context.WithValue(ctx, hedgingBudgetKey{}, budget)
+}
+
+// HedgingBudgetFromContext returns the hedging budget recorded in ctx by
+// WithHedgingBudget, or nil if there is none.
+func HedgingBudgetFromContext(ctx context.Context) *HedgingBudget {
+	budget, _ := ctx.Value(hedgingBudgetKey{}).(*HedgingBudget)
+	return budget
+}
@@ -0,0 +1,132 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// "weaver generate" records a hash of every component interface in the
+// generated Registration (see Registration.IfaceHash). At startup, the hash is
+// compared with the hash of the interface linked into the binary. A mismatch
+// means that the interface changed after the code was generated.
+//
+// The hash is the first 16 bytes of the hex encoding of the SHA-256 of the
+// interface's methods, sorted by name, one per line. A method is written as
+// its name followed by its signature, e.g., "Get(context.Context,string)(int,error)".
+// Types are written as follows:
+//
+//   - Named types are written as their package path, a dot, and their name,
+//     without type arguments, e.g., "context.Context" or "example.com/foo.T".
+//     Predeclared types are written as their name, e.g., "int" or "error".
+//     Aliases are written as the types they alias, so byte is written as
+//     uint8, and any is written as interface{}.
+//   - Other types are written like Go types, with types written recursively,
+//     without spaces between elements, and without struct tags or parameter
+//     names, e.g., "[]*example.com/foo.T" or "struct{A int;B string}".
+//     Interface types list their methods, written like interface methods.
+//
+// The generator computes the same string from go/types types.
+
+// InterfaceHash returns the hash of the provided component interface.
+func InterfaceHash(t reflect.Type) string {
+	methods := make([]string, t.NumMethod())
+	for i := range methods {
+		m := t.Method(i)
+		methods[i] = m.Name + signatureString(m.Type) + "\n"
+	}
+	sort.Strings(methods)
+	return HashInterfaceString(strings.Join(methods, ""))
+}
+
+// HashInterfaceString returns the hash of the string representation of an
+// interface, described above.
+func HashInterfaceString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%0x", sum)[:16]
+}
+
+// typeString returns the string representation of a type, described above.
+func typeString(t reflect.Type) string {
+	if t.Name() != "" {
+		name, _, _ := strings.Cut(t.Name(), "[")
+		if t.PkgPath() == "" {
+			return name
+		}
+		return t.PkgPath() + "." + name
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + typeString(t.Elem())
+	case reflect.Slice:
+		return "[]" + typeString(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), typeString(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", typeString(t.Key()), typeString(t.Elem()))
+	case reflect.Chan:
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + typeString(t.Elem())
+		case reflect.SendDir:
+			return "chan<- " + typeString(t.Elem())
+		default:
+			return "chan " + typeString(t.Elem())
+		}
+	case reflect.Func:
+		return "func" + signatureString(t)
+	case reflect.Struct:
+		fields := make([]string, t.NumField())
+		for i := range fields {
+			f := t.Field(i)
+			fields[i] = f.Name + " " + typeString(f.Type)
+		}
+		return "struct{" + strings.Join(fields, ";") + "}"
+	case reflect.Interface:
+		methods := make([]string, t.NumMethod())
+		for i := range methods {
+			m := t.Method(i)
+			methods[i] = m.Name + signatureString(m.Type)
+		}
+		sort.Strings(methods)
+		return "interface{" + strings.Join(methods, ";") + "}"
+	default:
+		// Unnamed basic types don't exist.
+		return t.String()
+	}
+}
+
+// signatureString returns the string representation of the signature of the
+// provided function type, e.g., "(int,...string)(error)".
+func signatureString(t reflect.Type) string {
+	args := make([]string, t.NumIn())
+	for i := range args {
+		if t.IsVariadic() && i == len(args)-1 {
+			args[i] = "..." + typeString(t.In(i).Elem())
+		} else {
+			args[i] = typeString(t.In(i))
+		}
+	}
+	results := make([]string, t.NumOut())
+	for i := range results {
+		results[i] = typeString(t.Out(i))
+	}
+	return "(" + strings.Join(args, ",") + ")(" + strings.Join(results, ",") + ")"
+}
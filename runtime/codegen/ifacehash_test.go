@@ -0,0 +1,47 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+type hashed interface {
+	Get(context.Context, string) ([]byte, error)
+	Put(ctx context.Context, kvs map[string]*time.Duration, opts ...struct{ N int }) error
+	Watch(<-chan any, func(int32) bool) (chan<- [2]interface{ Close() error }, error)
+}
+
+func TestInterfaceHash(t *testing.T) {
+	const want = "Get(context.Context,string)([]uint8,error)\n" +
+		"Put(context.Context,map[string]*time.Duration,...struct{N int})(error)\n" +
+		"Watch(<-chan interface{},func(int32)(bool))(chan<- [2]interface{Close()(error)},error)\n"
+	got := codegen.InterfaceHash(reflection.Type[hashed]())
+	if got != codegen.HashInterfaceString(want) {
+		t.Fatalf("InterfaceHash: got %s, want hash of\n%s", got, want)
+	}
+
+	type changed interface {
+		Get(context.Context, string) (string, error)
+	}
+	if codegen.InterfaceHash(reflection.Type[changed]()) == got {
+		t.Fatal("InterfaceHash: changed interface has the same hash")
+	}
+}
@@ -17,6 +17,8 @@ package codegen
 import (
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/internal/flightrecorder"
 	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
 	"github.com/ServiceWeaver/weaver/metrics"
 )
@@ -58,7 +60,8 @@ type MethodLabels struct {
 
 // MethodMetrics contains metrics for a single Service Weaver component method.
 type MethodMetrics struct {
-	remote       bool
+	labels       MethodLabels
+	record       bool               // Record calls in the flight recorder?
 	count        *metrics.Counter   // See MethodCounts.
 	errorCount   *metrics.Counter   // See MethodErrors.
 	latency      *metrics.Histogram // See MethodLatencies.
@@ -69,7 +72,8 @@ type MethodMetrics struct {
 // MethodMetricsFor returns metrics for the specified method.
 func MethodMetricsFor(labels MethodLabels) *MethodMetrics {
 	return &MethodMetrics{
-		remote:       labels.Remote,
+		labels:       labels,
+		record:       labels.Component != control.WeaveletPath && labels.Component != control.DeployerPath,
 		count:        methodCounts.Get(labels),
 		errorCount:   methodErrors.Get(labels),
 		latency:      methodLatencies.Get(labels),
@@ -82,22 +86,47 @@ func MethodMetricsFor(labels MethodLabels) *MethodMetrics {
 // updates for a method call.
 type MethodCallHandle struct {
 	start time.Time
+	id    uint64 // flight recorder call id
 }
 
 // Begin starts metric update recording for a call to method m.
 func (m *MethodMetrics) Begin() MethodCallHandle {
-	return MethodCallHandle{time.Now()}
+	h := MethodCallHandle{start: time.Now()}
+	if m.record {
+		h.id = flightrecorder.Default.Begin(flightrecorder.Call{
+			Start:     h.start,
+			Caller:    m.labels.Caller,
+			Component: m.labels.Component,
+			Method:    m.labels.Method,
+			Remote:    m.labels.Remote,
+		})
+	}
+	return h
 }
 
-// End ends metric update recording for a call to method m.
+// End ends metric update recording for a call to method m. Calls to
+// application components are also recorded by the flight recorder.
 func (m *MethodMetrics) End(h MethodCallHandle, failed bool, requestBytes, replyBytes int) {
-	latency := time.Since(h.start).Microseconds()
+	now := time.Now()
+	elapsed := now.Sub(h.start)
+	latency := elapsed.Microseconds()
 	m.count.Inc()
 	if failed {
 		m.errorCount.Inc()
 	}
 	m.latency.Put(float64(latency))
-	if m.remote {
+	if m.record {
+		flightrecorder.Default.End(h.id, flightrecorder.Event{
+			Time:      now,
+			Caller:    m.labels.Caller,
+			Component: m.labels.Component,
+			Method:    m.labels.Method,
+			Latency:   elapsed,
+			Failed:    failed,
+			Remote:    m.labels.Remote,
+		})
+	}
+	if m.labels.Remote {
 		m.bytesRequest.Put(float64(requestBytes))
 		m.bytesReply.Put(float64(replyBytes))
 	}
@@ -0,0 +1,122 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"math/big"
+	"net/netip"
+	"time"
+)
+
+// This file contains encoders and decoders for commonly used standard library
+// types that "weaver generate" serializes natively. time.Duration is not
+// listed here; like other named integer types, it is encoded as its underlying
+// int64.
+
+// Time encodes a time.Time as its Unix time in seconds, its nanosecond offset
+// within that second, and its zone. The monotonic clock reading of arg, if
+// any, is not encoded, so a decoded time never has one. Times in UTC are
+// decoded in UTC. Other times are decoded in time.Local, if time.Local has the
+// same zone name and offset at that instant, or in a fixed zone with the
+// encoded name and offset otherwise.
+func (e *Encoder) Time(arg time.Time) {
+	e.Int64(arg.Unix())
+	e.Int32(int32(arg.Nanosecond()))
+	if arg.Location() == time.UTC {
+		e.Bool(false)
+		return
+	}
+	name, offset := arg.Zone()
+	e.Bool(true)
+	e.String(name)
+	e.Int32(int32(offset))
+}
+
+// Time decodes a time.Time encoded by Encoder.Time.
+func (d *Decoder) Time() time.Time {
+	sec := d.Int64()
+	nsec := d.Int32()
+	if nsec < 0 || nsec >= 1e9 {
+		panic(makeDecodeError("unable to decode time; invalid nanoseconds %d", nsec))
+	}
+	t := time.Unix(sec, int64(nsec))
+	if !d.Bool() {
+		return t.UTC()
+	}
+	name := d.String()
+	offset := int(d.Int32())
+	if n, o := t.Zone(); n == name && o == offset {
+		// t is in time.Local.
+		return t
+	}
+	return t.In(time.FixedZone(name, offset))
+}
+
+// Addr encodes a netip.Addr in the format of its MarshalBinary method.
+func (e *Encoder) Addr(arg netip.Addr) {
+	// MarshalBinary never fails.
+	b, _ := arg.MarshalBinary()
+	e.Bytes(b)
+}
+
+// Addr decodes a netip.Addr encoded by Encoder.Addr.
+func (d *Decoder) Addr() netip.Addr {
+	var addr netip.Addr
+	if err := addr.UnmarshalBinary(d.Bytes()); err != nil {
+		panic(makeDecodeError("unable to decode netip.Addr: %w", err))
+	}
+	return addr
+}
+
+// Prefix encodes a netip.Prefix in the format of its MarshalBinary method.
+func (e *Encoder) Prefix(arg netip.Prefix) {
+	// MarshalBinary never fails.
+	b, _ := arg.MarshalBinary()
+	e.Bytes(b)
+}
+
+// Prefix decodes a netip.Prefix encoded by Encoder.Prefix.
+func (d *Decoder) Prefix() netip.Prefix {
+	var prefix netip.Prefix
+	if err := prefix.UnmarshalBinary(d.Bytes()); err != nil {
+		panic(makeDecodeError("unable to decode netip.Prefix: %w", err))
+	}
+	return prefix
+}
+
+// BigInt encodes a *big.Int as whether it is nil, its sign, and the
+// big-endian bytes of its absolute value.
+func (e *Encoder) BigInt(arg *big.Int) {
+	if arg == nil {
+		e.Bool(false)
+		return
+	}
+	e.Bool(true)
+	e.Bool(arg.Sign() < 0)
+	e.Bytes(arg.Bytes())
+}
+
+// BigInt decodes a *big.Int encoded by Encoder.BigInt.
+func (d *Decoder) BigInt() *big.Int {
+	if !d.Bool() {
+		return nil
+	}
+	neg := d.Bool()
+	x := new(big.Int).SetBytes(d.Bytes())
+	if neg {
+		x.Neg(x)
+	}
+	return x
+}
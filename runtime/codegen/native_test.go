@@ -0,0 +1,124 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeTime(t *testing.T) {
+	est := time.FixedZone("EST", -5*60*60)
+	for _, want := range []time.Time{
+		{},
+		time.Unix(0, 0).UTC(),
+		time.Date(2024, 2, 29, 12, 30, 15, 123456789, time.UTC),
+		time.Date(1900, 1, 1, 0, 0, 0, 1, est),
+		time.Date(2024, 2, 29, 12, 30, 15, 0, time.Local),
+	} {
+		t.Run(want.String(), func(t *testing.T) {
+			enc := NewEncoder()
+			enc.Time(want)
+			got := NewDecoder(enc.Data()).Time()
+			if !got.Equal(want) {
+				t.Fatalf("got %v, want %v", got, want)
+			}
+			if (got.Location() == time.UTC) != (want.Location() == time.UTC) {
+				t.Fatalf("got location %v, want %v", got.Location(), want.Location())
+			}
+			gotName, gotOffset := got.Zone()
+			wantName, wantOffset := want.Zone()
+			if gotName != wantName || gotOffset != wantOffset {
+				t.Fatalf("got zone (%s, %d), want (%s, %d)", gotName, gotOffset, wantName, wantOffset)
+			}
+		})
+	}
+}
+
+func TestEncodeDecodeTimeMonotonic(t *testing.T) {
+	// The monotonic clock reading is dropped.
+	now := time.Now()
+	enc := NewEncoder()
+	enc.Time(now)
+	got := NewDecoder(enc.Data()).Time()
+	if want := now.Round(0); got != want {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestErrorUnableToDecTime(t *testing.T) {
+	err := convertCallPanicToError(func() {
+		enc := NewEncoder()
+		enc.Int64(0)
+		enc.Int32(-1)
+		enc.Bool(false)
+		NewDecoder(enc.Data()).Time()
+	})
+	if err == nil || !strings.Contains(err.Error(), "invalid nanoseconds") {
+		t.Fatalf("got %v, want invalid nanoseconds error", err)
+	}
+}
+
+func TestEncodeDecodeNetip(t *testing.T) {
+	for _, want := range []netip.Addr{
+		{},
+		netip.MustParseAddr("127.0.0.1"),
+		netip.MustParseAddr("::1"),
+		netip.MustParseAddr("fe80::1%eth0"),
+	} {
+		enc := NewEncoder()
+		enc.Addr(want)
+		if got := NewDecoder(enc.Data()).Addr(); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+	for _, want := range []netip.Prefix{
+		{},
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	} {
+		enc := NewEncoder()
+		enc.Prefix(want)
+		if got := NewDecoder(enc.Data()).Prefix(); got != want {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestErrorUnableToDecAddr(t *testing.T) {
+	err := convertCallPanicToError(func() {
+		enc := NewEncoder()
+		enc.Bytes([]byte{1, 2, 3})
+		NewDecoder(enc.Data()).Addr()
+	})
+	if err == nil || !strings.Contains(err.Error(), "unable to decode netip.Addr") {
+		t.Fatalf("got %v, want netip.Addr decoding error", err)
+	}
+}
+
+func TestEncodeDecodeBigInt(t *testing.T) {
+	huge, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	for _, want := range []*big.Int{nil, big.NewInt(0), big.NewInt(42), big.NewInt(-42), huge} {
+		enc := NewEncoder()
+		enc.BigInt(want)
+		got := NewDecoder(enc.Data()).BigInt()
+		if (got == nil) != (want == nil) || (got != nil && got.Cmp(want) != 0) {
+			t.Errorf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/metrics"
+)
+
+// Serialization buffers are a top source of allocations in applications that
+// make a lot of remote calls. Pooled encoders (see NewPooledEncoder) reuse
+// their buffers across calls. Buffers are bucketed into size classes that are
+// powers of two, from minPooledSize to maxPooledSize bytes. Larger buffers are
+// never pooled. Decoders read from buffers they don't own, so there is
+// nothing to pool for them.
+
+const (
+	minPooledSize  = 1 << 10 // 1 KiB
+	maxPooledSize  = 1 << 20 // 1 MiB
+	numSizeClasses = 11      // log2(maxPooledSize / minPooledSize) + 1
+)
+
+// bufferPools[i] stores buffers with a capacity of exactly minPooledSize << i
+// bytes.
+var bufferPools [numSizeClasses]sync.Pool
+
+// BufferPoolLabels are the labels of buffer pool metrics.
+type BufferPoolLabels struct {
+	SizeClass int  // capacity, in bytes, of the pooled buffers, or 0 for oversized buffers
+	Generated bool `weaver:"serviceweaver_generated"` // always true
+}
+
+var (
+	bufferPoolGets = metrics.NewCounterMap[BufferPoolLabels](
+		"serviceweaver_codegen_buffer_pool_gets",
+		"Number of serialization buffers requested from the buffer pool",
+	)
+	bufferPoolHits = metrics.NewCounterMap[BufferPoolLabels](
+		"serviceweaver_codegen_buffer_pool_hits",
+		"Number of serialization buffer requests served by reusing a pooled buffer",
+	)
+
+	// Counters, by size class. The last entry is for oversized buffers.
+	gets, hits [numSizeClasses + 1]*metrics.Counter
+)
+
+func init() {
+	for i := range gets {
+		labels := BufferPoolLabels{Generated: true}
+		if i < numSizeClasses {
+			labels.SizeClass = minPooledSize << i
+		}
+		gets[i] = bufferPoolGets.Get(labels)
+		hits[i] = bufferPoolHits.Get(labels)
+	}
+}
+
+// sizeClass returns the index of the smallest size class that can hold n
+// bytes, or numSizeClasses if n is larger than maxPooledSize.
+func sizeClass(n int) int {
+	class, size := 0, minPooledSize
+	for size < n && class < numSizeClasses {
+		class++
+		size <<= 1
+	}
+	return class
+}
+
+// getBuffer returns an empty buffer with a capacity of at least n bytes.
+func getBuffer(n int) []byte {
+	class := sizeClass(n)
+	gets[class].Inc()
+	if class == numSizeClasses {
+		return make([]byte, 0, n)
+	}
+	if b, ok := bufferPools[class].Get().(*[]byte); ok {
+		hits[class].Inc()
+		return (*b)[:0]
+	}
+	return make([]byte, 0, minPooledSize<<class)
+}
+
+// putBuffer returns b to its buffer pool, if b was returned by getBuffer and
+// is not oversized. The caller must not use b afterwards.
+func putBuffer(b []byte) {
+	c := cap(b)
+	if c < minPooledSize || c > maxPooledSize || c&(c-1) != 0 {
+		// Builtin, oversized, or not allocated by getBuffer.
+		return
+	}
+	b = b[:0]
+	bufferPools[sizeClass(c)].Put(&b)
+}
+
+// NewPooledEncoder returns a new Encoder that takes its buffers from, and
+// returns them to, a buffer pool. Call Release once the encoded data is no
+// longer used.
+func NewPooledEncoder() *Encoder {
+	enc := NewEncoder()
+	enc.pooled = true
+	return enc
+}
+
+// Release returns the encoder's buffer to the buffer pool, if the encoder was
+// returned by NewPooledEncoder. The data previously returned by Data must not
+// be used after the call to Release. The encoder itself may be reused.
+func (e *Encoder) Release() {
+	if !e.pooled {
+		return
+	}
+	putBuffer(e.data)
+	e.data = e.space[:0]
+}
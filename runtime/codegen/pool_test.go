@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSizeClass(t *testing.T) {
+	for _, test := range []struct{ n, want int }{
+		{0, 0},
+		{1, 0},
+		{minPooledSize, 0},
+		{minPooledSize + 1, 1},
+		{2 * minPooledSize, 1},
+		{maxPooledSize, numSizeClasses - 1},
+		{maxPooledSize + 1, numSizeClasses},
+	} {
+		if got := sizeClass(test.n); got != test.want {
+			t.Errorf("sizeClass(%d): got %d, want %d", test.n, got, test.want)
+		}
+	}
+}
+
+func TestGetBuffer(t *testing.T) {
+	for _, n := range []int{0, 1, 1000, 5000, maxPooledSize, maxPooledSize + 1} {
+		b := getBuffer(n)
+		if len(b) != 0 || cap(b) < n {
+			t.Errorf("getBuffer(%d): got len %d, cap %d", n, len(b), cap(b))
+		}
+		putBuffer(b)
+	}
+}
+
+func TestPooledEncoder(t *testing.T) {
+	// Encode payloads of increasing size, so that the encoder grows through
+	// several size classes, and check that they decode correctly.
+	enc := NewPooledEncoder()
+	for _, n := range []int{10, 1000, 10000, 100000, 2 * maxPooledSize} {
+		want := bytes.Repeat([]byte{byte(n)}, n)
+		enc.Reset(0)
+		enc.Int(n)
+		enc.Bytes(want)
+		dec := NewDecoder(enc.Data())
+		if got := dec.Int(); got != n {
+			t.Fatalf("Int: got %d, want %d", got, n)
+		}
+		if got := dec.Bytes(); !bytes.Equal(got, want) {
+			t.Fatalf("Bytes: got %d bytes, want %d", len(got), n)
+		}
+		enc.Release()
+		if len(enc.Data()) != 0 {
+			t.Fatalf("Data after Release: got %d bytes, want 0", len(enc.Data()))
+		}
+	}
+}
+
+func BenchmarkPooledEncoder(b *testing.B) {
+	payload := make([]byte, 16*minPooledSize)
+	for _, pooled := range []bool{false, true} {
+		name := "Unpooled"
+		if pooled {
+			name = "Pooled"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				enc := NewEncoder()
+				if pooled {
+					enc = NewPooledEncoder()
+				}
+				enc.Bytes(payload)
+				enc.Release()
+			}
+		})
+	}
+}
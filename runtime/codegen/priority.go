@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codegen
+
+import (
+	"context"
+	"slices"
+)
+
+// priorityKey is the context key for a call priority.
+type priorityKey struct{}
+
+// admittedKey is the context key for the weavelets that admitted a call.
+type admittedKey struct{}
+
+// WithPriority returns a context that carries the provided call priority. See
+// weaver.WithPriority.
+func WithPriority(ctx context.Context, priority int) context.Context {
+	return context.WithValue(ctx, priorityKey{}, priority)
+}
+
+// PriorityFromContext returns the call priority recorded in ctx by
+// WithPriority, if any.
+func PriorityFromContext(ctx context.Context) (int, bool) {
+	priority, ok := ctx.Value(priorityKey{}).(int)
+	return priority, ok
+}
+
+// WithAdmitted returns a context that records that the call scheduler of the
+// weavelet with the provided id admitted a call, in addition to the weavelets
+// already recorded in ctx. Calls made with the returned context, and the calls
+// they make in turn, bypass the scheduler of that weavelet, so that a call
+// that comes back to a weavelet doesn't wait for the call that made it.
+func WithAdmitted(ctx context.Context, weavelet string) context.Context {
+	admitted := AdmittedFromContext(ctx)
+	if slices.Contains(admitted, weavelet) {
+		return ctx
+	}
+	return context.WithValue(ctx, admittedKey{}, append(slices.Clip(admitted), weavelet))
+}
+
+// AdmittedFromContext returns the weavelets recorded in ctx by WithAdmitted.
+func AdmittedFromContext(ctx context.Context) []string {
+	admitted, _ := ctx.Value(admittedKey{}).([]string)
+	return admitted
+}
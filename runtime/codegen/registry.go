@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"reflect"
 	"strings"
 	"sync"
@@ -29,29 +30,39 @@ import (
 )
 
 // globalRegistry is the global registry used by Register and Registered.
-var globalRegistry registry
+var globalRegistry Registry
 
 // Register registers a Service Weaver component.
 func Register(reg Registration) {
-	if err := globalRegistry.register(reg); err != nil {
+	if err := globalRegistry.Register(reg); err != nil {
 		panic(err)
 	}
 }
 
 // Registered returns the components registered with Register.
 func Registered() []*Registration {
-	return globalRegistry.allComponents()
+	return globalRegistry.Registered()
 }
 
 // Find returns the registration of the named component.
 func Find(name string) (*Registration, bool) {
-	return globalRegistry.find(name)
+	return globalRegistry.Find(name)
 }
 
-// registry is a repository for registered Service Weaver components.
-// Entries are typically added to the default registry by calls
-// to Register in init functions in code generated by "weaver generate".
-type registry struct {
+// Global returns the global registry used by Register, Registered, and Find.
+// It holds every component linked into the binary.
+func Global() *Registry {
+	return &globalRegistry
+}
+
+// Registry is a repository for registered Service Weaver components.
+// Entries are typically added to the global registry by calls to Register in
+// init functions in code generated by "weaver generate". A registry with a
+// subset of the components, like the components of one of several
+// applications linked into the same binary, can be obtained with Subset.
+//
+// The zero value is an empty registry.
+type Registry struct {
 	m          sync.Mutex
 	components map[reflect.Type]*Registration // the set of registered components, by their interface types
 	byName     map[string]*Registration       // map from full component name to registration
@@ -59,12 +70,17 @@ type registry struct {
 
 // Registration is the configuration needed to register a Service Weaver component.
 type Registration struct {
-	Name      string       // full package-prefixed component name
-	Iface     reflect.Type // interface type for the component
-	Impl      reflect.Type // implementation type (struct)
-	Routed    bool         // True if calls to this component should be routed
-	Listeners []string     // the names of any weaver.Listeners
-	NoRetry   []int        // indices of methods that should not be retried
+	Name       string       // full package-prefixed component name
+	Iface      reflect.Type // interface type for the component
+	Impl       reflect.Type // implementation type (struct)
+	Routed     bool         // True if calls to this component should be routed
+	Listeners  []string     // the names of any weaver.Listeners
+	NoRetry    []int        // indices of methods that should not be retried
+	AtMostOnce []int        // indices of methods that should be called at most once
+
+	// Directives holds the //weaver: comment directives of the component's
+	// methods, by method name. See Directive.
+	Directives map[string][]Directive
 
 	// Functions that return different types of stubs.
 	LocalStubFn   func(impl any, caller string, tracer trace.Tracer) any
@@ -75,11 +91,19 @@ type Registration struct {
 	// RefData holds a string containing the result of MakeEdgeString(Name, Dst)
 	// for all components named Dst used by this component.
 	RefData string
+
+	// Versions of the "weaver generate" tool and of the code it generated,
+	// and the hash of Iface (see InterfaceHash) when the code was generated.
+	// These fields are empty for code generated by older versions of
+	// "weaver generate".
+	GeneratorVersion string // e.g., "v0.22.0" or "(devel)"
+	CodegenVersion   string // e.g., "v0.27.0"
+	IfaceHash        string
 }
 
-// register registers a Service Weaver component. If the registry's close method was
-// previously called, Register will fail and return a non-nil error.
-func (r *registry) register(reg Registration) error {
+// Register registers a Service Weaver component. It returns an error if a
+// component with the same interface type is already registered.
+func (r *Registry) Register(reg Registration) error {
 	if err := verifyRegistration(reg); err != nil {
 		return fmt.Errorf("Register(%q): %w", reg.Name, err)
 	}
@@ -127,8 +151,8 @@ func verifyRegistration(reg Registration) error {
 	return nil
 }
 
-// allComponents returns all of the registered components, keyed by name.
-func (r *registry) allComponents() []*Registration {
+// Registered returns all of the registered components.
+func (r *Registry) Registered() []*Registration {
 	r.m.Lock()
 	defer r.m.Unlock()
 
@@ -139,20 +163,57 @@ func (r *registry) allComponents() []*Registration {
 	return components
 }
 
-func (r *registry) find(path string) (*Registration, bool) {
+// Find returns the registration of the named component.
+func (r *Registry) Find(path string) (*Registration, bool) {
 	r.m.Lock()
 	defer r.m.Unlock()
 	reg, ok := r.byName[path]
 	return reg, ok
 }
 
+// Subset returns a new registry with the named components and every component
+// they transitively reference, except that the references of the components
+// for which leaf returns true are not followed. leaf may be nil.
+func (r *Registry) Subset(names []string, leaf func(name string) bool) (*Registry, error) {
+	sub := &Registry{}
+	visit := append([]string(nil), names...)
+	for len(visit) > 0 {
+		name := visit[0]
+		visit = visit[1:]
+		if _, ok := sub.Find(name); ok {
+			continue
+		}
+		reg, ok := r.Find(name)
+		if !ok {
+			return nil, fmt.Errorf("component %s not registered", name)
+		}
+		if err := sub.Register(*reg); err != nil {
+			return nil, err
+		}
+		if leaf != nil && leaf(name) {
+			continue
+		}
+		for _, edge := range ExtractEdges([]byte(reg.RefData)) {
+			visit = append(visit, edge[1])
+		}
+	}
+	return sub, nil
+}
+
 // ComponentConfigValidator checks that cfg is a valid configuration
 // for the component type whose fully qualified name is given by path.
 //
 // TODO(mwhittaker): Move out of codegen package? It's not used by the
 // generated code.
 func ComponentConfigValidator(path, cfg string) error {
-	info, ok := globalRegistry.find(path)
+	return globalRegistry.ConfigValidator(path, cfg)
+}
+
+// ConfigValidator checks that cfg is a valid configuration for the component
+// in r whose fully qualified name is given by path. Configurations for
+// components that are not in r are ignored.
+func (r *Registry) ConfigValidator(path, cfg string) error {
+	info, ok := r.Find(path)
 	if !ok {
 		// Not for a known component.
 		return nil
@@ -177,12 +238,22 @@ func ComponentConfigValidator(path, cfg string) error {
 type CallEdge struct {
 	Caller reflect.Type
 	Callee reflect.Type
+
+	// The calls from methods of Caller to methods of Callee found by static
+	// analysis during "weaver generate", sorted by position. Calls made
+	// through values that are not statically known to be components, like
+	// values stored in an interface of another type, are missing.
+	Calls []MethodCall
 }
 
 // CallGraph returns the component call graph (as a list of CallEdge values).
 func CallGraph() []CallEdge {
 	var result []CallEdge
 	for _, reg := range Registered() {
+		calls := map[string][]MethodCall{} // by callee
+		for _, call := range ExtractCalls([]byte(reg.RefData)) {
+			calls[call.Callee] = append(calls[call.Callee], call)
+		}
 		impl := reg.Impl
 		for i, n := 0, impl.NumField(); i < n; i++ {
 			// Handle field with type weaver.Ref[T].
@@ -190,9 +261,11 @@ func CallGraph() []CallEdge {
 			if ref.PkgPath() == "github.com/ServiceWeaver/weaver" &&
 				strings.HasPrefix(ref.Name(), "Ref[") &&
 				ref.Kind() == reflect.Struct &&
-				ref.NumField() == 1 &&
+				ref.NumField() > 0 &&
 				ref.Field(0).Name == "value" {
-				result = append(result, CallEdge{reg.Iface, ref.Field(0).Type})
+				callee := ref.Field(0).Type
+				name := path.Join(callee.PkgPath(), callee.Name())
+				result = append(result, CallEdge{reg.Iface, callee, calls[name]})
 			}
 		}
 	}
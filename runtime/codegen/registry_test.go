@@ -80,6 +80,67 @@ func TestCallGraph(t *testing.T) {
 	}
 }
 
+func TestSubset(t *testing.T) {
+	// Register A -> B and C in a new registry.
+	reg := func(name string, intf, impl reflect.Type, refs ...string) codegen.Registration {
+		var refData strings.Builder
+		for _, ref := range refs {
+			refData.WriteString(codegen.MakeEdgeString(name, ref))
+		}
+		return codegen.Registration{
+			Name:         name,
+			Iface:        intf,
+			Impl:         impl,
+			LocalStubFn:  func(any, string, trace.Tracer) any { return nil },
+			ClientStubFn: func(codegen.Stub, string) any { return nil },
+			ServerStubFn: func(any, func(uint64, float64)) codegen.Server { return nil },
+			RefData:      refData.String(),
+		}
+	}
+	var r codegen.Registry
+	for _, reg := range []codegen.Registration{
+		reg("codegen_test/A", reflection.Type[A](), reflection.Type[aimpl](), "codegen_test/B"),
+		reg("codegen_test/B", reflection.Type[B](), reflection.Type[bimpl]()),
+		reg(typeWithoutConfig, reflection.Type[componentWithoutConfig](), reflection.Type[componentWithoutConfigImpl]()),
+	} {
+		if err := r.Register(reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	names := func(r *codegen.Registry) map[string]bool {
+		got := map[string]bool{}
+		for _, reg := range r.Registered() {
+			got[reg.Name] = true
+		}
+		return got
+	}
+	for _, test := range []struct {
+		name  string
+		roots []string
+		leaf  func(string) bool
+		want  map[string]bool
+	}{
+		{"Transitive", []string{"codegen_test/A"}, nil, map[string]bool{"codegen_test/A": true, "codegen_test/B": true}},
+		{"Leaf", []string{"codegen_test/A"}, func(name string) bool { return name == "codegen_test/A" }, map[string]bool{"codegen_test/A": true}},
+		{"Unreferenced", []string{"codegen_test/B"}, nil, map[string]bool{"codegen_test/B": true}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			sub, err := r.Subset(test.roots, test.leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := names(sub); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("Subset(%v): got %v, want %v", test.roots, got, test.want)
+			}
+		})
+	}
+
+	if _, err := r.Subset([]string{"codegen_test/Missing"}, nil); err == nil {
+		t.Fatal("Subset: unexpected success for unregistered component")
+	}
+}
+
 const (
 	typeWithoutConfig = "codegen_test/withoutConfig"
 	typeWithConfig    = "codegen_test/withConfig"
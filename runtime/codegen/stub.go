@@ -33,7 +33,8 @@ type Stub interface {
 	// At code generation time, an object's methods are deterministically
 	// ordered. method is the index into this slice. args and results are the
 	// serialized arguments and results, respectively. shardKey is the shard
-	// key for routed components, and 0 otherwise.
+	// key for routed components, and 0 otherwise. Run must not use args after
+	// it returns, as the caller may reuse the buffer.
 	Run(ctx context.Context, method int, args []byte, shardKey uint64) (results []byte, err error)
 }
 
@@ -16,6 +16,7 @@ package runtime
 
 import (
 	"fmt"
+	"net"
 	"path/filepath"
 	"strings"
 	"time"
@@ -109,6 +110,19 @@ func extractApp(file string, config *protos.AppConfig) error {
 		Env      []string
 		Colocate [][]string
 		Rollout  time.Duration
+
+		InitTimeout  time.Duration            `toml:"init_timeout"`
+		InitTimeouts map[string]time.Duration `toml:"init_timeouts"`
+		Lazy         []string
+		Audit        []string
+		LargePayload int64 `toml:"large_payload_bytes"`
+		Compress     int64 `toml:"compress_payload_bytes"`
+		MaxMessage   int64 `toml:"max_message_bytes"`
+		Checksum     bool  `toml:"checksum_payloads"`
+
+		IdempotencyWindow time.Duration `toml:"idempotency_window"`
+		AtMostOnce        []string      `toml:"at_most_once"`
+		GRPCAddress       string        `toml:"grpc_address"`
 	}
 
 	parsed := &appConfig{}
@@ -122,6 +136,56 @@ func extractApp(file string, config *protos.AppConfig) error {
 	config.Args = parsed.Args
 	config.Env = parsed.Env
 	config.RolloutNanos = int64(parsed.Rollout)
+	if parsed.InitTimeout < 0 {
+		return fmt.Errorf("invalid init_timeout %v: must be non-negative", parsed.InitTimeout)
+	}
+	config.InitTimeoutNanos = int64(parsed.InitTimeout)
+	for component, timeout := range parsed.InitTimeouts {
+		if timeout < 0 {
+			return fmt.Errorf("invalid init_timeout %v for component %q: must be non-negative", timeout, component)
+		}
+		if config.ComponentInitTimeoutNanos == nil {
+			config.ComponentInitTimeoutNanos = map[string]int64{}
+		}
+		config.ComponentInitTimeoutNanos[component] = int64(timeout)
+	}
+	for _, component := range parsed.Lazy {
+		if component == Main {
+			return fmt.Errorf("invalid lazy component %q: the main component cannot be lazy", component)
+		}
+	}
+	config.LazyComponents = parsed.Lazy
+	for _, method := range parsed.Audit {
+		i := strings.LastIndex(method, ".")
+		if i <= 0 || i == len(method)-1 || strings.Contains(method[i+1:], "/") {
+			return fmt.Errorf("invalid audited method %q: want <component>.<method>", method)
+		}
+	}
+	config.AuditedMethods = parsed.Audit
+	for _, method := range parsed.AtMostOnce {
+		i := strings.LastIndex(method, ".")
+		if i <= 0 || i == len(method)-1 || strings.Contains(method[i+1:], "/") {
+			return fmt.Errorf("invalid at-most-once method %q: want <component>.<method>", method)
+		}
+	}
+	config.AtMostOnceMethods = parsed.AtMostOnce
+	config.LargePayloadBytes = parsed.LargePayload
+	config.CompressPayloadBytes = parsed.Compress
+	if parsed.MaxMessage < 0 {
+		return fmt.Errorf("invalid max_message_bytes %d: must be non-negative", parsed.MaxMessage)
+	}
+	config.MaxMessageBytes = parsed.MaxMessage
+	config.ChecksumPayloads = parsed.Checksum
+	if parsed.IdempotencyWindow < 0 {
+		return fmt.Errorf("invalid idempotency_window %v: must be non-negative", parsed.IdempotencyWindow)
+	}
+	config.IdempotencyWindowNanos = int64(parsed.IdempotencyWindow)
+	if parsed.GRPCAddress != "" {
+		if _, _, err := net.SplitHostPort(parsed.GRPCAddress); err != nil {
+			return fmt.Errorf("invalid grpc_address %q: %w", parsed.GRPCAddress, err)
+		}
+	}
+	config.GrpcAddress = parsed.GRPCAddress
 	for _, colocate := range parsed.Colocate {
 		group := &protos.ComponentGroup{Components: colocate}
 		config.Colocate = append(config.Colocate, group)
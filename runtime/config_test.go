@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
@@ -102,6 +103,67 @@ func TestParseConfigSection(t *testing.T) {
 	}
 }
 
+func TestInitTimeouts(t *testing.T) {
+	const config = `
+[serviceweaver]
+init_timeout = "30s"
+
+[serviceweaver.init_timeouts]
+"github.com/foo/Bar" = "2m"
+"github.com/foo/Baz" = "0s"
+`
+	cfg, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := time.Duration(cfg.InitTimeoutNanos), 30*time.Second; got != want {
+		t.Errorf("init timeout: got %v, want %v", got, want)
+	}
+	want := map[string]int64{
+		"github.com/foo/Bar": int64(2 * time.Minute),
+		"github.com/foo/Baz": 0,
+	}
+	if diff := cmp.Diff(want, cfg.ComponentInitTimeoutNanos); diff != "" {
+		t.Errorf("component init timeouts: (-want +got):\n%s", diff)
+	}
+}
+
+func TestLargePayloadBytes(t *testing.T) {
+	const config = `
+[serviceweaver]
+large_payload_bytes = 1048576
+`
+	cfg, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.LargePayloadBytes, int64(1<<20); got != want {
+		t.Errorf("large payload bytes: got %d, want %d", got, want)
+	}
+}
+
+func TestTransportSettings(t *testing.T) {
+	const config = `
+[serviceweaver]
+compress_payload_bytes = 65536
+max_message_bytes = 16777216
+checksum_payloads = true
+`
+	cfg, err := runtime.ParseConfig("weaver.toml", config, codegen.ComponentConfigValidator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.CompressPayloadBytes, int64(64<<10); got != want {
+		t.Errorf("compress payload bytes: got %d, want %d", got, want)
+	}
+	if got, want := cfg.MaxMessageBytes, int64(16<<20); got != want {
+		t.Errorf("max message bytes: got %d, want %d", got, want)
+	}
+	if !cfg.ChecksumPayloads {
+		t.Error("checksum payloads: got false, want true")
+	}
+}
+
 func TestConfigErrors(t *testing.T) {
 	type testCase struct {
 		name          string
@@ -152,6 +214,62 @@ rollout = "hello"
 `,
 			expectedError: "invalid duration",
 		},
+		{
+			name: "negative init timeout",
+			cfg: `
+[serviceweaver]
+init_timeout = "-1s"
+`,
+			expectedError: "must be non-negative",
+		},
+		{
+			name: "negative idempotency window",
+			cfg: `
+[serviceweaver]
+idempotency_window = "-1m"
+`,
+			expectedError: "must be non-negative",
+		},
+		{
+			name: "negative max message bytes",
+			cfg: `
+[serviceweaver]
+max_message_bytes = -1
+`,
+			expectedError: "must be non-negative",
+		},
+		{
+			name: "at-most-once method without method name",
+			cfg: `
+[serviceweaver]
+at_most_once = ["github.com/example/bank/Payments"]
+`,
+			expectedError: "invalid at-most-once method",
+		},
+		{
+			name: "grpc address without port",
+			cfg: `
+[serviceweaver]
+grpc_address = "localhost"
+`,
+			expectedError: "invalid grpc_address",
+		},
+		{
+			name: "lazy main",
+			cfg: `
+[serviceweaver]
+lazy = ["github.com/ServiceWeaver/weaver/Main"]
+`,
+			expectedError: "cannot be lazy",
+		},
+		{
+			name: "audited method without method name",
+			cfg: `
+[serviceweaver]
+audit = ["github.com/ServiceWeaver/weaver/Main"]
+`,
+			expectedError: "invalid audited method",
+		},
 	} {
 		t.Run(c.name, func(t *testing.T) {
 			_, err := runtime.ParseConfig("weaver.toml", c.cfg, codegen.ComponentConfigValidator)
@@ -20,6 +20,7 @@ import (
 	"io"
 	"os"
 
+	"github.com/ServiceWeaver/weaver/internal/handoff"
 	"github.com/ServiceWeaver/weaver/internal/pipe"
 	"github.com/ServiceWeaver/weaver/internal/proto"
 	"github.com/ServiceWeaver/weaver/runtime"
@@ -49,9 +50,10 @@ type Child interface {
 
 // ProcessChild is a Child implemented as a process.
 type ProcessChild struct {
-	cmd    *pipe.Cmd // command that started the weavelet
-	stdout io.ReadCloser
-	stderr io.ReadCloser
+	cmd     *pipe.Cmd       // command that started the weavelet
+	handoff *handoff.Parent // files handed off to and from the weavelet, or nil
+	stdout  io.ReadCloser
+	stderr  io.ReadCloser
 }
 
 var _ Child = &ProcessChild{}
@@ -83,6 +85,17 @@ func (p *ProcessChild) Start(ctx context.Context, config *protos.AppConfig, args
 	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", runtime.WeaveletArgsKey, argsEnv))
 	cmd.Env = append(cmd.Env, config.Env...)
 
+	// Hand off files to the weavelet.
+	if p.handoff != nil {
+		defer p.handoff.Started()
+		files, value, err := p.handoff.Inherit(3 + len(cmd.ExtraFiles))
+		if err != nil {
+			return fmt.Errorf("handing off files: %w", err)
+		}
+		cmd.ExtraFiles = append(cmd.ExtraFiles, files...)
+		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", handoff.EnvKey, value))
+	}
+
 	if err := cmd.Start(); err != nil {
 		return err
 	}
@@ -27,6 +27,7 @@ import (
 	"sync"
 
 	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/internal/handoff"
 	"github.com/ServiceWeaver/weaver/internal/net/call"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
@@ -59,6 +60,10 @@ type EnvelopeHandler interface {
 	// traffic to the provided address.
 	ExportListener(context.Context, *protos.ExportListenerRequest) (*protos.ExportListenerReply, error)
 
+	// GetTopology returns the live component graph of the deployment,
+	// assembled from the method metrics of its weavelets.
+	GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error)
+
 	// GetSelfCertificate returns the certificate and the private key the
 	// weavelet should use for network connection establishment. The weavelet
 	// will issue this request each time it establishes a connection with
@@ -113,6 +118,7 @@ type Envelope struct {
 	config       *protos.AppConfig
 	child        Child                   // weavelet process handle
 	controller   control.WeaveletControl // Stub that talks to the weavelet controller
+	handoff      *handoff.Parent         // files handed off by the weavelet, or nil
 
 	// State needed to process metric updates.
 	metricsMu sync.Mutex
@@ -132,6 +138,12 @@ type Options struct {
 
 	// Child is used to run the weavelet. If nil, a sub-process is created.
 	Child Child
+
+	// Files are handed off to the weavelet, typically by the weavelet it
+	// replaces (see Envelope.HandedOff), keyed by name. The envelope takes
+	// ownership of the files. Files are only handed off to a sub-process
+	// created by the envelope, on platforms other than Windows.
+	Files map[string]*os.File
 }
 
 // NewEnvelope creates a new envelope, starting a weavelet subprocess (via child.Start) and
@@ -200,14 +212,37 @@ func NewEnvelope(ctx context.Context, wlet *protos.WeaveletArgs, config *protos.
 
 	child := options.Child
 	if child == nil {
-		child = &ProcessChild{}
+		// Hand off files to and from the weavelet, if supported.
+		pc := &ProcessChild{}
+		if parent, err := handoff.NewParent(options.Files); err != nil {
+			e.logger.Debug("Cannot hand off files to the weavelet", "err", err)
+		} else {
+			pc.handoff = parent
+			e.handoff = parent
+		}
+		child = pc
+	} else {
+		for _, f := range options.Files {
+			f.Close()
+		}
 	}
 	if err := child.Start(ctx, e.config, e.weavelet); err != nil {
 		return nil, fmt.Errorf("NewEnvelope: %w", err)
 	}
 
 	reply, err := controller.InitWeavelet(e.ctx, &protos.InitWeaveletRequest{
-		Sections: config.Sections,
+		Sections:                  config.Sections,
+		InitTimeoutNanos:          config.InitTimeoutNanos,
+		ComponentInitTimeoutNanos: config.ComponentInitTimeoutNanos,
+		LazyComponents:            config.LazyComponents,
+		AuditedMethods:            config.AuditedMethods,
+		LargePayloadBytes:         config.LargePayloadBytes,
+		CompressPayloadBytes:      config.CompressPayloadBytes,
+		MaxMessageBytes:           config.MaxMessageBytes,
+		ChecksumPayloads:          config.ChecksumPayloads,
+		IdempotencyWindowNanos:    config.IdempotencyWindowNanos,
+		AtMostOnceMethods:         config.AtMostOnceMethods,
+		GrpcAddress:               config.GrpcAddress,
 	})
 	if err != nil {
 		return nil, err
@@ -302,6 +337,19 @@ func (e *Envelope) Pid() (int, bool) {
 	return e.child.Pid()
 }
 
+// HandedOff waits until the weavelet exits, and returns the files that it
+// handed off (see weaver.HandOffFile) and that weren't taken, keyed by name.
+// The caller takes ownership of the files, and typically hands them off to the
+// weavelet that replaces this one (see Options.Files). HandedOff returns nil
+// if files aren't handed off to and from the weavelet. It should be called at
+// most once.
+func (e *Envelope) HandedOff() map[string]*os.File {
+	if e.handoff == nil {
+		return nil
+	}
+	return e.handoff.Files()
+}
+
 // WeaveletAddress returns the address that other components should dial to communicate with the
 // weavelet.
 func (e *Envelope) WeaveletAddress() string {
@@ -326,6 +374,24 @@ func (e *Envelope) GetProfile(req *protos.GetProfileRequest) ([]byte, error) {
 	return reply.Data, nil
 }
 
+// GetFlightRecord returns the component method calls recently recorded by the
+// weavelet's flight recorder.
+func (e *Envelope) GetFlightRecord() ([]*protos.CallEvent, error) {
+	reply, err := e.controller.GetFlightRecord(context.TODO(), &protos.GetFlightRecordRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Events, nil
+}
+
+// NotifyDeploymentEvent notifies the weavelet of a deployment event. It
+// returns once the weavelet's components have handled the event.
+func (e *Envelope) NotifyDeploymentEvent(event *protos.DeploymentEvent) error {
+	req := &protos.NotifyDeploymentEventRequest{Event: event}
+	_, err := e.controller.NotifyDeploymentEvent(context.TODO(), req)
+	return err
+}
+
 // GetMetrics returns a weavelet's metrics.
 func (e *Envelope) GetMetrics() ([]*metrics.MetricSnapshot, error) {
 	req := &protos.GetMetricsRequest{}
@@ -422,7 +488,7 @@ func getWeaveletControlStub(ctx context.Context, socket string, options Options)
 		return nil, err
 	}
 	// We skip waitUntilReady() and rely on automatic retries of methods
-	stub := call.NewStub(control.WeaveletPath, controllerReg, conn, options.Tracer, 0)
+	stub := call.NewStub(control.WeaveletPath, controllerReg, conn, options.Tracer, 0, nil)
 	obj := controllerReg.ClientStubFn(stub, "envelope")
 	return obj.(control.WeaveletControl), nil
 }
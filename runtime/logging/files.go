@@ -27,6 +27,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/atrest"
 	"github.com/ServiceWeaver/weaver/internal/cond"
 	"github.com/ServiceWeaver/weaver/internal/heap"
 	"github.com/ServiceWeaver/weaver/runtime/colors"
@@ -34,15 +35,19 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/fsnotify/fsnotify"
 	"github.com/google/cel-go/cel"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 // This file contains code to read and write log entries to and from files.
 
-// FileStore stores log entries in files.
+// FileStore stores log entries in files. If at-rest encryption is enabled (see
+// the atrest package), log entries are encrypted.
 type FileStore struct {
-	dir string
-	mu  sync.Mutex
-	pp  *PrettyPrinter
+	dir    string
+	cipher *atrest.Cipher
+	mu     sync.Mutex
+	pp     *PrettyPrinter
 
 	// We segregate into log files by app,deployment,node,level.
 	files map[string]*os.File
@@ -53,10 +58,15 @@ func NewFileStore(dir string) (*FileStore, error) {
 	if err := os.MkdirAll(dir, 0750); err != nil {
 		return nil, err
 	}
+	cipher, err := atrest.FromEnv()
+	if err != nil {
+		return nil, err
+	}
 	return &FileStore{
-		dir:   dir,
-		pp:    NewPrettyPrinter(colors.Enabled()),
-		files: map[string]*os.File{},
+		dir:    dir,
+		cipher: cipher,
+		pp:     NewPrettyPrinter(colors.Enabled()),
+		files:  map[string]*os.File{},
 	}, nil
 }
 
@@ -104,7 +114,7 @@ func (fs *FileStore) Add(e *protos.LogEntry) {
 
 	// Write to log file if available.
 	if f != nil {
-		err := protomsg.Write(f, e)
+		err := writeEntry(f, e, fs.cipher)
 		if err == nil {
 			return
 		}
@@ -260,11 +270,15 @@ func (fq *fileSource) Query(_ context.Context, q Query, follow bool) (Reader, er
 	if err := os.MkdirAll(fq.dir, 0750); err != nil {
 		return nil, err
 	}
+	cipher, err := atrest.FromEnv()
+	if err != nil {
+		return nil, err
+	}
 
 	if follow {
-		return newFileFollower(fq.dir, q)
+		return newFileFollower(fq.dir, q, cipher)
 	}
-	return newFileCatter(fq.dir, q)
+	return newFileCatter(fq.dir, q, cipher)
 }
 
 // A fileCatter performs a streaming heap sort on the set of files that match
@@ -301,7 +315,7 @@ type fileCatter struct {
 	closed bool                  // true if Close() has been called
 }
 
-func newFileCatter(logdir string, q Query) (*fileCatter, error) {
+func newFileCatter(logdir string, q Query, cipher *atrest.Cipher) (*fileCatter, error) {
 	// Compile the query.
 	env, ast, err := parse(q)
 	if err != nil {
@@ -329,7 +343,7 @@ func newFileCatter(logdir string, q Query) (*fileCatter, error) {
 		}
 		files = append(files, file)
 
-		buffered := newBuffered(file.Name(), file)
+		buffered := newBuffered(file.Name(), file, cipher)
 		if err = buffered.buffer(); err != nil {
 			return nil, err
 		}
@@ -443,7 +457,8 @@ func (fc *fileCatter) Close() {
 // fileFollower is a Reader implementation that reads from files written by a
 // FileLogger.
 type fileFollower struct {
-	prog cel.Program // the compiled user provided query
+	prog   cel.Program    // the compiled user provided query
+	cipher *atrest.Cipher // decrypts log entries
 
 	mu         sync.Mutex               // guards the following fields
 	scanners   map[string]*fileScanner  // all scanners, keyed by filename
@@ -472,7 +487,7 @@ func (fs *fileScanner) onHeap() bool {
 	return fs.entry != nil
 }
 
-func newFileFollower(logdir string, q Query) (*fileFollower, error) {
+func newFileFollower(logdir string, q Query, cipher *atrest.Cipher) (*fileFollower, error) {
 	// Compile the query.
 	env, ast, err := parse(q)
 	if err != nil {
@@ -497,7 +512,8 @@ func newFileFollower(logdir string, q Query) (*fileFollower, error) {
 	// Construct the follower.
 	ctx, cancel := context.WithCancel(context.Background())
 	follower := fileFollower{
-		prog: prog,
+		prog:   prog,
+		cipher: cipher,
 
 		scanners: map[string]*fileScanner{},
 		h: heap.New(func(a, b *fileScanner) bool {
@@ -632,6 +648,11 @@ func (ff *fileFollower) created(filename string) error {
 		return nil
 	}
 
+	// Subdirectories hold other artifacts, e.g., crash reports.
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		return nil
+	}
+
 	// Check to see if we need to watch this file.
 	logfile, err := parseLogfile(filepath.Base(filename))
 	if err != nil {
@@ -708,7 +729,7 @@ func (ff *fileFollower) scan(fs *fileScanner) error {
 		//
 		// Note that fs.reader is cancelled when ff.ctx is cancelled. This will
 		// also cause scanner.Scan to be cancelled.
-		err := protomsg.Read(fs.reader, entry)
+		err := readEntry(fs.reader, entry, ff.cipher)
 		if err != nil {
 			return err
 		}
@@ -838,7 +859,8 @@ func ls(dir string, prog cel.Program) ([]string, error) {
 	filenames := make([]string, 0, len(direntries))
 	for _, direntry := range direntries {
 		if direntry.IsDir() {
-			return nil, fmt.Errorf("unexpected directory %q in %q", direntry.Name(), dir)
+			// Subdirectories hold other artifacts, e.g., crash reports.
+			continue
 		}
 		filename := direntry.Name()
 		logfile, err := parseLogfile(filename)
@@ -861,14 +883,16 @@ type buffered struct {
 	filename string           // absolute filename of the file being scanned
 	entry    *protos.LogEntry // entry scanned from scanner
 	src      *bufio.Reader    // source of log entries
+	cipher   *atrest.Cipher   // decrypts log entries
 }
 
 // newBuffered returns a new buffered.
-func newBuffered(filename string, src io.Reader) *buffered {
+func newBuffered(filename string, src io.Reader, cipher *atrest.Cipher) *buffered {
 	return &buffered{
 		filename: filename,
 		entry:    nil,
 		src:      bufio.NewReader(src),
+		cipher:   cipher,
 	}
 }
 
@@ -881,7 +905,7 @@ func (b *buffered) buffer() error {
 	}
 
 	entry := &protos.LogEntry{}
-	err := protomsg.Read(b.src, entry)
+	err := readEntry(b.src, entry, b.cipher)
 	if err != nil && !errors.Is(err, io.EOF) {
 		return err
 	} else if errors.Is(err, io.EOF) {
@@ -903,3 +927,37 @@ func (b *buffered) pop() *protos.LogEntry {
 	b.entry = nil
 	return entry
 }
+
+// writeEntry writes a length-prefixed log entry to w, encrypting it with
+// cipher. Use readEntry to read it.
+func writeEntry(w io.Writer, entry *protos.LogEntry, cipher *atrest.Cipher) error {
+	if cipher == nil {
+		return protomsg.Write(w, entry)
+	}
+	data, err := proto.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	sealed, err := cipher.Seal(data)
+	if err != nil {
+		return err
+	}
+	return protomsg.Write(w, wrapperspb.Bytes(sealed))
+}
+
+// readEntry reads a log entry written by writeEntry from r, decrypting it with
+// cipher.
+func readEntry(r io.Reader, entry *protos.LogEntry, cipher *atrest.Cipher) error {
+	if cipher == nil {
+		return protomsg.Read(r, entry)
+	}
+	var sealed wrapperspb.BytesValue
+	if err := protomsg.Read(r, &sealed); err != nil {
+		return err
+	}
+	data, err := cipher.Open(sealed.Value)
+	if err != nil {
+		return err
+	}
+	return proto.Unmarshal(data, entry)
+}
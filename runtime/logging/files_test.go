@@ -22,9 +22,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/atrest"
 	"github.com/ServiceWeaver/weaver/runtime/protomsg"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/go-cmp/cmp"
@@ -357,6 +359,47 @@ func TestFileFollowerNoMatches(t *testing.T) {
 	}
 }
 
+func TestEncryptedFileStore(t *testing.T) {
+	logdir = t.TempDir()
+	ctx := ctx(t)
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(keyFile, []byte("000102030405060708090a0b0c0d0e0f"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(atrest.KeyFileEnvVar, keyFile)
+
+	// Log.
+	fs, err := NewFileStore(logdir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*protos.LogEntry{
+		{App: "test", Version: "v1", Node: "1", Level: "info", Msg: "secret message 1"},
+		{App: "test", Version: "v1", Node: "1", Level: "info", Msg: "secret message 2"},
+	}
+	for _, e := range want {
+		fs.Add(e)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The log file does not contain the messages in plaintext.
+	data, err := os.ReadFile(filepath.Join(logdir, filename("test", "v1", "1", "info")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret message") {
+		t.Fatal("log file contains plaintext messages")
+	}
+
+	// Cat.
+	got := drain(t, ctx, cat(t, ctx, `app=="test"`))
+	if diff := cmp.Diff(want, got, opts()...); diff != "" {
+		t.Errorf("bad cat (-want +got):\n%s", diff)
+	}
+}
+
 func TestParseLogfile(t *testing.T) {
 	for _, want := range []logfile{
 		// Simple strings.
@@ -24,9 +24,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/redact"
 	"github.com/ServiceWeaver/weaver/runtime/colors"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SystemAttributeKey is present as the key of an attribute (with an empty value)
@@ -55,7 +57,10 @@ type Options struct {
 	Attrs []string
 }
 
-// LogHandler implements a custom slog.Handler.
+// LogHandler implements a custom slog.Handler. Every log entry is annotated
+// with the application, deployment, component, and weavelet in Opts, as well
+// as with the ids of the trace and span in the context passed to the logging
+// call (e.g., via slog.Logger.InfoContext), if any.
 type LogHandler struct {
 	Opts  Options                      // configures the log entries
 	Write func(entry *protos.LogEntry) // called on every log entry
@@ -65,7 +70,12 @@ var _ slog.Handler = &LogHandler{}
 
 // Handle implements the slog.Handler interface.
 func (h *LogHandler) Handle(ctx context.Context, rec slog.Record) error {
-	h.Write(h.makeEntry(rec))
+	entry := h.makeEntry(rec)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		entry.TraceId = sc.TraceID().String()
+		entry.SpanId = sc.SpanID().String()
+	}
+	h.Write(entry)
 	return nil
 }
 
@@ -222,9 +232,13 @@ func appendAttrs(prefix []string, attrs []slog.Attr) []string {
 	var dst []string
 	dst = append(dst, prefix...)
 
-	// Extract key,value pairs from attrs.
+	// Extract key,value pairs from attrs, masking sensitive data.
 	for _, attr := range attrs {
-		dst = append(dst, []string{attr.Key, attr.Value.String()}...)
+		value := attr.Value.String()
+		if attr.Value.Kind() == slog.KindAny {
+			value = redact.String(attr.Value.Any())
+		}
+		dst = append(dst, []string{attr.Key, value}...)
 	}
 	return dst
 }
@@ -15,6 +15,7 @@
 package logging
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
 	"strconv"
@@ -26,6 +27,7 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestTestLogger(t *testing.T) {
@@ -73,6 +75,56 @@ func TestWithAttributes(t *testing.T) {
 	}
 }
 
+func TestTraceContext(t *testing.T) {
+	var got *protos.LogEntry
+	logSaver := func(e *protos.LogEntry) {
+		got = e
+	}
+	logger := newAttrLogger("app", "version", "component", "weavelet", logSaver)
+
+	// Log without a span.
+	logger.InfoContext(context.Background(), "")
+	if got.TraceId != "" || got.SpanId != "" {
+		t.Fatalf("unexpected trace context: trace %q, span %q", got.TraceId, got.SpanId)
+	}
+
+	// Log with a span.
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: trace.TraceID{1, 2, 3},
+		SpanID:  trace.SpanID{4, 5, 6},
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	logger.InfoContext(ctx, "")
+	if want := sc.TraceID().String(); got.TraceId != want {
+		t.Errorf("trace id: got %q, want %q", got.TraceId, want)
+	}
+	if want := sc.SpanID().String(); got.SpanId != want {
+		t.Errorf("span id: got %q, want %q", got.SpanId, want)
+	}
+	if got.Component != "component" || got.Node != "weavelet" {
+		t.Errorf("unexpected component %q and weavelet %q", got.Component, got.Node)
+	}
+}
+
+// credentials holds a redacted field. See TestRedaction.
+type credentials struct {
+	User     string
+	Password string `weaver:"redact"`
+}
+
+func TestRedaction(t *testing.T) {
+	var got *protos.LogEntry
+	logSaver := func(e *protos.LogEntry) {
+		got = e
+	}
+	logger := newAttrLogger("app", "version", "component", "weavelet", logSaver)
+	logger.Info("login", "creds", credentials{"alice", "hunter2"}, "n", 1)
+	want := []string{"creds", "{alice REDACTED}", "n", "1"}
+	if diff := cmp.Diff(want, got.Attrs); diff != "" {
+		t.Fatalf("attrs (-want +got):\n%s", diff)
+	}
+}
+
 func TestConcurrentAttributes(t *testing.T) {
 	// Test plan: start a number of goroutines that emit attributes with sequential
 	// values. Confirm that attributes are saved in the same sequential order
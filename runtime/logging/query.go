@@ -50,6 +50,8 @@ import (
 //   - level: string
 //   - source: string
 //   - msg: string
+//   - trace_id: string
+//   - span_id: string
 //   - attrs: map[string]string
 //
 // A query is restricted to:
@@ -112,6 +114,8 @@ func env() (*cel.Env, error) {
 		decls.NewVar("level", decls.String),
 		decls.NewVar("source", decls.String),
 		decls.NewVar("msg", decls.String),
+		decls.NewVar("trace_id", decls.String),
+		decls.NewVar("span_id", decls.String),
 		decls.NewVar("attrs", decls.NewMapType(decls.String, decls.String)),
 	))
 }
@@ -497,6 +501,8 @@ func matches(prog cel.Program, entry *protos.LogEntry) (bool, error) {
 		"level":          entry.Level,
 		"source":         fmt.Sprintf("%s:%d", entry.File, entry.Line),
 		"msg":            entry.Msg,
+		"trace_id":       entry.TraceId,
+		"span_id":        entry.SpanId,
 		"attrs":          attrs,
 	})
 	if err != nil {
@@ -16,6 +16,8 @@ package logging
 
 import (
 	"context"
+	"errors"
+	"io"
 
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 )
@@ -48,3 +50,45 @@ type Reader interface {
 	// Close closes the Reader. Close can safely be called multiple times.
 	Close()
 }
+
+// Entries returns an iterator over the log entries read from the provided
+// reader, so that logs can be consumed lazily:
+//
+//	logging.Entries(ctx, reader)(func(entry *protos.LogEntry, err error) bool {
+//	    if err != nil {
+//	        log.Print(err)
+//	        return false
+//	    }
+//	    fmt.Println(entry)
+//	    return true
+//	})
+//
+// The returned function has the same type as iter.Seq2[*protos.LogEntry,
+// error], so with Go 1.23 or later it can also be used in a range statement:
+//
+//	for entry, err := range logging.Entries(ctx, reader) {
+//	    if err != nil {
+//	        return err
+//	    }
+//	    fmt.Println(entry)
+//	}
+//
+// Iteration stops when Read returns io.EOF. If Read returns any other error,
+// the error is yielded with a nil entry and iteration stops. Entries does not
+// close the reader.
+func Entries(ctx context.Context, r Reader) func(yield func(*protos.LogEntry, error) bool) {
+	return func(yield func(*protos.LogEntry, error) bool) {
+		for {
+			entry, err := r.Read(ctx)
+			if errors.Is(err, io.EOF) {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(entry, nil) {
+				return
+			}
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"testing"
 
 	"github.com/ServiceWeaver/weaver/runtime/protos"
@@ -76,6 +77,44 @@ func ExampleReader() {
 	}
 }
 
+func TestEntries(t *testing.T) {
+	ctx := context.Background()
+
+	// Read all entries.
+	var got []string
+	Entries(ctx, getLogReader())(func(entry *protos.LogEntry, err error) bool {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, entry.Msg)
+		return true
+	})
+	if want := []string{"1", "2", "3"}; !slices.Equal(got, want) {
+		t.Fatalf("Entries: got %v, want %v", got, want)
+	}
+
+	// Stop early.
+	reader := getLogReader()
+	Entries(ctx, reader)(func(*protos.LogEntry, error) bool { return false })
+	if got, want := len(reader.entries), 2; got != want {
+		t.Fatalf("Entries: got %d unread entries, want %d", got, want)
+	}
+
+	// Read from a closed reader.
+	reader.Close()
+	var errs int
+	Entries(ctx, reader)(func(entry *protos.LogEntry, err error) bool {
+		if err == nil {
+			t.Fatalf("Entries: unexpected entry %v", entry)
+		}
+		errs++
+		return true
+	})
+	if errs != 1 {
+		t.Fatalf("Entries: got %d errors, want 1", errs)
+	}
+}
+
 // TestDontShowWholeFile is here so that this entire file isn't shown as an
 // example. As explained in [1],
 //
@@ -0,0 +1,251 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package payloads reports the sizes of the serialized arguments and results
+// of component methods, as observed at runtime by a Service Weaver
+// application's method metrics.
+//
+// Sizes are only recorded for remote calls, since local calls don't serialize
+// their arguments and results. To inspect the calls made by the current
+// process, pass the snapshots returned by metrics.Snapshot to Sizes:
+//
+//	methods, err := payloads.Sizes(metrics.Snapshot())
+//
+// Deployers can pass the snapshots they collect from all the processes of a
+// deployment instead. Check flags the methods whose payloads exceed a set of
+// thresholds.
+package payloads
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+)
+
+// Method holds the payload sizes of a component method.
+type Method struct {
+	Component string       // full component name
+	Method    string       // method name
+	Calls     int64        // number of remote calls observed
+	Args      Distribution // sizes of the serialized arguments, in bytes
+	Results   Distribution // sizes of the serialized results, in bytes
+}
+
+// Distribution is a distribution of payload sizes, in bytes. Percentiles are
+// estimated from a histogram, by linear interpolation within the bucket that
+// holds the percentile.
+type Distribution struct {
+	Mean float64 // mean size
+	P50  float64 // estimated median size
+	P90  float64 // estimated 90th percentile size
+	P99  float64 // estimated 99th percentile size
+	Max  float64 // upper bound of the largest non-empty bucket; may be +Inf
+
+	// Bounds and Counts are the histogram of sizes. Counts[i] is the number
+	// of payloads larger than Bounds[i-1] and no larger than Bounds[i]. The
+	// last count is the number of payloads larger than every bound.
+	Bounds []float64
+	Counts []uint64
+}
+
+// histogram is a histogram of payload sizes, aggregated across callers and
+// processes.
+type histogram struct {
+	sum    float64
+	bounds []float64
+	counts []uint64
+}
+
+// add adds the provided histogram snapshot to h.
+func (h *histogram) add(m *metrics.MetricSnapshot) error {
+	if len(m.Counts) != len(m.Bounds)+1 {
+		return fmt.Errorf("%s: got %d counts for %d bounds", m.Name, len(m.Counts), len(m.Bounds))
+	}
+	if h.counts == nil {
+		h.bounds = slices.Clone(m.Bounds)
+		h.counts = make([]uint64, len(m.Counts))
+	} else if !slices.Equal(h.bounds, m.Bounds) {
+		return fmt.Errorf("%s: mismatched bounds %v and %v", m.Name, h.bounds, m.Bounds)
+	}
+	h.sum += m.Value
+	for i, c := range m.Counts {
+		h.counts[i] += c
+	}
+	return nil
+}
+
+// count returns the number of values in h.
+func (h *histogram) count() uint64 {
+	var n uint64
+	for _, c := range h.counts {
+		n += c
+	}
+	return n
+}
+
+// distribution returns the distribution of the values in h.
+func (h *histogram) distribution() Distribution {
+	d := Distribution{Bounds: h.bounds, Counts: h.counts}
+	n := h.count()
+	if n == 0 {
+		return d
+	}
+	d.Mean = h.sum / float64(n)
+	d.P50 = imetrics.HistogramPercentile(h.bounds, h.counts, 0.5)
+	d.P90 = imetrics.HistogramPercentile(h.bounds, h.counts, 0.9)
+	d.P99 = imetrics.HistogramPercentile(h.bounds, h.counts, 0.99)
+	for i := len(h.counts) - 1; i >= 0; i-- {
+		if h.counts[i] == 0 {
+			continue
+		}
+		if i == len(h.bounds) {
+			d.Max = math.Inf(1)
+		} else {
+			d.Max = h.bounds[i]
+		}
+		break
+	}
+	return d
+}
+
+// Sizes returns the payload sizes of the component methods recorded by the
+// provided method metric snapshots, aggregated across callers and processes
+// and sorted by component and method name. Methods without remote calls are
+// omitted.
+func Sizes(snapshots []*metrics.MetricSnapshot) ([]Method, error) {
+	type key struct{ component, method string }
+	type sizes struct{ args, results histogram }
+	byMethod := map[key]*sizes{}
+	for _, m := range snapshots {
+		if m.Name != imetrics.MethodBytesRequestName && m.Name != imetrics.MethodBytesReplyName {
+			continue
+		}
+		if m.Labels["remote"] != "true" {
+			continue
+		}
+		k := key{m.Labels["component"], m.Labels["method"]}
+		if imetrics.IsSystem(k.component) {
+			continue
+		}
+		s, ok := byMethod[k]
+		if !ok {
+			s = &sizes{}
+			byMethod[k] = s
+		}
+		h := &s.args
+		if m.Name == imetrics.MethodBytesReplyName {
+			h = &s.results
+		}
+		if err := h.add(m); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", k.component, k.method, err)
+		}
+	}
+
+	var methods []Method
+	for k, s := range byMethod {
+		calls := s.args.count()
+		if calls == 0 {
+			continue
+		}
+		methods = append(methods, Method{
+			Component: k.component,
+			Method:    k.method,
+			Calls:     int64(calls),
+			Args:      s.args.distribution(),
+			Results:   s.results.distribution(),
+		})
+	}
+	sort.Slice(methods, func(i, j int) bool {
+		if methods[i].Component != methods[j].Component {
+			return methods[i].Component < methods[j].Component
+		}
+		return methods[i].Method < methods[j].Method
+	})
+	return methods, nil
+}
+
+// Thresholds are the payload sizes, in bytes, above which Check flags a
+// method. A zero threshold is ignored.
+type Thresholds struct {
+	Mean float64 // mean size of the arguments or results
+	P99  float64 // 99th percentile size of the arguments or results
+}
+
+// DefaultThresholds are reasonable default thresholds. Payloads of a few
+// hundred kilobytes or more delay the other calls multiplexed on the same
+// connection and increase the memory used by callers and callees.
+var DefaultThresholds = Thresholds{Mean: 256 << 10, P99: 1 << 20}
+
+// A Finding is a method whose arguments or results exceed a threshold.
+type Finding struct {
+	Method     Method
+	Results    bool   // true if the results exceed a threshold, false if the arguments do
+	Reason     string // which threshold is exceeded, e.g., "p99 of 2.0 MiB exceeds 1.0 MiB"
+	Suggestion string // how to reduce the payload size
+}
+
+// Check returns the methods whose arguments or results exceed the provided
+// thresholds.
+func Check(methods []Method, t Thresholds) []Finding {
+	var findings []Finding
+	for _, m := range methods {
+		for _, results := range []bool{false, true} {
+			d := m.Args
+			if results {
+				d = m.Results
+			}
+			var reason string
+			switch {
+			case t.P99 > 0 && d.P99 > t.P99:
+				reason = fmt.Sprintf("p99 of %s exceeds %s", FormatSize(d.P99), FormatSize(t.P99))
+			case t.Mean > 0 && d.Mean > t.Mean:
+				reason = fmt.Sprintf("mean of %s exceeds %s", FormatSize(d.Mean), FormatSize(t.Mean))
+			default:
+				continue
+			}
+			suggestion := "pass the arguments in smaller chunks over several calls (streaming), or pass a reference to data stored elsewhere"
+			if results {
+				suggestion = "return the results in pages with weaver.Page (pagination), or return a reference to data stored elsewhere"
+			}
+			findings = append(findings, Finding{
+				Method:     m,
+				Results:    results,
+				Reason:     reason,
+				Suggestion: suggestion,
+			})
+		}
+	}
+	return findings
+}
+
+// FormatSize formats a size in bytes using binary units, e.g., "1.5 KiB".
+func FormatSize(bytes float64) string {
+	if math.IsInf(bytes, 1) {
+		return "∞"
+	}
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	div, exp := float64(unit), 0
+	for n := bytes / unit; n >= unit && exp < 4; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", bytes/div, "KMGTP"[exp])
+}
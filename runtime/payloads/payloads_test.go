@@ -0,0 +1,146 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package payloads
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/internal/control"
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/google/go-cmp/cmp"
+)
+
+// sizes returns the snapshot of a payload size histogram for calls from
+// caller to component.M with the provided bucket counts.
+func sizes(name, caller, component string, remote bool, sum float64, counts ...uint64) *metrics.MetricSnapshot {
+	r := "false"
+	if remote {
+		r = "true"
+	}
+	return &metrics.MetricSnapshot{
+		Name: name,
+		Type: protos.MetricType_HISTOGRAM,
+		Labels: map[string]string{
+			"caller":    caller,
+			"component": component,
+			"method":    "M",
+			"remote":    r,
+		},
+		Value:  sum,
+		Bounds: []float64{100, 1000, 10000},
+		Counts: counts,
+	}
+}
+
+func TestSizes(t *testing.T) {
+	req, reply := imetrics.MethodBytesRequestName, imetrics.MethodBytesReplyName
+	snapshots := []*metrics.MetricSnapshot{
+		// Calls to A from two callers.
+		sizes(req, "main", "A", true, 5000, 50, 50, 0, 0),
+		sizes(reply, "main", "A", true, 20000, 0, 100, 0, 0),
+		sizes(req, "B", "A", true, 5000, 50, 50, 0, 0),
+		sizes(reply, "B", "A", true, 20000, 0, 90, 0, 10),
+
+		// Local calls and calls to control components are ignored.
+		sizes(req, "main", "B", false, 100, 1, 0, 0, 0),
+		sizes(req, "main", control.WeaveletPath, true, 100, 1, 0, 0, 0),
+	}
+	got, err := Sizes(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bounds := []float64{100, 1000, 10000}
+	want := []Method{{
+		Component: "A",
+		Method:    "M",
+		Calls:     200,
+		Args: Distribution{
+			Mean:   50,
+			P50:    100,
+			P90:    820,
+			P99:    982,
+			Max:    1000,
+			Bounds: bounds,
+			Counts: []uint64{100, 100, 0, 0},
+		},
+		Results: Distribution{
+			Mean:   200,
+			P50:    100 + 900*100.0/190,
+			P90:    100 + 900*180.0/190,
+			P99:    10000,
+			Max:    math.Inf(1),
+			Bounds: bounds,
+			Counts: []uint64{0, 190, 0, 10},
+		},
+	}}
+	approx := cmp.Comparer(func(x, y float64) bool {
+		return x == y || math.Abs(x-y) < 1e-9
+	})
+	if diff := cmp.Diff(want, got, approx); diff != "" {
+		t.Fatalf("Sizes (-want +got):\n%s", diff)
+	}
+
+	// Histograms with different bounds can't be aggregated.
+	bad := sizes(req, "C", "A", true, 0, 1, 0, 0)
+	bad.Bounds = []float64{1, 2}
+	if _, err := Sizes(append(snapshots, bad)); err == nil {
+		t.Fatal("Sizes with mismatched bounds: unexpected success")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	methods := []Method{
+		{Component: "small", Method: "M", Args: Distribution{Mean: 10, P99: 100}, Results: Distribution{Mean: 10, P99: 100}},
+		{Component: "bigresults", Method: "M", Args: Distribution{Mean: 10, P99: 100}, Results: Distribution{Mean: 100, P99: 5000}},
+		{Component: "bigargs", Method: "M", Args: Distribution{Mean: 2000, P99: 900}, Results: Distribution{Mean: 10, P99: 100}},
+	}
+	findings := Check(methods, Thresholds{Mean: 1000, P99: 1000})
+	if len(findings) != 2 {
+		t.Fatalf("got %d findings, want 2: %v", len(findings), findings)
+	}
+	if f := findings[0]; f.Method.Component != "bigresults" || !f.Results || !strings.Contains(f.Reason, "p99") || !strings.Contains(f.Suggestion, "pagination") {
+		t.Errorf("unexpected finding %+v", f)
+	}
+	if f := findings[1]; f.Method.Component != "bigargs" || f.Results || !strings.Contains(f.Reason, "mean") || !strings.Contains(f.Suggestion, "streaming") {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	// Zero thresholds are ignored.
+	if findings := Check(methods, Thresholds{}); len(findings) != 0 {
+		t.Errorf("got %d findings with zero thresholds, want 0", len(findings))
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for _, test := range []struct {
+		bytes float64
+		want  string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KiB"},
+		{1 << 20, "1.0 MiB"},
+		{5 << 30, "5.0 GiB"},
+		{math.Inf(1), "∞"},
+	} {
+		if got := FormatSize(test.bytes); got != test.want {
+			t.Errorf("FormatSize(%v): got %q, want %q", test.bytes, got, test.want)
+		}
+	}
+}
@@ -16,7 +16,7 @@
 // versions:
 // 	protoc-gen-go v1.34.1
 // 	protoc        v3.19.6
-// source: runtime/protos/config.proto
+// source: config.proto
 
 package protos
 
@@ -46,7 +46,7 @@ type ComponentGroup struct {
 func (x *ComponentGroup) Reset() {
 	*x = ComponentGroup{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_config_proto_msgTypes[0]
+		mi := &file_config_proto_msgTypes[0]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -59,7 +59,7 @@ func (x *ComponentGroup) String() string {
 func (*ComponentGroup) ProtoMessage() {}
 
 func (x *ComponentGroup) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_config_proto_msgTypes[0]
+	mi := &file_config_proto_msgTypes[0]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -72,7 +72,7 @@ func (x *ComponentGroup) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ComponentGroup.ProtoReflect.Descriptor instead.
 func (*ComponentGroup) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_config_proto_rawDescGZIP(), []int{0}
+	return file_config_proto_rawDescGZIP(), []int{0}
 }
 
 func (x *ComponentGroup) GetComponents() []string {
@@ -135,6 +135,48 @@ type AppConfig struct {
 	//
 	// If not specified, Service Weaver will pick a default value.
 	RolloutNanos int64 `protobuf:"varint,6,opt,name=rollout_nanos,json=rolloutNanos,proto3" json:"rollout_nanos,omitempty"`
+	// The maximum number of nanoseconds a component's Init method may take
+	// before initialization fails. If zero, Init is not bounded.
+	InitTimeoutNanos int64 `protobuf:"varint,8,opt,name=init_timeout_nanos,json=initTimeoutNanos,proto3" json:"init_timeout_nanos,omitempty"`
+	// Per-component overrides of init_timeout_nanos, keyed by full component
+	// name.
+	ComponentInitTimeoutNanos map[string]int64 `protobuf:"bytes,9,rep,name=component_init_timeout_nanos,json=componentInitTimeoutNanos,proto3" json:"component_init_timeout_nanos,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// Components, identified by their full names, that are constructed and
+	// initialized on their first method call rather than when a weavelet hosting
+	// them starts.
+	LazyComponents []string `protobuf:"bytes,10,rep,name=lazy_components,json=lazyComponents,proto3" json:"lazy_components,omitempty"`
+	// Component methods, identified as "<full component name>.<method name>",
+	// whose calls are recorded in the audit log.
+	AuditedMethods []string `protobuf:"bytes,11,rep,name=audited_methods,json=auditedMethods,proto3" json:"audited_methods,omitempty"`
+	// Arguments and results of remote calls larger than this many bytes are
+	// transferred out of band, over a separate connection, rather than on the
+	// connection shared by all calls. If zero, a default threshold is used. If
+	// negative, payloads are never transferred out of band.
+	LargePayloadBytes int64 `protobuf:"varint,12,opt,name=large_payload_bytes,json=largePayloadBytes,proto3" json:"large_payload_bytes,omitempty"`
+	// How many nanoseconds a replica remembers the result of a remote call made
+	// with an idempotency key, to deduplicate retries of the call. If zero, a
+	// default window is used.
+	IdempotencyWindowNanos int64 `protobuf:"varint,13,opt,name=idempotency_window_nanos,json=idempotencyWindowNanos,proto3" json:"idempotency_window_nanos,omitempty"`
+	// Component methods, identified as "<full component name>.<method name>",
+	// that are called at most once, in addition to the methods marked with
+	// weaver.AtMostOnce.
+	AtMostOnceMethods []string `protobuf:"bytes,14,rep,name=at_most_once_methods,json=atMostOnceMethods,proto3" json:"at_most_once_methods,omitempty"`
+	// Address on which every weavelet serves the standard gRPC health checking
+	// and reflection services. If empty, the services are not served.
+	GrpcAddress string `protobuf:"bytes,15,opt,name=grpc_address,json=grpcAddress,proto3" json:"grpc_address,omitempty"`
+	// Arguments and results of remote calls larger than this many bytes are
+	// compressed, if the peer supports compression. If zero or negative,
+	// payloads are never compressed.
+	CompressPayloadBytes int64 `protobuf:"varint,16,opt,name=compress_payload_bytes,json=compressPayloadBytes,proto3" json:"compress_payload_bytes,omitempty"`
+	// The largest message, in bytes, a weavelet accepts from another weavelet.
+	// The limit is advertised when weavelets connect, so that larger payloads
+	// are rejected by the sender. If zero, a default limit is used.
+	MaxMessageBytes int64 `protobuf:"varint,17,opt,name=max_message_bytes,json=maxMessageBytes,proto3" json:"max_message_bytes,omitempty"`
+	// If true, messages sent between weavelets carry a checksum, if the
+	// receiving weavelet supports checksums. A weavelet that receives a
+	// corrupted message closes the connection, and the calls in progress on it
+	// are retried if they allow it.
+	ChecksumPayloads bool `protobuf:"varint,18,opt,name=checksum_payloads,json=checksumPayloads,proto3" json:"checksum_payloads,omitempty"`
 	// All config sections (includes [serviceweaver], [<deployer>], and
 	// [<component>] sections).
 	Sections map[string]string `protobuf:"bytes,7,rep,name=sections,proto3" json:"sections,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
@@ -143,7 +185,7 @@ type AppConfig struct {
 func (x *AppConfig) Reset() {
 	*x = AppConfig{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_config_proto_msgTypes[1]
+		mi := &file_config_proto_msgTypes[1]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -156,7 +198,7 @@ func (x *AppConfig) String() string {
 func (*AppConfig) ProtoMessage() {}
 
 func (x *AppConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_config_proto_msgTypes[1]
+	mi := &file_config_proto_msgTypes[1]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -169,7 +211,7 @@ func (x *AppConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AppConfig.ProtoReflect.Descriptor instead.
 func (*AppConfig) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_config_proto_rawDescGZIP(), []int{1}
+	return file_config_proto_rawDescGZIP(), []int{1}
 }
 
 func (x *AppConfig) GetName() string {
@@ -214,6 +256,83 @@ func (x *AppConfig) GetRolloutNanos() int64 {
 	return 0
 }
 
+func (x *AppConfig) GetInitTimeoutNanos() int64 {
+	if x != nil {
+		return x.InitTimeoutNanos
+	}
+	return 0
+}
+
+func (x *AppConfig) GetComponentInitTimeoutNanos() map[string]int64 {
+	if x != nil {
+		return x.ComponentInitTimeoutNanos
+	}
+	return nil
+}
+
+func (x *AppConfig) GetLazyComponents() []string {
+	if x != nil {
+		return x.LazyComponents
+	}
+	return nil
+}
+
+func (x *AppConfig) GetAuditedMethods() []string {
+	if x != nil {
+		return x.AuditedMethods
+	}
+	return nil
+}
+
+func (x *AppConfig) GetLargePayloadBytes() int64 {
+	if x != nil {
+		return x.LargePayloadBytes
+	}
+	return 0
+}
+
+func (x *AppConfig) GetIdempotencyWindowNanos() int64 {
+	if x != nil {
+		return x.IdempotencyWindowNanos
+	}
+	return 0
+}
+
+func (x *AppConfig) GetAtMostOnceMethods() []string {
+	if x != nil {
+		return x.AtMostOnceMethods
+	}
+	return nil
+}
+
+func (x *AppConfig) GetGrpcAddress() string {
+	if x != nil {
+		return x.GrpcAddress
+	}
+	return ""
+}
+
+func (x *AppConfig) GetCompressPayloadBytes() int64 {
+	if x != nil {
+		return x.CompressPayloadBytes
+	}
+	return 0
+}
+
+func (x *AppConfig) GetMaxMessageBytes() int64 {
+	if x != nil {
+		return x.MaxMessageBytes
+	}
+	return 0
+}
+
+func (x *AppConfig) GetChecksumPayloads() bool {
+	if x != nil {
+		return x.ChecksumPayloads
+	}
+	return false
+}
+
 func (x *AppConfig) GetSections() map[string]string {
 	if x != nil {
 		return x.Sections
@@ -240,7 +359,7 @@ type Deployment struct {
 func (x *Deployment) Reset() {
 	*x = Deployment{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_config_proto_msgTypes[2]
+		mi := &file_config_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -253,7 +372,7 @@ func (x *Deployment) String() string {
 func (*Deployment) ProtoMessage() {}
 
 func (x *Deployment) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_config_proto_msgTypes[2]
+	mi := &file_config_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -266,7 +385,7 @@ func (x *Deployment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Deployment.ProtoReflect.Descriptor instead.
 func (*Deployment) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_config_proto_rawDescGZIP(), []int{2}
+	return file_config_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *Deployment) GetId() string {
@@ -290,30 +409,70 @@ func (x *Deployment) GetSingleProcess() bool {
 	return false
 }
 
-var File_runtime_protos_config_proto protoreflect.FileDescriptor
-
-var file_runtime_protos_config_proto_rawDesc = []byte{
-	0x0a, 0x1b, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2f, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x30, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
-	0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70,
-	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f,
-	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xb2, 0x02, 0x0a, 0x09, 0x41, 0x70, 0x70,
-	0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x69,
-	0x6e, 0x61, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x69, 0x6e, 0x61,
-	0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04, 0x20,
-	0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x33, 0x0a, 0x08, 0x63, 0x6f, 0x6c, 0x6f,
-	0x63, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e,
-	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72,
-	0x6f, 0x75, 0x70, 0x52, 0x08, 0x63, 0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x12, 0x23, 0x0a,
-	0x0d, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x06,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e,
-	0x6f, 0x73, 0x12, 0x3c, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41,
-	0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+var File_config_proto protoreflect.FileDescriptor
+
+var file_config_proto_rawDesc = []byte{
+	0x0a, 0x0c, 0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0x30, 0x0a, 0x0e, 0x43, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xc1, 0x07, 0x0a, 0x09, 0x41, 0x70,
+	0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x62,
+	0x69, 0x6e, 0x61, 0x72, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x62, 0x69, 0x6e,
+	0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x61, 0x72, 0x67, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x04, 0x61, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x76, 0x18, 0x04,
+	0x20, 0x03, 0x28, 0x09, 0x52, 0x03, 0x65, 0x6e, 0x76, 0x12, 0x33, 0x0a, 0x08, 0x63, 0x6f, 0x6c,
+	0x6f, 0x63, 0x61, 0x74, 0x65, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x47,
+	0x72, 0x6f, 0x75, 0x70, 0x52, 0x08, 0x63, 0x6f, 0x6c, 0x6f, 0x63, 0x61, 0x74, 0x65, 0x12, 0x23,
+	0x0a, 0x0d, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0c, 0x72, 0x6f, 0x6c, 0x6c, 0x6f, 0x75, 0x74, 0x4e, 0x61,
+	0x6e, 0x6f, 0x73, 0x12, 0x2c, 0x0a, 0x12, 0x69, 0x6e, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x10, 0x69, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f,
+	0x73, 0x12, 0x72, 0x0a, 0x1c, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x5f, 0x69,
+	0x6e, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f,
+	0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x31, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2e, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x43, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x19, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74,
+	0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x61, 0x7a, 0x79, 0x5f, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e,
+	0x6c, 0x61, 0x7a, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x27,
+	0x0a, 0x0f, 0x61, 0x75, 0x64, 0x69, 0x74, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64,
+	0x73, 0x18, 0x0b, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x75, 0x64, 0x69, 0x74, 0x65, 0x64,
+	0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x6c, 0x61, 0x72, 0x67, 0x65,
+	0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18, 0x0c,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x50, 0x61, 0x79, 0x6c, 0x6f,
+	0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x18, 0x69, 0x64, 0x65, 0x6d, 0x70,
+	0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6e, 0x61,
+	0x6e, 0x6f, 0x73, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x16, 0x69, 0x64, 0x65, 0x6d, 0x70,
+	0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4e, 0x61, 0x6e, 0x6f,
+	0x73, 0x12, 0x2f, 0x0a, 0x14, 0x61, 0x74, 0x5f, 0x6d, 0x6f, 0x73, 0x74, 0x5f, 0x6f, 0x6e, 0x63,
+	0x65, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x18, 0x0e, 0x20, 0x03, 0x28, 0x09, 0x52,
+	0x11, 0x61, 0x74, 0x4d, 0x6f, 0x73, 0x74, 0x4f, 0x6e, 0x63, 0x65, 0x4d, 0x65, 0x74, 0x68, 0x6f,
+	0x64, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
+	0x73, 0x73, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x67, 0x72, 0x70, 0x63, 0x41, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73,
+	0x73, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18,
+	0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65, 0x73, 0x73, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2a, 0x0a, 0x11, 0x6d,
+	0x61, 0x78, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73,
+	0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6d, 0x61, 0x78, 0x4d, 0x65, 0x73, 0x73, 0x61,
+	0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x68, 0x65, 0x63, 0x6b,
+	0x73, 0x75, 0x6d, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x18, 0x12, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x10, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x50, 0x61, 0x79, 0x6c,
+	0x6f, 0x61, 0x64, 0x73, 0x12, 0x3c, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
+	0x2e, 0x41, 0x70, 0x70, 0x43, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x73, 0x1a, 0x4c, 0x0a, 0x1e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49,
+	0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x45,
+	0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01,
 	0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72,
 	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
 	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
@@ -332,42 +491,44 @@ var file_runtime_protos_config_proto_rawDesc = []byte{
 }
 
 var (
-	file_runtime_protos_config_proto_rawDescOnce sync.Once
-	file_runtime_protos_config_proto_rawDescData = file_runtime_protos_config_proto_rawDesc
+	file_config_proto_rawDescOnce sync.Once
+	file_config_proto_rawDescData = file_config_proto_rawDesc
 )
 
-func file_runtime_protos_config_proto_rawDescGZIP() []byte {
-	file_runtime_protos_config_proto_rawDescOnce.Do(func() {
-		file_runtime_protos_config_proto_rawDescData = protoimpl.X.CompressGZIP(file_runtime_protos_config_proto_rawDescData)
+func file_config_proto_rawDescGZIP() []byte {
+	file_config_proto_rawDescOnce.Do(func() {
+		file_config_proto_rawDescData = protoimpl.X.CompressGZIP(file_config_proto_rawDescData)
 	})
-	return file_runtime_protos_config_proto_rawDescData
+	return file_config_proto_rawDescData
 }
 
-var file_runtime_protos_config_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
-var file_runtime_protos_config_proto_goTypes = []interface{}{
+var file_config_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_config_proto_goTypes = []interface{}{
 	(*ComponentGroup)(nil), // 0: runtime.ComponentGroup
 	(*AppConfig)(nil),      // 1: runtime.AppConfig
 	(*Deployment)(nil),     // 2: runtime.Deployment
-	nil,                    // 3: runtime.AppConfig.SectionsEntry
+	nil,                    // 3: runtime.AppConfig.ComponentInitTimeoutNanosEntry
+	nil,                    // 4: runtime.AppConfig.SectionsEntry
 }
-var file_runtime_protos_config_proto_depIdxs = []int32{
+var file_config_proto_depIdxs = []int32{
 	0, // 0: runtime.AppConfig.colocate:type_name -> runtime.ComponentGroup
-	3, // 1: runtime.AppConfig.sections:type_name -> runtime.AppConfig.SectionsEntry
-	1, // 2: runtime.Deployment.app:type_name -> runtime.AppConfig
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
-}
-
-func init() { file_runtime_protos_config_proto_init() }
-func file_runtime_protos_config_proto_init() {
-	if File_runtime_protos_config_proto != nil {
+	3, // 1: runtime.AppConfig.component_init_timeout_nanos:type_name -> runtime.AppConfig.ComponentInitTimeoutNanosEntry
+	4, // 2: runtime.AppConfig.sections:type_name -> runtime.AppConfig.SectionsEntry
+	1, // 3: runtime.Deployment.app:type_name -> runtime.AppConfig
+	4, // [4:4] is the sub-list for method output_type
+	4, // [4:4] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_config_proto_init() }
+func file_config_proto_init() {
+	if File_config_proto != nil {
 		return
 	}
 	if !protoimpl.UnsafeEnabled {
-		file_runtime_protos_config_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ComponentGroup); i {
 			case 0:
 				return &v.state
@@ -379,7 +540,7 @@ func file_runtime_protos_config_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_config_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*AppConfig); i {
 			case 0:
 				return &v.state
@@ -391,7 +552,7 @@ func file_runtime_protos_config_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+		file_config_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Deployment); i {
 			case 0:
 				return &v.state
@@ -408,18 +569,18 @@ func file_runtime_protos_config_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_runtime_protos_config_proto_rawDesc,
+			RawDescriptor: file_config_proto_rawDesc,
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   5,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
-		GoTypes:           file_runtime_protos_config_proto_goTypes,
-		DependencyIndexes: file_runtime_protos_config_proto_depIdxs,
-		MessageInfos:      file_runtime_protos_config_proto_msgTypes,
+		GoTypes:           file_config_proto_goTypes,
+		DependencyIndexes: file_config_proto_depIdxs,
+		MessageInfos:      file_config_proto_msgTypes,
 	}.Build()
-	File_runtime_protos_config_proto = out.File
-	file_runtime_protos_config_proto_rawDesc = nil
-	file_runtime_protos_config_proto_goTypes = nil
-	file_runtime_protos_config_proto_depIdxs = nil
+	File_config_proto = out.File
+	file_config_proto_rawDesc = nil
+	file_config_proto_goTypes = nil
+	file_config_proto_depIdxs = nil
 }
@@ -16,7 +16,7 @@
 // versions:
 // 	protoc-gen-go v1.34.1
 // 	protoc        v3.19.6
-// source: runtime/protos/runtime.proto
+// source: runtime.proto
 
 package protos
 
@@ -71,11 +71,11 @@ func (x HealthStatus) String() string {
 }
 
 func (HealthStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[0].Descriptor()
+	return file_runtime_proto_enumTypes[0].Descriptor()
 }
 
 func (HealthStatus) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[0]
+	return &file_runtime_proto_enumTypes[0]
 }
 
 func (x HealthStatus) Number() protoreflect.EnumNumber {
@@ -84,7 +84,7 @@ func (x HealthStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use HealthStatus.Descriptor instead.
 func (HealthStatus) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{0}
+	return file_runtime_proto_rawDescGZIP(), []int{0}
 }
 
 // MetricType specifies a particular kind of metric.
@@ -124,11 +124,11 @@ func (x MetricType) String() string {
 }
 
 func (MetricType) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[1].Descriptor()
+	return file_runtime_proto_enumTypes[1].Descriptor()
 }
 
 func (MetricType) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[1]
+	return &file_runtime_proto_enumTypes[1]
 }
 
 func (x MetricType) Number() protoreflect.EnumNumber {
@@ -137,7 +137,7 @@ func (x MetricType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use MetricType.Descriptor instead.
 func (MetricType) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{1}
+	return file_runtime_proto_rawDescGZIP(), []int{1}
 }
 
 // ProfileType specifies a type of profile.
@@ -174,11 +174,11 @@ func (x ProfileType) String() string {
 }
 
 func (ProfileType) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[2].Descriptor()
+	return file_runtime_proto_enumTypes[2].Descriptor()
 }
 
 func (ProfileType) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[2]
+	return &file_runtime_proto_enumTypes[2]
 }
 
 func (x ProfileType) Number() protoreflect.EnumNumber {
@@ -187,7 +187,59 @@ func (x ProfileType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ProfileType.Descriptor instead.
 func (ProfileType) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{2}
+	return file_runtime_proto_rawDescGZIP(), []int{2}
+}
+
+type DeploymentEvent_Kind int32
+
+const (
+	DeploymentEvent_UNSPECIFIED      DeploymentEvent_Kind = 0
+	DeploymentEvent_ROLLOUT          DeploymentEvent_Kind = 1 // a new version of the application is rolling out
+	DeploymentEvent_DRAINING         DeploymentEvent_Kind = 2 // the weavelet is about to be drained
+	DeploymentEvent_REPLICAS_CHANGED DeploymentEvent_Kind = 3 // the replicas of a component changed
+)
+
+// Enum value maps for DeploymentEvent_Kind.
+var (
+	DeploymentEvent_Kind_name = map[int32]string{
+		0: "UNSPECIFIED",
+		1: "ROLLOUT",
+		2: "DRAINING",
+		3: "REPLICAS_CHANGED",
+	}
+	DeploymentEvent_Kind_value = map[string]int32{
+		"UNSPECIFIED":      0,
+		"ROLLOUT":          1,
+		"DRAINING":         2,
+		"REPLICAS_CHANGED": 3,
+	}
+)
+
+func (x DeploymentEvent_Kind) Enum() *DeploymentEvent_Kind {
+	p := new(DeploymentEvent_Kind)
+	*p = x
+	return p
+}
+
+func (x DeploymentEvent_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (DeploymentEvent_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_runtime_proto_enumTypes[3].Descriptor()
+}
+
+func (DeploymentEvent_Kind) Type() protoreflect.EnumType {
+	return &file_runtime_proto_enumTypes[3]
+}
+
+func (x DeploymentEvent_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use DeploymentEvent_Kind.Descriptor instead.
+func (DeploymentEvent_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{25, 0}
 }
 
 // Span's role in the trace.
@@ -233,11 +285,11 @@ func (x Span_Kind) String() string {
 }
 
 func (Span_Kind) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[3].Descriptor()
+	return file_runtime_proto_enumTypes[4].Descriptor()
 }
 
 func (Span_Kind) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[3]
+	return &file_runtime_proto_enumTypes[4]
 }
 
 func (x Span_Kind) Number() protoreflect.EnumNumber {
@@ -246,7 +298,7 @@ func (x Span_Kind) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Span_Kind.Descriptor instead.
 func (Span_Kind) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0}
 }
 
 // Type describes the type of the value.
@@ -301,11 +353,11 @@ func (x Span_Attribute_Value_Type) String() string {
 }
 
 func (Span_Attribute_Value_Type) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[4].Descriptor()
+	return file_runtime_proto_enumTypes[5].Descriptor()
 }
 
 func (Span_Attribute_Value_Type) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[4]
+	return &file_runtime_proto_enumTypes[5]
 }
 
 func (x Span_Attribute_Value_Type) Number() protoreflect.EnumNumber {
@@ -314,7 +366,7 @@ func (x Span_Attribute_Value_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Span_Attribute_Value_Type.Descriptor instead.
 func (Span_Attribute_Value_Type) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0, 0, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0, 0, 0}
 }
 
 type Span_Status_Code int32
@@ -350,11 +402,11 @@ func (x Span_Status_Code) String() string {
 }
 
 func (Span_Status_Code) Descriptor() protoreflect.EnumDescriptor {
-	return file_runtime_protos_runtime_proto_enumTypes[5].Descriptor()
+	return file_runtime_proto_enumTypes[6].Descriptor()
 }
 
 func (Span_Status_Code) Type() protoreflect.EnumType {
-	return &file_runtime_protos_runtime_proto_enumTypes[5]
+	return &file_runtime_proto_enumTypes[6]
 }
 
 func (x Span_Status_Code) Number() protoreflect.EnumNumber {
@@ -363,7 +415,7 @@ func (x Span_Status_Code) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Span_Status_Code.Descriptor instead.
 func (Span_Status_Code) EnumDescriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 3, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 3, 0}
 }
 
 // WeaveletArgs is the information provided by an envelope to a weavelet when
@@ -392,12 +444,30 @@ type WeaveletArgs struct {
 	// method calls.
 	ControlSocket string                   `protobuf:"bytes,13,opt,name=control_socket,json=controlSocket,proto3" json:"control_socket,omitempty"`
 	Redirects     []*WeaveletArgs_Redirect `protobuf:"bytes,12,rep,name=redirects,proto3" json:"redirects,omitempty"`
+	// Directory where the weavelet should write a crash report if one of its
+	// components panics. If empty, no crash reports are written.
+	CrashReportDir string `protobuf:"bytes,14,opt,name=crash_report_dir,json=crashReportDir,proto3" json:"crash_report_dir,omitempty"`
+	// URL to which the weavelet should POST a JSON crash report if one of its
+	// components panics. If empty, crash reports are not posted.
+	CrashReportWebhook string `protobuf:"bytes,15,opt,name=crash_report_webhook,json=crashReportWebhook,proto3" json:"crash_report_webhook,omitempty"`
+	// Directory where the weavelet should write audit records for calls to
+	// audited component methods. If empty, no audit records are written.
+	AuditDir string `protobuf:"bytes,16,opt,name=audit_dir,json=auditDir,proto3" json:"audit_dir,omitempty"`
+	// Name of the colocation group the weavelet belongs to, and the index of
+	// the weavelet among the replicas of the group. Reported to components by
+	// weaver.ReplicaInfo. An empty group means the deployer didn't report it.
+	Group        string `protobuf:"bytes,17,opt,name=group,proto3" json:"group,omitempty"`
+	ReplicaIndex int32  `protobuf:"varint,18,opt,name=replica_index,json=replicaIndex,proto3" json:"replica_index,omitempty"`
+	// Region and zone of the machine running the weavelet. Calls to components
+	// in other colocation groups prefer replicas in the same zone, then in the
+	// same region. An empty locality means the deployer didn't report it.
+	Locality *Locality `protobuf:"bytes,19,opt,name=locality,proto3" json:"locality,omitempty"`
 }
 
 func (x *WeaveletArgs) Reset() {
 	*x = WeaveletArgs{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[0]
+		mi := &file_runtime_proto_msgTypes[0]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -410,7 +480,7 @@ func (x *WeaveletArgs) String() string {
 func (*WeaveletArgs) ProtoMessage() {}
 
 func (x *WeaveletArgs) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[0]
+	mi := &file_runtime_proto_msgTypes[0]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -423,7 +493,7 @@ func (x *WeaveletArgs) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletArgs.ProtoReflect.Descriptor instead.
 func (*WeaveletArgs) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{0}
+	return file_runtime_proto_rawDescGZIP(), []int{0}
 }
 
 func (x *WeaveletArgs) GetApp() string {
@@ -482,6 +552,104 @@ func (x *WeaveletArgs) GetRedirects() []*WeaveletArgs_Redirect {
 	return nil
 }
 
+func (x *WeaveletArgs) GetCrashReportDir() string {
+	if x != nil {
+		return x.CrashReportDir
+	}
+	return ""
+}
+
+func (x *WeaveletArgs) GetCrashReportWebhook() string {
+	if x != nil {
+		return x.CrashReportWebhook
+	}
+	return ""
+}
+
+func (x *WeaveletArgs) GetAuditDir() string {
+	if x != nil {
+		return x.AuditDir
+	}
+	return ""
+}
+
+func (x *WeaveletArgs) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *WeaveletArgs) GetReplicaIndex() int32 {
+	if x != nil {
+		return x.ReplicaIndex
+	}
+	return 0
+}
+
+func (x *WeaveletArgs) GetLocality() *Locality {
+	if x != nil {
+		return x.Locality
+	}
+	return nil
+}
+
+// Locality is the region and zone of a machine.
+type Locality struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Region string `protobuf:"bytes,1,opt,name=region,proto3" json:"region,omitempty"` // e.g., "us-east1"
+	Zone   string `protobuf:"bytes,2,opt,name=zone,proto3" json:"zone,omitempty"`     // e.g., "us-east1-b"
+}
+
+func (x *Locality) Reset() {
+	*x = Locality{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Locality) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Locality) ProtoMessage() {}
+
+func (x *Locality) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Locality.ProtoReflect.Descriptor instead.
+func (*Locality) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Locality) GetRegion() string {
+	if x != nil {
+		return x.Region
+	}
+	return ""
+}
+
+func (x *Locality) GetZone() string {
+	if x != nil {
+		return x.Zone
+	}
+	return ""
+}
+
 // InitWeaveletRequest holds the initialization info passed to the weavelet by the envelope.
 type InitWeaveletRequest struct {
 	state         protoimpl.MessageState
@@ -489,12 +657,34 @@ type InitWeaveletRequest struct {
 	unknownFields protoimpl.UnknownFields
 
 	Sections map[string]string `protobuf:"bytes,1,rep,name=sections,proto3" json:"sections,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"` // See AppConfig.Sections.
+	// See AppConfig.InitTimeoutNanos.
+	InitTimeoutNanos int64 `protobuf:"varint,2,opt,name=init_timeout_nanos,json=initTimeoutNanos,proto3" json:"init_timeout_nanos,omitempty"`
+	// See AppConfig.ComponentInitTimeoutNanos.
+	ComponentInitTimeoutNanos map[string]int64 `protobuf:"bytes,3,rep,name=component_init_timeout_nanos,json=componentInitTimeoutNanos,proto3" json:"component_init_timeout_nanos,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	// See AppConfig.LazyComponents.
+	LazyComponents []string `protobuf:"bytes,4,rep,name=lazy_components,json=lazyComponents,proto3" json:"lazy_components,omitempty"`
+	// See AppConfig.AuditedMethods.
+	AuditedMethods []string `protobuf:"bytes,5,rep,name=audited_methods,json=auditedMethods,proto3" json:"audited_methods,omitempty"`
+	// See AppConfig.LargePayloadBytes.
+	LargePayloadBytes int64 `protobuf:"varint,6,opt,name=large_payload_bytes,json=largePayloadBytes,proto3" json:"large_payload_bytes,omitempty"`
+	// See AppConfig.IdempotencyWindowNanos.
+	IdempotencyWindowNanos int64 `protobuf:"varint,7,opt,name=idempotency_window_nanos,json=idempotencyWindowNanos,proto3" json:"idempotency_window_nanos,omitempty"`
+	// See AppConfig.AtMostOnceMethods.
+	AtMostOnceMethods []string `protobuf:"bytes,8,rep,name=at_most_once_methods,json=atMostOnceMethods,proto3" json:"at_most_once_methods,omitempty"`
+	// See AppConfig.GrpcAddress.
+	GrpcAddress string `protobuf:"bytes,9,opt,name=grpc_address,json=grpcAddress,proto3" json:"grpc_address,omitempty"`
+	// See AppConfig.CompressPayloadBytes.
+	CompressPayloadBytes int64 `protobuf:"varint,10,opt,name=compress_payload_bytes,json=compressPayloadBytes,proto3" json:"compress_payload_bytes,omitempty"`
+	// See AppConfig.MaxMessageBytes.
+	MaxMessageBytes int64 `protobuf:"varint,11,opt,name=max_message_bytes,json=maxMessageBytes,proto3" json:"max_message_bytes,omitempty"`
+	// See AppConfig.ChecksumPayloads.
+	ChecksumPayloads bool `protobuf:"varint,12,opt,name=checksum_payloads,json=checksumPayloads,proto3" json:"checksum_payloads,omitempty"`
 }
 
 func (x *InitWeaveletRequest) Reset() {
 	*x = InitWeaveletRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+		mi := &file_runtime_proto_msgTypes[2]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -507,7 +697,7 @@ func (x *InitWeaveletRequest) String() string {
 func (*InitWeaveletRequest) ProtoMessage() {}
 
 func (x *InitWeaveletRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[1]
+	mi := &file_runtime_proto_msgTypes[2]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -520,7 +710,7 @@ func (x *InitWeaveletRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitWeaveletRequest.ProtoReflect.Descriptor instead.
 func (*InitWeaveletRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{1}
+	return file_runtime_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *InitWeaveletRequest) GetSections() map[string]string {
@@ -530,6 +720,83 @@ func (x *InitWeaveletRequest) GetSections() map[string]string {
 	return nil
 }
 
+func (x *InitWeaveletRequest) GetInitTimeoutNanos() int64 {
+	if x != nil {
+		return x.InitTimeoutNanos
+	}
+	return 0
+}
+
+func (x *InitWeaveletRequest) GetComponentInitTimeoutNanos() map[string]int64 {
+	if x != nil {
+		return x.ComponentInitTimeoutNanos
+	}
+	return nil
+}
+
+func (x *InitWeaveletRequest) GetLazyComponents() []string {
+	if x != nil {
+		return x.LazyComponents
+	}
+	return nil
+}
+
+func (x *InitWeaveletRequest) GetAuditedMethods() []string {
+	if x != nil {
+		return x.AuditedMethods
+	}
+	return nil
+}
+
+func (x *InitWeaveletRequest) GetLargePayloadBytes() int64 {
+	if x != nil {
+		return x.LargePayloadBytes
+	}
+	return 0
+}
+
+func (x *InitWeaveletRequest) GetIdempotencyWindowNanos() int64 {
+	if x != nil {
+		return x.IdempotencyWindowNanos
+	}
+	return 0
+}
+
+func (x *InitWeaveletRequest) GetAtMostOnceMethods() []string {
+	if x != nil {
+		return x.AtMostOnceMethods
+	}
+	return nil
+}
+
+func (x *InitWeaveletRequest) GetGrpcAddress() string {
+	if x != nil {
+		return x.GrpcAddress
+	}
+	return ""
+}
+
+func (x *InitWeaveletRequest) GetCompressPayloadBytes() int64 {
+	if x != nil {
+		return x.CompressPayloadBytes
+	}
+	return 0
+}
+
+func (x *InitWeaveletRequest) GetMaxMessageBytes() int64 {
+	if x != nil {
+		return x.MaxMessageBytes
+	}
+	return 0
+}
+
+func (x *InitWeaveletRequest) GetChecksumPayloads() bool {
+	if x != nil {
+		return x.ChecksumPayloads
+	}
+	return false
+}
+
 // InitWeaveletReply is the information provided by a weavelet to an envelope during
 // the initial envelope-weavelet handshake.
 type InitWeaveletReply struct {
@@ -547,7 +814,7 @@ type InitWeaveletReply struct {
 func (x *InitWeaveletReply) Reset() {
 	*x = InitWeaveletReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+		mi := &file_runtime_proto_msgTypes[3]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -560,7 +827,7 @@ func (x *InitWeaveletReply) String() string {
 func (*InitWeaveletReply) ProtoMessage() {}
 
 func (x *InitWeaveletReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[2]
+	mi := &file_runtime_proto_msgTypes[3]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -573,7 +840,7 @@ func (x *InitWeaveletReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use InitWeaveletReply.ProtoReflect.Descriptor instead.
 func (*InitWeaveletReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{2}
+	return file_runtime_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *InitWeaveletReply) GetDialAddr() string {
@@ -608,7 +875,7 @@ type SemVer struct {
 func (x *SemVer) Reset() {
 	*x = SemVer{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+		mi := &file_runtime_proto_msgTypes[4]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -621,7 +888,7 @@ func (x *SemVer) String() string {
 func (*SemVer) ProtoMessage() {}
 
 func (x *SemVer) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[3]
+	mi := &file_runtime_proto_msgTypes[4]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -634,7 +901,7 @@ func (x *SemVer) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SemVer.ProtoReflect.Descriptor instead.
 func (*SemVer) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{3}
+	return file_runtime_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *SemVer) GetMajor() int64 {
@@ -668,7 +935,7 @@ type GetHealthRequest struct {
 func (x *GetHealthRequest) Reset() {
 	*x = GetHealthRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[4]
+		mi := &file_runtime_proto_msgTypes[5]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -681,7 +948,7 @@ func (x *GetHealthRequest) String() string {
 func (*GetHealthRequest) ProtoMessage() {}
 
 func (x *GetHealthRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[4]
+	mi := &file_runtime_proto_msgTypes[5]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -694,7 +961,7 @@ func (x *GetHealthRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetHealthRequest.ProtoReflect.Descriptor instead.
 func (*GetHealthRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{4}
+	return file_runtime_proto_rawDescGZIP(), []int{5}
 }
 
 // GetHealthReply is a reply to a GetHealthRequest.
@@ -710,7 +977,7 @@ type GetHealthReply struct {
 func (x *GetHealthReply) Reset() {
 	*x = GetHealthReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[5]
+		mi := &file_runtime_proto_msgTypes[6]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -723,7 +990,7 @@ func (x *GetHealthReply) String() string {
 func (*GetHealthReply) ProtoMessage() {}
 
 func (x *GetHealthReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[5]
+	mi := &file_runtime_proto_msgTypes[6]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -736,7 +1003,7 @@ func (x *GetHealthReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetHealthReply.ProtoReflect.Descriptor instead.
 func (*GetHealthReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{5}
+	return file_runtime_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *GetHealthReply) GetStatus() HealthStatus {
@@ -764,7 +1031,7 @@ type GetMetricsRequest struct {
 func (x *GetMetricsRequest) Reset() {
 	*x = GetMetricsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[6]
+		mi := &file_runtime_proto_msgTypes[7]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -777,7 +1044,7 @@ func (x *GetMetricsRequest) String() string {
 func (*GetMetricsRequest) ProtoMessage() {}
 
 func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[6]
+	mi := &file_runtime_proto_msgTypes[7]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -790,7 +1057,7 @@ func (x *GetMetricsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetricsRequest.ProtoReflect.Descriptor instead.
 func (*GetMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{6}
+	return file_runtime_proto_rawDescGZIP(), []int{7}
 }
 
 // GetMetricsReply is a reply to a GetMetricsRequest. It only contains
@@ -807,7 +1074,7 @@ type GetMetricsReply struct {
 func (x *GetMetricsReply) Reset() {
 	*x = GetMetricsReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[7]
+		mi := &file_runtime_proto_msgTypes[8]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -820,7 +1087,7 @@ func (x *GetMetricsReply) String() string {
 func (*GetMetricsReply) ProtoMessage() {}
 
 func (x *GetMetricsReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[7]
+	mi := &file_runtime_proto_msgTypes[8]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -833,7 +1100,7 @@ func (x *GetMetricsReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetMetricsReply.ProtoReflect.Descriptor instead.
 func (*GetMetricsReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{7}
+	return file_runtime_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *GetMetricsReply) GetUpdate() *MetricUpdate {
@@ -862,7 +1129,7 @@ type MetricUpdate struct {
 func (x *MetricUpdate) Reset() {
 	*x = MetricUpdate{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+		mi := &file_runtime_proto_msgTypes[9]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -875,7 +1142,7 @@ func (x *MetricUpdate) String() string {
 func (*MetricUpdate) ProtoMessage() {}
 
 func (x *MetricUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[8]
+	mi := &file_runtime_proto_msgTypes[9]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -888,7 +1155,7 @@ func (x *MetricUpdate) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricUpdate.ProtoReflect.Descriptor instead.
 func (*MetricUpdate) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{8}
+	return file_runtime_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *MetricUpdate) GetDefs() []*MetricDef {
@@ -922,7 +1189,7 @@ type MetricDef struct {
 func (x *MetricDef) Reset() {
 	*x = MetricDef{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[9]
+		mi := &file_runtime_proto_msgTypes[10]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -935,7 +1202,7 @@ func (x *MetricDef) String() string {
 func (*MetricDef) ProtoMessage() {}
 
 func (x *MetricDef) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[9]
+	mi := &file_runtime_proto_msgTypes[10]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -948,7 +1215,7 @@ func (x *MetricDef) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricDef.ProtoReflect.Descriptor instead.
 func (*MetricDef) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{9}
+	return file_runtime_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *MetricDef) GetId() uint64 {
@@ -1007,7 +1274,7 @@ type MetricValue struct {
 func (x *MetricValue) Reset() {
 	*x = MetricValue{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[10]
+		mi := &file_runtime_proto_msgTypes[11]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1020,7 +1287,7 @@ func (x *MetricValue) String() string {
 func (*MetricValue) ProtoMessage() {}
 
 func (x *MetricValue) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[10]
+	mi := &file_runtime_proto_msgTypes[11]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1033,7 +1300,7 @@ func (x *MetricValue) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricValue.ProtoReflect.Descriptor instead.
 func (*MetricValue) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{10}
+	return file_runtime_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *MetricValue) GetId() uint64 {
@@ -1079,7 +1346,7 @@ type MetricSnapshot struct {
 func (x *MetricSnapshot) Reset() {
 	*x = MetricSnapshot{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[11]
+		mi := &file_runtime_proto_msgTypes[12]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1092,7 +1359,7 @@ func (x *MetricSnapshot) String() string {
 func (*MetricSnapshot) ProtoMessage() {}
 
 func (x *MetricSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[11]
+	mi := &file_runtime_proto_msgTypes[12]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1105,7 +1372,7 @@ func (x *MetricSnapshot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MetricSnapshot.ProtoReflect.Descriptor instead.
 func (*MetricSnapshot) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{11}
+	return file_runtime_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *MetricSnapshot) GetId() uint64 {
@@ -1174,7 +1441,7 @@ type GetLoadRequest struct {
 func (x *GetLoadRequest) Reset() {
 	*x = GetLoadRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[12]
+		mi := &file_runtime_proto_msgTypes[13]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1187,7 +1454,7 @@ func (x *GetLoadRequest) String() string {
 func (*GetLoadRequest) ProtoMessage() {}
 
 func (x *GetLoadRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[12]
+	mi := &file_runtime_proto_msgTypes[13]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1200,7 +1467,7 @@ func (x *GetLoadRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLoadRequest.ProtoReflect.Descriptor instead.
 func (*GetLoadRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{12}
+	return file_runtime_proto_rawDescGZIP(), []int{13}
 }
 
 // GetLoadReply is a reply to a GetLoadRequest.
@@ -1215,7 +1482,7 @@ type GetLoadReply struct {
 func (x *GetLoadReply) Reset() {
 	*x = GetLoadReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[13]
+		mi := &file_runtime_proto_msgTypes[14]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1228,7 +1495,7 @@ func (x *GetLoadReply) String() string {
 func (*GetLoadReply) ProtoMessage() {}
 
 func (x *GetLoadReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[13]
+	mi := &file_runtime_proto_msgTypes[14]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1241,7 +1508,7 @@ func (x *GetLoadReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetLoadReply.ProtoReflect.Descriptor instead.
 func (*GetLoadReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{13}
+	return file_runtime_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *GetLoadReply) GetLoad() *LoadReport {
@@ -1264,7 +1531,7 @@ type LoadReport struct {
 func (x *LoadReport) Reset() {
 	*x = LoadReport{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[14]
+		mi := &file_runtime_proto_msgTypes[15]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1277,7 +1544,7 @@ func (x *LoadReport) String() string {
 func (*LoadReport) ProtoMessage() {}
 
 func (x *LoadReport) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[14]
+	mi := &file_runtime_proto_msgTypes[15]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1290,7 +1557,7 @@ func (x *LoadReport) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoadReport.ProtoReflect.Descriptor instead.
 func (*LoadReport) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14}
+	return file_runtime_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *LoadReport) GetLoads() map[string]*LoadReport_ComponentLoad {
@@ -1317,7 +1584,7 @@ type GetProfileRequest struct {
 func (x *GetProfileRequest) Reset() {
 	*x = GetProfileRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[15]
+		mi := &file_runtime_proto_msgTypes[16]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1330,7 +1597,7 @@ func (x *GetProfileRequest) String() string {
 func (*GetProfileRequest) ProtoMessage() {}
 
 func (x *GetProfileRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[15]
+	mi := &file_runtime_proto_msgTypes[16]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1343,7 +1610,7 @@ func (x *GetProfileRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetProfileRequest.ProtoReflect.Descriptor instead.
 func (*GetProfileRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{15}
+	return file_runtime_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *GetProfileRequest) GetProfileType() ProfileType {
@@ -1372,7 +1639,7 @@ type GetProfileReply struct {
 func (x *GetProfileReply) Reset() {
 	*x = GetProfileReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[16]
+		mi := &file_runtime_proto_msgTypes[17]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1385,7 +1652,7 @@ func (x *GetProfileReply) String() string {
 func (*GetProfileReply) ProtoMessage() {}
 
 func (x *GetProfileReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[16]
+	mi := &file_runtime_proto_msgTypes[17]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1398,7 +1665,7 @@ func (x *GetProfileReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetProfileReply.ProtoReflect.Descriptor instead.
 func (*GetProfileReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{16}
+	return file_runtime_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *GetProfileReply) GetData() []byte {
@@ -1408,33 +1675,31 @@ func (x *GetProfileReply) GetData() []byte {
 	return nil
 }
 
-// UpdateRoutingInfoRequest is a request from an envelope to the weavelet to
-// update its routing information for a particular component.
-type UpdateRoutingInfoRequest struct {
+// GetFlightRecordRequest is a request from an envelope for the component
+// method calls recently recorded by a weavelet's flight recorder.
+type GetFlightRecordRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
-
-	RoutingInfo *RoutingInfo `protobuf:"bytes,1,opt,name=routing_info,json=routingInfo,proto3" json:"routing_info,omitempty"`
 }
 
-func (x *UpdateRoutingInfoRequest) Reset() {
-	*x = UpdateRoutingInfoRequest{}
+func (x *GetFlightRecordRequest) Reset() {
+	*x = GetFlightRecordRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[17]
+		mi := &file_runtime_proto_msgTypes[18]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UpdateRoutingInfoRequest) String() string {
+func (x *GetFlightRecordRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateRoutingInfoRequest) ProtoMessage() {}
+func (*GetFlightRecordRequest) ProtoMessage() {}
 
-func (x *UpdateRoutingInfoRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[17]
+func (x *GetFlightRecordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[18]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1445,42 +1710,37 @@ func (x *UpdateRoutingInfoRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateRoutingInfoRequest.ProtoReflect.Descriptor instead.
-func (*UpdateRoutingInfoRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{17}
-}
-
-func (x *UpdateRoutingInfoRequest) GetRoutingInfo() *RoutingInfo {
-	if x != nil {
-		return x.RoutingInfo
-	}
-	return nil
+// Deprecated: Use GetFlightRecordRequest.ProtoReflect.Descriptor instead.
+func (*GetFlightRecordRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{18}
 }
 
-// UpdateRoutingInfoReply is a reply to an UpdateRoutingInfoRequest.
-type UpdateRoutingInfoReply struct {
+// GetFlightRecordReply is a reply to a GetFlightRecordRequest.
+type GetFlightRecordReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
+
+	Events []*CallEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"` // recorded calls, from oldest to newest
 }
 
-func (x *UpdateRoutingInfoReply) Reset() {
-	*x = UpdateRoutingInfoReply{}
+func (x *GetFlightRecordReply) Reset() {
+	*x = GetFlightRecordReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[18]
+		mi := &file_runtime_proto_msgTypes[19]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *UpdateRoutingInfoReply) String() string {
+func (x *GetFlightRecordReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateRoutingInfoReply) ProtoMessage() {}
+func (*GetFlightRecordReply) ProtoMessage() {}
 
-func (x *UpdateRoutingInfoReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[18]
+func (x *GetFlightRecordReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[19]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1491,49 +1751,51 @@ func (x *UpdateRoutingInfoReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateRoutingInfoReply.ProtoReflect.Descriptor instead.
-func (*UpdateRoutingInfoReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use GetFlightRecordReply.ProtoReflect.Descriptor instead.
+func (*GetFlightRecordReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{19}
 }
 
-// RoutingInfo contains routing information for a component. A weavelet uses a
-// RoutingInfo to know where to send a remote method call.
-type RoutingInfo struct {
+func (x *GetFlightRecordReply) GetEvents() []*CallEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+// CallEvent records a single completed component method call.
+type CallEvent struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Component string `protobuf:"bytes,1,opt,name=component,proto3" json:"component,omitempty"`
-	// If true, perform method calls on the component locally. Replicas and
-	// assignment are ignored. Currently, local must always be true or always be
-	// false for a given component.
-	Local bool `protobuf:"varint,2,opt,name=local,proto3" json:"local,omitempty"`
-	// The addresses of the weavelets hosting a replica of the component. Every
-	// address is of the form "<net>://<addr>" (e.g., "tcp://host:1234",
-	// "unix:///tmp/unix.sock"). For non-local components, a weavelet will execute
-	// method calls on one of these replicas.
-	Replicas []string `protobuf:"bytes,3,rep,name=replicas,proto3" json:"replicas,omitempty"`
-	// Routing assignment, if the component is routed.
-	Assignment *Assignment `protobuf:"bytes,4,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	TimeMicros    int64  `protobuf:"varint,1,opt,name=time_micros,json=timeMicros,proto3" json:"time_micros,omitempty"`          // call end time, in microseconds since epoch
+	Caller        string `protobuf:"bytes,2,opt,name=caller,proto3" json:"caller,omitempty"`                                     // full calling component name
+	Component     string `protobuf:"bytes,3,opt,name=component,proto3" json:"component,omitempty"`                               // full callee component name
+	Method        string `protobuf:"bytes,4,opt,name=method,proto3" json:"method,omitempty"`                                     // callee method name
+	LatencyMicros int64  `protobuf:"varint,5,opt,name=latency_micros,json=latencyMicros,proto3" json:"latency_micros,omitempty"` // call latency, in microseconds
+	Failed        bool   `protobuf:"varint,6,opt,name=failed,proto3" json:"failed,omitempty"`                                    // did the call return an error?
+	Remote        bool   `protobuf:"varint,7,opt,name=remote,proto3" json:"remote,omitempty"`                                    // was the call remote?
+	Weavelet      string `protobuf:"bytes,8,opt,name=weavelet,proto3" json:"weavelet,omitempty"`                                 // id of the weavelet that recorded the call
 }
 
-func (x *RoutingInfo) Reset() {
-	*x = RoutingInfo{}
+func (x *CallEvent) Reset() {
+	*x = CallEvent{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[19]
+		mi := &file_runtime_proto_msgTypes[20]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *RoutingInfo) String() string {
+func (x *CallEvent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*RoutingInfo) ProtoMessage() {}
+func (*CallEvent) ProtoMessage() {}
 
-func (x *RoutingInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[19]
+func (x *CallEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[20]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1544,70 +1806,482 @@ func (x *RoutingInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use RoutingInfo.ProtoReflect.Descriptor instead.
-func (*RoutingInfo) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use CallEvent.ProtoReflect.Descriptor instead.
+func (*CallEvent) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{20}
 }
 
-func (x *RoutingInfo) GetComponent() string {
+func (x *CallEvent) GetTimeMicros() int64 {
+	if x != nil {
+		return x.TimeMicros
+	}
+	return 0
+}
+
+func (x *CallEvent) GetCaller() string {
+	if x != nil {
+		return x.Caller
+	}
+	return ""
+}
+
+func (x *CallEvent) GetComponent() string {
 	if x != nil {
 		return x.Component
 	}
 	return ""
 }
 
-func (x *RoutingInfo) GetLocal() bool {
+func (x *CallEvent) GetMethod() string {
 	if x != nil {
-		return x.Local
+		return x.Method
+	}
+	return ""
+}
+
+func (x *CallEvent) GetLatencyMicros() int64 {
+	if x != nil {
+		return x.LatencyMicros
+	}
+	return 0
+}
+
+func (x *CallEvent) GetFailed() bool {
+	if x != nil {
+		return x.Failed
 	}
 	return false
 }
 
-func (x *RoutingInfo) GetReplicas() []string {
+func (x *CallEvent) GetRemote() bool {
 	if x != nil {
-		return x.Replicas
+		return x.Remote
 	}
-	return nil
+	return false
 }
 
-func (x *RoutingInfo) GetAssignment() *Assignment {
+func (x *CallEvent) GetWeavelet() string {
 	if x != nil {
-		return x.Assignment
+		return x.Weavelet
 	}
-	return nil
+	return ""
 }
 
-// Assignment partitions a key space (e.g., the hash space [0, 2^64)) into a set
-// of subregions, called slices, and assigns each slice to a set of replicas.
-type Assignment struct {
+// UpdateRoutingInfoRequest is a request from an envelope to the weavelet to
+// update its routing information for a particular component.
+type UpdateRoutingInfoRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Slices []*Assignment_Slice `protobuf:"bytes,1,rep,name=slices,proto3" json:"slices,omitempty"`
-	// The assignment for a component can change over time. Every one of these
-	// assignments is given a monotonically increasing version. Versions are
-	// unique for a given component, but not unique across components.
-	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	RoutingInfo *RoutingInfo `protobuf:"bytes,1,opt,name=routing_info,json=routingInfo,proto3" json:"routing_info,omitempty"`
 }
 
-func (x *Assignment) Reset() {
-	*x = Assignment{}
+func (x *UpdateRoutingInfoRequest) Reset() {
+	*x = UpdateRoutingInfoRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[20]
+		mi := &file_runtime_proto_msgTypes[21]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *Assignment) String() string {
+func (x *UpdateRoutingInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRoutingInfoRequest) ProtoMessage() {}
+
+func (x *UpdateRoutingInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[21]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRoutingInfoRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRoutingInfoRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateRoutingInfoRequest) GetRoutingInfo() *RoutingInfo {
+	if x != nil {
+		return x.RoutingInfo
+	}
+	return nil
+}
+
+// UpdateRoutingInfoReply is a reply to an UpdateRoutingInfoRequest.
+type UpdateRoutingInfoReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *UpdateRoutingInfoReply) Reset() {
+	*x = UpdateRoutingInfoReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[22]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *UpdateRoutingInfoReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRoutingInfoReply) ProtoMessage() {}
+
+func (x *UpdateRoutingInfoReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[22]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRoutingInfoReply.ProtoReflect.Descriptor instead.
+func (*UpdateRoutingInfoReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{22}
+}
+
+// NotifyDeploymentEventRequest is a request from an envelope to notify the
+// weavelet of an event in the deployment of the application. The weavelet
+// replies once the components subscribed to deployment events have handled
+// the event.
+type NotifyDeploymentEventRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Event *DeploymentEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+}
+
+func (x *NotifyDeploymentEventRequest) Reset() {
+	*x = NotifyDeploymentEventRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[23]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NotifyDeploymentEventRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotifyDeploymentEventRequest) ProtoMessage() {}
+
+func (x *NotifyDeploymentEventRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[23]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotifyDeploymentEventRequest.ProtoReflect.Descriptor instead.
+func (*NotifyDeploymentEventRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *NotifyDeploymentEventRequest) GetEvent() *DeploymentEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// NotifyDeploymentEventReply is a reply to a NotifyDeploymentEventRequest.
+type NotifyDeploymentEventReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *NotifyDeploymentEventReply) Reset() {
+	*x = NotifyDeploymentEventReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[24]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *NotifyDeploymentEventReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NotifyDeploymentEventReply) ProtoMessage() {}
+
+func (x *NotifyDeploymentEventReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[24]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NotifyDeploymentEventReply.ProtoReflect.Descriptor instead.
+func (*NotifyDeploymentEventReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{24}
+}
+
+// DeploymentEvent is an event in the deployment of an application.
+type DeploymentEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Kind      DeploymentEvent_Kind `protobuf:"varint,1,opt,name=kind,proto3,enum=runtime.DeploymentEvent_Kind" json:"kind,omitempty"`
+	Version   string               `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`     // ROLLOUT: deployment id of the new version
+	Component string               `protobuf:"bytes,3,opt,name=component,proto3" json:"component,omitempty"` // REPLICAS_CHANGED: full component name
+	Replicas  []string             `protobuf:"bytes,4,rep,name=replicas,proto3" json:"replicas,omitempty"`   // REPLICAS_CHANGED: current replicas
+}
+
+func (x *DeploymentEvent) Reset() {
+	*x = DeploymentEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[25]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeploymentEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeploymentEvent) ProtoMessage() {}
+
+func (x *DeploymentEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[25]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeploymentEvent.ProtoReflect.Descriptor instead.
+func (*DeploymentEvent) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DeploymentEvent) GetKind() DeploymentEvent_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return DeploymentEvent_UNSPECIFIED
+}
+
+func (x *DeploymentEvent) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *DeploymentEvent) GetComponent() string {
+	if x != nil {
+		return x.Component
+	}
+	return ""
+}
+
+func (x *DeploymentEvent) GetReplicas() []string {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+// RoutingInfo contains routing information for a component. A weavelet uses a
+// RoutingInfo to know where to send a remote method call.
+type RoutingInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Component string `protobuf:"bytes,1,opt,name=component,proto3" json:"component,omitempty"`
+	// If true, perform method calls on the component locally. Replicas and
+	// assignment are ignored. Currently, local must always be true or always be
+	// false for a given component.
+	Local bool `protobuf:"varint,2,opt,name=local,proto3" json:"local,omitempty"`
+	// The addresses of the weavelets hosting a replica of the component. Every
+	// address is of the form "<net>://<addr>" (e.g., "tcp://host:1234",
+	// "unix:///tmp/unix.sock"). For non-local components, a weavelet will execute
+	// method calls on one of these replicas.
+	Replicas []string `protobuf:"bytes,3,rep,name=replicas,proto3" json:"replicas,omitempty"`
+	// Routing assignment, if the component is routed.
+	Assignment *Assignment `protobuf:"bytes,4,opt,name=assignment,proto3" json:"assignment,omitempty"`
+	// The localities of the replicas, keyed by replica address. Replicas
+	// without a locality are treated as being in an unknown region and zone.
+	Localities map[string]*Locality `protobuf:"bytes,5,rep,name=localities,proto3" json:"localities,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// The version of the routing info. Versions increase monotonically for a
+	// given component and receiver. If zero, the routing info is unversioned,
+	// and always replaces the routing info of the receiver.
+	Version uint64 `protobuf:"varint,6,opt,name=version,proto3" json:"version,omitempty"`
+	// If not zero, the routing info is a delta against the routing info with
+	// version base_version: replicas lists the added replicas, removed_replicas
+	// lists the removed replicas, localities lists the localities of the added
+	// replicas, and assignment, if present, replaces the assignment. A delta
+	// with version equal to base_version renews the lease of the routing info.
+	BaseVersion uint64 `protobuf:"varint,7,opt,name=base_version,json=baseVersion,proto3" json:"base_version,omitempty"`
+	// The replicas removed by a delta.
+	RemovedReplicas []string `protobuf:"bytes,8,rep,name=removed_replicas,json=removedReplicas,proto3" json:"removed_replicas,omitempty"`
+	// How long, in nanoseconds, the receiver can consider the routing info up
+	// to date without hearing from the sender. If zero, forever.
+	LeaseNanos int64 `protobuf:"varint,9,opt,name=lease_nanos,json=leaseNanos,proto3" json:"lease_nanos,omitempty"`
+}
+
+func (x *RoutingInfo) Reset() {
+	*x = RoutingInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[26]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *RoutingInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RoutingInfo) ProtoMessage() {}
+
+func (x *RoutingInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[26]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RoutingInfo.ProtoReflect.Descriptor instead.
+func (*RoutingInfo) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *RoutingInfo) GetComponent() string {
+	if x != nil {
+		return x.Component
+	}
+	return ""
+}
+
+func (x *RoutingInfo) GetLocal() bool {
+	if x != nil {
+		return x.Local
+	}
+	return false
+}
+
+func (x *RoutingInfo) GetReplicas() []string {
+	if x != nil {
+		return x.Replicas
+	}
+	return nil
+}
+
+func (x *RoutingInfo) GetAssignment() *Assignment {
+	if x != nil {
+		return x.Assignment
+	}
+	return nil
+}
+
+func (x *RoutingInfo) GetLocalities() map[string]*Locality {
+	if x != nil {
+		return x.Localities
+	}
+	return nil
+}
+
+func (x *RoutingInfo) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RoutingInfo) GetBaseVersion() uint64 {
+	if x != nil {
+		return x.BaseVersion
+	}
+	return 0
+}
+
+func (x *RoutingInfo) GetRemovedReplicas() []string {
+	if x != nil {
+		return x.RemovedReplicas
+	}
+	return nil
+}
+
+func (x *RoutingInfo) GetLeaseNanos() int64 {
+	if x != nil {
+		return x.LeaseNanos
+	}
+	return 0
+}
+
+// Assignment partitions a key space (e.g., the hash space [0, 2^64)) into a set
+// of subregions, called slices, and assigns each slice to a set of replicas.
+type Assignment struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Slices []*Assignment_Slice `protobuf:"bytes,1,rep,name=slices,proto3" json:"slices,omitempty"`
+	// The assignment for a component can change over time. Every one of these
+	// assignments is given a monotonically increasing version. Versions are
+	// unique for a given component, but not unique across components.
+	Version uint64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+}
+
+func (x *Assignment) Reset() {
+	*x = Assignment{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[27]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Assignment) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
 func (*Assignment) ProtoMessage() {}
 
 func (x *Assignment) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[20]
+	mi := &file_runtime_proto_msgTypes[27]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1620,7 +2294,7 @@ func (x *Assignment) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Assignment.ProtoReflect.Descriptor instead.
 func (*Assignment) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{20}
+	return file_runtime_proto_rawDescGZIP(), []int{27}
 }
 
 func (x *Assignment) GetSlices() []*Assignment_Slice {
@@ -1651,7 +2325,7 @@ type UpdateComponentsRequest struct {
 func (x *UpdateComponentsRequest) Reset() {
 	*x = UpdateComponentsRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[21]
+		mi := &file_runtime_proto_msgTypes[28]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1664,7 +2338,7 @@ func (x *UpdateComponentsRequest) String() string {
 func (*UpdateComponentsRequest) ProtoMessage() {}
 
 func (x *UpdateComponentsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[21]
+	mi := &file_runtime_proto_msgTypes[28]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1677,7 +2351,7 @@ func (x *UpdateComponentsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateComponentsRequest.ProtoReflect.Descriptor instead.
 func (*UpdateComponentsRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{21}
+	return file_runtime_proto_rawDescGZIP(), []int{28}
 }
 
 func (x *UpdateComponentsRequest) GetComponents() []string {
@@ -1697,7 +2371,7 @@ type UpdateComponentsReply struct {
 func (x *UpdateComponentsReply) Reset() {
 	*x = UpdateComponentsReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[22]
+		mi := &file_runtime_proto_msgTypes[29]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1710,7 +2384,7 @@ func (x *UpdateComponentsReply) String() string {
 func (*UpdateComponentsReply) ProtoMessage() {}
 
 func (x *UpdateComponentsReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[22]
+	mi := &file_runtime_proto_msgTypes[29]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1723,7 +2397,7 @@ func (x *UpdateComponentsReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateComponentsReply.ProtoReflect.Descriptor instead.
 func (*UpdateComponentsReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{22}
+	return file_runtime_proto_rawDescGZIP(), []int{29}
 }
 
 // ActivateComponentRequest is a request from a weavelet to ensure that the
@@ -1742,7 +2416,7 @@ type ActivateComponentRequest struct {
 func (x *ActivateComponentRequest) Reset() {
 	*x = ActivateComponentRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[23]
+		mi := &file_runtime_proto_msgTypes[30]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -1755,7 +2429,7 @@ func (x *ActivateComponentRequest) String() string {
 func (*ActivateComponentRequest) ProtoMessage() {}
 
 func (x *ActivateComponentRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[23]
+	mi := &file_runtime_proto_msgTypes[30]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1768,7 +2442,7 @@ func (x *ActivateComponentRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ActivateComponentRequest.ProtoReflect.Descriptor instead.
 func (*ActivateComponentRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{23}
+	return file_runtime_proto_rawDescGZIP(), []int{30}
 }
 
 func (x *ActivateComponentRequest) GetComponent() string {
@@ -1782,33 +2456,302 @@ func (x *ActivateComponentRequest) GetRouted() bool {
 	if x != nil {
 		return x.Routed
 	}
-	return false
+	return false
+}
+
+// ActivateComponentReply is a reply to an ActivateComponentRequest.
+type ActivateComponentReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ActivateComponentReply) Reset() {
+	*x = ActivateComponentReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[31]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ActivateComponentReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ActivateComponentReply) ProtoMessage() {}
+
+func (x *ActivateComponentReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[31]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ActivateComponentReply.ProtoReflect.Descriptor instead.
+func (*ActivateComponentReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{31}
+}
+
+// GetListenerAddressRequest is a request from a weavelet for the address the
+// weavelet should listen on for a particular listener.
+//
+// Imagine a weavelet calls GetListener with LocalAdress localhost:9000. Here
+// are some examples of how different deployers may handle a
+// GetListenerAddressRequest.
+//
+//   - The singleprocess deployer may instruct the weavelet to listen directly
+//     on localhost:9000.
+//   - The multiprocess deployer may instruct the weavelet to listen on
+//     localhost:0. It will separately start a proxy on localhost:9000.
+//   - The SSH deployer may instruct the weavelet to listen on
+//     $HOSTNAME:0. It will separately start a proxy on localhost:9000.
+type GetListenerAddressRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // listener name
+}
+
+func (x *GetListenerAddressRequest) Reset() {
+	*x = GetListenerAddressRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[32]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetListenerAddressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetListenerAddressRequest) ProtoMessage() {}
+
+func (x *GetListenerAddressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[32]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetListenerAddressRequest.ProtoReflect.Descriptor instead.
+func (*GetListenerAddressRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *GetListenerAddressRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// GetListenerAddressReply is a reply to a GetListenerAddressRequest.
+type GetListenerAddressReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"` // address to listen on
+}
+
+func (x *GetListenerAddressReply) Reset() {
+	*x = GetListenerAddressReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[33]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetListenerAddressReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetListenerAddressReply) ProtoMessage() {}
+
+func (x *GetListenerAddressReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[33]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetListenerAddressReply.ProtoReflect.Descriptor instead.
+func (*GetListenerAddressReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *GetListenerAddressReply) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+// ExportListenerRequest is a request from a weavelet to export the provided
+// listener. Exporting a listener typically, but not always, involves running a
+// proxy that forwards traffic to the provided address.
+type ExportListenerRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Listener string `protobuf:"bytes,1,opt,name=listener,proto3" json:"listener,omitempty"`
+	Address  string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+}
+
+func (x *ExportListenerRequest) Reset() {
+	*x = ExportListenerRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[34]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportListenerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportListenerRequest) ProtoMessage() {}
+
+func (x *ExportListenerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[34]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportListenerRequest.ProtoReflect.Descriptor instead.
+func (*ExportListenerRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ExportListenerRequest) GetListener() string {
+	if x != nil {
+		return x.Listener
+	}
+	return ""
+}
+
+func (x *ExportListenerRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+// ExportListenerReply is a reply to an ExportListenerRequest.
+type ExportListenerReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The address of the proxy that forwards traffic to the listener, or empty if
+	// there is no proxy.
+	ProxyAddress string `protobuf:"bytes,1,opt,name=proxy_address,json=proxyAddress,proto3" json:"proxy_address,omitempty"`
+	// If non-empty, a non-retriable error.
+	//
+	// TODO(mwhittaker): This field exists as a hack to avoid a weavelet retrying
+	// this error. In the future, we should have a more robust way to avoid
+	// retrying certain errors.
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+}
+
+func (x *ExportListenerReply) Reset() {
+	*x = ExportListenerReply{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_runtime_proto_msgTypes[35]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ExportListenerReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportListenerReply) ProtoMessage() {}
+
+func (x *ExportListenerReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[35]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportListenerReply.ProtoReflect.Descriptor instead.
+func (*ExportListenerReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ExportListenerReply) GetProxyAddress() string {
+	if x != nil {
+		return x.ProxyAddress
+	}
+	return ""
+}
+
+func (x *ExportListenerReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
 }
 
-// ActivateComponentReply is a reply to an ActivateComponentRequest.
-type ActivateComponentReply struct {
+// GetTopologyRequest is a request from a weavelet for the live component
+// graph of the deployment.
+type GetTopologyRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 }
 
-func (x *ActivateComponentReply) Reset() {
-	*x = ActivateComponentReply{}
+func (x *GetTopologyRequest) Reset() {
+	*x = GetTopologyRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[24]
+		mi := &file_runtime_proto_msgTypes[36]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ActivateComponentReply) String() string {
+func (x *GetTopologyRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ActivateComponentReply) ProtoMessage() {}
+func (*GetTopologyRequest) ProtoMessage() {}
 
-func (x *ActivateComponentReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[24]
+func (x *GetTopologyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[36]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1819,49 +2762,37 @@ func (x *ActivateComponentReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ActivateComponentReply.ProtoReflect.Descriptor instead.
-func (*ActivateComponentReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{24}
+// Deprecated: Use GetTopologyRequest.ProtoReflect.Descriptor instead.
+func (*GetTopologyRequest) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{36}
 }
 
-// GetListenerAddressRequest is a request from a weavelet for the address the
-// weavelet should listen on for a particular listener.
-//
-// Imagine a weavelet calls GetListener with LocalAdress localhost:9000. Here
-// are some examples of how different deployers may handle a
-// GetListenerAddressRequest.
-//
-//   - The singleprocess deployer may instruct the weavelet to listen directly
-//     on localhost:9000.
-//   - The multiprocess deployer may instruct the weavelet to listen on
-//     localhost:0. It will separately start a proxy on localhost:9000.
-//   - The SSH deployer may instruct the weavelet to listen on
-//     $HOSTNAME:0. It will separately start a proxy on localhost:9000.
-type GetListenerAddressRequest struct {
+// GetTopologyReply is a reply to a GetTopologyRequest.
+type GetTopologyReply struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // listener name
+	Topology *Topology `protobuf:"bytes,1,opt,name=topology,proto3" json:"topology,omitempty"`
 }
 
-func (x *GetListenerAddressRequest) Reset() {
-	*x = GetListenerAddressRequest{}
+func (x *GetTopologyReply) Reset() {
+	*x = GetTopologyReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[25]
+		mi := &file_runtime_proto_msgTypes[37]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetListenerAddressRequest) String() string {
+func (x *GetTopologyReply) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetListenerAddressRequest) ProtoMessage() {}
+func (*GetTopologyReply) ProtoMessage() {}
 
-func (x *GetListenerAddressRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[25]
+func (x *GetTopologyReply) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[37]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1872,44 +2803,47 @@ func (x *GetListenerAddressRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetListenerAddressRequest.ProtoReflect.Descriptor instead.
-func (*GetListenerAddressRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{25}
+// Deprecated: Use GetTopologyReply.ProtoReflect.Descriptor instead.
+func (*GetTopologyReply) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *GetListenerAddressRequest) GetName() string {
+func (x *GetTopologyReply) GetTopology() *Topology {
 	if x != nil {
-		return x.Name
+		return x.Topology
 	}
-	return ""
+	return nil
 }
 
-// GetListenerAddressReply is a reply to a GetListenerAddressRequest.
-type GetListenerAddressReply struct {
+// Topology is the live component graph of a deployment, assembled from the
+// method metrics of its weavelets.
+type Topology struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Address string `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"` // address to listen on
+	Components  []string        `protobuf:"bytes,1,rep,name=components,proto3" json:"components,omitempty"`                       // components in the graph, sorted
+	Edges       []*TopologyEdge `protobuf:"bytes,2,rep,name=edges,proto3" json:"edges,omitempty"`                                 // calls between components
+	WindowNanos int64           `protobuf:"varint,3,opt,name=window_nanos,json=windowNanos,proto3" json:"window_nanos,omitempty"` // period over which rates are computed
 }
 
-func (x *GetListenerAddressReply) Reset() {
-	*x = GetListenerAddressReply{}
+func (x *Topology) Reset() {
+	*x = Topology{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[26]
+		mi := &file_runtime_proto_msgTypes[38]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *GetListenerAddressReply) String() string {
+func (x *Topology) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GetListenerAddressReply) ProtoMessage() {}
+func (*Topology) ProtoMessage() {}
 
-func (x *GetListenerAddressReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[26]
+func (x *Topology) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[38]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1920,47 +2854,64 @@ func (x *GetListenerAddressReply) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GetListenerAddressReply.ProtoReflect.Descriptor instead.
-func (*GetListenerAddressReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{26}
+// Deprecated: Use Topology.ProtoReflect.Descriptor instead.
+func (*Topology) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *GetListenerAddressReply) GetAddress() string {
+func (x *Topology) GetComponents() []string {
 	if x != nil {
-		return x.Address
+		return x.Components
 	}
-	return ""
+	return nil
 }
 
-// ExportListenerRequest is a request from a weavelet to export the provided
-// listener. Exporting a listener typically, but not always, involves running a
-// proxy that forwards traffic to the provided address.
-type ExportListenerRequest struct {
+func (x *Topology) GetEdges() []*TopologyEdge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+func (x *Topology) GetWindowNanos() int64 {
+	if x != nil {
+		return x.WindowNanos
+	}
+	return 0
+}
+
+// TopologyEdge records the calls made by one component to another.
+type TopologyEdge struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Listener string `protobuf:"bytes,1,opt,name=listener,proto3" json:"listener,omitempty"`
-	Address  string `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Caller            string  `protobuf:"bytes,1,opt,name=caller,proto3" json:"caller,omitempty"`                                                    // full calling component name
+	Component         string  `protobuf:"bytes,2,opt,name=component,proto3" json:"component,omitempty"`                                              // full callee component name
+	Calls             int64   `protobuf:"varint,3,opt,name=calls,proto3" json:"calls,omitempty"`                                                     // total number of calls
+	Errors            int64   `protobuf:"varint,4,opt,name=errors,proto3" json:"errors,omitempty"`                                                   // total number of failed calls
+	CallsPerSecond    float64 `protobuf:"fixed64,5,opt,name=calls_per_second,json=callsPerSecond,proto3" json:"calls_per_second,omitempty"`          // call rate over the window
+	ErrorsPerSecond   float64 `protobuf:"fixed64,6,opt,name=errors_per_second,json=errorsPerSecond,proto3" json:"errors_per_second,omitempty"`       // failed call rate over the window
+	MeanLatencyMicros float64 `protobuf:"fixed64,7,opt,name=mean_latency_micros,json=meanLatencyMicros,proto3" json:"mean_latency_micros,omitempty"` // mean call latency over the window
 }
 
-func (x *ExportListenerRequest) Reset() {
-	*x = ExportListenerRequest{}
+func (x *TopologyEdge) Reset() {
+	*x = TopologyEdge{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[27]
+		mi := &file_runtime_proto_msgTypes[39]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
 }
 
-func (x *ExportListenerRequest) String() string {
+func (x *TopologyEdge) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ExportListenerRequest) ProtoMessage() {}
+func (*TopologyEdge) ProtoMessage() {}
 
-func (x *ExportListenerRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[27]
+func (x *TopologyEdge) ProtoReflect() protoreflect.Message {
+	mi := &file_runtime_proto_msgTypes[39]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1971,86 +2922,58 @@ func (x *ExportListenerRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ExportListenerRequest.ProtoReflect.Descriptor instead.
-func (*ExportListenerRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{27}
+// Deprecated: Use TopologyEdge.ProtoReflect.Descriptor instead.
+func (*TopologyEdge) Descriptor() ([]byte, []int) {
+	return file_runtime_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *ExportListenerRequest) GetListener() string {
+func (x *TopologyEdge) GetCaller() string {
 	if x != nil {
-		return x.Listener
+		return x.Caller
 	}
 	return ""
 }
 
-func (x *ExportListenerRequest) GetAddress() string {
+func (x *TopologyEdge) GetComponent() string {
 	if x != nil {
-		return x.Address
+		return x.Component
 	}
 	return ""
 }
 
-// ExportListenerReply is a reply to an ExportListenerRequest.
-type ExportListenerReply struct {
-	state         protoimpl.MessageState
-	sizeCache     protoimpl.SizeCache
-	unknownFields protoimpl.UnknownFields
-
-	// The address of the proxy that forwards traffic to the listener, or empty if
-	// there is no proxy.
-	ProxyAddress string `protobuf:"bytes,1,opt,name=proxy_address,json=proxyAddress,proto3" json:"proxy_address,omitempty"`
-	// If non-empty, a non-retriable error.
-	//
-	// TODO(mwhittaker): This field exists as a hack to avoid a weavelet retrying
-	// this error. In the future, we should have a more robust way to avoid
-	// retrying certain errors.
-	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-}
-
-func (x *ExportListenerReply) Reset() {
-	*x = ExportListenerReply{}
-	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[28]
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		ms.StoreMessageInfo(mi)
+func (x *TopologyEdge) GetCalls() int64 {
+	if x != nil {
+		return x.Calls
 	}
+	return 0
 }
 
-func (x *ExportListenerReply) String() string {
-	return protoimpl.X.MessageStringOf(x)
-}
-
-func (*ExportListenerReply) ProtoMessage() {}
-
-func (x *ExportListenerReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[28]
-	if protoimpl.UnsafeEnabled && x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+func (x *TopologyEdge) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
 	}
-	return mi.MessageOf(x)
+	return 0
 }
 
-// Deprecated: Use ExportListenerReply.ProtoReflect.Descriptor instead.
-func (*ExportListenerReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{28}
+func (x *TopologyEdge) GetCallsPerSecond() float64 {
+	if x != nil {
+		return x.CallsPerSecond
+	}
+	return 0
 }
 
-func (x *ExportListenerReply) GetProxyAddress() string {
+func (x *TopologyEdge) GetErrorsPerSecond() float64 {
 	if x != nil {
-		return x.ProxyAddress
+		return x.ErrorsPerSecond
 	}
-	return ""
+	return 0
 }
 
-func (x *ExportListenerReply) GetError() string {
+func (x *TopologyEdge) GetMeanLatencyMicros() float64 {
 	if x != nil {
-		return x.Error
+		return x.MeanLatencyMicros
 	}
-	return ""
+	return 0
 }
 
 // GetSelfCertificateRequest is a request from a weavelet for its certificate
@@ -2064,7 +2987,7 @@ type GetSelfCertificateRequest struct {
 func (x *GetSelfCertificateRequest) Reset() {
 	*x = GetSelfCertificateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[29]
+		mi := &file_runtime_proto_msgTypes[40]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2077,7 +3000,7 @@ func (x *GetSelfCertificateRequest) String() string {
 func (*GetSelfCertificateRequest) ProtoMessage() {}
 
 func (x *GetSelfCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[29]
+	mi := &file_runtime_proto_msgTypes[40]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2090,7 +3013,7 @@ func (x *GetSelfCertificateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSelfCertificateRequest.ProtoReflect.Descriptor instead.
 func (*GetSelfCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{29}
+	return file_runtime_proto_rawDescGZIP(), []int{40}
 }
 
 // GetSelfCertificateReply is a reply to a GetSelfCertificateRequest.
@@ -2109,7 +3032,7 @@ type GetSelfCertificateReply struct {
 func (x *GetSelfCertificateReply) Reset() {
 	*x = GetSelfCertificateReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[30]
+		mi := &file_runtime_proto_msgTypes[41]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2122,7 +3045,7 @@ func (x *GetSelfCertificateReply) String() string {
 func (*GetSelfCertificateReply) ProtoMessage() {}
 
 func (x *GetSelfCertificateReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[30]
+	mi := &file_runtime_proto_msgTypes[41]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2135,7 +3058,7 @@ func (x *GetSelfCertificateReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetSelfCertificateReply.ProtoReflect.Descriptor instead.
 func (*GetSelfCertificateReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{30}
+	return file_runtime_proto_rawDescGZIP(), []int{41}
 }
 
 func (x *GetSelfCertificateReply) GetCert() []byte {
@@ -2167,7 +3090,7 @@ type VerifyClientCertificateRequest struct {
 func (x *VerifyClientCertificateRequest) Reset() {
 	*x = VerifyClientCertificateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[31]
+		mi := &file_runtime_proto_msgTypes[42]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2180,7 +3103,7 @@ func (x *VerifyClientCertificateRequest) String() string {
 func (*VerifyClientCertificateRequest) ProtoMessage() {}
 
 func (x *VerifyClientCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[31]
+	mi := &file_runtime_proto_msgTypes[42]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2193,7 +3116,7 @@ func (x *VerifyClientCertificateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyClientCertificateRequest.ProtoReflect.Descriptor instead.
 func (*VerifyClientCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{31}
+	return file_runtime_proto_rawDescGZIP(), []int{42}
 }
 
 func (x *VerifyClientCertificateRequest) GetCertChain() [][]byte {
@@ -2219,7 +3142,7 @@ type VerifyClientCertificateReply struct {
 func (x *VerifyClientCertificateReply) Reset() {
 	*x = VerifyClientCertificateReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[32]
+		mi := &file_runtime_proto_msgTypes[43]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2232,7 +3155,7 @@ func (x *VerifyClientCertificateReply) String() string {
 func (*VerifyClientCertificateReply) ProtoMessage() {}
 
 func (x *VerifyClientCertificateReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[32]
+	mi := &file_runtime_proto_msgTypes[43]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2245,7 +3168,7 @@ func (x *VerifyClientCertificateReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyClientCertificateReply.ProtoReflect.Descriptor instead.
 func (*VerifyClientCertificateReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{32}
+	return file_runtime_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *VerifyClientCertificateReply) GetComponents() []string {
@@ -2272,7 +3195,7 @@ type VerifyServerCertificateRequest struct {
 func (x *VerifyServerCertificateRequest) Reset() {
 	*x = VerifyServerCertificateRequest{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[33]
+		mi := &file_runtime_proto_msgTypes[44]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2285,7 +3208,7 @@ func (x *VerifyServerCertificateRequest) String() string {
 func (*VerifyServerCertificateRequest) ProtoMessage() {}
 
 func (x *VerifyServerCertificateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[33]
+	mi := &file_runtime_proto_msgTypes[44]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2298,7 +3221,7 @@ func (x *VerifyServerCertificateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyServerCertificateRequest.ProtoReflect.Descriptor instead.
 func (*VerifyServerCertificateRequest) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{33}
+	return file_runtime_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *VerifyServerCertificateRequest) GetCertChain() [][]byte {
@@ -2325,7 +3248,7 @@ type VerifyServerCertificateReply struct {
 func (x *VerifyServerCertificateReply) Reset() {
 	*x = VerifyServerCertificateReply{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[34]
+		mi := &file_runtime_proto_msgTypes[45]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2338,7 +3261,7 @@ func (x *VerifyServerCertificateReply) String() string {
 func (*VerifyServerCertificateReply) ProtoMessage() {}
 
 func (x *VerifyServerCertificateReply) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[34]
+	mi := &file_runtime_proto_msgTypes[45]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2351,7 +3274,7 @@ func (x *VerifyServerCertificateReply) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use VerifyServerCertificateReply.ProtoReflect.Descriptor instead.
 func (*VerifyServerCertificateReply) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{34}
+	return file_runtime_proto_rawDescGZIP(), []int{45}
 }
 
 // LogEntry is a log entry. Every log entry consists of a message (the thing the
@@ -2375,12 +3298,16 @@ type LogEntry struct {
 	//
 	//	name1, value1, name2, value2, name3, value3, ...
 	Attrs []string `protobuf:"bytes,10,rep,name=attrs,proto3" json:"attrs,omitempty"`
+	// The hex-encoded ids of the trace and span that were active when the entry
+	// was logged, if any. These allow log entries to be joined with traces.
+	TraceId string `protobuf:"bytes,11,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanId  string `protobuf:"bytes,12,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
 }
 
 func (x *LogEntry) Reset() {
 	*x = LogEntry{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[35]
+		mi := &file_runtime_proto_msgTypes[46]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2393,7 +3320,7 @@ func (x *LogEntry) String() string {
 func (*LogEntry) ProtoMessage() {}
 
 func (x *LogEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[35]
+	mi := &file_runtime_proto_msgTypes[46]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2406,7 +3333,7 @@ func (x *LogEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogEntry.ProtoReflect.Descriptor instead.
 func (*LogEntry) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{35}
+	return file_runtime_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *LogEntry) GetApp() string {
@@ -2479,6 +3406,20 @@ func (x *LogEntry) GetAttrs() []string {
 	return nil
 }
 
+func (x *LogEntry) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *LogEntry) GetSpanId() string {
+	if x != nil {
+		return x.SpanId
+	}
+	return ""
+}
+
 // LogEntryBatch is a list of log entries.
 type LogEntryBatch struct {
 	state         protoimpl.MessageState
@@ -2491,7 +3432,7 @@ type LogEntryBatch struct {
 func (x *LogEntryBatch) Reset() {
 	*x = LogEntryBatch{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[36]
+		mi := &file_runtime_proto_msgTypes[47]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2504,7 +3445,7 @@ func (x *LogEntryBatch) String() string {
 func (*LogEntryBatch) ProtoMessage() {}
 
 func (x *LogEntryBatch) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[36]
+	mi := &file_runtime_proto_msgTypes[47]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2517,7 +3458,7 @@ func (x *LogEntryBatch) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogEntryBatch.ProtoReflect.Descriptor instead.
 func (*LogEntryBatch) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{36}
+	return file_runtime_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *LogEntryBatch) GetEntries() []*LogEntry {
@@ -2539,7 +3480,7 @@ type TraceSpans struct {
 func (x *TraceSpans) Reset() {
 	*x = TraceSpans{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[37]
+		mi := &file_runtime_proto_msgTypes[48]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2552,7 +3493,7 @@ func (x *TraceSpans) String() string {
 func (*TraceSpans) ProtoMessage() {}
 
 func (x *TraceSpans) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[37]
+	mi := &file_runtime_proto_msgTypes[48]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2565,7 +3506,7 @@ func (x *TraceSpans) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TraceSpans.ProtoReflect.Descriptor instead.
 func (*TraceSpans) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{37}
+	return file_runtime_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *TraceSpans) GetSpan() []*Span {
@@ -2611,7 +3552,7 @@ type Span struct {
 func (x *Span) Reset() {
 	*x = Span{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[38]
+		mi := &file_runtime_proto_msgTypes[49]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2624,7 +3565,7 @@ func (x *Span) String() string {
 func (*Span) ProtoMessage() {}
 
 func (x *Span) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[38]
+	mi := &file_runtime_proto_msgTypes[49]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2637,7 +3578,7 @@ func (x *Span) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span.ProtoReflect.Descriptor instead.
 func (*Span) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38}
+	return file_runtime_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *Span) GetName() string {
@@ -2781,7 +3722,7 @@ type WeaveletArgs_Redirect struct {
 func (x *WeaveletArgs_Redirect) Reset() {
 	*x = WeaveletArgs_Redirect{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[39]
+		mi := &file_runtime_proto_msgTypes[50]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2794,7 +3735,7 @@ func (x *WeaveletArgs_Redirect) String() string {
 func (*WeaveletArgs_Redirect) ProtoMessage() {}
 
 func (x *WeaveletArgs_Redirect) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[39]
+	mi := &file_runtime_proto_msgTypes[50]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2807,7 +3748,7 @@ func (x *WeaveletArgs_Redirect) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use WeaveletArgs_Redirect.ProtoReflect.Descriptor instead.
 func (*WeaveletArgs_Redirect) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{0, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{0, 0}
 }
 
 func (x *WeaveletArgs_Redirect) GetComponent() string {
@@ -2858,7 +3799,7 @@ type LoadReport_ComponentLoad struct {
 func (x *LoadReport_ComponentLoad) Reset() {
 	*x = LoadReport_ComponentLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[44]
+		mi := &file_runtime_proto_msgTypes[56]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2871,7 +3812,7 @@ func (x *LoadReport_ComponentLoad) String() string {
 func (*LoadReport_ComponentLoad) ProtoMessage() {}
 
 func (x *LoadReport_ComponentLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[44]
+	mi := &file_runtime_proto_msgTypes[56]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2884,7 +3825,7 @@ func (x *LoadReport_ComponentLoad) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoadReport_ComponentLoad.ProtoReflect.Descriptor instead.
 func (*LoadReport_ComponentLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14, 1}
+	return file_runtime_proto_rawDescGZIP(), []int{15, 1}
 }
 
 func (x *LoadReport_ComponentLoad) GetLoad() []*LoadReport_SliceLoad {
@@ -2931,7 +3872,7 @@ type LoadReport_SliceLoad struct {
 func (x *LoadReport_SliceLoad) Reset() {
 	*x = LoadReport_SliceLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[45]
+		mi := &file_runtime_proto_msgTypes[57]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -2944,7 +3885,7 @@ func (x *LoadReport_SliceLoad) String() string {
 func (*LoadReport_SliceLoad) ProtoMessage() {}
 
 func (x *LoadReport_SliceLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[45]
+	mi := &file_runtime_proto_msgTypes[57]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2957,7 +3898,7 @@ func (x *LoadReport_SliceLoad) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoadReport_SliceLoad.ProtoReflect.Descriptor instead.
 func (*LoadReport_SliceLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14, 2}
+	return file_runtime_proto_rawDescGZIP(), []int{15, 2}
 }
 
 func (x *LoadReport_SliceLoad) GetStart() uint64 {
@@ -3017,7 +3958,7 @@ type LoadReport_SubsliceLoad struct {
 func (x *LoadReport_SubsliceLoad) Reset() {
 	*x = LoadReport_SubsliceLoad{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[46]
+		mi := &file_runtime_proto_msgTypes[58]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3030,7 +3971,7 @@ func (x *LoadReport_SubsliceLoad) String() string {
 func (*LoadReport_SubsliceLoad) ProtoMessage() {}
 
 func (x *LoadReport_SubsliceLoad) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[46]
+	mi := &file_runtime_proto_msgTypes[58]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3043,7 +3984,7 @@ func (x *LoadReport_SubsliceLoad) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LoadReport_SubsliceLoad.ProtoReflect.Descriptor instead.
 func (*LoadReport_SubsliceLoad) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{14, 3}
+	return file_runtime_proto_rawDescGZIP(), []int{15, 3}
 }
 
 func (x *LoadReport_SubsliceLoad) GetStart() uint64 {
@@ -3079,7 +4020,7 @@ type Assignment_Slice struct {
 func (x *Assignment_Slice) Reset() {
 	*x = Assignment_Slice{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[47]
+		mi := &file_runtime_proto_msgTypes[60]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3092,7 +4033,7 @@ func (x *Assignment_Slice) String() string {
 func (*Assignment_Slice) ProtoMessage() {}
 
 func (x *Assignment_Slice) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[47]
+	mi := &file_runtime_proto_msgTypes[60]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3105,7 +4046,7 @@ func (x *Assignment_Slice) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Assignment_Slice.ProtoReflect.Descriptor instead.
 func (*Assignment_Slice) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{20, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{27, 0}
 }
 
 func (x *Assignment_Slice) GetStart() uint64 {
@@ -3135,7 +4076,7 @@ type Span_Attribute struct {
 func (x *Span_Attribute) Reset() {
 	*x = Span_Attribute{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[48]
+		mi := &file_runtime_proto_msgTypes[61]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3148,7 +4089,7 @@ func (x *Span_Attribute) String() string {
 func (*Span_Attribute) ProtoMessage() {}
 
 func (x *Span_Attribute) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[48]
+	mi := &file_runtime_proto_msgTypes[61]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3161,7 +4102,7 @@ func (x *Span_Attribute) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Attribute.ProtoReflect.Descriptor instead.
 func (*Span_Attribute) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0}
 }
 
 func (x *Span_Attribute) GetKey() string {
@@ -3194,7 +4135,7 @@ type Span_Link struct {
 func (x *Span_Link) Reset() {
 	*x = Span_Link{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[49]
+		mi := &file_runtime_proto_msgTypes[62]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3207,7 +4148,7 @@ func (x *Span_Link) String() string {
 func (*Span_Link) ProtoMessage() {}
 
 func (x *Span_Link) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[49]
+	mi := &file_runtime_proto_msgTypes[62]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3220,7 +4161,7 @@ func (x *Span_Link) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Link.ProtoReflect.Descriptor instead.
 func (*Span_Link) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 1}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 1}
 }
 
 func (x *Span_Link) GetTraceId() []byte {
@@ -3267,7 +4208,7 @@ type Span_Event struct {
 func (x *Span_Event) Reset() {
 	*x = Span_Event{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[50]
+		mi := &file_runtime_proto_msgTypes[63]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3280,7 +4221,7 @@ func (x *Span_Event) String() string {
 func (*Span_Event) ProtoMessage() {}
 
 func (x *Span_Event) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[50]
+	mi := &file_runtime_proto_msgTypes[63]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3293,7 +4234,7 @@ func (x *Span_Event) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Event.ProtoReflect.Descriptor instead.
 func (*Span_Event) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 2}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 2}
 }
 
 func (x *Span_Event) GetName() string {
@@ -3337,7 +4278,7 @@ type Span_Status struct {
 func (x *Span_Status) Reset() {
 	*x = Span_Status{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[51]
+		mi := &file_runtime_proto_msgTypes[64]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3350,7 +4291,7 @@ func (x *Span_Status) String() string {
 func (*Span_Status) ProtoMessage() {}
 
 func (x *Span_Status) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[51]
+	mi := &file_runtime_proto_msgTypes[64]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3363,7 +4304,7 @@ func (x *Span_Status) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Status.ProtoReflect.Descriptor instead.
 func (*Span_Status) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 3}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 3}
 }
 
 func (x *Span_Status) GetCode() Span_Status_Code {
@@ -3396,7 +4337,7 @@ type Span_Scope struct {
 func (x *Span_Scope) Reset() {
 	*x = Span_Scope{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[52]
+		mi := &file_runtime_proto_msgTypes[65]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3409,7 +4350,7 @@ func (x *Span_Scope) String() string {
 func (*Span_Scope) ProtoMessage() {}
 
 func (x *Span_Scope) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[52]
+	mi := &file_runtime_proto_msgTypes[65]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3422,7 +4363,7 @@ func (x *Span_Scope) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Scope.ProtoReflect.Descriptor instead.
 func (*Span_Scope) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 4}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 4}
 }
 
 func (x *Span_Scope) GetName() string {
@@ -3463,7 +4404,7 @@ type Span_Library struct {
 func (x *Span_Library) Reset() {
 	*x = Span_Library{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[53]
+		mi := &file_runtime_proto_msgTypes[66]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3476,7 +4417,7 @@ func (x *Span_Library) String() string {
 func (*Span_Library) ProtoMessage() {}
 
 func (x *Span_Library) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[53]
+	mi := &file_runtime_proto_msgTypes[66]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3489,7 +4430,7 @@ func (x *Span_Library) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Library.ProtoReflect.Descriptor instead.
 func (*Span_Library) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 5}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 5}
 }
 
 func (x *Span_Library) GetName() string {
@@ -3528,7 +4469,7 @@ type Span_Resource struct {
 func (x *Span_Resource) Reset() {
 	*x = Span_Resource{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[54]
+		mi := &file_runtime_proto_msgTypes[67]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3541,7 +4482,7 @@ func (x *Span_Resource) String() string {
 func (*Span_Resource) ProtoMessage() {}
 
 func (x *Span_Resource) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[54]
+	mi := &file_runtime_proto_msgTypes[67]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3554,7 +4495,7 @@ func (x *Span_Resource) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Resource.ProtoReflect.Descriptor instead.
 func (*Span_Resource) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 6}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 6}
 }
 
 func (x *Span_Resource) GetSchemaUrl() string {
@@ -3589,7 +4530,7 @@ type Span_Attribute_Value struct {
 func (x *Span_Attribute_Value) Reset() {
 	*x = Span_Attribute_Value{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[55]
+		mi := &file_runtime_proto_msgTypes[68]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3602,7 +4543,7 @@ func (x *Span_Attribute_Value) String() string {
 func (*Span_Attribute_Value) ProtoMessage() {}
 
 func (x *Span_Attribute_Value) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[55]
+	mi := &file_runtime_proto_msgTypes[68]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3615,7 +4556,7 @@ func (x *Span_Attribute_Value) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Attribute_Value.ProtoReflect.Descriptor instead.
 func (*Span_Attribute_Value) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0, 0}
 }
 
 func (x *Span_Attribute_Value) GetType() Span_Attribute_Value_Type {
@@ -3699,7 +4640,7 @@ type Span_Attribute_Value_NumberList struct {
 func (x *Span_Attribute_Value_NumberList) Reset() {
 	*x = Span_Attribute_Value_NumberList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[56]
+		mi := &file_runtime_proto_msgTypes[69]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3712,7 +4653,7 @@ func (x *Span_Attribute_Value_NumberList) String() string {
 func (*Span_Attribute_Value_NumberList) ProtoMessage() {}
 
 func (x *Span_Attribute_Value_NumberList) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[56]
+	mi := &file_runtime_proto_msgTypes[69]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3725,7 +4666,7 @@ func (x *Span_Attribute_Value_NumberList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Attribute_Value_NumberList.ProtoReflect.Descriptor instead.
 func (*Span_Attribute_Value_NumberList) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0, 0, 0}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0, 0, 0}
 }
 
 func (x *Span_Attribute_Value_NumberList) GetNums() []uint64 {
@@ -3746,7 +4687,7 @@ type Span_Attribute_Value_StringList struct {
 func (x *Span_Attribute_Value_StringList) Reset() {
 	*x = Span_Attribute_Value_StringList{}
 	if protoimpl.UnsafeEnabled {
-		mi := &file_runtime_protos_runtime_proto_msgTypes[57]
+		mi := &file_runtime_proto_msgTypes[70]
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		ms.StoreMessageInfo(mi)
 	}
@@ -3759,7 +4700,7 @@ func (x *Span_Attribute_Value_StringList) String() string {
 func (*Span_Attribute_Value_StringList) ProtoMessage() {}
 
 func (x *Span_Attribute_Value_StringList) ProtoReflect() protoreflect.Message {
-	mi := &file_runtime_protos_runtime_proto_msgTypes[57]
+	mi := &file_runtime_proto_msgTypes[70]
 	if protoimpl.UnsafeEnabled && x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3772,7 +4713,7 @@ func (x *Span_Attribute_Value_StringList) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Span_Attribute_Value_StringList.ProtoReflect.Descriptor instead.
 func (*Span_Attribute_Value_StringList) Descriptor() ([]byte, []int) {
-	return file_runtime_protos_runtime_proto_rawDescGZIP(), []int{38, 0, 0, 1}
+	return file_runtime_proto_rawDescGZIP(), []int{49, 0, 0, 1}
 }
 
 func (x *Span_Attribute_Value_StringList) GetStrs() []string {
@@ -3782,540 +4723,714 @@ func (x *Span_Attribute_Value_StringList) GetStrs() []string {
 	return nil
 }
 
-var File_runtime_protos_runtime_proto protoreflect.FileDescriptor
-
-var file_runtime_protos_runtime_proto_rawDesc = []byte{
-	0x0a, 0x1c, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x2f, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0xf6, 0x02, 0x0a, 0x0c, 0x57, 0x65, 0x61, 0x76,
-	0x65, 0x6c, 0x65, 0x74, 0x41, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64, 0x65,
-	0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64, 0x12,
-	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12,
-	0x19, 0x0a, 0x08, 0x72, 0x75, 0x6e, 0x5f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x07, 0x20, 0x01, 0x28,
-	0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x4d, 0x61, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6d, 0x74,
-	0x6c, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6d, 0x74, 0x6c, 0x73, 0x12, 0x29,
-	0x0a, 0x10, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65,
-	0x73, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e,
-	0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f, 0x6e,
-	0x74, 0x72, 0x6f, 0x6c, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x0d, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x53, 0x6f, 0x63, 0x6b, 0x65, 0x74,
-	0x12, 0x3c, 0x0a, 0x09, 0x72, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x73, 0x18, 0x0c, 0x20,
-	0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57, 0x65,
-	0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x41, 0x72, 0x67, 0x73, 0x2e, 0x52, 0x65, 0x64, 0x69, 0x72,
-	0x65, 0x63, 0x74, 0x52, 0x09, 0x72, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x73, 0x1a, 0x5a,
-	0x0a, 0x08, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f,
-	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63,
-	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x74, 0x61, 0x72, 0x67,
-	0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74,
-	0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x4a, 0x04, 0x08, 0x04, 0x10, 0x05,
-	0x22, 0x9a, 0x01, 0x0a, 0x13, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x46, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74,
-	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x72, 0x75, 0x6e,
-	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65,
-	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
-	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
-	0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x5b, 0x0a,
-	0x11, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x69, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x18,
-	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x69, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x12,
-	0x29, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x0f, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x65, 0x6d, 0x56, 0x65,
-	0x72, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22, 0x4a, 0x0a, 0x06, 0x53, 0x65,
-	0x6d, 0x56, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x61, 0x6a, 0x6f, 0x72, 0x18, 0x01, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x61, 0x6a, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x69,
-	0x6e, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x69, 0x6e, 0x6f, 0x72,
-	0x12, 0x14, 0x0a, 0x05, 0x70, 0x61, 0x74, 0x63, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52,
-	0x05, 0x70, 0x61, 0x74, 0x63, 0x68, 0x22, 0x12, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61,
-	0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x6e, 0x0a, 0x0e, 0x47, 0x65,
-	0x74, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x06,
-	0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x15, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a, 0x12, 0x68,
-	0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x11, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79,
-	0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x13, 0x0a, 0x11, 0x47, 0x65,
-	0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
-	0x40, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x22, 0x64, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61, 0x74,
-	0x65, 0x12, 0x26, 0x0a, 0x04, 0x64, 0x65, 0x66, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
-	0x44, 0x65, 0x66, 0x52, 0x04, 0x64, 0x65, 0x66, 0x73, 0x12, 0x2c, 0x0a, 0x06, 0x76, 0x61, 0x6c,
-	0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52,
-	0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0xf5, 0x01, 0x0a, 0x09, 0x4d, 0x65, 0x74, 0x72,
-	0x69, 0x63, 0x44, 0x65, 0x66, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79, 0x70,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
-	0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79, 0x70,
-	0x12, 0x12, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04,
-	0x68, 0x65, 0x6c, 0x70, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x05,
-	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d,
-	0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45,
-	0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x0a, 0x06,
-	0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x62, 0x6f,
-	0x75, 0x6e, 0x64, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
-	0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22,
-	0x4b, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x0e,
-	0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x14,
-	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76,
-	0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x03,
-	0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x22, 0xad, 0x02, 0x0a,
-	0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x12,
-	0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12,
-	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
-	0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69,
-	0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x65,
-	0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x12, 0x3b,
-	0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x23,
-	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53,
-	0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e,
-	0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x62,
-	0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01, 0x52, 0x06, 0x62, 0x6f, 0x75,
-	0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x07, 0x20, 0x01,
+var File_runtime_proto protoreflect.FileDescriptor
+
+var file_runtime_proto_rawDesc = []byte{
+	0x0a, 0x0d, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x07, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x22, 0xd9, 0x04, 0x0a, 0x0c, 0x57, 0x65, 0x61,
+	0x76, 0x65, 0x6c, 0x65, 0x74, 0x41, 0x72, 0x67, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x23, 0x0a, 0x0d, 0x64,
+	0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0c, 0x64, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x49, 0x64,
+	0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64,
+	0x12, 0x19, 0x0a, 0x08, 0x72, 0x75, 0x6e, 0x5f, 0x6d, 0x61, 0x69, 0x6e, 0x18, 0x07, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x07, 0x72, 0x75, 0x6e, 0x4d, 0x61, 0x69, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6d,
+	0x74, 0x6c, 0x73, 0x18, 0x08, 0x20, 0x01, 0x28, 0x08, 0x52, 0x04, 0x6d, 0x74, 0x6c, 0x73, 0x12,
+	0x29, 0x0a, 0x10, 0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x5f, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x69, 0x6e, 0x74, 0x65, 0x72,
+	0x6e, 0x61, 0x6c, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x25, 0x0a, 0x0e, 0x63, 0x6f,
+	0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x5f, 0x73, 0x6f, 0x63, 0x6b, 0x65, 0x74, 0x18, 0x0d, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0d, 0x63, 0x6f, 0x6e, 0x74, 0x72, 0x6f, 0x6c, 0x53, 0x6f, 0x63, 0x6b, 0x65,
+	0x74, 0x12, 0x3c, 0x0a, 0x09, 0x72, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x73, 0x18, 0x0c,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x57,
+	0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x41, 0x72, 0x67, 0x73, 0x2e, 0x52, 0x65, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x52, 0x09, 0x72, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x73, 0x12,
+	0x28, 0x0a, 0x10, 0x63, 0x72, 0x61, 0x73, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x5f,
+	0x64, 0x69, 0x72, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0e, 0x63, 0x72, 0x61, 0x73, 0x68,
+	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x44, 0x69, 0x72, 0x12, 0x30, 0x0a, 0x14, 0x63, 0x72, 0x61,
+	0x73, 0x68, 0x5f, 0x72, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x5f, 0x77, 0x65, 0x62, 0x68, 0x6f, 0x6f,
+	0x6b, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x63, 0x72, 0x61, 0x73, 0x68, 0x52, 0x65,
+	0x70, 0x6f, 0x72, 0x74, 0x57, 0x65, 0x62, 0x68, 0x6f, 0x6f, 0x6b, 0x12, 0x1b, 0x0a, 0x09, 0x61,
+	0x75, 0x64, 0x69, 0x74, 0x5f, 0x64, 0x69, 0x72, 0x18, 0x10, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08,
+	0x61, 0x75, 0x64, 0x69, 0x74, 0x44, 0x69, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x67, 0x72, 0x6f, 0x75,
+	0x70, 0x18, 0x11, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x67, 0x72, 0x6f, 0x75, 0x70, 0x12, 0x23,
+	0x0a, 0x0d, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x5f, 0x69, 0x6e, 0x64, 0x65, 0x78, 0x18,
+	0x12, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0c, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x49, 0x6e,
+	0x64, 0x65, 0x78, 0x12, 0x2d, 0x0a, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x18,
+	0x13, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
+	0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x52, 0x08, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x69,
+	0x74, 0x79, 0x1a, 0x5a, 0x0a, 0x08, 0x52, 0x65, 0x64, 0x69, 0x72, 0x65, 0x63, 0x74, 0x12, 0x1c,
+	0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06,
+	0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x74, 0x61,
+	0x72, 0x67, 0x65, 0x74, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x4a, 0x04,
+	0x08, 0x04, 0x10, 0x05, 0x22, 0x36, 0x0a, 0x08, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x69, 0x74, 0x79,
+	0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x72, 0x65, 0x67, 0x69, 0x6f, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x7a, 0x6f, 0x6e, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x7a, 0x6f, 0x6e, 0x65, 0x22, 0xb3, 0x06, 0x0a,
+	0x13, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x46, 0x0a, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2a, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
+	0x2e, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x52, 0x08, 0x73, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x2c, 0x0a, 0x12,
+	0x69, 0x6e, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e,
+	0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x69, 0x6e, 0x69, 0x74, 0x54, 0x69,
+	0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x12, 0x7c, 0x0a, 0x1c, 0x63, 0x6f,
+	0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x5f, 0x69, 0x6e, 0x69, 0x74, 0x5f, 0x74, 0x69, 0x6d,
+	0x65, 0x6f, 0x75, 0x74, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x3b, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x49, 0x6e, 0x69, 0x74, 0x57,
+	0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x43,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x19, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65,
+	0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x12, 0x27, 0x0a, 0x0f, 0x6c, 0x61, 0x7a, 0x79,
+	0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x0e, 0x6c, 0x61, 0x7a, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x73, 0x12, 0x27, 0x0a, 0x0f, 0x61, 0x75, 0x64, 0x69, 0x74, 0x65, 0x64, 0x5f, 0x6d, 0x65, 0x74,
+	0x68, 0x6f, 0x64, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0e, 0x61, 0x75, 0x64, 0x69,
+	0x74, 0x65, 0x64, 0x4d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x12, 0x2e, 0x0a, 0x13, 0x6c, 0x61,
+	0x72, 0x67, 0x65, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65,
+	0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x6c, 0x61, 0x72, 0x67, 0x65, 0x50, 0x61,
+	0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x18, 0x69, 0x64,
+	0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77,
+	0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x03, 0x52, 0x16, 0x69, 0x64,
+	0x65, 0x6d, 0x70, 0x6f, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4e,
+	0x61, 0x6e, 0x6f, 0x73, 0x12, 0x2f, 0x0a, 0x14, 0x61, 0x74, 0x5f, 0x6d, 0x6f, 0x73, 0x74, 0x5f,
+	0x6f, 0x6e, 0x63, 0x65, 0x5f, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x73, 0x18, 0x08, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x11, 0x61, 0x74, 0x4d, 0x6f, 0x73, 0x74, 0x4f, 0x6e, 0x63, 0x65, 0x4d, 0x65,
+	0x74, 0x68, 0x6f, 0x64, 0x73, 0x12, 0x21, 0x0a, 0x0c, 0x67, 0x72, 0x70, 0x63, 0x5f, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x67, 0x72, 0x70,
+	0x63, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x34, 0x0a, 0x16, 0x63, 0x6f, 0x6d, 0x70,
+	0x72, 0x65, 0x73, 0x73, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x5f, 0x62, 0x79, 0x74,
+	0x65, 0x73, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x14, 0x63, 0x6f, 0x6d, 0x70, 0x72, 0x65,
+	0x73, 0x73, 0x50, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2a,
+	0x0a, 0x11, 0x6d, 0x61, 0x78, 0x5f, 0x6d, 0x65, 0x73, 0x73, 0x61, 0x67, 0x65, 0x5f, 0x62, 0x79,
+	0x74, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x6d, 0x61, 0x78, 0x4d, 0x65,
+	0x73, 0x73, 0x61, 0x67, 0x65, 0x42, 0x79, 0x74, 0x65, 0x73, 0x12, 0x2b, 0x0a, 0x11, 0x63, 0x68,
+	0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x5f, 0x70, 0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x18,
+	0x0c, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x63, 0x68, 0x65, 0x63, 0x6b, 0x73, 0x75, 0x6d, 0x50,
+	0x61, 0x79, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x1a, 0x3b, 0x0a, 0x0d, 0x53, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x1a, 0x4c, 0x0a, 0x1e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x49, 0x6e, 0x69, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x6f, 0x75, 0x74, 0x4e, 0x61, 0x6e, 0x6f,
+	0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02,
+	0x38, 0x01, 0x22, 0x5b, 0x0a, 0x11, 0x49, 0x6e, 0x69, 0x74, 0x57, 0x65, 0x61, 0x76, 0x65, 0x6c,
+	0x65, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x69, 0x61, 0x6c, 0x5f,
+	0x61, 0x64, 0x64, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x69, 0x61, 0x6c,
+	0x41, 0x64, 0x64, 0x72, 0x12, 0x29, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
+	0x53, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x22,
+	0x4a, 0x0a, 0x06, 0x53, 0x65, 0x6d, 0x56, 0x65, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x6d, 0x61, 0x6a,
+	0x6f, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x6d, 0x61, 0x6a, 0x6f, 0x72, 0x12,
+	0x14, 0x0a, 0x05, 0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05,
+	0x6d, 0x69, 0x6e, 0x6f, 0x72, 0x12, 0x14, 0x0a, 0x05, 0x70, 0x61, 0x74, 0x63, 0x68, 0x18, 0x03,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x70, 0x61, 0x74, 0x63, 0x68, 0x22, 0x12, 0x0a, 0x10, 0x47,
+	0x65, 0x74, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x6e, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x52, 0x65, 0x70, 0x6c,
+	0x79, 0x12, 0x2d, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x48, 0x65, 0x61, 0x6c,
+	0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73,
+	0x12, 0x2d, 0x0a, 0x12, 0x68, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x79, 0x5f, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x11, 0x68, 0x65,
+	0x61, 0x6c, 0x74, 0x68, 0x79, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22,
+	0x13, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x73, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x22, 0x40, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x4d, 0x65, 0x74, 0x72, 0x69,
+	0x63, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x06, 0x75, 0x70, 0x64, 0x61, 0x74,
+	0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x06,
+	0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x22, 0x64, 0x0a, 0x0c, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63,
+	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x12, 0x26, 0x0a, 0x04, 0x64, 0x65, 0x66, 0x73, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d,
+	0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x52, 0x04, 0x64, 0x65, 0x66, 0x73, 0x12, 0x2c,
+	0x0a, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14,
+	0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x52, 0x06, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x22, 0xf5, 0x01, 0x0a,
+	0x09, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61,
+	0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25,
+	0x0a, 0x03, 0x74, 0x79, 0x70, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65,
+	0x52, 0x03, 0x74, 0x79, 0x70, 0x12, 0x12, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x12, 0x36, 0x0a, 0x06, 0x6c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x18, 0x05, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x44, 0x65, 0x66, 0x2e, 0x4c, 0x61,
+	0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28,
+	0x01, 0x52, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x3a, 0x02, 0x38, 0x01, 0x22, 0x4b, 0x0a, 0x0b, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x56, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
 	0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x6f, 0x75,
-	0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74,
-	0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b,
-	0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x22, 0x10, 0x0a, 0x0e,
-	0x47, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x37,
-	0x0a, 0x0c, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x27,
-	0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72,
-	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72,
-	0x74, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0xcf, 0x03, 0x0a, 0x0a, 0x4c, 0x6f, 0x61, 0x64,
-	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x34, 0x0a, 0x05, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x18,
-	0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x73,
-	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x6c, 0x6f, 0x61, 0x64, 0x73, 0x1a, 0x5b, 0x0a, 0x0a,
-	0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65,
-	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x37, 0x0a, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x21, 0x2e, 0x72, 0x75,
-	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74,
-	0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x05,
-	0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c, 0x0a, 0x0d, 0x43, 0x6f, 0x6d,
-	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x31, 0x0a, 0x04, 0x6c, 0x6f,
-	0x61, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
-	0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x53, 0x6c,
-	0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x18, 0x0a,
-	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x95, 0x01, 0x0a, 0x09, 0x53, 0x6c, 0x69, 0x63,
-	0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x65,
-	0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x65, 0x6e, 0x64, 0x12, 0x12, 0x0a,
-	0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61,
-	0x64, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x20, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64,
-	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x4c,
-	0x6f, 0x61, 0x64, 0x52, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73, 0x12, 0x12, 0x0a, 0x04, 0x73,
-	0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x1a,
-	0x38, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12,
-	0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
-	0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x74, 0x0a, 0x11, 0x47, 0x65, 0x74,
-	0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37,
-	0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x50,
-	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52, 0x0b, 0x70, 0x72, 0x6f, 0x66,
-	0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x26, 0x0a, 0x0f, 0x63, 0x70, 0x75, 0x5f, 0x64,
-	0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03,
-	0x52, 0x0d, 0x63, 0x70, 0x75, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x4e, 0x73, 0x22,
-	0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x70,
-	0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c,
-	0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x53, 0x0a, 0x18, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65,
-	0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65,
-	0x73, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x5f, 0x69, 0x6e,
-	0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
-	0x6d, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0b,
-	0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x18, 0x0a, 0x16, 0x55,
-	0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x92, 0x01, 0x0a, 0x0b, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e,
-	0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
-	0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
-	0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x08, 0x52, 0x05, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70,
-	0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x70,
-	0x6c, 0x69, 0x63, 0x61, 0x73, 0x12, 0x33, 0x0a, 0x0a, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d,
-	0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0a,
-	0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x22, 0x94, 0x01, 0x0a, 0x0a, 0x41,
-	0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x06, 0x73, 0x6c, 0x69,
-	0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x53,
-	0x6c, 0x69, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07,
-	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76,
-	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x39, 0x0a, 0x05, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x12,
-	0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05,
-	0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
-	0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
-	0x73, 0x22, 0x39, 0x0a, 0x17, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f,
-	0x6e, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a,
-	0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x17, 0x0a, 0x15,
-	0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73,
-	0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x50, 0x0a, 0x18, 0x41, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74,
-	0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
-	0x74, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12,
-	0x16, 0x0a, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52,
-	0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x22, 0x18, 0x0a, 0x16, 0x41, 0x63, 0x74, 0x69, 0x76,
-	0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c,
-	0x79, 0x22, 0x2f, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72,
-	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12,
-	0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61,
-	0x6d, 0x65, 0x22, 0x33, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65,
-	0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a,
-	0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
-	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x4d, 0x0a, 0x15, 0x45, 0x78, 0x70, 0x6f, 0x72,
-	0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
-	0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x08, 0x6c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07,
-	0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61,
-	0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x50, 0x0a, 0x13, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74,
-	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x23, 0x0a,
-	0x0d, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01,
-	0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65,
-	0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x1b, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x53,
-	0x65, 0x6c, 0x66, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3f, 0x0a, 0x17, 0x47, 0x65, 0x74, 0x53, 0x65, 0x6c, 0x66,
-	0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79,
-	0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04,
-	0x63, 0x65, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x3f, 0x0a, 0x1e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79,
-	0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74,
-	0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x74,
-	0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x65,
-	0x72, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22, 0x3e, 0x0a, 0x1c, 0x56, 0x65, 0x72, 0x69, 0x66,
-	0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
-	0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
-	0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d,
-	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x6a, 0x0a, 0x1e, 0x56, 0x65, 0x72, 0x69, 0x66,
-	0x79, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
-	0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x65, 0x72,
-	0x74, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x63,
-	0x65, 0x72, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x74, 0x61, 0x72, 0x67,
-	0x65, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x0f, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
-	0x65, 0x6e, 0x74, 0x22, 0x1e, 0x0a, 0x1c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x53, 0x65, 0x72,
-	0x76, 0x65, 0x72, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65,
-	0x70, 0x6c, 0x79, 0x22, 0xef, 0x01, 0x0a, 0x08, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79,
-	0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61,
-	0x70, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09,
-	0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
-	0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f,
-	0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x1f,
-	0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20,
-	0x01, 0x28, 0x10, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12,
-	0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
-	0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20,
-	0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e,
-	0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x12, 0x10, 0x0a,
-	0x03, 0x6d, 0x73, 0x67, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12,
-	0x14, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05,
-	0x61, 0x74, 0x74, 0x72, 0x73, 0x22, 0x3c, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72,
-	0x79, 0x42, 0x61, 0x74, 0x63, 0x68, 0x12, 0x2b, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65,
-	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
-	0x65, 0x2e, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72,
-	0x69, 0x65, 0x73, 0x22, 0x2f, 0x0a, 0x0a, 0x54, 0x72, 0x61, 0x63, 0x65, 0x53, 0x70, 0x61, 0x6e,
-	0x73, 0x12, 0x21, 0x0a, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32,
-	0x0d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x52, 0x04,
-	0x73, 0x70, 0x61, 0x6e, 0x22, 0xb9, 0x10, 0x0a, 0x04, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a,
-	0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d,
-	0x65, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20,
-	0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07,
-	0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73,
-	0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x24, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f,
-	0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70,
-	0x61, 0x72, 0x65, 0x6e, 0x74, 0x53, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x04, 0x6b,
-	0x69, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b,
-	0x69, 0x6e, 0x64, 0x12, 0x21, 0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x63,
-	0x72, 0x6f, 0x73, 0x18, 0x06, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74,
-	0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x69,
-	0x63, 0x72, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x10, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x4d,
-	0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x37, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74,
-	0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75,
-	0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x28,
-	0x0a, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
-	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x6e,
-	0x6b, 0x52, 0x05, 0x6c, 0x69, 0x6e, 0x6b, 0x73, 0x12, 0x2b, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e,
-	0x74, 0x73, 0x18, 0x0a, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
-	0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65,
-	0x76, 0x65, 0x6e, 0x74, 0x73, 0x12, 0x2c, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18,
-	0x0b, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
-	0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61,
-	0x74, 0x75, 0x73, 0x12, 0x29, 0x0a, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x18, 0x12, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61,
-	0x6e, 0x2e, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x2f,
-	0x0a, 0x07, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32,
-	0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c,
-	0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x52, 0x07, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x12,
-	0x32, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28,
-	0x0b, 0x32, 0x16, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e,
-	0x2e, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75,
-	0x72, 0x63, 0x65, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61,
-	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0e,
-	0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74,
-	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x64,
-	0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x03, 0x52, 0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
-	0x4c, 0x69, 0x6e, 0x6b, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x72, 0x6f,
-	0x70, 0x70, 0x65, 0x64, 0x5f, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74,
-	0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x52, 0x11, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x45,
-	0x76, 0x65, 0x6e, 0x74, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x63, 0x68, 0x69,
-	0x6c, 0x64, 0x5f, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x11, 0x20,
-	0x01, 0x28, 0x03, 0x52, 0x0e, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x43, 0x6f,
-	0x75, 0x6e, 0x74, 0x1a, 0x8a, 0x04, 0x0a, 0x09, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
-	0x65, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03,
-	0x6b, 0x65, 0x79, 0x12, 0x33, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01,
-	0x28, 0x0b, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61,
-	0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75,
-	0x65, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0xb5, 0x03, 0x0a, 0x05, 0x56, 0x61, 0x6c,
-	0x75, 0x65, 0x12, 0x36, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e,
-	0x32, 0x22, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e,
-	0x54, 0x79, 0x70, 0x65, 0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x03, 0x6e, 0x75,
-	0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x48, 0x00, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x12, 0x12,
-	0x0a, 0x03, 0x73, 0x74, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73,
-	0x74, 0x72, 0x12, 0x3e, 0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x28, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e,
-	0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x75,
-	0x6d, 0x73, 0x12, 0x3e, 0x0a, 0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b,
-	0x32, 0x28, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e,
-	0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x73, 0x74,
-	0x72, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c, 0x69, 0x73, 0x74,
-	0x12, 0x12, 0x0a, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04, 0x52, 0x04,
-	0x6e, 0x75, 0x6d, 0x73, 0x1a, 0x20, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69,
-	0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09,
-	0x52, 0x04, 0x73, 0x74, 0x72, 0x73, 0x22, 0x7f, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b,
-	0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x42,
-	0x4f, 0x4f, 0x4c, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x10, 0x02,
-	0x12, 0x0b, 0x0a, 0x07, 0x46, 0x4c, 0x4f, 0x41, 0x54, 0x36, 0x34, 0x10, 0x03, 0x12, 0x0a, 0x0a,
-	0x06, 0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x42, 0x4f, 0x4f,
-	0x4c, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x05, 0x12, 0x0d, 0x0a, 0x09, 0x49, 0x4e, 0x54, 0x36, 0x34,
-	0x4c, 0x49, 0x53, 0x54, 0x10, 0x06, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x4c, 0x4f, 0x41, 0x54, 0x36,
-	0x34, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x07, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x54, 0x52, 0x49, 0x4e,
-	0x47, 0x4c, 0x49, 0x53, 0x54, 0x10, 0x08, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
-	0x1a, 0xab, 0x01, 0x0a, 0x04, 0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61,
-	0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x72, 0x61,
-	0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18,
-	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x37, 0x0a,
-	0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e,
-	0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72,
-	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65,
-	0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0xad,
-	0x01, 0x0a, 0x05, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b,
-	0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x10, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x37, 0x0a,
-	0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
-	0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e,
-	0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72,
-	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65,
-	0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e,
-	0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0x73,
-	0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65,
-	0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
-	0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x43, 0x6f, 0x64,
-	0x65, 0x52, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72,
-	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x24, 0x0a,
-	0x04, 0x43, 0x6f, 0x64, 0x65, 0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45, 0x54, 0x10, 0x00,
-	0x12, 0x09, 0x0a, 0x05, 0x45, 0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x06, 0x0a, 0x02, 0x4f,
-	0x4b, 0x10, 0x02, 0x1a, 0x54, 0x0a, 0x05, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04,
-	0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65,
-	0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63,
-	0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
-	0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x1a, 0x56, 0x0a, 0x07, 0x4c, 0x69, 0x62,
-	0x72, 0x61, 0x72, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01,
-	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73,
-	0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69,
-	0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c,
-	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72,
-	0x6c, 0x1a, 0x62, 0x0a, 0x08, 0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a,
-	0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28,
-	0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x12, 0x37, 0x0a, 0x0a,
-	0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b,
-	0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e,
-	0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
-	0x62, 0x75, 0x74, 0x65, 0x73, 0x22, 0x59, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0f, 0x0a,
-	0x0b, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c,
-	0x0a, 0x08, 0x49, 0x4e, 0x54, 0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06,
-	0x53, 0x45, 0x52, 0x56, 0x45, 0x52, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x4c, 0x49, 0x45,
-	0x4e, 0x54, 0x10, 0x03, 0x12, 0x0c, 0x0a, 0x08, 0x50, 0x52, 0x4f, 0x44, 0x55, 0x43, 0x45, 0x52,
-	0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x43, 0x4f, 0x4e, 0x53, 0x55, 0x4d, 0x45, 0x52, 0x10, 0x05,
-	0x2a, 0x47, 0x0a, 0x0c, 0x48, 0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73,
-	0x12, 0x0b, 0x0a, 0x07, 0x55, 0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a,
-	0x07, 0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e,
-	0x48, 0x45, 0x41, 0x4c, 0x54, 0x48, 0x59, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52,
-	0x4d, 0x49, 0x4e, 0x41, 0x54, 0x45, 0x44, 0x10, 0x03, 0x2a, 0x40, 0x0a, 0x0a, 0x4d, 0x65, 0x74,
-	0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c,
-	0x49, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x45, 0x52, 0x10,
-	0x01, 0x12, 0x09, 0x0a, 0x05, 0x47, 0x41, 0x55, 0x47, 0x45, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09,
-	0x48, 0x49, 0x53, 0x54, 0x4f, 0x47, 0x52, 0x41, 0x4d, 0x10, 0x03, 0x2a, 0x31, 0x0a, 0x0b, 0x50,
-	0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x6e,
-	0x73, 0x70, 0x65, 0x63, 0x69, 0x66, 0x69, 0x65, 0x64, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48,
-	0x65, 0x61, 0x70, 0x10, 0x01, 0x12, 0x07, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x10, 0x02, 0x42, 0x30,
-	0x5a, 0x2e, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x65, 0x72,
-	0x76, 0x69, 0x63, 0x65, 0x57, 0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x77, 0x65, 0x61, 0x76, 0x65,
-	0x72, 0x2f, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73,
-	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+	0x6e, 0x74, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74,
+	0x73, 0x22, 0xad, 0x02, 0x0a, 0x0e, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x53, 0x6e, 0x61, 0x70,
+	0x73, 0x68, 0x6f, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04,
+	0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x25, 0x0a, 0x03, 0x74, 0x79, 0x70, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e,
+	0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79, 0x70, 0x65, 0x52, 0x03, 0x74, 0x79, 0x70, 0x12,
+	0x12, 0x0a, 0x04, 0x68, 0x65, 0x6c, 0x70, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x68,
+	0x65, 0x6c, 0x70, 0x12, 0x3b, 0x0a, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73, 0x18, 0x05, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x23, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4d, 0x65,
+	0x74, 0x72, 0x69, 0x63, 0x53, 0x6e, 0x61, 0x70, 0x73, 0x68, 0x6f, 0x74, 0x2e, 0x4c, 0x61, 0x62,
+	0x65, 0x6c, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x06, 0x6c, 0x61, 0x62, 0x65, 0x6c, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x01,
+	0x52, 0x06, 0x62, 0x6f, 0x75, 0x6e, 0x64, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75,
+	0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x04, 0x52, 0x06,
+	0x63, 0x6f, 0x75, 0x6e, 0x74, 0x73, 0x1a, 0x39, 0x0a, 0x0b, 0x4c, 0x61, 0x62, 0x65, 0x6c, 0x73,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x10, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0x37, 0x0a, 0x0c, 0x47, 0x65, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65,
+	0x70, 0x6c, 0x79, 0x12, 0x27, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64,
+	0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0xcf, 0x03, 0x0a,
+	0x0a, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x12, 0x34, 0x0a, 0x05, 0x6c,
+	0x6f, 0x61, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1e, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e,
+	0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x05, 0x6c, 0x6f, 0x61, 0x64,
+	0x73, 0x1a, 0x5b, 0x0a, 0x0a, 0x4c, 0x6f, 0x61, 0x64, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12,
+	0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65,
+	0x79, 0x12, 0x37, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x21, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52,
+	0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c,
+	0x6f, 0x61, 0x64, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x1a, 0x5c,
+	0x0a, 0x0d, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x4c, 0x6f, 0x61, 0x64, 0x12,
+	0x31, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f,
+	0x72, 0x74, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x04, 0x6c, 0x6f,
+	0x61, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x95, 0x01, 0x0a,
+	0x09, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x10, 0x0a, 0x03, 0x65, 0x6e, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x04, 0x52, 0x03, 0x65,
+	0x6e, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x12, 0x38, 0x0a, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x20, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65,
+	0x2e, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x65, 0x70, 0x6f, 0x72, 0x74, 0x2e, 0x53, 0x75, 0x62, 0x73,
+	0x6c, 0x69, 0x63, 0x65, 0x4c, 0x6f, 0x61, 0x64, 0x52, 0x06, 0x73, 0x70, 0x6c, 0x69, 0x74, 0x73,
+	0x12, 0x12, 0x0a, 0x04, 0x73, 0x69, 0x7a, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x04, 0x52, 0x04,
+	0x73, 0x69, 0x7a, 0x65, 0x1a, 0x38, 0x0a, 0x0c, 0x53, 0x75, 0x62, 0x73, 0x6c, 0x69, 0x63, 0x65,
+	0x4c, 0x6f, 0x61, 0x64, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6c, 0x6f,
+	0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x04, 0x6c, 0x6f, 0x61, 0x64, 0x22, 0x74,
+	0x0a, 0x11, 0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x5f, 0x74,
+	0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74,
+	0x69, 0x6d, 0x65, 0x2e, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x0b, 0x70, 0x72, 0x6f, 0x66, 0x69, 0x6c, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x26, 0x0a, 0x0f,
+	0x63, 0x70, 0x75, 0x5f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x5f, 0x6e, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x63, 0x70, 0x75, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69,
+	0x6f, 0x6e, 0x4e, 0x73, 0x22, 0x25, 0x0a, 0x0f, 0x47, 0x65, 0x74, 0x50, 0x72, 0x6f, 0x66, 0x69,
+	0x6c, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x64, 0x61, 0x74, 0x61, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x64, 0x61, 0x74, 0x61, 0x22, 0x18, 0x0a, 0x16, 0x47,
+	0x65, 0x74, 0x46, 0x6c, 0x69, 0x67, 0x68, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x42, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x46, 0x6c, 0x69, 0x67,
+	0x68, 0x74, 0x52, 0x65, 0x63, 0x6f, 0x72, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2a, 0x0a,
+	0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x43, 0x61, 0x6c, 0x6c, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x22, 0xed, 0x01, 0x0a, 0x09, 0x43, 0x61,
+	0x6c, 0x6c, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0a, 0x74, 0x69,
+	0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x61, 0x6c, 0x6c,
+	0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x61, 0x6c, 0x6c, 0x65, 0x72,
+	0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x16,
+	0x0a, 0x06, 0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06,
+	0x6d, 0x65, 0x74, 0x68, 0x6f, 0x64, 0x12, 0x25, 0x0a, 0x0e, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63,
+	0x79, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d,
+	0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x16, 0x0a,
+	0x06, 0x66, 0x61, 0x69, 0x6c, 0x65, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x66,
+	0x61, 0x69, 0x6c, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x65, 0x6d, 0x6f, 0x74, 0x65, 0x12, 0x1a, 0x0a,
+	0x08, 0x77, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x08, 0x77, 0x65, 0x61, 0x76, 0x65, 0x6c, 0x65, 0x74, 0x22, 0x53, 0x0a, 0x18, 0x55, 0x70, 0x64,
+	0x61, 0x74, 0x65, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x37, 0x0a, 0x0c, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67,
+	0x5f, 0x69, 0x6e, 0x66, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66,
+	0x6f, 0x52, 0x0b, 0x72, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x22, 0x18,
+	0x0a, 0x16, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x4e, 0x0a, 0x1c, 0x4e, 0x6f, 0x74, 0x69,
+	0x66, 0x79, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x2e, 0x0a, 0x05, 0x65, 0x76, 0x65, 0x6e,
+	0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d,
+	0x65, 0x2e, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x52, 0x05, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x22, 0x1c, 0x0a, 0x1a, 0x4e, 0x6f, 0x74, 0x69,
+	0x66, 0x79, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0xe2, 0x01, 0x0a, 0x0f, 0x44, 0x65, 0x70, 0x6c, 0x6f,
+	0x79, 0x6d, 0x65, 0x6e, 0x74, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x31, 0x0a, 0x04, 0x6b, 0x69,
+	0x6e, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x44, 0x65, 0x70, 0x6c, 0x6f, 0x79, 0x6d, 0x65, 0x6e, 0x74, 0x45, 0x76, 0x65,
+	0x6e, 0x74, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x18, 0x0a,
+	0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f,
+	0x6e, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61,
+	0x73, 0x22, 0x48, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x53,
+	0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x52, 0x4f,
+	0x4c, 0x4c, 0x4f, 0x55, 0x54, 0x10, 0x01, 0x12, 0x0c, 0x0a, 0x08, 0x44, 0x52, 0x41, 0x49, 0x4e,
+	0x49, 0x4e, 0x47, 0x10, 0x02, 0x12, 0x14, 0x0a, 0x10, 0x52, 0x45, 0x50, 0x4c, 0x49, 0x43, 0x41,
+	0x53, 0x5f, 0x43, 0x48, 0x41, 0x4e, 0x47, 0x45, 0x44, 0x10, 0x03, 0x22, 0xb3, 0x03, 0x0a, 0x0b,
+	0x52, 0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1c, 0x0a, 0x09, 0x63,
+	0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x6f, 0x63,
+	0x61, 0x6c, 0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x12,
+	0x1a, 0x0a, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28,
+	0x09, 0x52, 0x08, 0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x12, 0x33, 0x0a, 0x0a, 0x61,
+	0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e,
+	0x6d, 0x65, 0x6e, 0x74, 0x52, 0x0a, 0x61, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x44, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x18, 0x05,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x24, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x52,
+	0x6f, 0x75, 0x74, 0x69, 0x6e, 0x67, 0x49, 0x6e, 0x66, 0x6f, 0x2e, 0x4c, 0x6f, 0x63, 0x61, 0x6c,
+	0x69, 0x74, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x0a, 0x6c, 0x6f, 0x63, 0x61,
+	0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f,
+	0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x12, 0x21, 0x0a, 0x0c, 0x62, 0x61, 0x73, 0x65, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x04, 0x52, 0x0b, 0x62, 0x61, 0x73, 0x65, 0x56, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x29, 0x0a, 0x10, 0x72, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x5f, 0x72,
+	0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x08, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0f, 0x72,
+	0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x12, 0x1f,
+	0x0a, 0x0b, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x5f, 0x6e, 0x61, 0x6e, 0x6f, 0x73, 0x18, 0x09, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0a, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x4e, 0x61, 0x6e, 0x6f, 0x73, 0x1a,
+	0x50, 0x0a, 0x0f, 0x4c, 0x6f, 0x63, 0x61, 0x6c, 0x69, 0x74, 0x69, 0x65, 0x73, 0x45, 0x6e, 0x74,
+	0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x03, 0x6b, 0x65, 0x79, 0x12, 0x27, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20,
+	0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f,
+	0x63, 0x61, 0x6c, 0x69, 0x74, 0x79, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38,
+	0x01, 0x22, 0x94, 0x01, 0x0a, 0x0a, 0x41, 0x73, 0x73, 0x69, 0x67, 0x6e, 0x6d, 0x65, 0x6e, 0x74,
+	0x12, 0x31, 0x0a, 0x06, 0x73, 0x6c, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x41, 0x73, 0x73, 0x69, 0x67,
+	0x6e, 0x6d, 0x65, 0x6e, 0x74, 0x2e, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x52, 0x06, 0x73, 0x6c, 0x69,
+	0x63, 0x65, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x04, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x1a, 0x39, 0x0a,
+	0x05, 0x53, 0x6c, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x04, 0x52, 0x05, 0x73, 0x74, 0x61, 0x72, 0x74, 0x12, 0x1a, 0x0a, 0x08,
+	0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x08,
+	0x72, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x73, 0x22, 0x39, 0x0a, 0x17, 0x55, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65,
+	0x6e, 0x74, 0x73, 0x22, 0x17, 0x0a, 0x15, 0x55, 0x70, 0x64, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x50, 0x0a, 0x18,
+	0x41, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70,
+	0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d,
+	0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x06, 0x72, 0x6f, 0x75, 0x74, 0x65, 0x64, 0x22, 0x18,
+	0x0a, 0x16, 0x41, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x2f, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x4c,
+	0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22, 0x33, 0x0a, 0x17, 0x47, 0x65, 0x74,
+	0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x4d,
+	0x0a, 0x15, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x65, 0x72, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x69, 0x73, 0x74, 0x65,
+	0x6e, 0x65, 0x72, 0x12, 0x18, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x22, 0x50, 0x0a,
+	0x13, 0x45, 0x78, 0x70, 0x6f, 0x72, 0x74, 0x4c, 0x69, 0x73, 0x74, 0x65, 0x6e, 0x65, 0x72, 0x52,
+	0x65, 0x70, 0x6c, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x70, 0x72, 0x6f, 0x78, 0x79, 0x5f, 0x61, 0x64,
+	0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x72, 0x6f,
+	0x78, 0x79, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72,
+	0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22,
+	0x14, 0x0a, 0x12, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x41, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x54, 0x6f, 0x70, 0x6f,
+	0x6c, 0x6f, 0x67, 0x79, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x2d, 0x0a, 0x08, 0x74, 0x6f, 0x70,
+	0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x54, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x52, 0x08,
+	0x74, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x22, 0x7a, 0x0a, 0x08, 0x54, 0x6f, 0x70, 0x6f,
+	0x6c, 0x6f, 0x67, 0x79, 0x12, 0x1e, 0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x73, 0x12, 0x2b, 0x0a, 0x05, 0x65, 0x64, 0x67, 0x65, 0x73, 0x18, 0x02, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x54, 0x6f,
+	0x70, 0x6f, 0x6c, 0x6f, 0x67, 0x79, 0x45, 0x64, 0x67, 0x65, 0x52, 0x05, 0x65, 0x64, 0x67, 0x65,
+	0x73, 0x12, 0x21, 0x0a, 0x0c, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x5f, 0x6e, 0x61, 0x6e, 0x6f,
+	0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0b, 0x77, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x4e,
+	0x61, 0x6e, 0x6f, 0x73, 0x22, 0xf8, 0x01, 0x0a, 0x0c, 0x54, 0x6f, 0x70, 0x6f, 0x6c, 0x6f, 0x67,
+	0x79, 0x45, 0x64, 0x67, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x63, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x61, 0x6c, 0x6c, 0x65, 0x72, 0x12, 0x1c, 0x0a,
+	0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x63,
+	0x61, 0x6c, 0x6c, 0x73, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x05, 0x63, 0x61, 0x6c, 0x6c,
+	0x73, 0x12, 0x16, 0x0a, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x06, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x12, 0x28, 0x0a, 0x10, 0x63, 0x61, 0x6c,
+	0x6c, 0x73, 0x5f, 0x70, 0x65, 0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x05, 0x20,
+	0x01, 0x28, 0x01, 0x52, 0x0e, 0x63, 0x61, 0x6c, 0x6c, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63,
+	0x6f, 0x6e, 0x64, 0x12, 0x2a, 0x0a, 0x11, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x5f, 0x70, 0x65,
+	0x72, 0x5f, 0x73, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0f,
+	0x65, 0x72, 0x72, 0x6f, 0x72, 0x73, 0x50, 0x65, 0x72, 0x53, 0x65, 0x63, 0x6f, 0x6e, 0x64, 0x12,
+	0x2e, 0x0a, 0x13, 0x6d, 0x65, 0x61, 0x6e, 0x5f, 0x6c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x5f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x6d, 0x65,
+	0x61, 0x6e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x63, 0x79, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x22,
+	0x1b, 0x0a, 0x19, 0x47, 0x65, 0x74, 0x53, 0x65, 0x6c, 0x66, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x3f, 0x0a, 0x17,
+	0x47, 0x65, 0x74, 0x53, 0x65, 0x6c, 0x66, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61,
+	0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x12, 0x0a, 0x04, 0x63, 0x65, 0x72, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x04, 0x63, 0x65, 0x72, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x6b,
+	0x65, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x22, 0x3f, 0x0a,
+	0x1e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65, 0x72,
+	0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x1d, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x01, 0x20,
+	0x03, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x65, 0x72, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x22, 0x3e,
+	0x0a, 0x1c, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x43, 0x6c, 0x69, 0x65, 0x6e, 0x74, 0x43, 0x65,
+	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x1e,
+	0x0a, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x0a, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x6a,
+	0x0a, 0x1e, 0x56, 0x65, 0x72, 0x69, 0x66, 0x79, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x43, 0x65,
+	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x12, 0x1d, 0x0a, 0x0a, 0x63, 0x65, 0x72, 0x74, 0x5f, 0x63, 0x68, 0x61, 0x69, 0x6e, 0x18, 0x01,
+	0x20, 0x03, 0x28, 0x0c, 0x52, 0x09, 0x63, 0x65, 0x72, 0x74, 0x43, 0x68, 0x61, 0x69, 0x6e, 0x12,
+	0x29, 0x0a, 0x10, 0x74, 0x61, 0x72, 0x67, 0x65, 0x74, 0x5f, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e,
+	0x65, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0f, 0x74, 0x61, 0x72, 0x67, 0x65,
+	0x74, 0x43, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74, 0x22, 0x1e, 0x0a, 0x1c, 0x56, 0x65,
+	0x72, 0x69, 0x66, 0x79, 0x53, 0x65, 0x72, 0x76, 0x65, 0x72, 0x43, 0x65, 0x72, 0x74, 0x69, 0x66,
+	0x69, 0x63, 0x61, 0x74, 0x65, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0xa3, 0x02, 0x0a, 0x08, 0x4c,
+	0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x61, 0x70, 0x70, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x61, 0x70, 0x70, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72,
+	0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x1c, 0x0a, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e, 0x74,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x63, 0x6f, 0x6d, 0x70, 0x6f, 0x6e, 0x65, 0x6e,
+	0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x6f, 0x64, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x04, 0x6e, 0x6f, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f, 0x6d, 0x69,
+	0x63, 0x72, 0x6f, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0a, 0x74, 0x69, 0x6d, 0x65,
+	0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18,
+	0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x12, 0x0a, 0x04,
+	0x66, 0x69, 0x6c, 0x65, 0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x66, 0x69, 0x6c, 0x65,
+	0x12, 0x12, 0x0a, 0x04, 0x6c, 0x69, 0x6e, 0x65, 0x18, 0x08, 0x20, 0x01, 0x28, 0x05, 0x52, 0x04,
+	0x6c, 0x69, 0x6e, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x6d, 0x73, 0x67, 0x18, 0x09, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x03, 0x6d, 0x73, 0x67, 0x12, 0x14, 0x0a, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x18,
+	0x0a, 0x20, 0x03, 0x28, 0x09, 0x52, 0x05, 0x61, 0x74, 0x74, 0x72, 0x73, 0x12, 0x19, 0x0a, 0x08,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f,
+	0x69, 0x64, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64,
+	0x22, 0x3c, 0x0a, 0x0d, 0x4c, 0x6f, 0x67, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x42, 0x61, 0x74, 0x63,
+	0x68, 0x12, 0x2b, 0x0a, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x11, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x4c, 0x6f, 0x67,
+	0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07, 0x65, 0x6e, 0x74, 0x72, 0x69, 0x65, 0x73, 0x22, 0x2f,
+	0x0a, 0x0a, 0x54, 0x72, 0x61, 0x63, 0x65, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x21, 0x0a, 0x04,
+	0x73, 0x70, 0x61, 0x6e, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x0d, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x52, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x22,
+	0xb9, 0x10, 0x0a, 0x04, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x19, 0x0a, 0x08,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07,
+	0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12, 0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f,
+	0x69, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64,
+	0x12, 0x24, 0x0a, 0x0e, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74, 0x5f, 0x73, 0x70, 0x61, 0x6e, 0x5f,
+	0x69, 0x64, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x0c, 0x70, 0x61, 0x72, 0x65, 0x6e, 0x74,
+	0x53, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x26, 0x0a, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x18, 0x05,
+	0x20, 0x01, 0x28, 0x0e, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53,
+	0x70, 0x61, 0x6e, 0x2e, 0x4b, 0x69, 0x6e, 0x64, 0x52, 0x04, 0x6b, 0x69, 0x6e, 0x64, 0x12, 0x21,
+	0x0a, 0x0c, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x06,
+	0x20, 0x01, 0x28, 0x10, 0x52, 0x0b, 0x73, 0x74, 0x61, 0x72, 0x74, 0x4d, 0x69, 0x63, 0x72, 0x6f,
+	0x73, 0x12, 0x1d, 0x0a, 0x0a, 0x65, 0x6e, 0x64, 0x5f, 0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x10, 0x52, 0x09, 0x65, 0x6e, 0x64, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73,
+	0x12, 0x37, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x08,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53,
+	0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61,
+	0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x12, 0x28, 0x0a, 0x05, 0x6c, 0x69, 0x6e,
+	0x6b, 0x73, 0x18, 0x09, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69,
+	0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x6e, 0x6b, 0x52, 0x05, 0x6c, 0x69,
+	0x6e, 0x6b, 0x73, 0x12, 0x2b, 0x0a, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x0a, 0x20,
+	0x03, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70,
+	0x61, 0x6e, 0x2e, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x52, 0x06, 0x65, 0x76, 0x65, 0x6e, 0x74, 0x73,
+	0x12, 0x2c, 0x0a, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x14, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x06, 0x73, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x29,
+	0x0a, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x18, 0x12, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x53, 0x63, 0x6f,
+	0x70, 0x65, 0x52, 0x05, 0x73, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x2f, 0x0a, 0x07, 0x6c, 0x69, 0x62,
+	0x72, 0x61, 0x72, 0x79, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15, 0x2e, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x4c, 0x69, 0x62, 0x72, 0x61, 0x72,
+	0x79, 0x52, 0x07, 0x6c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x12, 0x32, 0x0a, 0x08, 0x72, 0x65,
+	0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x16, 0x2e, 0x72,
+	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x52, 0x65, 0x73, 0x6f,
+	0x75, 0x72, 0x63, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x36,
+	0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62,
+	0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74,
+	0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2c, 0x0a, 0x12, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65,
+	0x64, 0x5f, 0x6c, 0x69, 0x6e, 0x6b, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x10, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x4c, 0x69, 0x6e, 0x6b, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x2e, 0x0a, 0x13, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f,
+	0x65, 0x76, 0x65, 0x6e, 0x74, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x10, 0x20, 0x01, 0x28,
+	0x03, 0x52, 0x11, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x43,
+	0x6f, 0x75, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x10, 0x63, 0x68, 0x69, 0x6c, 0x64, 0x5f, 0x73, 0x70,
+	0x61, 0x6e, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x11, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e,
+	0x63, 0x68, 0x69, 0x6c, 0x64, 0x53, 0x70, 0x61, 0x6e, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0x8a,
+	0x04, 0x0a, 0x09, 0x41, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x12, 0x10, 0x0a, 0x03,
+	0x6b, 0x65, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x33,
+	0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1d, 0x2e,
+	0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x1a, 0xb5, 0x03, 0x0a, 0x05, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x12, 0x36, 0x0a,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x22, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x54, 0x79, 0x70, 0x65, 0x52,
+	0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x03, 0x6e, 0x75, 0x6d, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x04, 0x48, 0x00, 0x52, 0x03, 0x6e, 0x75, 0x6d, 0x12, 0x12, 0x0a, 0x03, 0x73, 0x74, 0x72,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x48, 0x00, 0x52, 0x03, 0x73, 0x74, 0x72, 0x12, 0x3e, 0x0a,
+	0x04, 0x6e, 0x75, 0x6d, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x4e, 0x75, 0x6d, 0x62, 0x65,
+	0x72, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x12, 0x3e, 0x0a,
+	0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x28, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x2e, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x2e, 0x53, 0x74, 0x72, 0x69, 0x6e,
+	0x67, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x00, 0x52, 0x04, 0x73, 0x74, 0x72, 0x73, 0x1a, 0x20, 0x0a,
+	0x0a, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x75, 0x6d, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x04, 0x52, 0x04, 0x6e, 0x75, 0x6d, 0x73, 0x1a,
+	0x20, 0x0a, 0x0a, 0x53, 0x74, 0x72, 0x69, 0x6e, 0x67, 0x4c, 0x69, 0x73, 0x74, 0x12, 0x12, 0x0a,
+	0x04, 0x73, 0x74, 0x72, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x73, 0x74, 0x72,
+	0x73, 0x22, 0x7f, 0x0a, 0x04, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56,
+	0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x42, 0x4f, 0x4f, 0x4c, 0x10, 0x01,
+	0x12, 0x09, 0x0a, 0x05, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x10, 0x02, 0x12, 0x0b, 0x0a, 0x07, 0x46,
+	0x4c, 0x4f, 0x41, 0x54, 0x36, 0x34, 0x10, 0x03, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x54, 0x52, 0x49,
+	0x4e, 0x47, 0x10, 0x04, 0x12, 0x0c, 0x0a, 0x08, 0x42, 0x4f, 0x4f, 0x4c, 0x4c, 0x49, 0x53, 0x54,
+	0x10, 0x05, 0x12, 0x0d, 0x0a, 0x09, 0x49, 0x4e, 0x54, 0x36, 0x34, 0x4c, 0x49, 0x53, 0x54, 0x10,
+	0x06, 0x12, 0x0f, 0x0a, 0x0b, 0x46, 0x4c, 0x4f, 0x41, 0x54, 0x36, 0x34, 0x4c, 0x49, 0x53, 0x54,
+	0x10, 0x07, 0x12, 0x0e, 0x0a, 0x0a, 0x53, 0x54, 0x52, 0x49, 0x4e, 0x47, 0x4c, 0x49, 0x53, 0x54,
+	0x10, 0x08, 0x42, 0x07, 0x0a, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x1a, 0xab, 0x01, 0x0a, 0x04,
+	0x4c, 0x69, 0x6e, 0x6b, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x72, 0x61, 0x63, 0x65, 0x5f, 0x69, 0x64,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x74, 0x72, 0x61, 0x63, 0x65, 0x49, 0x64, 0x12,
+	0x17, 0x0a, 0x07, 0x73, 0x70, 0x61, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c,
+	0x52, 0x06, 0x73, 0x70, 0x61, 0x6e, 0x49, 0x64, 0x12, 0x37, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72,
+	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
+	0x73, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0xad, 0x01, 0x0a, 0x05, 0x45, 0x76,
+	0x65, 0x6e, 0x74, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74, 0x69, 0x6d, 0x65, 0x5f,
+	0x6d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x18, 0x02, 0x20, 0x01, 0x28, 0x10, 0x52, 0x0a, 0x74, 0x69,
+	0x6d, 0x65, 0x4d, 0x69, 0x63, 0x72, 0x6f, 0x73, 0x12, 0x37, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72,
+	0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72,
+	0x69, 0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65,
+	0x73, 0x12, 0x36, 0x0a, 0x17, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x74,
+	0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x5f, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x15, 0x64, 0x72, 0x6f, 0x70, 0x70, 0x65, 0x64, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x43, 0x6f, 0x75, 0x6e, 0x74, 0x1a, 0x73, 0x0a, 0x06, 0x53, 0x74, 0x61,
+	0x74, 0x75, 0x73, 0x12, 0x2d, 0x0a, 0x04, 0x63, 0x6f, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0e, 0x32, 0x19, 0x2e, 0x72, 0x75, 0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e,
+	0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x2e, 0x43, 0x6f, 0x64, 0x65, 0x52, 0x04, 0x63, 0x6f,
+	0x64, 0x65, 0x12, 0x14, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x22, 0x24, 0x0a, 0x04, 0x43, 0x6f, 0x64, 0x65,
+	0x12, 0x09, 0x0a, 0x05, 0x55, 0x4e, 0x53, 0x45, 0x54, 0x10, 0x00, 0x12, 0x09, 0x0a, 0x05, 0x45,
+	0x52, 0x52, 0x4f, 0x52, 0x10, 0x01, 0x12, 0x06, 0x0a, 0x02, 0x4f, 0x4b, 0x10, 0x02, 0x1a, 0x54,
+	0x0a, 0x05, 0x53, 0x63, 0x6f, 0x70, 0x65, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76,
+	0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65,
+	0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f,
+	0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d,
+	0x61, 0x55, 0x72, 0x6c, 0x1a, 0x56, 0x0a, 0x07, 0x4c, 0x69, 0x62, 0x72, 0x61, 0x72, 0x79, 0x12,
+	0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x1d, 0x0a,
+	0x0a, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x03, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x09, 0x73, 0x63, 0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x1a, 0x62, 0x0a, 0x08,
+	0x52, 0x65, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x63, 0x68, 0x65,
+	0x6d, 0x61, 0x5f, 0x75, 0x72, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x73, 0x63,
+	0x68, 0x65, 0x6d, 0x61, 0x55, 0x72, 0x6c, 0x12, 0x37, 0x0a, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x72, 0x75,
+	0x6e, 0x74, 0x69, 0x6d, 0x65, 0x2e, 0x53, 0x70, 0x61, 0x6e, 0x2e, 0x41, 0x74, 0x74, 0x72, 0x69,
+	0x62, 0x75, 0x74, 0x65, 0x52, 0x0a, 0x61, 0x74, 0x74, 0x72, 0x69, 0x62, 0x75, 0x74, 0x65, 0x73,
+	0x22, 0x59, 0x0a, 0x04, 0x4b, 0x69, 0x6e, 0x64, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x4e, 0x53, 0x50,
+	0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x49, 0x4e, 0x54,
+	0x45, 0x52, 0x4e, 0x41, 0x4c, 0x10, 0x01, 0x12, 0x0a, 0x0a, 0x06, 0x53, 0x45, 0x52, 0x56, 0x45,
+	0x52, 0x10, 0x02, 0x12, 0x0a, 0x0a, 0x06, 0x43, 0x4c, 0x49, 0x45, 0x4e, 0x54, 0x10, 0x03, 0x12,
+	0x0c, 0x0a, 0x08, 0x50, 0x52, 0x4f, 0x44, 0x55, 0x43, 0x45, 0x52, 0x10, 0x04, 0x12, 0x0c, 0x0a,
+	0x08, 0x43, 0x4f, 0x4e, 0x53, 0x55, 0x4d, 0x45, 0x52, 0x10, 0x05, 0x2a, 0x47, 0x0a, 0x0c, 0x48,
+	0x65, 0x61, 0x6c, 0x74, 0x68, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x0b, 0x0a, 0x07, 0x55,
+	0x4e, 0x4b, 0x4e, 0x4f, 0x57, 0x4e, 0x10, 0x00, 0x12, 0x0b, 0x0a, 0x07, 0x48, 0x45, 0x41, 0x4c,
+	0x54, 0x48, 0x59, 0x10, 0x01, 0x12, 0x0d, 0x0a, 0x09, 0x55, 0x4e, 0x48, 0x45, 0x41, 0x4c, 0x54,
+	0x48, 0x59, 0x10, 0x02, 0x12, 0x0e, 0x0a, 0x0a, 0x54, 0x45, 0x52, 0x4d, 0x49, 0x4e, 0x41, 0x54,
+	0x45, 0x44, 0x10, 0x03, 0x2a, 0x40, 0x0a, 0x0a, 0x4d, 0x65, 0x74, 0x72, 0x69, 0x63, 0x54, 0x79,
+	0x70, 0x65, 0x12, 0x0b, 0x0a, 0x07, 0x49, 0x4e, 0x56, 0x41, 0x4c, 0x49, 0x44, 0x10, 0x00, 0x12,
+	0x0b, 0x0a, 0x07, 0x43, 0x4f, 0x55, 0x4e, 0x54, 0x45, 0x52, 0x10, 0x01, 0x12, 0x09, 0x0a, 0x05,
+	0x47, 0x41, 0x55, 0x47, 0x45, 0x10, 0x02, 0x12, 0x0d, 0x0a, 0x09, 0x48, 0x49, 0x53, 0x54, 0x4f,
+	0x47, 0x52, 0x41, 0x4d, 0x10, 0x03, 0x2a, 0x31, 0x0a, 0x0b, 0x50, 0x72, 0x6f, 0x66, 0x69, 0x6c,
+	0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x0f, 0x0a, 0x0b, 0x55, 0x6e, 0x73, 0x70, 0x65, 0x63, 0x69,
+	0x66, 0x69, 0x65, 0x64, 0x10, 0x00, 0x12, 0x08, 0x0a, 0x04, 0x48, 0x65, 0x61, 0x70, 0x10, 0x01,
+	0x12, 0x07, 0x0a, 0x03, 0x43, 0x50, 0x55, 0x10, 0x02, 0x42, 0x30, 0x5a, 0x2e, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x57,
+	0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x77, 0x65, 0x61, 0x76, 0x65, 0x72, 0x2f, 0x72, 0x75, 0x6e,
+	0x74, 0x69, 0x6d, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x73, 0x62, 0x06, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x33,
 }
 
 var (
-	file_runtime_protos_runtime_proto_rawDescOnce sync.Once
-	file_runtime_protos_runtime_proto_rawDescData = file_runtime_protos_runtime_proto_rawDesc
+	file_runtime_proto_rawDescOnce sync.Once
+	file_runtime_proto_rawDescData = file_runtime_proto_rawDesc
 )
 
-func file_runtime_protos_runtime_proto_rawDescGZIP() []byte {
-	file_runtime_protos_runtime_proto_rawDescOnce.Do(func() {
-		file_runtime_protos_runtime_proto_rawDescData = protoimpl.X.CompressGZIP(file_runtime_protos_runtime_proto_rawDescData)
+func file_runtime_proto_rawDescGZIP() []byte {
+	file_runtime_proto_rawDescOnce.Do(func() {
+		file_runtime_proto_rawDescData = protoimpl.X.CompressGZIP(file_runtime_proto_rawDescData)
 	})
-	return file_runtime_protos_runtime_proto_rawDescData
+	return file_runtime_proto_rawDescData
 }
 
-var file_runtime_protos_runtime_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
-var file_runtime_protos_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 58)
-var file_runtime_protos_runtime_proto_goTypes = []interface{}{
+var file_runtime_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_runtime_proto_msgTypes = make([]protoimpl.MessageInfo, 71)
+var file_runtime_proto_goTypes = []interface{}{
 	(HealthStatus)(0),                       // 0: runtime.HealthStatus
 	(MetricType)(0),                         // 1: runtime.MetricType
 	(ProfileType)(0),                        // 2: runtime.ProfileType
-	(Span_Kind)(0),                          // 3: runtime.Span.Kind
-	(Span_Attribute_Value_Type)(0),          // 4: runtime.Span.Attribute.Value.Type
-	(Span_Status_Code)(0),                   // 5: runtime.Span.Status.Code
-	(*WeaveletArgs)(nil),                    // 6: runtime.WeaveletArgs
-	(*InitWeaveletRequest)(nil),             // 7: runtime.InitWeaveletRequest
-	(*InitWeaveletReply)(nil),               // 8: runtime.InitWeaveletReply
-	(*SemVer)(nil),                          // 9: runtime.SemVer
-	(*GetHealthRequest)(nil),                // 10: runtime.GetHealthRequest
-	(*GetHealthReply)(nil),                  // 11: runtime.GetHealthReply
-	(*GetMetricsRequest)(nil),               // 12: runtime.GetMetricsRequest
-	(*GetMetricsReply)(nil),                 // 13: runtime.GetMetricsReply
-	(*MetricUpdate)(nil),                    // 14: runtime.MetricUpdate
-	(*MetricDef)(nil),                       // 15: runtime.MetricDef
-	(*MetricValue)(nil),                     // 16: runtime.MetricValue
-	(*MetricSnapshot)(nil),                  // 17: runtime.MetricSnapshot
-	(*GetLoadRequest)(nil),                  // 18: runtime.GetLoadRequest
-	(*GetLoadReply)(nil),                    // 19: runtime.GetLoadReply
-	(*LoadReport)(nil),                      // 20: runtime.LoadReport
-	(*GetProfileRequest)(nil),               // 21: runtime.GetProfileRequest
-	(*GetProfileReply)(nil),                 // 22: runtime.GetProfileReply
-	(*UpdateRoutingInfoRequest)(nil),        // 23: runtime.UpdateRoutingInfoRequest
-	(*UpdateRoutingInfoReply)(nil),          // 24: runtime.UpdateRoutingInfoReply
-	(*RoutingInfo)(nil),                     // 25: runtime.RoutingInfo
-	(*Assignment)(nil),                      // 26: runtime.Assignment
-	(*UpdateComponentsRequest)(nil),         // 27: runtime.UpdateComponentsRequest
-	(*UpdateComponentsReply)(nil),           // 28: runtime.UpdateComponentsReply
-	(*ActivateComponentRequest)(nil),        // 29: runtime.ActivateComponentRequest
-	(*ActivateComponentReply)(nil),          // 30: runtime.ActivateComponentReply
-	(*GetListenerAddressRequest)(nil),       // 31: runtime.GetListenerAddressRequest
-	(*GetListenerAddressReply)(nil),         // 32: runtime.GetListenerAddressReply
-	(*ExportListenerRequest)(nil),           // 33: runtime.ExportListenerRequest
-	(*ExportListenerReply)(nil),             // 34: runtime.ExportListenerReply
-	(*GetSelfCertificateRequest)(nil),       // 35: runtime.GetSelfCertificateRequest
-	(*GetSelfCertificateReply)(nil),         // 36: runtime.GetSelfCertificateReply
-	(*VerifyClientCertificateRequest)(nil),  // 37: runtime.VerifyClientCertificateRequest
-	(*VerifyClientCertificateReply)(nil),    // 38: runtime.VerifyClientCertificateReply
-	(*VerifyServerCertificateRequest)(nil),  // 39: runtime.VerifyServerCertificateRequest
-	(*VerifyServerCertificateReply)(nil),    // 40: runtime.VerifyServerCertificateReply
-	(*LogEntry)(nil),                        // 41: runtime.LogEntry
-	(*LogEntryBatch)(nil),                   // 42: runtime.LogEntryBatch
-	(*TraceSpans)(nil),                      // 43: runtime.TraceSpans
-	(*Span)(nil),                            // 44: runtime.Span
-	(*WeaveletArgs_Redirect)(nil),           // 45: runtime.WeaveletArgs.Redirect
-	nil,                                     // 46: runtime.InitWeaveletRequest.SectionsEntry
-	nil,                                     // 47: runtime.MetricDef.LabelsEntry
-	nil,                                     // 48: runtime.MetricSnapshot.LabelsEntry
-	nil,                                     // 49: runtime.LoadReport.LoadsEntry
-	(*LoadReport_ComponentLoad)(nil),        // 50: runtime.LoadReport.ComponentLoad
-	(*LoadReport_SliceLoad)(nil),            // 51: runtime.LoadReport.SliceLoad
-	(*LoadReport_SubsliceLoad)(nil),         // 52: runtime.LoadReport.SubsliceLoad
-	(*Assignment_Slice)(nil),                // 53: runtime.Assignment.Slice
-	(*Span_Attribute)(nil),                  // 54: runtime.Span.Attribute
-	(*Span_Link)(nil),                       // 55: runtime.Span.Link
-	(*Span_Event)(nil),                      // 56: runtime.Span.Event
-	(*Span_Status)(nil),                     // 57: runtime.Span.Status
-	(*Span_Scope)(nil),                      // 58: runtime.Span.Scope
-	(*Span_Library)(nil),                    // 59: runtime.Span.Library
-	(*Span_Resource)(nil),                   // 60: runtime.Span.Resource
-	(*Span_Attribute_Value)(nil),            // 61: runtime.Span.Attribute.Value
-	(*Span_Attribute_Value_NumberList)(nil), // 62: runtime.Span.Attribute.Value.NumberList
-	(*Span_Attribute_Value_StringList)(nil), // 63: runtime.Span.Attribute.Value.StringList
-}
-var file_runtime_protos_runtime_proto_depIdxs = []int32{
-	45, // 0: runtime.WeaveletArgs.redirects:type_name -> runtime.WeaveletArgs.Redirect
-	46, // 1: runtime.InitWeaveletRequest.sections:type_name -> runtime.InitWeaveletRequest.SectionsEntry
-	9,  // 2: runtime.InitWeaveletReply.version:type_name -> runtime.SemVer
-	0,  // 3: runtime.GetHealthReply.status:type_name -> runtime.HealthStatus
-	14, // 4: runtime.GetMetricsReply.update:type_name -> runtime.MetricUpdate
-	15, // 5: runtime.MetricUpdate.defs:type_name -> runtime.MetricDef
-	16, // 6: runtime.MetricUpdate.values:type_name -> runtime.MetricValue
-	1,  // 7: runtime.MetricDef.typ:type_name -> runtime.MetricType
-	47, // 8: runtime.MetricDef.labels:type_name -> runtime.MetricDef.LabelsEntry
-	1,  // 9: runtime.MetricSnapshot.typ:type_name -> runtime.MetricType
-	48, // 10: runtime.MetricSnapshot.labels:type_name -> runtime.MetricSnapshot.LabelsEntry
-	20, // 11: runtime.GetLoadReply.load:type_name -> runtime.LoadReport
-	49, // 12: runtime.LoadReport.loads:type_name -> runtime.LoadReport.LoadsEntry
-	2,  // 13: runtime.GetProfileRequest.profile_type:type_name -> runtime.ProfileType
-	25, // 14: runtime.UpdateRoutingInfoRequest.routing_info:type_name -> runtime.RoutingInfo
-	26, // 15: runtime.RoutingInfo.assignment:type_name -> runtime.Assignment
-	53, // 16: runtime.Assignment.slices:type_name -> runtime.Assignment.Slice
-	41, // 17: runtime.LogEntryBatch.entries:type_name -> runtime.LogEntry
-	44, // 18: runtime.TraceSpans.span:type_name -> runtime.Span
-	3,  // 19: runtime.Span.kind:type_name -> runtime.Span.Kind
-	54, // 20: runtime.Span.attributes:type_name -> runtime.Span.Attribute
-	55, // 21: runtime.Span.links:type_name -> runtime.Span.Link
-	56, // 22: runtime.Span.events:type_name -> runtime.Span.Event
-	57, // 23: runtime.Span.status:type_name -> runtime.Span.Status
-	58, // 24: runtime.Span.scope:type_name -> runtime.Span.Scope
-	59, // 25: runtime.Span.library:type_name -> runtime.Span.Library
-	60, // 26: runtime.Span.resource:type_name -> runtime.Span.Resource
-	50, // 27: runtime.LoadReport.LoadsEntry.value:type_name -> runtime.LoadReport.ComponentLoad
-	51, // 28: runtime.LoadReport.ComponentLoad.load:type_name -> runtime.LoadReport.SliceLoad
-	52, // 29: runtime.LoadReport.SliceLoad.splits:type_name -> runtime.LoadReport.SubsliceLoad
-	61, // 30: runtime.Span.Attribute.value:type_name -> runtime.Span.Attribute.Value
-	54, // 31: runtime.Span.Link.attributes:type_name -> runtime.Span.Attribute
-	54, // 32: runtime.Span.Event.attributes:type_name -> runtime.Span.Attribute
-	5,  // 33: runtime.Span.Status.code:type_name -> runtime.Span.Status.Code
-	54, // 34: runtime.Span.Resource.attributes:type_name -> runtime.Span.Attribute
-	4,  // 35: runtime.Span.Attribute.Value.type:type_name -> runtime.Span.Attribute.Value.Type
-	62, // 36: runtime.Span.Attribute.Value.nums:type_name -> runtime.Span.Attribute.Value.NumberList
-	63, // 37: runtime.Span.Attribute.Value.strs:type_name -> runtime.Span.Attribute.Value.StringList
-	38, // [38:38] is the sub-list for method output_type
-	38, // [38:38] is the sub-list for method input_type
-	38, // [38:38] is the sub-list for extension type_name
-	38, // [38:38] is the sub-list for extension extendee
-	0,  // [0:38] is the sub-list for field type_name
-}
-
-func init() { file_runtime_protos_runtime_proto_init() }
-func file_runtime_protos_runtime_proto_init() {
-	if File_runtime_protos_runtime_proto != nil {
+	(DeploymentEvent_Kind)(0),               // 3: runtime.DeploymentEvent.Kind
+	(Span_Kind)(0),                          // 4: runtime.Span.Kind
+	(Span_Attribute_Value_Type)(0),          // 5: runtime.Span.Attribute.Value.Type
+	(Span_Status_Code)(0),                   // 6: runtime.Span.Status.Code
+	(*WeaveletArgs)(nil),                    // 7: runtime.WeaveletArgs
+	(*Locality)(nil),                        // 8: runtime.Locality
+	(*InitWeaveletRequest)(nil),             // 9: runtime.InitWeaveletRequest
+	(*InitWeaveletReply)(nil),               // 10: runtime.InitWeaveletReply
+	(*SemVer)(nil),                          // 11: runtime.SemVer
+	(*GetHealthRequest)(nil),                // 12: runtime.GetHealthRequest
+	(*GetHealthReply)(nil),                  // 13: runtime.GetHealthReply
+	(*GetMetricsRequest)(nil),               // 14: runtime.GetMetricsRequest
+	(*GetMetricsReply)(nil),                 // 15: runtime.GetMetricsReply
+	(*MetricUpdate)(nil),                    // 16: runtime.MetricUpdate
+	(*MetricDef)(nil),                       // 17: runtime.MetricDef
+	(*MetricValue)(nil),                     // 18: runtime.MetricValue
+	(*MetricSnapshot)(nil),                  // 19: runtime.MetricSnapshot
+	(*GetLoadRequest)(nil),                  // 20: runtime.GetLoadRequest
+	(*GetLoadReply)(nil),                    // 21: runtime.GetLoadReply
+	(*LoadReport)(nil),                      // 22: runtime.LoadReport
+	(*GetProfileRequest)(nil),               // 23: runtime.GetProfileRequest
+	(*GetProfileReply)(nil),                 // 24: runtime.GetProfileReply
+	(*GetFlightRecordRequest)(nil),          // 25: runtime.GetFlightRecordRequest
+	(*GetFlightRecordReply)(nil),            // 26: runtime.GetFlightRecordReply
+	(*CallEvent)(nil),                       // 27: runtime.CallEvent
+	(*UpdateRoutingInfoRequest)(nil),        // 28: runtime.UpdateRoutingInfoRequest
+	(*UpdateRoutingInfoReply)(nil),          // 29: runtime.UpdateRoutingInfoReply
+	(*NotifyDeploymentEventRequest)(nil),    // 30: runtime.NotifyDeploymentEventRequest
+	(*NotifyDeploymentEventReply)(nil),      // 31: runtime.NotifyDeploymentEventReply
+	(*DeploymentEvent)(nil),                 // 32: runtime.DeploymentEvent
+	(*RoutingInfo)(nil),                     // 33: runtime.RoutingInfo
+	(*Assignment)(nil),                      // 34: runtime.Assignment
+	(*UpdateComponentsRequest)(nil),         // 35: runtime.UpdateComponentsRequest
+	(*UpdateComponentsReply)(nil),           // 36: runtime.UpdateComponentsReply
+	(*ActivateComponentRequest)(nil),        // 37: runtime.ActivateComponentRequest
+	(*ActivateComponentReply)(nil),          // 38: runtime.ActivateComponentReply
+	(*GetListenerAddressRequest)(nil),       // 39: runtime.GetListenerAddressRequest
+	(*GetListenerAddressReply)(nil),         // 40: runtime.GetListenerAddressReply
+	(*ExportListenerRequest)(nil),           // 41: runtime.ExportListenerRequest
+	(*ExportListenerReply)(nil),             // 42: runtime.ExportListenerReply
+	(*GetTopologyRequest)(nil),              // 43: runtime.GetTopologyRequest
+	(*GetTopologyReply)(nil),                // 44: runtime.GetTopologyReply
+	(*Topology)(nil),                        // 45: runtime.Topology
+	(*TopologyEdge)(nil),                    // 46: runtime.TopologyEdge
+	(*GetSelfCertificateRequest)(nil),       // 47: runtime.GetSelfCertificateRequest
+	(*GetSelfCertificateReply)(nil),         // 48: runtime.GetSelfCertificateReply
+	(*VerifyClientCertificateRequest)(nil),  // 49: runtime.VerifyClientCertificateRequest
+	(*VerifyClientCertificateReply)(nil),    // 50: runtime.VerifyClientCertificateReply
+	(*VerifyServerCertificateRequest)(nil),  // 51: runtime.VerifyServerCertificateRequest
+	(*VerifyServerCertificateReply)(nil),    // 52: runtime.VerifyServerCertificateReply
+	(*LogEntry)(nil),                        // 53: runtime.LogEntry
+	(*LogEntryBatch)(nil),                   // 54: runtime.LogEntryBatch
+	(*TraceSpans)(nil),                      // 55: runtime.TraceSpans
+	(*Span)(nil),                            // 56: runtime.Span
+	(*WeaveletArgs_Redirect)(nil),           // 57: runtime.WeaveletArgs.Redirect
+	nil,                                     // 58: runtime.InitWeaveletRequest.SectionsEntry
+	nil,                                     // 59: runtime.InitWeaveletRequest.ComponentInitTimeoutNanosEntry
+	nil,                                     // 60: runtime.MetricDef.LabelsEntry
+	nil,                                     // 61: runtime.MetricSnapshot.LabelsEntry
+	nil,                                     // 62: runtime.LoadReport.LoadsEntry
+	(*LoadReport_ComponentLoad)(nil),        // 63: runtime.LoadReport.ComponentLoad
+	(*LoadReport_SliceLoad)(nil),            // 64: runtime.LoadReport.SliceLoad
+	(*LoadReport_SubsliceLoad)(nil),         // 65: runtime.LoadReport.SubsliceLoad
+	nil,                                     // 66: runtime.RoutingInfo.LocalitiesEntry
+	(*Assignment_Slice)(nil),                // 67: runtime.Assignment.Slice
+	(*Span_Attribute)(nil),                  // 68: runtime.Span.Attribute
+	(*Span_Link)(nil),                       // 69: runtime.Span.Link
+	(*Span_Event)(nil),                      // 70: runtime.Span.Event
+	(*Span_Status)(nil),                     // 71: runtime.Span.Status
+	(*Span_Scope)(nil),                      // 72: runtime.Span.Scope
+	(*Span_Library)(nil),                    // 73: runtime.Span.Library
+	(*Span_Resource)(nil),                   // 74: runtime.Span.Resource
+	(*Span_Attribute_Value)(nil),            // 75: runtime.Span.Attribute.Value
+	(*Span_Attribute_Value_NumberList)(nil), // 76: runtime.Span.Attribute.Value.NumberList
+	(*Span_Attribute_Value_StringList)(nil), // 77: runtime.Span.Attribute.Value.StringList
+}
+var file_runtime_proto_depIdxs = []int32{
+	57, // 0: runtime.WeaveletArgs.redirects:type_name -> runtime.WeaveletArgs.Redirect
+	8,  // 1: runtime.WeaveletArgs.locality:type_name -> runtime.Locality
+	58, // 2: runtime.InitWeaveletRequest.sections:type_name -> runtime.InitWeaveletRequest.SectionsEntry
+	59, // 3: runtime.InitWeaveletRequest.component_init_timeout_nanos:type_name -> runtime.InitWeaveletRequest.ComponentInitTimeoutNanosEntry
+	11, // 4: runtime.InitWeaveletReply.version:type_name -> runtime.SemVer
+	0,  // 5: runtime.GetHealthReply.status:type_name -> runtime.HealthStatus
+	16, // 6: runtime.GetMetricsReply.update:type_name -> runtime.MetricUpdate
+	17, // 7: runtime.MetricUpdate.defs:type_name -> runtime.MetricDef
+	18, // 8: runtime.MetricUpdate.values:type_name -> runtime.MetricValue
+	1,  // 9: runtime.MetricDef.typ:type_name -> runtime.MetricType
+	60, // 10: runtime.MetricDef.labels:type_name -> runtime.MetricDef.LabelsEntry
+	1,  // 11: runtime.MetricSnapshot.typ:type_name -> runtime.MetricType
+	61, // 12: runtime.MetricSnapshot.labels:type_name -> runtime.MetricSnapshot.LabelsEntry
+	22, // 13: runtime.GetLoadReply.load:type_name -> runtime.LoadReport
+	62, // 14: runtime.LoadReport.loads:type_name -> runtime.LoadReport.LoadsEntry
+	2,  // 15: runtime.GetProfileRequest.profile_type:type_name -> runtime.ProfileType
+	27, // 16: runtime.GetFlightRecordReply.events:type_name -> runtime.CallEvent
+	33, // 17: runtime.UpdateRoutingInfoRequest.routing_info:type_name -> runtime.RoutingInfo
+	32, // 18: runtime.NotifyDeploymentEventRequest.event:type_name -> runtime.DeploymentEvent
+	3,  // 19: runtime.DeploymentEvent.kind:type_name -> runtime.DeploymentEvent.Kind
+	34, // 20: runtime.RoutingInfo.assignment:type_name -> runtime.Assignment
+	66, // 21: runtime.RoutingInfo.localities:type_name -> runtime.RoutingInfo.LocalitiesEntry
+	67, // 22: runtime.Assignment.slices:type_name -> runtime.Assignment.Slice
+	45, // 23: runtime.GetTopologyReply.topology:type_name -> runtime.Topology
+	46, // 24: runtime.Topology.edges:type_name -> runtime.TopologyEdge
+	53, // 25: runtime.LogEntryBatch.entries:type_name -> runtime.LogEntry
+	56, // 26: runtime.TraceSpans.span:type_name -> runtime.Span
+	4,  // 27: runtime.Span.kind:type_name -> runtime.Span.Kind
+	68, // 28: runtime.Span.attributes:type_name -> runtime.Span.Attribute
+	69, // 29: runtime.Span.links:type_name -> runtime.Span.Link
+	70, // 30: runtime.Span.events:type_name -> runtime.Span.Event
+	71, // 31: runtime.Span.status:type_name -> runtime.Span.Status
+	72, // 32: runtime.Span.scope:type_name -> runtime.Span.Scope
+	73, // 33: runtime.Span.library:type_name -> runtime.Span.Library
+	74, // 34: runtime.Span.resource:type_name -> runtime.Span.Resource
+	63, // 35: runtime.LoadReport.LoadsEntry.value:type_name -> runtime.LoadReport.ComponentLoad
+	64, // 36: runtime.LoadReport.ComponentLoad.load:type_name -> runtime.LoadReport.SliceLoad
+	65, // 37: runtime.LoadReport.SliceLoad.splits:type_name -> runtime.LoadReport.SubsliceLoad
+	8,  // 38: runtime.RoutingInfo.LocalitiesEntry.value:type_name -> runtime.Locality
+	75, // 39: runtime.Span.Attribute.value:type_name -> runtime.Span.Attribute.Value
+	68, // 40: runtime.Span.Link.attributes:type_name -> runtime.Span.Attribute
+	68, // 41: runtime.Span.Event.attributes:type_name -> runtime.Span.Attribute
+	6,  // 42: runtime.Span.Status.code:type_name -> runtime.Span.Status.Code
+	68, // 43: runtime.Span.Resource.attributes:type_name -> runtime.Span.Attribute
+	5,  // 44: runtime.Span.Attribute.Value.type:type_name -> runtime.Span.Attribute.Value.Type
+	76, // 45: runtime.Span.Attribute.Value.nums:type_name -> runtime.Span.Attribute.Value.NumberList
+	77, // 46: runtime.Span.Attribute.Value.strs:type_name -> runtime.Span.Attribute.Value.StringList
+	47, // [47:47] is the sub-list for method output_type
+	47, // [47:47] is the sub-list for method input_type
+	47, // [47:47] is the sub-list for extension type_name
+	47, // [47:47] is the sub-list for extension extendee
+	0,  // [0:47] is the sub-list for field type_name
+}
+
+func init() { file_runtime_proto_init() }
+func file_runtime_proto_init() {
+	if File_runtime_proto != nil {
 		return
 	}
 	if !protoimpl.UnsafeEnabled {
-		file_runtime_protos_runtime_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WeaveletArgs); i {
 			case 0:
 				return &v.state
@@ -4327,7 +5442,19 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Locality); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*InitWeaveletRequest); i {
 			case 0:
 				return &v.state
@@ -4339,7 +5466,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*InitWeaveletReply); i {
 			case 0:
 				return &v.state
@@ -4351,7 +5478,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[3].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*SemVer); i {
 			case 0:
 				return &v.state
@@ -4363,7 +5490,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[4].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetHealthRequest); i {
 			case 0:
 				return &v.state
@@ -4375,7 +5502,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[5].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetHealthReply); i {
 			case 0:
 				return &v.state
@@ -4387,7 +5514,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[6].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMetricsRequest); i {
 			case 0:
 				return &v.state
@@ -4399,7 +5526,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[7].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetMetricsReply); i {
 			case 0:
 				return &v.state
@@ -4411,7 +5538,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[8].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MetricUpdate); i {
 			case 0:
 				return &v.state
@@ -4423,7 +5550,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[9].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MetricDef); i {
 			case 0:
 				return &v.state
@@ -4435,7 +5562,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[10].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MetricValue); i {
 			case 0:
 				return &v.state
@@ -4447,7 +5574,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[11].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*MetricSnapshot); i {
 			case 0:
 				return &v.state
@@ -4459,7 +5586,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[12].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetLoadRequest); i {
 			case 0:
 				return &v.state
@@ -4471,7 +5598,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[13].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetLoadReply); i {
 			case 0:
 				return &v.state
@@ -4483,7 +5610,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[14].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LoadReport); i {
 			case 0:
 				return &v.state
@@ -4495,7 +5622,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[15].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetProfileRequest); i {
 			case 0:
 				return &v.state
@@ -4507,7 +5634,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[16].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetProfileReply); i {
 			case 0:
 				return &v.state
@@ -4519,7 +5646,43 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[17].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFlightRecordRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetFlightRecordReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*CallEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UpdateRoutingInfoRequest); i {
 			case 0:
 				return &v.state
@@ -4531,7 +5694,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[18].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UpdateRoutingInfoReply); i {
 			case 0:
 				return &v.state
@@ -4543,7 +5706,43 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[19].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NotifyDeploymentEventRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*NotifyDeploymentEventReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*DeploymentEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*RoutingInfo); i {
 			case 0:
 				return &v.state
@@ -4555,7 +5754,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[20].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Assignment); i {
 			case 0:
 				return &v.state
@@ -4567,7 +5766,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[21].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UpdateComponentsRequest); i {
 			case 0:
 				return &v.state
@@ -4579,7 +5778,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[22].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*UpdateComponentsReply); i {
 			case 0:
 				return &v.state
@@ -4591,7 +5790,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[23].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ActivateComponentRequest); i {
 			case 0:
 				return &v.state
@@ -4603,7 +5802,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[24].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ActivateComponentReply); i {
 			case 0:
 				return &v.state
@@ -4615,7 +5814,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[25].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetListenerAddressRequest); i {
 			case 0:
 				return &v.state
@@ -4627,7 +5826,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[26].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetListenerAddressReply); i {
 			case 0:
 				return &v.state
@@ -4639,7 +5838,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[27].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ExportListenerRequest); i {
 			case 0:
 				return &v.state
@@ -4651,7 +5850,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[28].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*ExportListenerReply); i {
 			case 0:
 				return &v.state
@@ -4663,7 +5862,55 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[29].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTopologyRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetTopologyReply); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Topology); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*TopologyEdge); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_runtime_proto_msgTypes[40].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSelfCertificateRequest); i {
 			case 0:
 				return &v.state
@@ -4675,7 +5922,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[30].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[41].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*GetSelfCertificateReply); i {
 			case 0:
 				return &v.state
@@ -4687,7 +5934,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[31].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[42].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VerifyClientCertificateRequest); i {
 			case 0:
 				return &v.state
@@ -4699,7 +5946,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[32].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[43].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VerifyClientCertificateReply); i {
 			case 0:
 				return &v.state
@@ -4711,7 +5958,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[33].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VerifyServerCertificateRequest); i {
 			case 0:
 				return &v.state
@@ -4723,7 +5970,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[34].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*VerifyServerCertificateReply); i {
 			case 0:
 				return &v.state
@@ -4735,7 +5982,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[35].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LogEntry); i {
 			case 0:
 				return &v.state
@@ -4747,7 +5994,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[36].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LogEntryBatch); i {
 			case 0:
 				return &v.state
@@ -4759,7 +6006,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[37].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*TraceSpans); i {
 			case 0:
 				return &v.state
@@ -4771,7 +6018,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[38].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span); i {
 			case 0:
 				return &v.state
@@ -4783,7 +6030,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[39].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*WeaveletArgs_Redirect); i {
 			case 0:
 				return &v.state
@@ -4795,7 +6042,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[44].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LoadReport_ComponentLoad); i {
 			case 0:
 				return &v.state
@@ -4807,7 +6054,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[45].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LoadReport_SliceLoad); i {
 			case 0:
 				return &v.state
@@ -4819,7 +6066,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[46].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[58].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*LoadReport_SubsliceLoad); i {
 			case 0:
 				return &v.state
@@ -4831,7 +6078,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[47].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[60].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Assignment_Slice); i {
 			case 0:
 				return &v.state
@@ -4843,7 +6090,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[48].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[61].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Attribute); i {
 			case 0:
 				return &v.state
@@ -4855,7 +6102,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[49].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[62].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Link); i {
 			case 0:
 				return &v.state
@@ -4867,7 +6114,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[50].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[63].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Event); i {
 			case 0:
 				return &v.state
@@ -4879,7 +6126,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[51].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[64].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Status); i {
 			case 0:
 				return &v.state
@@ -4891,7 +6138,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[52].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[65].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Scope); i {
 			case 0:
 				return &v.state
@@ -4903,7 +6150,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[53].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[66].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Library); i {
 			case 0:
 				return &v.state
@@ -4915,7 +6162,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[54].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[67].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Resource); i {
 			case 0:
 				return &v.state
@@ -4927,7 +6174,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[55].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[68].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Attribute_Value); i {
 			case 0:
 				return &v.state
@@ -4939,7 +6186,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[56].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[69].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Attribute_Value_NumberList); i {
 			case 0:
 				return &v.state
@@ -4951,7 +6198,7 @@ func file_runtime_protos_runtime_proto_init() {
 				return nil
 			}
 		}
-		file_runtime_protos_runtime_proto_msgTypes[57].Exporter = func(v interface{}, i int) interface{} {
+		file_runtime_proto_msgTypes[70].Exporter = func(v interface{}, i int) interface{} {
 			switch v := v.(*Span_Attribute_Value_StringList); i {
 			case 0:
 				return &v.state
@@ -4964,7 +6211,7 @@ func file_runtime_protos_runtime_proto_init() {
 			}
 		}
 	}
-	file_runtime_protos_runtime_proto_msgTypes[55].OneofWrappers = []interface{}{
+	file_runtime_proto_msgTypes[68].OneofWrappers = []interface{}{
 		(*Span_Attribute_Value_Num)(nil),
 		(*Span_Attribute_Value_Str)(nil),
 		(*Span_Attribute_Value_Nums)(nil),
@@ -4974,19 +6221,19 @@ func file_runtime_protos_runtime_proto_init() {
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
-			RawDescriptor: file_runtime_protos_runtime_proto_rawDesc,
-			NumEnums:      6,
-			NumMessages:   58,
+			RawDescriptor: file_runtime_proto_rawDesc,
+			NumEnums:      7,
+			NumMessages:   71,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
-		GoTypes:           file_runtime_protos_runtime_proto_goTypes,
-		DependencyIndexes: file_runtime_protos_runtime_proto_depIdxs,
-		EnumInfos:         file_runtime_protos_runtime_proto_enumTypes,
-		MessageInfos:      file_runtime_protos_runtime_proto_msgTypes,
+		GoTypes:           file_runtime_proto_goTypes,
+		DependencyIndexes: file_runtime_proto_depIdxs,
+		EnumInfos:         file_runtime_proto_enumTypes,
+		MessageInfos:      file_runtime_proto_msgTypes,
 	}.Build()
-	File_runtime_protos_runtime_proto = out.File
-	file_runtime_protos_runtime_proto_rawDesc = nil
-	file_runtime_protos_runtime_proto_goTypes = nil
-	file_runtime_protos_runtime_proto_depIdxs = nil
+	File_runtime_proto = out.File
+	file_runtime_proto_rawDesc = nil
+	file_runtime_proto_goTypes = nil
+	file_runtime_proto_depIdxs = nil
 }
@@ -45,7 +45,7 @@ func TestDeployerVersion(t *testing.T) {
 	got := fmt.Sprintf("%x", h.Sum(nil))
 
 	// If runtime.proto has changed, the deployer API version may need updating.
-	const want = "0b686af008fb1239c69ce217fdb057972db62c67d83a20ef62b3398ebd47afcf"
+	const want = "2f3a5661d42731225cb59e41e1577a7a9b4bb2937625bca2bc209e14a08faaeb"
 	if got != want {
 		t.Fatalf(`Unexpected SHA-256 hash of runtime.proto: got %s, want %s. If this change is meaningful, REMEMBER TO UPDATE THE DEPLOYER API VERSION in runtime/version/version.go.`, got, want)
 	}
@@ -58,6 +58,8 @@ type fullEntry struct {
 	File          string            `json:"file"`
 	Line          int32             `json:"line"`
 	Msg           string            `json:"msg"`
+	TraceID       string            `json:"trace_id,omitempty"`
+	SpanID        string            `json:"span_id,omitempty"`
 	Attrs         map[string]string `json:"attrs"`
 }
 
@@ -91,6 +93,8 @@ Queries:
       * level          : the level of the log (e.g., debug, info)
       * source         : the file:line from which the log entry was logged
       * msg            : the logged message
+      * trace_id       : the id of the trace active when logging, if any
+      * span_id        : the id of the span active when logging, if any
       * attrs          : the user provided attributes
 
   Queries are boolean expressions over these fields. For example, the query
@@ -199,6 +203,8 @@ Query Reference:
       * level: string
       * source: string
       * msg: string
+      * trace_id: string
+      * span_id: string
       * attrs: map[string]string
 
   A query is restricted to:
@@ -307,6 +313,8 @@ func (s *LogsSpec) logFn(ctx context.Context, args []string) error {
 				File:          entry.File,
 				Line:          entry.Line,
 				Msg:           entry.Msg,
+				TraceID:       entry.TraceId,
+				SpanID:        entry.SpanId,
 				Attrs:         attrs,
 			}, "", "    ")
 			if err != nil {
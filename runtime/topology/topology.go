@@ -0,0 +1,166 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package topology assembles the live component graph of a Service Weaver
+// application, i.e., which components call which and how often, from the
+// method metrics exported by its processes.
+//
+// Unlike the static graph of component registrations, the live graph only
+// includes the calls that were actually made. A Tracker computes call rates by
+// comparing successive metric samples:
+//
+//	tracker := topology.NewTracker(time.Minute)
+//	...
+//	graph := tracker.Record(time.Now(), metrics.Snapshot())
+//
+// Deployers record the snapshots they collect from all the processes of a
+// deployment.
+package topology
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+)
+
+// DefaultWindow is the default period over which a Tracker computes rates.
+const DefaultWindow = time.Minute
+
+// sample is a set of cumulative edge counts taken at a point in time.
+type sample struct {
+	at    time.Time
+	edges map[imetrics.MethodKey]*imetrics.MethodCounts // by caller and component
+}
+
+// Tracker computes the component graph, with call rates, from successive
+// samples of method metrics. It is safe for concurrent use.
+type Tracker struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	samples []sample // previous samples, oldest first
+}
+
+// NewTracker returns a tracker that computes rates over the provided window.
+// If window is not positive, DefaultWindow is used.
+func NewTracker(window time.Duration) *Tracker {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Tracker{window: window}
+}
+
+// Record records the provided metric snapshots, taken at the provided time,
+// and returns the component graph. Rates are computed against the latest
+// previous sample that is at least a window old, or against the oldest
+// previous sample if none is. If there is no previous sample, the returned
+// graph has a zero window, no rates, and cumulative mean latencies.
+//
+// Snapshots of the same metric taken by different processes are summed, so
+// the snapshots should include every process of a deployment.
+func (t *Tracker) Record(now time.Time, snapshots []*metrics.MetricSnapshot) *protos.Topology {
+	cur := sample{at: now, edges: aggregate(snapshots)}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	base := 0
+	for i, s := range t.samples {
+		if now.Sub(s.at) >= t.window {
+			base = i
+		}
+	}
+	t.samples = t.samples[base:]
+	var prev *sample
+	if len(t.samples) > 0 && t.samples[0].at.Before(now) {
+		prev = &t.samples[0]
+	}
+	result := graph(prev, cur)
+
+	// Retain at most about a sample per second, for a one minute window, to
+	// bound the memory used by frequent callers.
+	if n := len(t.samples); n == 0 || now.Sub(t.samples[n-1].at) >= t.window/60 {
+		t.samples = append(t.samples, cur)
+	}
+	return result
+}
+
+// Graph returns the component graph for the provided metric snapshots, with
+// cumulative counts and mean latencies but no rates.
+func Graph(snapshots []*metrics.MetricSnapshot) *protos.Topology {
+	return graph(nil, sample{edges: aggregate(snapshots)})
+}
+
+// graph returns the component graph for the provided samples. prev may be nil.
+func graph(prev *sample, cur sample) *protos.Topology {
+	topology := &protos.Topology{}
+	var elapsed float64
+	if prev != nil {
+		elapsed = cur.at.Sub(prev.at).Seconds()
+		topology.WindowNanos = int64(cur.at.Sub(prev.at))
+	}
+
+	components := map[string]bool{}
+	for k, c := range cur.edges {
+		components[k.Caller] = true
+		components[k.Component] = true
+		edge := &protos.TopologyEdge{
+			Caller:    k.Caller,
+			Component: k.Component,
+			Calls:     int64(c.Calls),
+			Errors:    int64(c.Errors),
+		}
+		if c.LatencyCount > 0 {
+			edge.MeanLatencyMicros = c.LatencySum / c.LatencyCount
+		}
+		if prev != nil {
+			d := c.Sub(prev.edges[k])
+			edge.CallsPerSecond = d.Calls / elapsed
+			edge.ErrorsPerSecond = d.Errors / elapsed
+			edge.MeanLatencyMicros = 0
+			if d.LatencyCount > 0 {
+				edge.MeanLatencyMicros = d.LatencySum / d.LatencyCount
+			}
+		}
+		topology.Edges = append(topology.Edges, edge)
+	}
+	for component := range components {
+		topology.Components = append(topology.Components, component)
+	}
+	sort.Strings(topology.Components)
+	sort.Slice(topology.Edges, func(i, j int) bool {
+		a, b := topology.Edges[i], topology.Edges[j]
+		if a.Caller != b.Caller {
+			return a.Caller < b.Caller
+		}
+		return a.Component < b.Component
+	})
+	return topology
+}
+
+// aggregate sums the method metrics in the provided snapshots by edge.
+func aggregate(snapshots []*metrics.MetricSnapshot) map[imetrics.MethodKey]*imetrics.MethodCounts {
+	edges := imetrics.AggregateMethods(snapshots, func(k imetrics.MethodKey) imetrics.MethodKey {
+		return imetrics.MethodKey{Caller: k.Caller, Component: k.Component}
+	})
+	for k := range edges {
+		if k.Caller == "" {
+			delete(edges, k)
+		}
+	}
+	return edges
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package topology_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/control"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/ServiceWeaver/weaver/runtime/topology"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// snapshots returns method metric snapshots for the provided calls, errors,
+// and total latency (in microseconds) from caller to component, as exported
+// by one process.
+func snapshots(caller, component string, calls, errors, latency float64) []*metrics.MetricSnapshot {
+	labels := map[string]string{"caller": caller, "component": component, "method": "M"}
+	return []*metrics.MetricSnapshot{
+		{Name: "serviceweaver_method_count", Labels: labels, Value: calls},
+		{Name: "serviceweaver_method_error_count", Labels: labels, Value: errors},
+		{
+			Name:   "serviceweaver_method_latency_micros",
+			Labels: labels,
+			Value:  latency,
+			Bounds: []float64{10},
+			Counts: []uint64{uint64(calls) / 2, uint64(calls) - uint64(calls)/2},
+		},
+	}
+}
+
+func TestGraph(t *testing.T) {
+	var ms []*metrics.MetricSnapshot
+	ms = append(ms, snapshots("main", "a/B", 10, 1, 1000)...)
+	ms = append(ms, snapshots("main", "a/B", 10, 1, 1000)...) // another process
+	ms = append(ms, snapshots("a/B", "a/C", 4, 0, 40)...)
+	ms = append(ms, snapshots(control.WeaveletPath, "a/B", 7, 0, 7)...)
+	ms = append(ms, &metrics.MetricSnapshot{Name: "other", Labels: map[string]string{"caller": "x", "component": "y"}, Value: 1})
+
+	want := &protos.Topology{
+		Components: []string{"a/B", "a/C", "main"},
+		Edges: []*protos.TopologyEdge{
+			{Caller: "a/B", Component: "a/C", Calls: 4, MeanLatencyMicros: 10},
+			{Caller: "main", Component: "a/B", Calls: 20, Errors: 2, MeanLatencyMicros: 100},
+		},
+	}
+	if diff := cmp.Diff(want, topology.Graph(ms), protocmp.Transform()); diff != "" {
+		t.Fatalf("Graph (-want +got):\n%s", diff)
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := topology.NewTracker(time.Minute)
+	start := time.Now()
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+
+	// Without an earlier sample, there are no rates.
+	got := tracker.Record(at(0), snapshots("main", "a/B", 10, 0, 100))
+	if got.WindowNanos != 0 || got.Edges[0].CallsPerSecond != 0 || got.Edges[0].MeanLatencyMicros != 10 {
+		t.Fatalf("first sample: got %v", got)
+	}
+
+	// Rates are computed against the first sample until it is a window old.
+	got = tracker.Record(at(30*time.Second), snapshots("main", "a/B", 40, 3, 400))
+	want := &protos.TopologyEdge{Caller: "main", Component: "a/B", Calls: 40, Errors: 3, CallsPerSecond: 1, ErrorsPerSecond: 0.1, MeanLatencyMicros: 10}
+	if diff := cmp.Diff(want, got.Edges[0], protocmp.Transform()); diff != "" {
+		t.Fatalf("second sample (-want +got):\n%s", diff)
+	}
+	if got.WindowNanos != int64(30*time.Second) {
+		t.Fatalf("second sample: got window %v, want 30s", time.Duration(got.WindowNanos))
+	}
+
+	// Then, against the latest sample that is at least a window old.
+	got = tracker.Record(at(90*time.Second), snapshots("main", "a/B", 100, 3, 1600))
+	want = &protos.TopologyEdge{Caller: "main", Component: "a/B", Calls: 100, Errors: 3, CallsPerSecond: 1, MeanLatencyMicros: 20}
+	if diff := cmp.Diff(want, got.Edges[0], protocmp.Transform()); diff != "" {
+		t.Fatalf("third sample (-want +got):\n%s", diff)
+	}
+	if got.WindowNanos != int64(time.Minute) {
+		t.Fatalf("third sample: got window %v, want 1m", time.Duration(got.WindowNanos))
+	}
+
+	// A counter that decreased restarted from zero.
+	got = tracker.Record(at(150*time.Second), snapshots("main", "a/B", 30, 0, 300))
+	if r := got.Edges[0].CallsPerSecond; r != 0.5 {
+		t.Fatalf("after restart: got %v calls/s, want 0.5", r)
+	}
+}
@@ -29,11 +29,9 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/ServiceWeaver/weaver/internal/sqlitedb"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
-	"github.com/ServiceWeaver/weaver/runtime/retry"
 	"google.golang.org/protobuf/proto"
-	"modernc.org/sqlite"
-	sqlite3 "modernc.org/sqlite/lib"
 )
 
 // DB is a trace database that stores traces on the local file system.
@@ -53,16 +51,10 @@ func OpenDB(ctx context.Context, fname string) (*DB, error) {
 		return nil, err
 	}
 
-	// The DB may be opened by multiple writers. Turn on appropriate
-	// concurrency control options. See:
-	//   https://www.sqlite.org/pragma.html#pragma_locking_mode
-	//   https://www.sqlite.org/pragma.html#pragma_busy_timeout
-	const params = "?_locking_mode=NORMAL&_busy_timeout=10000"
-	db, err := sql.Open("sqlite", fname+params)
+	db, err := sqlitedb.Open(fname)
 	if err != nil {
 		return nil, fmt.Errorf("open perfetto db %q: %w", fname, err)
 	}
-	db.SetMaxOpenConns(1)
 
 	t := &DB{
 		fname: fname,
@@ -108,7 +100,7 @@ BEGIN
 	WHERE start_time_unix_us < (1000000 * unixepoch('now', '-30 days'));
 END;
 `
-	if _, err := t.execDB(ctx, initDB); err != nil {
+	if _, err := sqlitedb.Exec(ctx, t.db, initDB); err != nil {
 		return nil, fmt.Errorf("open trace DB %s: %w", fname, err)
 	}
 
@@ -207,7 +199,7 @@ LIMIT ?
 	if limit <= 0 {
 		limit = math.MaxInt64
 	}
-	rows, err := d.queryDB(ctx, query, app, app, version, version, startTimeUs, startTimeUs, endTimeUs, endTimeUs, durationLowerUs, durationLowerUs, durationUpperUs, durationUpperUs, onlyErrors, limit)
+	rows, err := sqlitedb.Query(ctx, d.db, query, app, app, version, version, startTimeUs, startTimeUs, endTimeUs, endTimeUs, durationLowerUs, durationLowerUs, durationUpperUs, durationUpperUs, onlyErrors, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -239,7 +231,7 @@ LIMIT ?
 // FetchSpans returns all of the spans that have a given trace id.
 func (d *DB) FetchSpans(ctx context.Context, traceID string) ([]*protos.Span, error) {
 	const query = `SELECT data FROM encoded_spans WHERE trace_id=?`
-	rows, err := d.queryDB(ctx, query, traceID)
+	rows, err := sqlitedb.Query(ctx, d.db, query, traceID)
 	if err != nil {
 		return nil, err
 	}
@@ -262,37 +254,6 @@ func (d *DB) FetchSpans(ctx context.Context, traceID string) ([]*protos.Span, er
 	return spans, nil
 }
 
-func (d *DB) queryDB(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
-	// Keep retrying as long as we are getting the "locked" error.
-	for r := retry.Begin(); r.Continue(ctx); {
-		rows, err := d.db.QueryContext(ctx, query, args...)
-		if isLocked(err) {
-			continue
-		}
-		return rows, err
-	}
-	return nil, ctx.Err()
-}
-
-func (d *DB) execDB(ctx context.Context, query string, args ...any) (sql.Result, error) {
-	// Keep retrying as long as we are getting the "locked" error.
-	for r := retry.Begin(); r.Continue(ctx); {
-		res, err := d.db.ExecContext(ctx, query, args...)
-		if isLocked(err) {
-			continue
-		}
-		return res, err
-	}
-	return nil, ctx.Err()
-}
-
-// isLocked returns whether the error is a "database is locked" error.
-func isLocked(err error) bool {
-	sqlError := &sqlite.Error{}
-	ok := errors.As(err, &sqlError)
-	return ok && (sqlError.Code() == sqlite3.SQLITE_BUSY || sqlError.Code() == sqlite3.SQLITE_LOCKED)
-}
-
 // isRootSpan returns true iff the given span is a root span.
 func isRootSpan(span *protos.Span) bool {
 	var nilSpanID [8]byte
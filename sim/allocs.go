@@ -0,0 +1,241 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// Within an execution, only one goroutine runs at a time: an op or a
+// component method call runs until it hands control back to the executor by
+// taking a step, and resumes when the executor hands control back to it (see
+// executor.step). When allocation tracking is enabled, the executor reads the
+// runtime's allocation statistics every time control changes hands and charges
+// the difference to the op or method call that was running. Allocations made
+// while the executor takes a step are charged to nobody. Other overheads of
+// the simulator, like recording the history, are charged to the op or method
+// call that caused them, which is fine for comparing runs.
+
+// AllocStats are the allocations of an op or component method.
+type AllocStats struct {
+	Calls      int64 `json:"calls"`       // number of invocations
+	Bytes      int64 `json:"bytes"`       // bytes allocated
+	Objects    int64 `json:"objects"`     // objects allocated
+	HeapGrowth int64 `json:"heap_growth"` // growth of live heap bytes, possibly negative
+}
+
+// BytesPerCall returns the average number of bytes allocated per invocation.
+func (a AllocStats) BytesPerCall() float64 {
+	if a.Calls == 0 {
+		return 0
+	}
+	return float64(a.Bytes) / float64(a.Calls)
+}
+
+// AllocProfile records the allocations of every op and component method
+// during a simulation.
+type AllocProfile struct {
+	Ops     map[string]AllocStats `json:"ops"`     // by op name
+	Methods map[string]AllocStats `json:"methods"` // by "<component>.<method>"
+}
+
+// AllocRegression is an op or method whose bytes allocated per invocation
+// grew, relative to a baseline.
+type AllocRegression struct {
+	Name     string  // "op <op>" or "method <component>.<method>"
+	Baseline float64 // bytes per invocation in the baseline
+	Current  float64 // bytes per invocation in the current run
+}
+
+func (r AllocRegression) String() string {
+	return fmt.Sprintf("%s: %.0f -> %.0f bytes per call (%+.1f%%)", r.Name, r.Baseline, r.Current, 100*(r.Current-r.Baseline)/r.Baseline)
+}
+
+// Regressions returns the ops and methods in p whose bytes allocated per
+// invocation exceed those in baseline by more than the provided fraction,
+// sorted by name. Ops and methods missing from the baseline are ignored.
+func (p AllocProfile) Regressions(baseline AllocProfile, slack float64) []AllocRegression {
+	var regressions []AllocRegression
+	compare := func(prefix string, current, base map[string]AllocStats) {
+		for name, stats := range current {
+			b, ok := base[name]
+			if !ok || b.Calls == 0 || stats.Calls == 0 {
+				continue
+			}
+			if stats.BytesPerCall() > b.BytesPerCall()*(1+slack) {
+				regressions = append(regressions, AllocRegression{prefix + name, b.BytesPerCall(), stats.BytesPerCall()})
+			}
+		}
+	}
+	compare("op ", p.Ops, baseline.Ops)
+	compare("method ", p.Methods, baseline.Methods)
+	sort.Slice(regressions, func(i, j int) bool { return regressions[i].Name < regressions[j].Name })
+	return regressions
+}
+
+// reportAllocs populates the allocation profile of the provided results and
+// compares it against the baseline, if there is one.
+func (s *Simulator) reportAllocs(results *Results) {
+	if s.allocs == nil {
+		return
+	}
+	profile := s.allocs.profile()
+	results.Allocs = &profile
+	if s.opts.AllocBaseline == "" {
+		return
+	}
+
+	baseline, err := readAllocProfile(s.opts.AllocBaseline)
+	if err != nil {
+		s.t.Logf("Failed to read allocation baseline: %v", err)
+		return
+	}
+	if baseline == nil {
+		if err := writeAllocProfile(s.opts.AllocBaseline, profile); err != nil {
+			s.t.Logf("Failed to write allocation baseline: %v", err)
+			return
+		}
+		s.t.Logf("Allocation baseline written to %s.", s.opts.AllocBaseline)
+		return
+	}
+
+	slack := s.opts.AllocSlack
+	if slack == 0 {
+		slack = 0.1
+	}
+	results.AllocRegressions = profile.Regressions(*baseline, slack)
+	for _, r := range results.AllocRegressions {
+		s.t.Logf("Allocation regression in %v.", r)
+	}
+}
+
+// readAllocProfile reads the allocation profile stored in the provided file.
+// It returns nil if the file does not exist.
+func readAllocProfile(filename string) (*AllocProfile, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read allocation profile %q: %w", filename, err)
+	}
+	var p AllocProfile
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("unmarshal allocation profile %q: %w", filename, err)
+	}
+	return &p, nil
+}
+
+// writeAllocProfile writes an allocation profile to the provided file.
+func writeAllocProfile(filename string, p AllocProfile) error {
+	data, err := json.MarshalIndent(p, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshal allocation profile: %w", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("write allocation profile %q: %w", filename, err)
+	}
+	return nil
+}
+
+// allocTracker attributes allocations to ops and component method calls. An
+// allocTracker is shared by the executors of a simulator.
+//
+// An allocTracker reads runtime.MemStats rather than runtime/metrics. The
+// allocation metrics in runtime/metrics are only updated when a P's cache of
+// free objects is refilled, which is too coarse to attribute allocations to
+// individual ops and method calls. runtime.ReadMemStats flushes these caches.
+type allocTracker struct {
+	mu      sync.Mutex
+	stats   runtime.MemStats       // scratch space for runtime.ReadMemStats
+	last    AllocStats             // allocation totals at the last charge
+	owner   *AllocStats            // stats charged for allocations since last
+	ops     map[string]*AllocStats // by op name
+	methods map[string]*AllocStats // by component method
+}
+
+// newAllocTracker returns a new allocTracker.
+func newAllocTracker() *allocTracker {
+	return &allocTracker{
+		ops:     map[string]*AllocStats{},
+		methods: map[string]*AllocStats{},
+	}
+}
+
+// op returns the stats of the provided op and counts an invocation.
+func (a *allocTracker) op(name string) *AllocStats {
+	return a.invoke(a.ops, name)
+}
+
+// method returns the stats of the provided component method and counts an
+// invocation.
+func (a *allocTracker) method(component, method string) *AllocStats {
+	return a.invoke(a.methods, component+"."+method)
+}
+
+func (a *allocTracker) invoke(m map[string]*AllocStats, name string) *AllocStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	stats, ok := m[name]
+	if !ok {
+		stats = &AllocStats{}
+		m[name] = stats
+	}
+	stats.Calls++
+	return stats
+}
+
+// charge charges the allocations since the last call to charge to the
+// previous owner and makes the provided stats the new owner. A nil owner
+// charges allocations to nobody.
+func (a *allocTracker) charge(owner *AllocStats) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	runtime.ReadMemStats(&a.stats)
+	now := AllocStats{
+		Bytes:      int64(a.stats.TotalAlloc),
+		Objects:    int64(a.stats.Mallocs),
+		HeapGrowth: int64(a.stats.HeapAlloc),
+	}
+	if a.owner != nil {
+		a.owner.Bytes += now.Bytes - a.last.Bytes
+		a.owner.Objects += now.Objects - a.last.Objects
+		a.owner.HeapGrowth += now.HeapGrowth - a.last.HeapGrowth
+	}
+	a.last = now
+	a.owner = owner
+}
+
+// profile returns the allocations tracked so far.
+func (a *allocTracker) profile() AllocProfile {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	p := AllocProfile{
+		Ops:     make(map[string]AllocStats, len(a.ops)),
+		Methods: make(map[string]AllocStats, len(a.methods)),
+	}
+	for name, stats := range a.ops {
+		p.Ops[name] = *stats
+	}
+	for name, stats := range a.methods {
+		p.Methods[name] = *stats
+	}
+	return p
+}
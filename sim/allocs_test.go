@@ -0,0 +1,117 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAllocRegressions(t *testing.T) {
+	baseline := AllocProfile{
+		Ops: map[string]AllocStats{
+			"Same":   {Calls: 10, Bytes: 1000},
+			"Slower": {Calls: 10, Bytes: 1000},
+			"Faster": {Calls: 10, Bytes: 1000},
+		},
+		Methods: map[string]AllocStats{
+			"C.Slack": {Calls: 10, Bytes: 1000},
+		},
+	}
+	current := AllocProfile{
+		Ops: map[string]AllocStats{
+			"Same":   {Calls: 20, Bytes: 2000},
+			"Slower": {Calls: 5, Bytes: 1000},
+			"Faster": {Calls: 10, Bytes: 500},
+			"New":    {Calls: 10, Bytes: 100000},
+		},
+		Methods: map[string]AllocStats{
+			"C.Slack": {Calls: 10, Bytes: 1050},
+		},
+	}
+	got := current.Regressions(baseline, 0.1)
+	want := []AllocRegression{{Name: "op Slower", Baseline: 100, Current: 200}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Regressions (-want +got):\n%s", diff)
+	}
+}
+
+func TestTrackAllocs(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "allocs.json")
+	opts := Options{Seed: 1, MaxExecutions: 20, TrackAllocs: true, AllocBaseline: filename}
+
+	// The first run writes the baseline.
+	s := New(t, &divModWorkload{}, opts)
+	r := s.Run(time.Minute)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if r.Allocs == nil {
+		t.Fatal("Allocs: got nil, want profile")
+	}
+	for _, op := range []string{"Div", "DivMod", "Mod"} {
+		if stats := r.Allocs.Ops[op]; stats.Calls == 0 || stats.Bytes == 0 {
+			t.Errorf("op %s: got %+v, want calls and allocations", op, stats)
+		}
+	}
+	for name, stats := range r.Allocs.Methods {
+		if stats.Calls == 0 {
+			t.Errorf("method %s: got %+v, want calls", name, stats)
+		}
+	}
+	if len(r.Allocs.Methods) == 0 {
+		t.Error("Methods: got none, want component methods")
+	}
+	if _, err := os.Stat(filename); err != nil {
+		t.Fatalf("baseline not written: %v", err)
+	}
+
+	// A later run compares against the baseline. Shrink the baseline, so that
+	// every op regresses.
+	baseline, err := readAllocProfile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, stats := range baseline.Ops {
+		stats.Bytes = 1
+		baseline.Ops[name] = stats
+	}
+	if err := writeAllocProfile(filename, *baseline); err != nil {
+		t.Fatal(err)
+	}
+	s = New(t, &divModWorkload{}, opts)
+	r = s.Run(time.Minute)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	var got []string
+	for _, regression := range r.AllocRegressions {
+		got = append(got, regression.Name)
+	}
+	want := []string{"op Div", "op DivMod", "op Mod"}
+	for _, w := range want {
+		found := false
+		for _, g := range got {
+			found = found || g == w
+		}
+		if !found {
+			t.Errorf("AllocRegressions: got %v, want %s", got, w)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+// cacheWorkload puts, gets, and invalidates entries of the built-in Cache
+// component, and checks that a key that was invalidated is not read back from
+// any of the cache's shards.
+type cacheWorkload struct {
+	cache weaver.Ref[weaver.Cache]
+
+	mu   sync.Mutex
+	next int // the next key used by Invalidated
+}
+
+func (w *cacheWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Put", Range(0, 3), Range(0, 10))
+	r.RegisterGenerators("Get", Range(0, 3))
+	r.RegisterGenerators("Invalidated")
+	return nil
+}
+
+func (w *cacheWorkload) Put(ctx context.Context, key, value int) error {
+	w.cache.Get().Put(ctx, fmt.Sprint(key), []byte(fmt.Sprint(value)))
+	return nil
+}
+
+func (w *cacheWorkload) Get(ctx context.Context, key int) error {
+	w.cache.Get().Get(ctx, fmt.Sprint(key))
+	return nil
+}
+
+func (w *cacheWorkload) Invalidated(ctx context.Context) error {
+	// Use a key no other op uses, so that no op puts it concurrently.
+	w.mu.Lock()
+	key := fmt.Sprintf("invalidated/%d", w.next)
+	w.next++
+	w.mu.Unlock()
+
+	cache := w.cache.Get()
+	if err := cache.Put(ctx, key, []byte("stale")); err != nil {
+		return nil
+	}
+	if err := cache.Invalidate(ctx, key); err != nil {
+		return nil
+	}
+	value, ok, err := cache.Get(ctx, key)
+	if err != nil {
+		return nil
+	}
+	if ok {
+		return fmt.Errorf("Get(%q) after Invalidate: got %q, want miss", key, value)
+	}
+	return nil
+}
+
+func TestCacheSimulation(t *testing.T) {
+	// Calls for a key are delivered to random replicas of the cache shards,
+	// so the check fails unless invalidations reach every replica.
+	s := New(t, &cacheWorkload{}, Options{})
+	for seed := int64(0); seed < 50; seed++ {
+		result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+			Seed:        seed,
+			NumReplicas: 3,
+			NumOps:      20,
+			FailureRate: 0.05,
+			YieldRate:   0.5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.err != nil {
+			t.Fatalf("seed %d: %v", seed, result.err)
+		}
+	}
+}
+
+func TestCacheSimulationIsDeterministic(t *testing.T) {
+	s := New(t, &cacheWorkload{}, Options{})
+	params := hyperparameters{Seed: 7, NumReplicas: 3, NumOps: 20, FailureRate: 0.05, YieldRate: 0.5}
+	var histories [2][]Event
+	for i := range histories {
+		result, err := s.newExecutor().execute(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		histories[i] = result.history
+	}
+	if diff := cmp.Diff(histories[0], histories[1]); diff != "" {
+		t.Fatalf("histories differ (-first +second):\n%s", diff)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// # Checkpoints
+//
+// A simulator explores a deterministic sweep of executions. The i-th
+// execution of the sweep is determined entirely by the sweep's seed and i
+// (see sweep). A simulation's progress is therefore captured by the seed and
+// the index of the first execution that has not yet completed. A checkpoint
+// stores exactly that, along with some statistics.
+//
+// Executions run in parallel and may complete out of order. A checkpoint only
+// records a low watermark, so executions that completed after the first
+// incomplete one are executed again when the simulation is resumed. The same
+// is true of executions that were in flight when the simulation was paused:
+// their pending calls and replica states are not saved. Instead, because
+// executions are deterministic, they are re-executed from scratch.
+//
+// A sweep can be split into shards, each of which can run on a different
+// worker. Shard s of n runs executions s, s+n, s+2n, and so on.
+
+// The current version of the checkpoint format.
+const checkpointVersion = 1
+
+// checkpoint records the progress of a simulation.
+type checkpoint struct {
+	Version       int   `json:"version"`
+	Seed          int64 `json:"seed"`           // seed of the sweep
+	Shard         int   `json:"shard"`          // shard of the sweep
+	NumShards     int   `json:"num_shards"`     // number of shards
+	Next          int64 `json:"next"`           // first incomplete execution in the shard
+	NumExecutions int64 `json:"num_executions"` // executions completed so far
+	NumOps        int64 `json:"num_ops"`        // ops completed so far
+}
+
+// readCheckpoint reads the checkpoint stored in the provided file. It returns
+// nil if the file does not exist.
+func readCheckpoint(filename string) (*checkpoint, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read checkpoint %q: %w", filename, err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("unmarshal checkpoint %q: %w", filename, err)
+	}
+	if cp.Version != checkpointVersion {
+		return nil, fmt.Errorf("checkpoint %q: version %d, want %d", filename, cp.Version, checkpointVersion)
+	}
+	return &cp, nil
+}
+
+// writeCheckpoint atomically writes a checkpoint to the provided file.
+func writeCheckpoint(filename string, cp checkpoint) error {
+	cp.Version = checkpointVersion
+	data, err := json.MarshalIndent(cp, "", "    ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create checkpoint directory %q: %w", dir, err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(filename)+".tmp*")
+	if err != nil {
+		return fmt.Errorf("write checkpoint %q: %w", filename, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write checkpoint %q: %w", filename, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write checkpoint %q: %w", filename, err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("write checkpoint %q: %w", filename, err)
+	}
+	return nil
+}
+
+// Dimensions of the sweep. Executions sweep over these values, running
+// executionsPerSetting executions with every combination. Every sweep of all
+// combinations increments the number of ops per execution.
+var (
+	sweepReplicas     = []int{1, 2, 3}
+	sweepFailureRates = []float64{0.0, 0.01, 0.05, 0.1}
+	sweepYieldRates   = []float64{0.0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0}
+)
+
+const executionsPerSetting = 1000
+
+// sweep returns the hyperparameters of the i-th execution of the sweep with
+// the provided seed.
+//
+// TODO(mwhittaker): Use a smarter algorithm to sweep over hyperparameters.
+func sweep(seed, i int64) hyperparameters {
+	p := hyperparameters{Seed: seed + i + 1}
+	j := i / executionsPerSetting
+	p.YieldRate = sweepYieldRates[j%int64(len(sweepYieldRates))]
+	j /= int64(len(sweepYieldRates))
+	p.FailureRate = sweepFailureRates[j%int64(len(sweepFailureRates))]
+	j /= int64(len(sweepFailureRates))
+	p.NumReplicas = sweepReplicas[j%int64(len(sweepReplicas))]
+	j /= int64(len(sweepReplicas))
+	p.NumOps = int(j) + 1
+	return p
+}
+
+// progress tracks which executions of a shard have completed.
+type progress struct {
+	mu        sync.Mutex
+	next      int64              // every execution before next has completed
+	completed map[int64]struct{} // completed executions after next
+}
+
+// newProgress returns a progress in which every execution before next has
+// completed.
+func newProgress(next int64) *progress {
+	return &progress{next: next, completed: map[int64]struct{}{}}
+}
+
+// complete marks the i-th execution of the shard completed.
+func (p *progress) complete(i int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if i < p.next {
+		return
+	}
+	p.completed[i] = struct{}{}
+	for {
+		if _, ok := p.completed[p.next]; !ok {
+			break
+		}
+		delete(p.completed, p.next)
+		p.next++
+	}
+}
+
+// low returns the first execution of the shard that has not completed.
+func (p *progress) low() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.next
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSweep(t *testing.T) {
+	// Check that sweep enumerates hyperparameters in the same order as a
+	// series of nested loops.
+	const seed = 100
+	var i int64
+	for numOps := 1; numOps <= 2; numOps++ {
+		for _, numReplicas := range sweepReplicas {
+			for _, failureRate := range sweepFailureRates {
+				for _, yieldRate := range sweepYieldRates {
+					for j := 0; j < executionsPerSetting; j++ {
+						want := hyperparameters{
+							Seed:        seed + i + 1,
+							NumOps:      numOps,
+							NumReplicas: numReplicas,
+							FailureRate: failureRate,
+							YieldRate:   yieldRate,
+						}
+						if got := sweep(seed, i); got != want {
+							t.Fatalf("sweep(%d, %d): got %+v, want %+v", seed, i, got, want)
+						}
+						i++
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestProgress(t *testing.T) {
+	p := newProgress(10)
+	for _, test := range []struct {
+		complete int64
+		want     int64
+	}{
+		{12, 10},
+		{5, 10}, // already completed
+		{10, 11},
+		{11, 13},
+		{14, 13},
+		{13, 15},
+	} {
+		p.complete(test.complete)
+		if got := p.low(); got != test.want {
+			t.Fatalf("complete(%d): got low %d, want %d", test.complete, got, test.want)
+		}
+	}
+}
+
+func TestCheckpointReadWrite(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "dir", "checkpoint.json")
+	if cp, err := readCheckpoint(filename); err != nil || cp != nil {
+		t.Fatalf("readCheckpoint(missing): got %v, %v; want nil, nil", cp, err)
+	}
+	want := checkpoint{Seed: 42, Shard: 1, NumShards: 3, Next: 100, NumExecutions: 120, NumOps: 1000}
+	if err := writeCheckpoint(filename, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readCheckpoint(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want.Version = checkpointVersion
+	if diff := cmp.Diff(want, *got); diff != "" {
+		t.Fatalf("readCheckpoint (-want +got):\n%s", diff)
+	}
+
+	if err := os.WriteFile(filename, []byte(`{"version": 999}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(filename); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Fatalf("readCheckpoint(bad version): got %v, want version error", err)
+	}
+}
+
+func TestCheckpointResume(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "checkpoint.json")
+	opts := Options{Seed: 1, MaxExecutions: 50, Checkpoint: filename, Shard: 1, NumShards: 2}
+	for i := 1; i <= 3; i++ {
+		s := New(t, &divModWorkload{}, opts)
+		if r := s.Run(time.Minute); r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		cp, err := readCheckpoint(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := checkpoint{
+			Version:   checkpointVersion,
+			Seed:      1,
+			Shard:     1,
+			NumShards: 2,
+			Next:      int64(50 * i),
+		}
+		got := *cp
+		got.NumExecutions, got.NumOps = 0, 0
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Fatalf("run %d: checkpoint (-want +got):\n%s", i, diff)
+		}
+		if cp.NumExecutions < int64(50*i) {
+			t.Fatalf("run %d: NumExecutions: got %d, want >= %d", i, cp.NumExecutions, 50*i)
+		}
+	}
+}
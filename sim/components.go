@@ -16,7 +16,9 @@ package sim
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"sync"
 
 	"github.com/ServiceWeaver/weaver"
 )
@@ -58,6 +60,60 @@ type panicker interface {
 	Panic(context.Context, bool) error
 }
 
+type relay interface {
+	// Caller returns the caller identity observed by the whoami component
+	// when called by the relay.
+	Caller(context.Context) (string, error)
+}
+
+type whoami interface {
+	// WhoAmI returns the full name of the calling component.
+	WhoAmI(context.Context) (string, error)
+}
+
+type locator interface {
+	// Locate returns the colocation group and the index of the replica that
+	// handles the call.
+	Locate(context.Context) (string, int, error)
+}
+
+type limiter interface {
+	// Limit returns the replica's current limit.
+	Limit(context.Context) (int, error)
+}
+
+type tally interface {
+	// Add adds n to the replica's copy of a replicated counter.
+	Add(context.Context, int) error
+
+	// Value returns the replica's value of the counter.
+	Value(context.Context) (int64, error)
+
+	// Sync gossips the replica's counter with another replica.
+	Sync(context.Context) error
+
+	// Exchange merges a peer's counter and returns the replica's counter.
+	Exchange(context.Context, []byte) ([]byte, error)
+}
+
+// checking and savings are accounts that participate in two-phase commit
+// transactions, whose ops add an amount to the account's balance.
+type checking interface {
+	Prepare(ctx context.Context, tx string, op []byte) error
+	Commit(ctx context.Context, tx string) error
+	Abort(ctx context.Context, tx string) error
+	State(ctx context.Context, tx string) (weaver.TxState, error)
+	Balance(context.Context) (int, error)
+}
+
+type savings interface {
+	Prepare(ctx context.Context, tx string, op []byte) error
+	Commit(ctx context.Context, tx string) error
+	Abort(ctx context.Context, tx string) error
+	State(ctx context.Context, tx string) (weaver.TxState, error)
+	Balance(context.Context) (int, error)
+}
+
 // Component implementation structs.
 
 type divModImpl struct {
@@ -88,6 +144,58 @@ type panickerImpl struct {
 	weaver.Implements[panicker]
 }
 
+type relayImpl struct {
+	weaver.Implements[relay]
+	whoami weaver.Ref[whoami]
+}
+
+type whoamiImpl struct {
+	weaver.Implements[whoami]
+}
+
+type locatorImpl struct {
+	weaver.Implements[locator]
+}
+
+type limiterConfig struct {
+	Max int
+}
+
+type limiterImpl struct {
+	weaver.Implements[limiter]
+	weaver.WithConfig[limiterConfig]
+	limit int // the limit, as of the last config update
+}
+
+type tallyImpl struct {
+	weaver.Implements[tally]
+	self  weaver.Ref[tally]
+	count *weaver.CRDT[*weaver.PNCounter]
+}
+
+type checkingImpl struct {
+	weaver.Implements[checking]
+	account
+}
+
+type savingsImpl struct {
+	weaver.Implements[savings]
+	account
+}
+
+// account implements the checking and savings components.
+type account struct {
+	balance *balance
+	manager *weaver.TxResourceManager
+}
+
+// balance is the TxResource of an account.
+type balance struct {
+	mu        sync.Mutex
+	committed int            // the committed balance
+	pending   map[string]int // prepared amounts, by transaction
+}
+
 // Component implementations.
 
 func (i *divModImpl) DivMod(ctx context.Context, n, d int) (int, int, error) {
@@ -148,6 +256,18 @@ func (*panickerImpl) Panic(_ context.Context, b bool) error {
 	return nil
 }
 
+func (r *relayImpl) Caller(ctx context.Context) (string, error) {
+	return r.whoami.Get().WhoAmI(ctx)
+}
+
+func (*whoamiImpl) WhoAmI(ctx context.Context) (string, error) {
+	id, ok := weaver.CallerIdentity(ctx)
+	if !ok {
+		return "", fmt.Errorf("missing caller identity")
+	}
+	return id.Component, nil
+}
+
 // Errors.
 
 type zeroError struct {
@@ -157,3 +277,123 @@ type zeroError struct {
 func (zeroError) Error() string {
 	return "zero"
 }
+
+func (*locatorImpl) Locate(ctx context.Context) (string, int, error) {
+	r, ok := weaver.ReplicaInfo(ctx)
+	if !ok {
+		return "", 0, fmt.Errorf("missing replica info")
+	}
+	return r.Group, r.Index, nil
+}
+
+func (l *limiterImpl) Init(context.Context) error {
+	l.limit = l.Config().Max
+	return nil
+}
+
+func (l *limiterImpl) UpdateConfig(context.Context) error {
+	if l.Config().Max < 0 {
+		return fmt.Errorf("negative limit %d", l.Config().Max)
+	}
+	l.limit = l.Config().Max
+	return nil
+}
+
+func (l *limiterImpl) Limit(context.Context) (int, error) {
+	return l.limit, nil
+}
+
+func (t *tallyImpl) Init(ctx context.Context) error {
+	t.count = weaver.NewCRDT(ctx, weaver.NewPNCounter)
+	return nil
+}
+
+func (t *tallyImpl) Add(_ context.Context, n int) error {
+	return t.count.Update(func(c *weaver.PNCounter) error {
+		c.Add(int64(n))
+		return nil
+	})
+}
+
+func (t *tallyImpl) Value(context.Context) (int64, error) {
+	var v int64
+	err := t.count.Read(func(c *weaver.PNCounter) error {
+		v = c.Value()
+		return nil
+	})
+	return v, err
+}
+
+func (t *tallyImpl) Sync(ctx context.Context) error {
+	return t.count.Gossip(ctx, t.self.Get().Exchange)
+}
+
+func (t *tallyImpl) Exchange(_ context.Context, state []byte) ([]byte, error) {
+	return t.count.Exchange(state)
+}
+
+func (a *account) Init(context.Context) error {
+	a.balance = &balance{committed: initialBalance, pending: map[string]int{}}
+	var err error
+	a.manager, err = weaver.NewTxResourceManager(weaver.NewMemTxLog(), a.balance)
+	return err
+}
+
+func (a *account) Prepare(ctx context.Context, tx string, op []byte) error {
+	return a.manager.Prepare(ctx, tx, op)
+}
+
+func (a *account) Commit(ctx context.Context, tx string) error {
+	return a.manager.Commit(ctx, tx)
+}
+
+func (a *account) Abort(ctx context.Context, tx string) error {
+	return a.manager.Abort(ctx, tx)
+}
+
+func (a *account) State(_ context.Context, tx string) (weaver.TxState, error) {
+	return a.manager.State(tx), nil
+}
+
+func (a *account) Balance(context.Context) (int, error) {
+	a.balance.mu.Lock()
+	defer a.balance.mu.Unlock()
+	return a.balance.committed, nil
+}
+
+// initialBalance is the initial balance of an account.
+const initialBalance = 10
+
+// Prepare implements the weaver.TxResource interface. It votes to abort
+// withdrawals that could overdraw the account.
+func (b *balance) Prepare(tx string, op []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	amount := int(int64(binary.LittleEndian.Uint64(op)))
+	available := b.committed
+	for _, pending := range b.pending {
+		available += min(pending, 0)
+	}
+	if available+amount < 0 {
+		return fmt.Errorf("insufficient funds: %d < %d", available, -amount)
+	}
+	b.pending[tx] = amount
+	return nil
+}
+
+// Commit implements the weaver.TxResource interface.
+func (b *balance) Commit(tx string, _ []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.committed += b.pending[tx]
+	delete(b.pending, tx)
+	return nil
+}
+
+// Abort implements the weaver.TxResource interface.
+func (b *balance) Abort(tx string, _ []byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.pending, tx)
+	return nil
+}
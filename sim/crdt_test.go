@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// tallyWorkload adds to a CRDT counter replicated by the tally component while
+// its replicas gossip concurrently, and checks that merges never count an
+// addition twice.
+type tallyWorkload struct {
+	tally weaver.Ref[tally]
+
+	mu    sync.Mutex
+	added int64 // the sum of the additions attempted so far
+}
+
+func (w *tallyWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Add", Range(0, 10))
+	r.RegisterGenerators("Sync")
+	r.RegisterGenerators("Check")
+	return nil
+}
+
+func (w *tallyWorkload) Add(ctx context.Context, n int) error {
+	// An addition that fails may or may not have been applied, so count it
+	// as attempted before the call.
+	w.mu.Lock()
+	w.added += int64(n)
+	w.mu.Unlock()
+	w.tally.Get().Add(ctx, n)
+	return nil
+}
+
+func (w *tallyWorkload) Sync(ctx context.Context) error {
+	w.tally.Get().Sync(ctx)
+	return nil
+}
+
+func (w *tallyWorkload) Check(ctx context.Context) error {
+	v, err := w.tally.Get().Value(ctx)
+	if err != nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if v < 0 || v > w.added {
+		return fmt.Errorf("value %d not in range [0, %d]", v, w.added)
+	}
+	return nil
+}
+
+func TestCRDTSimulation(t *testing.T) {
+	// Run long executions, in which replicas gossip many times while
+	// additions and other gossip rounds are in flight.
+	s := New(t, &tallyWorkload{}, Options{})
+	for seed := int64(0); seed < 100; seed++ {
+		result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+			Seed:        seed,
+			NumReplicas: 3,
+			NumOps:      100,
+			FailureRate: 0.05,
+			YieldRate:   0.5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.err != nil {
+			t.Fatalf("seed %d: %v", seed, result.err)
+		}
+	}
+}
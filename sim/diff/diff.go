@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diff produces human-readable structural diffs for simulation
+// assertions.
+//
+// When an op or invariant detects that a value is not what it expected, it
+// can return the error produced by [Check]. The error reports exactly which
+// fields differ, rather than printing two large values side by side, and the
+// diff is included in the simulator's failure report.
+//
+//	func (w *Workload) Transfer(ctx context.Context, amount int) error {
+//		...
+//		return diff.Check("balances after transfer", want, got)
+//	}
+package diff
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// options are the options used to compare values. Unexported fields are
+// compared like exported ones, and protocol buffers are compared by value.
+var options = []cmp.Option{
+	cmp.Exporter(func(reflect.Type) bool { return true }),
+	protocmp.Transform(),
+}
+
+// Diff returns a human-readable report of the differences between want and
+// got, or the empty string if they are equal. Lines prefixed with "-" show
+// values from want, and lines prefixed with "+" show values from got.
+//
+// The format of the report is not stable and should not be parsed.
+func Diff(want, got any) string {
+	return cmp.Diff(want, got, options...)
+}
+
+// Equal returns whether want and got are structurally equal.
+func Equal(want, got any) bool {
+	return cmp.Equal(want, got, options...)
+}
+
+// Error is an assertion failure that carries a diff between an expected and
+// an actual value.
+type Error struct {
+	Msg  string // a description of the compared values
+	Diff string // the output of Diff(want, got)
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s mismatch (-want +got):\n%s", e.Msg, e.Diff)
+}
+
+// Check returns nil if want and got are structurally equal. Otherwise, it
+// returns an *Error, described by msg, with the diff between them.
+func Check(msg string, want, got any) error {
+	if d := Diff(want, got); d != "" {
+		return &Error{Msg: msg, Diff: d}
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diff_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/ServiceWeaver/weaver/sim/diff"
+)
+
+type account struct {
+	owner   string
+	balance int
+	history []int
+}
+
+func TestCheckEqual(t *testing.T) {
+	a := account{"alice", 10, []int{5, 5}}
+	b := account{"alice", 10, []int{5, 5}}
+	if err := diff.Check("account", a, b); err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if !diff.Equal(a, b) {
+		t.Fatalf("Equal(%v, %v): got false, want true", a, b)
+	}
+}
+
+func TestCheckUnequal(t *testing.T) {
+	want := map[string]account{
+		"alice": {"alice", 10, []int{5, 5}},
+		"bob":   {"bob", 0, nil},
+	}
+	got := map[string]account{
+		"alice": {"alice", 7, []int{5, 5, -3}},
+		"bob":   {"bob", 0, nil},
+	}
+	err := diff.Check("accounts", want, got)
+	var d *diff.Error
+	if !errors.As(err, &d) {
+		t.Fatalf("Check: got %v, want *diff.Error", err)
+	}
+	if d.Msg != "accounts" {
+		t.Errorf("Msg: got %q, want %q", d.Msg, "accounts")
+	}
+	// The diff should point at the differing fields.
+	for _, want := range []string{"balance", "10", "7", "-3"} {
+		if !strings.Contains(d.Diff, want) {
+			t.Errorf("Diff missing %q:\n%s", want, d.Diff)
+		}
+	}
+	if !strings.HasPrefix(err.Error(), "accounts mismatch (-want +got):\n") {
+		t.Errorf("Error: got %q", err.Error())
+	}
+}
+
+func TestDiffProtos(t *testing.T) {
+	want := &protos.Locality{Region: "us-east1", Zone: "us-east1-a"}
+	got := &protos.Locality{Region: "us-east1", Zone: "us-east1-b"}
+	if d := diff.Diff(want, want); d != "" {
+		t.Errorf("Diff(want, want): got %q, want empty", d)
+	}
+	if d := diff.Diff(want, got); !strings.Contains(d, "us-east1-b") {
+		t.Errorf("Diff(want, got) missing zone:\n%s", d)
+	}
+}
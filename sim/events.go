@@ -14,6 +14,8 @@
 
 package sim
 
+import "context"
+
 // An Event represents an atomic step of a execution.
 type Event interface {
 	isEvent()
@@ -31,6 +33,7 @@ type EventOpStart struct {
 type EventOpFinish struct {
 	TraceID int    // trace id
 	SpanID  int    // span id
+	Result  string // returned result value, if the op returns one
 	Error   string // returned error message
 }
 
@@ -38,6 +41,7 @@ type EventOpFinish struct {
 type EventCall struct {
 	TraceID   int      // trace id
 	SpanID    int      // span id
+	Parent    int      // span id of the calling op or method call
 	Caller    string   // calling component (or "op")
 	Replica   int      // calling component replica (or op number)
 	Component string   // component being called
@@ -84,6 +88,58 @@ type EventPanic struct {
 	Stack    string // stack trace
 }
 
+// EventSnapshot represents a snapshot of the state of a component replica,
+// taken after the replica returns from a method call. Snapshots are only
+// recorded for components that implement [Snapshotter].
+type EventSnapshot struct {
+	TraceID   int    // trace id
+	SpanID    int    // span id
+	Component string // component
+	Replica   int    // component replica
+	State     string // formatted result of the replica's Snapshot method
+}
+
+// EventUpdateConfig represents a config update being applied to a running
+// component replica. Config updates are not part of any op.
+type EventUpdateConfig struct {
+	Component string // component
+	Replica   int    // component replica
+	Config    string // the component's new config section, in TOML
+}
+
+// EventUpgrade represents a component replica being replaced by a replica of
+// the component's upgraded implementation. Upgrades are not part of any op.
+type EventUpgrade struct {
+	Component string // component
+	Replica   int    // component replica
+}
+
+// A Snapshotter is a component implementation whose state can be captured.
+// After a replica of a Snapshotter component returns from a method call, the
+// simulator records the result of its Snapshot method in the history as an
+// [EventSnapshot]. Snapshots let tools like "weaver sim debug" show the state
+// of every replica at any point in an execution.
+//
+// Snapshot is called on the replica's goroutine between method calls, so it
+// does not race with the replica's methods in the simulator.
+type Snapshotter interface {
+	Snapshot() any
+}
+
+// A ConfigUpdater is a component implementation that reloads its config while
+// running. When the simulator applies a config update registered with
+// [Registrar.RegisterConfigUpdate] to a replica, it replaces the replica's
+// weaver.WithConfig config with the new one and then, if the replica is a
+// ConfigUpdater, calls its UpdateConfig method. If UpdateConfig returns a
+// non-nil error, the execution fails.
+//
+// Config updates can be applied while the replica has method calls in
+// flight, so a method can observe its replica's config change between two of
+// the calls it makes. UpdateConfig must not call component methods.
+type ConfigUpdater interface {
+	UpdateConfig(context.Context) error
+}
+
 func (EventOpStart) isEvent()       {}
 func (EventOpFinish) isEvent()      {}
 func (EventCall) isEvent()          {}
@@ -92,6 +148,9 @@ func (EventReturn) isEvent()        {}
 func (EventDeliverReturn) isEvent() {}
 func (EventDeliverError) isEvent()  {}
 func (EventPanic) isEvent()         {}
+func (EventSnapshot) isEvent()      {}
+func (EventUpdateConfig) isEvent()  {}
+func (EventUpgrade) isEvent()       {}
 
 var _ Event = EventOpStart{}
 var _ Event = EventOpFinish{}
@@ -101,3 +160,6 @@ var _ Event = EventReturn{}
 var _ Event = EventDeliverReturn{}
 var _ Event = EventDeliverError{}
 var _ Event = EventPanic{}
+var _ Event = EventSnapshot{}
+var _ Event = EventUpdateConfig{}
+var _ Event = EventUpgrade{}
@@ -16,12 +16,17 @@ package sim
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"log/slog"
 	"math/rand"
 	"net"
 	"reflect"
 	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
 	"sync"
 	"testing"
 
@@ -29,8 +34,10 @@ import (
 	"github.com/ServiceWeaver/weaver/internal/weaver"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -76,6 +83,7 @@ type generator func(*rand.Rand) reflect.Value
 type op struct {
 	m          reflect.Method // the op itself
 	generators []generator    // the op's generators
+	tags       []string       // the op's tags
 }
 
 // An executor deterministically executes a Service Weaver application. An
@@ -93,11 +101,21 @@ type executor struct {
 	regsByIntf map[reflect.Type]*codegen.Registration // registrations, by component interface
 	info       componentInfo                          // component information
 	config     *protos.AppConfig                      // application config
+	log        func(*protos.LogEntry)                 // logs component log entries
+	formatter  Formatter                              // formats recorded values
+	allocs     *allocTracker                          // allocations, if tracked
+	queueStats *queueTracker                          // queueing statistics, if tracked
+	timer      *stepTimer                             // times steps, if profiled
+	scheduler  Scheduler                              // picks steps, if not random
+	opFilter   func(string, []string) bool            // selects ops, if not all
+	streams    map[string]*rand.Rand                  // random number generators by resource, if not shared
 
 	registrar  *registrar       // registrar
 	params     hyperparameters  // hyperparameters
 	workload   reflect.Value    // workload instance
-	ops        []*op            // registered ops
+	ops        []*op            // registered ops, or the ones selected by opFilter
+	selected   []*op            // ops selected by opFilter
+	scenario   []*scenarioOp    // scenario ops, if any
 	components map[string][]any // component replicas
 
 	ctx   context.Context // execution context
@@ -106,13 +124,25 @@ type executor struct {
 	mu          sync.Mutex       // guards the following fields
 	rand        *rand.Rand       // random number generator
 	current     int              // currently running op
+	nextOp      *op              // the next op to start, if picked by a scheduler
 	numStarted  int              // number of started ops
 	notFinished ints             // not finished op trace ids, optimized for removal and sampling
 	calls       map[int][]*call  // pending calls, by trace id
 	replies     map[int][]*reply // pending replies, by trace id
+	updates     []*configUpdate  // pending config updates
+	upgrades    []*upgrade       // pending replica upgrades
 	history     []Event          // history of events
+	results     []OpResult       // results of successfully finished ops
+	started     []ScenarioOp     // started ops, with their arguments, by trace id
+	deployment  string           // deployment id of the current execution
 	nextTraceID int              // next trace id
 	nextSpanID  int              // next span id
+	steps       int64            // number of steps taken
+	queues      queues           // queueing statistics of the current execution
+
+	// Allocations of running ops and method calls, by span id, if
+	// allocations are tracked. Guarded by mu.
+	owners map[int]*AllocStats
 }
 
 // result is the result of an execution.
@@ -120,6 +150,7 @@ type result struct {
 	params  hyperparameters // input hyperparameters
 	err     error           // first non-nil error returned by an op
 	history []Event         // a history of the execution, if err is not nil
+	ops     []ScenarioOp    // the started ops, with their arguments
 }
 
 // fate dictates if and how a call should fail.
@@ -146,10 +177,13 @@ type call struct {
 	traceID   int
 	spanID    int
 	fate      fate            // whether to fail the operation
+	caller    codegen.Caller  // the calling component, if any
 	component reflect.Type    // the component being called
 	method    string          // the method being called
 	args      []reflect.Value // the call's arguments
+	target    int             // the replica to deliver to, or -1 for any
 	reply     chan *reply     // a channel to receive the call's reply
+	queued    int64           // the step at which the call was made
 }
 
 // reply is a pending method reply.
@@ -158,6 +192,28 @@ type reply struct {
 	returns []reflect.Value // the call's return values
 }
 
+// configUpdate is a pending config update to a component replica.
+type configUpdate struct {
+	component string // component name
+	replica   int    // component replica
+	section   string // new config section
+}
+
+// upgrade is a pending upgrade of a component replica.
+type upgrade struct {
+	reg     *codegen.Registration // component
+	impl    reflect.Type          // upgraded implementation
+	replica int                   // component replica
+}
+
+// upgradeRate is the probability that a step upgrades a replica, if there is
+// a pending upgrade.
+const upgradeRate = 0.1
+
+// configUpdateRate is the probability that a step applies a pending config
+// update, if there is one.
+const configUpdateRate = 0.1
+
 // TODO(mwhittaker): If a user doesn't propagate contexts correctly, we lose
 // trace and span ids. Detect this and return an error.
 
@@ -175,9 +231,17 @@ type traceContext struct {
 	spanID  int
 }
 
-// withIDs returns a context embedded with the provided trace and span id.
+// withIDs returns a context embedded with the provided trace and span id. The
+// ids are also embedded as an OpenTelemetry span context, so that log entries
+// produced by components are annotated with them.
 func withIDs(ctx context.Context, traceID, spanID int) context.Context {
-	return context.WithValue(ctx, traceContextKey, traceContext{traceID, spanID})
+	ctx = context.WithValue(ctx, traceContextKey, traceContext{traceID, spanID})
+	var tid trace.TraceID
+	var sid trace.SpanID
+	binary.BigEndian.PutUint64(tid[8:], uint64(traceID))
+	binary.BigEndian.PutUint64(sid[:], uint64(spanID))
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: tid, SpanID: sid})
+	return trace.ContextWithSpanContext(ctx, sc)
 }
 
 // extractIDs returns the trace and span id embedded in the provided context.
@@ -193,7 +257,7 @@ func extractIDs(ctx context.Context) (int, int) {
 }
 
 // newExecutor returns a new executor.
-func newExecutor(t testing.TB, w reflect.Type, regsByIntf map[reflect.Type]*codegen.Registration, info componentInfo, app *protos.AppConfig) *executor {
+func newExecutor(t testing.TB, w reflect.Type, regsByIntf map[reflect.Type]*codegen.Registration, info componentInfo, app *protos.AppConfig, formatter Formatter) *executor {
 	registered := map[reflect.Type]struct{}{}
 	for intf := range regsByIntf {
 		registered[intf] = struct{}{}
@@ -203,11 +267,15 @@ func newExecutor(t testing.TB, w reflect.Type, regsByIntf map[reflect.Type]*code
 		regsByIntf: regsByIntf,
 		info:       info,
 		config:     app,
-		registrar:  newRegistrar(t, w, registered),
+		log:        logging.NewTestLogger(t, testing.Verbose()).Log,
+		formatter:  formatter,
+		registrar:  newRegistrar(t, w, registered, configValidator(regsByIntf)),
 		components: make(map[string][]any, len(regsByIntf)),
 		rand:       rand.New(&wyrand{0}),
 		calls:      map[int][]*call{},
 		replies:    map[int][]*reply{},
+		owners:     map[int]*AllocStats{},
+		queues:     newQueues(),
 	}
 }
 
@@ -229,6 +297,10 @@ func (e *executor) execute(ctx context.Context, params hyperparameters) (result,
 	if params.NumReplicas <= 0 {
 		return result{}, fmt.Errorf("NumReplicas (%d) <= 0", params.NumReplicas)
 	}
+	if e.scenario != nil {
+		// Run the ops of the scenario.
+		params.NumOps = len(e.scenario)
+	}
 	if params.NumOps <= 0 {
 		return result{}, fmt.Errorf("NumOps (%d) <= 0", params.NumOps)
 	}
@@ -254,28 +326,51 @@ func (e *executor) execute(ctx context.Context, params hyperparameters) (result,
 	// Reset the executor.
 	fakes := e.registrar.fakes
 	ops := e.registrar.ops
-	if err := e.reset(workload, fakes, ops, params); err != nil {
+	if e.opFilter != nil && e.scenario == nil {
+		e.selected = e.selected[:0]
+		for _, o := range ops {
+			if e.opFilter(o.m.Name, o.tags) {
+				e.selected = append(e.selected, o)
+			}
+		}
+		if len(e.selected) == 0 {
+			return result{}, fmt.Errorf("OpFilter selects none of the %d ops", len(ops))
+		}
+		ops = e.selected
+	}
+	updates := e.registrar.updates
+	upgrades := e.registrar.upgrades
+	if err := e.reset(workload, fakes, ops, updates, upgrades, params); err != nil {
 		return result{}, err
 	}
+	if e.scheduler != nil {
+		e.scheduler.Start(e.rand, params.NumOps)
+	}
 
 	// Perform the execution.
 	e.group, e.ctx = errgroup.WithContext(ctx)
+	e.timer.start()
 	e.step()
 	err := e.group.Wait()
+	e.timer.stop()
+	if e.queueStats != nil {
+		e.queueStats.merge(&e.queues)
+	}
 	if err != nil && err == ctx.Err() {
 		return result{}, err
 	}
-	return result{params, err, e.history}, nil
+	return result{params, err, e.history, e.started}, nil
 }
 
 // reset resets the state of an executor, preparing it for the next execution.
-func (e *executor) reset(workload Workload, fakes map[reflect.Type]any, ops []*op, params hyperparameters) error {
+func (e *executor) reset(workload Workload, fakes map[reflect.Type]any, ops []*op, updates []map[string]string, upgrades map[reflect.Type]reflect.Type, params hyperparameters) error {
 	e.workload = reflect.ValueOf(workload)
 	e.params = params
 	e.ops = ops
 	e.rand.Seed(params.Seed)
 	e.current = 1
 	e.numStarted = 0
+	e.nextOp = nil
 	e.notFinished.reset(1, 1+params.NumOps)
 	for k, v := range e.calls {
 		e.calls[k] = v[:0]
@@ -283,22 +378,30 @@ func (e *executor) reset(workload Workload, fakes map[reflect.Type]any, ops []*o
 	for k, v := range e.replies {
 		e.replies[k] = v[:0]
 	}
+	e.updates = e.updates[:0]
+	e.upgrades = e.upgrades[:0]
 	e.history = []Event{}
+	e.results = nil
+	e.started = nil
 	e.nextTraceID = 1
 	e.nextSpanID = 1
+	e.steps = 0
+	e.queues.reset()
+	clear(e.streams)
+	clear(e.owners)
 
 	// Pick a deterministic deployment ID.
 	depID, err := newUUID(e.rand)
 	if err != nil {
 		return err
 	}
-	weaverInfo := &weaver.WeaverInfo{
-		DeploymentID: depID.String(),
-	}
+	e.deployment = depID.String()
 
 	// Fill ref fields inside the workload struct.
 	if err := weaver.FillRefs(workload, func(t reflect.Type) (any, error) {
 		return e.getIntf(t, "op", 0)
+	}, func(t reflect.Type) ([]any, error) {
+		return e.getReplicas(t, "op", 0)
 	}); err != nil {
 		return err
 	}
@@ -315,65 +418,138 @@ func (e *executor) reset(workload Workload, fakes map[reflect.Type]any, ops []*o
 			continue
 		}
 
-		for i := 0; i < params.NumReplicas; i++ {
-			// Create the component implementation.
-			v := reflect.New(reg.Impl)
-			obj := v.Interface()
-
-			// Fill config.
-			if e.info.hasConfig[reg.Iface] {
-				if cfg := weaver.GetConfig(obj); cfg != nil {
-					if err := runtime.ParseConfigSection(reg.Name, "", e.config.Sections, cfg); err != nil {
-						return err
-					}
-				}
-			}
-
-			// Set logger.
-			//
-			// TODO(mwhittaker): Use custom logger.
-			if err := weaver.SetLogger(obj, slog.Default()); err != nil {
+		n := params.NumReplicas
+		if reg.Iface == reflect.TypeOf((*core.ReplicatedStateMachine)(nil)).Elem() {
+			// The replicas of a ReplicatedStateMachine communicate with
+			// each other directly, which the simulator doesn't support.
+			// A single replica behaves the same and is deterministic.
+			n = 1
+		}
+		for i := 0; i < n; i++ {
+			obj, err := e.newReplica(reg, reg.Impl, i)
+			if err != nil {
 				return err
 			}
+			components = append(components, obj)
+		}
+		e.components[reg.Name] = components
+	}
 
-			// Set application runtime information.
-			if err := weaver.SetWeaverInfo(obj, weaverInfo); err != nil {
-				return err
+	// Schedule config updates. Sections are validated when registered.
+	for _, sections := range updates {
+		for _, reg := range e.regsByIntf {
+			section, ok := sections[reg.Name]
+			if !ok {
+				continue
 			}
-
-			// Fill ref fields.
-			if e.info.hasRefs[reg.Iface] {
-				if err := weaver.FillRefs(obj, func(t reflect.Type) (any, error) {
-					return e.getIntf(t, reg.Name, i)
-				}); err != nil {
-					return err
-				}
+			if _, ok := fakes[reg.Iface]; ok {
+				continue
 			}
-
-			// Fill listener fields.
-			if e.info.hasListeners[reg.Iface] {
-				if err := weaver.FillListeners(obj, func(name string) (net.Listener, string, error) {
-					lis, err := net.Listen("tcp", ":0")
-					return lis, "", err
-				}); err != nil {
-					return err
-				}
+			for i := range e.components[reg.Name] {
+				e.updates = append(e.updates, &configUpdate{reg.Name, i, section})
 			}
+		}
+	}
+	// Map iteration order is random. Sort to keep executions deterministic.
+	sort.SliceStable(e.updates, func(i, j int) bool {
+		if e.updates[i].component != e.updates[j].component {
+			return e.updates[i].component < e.updates[j].component
+		}
+		return e.updates[i].replica < e.updates[j].replica
+	})
+
+	// Schedule upgrades.
+	for intf, impl := range upgrades {
+		reg := e.regsByIntf[intf]
+		for i := range e.components[reg.Name] {
+			e.upgrades = append(e.upgrades, &upgrade{reg, impl, i})
+		}
+	}
+	sort.Slice(e.upgrades, func(i, j int) bool {
+		if e.upgrades[i].reg.Name != e.upgrades[j].reg.Name {
+			return e.upgrades[i].reg.Name < e.upgrades[j].reg.Name
+		}
+		return e.upgrades[i].replica < e.upgrades[j].replica
+	})
+
+	return nil
+}
+
+// newReplica returns a new replica with the provided index of the provided
+// component, using the provided implementation type. The implementation is
+// either the component's registered implementation or an upgrade of it.
+func (e *executor) newReplica(reg *codegen.Registration, impl reflect.Type, i int) (any, error) {
+	// Create the component implementation.
+	v := reflect.New(impl)
+	obj := v.Interface()
+	hasConfig := e.info.hasConfig[reg.Iface]
+	hasRefs := e.info.hasRefs[reg.Iface]
+	hasListeners := e.info.hasListeners[reg.Iface]
+	if impl != reg.Impl {
+		// Information about upgrades is not cached.
+		hasConfig = weaver.HasConfig(obj)
+		hasRefs = weaver.HasRefs(obj)
+		hasListeners = weaver.HasListeners(obj)
+	}
 
-			// Call Init if available.
-			if i, ok := obj.(interface{ Init(context.Context) error }); ok {
-				// TODO(mwhittaker): Use better context.
-				if err := i.Init(context.Background()); err != nil {
-					return fmt.Errorf("component %q initialization failed: %w", reg.Name, err)
-				}
+	// Fill config.
+	if hasConfig {
+		if cfg := weaver.GetConfig(obj); cfg != nil {
+			if err := runtime.ParseConfigSection(reg.Name, "", e.config.Sections, cfg); err != nil {
+				return nil, err
 			}
+		}
+	}
 
-			components = append(components, obj)
+	// Set logger.
+	logger := slog.New(&logging.LogHandler{
+		Opts: logging.Options{
+			App:        e.config.Name,
+			Deployment: e.deployment,
+			Component:  reg.Name,
+			Weavelet:   strconv.Itoa(i),
+		},
+		Write: e.log,
+	})
+	if err := weaver.SetLogger(obj, logger); err != nil {
+		return nil, err
+	}
+
+	// Set application runtime information.
+	if err := weaver.SetWeaverInfo(obj, &weaver.WeaverInfo{DeploymentID: e.deployment}); err != nil {
+		return nil, err
+	}
+
+	// Fill ref fields.
+	if hasRefs {
+		if err := weaver.FillRefs(obj, func(t reflect.Type) (any, error) {
+			return e.getIntf(t, reg.Name, i)
+		}, func(t reflect.Type) ([]any, error) {
+			return e.getReplicas(t, reg.Name, i)
+		}); err != nil {
+			return nil, err
 		}
-		e.components[reg.Name] = components
 	}
 
-	return nil
+	// Fill listener fields.
+	if hasListeners {
+		if err := weaver.FillListeners(obj, func(name string) (net.Listener, string, error) {
+			lis, err := net.Listen("tcp", ":0")
+			return lis, "", err
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	// Call Init if available.
+	if x, ok := obj.(interface{ Init(context.Context) error }); ok {
+		// TODO(mwhittaker): Use better context.
+		ctx := weaver.WithReplicaInfo(context.Background(), e.replicaInfo(reg.Name, i))
+		if err := x.Init(ctx); err != nil {
+			return nil, fmt.Errorf("component %q initialization failed: %w", reg.Name, err)
+		}
+	}
+	return obj, nil
 }
 
 // getIntf returns a handle to the component of the provided type.
@@ -383,24 +559,49 @@ func (e *executor) getIntf(t reflect.Type, caller string, replica int) (any, err
 		return nil, fmt.Errorf("component %v not found", t)
 	}
 	call := func(method string, ctx context.Context, args []any, returns []any) error {
-		return e.call(caller, replica, reg, method, ctx, args, returns)
+		return e.call(caller, replica, reg, -1, method, ctx, args, returns)
 	}
 	return reg.ReflectStubFn(call), nil
 }
 
-// call executes a component method call against a random replica.
-func (e *executor) call(caller string, replica int, reg *codegen.Registration, method string, ctx context.Context, args []any, returns []any) error {
+// getReplicas returns one handle per replica of the component of the provided
+// type, each of which delivers its calls to its replica, as needed by
+// weaver.Broadcast.
+func (e *executor) getReplicas(t reflect.Type, caller string, replica int) ([]any, error) {
+	reg, ok := e.regsByIntf[t]
+	if !ok {
+		return nil, fmt.Errorf("component %v not found", t)
+	}
+	e.mu.Lock()
+	n := len(e.components[reg.Name])
+	e.mu.Unlock()
+	handles := make([]any, n)
+	for i := range handles {
+		target := i
+		call := func(method string, ctx context.Context, args []any, returns []any) error {
+			return e.call(caller, replica, reg, target, method, ctx, args, returns)
+		}
+		handles[i] = reg.ReflectStubFn(call)
+	}
+	return handles, nil
+}
+
+// call executes a component method call against the replica with index
+// target, or against a random replica if target is -1.
+func (e *executor) call(caller string, replica int, reg *codegen.Registration, target int, method string, ctx context.Context, args []any, returns []any) error {
 	// Convert the arguments to reflect.Values.
 	in := make([]reflect.Value, 1+len(args))
 	in[0] = reflect.ValueOf(ctx)
 	strings := make([]string, len(args))
+	e.timer.enter(stepFormat)
 	for i, arg := range args {
 		in[i+1] = reflect.ValueOf(arg)
-		strings[i] = fmt.Sprint(arg)
+		strings[i] = e.formatter.Format(arg)
 	}
+	e.timer.enter(stepSchedule)
 
-	// Extract the trace id.
-	traceID, _ := extractIDs(ctx)
+	// Extract the trace id and the span id of the caller.
+	traceID, parentID := extractIDs(ctx)
 	if traceID == 0 {
 		// TODO(mwhittaker): Link to online documentation with better
 		// explanation of this error.
@@ -415,33 +616,43 @@ func (e *executor) call(caller string, replica int, reg *codegen.Registration, m
 
 	// Determine the fate of the call.
 	fate := dontFail
-	if flip(e.rand, e.params.FailureRate) {
+	r := e.randFor(caller)
+	if flip(r, e.params.FailureRate) {
 		// TODO(mwhittaker): Have two parameters to control the rate of failing
 		// before and after delivery? This level of control might be
 		// unnecessary. For now, we pick between them equiprobably.
-		if flip(e.rand, 0.5) {
+		if flip(r, 0.5) {
 			fate = failBeforeDelivery
 		} else {
 			fate = failAfterDelivery
 		}
 	}
 
+	var from codegen.Caller
+	if caller != "op" {
+		from = codegen.Caller{Component: caller, Version: e.deployment}
+	}
 	e.calls[traceID] = append(e.calls[traceID], &call{
 		traceID:   traceID,
 		spanID:    spanID,
 		fate:      fate,
+		caller:    from,
 		component: reg.Iface,
 		method:    method,
 		args:      in,
+		target:    target,
 		reply:     reply,
+		queued:    e.steps,
 	})
+	e.queues.enqueue(reg.Name)
 
 	if caller == "op" {
 		replica = traceID
 	}
-	e.history = append(e.history, EventCall{
+	e.record(EventCall{
 		TraceID:   traceID,
 		SpanID:    spanID,
+		Parent:    parentID,
 		Caller:    caller,
 		Replica:   replica,
 		Component: reg.Name,
@@ -459,6 +670,8 @@ func (e *executor) call(caller string, replica int, reg *codegen.Registration, m
 	case <-e.ctx.Done():
 		return e.ctx.Err()
 	}
+	e.resume(parentID)
+	e.timer.enter(stepCall)
 
 	// Populate return values.
 	if len(returns) != len(out)-1 {
@@ -477,6 +690,11 @@ func (e *executor) call(caller string, replica int, reg *codegen.Registration, m
 
 // step performs one step of an execution.
 func (e *executor) step() {
+	if e.allocs != nil {
+		// Don't charge the executor's allocations to any op or method.
+		e.allocs.charge(nil)
+	}
+	e.timer.enter(stepSchedule)
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
@@ -489,12 +707,44 @@ func (e *executor) step() {
 		// The execution is finished.
 		return
 	}
+	e.steps++
+
+	if e.scheduler != nil {
+		e.schedule()
+		return
+	}
+
+	if len(e.updates) > 0 && flip(e.rand, configUpdateRate) {
+		// Apply a config update.
+		var update *configUpdate
+		update, e.updates = pop(e.rand, e.updates)
+		e.group.Go(func() error {
+			return e.updateConfig(update)
+		})
+		return
+	}
+
+	if len(e.upgrades) > 0 && flip(e.rand, upgradeRate) {
+		// Upgrade a replica.
+		var u *upgrade
+		u, e.upgrades = pop(e.rand, e.upgrades)
+		e.group.Go(func() error {
+			return e.upgrade(u)
+		})
+		return
+	}
 
 	if !e.notFinished.has(e.current) || flip(e.rand, e.params.YieldRate) {
 		// Yield execution to a (potentially) different op.
 		e.current = e.notFinished.pick(e.rand)
 	}
 
+	if e.current > e.numStarted && e.scenario != nil && !e.ready(e.numStarted+1) {
+		// The next op of the scenario must wait for the ops it depends on,
+		// which have started but not finished. Step one of them instead.
+		e.current = e.pickStarted()
+	}
+
 	if e.current > e.numStarted {
 		// Make sure to start ops in increasing order. Op 1 starts first, then
 		// Op 2, and so on.
@@ -502,7 +752,12 @@ func (e *executor) step() {
 		e.numStarted++
 
 		// Start the op.
-		o := pick(e.rand, e.ops)
+		var o *op
+		if e.scenario != nil {
+			o = e.scenario[e.current-1].op
+		} else {
+			o = pick(e.rand, e.ops)
+		}
 		e.group.Go(func() error {
 			return e.runOp(e.ctx, o)
 		})
@@ -532,49 +787,64 @@ func (e *executor) step() {
 	if deliverCall {
 		var call *call
 		call, e.calls[e.current] = pop(e.rand, e.calls[e.current])
-
-		if call.fate == failBeforeDelivery {
-			// Fail the call before delivering it.
-			e.history = append(e.history, EventDeliverError{
-				TraceID: call.traceID,
-				SpanID:  call.spanID,
-			})
-			call.reply <- &reply{
-				call:    call,
-				returns: returnError(call.component, call.method, core.RemoteCallError),
-			}
-			close(call.reply)
-			return
-		}
-
-		// Deliver the call.
-		e.group.Go(func() error {
-			return e.deliverCall(call)
-		})
+		e.deliver(call)
 	} else {
 		var reply *reply
 		reply, e.replies[e.current] = pop(e.rand, e.replies[e.current])
+		e.reply(reply)
+	}
+}
 
-		if reply.call.fate == failAfterDelivery {
-			// Fail the call after delivering it.
-			e.history = append(e.history, EventDeliverError{
-				TraceID: reply.call.traceID,
-				SpanID:  reply.call.spanID,
-			})
-			reply.returns = returnError(reply.call.component, reply.call.method, core.RemoteCallError)
-			reply.call.reply <- reply
-			close(reply.call.reply)
-			return
+// deliver delivers the provided call, which has been removed from e.calls.
+//
+// REQUIRES: e.mu is held.
+func (e *executor) deliver(call *call) {
+	e.queues.dequeue(e.regsByIntf[call.component].Name, e.steps-call.queued)
+
+	if call.fate == failBeforeDelivery {
+		// Fail the call before delivering it.
+		e.record(EventDeliverError{
+			TraceID: call.traceID,
+			SpanID:  call.spanID,
+		})
+		call.reply <- &reply{
+			call:    call,
+			returns: returnError(call.component, call.method, core.RemoteCallError),
 		}
+		close(call.reply)
+		return
+	}
+
+	// Deliver the call.
+	e.group.Go(func() error {
+		return e.deliverCall(call)
+	})
+}
 
-		// Return successfully.
-		e.history = append(e.history, EventDeliverReturn{
+// reply returns the provided reply, which has been removed from e.replies, to
+// its caller.
+//
+// REQUIRES: e.mu is held.
+func (e *executor) reply(reply *reply) {
+	if reply.call.fate == failAfterDelivery {
+		// Fail the call after delivering it.
+		e.record(EventDeliverError{
 			TraceID: reply.call.traceID,
 			SpanID:  reply.call.spanID,
 		})
+		reply.returns = returnError(reply.call.component, reply.call.method, core.RemoteCallError)
 		reply.call.reply <- reply
 		close(reply.call.reply)
+		return
 	}
+
+	// Return successfully.
+	e.record(EventDeliverReturn{
+		TraceID: reply.call.traceID,
+		SpanID:  reply.call.spanID,
+	})
+	reply.call.reply <- reply
+	close(reply.call.reply)
 }
 
 // runOp runs the provided operation.
@@ -584,7 +854,7 @@ func (e *executor) runOp(ctx context.Context, o *op) (err error) {
 		if x := recover(); x != nil {
 			err = fmt.Errorf("panic: %v", x)
 			e.mu.Lock()
-			e.history = append(e.history, EventPanic{
+			e.record(EventPanic{
 				TraceID:  traceID,
 				SpanID:   spanID,
 				Panicker: "op",
@@ -605,25 +875,45 @@ func (e *executor) runOp(ctx context.Context, o *op) (err error) {
 	} else {
 		args = make([]reflect.Value, n)
 	}
+	inputs := make([]any, len(o.generators))
 	formatted := make([]string, len(o.generators))
 
 	e.mu.Lock()
 	traceID, spanID = e.nextTraceID, e.nextSpanID
 	e.nextTraceID++
 	e.nextSpanID++
+	if e.allocs != nil {
+		owner := e.allocs.op(o.m.Name)
+		e.owners[spanID] = owner
+		e.allocs.charge(owner)
+	}
 
 	// Generate random op inputs. Lock s.mu because s.rand is not safe for
 	// concurrent use by multiple goroutines.
 	args[0] = e.workload
-	args[1] = reflect.ValueOf(withIDs(ctx, traceID, spanID))
+	args[1] = reflect.ValueOf(weaver.WithSerialCalls(withIDs(ctx, traceID, spanID)))
 	for i, generator := range o.generators {
-		x := generator(e.rand)
+		e.timer.enter(stepGenerate)
+		x := generator(e.randFor(opResource))
 		args[i+2] = x
-		formatted[i] = fmt.Sprint(x.Interface())
+		inputs[i] = x.Interface()
+		e.timer.enter(stepFormat)
+		formatted[i] = e.formatter.Format(inputs[i])
 	}
+	e.timer.enter(stepSchedule)
+
+	// Record the op and its arguments, so that shrinking can rerun it. Trace
+	// ids are assigned in the order ops start, which is also the order of the
+	// ops of a scenario.
+	started := ScenarioOp{Name: o.m.Name, Args: inputs}
+	if e.scenario != nil {
+		started.After = e.scenario[traceID-1].after
+	}
+	e.started = append(e.started, started)
 
 	// Record an OpStart event.
-	e.history = append(e.history, EventOpStart{
+	start := len(e.history)
+	e.record(EventOpStart{
 		TraceID: traceID,
 		SpanID:  spanID,
 		Name:    o.m.Name,
@@ -632,7 +922,14 @@ func (e *executor) runOp(ctx context.Context, o *op) (err error) {
 	e.mu.Unlock()
 
 	// Invoke the op.
-	if x := o.m.Func.Call(args)[0].Interface(); x != nil {
+	e.timer.enter(stepCall)
+	out := o.m.Func.Call(args)
+	e.timer.enter(stepSchedule)
+	var value any
+	if len(out) == 2 {
+		value = out[0].Interface()
+	}
+	if x := out[len(out)-1].Interface(); x != nil {
 		err = x.(error)
 	}
 
@@ -641,15 +938,42 @@ func (e *executor) runOp(ctx context.Context, o *op) (err error) {
 		return e.ctx.Err()
 	}
 
+	// Record the op's result and check the workload's invariants.
+	if err == nil {
+		e.mu.Lock()
+		e.results = append(e.results, OpResult{
+			TraceID: traceID,
+			Name:    o.m.Name,
+			Args:    inputs,
+			Value:   value,
+			Start:   start,
+			Finish:  len(e.history),
+		})
+		results := slices.Clip(e.results)
+		e.mu.Unlock()
+		if checker, ok := e.workload.Interface().(Checker); ok {
+			e.timer.enter(stepCheck)
+			err = checker.Check(results)
+			e.timer.enter(stepSchedule)
+		}
+	}
+
 	// Record an OpFinish event.
 	msg := "<nil>"
 	if err != nil {
 		msg = err.Error()
 	}
 	e.mu.Lock()
-	e.history = append(e.history, EventOpFinish{
+	var formattedValue string
+	if len(out) == 2 {
+		e.timer.enter(stepFormat)
+		formattedValue = e.formatter.Format(value)
+		e.timer.enter(stepSchedule)
+	}
+	e.record(EventOpFinish{
 		TraceID: traceID,
 		SpanID:  spanID,
+		Result:  formattedValue,
 		Error:   msg,
 	})
 	e.notFinished.remove(traceID)
@@ -667,6 +991,69 @@ func (e *executor) runOp(ctx context.Context, o *op) (err error) {
 	return nil
 }
 
+// resume charges the allocations that follow to the op or method call with
+// the provided span, which is resuming after a component method call
+// returned.
+func (e *executor) resume(spanID int) {
+	if e.allocs == nil {
+		return
+	}
+	e.mu.Lock()
+	owner := e.owners[spanID]
+	e.mu.Unlock()
+	e.allocs.charge(owner)
+}
+
+// opResource is the resource of the steps that run the code of ops, which
+// share the workload struct. See randFor.
+const opResource = "op"
+
+// randFor returns the random number generator used by the steps that run the
+// code of the provided resource: the name of a component, or opResource.
+//
+// By default, every step uses e.rand, and the random numbers a step draws
+// depend on the steps taken before it. When the executor has a stream per
+// resource, the steps of a resource draw from the resource's own stream, so
+// that taking steps of different resources in either order has the same
+// outcome. An explorer relies on this to skip equivalent interleavings (see
+// explore.go).
+//
+// REQUIRES: e.mu is held.
+func (e *executor) randFor(resource string) *rand.Rand {
+	if e.streams == nil {
+		return e.rand
+	}
+	r, ok := e.streams[resource]
+	if !ok {
+		h := fnv.New64a()
+		h.Write([]byte(resource))
+		r = rand.New(rand.NewSource(e.params.Seed ^ int64(h.Sum64())))
+		e.streams[resource] = r
+	}
+	return r
+}
+
+// record appends the provided event to the history.
+//
+// REQUIRES: e.mu is held.
+func (e *executor) record(event Event) {
+	prev := e.timer.enter(stepHistory)
+	e.history = append(e.history, event)
+	e.timer.enter(prev)
+}
+
+// replicaInfo returns the information reported by weaver.ReplicaInfo to the
+// provided replica of the provided component. Every component runs in a
+// simulated colocation group of its own.
+func (e *executor) replicaInfo(component string, index int) weaver.ReplicaInfo {
+	return weaver.ReplicaInfo{
+		Index:      index,
+		WeaveletID: fmt.Sprintf("%s/%d", component, index),
+		Group:      component,
+		Version:    e.deployment,
+	}
+}
+
 // deliverCall delivers the provided pending method call.
 func (e *executor) deliverCall(call *call) (err error) {
 	var component string
@@ -675,7 +1062,7 @@ func (e *executor) deliverCall(call *call) (err error) {
 		if x := recover(); x != nil {
 			err = fmt.Errorf("panic: %v", x)
 			e.mu.Lock()
-			e.history = append(e.history, EventPanic{
+			e.record(EventPanic{
 				TraceID:  call.traceID,
 				SpanID:   call.spanID,
 				Panicker: component,
@@ -694,13 +1081,23 @@ func (e *executor) deliverCall(call *call) (err error) {
 
 	// Pick a replica to execute the call.
 	component = reg.Name
-	replicas := e.components[component]
 	e.mu.Lock()
-	index = e.rand.Intn(len(replicas))
+	if e.allocs != nil {
+		owner := e.allocs.method(component, call.method)
+		e.owners[call.spanID] = owner
+		e.allocs.charge(owner)
+	}
+	replicas := e.components[component]
+	if call.target >= 0 {
+		index = call.target
+	} else {
+		index = e.randFor(component).Intn(len(replicas))
+	}
 	replica := replicas[index]
+	e.queues.begin(component)
 
 	// Record a DeliverCall event.
-	e.history = append(e.history, EventDeliverCall{
+	e.record(EventDeliverCall{
 		TraceID:   call.traceID,
 		SpanID:    call.spanID,
 		Component: reg.Name,
@@ -708,12 +1105,48 @@ func (e *executor) deliverCall(call *call) (err error) {
 	})
 	e.mu.Unlock()
 
-	// Call the component method.
-	returns := reflect.ValueOf(replica).MethodByName(call.method).Call(call.args)
+	// Call the component method, with the call's span, caller, and replica
+	// in the context. Calls made by ops have no calling component.
+	args := append([]reflect.Value{}, call.args...)
+	ctx := withIDs(args[0].Interface().(context.Context), call.traceID, call.spanID)
+	ctx = weaver.WithSerialCalls(ctx)
+	ctx = weaver.WithReplicaInfo(ctx, e.replicaInfo(component, index))
+	if call.caller.Component != "" {
+		ctx = codegen.WithCaller(ctx, call.caller)
+	}
+	args[0] = reflect.ValueOf(ctx)
+	var returns []reflect.Value
+	e.timer.enter(stepMarshal)
+	if err := roundTripAll(args[1:]); err != nil {
+		// The arguments failed to serialize, so the method is not called.
+		returns = returnError(call.component, call.method, err)
+	} else {
+		e.timer.enter(stepCall)
+		returns = reflect.ValueOf(replica).MethodByName(call.method).Call(args)
+		e.timer.enter(stepMarshal)
+		if err := roundTripAll(returns[:len(returns)-1]); err != nil {
+			returns = returnError(call.component, call.method, err)
+		}
+	}
+	e.timer.enter(stepFormat)
 	strings := make([]string, len(returns))
 	for i, ret := range returns {
-		strings[i] = fmt.Sprint(ret.Interface())
+		strings[i] = e.formatter.Format(ret.Interface())
+	}
+	var snapshot *EventSnapshot
+	if s, ok := replica.(Snapshotter); ok {
+		e.timer.enter(stepCall)
+		state := s.Snapshot()
+		e.timer.enter(stepFormat)
+		snapshot = &EventSnapshot{
+			TraceID:   call.traceID,
+			SpanID:    call.spanID,
+			Component: reg.Name,
+			Replica:   index,
+			State:     e.formatter.Format(state),
+		}
 	}
+	e.timer.enter(stepSchedule)
 
 	if e.ctx.Err() != nil {
 		// The simulation was cancelled. Abort.
@@ -722,23 +1155,120 @@ func (e *executor) deliverCall(call *call) (err error) {
 
 	// Record the reply and take a step.
 	e.mu.Lock()
+	e.queues.end(component)
 	e.replies[call.traceID] = append(e.replies[call.traceID], &reply{
 		call:    call,
 		returns: returns,
 	})
 
-	e.history = append(e.history, EventReturn{
+	e.record(EventReturn{
 		TraceID:   call.traceID,
 		SpanID:    call.spanID,
 		Component: reg.Name,
 		Replica:   index,
 		Returns:   strings,
 	})
+	if snapshot != nil {
+		e.record(*snapshot)
+	}
 	e.mu.Unlock()
 	e.step()
 	return nil
 }
 
+// updateConfig applies the provided config update.
+func (e *executor) updateConfig(update *configUpdate) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("panic: %v", x)
+			e.mu.Lock()
+			e.record(EventPanic{
+				Panicker: update.component,
+				Replica:  update.replica,
+				Error:    err.Error(),
+				Stack:    string(debug.Stack()),
+			})
+			e.mu.Unlock()
+		}
+	}()
+
+	// Replace the replica's config. The replica may have been upgraded to an
+	// implementation without a config.
+	e.mu.Lock()
+	replica := e.components[update.component][update.replica]
+	e.mu.Unlock()
+	if c := weaver.GetConfig(replica); c != nil {
+		cfg := reflect.ValueOf(c)
+		fresh := reflect.New(cfg.Type().Elem())
+		sections := map[string]string{update.component: update.section}
+		if err := runtime.ParseConfigSection(update.component, "", sections, fresh.Interface()); err != nil {
+			return err
+		}
+		cfg.Elem().Set(fresh.Elem())
+	}
+
+	e.mu.Lock()
+	e.record(EventUpdateConfig{
+		Component: update.component,
+		Replica:   update.replica,
+		Config:    update.section,
+	})
+	e.mu.Unlock()
+
+	// Notify the replica.
+	if x, ok := replica.(ConfigUpdater); ok {
+		ctx := weaver.WithReplicaInfo(e.ctx, e.replicaInfo(update.component, update.replica))
+		if err := x.UpdateConfig(ctx); err != nil {
+			return fmt.Errorf("component %q replica %d: UpdateConfig: %w", update.component, update.replica, err)
+		}
+	}
+
+	if e.ctx.Err() != nil {
+		// The simulation was cancelled. Abort.
+		return e.ctx.Err()
+	}
+	e.step()
+	return nil
+}
+
+// upgrade replaces a replica with a replica of its component's upgraded
+// implementation.
+func (e *executor) upgrade(u *upgrade) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = fmt.Errorf("panic: %v", x)
+			e.mu.Lock()
+			e.record(EventPanic{
+				Panicker: u.reg.Name,
+				Replica:  u.replica,
+				Error:    err.Error(),
+				Stack:    string(debug.Stack()),
+			})
+			e.mu.Unlock()
+		}
+	}()
+
+	replica, err := e.newReplica(u.reg, u.impl, u.replica)
+	if err != nil {
+		return fmt.Errorf("upgrade component %q replica %d: %w", u.reg.Name, u.replica, err)
+	}
+
+	e.mu.Lock()
+	e.components[u.reg.Name][u.replica] = replica
+	e.record(EventUpgrade{
+		Component: u.reg.Name,
+		Replica:   u.replica,
+	})
+	e.mu.Unlock()
+
+	if e.ctx.Err() != nil {
+		// The simulation was cancelled. Abort.
+		return e.ctx.Err()
+	}
+	e.step()
+	return nil
+}
+
 // returnError returns a slice of reflect.Values compatible with the return
 // type of the provided method. The final return value is the provided error.
 // All other return values are zero initialized.
@@ -18,14 +18,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/sim/diff"
+	"github.com/google/go-cmp/cmp"
 )
 
 // See TestPassingExecution.
@@ -425,6 +429,562 @@ func TestFakes(t *testing.T) {
 	}
 }
 
+// See TestDiffFailure.
+type diffWorkload struct {
+	divmod weaver.Ref[divMod]
+}
+
+func (d *diffWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("DivMod", Range(0, 100), Range(1, 100))
+	return nil
+}
+
+func (d *diffWorkload) DivMod(ctx context.Context, x, y int) error {
+	div, mod, err := d.divmod.Get().DivMod(ctx, x, y)
+	if err != nil {
+		// Ignore errors.
+		return nil
+	}
+	type quotient struct{ Div, Mod int }
+	// The expected value is deliberately wrong.
+	return diff.Check("quotient", quotient{x/y + 1, x % y}, quotient{div, mod})
+}
+
+func TestDiffFailure(t *testing.T) {
+	params := hyperparameters{
+		NumReplicas: 1,
+		NumOps:      10,
+		FailureRate: 0.0,
+	}
+	s := New(t, &diffWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mismatch *diff.Error
+	if !errors.As(result.err, &mismatch) {
+		t.Fatalf("got error %v, want *diff.Error", result.err)
+	}
+	if !strings.Contains(mismatch.Diff, "Div:") {
+		t.Fatalf("diff does not mention Div:\n%s", mismatch.Diff)
+	}
+
+	// The diff should be recorded in the history.
+	var found bool
+	for _, event := range result.history {
+		if finish, ok := event.(EventOpFinish); ok && finish.Error == result.err.Error() {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("diff not found in history %v", result.history)
+	}
+}
+
+// See TestCheckerExecution.
+type registerWorkload struct {
+	value, prev int
+}
+
+func (r *registerWorkload) Init(registrar Registrar) error {
+	registrar.RegisterGenerators("Write", Range(1, 100))
+	registrar.RegisterGenerators("Read")
+	return nil
+}
+
+func (r *registerWorkload) Write(_ context.Context, x int) (int, error) {
+	r.prev, r.value = r.value, x
+	return x, nil
+}
+
+func (r *registerWorkload) Read(context.Context) (int, error) {
+	return r.value, nil
+}
+
+// See TestCheckerExecution. Reads return the value written before the last
+// write.
+type staleRegisterWorkload struct {
+	registerWorkload
+}
+
+func (r *staleRegisterWorkload) Read(context.Context) (int, error) {
+	return r.prev, nil
+}
+
+// Check checks that every read observes the last write that finished before
+// the read started.
+func (r *registerWorkload) Check(results []OpResult) error {
+	read := results[len(results)-1]
+	if read.Name != "Read" {
+		return nil
+	}
+	want := 0
+	for _, write := range results {
+		if write.Name == "Write" && write.Finish < read.Start {
+			want = write.Value.(int)
+		}
+	}
+	return diff.Check("read", want, read.Value)
+}
+
+func TestCheckerExecution(t *testing.T) {
+	params := hyperparameters{
+		NumReplicas: 1,
+		NumOps:      100,
+		YieldRate:   0.5,
+	}
+	for _, test := range []struct {
+		name     string
+		workload Workload
+		fail     bool
+	}{
+		{"Linearizable", &registerWorkload{}, false},
+		{"Stale", &staleRegisterWorkload{}, true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t, test.workload, Options{})
+			result, err := s.newExecutor().execute(context.Background(), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !test.fail {
+				if result.err != nil {
+					t.Fatal(result.err)
+				}
+				return
+			}
+
+			var mismatch *diff.Error
+			if !errors.As(result.err, &mismatch) {
+				t.Fatalf("got error %v, want *diff.Error", result.err)
+			}
+			// Results should be recorded in the history.
+			for _, event := range result.history {
+				if finish, ok := event.(EventOpFinish); ok && finish.Result == "" {
+					t.Fatalf("missing result in %v", finish)
+				}
+			}
+		})
+	}
+}
+
+// See TestSnapshots.
+type countingIdentity struct {
+	calls int
+}
+
+func (c *countingIdentity) Identity(_ context.Context, x int) (int, error) {
+	c.calls++
+	return x, nil
+}
+
+func (c *countingIdentity) Snapshot() any {
+	return map[string]int{"calls": c.calls}
+}
+
+type snapshotWorkload struct {
+	identity weaver.Ref[identity]
+}
+
+func (s *snapshotWorkload) Init(r Registrar) error {
+	r.RegisterFake(Fake[identity](&countingIdentity{}))
+	r.RegisterGenerators("Identity", Range(0, 100))
+	return nil
+}
+
+func (s *snapshotWorkload) Identity(ctx context.Context, x int) error {
+	_, err := s.identity.Get().Identity(ctx, x)
+	return err
+}
+
+func TestSnapshots(t *testing.T) {
+	params := hyperparameters{NumReplicas: 1, NumOps: 3}
+	s := New(t, &snapshotWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+	var got []string
+	for _, event := range result.history {
+		if snapshot, ok := event.(EventSnapshot); ok {
+			got = append(got, snapshot.State)
+		}
+	}
+	want := []string{"map[calls:1]", "map[calls:2]", "map[calls:3]"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("snapshots (-want +got):\n%s", diff)
+	}
+}
+
+// See TestConfigUpdates.
+type configUpdateWorkload struct {
+	limiter weaver.Ref[limiter]
+}
+
+func (c *configUpdateWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Limit")
+	r.RegisterConfigUpdate(`
+["github.com/ServiceWeaver/weaver/sim/limiter"]
+Max = 5
+`)
+	return nil
+}
+
+func (c *configUpdateWorkload) Limit(ctx context.Context) (int, error) {
+	return c.limiter.Get().Limit(ctx)
+}
+
+// See TestFailingConfigUpdate.
+type badConfigUpdateWorkload struct {
+	limiter weaver.Ref[limiter]
+}
+
+func (b *badConfigUpdateWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Limit")
+	r.RegisterConfigUpdate(`
+["github.com/ServiceWeaver/weaver/sim/limiter"]
+Max = -1
+`)
+	return nil
+}
+
+func (b *badConfigUpdateWorkload) Limit(ctx context.Context) (int, error) {
+	return b.limiter.Get().Limit(ctx)
+}
+
+const limiterAppConfig = `
+["github.com/ServiceWeaver/weaver/sim/limiter"]
+Max = 1
+`
+
+func TestConfigUpdates(t *testing.T) {
+	s := New(t, &configUpdateWorkload{}, Options{Config: limiterAppConfig})
+	exec := s.newExecutor()
+	limits := map[string]bool{}
+	for seed := int64(0); seed < 10; seed++ {
+		params := hyperparameters{Seed: seed, NumReplicas: 2, NumOps: 50, YieldRate: 0.5}
+		result, err := exec.execute(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.err != nil {
+			t.Fatal(result.err)
+		}
+
+		// Every replica is updated once.
+		updated := map[int]int{}
+		for _, event := range result.history {
+			switch x := event.(type) {
+			case EventUpdateConfig:
+				updated[x.Replica]++
+				if !strings.Contains(x.Config, "Max = 5") {
+					t.Errorf("seed %d: config update %q does not set Max = 5", seed, x.Config)
+				}
+			case EventOpFinish:
+				limits[x.Result] = true
+			}
+		}
+		if diff := cmp.Diff(map[int]int{0: 1, 1: 1}, updated); diff != "" {
+			t.Fatalf("seed %d: updates by replica (-want +got):\n%s", seed, diff)
+		}
+	}
+
+	// Ops observe both the old and the new limit.
+	if !limits["1"] || !limits["5"] {
+		t.Fatalf("ops observed limits %v, want 1 and 5", limits)
+	}
+}
+
+func TestFailingConfigUpdate(t *testing.T) {
+	s := New(t, &badConfigUpdateWorkload{}, Options{Config: limiterAppConfig})
+	params := hyperparameters{Seed: 1, NumReplicas: 2, NumOps: 100}
+	result, err := s.newExecutor().execute(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err == nil || !strings.Contains(result.err.Error(), "negative limit -1") {
+		t.Fatalf("got error %v, want negative limit error", result.err)
+	}
+}
+
+// limiterV2 is an upgraded implementation of the limiter component that
+// reports its limit in different units. See TestUpgrades.
+type limiterV2 struct {
+	weaver.Implements[limiter]
+	weaver.WithConfig[limiterConfig]
+}
+
+func (l *limiterV2) Limit(context.Context) (int, error) {
+	return 100 * l.Config().Max, nil
+}
+
+// See TestUpgrades.
+type upgradeWorkload struct {
+	limiter weaver.Ref[limiter]
+}
+
+func (u *upgradeWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Limit")
+	r.RegisterGenerators("LimitTwice")
+	r.RegisterUpgrade(Upgrade[limiter](&limiterV2{}))
+	return nil
+}
+
+func (u *upgradeWorkload) Limit(ctx context.Context) (int, error) {
+	return u.limiter.Get().Limit(ctx)
+}
+
+// LimitTwice fails if two calls to the limiter disagree, which is only
+// possible while the limiter is being upgraded.
+func (u *upgradeWorkload) LimitTwice(ctx context.Context) error {
+	x, err := u.limiter.Get().Limit(ctx)
+	if err != nil {
+		return err
+	}
+	y, err := u.limiter.Get().Limit(ctx)
+	if err != nil {
+		return err
+	}
+	if x != y {
+		return fmt.Errorf("limits %d and %d disagree", x, y)
+	}
+	return nil
+}
+
+func TestUpgrades(t *testing.T) {
+	s := New(t, &upgradeWorkload{}, Options{Config: limiterAppConfig})
+	exec := s.newExecutor()
+	found := false
+	for seed := int64(0); seed < 100; seed++ {
+		params := hyperparameters{Seed: seed, NumReplicas: 3, NumOps: 20, YieldRate: 0.5}
+		result, err := exec.execute(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		upgraded := map[int]bool{}
+		for _, event := range result.history {
+			if u, ok := event.(EventUpgrade); ok {
+				if upgraded[u.Replica] {
+					t.Fatalf("seed %d: replica %d upgraded twice", seed, u.Replica)
+				}
+				upgraded[u.Replica] = true
+			}
+		}
+		if result.err != nil {
+			if !strings.Contains(result.err.Error(), "disagree") {
+				t.Fatalf("seed %d: unexpected error %v", seed, result.err)
+			}
+			if len(upgraded) == 0 {
+				t.Fatalf("seed %d: %v before any upgrade", seed, result.err)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("no rolling upgrade incompatibility found")
+	}
+}
+
+func TestFakedAndUpgraded(t *testing.T) {
+	r := newTestRegistrar[*upgradeWorkload](t)
+	if err := r.registerFakes(Fake[limiter](&limiterV2{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.registerUpgrade(Upgrade[limiter](&limiterV2{})); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.registerUpgrade(Upgrade[limiter](&limiterV2{})); err == nil {
+		t.Fatal("duplicate registerUpgrade: unexpected success")
+	}
+	r.registerGenerators("Limit")
+	r.registerGenerators("LimitTwice")
+	if err := r.finalize(); err == nil || !strings.Contains(err.Error(), "both faked and upgraded") {
+		t.Fatalf("finalize: got %v, want faked and upgraded error", err)
+	}
+}
+
+// pin is a sensitive op argument. See TestRedaction.
+type pin int
+
+func (pin) Redact() any { return "****" }
+
+// See TestRedaction.
+type redactionWorkload struct {
+	identity weaver.Ref[identity]
+}
+
+func (r *redactionWorkload) Init(registrar Registrar) error {
+	registrar.RegisterGenerators("Identity", generatorFunc[pin](func(r *rand.Rand) pin {
+		return pin(r.Intn(10000))
+	}))
+	return nil
+}
+
+func (r *redactionWorkload) Identity(ctx context.Context, x pin) error {
+	_, err := r.identity.Get().Identity(ctx, int(x))
+	return err
+}
+
+func TestRedaction(t *testing.T) {
+	s := New(t, &redactionWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+		NumReplicas: 1,
+		NumOps:      10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+	for _, event := range result.history {
+		op, ok := event.(EventOpStart)
+		if !ok {
+			continue
+		}
+		if diff := cmp.Diff([]string{"****"}, op.Args); diff != "" {
+			t.Fatalf("op args (-want +got):\n%s", diff)
+		}
+	}
+}
+
+// See TestCallerIdentity.
+type callerWorkload struct {
+	relay weaver.Ref[relay]
+}
+
+func (c *callerWorkload) Init(r Registrar) error {
+	return nil
+}
+
+func (c *callerWorkload) Caller(ctx context.Context) error {
+	got, err := c.relay.Get().Caller(ctx)
+	if errors.Is(err, weaver.RemoteCallError) {
+		// Ignore injected errors.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if want := "github.com/ServiceWeaver/weaver/sim/relay"; got != want {
+		return fmt.Errorf("caller: got %q, want %q", got, want)
+	}
+	return nil
+}
+
+func TestCallerIdentity(t *testing.T) {
+	s := New(t, &callerWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+		NumReplicas: 3,
+		NumOps:      100,
+		FailureRate: 0.1,
+		YieldRate:   0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+}
+
+// See TestReplicaInfo.
+type locatorWorkload struct {
+	locator weaver.Ref[locator]
+}
+
+func (l *locatorWorkload) Init(r Registrar) error {
+	return nil
+}
+
+func (l *locatorWorkload) Locate(ctx context.Context) error {
+	group, index, err := l.locator.Get().Locate(ctx)
+	if errors.Is(err, weaver.RemoteCallError) {
+		// Ignore injected errors.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if want := "github.com/ServiceWeaver/weaver/sim/locator"; group != want {
+		return fmt.Errorf("group: got %q, want %q", group, want)
+	}
+	if index < 0 || index >= 3 {
+		return fmt.Errorf("index: got %d, want [0, 3)", index)
+	}
+	return nil
+}
+
+func TestReplicaInfo(t *testing.T) {
+	s := New(t, &locatorWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+		NumReplicas: 3,
+		NumOps:      100,
+		FailureRate: 0.1,
+		YieldRate:   0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+}
+
+// See TestBlobStore.
+type blobWorkload struct {
+	blobs weaver.Ref[weaver.BlobStore]
+}
+
+func (b *blobWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("PutGet", Range(0, 10))
+	return nil
+}
+
+func (b *blobWorkload) PutGet(ctx context.Context, i int) error {
+	key, want := fmt.Sprintf("blobs/%d", i), fmt.Sprint(i)
+	err := b.blobs.Get().Put(ctx, key, []byte(want))
+	if errors.Is(err, weaver.RemoteCallError) {
+		// Ignore injected errors.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	got, err := b.blobs.Get().Get(ctx, key)
+	if errors.Is(err, weaver.RemoteCallError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if string(got) != want {
+		return fmt.Errorf("Get(%q): got %q, want %q", key, got, want)
+	}
+	return nil
+}
+
+func TestBlobStore(t *testing.T) {
+	// The in-memory BlobStore holds blobs per replica, so we run a single
+	// replica to read our own writes.
+	s := New(t, &blobWorkload{}, Options{})
+	result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+		NumReplicas: 1,
+		NumOps:      100,
+		FailureRate: 0.1,
+		YieldRate:   0.5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+}
+
 func TestExtractIDs(t *testing.T) {
 	const traceID = 42
 	const spanID = 9001
@@ -577,7 +1137,7 @@ func BenchmarkResetExecutor(b *testing.B) {
 				}
 				fakes := exec.registrar.fakes
 				ops := exec.registrar.ops
-				if err := exec.reset(workload, fakes, ops, params); err != nil {
+				if err := exec.reset(workload, fakes, ops, exec.registrar.updates, exec.registrar.upgrades, params); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -671,3 +1231,68 @@ func BenchmarkParallelExecutions(b *testing.B) {
 		}
 	}
 }
+
+// taggedWorkload is a workload with tagged ops. See TestOpFilter.
+type taggedWorkload struct{}
+
+func (*taggedWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Deposit")
+	r.RegisterGenerators("Withdraw")
+	r.RegisterGenerators("Audit")
+	r.RegisterTags("Deposit", "smoke", "money")
+	r.RegisterTags("Withdraw", "money")
+	return nil
+}
+
+func (*taggedWorkload) Deposit(context.Context) error  { return nil }
+func (*taggedWorkload) Withdraw(context.Context) error { return nil }
+func (*taggedWorkload) Audit(context.Context) error    { return nil }
+
+func TestOpFilter(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		filter func(string, []string) bool
+		want   []string
+	}{
+		{"All", nil, []string{"Audit", "Deposit", "Withdraw"}},
+		{"Smoke", Tagged("smoke"), []string{"Deposit"}},
+		{"SmokeOrMoney", Tagged("smoke", "money"), []string{"Deposit", "Withdraw"}},
+		{"ByName", func(op string, _ []string) bool { return op == "Audit" }, []string{"Audit"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t, &taggedWorkload{}, Options{OpFilter: test.filter})
+			params := hyperparameters{Seed: 1, NumReplicas: 1, NumOps: 100}
+			result, err := s.newExecutor().execute(context.Background(), params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.err != nil {
+				t.Fatal(result.err)
+			}
+			ran := map[string]bool{}
+			for _, event := range result.history {
+				if start, ok := event.(EventOpStart); ok {
+					ran[start.Name] = true
+				}
+			}
+			var got []string
+			for _, op := range []string{"Audit", "Deposit", "Withdraw"} {
+				if ran[op] {
+					got = append(got, op)
+				}
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Fatalf("ops (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestOpFilterSelectsNothing(t *testing.T) {
+	s := New(t, &taggedWorkload{}, Options{OpFilter: Tagged("nightly")})
+	params := hyperparameters{Seed: 1, NumReplicas: 1, NumOps: 10}
+	_, err := s.newExecutor().execute(context.Background(), params)
+	if err == nil || !strings.Contains(err.Error(), "selects none") {
+		t.Fatalf("execute: got %v, want OpFilter error", err)
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// Simulator.Explore is a stateless model checker. It explores the schedules
+// of an execution depth first, by re-executing the execution and replaying
+// the steps of an explored schedule up to the step where the next schedule
+// diverges.
+//
+// Many schedules are equivalent. Two steps are independent if they run the
+// code of different resources, e.g., deliver calls to different components,
+// and taking them in either order has the same outcome. Schedules that only
+// differ in the order of adjacent independent steps are equivalent, and only
+// one of them needs to be explored. The explorer uses dynamic partial-order
+// reduction (DPOR) [1] with sleep sets [2] to skip most equivalent schedules:
+//
+//   - DPOR: while exploring a schedule, the explorer tracks which steps
+//     happen before which, and finds races: pairs of dependent steps that
+//     could have been taken in the opposite order. For every race, it marks
+//     the step that reverses the race as one to explore at the point where
+//     the first step of the race was taken. Only marked steps are explored.
+//   - Sleep sets: once the explorer has explored a step at some point, it
+//     doesn't explore the step again, later in a schedule that diverges from
+//     that point, until a step dependent with it is taken. A schedule whose
+//     every candidate step is asleep is equivalent to an explored schedule,
+//     and is abandoned.
+//
+// Steps of different resources only commute if they don't draw from a shared
+// random number generator, so the explorer gives every resource its own
+// stream (see executor.randFor).
+//
+// [1]: https://dl.acm.org/doi/10.1145/1040305.1040315
+// [2]: https://link.springer.com/book/10.1007/3-540-60761-7
+
+// ExploreOptions configure Simulator.Explore.
+type ExploreOptions struct {
+	// Seed seeds the ops, their inputs, the replicas calls are delivered to,
+	// and the calls that fail. Every explored schedule uses the same seed.
+	Seed int64
+
+	// NumOps is the number of ops of every execution. If zero, 3 is used.
+	// The number of schedules grows exponentially with the number of ops.
+	NumOps int
+
+	// NumReplicas is the number of replicas of every component. If zero, 1
+	// is used.
+	NumReplicas int
+
+	// FailureRate is the fraction of method calls that fail.
+	FailureRate float64
+
+	// MaxExecutions bounds the number of executions. If zero, 10,000 is
+	// used.
+	MaxExecutions int
+
+	// If true, Explore explores every schedule, including schedules that are
+	// equivalent to explored ones. This is useful to measure the reduction.
+	NoReduction bool
+}
+
+// ExploreResult is the result of Simulator.Explore.
+type ExploreResult struct {
+	Err           error         // error returned by an op of a failing execution, if found
+	History       []Event       // a history of the failing execution, if found
+	NumExecutions int           // number of executions run to completion
+	NumPruned     int           // number of executions abandoned as equivalent to explored ones
+	Complete      bool          // whether every schedule was explored, up to equivalence
+	Duration      time.Duration // duration of exploration
+}
+
+// Explore explores the schedules of a single execution of the workload,
+// with the ops and inputs picked by opts.Seed: every order in which the
+// execution can start ops, deliver calls and replies, and apply config
+// updates and upgrades, up to equivalence. It stops at the first failing
+// execution, once every schedule has been explored, or after
+// opts.MaxExecutions executions. Unlike Run, which samples schedules at
+// random, Explore is exhaustive, so it can prove the absence of bugs in an
+// execution with a few ops, and find bugs that need unlikely schedules.
+//
+// Explore assumes that components only share state by calling each other,
+// and that ops only share the workload struct, which makes the steps of
+// different components independent.
+func (s *Simulator) Explore(opts ExploreOptions) ExploreResult {
+	s.t.Helper()
+	if opts.NumOps == 0 {
+		opts.NumOps = 3
+	}
+	if opts.NumReplicas == 0 {
+		opts.NumReplicas = 1
+	}
+	if opts.MaxExecutions == 0 {
+		opts.MaxExecutions = 10_000
+	}
+
+	x := newExplorer(!opts.NoReduction)
+	e := s.newExecutor()
+	e.scheduler = x
+	e.streams = map[string]*rand.Rand{}
+	params := hyperparameters{
+		Seed:        opts.Seed,
+		NumReplicas: opts.NumReplicas,
+		NumOps:      opts.NumOps,
+		FailureRate: opts.FailureRate,
+	}
+
+	start := time.Now()
+	var result ExploreResult
+	for result.NumExecutions+result.NumPruned < opts.MaxExecutions {
+		ctx, cancel := context.WithCancel(context.Background())
+		x.cancel = cancel
+		r, err := e.execute(ctx, params)
+		cancel()
+		switch {
+		case x.pruned:
+			result.NumPruned++
+		case err != nil:
+			s.t.Fatalf("Simulator.Explore: %v", err)
+			return ExploreResult{}
+		case r.err != nil:
+			result.NumExecutions++
+			result.Err = r.err
+			result.History = r.history
+			result.Duration = time.Since(start)
+			s.t.Log(result.summary())
+			return result
+		default:
+			result.NumExecutions++
+		}
+		x.finish()
+		if !x.backtrack() {
+			result.Complete = true
+			break
+		}
+	}
+	result.Duration = time.Since(start)
+	s.t.Log(result.summary())
+	return result
+}
+
+// summary returns a human readable summary of the result.
+func (r *ExploreResult) summary() string {
+	prefix := "No errors found"
+	switch {
+	case r.Err != nil:
+		prefix = "Error found"
+	case !r.Complete:
+		prefix = "No errors found (incomplete)"
+	}
+	return fmt.Sprintf("%s after exploring %d executions (%d more pruned) in %v.", prefix, r.NumExecutions, r.NumPruned, r.Duration.Truncate(time.Millisecond))
+}
+
+// stepKey identifies a candidate step among the candidates of the same
+// point of a schedule, across re-executions.
+type stepKey struct {
+	kind      StepKind
+	traceID   int
+	spanID    int
+	component string
+	replica   int
+}
+
+func keyOf(c Candidate) stepKey {
+	return stepKey{c.Kind, c.TraceID, c.SpanID, c.Component, c.Replica}
+}
+
+// exploreNode is a point of an explored schedule, where a step is taken.
+type exploreNode struct {
+	keys      []stepKey          // the candidate steps, in order
+	resources map[stepKey]string // the resource of every candidate step
+	enablers  map[stepKey]int    // the step that enabled every candidate, or -1
+	taken     stepKey            // the step taken
+	before    bitset             // the steps that happen before the step taken
+	backtrack map[stepKey]bool   // the steps to explore from this point
+	done      map[stepKey]bool   // the steps explored from this point
+	sleep     map[stepKey]bool   // the steps not to explore from this point
+}
+
+// explorer is a Scheduler that explores the schedules of an execution, one
+// execution at a time.
+type explorer struct {
+	reduce bool           // whether to skip equivalent schedules
+	cancel func()         // cancels the current execution
+	pruned bool           // whether the current execution was abandoned
+	stack  []*exploreNode // the points of the current schedule
+	depth  int            // the number of steps taken in the current execution
+}
+
+var _ Scheduler = &explorer{}
+
+func newExplorer(reduce bool) *explorer {
+	return &explorer{reduce: reduce}
+}
+
+// Start implements the Scheduler interface.
+func (x *explorer) Start(*rand.Rand, int) {
+	x.pruned = false
+	x.depth = 0
+}
+
+// Next implements the Scheduler interface.
+func (x *explorer) Next(candidates []Candidate) int {
+	n := x.depth
+	x.depth++
+	if x.pruned {
+		// The execution is being cancelled.
+		return 0
+	}
+
+	if n < len(x.stack) {
+		// Replay the schedule. The last point of the stack is where the
+		// schedule diverges from the last explored one; see backtrack.
+		node := x.stack[n]
+		if len(candidates) != len(node.keys) {
+			panic(fmt.Errorf("nondeterministic execution: %d candidate steps at step %d, want %d", len(candidates), n, len(node.keys)))
+		}
+		i := slices.IndexFunc(candidates, func(c Candidate) bool { return keyOf(c) == node.taken })
+		if i < 0 {
+			panic(fmt.Errorf("nondeterministic execution: step %v not found at step %d", node.taken, n))
+		}
+		if n == len(x.stack)-1 {
+			x.take(n, node.taken)
+		}
+		return i
+	}
+
+	// Reach a new point of the schedule.
+	node := &exploreNode{
+		keys:      make([]stepKey, len(candidates)),
+		resources: make(map[stepKey]string, len(candidates)),
+		enablers:  make(map[stepKey]int, len(candidates)),
+		backtrack: map[stepKey]bool{},
+		done:      map[stepKey]bool{},
+		sleep:     map[stepKey]bool{},
+	}
+	for i, c := range candidates {
+		key := keyOf(c)
+		node.keys[i] = key
+		node.resources[key] = c.resource
+		node.enablers[key] = -1
+		if n > 0 {
+			// A candidate that wasn't a candidate of the previous point
+			// was enabled by the previous step.
+			if enabler, ok := x.stack[n-1].enablers[key]; ok {
+				node.enablers[key] = enabler
+			} else {
+				node.enablers[key] = n - 1
+			}
+		}
+	}
+	if !x.reduce {
+		for _, key := range node.keys {
+			node.backtrack[key] = true
+		}
+	} else if n > 0 {
+		// Steps asleep or explored at the previous point, other than the
+		// step taken, stay asleep if they are independent of it.
+		parent := x.stack[n-1]
+		resource := parent.resources[parent.taken]
+		for _, key := range parent.keys {
+			if key == parent.taken || !(parent.sleep[key] || parent.done[key]) {
+				continue
+			}
+			if _, ok := node.resources[key]; ok && parent.resources[key] != resource {
+				node.sleep[key] = true
+			}
+		}
+	}
+
+	if x.reduce {
+		x.reverseRaces(node, n)
+	}
+
+	// Take the first step that isn't asleep.
+	i := slices.IndexFunc(node.keys, func(key stepKey) bool { return !node.sleep[key] })
+	if i < 0 {
+		// Every step is asleep, so the rest of the schedule is equivalent to
+		// an explored one.
+		x.pruned = true
+		x.cancel()
+		return 0
+	}
+	x.stack = append(x.stack, node)
+	x.take(n, node.keys[i])
+	return i
+}
+
+// take records that the provided step is taken at the nth point of the
+// schedule.
+func (x *explorer) take(n int, key stepKey) {
+	node := x.stack[n]
+	node.taken = key
+	node.done[key] = true
+	node.backtrack[key] = true
+	if !x.reduce {
+		return
+	}
+
+	// The steps that happen before the step are the step that enabled it,
+	// the earlier steps dependent with it, and, transitively, the steps that
+	// happen before those.
+	node.before = x.enabledBefore(node.enablers[key])
+	resource := node.resources[key]
+	for i := n - 1; i >= 0; i-- {
+		prev := x.stack[i]
+		if prev.resources[prev.taken] == resource {
+			node.before.add(i)
+			node.before.or(prev.before)
+		}
+	}
+}
+
+// enabledBefore returns the steps that happen before a step enabled by the
+// provided step, or by no step if enabler is -1.
+func (x *explorer) enabledBefore(enabler int) bitset {
+	var before bitset
+	if enabler >= 0 {
+		before.add(enabler)
+		before.or(x.stack[enabler].before)
+	}
+	return before
+}
+
+// reverseRaces finds the races of the candidate steps of the provided node,
+// which are ready to be taken after the first n steps of the schedule, and
+// marks the steps that reverse them to be explored. The steps taken later
+// in the schedule don't need to be candidates: a candidate that is never
+// taken, e.g., an upgrade pending when the last op finishes, can still race
+// with the steps taken before it.
+func (x *explorer) reverseRaces(node *exploreNode, n int) {
+	for _, key := range node.keys {
+		// The latest step dependent with the candidate that doesn't happen
+		// before it races with it.
+		before := x.enabledBefore(node.enablers[key])
+		resource := node.resources[key]
+		race := -1
+		for i := n - 1; i >= 0; i-- {
+			prev := x.stack[i]
+			if prev.resources[prev.taken] == resource && !before.has(i) {
+				race = i
+				break
+			}
+		}
+		if race < 0 {
+			continue
+		}
+
+		// Reverse the race: explore, at the point of the race, the step
+		// that leads to the candidate. That is the candidate itself, if it
+		// was a candidate at the point of the race, or else the step that
+		// enabled it, and so on. If there is no such step, explore every
+		// step.
+		target := x.stack[race]
+		for k, enabler := key, node.enablers[key]; ; {
+			if _, ok := target.resources[k]; ok {
+				target.backtrack[k] = true
+				break
+			}
+			if enabler < 0 {
+				for _, k := range target.keys {
+					target.backtrack[k] = true
+				}
+				break
+			}
+			k = x.stack[enabler].taken
+			enabler = x.stack[enabler].enablers[k]
+		}
+	}
+}
+
+// finish finishes the exploration of the current schedule.
+func (x *explorer) finish() {
+	if !x.reduce || x.pruned || len(x.stack) == 0 {
+		return
+	}
+	// The steps left untaken when the execution finished may race with the
+	// last step.
+	last := x.stack[len(x.stack)-1]
+	pending := &exploreNode{resources: last.resources, enablers: last.enablers}
+	for _, key := range last.keys {
+		if key != last.taken {
+			pending.keys = append(pending.keys, key)
+		}
+	}
+	x.reverseRaces(pending, len(x.stack))
+}
+
+// backtrack prepares the explorer to explore the next schedule, which
+// diverges from the current one at the latest point with a step left to
+// explore. It returns false if there are no schedules left to explore.
+func (x *explorer) backtrack() bool {
+	for i := len(x.stack) - 1; i >= 0; i-- {
+		node := x.stack[i]
+		for _, key := range node.keys {
+			if node.backtrack[key] && !node.done[key] && !node.sleep[key] {
+				node.taken = key
+				x.stack = x.stack[:i+1]
+				return true
+			}
+		}
+	}
+	x.stack = x.stack[:0]
+	return false
+}
+
+// bitset is a set of small non-negative integers.
+type bitset []uint64
+
+func (b *bitset) add(i int) {
+	for len(*b) <= i/64 {
+		*b = append(*b, 0)
+	}
+	(*b)[i/64] |= 1 << (i % 64)
+}
+
+func (b bitset) has(i int) bool {
+	return i/64 < len(b) && b[i/64]&(1<<(i%64)) != 0
+}
+
+func (b *bitset) or(o bitset) {
+	for len(*b) < len(o) {
+		*b = append(*b, 0)
+	}
+	for i := range o {
+		(*b)[i] |= o[i]
+	}
+}
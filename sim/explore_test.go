@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExploreReduction(t *testing.T) {
+	s := New(t, &divModWorkload{}, Options{})
+	opts := ExploreOptions{Seed: 2, NumOps: 2}
+	reduced := s.Explore(opts)
+	opts.NoReduction = true
+	full := s.Explore(opts)
+	for _, r := range []ExploreResult{reduced, full} {
+		if r.Err != nil {
+			t.Fatal(r.Err)
+		}
+		if !r.Complete {
+			t.Fatalf("incomplete exploration: %+v", r)
+		}
+	}
+	if full.NumPruned != 0 {
+		t.Fatalf("NumPruned without reduction: got %d, want 0", full.NumPruned)
+	}
+	if reduced.NumExecutions >= full.NumExecutions {
+		t.Fatalf("NumExecutions: got %d with reduction, %d without, want fewer with reduction", reduced.NumExecutions, full.NumExecutions)
+	}
+}
+
+func TestExploreIsDeterministic(t *testing.T) {
+	s := New(t, &divModWorkload{}, Options{})
+	opts := ExploreOptions{Seed: 2, NumOps: 2, NumReplicas: 2, FailureRate: 0.2}
+	first := s.Explore(opts)
+	second := s.Explore(opts)
+	if first.NumExecutions != second.NumExecutions || first.NumPruned != second.NumPruned {
+		t.Fatalf("explored %d (%d pruned), then %d (%d pruned)", first.NumExecutions, first.NumPruned, second.NumExecutions, second.NumPruned)
+	}
+}
+
+func TestExploreMaxExecutions(t *testing.T) {
+	s := New(t, &divModWorkload{}, Options{})
+	r := s.Explore(ExploreOptions{Seed: 1, NumOps: 4, MaxExecutions: 10, NoReduction: true})
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if r.Complete {
+		t.Fatal("unexpectedly complete exploration")
+	}
+	if r.NumExecutions != 10 {
+		t.Fatalf("NumExecutions: got %d, want 10", r.NumExecutions)
+	}
+}
+
+func TestExploreFindsUpgradeBug(t *testing.T) {
+	for _, reduce := range []bool{true, false} {
+		s := New(t, &upgradeWorkload{}, Options{Config: limiterAppConfig})
+		var r ExploreResult
+		for seed := int64(0); seed < 20 && r.Err == nil; seed++ {
+			r = s.Explore(ExploreOptions{Seed: seed, NumOps: 2, NumReplicas: 2, MaxExecutions: 1000, NoReduction: !reduce})
+		}
+		if r.Err == nil {
+			t.Fatalf("reduce=%t: no rolling upgrade incompatibility found", reduce)
+		}
+		if !strings.Contains(r.Err.Error(), "disagree") {
+			t.Fatalf("reduce=%t: unexpected error %v", reduce, r.Err)
+		}
+		if len(r.History) == 0 {
+			t.Fatalf("reduce=%t: missing history", reduce)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/simfarm"
+)
+
+// farmEnvVar is the environment variable that holds the address of a farm
+// coordinator, if Options.Farm is not set.
+const farmEnvVar = "WEAVER_SIM_FARM"
+
+// farmAddr returns the address of the farm coordinator the simulator should
+// join, or "" if the simulator should run on its own.
+func (s *Simulator) farmAddr() string {
+	if s.opts.Farm != "" {
+		return s.opts.Farm
+	}
+	return os.Getenv(farmEnvVar)
+}
+
+// runFarm runs a simulation as a worker of the farm coordinated by the
+// coordinator at the provided address, until the provided context is
+// cancelled. Unlike run, runFarm does not stop when it finds a failing
+// execution. Instead, it reports the failure to the coordinator and keeps
+// going. When the context is cancelled, it returns the first failing
+// execution it found, if any.
+func (s *Simulator) runFarm(ctx context.Context, stats *stats, addr string) (result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Spawn a goroutine to periodically print progress.
+	done := sync.WaitGroup{}
+	defer done.Wait()
+	done.Add(1)
+	go func() {
+		defer done.Done()
+		s.printProgress(ctx, stats)
+	}()
+
+	// Execute the graveyard entries.
+	if r, err := s.executeGraveyard(ctx, stats); err != nil || r.err != nil {
+		return r, err
+	}
+
+	n := s.parallelism()
+	executors := make([]*executor, n)
+	for i := range executors {
+		executors[i] = s.newExecutor()
+	}
+
+	client := simfarm.Client{Addr: addr}
+	worker := workerName()
+	s.t.Logf("Joining farm %s as worker %s with %d executors.", addr, worker, n)
+	var first result
+	for ctx.Err() == nil {
+		lease, err := client.Lease(ctx, worker)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return result{}, err
+		}
+		report, failing, err := s.runLease(ctx, stats, executors, lease)
+		if err != nil && err != ctx.Err() {
+			return result{}, err
+		}
+		if first.err == nil {
+			first = failing
+		}
+
+		// Report the lease's results, even if the context was cancelled
+		// while running it.
+		report.Worker = worker
+		reportCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err = client.Report(reportCtx, report)
+		cancel()
+		if err != nil {
+			return result{}, err
+		}
+		if len(report.Failures) > 0 {
+			s.t.Logf("Reported %d failures from lease %d.", len(report.Failures), lease.ID)
+		}
+	}
+	if first.err != nil {
+		return first, nil
+	}
+	return result{}, ctx.Err()
+}
+
+// runLease runs the executions of a lease using the provided executors, one
+// goroutine per executor, and returns a report of their results along with
+// the first failing execution. Failing executions do not stop the lease.
+func (s *Simulator) runLease(ctx context.Context, stats *stats, executors []*executor, lease simfarm.Lease) (simfarm.Report, result, error) {
+	params := make(chan hyperparameters, len(executors))
+	go func() {
+		defer close(params)
+		for i := lease.Start; i < lease.Start+lease.Count; i++ {
+			select {
+			case <-ctx.Done():
+				return
+			case params <- sweep(lease.Seed, i):
+			}
+		}
+		for _, c := range lease.Candidates {
+			select {
+			case <-ctx.Done():
+				return
+			case params <- hyperparameters(c):
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	report := simfarm.Report{Lease: lease.ID, Coverage: map[string]int64{}}
+	fingerprints := map[string]bool{}
+	var first result
+	var firstErr error
+	var wait sync.WaitGroup
+	wait.Add(len(executors))
+	for _, exec := range executors {
+		exec := exec
+		go func() {
+			defer wait.Done()
+			for p := range params {
+				r, err := exec.execute(ctx, p)
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+					return
+				}
+				atomic.AddInt64(&stats.numExecutions, 1)
+				atomic.AddInt64(&stats.numOps, int64(r.params.NumOps))
+
+				mu.Lock()
+				report.Executions++
+				report.Ops += int64(r.params.NumOps)
+				addCoverage(report.Coverage, r.history)
+				if r.err != nil {
+					if first.err == nil {
+						first = r
+					}
+					s.failures.add(r)
+					fp := Fingerprint(r.history, r.err)
+					if !fingerprints[fp] {
+						fingerprints[fp] = true
+						var history bytes.Buffer
+						WriteHistory(&history, r.history)
+						report.Failures = append(report.Failures, simfarm.Failure{
+							Fingerprint: fp,
+							Error:       r.err.Error(),
+							Params:      simfarm.Params(p),
+							History:     history.String(),
+						})
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wait.Wait()
+
+	// Drain the params channel, in case the executors exited early.
+	for range params {
+	}
+	return report, first, firstErr
+}
+
+// addCoverage adds the coverage of an execution with the provided history to
+// coverage. Coverage counts how often every op was run, every component
+// method was called, failed, or panicked, and every component's config was
+// updated or replicas upgraded:
+//
+//	op <op>
+//	call <component>.<method>
+//	error <component>.<method>
+//	panic <component>
+//	config <component>
+//	upgrade <component>
+func addCoverage(coverage map[string]int64, history []Event) {
+	calls := map[int]EventCall{}
+	for _, event := range history {
+		switch x := event.(type) {
+		case EventOpStart:
+			coverage["op "+x.Name]++
+		case EventCall:
+			calls[x.SpanID] = x
+			coverage["call "+x.Component+"."+x.Method]++
+		case EventDeliverError:
+			call := calls[x.SpanID]
+			coverage["error "+call.Component+"."+call.Method]++
+		case EventPanic:
+			coverage["panic "+x.Panicker]++
+		case EventUpdateConfig:
+			coverage["config "+x.Component]++
+		case EventUpgrade:
+			coverage["upgrade "+x.Component]++
+		}
+	}
+}
+
+// workerName returns the name of this process as a farm worker.
+func workerName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/simfarm"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFarmWorker(t *testing.T) {
+	c, err := simfarm.NewCoordinator(1, 20, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	s := New(t, &divModWorkload{}, Options{Farm: server.URL, Parallelism: 2})
+	r := s.Run(time.Second)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	status := c.Status()
+	if status.Leases == 0 || status.Executions == 0 {
+		t.Fatalf("status: got %d leases and %d executions, want > 0", status.Leases, status.Executions)
+	}
+	if len(status.Failures) != 0 {
+		t.Fatalf("status: got failures %v, want none", status.Failures)
+	}
+	for _, key := range []string{"op DivMod", "op Div", "op Mod"} {
+		if status.Coverage[key] == 0 {
+			t.Errorf("coverage[%q]: got 0, want > 0", key)
+		}
+	}
+}
+
+func TestAddCoverage(t *testing.T) {
+	history := []Event{
+		EventOpStart{TraceID: 1, SpanID: 1, Name: "Deposit"},
+		EventCall{TraceID: 1, SpanID: 2, Parent: 1, Caller: "op", Component: "bank/Store", Method: "Add"},
+		EventDeliverError{TraceID: 1, SpanID: 2},
+		EventCall{TraceID: 1, SpanID: 3, Parent: 1, Caller: "op", Component: "bank/Store", Method: "Add"},
+		EventPanic{TraceID: 1, SpanID: 3, Panicker: "bank/Store"},
+	}
+	got := map[string]int64{}
+	addCoverage(got, history)
+	want := map[string]int64{
+		"op Deposit":           1,
+		"call bank/Store.Add":  2,
+		"error bank/Store.Add": 1,
+		"panic bank/Store":     1,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("addCoverage (-want +got):\n%s", diff)
+	}
+}
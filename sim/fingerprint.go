@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	// quoted matches double quoted strings, e.g., formatted with %q.
+	quoted = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+	// hexNumbers matches hexadecimal numbers, e.g., pointers.
+	hexNumbers = regexp.MustCompile(`0x[0-9a-fA-F]+`)
+
+	// digits matches runs of decimal digits.
+	digits = regexp.MustCompile(`[0-9]+`)
+)
+
+// Fingerprint returns a canonical fingerprint of a failing execution with the
+// provided history and error. Failures caused by the same bug usually have the
+// same fingerprint, even if they were found with different seeds, inputs, and
+// interleavings, so fingerprints can be used to deduplicate failures.
+//
+// A fingerprint is a hash of the error and of the failing op: the first op
+// that returned an error or panicked. The error is hashed with its numbers
+// and quoted strings elided. The failing op is hashed as its name and the
+// sequence of component methods it called, along with the calls that failed
+// and the components that panicked. Trace ids, span ids, replicas, arguments,
+// and return values are ignored, and consecutive identical calls are hashed
+// as one, so that a loop hashes the same no matter how many times it runs. If
+// the failing op cannot be found, only the error is hashed.
+func Fingerprint(history []Event, err error) string {
+	h := sha256.New()
+	fmt.Fprintln(h, canonicalError(err.Error()))
+
+	// Find the trace of the failing op: the first op that panicked or
+	// returned an error.
+	trace := -1
+	for _, event := range history {
+		switch x := event.(type) {
+		case EventOpFinish:
+			if x.Error != "<nil>" && x.Error != "" && trace == -1 {
+				trace = x.TraceID
+			}
+		case EventPanic:
+			if trace == -1 {
+				trace = x.TraceID
+			}
+		}
+	}
+
+	calls := map[int]EventCall{}
+	last := ""
+	for _, event := range history {
+		var line string
+		switch x := event.(type) {
+		case EventOpStart:
+			if x.TraceID == trace {
+				line = "op " + x.Name
+			}
+		case EventCall:
+			if x.TraceID == trace {
+				calls[x.SpanID] = x
+				line = "call " + x.Component + "." + x.Method
+			}
+		case EventDeliverError:
+			if call, ok := calls[x.SpanID]; ok {
+				line = "error " + call.Component + "." + call.Method
+			}
+		case EventPanic:
+			if x.TraceID == trace {
+				line = "panic " + x.Panicker
+			}
+		}
+		if line == "" || line == last {
+			continue
+		}
+		fmt.Fprintln(h, line)
+		last = line
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))[:16]
+}
+
+// canonicalError returns the provided error message with its numbers and
+// quoted strings, which often embed op inputs, elided.
+func canonicalError(msg string) string {
+	msg = quoted.ReplaceAllString(msg, `"S"`)
+	msg = hexNumbers.ReplaceAllString(msg, "0xN")
+	return digits.ReplaceAllString(msg, "N")
+}
+
+// FailureCluster is a set of failing executions that share a fingerprint
+// (see Fingerprint), and are likely caused by the same bug.
+type FailureCluster struct {
+	Fingerprint string  // the shared fingerprint
+	Count       int     // number of failing executions in the cluster
+	Err         error   // error returned by an op of the reproducer
+	History     []Event // history of the reproducer
+
+	params hyperparameters // hyperparameters of the reproducer
+}
+
+// failureTracker clusters the failing executions of a simulation by
+// fingerprint. Of every cluster, it keeps the execution with the shortest
+// history as the cluster's reproducer. A failureTracker is shared by the
+// executors of a simulator.
+type failureTracker struct {
+	mu       sync.Mutex
+	first    result                     // the first failing execution
+	clusters map[string]*FailureCluster // by fingerprint
+	order    []string                   // fingerprints, in the order found
+}
+
+// newFailureTracker returns a new, empty failureTracker.
+func newFailureTracker() *failureTracker {
+	return &failureTracker{clusters: map[string]*FailureCluster{}}
+}
+
+// add adds a failing execution to its cluster.
+func (t *failureTracker) add(r result) {
+	fp := Fingerprint(r.history, r.err)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.first.err == nil {
+		t.first = r
+	}
+	c, ok := t.clusters[fp]
+	if !ok {
+		c = &FailureCluster{Fingerprint: fp}
+		t.clusters[fp] = c
+		t.order = append(t.order, fp)
+	}
+	c.Count++
+	if c.History == nil || len(r.history) < len(c.History) {
+		c.Err = r.err
+		c.History = r.history
+		c.params = r.params
+	}
+}
+
+// firstFailure returns the first failing execution added to the tracker, if
+// any.
+func (t *failureTracker) firstFailure() (result, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.first, t.first.err != nil
+}
+
+// failures returns the clusters tracked so far, largest first. Clusters of the
+// same size are ordered by when they were found.
+func (t *failureTracker) failures() []FailureCluster {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clusters := make([]FailureCluster, len(t.order))
+	for i, fp := range t.order {
+		clusters[i] = *t.clusters[fp]
+	}
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Count > clusters[j].Count
+	})
+	return clusters
+}
+
+// failureSummary returns a human readable summary of the failure clusters in
+// r.
+func (r *Results) failureSummary() string {
+	n := 0
+	for _, c := range r.Failures {
+		n += c.Count
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d failing executions in %d clusters:", n, len(r.Failures))
+	for _, c := range r.Failures {
+		fmt.Fprintf(&b, "\n  %s: %d executions, e.g., %v", c.Fingerprint, c.Count, c.Err)
+	}
+	return b.String()
+}
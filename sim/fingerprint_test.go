@@ -0,0 +1,128 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	history := func(amount string, method string) []Event {
+		return []Event{
+			EventOpStart{TraceID: 1, SpanID: 1, Name: "Deposit", Args: []string{amount}},
+			EventOpFinish{TraceID: 1, SpanID: 1, Error: "<nil>"},
+			EventOpStart{TraceID: 2, SpanID: 2, Name: "Withdraw", Args: []string{amount}},
+			EventCall{TraceID: 2, SpanID: 3, Parent: 2, Caller: "op", Component: "bank/Store", Method: method},
+			EventOpFinish{TraceID: 2, SpanID: 2, Error: "balance -" + amount},
+		}
+	}
+	a := Fingerprint(history("10", "Sub"), errors.New("balance -10"))
+	b := Fingerprint(history("20", "Sub"), errors.New("balance -20"))
+	c := Fingerprint(history("10", "Add"), errors.New("balance -10"))
+	d := Fingerprint(history("10", "Sub"), errors.New("overdrawn"))
+	if a != b {
+		t.Errorf("failures differing only in numbers: got fingerprints %s and %s, want equal", a, b)
+	}
+	if a == c {
+		t.Errorf("failures with different calls: got equal fingerprints %s", a)
+	}
+	if a == d {
+		t.Errorf("failures with different errors: got equal fingerprints %s", a)
+	}
+}
+
+func TestFingerprintIgnoresNoise(t *testing.T) {
+	// history returns the history of an execution in which op number trace
+	// calls Store.Get n times on the provided replica and fails.
+	history := func(trace, replica, n int, key string) []Event {
+		history := []Event{
+			EventOpStart{TraceID: 1, SpanID: 1, Name: "Put", Args: []string{key}},
+			EventCall{TraceID: 1, SpanID: 2, Parent: 1, Caller: "op", Component: "kv/Store", Method: "Put"},
+			EventOpFinish{TraceID: 1, SpanID: 1, Error: "<nil>"},
+			EventOpStart{TraceID: trace, SpanID: 10 * trace, Name: "Get", Args: []string{key}},
+		}
+		for i := 1; i <= n; i++ {
+			span := 10*trace + i
+			history = append(history,
+				EventCall{TraceID: trace, SpanID: span, Parent: 10 * trace, Caller: "op", Replica: trace, Component: "kv/Store", Method: "Get", Args: []string{key}},
+				EventDeliverCall{TraceID: trace, SpanID: span, Component: "kv/Store", Replica: replica},
+			)
+		}
+		return append(history, EventOpFinish{TraceID: trace, SpanID: 10 * trace, Error: "missing key " + key})
+	}
+	a := Fingerprint(history(2, 0, 1, `"a"`), errors.New(`missing key "a"`))
+	b := Fingerprint(history(3, 2, 5, `"b\"c"`), errors.New(`missing key "b\"c"`))
+	if a != b {
+		t.Errorf("failures differing only in ids, replicas, arguments, and loop counts: got fingerprints %s and %s, want equal", a, b)
+	}
+
+	// A failed call is not noise.
+	failed := append(history(2, 0, 1, `"a"`), EventDeliverError{TraceID: 2, SpanID: 21})
+	if c := Fingerprint(failed, errors.New(`missing key "a"`)); a == c {
+		t.Errorf("failures with and without a failed call: got equal fingerprints %s", a)
+	}
+}
+
+func TestKeepGoing(t *testing.T) {
+	s := New(t, &divideByZeroWorkload{}, Options{Seed: 1, MaxExecutions: 200, KeepGoing: true})
+	t.Cleanup(func() { os.RemoveAll(s.graveyardDir()) })
+	r := s.Run(time.Minute)
+	if r.Err == nil {
+		t.Fatal("Unexpected success")
+	}
+	if r.NumExecutions != 200 {
+		t.Fatalf("NumExecutions: got %d, want 200", r.NumExecutions)
+	}
+
+	n := 0
+	fingerprints := map[string]bool{}
+	for _, c := range r.Failures {
+		n += c.Count
+		if fingerprints[c.Fingerprint] {
+			t.Fatalf("duplicate cluster %s", c.Fingerprint)
+		}
+		fingerprints[c.Fingerprint] = true
+		if got := Fingerprint(c.History, c.Err); got != c.Fingerprint {
+			t.Fatalf("reproducer of cluster %s has fingerprint %s", c.Fingerprint, got)
+		}
+	}
+	if n <= len(r.Failures) {
+		t.Fatalf("%d failing executions in %d clusters, want fewer clusters", n, len(r.Failures))
+	}
+
+	// Only the reproducer of every cluster is written to the graveyard.
+	graveyard, err := readGraveyard(s.graveyardDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(graveyard) != len(r.Failures) {
+		t.Fatalf("graveyard: got %d entries, want %d", len(graveyard), len(r.Failures))
+	}
+}
+
+func TestStopAtFirstFailure(t *testing.T) {
+	s := New(t, &divideByZeroWorkload{}, Options{Seed: 1, MaxExecutions: 200, Parallelism: 1})
+	t.Cleanup(func() { os.RemoveAll(s.graveyardDir()) })
+	r := s.Run(time.Minute)
+	if r.Err == nil {
+		t.Fatal("Unexpected success")
+	}
+	if len(r.Failures) != 1 || r.Failures[0].Count != 1 {
+		t.Fatalf("Failures: got %+v, want a single failing execution", r.Failures)
+	}
+}
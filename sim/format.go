@@ -0,0 +1,131 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"unicode/utf8"
+
+	"github.com/ServiceWeaver/weaver/internal/redact"
+)
+
+// A Formatter formats the op arguments, method arguments, and method return
+// values recorded in a simulation's history.
+//
+// Every recorded value is formatted, so formatting large values can dominate
+// the cost of a simulation and bloat its history. MaxSize skips large values
+// without formatting them at all, and MaxLength bounds the length of every
+// formatted value.
+//
+// By default, values are formatted like fmt.Sprint, with their sensitive data
+// masked (see [weaver.Redactor]).
+type Formatter struct {
+	// If positive, values whose estimated in-memory size exceeds MaxSize bytes
+	// are not formatted. They are recorded as "<skipped T>" instead.
+	MaxSize int
+
+	// If positive, formatted values are truncated to at most MaxLength bytes,
+	// followed by a note of how many bytes were removed.
+	MaxLength int
+
+	// If true, values are formatted as JSON. Values that may contain sensitive
+	// data are formatted like fmt.Sprint with their sensitive data masked, as
+	// JSON encoding would not mask them.
+	JSON bool
+
+	// If non-nil, Func formats values instead of the default formatting.
+	// MaxSize and MaxLength still apply. Func is responsible for masking any
+	// sensitive data.
+	Func func(any) string
+}
+
+// Format formats x.
+func (f Formatter) Format(x any) string {
+	if f.MaxSize > 0 && x != nil && exceeds(reflect.ValueOf(x), f.MaxSize) {
+		return fmt.Sprintf("<skipped %T>", x)
+	}
+
+	var s string
+	switch {
+	case f.Func != nil:
+		s = f.Func(x)
+	case f.JSON && !redact.Sensitive(x):
+		if b, err := json.Marshal(x); err == nil {
+			s = string(b)
+		} else {
+			s = redact.String(x)
+		}
+	default:
+		s = redact.String(x)
+	}
+
+	if f.MaxLength > 0 && len(s) > f.MaxLength {
+		n := f.MaxLength
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			// Don't split a multi-byte rune.
+			n--
+		}
+		s = fmt.Sprintf("%s...(%d more bytes)", s[:n], len(s)-n)
+	}
+	return s
+}
+
+// exceeds returns whether the estimated in-memory size of v exceeds limit
+// bytes. exceeds stops walking v as soon as the limit is exceeded, so it is
+// cheap even for very large values.
+func exceeds(v reflect.Value, limit int) bool {
+	budget := limit
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		switch v.Kind() {
+		case reflect.Invalid:
+		case reflect.String:
+			budget -= v.Len()
+		case reflect.Slice:
+			if v.Type().Elem().Kind() == reflect.Uint8 {
+				budget -= v.Len()
+				return
+			}
+			fallthrough
+		case reflect.Array:
+			budget -= 8
+			for i := 0; i < v.Len() && budget >= 0; i++ {
+				walk(v.Index(i))
+			}
+		case reflect.Map:
+			budget -= 8
+			for iter := v.MapRange(); budget >= 0 && iter.Next(); {
+				walk(iter.Key())
+				walk(iter.Value())
+			}
+		case reflect.Struct:
+			budget--
+			for i := 0; i < v.NumField() && budget >= 0; i++ {
+				walk(v.Field(i))
+			}
+		case reflect.Pointer, reflect.Interface:
+			budget -= 8
+			if !v.IsNil() && budget >= 0 {
+				walk(v.Elem())
+			}
+		default:
+			budget -= 8
+		}
+	}
+	walk(v)
+	return budget < 0
+}
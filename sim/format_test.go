@@ -0,0 +1,59 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+// secret is a type with sensitive data. See TestFormatter.
+type secret struct {
+	User     string
+	Password string `weaver:"redact"`
+}
+
+func TestFormatter(t *testing.T) {
+	large := strings.Repeat("x", 1<<20)
+	for _, test := range []struct {
+		name      string
+		formatter Formatter
+		x         any
+		want      string
+	}{
+		{"Default", Formatter{}, []int{1, 2}, "[1 2]"},
+		{"DefaultRedacts", Formatter{}, secret{"alice", "hunter2"}, "{alice REDACTED}"},
+		{"JSON", Formatter{JSON: true}, map[string]int{"b": 2, "a": 1}, `{"a":1,"b":2}`},
+		{"JSONRedacts", Formatter{JSON: true}, secret{"alice", "hunter2"}, "{alice REDACTED}"},
+		{"JSONUnsupported", Formatter{JSON: true}, complex(1, 2), "(1+2i)"},
+		{"Truncate", Formatter{MaxLength: 5}, "abcdefgh", "abcde...(3 more bytes)"},
+		{"TruncateRune", Formatter{MaxLength: 2}, "aé", "a...(2 more bytes)"},
+		{"NoTruncate", Formatter{MaxLength: 5}, "abc", "abc"},
+		{"SkipString", Formatter{MaxSize: 1024}, large, "<skipped string>"},
+		{"SkipBytes", Formatter{MaxSize: 1024}, []byte(large), "<skipped []uint8>"},
+		{"SkipSlice", Formatter{MaxSize: 1024}, make([]int, 1000), "<skipped []int>"},
+		{"SkipMap", Formatter{MaxSize: 16}, map[int]int{1: 1, 2: 2}, "<skipped map[int]int>"},
+		{"SkipNested", Formatter{MaxSize: 1024}, &secret{User: large}, "<skipped *sim.secret>"},
+		{"NoSkip", Formatter{MaxSize: 1024}, "small", "small"},
+		{"Func", Formatter{Func: func(any) string { return "custom" }}, 1, "custom"},
+		{"FuncTruncate", Formatter{Func: func(any) string { return "custom" }, MaxLength: 3}, 1, "cus...(3 more bytes)"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.formatter.Format(test.x); got != test.want {
+				t.Fatalf("Format: got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+)
+
+// A history file stores a history as JSON, one event per line. Every line is
+// an object with the type of the event (e.g., "OpStart" for an EventOpStart)
+// and the event itself:
+//
+//	{"type":"OpStart","event":{"TraceID":1,"SpanID":1,"Name":"Add","Args":["2"]}}
+//	{"type":"OpFinish","event":{"TraceID":1,"SpanID":1,"Result":"","Error":"<nil>"}}
+
+// eventTypes maps the names of event types in history files to event types.
+var eventTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, e := range []Event{
+		EventOpStart{},
+		EventOpFinish{},
+		EventCall{},
+		EventDeliverCall{},
+		EventReturn{},
+		EventDeliverReturn{},
+		EventDeliverError{},
+		EventPanic{},
+		EventSnapshot{},
+		EventUpdateConfig{},
+		EventUpgrade{},
+	} {
+		t := reflect.TypeOf(e)
+		eventTypes[eventTypeName(t)] = t
+	}
+}
+
+// eventTypeName returns the name of an event type in history files.
+func eventTypeName(t reflect.Type) string {
+	return t.Name()[len("Event"):]
+}
+
+// historyLine is a line of a history file.
+type historyLine struct {
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event"`
+}
+
+// WriteHistory writes a history to w in the format read by ReadHistory and
+// "weaver sim debug".
+func WriteHistory(w io.Writer, history []Event) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, event := range history {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("encode %T: %w", event, err)
+		}
+		line := historyLine{eventTypeName(reflect.TypeOf(event)), data}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadHistory reads a history written by WriteHistory.
+func ReadHistory(r io.Reader) ([]Event, error) {
+	var history []Event
+	dec := json.NewDecoder(r)
+	for i := 1; ; i++ {
+		var line historyLine
+		if err := dec.Decode(&line); err == io.EOF {
+			return history, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		t, ok := eventTypes[line.Type]
+		if !ok {
+			return nil, fmt.Errorf("event %d: unknown event type %q", i, line.Type)
+		}
+		event := reflect.New(t)
+		if err := json.Unmarshal(line.Event, event.Interface()); err != nil {
+			return nil, fmt.Errorf("event %d: %w", i, err)
+		}
+		history = append(history, event.Elem().Interface().(Event))
+	}
+}
+
+// writeHistoryFile writes a history to a new file in the temporary directory
+// and returns the file's name.
+func writeHistoryFile(history []Event) (string, error) {
+	f, err := os.CreateTemp("", "weaver-sim-*.history")
+	if err != nil {
+		return "", err
+	}
+	if err := WriteHistory(f, history); err != nil {
+		f.Close()
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	history := []Event{
+		EventOpStart{TraceID: 1, SpanID: 1, Name: "Add", Args: []string{"1", "2"}},
+		EventCall{TraceID: 1, SpanID: 2, Parent: 1, Caller: "op", Replica: 1, Component: "a/B", Method: "Add", Args: []string{"1", "2"}},
+		EventDeliverCall{TraceID: 1, SpanID: 2, Component: "a/B", Replica: 0},
+		EventReturn{TraceID: 1, SpanID: 2, Component: "a/B", Replica: 0, Returns: []string{"3", "<nil>"}},
+		EventSnapshot{TraceID: 1, SpanID: 2, Component: "a/B", Replica: 0, State: "{sum:3}"},
+		EventDeliverReturn{TraceID: 1, SpanID: 2},
+		EventDeliverError{TraceID: 1, SpanID: 3},
+		EventPanic{TraceID: 1, SpanID: 3, Panicker: "a/B", Replica: 0, Error: "boom", Stack: "stack"},
+		EventOpFinish{TraceID: 1, SpanID: 1, Result: "3", Error: "<nil>"},
+	}
+	var b bytes.Buffer
+	if err := WriteHistory(&b, history); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadHistory(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(history, got); diff != "" {
+		t.Fatalf("ReadHistory (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadHistoryErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, contents, want string
+	}{
+		{"UnknownType", `{"type":"Foo","event":{}}`, `unknown event type "Foo"`},
+		{"Malformed", `{"type":`, "event 1"},
+		{"BadEvent", `{"type":"OpStart","event":{"TraceID":"x"}}`, "event 1"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ReadHistory(strings.NewReader(test.contents))
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("ReadHistory: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
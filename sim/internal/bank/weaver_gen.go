@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*Bank)(nil)).Elem(),
 		Impl:  reflect.TypeOf(bank{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return bank_local_stub{impl: impl.(Bank), tracer: tracer, depositMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Deposit", Remote: false, Generated: true}), withdrawMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Withdraw", Remote: false, Generated: true})}
+			return bank_local_stub{impl: impl.(Bank), tracer: tracer, caller: codegen.Caller{Component: caller}, depositMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Deposit", Remote: false, Generated: true}), withdrawMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Withdraw", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return bank_client_stub{stub: stub, depositMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Deposit", Remote: true, Generated: true}), withdrawMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Bank", Method: "Withdraw", Remote: true, Generated: true})}
@@ -30,14 +30,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return bank_reflect_stub{caller: caller}
 		},
-		RefData: "⟦dab0c530:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/internal/bank/Bank→github.com/ServiceWeaver/weaver/sim/internal/bank/Store⟧\n",
+		RefData:          "⟦dab0c530:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/internal/bank/Bank→github.com/ServiceWeaver/weaver/sim/internal/bank/Store⟧\n⟦1f0461a3:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/internal/bank/Bank.Deposit→github.com/ServiceWeaver/weaver/sim/internal/bank/Store.Add@bank.go:67:9⟧\n⟦97a1cbba:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/internal/bank/Bank.Withdraw→github.com/ServiceWeaver/weaver/sim/internal/bank/Store.Get@bank.go:75:18⟧\n⟦ef47b1db:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/internal/bank/Bank.Withdraw→github.com/ServiceWeaver/weaver/sim/internal/bank/Store.Add@bank.go:82:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e055a5f762009364",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/internal/bank/Store",
 		Iface: reflect.TypeOf((*Store)(nil)).Elem(),
 		Impl:  reflect.TypeOf(store{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return store_local_stub{impl: impl.(Store), tracer: tracer, addMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Add", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Get", Remote: false, Generated: true})}
+			return store_local_stub{impl: impl.(Store), tracer: tracer, caller: codegen.Caller{Component: caller}, addMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Add", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Get", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return store_client_stub{stub: stub, addMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Add", Remote: true, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/internal/bank/Store", Method: "Get", Remote: true, Generated: true})}
@@ -48,7 +51,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return store_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "cf184961c56dcef4",
 	})
 }
 
@@ -65,6 +71,7 @@ var _ weaver.Unrouted = (*store)(nil)
 type bank_local_stub struct {
 	impl            Bank
 	tracer          trace.Tracer
+	caller          codegen.Caller
 	depositMetrics  *codegen.MethodMetrics
 	withdrawMetrics *codegen.MethodMetrics
 }
@@ -76,6 +83,10 @@ func (s bank_local_stub) Deposit(ctx context.Context, a0 string, a1 int) (r0 int
 	// Update metrics.
 	begin := s.depositMetrics.Begin()
 	defer func() { s.depositMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -96,6 +107,10 @@ func (s bank_local_stub) Withdraw(ctx context.Context, a0 string, a1 int) (r0 in
 	// Update metrics.
 	begin := s.withdrawMetrics.Begin()
 	defer func() { s.withdrawMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -115,6 +130,7 @@ func (s bank_local_stub) Withdraw(ctx context.Context, a0 string, a1 int) (r0 in
 type store_local_stub struct {
 	impl       Store
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	addMetrics *codegen.MethodMetrics
 	getMetrics *codegen.MethodMetrics
 }
@@ -126,6 +142,10 @@ func (s store_local_stub) Add(ctx context.Context, a0 string, a1 int) (r0 int, e
 	// Update metrics.
 	begin := s.addMetrics.Begin()
 	defer func() { s.addMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -146,6 +166,10 @@ func (s store_local_stub) Get(ctx context.Context, a0 string) (r0 int, err error
 	// Update metrics.
 	begin := s.getMetrics.Begin()
 	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -206,7 +230,7 @@ func (s bank_client_stub) Deposit(ctx context.Context, a0 string, a1 int) (r0 in
 	size := 0
 	size += (4 + len(a0))
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -218,6 +242,7 @@ func (s bank_client_stub) Deposit(ctx context.Context, a0 string, a1 int) (r0 in
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -264,7 +289,7 @@ func (s bank_client_stub) Withdraw(ctx context.Context, a0 string, a1 int) (r0 i
 	size := 0
 	size += (4 + len(a0))
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -276,6 +301,7 @@ func (s bank_client_stub) Withdraw(ctx context.Context, a0 string, a1 int) (r0 i
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -331,7 +357,7 @@ func (s store_client_stub) Add(ctx context.Context, a0 string, a1 int) (r0 int,
 	size := 0
 	size += (4 + len(a0))
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -343,6 +369,7 @@ func (s store_client_stub) Add(ctx context.Context, a0 string, a1 int) (r0 int,
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -388,7 +415,7 @@ func (s store_client_stub) Get(ctx context.Context, a0 string) (r0 int, err erro
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -399,6 +426,7 @@ func (s store_client_stub) Get(ctx context.Context, a0 string) (r0 int, err erro
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -415,10 +443,10 @@ func (s store_client_stub) Get(ctx context.Context, a0 string) (r0 int, err erro
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
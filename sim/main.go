@@ -0,0 +1,175 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// suite holds the workloads registered with Register.
+var suite struct {
+	mu        sync.Mutex
+	workloads map[string]registered // by test name
+}
+
+// registered is a workload registered with Register.
+type registered struct {
+	workload Workload
+	opts     Options
+}
+
+// Register registers a workload to be simulated by [Main] in a test named
+// name. Register is typically called from an init function in a _test.go
+// file:
+//
+//	func init() {
+//	    sim.Register("TestBankSimulation", &bankWorkload{}, sim.Options{})
+//	}
+//
+// Register panics if name is already registered.
+func Register(name string, x Workload, opts Options) {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	if _, ok := suite.workloads[name]; ok {
+		panic(fmt.Sprintf("sim.Register: workload %q already registered", name))
+	}
+	if suite.workloads == nil {
+		suite.workloads = map[string]registered{}
+	}
+	suite.workloads[name] = registered{x, opts}
+}
+
+// MainOptions configure [Main].
+type MainOptions struct {
+	// Duration is the simulation budget of every registered workload. It can
+	// be overridden with the -sim.duration flag. If zero, it defaults to 10
+	// seconds, or one second when tests run with -short.
+	Duration time.Duration
+}
+
+// Main runs the simulation suite of a test binary and then its regular tests.
+// It is meant to be called from TestMain:
+//
+//	func TestMain(m *testing.M) {
+//	    sim.Main(m, sim.MainOptions{Duration: time.Minute})
+//	}
+//
+// Every workload registered with [Register] is simulated in a top-level test
+// of the registered name. Simulation tests behave like regular tests: -run
+// and -skip select which of them run, -v logs their progress, -count repeats
+// them, and a workload that finds a failing execution fails its test. The
+// -sim.profile flag profiles the steps of every simulation (see
+// [Options.ProfileSteps]), the -sim.pct=d flag simulates every workload with a
+// PCT scheduler of bug depth d (see [NewPCTScheduler]), and the
+// -sim.tags=smoke,bank flag only runs the ops tagged with at least one of the
+// comma-separated tags (see [Registrar.RegisterTags]). Main calls os.Exit and
+// does not return.
+func Main(m *testing.M, opts MainOptions) {
+	duration := flag.Duration("sim.duration", 0, "Simulation budget of every registered workload")
+	profile := flag.Bool("sim.profile", false, "Profile the steps of every registered workload's simulation")
+	pct := flag.Int("sim.pct", 0, "If positive, simulate every registered workload with a PCT scheduler of this bug depth")
+	tags := flag.String("sim.tags", "", "If non-empty, only run the ops tagged with at least one of these comma-separated tags")
+	flag.Parse()
+
+	budget := opts.Duration
+	switch {
+	case *duration != 0:
+		budget = *duration
+	case budget == 0 && testing.Short():
+		budget = time.Second
+	case budget == 0:
+		budget = 10 * time.Second
+	}
+
+	tests := suiteTests(budget, *profile, *pct, *tags)
+	ok := true
+	if selected(tests) {
+		ok = testing.RunTests(matchString, tests)
+	}
+	code := m.Run()
+	if !ok && code == 0 {
+		fmt.Println("FAIL")
+		code = 1
+	}
+	os.Exit(code)
+}
+
+// suiteTests returns a test for every registered workload, sorted by name. If
+// profile is true, every simulation profiles its steps. If pct is positive,
+// every simulation uses a PCT scheduler with bug depth pct. If tags is not
+// empty, every simulation only runs the ops tagged with at least one of the
+// comma-separated tags.
+func suiteTests(budget time.Duration, profile bool, pct int, tags string) []testing.InternalTest {
+	suite.mu.Lock()
+	defer suite.mu.Unlock()
+	var tests []testing.InternalTest
+	for name, w := range suite.workloads {
+		w := w
+		tests = append(tests, testing.InternalTest{
+			Name: name,
+			F: func(t *testing.T) {
+				opts := w.opts
+				opts.ProfileSteps = opts.ProfileSteps || profile
+				if pct > 0 {
+					opts.Scheduler = func() Scheduler { return NewPCTScheduler(pct) }
+				}
+				if tags != "" {
+					opts.OpFilter = Tagged(strings.Split(tags, ",")...)
+				}
+				s := New(t, w.workload, opts)
+				if r := s.Run(budget); r.Err != nil {
+					t.Fatal(r.Err)
+				}
+			},
+		})
+	}
+	sort.Slice(tests, func(i, j int) bool { return tests[i].Name < tests[j].Name })
+	return tests
+}
+
+// selected returns whether any of the provided tests is selected by the -run
+// flag. testing.RunTests warns when no test is selected, which is noise when
+// -run only selects regular tests.
+func selected(tests []testing.InternalTest) bool {
+	pattern := ""
+	if f := flag.Lookup("test.run"); f != nil {
+		pattern = f.Value.String()
+	}
+	if pattern == "" {
+		return len(tests) > 0
+	}
+	// Only the top-level part of the pattern applies to test names.
+	top, _, _ := strings.Cut(pattern, "/")
+	for _, test := range tests {
+		if ok, err := matchString(top, test.Name); err != nil || ok {
+			// Let testing.RunTests report invalid patterns.
+			return true
+		}
+	}
+	return false
+}
+
+// matchString is the matchString function passed to testing.RunTests.
+func matchString(pat, str string) (bool, error) {
+	return regexp.MatchString(pat, str)
+}
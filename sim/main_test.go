@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestRegister(t *testing.T) {
+	defer func() { suite.workloads = nil }()
+	Register("TestB", &divModWorkload{}, Options{})
+	Register("TestA", &divModWorkload{}, Options{})
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Register(duplicate): unexpected success")
+			}
+		}()
+		Register("TestA", &divModWorkload{}, Options{})
+	}()
+
+	tests := suiteTests(time.Second, false, 0, "")
+	var names []string
+	for _, test := range tests {
+		names = append(names, test.Name)
+	}
+	if len(names) != 2 || names[0] != "TestA" || names[1] != "TestB" {
+		t.Fatalf("suiteTests: got %v, want [TestA TestB]", names)
+	}
+}
+
+func TestSelected(t *testing.T) {
+	run := flag.Lookup("test.run")
+	old := run.Value.String()
+	defer run.Value.Set(old)
+
+	tests := []testing.InternalTest{{Name: "TestBankSimulation"}, {Name: "TestCartSimulation"}}
+	for _, test := range []struct {
+		pattern string
+		want    bool
+	}{
+		{"", true},
+		{"Simulation", true},
+		{"Bank", true},
+		{"Bank/Sub", true},
+		{"TestOther", false},
+		{"Other/Bank", false},
+	} {
+		run.Value.Set(test.pattern)
+		if got := selected(tests); got != test.want {
+			t.Errorf("selected(-run=%q): got %t, want %t", test.pattern, got, test.want)
+		}
+	}
+	run.Value.Set("")
+	if selected(nil) {
+		t.Error("selected(no tests): got true, want false")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"encoding"
+	"net/netip"
+	"reflect"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// The simulator passes method arguments and results to components directly,
+// without serializing them. For most types, this is indistinguishable from
+// serializing them. Types with custom marshalers, however, may not survive
+// serialization unchanged. A custom marshaler may drop fields, normalize
+// values, or fail outright. To find bugs caused by these differences, the
+// simulator serializes and deserializes every top-level argument and result
+// whose type has a custom marshaler.
+
+var (
+	autoMarshalType       = reflect.TypeOf((*codegen.AutoMarshal)(nil)).Elem()
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+
+	// Types that "weaver generate" serializes natively, even though they
+	// implement encoding.BinaryMarshaler.
+	nativeTypes = map[reflect.Type]bool{
+		reflect.TypeOf(time.Time{}):    true,
+		reflect.TypeOf(netip.Addr{}):   true,
+		reflect.TypeOf(netip.Prefix{}): true,
+	}
+)
+
+// roundTripAll round trips every value in vs in place. See roundTrip.
+func roundTripAll(vs []reflect.Value) error {
+	for i, v := range vs {
+		rt, err := roundTrip(v)
+		if err != nil {
+			return err
+		}
+		vs[i] = rt
+	}
+	return nil
+}
+
+// roundTrip serializes and deserializes v, returning the deserialized value,
+// if v's type T (or *T) implements codegen.Marshaler and codegen.Unmarshaler,
+// or encoding.BinaryMarshaler and encoding.BinaryUnmarshaler. Otherwise, v is
+// returned unchanged. An error is returned if serialization fails.
+func roundTrip(v reflect.Value) (result reflect.Value, err error) {
+	if !v.IsValid() {
+		return v, nil
+	}
+
+	// Determine the type that implements the marshaler, and whether v is a
+	// value of that type or a pointer to one.
+	t := v.Type()
+	var elem reflect.Type
+	var ptr bool
+	switch {
+	case t.Kind() == reflect.Pointer && hasCustomMarshaler(t):
+		elem, ptr = t.Elem(), true
+	case hasCustomMarshaler(reflect.PointerTo(t)):
+		elem, ptr = t, false
+	default:
+		return v, nil
+	}
+	if ptr && v.IsNil() {
+		return v, nil
+	}
+
+	defer func() { err = codegen.CatchPanics(recover()) }()
+
+	// Serialize v.
+	src := v
+	if !ptr {
+		src = reflect.New(elem)
+		src.Elem().Set(v)
+	}
+	enc := codegen.NewEncoder()
+	if m, ok := src.Interface().(codegen.AutoMarshal); ok {
+		m.WeaverMarshal(enc)
+	} else {
+		enc.EncodeBinaryMarshaler(src.Interface().(encoding.BinaryMarshaler))
+	}
+
+	// Deserialize v.
+	dst := reflect.New(elem)
+	dec := codegen.NewDecoder(enc.Data())
+	if m, ok := dst.Interface().(codegen.AutoMarshal); ok {
+		m.WeaverUnmarshal(dec)
+	} else {
+		dec.DecodeBinaryUnmarshaler(dst.Interface().(encoding.BinaryUnmarshaler))
+	}
+	if ptr {
+		return dst, nil
+	}
+	return dst.Elem(), nil
+}
+
+// hasCustomMarshaler returns whether the pointer type t has a custom
+// marshaler.
+func hasCustomMarshaler(t reflect.Type) bool {
+	if nativeTypes[t.Elem()] {
+		return false
+	}
+	return t.Implements(autoMarshalType) ||
+		(t.Implements(binaryMarshalerType) && t.Implements(binaryUnmarshalerType))
+}
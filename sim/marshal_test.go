@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+)
+
+// lossy is a type with a custom marshaler that drops its cache. See
+// TestRoundTrip.
+type lossy struct {
+	Value int
+	cache string
+}
+
+func (l *lossy) WeaverMarshal(enc *codegen.Encoder)   { enc.Int(l.Value) }
+func (l *lossy) WeaverUnmarshal(dec *codegen.Decoder) { l.Value = dec.Int() }
+
+// unmarshalable is a type whose MarshalBinary method fails. See TestRoundTrip.
+type unmarshalable struct{}
+
+func (unmarshalable) MarshalBinary() ([]byte, error) { return nil, errors.New("oops") }
+func (*unmarshalable) UnmarshalBinary([]byte) error  { return nil }
+
+func TestRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   any
+		want any
+	}{
+		{"Int", 42, 42},
+		{"Slice", []int{1, 2}, []int{1, 2}},
+		{"Time", time.Unix(1, 0), time.Unix(1, 0)},
+		{"Value", lossy{1, "x"}, lossy{1, ""}},
+		{"Pointer", &lossy{1, "x"}, &lossy{1, ""}},
+		{"NilPointer", (*lossy)(nil), (*lossy)(nil)},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := roundTrip(reflect.ValueOf(test.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got.Interface(), test.want) {
+				t.Fatalf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRoundTripPointerIsCopied(t *testing.T) {
+	in := &lossy{Value: 1}
+	got, err := roundTrip(reflect.ValueOf(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Interface().(*lossy) == in {
+		t.Fatal("round tripped pointer aliases the original")
+	}
+}
+
+func TestRoundTripError(t *testing.T) {
+	_, err := roundTrip(reflect.ValueOf(unmarshalable{}))
+	if err == nil || !strings.Contains(err.Error(), "oops") {
+		t.Fatalf("got %v, want error containing %q", err, "oops")
+	}
+}
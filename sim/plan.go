@@ -0,0 +1,394 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+)
+
+// A Plan is a candidate deployment plan for an application, evaluated by
+// Simulator.Plan.
+type Plan struct {
+	// Name identifies the plan in reports.
+	Name string
+
+	// Colocate lists groups of components, by full name, that run in the same
+	// process. Calls between components in the same group are local; calls
+	// between components in different groups incur the network latency of the
+	// cost model. Components not listed run in their own group.
+	Colocate [][]string
+
+	// Replicas holds the number of replicas of the group of every listed
+	// component. If the components of a group have different replica counts,
+	// the largest is used. Groups without a replica count have one replica.
+	Replicas map[string]int
+}
+
+// A CostModel describes the latency and cost of running an application.
+type CostModel struct {
+	// Latencies holds the time it takes a replica to execute a method,
+	// excluding the time spent waiting for the methods it calls, keyed by
+	// "<component>.<method>" where <component> is the full component name
+	// (e.g., "github.com/example/app/Cart.Add").
+	Latencies map[string]time.Duration
+
+	// DefaultLatency is the latency of the methods missing from Latencies.
+	DefaultLatency time.Duration
+
+	// NetworkLatency is the round trip latency of a call between components
+	// in different groups.
+	NetworkLatency time.Duration
+
+	// ReplicaCost is the cost of running a replica for an hour.
+	ReplicaCost float64
+}
+
+// PlanOptions configure Simulator.Plan.
+type PlanOptions struct {
+	CostModel
+
+	// Rate is the target workload, in ops per second. Ops arrive randomly,
+	// following a Poisson process.
+	Rate float64
+
+	// Duration is the length of simulated time during which ops arrive. If
+	// zero, a minute is used.
+	Duration time.Duration
+
+	// Samples is the number of ops executed to learn the calls made by every
+	// op. If zero, 100 ops are executed.
+	Samples int
+
+	// Seed seeds the random number generator used to pick and schedule ops.
+	Seed int64
+}
+
+// PlanResult is the expected performance of a plan under a target workload.
+type PlanResult struct {
+	Plan        Plan               // the plan
+	NumOps      int                // number of simulated ops
+	P50         time.Duration      // median op latency
+	P99         time.Duration      // 99th percentile op latency
+	Replicas    int                // total number of replicas
+	Cost        float64            // cost of running the replicas for an hour
+	Utilization map[string]float64 // fraction of time the replicas of every component's group are busy
+}
+
+// String returns a one line summary of r.
+func (r PlanResult) String() string {
+	return fmt.Sprintf("%s: p50=%v p99=%v replicas=%d cost=%.2f/h", r.Plan.Name, r.P50, r.P99, r.Replicas, r.Cost)
+}
+
+// Plan estimates the latency and cost of every provided plan when serving the
+// target workload described by opts. It is a capacity planning aid: rather
+// than looking for bugs, like Run does, it compares the expected p50 and p99
+// op latencies and the cost of different replica counts and colocations.
+//
+// Plan first executes a sample of ops to learn which methods every op calls.
+// Then, for every plan, it simulates the arrival of ops at the target rate.
+// Every method call waits for a free replica of its group, occupies the
+// replica for the method's latency, and then performs its own calls in the
+// order they were made. Calls between groups add the network latency.
+func (s *Simulator) Plan(opts PlanOptions, plans ...Plan) []PlanResult {
+	s.t.Helper()
+	if opts.Rate <= 0 {
+		s.t.Fatalf("Simulator.Plan: Rate (%v) <= 0", opts.Rate)
+	}
+	if opts.Duration == 0 {
+		opts.Duration = time.Minute
+	}
+	if opts.Samples == 0 {
+		opts.Samples = 100
+	}
+
+	ops, err := s.sampleOps(opts.Samples, opts.Seed)
+	if err != nil {
+		s.t.Fatalf("Simulator.Plan: %v", err)
+	}
+
+	var results []PlanResult
+	for _, plan := range plans {
+		groups, err := s.planGroups(plan, ops)
+		if err != nil {
+			s.t.Fatalf("Simulator.Plan: plan %q: %v", plan.Name, err)
+		}
+		r := simulatePlan(plan, groups, ops, opts)
+		s.t.Log(r.String())
+		results = append(results, r)
+	}
+	return results
+}
+
+// A callTree is a method call, or an op, along with the calls it made.
+type callTree struct {
+	component string      // called component, or "" for an op
+	method    string      // called method, or the op name
+	children  []*callTree // calls made, in order
+}
+
+// sampleOps executes n ops one at a time, without failures, and returns their
+// call trees.
+func (s *Simulator) sampleOps(n int, seed int64) ([]*callTree, error) {
+	e := newExecutor(s.t, s.w, s.regsByIntf, s.info, s.config, s.opts.Format)
+	params := hyperparameters{Seed: seed, NumReplicas: 1, NumOps: n}
+	r, err := e.execute(context.Background(), params)
+	if err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, fmt.Errorf("sample op failed: %w", r.err)
+	}
+
+	var ops []*callTree
+	spans := map[int]*callTree{}
+	for _, event := range r.history {
+		switch x := event.(type) {
+		case EventOpStart:
+			op := &callTree{method: x.Name}
+			spans[x.SpanID] = op
+			ops = append(ops, op)
+		case EventCall:
+			call := &callTree{component: x.Component, method: x.Method}
+			spans[x.SpanID] = call
+			if parent, ok := spans[x.Parent]; ok {
+				parent.children = append(parent.children, call)
+			}
+		}
+	}
+	return ops, nil
+}
+
+// planGroup is a colocation group of a plan being simulated.
+type planGroup struct {
+	components []string       // components in the group
+	replicas   int            // number of replicas
+	busy       int            // number of busy replicas
+	queue      []*pendingCall // calls waiting for a replica
+	busyTime   time.Duration  // total time replicas spent busy
+}
+
+// planGroups returns the colocation group of every component called by ops.
+func (s *Simulator) planGroups(plan Plan, ops []*callTree) (map[string]*planGroup, error) {
+	known := map[string]bool{}
+	for _, reg := range s.regsByIntf {
+		known[reg.Name] = true
+	}
+
+	groups := map[string]*planGroup{}
+	for _, colocated := range plan.Colocate {
+		g := &planGroup{replicas: 1}
+		for _, name := range colocated {
+			if !known[name] {
+				return nil, fmt.Errorf("unknown component %q", name)
+			}
+			if _, ok := groups[name]; ok {
+				return nil, fmt.Errorf("component %q colocated more than once", name)
+			}
+			g.components = append(g.components, name)
+			groups[name] = g
+		}
+	}
+	var visit func(*callTree)
+	visit = func(t *callTree) {
+		if _, ok := groups[t.component]; t.component != "" && !ok {
+			groups[t.component] = &planGroup{components: []string{t.component}, replicas: 1}
+		}
+		for _, child := range t.children {
+			visit(child)
+		}
+	}
+	for _, op := range ops {
+		visit(op)
+	}
+
+	for name, n := range plan.Replicas {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown component %q", name)
+		}
+		if n <= 0 {
+			return nil, fmt.Errorf("component %q has %d replicas", name, n)
+		}
+		g, ok := groups[name]
+		if !ok {
+			// The component isn't called by the workload.
+			continue
+		}
+		g.replicas = max(g.replicas, n)
+	}
+	return groups, nil
+}
+
+// pendingCall is an op or method call in progress.
+type pendingCall struct {
+	tree   *callTree
+	group  *planGroup   // group executing the call, or nil for an op
+	parent *pendingCall // caller, or nil for an op
+	next   int          // index of the next child to call
+	start  time.Duration
+}
+
+// planEvent is a scheduled step of a plan simulation.
+type planEvent struct {
+	at  time.Duration
+	seq int // breaks ties, for determinism
+	fn  func(now time.Duration)
+}
+
+// planEvents is a priority queue of events, ordered by time.
+type planEvents []planEvent
+
+func (p planEvents) Len() int { return len(p) }
+func (p planEvents) Less(i, j int) bool {
+	if p[i].at != p[j].at {
+		return p[i].at < p[j].at
+	}
+	return p[i].seq < p[j].seq
+}
+func (p planEvents) Swap(i, j int) { p[i], p[j] = p[j], p[i] }
+func (p *planEvents) Push(x any)   { *p = append(*p, x.(planEvent)) }
+func (p *planEvents) Pop() any {
+	old := *p
+	x := old[len(old)-1]
+	*p = old[:len(old)-1]
+	return x
+}
+
+// simulatePlan simulates the provided plan serving the target workload.
+func simulatePlan(plan Plan, groups map[string]*planGroup, ops []*callTree, opts PlanOptions) PlanResult {
+	var events planEvents
+	seq := 0
+	schedule := func(at time.Duration, fn func(time.Duration)) {
+		seq++
+		heap.Push(&events, planEvent{at, seq, fn})
+	}
+
+	latency := func(c *pendingCall) time.Duration {
+		if d, ok := opts.Latencies[c.tree.component+"."+c.tree.method]; ok {
+			return d
+		}
+		return opts.DefaultLatency
+	}
+	// hop returns the one way network latency of a call from caller to callee.
+	// Calls made by ops enter the application from outside, so they don't
+	// depend on the plan.
+	hop := func(caller, callee *pendingCall) time.Duration {
+		if caller.group == nil || caller.group == callee.group {
+			return 0
+		}
+		return opts.NetworkLatency / 2
+	}
+
+	var latencies []time.Duration
+	var resume, arrive func(*pendingCall, time.Duration)
+	var release func(*planGroup, time.Duration)
+
+	// resume makes the next call of c, or returns from c if it has made all
+	// its calls.
+	resume = func(c *pendingCall, now time.Duration) {
+		if c.next < len(c.tree.children) {
+			child := c.tree.children[c.next]
+			c.next++
+			callee := &pendingCall{tree: child, group: groups[child.component], parent: c}
+			schedule(now+hop(c, callee), func(now time.Duration) { arrive(callee, now) })
+			return
+		}
+		if c.parent == nil {
+			latencies = append(latencies, now-c.start)
+			return
+		}
+		schedule(now+hop(c.parent, c), func(now time.Duration) { resume(c.parent, now) })
+	}
+
+	// start occupies a replica of c's group for the duration of c.
+	start := func(c *pendingCall, now time.Duration) {
+		g := c.group
+		g.busy++
+		d := latency(c)
+		g.busyTime += d
+		schedule(now+d, func(now time.Duration) {
+			release(g, now)
+			resume(c, now)
+		})
+	}
+
+	// arrive delivers c to its group, which executes it when a replica is
+	// free.
+	arrive = func(c *pendingCall, now time.Duration) {
+		if c.group.busy < c.group.replicas {
+			start(c, now)
+			return
+		}
+		c.group.queue = append(c.group.queue, c)
+	}
+
+	// release frees a replica of g, which executes the next queued call, if
+	// any.
+	release = func(g *planGroup, now time.Duration) {
+		g.busy--
+		if len(g.queue) > 0 {
+			c := g.queue[0]
+			g.queue = g.queue[1:]
+			start(c, now)
+		}
+	}
+
+	// Schedule the arrival of ops.
+	r := rand.New(&wyrand{uint64(opts.Seed)})
+	numOps := 0
+	for now := time.Duration(0); ; {
+		now += time.Duration(r.ExpFloat64() / opts.Rate * float64(time.Second))
+		if now >= opts.Duration {
+			break
+		}
+		op := &pendingCall{tree: pick(r, ops), start: now}
+		schedule(now, func(now time.Duration) { resume(op, now) })
+		numOps++
+	}
+
+	// Run the simulation.
+	end := opts.Duration
+	for events.Len() > 0 {
+		e := heap.Pop(&events).(planEvent)
+		end = max(end, e.at)
+		e.fn(e.at)
+	}
+
+	// Summarize the results.
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result := PlanResult{
+		Plan:        plan,
+		NumOps:      numOps,
+		P50:         imetrics.NearestRank(latencies, 0.5),
+		P99:         imetrics.NearestRank(latencies, 0.99),
+		Utilization: map[string]float64{},
+	}
+	seen := map[*planGroup]bool{}
+	for name, g := range groups {
+		result.Utilization[name] = float64(g.busyTime) / (float64(g.replicas) * float64(end))
+		if !seen[g] {
+			seen[g] = true
+			result.Replicas += g.replicas
+		}
+	}
+	result.Cost = float64(result.Replicas) * opts.ReplicaCost
+	return result
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// divModOnlyWorkload calls DivMod, which calls Div and Mod, which each call
+// Identity twice.
+type divModOnlyWorkload struct {
+	divmod weaver.Ref[divMod]
+}
+
+func (d *divModOnlyWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("DivMod", NonNegativeInt(), positive)
+	return nil
+}
+
+func (d *divModOnlyWorkload) DivMod(ctx context.Context, x, y int) error {
+	_, _, err := d.divmod.Get().DivMod(ctx, x, y)
+	return err
+}
+
+const (
+	divModName   = "github.com/ServiceWeaver/weaver/sim/divMod"
+	divName      = "github.com/ServiceWeaver/weaver/sim/div"
+	modName      = "github.com/ServiceWeaver/weaver/sim/mod"
+	identityName = "github.com/ServiceWeaver/weaver/sim/identity"
+)
+
+func TestPlanColocation(t *testing.T) {
+	s := New(t, &divModOnlyWorkload{}, Options{})
+	opts := PlanOptions{
+		CostModel: CostModel{
+			DefaultLatency: time.Millisecond,
+			NetworkLatency: 10 * time.Millisecond,
+			ReplicaCost:    0.5,
+		},
+		// A low rate, so that ops don't queue.
+		Rate:     0.1,
+		Duration: 10 * time.Minute,
+	}
+	split := Plan{Name: "split"}
+	colocated := Plan{
+		Name:     "colocated",
+		Colocate: [][]string{{divModName, divName, modName, identityName}},
+	}
+	results := s.Plan(opts, split, colocated)
+
+	// An op makes 7 method calls, 6 of which are between components.
+	for i, want := range []time.Duration{67 * time.Millisecond, 7 * time.Millisecond} {
+		r := results[i]
+		if r.NumOps == 0 {
+			t.Fatalf("%s: no ops simulated", r.Plan.Name)
+		}
+		if r.P50 != want || r.P99 != want {
+			t.Errorf("%s: got p50=%v p99=%v, want %v", r.Plan.Name, r.P50, r.P99, want)
+		}
+	}
+	if got, want := results[0].Cost, 2.0; got != want {
+		t.Errorf("split: got cost %v, want %v", got, want)
+	}
+	if got, want := results[1].Cost, 0.5; got != want {
+		t.Errorf("colocated: got cost %v, want %v", got, want)
+	}
+}
+
+func TestPlanReplicas(t *testing.T) {
+	s := New(t, &divModOnlyWorkload{}, Options{})
+	opts := PlanOptions{
+		CostModel: CostModel{
+			Latencies: map[string]time.Duration{
+				identityName + ".Identity": 10 * time.Millisecond,
+			},
+			DefaultLatency: time.Millisecond,
+		},
+		// Identity is called 4 times per op, for 40ms. At 20 ops per second,
+		// a single replica of Identity is 80% busy.
+		Rate:     20,
+		Duration: time.Minute,
+	}
+	one := Plan{Name: "one"}
+	four := Plan{Name: "four", Replicas: map[string]int{identityName: 4}}
+	results := s.Plan(opts, one, four)
+
+	if got := results[0].Utilization[identityName]; got < 0.7 || got > 0.9 {
+		t.Errorf("one: got identity utilization %v, want ~0.8", got)
+	}
+	if got := results[1].Utilization[identityName]; got < 0.15 || got > 0.25 {
+		t.Errorf("four: got identity utilization %v, want ~0.2", got)
+	}
+	if results[1].P99 >= results[0].P99 {
+		t.Errorf("four replicas p99 (%v) not lower than one replica p99 (%v)", results[1].P99, results[0].P99)
+	}
+	if got, want := results[1].Replicas, 7; got != want {
+		t.Errorf("four: got %d replicas, want %d", got, want)
+	}
+}
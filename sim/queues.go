@@ -0,0 +1,185 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/runtime/logging"
+)
+
+// The simulator queues every method call between the moment it is made and
+// the moment the executor delivers it to a replica, and every delivered call
+// executes until it returns. The simulator has no clock, so waits are
+// measured in steps (see executor.step). Queueing statistics show which
+// components calls pile up in front of, which is where a real deployment
+// would see pending calls if the component's handlers were slow.
+
+// QueueStats are the queueing statistics of a component during a simulation.
+type QueueStats struct {
+	Calls      int64 `json:"calls"`       // calls dequeued, i.e., delivered or failed before delivery
+	WaitSteps  int64 `json:"wait_steps"`  // total number of steps calls waited before being dequeued
+	MaxWait    int64 `json:"max_wait"`    // maximum number of steps a call waited
+	MaxPending int   `json:"max_pending"` // maximum number of calls queued at once in an execution
+	MaxActive  int   `json:"max_active"`  // maximum number of calls executing at once in an execution
+}
+
+// MeanWait returns the average number of steps a call waited before being
+// dequeued.
+func (q QueueStats) MeanWait() float64 {
+	if q.Calls == 0 {
+		return 0
+	}
+	return float64(q.WaitSteps) / float64(q.Calls)
+}
+
+// merge merges o into q.
+func (q *QueueStats) merge(o *QueueStats) {
+	q.Calls += o.Calls
+	q.WaitSteps += o.WaitSteps
+	q.MaxWait = max(q.MaxWait, o.MaxWait)
+	q.MaxPending = max(q.MaxPending, o.MaxPending)
+	q.MaxActive = max(q.MaxActive, o.MaxActive)
+}
+
+// queues tracks the queueing statistics of an execution. It is not safe for
+// concurrent use; the executor guards it with its mutex.
+type queues struct {
+	pending map[string]int         // number of queued calls, by component
+	active  map[string]int         // number of executing calls, by component
+	stats   map[string]*QueueStats // statistics not yet merged, by component
+}
+
+func newQueues() queues {
+	return queues{
+		pending: map[string]int{},
+		active:  map[string]int{},
+		stats:   map[string]*QueueStats{},
+	}
+}
+
+// reset prepares the queues for a new execution.
+func (q *queues) reset() {
+	clear(q.pending)
+	clear(q.active)
+}
+
+func (q *queues) get(component string) *QueueStats {
+	s, ok := q.stats[component]
+	if !ok {
+		s = &QueueStats{}
+		q.stats[component] = s
+	}
+	return s
+}
+
+// enqueue records that a call to the provided component was queued.
+func (q *queues) enqueue(component string) {
+	q.pending[component]++
+	s := q.get(component)
+	s.MaxPending = max(s.MaxPending, q.pending[component])
+}
+
+// dequeue records that a call to the provided component that waited the
+// provided number of steps was dequeued.
+func (q *queues) dequeue(component string, wait int64) {
+	q.pending[component]--
+	s := q.get(component)
+	s.Calls++
+	s.WaitSteps += wait
+	s.MaxWait = max(s.MaxWait, wait)
+}
+
+// begin records that a call to the provided component started executing.
+func (q *queues) begin(component string) {
+	q.active[component]++
+	s := q.get(component)
+	s.MaxActive = max(s.MaxActive, q.active[component])
+}
+
+// end records that a call to the provided component finished executing.
+func (q *queues) end(component string) {
+	q.active[component]--
+}
+
+// queueTracker aggregates the queueing statistics of every execution of a
+// simulation. A queueTracker is shared by the executors of a simulator.
+type queueTracker struct {
+	mu    sync.Mutex
+	stats map[string]*QueueStats // by component
+}
+
+func newQueueTracker() *queueTracker {
+	return &queueTracker{stats: map[string]*QueueStats{}}
+}
+
+// merge merges and clears the statistics of the provided queues.
+func (t *queueTracker) merge(q *queues) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for component, s := range q.stats {
+		total, ok := t.stats[component]
+		if !ok {
+			total = &QueueStats{}
+			t.stats[component] = total
+		}
+		total.merge(s)
+	}
+	clear(q.stats)
+}
+
+// snapshot returns the statistics tracked so far, by component.
+func (t *queueTracker) snapshot() map[string]QueueStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := make(map[string]QueueStats, len(t.stats))
+	for component, s := range t.stats {
+		stats[component] = *s
+	}
+	return stats
+}
+
+// reportQueues populates the queueing statistics of the provided results and
+// logs them.
+func (s *Simulator) reportQueues(results *Results) {
+	if s.queues == nil {
+		return
+	}
+	results.Queues = s.queues.snapshot()
+	if len(results.Queues) > 0 {
+		s.t.Log(results.queueSummary())
+	}
+}
+
+// queueSummary returns a table of the queueing statistics in r.
+func (r *Results) queueSummary() string {
+	components := make([]string, 0, len(r.Queues))
+	for component := range r.Queues {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	var b strings.Builder
+	fmt.Fprintln(&b, "Queueing statistics (waits in steps):")
+	fmt.Fprintf(&b, "%-30s %10s %10s %10s %12s %11s\n", "component", "calls", "mean wait", "max wait", "max pending", "max active")
+	for _, component := range components {
+		q := r.Queues[component]
+		fmt.Fprintf(&b, "%-30s %10d %10.2f %10d %12d %11d\n", logging.ShortenComponent(component), q.Calls, q.MeanWait(), q.MaxWait, q.MaxPending, q.MaxActive)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueueStats(t *testing.T) {
+	s := New(t, &scenarioWorkload{}, Options{MaxExecutions: 100})
+	r := s.Run(time.Minute)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	q, ok := r.Queues["github.com/ServiceWeaver/weaver/sim/identity"]
+	if !ok {
+		t.Fatalf("no queueing statistics for identity: %v", r.Queues)
+	}
+	if q.Calls == 0 {
+		t.Errorf("Calls: got 0, want > 0")
+	}
+	if q.MaxPending < 1 || q.MaxActive < 1 {
+		t.Errorf("MaxPending, MaxActive: got %d, %d, want >= 1", q.MaxPending, q.MaxActive)
+	}
+	if q.MeanWait() <= 0 || float64(q.MaxWait) < q.MeanWait() {
+		t.Errorf("MeanWait, MaxWait: got %v, %d, want 0 < mean <= max", q.MeanWait(), q.MaxWait)
+	}
+	if got := r.queueSummary(); !strings.Contains(got, "sim.identity") {
+		t.Errorf("queueSummary: got %q, want identity row", got)
+	}
+}
+
+func TestMergeQueueStats(t *testing.T) {
+	q := QueueStats{Calls: 2, WaitSteps: 4, MaxWait: 3, MaxPending: 1, MaxActive: 2}
+	q.merge(&QueueStats{Calls: 1, WaitSteps: 5, MaxWait: 5, MaxPending: 2, MaxActive: 1})
+	want := QueueStats{Calls: 3, WaitSteps: 9, MaxWait: 5, MaxPending: 2, MaxActive: 2}
+	if q != want {
+		t.Fatalf("merge: got %+v, want %+v", q, want)
+	}
+	if got := q.MeanWait(); got != 3 {
+		t.Fatalf("MeanWait: got %v, want 3", got)
+	}
+}
@@ -19,9 +19,13 @@ import (
 	"fmt"
 	"math/rand"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+	swruntime "github.com/ServiceWeaver/weaver/runtime"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
 )
 
 // registrar is the canonical Registrar implementation. A registrar is not safe
@@ -37,16 +41,19 @@ import (
 //	}
 type registrar struct {
 	// Immutable fields.
-	t          testing.TB                // underlying test
-	registered map[reflect.Type]struct{} // registered component interfaces
-	opsByName  map[string]int            // index into ops, by op name
+	t          testing.TB                      // underlying test
+	registered map[reflect.Type]struct{}       // registered component interfaces
+	opsByName  map[string]int                  // index into ops, by op name
+	validate   func(key, section string) error // validates config update sections
 
 	// Cached after the first execution.
 	typeInfo map[string][]generatorTypeInfo // generator type info
 
 	// Updated for every execution.
-	fakes map[reflect.Type]any // fakes, by component interface
-	ops   []*op                // operations
+	fakes    map[reflect.Type]any          // fakes, by component interface
+	ops      []*op                         // operations
+	updates  []map[string]string           // config update sections, by component name
+	upgrades map[reflect.Type]reflect.Type // upgraded implementations, by component interface
 }
 
 var _ Registrar = &registrar{}
@@ -58,24 +65,26 @@ type generatorTypeInfo struct {
 }
 
 // newRegistrar returns a new registrar.
-func newRegistrar(t testing.TB, w reflect.Type, registered map[reflect.Type]struct{}) *registrar {
+func newRegistrar(t testing.TB, w reflect.Type, registered map[reflect.Type]struct{}, validate func(key, section string) error) *registrar {
 	// Gather the set of ops.
 	ops := []*op{}
 	opsByName := map[string]int{}
 	for i := 0; i < w.NumMethod(); i++ {
 		m := w.Method(i)
-		if m.Name == "Init" {
+		if m.Name == "Init" || isCheck(w, m) {
 			continue
 		}
 		arity := m.Type.NumIn() - 2 // ignore receiver and context arguments
-		ops = append(ops, &op{m, make([]generator, 0, arity)})
+		ops = append(ops, &op{m, make([]generator, 0, arity), nil})
 		opsByName[m.Name] = len(ops) - 1
 	}
 
 	return &registrar{
 		t:          t,
 		registered: registered,
+		validate:   validate,
 		fakes:      map[reflect.Type]any{},
+		upgrades:   map[reflect.Type]reflect.Type{},
 		typeInfo:   map[string][]generatorTypeInfo{},
 		ops:        ops,
 		opsByName:  opsByName,
@@ -89,6 +98,11 @@ func (r *registrar) reset() {
 	}
 	for _, op := range r.ops {
 		op.generators = op.generators[:0]
+		op.tags = op.tags[:0]
+	}
+	r.updates = r.updates[:0]
+	for k := range r.upgrades {
+		delete(r.upgrades, k)
 	}
 }
 
@@ -108,6 +122,30 @@ func (r *registrar) RegisterGenerators(method string, generators ...any) {
 	}
 }
 
+// RegisterTags implements the Registrar interface.
+func (r *registrar) RegisterTags(method string, tags ...string) {
+	r.t.Helper()
+	if err := r.registerTags(method, tags...); err != nil {
+		r.t.Fatalf("RegisterTags: %v", err)
+	}
+}
+
+// RegisterConfigUpdate implements the Registrar interface.
+func (r *registrar) RegisterConfigUpdate(config string) {
+	r.t.Helper()
+	if err := r.registerConfigUpdate(config); err != nil {
+		r.t.Fatalf("RegisterConfigUpdate: %v", err)
+	}
+}
+
+// RegisterUpgrade implements the Registrar interface.
+func (r *registrar) RegisterUpgrade(upgrade UpgradeComponent) {
+	r.t.Helper()
+	if err := r.registerUpgrade(upgrade); err != nil {
+		r.t.Fatalf("RegisterUpgrade: %v", err)
+	}
+}
+
 // registerFakes implements RegisterFakes.
 func (r *registrar) registerFakes(fake FakeComponent) error {
 	if _, ok := r.fakes[fake.intf]; ok {
@@ -217,6 +255,75 @@ func (r *registrar) registerGenerators(method string, generators ...any) error {
 	return err
 }
 
+// registerTags implements RegisterTags.
+func (r *registrar) registerTags(method string, tags ...string) error {
+	i, ok := r.opsByName[method]
+	if !ok {
+		return fmt.Errorf("method %q not found", method)
+	}
+	op := r.ops[i]
+	if len(op.tags) > 0 {
+		return fmt.Errorf("method %q tags already registered", method)
+	}
+	for _, tag := range tags {
+		if !validTag(tag) {
+			return fmt.Errorf("method %q: invalid tag %q", method, tag)
+		}
+	}
+	op.tags = append(op.tags, tags...)
+	return nil
+}
+
+// validTag returns whether the provided op tag is valid: non-empty, without
+// commas or spaces, so that tags can be listed in flags like -sim.tags.
+func validTag(tag string) bool {
+	return tag != "" && !strings.ContainsAny(tag, ", \t\n")
+}
+
+// registerUpgrade implements RegisterUpgrade.
+func (r *registrar) registerUpgrade(upgrade UpgradeComponent) error {
+	if _, ok := r.upgrades[upgrade.intf]; ok {
+		return fmt.Errorf("upgrade for %v already registered", upgrade.intf)
+	}
+	if _, ok := r.registered[upgrade.intf]; !ok {
+		return fmt.Errorf("component %v not found", upgrade.intf)
+	}
+	r.upgrades[upgrade.intf] = upgrade.impl
+	return nil
+}
+
+// registerConfigUpdate implements RegisterConfigUpdate.
+func (r *registrar) registerConfigUpdate(config string) error {
+	app, err := swruntime.ParseConfig("", config, r.validate)
+	if err != nil {
+		return err
+	}
+	if len(app.Sections) == 0 {
+		return fmt.Errorf("empty config update")
+	}
+	r.updates = append(r.updates, app.Sections)
+	return nil
+}
+
+// configValidator returns a function that validates a config update section
+// for the component with the provided name. The component must be one of the
+// provided components, and it must embed weaver.WithConfig.
+func configValidator(regsByIntf map[reflect.Type]*codegen.Registration) func(key, section string) error {
+	return func(key, section string) error {
+		for _, reg := range regsByIntf {
+			if reg.Name != key {
+				continue
+			}
+			cfg := weaver.GetConfig(reflect.New(reg.Impl).Interface())
+			if cfg == nil {
+				return fmt.Errorf("component %q does not embed weaver.WithConfig", key)
+			}
+			return swruntime.ParseConfigSection(key, "", map[string]string{key: section}, cfg)
+		}
+		return fmt.Errorf("section %q is not the section of a registered component", key)
+	}
+}
+
 // finalize finalizes registration.
 func (r *registrar) finalize() error {
 	var errs []error
@@ -226,5 +333,10 @@ func (r *registrar) finalize() error {
 			errs = append(errs, fmt.Errorf("no generators registered for method %s", op.m.Name))
 		}
 	}
+	for intf := range r.upgrades {
+		if _, ok := r.fakes[intf]; ok {
+			errs = append(errs, fmt.Errorf("component %v is both faked and upgraded", intf))
+		}
+	}
 	return errors.Join(errs...)
 }
@@ -27,10 +27,12 @@ import (
 // newTestRegistrar[T] returns a new registrar for workload type T.
 func newTestRegistrar[T Workload](t *testing.T) *registrar {
 	registered := map[reflect.Type]struct{}{}
+	regsByIntf := map[reflect.Type]*codegen.Registration{}
 	for _, reg := range codegen.Registered() {
 		registered[reg.Iface] = struct{}{}
+		regsByIntf[reg.Iface] = reg
 	}
-	return newRegistrar(t, reflection.Type[T](), registered)
+	return newRegistrar(t, reflection.Type[T](), registered, configValidator(regsByIntf))
 }
 
 func TestDuplicateRegisterGeneratorsCalls(t *testing.T) {
@@ -137,3 +139,74 @@ func TestChangeGeneratorType(t *testing.T) {
 		t.Errorf("Error does not contain %q:\n%s", want, err.Error())
 	}
 }
+
+func TestRegisterConfigUpdate(t *testing.T) {
+	r := newTestRegistrar[*divModWorkload](t)
+	for _, test := range []struct {
+		name   string
+		config string
+		want   string // error substring, or "" for success
+	}{
+		{"Valid", "[\"github.com/ServiceWeaver/weaver/sim/limiter\"]\nMax = 10", ""},
+		{"Empty", "", "empty config update"},
+		{"UnknownComponent", "[\"github.com/ServiceWeaver/weaver/sim/missing\"]\nMax = 10", "not the section of a registered component"},
+		{"NoConfig", "[\"github.com/ServiceWeaver/weaver/sim/div\"]\nMax = 10", "does not embed weaver.WithConfig"},
+		{"UnknownKey", "[\"github.com/ServiceWeaver/weaver/sim/limiter\"]\nMin = 10", "unknown keys"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := r.registerConfigUpdate(test.config)
+			switch {
+			case test.want == "" && err != nil:
+				t.Fatal(err)
+			case test.want != "" && (err == nil || !strings.Contains(err.Error(), test.want)):
+				t.Fatalf("registerConfigUpdate: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterInvalidTags(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		method string
+		tags   []string
+		want   string
+	}{
+		{"MissingMethod", "Foo", []string{"smoke"}, "not found"},
+		{"EmptyTag", "Div", []string{"smoke", ""}, "invalid tag"},
+		{"Comma", "Div", []string{"smoke,nightly"}, "invalid tag"},
+		{"Space", "Div", []string{"smoke test"}, "invalid tag"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := newTestRegistrar[*divModWorkload](t)
+			err := r.registerTags(test.method, test.tags...)
+			if err == nil {
+				t.Fatal("unexpected success")
+			}
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("Error does not contain %q:\n%s", test.want, err.Error())
+			}
+		})
+	}
+}
+
+func TestDuplicateRegisterTagsCalls(t *testing.T) {
+	r := newTestRegistrar[*divModWorkload](t)
+	if err := r.registerTags("Div", "smoke"); err != nil {
+		t.Fatal(err)
+	}
+	err := r.registerTags("Div", "nightly")
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	const want = "already registered"
+	if !strings.Contains(err.Error(), want) {
+		t.Errorf("Error does not contain %q:\n%s", want, err.Error())
+	}
+
+	// Tags are registered anew in every execution.
+	r.reset()
+	if err := r.registerTags("Div", "nightly"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// A Scenario is a fixed set of ops, with fixed arguments, that a simulator
+// runs instead of randomly generated ops. See Options.Scenario.
+type Scenario struct {
+	Ops []ScenarioOp
+}
+
+// A ScenarioOp is an op of a Scenario.
+type ScenarioOp struct {
+	// Name is the name of the workload method, e.g., "Deposit".
+	Name string
+
+	// Args holds the arguments of the op, excluding the context. Every
+	// argument must be assignable or convertible to the type of the
+	// corresponding method parameter. Slices are converted element by
+	// element, so that, for example, a []int64 can be passed for a []int.
+	Args []any
+
+	// After holds the indices, in Scenario.Ops, of the ops that must finish
+	// before this op starts. Every index must be smaller than the index of
+	// this op. Ops that don't depend on each other may run concurrently.
+	After []int
+}
+
+// SpanOp describes how to convert a span into a ScenarioOp. See
+// ScenarioFromTraces.
+type SpanOp struct {
+	// Op is the name of the workload method.
+	Op string
+
+	// Args holds the keys of the span attributes that hold the arguments of
+	// the op, in order. A missing attribute is an error.
+	Args []string
+}
+
+// ScenarioFromTraces converts traces, like traces exported by a production
+// deployment, into a scenario that replays them, so that an incident can be
+// reproduced in the simulator, with injected failures.
+//
+// ops maps span names to the ops they are converted to. Spans with other
+// names, and spans nested within a converted span, are ignored, since the
+// calls they trace are made by the op. The arguments of an op are read from
+// the attributes of its span, e.g., attributes recorded with
+// weaver.SpanFromContext(ctx).SetAttributes. For example:
+//
+//	scenario, err := sim.ScenarioFromTraces(spans, map[string]sim.SpanOp{
+//	    "bank.Bank.Deposit":  {Op: "Deposit", Args: []string{"user", "amount"}},
+//	    "bank.Bank.Withdraw": {Op: "Withdraw", Args: []string{"user", "amount"}},
+//	})
+//
+// The ops are ordered by the start times of their spans, and every op runs
+// after the ops whose spans ended before its span started, preserving the
+// causal order of the traces. Spans stored by a deployer can be read from
+// its trace database with traces.DB.FetchSpans and wrapped in
+// traces.ReadSpan.
+func ScenarioFromTraces(spans []sdktrace.ReadOnlySpan, ops map[string]SpanOp) (*Scenario, error) {
+	// Find the spans that are converted to ops.
+	converted := map[trace.SpanID]bool{}
+	for _, span := range spans {
+		if _, ok := ops[span.Name()]; ok {
+			converted[span.SpanContext().SpanID()] = true
+		}
+	}
+	parents := map[trace.SpanID]trace.SpanID{}
+	for _, span := range spans {
+		if span.Parent().IsValid() {
+			parents[span.SpanContext().SpanID()] = span.Parent().SpanID()
+		}
+	}
+	nested := func(span sdktrace.ReadOnlySpan) bool {
+		seen := map[trace.SpanID]bool{}
+		for id, ok := parents[span.SpanContext().SpanID()]; ok && !seen[id]; id, ok = parents[id] {
+			if converted[id] {
+				return true
+			}
+			seen[id] = true
+		}
+		return false
+	}
+	var roots []sdktrace.ReadOnlySpan
+	for _, span := range spans {
+		if converted[span.SpanContext().SpanID()] && !nested(span) {
+			roots = append(roots, span)
+		}
+	}
+	sort.SliceStable(roots, func(i, j int) bool {
+		return roots[i].StartTime().Before(roots[j].StartTime())
+	})
+
+	// Convert the spans.
+	scenario := &Scenario{Ops: make([]ScenarioOp, len(roots))}
+	for j, span := range roots {
+		spanOp := ops[span.Name()]
+		attrs := map[attribute.Key]attribute.Value{}
+		for _, kv := range span.Attributes() {
+			attrs[kv.Key] = kv.Value
+		}
+		op := ScenarioOp{Name: spanOp.Op, Args: make([]any, len(spanOp.Args))}
+		for i, key := range spanOp.Args {
+			v, ok := attrs[attribute.Key(key)]
+			if !ok {
+				return nil, fmt.Errorf("span %s %v: missing attribute %q", span.Name(), span.SpanContext().SpanID(), key)
+			}
+			op.Args[i] = v.AsInterface()
+		}
+
+		// Depend on the ops that ended before this op started, except for
+		// those that ended before another one of them started, since the
+		// dependency is implied.
+		start := span.StartTime()
+		var before []int
+		for i := 0; i < j; i++ {
+			if !roots[i].EndTime().After(start) {
+				before = append(before, i)
+			}
+		}
+		for _, i := range before {
+			implied := false
+			for _, k := range before {
+				if k != i && !roots[i].EndTime().After(roots[k].StartTime()) {
+					implied = true
+					break
+				}
+			}
+			if !implied {
+				op.After = append(op.After, i)
+			}
+		}
+		scenario.Ops[j] = op
+	}
+	return scenario, nil
+}
+
+// scenarioOp is a validated ScenarioOp.
+type scenarioOp struct {
+	op    *op   // the op, with generators that return its arguments
+	after []int // indices of the ops that must finish first
+}
+
+// compileScenario validates the provided scenario of a workload of type w.
+func compileScenario(w reflect.Type, scenario *Scenario) ([]*scenarioOp, error) {
+	if len(scenario.Ops) == 0 {
+		return nil, fmt.Errorf("empty scenario")
+	}
+	compiled := make([]*scenarioOp, len(scenario.Ops))
+	for i, o := range scenario.Ops {
+		m, ok := w.MethodByName(o.Name)
+		if !ok || m.Name == "Init" || isCheck(w, m) {
+			return nil, fmt.Errorf("scenario op %d: op %q not found", i, o.Name)
+		}
+		if got, want := len(o.Args), m.Type.NumIn()-2; got != want {
+			return nil, fmt.Errorf("scenario op %d: %s: got %d arguments, want %d", i, o.Name, got, want)
+		}
+		generators := make([]generator, len(o.Args))
+		for j, arg := range o.Args {
+			t := m.Type.In(j + 2)
+			if _, err := convertArg(arg, t); err != nil {
+				return nil, fmt.Errorf("scenario op %d: %s: argument %d: %w", i, o.Name, j, err)
+			}
+			// Convert the argument for every execution, so that an op
+			// that modifies its arguments doesn't affect later executions.
+			arg := arg
+			generators[j] = func(*rand.Rand) reflect.Value {
+				v, _ := convertArg(arg, t)
+				return v
+			}
+		}
+		for _, a := range o.After {
+			if a < 0 || a >= i {
+				return nil, fmt.Errorf("scenario op %d: %s: invalid After index %d", i, o.Name, a)
+			}
+		}
+		compiled[i] = &scenarioOp{op: &op{m: m, generators: generators}, after: o.After}
+	}
+	return compiled, nil
+}
+
+// convertArg converts x to a value of type t.
+func convertArg(x any, t reflect.Type) (reflect.Value, error) {
+	if x == nil {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			return reflect.Zero(t), nil
+		}
+		return reflect.Value{}, fmt.Errorf("cannot use nil as %v", t)
+	}
+	v := reflect.ValueOf(x)
+	switch {
+	case v.Type().AssignableTo(t):
+		if v.Kind() == reflect.Slice && t.Kind() == reflect.Slice {
+			// Copy the slice; see compileScenario.
+			c := reflect.MakeSlice(t, v.Len(), v.Len())
+			reflect.Copy(c, v)
+			return c, nil
+		}
+		x := reflect.New(t).Elem()
+		x.Set(v)
+		return x, nil
+	case v.Kind() == reflect.Slice && t.Kind() == reflect.Slice:
+		c := reflect.MakeSlice(t, v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			e, err := convertArg(v.Index(i).Interface(), t.Elem())
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("element %d: %w", i, err)
+			}
+			c.Index(i).Set(e)
+		}
+		return c, nil
+	case convertible(v.Type(), t) && v.CanConvert(t):
+		return v.Convert(t), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot convert %v to %v", v.Type(), t)
+}
+
+// convertible returns whether a value of type from can be meaningfully
+// converted to type to: numbers to numbers, booleans to booleans, and strings
+// to strings. Other conversions Go allows, like from an integer to a string,
+// are not meaningful for arguments.
+func convertible(from, to reflect.Type) bool {
+	kind := func(t reflect.Type) int {
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+			reflect.Float32, reflect.Float64:
+			return 1
+		case reflect.Bool:
+			return 2
+		case reflect.String:
+			return 3
+		default:
+			return 0
+		}
+	}
+	return kind(from) != 0 && kind(from) == kind(to)
+}
+
+// ready returns whether the dependencies of the i-th op of the scenario
+// (1-indexed, like trace ids) have finished.
+func (e *executor) ready(i int) bool {
+	for _, a := range e.scenario[i-1].after {
+		if e.notFinished.has(a + 1) {
+			return false
+		}
+	}
+	return true
+}
+
+// pickStarted returns a random op that has started but not finished.
+func (e *executor) pickStarted() int {
+	started := make([]int, 0, e.notFinished.size())
+	for _, id := range e.notFinished.elements {
+		if id <= e.numStarted {
+			started = append(started, id)
+		}
+	}
+	return started[e.rand.Intn(len(started))]
+}
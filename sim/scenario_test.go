@@ -0,0 +1,231 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type user string
+
+type scenarioWorkload struct {
+	id weaver.Ref[identity]
+}
+
+func (*scenarioWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Put", Range(0, 10), OneOf[user]("alice", "bob", "carol"))
+	r.RegisterGenerators("Sum", Slice(Range(0, 10), Int()))
+	return nil
+}
+
+func (w *scenarioWorkload) Put(ctx context.Context, x int, u user) error {
+	_, err := w.id.Get().Identity(ctx, x)
+	if errors.Is(err, weaver.RemoteCallError) {
+		return nil
+	}
+	return err
+}
+
+func (w *scenarioWorkload) Sum(ctx context.Context, xs []int) (int, error) {
+	sum := 0
+	for _, x := range xs {
+		y, err := w.id.Get().Identity(ctx, x)
+		if errors.Is(err, weaver.RemoteCallError) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		sum += y
+	}
+	return sum, nil
+}
+
+// recordSpans records spans with the provided names, attributes, and start
+// and end times, in seconds. A span with a non-empty parent is a child of the
+// previously recorded span with that name.
+func recordSpans(t *testing.T, spans []testSpan) []sdktrace.ReadOnlySpan {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	epoch := time.Now()
+	ctxs := map[string]context.Context{}
+	for _, s := range spans {
+		ctx := context.Background()
+		if s.parent != "" {
+			ctx = ctxs[s.parent]
+		}
+		ctx, span := tracer.Start(ctx, s.name,
+			trace.WithTimestamp(epoch.Add(time.Duration(s.start)*time.Second)),
+			trace.WithAttributes(s.attrs...))
+		span.End(trace.WithTimestamp(epoch.Add(time.Duration(s.end) * time.Second)))
+		ctxs[s.name] = ctx
+	}
+	return recorder.Ended()
+}
+
+type testSpan struct {
+	name       string
+	parent     string
+	start, end int
+	attrs      []attribute.KeyValue
+}
+
+func TestScenarioFromTraces(t *testing.T) {
+	spans := recordSpans(t, []testSpan{
+		{name: "put1", start: 0, end: 10, attrs: []attribute.KeyValue{attribute.Int("x", 1), attribute.String("user", "alice")}},
+		{name: "put2", start: 5, end: 15, attrs: []attribute.KeyValue{attribute.Int("x", 2), attribute.String("user", "bob")}},
+		{name: "sum1", parent: "put1", start: 1, end: 2, attrs: []attribute.KeyValue{attribute.IntSlice("xs", []int{0})}},
+		{name: "unrelated", start: 16, end: 17},
+		{name: "sum2", start: 20, end: 30, attrs: []attribute.KeyValue{attribute.IntSlice("xs", []int{1, 2})}},
+		{name: "put3", start: 40, end: 50, attrs: []attribute.KeyValue{attribute.Int("x", 3), attribute.String("user", "carol")}},
+	})
+	put := SpanOp{Op: "Put", Args: []string{"x", "user"}}
+	sum := SpanOp{Op: "Sum", Args: []string{"xs"}}
+	ops := map[string]SpanOp{"put1": put, "put2": put, "put3": put, "sum1": sum, "sum2": sum}
+	got, err := ScenarioFromTraces(spans, ops)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Scenario{Ops: []ScenarioOp{
+		{Name: "Put", Args: []any{int64(1), "alice"}},
+		{Name: "Put", Args: []any{int64(2), "bob"}},
+		// sum1 is nested in put1. sum2 runs after put1 and put2.
+		{Name: "Sum", Args: []any{[]int64{1, 2}}, After: []int{0, 1}},
+		// put3 runs after sum2, and therefore after put1 and put2.
+		{Name: "Put", Args: []any{int64(3), "carol"}, After: []int{2}},
+	}}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("ScenarioFromTraces (-want +got):\n%s", diff)
+	}
+
+	// The compiled scenario converts the arguments.
+	s := New(t, &scenarioWorkload{}, Options{Scenario: got})
+	var args [][]any
+	for _, o := range s.scenario {
+		var a []any
+		for _, g := range o.op.generators {
+			a = append(a, g(nil).Interface())
+		}
+		args = append(args, a)
+	}
+	wantArgs := [][]any{{1, user("alice")}, {2, user("bob")}, {[]int{1, 2}}, {3, user("carol")}}
+	if diff := cmp.Diff(wantArgs, args); diff != "" {
+		t.Fatalf("compiled arguments (-want +got):\n%s", diff)
+	}
+}
+
+func TestScenarioFromTracesMissingAttribute(t *testing.T) {
+	spans := recordSpans(t, []testSpan{{name: "put", start: 0, end: 1}})
+	_, err := ScenarioFromTraces(spans, map[string]SpanOp{"put": {Op: "Put", Args: []string{"x", "user"}}})
+	if err == nil || !strings.Contains(err.Error(), `missing attribute "x"`) {
+		t.Fatalf("ScenarioFromTraces: got %v, want missing attribute error", err)
+	}
+}
+
+func TestScenarioExecution(t *testing.T) {
+	scenario := &Scenario{Ops: []ScenarioOp{
+		{Name: "Put", Args: []any{1, user("alice")}},
+		{Name: "Sum", Args: []any{[]int{1, 2, 3}}},
+		{Name: "Put", Args: []any{2, user("bob")}, After: []int{0, 1}},
+		{Name: "Sum", Args: []any{[]int64{4, 5}}},
+		{Name: "Put", Args: []any{int64(3), "carol"}, After: []int{3}},
+	}}
+	s := New(t, &scenarioWorkload{}, Options{Scenario: scenario})
+	exec := s.newExecutor()
+	for seed := int64(0); seed < 100; seed++ {
+		params := hyperparameters{Seed: seed, NumReplicas: 2, NumOps: 1, FailureRate: 0.1, YieldRate: 0.5}
+		r, err := exec.execute(context.Background(), params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+
+		// Every op of the scenario runs, in order, with its arguments.
+		var names, args []string
+		finished := map[int]bool{}
+		for _, event := range r.history {
+			switch x := event.(type) {
+			case EventOpStart:
+				names = append(names, x.Name)
+				args = append(args, strings.Join(x.Args, " "))
+				for _, after := range scenario.Ops[x.TraceID-1].After {
+					if !finished[after+1] {
+						t.Fatalf("seed %d: op %d started before op %d finished", seed, x.TraceID, after+1)
+					}
+				}
+			case EventOpFinish:
+				finished[x.TraceID] = true
+			}
+		}
+		if diff := cmp.Diff([]string{"Put", "Sum", "Put", "Sum", "Put"}, names); diff != "" {
+			t.Fatalf("seed %d: ops (-want +got):\n%s", seed, diff)
+		}
+		if diff := cmp.Diff([]string{"1 alice", "[1 2 3]", "2 bob", "[4 5]", "3 carol"}, args); diff != "" {
+			t.Fatalf("seed %d: args (-want +got):\n%s", seed, diff)
+		}
+		if len(finished) != len(scenario.Ops) {
+			t.Fatalf("seed %d: %d ops finished, want %d", seed, len(finished), len(scenario.Ops))
+		}
+	}
+
+	// Run the scenario with the full sweep of hyperparameters.
+	if r := s.Run(time.Second); r.Err != nil {
+		t.Fatal(r.Err)
+	}
+}
+
+func TestCompileScenarioErrors(t *testing.T) {
+	w := reflect.TypeOf(&scenarioWorkload{})
+	for _, test := range []struct {
+		op   ScenarioOp
+		want string
+	}{
+		{ScenarioOp{Name: "Missing"}, `op "Missing" not found`},
+		{ScenarioOp{Name: "Init"}, `op "Init" not found`},
+		{ScenarioOp{Name: "Put", Args: []any{1}}, "got 1 arguments, want 2"},
+		{ScenarioOp{Name: "Put", Args: []any{"1", "alice"}}, "cannot convert string to int"},
+		{ScenarioOp{Name: "Put", Args: []any{1, 2}}, "cannot convert int to sim.user"},
+		{ScenarioOp{Name: "Sum", Args: []any{[]string{"1"}}}, "element 0: cannot convert string to int"},
+		{ScenarioOp{Name: "Put", Args: []any{nil, "alice"}}, "cannot use nil as int"},
+		{ScenarioOp{Name: "Sum", Args: []any{nil}, After: []int{1}}, "invalid After index 1"},
+	} {
+		t.Run(fmt.Sprint(test.op), func(t *testing.T) {
+			_, err := compileScenario(w, &Scenario{Ops: []ScenarioOp{test.op}})
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Fatalf("compileScenario: got %v, want error containing %q", err, test.want)
+			}
+		})
+	}
+	if _, err := compileScenario(w, &Scenario{}); err == nil {
+		t.Fatal("compileScenario: unexpected success for an empty scenario")
+	}
+}
@@ -0,0 +1,436 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+)
+
+// StepKind is the kind of a step an execution can take.
+type StepKind int
+
+const (
+	StepStartOp      StepKind = iota // start the next op
+	StepDeliverCall                  // deliver a pending method call to a replica
+	StepDeliverReply                 // return the reply of a method call to its caller
+	StepUpdateConfig                 // apply a pending config update to a replica
+	StepUpgrade                      // upgrade a replica to a new implementation
+)
+
+func (k StepKind) String() string {
+	switch k {
+	case StepStartOp:
+		return "StepStartOp"
+	case StepDeliverCall:
+		return "StepDeliverCall"
+	case StepDeliverReply:
+		return "StepDeliverReply"
+	case StepUpdateConfig:
+		return "StepUpdateConfig"
+	case StepUpgrade:
+		return "StepUpgrade"
+	default:
+		return fmt.Sprintf("StepKind(%d)", int(k))
+	}
+}
+
+// A Candidate is a step that an execution can take next.
+type Candidate struct {
+	Kind StepKind
+
+	// The op the step belongs to, i.e., the trace id of the op, or 0 for
+	// config updates and upgrades. Ops are numbered 1, 2, ... in the order
+	// they start, and the op started by a StepStartOp candidate is the op
+	// with the next number.
+	TraceID int
+
+	// The span of the method call that is delivered or replied to, if Kind
+	// is StepDeliverCall or StepDeliverReply.
+	SpanID int
+
+	// The name of the op to start, if Kind is StepStartOp.
+	Op string
+
+	// The component and replica of a StepUpdateConfig or StepUpgrade step,
+	// or the component and method called by a StepDeliverCall or
+	// StepDeliverReply step. Replica is -1 for calls and replies, which are
+	// delivered to a random replica.
+	Component string
+	Method    string
+	Replica   int
+
+	// The resource whose code the step runs; see executor.randFor.
+	resource string
+
+	// The step the candidate takes.
+	call    *call
+	reply   *reply
+	update  *configUpdate
+	upgrade *upgrade
+}
+
+// A Scheduler decides which step an execution takes next, among the steps it
+// can take. The order in which the steps of concurrent ops are taken, and
+// calls and replies are delivered, determines which interleavings of the ops
+// a simulation explores. See the "Schedulers" section of the package
+// documentation.
+//
+// A simulator creates one Scheduler per executor, and an executor runs one
+// execution at a time, so a Scheduler is not used concurrently. To keep
+// executions reproducible, a Scheduler's decisions must only depend on its
+// inputs and the random number generator passed to Start.
+type Scheduler interface {
+	// Start is called at the start of every execution, with the execution's
+	// random number generator and the number of ops the execution runs.
+	Start(r *rand.Rand, numOps int)
+
+	// Next returns the index of the candidate step to take next. Next is
+	// only called with a non-empty slice of candidates, in a deterministic
+	// order, and must not retain it.
+	Next(candidates []Candidate) int
+}
+
+// NewRandomScheduler returns a Scheduler that takes a step picked uniformly
+// at random among the candidates.
+func NewRandomScheduler() Scheduler {
+	return &randomScheduler{}
+}
+
+type randomScheduler struct {
+	rand *rand.Rand
+}
+
+func (s *randomScheduler) Start(r *rand.Rand, _ int) {
+	s.rand = r
+}
+
+func (s *randomScheduler) Next(candidates []Candidate) int {
+	return s.rand.Intn(len(candidates))
+}
+
+// NewPCTScheduler returns a Scheduler that implements probabilistic
+// concurrency testing (PCT) [1] with the provided bug depth. Every op, and
+// config updates and upgrades as a whole, gets a random priority, and the
+// scheduler always takes a step of the highest priority op that can take one.
+// At depth-1 distinct random steps of an execution, the scheduler lowers the
+// priority of the op it is running below that of every other op. The depth of
+// a bug is the number of ordering constraints between steps needed for the
+// bug to manifest; most concurrency bugs have a depth of 1 or 2. PCT finds a
+// bug of depth d <= depth with probability at least 1/(n k^(d-1)) in every
+// execution with n ops and k steps, while a random scheduler may only find it
+// with a probability that is exponentially small in k.
+//
+// The number of steps an execution takes isn't known in advance, so the
+// scheduler estimates it from the executions it has run so far.
+//
+// NewPCTScheduler panics if depth is smaller than 1.
+//
+// [1]: https://www.microsoft.com/en-us/research/publication/a-randomized-scheduler-with-probabilistic-guarantees-of-finding-bugs/
+func NewPCTScheduler(depth int) Scheduler {
+	if depth < 1 {
+		panic(fmt.Errorf("NewPCTScheduler: depth (%d) < 1", depth))
+	}
+	return &pctScheduler{depth: depth, stepsPerOp: 10}
+}
+
+type pctScheduler struct {
+	depth      int
+	rand       *rand.Rand
+	priorities map[int]int // by trace id
+	changes    []int       // distinct steps at which to lower a priority, sorted
+	numOps     int         // number of ops in the current execution
+	steps      int         // steps taken in the current execution
+	stepsPerOp float64     // estimated number of steps per op
+}
+
+func (s *pctScheduler) Start(r *rand.Rand, numOps int) {
+	// Update the estimated number of steps per op.
+	if s.numOps > 0 && s.steps > 0 {
+		s.stepsPerOp = max(s.stepsPerOp, float64(s.steps)/float64(s.numOps))
+	}
+	s.rand = r
+	s.numOps = numOps
+	s.steps = 0
+
+	// Assign the ops, and config updates and upgrades (trace id 0), random
+	// distinct priorities above depth-1. Priorities 1 through depth-1 are
+	// reserved for lowered priorities.
+	s.priorities = make(map[int]int, numOps+1)
+	for i, p := range r.Perm(numOps + 1) {
+		s.priorities[i] = s.depth + p
+	}
+
+	// Pick the change points among the steps the execution is expected to
+	// take.
+	k := max(int(math.Ceil(s.stepsPerOp*float64(numOps))), s.depth-1)
+	s.changes = s.changes[:0]
+	for len(s.changes) < s.depth-1 {
+		if step := 1 + r.Intn(k); !slices.Contains(s.changes, step) {
+			s.changes = append(s.changes, step)
+		}
+	}
+	sort.Ints(s.changes)
+}
+
+func (s *pctScheduler) Next(candidates []Candidate) int {
+	s.steps++
+
+	// Pick a random step of the highest priority op.
+	best := -1
+	var steps []int
+	for i, c := range candidates {
+		switch p := s.priorities[c.TraceID]; {
+		case p > best:
+			best = p
+			steps = append(steps[:0], i)
+		case p == best:
+			steps = append(steps, i)
+		}
+	}
+	i := steps[s.rand.Intn(len(steps))]
+
+	// At the i-th change point, lower the priority of the op to depth-i.
+	if len(s.changes) > 0 && s.changes[0] == s.steps {
+		s.priorities[candidates[i].TraceID] = len(s.changes)
+		s.changes = s.changes[1:]
+	}
+	return i
+}
+
+// NewDelayBoundedScheduler returns a Scheduler that implements delay-bounded
+// scheduling [1]. The scheduler is deterministic: it keeps taking the first
+// step of the op it is running, and when the op has no step to take, it
+// moves on to the next op, in round-robin order. At up to delays random
+// points of an execution, the scheduler delays the op it is running and moves
+// on to the next op anyway. Bugs that manifest with few delays, which are
+// common, are found quickly, as there are few schedules with few delays.
+//
+// NewDelayBoundedScheduler panics if delays is negative.
+//
+// [1]: https://dl.acm.org/doi/10.1145/1925844.1926432
+func NewDelayBoundedScheduler(delays int) Scheduler {
+	if delays < 0 {
+		panic(fmt.Errorf("NewDelayBoundedScheduler: delays (%d) < 0", delays))
+	}
+	return &delayBoundedScheduler{delays: delays}
+}
+
+type delayBoundedScheduler struct {
+	delays   int
+	current  int   // trace id of the op being run
+	delayAt  []int // steps at which to delay the current op, sorted
+	steps    int   // steps taken in the current execution
+	maxSteps int   // maximum steps taken by an execution so far
+}
+
+func (s *delayBoundedScheduler) Start(r *rand.Rand, numOps int) {
+	s.maxSteps = max(s.maxSteps, s.steps)
+	s.steps = 0
+	s.current = 0
+
+	// Pick the delays among the steps an execution is expected to take,
+	// estimated from earlier executions.
+	k := max(s.maxSteps, 10*numOps)
+	s.delayAt = s.delayAt[:0]
+	for i := 0; i < s.delays; i++ {
+		s.delayAt = append(s.delayAt, 1+r.Intn(k))
+	}
+	sort.Ints(s.delayAt)
+}
+
+func (s *delayBoundedScheduler) Next(candidates []Candidate) int {
+	s.steps++
+	delay := false
+	for len(s.delayAt) > 0 && s.delayAt[0] <= s.steps {
+		delay = true
+		s.delayAt = s.delayAt[1:]
+	}
+
+	// Run the current op, unless it is delayed or has no step to take.
+	if !delay {
+		for i, c := range candidates {
+			if c.TraceID == s.current {
+				return i
+			}
+		}
+	}
+
+	// Move on to the next op in round-robin order, i.e., the op with the
+	// smallest trace id above the current one, or else the smallest trace id.
+	next := -1
+	for i, c := range candidates {
+		if c.TraceID > s.current && (next == -1 || c.TraceID < candidates[next].TraceID) {
+			next = i
+		}
+	}
+	if next == -1 {
+		for i, c := range candidates {
+			if next == -1 || c.TraceID < candidates[next].TraceID {
+				next = i
+			}
+		}
+	}
+	s.current = candidates[next].TraceID
+	return next
+}
+
+// schedule lets the executor's scheduler pick the next step, and takes it.
+//
+// REQUIRES: e.mu is held.
+func (e *executor) schedule() {
+	candidates := e.candidates()
+	if len(candidates) == 0 {
+		// This should be impossible. If it ever happens, there's a bug.
+		panic(fmt.Errorf("no candidate steps"))
+	}
+	i := e.scheduler.Next(candidates)
+	if e.ctx.Err() != nil {
+		// The scheduler cancelled the execution.
+		return
+	}
+	if i < 0 || i >= len(candidates) {
+		panic(fmt.Errorf("Scheduler.Next returned %d for %d candidates", i, len(candidates)))
+	}
+
+	c := candidates[i]
+	if c.TraceID != 0 {
+		e.current = c.TraceID
+	}
+	switch c.Kind {
+	case StepStartOp:
+		o := e.nextOp
+		e.nextOp = nil
+		e.numStarted++
+		e.group.Go(func() error {
+			return e.runOp(e.ctx, o)
+		})
+	case StepDeliverCall:
+		e.calls[c.TraceID] = remove(e.calls[c.TraceID], c.call)
+		e.deliver(c.call)
+	case StepDeliverReply:
+		e.replies[c.TraceID] = remove(e.replies[c.TraceID], c.reply)
+		e.reply(c.reply)
+	case StepUpdateConfig:
+		e.updates = remove(e.updates, c.update)
+		e.group.Go(func() error {
+			return e.updateConfig(c.update)
+		})
+	case StepUpgrade:
+		e.upgrades = remove(e.upgrades, c.upgrade)
+		e.group.Go(func() error {
+			return e.upgrade(c.upgrade)
+		})
+	}
+}
+
+// candidates returns the steps the execution can take next, in a
+// deterministic order: starting the next op, then the calls and replies of
+// started ops by trace id, then config updates and upgrades.
+//
+// REQUIRES: e.mu is held.
+func (e *executor) candidates() []Candidate {
+	var candidates []Candidate
+
+	// Start the next op, if any, unless it is a scenario op that must wait
+	// for the ops it depends on.
+	if next := e.numStarted + 1; e.notFinished.has(next) && (e.scenario == nil || e.ready(next)) {
+		if e.nextOp == nil {
+			// Pick the op once, so that it doesn't change between steps.
+			if e.scenario != nil {
+				e.nextOp = e.scenario[next-1].op
+			} else {
+				e.nextOp = pick(e.randFor(opResource), e.ops)
+			}
+		}
+		candidates = append(candidates, Candidate{Kind: StepStartOp, TraceID: next, Op: e.nextOp.m.Name, Replica: -1, resource: opResource})
+	}
+
+	// Deliver calls and replies of started ops.
+	started := make([]int, 0, e.notFinished.size())
+	for _, id := range e.notFinished.elements {
+		if id <= e.numStarted {
+			started = append(started, id)
+		}
+	}
+	slices.Sort(started)
+	for _, id := range started {
+		for _, call := range e.calls[id] {
+			candidates = append(candidates, Candidate{
+				Kind:      StepDeliverCall,
+				TraceID:   id,
+				SpanID:    call.spanID,
+				Component: e.regsByIntf[call.component].Name,
+				Method:    call.method,
+				Replica:   -1,
+				resource:  e.regsByIntf[call.component].Name,
+				call:      call,
+			})
+		}
+		for _, reply := range e.replies[id] {
+			candidates = append(candidates, Candidate{
+				Kind:      StepDeliverReply,
+				TraceID:   id,
+				SpanID:    reply.call.spanID,
+				Component: e.regsByIntf[reply.call.component].Name,
+				Method:    reply.call.method,
+				Replica:   -1,
+				resource:  callerResource(reply.call),
+				reply:     reply,
+			})
+		}
+	}
+
+	// Apply config updates and upgrades.
+	for _, update := range e.updates {
+		candidates = append(candidates, Candidate{
+			Kind:      StepUpdateConfig,
+			Component: update.component,
+			Replica:   update.replica,
+			resource:  update.component,
+			update:    update,
+		})
+	}
+	for _, u := range e.upgrades {
+		candidates = append(candidates, Candidate{
+			Kind:      StepUpgrade,
+			Component: u.reg.Name,
+			Replica:   u.replica,
+			resource:  u.reg.Name,
+			upgrade:   u,
+		})
+	}
+	return candidates
+}
+
+// callerResource returns the resource of the code that made the provided call,
+// which resumes when the call's reply is delivered.
+func callerResource(call *call) string {
+	if call.caller.Component == "" {
+		return opResource
+	}
+	return call.caller.Component
+}
+
+// remove returns xs without x, preserving the order of the other elements.
+func remove[T comparable](xs []T, x T) []T {
+	if i := slices.Index(xs, x); i >= 0 {
+		return slices.Delete(xs, i, i+1)
+	}
+	return xs
+}
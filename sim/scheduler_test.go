@@ -0,0 +1,208 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var schedulers = []struct {
+	name string
+	new  func() Scheduler
+}{
+	{"Random", NewRandomScheduler},
+	{"PCT", func() Scheduler { return NewPCTScheduler(3) }},
+	{"DelayBounded", func() Scheduler { return NewDelayBoundedScheduler(2) }},
+}
+
+func TestSchedulersAreDeterministic(t *testing.T) {
+	for _, test := range schedulers {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t, &divModWorkload{}, Options{Scheduler: test.new})
+			histories := func() [][]Event {
+				exec := s.newExecutor()
+				var histories [][]Event
+				for seed := int64(0); seed < 20; seed++ {
+					params := hyperparameters{Seed: seed, NumReplicas: 3, NumOps: 10, FailureRate: 0.1}
+					result, err := exec.execute(context.Background(), params)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if result.err != nil {
+						t.Fatalf("seed %d: %v", seed, result.err)
+					}
+					histories = append(histories, result.history)
+				}
+				return histories
+			}
+			if diff := cmp.Diff(histories(), histories()); diff != "" {
+				t.Fatalf("histories (-first +second):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSchedulersFindUpgradeBug(t *testing.T) {
+	for _, test := range schedulers {
+		t.Run(test.name, func(t *testing.T) {
+			s := New(t, &upgradeWorkload{}, Options{Config: limiterAppConfig, Scheduler: test.new})
+			exec := s.newExecutor()
+			found := false
+			for seed := int64(0); seed < 200 && !found; seed++ {
+				params := hyperparameters{Seed: seed, NumReplicas: 3, NumOps: 20}
+				result, err := exec.execute(context.Background(), params)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if result.err != nil {
+					if !strings.Contains(result.err.Error(), "disagree") {
+						t.Fatalf("seed %d: unexpected error %v", seed, result.err)
+					}
+					found = true
+				}
+			}
+			if !found {
+				t.Fatal("no rolling upgrade incompatibility found")
+			}
+		})
+	}
+}
+
+// fifoScheduler always takes the first candidate step, and records the
+// candidates of the first step of every execution.
+type fifoScheduler struct {
+	first []Candidate
+}
+
+func (f *fifoScheduler) Start(*rand.Rand, int) {
+	f.first = nil
+}
+
+func (f *fifoScheduler) Next(candidates []Candidate) int {
+	if f.first == nil {
+		f.first = append([]Candidate{}, candidates...)
+	}
+	return 0
+}
+
+func TestCustomScheduler(t *testing.T) {
+	fifo := &fifoScheduler{}
+	s := New(t, &divModWorkload{}, Options{Scheduler: func() Scheduler { return fifo }})
+	params := hyperparameters{Seed: 1, NumReplicas: 2, NumOps: 5}
+	result, err := s.newExecutor().execute(context.Background(), params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err != nil {
+		t.Fatal(result.err)
+	}
+
+	// The only candidate of the first step starts the first op.
+	if len(fifo.first) != 1 || fifo.first[0].Kind != StepStartOp || fifo.first[0].TraceID != 1 || fifo.first[0].Op == "" {
+		t.Fatalf("first candidates: got %+v, want one StepStartOp of op 1", fifo.first)
+	}
+
+	// Starting the next op is always the first candidate, so every op starts
+	// before any call is delivered.
+	delivered := false
+	for _, event := range result.history {
+		switch event.(type) {
+		case EventDeliverCall:
+			delivered = true
+		case EventOpStart:
+			if delivered {
+				t.Fatalf("op started after a call was delivered:\n%v", result.history)
+			}
+		}
+	}
+}
+
+func TestSchedulerSimulation(t *testing.T) {
+	s := New(t, &divModWorkload{}, Options{
+		Seed:          1,
+		MaxExecutions: 100,
+		Scheduler:     func() Scheduler { return NewPCTScheduler(2) },
+	})
+	r := s.Run(time.Minute)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if r.NumExecutions != 100 {
+		t.Fatalf("NumExecutions: got %d, want 100", r.NumExecutions)
+	}
+}
+
+// runPCT runs a PCT scheduler of the provided depth for the provided number of
+// steps, in which every one of numOps ops can always take a step, and returns
+// the ops it ran.
+func runPCT(seed int64, depth, numOps, steps int) []int {
+	s := NewPCTScheduler(depth)
+	s.Start(rand.New(rand.NewSource(seed)), numOps)
+	candidates := make([]Candidate, numOps)
+	for i := range candidates {
+		candidates[i] = Candidate{Kind: StepDeliverCall, TraceID: i + 1}
+	}
+	var ran []int
+	for i := 0; i < steps; i++ {
+		ran = append(ran, candidates[s.Next(candidates)].TraceID)
+	}
+	return ran
+}
+
+func TestPCTRunsHighestPriorityOp(t *testing.T) {
+	// With a depth of 1, the priorities never change, so the scheduler
+	// always runs the same op, picked at random.
+	picked := map[int]bool{}
+	for seed := int64(0); seed < 50; seed++ {
+		ran := runPCT(seed, 1, 3, 30)
+		for _, op := range ran {
+			if op != ran[0] {
+				t.Fatalf("seed %d: ran ops %v, want a single op", seed, ran)
+			}
+		}
+		picked[ran[0]] = true
+	}
+	if len(picked) != 3 {
+		t.Fatalf("picked ops %v, want all of 1, 2, 3", picked)
+	}
+}
+
+func TestPCTChangePoints(t *testing.T) {
+	// The scheduler expects 10 steps per op, so all depth-1 change points
+	// fall within the first 30 steps of an execution with 3 ops. Every
+	// change point lowers the priority of the running op below that of the
+	// other ops, so the scheduler switches ops exactly depth-1 times, the
+	// last time at step 31 at the latest.
+	for _, depth := range []int{1, 2, 3} {
+		for seed := int64(0); seed < 50; seed++ {
+			ran := runPCT(seed, depth, 3, 31)
+			switches := 0
+			for i := 1; i < len(ran); i++ {
+				if ran[i] != ran[i-1] {
+					switches++
+				}
+			}
+			if switches != depth-1 {
+				t.Fatalf("depth %d, seed %d: ran ops %v with %d switches, want %d", depth, seed, ran, switches, depth-1)
+			}
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+const (
+	// shrinkAttempts is the number of executions, with different seeds, that
+	// shrinking runs to check whether a smaller scenario still fails. A
+	// failure that depends on a rare interleaving may not reproduce with the
+	// first seed.
+	shrinkAttempts = 8
+
+	// maxShrinkExecutions bounds the number of executions that shrinking
+	// runs, so that shrinking a long history terminates in a reasonable
+	// amount of time. Shrinking returns the smallest reproducer found so far
+	// when it runs out of executions.
+	maxShrinkExecutions = 5000
+)
+
+// A shrinker minimizes a failing execution, delta debugging style. It reruns
+// the ops of the execution as a scenario, first with fewer ops, and then with
+// simpler arguments, and keeps every change that still fails the same way,
+// i.e., with the same fingerprint.
+type shrinker struct {
+	exec        *executor       // executes candidate scenarios
+	w           reflect.Type    // workload type
+	params      hyperparameters // hyperparameters of the failing execution
+	fingerprint string          // fingerprint of the failure
+	best        result          // the smallest failing execution found
+	ops         []ScenarioOp    // the ops of best
+	executions  int             // number of executions run
+}
+
+// shrink minimizes the provided failing execution. It returns the smallest
+// failing execution it finds, and the scenario that reproduces it.
+func (s *Simulator) shrink(ctx context.Context, failing result) (result, *Scenario, error) {
+	if len(failing.ops) == 0 {
+		return result{}, nil, fmt.Errorf("no ops recorded")
+	}
+
+	// Shrinking executions are not part of the simulation, so they are not
+	// profiled or included in allocation and queueing statistics.
+	exec := s.newExecutor()
+	exec.allocs = nil
+	exec.queueStats = nil
+	exec.timer = nil
+
+	sh := &shrinker{
+		exec:        exec,
+		w:           s.w,
+		params:      failing.params,
+		fingerprint: Fingerprint(failing.history, failing.err),
+		best:        failing,
+		ops:         failing.ops,
+	}
+	if err := sh.removeOps(ctx); err != nil {
+		return result{}, nil, err
+	}
+	if err := sh.simplifyArgs(ctx); err != nil {
+		return result{}, nil, err
+	}
+	return sh.best, &Scenario{Ops: sh.ops}, nil
+}
+
+// removeOps removes as many ops as possible, first in large chunks, and then
+// in smaller and smaller chunks, down to single ops.
+func (sh *shrinker) removeOps(ctx context.Context) error {
+	for n := len(sh.ops) / 2; n >= 1; n /= 2 {
+		for i := 0; i < len(sh.ops) && sh.executions < maxShrinkExecutions; {
+			drop := map[int]bool{}
+			for j := i; j < i+n && j < len(sh.ops); j++ {
+				drop[j] = true
+			}
+			ok, err := sh.try(ctx, without(sh.ops, drop))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				i += n
+			}
+			// Otherwise, the next chunk has moved to index i.
+		}
+	}
+	return nil
+}
+
+// simplifyArgs replaces every argument of every op with the simplest value
+// that still fails.
+func (sh *shrinker) simplifyArgs(ctx context.Context) error {
+	for i := range sh.ops {
+		for j := range sh.ops[i].Args {
+			for simplified := true; simplified && sh.executions < maxShrinkExecutions; {
+				simplified = false
+				for _, arg := range simpler(sh.ops[i].Args[j]) {
+					candidate := make([]ScenarioOp, len(sh.ops))
+					copy(candidate, sh.ops)
+					candidate[i].Args = append([]any{}, sh.ops[i].Args...)
+					candidate[i].Args[j] = arg
+					ok, err := sh.try(ctx, candidate)
+					if err != nil {
+						return err
+					}
+					if ok {
+						simplified = true
+						break
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// try runs the provided ops as a scenario, and keeps them if they fail the
+// same way as the original execution.
+func (sh *shrinker) try(ctx context.Context, ops []ScenarioOp) (bool, error) {
+	scenario, err := compileScenario(sh.w, &Scenario{Ops: ops})
+	if err != nil {
+		// For example, a simplified argument can't be converted to the type
+		// of its parameter.
+		return false, nil
+	}
+	sh.exec.scenario = scenario
+	for i := 0; i < shrinkAttempts && sh.executions < maxShrinkExecutions; i++ {
+		params := sh.params
+		params.Seed += int64(i)
+		r, err := sh.exec.execute(ctx, params)
+		if err != nil {
+			return false, err
+		}
+		sh.executions++
+		if r.err != nil && Fingerprint(r.history, r.err) == sh.fingerprint {
+			sh.best = r
+			sh.ops = ops
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// without returns the provided ops, without the dropped ones. An op that
+// depends on a dropped op inherits the dependencies of the dropped op, so
+// that the remaining ops run in the same order.
+func without(ops []ScenarioOp, drop map[int]bool) []ScenarioOp {
+	index := make([]int, len(ops)) // index of every kept op in the result
+	for i, n := 0, 0; i < len(ops); i++ {
+		index[i] = n
+		if !drop[i] {
+			n++
+		}
+	}
+
+	var deps func(after []int, into map[int]bool)
+	deps = func(after []int, into map[int]bool) {
+		for _, a := range after {
+			if drop[a] {
+				deps(ops[a].After, into)
+			} else {
+				into[index[a]] = true
+			}
+		}
+	}
+
+	var kept []ScenarioOp
+	for i, o := range ops {
+		if drop[i] {
+			continue
+		}
+		if len(o.After) > 0 {
+			into := map[int]bool{}
+			deps(o.After, into)
+			o.After = nil
+			for a := range into {
+				o.After = append(o.After, a)
+			}
+			sort.Ints(o.After)
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// simpler returns values simpler than x, simplest first: zero values, halves,
+// and values one step closer to zero. It returns nil if x can't be simplified.
+func simpler(x any) []any {
+	if x == nil {
+		return nil
+	}
+	v := reflect.ValueOf(x)
+	var candidates []reflect.Value
+	add := func(set func(reflect.Value)) {
+		c := reflect.New(v.Type()).Elem()
+		set(c)
+		candidates = append(candidates, c)
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			add(func(c reflect.Value) { c.SetBool(false) })
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		for _, y := range []int64{0, v.Int() / 2, v.Int() - sign(v.Int())} {
+			add(func(c reflect.Value) { c.SetInt(y) })
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > 0 {
+			for _, y := range []uint64{0, v.Uint() / 2, v.Uint() - 1} {
+				add(func(c reflect.Value) { c.SetUint(y) })
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		if !math.IsNaN(v.Float()) {
+			for _, y := range []float64{0, math.Trunc(v.Float()), math.Trunc(v.Float() / 2)} {
+				add(func(c reflect.Value) { c.SetFloat(y) })
+			}
+		}
+	case reflect.String:
+		runes := []rune(v.String())
+		for _, n := range []int{0, len(runes) / 2, len(runes) - 1} {
+			if n >= 0 {
+				add(func(c reflect.Value) { c.SetString(string(runes[:n])) })
+			}
+		}
+	case reflect.Slice:
+		n := v.Len()
+		candidates = append(candidates, v.Slice(0, 0), v.Slice(0, n/2))
+		if n > 0 {
+			candidates = append(candidates, v.Slice(0, n-1), v.Slice(1, n))
+		}
+	}
+
+	// Remove candidates that aren't simpler than x, and duplicates.
+	var simpler []any
+	for _, c := range candidates {
+		if simplerThan(c, v) && !containsValue(simpler, c) {
+			simpler = append(simpler, c.Interface())
+		}
+	}
+	return simpler
+}
+
+// simplerThan returns whether a is simpler than b, two values of the same
+// type returned by simpler.
+func simplerThan(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return abs(a.Int()) < abs(b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return math.Abs(a.Float()) < math.Abs(b.Float()) ||
+			(math.Abs(a.Float()) == math.Abs(b.Float()) && a.Float() == math.Trunc(a.Float()) && b.Float() != math.Trunc(b.Float()))
+	case reflect.String:
+		return len(a.String()) < len(b.String())
+	case reflect.Slice:
+		return a.Len() < b.Len()
+	default:
+		return false
+	}
+}
+
+// containsValue returns whether values contains a value equal to v.
+func containsValue(values []any, v reflect.Value) bool {
+	for _, x := range values {
+		if reflect.DeepEqual(x, v.Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+// sign returns the sign of x: -1, 0, or 1.
+func sign(x int64) int64 {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// abs returns the absolute value of x, or math.MaxInt64 for math.MinInt64.
+func abs(x int64) int64 {
+	switch {
+	case x == math.MinInt64:
+		return math.MaxInt64
+	case x < 0:
+		return -x
+	default:
+		return x
+	}
+}
+
+// formatScenario formats the ops of a scenario, one per line, like
+//
+//	0: Deposit(alice, 10)
+//	1: Withdraw(alice, 20) after 0
+func formatScenario(scenario *Scenario, f Formatter) string {
+	var b strings.Builder
+	for i, o := range scenario.Ops {
+		args := make([]string, len(o.Args))
+		for j, arg := range o.Args {
+			args[j] = f.Format(arg)
+		}
+		fmt.Fprintf(&b, "%d: %s(%s)", i, o.Name, strings.Join(args, ", "))
+		if len(o.After) > 0 {
+			after := make([]string, len(o.After))
+			for j, a := range o.After {
+				after[j] = fmt.Sprint(a)
+			}
+			fmt.Fprintf(&b, " after %s", strings.Join(after, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+// overdraftWorkload deposits to and withdraws from accounts, and fails when
+// an account is overdrawn by more than its overdraft limit. Withdraw forgets
+// to check the balance.
+type overdraftWorkload struct {
+	id weaver.Ref[identity]
+
+	mu       sync.Mutex
+	balances map[user]int
+}
+
+func (w *overdraftWorkload) Init(r Registrar) error {
+	w.balances = map[user]int{}
+	r.RegisterGenerators("Deposit", OneOf[user]("alice", "bob"), Range(0, 100))
+	r.RegisterGenerators("Withdraw", OneOf[user]("alice", "bob"), Range(0, 100))
+	r.RegisterGenerators("Log", Slice(Range(0, 10), String()))
+	return nil
+}
+
+func (w *overdraftWorkload) Deposit(ctx context.Context, u user, amount int) error {
+	amount, err := w.id.Get().Identity(ctx, amount)
+	if errors.Is(err, weaver.RemoteCallError) {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balances[u] += amount
+	return err
+}
+
+func (w *overdraftWorkload) Withdraw(ctx context.Context, u user, amount int) error {
+	amount, err := w.id.Get().Identity(ctx, amount)
+	if errors.Is(err, weaver.RemoteCallError) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.balances[u] -= amount
+	if w.balances[u] < -150 {
+		return fmt.Errorf("%s has balance %d below the overdraft limit", u, w.balances[u])
+	}
+	return nil
+}
+
+func (w *overdraftWorkload) Log(context.Context, []string) error {
+	return nil
+}
+
+func TestShrink(t *testing.T) {
+	// alice exceeds her overdraft limit with her last withdrawal.
+	scenario := &Scenario{Ops: []ScenarioOp{
+		{Name: "Deposit", Args: []any{"alice", 20}},
+		{Name: "Withdraw", Args: []any{"bob", 30}},
+		{Name: "Log", Args: []any{[]string{"a", "b"}}},
+		{Name: "Withdraw", Args: []any{"alice", 40}, After: []int{0}},
+		{Name: "Deposit", Args: []any{"bob", 50}},
+		{Name: "Withdraw", Args: []any{"alice", 60}, After: []int{3}},
+		{Name: "Log", Args: []any{[]string{"c"}}},
+		{Name: "Withdraw", Args: []any{"alice", 70}, After: []int{5}},
+		{Name: "Withdraw", Args: []any{"bob", 80}},
+		{Name: "Withdraw", Args: []any{"alice", 90}, After: []int{7}},
+		{Name: "Deposit", Args: []any{"alice", 10}},
+	}}
+	s := New(t, &overdraftWorkload{}, Options{Seed: 1, MaxExecutions: 200, Parallelism: 1, Scenario: scenario, Shrink: true})
+	t.Cleanup(func() { os.RemoveAll(s.graveyardDir()) })
+	r := s.Run(time.Minute)
+	if r.Err == nil {
+		t.Fatal("Unexpected success")
+	}
+	if r.Reproducer == nil {
+		t.Fatal("Reproducer: got nil")
+	}
+
+	// Two withdrawals, of at most 99 each, of 151 in total, from the same
+	// account exceed the overdraft limit.
+	ops := r.Reproducer.Ops
+	if len(ops) != 2 || ops[0].Name != "Withdraw" || ops[1].Name != "Withdraw" {
+		t.Fatalf("Reproducer: got %v, want two withdrawals", ops)
+	}
+	if ops[0].Args[0] != ops[1].Args[0] {
+		t.Fatalf("Reproducer: got %v, want withdrawals from the same account", ops)
+	}
+	if got := ops[0].Args[1].(int) + ops[1].Args[1].(int); got != 151 {
+		t.Fatalf("Reproducer: got %v, want withdrawals of 151 in total", ops)
+	}
+	if len(r.History) >= len(r.Failures[0].History) {
+		t.Fatalf("shrunk history has %d events, want fewer than %d", len(r.History), len(r.Failures[0].History))
+	}
+	if got, want := Fingerprint(r.History, r.Err), r.Failures[0].Fingerprint; got != want {
+		t.Fatalf("shrunk execution has fingerprint %s, want %s", got, want)
+	}
+
+	// The reproducer reproduces the failure.
+	s = New(t, &overdraftWorkload{}, Options{Scenario: r.Reproducer})
+	result, err := s.newExecutor().execute(context.Background(), hyperparameters{Seed: 1, NumReplicas: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.err == nil {
+		t.Fatal("Reproducer: unexpected success")
+	}
+}
+
+func TestWithout(t *testing.T) {
+	ops := []ScenarioOp{
+		{Name: "A"},
+		{Name: "B", After: []int{0}},
+		{Name: "C", After: []int{1}},
+		{Name: "D", After: []int{0, 2}},
+	}
+	got := without(ops, map[int]bool{1: true})
+	want := []ScenarioOp{
+		{Name: "A"},
+		{Name: "C", After: []int{0}}, // inherited from B
+		{Name: "D", After: []int{0, 1}},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("without (-want +got):\n%s", diff)
+	}
+}
+
+func TestSimpler(t *testing.T) {
+	for _, test := range []struct {
+		x    any
+		want []any
+	}{
+		{true, []any{false}},
+		{false, nil},
+		{10, []any{0, 5, 9}},
+		{-3, []any{0, -1, -2}},
+		{1, []any{0}},
+		{0, nil},
+		{uint8(2), []any{uint8(0), uint8(1)}},
+		{2.5, []any{0.0, 2.0, 1.0}},
+		{"abcd", []any{"", "ab", "abc"}},
+		{user("a"), []any{user("")}},
+		{[]int{1, 2, 3}, []any{[]int{}, []int{1}, []int{1, 2}, []int{2, 3}}},
+		{struct{}{}, nil},
+	} {
+		if diff := cmp.Diff(test.want, simpler(test.x)); diff != "" {
+			t.Errorf("simpler(%v) (-want +got):\n%s", test.x, diff)
+		}
+	}
+}
@@ -120,6 +120,66 @@
 // to a component using weaver.Ref. See serviceweaver.dev/blog/testing.html for
 // a complete example.
 //
+// The simulator delivers every method call to a random replica of the called
+// component, even if the component is routed. A weaver.Broadcast, however,
+// calls every replica, one at a time and in order, so that components that
+// broadcast, like weaver.Cache, which broadcasts invalidations to its shards,
+// can be simulated deterministically.
+//
+// When an op compares an expected value against an actual one, it can return
+// the error produced by the [diff.Check] function in the sim/diff package. If
+// the values differ, the error includes a structural diff of the two values,
+// and the simulator includes the diff in its failure report.
+//
+//	if err := diff.Check("balances", want, got); err != nil {
+//		return err
+//	}
+//
+// # Op Tags
+//
+// A workload's ops can be tagged in its Init method, and [Options.OpFilter]
+// selects which ops a simulation runs, by name and tags. A single workload
+// can then back a quick smoke test, a long nightly simulation, and targeted
+// simulations of a few ops, without registering different subsets of its ops
+// in different workloads:
+//
+//	func (w *bankWorkload) Init(r sim.Registrar) error {
+//		r.RegisterGenerators("Deposit", sim.Range(0, 100))
+//		r.RegisterGenerators("Transfer", sim.Range(0, 100))
+//		r.RegisterTags("Deposit", "smoke")
+//		return nil
+//	}
+//
+//	smoke := sim.New(t, &bankWorkload{}, sim.Options{OpFilter: sim.Tagged("smoke")})
+//	transfers := sim.New(t, &bankWorkload{}, sim.Options{
+//		OpFilter: func(op string, _ []string) bool { return op == "Transfer" },
+//	})
+//
+// The -sim.tags flag of [Main] selects ops by tag for every registered
+// workload.
+//
+// # Config Updates
+//
+// A workload can also register config updates with
+// [Registrar.RegisterConfigUpdate]. The simulator applies them to running
+// component replicas at random points in an execution, possibly while the
+// replicas have method calls in flight, and tells replicas that implement
+// [ConfigUpdater] about them. This catches components that mishandle a
+// config change in the middle of a run.
+//
+// # Rolling Upgrades
+//
+// A workload can register a new implementation of a component with
+// [Registrar.RegisterUpgrade]. The simulator then swaps the component's
+// replicas from the old implementation to the new one, one at a time, at
+// random points in an execution, so that ops run against a mix of old and new
+// replicas.
+//
+//	func (w *cacheWorkload) Init(r sim.Registrar) error {
+//	    r.RegisterUpgrade(sim.Upgrade[Cache](&cacheV2{}))
+//	    ...
+//	}
+//
 // # Graveyard
 //
 // When the simulator runs a failed execution, it persists the failing inputs
@@ -149,6 +209,264 @@
 // Users are responsible for manually deleting graveyard entries when
 // appropriate.
 //
+// # Failure Clustering
+//
+// By default, Run stops at the first failing execution. With
+// [Options.KeepGoing] set, Run keeps simulating and clusters the failing
+// executions by their [Fingerprint]: a hash of the error and of the component
+// methods called by the failing op, which ignores trace and span ids,
+// replicas, arguments, and numbers in errors. Failures found with different
+// seeds are usually caused by the same handful of bugs, so instead of one
+// graveyard entry and history per failing execution, Run writes one per
+// cluster, using the failing execution with the shortest history as the
+// cluster's reproducer, and reports the clusters in [Results.Failures]:
+//
+//	Found 342 failing executions in 2 clusters:
+//	  3f1c8e0b7a2d9e64: 339 executions, e.g., user alice has negative balance -10
+//	  b07d52a1c9e3f810: 3 executions, e.g., transfer of 5 from bob lost
+//
+// Farm coordinators deduplicate the failures reported by their workers by
+// fingerprint too.
+//
+// # Debugging
+//
+// When a simulation fails, the simulator also writes the history of the
+// failing execution to a file (see [WriteHistory]) and logs its name. The
+// "weaver sim debug" command is an interactive debugger for history files. It
+// steps forward and backward through the history, jumps to the first
+// invariant violation, and shows the state of every component replica at any
+// point in the execution. Replica state is recorded for components whose
+// implementations implement [Snapshotter].
+//
+//	$ weaver sim debug /tmp/weaver-sim-1234.history
+//	(sim) violation
+//	  42  [7:7] op 7 returns user alice has negative balance -10
+//	(sim) state
+//	bank.Store 0 (as of [7:9]): map[alice:-10]
+//
+// # Checkpoints
+//
+// A simulator explores a deterministic sequence of executions, called a
+// sweep, determined by [Options.Seed]. Long explorations can be paused and
+// resumed by setting [Options.Checkpoint] to the name of a file. When Run
+// returns, because its duration elapsed, because it ran
+// [Options.MaxExecutions] executions, or because it found a failing
+// execution, it records the seed of the sweep and the progress made through
+// it in the file. The next Run with the same checkpoint file, in the same
+// process or on another machine, resumes the sweep where the previous one
+// left off. Executions that were in flight when a simulation was paused are
+// re-executed from scratch when it resumes.
+//
+// A sweep can also be split across workers with [Options.Shard] and
+// [Options.NumShards], with every worker using the same seed and its own
+// checkpoint file.
+//
+//	opts := sim.Options{
+//	    Seed:          42,
+//	    Shard:         worker,
+//	    NumShards:     numWorkers,
+//	    MaxExecutions: 100_000,
+//	    Checkpoint:    fmt.Sprintf("checkpoints/%d.json", worker),
+//	}
+//
+// # Test Suites
+//
+// A test binary can register its workloads with [Register] and simulate all
+// of them from TestMain with [Main]. Every registered workload runs as a
+// top-level test that -run, -v, and -count apply to, with a simulation budget
+// set by [MainOptions] or the -sim.duration flag.
+//
+//	func init() {
+//	    sim.Register("TestBankSimulation", &bankWorkload{}, sim.Options{})
+//	}
+//
+//	func TestMain(m *testing.M) {
+//	    sim.Main(m, sim.MainOptions{Duration: time.Minute})
+//	}
+//
+// # Farms
+//
+// A farm runs a simulation across many machines. The "weaver sim farm"
+// command starts a coordinator that leases ranges of a single sweep to
+// workers, along with mutation candidates: variations of the inputs of
+// failing executions found so far. A worker is a simulator whose
+// [Options.Farm] field, or the WEAVER_SIM_FARM environment variable, holds
+// the address of the coordinator. Workers report the failures they find and
+// keep going. The coordinator deduplicates failures by a fingerprint of their
+// histories and aggregates the coverage of ops and component methods across
+// all workers.
+//
+//	$ weaver sim farm --address=:9000 --seed=42
+//	$ WEAVER_SIM_FARM=http://coordinator:9000 go test -run=TestBank -timeout=0
+//
+// # Allocation Tracking
+//
+// A simulator can double as a cheap detector of performance regressions. With
+// [Options.TrackAllocs] set, Run records the bytes and objects allocated, and
+// the growth of the live heap, during every op and every component method
+// call, and reports them in [Results.Allocs]. With [Options.AllocBaseline]
+// set to a file name, the first Run writes its profile to the file, and later
+// runs report the ops and methods whose bytes allocated per call grew by more
+// than [Options.AllocSlack] in [Results.AllocRegressions]:
+//
+//	s := sim.New(t, &bankWorkload{}, sim.Options{
+//	    TrackAllocs:   true,
+//	    AllocBaseline: "testdata/allocs.json",
+//	})
+//	r := s.Run(10 * time.Second)
+//	for _, regression := range r.AllocRegressions {
+//	    t.Error(regression)
+//	}
+//
+// Allocations are measured with process-wide runtime metrics, so executions
+// run one at a time while allocations are tracked, and allocations made by
+// other goroutines in the process are charged to whichever op or method call
+// is running. Delete the baseline file to record a new baseline.
+//
+// # Queueing
+//
+// Run also reports, in [Results.Queues], how calls to every component queued
+// in the simulator: how many steps calls waited between being made and being
+// delivered, and how many calls were pending and executing at once. The
+// simulator has no clock, so these are not latencies, but components with
+// long waits or many pending calls are the ones whose replicas would see
+// pending calls in a deployment. The statistics are also logged after every
+// run, next to the summary of the run.
+//
+// # Schedulers
+//
+// Which interleavings of ops an execution explores depends on the order in
+// which it takes steps: starting ops, delivering method calls to replicas,
+// returning their replies, and applying config updates and upgrades. By
+// default, an execution runs an op for a random number of steps and then
+// yields to another random op. [Options.Scheduler] replaces this strategy
+// with a [Scheduler], which picks every step among the candidate steps. The
+// package provides three schedulers:
+//
+//   - [NewRandomScheduler] picks a step uniformly at random.
+//   - [NewPCTScheduler] implements probabilistic concurrency testing, which
+//     runs the ops in a random priority order and changes the order at a
+//     few random points. It finds bugs that need a few specific orderings
+//     of steps with a probability it guarantees, including interleavings
+//     that a random scheduler is unlikely to explore. Run a suite with
+//     -sim.pct=2, for example, to use it with a bug depth of 2 (see
+//     [Main]).
+//   - [NewDelayBoundedScheduler] runs the ops in round-robin order and
+//     deviates from it at a few random points.
+//
+// For example:
+//
+//	s := sim.New(t, &bankWorkload{}, sim.Options{
+//	    Scheduler: func() sim.Scheduler { return sim.NewPCTScheduler(3) },
+//	})
+//
+// You can also write your own Scheduler, e.g., to prioritize the steps of
+// ops or components you suspect. Graveyard entries don't record the
+// scheduler, so a failing execution found with a scheduler is only
+// reproduced when the simulator uses the same scheduler.
+//
+// # Step Profiling
+//
+// When a simulation runs slower than expected, [Options.ProfileSteps] shows
+// where the time goes. With it set, Run records the wall time the simulator
+// spends generating op inputs, calling ops and component methods (which
+// includes running them), checking invariants, serializing method arguments
+// and results, formatting values recorded in histories, appending events to
+// histories, and everything else, like picking the next step. Run reports the
+// breakdown in [Results.Steps] and logs it, most time consuming work first.
+// Executions run one at a time while steps are profiled, so that executions
+// don't contend for CPUs.
+// A large share of formatting, for example, suggests bounding the cost of
+// formatting with [Options.Format], while a large share of calls points at the
+// workload and components themselves. The -sim.profile flag of [Main] turns on
+// step profiling for every registered workload.
+//
+// # Exhaustive Exploration
+//
+// Run samples executions at random, and a scheduler can only make unlikely
+// schedules more likely. Simulator.Explore instead explores every schedule of
+// a single small execution, picked by a seed: every order in which the
+// execution can start its ops, deliver calls and replies, and apply config
+// updates and upgrades. Schedules that differ only in the order of
+// independent steps, like delivering calls to two different components, are
+// equivalent, and Explore skips most of them:
+//
+//	r := s.Explore(sim.ExploreOptions{Seed: 1, NumOps: 3, NumReplicas: 2})
+//	if r.Err != nil {
+//	    t.Fatal(r.Err)
+//	}
+//
+// Explore stops at the first failing execution and returns its history. If
+// it explores every schedule without failing, [ExploreResult.Complete] is
+// set, and the execution can't fail. Explore assumes that components only
+// share state by calling each other. The number of schedules grows
+// exponentially with the number of ops, so keep executions small and bound
+// them with [ExploreOptions.MaxExecutions].
+//
+// # Capacity Planning
+//
+// A simulator can also compare deployment plans. Simulator.Plan learns which
+// component methods every op calls and then simulates ops arriving at a
+// target rate against every [Plan], a choice of colocation and replica
+// counts. Given the latency of every method, the network latency of calls
+// between processes, and the cost of a replica, described by a [CostModel],
+// it reports the expected p50 and p99 op latency and the cost of every plan:
+//
+//	opts := sim.PlanOptions{
+//	    CostModel: sim.CostModel{
+//	        DefaultLatency: time.Millisecond,
+//	        NetworkLatency: 2 * time.Millisecond,
+//	        ReplicaCost:    0.05,
+//	    },
+//	    Rate: 500, // ops per second
+//	}
+//	split := sim.Plan{Name: "split", Replicas: map[string]int{cart: 4}}
+//	colocated := sim.Plan{Name: "colocated", Colocate: [][]string{{cart, catalog}}}
+//	for _, r := range s.Plan(opts, split, colocated) {
+//	    fmt.Println(r)
+//	}
+//
+// # Scenarios
+//
+// A simulator can also replay a fixed [Scenario] rather than random ops, e.g.,
+// to reproduce a production incident. Every execution runs the ops of
+// [Options.Scenario] with their recorded arguments, in an order consistent
+// with their dependencies, while the simulator still injects failures and
+// varies interleavings and replica counts. [ScenarioFromTraces] converts the
+// traces of a deployed application into a scenario:
+//
+//	spans := ... // e.g., from a deployer's trace database
+//	scenario, err := sim.ScenarioFromTraces(spans, map[string]sim.SpanOp{
+//	    "bank.Bank.Deposit": {Op: "Deposit", Args: []string{"user", "amount"}},
+//	})
+//	if err != nil {
+//	    t.Fatal(err)
+//	}
+//	s := sim.New(t, &bankWorkload{}, sim.Options{Scenario: scenario})
+//	r := s.Run(10 * time.Second)
+//
+// # Shrinking
+//
+// The history of a failing execution is often thousands of events long, most
+// of which have nothing to do with the failure. With [Options.Shrink] set, Run
+// minimizes the failing execution before reporting it, delta debugging style.
+// It reruns the ops of the execution as a scenario, first removing chunks of
+// ops, from large chunks down to single ops, and then replacing the arguments
+// of the remaining ops with simpler values, like zero, half of the argument,
+// or a shorter string or slice. A change is kept if an execution of the
+// smaller scenario, with one of a handful of seeds, fails with the same
+// [Fingerprint] as the original failure, so that shrinking doesn't slip to a
+// different bug. Run logs the ops that remain,
+//
+//	Shrunk failing execution from 20 ops (1734 events) to 2 ops (31 events):
+//	0: Deposit(alice, 0)
+//	1: Withdraw(alice, 1)
+//
+// and returns them in [Results.Reproducer], which can be passed as
+// [Options.Scenario] to rerun them. [Results.History] and the history file
+// hold the shrunk execution. The graveyard entry still holds the
+// hyperparameters of the original execution.
+//
 // TODO(mwhittaker): Move things to the weavertest package.
 //
 // [1]: https://asatarin.github.io/testing-distributed-systems/#deterministic-simulation
@@ -176,6 +494,7 @@ import (
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/ServiceWeaver/weaver/sim/diff"
 	"golang.org/x/exp/maps"
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -201,6 +520,53 @@ func Fake[T any](impl any) FakeComponent {
 	return FakeComponent{intf: t, impl: impl}
 }
 
+// UpgradeComponent is a new implementation of a component, used to simulate
+// rolling upgrades. See [Upgrade] and [Registrar.RegisterUpgrade].
+type UpgradeComponent struct {
+	intf reflect.Type
+	impl reflect.Type
+}
+
+// Upgrade returns a new implementation of component T, which replicas of T
+// are upgraded to during a simulated rolling upgrade. impl is a pointer to a
+// zero value of the new implementation struct, which, like the component's
+// registered implementation, must embed weaver.Implements[T]. For every
+// upgraded replica, the simulator creates a new instance of the struct and
+// initializes it like it initializes the registered implementation.
+//
+//	r.RegisterUpgrade(sim.Upgrade[Cache](&cacheV2{}))
+func Upgrade[T any](impl any) UpgradeComponent {
+	t := reflection.Type[T]()
+	if _, ok := impl.(T); !ok {
+		panic(fmt.Sprintf("%T does not implement %v", impl, t))
+	}
+	v := reflect.TypeOf(impl)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("%T is not a pointer to a struct", impl))
+	}
+	return UpgradeComponent{intf: t, impl: v.Elem()}
+}
+
+// Tagged returns an op filter, for [Options.OpFilter], that selects the ops
+// tagged with at least one of the provided tags. For example, the following
+// simulation only runs the ops tagged "smoke":
+//
+//	s := sim.New(t, &bankWorkload{}, sim.Options{OpFilter: sim.Tagged("smoke")})
+func Tagged(tags ...string) func(op string, tags []string) bool {
+	want := map[string]bool{}
+	for _, tag := range tags {
+		want[tag] = true
+	}
+	return func(_ string, tags []string) bool {
+		for _, tag := range tags {
+			if want[tag] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // A Generator[T] generates random values of type T.
 type Generator[T any] interface {
 	// Generate returns a randomly generated value of type T. While Generate is
@@ -233,6 +599,43 @@ type Registrar interface {
 	// TODO(mwhittaker): Allow people to register a func(*rand.Rand) T instead
 	// of a Generator[T] for convenience.
 	RegisterGenerators(method string, generators ...any)
+
+	// RegisterTags tags a workload method, e.g., as "smoke" or "nightly".
+	// [Options.OpFilter] selects the ops to simulate by name and tags, so
+	// that a single workload can be simulated in different ways without
+	// registering different subsets of its ops. Tags must be non-empty and
+	// must not contain commas or spaces. For example:
+	//
+	//     r.RegisterTags("Deposit", "smoke", "bank")
+	RegisterTags(method string, tags ...string)
+
+	// RegisterConfigUpdate registers an update to the config of running
+	// component replicas. The update is written in TOML, in the same format
+	// as Options.Config, and every section must be the section of a
+	// component that embeds weaver.WithConfig. For example:
+	//
+	//     r.RegisterConfigUpdate(`
+	//     ["example.com/mypkg/Cache"]
+	//     Size = 10
+	//     `)
+	//
+	// During an execution, the simulator applies every section of every
+	// registered update to every replica of the section's component, one
+	// replica at a time, in no particular order, at random points between
+	// other steps. See
+	// [ConfigUpdater] for how replicas are told about updates. Fakes are not
+	// updated.
+	RegisterConfigUpdate(config string)
+
+	// RegisterUpgrade registers a new implementation of a component. During
+	// an execution, the simulator performs a rolling upgrade of the
+	// component: at random points between other steps, it replaces the
+	// component's replicas with new replicas of the new implementation, one
+	// replica at a time, while ops run. Method calls in flight on a replaced
+	// replica finish on the old implementation. Ops therefore observe a mix
+	// of old and new replicas, which exposes incompatibilities between the
+	// two implementations. A component cannot be both faked and upgraded.
+	RegisterUpgrade(UpgradeComponent)
 }
 
 // A Workload defines the set of operations to run as part of a simulation.
@@ -253,8 +656,10 @@ type Registrar interface {
 // ignored.
 //
 // Note that every exported workload method must receive a [context.Context] as
-// its first argument and must return a single error value. A simulation is
-// aborted when a method returns a non-nil error.
+// its first argument and must return either a single error value or a result
+// value followed by an error. A simulation is aborted when a method returns a
+// non-nil error. Result values are recorded in the history and passed to the
+// workload's Check method, if it has one (see [Checker]).
 //
 // TODO(mwhittaker): For now, the Init method is required. In the future, we
 // could make it optional and use default generators for methods.
@@ -264,6 +669,60 @@ type Workload interface {
 	Init(Registrar) error
 }
 
+// A Checker is a [Workload] that checks invariants across ops. After every op
+// finishes successfully, the simulator calls Check with the results of every
+// op that has successfully finished so far in the current execution, in the
+// order they finished. If Check returns a non-nil error, the execution fails
+// with that error. The Check method is not an op.
+//
+// Checkers can express assertions that no single op can, like "a read must
+// observe the last acknowledged write":
+//
+//	func (w *kvWorkload) Write(ctx context.Context, v int) (int, error) {...}
+//	func (w *kvWorkload) Read(ctx context.Context) (int, error) {...}
+//
+//	func (w *kvWorkload) Check(results []sim.OpResult) error {
+//	    read := results[len(results)-1]
+//	    if read.Name != "Read" {
+//	        return nil
+//	    }
+//	    // Find the last write that finished before the read started.
+//	    for i := len(results) - 2; i >= 0; i-- {
+//	        write := results[i]
+//	        if write.Name == "Write" && write.Finish < read.Start {
+//	            return diff.Check("read", write.Value, read.Value)
+//	        }
+//	    }
+//	    return nil
+//	}
+//
+// Check must not modify or retain results, and it must not call component
+// methods.
+type Checker interface {
+	Workload
+	Check(results []OpResult) error
+}
+
+// OpResult is the result of an op that finished successfully.
+//
+// Start and Finish are logical timestamps: the positions in the execution's
+// history of the op's EventOpStart and EventOpFinish events. If one op's
+// Finish is less than another op's Start, the first op finished before the
+// second began.
+type OpResult struct {
+	TraceID int    // trace id of the op
+	Name    string // op name
+	Args    []any  // op arguments, excluding the context
+	Value   any    // returned result value, or nil if the op returns only an error
+	Start   int    // logical start time
+	Finish  int    // logical finish time
+}
+
+// isCheck returns whether m is the Check method of a Checker workload w.
+func isCheck(w reflect.Type, m reflect.Method) bool {
+	return m.Name == "Check" && w.Implements(reflection.Type[Checker]())
+}
+
 // Options configure a Simulator.
 type Options struct {
 	// TOML config file contents.
@@ -272,6 +731,108 @@ type Options struct {
 	// The number of executions to run in parallel. If Parallelism is 0, the
 	// simulator picks the degree of parallelism.
 	Parallelism int
+
+	// Registry holds the components available to the simulated application.
+	// If nil, the global registry, which holds every component linked into
+	// the binary, is used.
+	Registry *codegen.Registry
+
+	// Format formats the arguments and return values recorded in histories.
+	// By default, values are formatted like fmt.Sprint, with their sensitive
+	// data masked. See [Formatter] for ways to bound the cost of formatting
+	// large values.
+	Format Formatter
+
+	// Seed seeds the sweep of executions explored by Run. If 0, a time-based
+	// seed is used. Ignored when resuming from a checkpoint.
+	Seed int64
+
+	// If positive, Run pauses after MaxExecutions executions of the sweep,
+	// excluding graveyard entries, even if the duration passed to Run has not
+	// elapsed.
+	MaxExecutions int
+
+	// If non-empty, Checkpoint is the path of a file that records the
+	// progress of the simulation. If the file exists, Run resumes the
+	// simulation it records. When Run returns, it updates the file, so that a
+	// later Run, possibly on another machine, can pick up where it left off.
+	// See the "Checkpoints" section of the package documentation.
+	Checkpoint string
+
+	// Shard and NumShards split the sweep of executions across NumShards
+	// workers, of which this is worker Shard. Every worker should use the
+	// same Seed. If NumShards is 0, the sweep is not split.
+	Shard, NumShards int
+
+	// If non-empty, Farm is the address of a farm coordinator (see "weaver
+	// sim farm"), e.g. "http://coordinator:9000". Run then leases executions
+	// from the coordinator instead of exploring its own sweep, and reports the
+	// failures it finds instead of stopping at the first one. If Farm is
+	// empty, the WEAVER_SIM_FARM environment variable is used instead. Seed,
+	// Shard, NumShards, MaxExecutions, and Checkpoint are ignored in a farm.
+	// See the "Farms" section of the package documentation.
+	Farm string
+
+	// If true, Run doesn't stop at the first failing execution. Instead, it
+	// keeps simulating until the duration passed to Run elapses or
+	// MaxExecutions executions run, and clusters the failing executions by
+	// fingerprint in Results.Failures. Run writes a single graveyard entry
+	// for every cluster. See the "Failure Clustering" section of the package
+	// documentation.
+	KeepGoing bool
+
+	// If true, Run records the memory allocated by every op and component
+	// method call in Results.Allocs. Executions then run one at a time, and
+	// Parallelism is ignored. See the "Allocation Tracking" section of the
+	// package documentation.
+	TrackAllocs bool
+
+	// If non-empty and TrackAllocs is true, AllocBaseline is the path of a
+	// file that holds the allocation profile of an earlier run. If the file
+	// does not exist, Run writes the profile of this run to it. Otherwise,
+	// Run reports the ops and methods that allocate more bytes per call than
+	// in the baseline in Results.AllocRegressions.
+	AllocBaseline string
+
+	// AllocSlack is the fraction by which the bytes allocated per call of an
+	// op or method may exceed its baseline before it is reported as a
+	// regression. If zero, 0.1 (i.e. 10%) is used.
+	AllocSlack float64
+
+	// If true, Run records how much wall time the simulator spends on every
+	// kind of work, e.g., generating op inputs or formatting values recorded
+	// in histories, in Results.Steps. Executions then run one at a time, and
+	// Parallelism is ignored. See the "Step Profiling" section of the package
+	// documentation.
+	ProfileSteps bool
+
+	// If non-nil, Scheduler returns a new Scheduler, which decides which step
+	// every execution takes next. The simulator calls Scheduler once for
+	// every executor. If nil, an execution runs an op for a random number of
+	// steps, and then yields to another random op. See the "Schedulers"
+	// section of the package documentation.
+	Scheduler func() Scheduler
+
+	// If non-nil, OpFilter selects the ops that executions run, by op name
+	// and tags (see Registrar.RegisterTags). If nil, every op is selected.
+	// The generators of every op must still be registered. Ignored if
+	// Scenario is set. See [Tagged] and the "Op Tags" section of the package
+	// documentation.
+	OpFilter func(op string, tags []string) bool
+
+	// If non-nil, every execution runs the ops of Scenario, with their
+	// arguments, instead of randomly generated ops. Failures, interleavings,
+	// and the number of replicas still vary across executions. See the
+	// "Scenarios" section of the package documentation.
+	Scenario *Scenario
+
+	// If true, Run shrinks the failing execution it reports before
+	// returning. It reruns the execution's ops as a scenario, with subsets
+	// of the ops and simpler arguments, and reports the smallest execution
+	// that still fails the same way in Results.History, and its ops in
+	// Results.Reproducer. See the "Shrinking" section of the package
+	// documentation.
+	Shrink bool
 }
 
 // A Simulator deterministically simulates a Service Weaver application. See
@@ -283,6 +844,11 @@ type Simulator struct {
 	regsByIntf map[reflect.Type]*codegen.Registration // components, by interface
 	info       componentInfo                          // component metadata
 	config     *protos.AppConfig                      // application config
+	allocs     *allocTracker                          // allocations, if tracked
+	queues     *queueTracker                          // queueing statistics
+	steps      *stepTracker                           // step profile, if profiled
+	failures   *failureTracker                        // failing executions
+	scenario   []*scenarioOp                          // scenario ops, if any
 }
 
 // Results are the results of simulating a workload.
@@ -292,17 +858,71 @@ type Results struct {
 	NumExecutions int           // number of executions ran
 	NumOps        int           // number of ops ran
 	Duration      time.Duration // duration of simulation
+
+	// The failing executions, clustered by fingerprint, largest cluster
+	// first. Unless Options.KeepGoing is set, a simulation stops at its
+	// first failing execution, and there is at most one cluster.
+	Failures []FailureCluster
+
+	// Allocations of ops and component methods, if Options.TrackAllocs is
+	// set, and the ops and methods that regressed relative to
+	// Options.AllocBaseline, if any.
+	Allocs           *AllocProfile
+	AllocRegressions []AllocRegression
+
+	// Wall time of the simulator's work, by kind, if Options.ProfileSteps
+	// is set.
+	Steps *StepProfile
+
+	// Queueing statistics of every called component, by component name. See
+	// QueueStats. Not collected for executions that run on a farm.
+	Queues map[string]QueueStats
+
+	// The ops of the shrunk failing execution, if Options.Shrink is set and
+	// Err is not nil. Pass Reproducer as Options.Scenario to rerun them.
+	Reproducer *Scenario
+}
+
+// HistoryEvents returns an iterator over the events in r.History. It is a
+// view of r.History, which the simulator has already recorded in full by the
+// time Run returns. The iterator does not copy the history, and it stops early
+// if yield returns false:
+//
+//	results.HistoryEvents()(func(event sim.Event) bool {
+//	    fmt.Println(event)
+//	    return true
+//	})
+//
+// The returned function has the same type as iter.Seq[Event], so with Go 1.23
+// or later it can also be used in a range statement:
+//
+//	for event := range results.HistoryEvents() {
+//	    fmt.Println(event)
+//	}
+func (r *Results) HistoryEvents() func(yield func(Event) bool) {
+	return func(yield func(Event) bool) {
+		for _, event := range r.History {
+			if !yield(event) {
+				return
+			}
+		}
+	}
 }
 
 // New returns a new Simulator that simulates the provided workload.
 func New(t testing.TB, x Workload, opts Options) *Simulator {
 	t.Helper()
 
+	reg := opts.Registry
+	if reg == nil {
+		reg = codegen.Global()
+	}
+
 	// Parse config.
 	app := &protos.AppConfig{}
 	if opts.Config != "" {
 		var err error
-		app, err = swruntime.ParseConfig("", opts.Config, codegen.ComponentConfigValidator)
+		app, err = swruntime.ParseConfig("", opts.Config, reg.ConfigValidator)
 		if err != nil {
 			t.Fatalf("sim.New: parse config: %v", err)
 		}
@@ -343,17 +963,17 @@ func New(t testing.TB, x Workload, opts Options) *Simulator {
 		hasListeners: map[reflect.Type]bool{},
 		hasConfig:    map[reflect.Type]bool{},
 	}
-	for _, reg := range codegen.Registered() {
-		x := reflect.New(reg.Impl).Interface()
-		registered[reg.Iface] = struct{}{}
-		regsByIntf[reg.Iface] = reg
-		info.hasRefs[reg.Iface] = weaver.HasRefs(x)
-		info.hasListeners[reg.Iface] = weaver.HasListeners(x)
-		info.hasConfig[reg.Iface] = weaver.HasConfig(x)
+	for _, r := range reg.Registered() {
+		x := reflect.New(r.Impl).Interface()
+		registered[r.Iface] = struct{}{}
+		regsByIntf[r.Iface] = r
+		info.hasRefs[r.Iface] = weaver.HasRefs(x)
+		info.hasListeners[r.Iface] = weaver.HasListeners(x)
+		info.hasConfig[r.Iface] = weaver.HasConfig(x)
 	}
 
 	// Call Init and validate the registered fakes and generators.
-	r := newRegistrar(t, w, registered)
+	r := newRegistrar(t, w, registered, configValidator(regsByIntf))
 	if err := x.Init(r); err != nil {
 		t.Fatalf("sim.New: %v", err)
 	}
@@ -361,7 +981,16 @@ func New(t testing.TB, x Workload, opts Options) *Simulator {
 		t.Fatalf("sim.New: %v", err)
 	}
 
-	return &Simulator{opts, t, w, regsByIntf, info, app}
+	// Validate the scenario.
+	var scenario []*scenarioOp
+	if opts.Scenario != nil {
+		var err error
+		if scenario, err = compileScenario(w, opts.Scenario); err != nil {
+			t.Fatalf("sim.New: %v", err)
+		}
+	}
+
+	return &Simulator{opts, t, w, regsByIntf, info, app, nil, nil, nil, nil, scenario}
 }
 
 // validateWorkload validates a workload struct of the provided type.
@@ -370,13 +999,14 @@ func validateWorkload(w reflect.Type) error {
 	numOps := 0
 	for i := 0; i < w.NumMethod(); i++ {
 		m := w.Method(i)
-		if m.Name == "Init" {
+		if m.Name == "Init" || isCheck(w, m) {
 			continue
 		}
 		numOps++
 
-		// Method should have type func(context.Context, ...) error.
-		err := fmt.Errorf("method %s has type '%v' but should have type 'func(%v, context.Context, ...) error'", m.Name, m.Type, w)
+		// Method should have type func(context.Context, ...) error or
+		// func(context.Context, ...) (T, error).
+		err := fmt.Errorf("method %s has type '%v' but should have type 'func(%v, context.Context, ...) error' or 'func(%v, context.Context, ...) (T, error)'", m.Name, m.Type, w, w)
 		switch {
 		case m.Type.NumIn() < 2:
 			errs = append(errs, fmt.Errorf("%w: no arguments", err))
@@ -384,10 +1014,10 @@ func validateWorkload(w reflect.Type) error {
 			errs = append(errs, fmt.Errorf("%w: first argument is not context.Context", err))
 		case m.Type.NumOut() == 0:
 			errs = append(errs, fmt.Errorf("%w: no return value", err))
-		case m.Type.NumOut() > 1:
+		case m.Type.NumOut() > 2:
 			errs = append(errs, fmt.Errorf("%w: too many return values", err))
-		case m.Type.Out(0) != reflection.Type[error]():
-			errs = append(errs, fmt.Errorf("%w: return value is not error", err))
+		case m.Type.Out(m.Type.NumOut()-1) != reflection.Type[error]():
+			errs = append(errs, fmt.Errorf("%w: last return value is not error", err))
 		}
 	}
 	if numOps == 0 {
@@ -398,7 +1028,36 @@ func validateWorkload(w reflect.Type) error {
 
 // newExecutor returns a new executor.
 func (s *Simulator) newExecutor() *executor {
-	return newExecutor(s.t, s.w, s.regsByIntf, s.info, s.config)
+	e := newExecutor(s.t, s.w, s.regsByIntf, s.info, s.config, s.opts.Format)
+	e.allocs = s.allocs
+	e.queueStats = s.queues
+	if s.steps != nil {
+		e.timer = newStepTimer(s.steps)
+	}
+	if s.opts.Scheduler != nil {
+		e.scheduler = s.opts.Scheduler()
+	}
+	e.opFilter = s.opts.OpFilter
+	e.scenario = s.scenario
+	return e
+}
+
+// parallelism returns the number of executors to run.
+func (s *Simulator) parallelism() int {
+	switch {
+	case s.allocs != nil:
+		// Allocations are only attributed correctly when executions run one
+		// at a time.
+		return 1
+	case s.steps != nil:
+		// Executions running in parallel contend for CPUs, and the time they
+		// wait for a CPU would be charged to whatever work they are doing.
+		return 1
+	case s.opts.Parallelism == 0:
+		return 10 * runtime.NumCPU()
+	default:
+		return s.opts.Parallelism
+	}
 }
 
 // graveyardDir returns the graveyard directory for this simulator.
@@ -420,8 +1079,29 @@ func (s *Simulator) Run(duration time.Duration) Results {
 	defer cancel()
 
 	s.t.Logf("Simulating workload %v for %v.", s.w, duration)
+	run := s.run
+	if addr := s.farmAddr(); addr != "" {
+		run = func(ctx context.Context, stats *stats) (result, error) {
+			return s.runFarm(ctx, stats, addr)
+		}
+	}
+	s.allocs = nil
+	if s.opts.TrackAllocs {
+		s.allocs = newAllocTracker()
+	}
+	s.queues = newQueueTracker()
+	s.steps = nil
+	if s.opts.ProfileSteps {
+		s.steps = &stepTracker{}
+	}
+	s.failures = newFailureTracker()
 	stats := &stats{start: time.Now()}
-	switch result, err := s.run(ctx, stats); {
+	result, err := run(ctx, stats)
+	if first, ok := s.failures.firstFailure(); ok && result.err == nil && (err == nil || err == ctx.Err()) {
+		// The simulation kept going after finding failing executions.
+		result, err = first, nil
+	}
+	switch {
 	case err != nil && err == ctx.Err():
 		// The simulation was cancelled.
 		results := Results{
@@ -429,7 +1109,10 @@ func (s *Simulator) Run(duration time.Duration) Results {
 			NumOps:        int(stats.numOps),
 			Duration:      time.Since(stats.start),
 		}
+		s.reportAllocs(&results)
 		s.t.Log(results.summary())
+		s.reportQueues(&results)
+		s.reportSteps(&results)
 		return results
 
 	case err != nil:
@@ -445,19 +1128,42 @@ func (s *Simulator) Run(duration time.Duration) Results {
 			NumExecutions: int(stats.numExecutions),
 			NumOps:        int(stats.numOps),
 			Duration:      time.Since(stats.start),
+			Failures:      s.failures.failures(),
 		}
+		if s.opts.Shrink {
+			// The graveyard entry records the hyperparameters of the
+			// original execution, which reproduce it, while the history
+			// file records the shrunk execution.
+			shrunk, reproducer, err := s.shrink(context.Background(), result)
+			if err != nil {
+				s.t.Logf("Failed to shrink failing execution: %v", err)
+			} else {
+				s.t.Logf("Shrunk failing execution from %d ops (%d events) to %d ops (%d events):\n%s", len(result.ops), len(result.history), len(reproducer.Ops), len(shrunk.history), formatScenario(reproducer, s.opts.Format))
+				results.Err = shrunk.err
+				results.History = shrunk.history
+				results.Reproducer = reproducer
+				result.err = shrunk.err
+				result.history = shrunk.history
+			}
+		}
+		s.reportAllocs(&results)
 		s.t.Log(results.summary())
-
-		entry := graveyardEntry{
-			Version:     version,
-			Seed:        result.params.Seed,
-			NumReplicas: result.params.NumReplicas,
-			NumOps:      result.params.NumOps,
-			FailureRate: result.params.FailureRate,
-			YieldRate:   result.params.YieldRate,
+		s.reportQueues(&results)
+		s.reportSteps(&results)
+		var mismatch *diff.Error
+		if errors.As(result.err, &mismatch) {
+			s.t.Logf("%s mismatch (-want +got):\n%s", mismatch.Msg, mismatch.Diff)
 		}
-		if filename, err := writeGraveyardEntry(s.graveyardDir(), entry); err == nil {
-			s.t.Logf("Failing input written to %s.", filename)
+		if !s.opts.KeepGoing {
+			s.bury(result.params, result.history)
+			return results
+		}
+
+		// Write the reproducer of every cluster, rather than every failing
+		// execution, most of which are likely equivalent.
+		s.t.Log(results.failureSummary())
+		for _, c := range results.Failures {
+			s.bury(c.params, c.History)
 		}
 		return results
 
@@ -468,11 +1174,33 @@ func (s *Simulator) Run(duration time.Duration) Results {
 			NumOps:        int(stats.numOps),
 			Duration:      time.Since(stats.start),
 		}
+		s.reportAllocs(&results)
 		s.t.Log(results.summary())
+		s.reportQueues(&results)
+		s.reportSteps(&results)
 		return results
 	}
 }
 
+// bury writes a graveyard entry and a history file for the failing execution
+// with the provided hyperparameters and history.
+func (s *Simulator) bury(params hyperparameters, history []Event) {
+	entry := graveyardEntry{
+		Version:     version,
+		Seed:        params.Seed,
+		NumReplicas: params.NumReplicas,
+		NumOps:      params.NumOps,
+		FailureRate: params.FailureRate,
+		YieldRate:   params.YieldRate,
+	}
+	if filename, err := writeGraveyardEntry(s.graveyardDir(), entry); err == nil {
+		s.t.Logf("Failing input written to %s.", filename)
+	}
+	if filename, err := writeHistoryFile(history); err == nil {
+		s.t.Logf("History written to %s. Run 'weaver sim debug %s' to step through it.", filename, filename)
+	}
+}
+
 // stats contains simulation statistics.
 type stats struct {
 	start         time.Time // start of simulation
@@ -499,26 +1227,62 @@ func (s *Simulator) run(ctx context.Context, stats *stats) (result, error) {
 		return r, err
 	}
 
+	// Resume from a checkpoint, if there is one.
+	numShards := max(s.opts.NumShards, 1)
+	if s.opts.Shard < 0 || s.opts.Shard >= numShards {
+		return result{}, fmt.Errorf("Shard (%d) out of range [0, %d)", s.opts.Shard, numShards)
+	}
+	cp := checkpoint{Seed: s.opts.Seed, Shard: s.opts.Shard, NumShards: numShards}
+	if cp.Seed == 0 {
+		cp.Seed = time.Now().UnixNano()
+	}
+	if s.opts.Checkpoint != "" {
+		saved, err := readCheckpoint(s.opts.Checkpoint)
+		if err != nil {
+			return result{}, err
+		}
+		if saved != nil {
+			if saved.Shard != cp.Shard || saved.NumShards != cp.NumShards {
+				return result{}, fmt.Errorf("checkpoint %q is for shard %d of %d, not shard %d of %d", s.opts.Checkpoint, saved.Shard, saved.NumShards, cp.Shard, cp.NumShards)
+			}
+			cp = *saved
+			s.t.Logf("Resuming from checkpoint %s at execution %d.", s.opts.Checkpoint, cp.Next)
+		}
+	}
+	progress := newProgress(cp.Next)
+	if s.opts.Checkpoint != "" {
+		defer func() {
+			cp.Next = progress.low()
+			cp.NumExecutions += atomic.LoadInt64(&stats.numExecutions)
+			cp.NumOps += atomic.LoadInt64(&stats.numOps)
+			if err := writeCheckpoint(s.opts.Checkpoint, cp); err != nil {
+				s.t.Logf("Failed to write checkpoint: %v", err)
+				return
+			}
+			s.t.Logf("Checkpoint written to %s.", s.opts.Checkpoint)
+		}()
+	}
+
 	// Spawn n concurrent executors which read hyperparamters from the params
 	// channel. Simulation ends when:
 	//
 	//     1. the context is cancelled;
 	//     2. an execution fails to run properly (written to errs); or
 	//     3. a failing execution is found (written to failing).
-	n := s.opts.Parallelism
-	if n == 0 {
-		n = 10 * runtime.NumCPU()
-	}
-	params := make(chan hyperparameters, n)
+	n := s.parallelism()
+	tasks := make(chan task, n)
 	errs := make(chan error, n)
 	failing := make(chan result, n)
 
 	s.t.Logf("Executing with %d executors.", n)
+	executors := sync.WaitGroup{}
+	executors.Add(n)
 	done.Add(n)
 	for i := 0; i < n; i++ {
 		go func() {
 			defer done.Done()
-			switch r, err := s.execute(ctx, stats, params); {
+			defer executors.Done()
+			switch r, err := s.execute(ctx, stats, tasks, progress); {
 			case err != nil && err == ctx.Err():
 				return
 			case err != nil:
@@ -530,43 +1294,55 @@ func (s *Simulator) run(ctx context.Context, stats *stats) (result, error) {
 		}()
 	}
 
-	// Spawn a goroutine that writes to the params channel.
-	//
-	// TODO(mwhittaker): Use a smarter algorithm to sweep over hyperparameters.
+	// Spawn a goroutine that writes the shard's executions to the tasks
+	// channel. If MaxExecutions is set, the channel is closed after
+	// MaxExecutions executions, and the executors exit once they finish.
 	done.Add(1)
 	go func() {
 		defer done.Done()
-		seed := time.Now().UnixNano()
-		for numOps := 1; ; numOps++ {
-			for _, numReplicas := range []int{1, 2, 3} {
-				for _, failureRate := range []float64{0.0, 0.01, 0.05, 0.1} {
-					for _, yieldRate := range []float64{0.0, 0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7, 0.8, 0.9, 1.0} {
-						for i := 0; i < 1000; i++ {
-							seed++
-							p := hyperparameters{
-								Seed:        seed,
-								NumOps:      numOps,
-								NumReplicas: numReplicas,
-								FailureRate: failureRate,
-								YieldRate:   yieldRate,
-							}
-							select {
-							case <-ctx.Done():
-								return
-							case params <- p:
-							}
-						}
-					}
-				}
+		defer close(tasks)
+		for i := cp.Next; s.opts.MaxExecutions <= 0 || i < cp.Next+int64(s.opts.MaxExecutions); i++ {
+			t := task{i, sweep(cp.Seed, int64(cp.Shard)+i*int64(cp.NumShards))}
+			select {
+			case <-ctx.Done():
+				return
+			case tasks <- t:
 			}
 		}
 	}()
+	paused := make(chan struct{})
+	go func() {
+		executors.Wait()
+		close(paused)
+	}()
 
 	// Wait for the simulation to end.
 	select {
 	case <-ctx.Done():
 		done.Wait()
 		return result{}, ctx.Err()
+	case <-paused:
+		// Errors and failing executions are sent before executors exit.
+		select {
+		case err := <-errs:
+			cancel()
+			done.Wait()
+			return result{}, err
+		case r := <-failing:
+			cancel()
+			done.Wait()
+			return r, nil
+		default:
+		}
+		if ctx.Err() != nil {
+			// The tasks channel was closed because the context was cancelled.
+			done.Wait()
+			return result{}, ctx.Err()
+		}
+		cancel()
+		done.Wait()
+		s.t.Logf("Paused after %d executions.", s.opts.MaxExecutions)
+		return result{}, nil
 	case err := <-errs:
 		cancel()
 		done.Wait()
@@ -624,34 +1400,51 @@ func (s *Simulator) executeGraveyard(ctx context.Context, stats *stats) (result,
 			return result{}, err
 		}
 		atomic.AddInt64(&stats.numExecutions, 1)
-		atomic.AddInt64(&stats.numOps, int64(p.NumOps))
+		atomic.AddInt64(&stats.numOps, int64(r.params.NumOps))
 		if r.err != nil {
-			return r, nil
+			s.failures.add(r)
+			if !s.opts.KeepGoing {
+				return r, nil
+			}
 		}
 	}
 	s.t.Log("Done executing graveyard entries.")
 	return result{}, nil
 }
 
+// task is an execution of a shard of the sweep.
+type task struct {
+	index  int64           // index of the execution within the shard
+	params hyperparameters // hyperparameters of the execution
+}
+
 // execute repeatedly performs executions until the provided context is
-// cancelled or until a failing result is found. Hyperparameters for the
-// executions are read from the provided params channel.
-func (s *Simulator) execute(ctx context.Context, stats *stats, params <-chan hyperparameters) (result, error) {
+// cancelled, the tasks channel is closed, or a failing result is found and
+// Options.KeepGoing is not set.
+// Completed executions are recorded in the provided progress.
+func (s *Simulator) execute(ctx context.Context, stats *stats, tasks <-chan task, progress *progress) (result, error) {
 	exec := s.newExecutor()
 	for {
 		select {
 		case <-ctx.Done():
 			return result{}, ctx.Err()
-		case p := <-params:
-			r, err := exec.execute(ctx, p)
+		case t, ok := <-tasks:
+			if !ok {
+				return result{}, nil
+			}
+			r, err := exec.execute(ctx, t.params)
 			if err != nil {
 				return result{}, err
 			}
 			atomic.AddInt64(&stats.numExecutions, 1)
-			atomic.AddInt64(&stats.numOps, int64(p.NumOps))
+			atomic.AddInt64(&stats.numOps, int64(r.params.NumOps))
 			if r.err != nil {
-				return r, nil
+				s.failures.add(r)
+				if !s.opts.KeepGoing {
+					return r, nil
+				}
 			}
+			progress.complete(t.index)
 		}
 	}
 }
@@ -730,7 +1523,11 @@ func (r *Results) Mermaid() string {
 		case EventOpStart:
 			fmt.Fprintf(&b, "    note right of op%d: [%d:%d] %s(%s)\n", x.TraceID, x.TraceID, x.SpanID, x.Name, commas(x.Args))
 		case EventOpFinish:
-			fmt.Fprintf(&b, "    note right of op%d: [%d:%d] return %s\n", x.TraceID, x.TraceID, x.SpanID, x.Error)
+			if x.Result != "" {
+				fmt.Fprintf(&b, "    note right of op%d: [%d:%d] return %s, %s\n", x.TraceID, x.TraceID, x.SpanID, x.Result, x.Error)
+			} else {
+				fmt.Fprintf(&b, "    note right of op%d: [%d:%d] return %s\n", x.TraceID, x.TraceID, x.SpanID, x.Error)
+			}
 		case EventDeliverCall:
 			call := calls[x.SpanID]
 			fmt.Fprintf(&b, "    %s%d->>%s%d: [%d:%d] %s.%s(%s)\n", call.Caller, call.Replica, call.Component, x.Replica, x.TraceID, x.SpanID, shorten(call.Component), call.Method, commas(call.Args))
@@ -25,6 +25,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver"
 	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
 )
 
 var positive = Filter(NonNegativeInt(), func(x int) bool { return x != 0 })
@@ -77,6 +78,24 @@ func TestPassingSimulations(t *testing.T) {
 	}
 }
 
+func TestRegistrySimulation(t *testing.T) {
+	// Simulate the DivMod workload against a registry that holds only the
+	// components it uses.
+	reg, err := codegen.Global().Subset([]string{
+		"github.com/ServiceWeaver/weaver/sim/divMod",
+		"github.com/ServiceWeaver/weaver/sim/div",
+		"github.com/ServiceWeaver/weaver/sim/mod",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New(t, &divModWorkload{}, Options{Registry: reg})
+	r := s.Run(1 * time.Second)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+}
+
 // See TestInitByValueSimulation.
 type initByValueWorkload struct{}
 
@@ -157,6 +176,16 @@ func TestFailingSimulation(t *testing.T) {
 	if r.Err == nil {
 		t.Fatal("Unexpected success")
 	}
+
+	// Check that HistoryEvents iterates over the history.
+	var events []Event
+	r.HistoryEvents()(func(event Event) bool {
+		events = append(events, event)
+		return true
+	})
+	if !reflect.DeepEqual(events, r.History) {
+		t.Fatalf("HistoryEvents: got %v, want %v", events, r.History)
+	}
 }
 
 func TestValidateValidWorkload(t *testing.T) {
@@ -170,11 +199,12 @@ func TestValidateValidWorkload(t *testing.T) {
 // invalidWorkload is an invalid workload. See TestValidateInvalidWorkload.
 type invalidWorkload struct{}
 
-func (*invalidWorkload) NoArguments() error                           { return nil }
-func (*invalidWorkload) WrongFirstArgument(int) error                 { return nil }
-func (*invalidWorkload) NoReturns(context.Context)                    {}
-func (*invalidWorkload) TooManyReturnss(context.Context) (int, error) { return 0, nil }
-func (*invalidWorkload) WrongReturn(context.Context) int              { return 0 }
+func (*invalidWorkload) NoArguments() error                                   { return nil }
+func (*invalidWorkload) WrongFirstArgument(int) error                         { return nil }
+func (*invalidWorkload) NoReturns(context.Context)                            {}
+func (*invalidWorkload) TooManyReturnss(context.Context) (int, string, error) { return 0, "", nil }
+func (*invalidWorkload) WrongReturn(context.Context) int                      { return 0 }
+func (*invalidWorkload) WrongLastReturn(context.Context) (error, int)         { return nil, 0 }
 
 func TestValidateInvalidWorkload(t *testing.T) {
 	// Call validateWorkload on an invalid workload.
@@ -188,7 +218,7 @@ func TestValidateInvalidWorkload(t *testing.T) {
 		"first argument is not context.Context",
 		"no return value",
 		"too many return values",
-		"return value is not error",
+		"last return value is not error",
 	} {
 		if !strings.Contains(err.Error(), want) {
 			t.Errorf("Error does not contain %q:\n%s", want, err.Error())
@@ -0,0 +1,224 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Like allocation tracking (see allocs.go), step profiling relies on only one
+// goroutine of an execution running at a time. When step profiling is
+// enabled, the executor reads the clock every time it switches from one kind
+// of work to another, e.g., from generating the inputs of an op to calling
+// the op, and charges the elapsed wall time to the kind of work it switched
+// from. Every nanosecond of an execution is charged to exactly one kind of
+// work, so the breakdown adds up to the time spent executing.
+
+// StepStats is the wall time a simulator spent on one kind of work.
+type StepStats struct {
+	Count    int64         `json:"count"`    // number of times the work was started or resumed
+	Duration time.Duration `json:"duration"` // total wall time
+}
+
+// Mean returns the average wall time of the work.
+func (s StepStats) Mean() time.Duration {
+	if s.Count == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Count)
+}
+
+// StepProfile breaks down the wall time of a simulation's executions by the
+// kind of work the simulator did.
+type StepProfile struct {
+	Generate StepStats `json:"generate"` // generating op inputs
+	Call     StepStats `json:"call"`     // calling ops and component methods by reflection, and running them
+	Check    StepStats `json:"check"`    // checking invariants (see Checker)
+	Marshal  StepStats `json:"marshal"`  // serializing method arguments and results
+	Format   StepStats `json:"format"`   // formatting values recorded in histories
+	History  StepStats `json:"history"`  // appending events to histories
+	Schedule StepStats `json:"schedule"` // everything else, e.g., picking and taking steps
+}
+
+// Total returns the total wall time in the profile.
+func (p *StepProfile) Total() time.Duration {
+	var total time.Duration
+	for _, s := range p.steps() {
+		total += s.stats.Duration
+	}
+	return total
+}
+
+// namedStats are the stats of a named kind of work.
+type namedStats struct {
+	name  string
+	stats *StepStats
+}
+
+// steps returns the stats in the profile, indexed by kind of work.
+func (p *StepProfile) steps() []namedStats {
+	return []namedStats{
+		stepSchedule: {"schedule", &p.Schedule},
+		stepGenerate: {"generate", &p.Generate},
+		stepCall:     {"call", &p.Call},
+		stepCheck:    {"check", &p.Check},
+		stepMarshal:  {"marshal", &p.Marshal},
+		stepFormat:   {"format", &p.Format},
+		stepHistory:  {"history", &p.History},
+	}
+}
+
+// step is a kind of work done by an executor.
+type step int
+
+const (
+	stepSchedule step = iota
+	stepGenerate
+	stepCall
+	stepCheck
+	stepMarshal
+	stepFormat
+	stepHistory
+	numSteps
+)
+
+// stepTimer charges the wall time of an executor's executions to the kinds of
+// work the executor does. A nil *stepTimer is valid and times nothing, so
+// that executors don't have to check whether steps are profiled.
+type stepTimer struct {
+	tracker *stepTracker // where to merge the stats of every execution
+
+	mu      sync.Mutex
+	current step                // the work being done
+	last    time.Time           // when the current work started
+	stats   [numSteps]StepStats // stats not yet merged into the tracker
+}
+
+// newStepTimer returns a new stepTimer that merges its stats into the
+// provided tracker.
+func newStepTimer(tracker *stepTracker) *stepTimer {
+	return &stepTimer{tracker: tracker}
+}
+
+// start starts timing an execution.
+func (t *stepTimer) start() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = stepSchedule
+	t.last = time.Now()
+	t.stats[stepSchedule].Count++
+}
+
+// enter charges the wall time since the current work started to the current
+// work, and starts the provided work. It returns the work that was current,
+// so that callers can resume it:
+//
+//	prev := e.timer.enter(stepFormat)
+//	...
+//	e.timer.enter(prev)
+func (t *stepTimer) enter(s step) step {
+	if t == nil {
+		return s
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	prev := t.current
+	t.stats[prev].Duration += now.Sub(t.last)
+	t.current = s
+	t.last = now
+	if s != prev {
+		t.stats[s].Count++
+	}
+	return prev
+}
+
+// stop stops timing an execution and merges its stats into the tracker.
+func (t *stepTimer) stop() {
+	if t == nil {
+		return
+	}
+	t.enter(stepSchedule)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tracker.merge(&t.stats)
+	t.stats = [numSteps]StepStats{}
+}
+
+// stepTracker aggregates the step stats of every execution of a simulation.
+// A stepTracker is shared by the executors of a simulator.
+type stepTracker struct {
+	mu    sync.Mutex
+	stats [numSteps]StepStats
+}
+
+// merge merges the provided stats into the tracker.
+func (t *stepTracker) merge(stats *[numSteps]StepStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for s := range stats {
+		t.stats[s].Count += stats[s].Count
+		t.stats[s].Duration += stats[s].Duration
+	}
+}
+
+// profile returns the stats tracked so far.
+func (t *stepTracker) profile() StepProfile {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var p StepProfile
+	for s, step := range p.steps() {
+		*step.stats = t.stats[s]
+	}
+	return p
+}
+
+// reportSteps populates the step profile of the provided results and logs it.
+func (s *Simulator) reportSteps(results *Results) {
+	if s.steps == nil {
+		return
+	}
+	profile := s.steps.profile()
+	results.Steps = &profile
+	s.t.Log(results.stepSummary())
+}
+
+// stepSummary returns a table of the step profile in r, with the most time
+// consuming work first.
+func (r *Results) stepSummary() string {
+	steps := r.Steps.steps()
+	total := r.Steps.Total()
+	var b strings.Builder
+	fmt.Fprintln(&b, "Step profile (wall time of executions):")
+	fmt.Fprintf(&b, "%-10s %12s %14s %12s %7s\n", "step", "count", "total", "mean", "share")
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].stats.Duration > steps[j].stats.Duration
+	})
+	for _, s := range steps {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(s.stats.Duration) / float64(total)
+		}
+		fmt.Fprintf(&b, "%-10s %12d %14v %12v %6.1f%%\n", s.name, s.stats.Count, s.stats.Duration.Round(time.Microsecond), s.stats.Mean(), share)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileSteps(t *testing.T) {
+	start := time.Now()
+	s := New(t, &divModWorkload{}, Options{Seed: 1, MaxExecutions: 100, ProfileSteps: true})
+	r := s.Run(time.Minute)
+	elapsed := time.Since(start)
+	if r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	if r.Steps == nil {
+		t.Fatal("Steps: got nil, want profile")
+	}
+	for _, step := range r.Steps.steps() {
+		if step.name == "check" || step.name == "marshal" {
+			// divModWorkload has no checks, and marshaling is only timed
+			// when calls are delivered.
+			continue
+		}
+		if step.stats.Count == 0 || step.stats.Duration <= 0 {
+			t.Errorf("%s: got %+v, want count and duration", step.name, *step.stats)
+		}
+	}
+	if total := r.Steps.Total(); total <= 0 || total > elapsed {
+		t.Errorf("Total: got %v, want in (0, %v]", total, elapsed)
+	}
+	summary := r.stepSummary()
+	for _, name := range []string{"generate", "call", "format", "history", "schedule"} {
+		if !strings.Contains(summary, name) {
+			t.Errorf("stepSummary: missing %q in\n%s", name, summary)
+		}
+	}
+}
+
+func TestNoStepProfile(t *testing.T) {
+	s := New(t, &divModWorkload{}, Options{Seed: 1, MaxExecutions: 10})
+	if r := s.Run(time.Minute); r.Steps != nil {
+		t.Fatalf("Steps: got %+v, want nil", r.Steps)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sim
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// errCrashed is returned by the steps of a crashed coordinator.
+var errCrashed = errors.New("coordinator crashed")
+
+// A crasher crashes a coordinator at a step of the two-phase commit protocol.
+// A step is a write to the coordinator's log or a call to a participant. The
+// coordinator crashes either right before step at, or right after it, before
+// it observes the step's result. Once crashed, every step fails without being
+// performed, as if the coordinator had stopped.
+type crasher struct {
+	at      int  // the step at which to crash, or 0 to not crash
+	after   bool // crash after step at, rather than before
+	step    int  // the number of steps taken
+	crashed bool // has the coordinator crashed?
+}
+
+// do performs a step.
+func (c *crasher) do(f func() error) error {
+	if c.crashed {
+		return errCrashed
+	}
+	c.step++
+	if c.step == c.at && !c.after {
+		c.crashed = true
+		return errCrashed
+	}
+	err := f()
+	if c.step == c.at {
+		c.crashed = true
+		return errCrashed
+	}
+	return err
+}
+
+// crashLog is a TxLog that crashes.
+type crashLog struct {
+	c   *crasher
+	log weaver.TxLog
+}
+
+func (l crashLog) Store(tx string, record []byte) error {
+	return l.c.do(func() error { return l.log.Store(tx, record) })
+}
+
+func (l crashLog) Delete(tx string) error {
+	return l.c.do(func() error { return l.log.Delete(tx) })
+}
+
+func (l crashLog) Load() (map[string][]byte, error) {
+	return l.log.Load()
+}
+
+// crashParticipant is a TxParticipant that crashes.
+type crashParticipant struct {
+	c *crasher
+	p weaver.TxParticipant
+}
+
+func (p crashParticipant) Prepare(ctx context.Context, tx string, op []byte) error {
+	return p.c.do(func() error { return p.p.Prepare(ctx, tx, op) })
+}
+
+func (p crashParticipant) Commit(ctx context.Context, tx string) error {
+	return p.c.do(func() error { return p.p.Commit(ctx, tx) })
+}
+
+func (p crashParticipant) Abort(ctx context.Context, tx string) error {
+	return p.c.do(func() error { return p.p.Abort(ctx, tx) })
+}
+
+// maxSteps is the largest number of steps of a transaction with two
+// participants: log, prepare twice, log, commit twice, and delete the log.
+const maxSteps = 7
+
+// transferWorkload transfers money between the checking and savings accounts
+// in two-phase commit transactions, crashing the coordinator at every step of
+// the protocol, and checks that the transactions are atomic once a restarted
+// coordinator recovers them.
+type transferWorkload struct {
+	checking weaver.Ref[checking]
+	savings  weaver.Ref[savings]
+
+	mu   sync.Mutex
+	next int // the next transaction ID
+}
+
+func (w *transferWorkload) Init(r Registrar) error {
+	r.RegisterGenerators("Transfer", Range(0, 2*(maxSteps+1)), Range(-5, 6))
+	r.RegisterGenerators("Check")
+	return nil
+}
+
+// retry calls f until it succeeds, up to a bound on the number of attempts.
+func retry(ctx context.Context, f func() error) error {
+	var err error
+	for i := 0; i < 100; i++ {
+		if err = f(); err == nil || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func (w *transferWorkload) Transfer(ctx context.Context, crashAt, amount int) error {
+	w.mu.Lock()
+	tx := fmt.Sprintf("tx%d", w.next)
+	w.next++
+	w.mu.Unlock()
+
+	// Every transaction has a coordinator, and a log, of its own, so that a
+	// restarted coordinator only recovers the transaction it crashed in.
+	participants := map[string]weaver.TxParticipant{
+		"checking": w.checking.Get(),
+		"savings":  w.savings.Get(),
+	}
+	c := &crasher{at: crashAt / 2, after: crashAt%2 == 1}
+	crashing := map[string]weaver.TxParticipant{}
+	for name, p := range participants {
+		crashing[name] = crashParticipant{c, p}
+	}
+	log := weaver.NewMemTxLog()
+	ops := map[string][]byte{
+		"checking": binary.LittleEndian.AppendUint64(nil, uint64(-amount)),
+		"savings":  binary.LittleEndian.AppendUint64(nil, uint64(amount)),
+	}
+	err := weaver.NewTxCoordinator(crashLog{c, log}, crashing).Run(ctx, tx, ops)
+
+	// Restart the coordinator, and recover until the transaction finishes.
+	coord := weaver.NewTxCoordinator(log, participants)
+	if err := retry(ctx, func() error { return coord.Recover(ctx) }); err != nil {
+		return fmt.Errorf("%s: recover: %w", tx, err)
+	}
+	if records, _ := log.Load(); len(records) > 0 {
+		return fmt.Errorf("%s: log not empty after recovery", tx)
+	}
+
+	var checkingState, savingsState weaver.TxState
+	if err := retry(ctx, func() error {
+		var err error
+		checkingState, err = w.checking.Get().State(ctx, tx)
+		return err
+	}); err != nil {
+		return nil
+	}
+	if err := retry(ctx, func() error {
+		var err error
+		savingsState, err = w.savings.Get().State(ctx, tx)
+		return err
+	}); err != nil {
+		return nil
+	}
+
+	switch {
+	case checkingState != savingsState:
+		return fmt.Errorf("%s (crash at step %d, after=%t): checking %v, savings %v", tx, c.at, c.after, checkingState, savingsState)
+	case checkingState == weaver.TxPrepared:
+		return fmt.Errorf("%s: prepared after recovery", tx)
+	case err == nil && checkingState != weaver.TxCommitted:
+		return fmt.Errorf("%s: Run succeeded, but transaction %v", tx, checkingState)
+	case errors.Is(err, weaver.ErrTxAborted) && checkingState != weaver.TxAborted:
+		return fmt.Errorf("%s: Run aborted, but transaction %v", tx, checkingState)
+	}
+	return nil
+}
+
+func (w *transferWorkload) Check(ctx context.Context) error {
+	for _, b := range []func(context.Context) (int, error){w.checking.Get().Balance, w.savings.Get().Balance} {
+		balance, err := b(ctx)
+		if err != nil {
+			continue
+		}
+		if balance < 0 {
+			return fmt.Errorf("negative balance %d", balance)
+		}
+	}
+	return nil
+}
+
+func TestTwoPhaseCommitSimulation(t *testing.T) {
+	s := New(t, &transferWorkload{}, Options{})
+	for seed := int64(0); seed < 100; seed++ {
+		result, err := s.newExecutor().execute(context.Background(), hyperparameters{
+			Seed:        seed,
+			NumReplicas: 1,
+			NumOps:      50,
+			FailureRate: 0.05,
+			YieldRate:   0.5,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.err != nil {
+			t.Fatalf("seed %d: %v", seed, result.err)
+		}
+	}
+}
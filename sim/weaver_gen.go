@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*blocker)(nil)).Elem(),
 		Impl:  reflect.TypeOf(blockerImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return blocker_local_stub{impl: impl.(blocker), tracer: tracer, blockMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/blocker", Method: "Block", Remote: false, Generated: true})}
+			return blocker_local_stub{impl: impl.(blocker), tracer: tracer, caller: codegen.Caller{Component: caller}, blockMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/blocker", Method: "Block", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return blocker_client_stub{stub: stub, blockMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/blocker", Method: "Block", Remote: true, Generated: true})}
@@ -31,14 +31,38 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return blocker_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "edda4d2b87f8c91a",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/checking",
+		Iface: reflect.TypeOf((*checking)(nil)).Elem(),
+		Impl:  reflect.TypeOf(checkingImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return checking_local_stub{impl: impl.(checking), tracer: tracer, caller: codegen.Caller{Component: caller}, abortMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Abort", Remote: false, Generated: true}), balanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Balance", Remote: false, Generated: true}), commitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Commit", Remote: false, Generated: true}), prepareMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Prepare", Remote: false, Generated: true}), stateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "State", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return checking_client_stub{stub: stub, abortMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Abort", Remote: true, Generated: true}), balanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Balance", Remote: true, Generated: true}), commitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Commit", Remote: true, Generated: true}), prepareMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "Prepare", Remote: true, Generated: true}), stateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/checking", Method: "State", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return checking_server_stub{impl: impl.(checking), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return checking_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "0e19c663babd71b6",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/div",
 		Iface: reflect.TypeOf((*div)(nil)).Elem(),
 		Impl:  reflect.TypeOf(divImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return div_local_stub{impl: impl.(div), tracer: tracer, divMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/div", Method: "Div", Remote: false, Generated: true})}
+			return div_local_stub{impl: impl.(div), tracer: tracer, caller: codegen.Caller{Component: caller}, divMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/div", Method: "Div", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return div_client_stub{stub: stub, divMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/div", Method: "Div", Remote: true, Generated: true})}
@@ -49,14 +73,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return div_reflect_stub{caller: caller}
 		},
-		RefData: "⟦6ddebe91:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/div→github.com/ServiceWeaver/weaver/sim/identity⟧\n",
+		RefData:          "⟦6ddebe91:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/div→github.com/ServiceWeaver/weaver/sim/identity⟧\n⟦d226fbe6:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/div.Div→github.com/ServiceWeaver/weaver/sim/identity.Identity@components.go:217:12⟧\n⟦6a813a1b:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/div.Div→github.com/ServiceWeaver/weaver/sim/identity.Identity@components.go:221:11⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "af240dbe19471b4f",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/divMod",
 		Iface: reflect.TypeOf((*divMod)(nil)).Elem(),
 		Impl:  reflect.TypeOf(divModImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return divMod_local_stub{impl: impl.(divMod), tracer: tracer, divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/divMod", Method: "DivMod", Remote: false, Generated: true})}
+			return divMod_local_stub{impl: impl.(divMod), tracer: tracer, caller: codegen.Caller{Component: caller}, divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/divMod", Method: "DivMod", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return divMod_client_stub{stub: stub, divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/divMod", Method: "DivMod", Remote: true, Generated: true})}
@@ -67,14 +94,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return divMod_reflect_stub{caller: caller}
 		},
-		RefData: "⟦df3a80a0:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/divMod→github.com/ServiceWeaver/weaver/sim/div⟧\n⟦b28314dd:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/divMod→github.com/ServiceWeaver/weaver/sim/mod⟧\n",
+		RefData:          "⟦df3a80a0:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/divMod→github.com/ServiceWeaver/weaver/sim/div⟧\n⟦b28314dd:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/divMod→github.com/ServiceWeaver/weaver/sim/mod⟧\n⟦b6cf0ed6:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/divMod.DivMod→github.com/ServiceWeaver/weaver/sim/div.Div@components.go:202:14⟧\n⟦41b3132f:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/divMod.DivMod→github.com/ServiceWeaver/weaver/sim/mod.Mod@components.go:206:14⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "0f7f0a88d3d35cd3",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/identity",
 		Iface: reflect.TypeOf((*identity)(nil)).Elem(),
 		Impl:  reflect.TypeOf(identityImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return identity_local_stub{impl: impl.(identity), tracer: tracer, identityMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/identity", Method: "Identity", Remote: false, Generated: true})}
+			return identity_local_stub{impl: impl.(identity), tracer: tracer, caller: codegen.Caller{Component: caller}, identityMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/identity", Method: "Identity", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return identity_client_stub{stub: stub, identityMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/identity", Method: "Identity", Remote: true, Generated: true})}
@@ -85,14 +115,59 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return identity_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "4c8e76d158ba1682",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/limiter",
+		Iface: reflect.TypeOf((*limiter)(nil)).Elem(),
+		Impl:  reflect.TypeOf(limiterImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return limiter_local_stub{impl: impl.(limiter), tracer: tracer, caller: codegen.Caller{Component: caller}, limitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/limiter", Method: "Limit", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return limiter_client_stub{stub: stub, limitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/limiter", Method: "Limit", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return limiter_server_stub{impl: impl.(limiter), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return limiter_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "fafe9dc5aa07e4b8",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/locator",
+		Iface: reflect.TypeOf((*locator)(nil)).Elem(),
+		Impl:  reflect.TypeOf(locatorImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return locator_local_stub{impl: impl.(locator), tracer: tracer, caller: codegen.Caller{Component: caller}, locateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/locator", Method: "Locate", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return locator_client_stub{stub: stub, locateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/locator", Method: "Locate", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return locator_server_stub{impl: impl.(locator), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return locator_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "09bbdc600a3a0b8a",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/mod",
 		Iface: reflect.TypeOf((*mod)(nil)).Elem(),
 		Impl:  reflect.TypeOf(modImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return mod_local_stub{impl: impl.(mod), tracer: tracer, modMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/mod", Method: "Mod", Remote: false, Generated: true})}
+			return mod_local_stub{impl: impl.(mod), tracer: tracer, caller: codegen.Caller{Component: caller}, modMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/mod", Method: "Mod", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return mod_client_stub{stub: stub, modMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/mod", Method: "Mod", Remote: true, Generated: true})}
@@ -103,14 +178,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return mod_reflect_stub{caller: caller}
 		},
-		RefData: "⟦5bf2dcf2:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/mod→github.com/ServiceWeaver/weaver/sim/identity⟧\n",
+		RefData:          "⟦5bf2dcf2:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/mod→github.com/ServiceWeaver/weaver/sim/identity⟧\n⟦2aa14ca2:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/mod.Mod→github.com/ServiceWeaver/weaver/sim/identity.Identity@components.go:232:12⟧\n⟦fdb9ad81:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/mod.Mod→github.com/ServiceWeaver/weaver/sim/identity.Identity@components.go:236:11⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "8bc1a817f855da16",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/sim/panicker",
 		Iface: reflect.TypeOf((*panicker)(nil)).Elem(),
 		Impl:  reflect.TypeOf(panickerImpl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return panicker_local_stub{impl: impl.(panicker), tracer: tracer, panicMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/panicker", Method: "Panic", Remote: false, Generated: true})}
+			return panicker_local_stub{impl: impl.(panicker), tracer: tracer, caller: codegen.Caller{Component: caller}, panicMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/panicker", Method: "Panic", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return panicker_client_stub{stub: stub, panicMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/panicker", Method: "Panic", Remote: true, Generated: true})}
@@ -121,31 +199,133 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return panicker_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "5062eb01209ad491",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/relay",
+		Iface: reflect.TypeOf((*relay)(nil)).Elem(),
+		Impl:  reflect.TypeOf(relayImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return relay_local_stub{impl: impl.(relay), tracer: tracer, caller: codegen.Caller{Component: caller}, callerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/relay", Method: "Caller", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return relay_client_stub{stub: stub, callerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/relay", Method: "Caller", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return relay_server_stub{impl: impl.(relay), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return relay_reflect_stub{caller: caller}
+		},
+		RefData:          "⟦028dc460:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/relay→github.com/ServiceWeaver/weaver/sim/whoami⟧\n⟦2ba4f89b:wEaVeRcAlL:github.com/ServiceWeaver/weaver/sim/relay.Caller→github.com/ServiceWeaver/weaver/sim/whoami.WhoAmI@components.go:260:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e4c9e1f1aaec500b",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/savings",
+		Iface: reflect.TypeOf((*savings)(nil)).Elem(),
+		Impl:  reflect.TypeOf(savingsImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return savings_local_stub{impl: impl.(savings), tracer: tracer, caller: codegen.Caller{Component: caller}, abortMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Abort", Remote: false, Generated: true}), balanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Balance", Remote: false, Generated: true}), commitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Commit", Remote: false, Generated: true}), prepareMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Prepare", Remote: false, Generated: true}), stateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "State", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return savings_client_stub{stub: stub, abortMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Abort", Remote: true, Generated: true}), balanceMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Balance", Remote: true, Generated: true}), commitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Commit", Remote: true, Generated: true}), prepareMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "Prepare", Remote: true, Generated: true}), stateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/savings", Method: "State", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return savings_server_stub{impl: impl.(savings), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return savings_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "0e19c663babd71b6",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/tally",
+		Iface: reflect.TypeOf((*tally)(nil)).Elem(),
+		Impl:  reflect.TypeOf(tallyImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return tally_local_stub{impl: impl.(tally), tracer: tracer, caller: codegen.Caller{Component: caller}, addMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Add", Remote: false, Generated: true}), exchangeMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Exchange", Remote: false, Generated: true}), syncMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Sync", Remote: false, Generated: true}), valueMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Value", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return tally_client_stub{stub: stub, addMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Add", Remote: true, Generated: true}), exchangeMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Exchange", Remote: true, Generated: true}), syncMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Sync", Remote: true, Generated: true}), valueMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/tally", Method: "Value", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return tally_server_stub{impl: impl.(tally), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return tally_reflect_stub{caller: caller}
+		},
+		RefData:          "⟦21f4eef5:wEaVeReDgE:github.com/ServiceWeaver/weaver/sim/tally→github.com/ServiceWeaver/weaver/sim/tally⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "e68b4604692585ae",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/sim/whoami",
+		Iface: reflect.TypeOf((*whoami)(nil)).Elem(),
+		Impl:  reflect.TypeOf(whoamiImpl{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return whoami_local_stub{impl: impl.(whoami), tracer: tracer, caller: codegen.Caller{Component: caller}, whoAmIMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/whoami", Method: "WhoAmI", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return whoami_client_stub{stub: stub, whoAmIMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/sim/whoami", Method: "WhoAmI", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return whoami_server_stub{impl: impl.(whoami), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return whoami_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "960e00d51160486a",
 	})
 }
 
 // weaver.InstanceOf checks.
 var _ weaver.InstanceOf[blocker] = (*blockerImpl)(nil)
+var _ weaver.InstanceOf[checking] = (*checkingImpl)(nil)
 var _ weaver.InstanceOf[div] = (*divImpl)(nil)
 var _ weaver.InstanceOf[divMod] = (*divModImpl)(nil)
 var _ weaver.InstanceOf[identity] = (*identityImpl)(nil)
+var _ weaver.InstanceOf[limiter] = (*limiterImpl)(nil)
+var _ weaver.InstanceOf[locator] = (*locatorImpl)(nil)
 var _ weaver.InstanceOf[mod] = (*modImpl)(nil)
 var _ weaver.InstanceOf[panicker] = (*panickerImpl)(nil)
+var _ weaver.InstanceOf[relay] = (*relayImpl)(nil)
+var _ weaver.InstanceOf[savings] = (*savingsImpl)(nil)
+var _ weaver.InstanceOf[tally] = (*tallyImpl)(nil)
+var _ weaver.InstanceOf[whoami] = (*whoamiImpl)(nil)
 
 // weaver.Router checks.
 var _ weaver.Unrouted = (*blockerImpl)(nil)
+var _ weaver.Unrouted = (*checkingImpl)(nil)
 var _ weaver.Unrouted = (*divImpl)(nil)
 var _ weaver.Unrouted = (*divModImpl)(nil)
 var _ weaver.Unrouted = (*identityImpl)(nil)
+var _ weaver.Unrouted = (*limiterImpl)(nil)
+var _ weaver.Unrouted = (*locatorImpl)(nil)
 var _ weaver.Unrouted = (*modImpl)(nil)
 var _ weaver.Unrouted = (*panickerImpl)(nil)
+var _ weaver.Unrouted = (*relayImpl)(nil)
+var _ weaver.Unrouted = (*savingsImpl)(nil)
+var _ weaver.Unrouted = (*tallyImpl)(nil)
+var _ weaver.Unrouted = (*whoamiImpl)(nil)
 
 // Local stub implementations.
 
 type blocker_local_stub struct {
 	impl         blocker
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	blockMetrics *codegen.MethodMetrics
 }
 
@@ -156,6 +336,10 @@ func (s blocker_local_stub) Block(ctx context.Context) (err error) {
 	// Update metrics.
 	begin := s.blockMetrics.Begin()
 	defer func() { s.blockMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -172,9 +356,144 @@ func (s blocker_local_stub) Block(ctx context.Context) (err error) {
 	return s.impl.Block(ctx)
 }
 
+type checking_local_stub struct {
+	impl           checking
+	tracer         trace.Tracer
+	caller         codegen.Caller
+	abortMetrics   *codegen.MethodMetrics
+	balanceMetrics *codegen.MethodMetrics
+	commitMetrics  *codegen.MethodMetrics
+	prepareMetrics *codegen.MethodMetrics
+	stateMetrics   *codegen.MethodMetrics
+}
+
+// Check that checking_local_stub implements the checking interface.
+var _ checking = (*checking_local_stub)(nil)
+
+func (s checking_local_stub) Abort(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	begin := s.abortMetrics.Begin()
+	defer func() { s.abortMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.checking.Abort", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Abort(ctx, a0)
+}
+
+func (s checking_local_stub) Balance(ctx context.Context) (r0 int, err error) {
+	// Update metrics.
+	begin := s.balanceMetrics.Begin()
+	defer func() { s.balanceMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.checking.Balance", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Balance(ctx)
+}
+
+func (s checking_local_stub) Commit(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	begin := s.commitMetrics.Begin()
+	defer func() { s.commitMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.checking.Commit", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Commit(ctx, a0)
+}
+
+func (s checking_local_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
+	// Update metrics.
+	begin := s.prepareMetrics.Begin()
+	defer func() { s.prepareMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.checking.Prepare", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Prepare(ctx, a0, a1)
+}
+
+func (s checking_local_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	// Update metrics.
+	begin := s.stateMetrics.Begin()
+	defer func() { s.stateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.checking.State", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.State(ctx, a0)
+}
+
 type div_local_stub struct {
 	impl       div
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	divMetrics *codegen.MethodMetrics
 }
 
@@ -185,6 +504,10 @@ func (s div_local_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err er
 	// Update metrics.
 	begin := s.divMetrics.Begin()
 	defer func() { s.divMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -204,6 +527,7 @@ func (s div_local_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err er
 type divMod_local_stub struct {
 	impl          divMod
 	tracer        trace.Tracer
+	caller        codegen.Caller
 	divModMetrics *codegen.MethodMetrics
 }
 
@@ -214,6 +538,10 @@ func (s divMod_local_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int,
 	// Update metrics.
 	begin := s.divModMetrics.Begin()
 	defer func() { s.divModMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -233,6 +561,7 @@ func (s divMod_local_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int,
 type identity_local_stub struct {
 	impl            identity
 	tracer          trace.Tracer
+	caller          codegen.Caller
 	identityMetrics *codegen.MethodMetrics
 }
 
@@ -243,6 +572,10 @@ func (s identity_local_stub) Identity(ctx context.Context, a0 int) (r0 int, err
 	// Update metrics.
 	begin := s.identityMetrics.Begin()
 	defer func() { s.identityMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -259,9 +592,78 @@ func (s identity_local_stub) Identity(ctx context.Context, a0 int) (r0 int, err
 	return s.impl.Identity(ctx, a0)
 }
 
+type limiter_local_stub struct {
+	impl         limiter
+	tracer       trace.Tracer
+	caller       codegen.Caller
+	limitMetrics *codegen.MethodMetrics
+}
+
+// Check that limiter_local_stub implements the limiter interface.
+var _ limiter = (*limiter_local_stub)(nil)
+
+func (s limiter_local_stub) Limit(ctx context.Context) (r0 int, err error) {
+	// Update metrics.
+	begin := s.limitMetrics.Begin()
+	defer func() { s.limitMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.limiter.Limit", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Limit(ctx)
+}
+
+type locator_local_stub struct {
+	impl          locator
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	locateMetrics *codegen.MethodMetrics
+}
+
+// Check that locator_local_stub implements the locator interface.
+var _ locator = (*locator_local_stub)(nil)
+
+func (s locator_local_stub) Locate(ctx context.Context) (r0 string, r1 int, err error) {
+	// Update metrics.
+	begin := s.locateMetrics.Begin()
+	defer func() { s.locateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.locator.Locate", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Locate(ctx)
+}
+
 type mod_local_stub struct {
 	impl       mod
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	modMetrics *codegen.MethodMetrics
 }
 
@@ -272,6 +674,10 @@ func (s mod_local_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err er
 	// Update metrics.
 	begin := s.modMetrics.Begin()
 	defer func() { s.modMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -291,6 +697,7 @@ func (s mod_local_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err er
 type panicker_local_stub struct {
 	impl         panicker
 	tracer       trace.Tracer
+	caller       codegen.Caller
 	panicMetrics *codegen.MethodMetrics
 }
 
@@ -301,6 +708,10 @@ func (s panicker_local_stub) Panic(ctx context.Context, a0 bool) (err error) {
 	// Update metrics.
 	begin := s.panicMetrics.Begin()
 	defer func() { s.panicMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -317,80 +728,337 @@ func (s panicker_local_stub) Panic(ctx context.Context, a0 bool) (err error) {
 	return s.impl.Panic(ctx, a0)
 }
 
-// Client stub implementations.
-
-type blocker_client_stub struct {
-	stub         codegen.Stub
-	blockMetrics *codegen.MethodMetrics
+type relay_local_stub struct {
+	impl          relay
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	callerMetrics *codegen.MethodMetrics
 }
 
-// Check that blocker_client_stub implements the blocker interface.
-var _ blocker = (*blocker_client_stub)(nil)
+// Check that relay_local_stub implements the relay interface.
+var _ relay = (*relay_local_stub)(nil)
 
-func (s blocker_client_stub) Block(ctx context.Context) (err error) {
+func (s relay_local_stub) Caller(ctx context.Context) (r0 string, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
-	begin := s.blockMetrics.Begin()
-	defer func() { s.blockMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.callerMetrics.Begin()
+	defer func() { s.callerMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.blocker.Block", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "sim.relay.Caller", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(weaver.RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.Caller(ctx)
+}
 
-	}()
+type savings_local_stub struct {
+	impl           savings
+	tracer         trace.Tracer
+	caller         codegen.Caller
+	abortMetrics   *codegen.MethodMetrics
+	balanceMetrics *codegen.MethodMetrics
+	commitMetrics  *codegen.MethodMetrics
+	prepareMetrics *codegen.MethodMetrics
+	stateMetrics   *codegen.MethodMetrics
+}
 
-	var shardKey uint64
+// Check that savings_local_stub implements the savings interface.
+var _ savings = (*savings_local_stub)(nil)
 
-	// Call the remote method.
-	var results []byte
-	results, err = s.stub.Run(ctx, 0, nil, shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(weaver.RemoteCallError, err)
-		return
+func (s savings_local_stub) Abort(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	begin := s.abortMetrics.Begin()
+	defer func() { s.abortMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.savings.Abort", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	err = dec.Error()
-	return
+	return s.impl.Abort(ctx, a0)
 }
 
-type div_client_stub struct {
-	stub       codegen.Stub
-	divMetrics *codegen.MethodMetrics
+func (s savings_local_stub) Balance(ctx context.Context) (r0 int, err error) {
+	// Update metrics.
+	begin := s.balanceMetrics.Begin()
+	defer func() { s.balanceMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.savings.Balance", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Balance(ctx)
 }
 
-// Check that div_client_stub implements the div interface.
-var _ div = (*div_client_stub)(nil)
+func (s savings_local_stub) Commit(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	begin := s.commitMetrics.Begin()
+	defer func() { s.commitMetrics.End(begin, err != nil, 0, 0) }()
 
-func (s div_client_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err error) {
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.savings.Commit", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Commit(ctx, a0)
+}
+
+func (s savings_local_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
+	// Update metrics.
+	begin := s.prepareMetrics.Begin()
+	defer func() { s.prepareMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.savings.Prepare", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Prepare(ctx, a0, a1)
+}
+
+func (s savings_local_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	// Update metrics.
+	begin := s.stateMetrics.Begin()
+	defer func() { s.stateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.savings.State", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.State(ctx, a0)
+}
+
+type tally_local_stub struct {
+	impl            tally
+	tracer          trace.Tracer
+	caller          codegen.Caller
+	addMetrics      *codegen.MethodMetrics
+	exchangeMetrics *codegen.MethodMetrics
+	syncMetrics     *codegen.MethodMetrics
+	valueMetrics    *codegen.MethodMetrics
+}
+
+// Check that tally_local_stub implements the tally interface.
+var _ tally = (*tally_local_stub)(nil)
+
+func (s tally_local_stub) Add(ctx context.Context, a0 int) (err error) {
+	// Update metrics.
+	begin := s.addMetrics.Begin()
+	defer func() { s.addMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.tally.Add", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Add(ctx, a0)
+}
+
+func (s tally_local_stub) Exchange(ctx context.Context, a0 []byte) (r0 []byte, err error) {
+	// Update metrics.
+	begin := s.exchangeMetrics.Begin()
+	defer func() { s.exchangeMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.tally.Exchange", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Exchange(ctx, a0)
+}
+
+func (s tally_local_stub) Sync(ctx context.Context) (err error) {
+	// Update metrics.
+	begin := s.syncMetrics.Begin()
+	defer func() { s.syncMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.tally.Sync", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Sync(ctx)
+}
+
+func (s tally_local_stub) Value(ctx context.Context) (r0 int64, err error) {
+	// Update metrics.
+	begin := s.valueMetrics.Begin()
+	defer func() { s.valueMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.tally.Value", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Value(ctx)
+}
+
+type whoami_local_stub struct {
+	impl          whoami
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	whoAmIMetrics *codegen.MethodMetrics
+}
+
+// Check that whoami_local_stub implements the whoami interface.
+var _ whoami = (*whoami_local_stub)(nil)
+
+func (s whoami_local_stub) WhoAmI(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	begin := s.whoAmIMetrics.Begin()
+	defer func() { s.whoAmIMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "sim.whoami.WhoAmI", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.WhoAmI(ctx)
+}
+
+// Client stub implementations.
+
+type blocker_client_stub struct {
+	stub         codegen.Stub
+	blockMetrics *codegen.MethodMetrics
+}
+
+// Check that blocker_client_stub implements the blocker interface.
+var _ blocker = (*blocker_client_stub)(nil)
+
+func (s blocker_client_stub) Block(ctx context.Context) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.divMetrics.Begin()
-	defer func() { s.divMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.blockMetrics.Begin()
+	defer func() { s.blockMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.div.Div", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.blocker.Block", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -410,22 +1078,11 @@ func (s div_client_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err e
 
 	}()
 
-	// Preallocate a buffer of the right size.
-	size := 0
-	size += 8
-	size += 8
-	enc := codegen.NewEncoder()
-	enc.Reset(size)
-
-	// Encode arguments.
-	enc.Int(a0)
-	enc.Int(a1)
 	var shardKey uint64
 
 	// Call the remote method.
-	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -434,29 +1091,32 @@ func (s div_client_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err e
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = dec.Int()
 	err = dec.Error()
 	return
 }
 
-type divMod_client_stub struct {
-	stub          codegen.Stub
-	divModMetrics *codegen.MethodMetrics
+type checking_client_stub struct {
+	stub           codegen.Stub
+	abortMetrics   *codegen.MethodMetrics
+	balanceMetrics *codegen.MethodMetrics
+	commitMetrics  *codegen.MethodMetrics
+	prepareMetrics *codegen.MethodMetrics
+	stateMetrics   *codegen.MethodMetrics
 }
 
-// Check that divMod_client_stub implements the divMod interface.
-var _ divMod = (*divMod_client_stub)(nil)
+// Check that checking_client_stub implements the checking interface.
+var _ checking = (*checking_client_stub)(nil)
 
-func (s divMod_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int, r1 int, err error) {
+func (s checking_client_stub) Abort(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.divModMetrics.Begin()
-	defer func() { s.divModMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.abortMetrics.Begin()
+	defer func() { s.abortMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.divMod.DivMod", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.checking.Abort", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -478,20 +1138,19 @@ func (s divMod_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int,
 
 	// Preallocate a buffer of the right size.
 	size := 0
-	size += 8
-	size += 8
-	enc := codegen.NewEncoder()
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
-	enc.Int(a0)
-	enc.Int(a1)
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -500,30 +1159,20 @@ func (s divMod_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int,
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = dec.Int()
-	r1 = dec.Int()
 	err = dec.Error()
 	return
 }
 
-type identity_client_stub struct {
-	stub            codegen.Stub
-	identityMetrics *codegen.MethodMetrics
-}
-
-// Check that identity_client_stub implements the identity interface.
-var _ identity = (*identity_client_stub)(nil)
-
-func (s identity_client_stub) Identity(ctx context.Context, a0 int) (r0 int, err error) {
+func (s checking_client_stub) Balance(ctx context.Context) (r0 int, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.identityMetrics.Begin()
-	defer func() { s.identityMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.balanceMetrics.Begin()
+	defer func() { s.balanceMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.identity.Identity", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.checking.Balance", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -543,20 +1192,11 @@ func (s identity_client_stub) Identity(ctx context.Context, a0 int) (r0 int, err
 
 	}()
 
-	// Preallocate a buffer of the right size.
-	size := 0
-	size += 8
-	enc := codegen.NewEncoder()
-	enc.Reset(size)
-
-	// Encode arguments.
-	enc.Int(a0)
 	var shardKey uint64
 
 	// Call the remote method.
-	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 1, nil, shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -570,24 +1210,196 @@ func (s identity_client_stub) Identity(ctx context.Context, a0 int) (r0 int, err
 	return
 }
 
-type mod_client_stub struct {
-	stub       codegen.Stub
-	modMetrics *codegen.MethodMetrics
-}
+func (s checking_client_stub) Commit(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.commitMetrics.Begin()
+	defer func() { s.commitMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
-// Check that mod_client_stub implements the mod interface.
-var _ mod = (*mod_client_stub)(nil)
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.checking.Commit", trace.WithSpanKind(trace.SpanKindClient))
+	}
 
-func (s mod_client_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err error) {
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s checking_client_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.modMetrics.Begin()
-	defer func() { s.modMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.prepareMetrics.Begin()
+	defer func() { s.prepareMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.mod.Mod", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.checking.Prepare", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s checking_client_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.stateMetrics.Begin()
+	defer func() { s.stateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.checking.State", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	*(*int)(&r0) = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type div_client_stub struct {
+	stub       codegen.Stub
+	divMetrics *codegen.MethodMetrics
+}
+
+// Check that div_client_stub implements the div interface.
+var _ div = (*div_client_stub)(nil)
+
+func (s div_client_stub) Div(ctx context.Context, a0 int, a1 int) (r0 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.divMetrics.Begin()
+	defer func() { s.divMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.div.Div", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -611,7 +1423,7 @@ func (s mod_client_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err e
 	size := 0
 	size += 8
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -623,6 +1435,7 @@ func (s mod_client_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err e
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -636,24 +1449,24 @@ func (s mod_client_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err e
 	return
 }
 
-type panicker_client_stub struct {
-	stub         codegen.Stub
-	panicMetrics *codegen.MethodMetrics
+type divMod_client_stub struct {
+	stub          codegen.Stub
+	divModMetrics *codegen.MethodMetrics
 }
 
-// Check that panicker_client_stub implements the panicker interface.
-var _ panicker = (*panicker_client_stub)(nil)
+// Check that divMod_client_stub implements the divMod interface.
+var _ divMod = (*divMod_client_stub)(nil)
 
-func (s panicker_client_stub) Panic(ctx context.Context, a0 bool) (err error) {
+func (s divMod_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int, r1 int, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.panicMetrics.Begin()
-	defer func() { s.panicMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.divModMetrics.Begin()
+	defer func() { s.divModMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "sim.panicker.Panic", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.divMod.DivMod", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -675,18 +1488,21 @@ func (s panicker_client_stub) Panic(ctx context.Context, a0 bool) (err error) {
 
 	// Preallocate a buffer of the right size.
 	size := 0
-	size += 1
-	enc := codegen.NewEncoder()
+	size += 8
+	size += 8
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
-	enc.Bool(a0)
+	enc.Int(a0)
+	enc.Int(a1)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -695,54 +1511,1505 @@ func (s panicker_client_stub) Panic(ctx context.Context, a0 bool) (err error) {
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	r1 = dec.Int()
 	err = dec.Error()
 	return
 }
 
-// Note that "weaver generate" will always generate the error message below.
-// Everything is okay. The error message is only relevant if you see it when
-// you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+type identity_client_stub struct {
+	stub            codegen.Stub
+	identityMetrics *codegen.MethodMetrics
+}
 
-ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
-github.com/ServiceWeaver/weaver module that you're using. The weaver module
-version can be found in your go.mod file or by running the following command.
+// Check that identity_client_stub implements the identity interface.
+var _ identity = (*identity_client_stub)(nil)
 
-    go list -m github.com/ServiceWeaver/weaver
+func (s identity_client_stub) Identity(ctx context.Context, a0 int) (r0 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.identityMetrics.Begin()
+	defer func() { s.identityMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
-We recommend updating the weaver module and the 'weaver generate' command by
-running the following.
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.identity.Identity", trace.WithSpanKind(trace.SpanKindClient))
+	}
 
-    go get github.com/ServiceWeaver/weaver@latest
-    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
 
-Then, re-run 'weaver generate' and re-build your code. If the problem persists,
-please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
 
-`)
+	}()
 
-// Server stub implementations.
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
 
-type blocker_server_stub struct {
-	impl    blocker
-	addLoad func(key uint64, load float64)
-}
+	// Encode arguments.
+	enc.Int(a0)
+	var shardKey uint64
 
-// Check that blocker_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*blocker_server_stub)(nil)
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
 
-// GetStubFn implements the codegen.Server interface.
-func (s blocker_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
-	switch method {
-	case "Block":
-		return s.block
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type limiter_client_stub struct {
+	stub         codegen.Stub
+	limitMetrics *codegen.MethodMetrics
+}
+
+// Check that limiter_client_stub implements the limiter interface.
+var _ limiter = (*limiter_client_stub)(nil)
+
+func (s limiter_client_stub) Limit(ctx context.Context) (r0 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.limitMetrics.Begin()
+	defer func() { s.limitMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.limiter.Limit", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type locator_client_stub struct {
+	stub          codegen.Stub
+	locateMetrics *codegen.MethodMetrics
+}
+
+// Check that locator_client_stub implements the locator interface.
+var _ locator = (*locator_client_stub)(nil)
+
+func (s locator_client_stub) Locate(ctx context.Context) (r0 string, r1 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.locateMetrics.Begin()
+	defer func() { s.locateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.locator.Locate", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	r1 = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type mod_client_stub struct {
+	stub       codegen.Stub
+	modMetrics *codegen.MethodMetrics
+}
+
+// Check that mod_client_stub implements the mod interface.
+var _ mod = (*mod_client_stub)(nil)
+
+func (s mod_client_stub) Mod(ctx context.Context, a0 int, a1 int) (r0 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.modMetrics.Begin()
+	defer func() { s.modMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.mod.Mod", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Int(a0)
+	enc.Int(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type panicker_client_stub struct {
+	stub         codegen.Stub
+	panicMetrics *codegen.MethodMetrics
+}
+
+// Check that panicker_client_stub implements the panicker interface.
+var _ panicker = (*panicker_client_stub)(nil)
+
+func (s panicker_client_stub) Panic(ctx context.Context, a0 bool) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.panicMetrics.Begin()
+	defer func() { s.panicMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.panicker.Panic", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 1
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Bool(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+type relay_client_stub struct {
+	stub          codegen.Stub
+	callerMetrics *codegen.MethodMetrics
+}
+
+// Check that relay_client_stub implements the relay interface.
+var _ relay = (*relay_client_stub)(nil)
+
+func (s relay_client_stub) Caller(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.callerMetrics.Begin()
+	defer func() { s.callerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.relay.Caller", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+type savings_client_stub struct {
+	stub           codegen.Stub
+	abortMetrics   *codegen.MethodMetrics
+	balanceMetrics *codegen.MethodMetrics
+	commitMetrics  *codegen.MethodMetrics
+	prepareMetrics *codegen.MethodMetrics
+	stateMetrics   *codegen.MethodMetrics
+}
+
+// Check that savings_client_stub implements the savings interface.
+var _ savings = (*savings_client_stub)(nil)
+
+func (s savings_client_stub) Abort(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.abortMetrics.Begin()
+	defer func() { s.abortMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.savings.Abort", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s savings_client_stub) Balance(ctx context.Context) (r0 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.balanceMetrics.Begin()
+	defer func() { s.balanceMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.savings.Balance", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	err = dec.Error()
+	return
+}
+
+func (s savings_client_stub) Commit(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.commitMetrics.Begin()
+	defer func() { s.commitMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.savings.Commit", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s savings_client_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.prepareMetrics.Begin()
+	defer func() { s.prepareMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.savings.Prepare", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s savings_client_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.stateMetrics.Begin()
+	defer func() { s.stateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.savings.State", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	*(*int)(&r0) = dec.Int()
+	err = dec.Error()
+	return
+}
+
+type tally_client_stub struct {
+	stub            codegen.Stub
+	addMetrics      *codegen.MethodMetrics
+	exchangeMetrics *codegen.MethodMetrics
+	syncMetrics     *codegen.MethodMetrics
+	valueMetrics    *codegen.MethodMetrics
+}
+
+// Check that tally_client_stub implements the tally interface.
+var _ tally = (*tally_client_stub)(nil)
+
+func (s tally_client_stub) Add(ctx context.Context, a0 int) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.addMetrics.Begin()
+	defer func() { s.addMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.tally.Add", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.Int(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s tally_client_stub) Exchange(ctx context.Context, a0 []byte) (r0 []byte, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.exchangeMetrics.Begin()
+	defer func() { s.exchangeMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.tally.Exchange", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + (len(a0) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	serviceweaver_enc_slice_byte_87461245(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
+	err = dec.Error()
+	return
+}
+
+func (s tally_client_stub) Sync(ctx context.Context) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.syncMetrics.Begin()
+	defer func() { s.syncMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.tally.Sync", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s tally_client_stub) Value(ctx context.Context) (r0 int64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.valueMetrics.Begin()
+	defer func() { s.valueMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.tally.Value", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int64()
+	err = dec.Error()
+	return
+}
+
+type whoami_client_stub struct {
+	stub          codegen.Stub
+	whoAmIMetrics *codegen.MethodMetrics
+}
+
+// Check that whoami_client_stub implements the whoami interface.
+var _ whoami = (*whoami_client_stub)(nil)
+
+func (s whoami_client_stub) WhoAmI(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.whoAmIMetrics.Begin()
+	defer func() { s.whoAmIMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "sim.whoami.WhoAmI", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+// Note that "weaver generate" will always generate the error message below.
+// Everything is okay. The error message is only relevant if you see it when
+// you run "go build" or "go run".
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
+
+ERROR: You generated this file with 'weaver generate' (devel) (codegen
+version v0.27.0). The generated code is incompatible with the version of the
+github.com/ServiceWeaver/weaver module that you're using. The weaver module
+version can be found in your go.mod file or by running the following command.
+
+    go list -m github.com/ServiceWeaver/weaver
+
+We recommend updating the weaver module and the 'weaver generate' command by
+running the following.
+
+    go get github.com/ServiceWeaver/weaver@latest
+    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+
+Then, re-run 'weaver generate' and re-build your code. If the problem persists,
+please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+
+`)
+
+// Server stub implementations.
+
+type blocker_server_stub struct {
+	impl    blocker
+	addLoad func(key uint64, load float64)
+}
+
+// Check that blocker_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*blocker_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s blocker_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Block":
+		return s.block
+	default:
+		return nil
+	}
+}
+
+func (s blocker_server_stub) block(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Block(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type checking_server_stub struct {
+	impl    checking
+	addLoad func(key uint64, load float64)
+}
+
+// Check that checking_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*checking_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s checking_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Abort":
+		return s.abort
+	case "Balance":
+		return s.balance
+	case "Commit":
+		return s.commit
+	case "Prepare":
+		return s.prepare
+	case "State":
+		return s.state
+	default:
+		return nil
+	}
+}
+
+func (s checking_server_stub) abort(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Abort(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s checking_server_stub) balance(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Balance(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s checking_server_stub) commit(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Commit(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s checking_server_stub) prepare(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Prepare(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s checking_server_stub) state(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.State(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int((int)(r0))
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type div_server_stub struct {
+	impl    div
+	addLoad func(key uint64, load float64)
+}
+
+// Check that div_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*div_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s div_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Div":
+		return s.div
+	default:
+		return nil
+	}
+}
+
+func (s div_server_stub) div(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 int
+	a0 = dec.Int()
+	var a1 int
+	a1 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Div(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type divMod_server_stub struct {
+	impl    divMod
+	addLoad func(key uint64, load float64)
+}
+
+// Check that divMod_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*divMod_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s divMod_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "DivMod":
+		return s.divMod
+	default:
+		return nil
+	}
+}
+
+func (s divMod_server_stub) divMod(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 int
+	a0 = dec.Int()
+	var a1 int
+	a1 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.DivMod(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Int(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type identity_server_stub struct {
+	impl    identity
+	addLoad func(key uint64, load float64)
+}
+
+// Check that identity_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*identity_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s identity_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Identity":
+		return s.identity
+	default:
+		return nil
+	}
+}
+
+func (s identity_server_stub) identity(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 int
+	a0 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Identity(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type limiter_server_stub struct {
+	impl    limiter
+	addLoad func(key uint64, load float64)
+}
+
+// Check that limiter_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*limiter_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s limiter_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Limit":
+		return s.limit
+	default:
+		return nil
+	}
+}
+
+func (s limiter_server_stub) limit(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Limit(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type locator_server_stub struct {
+	impl    locator
+	addLoad func(key uint64, load float64)
+}
+
+// Check that locator_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*locator_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s locator_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Locate":
+		return s.locate
+	default:
+		return nil
+	}
+}
+
+func (s locator_server_stub) locate(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Locate(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Int(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type mod_server_stub struct {
+	impl    mod
+	addLoad func(key uint64, load float64)
+}
+
+// Check that mod_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*mod_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s mod_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Mod":
+		return s.mod
+	default:
+		return nil
+	}
+}
+
+func (s mod_server_stub) mod(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 int
+	a0 = dec.Int()
+	var a1 int
+	a1 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Mod(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type panicker_server_stub struct {
+	impl    panicker
+	addLoad func(key uint64, load float64)
+}
+
+// Check that panicker_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*panicker_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s panicker_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Panic":
+		return s.panic
+	default:
+		return nil
+	}
+}
+
+func (s panicker_server_stub) panic(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 bool
+	a0 = dec.Bool()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Panic(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type relay_server_stub struct {
+	impl    relay
+	addLoad func(key uint64, load float64)
+}
+
+// Check that relay_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*relay_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s relay_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Caller":
+		return s.caller
+	default:
+		return nil
+	}
+}
+
+func (s relay_server_stub) caller(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Caller(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type savings_server_stub struct {
+	impl    savings
+	addLoad func(key uint64, load float64)
+}
+
+// Check that savings_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*savings_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s savings_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Abort":
+		return s.abort
+	case "Balance":
+		return s.balance
+	case "Commit":
+		return s.commit
+	case "Prepare":
+		return s.prepare
+	case "State":
+		return s.state
 	default:
 		return nil
 	}
 }
 
-func (s blocker_server_stub) block(ctx context.Context, args []byte) (res []byte, err error) {
+func (s savings_server_stub) abort(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -750,10 +3017,15 @@ func (s blocker_server_stub) block(ctx context.Context, args []byte) (res []byte
 		}
 	}()
 
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	appErr := s.impl.Block(ctx)
+	appErr := s.impl.Abort(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
@@ -761,25 +3033,27 @@ func (s blocker_server_stub) block(ctx context.Context, args []byte) (res []byte
 	return enc.Data(), nil
 }
 
-type div_server_stub struct {
-	impl    div
-	addLoad func(key uint64, load float64)
-}
+func (s savings_server_stub) balance(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-// Check that div_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*div_server_stub)(nil)
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Balance(ctx)
 
-// GetStubFn implements the codegen.Server interface.
-func (s div_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
-	switch method {
-	case "Div":
-		return s.div
-	default:
-		return nil
-	}
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
 }
 
-func (s div_server_stub) div(ctx context.Context, args []byte) (res []byte, err error) {
+func (s savings_server_stub) commit(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -789,42 +3063,47 @@ func (s div_server_stub) div(ctx context.Context, args []byte) (res []byte, err
 
 	// Decode arguments.
 	dec := codegen.NewDecoder(args)
-	var a0 int
-	a0 = dec.Int()
-	var a1 int
-	a1 = dec.Int()
+	var a0 string
+	a0 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.Div(ctx, a0, a1)
+	appErr := s.impl.Commit(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Int(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-type divMod_server_stub struct {
-	impl    divMod
-	addLoad func(key uint64, load float64)
-}
+func (s savings_server_stub) prepare(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-// Check that divMod_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*divMod_server_stub)(nil)
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
 
-// GetStubFn implements the codegen.Server interface.
-func (s divMod_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
-	switch method {
-	case "DivMod":
-		return s.divMod
-	default:
-		return nil
-	}
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Prepare(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
 }
 
-func (s divMod_server_stub) divMod(ctx context.Context, args []byte) (res []byte, err error) {
+func (s savings_server_stub) state(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -834,43 +3113,46 @@ func (s divMod_server_stub) divMod(ctx context.Context, args []byte) (res []byte
 
 	// Decode arguments.
 	dec := codegen.NewDecoder(args)
-	var a0 int
-	a0 = dec.Int()
-	var a1 int
-	a1 = dec.Int()
+	var a0 string
+	a0 = dec.String()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, r1, appErr := s.impl.DivMod(ctx, a0, a1)
+	r0, appErr := s.impl.State(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Int(r0)
-	enc.Int(r1)
+	enc.Int((int)(r0))
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-type identity_server_stub struct {
-	impl    identity
+type tally_server_stub struct {
+	impl    tally
 	addLoad func(key uint64, load float64)
 }
 
-// Check that identity_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*identity_server_stub)(nil)
+// Check that tally_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*tally_server_stub)(nil)
 
 // GetStubFn implements the codegen.Server interface.
-func (s identity_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+func (s tally_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
-	case "Identity":
-		return s.identity
+	case "Add":
+		return s.add
+	case "Exchange":
+		return s.exchange
+	case "Sync":
+		return s.sync
+	case "Value":
+		return s.value
 	default:
 		return nil
 	}
 }
 
-func (s identity_server_stub) identity(ctx context.Context, args []byte) (res []byte, err error) {
+func (s tally_server_stub) add(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -886,34 +3168,40 @@ func (s identity_server_stub) identity(ctx context.Context, args []byte) (res []
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.Identity(ctx, a0)
+	appErr := s.impl.Add(ctx, a0)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Int(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-type mod_server_stub struct {
-	impl    mod
-	addLoad func(key uint64, load float64)
-}
+func (s tally_server_stub) exchange(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-// Check that mod_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*mod_server_stub)(nil)
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 []byte
+	a0 = serviceweaver_dec_slice_byte_87461245(dec)
 
-// GetStubFn implements the codegen.Server interface.
-func (s mod_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
-	switch method {
-	case "Mod":
-		return s.mod
-	default:
-		return nil
-	}
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Exchange(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
 }
 
-func (s mod_server_stub) mod(ctx context.Context, args []byte) (res []byte, err error) {
+func (s tally_server_stub) sync(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -921,44 +3209,56 @@ func (s mod_server_stub) mod(ctx context.Context, args []byte) (res []byte, err
 		}
 	}()
 
-	// Decode arguments.
-	dec := codegen.NewDecoder(args)
-	var a0 int
-	a0 = dec.Int()
-	var a1 int
-	a1 = dec.Int()
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Sync(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s tally_server_stub) value(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	r0, appErr := s.impl.Mod(ctx, a0, a1)
+	r0, appErr := s.impl.Value(ctx)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
-	enc.Int(r0)
+	enc.Int64(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
 
-type panicker_server_stub struct {
-	impl    panicker
+type whoami_server_stub struct {
+	impl    whoami
 	addLoad func(key uint64, load float64)
 }
 
-// Check that panicker_server_stub implements the codegen.Server interface.
-var _ codegen.Server = (*panicker_server_stub)(nil)
+// Check that whoami_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*whoami_server_stub)(nil)
 
 // GetStubFn implements the codegen.Server interface.
-func (s panicker_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+func (s whoami_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
-	case "Panic":
-		return s.panic
+	case "WhoAmI":
+		return s.whoAmI
 	default:
 		return nil
 	}
 }
 
-func (s panicker_server_stub) panic(ctx context.Context, args []byte) (res []byte, err error) {
+func (s whoami_server_stub) whoAmI(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
 		if err == nil {
@@ -966,18 +3266,14 @@ func (s panicker_server_stub) panic(ctx context.Context, args []byte) (res []byt
 		}
 	}()
 
-	// Decode arguments.
-	dec := codegen.NewDecoder(args)
-	var a0 bool
-	a0 = dec.Bool()
-
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
 	// Call the local method.
-	appErr := s.impl.Panic(ctx, a0)
+	r0, appErr := s.impl.WhoAmI(ctx)
 
 	// Encode the results.
 	enc := codegen.NewEncoder()
+	enc.String(r0)
 	enc.Error(appErr)
 	return enc.Data(), nil
 }
@@ -996,6 +3292,38 @@ func (s blocker_reflect_stub) Block(ctx context.Context) (err error) {
 	return
 }
 
+type checking_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that checking_reflect_stub implements the checking interface.
+var _ checking = (*checking_reflect_stub)(nil)
+
+func (s checking_reflect_stub) Abort(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Abort", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s checking_reflect_stub) Balance(ctx context.Context) (r0 int, err error) {
+	err = s.caller("Balance", ctx, []any{}, []any{&r0})
+	return
+}
+
+func (s checking_reflect_stub) Commit(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Commit", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s checking_reflect_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
+	err = s.caller("Prepare", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+func (s checking_reflect_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	err = s.caller("State", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 type div_reflect_stub struct {
 	caller func(string, context.Context, []any, []any) error
 }
@@ -1032,6 +3360,30 @@ func (s identity_reflect_stub) Identity(ctx context.Context, a0 int) (r0 int, er
 	return
 }
 
+type limiter_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that limiter_reflect_stub implements the limiter interface.
+var _ limiter = (*limiter_reflect_stub)(nil)
+
+func (s limiter_reflect_stub) Limit(ctx context.Context) (r0 int, err error) {
+	err = s.caller("Limit", ctx, []any{}, []any{&r0})
+	return
+}
+
+type locator_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that locator_reflect_stub implements the locator interface.
+var _ locator = (*locator_reflect_stub)(nil)
+
+func (s locator_reflect_stub) Locate(ctx context.Context) (r0 string, r1 int, err error) {
+	err = s.caller("Locate", ctx, []any{}, []any{&r0, &r1})
+	return
+}
+
 type mod_reflect_stub struct {
 	caller func(string, context.Context, []any, []any) error
 }
@@ -1056,6 +3408,89 @@ func (s panicker_reflect_stub) Panic(ctx context.Context, a0 bool) (err error) {
 	return
 }
 
+type relay_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that relay_reflect_stub implements the relay interface.
+var _ relay = (*relay_reflect_stub)(nil)
+
+func (s relay_reflect_stub) Caller(ctx context.Context) (r0 string, err error) {
+	err = s.caller("Caller", ctx, []any{}, []any{&r0})
+	return
+}
+
+type savings_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that savings_reflect_stub implements the savings interface.
+var _ savings = (*savings_reflect_stub)(nil)
+
+func (s savings_reflect_stub) Abort(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Abort", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s savings_reflect_stub) Balance(ctx context.Context) (r0 int, err error) {
+	err = s.caller("Balance", ctx, []any{}, []any{&r0})
+	return
+}
+
+func (s savings_reflect_stub) Commit(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Commit", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s savings_reflect_stub) Prepare(ctx context.Context, a0 string, a1 []byte) (err error) {
+	err = s.caller("Prepare", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+func (s savings_reflect_stub) State(ctx context.Context, a0 string) (r0 weaver.TxState, err error) {
+	err = s.caller("State", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+type tally_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that tally_reflect_stub implements the tally interface.
+var _ tally = (*tally_reflect_stub)(nil)
+
+func (s tally_reflect_stub) Add(ctx context.Context, a0 int) (err error) {
+	err = s.caller("Add", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s tally_reflect_stub) Exchange(ctx context.Context, a0 []byte) (r0 []byte, err error) {
+	err = s.caller("Exchange", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s tally_reflect_stub) Sync(ctx context.Context) (err error) {
+	err = s.caller("Sync", ctx, []any{}, []any{})
+	return
+}
+
+func (s tally_reflect_stub) Value(ctx context.Context) (r0 int64, err error) {
+	err = s.caller("Value", ctx, []any{}, []any{&r0})
+	return
+}
+
+type whoami_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that whoami_reflect_stub implements the whoami interface.
+var _ whoami = (*whoami_reflect_stub)(nil)
+
+func (s whoami_reflect_stub) WhoAmI(ctx context.Context) (r0 string, err error) {
+	err = s.caller("WhoAmI", ctx, []any{}, []any{&r0})
+	return
+}
+
 // AutoMarshal implementations.
 
 var _ codegen.AutoMarshal = (*zeroError)(nil)
@@ -1076,3 +3511,39 @@ func (x *zeroError) WeaverUnmarshal(dec *codegen.Decoder) {
 	}
 }
 func init() { codegen.RegisterSerializable[*zeroError]() }
+
+// Encoding/decoding implementations.
+
+func serviceweaver_enc_slice_byte_87461245(enc *codegen.Encoder, arg []byte) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	if codegen.EncodeFixed(enc, arg) {
+		return
+	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Byte(arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_byte_87461245(dec *codegen.Decoder) []byte {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 1)
+	res := make([]byte, n)
+	if codegen.DecodeFixed[byte](dec, res) {
+		return res
+	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Byte()
+		}
+	})
+	return res
+}
@@ -0,0 +1,167 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ServiceWeaver/weaver/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"golang.org/x/sync/singleflight"
+)
+
+type singleflightLabels struct {
+	Name string // name of the Singleflight
+
+	// Is this a metric implicitly created by the framework?
+	Generated bool `weaver:"serviceweaver_generated"`
+}
+
+var (
+	singleflightCalls = metrics.NewCounterMap[singleflightLabels](
+		"serviceweaver_singleflight_calls",
+		"Number of calls made through a Singleflight",
+	)
+	singleflightDeduplicated = metrics.NewCounterMap[singleflightLabels](
+		"serviceweaver_singleflight_deduplicated_calls",
+		"Number of Singleflight calls that shared the result of a concurrent identical call",
+	)
+)
+
+// Singleflight coalesces concurrent identical calls into one. Two calls are
+// identical if they are made with the same method name and arguments. When a
+// call is made while an identical call is in progress, it waits for and
+// returns the result of the call in progress, rather than calling the
+// upstream itself. This protects a component's dependencies, like a database
+// or another component, from thundering herds of identical requests, e.g.,
+// when a popular cache entry expires:
+//
+//	type cache struct {
+//	    weaver.Implements[Cache]
+//	    users   weaver.Ref[Users]
+//	    flights *weaver.Singleflight[User]
+//	}
+//
+//	func (c *cache) Init(context.Context) error {
+//	    c.flights = weaver.NewSingleflight[User]("users")
+//	    return nil
+//	}
+//
+//	func (c *cache) Get(ctx context.Context, id string) (User, error) {
+//	    return c.flights.Do(ctx, "Get", []any{id}, func(ctx context.Context) (User, error) {
+//	        return c.users.Get().Get(ctx, id)
+//	    })
+//	}
+//
+// Calls are only coalesced within a Singleflight, and so within a weavelet.
+// The following metrics, labelled with the Singleflight's name, are exported:
+//
+//   - serviceweaver_singleflight_calls: Number of calls.
+//   - serviceweaver_singleflight_deduplicated_calls: Number of calls that
+//     shared the result of a concurrent identical call, i.e., the number of
+//     upstream calls saved.
+type Singleflight[T any] struct {
+	name         string
+	group        singleflight.Group
+	calls        atomic.Int64
+	deduplicated atomic.Int64
+
+	callsCounter        *metrics.Counter
+	deduplicatedCounter *metrics.Counter
+}
+
+// SingleflightStats are statistics about the calls made through a
+// Singleflight.
+type SingleflightStats struct {
+	Calls        int64 // number of calls
+	Deduplicated int64 // number of calls that shared another call's result
+}
+
+// NewSingleflight returns a new Singleflight with the provided name, which is
+// used to label its metrics.
+func NewSingleflight[T any](name string) *Singleflight[T] {
+	labels := singleflightLabels{Name: name, Generated: true}
+	return &Singleflight[T]{
+		name:                name,
+		callsCounter:        singleflightCalls.Get(labels),
+		deduplicatedCounter: singleflightDeduplicated.Get(labels),
+	}
+}
+
+// Do calls fn and returns its result, unless a call with the same method and
+// args is already in progress, in which case Do waits for that call and
+// returns its result instead. Calls are identical if their args are equal,
+// as determined by codegen.EncodeValue, which compares all the fields of
+// structs, including unexported ones, and the dynamic types of values. args
+// must not contain channels, functions, or cycles.
+//
+// fn is passed a context that carries the values of ctx, like its trace, but
+// that is not cancelled when ctx is, so that a caller that gives up doesn't
+// fail the callers sharing its call. If ctx is done before the call finishes,
+// Do returns ctx's error, and the call keeps running for the other callers.
+func (s *Singleflight[T]) Do(ctx context.Context, method string, args []any, fn func(context.Context) (T, error)) (T, error) {
+	var zero T
+	key, err := singleflightKey(method, args)
+	if err != nil {
+		return zero, fmt.Errorf("weaver.Singleflight %q: %w", s.name, err)
+	}
+
+	detached := context.WithoutCancel(ctx)
+	leader := false
+	ch := s.group.DoChan(key, func() (any, error) {
+		leader = true
+		return fn(detached)
+	})
+	s.calls.Add(1)
+	s.callsCounter.Inc()
+
+	select {
+	case r := <-ch:
+		if r.Shared && !leader {
+			s.deduplicated.Add(1)
+			s.deduplicatedCounter.Inc()
+		}
+		if r.Err != nil {
+			return zero, r.Err
+		}
+		return r.Val.(T), nil
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// Stats returns statistics about the calls made through s so far.
+func (s *Singleflight[T]) Stats() SingleflightStats {
+	return SingleflightStats{
+		Calls:        s.calls.Load(),
+		Deduplicated: s.deduplicated.Load(),
+	}
+}
+
+// singleflightKey returns a canonical hash of the provided method name and
+// arguments.
+func singleflightKey(method string, args []any) (string, error) {
+	enc := codegen.NewEncoder()
+	enc.String(method)
+	enc.Len(len(args))
+	for _, arg := range args {
+		if err := codegen.EncodeValue(enc, arg); err != nil {
+			return "", fmt.Errorf("hash arguments of %s: %w", method, err)
+		}
+	}
+	return codegen.CanonicalHash(enc.Data()), nil
+}
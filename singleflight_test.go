@@ -0,0 +1,200 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// waitForCalls waits until n calls have been made through s.
+func waitForCalls[T any](s *weaver.Singleflight[T], n int64) {
+	for s.Stats().Calls < n {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSingleflightCoalesces(t *testing.T) {
+	ctx := context.Background()
+	s := weaver.NewSingleflight[int]("test")
+
+	const n = 10
+	var upstream atomic.Int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	results := make([]int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			v, err := s.Do(ctx, "Get", []any{"key", 1}, func(context.Context) (int, error) {
+				upstream.Add(1)
+				close(started)
+				<-release
+				return 42, nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			results[i] = v
+		}(i)
+	}
+
+	// Wait for the upstream call to start and for the other calls to join it.
+	<-started
+	waitForCalls(s, n)
+	close(release)
+	wg.Wait()
+
+	if got := upstream.Load(); got != 1 {
+		t.Errorf("upstream calls: got %d, want 1", got)
+	}
+	for i, v := range results {
+		if v != 42 {
+			t.Errorf("result %d: got %d, want 42", i, v)
+		}
+	}
+	if got, want := s.Stats(), (weaver.SingleflightStats{Calls: n, Deduplicated: n - 1}); got != want {
+		t.Errorf("Stats: got %+v, want %+v", got, want)
+	}
+}
+
+func TestSingleflightDistinctArgs(t *testing.T) {
+	ctx := context.Background()
+	s := weaver.NewSingleflight[string]("test")
+	for _, args := range [][]any{{"a"}, {"b"}, {map[string]int{"x": 1, "y": 2}}} {
+		args := args
+		got, err := s.Do(ctx, "Echo", args, func(context.Context) (string, error) {
+			return "called", nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != "called" {
+			t.Fatalf("Do(%v): got %q, want %q", args, got, "called")
+		}
+	}
+	if got := s.Stats().Deduplicated; got != 0 {
+		t.Errorf("Deduplicated: got %d, want 0", got)
+	}
+}
+
+func TestSingleflightUnexportedFields(t *testing.T) {
+	// Concurrent calls whose arguments differ only in an unexported field
+	// are not identical.
+	type query struct {
+		Table string
+		limit int
+	}
+	ctx := context.Background()
+	s := weaver.NewSingleflight[int]("test")
+	release := make(chan struct{})
+	first := make(chan int, 1)
+	go func() {
+		got, err := s.Do(ctx, "Get", []any{query{"users", 1}}, func(context.Context) (int, error) {
+			<-release
+			return 1, nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+		first <- got
+	}()
+	waitForCalls(s, 1)
+
+	// If the second call were coalesced with the first, it would block until
+	// its context expires.
+	ctx2, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	got, err := s.Do(ctx2, "Get", []any{query{"users", 2}}, func(context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if got != 2 {
+		t.Errorf("Do: got %d, want 2", got)
+	}
+	close(release)
+	if got := <-first; got != 1 {
+		t.Errorf("Do: got %d, want 1", got)
+	}
+	if got := s.Stats().Deduplicated; got != 0 {
+		t.Errorf("Deduplicated: got %d, want 0", got)
+	}
+}
+
+func TestSingleflightErrors(t *testing.T) {
+	ctx := context.Background()
+	s := weaver.NewSingleflight[int]("test")
+
+	// Errors returned by the call are returned by Do.
+	want := errors.New("boom")
+	if _, err := s.Do(ctx, "Get", nil, func(context.Context) (int, error) {
+		return 0, want
+	}); !errors.Is(err, want) {
+		t.Errorf("Do: got %v, want %v", err, want)
+	}
+
+	// Arguments that can't be hashed are rejected.
+	if _, err := s.Do(ctx, "Get", []any{make(chan int)}, func(context.Context) (int, error) {
+		t.Error("unexpected call")
+		return 0, nil
+	}); err == nil {
+		t.Error("Do: unexpected success with unhashable arguments")
+	}
+}
+
+func TestSingleflightCallerCancelled(t *testing.T) {
+	s := weaver.NewSingleflight[int]("test")
+	ctx, cancel := context.WithCancel(context.Background())
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Do(ctx, "Get", nil, func(ctx context.Context) (int, error) {
+			<-release
+			// The call's context isn't cancelled with the caller's.
+			return 1, ctx.Err()
+		})
+		done <- err
+	}()
+	waitForCalls(s, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Do: got %v, want %v", err, context.Canceled)
+	}
+
+	// A caller that joins the call still gets its result.
+	go func() {
+		waitForCalls(s, 2)
+		close(release)
+	}()
+	got, err := s.Do(context.Background(), "Get", nil, func(context.Context) (int, error) {
+		return 2, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 1 {
+		t.Errorf("Do: got %d, want 1", got)
+	}
+}
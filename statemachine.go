@@ -0,0 +1,685 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/raft"
+	"github.com/google/uuid"
+)
+
+// ReplicatedStateMachine is a built-in component that keeps strongly
+// consistent state replicated across its replicas, using the Raft consensus
+// algorithm. Every replica holds a copy of every state machine, and a
+// command is applied only once a majority of the replicas have persisted it.
+// Unlike a leader-replicated component (see ReplicationLog), no command that
+// Apply reports as applied is ever lost, as long as a majority of the
+// replicas keep their storage.
+//
+// A state machine is a named StateMachine, registered with
+// RegisterStateMachine by every binary of the application, typically in an
+// init function:
+//
+//	func init() {
+//	    weaver.RegisterStateMachine("accounts", func() weaver.StateMachine {
+//	        return newAccounts()
+//	    })
+//	}
+//
+// Components apply commands to, and query, a state machine by name:
+//
+//	type server struct {
+//	    weaver.Implements[weaver.Main]
+//	    rsm weaver.Ref[weaver.ReplicatedStateMachine]
+//	}
+//
+//	func (s *server) deposit(ctx context.Context, account string, amount int) error {
+//	    _, err := s.rsm.Get().Apply(ctx, "accounts", encodeDeposit(account, amount))
+//	    return err
+//	}
+//
+// Calls for a state machine are routed to the same replica, which forwards
+// them to the leader of the machine's Raft group if it isn't the leader. The
+// replicas persist their Raft logs in memory by default, so the state
+// survives the failure of a minority of the replicas but not the restart of
+// the whole application. With disk storage, the logs are persisted under a
+// directory on the machine that runs the replica. Storage is configured in
+// the ReplicatedStateMachine component's section of the config file:
+//
+//	["github.com/ServiceWeaver/weaver/ReplicatedStateMachine"]
+//	cluster_size = 3
+//	storage = "disk"
+//	dir = "/var/lib/myapp/rsm"
+//
+// cluster_size is the number of replicas of the component. A Raft group only
+// makes progress when a majority of cluster_size replicas are reachable, so
+// that replicas that haven't discovered each other yet can't both elect a
+// leader. If it isn't set, majorities are computed over the replicas
+// discovered so far.
+//
+// When the component runs in a single process, e.g., under "go run" or
+// weavertest, it has a single replica that leads every state machine, and
+// commands are applied synchronously and deterministically.
+type ReplicatedStateMachine interface {
+	// Apply applies a command to the named state machine and returns the
+	// result of StateMachine.Apply. The command is applied by every replica
+	// exactly once.
+	Apply(ctx context.Context, machine string, cmd []byte) ([]byte, error)
+
+	// Query runs a read-only query against the named state machine and
+	// returns the result of StateMachine.Query. The query observes every
+	// command applied before Query was called.
+	Query(ctx context.Context, machine string, query []byte) ([]byte, error)
+
+	// Raft delivers Raft messages, encoded by the raft package, to the named
+	// state machine's Raft group on the replica, and returns the replica's
+	// node ID in the group. It is used by the replicas to communicate with
+	// each other and should not be called by applications.
+	Raft(ctx context.Context, machine string, msgs []byte) (string, error)
+}
+
+// Apply is not idempotent: retrying it may apply a command twice.
+var _ NotRetriable = ReplicatedStateMachine.Apply
+
+// StateMachine is a state replicated by the ReplicatedStateMachine component.
+// It must be deterministic: applying the same commands to the same state must
+// produce the same state on every replica. A StateMachine is not called
+// concurrently.
+type StateMachine interface {
+	ReplicatedState
+
+	// Query returns the result of a query that doesn't modify the state.
+	Query(query []byte) ([]byte, error)
+}
+
+// stateMachines holds the state machines registered with
+// RegisterStateMachine.
+var stateMachines = struct {
+	sync.Mutex
+	constructors map[string]func() StateMachine // by name
+}{constructors: map[string]func() StateMachine{}}
+
+// RegisterStateMachine registers a state machine with the provided name.
+// newMachine returns a state machine in its initial state. A replica of the
+// ReplicatedStateMachine component calls newMachine the first time it
+// serves the named state machine. RegisterStateMachine panics if a state
+// machine with the same name has already been registered.
+func RegisterStateMachine(name string, newMachine func() StateMachine) {
+	stateMachines.Lock()
+	defer stateMachines.Unlock()
+	if _, ok := stateMachines.constructors[name]; ok {
+		panic(fmt.Sprintf("weaver.RegisterStateMachine: state machine %q already registered", name))
+	}
+	stateMachines.constructors[name] = newMachine
+}
+
+const (
+	// rsmTickInterval is the duration of a Raft tick.
+	rsmTickInterval = 50 * time.Millisecond
+
+	// rsmElectionTicks is the number of ticks without hearing from a leader
+	// after which a replica starts an election.
+	rsmElectionTicks = 10
+
+	// rsmDiscoveryTicks is the number of ticks between discoveries of the
+	// other replicas.
+	rsmDiscoveryTicks = 20
+
+	// rsmSendTimeout bounds the delivery of Raft messages to a replica.
+	rsmSendTimeout = time.Second
+
+	// rsmSnapshotEvery is the default number of commands applied between
+	// snapshots.
+	rsmSnapshotEvery = 1000
+)
+
+// errRSMDropped is returned internally when a command or query is dropped
+// because the leader that accepted it lost its leadership. The command or
+// query was not applied, and can be retried.
+var errRSMDropped = errors.New("dropped by a leader change")
+
+// replicatedStateMachineConfig configures the ReplicatedStateMachine
+// component.
+type replicatedStateMachineConfig struct {
+	ClusterSize   int    `toml:"cluster_size"`   // number of replicas
+	Storage       string `toml:"storage"`        // "memory" (default) or "disk"
+	Dir           string `toml:"dir"`            // directory of disk storage
+	SnapshotEvery int    `toml:"snapshot_every"` // commands applied between snapshots
+}
+
+// replicatedStateMachine is the implementation of the ReplicatedStateMachine
+// component.
+type replicatedStateMachine struct {
+	Implements[ReplicatedStateMachine]
+	WithConfig[replicatedStateMachineConfig]
+	WithRouter[rsmRouter]
+	self Ref[ReplicatedStateMachine]
+
+	single bool // does the component have a single replica?
+
+	ctx    context.Context // canceled on shutdown
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the tick loop stops
+
+	mu     sync.Mutex
+	groups map[string]*rsmGroup // by state machine name
+}
+
+// rsmGroup is a replica's member of the Raft group of a state machine.
+type rsmGroup struct {
+	r       *replicatedStateMachine
+	name    string
+	machine StateMachine
+	close   func() error // closes the storage
+	ticks   int          // ticks since the group was joined; used by the tick loop
+
+	mu        sync.Mutex
+	node      *raft.Node
+	peers     map[string]ReplicatedStateMachine // replicas, by node ID
+	proposals map[uint64]*rsmProposal           // pending commands, by log index
+	reads     map[uint64]*rsmRead               // pending queries, by read ID
+	nextRead  uint64                            // ID of the next query
+	applied   uint64                            // index of the last applied entry
+	snapshot  uint64                            // index of the last snapshot
+}
+
+// rsmProposal is a command proposed by the leader.
+type rsmProposal struct {
+	term uint64              // the leader's term
+	done chan rsmApplyResult // receives the result once applied
+}
+
+// rsmApplyResult is the result of applying a command.
+type rsmApplyResult struct {
+	result []byte
+	err    error
+}
+
+// rsmRead is a query waiting for the leader to confirm its leadership.
+type rsmRead struct {
+	index     uint64     // the commit index to apply before the query
+	confirmed bool       // has the leader confirmed the read?
+	done      chan error // receives nil once the query may run
+}
+
+var _ ReplicatedStateMachine = &replicatedStateMachine{}
+
+// Init initializes the ReplicatedStateMachine component.
+func (r *replicatedStateMachine) Init(context.Context) error {
+	config := r.Config()
+	switch config.Storage {
+	case "", "memory":
+	case "disk":
+		if config.Dir == "" {
+			return fmt.Errorf("replicated state machine: disk storage requires a dir")
+		}
+	default:
+		return fmt.Errorf("replicated state machine: unknown storage %q", config.Storage)
+	}
+	if config.ClusterSize < 0 {
+		return fmt.Errorf("replicated state machine: negative cluster_size %d", config.ClusterSize)
+	}
+	if config.SnapshotEvery < 0 {
+		return fmt.Errorf("replicated state machine: negative snapshot_every %d", config.SnapshotEvery)
+	}
+
+	// Without a way to reach individual replicas, the component has a
+	// single replica.
+	r.single = r.self.replicas == nil
+	r.groups = map[string]*rsmGroup{}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.done = make(chan struct{})
+	if r.single {
+		close(r.done)
+	} else {
+		go r.tickPeriodically()
+	}
+	return nil
+}
+
+// Shutdown shuts down the ReplicatedStateMachine component.
+func (r *replicatedStateMachine) Shutdown(context.Context) error {
+	r.cancel()
+	<-r.done
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var errs []error
+	for _, g := range r.groups {
+		errs = append(errs, g.close())
+	}
+	return errors.Join(errs...)
+}
+
+// group returns the Raft group of the named state machine, joining it if
+// necessary.
+func (r *replicatedStateMachine) group(name string) (*rsmGroup, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.groups[name]; ok {
+		return g, nil
+	}
+	if r.ctx.Err() != nil {
+		return nil, fmt.Errorf("replicated state machine %q: shut down", name)
+	}
+
+	stateMachines.Lock()
+	newMachine, ok := stateMachines.constructors[name]
+	stateMachines.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("replicated state machine %q not registered", name)
+	}
+
+	// Open the storage.
+	config := r.Config()
+	var id string
+	var storage raft.Storage
+	closeStorage := func() error { return nil }
+	if config.Storage == "disk" {
+		var disk *raft.DiskStorage
+		var err error
+		id, disk, err = raft.OpenDisk(filepath.Join(config.Dir, url.PathEscape(name)))
+		if err != nil {
+			return nil, fmt.Errorf("replicated state machine %q: %w", name, err)
+		}
+		storage, closeStorage = disk, disk.Close
+	} else {
+		id, storage = uuid.NewString(), raft.NewMemoryStorage()
+	}
+
+	clusterSize := config.ClusterSize
+	if r.single {
+		clusterSize = 1
+	}
+	node, err := raft.New(raft.Config{
+		ID:            id,
+		ClusterSize:   clusterSize,
+		ElectionTicks: rsmElectionTicks,
+		Storage:       storage,
+	})
+	if err != nil {
+		closeStorage()
+		return nil, fmt.Errorf("replicated state machine %q: %w", name, err)
+	}
+	g := &rsmGroup{
+		r:         r,
+		name:      name,
+		machine:   newMachine(),
+		close:     closeStorage,
+		node:      node,
+		peers:     map[string]ReplicatedStateMachine{},
+		proposals: map[uint64]*rsmProposal{},
+		reads:     map[uint64]*rsmRead{},
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err := g.machine.Restore(nil); err != nil {
+		closeStorage()
+		return nil, fmt.Errorf("replicated state machine %q: %w", name, err)
+	}
+	if r.single {
+		// A single replica leads the group right away.
+		if err := node.Campaign(); err != nil {
+			closeStorage()
+			return nil, fmt.Errorf("replicated state machine %q: %w", name, err)
+		}
+	}
+	if err := g.process(); err != nil {
+		closeStorage()
+		return nil, fmt.Errorf("replicated state machine %q: %w", name, err)
+	}
+	r.groups[name] = g
+	return g, nil
+}
+
+// Apply implements the ReplicatedStateMachine interface.
+func (r *replicatedStateMachine) Apply(ctx context.Context, machine string, cmd []byte) ([]byte, error) {
+	g, err := r.group(machine)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		ch, leader, err := g.propose(cmd)
+		switch {
+		case err != nil:
+			return nil, err
+		case leader != nil:
+			return leader.Apply(ctx, machine, cmd)
+		case ch == nil:
+			// The leader is unknown.
+			if err := r.sleep(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		select {
+		case res := <-ch:
+			if errors.Is(res.err, errRSMDropped) {
+				continue
+			}
+			return res.result, res.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Query implements the ReplicatedStateMachine interface.
+func (r *replicatedStateMachine) Query(ctx context.Context, machine string, query []byte) ([]byte, error) {
+	g, err := r.group(machine)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		id, ch, leader, err := g.readIndex()
+		switch {
+		case err != nil:
+			return nil, err
+		case leader != nil:
+			return leader.Query(ctx, machine, query)
+		case ch == nil:
+			// The leader is unknown.
+			if err := r.sleep(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		select {
+		case err := <-ch:
+			if errors.Is(err, errRSMDropped) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		case <-ctx.Done():
+			g.mu.Lock()
+			delete(g.reads, id)
+			g.mu.Unlock()
+			return nil, ctx.Err()
+		}
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		return g.machine.Query(query)
+	}
+}
+
+// Raft implements the ReplicatedStateMachine interface.
+func (r *replicatedStateMachine) Raft(_ context.Context, machine string, data []byte) (string, error) {
+	g, err := r.group(machine)
+	if err != nil {
+		return "", err
+	}
+	var msgs []raft.Message
+	if len(data) > 0 {
+		msgs, err = raft.DecodeMessages(data)
+		if err != nil {
+			return "", err
+		}
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, m := range msgs {
+		if err := g.node.Step(m); err != nil {
+			return "", err
+		}
+	}
+	return g.node.ID(), g.process()
+}
+
+// sleep waits for a tick, e.g., for a leader to be elected.
+func (r *replicatedStateMachine) sleep(ctx context.Context) error {
+	timer := time.NewTimer(rsmTickInterval)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tickPeriodically ticks the Raft groups and discovers their members until
+// the component shuts down.
+func (r *replicatedStateMachine) tickPeriodically() {
+	defer close(r.done)
+	ticker := time.NewTicker(rsmTickInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.ctx.Done():
+			return
+		}
+		r.mu.Lock()
+		groups := make([]*rsmGroup, 0, len(r.groups))
+		for _, g := range r.groups {
+			groups = append(groups, g)
+		}
+		r.mu.Unlock()
+
+		for _, g := range groups {
+			// A new group discovers its members on its first tick.
+			if g.ticks%rsmDiscoveryTicks == 0 {
+				r.discover(g)
+			}
+			g.ticks++
+			g.mu.Lock()
+			err := g.node.Tick()
+			if err == nil {
+				err = g.process()
+			}
+			g.mu.Unlock()
+			if err != nil {
+				r.Logger(r.ctx).Error("Raft tick", "machine", g.name, "err", err)
+			}
+		}
+	}
+}
+
+// discover updates the members of a Raft group with the replicas of the
+// component. Replicas that don't respond are left out.
+func (r *replicatedStateMachine) discover(g *rsmGroup) {
+	handles, err := r.self.replicas()
+	if err != nil {
+		r.Logger(r.ctx).Error("Raft discovery", "machine", g.name, "err", err)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.ctx, rsmSendTimeout)
+	defer cancel()
+	var mu sync.Mutex
+	peers := map[string]ReplicatedStateMachine{}
+	var wg sync.WaitGroup
+	for _, h := range handles {
+		h := h.(ReplicatedStateMachine)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id, err := h.Raft(ctx, g.name, nil)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			peers[id] = h
+		}()
+	}
+	wg.Wait()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ids := make([]string, 0, len(peers))
+	for id := range peers {
+		ids = append(ids, id)
+	}
+	g.peers = peers
+	g.node.SetPeers(ids)
+	if err := g.process(); err != nil {
+		r.Logger(r.ctx).Error("Raft discovery", "machine", g.name, "err", err)
+	}
+}
+
+// propose proposes a command if the replica is the leader. Otherwise, it
+// returns the leader, or nothing if the leader is unknown.
+func (g *rsmGroup) propose(cmd []byte) (chan rsmApplyResult, ReplicatedStateMachine, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.node.IsLeader() {
+		return nil, g.peers[g.node.Leader()], nil
+	}
+	index, term, err := g.node.Propose(cmd)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p, ok := g.proposals[index]; ok {
+		// A command proposed at the same index in an earlier term was
+		// replaced by another leader.
+		p.done <- rsmApplyResult{err: errRSMDropped}
+	}
+	ch := make(chan rsmApplyResult, 1)
+	g.proposals[index] = &rsmProposal{term: term, done: ch}
+	return ch, nil, g.process()
+}
+
+// readIndex requests a linearizable read if the replica is the leader.
+// Otherwise, it returns the leader, or nothing if the leader is unknown.
+func (g *rsmGroup) readIndex() (uint64, chan error, ReplicatedStateMachine, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.node.IsLeader() {
+		return 0, nil, g.peers[g.node.Leader()], nil
+	}
+	g.nextRead++
+	id := g.nextRead
+	if err := g.node.ReadIndex(id); err != nil {
+		return 0, nil, nil, err
+	}
+	ch := make(chan error, 1)
+	g.reads[id] = &rsmRead{done: ch}
+	return id, ch, nil, g.process()
+}
+
+// process handles the output of the Raft node: it applies committed entries
+// to the state machine, completes pending commands and queries, takes
+// snapshots, and sends messages to the other replicas.
+//
+// REQUIRES: g.mu is held.
+func (g *rsmGroup) process() error {
+	rd := g.node.Ready()
+
+	if s := rd.Snapshot; s != nil {
+		if err := g.machine.Restore(s.Data); err != nil {
+			return err
+		}
+		g.applied, g.snapshot = s.Index, s.Index
+		for index, p := range g.proposals {
+			if index <= s.Index {
+				// The command may or may not be covered by the snapshot.
+				p.done <- rsmApplyResult{err: fmt.Errorf("replicated state machine %q: result of command %d unavailable", g.name, index)}
+				delete(g.proposals, index)
+			}
+		}
+	}
+
+	for _, e := range rd.CommittedEntries {
+		var res rsmApplyResult
+		if e.Type == raft.EntryNormal {
+			res.result, res.err = g.machine.Apply(e.Data)
+		}
+		if p, ok := g.proposals[e.Index]; ok {
+			if p.term != e.Term {
+				res = rsmApplyResult{err: errRSMDropped}
+			}
+			p.done <- res
+			delete(g.proposals, e.Index)
+		}
+		g.applied = e.Index
+	}
+
+	for _, rs := range rd.ReadStates {
+		read, ok := g.reads[rs.ID]
+		if !ok {
+			continue
+		}
+		if rs.Dropped {
+			read.done <- errRSMDropped
+			delete(g.reads, rs.ID)
+			continue
+		}
+		read.index, read.confirmed = rs.Index, true
+	}
+	for id, read := range g.reads {
+		if read.confirmed && read.index <= g.applied {
+			read.done <- nil
+			delete(g.reads, id)
+		}
+	}
+
+	every := uint64(g.r.Config().SnapshotEvery)
+	if every == 0 {
+		every = rsmSnapshotEvery
+	}
+	if g.applied-g.snapshot >= every {
+		data, err := g.machine.Snapshot()
+		if err != nil {
+			return err
+		}
+		if err := g.node.Compact(g.applied, data); err != nil {
+			return err
+		}
+		g.snapshot = g.applied
+	}
+
+	// Send messages, batched per replica.
+	batches := map[string][]raft.Message{}
+	for _, m := range rd.Messages {
+		batches[m.To] = append(batches[m.To], m)
+	}
+	for to, msgs := range batches {
+		peer, ok := g.peers[to]
+		if !ok {
+			// The replica hasn't been discovered yet.
+			continue
+		}
+		to, data := to, raft.EncodeMessages(msgs)
+		go func() {
+			ctx, cancel := context.WithTimeout(g.r.ctx, rsmSendTimeout)
+			defer cancel()
+			if _, err := peer.Raft(ctx, g.name, data); err != nil {
+				g.r.Logger(ctx).Debug("Raft send", "machine", g.name, "to", to, "err", err)
+			}
+		}()
+	}
+	return nil
+}
+
+// rsmRouter routes calls to the ReplicatedStateMachine component.
+type rsmRouter struct{}
+
+// Apply routes calls to ReplicatedStateMachine.Apply by state machine name.
+func (rsmRouter) Apply(_ context.Context, machine string, _ []byte) string {
+	return machine
+}
+
+// Query routes calls to ReplicatedStateMachine.Query by state machine name.
+func (rsmRouter) Query(_ context.Context, machine string, _ []byte) string {
+	return machine
+}
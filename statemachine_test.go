@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+)
+
+// sumMachine is a StateMachine that sums the integers it is applied.
+type sumMachine struct {
+	sum int64
+}
+
+func init() {
+	weaver.RegisterStateMachine("sum", func() weaver.StateMachine { return &sumMachine{} })
+}
+
+func (s *sumMachine) Apply(cmd []byte) ([]byte, error) {
+	if len(cmd) != 8 {
+		return nil, fmt.Errorf("bad command %x", cmd)
+	}
+	s.sum += int64(binary.LittleEndian.Uint64(cmd))
+	return binary.LittleEndian.AppendUint64(nil, uint64(s.sum)), nil
+}
+
+func (s *sumMachine) Query([]byte) ([]byte, error) {
+	return binary.LittleEndian.AppendUint64(nil, uint64(s.sum)), nil
+}
+
+func (s *sumMachine) Snapshot() ([]byte, error) {
+	return binary.LittleEndian.AppendUint64(nil, uint64(s.sum)), nil
+}
+
+func (s *sumMachine) Restore(snapshot []byte) error {
+	s.sum = 0
+	if snapshot != nil {
+		s.sum = int64(binary.LittleEndian.Uint64(snapshot))
+	}
+	return nil
+}
+
+func TestReplicatedStateMachine(t *testing.T) {
+	// Snapshot often to exercise compaction.
+	const config = `
+		["github.com/ServiceWeaver/weaver/ReplicatedStateMachine"]
+		snapshot_every = 3
+	`
+	for _, runner := range weavertest.AllRunners() {
+		runner.Config = config
+		runner.Test(t, func(t *testing.T, rsm weaver.ReplicatedStateMachine) {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			for i := 1; i <= 10; i++ {
+				got, err := rsm.Apply(ctx, "sum", binary.LittleEndian.AppendUint64(nil, uint64(i)))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if want := uint64(i * (i + 1) / 2); binary.LittleEndian.Uint64(got) != want {
+					t.Fatalf("Apply(%d): got %d, want %d", i, binary.LittleEndian.Uint64(got), want)
+				}
+			}
+			got, err := rsm.Query(ctx, "sum", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if binary.LittleEndian.Uint64(got) != 55 {
+				t.Fatalf("Query: got %d, want 55", binary.LittleEndian.Uint64(got))
+			}
+
+			// Errors of the state machine are returned.
+			if _, err := rsm.Apply(ctx, "sum", []byte("bad")); err == nil {
+				t.Fatal("Apply: unexpected success for bad command")
+			}
+
+			// Unregistered state machines are an error.
+			if _, err := rsm.Query(ctx, "unknown", nil); err == nil {
+				t.Fatal("Query: unexpected success for unregistered state machine")
+			}
+		})
+	}
+}
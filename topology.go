@@ -0,0 +1,91 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/internal/weaver"
+)
+
+// ComponentGraph is the live component graph of a deployment: which
+// components call which, and how often. Unlike the graph of the components
+// that a component's implementation references, it only includes the calls
+// that were actually made.
+type ComponentGraph struct {
+	// Components holds the full names of the components that made or
+	// received calls, sorted.
+	Components []string
+
+	// Edges holds the calls between components, sorted by caller and callee.
+	Edges []ComponentEdge
+
+	// Window is the period over which the rates of the edges are computed.
+	// It is zero if the deployer had no earlier metrics to compute rates
+	// against, in which case all rates are zero.
+	Window time.Duration
+}
+
+// ComponentEdge records the calls made by one component to another.
+type ComponentEdge struct {
+	Caller          string        // full name of the calling component
+	Component       string        // full name of the called component
+	Calls           int64         // total number of calls
+	Errors          int64         // total number of calls that returned an error
+	CallsPerSecond  float64       // call rate over the window
+	ErrorsPerSecond float64       // failed call rate over the window
+	MeanLatency     time.Duration // mean call latency over the window, or overall if there is no window
+}
+
+// Topology returns the live component graph of the deployment that runs the
+// component method, Init method, or main function that received ctx. The
+// graph is assembled by the deployer from the method metrics of all the
+// replicas of the deployment, so it only includes the calls reported so far;
+// the multiprocess deployers collect metrics about once a minute. For
+// example:
+//
+//	graph, err := weaver.Topology(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, e := range graph.Edges {
+//	    logger.Info("traffic", "from", e.Caller, "to", e.Component, "qps", e.CallsPerSecond)
+//	}
+//
+// Topology returns an error if ctx was not passed by Service Weaver, if the
+// deployer doesn't report topologies, or under the simulator.
+func Topology(ctx context.Context) (ComponentGraph, error) {
+	t, err := weaver.TopologyFromContext(ctx)
+	if err != nil {
+		return ComponentGraph{}, err
+	}
+	graph := ComponentGraph{
+		Components: t.Components,
+		Window:     time.Duration(t.WindowNanos),
+	}
+	for _, e := range t.Edges {
+		graph.Edges = append(graph.Edges, ComponentEdge{
+			Caller:          e.Caller,
+			Component:       e.Component,
+			Calls:           e.Calls,
+			Errors:          e.Errors,
+			CallsPerSecond:  e.CallsPerSecond,
+			ErrorsPerSecond: e.ErrorsPerSecond,
+			MeanLatency:     time.Duration(e.MeanLatencyMicros * float64(time.Microsecond)),
+		})
+	}
+	return graph, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span is the span that traces the current operation, e.g., the component
+// method call being handled. It is a thin wrapper around an OpenTelemetry
+// span, for annotating traces without using the OpenTelemetry API. The zero
+// value, like the span of an untraced operation, is a no-op.
+type Span struct {
+	span trace.Span // nil for the zero value
+}
+
+// SpanFromContext returns the span in ctx. Inside a component method, it is
+// the span Service Weaver created to trace the method call, if the call is
+// traced. If ctx isn't traced, the returned span is a no-op. For example:
+//
+//	func (c *cache) Get(ctx context.Context, key string) (string, error) {
+//	    span := weaver.SpanFromContext(ctx)
+//	    span.SetAttributes("key", key)
+//	    value, ok := c.lookup(key)
+//	    span.AddEvent("lookup", "hit", ok)
+//	    ...
+//	}
+func SpanFromContext(ctx context.Context) Span {
+	return Span{span: trace.SpanFromContext(ctx)}
+}
+
+// IsRecording returns whether the span is recording, i.e., whether the
+// operation is traced and sampled. Annotations of spans that aren't recording
+// are discarded, so use IsRecording to avoid computing expensive annotations
+// that would be discarded.
+func (s Span) IsRecording() bool {
+	return s.span != nil && s.span.IsRecording()
+}
+
+// SetAttributes sets attributes on the span. keysAndValues alternates
+// between string keys and values, like the arguments of slog.Logger.Info.
+// Strings, booleans, integers, floats, and slices of them are recorded as is.
+// Other values are formatted with fmt.Sprint, or recorded by calling
+// String, if they implement fmt.Stringer.
+func (s Span) SetAttributes(keysAndValues ...any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.span.SetAttributes(attributes(keysAndValues)...)
+}
+
+// AddEvent adds an event with the provided name and attributes to the span.
+// See SetAttributes for the format of keysAndValues.
+func (s Span) AddEvent(name string, keysAndValues ...any) {
+	if !s.IsRecording() {
+		return
+	}
+	s.span.AddEvent(name, trace.WithAttributes(attributes(keysAndValues)...))
+}
+
+// RecordError records err as an event of the span, and marks the span as
+// failed. It does nothing if err is nil.
+func (s Span) RecordError(err error) {
+	if err == nil || !s.IsRecording() {
+		return
+	}
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+// attributes converts alternating keys and values to attributes. A key that
+// isn't a string is formatted with fmt.Sprint, and a key without a value is
+// recorded with the value "!MISSING".
+func attributes(keysAndValues []any) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		if i+1 == len(keysAndValues) {
+			attrs = append(attrs, attribute.String(key, "!MISSING"))
+			break
+		}
+		attrs = append(attrs, attributeOf(key, keysAndValues[i+1]))
+	}
+	return attrs
+}
+
+// attributeOf returns the attribute with the provided key and value.
+func attributeOf(key string, value any) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int8:
+		return attribute.Int64(key, int64(v))
+	case int16:
+		return attribute.Int64(key, int64(v))
+	case int32:
+		return attribute.Int64(key, int64(v))
+	case int64:
+		return attribute.Int64(key, v)
+	case uint8:
+		return attribute.Int64(key, int64(v))
+	case uint16:
+		return attribute.Int64(key, int64(v))
+	case uint32:
+		return attribute.Int64(key, int64(v))
+	case float32:
+		return attribute.Float64(key, float64(v))
+	case float64:
+		return attribute.Float64(key, v)
+	case []string:
+		return attribute.StringSlice(key, v)
+	case []bool:
+		return attribute.BoolSlice(key, v)
+	case []int:
+		return attribute.IntSlice(key, v)
+	case []int64:
+		return attribute.Int64Slice(key, v)
+	case []float64:
+		return attribute.Float64Slice(key, v)
+	case fmt.Stringer:
+		return attribute.Stringer(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
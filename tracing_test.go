@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSpanFromContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+	ctx, otelSpan := tracer.Start(context.Background(), "test")
+
+	span := weaver.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		t.Fatal("span is not recording")
+	}
+	span.SetAttributes(
+		"string", "hello",
+		"int", 42,
+		"uint16", uint16(7),
+		"float", 1.5,
+		"bool", true,
+		"strings", []string{"a", "b"},
+		"stringer", netip.MustParseAddr("10.0.0.1"),
+		"other", struct{ X int }{1},
+		"missing",
+	)
+	span.AddEvent("lookup", "hit", false)
+	span.RecordError(nil)
+	span.RecordError(errors.New("boom"))
+	otelSpan.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+	got := spans[0]
+	want := []attribute.KeyValue{
+		attribute.String("string", "hello"),
+		attribute.Int("int", 42),
+		attribute.Int64("uint16", 7),
+		attribute.Float64("float", 1.5),
+		attribute.Bool("bool", true),
+		attribute.StringSlice("strings", []string{"a", "b"}),
+		attribute.String("stringer", "10.0.0.1"),
+		attribute.String("other", "{1}"),
+		attribute.String("missing", "!MISSING"),
+	}
+	opt := cmp.Comparer(func(x, y attribute.Value) bool { return x.Emit() == y.Emit() && x.Type() == y.Type() })
+	if diff := cmp.Diff(want, got.Attributes(), opt); diff != "" {
+		t.Errorf("attributes (-want +got):\n%s", diff)
+	}
+	events := got.Events()
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want 2", len(events))
+	}
+	if events[0].Name != "lookup" {
+		t.Errorf("event name: got %q, want %q", events[0].Name, "lookup")
+	}
+	if diff := cmp.Diff([]attribute.KeyValue{attribute.Bool("hit", false)}, events[0].Attributes, opt); diff != "" {
+		t.Errorf("event attributes (-want +got):\n%s", diff)
+	}
+	if events[1].Name != "exception" {
+		t.Errorf("error event name: got %q, want %q", events[1].Name, "exception")
+	}
+	if got, want := got.Status(), (sdktrace.Status{Code: codes.Error, Description: "boom"}); got != want {
+		t.Errorf("status: got %v, want %v", got, want)
+	}
+}
+
+func TestSpanFromContextUntraced(t *testing.T) {
+	for _, span := range []weaver.Span{weaver.SpanFromContext(context.Background()), {}} {
+		if span.IsRecording() {
+			t.Error("untraced span is recording")
+		}
+		// These are no-ops.
+		span.SetAttributes("key", "value")
+		span.AddEvent("event", "key", "value")
+		span.RecordError(errors.New("boom"))
+	}
+}
@@ -0,0 +1,653 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+)
+
+// Bulk transfers move large amounts of data, like datasets or model weights,
+// between components on connections of their own, rather than through
+// component method calls. A method call carries its arguments in a single
+// message on the connection shared by all calls between two weavelets, so a
+// large argument delays the calls queued behind it. A transfer instead streams
+// its data in chunks on a dedicated connection, resumes from the last chunk
+// the receiver wrote if the connection fails, and sends no faster than the
+// receiver writes.
+//
+// The receiving component serves transfers on a Listener, and returns the
+// address of the listener from a method that senders call first:
+//
+//	type store struct {
+//	    weaver.Implements[Store]
+//	    bulk     weaver.Listener
+//	    receiver *weaver.TransferReceiver
+//	}
+//
+//	func (s *store) Init(context.Context) error {
+//	    s.receiver = weaver.NewTransferReceiver(s.bulk, s.open)
+//	    return nil
+//	}
+//
+//	func (s *store) Shutdown(context.Context) error {
+//	    return s.receiver.Close()
+//	}
+//
+//	func (s *store) TransferAddress(context.Context) (string, error) {
+//	    return s.receiver.Addr(), nil
+//	}
+//
+//	func (s *store) open(ctx context.Context, id string, size int64) (weaver.TransferSink, error) {
+//	    // Return a sink that writes the data to a file, for example.
+//	}
+//
+// The sender then transfers the data to the address:
+//
+//	addr, err := s.store.Get().TransferAddress(ctx)
+//	if err != nil {
+//	    return err
+//	}
+//	f, err := os.Open("dataset.bin")
+//	...
+//	stats, err := weaver.Transfer(ctx, addr, "dataset", f, size, weaver.TransferOptions{})
+
+// Defaults and limits of transfers.
+const (
+	defaultTransferChunkSize = 1 << 20
+	defaultTransferWindow    = 8
+	maxTransferChunkSize     = 16 << 20
+
+	// transferIdleTimeout is how long a receiver keeps the state of a
+	// transfer that no connection is receiving, so that the sender can
+	// resume it, and how long it waits for the next chunk of a transfer.
+	transferIdleTimeout = 10 * time.Minute
+)
+
+// transferMagic starts every transfer connection.
+const transferMagic = "WVTX"
+
+// Kinds of the replies sent by a TransferReceiver.
+const (
+	transferAck  byte = iota + 1 // data up to the offset is written
+	transferDone                 // the transfer completed
+	transferFail                 // the transfer failed and can't be resumed
+)
+
+// transferCRC is the table of the checksums of chunks.
+var transferCRC = crc32.MakeTable(crc32.Castagnoli)
+
+type transferLabels struct {
+	Direction string // "send" or "receive"
+
+	// Is this a metric implicitly created by the framework?
+	Generated bool `weaver:"serviceweaver_generated"`
+}
+
+var (
+	transferBytes = metrics.NewCounterMap[transferLabels](
+		"serviceweaver_transfer_bytes",
+		"Number of bytes of bulk transfers sent or written by a receiver",
+	)
+	transferResumes = metrics.NewCounterMap[transferLabels](
+		"serviceweaver_transfer_resumes",
+		"Number of times bulk transfers resumed after a failure",
+	)
+	transferActive = metrics.NewGaugeMap[transferLabels](
+		"serviceweaver_transfer_active",
+		"Number of bulk transfers in progress",
+	)
+	transferThroughput = metrics.NewHistogramMap[transferLabels](
+		"serviceweaver_transfer_throughput_bytes_per_second",
+		"Throughput, in bytes per second, of completed bulk transfers",
+		imetrics.GeneratedBuckets,
+	)
+)
+
+// TransferOptions configure a Transfer.
+type TransferOptions struct {
+	// ChunkSize is the number of bytes sent in every chunk, at most 16 MiB.
+	// A transfer that resumes after a failure resends at most the chunks
+	// in flight. Defaults to 1 MiB.
+	ChunkSize int
+
+	// Window is the largest number of chunks sent but not yet written by
+	// the receiver. When the window is full, the sender waits for the
+	// receiver to catch up. Defaults to 8.
+	Window int
+}
+
+// TransferStats are statistics of a Transfer.
+type TransferStats struct {
+	Bytes    int64         // bytes sent by the call and written by the receiver
+	Resumes  int           // times the transfer resumed after a failure
+	Duration time.Duration // duration of the call
+}
+
+// Throughput returns the number of bytes sent per second.
+func (s TransferStats) Throughput() float64 {
+	if s.Duration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / s.Duration.Seconds()
+}
+
+// permanentTransferError is an error after which a transfer doesn't resume.
+type permanentTransferError struct {
+	err error
+}
+
+func (e permanentTransferError) Error() string { return e.err.Error() }
+func (e permanentTransferError) Unwrap() error { return e.err }
+
+// Transfer sends size bytes read from src to the TransferReceiver at addr, as
+// transfer id. If the transfer fails, Transfer resumes it, with backoff, from
+// the last byte the receiver wrote, until the transfer completes, the receiver
+// reports an error, or ctx is done. Calling Transfer again with the same id
+// also resumes the transfer, if the receiver still has it.
+//
+// Transfer returns once the receiver has committed the data to its sink.
+func Transfer(ctx context.Context, addr, id string, src io.ReaderAt, size int64, opts TransferOptions) (TransferStats, error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = defaultTransferChunkSize
+	}
+	if opts.ChunkSize > maxTransferChunkSize {
+		return TransferStats{}, fmt.Errorf("transfer %q: chunk size %d larger than %d", id, opts.ChunkSize, maxTransferChunkSize)
+	}
+	if opts.Window <= 0 {
+		opts.Window = defaultTransferWindow
+	}
+	if size < 0 {
+		return TransferStats{}, fmt.Errorf("transfer %q: negative size %d", id, size)
+	}
+
+	labels := transferLabels{Direction: "send", Generated: true}
+	transferActive.Get(labels).Add(1)
+	defer transferActive.Get(labels).Sub(1)
+
+	start := time.Now()
+	var stats TransferStats
+	var err error
+	for r := retry.Begin(); ; {
+		var sent int64
+		sent, err = sendTransfer(ctx, addr, id, src, size, opts)
+		stats.Bytes += sent
+		transferBytes.Get(labels).Add(float64(sent))
+		if err == nil || errors.As(err, &permanentTransferError{}) {
+			break
+		}
+		if !r.Continue(ctx) {
+			err = fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			break
+		}
+		stats.Resumes++
+		transferResumes.Get(labels).Inc()
+	}
+	stats.Duration = time.Since(start)
+	if err != nil {
+		return stats, fmt.Errorf("transfer %q to %s: %w", id, addr, err)
+	}
+	transferThroughput.Get(labels).Put(stats.Throughput())
+	return stats, nil
+}
+
+// sendTransfer sends the data of a transfer on a single connection, starting
+// from the offset reported by the receiver. It returns the number of bytes
+// that the receiver acknowledged.
+func sendTransfer(ctx context.Context, addr, id string, src io.ReaderAt, size int64, opts TransferOptions) (int64, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// Unblock reads and writes when ctx is done.
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	hello := []byte(transferMagic)
+	hello = binary.LittleEndian.AppendUint32(hello, uint32(len(id)))
+	hello = append(hello, id...)
+	hello = binary.LittleEndian.AppendUint64(hello, uint64(size))
+	if _, err := conn.Write(hello); err != nil {
+		return 0, err
+	}
+	r := bufio.NewReader(conn)
+	kind, offset, err := readTransferReply(r)
+	switch {
+	case err != nil:
+		return 0, err
+	case kind == transferDone:
+		return 0, nil
+	case offset < 0 || offset > size:
+		return 0, permanentTransferError{fmt.Errorf("bad offset %d", offset)}
+	}
+
+	// Read the acknowledgements of the chunks, freeing a slot of the window
+	// for every one of them.
+	window := make(chan struct{}, opts.Window)
+	type result struct {
+		acked int64 // the largest offset acknowledged
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		acked := offset
+		for {
+			kind, off, err := readTransferReply(r)
+			switch {
+			case err != nil:
+				results <- result{acked, err}
+				return
+			case kind == transferDone:
+				results <- result{size, nil}
+				return
+			}
+			acked = off
+			<-window
+		}
+	}()
+
+	buf := make([]byte, 8+opts.ChunkSize)
+	for off := offset; off < size; {
+		select {
+		case window <- struct{}{}:
+		case res := <-results:
+			return res.acked - offset, res.err
+		}
+		n := int(min(int64(opts.ChunkSize), size-off))
+		chunk := buf[8 : 8+n]
+		if m, err := src.ReadAt(chunk, off); m < n {
+			return 0, permanentTransferError{fmt.Errorf("read source at offset %d: %w", off, err)}
+		}
+		binary.LittleEndian.PutUint32(buf, uint32(n))
+		binary.LittleEndian.PutUint32(buf[4:], crc32.Checksum(chunk, transferCRC))
+		if _, err := conn.Write(buf[:8+n]); err != nil {
+			conn.Close()
+			res := <-results
+			return res.acked - offset, err
+		}
+		off += int64(n)
+	}
+	res := <-results
+	return res.acked - offset, res.err
+}
+
+// readTransferReply reads a reply sent by a TransferReceiver. A transferFail
+// reply is returned as a permanentTransferError.
+func readTransferReply(r *bufio.Reader) (byte, int64, error) {
+	var header [13]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, 0, err
+	}
+	kind := header[0]
+	offset := int64(binary.LittleEndian.Uint64(header[1:]))
+	msg := make([]byte, binary.LittleEndian.Uint32(header[9:]))
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return 0, 0, err
+	}
+	switch kind {
+	case transferAck, transferDone:
+		return kind, offset, nil
+	case transferFail:
+		return 0, 0, permanentTransferError{fmt.Errorf("receiver: %s", msg)}
+	default:
+		return 0, 0, permanentTransferError{fmt.Errorf("unknown reply kind %d", kind)}
+	}
+}
+
+// writeTransferReply writes a reply to a sender.
+func writeTransferReply(w io.Writer, kind byte, offset int64, msg string) error {
+	reply := []byte{kind}
+	reply = binary.LittleEndian.AppendUint64(reply, uint64(offset))
+	reply = binary.LittleEndian.AppendUint32(reply, uint32(len(msg)))
+	reply = append(reply, msg...)
+	_, err := w.Write(reply)
+	return err
+}
+
+// TransferSink receives the data of a transfer, in order. A sink is used by
+// one connection at a time.
+type TransferSink interface {
+	io.Writer
+
+	// Commit is called once all of the data has been written. Transfer
+	// returns the error returned by Commit to the sender.
+	Commit() error
+
+	// Abort is called if the transfer is abandoned before it completes,
+	// because a write failed, the sender didn't resume it in time, or the
+	// receiver was closed.
+	Abort()
+}
+
+// TransferReceiver receives transfers sent with Transfer. It keeps the state
+// of a transfer whose connection failed for 10 minutes, so that the sender
+// can resume it, and remembers completed transfers for as long, so that a
+// sender that missed the completion doesn't transfer the data again.
+type TransferReceiver struct {
+	lis  net.Listener
+	open func(ctx context.Context, id string, size int64) (TransferSink, error)
+
+	ctx    context.Context // canceled by Close
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	transfers map[string]*incomingTransfer
+	conns     map[net.Conn]struct{} // open connections
+}
+
+// incomingTransfer is the state of a transfer kept by a TransferReceiver.
+type incomingTransfer struct {
+	sink   TransferSink
+	size   int64
+	offset int64     // the number of bytes written to sink
+	done   bool      // has sink been committed?
+	last   time.Time // the last time the transfer made progress
+
+	// conn is the connection receiving the transfer, if any. idle is
+	// closed once conn stops receiving it.
+	conn net.Conn
+	idle chan struct{}
+}
+
+// NewTransferReceiver returns a receiver that serves transfers on lis, which
+// is typically a Listener of the receiving component. It calls open to get a
+// sink for the data of every new transfer. open is passed a context that is
+// canceled when the receiver is closed.
+func NewTransferReceiver(lis net.Listener, open func(ctx context.Context, id string, size int64) (TransferSink, error)) *TransferReceiver {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &TransferReceiver{
+		lis:       lis,
+		open:      open,
+		ctx:       ctx,
+		cancel:    cancel,
+		transfers: map[string]*incomingTransfer{},
+		conns:     map[net.Conn]struct{}{},
+	}
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		r.serve()
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.expire()
+	}()
+	return r
+}
+
+// Addr returns the address that senders pass to Transfer.
+func (r *TransferReceiver) Addr() string {
+	return r.lis.Addr().String()
+}
+
+// Close closes the listener, stops receiving transfers, and aborts the sinks
+// of incomplete transfers.
+func (r *TransferReceiver) Close() error {
+	r.cancel()
+	err := r.lis.Close()
+	r.mu.Lock()
+	for conn := range r.conns {
+		conn.Close()
+	}
+	r.mu.Unlock()
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, t := range r.transfers {
+		if !t.done {
+			t.sink.Abort()
+		}
+		delete(r.transfers, id)
+	}
+	return err
+}
+
+// serve accepts connections until the listener is closed.
+func (r *TransferReceiver) serve() {
+	for {
+		conn, err := r.lis.Accept()
+		if err != nil {
+			return
+		}
+		r.mu.Lock()
+		if r.ctx.Err() != nil {
+			r.mu.Unlock()
+			conn.Close()
+			return
+		}
+		r.conns[conn] = struct{}{}
+		r.wg.Add(1)
+		r.mu.Unlock()
+		go func() {
+			defer r.wg.Done()
+			r.receive(conn)
+			r.mu.Lock()
+			delete(r.conns, conn)
+			r.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// expire periodically forgets the transfers that made no progress for
+// transferIdleTimeout, until the receiver is closed.
+func (r *TransferReceiver) expire() {
+	ticker := time.NewTicker(transferIdleTimeout / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		r.mu.Lock()
+		for id, t := range r.transfers {
+			if t.conn == nil && time.Since(t.last) > transferIdleTimeout {
+				if !t.done {
+					t.sink.Abort()
+				}
+				delete(r.transfers, id)
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// receive receives a transfer on a connection.
+func (r *TransferReceiver) receive(conn net.Conn) {
+	conn.SetReadDeadline(time.Now().Add(transferIdleTimeout)) //nolint:errcheck
+	br := bufio.NewReader(conn)
+	var header [8]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil || string(header[:4]) != transferMagic {
+		return
+	}
+	id := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(br, id); err != nil {
+		return
+	}
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		return
+	}
+	size := int64(binary.LittleEndian.Uint64(header[:]))
+
+	t, err := r.acquire(conn, string(id), size)
+	if err != nil {
+		writeTransferReply(conn, transferFail, 0, err.Error()) //nolint:errcheck
+		return
+	}
+	defer r.release(t)
+	if t.done {
+		writeTransferReply(conn, transferDone, size, "") //nolint:errcheck
+		return
+	}
+	if err := writeTransferReply(conn, transferAck, t.offset, ""); err != nil {
+		return
+	}
+
+	labels := transferLabels{Direction: "receive", Generated: true}
+	transferActive.Get(labels).Add(1)
+	defer transferActive.Get(labels).Sub(1)
+	start, offset := time.Now(), t.offset
+	var buf []byte
+	for t.offset < size {
+		conn.SetReadDeadline(time.Now().Add(transferIdleTimeout)) //nolint:errcheck
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			return
+		}
+		n := binary.LittleEndian.Uint32(header[:])
+		if n == 0 || n > maxTransferChunkSize || int64(n) > size-t.offset {
+			writeTransferReply(conn, transferFail, 0, fmt.Sprintf("bad chunk size %d", n)) //nolint:errcheck
+			return
+		}
+		if cap(buf) < int(n) {
+			buf = make([]byte, n)
+		}
+		chunk := buf[:n]
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return
+		}
+		if crc32.Checksum(chunk, transferCRC) != binary.LittleEndian.Uint32(header[4:]) {
+			// Drop the connection. The sender resumes from the last
+			// chunk written.
+			return
+		}
+		if _, err := t.sink.Write(chunk); err != nil {
+			r.fail(t)
+			writeTransferReply(conn, transferFail, 0, err.Error()) //nolint:errcheck
+			return
+		}
+		transferBytes.Get(labels).Add(float64(n))
+		r.progress(t, int64(n))
+		if t.offset < size {
+			if err := writeTransferReply(conn, transferAck, t.offset, ""); err != nil {
+				return
+			}
+		}
+	}
+
+	if err := t.sink.Commit(); err != nil {
+		r.fail(t)
+		writeTransferReply(conn, transferFail, 0, err.Error()) //nolint:errcheck
+		return
+	}
+	r.mu.Lock()
+	t.done = true
+	r.mu.Unlock()
+	if elapsed := time.Since(start); elapsed > 0 {
+		transferThroughput.Get(labels).Put(float64(size-offset) / elapsed.Seconds())
+	}
+	writeTransferReply(conn, transferDone, size, "") //nolint:errcheck
+}
+
+// acquire returns the state of transfer id, creating it if needed, and makes
+// conn the connection receiving it. If another connection is receiving the
+// transfer, as happens when a sender resumes before the receiver notices that
+// the sender's previous connection failed, acquire closes the other connection
+// and waits for it to let go of the transfer.
+func (r *TransferReceiver) acquire(conn net.Conn, id string, size int64) (*incomingTransfer, error) {
+	for {
+		r.mu.Lock()
+		t, ok := r.transfers[id]
+		if !ok {
+			break
+		}
+		if t.size != size {
+			r.mu.Unlock()
+			return nil, fmt.Errorf("transfer %q has size %d, not %d", id, t.size, size)
+		}
+		if t.conn == nil {
+			t.conn, t.idle = conn, make(chan struct{})
+			r.mu.Unlock()
+			return t, nil
+		}
+		other, idle := t.conn, t.idle
+		r.mu.Unlock()
+		other.Close()
+		select {
+		case <-idle:
+		case <-r.ctx.Done():
+			return nil, r.ctx.Err()
+		}
+	}
+	r.mu.Unlock()
+
+	// Open a sink for a new transfer. Two connections may race to open the
+	// same transfer, in which case the loser aborts its sink and retries.
+	sink, err := r.open(r.ctx, id, size)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	if _, ok := r.transfers[id]; ok {
+		r.mu.Unlock()
+		sink.Abort()
+		return r.acquire(conn, id, size)
+	}
+	t := &incomingTransfer{
+		sink: sink,
+		size: size,
+		last: time.Now(),
+		conn: conn,
+		idle: make(chan struct{}),
+	}
+	r.transfers[id] = t
+	r.mu.Unlock()
+	return t, nil
+}
+
+// release lets go of a transfer acquired by acquire.
+func (r *TransferReceiver) release(t *incomingTransfer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.conn = nil
+	t.last = time.Now()
+	close(t.idle)
+}
+
+// progress records that n more bytes of transfer t were written.
+func (r *TransferReceiver) progress(t *incomingTransfer, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.offset += n
+	t.last = time.Now()
+}
+
+// fail aborts and forgets a transfer whose sink failed.
+func (r *TransferReceiver) fail(t *incomingTransfer) {
+	t.sink.Abort()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, other := range r.transfers {
+		if other == t {
+			delete(r.transfers, id)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// bufferSink is a TransferSink that writes to a buffer.
+type bufferSink struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	committed bool
+	aborted   bool
+}
+
+func (s *bufferSink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *bufferSink) Commit() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = true
+	return nil
+}
+
+func (s *bufferSink) Abort() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.aborted = true
+}
+
+// sinks returns an open function that records the sinks it returns.
+func sinks() (func(context.Context, string, int64) (weaver.TransferSink, error), map[string]*bufferSink) {
+	var mu sync.Mutex
+	opened := map[string]*bufferSink{}
+	open := func(_ context.Context, id string, _ int64) (weaver.TransferSink, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		s := &bufferSink{}
+		opened[id] = s
+		return s, nil
+	}
+	return open, opened
+}
+
+// flakyListener is a listener whose first connections break after reading
+// limit bytes.
+type flakyListener struct {
+	net.Listener
+	limit int64
+	flaky atomic.Int32 // the number of connections left to break
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil || l.flaky.Add(-1) < 0 {
+		return conn, err
+	}
+	return &flakyConn{Conn: conn, left: l.limit}, nil
+}
+
+type flakyConn struct {
+	net.Conn
+	left int64
+}
+
+func (c *flakyConn) Read(p []byte) (int, error) {
+	if c.left <= 0 {
+		c.Conn.Close()
+		return 0, errors.New("connection broken")
+	}
+	if int64(len(p)) > c.left {
+		p = p[:c.left]
+	}
+	n, err := c.Conn.Read(p)
+	c.left -= int64(n)
+	return n, err
+}
+
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lis
+}
+
+func randomData(n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(0)).Read(data)
+	return data
+}
+
+func TestTransfer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	open, opened := sinks()
+	r := weaver.NewTransferReceiver(listen(t), open)
+	defer r.Close()
+
+	data := randomData(1<<20 + 123)
+	opts := weaver.TransferOptions{ChunkSize: 64 << 10, Window: 4}
+	stats, err := weaver.Transfer(ctx, r.Addr(), "t", bytes.NewReader(data), int64(len(data)), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Bytes != int64(len(data)) || stats.Resumes != 0 {
+		t.Errorf("stats: got %+v, want %d bytes and no resumes", stats, len(data))
+	}
+	if !opened["t"].committed || !bytes.Equal(opened["t"].buf.Bytes(), data) {
+		t.Fatal("data not committed")
+	}
+
+	// Transferring a completed transfer again sends nothing.
+	stats, err = weaver.Transfer(ctx, r.Addr(), "t", bytes.NewReader(data), int64(len(data)), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Bytes != 0 {
+		t.Errorf("stats: got %d bytes, want 0", stats.Bytes)
+	}
+
+	// Empty transfers work.
+	if _, err := weaver.Transfer(ctx, r.Addr(), "empty", bytes.NewReader(nil), 0, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !opened["empty"].committed {
+		t.Fatal("empty transfer not committed")
+	}
+}
+
+func TestTransferResume(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	lis := &flakyListener{Listener: listen(t), limit: 300 << 10}
+	lis.flaky.Store(3)
+	open, opened := sinks()
+	r := weaver.NewTransferReceiver(lis, open)
+	defer r.Close()
+
+	data := randomData(1 << 20)
+	opts := weaver.TransferOptions{ChunkSize: 64 << 10, Window: 2}
+	stats, err := weaver.Transfer(ctx, r.Addr(), "t", bytes.NewReader(data), int64(len(data)), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Resumes != 3 {
+		t.Errorf("stats: got %d resumes, want 3", stats.Resumes)
+	}
+	// Resumed transfers don't start over. Acknowledgements lost with a
+	// broken connection aren't counted, so fewer bytes may be reported.
+	if stats.Bytes <= 0 || stats.Bytes > int64(len(data)) {
+		t.Errorf("stats: got %d bytes, want (0, %d]", stats.Bytes, len(data))
+	}
+	if !opened["t"].committed || !bytes.Equal(opened["t"].buf.Bytes(), data) {
+		t.Fatal("data not committed")
+	}
+}
+
+func TestTransferOpenError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	open := func(context.Context, string, int64) (weaver.TransferSink, error) {
+		return nil, errors.New("no space")
+	}
+	r := weaver.NewTransferReceiver(listen(t), open)
+	defer r.Close()
+
+	// The receiver's error is returned right away, without resuming.
+	stats, err := weaver.Transfer(ctx, r.Addr(), "t", bytes.NewReader([]byte("x")), 1, weaver.TransferOptions{})
+	if err == nil {
+		t.Fatal("Transfer: unexpected success")
+	}
+	if stats.Resumes != 0 {
+		t.Errorf("stats: got %d resumes, want 0", stats.Resumes)
+	}
+}
+
+func TestTransferClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A sink that blocks until the transfer is canceled, which keeps the
+	// sender waiting for the receiver.
+	blocked := make(chan struct{})
+	sink := &bufferSink{}
+	open := func(context.Context, string, int64) (weaver.TransferSink, error) {
+		return blockingSink{sink, blocked}, nil
+	}
+	r := weaver.NewTransferReceiver(listen(t), open)
+	errs := make(chan error, 1)
+	go func() {
+		data := randomData(1 << 20)
+		_, err := weaver.Transfer(ctx, r.Addr(), "t", bytes.NewReader(data), int64(len(data)), weaver.TransferOptions{ChunkSize: 1024, Window: 2})
+		errs <- err
+	}()
+
+	// Let the sender stall, then cancel it and close the receiver.
+	time.Sleep(100 * time.Millisecond)
+	select {
+	case err := <-errs:
+		t.Fatalf("Transfer returned before the receiver wrote the data: %v", err)
+	default:
+	}
+	cancel()
+	close(blocked)
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("Transfer: got %v, want context.Canceled", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if sink.committed || !sink.aborted {
+		t.Fatal("incomplete transfer not aborted")
+	}
+}
+
+// blockingSink is a sink whose writes block until a channel is closed.
+type blockingSink struct {
+	*bufferSink
+	blocked chan struct{}
+}
+
+func (s blockingSink) Write(p []byte) (int, error) {
+	<-s.blocked
+	return s.bufferSink.Write(p)
+}
@@ -0,0 +1,620 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver/internal/files"
+)
+
+// ErrTxAborted is returned by TxCoordinator.Run when a transaction aborts.
+var ErrTxAborted = errors.New("transaction aborted")
+
+// TxState is the state of a two-phase commit transaction.
+type TxState int
+
+const (
+	TxUnknown   TxState = iota // never prepared, or forgotten
+	TxPrepared                 // prepared, waiting for the outcome
+	TxCommitted                // committed
+	TxAborted                  // aborted
+)
+
+// String implements the fmt.Stringer interface.
+func (s TxState) String() string {
+	switch s {
+	case TxUnknown:
+		return "unknown"
+	case TxPrepared:
+		return "prepared"
+	case TxCommitted:
+		return "committed"
+	case TxAborted:
+		return "aborted"
+	default:
+		return fmt.Sprintf("TxState(%d)", int(s))
+	}
+}
+
+// TxParticipant is a participant in two-phase commit transactions run by a
+// TxCoordinator, typically a component. A component becomes a participant by
+// declaring the three methods below in its interface and implementing them
+// with a TxResourceManager:
+//
+//	type Account interface {
+//	    Prepare(ctx context.Context, tx string, op []byte) error
+//	    Commit(ctx context.Context, tx string) error
+//	    Abort(ctx context.Context, tx string) error
+//	}
+//
+// A coordinator calls the methods again when it can't tell whether earlier
+// calls succeeded, so they must be idempotent. A TxResourceManager makes them
+// so.
+type TxParticipant interface {
+	// Prepare prepares the participant to apply op as part of transaction
+	// tx. It returns nil to vote to commit the transaction, after which the
+	// participant must be able to commit it even if it restarts, and an
+	// error to vote to abort it.
+	Prepare(ctx context.Context, tx string, op []byte) error
+
+	// Commit commits transaction tx, which the participant prepared.
+	Commit(ctx context.Context, tx string) error
+
+	// Abort aborts transaction tx, which the participant may or may not
+	// have prepared.
+	Abort(ctx context.Context, tx string) error
+}
+
+// TxLog durably stores the records of transactions, for a TxCoordinator or a
+// TxResourceManager. A TxLog must be used by a single coordinator or resource
+// manager at a time.
+type TxLog interface {
+	// Store durably stores the record of transaction tx, replacing the
+	// record stored previously, if any.
+	Store(tx string, record []byte) error
+
+	// Delete deletes the record of transaction tx, if any.
+	Delete(tx string) error
+
+	// Load returns the records of all transactions, by transaction.
+	Load() (map[string][]byte, error)
+}
+
+// memTxLog is a TxLog that stores records in memory.
+type memTxLog struct {
+	mu      sync.Mutex
+	records map[string][]byte
+}
+
+// NewMemTxLog returns a TxLog that stores records in memory. The records are
+// lost when the process exits, so use it for testing, or for transactions
+// whose participants don't outlive the process either.
+func NewMemTxLog() TxLog {
+	return &memTxLog{records: map[string][]byte{}}
+}
+
+// Store implements the TxLog interface.
+func (m *memTxLog) Store(tx string, record []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records[tx] = append([]byte(nil), record...)
+	return nil
+}
+
+// Delete implements the TxLog interface.
+func (m *memTxLog) Delete(tx string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, tx)
+	return nil
+}
+
+// Load implements the TxLog interface.
+func (m *memTxLog) Load() (map[string][]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	records := make(map[string][]byte, len(m.records))
+	for tx, record := range m.records {
+		records[tx] = append([]byte(nil), record...)
+	}
+	return records, nil
+}
+
+// diskTxLog is a TxLog that stores every record in a file of its own.
+type diskTxLog struct {
+	dir string
+}
+
+// txFileSuffix is the suffix of the files in which a diskTxLog stores records.
+const txFileSuffix = ".tx"
+
+// NewDiskTxLog returns a TxLog that stores records in files in the provided
+// directory, creating it if needed. Records survive restarts of the process.
+func NewDiskTxLog(dir string) (TxLog, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &diskTxLog{dir: dir}, nil
+}
+
+// file returns the name of the file that stores the record of transaction tx.
+// Transaction IDs are hex encoded, so that they can be arbitrary strings.
+func (d *diskTxLog) file(tx string) string {
+	return filepath.Join(d.dir, hex.EncodeToString([]byte(tx))+txFileSuffix)
+}
+
+// Store implements the TxLog interface.
+func (d *diskTxLog) Store(tx string, record []byte) error {
+	return files.WriteFileSync(d.file(tx), record)
+}
+
+// Delete implements the TxLog interface.
+func (d *diskTxLog) Delete(tx string) error {
+	if err := os.Remove(d.file(tx)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Load implements the TxLog interface.
+func (d *diskTxLog) Load() (map[string][]byte, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, err
+	}
+	records := map[string][]byte{}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), txFileSuffix)
+		if !ok || entry.IsDir() {
+			// Skip temporary files left by a crash during a Store.
+			continue
+		}
+		tx, err := hex.DecodeString(name)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected file %q in %q", entry.Name(), d.dir)
+		}
+		record, err := os.ReadFile(filepath.Join(d.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		records[string(tx)] = record
+	}
+	return records, nil
+}
+
+// coordinatorRecord is the record of a transaction stored by a TxCoordinator.
+type coordinatorRecord struct {
+	// State is TxPrepared while the participants are being prepared, and
+	// the outcome once it is decided.
+	State        TxState
+	Participants []string
+}
+
+// TxCoordinator runs two-phase commit transactions that span multiple
+// participants, typically components. For example, the following component
+// transfers money between accounts held by two components:
+//
+//	type bank struct {
+//	    weaver.Implements[Bank]
+//	    checking weaver.Ref[Checking]
+//	    savings  weaver.Ref[Savings]
+//	    coord    *weaver.TxCoordinator
+//	}
+//
+//	func (b *bank) Init(ctx context.Context) error {
+//	    log, err := weaver.NewDiskTxLog("/var/lib/bank/tx")
+//	    if err != nil {
+//	        return err
+//	    }
+//	    b.coord = weaver.NewTxCoordinator(log, map[string]weaver.TxParticipant{
+//	        "checking": b.checking.Get(),
+//	        "savings":  b.savings.Get(),
+//	    })
+//	    return b.coord.Recover(ctx)
+//	}
+//
+//	func (b *bank) Save(ctx context.Context, amount int) error {
+//	    return b.coord.Run(ctx, uuid.NewString(), map[string][]byte{
+//	        "checking": encodeDelta(-amount),
+//	        "savings":  encodeDelta(amount),
+//	    })
+//	}
+//
+// A TxCoordinator logs the participants of a transaction before preparing
+// them, and the outcome of a transaction before telling the participants, so
+// that it can finish the transactions that were in flight when it crashed:
+// Recover aborts the transactions whose outcome wasn't logged, and tells the
+// participants the outcome of the others. Call Recover when the coordinator
+// starts, and periodically afterwards to finish the transactions whose
+// participants couldn't be reached.
+//
+// A TxCoordinator is safe for concurrent use.
+type TxCoordinator struct {
+	log          TxLog
+	participants map[string]TxParticipant
+
+	mu     sync.Mutex
+	active map[string]bool // transactions being run or recovered
+}
+
+// NewTxCoordinator returns a coordinator that logs transactions to the
+// provided log and runs them on the provided participants, by name. The log
+// must not be shared with other coordinators. Participants must keep their
+// names across restarts, so that Recover can finish the transactions of a
+// previous run.
+func NewTxCoordinator(log TxLog, participants map[string]TxParticipant) *TxCoordinator {
+	return &TxCoordinator{
+		log:          log,
+		participants: participants,
+		active:       map[string]bool{},
+	}
+}
+
+// Run runs transaction tx, which applies ops[name] at participant name, and
+// returns once the outcome of the transaction is decided. It returns nil if
+// the transaction commits, and an error that wraps ErrTxAborted and the
+// participant's error if a participant votes to abort it. Participants are
+// prepared one at a time, in the order of their names.
+//
+// Any other error means that the outcome is unknown: Recover aborts the
+// transaction if it wasn't decided yet, and finishes it otherwise.
+//
+// tx must be unique, e.g., a UUID. Participants ignore a transaction that
+// reuses the ID of a transaction they committed.
+func (c *TxCoordinator) Run(ctx context.Context, tx string, ops map[string][]byte) error {
+	if len(ops) == 0 {
+		return fmt.Errorf("transaction %q has no participants", tx)
+	}
+	names := make([]string, 0, len(ops))
+	for name := range ops {
+		if _, ok := c.participants[name]; !ok {
+			return fmt.Errorf("transaction %q: participant %q not found", tx, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if !c.acquire(tx) {
+		return fmt.Errorf("transaction %q is already running", tx)
+	}
+	defer c.release(tx)
+
+	// Log the participants, so that Recover can abort the transaction if the
+	// coordinator crashes before logging the outcome.
+	record := coordinatorRecord{State: TxPrepared, Participants: names}
+	if err := c.store(tx, record); err != nil {
+		return err
+	}
+
+	var cause error
+	for _, name := range names {
+		if err := c.participants[name].Prepare(ctx, tx, ops[name]); err != nil {
+			cause = fmt.Errorf("participant %q: %w", name, err)
+			break
+		}
+	}
+
+	record.State = TxCommitted
+	if cause != nil {
+		record.State = TxAborted
+	}
+	if err := c.store(tx, record); err != nil {
+		return err
+	}
+
+	// The outcome is decided. If a participant can't be told the outcome
+	// now, Recover tells it later.
+	c.finish(ctx, tx, record) //nolint:errcheck
+	if cause != nil {
+		return fmt.Errorf("%w: %w", ErrTxAborted, cause)
+	}
+	return nil
+}
+
+// Recover finishes the logged transactions that aren't running: it aborts the
+// transactions whose outcome isn't decided, and tells the participants of the
+// other transactions their outcome. It returns an error if some transactions
+// couldn't be finished, in which case calling Recover again retries them.
+func (c *TxCoordinator) Recover(ctx context.Context) error {
+	records, err := c.log.Load()
+	if err != nil {
+		return err
+	}
+	txs := make([]string, 0, len(records))
+	for tx := range records {
+		txs = append(txs, tx)
+	}
+	sort.Strings(txs)
+
+	var errs []error
+	for _, tx := range txs {
+		if err := c.recover(ctx, tx, records[tx]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recover finishes transaction tx, whose logged record is provided.
+func (c *TxCoordinator) recover(ctx context.Context, tx string, data []byte) error {
+	if !c.acquire(tx) {
+		// Run or another call to Recover is finishing the transaction.
+		return nil
+	}
+	defer c.release(tx)
+
+	var record coordinatorRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return fmt.Errorf("transaction %q: decode record: %w", tx, err)
+	}
+	for _, name := range record.Participants {
+		if _, ok := c.participants[name]; !ok {
+			return fmt.Errorf("transaction %q: participant %q not found", tx, name)
+		}
+	}
+	if record.State == TxPrepared {
+		// The coordinator crashed before deciding the outcome. Some
+		// participants may have voted to commit, but no participant was
+		// told to commit, so the transaction can be aborted.
+		record.State = TxAborted
+		if err := c.store(tx, record); err != nil {
+			return err
+		}
+	}
+	return c.finish(ctx, tx, record)
+}
+
+// finish tells the participants of transaction tx its outcome, and deletes
+// the transaction from the log once they all have been told.
+func (c *TxCoordinator) finish(ctx context.Context, tx string, record coordinatorRecord) error {
+	var errs []error
+	for _, name := range record.Participants {
+		p := c.participants[name]
+		var err error
+		if record.State == TxCommitted {
+			err = p.Commit(ctx, tx)
+		} else {
+			err = p.Abort(ctx, tx)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("transaction %q: participant %q: %w", tx, name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return c.log.Delete(tx)
+}
+
+// store logs the record of transaction tx.
+func (c *TxCoordinator) store(tx string, record coordinatorRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := c.log.Store(tx, data); err != nil {
+		return fmt.Errorf("transaction %q: log %v: %w", tx, record.State, err)
+	}
+	return nil
+}
+
+// acquire marks transaction tx as active, and returns false if it already is.
+func (c *TxCoordinator) acquire(tx string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.active[tx] {
+		return false
+	}
+	c.active[tx] = true
+	return true
+}
+
+// release marks transaction tx as inactive.
+func (c *TxCoordinator) release(tx string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.active, tx)
+}
+
+// TxResource is a resource changed by two-phase commit transactions, like the
+// balances of accounts. A TxResourceManager calls its methods one at a time.
+type TxResource interface {
+	// Prepare checks that op can be applied as part of transaction tx, and
+	// reserves what it needs to be applied, e.g., by locking the keys it
+	// changes. It returns an error to vote to abort the transaction.
+	//
+	// After a restart, the TxResourceManager calls Prepare again for the
+	// transactions that were prepared before, and Prepare must succeed.
+	Prepare(tx string, op []byte) error
+
+	// Commit applies op, which was prepared, as part of transaction tx. If
+	// the process crashes before the TxResourceManager logs that tx
+	// committed, Commit is called again after the restart.
+	Commit(tx string, op []byte) error
+
+	// Abort releases what Prepare reserved for op.
+	Abort(tx string, op []byte) error
+}
+
+// participantRecord is the record of a transaction stored by a
+// TxResourceManager.
+type participantRecord struct {
+	State TxState
+	Op    []byte `json:",omitempty"` // the op, while prepared
+}
+
+// TxResourceManager implements the TxParticipant methods for a TxResource. It
+// logs the transactions that the resource prepared, so that they survive
+// restarts, and the outcome of transactions, so that retried and late calls
+// from the coordinator are handled correctly. For example, a Prepare that
+// arrives after the coordinator gave up on it and aborted the transaction
+// votes to abort.
+//
+// The records of finished transactions are small but are kept forever, so
+// use a TxResourceManager for transactions that are rare enough.
+//
+// A TxResourceManager is safe for concurrent use.
+type TxResourceManager struct {
+	log      TxLog
+	resource TxResource
+
+	mu  sync.Mutex
+	txs map[string]participantRecord
+}
+
+var _ TxParticipant = &TxResourceManager{}
+
+// NewTxResourceManager returns a resource manager for the provided resource,
+// which logs transactions to the provided log. It prepares the resource again
+// for the transactions that were prepared but not finished when the log was
+// last used.
+func NewTxResourceManager(log TxLog, resource TxResource) (*TxResourceManager, error) {
+	records, err := log.Load()
+	if err != nil {
+		return nil, err
+	}
+	m := &TxResourceManager{log: log, resource: resource, txs: map[string]participantRecord{}}
+	txs := make([]string, 0, len(records))
+	for tx := range records {
+		txs = append(txs, tx)
+	}
+	sort.Strings(txs)
+	for _, tx := range txs {
+		var record participantRecord
+		if err := json.Unmarshal(records[tx], &record); err != nil {
+			return nil, fmt.Errorf("transaction %q: decode record: %w", tx, err)
+		}
+		if record.State == TxPrepared {
+			if err := resource.Prepare(tx, record.Op); err != nil {
+				return nil, fmt.Errorf("transaction %q: prepare again: %w", tx, err)
+			}
+		}
+		m.txs[tx] = record
+	}
+	return m, nil
+}
+
+// Prepare implements the TxParticipant interface.
+func (m *TxResourceManager) Prepare(_ context.Context, tx string, op []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch m.txs[tx].State {
+	case TxPrepared, TxCommitted:
+		// A retried call.
+		return nil
+	case TxAborted:
+		return fmt.Errorf("transaction %q already aborted", tx)
+	}
+	if err := m.resource.Prepare(tx, op); err != nil {
+		return err
+	}
+	record := participantRecord{State: TxPrepared, Op: op}
+	if err := m.store(tx, record); err != nil {
+		return errors.Join(err, m.resource.Abort(tx, op))
+	}
+	m.txs[tx] = record
+	return nil
+}
+
+// Commit implements the TxParticipant interface.
+func (m *TxResourceManager) Commit(_ context.Context, tx string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.txs[tx]
+	switch record.State {
+	case TxCommitted:
+		return nil
+	case TxUnknown:
+		return fmt.Errorf("transaction %q not prepared", tx)
+	case TxAborted:
+		return fmt.Errorf("transaction %q already aborted", tx)
+	}
+	if err := m.resource.Commit(tx, record.Op); err != nil {
+		return err
+	}
+	return m.finish(tx, TxCommitted)
+}
+
+// Abort implements the TxParticipant interface.
+func (m *TxResourceManager) Abort(_ context.Context, tx string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	record := m.txs[tx]
+	switch record.State {
+	case TxAborted:
+		return nil
+	case TxCommitted:
+		return fmt.Errorf("transaction %q already committed", tx)
+	case TxPrepared:
+		if err := m.resource.Abort(tx, record.Op); err != nil {
+			return err
+		}
+	}
+	// Record the outcome even if the transaction wasn't prepared, in case
+	// a delayed Prepare arrives.
+	return m.finish(tx, TxAborted)
+}
+
+// State returns the state of transaction tx.
+func (m *TxResourceManager) State(tx string) TxState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.txs[tx].State
+}
+
+// InDoubt returns the transactions that are prepared but not finished, in
+// sorted order.
+func (m *TxResourceManager) InDoubt() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var txs []string
+	for tx, record := range m.txs {
+		if record.State == TxPrepared {
+			txs = append(txs, tx)
+		}
+	}
+	sort.Strings(txs)
+	return txs
+}
+
+// finish records the outcome of transaction tx.
+//
+// REQUIRES: m.mu is held.
+func (m *TxResourceManager) finish(tx string, state TxState) error {
+	record := participantRecord{State: state}
+	m.txs[tx] = record
+	return m.store(tx, record)
+}
+
+// store logs the record of transaction tx.
+//
+// REQUIRES: m.mu is held.
+func (m *TxResourceManager) store(tx string, record participantRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return m.log.Store(tx, data)
+}
@@ -0,0 +1,198 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+// kvResource is a TxResource that stores the ops of committed transactions.
+type kvResource struct {
+	prepared  map[string]string
+	committed map[string]string
+}
+
+func newKVResource() *kvResource {
+	return &kvResource{prepared: map[string]string{}, committed: map[string]string{}}
+}
+
+func (r *kvResource) Prepare(tx string, op []byte) error {
+	if string(op) == "no" {
+		return fmt.Errorf("vote no")
+	}
+	r.prepared[tx] = string(op)
+	return nil
+}
+
+func (r *kvResource) Commit(tx string, op []byte) error {
+	r.committed[tx] = string(op)
+	delete(r.prepared, tx)
+	return nil
+}
+
+func (r *kvResource) Abort(tx string, _ []byte) error {
+	delete(r.prepared, tx)
+	return nil
+}
+
+func TestDiskTxLog(t *testing.T) {
+	dir := t.TempDir()
+	log, err := weaver.NewDiskTxLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for tx, record := range map[string]string{"a": "1", "b/c": "2", "": "3"} {
+		if err := log.Store(tx, []byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := log.Store("a", []byte("4")); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Delete("b/c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := log.Delete("missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the log.
+	log, err = weaver.NewDiskTxLog(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := log.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{"a": []byte("4"), "": []byte("3")}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("Load (-want +got):\n%s", diff)
+	}
+}
+
+func TestTxCommit(t *testing.T) {
+	ctx := context.Background()
+	a, b := newKVResource(), newKVResource()
+	am, err := weaver.NewTxResourceManager(weaver.NewMemTxLog(), a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bm, err := weaver.NewTxResourceManager(weaver.NewMemTxLog(), b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coord := weaver.NewTxCoordinator(weaver.NewMemTxLog(), map[string]weaver.TxParticipant{"a": am, "b": bm})
+
+	if err := coord.Run(ctx, "t1", map[string][]byte{"a": []byte("x"), "b": []byte("y")}); err != nil {
+		t.Fatal(err)
+	}
+	if a.committed["t1"] != "x" || b.committed["t1"] != "y" {
+		t.Fatalf("t1 not committed: %v, %v", a.committed, b.committed)
+	}
+
+	err = coord.Run(ctx, "t2", map[string][]byte{"a": []byte("x"), "b": []byte("no")})
+	if !errors.Is(err, weaver.ErrTxAborted) {
+		t.Fatalf("Run: got %v, want ErrTxAborted", err)
+	}
+	if len(a.prepared) > 0 || a.committed["t2"] != "" {
+		t.Fatalf("t2 not aborted: %v, %v", a.prepared, a.committed)
+	}
+	for _, m := range []*weaver.TxResourceManager{am, bm} {
+		if got := m.State("t2"); got != weaver.TxAborted {
+			t.Fatalf("State: got %v, want %v", got, weaver.TxAborted)
+		}
+	}
+}
+
+func TestTxRecoverAbortsUndecided(t *testing.T) {
+	ctx := context.Background()
+	r := newKVResource()
+	m, err := weaver.NewTxResourceManager(weaver.NewMemTxLog(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a coordinator that crashed after logging the participants and
+	// preparing them.
+	log := weaver.NewMemTxLog()
+	if err := log.Store("t", []byte(`{"State":1,"Participants":["p"]}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Prepare(ctx, "t", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"t"}, m.InDoubt()); diff != "" {
+		t.Fatalf("InDoubt (-want +got):\n%s", diff)
+	}
+
+	coord := weaver.NewTxCoordinator(log, map[string]weaver.TxParticipant{"p": m})
+	if err := coord.Recover(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.State("t"); got != weaver.TxAborted {
+		t.Fatalf("State: got %v, want %v", got, weaver.TxAborted)
+	}
+	if records, _ := log.Load(); len(records) > 0 {
+		t.Fatalf("log not empty after recovery: %v", records)
+	}
+
+	// A delayed Prepare votes to abort.
+	if err := m.Prepare(ctx, "t", []byte("x")); err == nil {
+		t.Fatal("Prepare: unexpected success after abort")
+	}
+}
+
+func TestTxResourceManagerRestart(t *testing.T) {
+	ctx := context.Background()
+	log, err := weaver.NewDiskTxLog(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := weaver.NewTxResourceManager(log, newKVResource())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Prepare(ctx, "t", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart. The prepared transaction is prepared again, and commits.
+	r := newKVResource()
+	m, err = weaver.NewTxResourceManager(log, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.prepared["t"] != "x" {
+		t.Fatalf("t not prepared again: %v", r.prepared)
+	}
+	for i := 0; i < 2; i++ { // Commit is idempotent.
+		if err := m.Commit(ctx, "t"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r.committed["t"] != "x" {
+		t.Fatalf("t not committed: %v", r.committed)
+	}
+	if err := m.Abort(ctx, "t"); err == nil {
+		t.Fatal("Abort: unexpected success after commit")
+	}
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver/internal/reflection"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/version"
 	"golang.org/x/exp/slices"
 )
 
@@ -36,9 +37,17 @@ func validateRegistrations(regs []*codegen.Registration) error {
 		intfs[reg.Iface] = struct{}{}
 	}
 
+	// Check that every registration was generated by a compatible version of
+	// 'weaver generate' from the component interface that is linked in.
+	var errs []error
+	for _, reg := range regs {
+		if err := validateCodegenVersion(reg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
 	// Check that for every weaver.Ref[T] field in a component implementation
 	// struct, T is a registered interface.
-	var errs []error
 	for _, reg := range regs {
 		for i := 0; i < reg.Impl.NumField(); i++ {
 			f := reg.Impl.Field(i)
@@ -76,6 +85,33 @@ func validateRegistrations(regs []*codegen.Registration) error {
 	return errors.Join(errs...)
 }
 
+// validateCodegenVersion returns an error if the provided registration was
+// generated by an incompatible version of 'weaver generate', or if the
+// component interface changed after the registration was generated.
+// Registrations generated by versions of 'weaver generate' that don't record
+// this information are not checked.
+func validateCodegenVersion(reg *codegen.Registration) error {
+	if reg.CodegenVersion != "" {
+		var major, minor, patch int
+		if _, err := fmt.Sscanf(reg.CodegenVersion, "v%d.%d.%d", &major, &minor, &patch); err != nil {
+			return fmt.Errorf("component %s has invalid codegen version %q", reg.Name, reg.CodegenVersion)
+		}
+		if major != version.CodegenMajor || minor != version.CodegenMinor {
+			return fmt.Errorf(
+				"component %s was generated by 'weaver generate' %s (codegen version %s), but the weaver module expects codegen version %s; update 'weaver generate' and re-run it",
+				reg.Name, reg.GeneratorVersion, reg.CodegenVersion, version.CodegenVersion,
+			)
+		}
+	}
+	if reg.IfaceHash != "" && reg.IfaceHash != codegen.InterfaceHash(reg.Iface) {
+		return fmt.Errorf(
+			"component interface %v changed after its code was generated by 'weaver generate' %s; re-run 'weaver generate'",
+			reg.Iface, reg.GeneratorVersion,
+		)
+	}
+	return nil
+}
+
 // isValidListenerName returns whether the provided name is a valid
 // weaver.Listener name.
 func isValidListenerName(name string) bool {
@@ -21,6 +21,7 @@ import (
 
 	"github.com/ServiceWeaver/weaver/internal/reflection"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
+	"github.com/ServiceWeaver/weaver/runtime/version"
 )
 
 // TestValidateNoRegistrations tests that validateRegistrations succeeds on an
@@ -149,3 +150,67 @@ func TestValidateUnregisteredListener(t *testing.T) {
 		}
 	}
 }
+
+// TestValidateCodegenVersion tests that validateRegistrations fails on
+// registrations generated by an incompatible version of 'weaver generate'.
+func TestValidateCodegenVersion(t *testing.T) {
+	type foo interface{}
+	type fooImpl struct{}
+	for _, test := range []struct {
+		version string
+		want    string
+	}{
+		{version.CodegenVersion.String(), ""},
+		{"v0.1.0", "but the weaver module expects codegen version"},
+		{"v1000.0.0", "but the weaver module expects codegen version"},
+		{"garbage", `invalid codegen version "garbage"`},
+	} {
+		t.Run(test.version, func(t *testing.T) {
+			regs := []*codegen.Registration{
+				{
+					Name:             "foo",
+					Iface:            reflection.Type[foo](),
+					Impl:             reflection.Type[fooImpl](),
+					GeneratorVersion: "v0.0.0",
+					CodegenVersion:   test.version,
+				},
+			}
+			err := validateRegistrations(regs)
+			switch {
+			case test.want == "" && err != nil:
+				t.Fatal(err)
+			case test.want != "" && err == nil:
+				t.Fatal("unexpected validateRegistrations success")
+			case test.want != "" && !strings.Contains(err.Error(), test.want):
+				t.Fatalf("validateRegistrations: got %q, want %q", err, test.want)
+			}
+		})
+	}
+}
+
+// TestValidateInterfaceHash tests that validateRegistrations fails when a
+// component interface changed after its code was generated.
+func TestValidateInterfaceHash(t *testing.T) {
+	type foo interface{ Get(string) (int, error) }
+	type fooImpl struct{}
+	reg := &codegen.Registration{
+		Name:      "foo",
+		Iface:     reflection.Type[foo](),
+		Impl:      reflection.Type[fooImpl](),
+		IfaceHash: codegen.InterfaceHash(reflection.Type[foo]()),
+	}
+	if err := validateRegistrations([]*codegen.Registration{reg}); err != nil {
+		t.Fatal(err)
+	}
+
+	type oldFoo interface{ Get(string) (string, error) }
+	reg.IfaceHash = codegen.InterfaceHash(reflection.Type[oldFoo]())
+	err := validateRegistrations([]*codegen.Registration{reg})
+	if err == nil {
+		t.Fatal("unexpected validateRegistrations success")
+	}
+	const want = "changed after its code was generated"
+	if !strings.Contains(err.Error(), want) {
+		t.Fatalf("validateRegistrations: got %q, want %q", err, want)
+	}
+}
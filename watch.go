@@ -0,0 +1,304 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ServiceWeaver/weaver/runtime/retry"
+	"github.com/google/uuid"
+)
+
+const (
+	// maxWatchBuffer is the least number of changes a Publisher retains for
+	// watchers that fall behind. A watcher that falls further behind may
+	// have to resync.
+	maxWatchBuffer = 1024
+
+	// maxWatchBatch is the largest number of changes returned by a single
+	// call to WatchChanges.
+	maxWatchBatch = 256
+
+	// watchWait is how long WatchChanges waits for a change before returning
+	// an empty batch, so that a watch call doesn't run into the timeouts of
+	// the network between the caller and the component.
+	watchWait = 30 * time.Second
+)
+
+// ErrWatchTokenExpired is returned when a watch resumes from a token that a
+// Publisher can no longer resume from, because the watcher fell too far
+// behind, or because the token was returned by another replica, or by the
+// replica before it restarted. The watcher must then resync: read the state
+// it watches anew, and watch from the token returned with it.
+var ErrWatchTokenExpired = errors.New("watch token expired")
+
+// WatchToken is an opaque position in the changes published by a Publisher,
+// returned with every change so that a watcher can resume from it. The empty
+// token denotes the position at which a watch call arrives.
+type WatchToken string
+
+// WatchChange is a change of type T published by a Publisher.
+//
+// The code generator serializes WatchChange[T] for every serializable type T.
+type WatchChange[T any] struct {
+	Key   string     // the key of the changed state, e.g., a user id
+	Value T          // the change
+	Token WatchToken // the token to resume from, after the change
+}
+
+// WatchBatch is a batch of changes returned by WatchChanges.
+//
+// The code generator serializes WatchBatch[T] for every serializable type T,
+// so that WatchChanges methods can return it.
+type WatchBatch[T any] struct {
+	Changes []WatchChange[T] // the changes, oldest first
+	Token   WatchToken       // the token to resume from, after the batch
+}
+
+// Watchable is implemented by components that publish changes to their state.
+// A component that publishes changes declares the method below in its
+// interface, and implements it with a Publisher, so that callers can watch
+// the changes with Watch, instead of polling for them:
+//
+//	type Users interface {
+//	    Get(ctx context.Context, id string) (User, weaver.WatchToken, error)
+//	    Update(ctx context.Context, user User) error
+//	    WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[User], error)
+//	}
+//
+// "weaver generate" requires WatchChanges to be routed by a router (see
+// WithRouter), since a Publisher keeps its changes in the memory of a single
+// replica. See Publisher.
+type Watchable[T any] interface {
+	// WatchChanges returns the changes published after the position of the
+	// provided token whose keys start with the provided prefix. If there
+	// are none, it waits for some, up to 30 seconds, and then returns an
+	// empty batch. It returns an error that wraps ErrWatchTokenExpired if it
+	// can't resume from the token.
+	WatchChanges(ctx context.Context, prefix string, token WatchToken) (WatchBatch[T], error)
+}
+
+// Publisher publishes the changes to the state of a component, as values of
+// type T, to the callers that watch them. It implements the Watchable
+// interface. For example:
+//
+//	type users struct {
+//	    weaver.Implements[Users]
+//	    weaver.WithRouter[usersRouter]
+//	    mu        sync.Mutex
+//	    users     map[string]User
+//	    publisher *weaver.Publisher[User]
+//	}
+//
+//	func (u *users) Init(context.Context) error {
+//	    u.publisher = weaver.NewPublisher[User]()
+//	    return nil
+//	}
+//
+//	func (u *users) Update(ctx context.Context, user User) error {
+//	    u.mu.Lock()
+//	    defer u.mu.Unlock()
+//	    u.users[user.ID] = user
+//	    u.publisher.Publish(user.ID, user)
+//	    return nil
+//	}
+//
+//	func (u *users) Get(ctx context.Context, id string) (User, weaver.WatchToken, error) {
+//	    u.mu.Lock()
+//	    defer u.mu.Unlock()
+//	    return u.users[id], u.publisher.Token(), nil
+//	}
+//
+//	func (u *users) WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[User], error) {
+//	    return u.publisher.WatchChanges(ctx, prefix, token)
+//	}
+//
+//	// usersRouter routes every call by the user id, or the watched prefix.
+//	type usersRouter struct{}
+//
+//	func (usersRouter) Get(_ context.Context, id string) string {
+//	    return id
+//	}
+//
+//	func (usersRouter) Update(_ context.Context, user User) string {
+//	    return user.ID
+//	}
+//
+//	func (usersRouter) WatchChanges(_ context.Context, prefix string, _ weaver.WatchToken) string {
+//	    return prefix
+//	}
+//
+// A Publisher retains the latest changes in memory, so a watcher can only
+// resume from a token returned by the same replica, since it last started.
+// WatchChanges must be routed, and the methods that publish changes should
+// be routed by the same key, so that watchers reach the replica that
+// publishes the changes they watch. A watch sees only the changes published
+// on the replica its prefix is routed to.
+type Publisher[T any] struct {
+	epoch string // distinguishes the tokens of different publishers
+
+	mu        sync.Mutex
+	first     uint64           // the sequence number of changes[0]
+	changes   []WatchChange[T] // the retained changes, oldest first
+	published chan struct{}    // closed and replaced on every publish
+}
+
+var _ Watchable[int] = &Publisher[int]{}
+
+// NewPublisher returns a new Publisher.
+func NewPublisher[T any]() *Publisher[T] {
+	return &Publisher[T]{
+		epoch:     uuid.NewString(),
+		first:     1,
+		published: make(chan struct{}),
+	}
+}
+
+// Publish publishes a change to the state with the provided key, e.g., the
+// new value of the state. The change is retained and returned to watchers
+// as is, so it must not be modified after it is published.
+func (p *Publisher[T]) Publish(key string, change T) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.changes = append(p.changes, WatchChange[T]{Key: key, Value: change})
+	if len(p.changes) >= 2*maxWatchBuffer {
+		// Drop the oldest changes in bulk, rather than one at a time.
+		n := copy(p.changes, p.changes[maxWatchBuffer:])
+		clear(p.changes[n:])
+		p.changes = p.changes[:n]
+		p.first += maxWatchBuffer
+	}
+	close(p.published)
+	p.published = make(chan struct{})
+}
+
+// Token returns the token of the current position, after every change
+// published so far. Return it along with a read of the state, so that a
+// watcher can watch the changes made after the read.
+func (p *Publisher[T]) Token() WatchToken {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.token(p.last())
+}
+
+// last returns the sequence number of the last published change, or zero.
+func (p *Publisher[T]) last() uint64 {
+	return p.first + uint64(len(p.changes)) - 1
+}
+
+// token returns the token of the position after the change with the
+// provided sequence number.
+func (p *Publisher[T]) token(seq uint64) WatchToken {
+	return WatchToken(fmt.Sprintf("%s.%d", p.epoch, seq))
+}
+
+// WatchChanges implements the Watchable interface.
+func (p *Publisher[T]) WatchChanges(ctx context.Context, prefix string, token WatchToken) (WatchBatch[T], error) {
+	p.mu.Lock()
+	after := p.last()
+	if token != "" {
+		epoch, seq, ok := strings.Cut(string(token), ".")
+		n, err := strconv.ParseUint(seq, 10, 64)
+		if !ok || err != nil || epoch != p.epoch || n > after || n+1 < p.first {
+			p.mu.Unlock()
+			return WatchBatch[T]{}, fmt.Errorf("watch from %q: %w", token, ErrWatchTokenExpired)
+		}
+		after = n
+	}
+	p.mu.Unlock()
+
+	timer := time.NewTimer(watchWait)
+	defer timer.Stop()
+	for {
+		p.mu.Lock()
+		if after+1 < p.first {
+			// The changes after the token were dropped while waiting.
+			p.mu.Unlock()
+			return WatchBatch[T]{}, fmt.Errorf("watch from %q: %w", token, ErrWatchTokenExpired)
+		}
+		var batch WatchBatch[T]
+		for seq := after + 1; seq <= p.last() && len(batch.Changes) < maxWatchBatch; seq++ {
+			c := p.changes[seq-p.first]
+			if strings.HasPrefix(c.Key, prefix) {
+				c.Token = p.token(seq)
+				batch.Changes = append(batch.Changes, c)
+			}
+			after = seq
+		}
+		published := p.published
+		p.mu.Unlock()
+
+		if len(batch.Changes) > 0 {
+			batch.Token = p.token(after)
+			return batch, nil
+		}
+		select {
+		case <-published:
+		case <-timer.C:
+			return WatchBatch[T]{Token: p.token(after)}, nil
+		case <-ctx.Done():
+			return WatchBatch[T]{}, ctx.Err()
+		}
+	}
+}
+
+// Watch watches the changes published by w whose keys start with prefix,
+// after the position of token, and calls fn with every change, in order. For
+// example:
+//
+//	user, token, err := users.Get(ctx, id)
+//	...
+//	err = weaver.Watch(ctx, users, id, token, func(c weaver.WatchChange[User]) error {
+//	    user = c.Value
+//	    return nil
+//	})
+//
+// A watch is a long polling loop: it calls WatchChanges over and over, and
+// every call returns as soon as there are changes to return, or after 30
+// seconds without any. Changes reach the watcher shortly after they are
+// published, without a push protocol of their own.
+//
+// Watch retries calls that fail with a RemoteCallError, with exponential
+// backoff. It returns when ctx is done, when fn returns an error, or when
+// WatchChanges returns any other error. If the error wraps
+// ErrWatchTokenExpired, the caller should resync. To resume a watch that
+// returned, watch again from the token of the last change passed to fn.
+func Watch[T any](ctx context.Context, w Watchable[T], prefix string, token WatchToken, fn func(WatchChange[T]) error) error {
+	for r := retry.Begin(); ; {
+		batch, err := w.WatchChanges(ctx, prefix, token)
+		if err != nil && !errors.Is(err, RemoteCallError) {
+			return err
+		}
+		if err != nil {
+			if !r.Continue(ctx) {
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			}
+			continue
+		}
+		r.Reset()
+		for _, c := range batch.Changes {
+			if err := fn(c); err != nil {
+				return err
+			}
+		}
+		token = batch.Token
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weaver_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatchChanges(t *testing.T) {
+	ctx := context.Background()
+	p := weaver.NewPublisher[int]()
+	token := p.Token()
+	for i, key := range []string{"users/a", "orders/b", "users/c"} {
+		p.Publish(key, i)
+	}
+
+	batch, err := p.WatchChanges(ctx, "users/", token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, c := range batch.Changes {
+		keys = append(keys, fmt.Sprintf("%s=%d", c.Key, c.Value))
+	}
+	if diff := cmp.Diff([]string{"users/a=0", "users/c=2"}, keys); diff != "" {
+		t.Fatalf("WatchChanges (-want +got):\n%s", diff)
+	}
+
+	// Resuming from the token of a change skips it.
+	batch, err = p.WatchChanges(ctx, "", batch.Changes[0].Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(batch.Changes); got != 2 {
+		t.Fatalf("WatchChanges after users/a: got %d changes, want 2", got)
+	}
+}
+
+func TestWatchChangesWaits(t *testing.T) {
+	ctx := context.Background()
+	p := weaver.NewPublisher[string]()
+	token := p.Token()
+	done := make(chan weaver.WatchBatch[string])
+	go func() {
+		batch, err := p.WatchChanges(ctx, "", token)
+		if err != nil {
+			t.Error(err)
+		}
+		done <- batch
+	}()
+	p.Publish("a", "hello")
+	batch := <-done
+	if len(batch.Changes) != 1 || batch.Changes[0].Value != "hello" {
+		t.Fatalf("WatchChanges: got %+v, want a single change", batch)
+	}
+}
+
+func TestWatchTokenExpired(t *testing.T) {
+	ctx := context.Background()
+	p := weaver.NewPublisher[int]()
+	old := p.Token()
+	for i := 0; i < 5000; i++ {
+		p.Publish("k", i)
+	}
+	for _, token := range []weaver.WatchToken{
+		old,                                // too far behind
+		weaver.NewPublisher[int]().Token(), // another publisher's
+		"bogus",
+	} {
+		if _, err := p.WatchChanges(ctx, "", token); !errors.Is(err, weaver.ErrWatchTokenExpired) {
+			t.Errorf("WatchChanges(%q): got %v, want ErrWatchTokenExpired", token, err)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	ctx := context.Background()
+	p := weaver.NewPublisher[int]()
+	token := p.Token()
+	go func() {
+		for i := 0; i < 10; i++ {
+			p.Publish(fmt.Sprint(i), i)
+		}
+	}()
+
+	errDone := errors.New("done")
+	var got []int
+	err := weaver.Watch(ctx, p, "", token, func(c weaver.WatchChange[int]) error {
+		got = append(got, c.Value)
+		if len(got) == 10 {
+			return errDone
+		}
+		return nil
+	})
+	if !errors.Is(err, errDone) {
+		t.Fatalf("Watch: got %v, want %v", err, errDone)
+	}
+	if diff := cmp.Diff([]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, got); diff != "" {
+		t.Fatalf("Watch (-want +got):\n%s", diff)
+	}
+}
+
+// flakyWatchable fails every other WatchChanges call with a RemoteCallError.
+type flakyWatchable struct {
+	weaver.Watchable[string]
+	calls int
+}
+
+func (f *flakyWatchable) WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[string], error) {
+	f.calls++
+	if f.calls%2 == 1 {
+		return weaver.WatchBatch[string]{}, weaver.RemoteCallError
+	}
+	return f.Watchable.WatchChanges(ctx, prefix, token)
+}
+
+func TestWatchRetries(t *testing.T) {
+	ctx := context.Background()
+	p := weaver.NewPublisher[string]()
+	token := p.Token()
+	p.Publish("a", "x")
+	p.Publish("b", "y")
+
+	var got []string
+	errDone := errors.New("done")
+	err := weaver.Watch(ctx, &flakyWatchable{Watchable: p}, "", token, func(c weaver.WatchChange[string]) error {
+		got = append(got, c.Value)
+		if len(got) == 2 {
+			return errDone
+		}
+		return nil
+	})
+	if !errors.Is(err, errDone) {
+		t.Fatalf("Watch: got %v, want %v", err, errDone)
+	}
+	if diff := cmp.Diff([]string{"x", "y"}, got); diff != "" {
+		t.Fatalf("Watch (-want +got):\n%s", diff)
+	}
+}
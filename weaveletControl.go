@@ -69,3 +69,13 @@ func (*noopWeaveletControl) GetMetrics(context.Context, *protos.GetMetricsReques
 func (*noopWeaveletControl) GetProfile(context.Context, *protos.GetProfileRequest) (*protos.GetProfileReply, error) {
 	return nil, fmt.Errorf("weaveletControl.GetProfile not implemented")
 }
+
+// GetFlightRecord implements weaveletControl interface.
+func (*noopWeaveletControl) GetFlightRecord(context.Context, *protos.GetFlightRecordRequest) (*protos.GetFlightRecordReply, error) {
+	return nil, fmt.Errorf("weaveletControl.GetFlightRecord not implemented")
+}
+
+// NotifyDeploymentEvent implements weaveletControl interface.
+func (*noopWeaveletControl) NotifyDeploymentEvent(context.Context, *protos.NotifyDeploymentEventRequest) (*protos.NotifyDeploymentEventReply, error) {
+	return nil, fmt.Errorf("weaveletControl.NotifyDeploymentEvent not implemented")
+}
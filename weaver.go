@@ -32,9 +32,12 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"reflect"
 	"sync"
 
+	"github.com/ServiceWeaver/weaver/internal/net/call"
 	"github.com/ServiceWeaver/weaver/internal/reflection"
+	"github.com/ServiceWeaver/weaver/internal/scheduling"
 	"github.com/ServiceWeaver/weaver/internal/weaver"
 	"github.com/ServiceWeaver/weaver/runtime"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
@@ -71,6 +74,21 @@ import (
 // example.
 var RemoteCallError = errors.New("Service Weaver remote call error")
 
+// MaybeCommitted indicates that a remote call to an at-most-once method (see
+// AtMostOnce) failed in a way that leaves its outcome unknown: the call may or
+// may not have executed. For example, the network connection to the callee
+// may have broken after the call was sent, but before its result arrived. An
+// error that embeds MaybeCommitted also embeds RemoteCallError.
+//
+//	err := payments.Charge(ctx, paymentID, amount)
+//	if errors.Is(err, weaver.MaybeCommitted) {
+//	    // The charge may or may not have happened. Reconcile before retrying.
+//	}
+//
+// Failures that happen before a call is sent, like failing to find a replica
+// of the callee, don't embed MaybeCommitted.
+var MaybeCommitted error = call.MaybeCommitted
+
 // HealthzHandler is a health-check handler that returns an OK status for all
 // incoming HTTP requests.
 var HealthzHandler = func(w http.ResponseWriter, _ *http.Request) {
@@ -130,6 +148,14 @@ type PointerToMain[T any] interface {
 //	    }
 //	}
 func Run[T any, P PointerToMain[T]](ctx context.Context, app func(context.Context, *T) error) error {
+	return RunWithRegistry[T, P](ctx, codegen.Global(), app)
+}
+
+// RunWithRegistry is like Run, but the application is composed of the
+// components in reg rather than the components in the global registry, which
+// holds every component linked into the binary. See codegen.Registry.Subset
+// for one way to construct reg.
+func RunWithRegistry[T any, P PointerToMain[T]](ctx context.Context, reg *codegen.Registry, app func(context.Context, *T) error) error {
 	// Register HealthzHandler in the default ServerMux.
 	healthzInit.Do(func() {
 		http.HandleFunc(HealthzURL, HealthzHandler)
@@ -140,24 +166,18 @@ func Run[T any, P PointerToMain[T]](ctx context.Context, app func(context.Contex
 		return err
 	}
 	if !bootstrap.Exists() {
-		return runLocal[T, P](ctx, app)
+		return runLocal[T, P](ctx, reg, app)
 	}
-	return runRemote[T, P](ctx, app, bootstrap)
+	return runRemote[T, P](ctx, reg, app, bootstrap)
 }
 
-func runLocal[T any, _ PointerToMain[T]](ctx context.Context, app func(context.Context, *T) error) error {
-	// Read config from SERVICEWEAVER_CONFIG env variable, if non-empty.
+func runLocal[T any, _ PointerToMain[T]](ctx context.Context, reg *codegen.Registry, app func(context.Context, *T) error) error {
 	opts := weaver.SingleWeaveletOptions{}
-	if filename := os.Getenv("SERVICEWEAVER_CONFIG"); filename != "" {
-		contents, err := os.ReadFile(filename)
-		if err != nil {
-			return fmt.Errorf("config file: %w", err)
-		}
-		opts.ConfigFilename = filename
-		opts.Config = string(contents)
+	if err := readConfigEnv(&opts); err != nil {
+		return err
 	}
 
-	regs := codegen.Registered()
+	regs := reg.Registered()
 	if err := validateRegistrations(regs); err != nil {
 		return err
 	}
@@ -177,11 +197,27 @@ func runLocal[T any, _ PointerToMain[T]](ctx context.Context, app func(context.C
 	if err != nil {
 		return err
 	}
-	return app(ctx, main.(*T))
+	return app(wlet.WithReplica(ctx), main.(*T))
+}
+
+// readConfigEnv reads the config file named by the SERVICEWEAVER_CONFIG
+// environment variable, if non-empty, into opts.
+func readConfigEnv(opts *weaver.SingleWeaveletOptions) error {
+	filename := os.Getenv("SERVICEWEAVER_CONFIG")
+	if filename == "" {
+		return nil
+	}
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+	opts.ConfigFilename = filename
+	opts.Config = string(contents)
+	return nil
 }
 
-func runRemote[T any, _ PointerToMain[T]](ctx context.Context, app func(context.Context, *T) error, bootstrap runtime.Bootstrap) error {
-	regs := codegen.Registered()
+func runRemote[T any, _ PointerToMain[T]](ctx context.Context, reg *codegen.Registry, app func(context.Context, *T) error, bootstrap runtime.Bootstrap) error {
+	regs := reg.Registered()
 	if err := validateRegistrations(regs); err != nil {
 		return err
 	}
@@ -201,7 +237,7 @@ func runRemote[T any, _ PointerToMain[T]](ctx context.Context, app func(context.
 			return err
 		}
 		go func() {
-			errs <- app(ctx, main.(*T))
+			errs <- app(wlet.WithReplica(ctx), main.(*T))
 		}()
 	}
 	go func() {
@@ -311,7 +347,8 @@ type InstanceOf[T any] interface {
 // struct. T must be a component type. Service Weaver will automatically
 // fill such a field with a handle to the corresponding component.
 type Ref[T any] struct {
-	value T
+	value    T
+	replicas func() ([]any, error) // see Broadcast; nil if unsupported
 }
 
 // Get returns a handle to the component of type T.
@@ -321,9 +358,11 @@ func (r Ref[T]) Get() T { return r.value }
 // used internally to check that a value is of type Ref[T].
 func (r Ref[T]) isRef() {}
 
-// setRef sets the underlying value of a Ref.
-func (r *Ref[T]) setRef(value any) {
+// setRef sets the underlying value of a Ref and the function that returns
+// its per-replica handles.
+func (r *Ref[T]) setRef(value any, replicas func() ([]any, error)) {
 	r.value = value.(T)
+	r.replicas = replicas
 }
 
 // Listener is a network listener that can be placed as a field inside a
@@ -525,6 +564,11 @@ type WithRouter[T any] struct{}
 //lint:ignore U1000 routedBy is used by RoutedBy and Unrouted.
 func (WithRouter[T]) routedBy(T) {}
 
+// routerType returns T. See Components.
+func (WithRouter[T]) routerType() reflect.Type {
+	return reflection.Type[T]()
+}
+
 // RoutedBy[T] is the interface implemented by a struct that embeds
 // weaver.RoutedBy[T].
 type RoutedBy[T any] interface {
@@ -581,4 +625,213 @@ type AutoMarshal struct{}
 func (AutoMarshal) WeaverMarshal(*codegen.Encoder)   {}
 func (AutoMarshal) WeaverUnmarshal(*codegen.Decoder) {}
 
+// Identity identifies the caller of a component method.
+type Identity struct {
+	// Component is the full name of the calling component (e.g.,
+	// "github.com/example/sandy/Jelly").
+	Component string
+
+	// Version is the deployment id of the application version the caller
+	// runs. It is empty if the caller runs in the same process as the callee,
+	// in which case both run the same version.
+	Version string
+}
+
+// CallerIdentity returns the identity of the component that called the
+// component method with the provided context. The identity is recorded by the
+// generated stubs and by the RPC transport, so components can use it for
+// authorization and auditing. Calls made by the main component have the
+// identity of the main component. CallerIdentity returns false if ctx was not
+// passed to a component method by Service Weaver, e.g., if a method of a
+// component implementation is called directly.
+//
+// When mTLS is enabled, remote calls are only accepted from weavelets of the
+// same deployment that are allowed to call the component.
+func CallerIdentity(ctx context.Context) (Identity, bool) {
+	c, ok := codegen.CallerFromContext(ctx)
+	if !ok {
+		return Identity{}, false
+	}
+	return Identity{Component: c.Component, Version: c.Version}, true
+}
+
+var (
+	// Equivalence checks with the struct in internal/weaver/types.go.
+	_ = Replica(weaver.ReplicaInfo{})
+	_ = weaver.ReplicaInfo(Replica{})
+)
+
+// Replica describes a replica of a colocation group, i.e., a weavelet, and
+// thus a replica of every component in the group.
+type Replica struct {
+	// Index is the index of the replica among the replicas of its group, in
+	// the range [0, n) where n is the number of replicas the deployer runs.
+	// Deployers that replace replicas may reuse indices; use WeaveletID to
+	// tell replicas apart.
+	Index int
+
+	// WeaveletID is a globally unique id of the replica.
+	WeaveletID string
+
+	// Group is the name of the colocation group, as chosen by the deployer.
+	// It is empty if the deployer doesn't report it.
+	Group string
+
+	// Version is the deployment id of the application version the replica
+	// runs.
+	Version string
+
+	// Region and Zone are the region and zone of the machine running the
+	// replica. They are empty if the deployer doesn't report them.
+	Region string
+	Zone   string
+}
+
+// ReplicaInfo returns information about the replica running the component
+// method, Init method, or main function that received ctx. It is useful to
+// split background work among replicas or to label logs, e.g.:
+//
+//	r, _ := weaver.ReplicaInfo(ctx)
+//	if r.Index == 0 {
+//	    // Only the first replica runs the periodic cleanup.
+//	}
+//
+// ReplicaInfo returns false if ctx was not passed by Service Weaver, e.g., if
+// it is a new context created by the component. Calls to co-located
+// components carry the caller's context, so they report the replica only if
+// the caller's context does. Under the simulator, every
+// component runs in a group of its own, and ReplicaInfo reports the simulated
+// replica that handles the call.
+func ReplicaInfo(ctx context.Context) (Replica, bool) {
+	info, ok := weaver.ReplicaInfoFromContext(ctx)
+	return Replica(info), ok
+}
+
+// WithIdempotencyKey returns a context that carries the provided idempotency
+// key. Remote component method calls made with the returned context are
+// deduplicated: if the replica that handles a call has already handled a call
+// to the same method with the same key within the idempotency window (see the
+// idempotency_window config field), it returns the result of the earlier call
+// rather than running the method again. A call that arrives while an earlier
+// call with the same key is still running waits for its result. A call with
+// the same key as an earlier call but different arguments fails.
+//
+// This makes it safe to retry calls to methods that mutate state, like
+// methods marked NotRetriable. Service Weaver retries such calls
+// automatically when they carry an idempotency key and the retry reaches the
+// same replica, i.e., when the call is to a routed method. For example:
+//
+//	ctx = weaver.WithIdempotencyKey(ctx, paymentID)
+//	err := payments.Charge(ctx, paymentID, amount)
+//
+// Keys are scoped to a component method, and they should uniquely identify an
+// operation, e.g., by way of a client-generated request id. Calls are
+// deduplicated by the replica that handles them. Calls to a routed method
+// with the same routing key are usually handled by the same replica, but a
+// retry that lands on a different replica is not deduplicated. Calls to
+// colocated components are not deduplicated either, and calls that fail
+// (e.g., because the caller's context was cancelled) are forgotten, so
+// retrying them runs the method again.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return codegen.WithIdempotencyKey(ctx, key)
+}
+
+// Priority is the priority of a component method call. See WithPriority.
+type Priority int
+
+const (
+	PriorityLow    Priority = scheduling.Low
+	PriorityNormal Priority = scheduling.Normal
+	PriorityHigh   Priority = scheduling.High
+)
+
+// WithPriority returns a context that carries the provided call priority.
+// When a replica bounds the number of calls it executes at a time (see the
+// max_concurrent_calls field of the [scheduling] config section), remote
+// component method calls made with the returned context are admitted before
+// waiting calls with a lower priority, and after waiting calls with a higher
+// one. Calls with the same priority are admitted in order of deadline. For
+// example:
+//
+//	ctx = weaver.WithPriority(ctx, weaver.PriorityHigh)
+//	value, err := cache.Get(ctx, key)
+//
+// A call made without a priority has the priority of its method in the
+// config, or PriorityNormal. The calls that a method makes with the context
+// it was called with inherit the priority of the call.
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return codegen.WithPriority(ctx, int(p))
+}
+
+// WithHedgingBudget returns a context that allows the remote component method
+// calls made with it, and the calls they make in turn, to hedge at most n
+// calls in total (see the [hedging] config section). By default, a request
+// hedges at most as many calls as the budget field of the [hedging] config
+// section allows, or 10. For example, to disable hedging for a request:
+//
+//	ctx = weaver.WithHedgingBudget(ctx, 0)
+func WithHedgingBudget(ctx context.Context, n int) context.Context {
+	return codegen.WithHedgingBudget(ctx, codegen.NewHedgingBudget(n))
+}
+
+// WithProgress returns a context that carries the provided progress function.
+// Remote component method calls made with the returned context call progress
+// while they serialize a slice or map argument, or deserialize a slice or map
+// result, with thousands of elements. progress is called with the number of
+// elements processed so far and the total number of elements in the slice or
+// map, every few thousand elements, or only once for slices of fixed-size
+// numeric types, which are serialized with a single copy. For example:
+//
+//	ctx = weaver.WithProgress(ctx, func(done, total int) {
+//	    logger.Debug("Upload", "done", done, "total", total)
+//	})
+//	err := storage.Put(ctx, records)
+//
+// progress is called from the goroutine making the call. It is not called for
+// small slices and maps, or for calls to colocated components, whose
+// arguments and results are not serialized.
+func WithProgress(ctx context.Context, progress func(done, total int)) context.Context {
+	return codegen.WithProgress(ctx, progress)
+}
+
+// Redactor is the interface implemented by types that hold sensitive data,
+// like passwords or credit card numbers. When Service Weaver formats a value
+// of a type that implements Redactor for logs or simulator histories, it
+// formats the result of Redact instead. For example:
+//
+//	type Password string
+//
+//	func (Password) Redact() any { return "********" }
+//
+// Alternatively, individual struct fields can be masked by tagging them with
+// `weaver:"redact"`:
+//
+//	type Payment struct {
+//	    weaver.AutoMarshal
+//	    Holder string
+//	    Card   string `weaver:"redact"`
+//	}
+//
+// Redaction only affects formatting. Method arguments and results are
+// delivered to components unchanged.
+type Redactor interface {
+	Redact() any
+}
+
 type NotRetriable interface{}
+
+// AtMostOnce marks a component method as at-most-once. Service Weaver never
+// retries calls to an at-most-once method, even if they carry an idempotency
+// key (see WithIdempotencyKey), and remote calls that fail with an unknown
+// outcome return an error that embeds MaybeCommitted. Use it for methods, like
+// financial transactions, that must not be executed twice:
+//
+//	type Payments interface {
+//	    Charge(ctx context.Context, id string, amount int) error
+//	}
+//
+//	var _ weaver.AtMostOnce = Payments.Charge
+//
+// Methods can also be marked at-most-once with the at_most_once field of the
+// [serviceweaver] section of the config file.
+type AtMostOnce interface{}
@@ -6,6 +6,7 @@ package weaver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"go.opentelemetry.io/otel/codes"
@@ -14,15 +15,169 @@ import (
 )
 
 func init() {
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/BlobStore",
+		Iface: reflect.TypeOf((*BlobStore)(nil)).Elem(),
+		Impl:  reflect.TypeOf(blobStore{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return blobStore_local_stub{impl: impl.(BlobStore), tracer: tracer, caller: codegen.Caller{Component: caller}, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Delete", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Get", Remote: false, Generated: true}), listMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "List", Remote: false, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Put", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return blobStore_client_stub{stub: stub, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Delete", Remote: true, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Get", Remote: true, Generated: true}), listMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "List", Remote: true, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/BlobStore", Method: "Put", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return blobStore_server_stub{impl: impl.(BlobStore), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return blobStore_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "05256f92ea39b820",
+	})
+	codegen.Register(codegen.Registration{
+		Name:  "github.com/ServiceWeaver/weaver/Cache",
+		Iface: reflect.TypeOf((*Cache)(nil)).Elem(),
+		Impl:  reflect.TypeOf(cache{}),
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return cache_local_stub{impl: impl.(Cache), tracer: tracer, caller: codegen.Caller{Component: caller}, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Get", Remote: false, Generated: true}), invalidateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Invalidate", Remote: false, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Put", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return cache_client_stub{stub: stub, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Get", Remote: true, Generated: true}), invalidateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Invalidate", Remote: true, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Cache", Method: "Put", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return cache_server_stub{impl: impl.(Cache), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return cache_reflect_stub{caller: caller}
+		},
+		RefData:          "⟦646abb64:wEaVeReDgE:github.com/ServiceWeaver/weaver/Cache→github.com/ServiceWeaver/weaver/cacheShard⟧\n⟦46b2e6e1:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Cache.Get→github.com/ServiceWeaver/weaver/cacheShard.Get@cache.go:153:9⟧\n⟦85dc8771:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Cache.Invalidate→github.com/ServiceWeaver/weaver/cacheShard.Delete@cache.go:165:23⟧\n⟦4f0a1e09:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Cache.Put→github.com/ServiceWeaver/weaver/cacheShard.Put@cache.go:158:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "bb1e3905e7ca0c13",
+	})
+	codegen.Register(codegen.Registration{
+		Name:    "github.com/ServiceWeaver/weaver/Notifier",
+		Iface:   reflect.TypeOf((*Notifier)(nil)).Elem(),
+		Impl:    reflect.TypeOf(notifier{}),
+		NoRetry: []int{0},
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return notifier_local_stub{impl: impl.(Notifier), tracer: tracer, caller: codegen.Caller{Component: caller}, notifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Notifier", Method: "Notify", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return notifier_client_stub{stub: stub, notifyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Notifier", Method: "Notify", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return notifier_server_stub{impl: impl.(Notifier), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return notifier_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "d2e345b4b09dbb2f",
+	})
+	codegen.Register(codegen.Registration{
+		Name:    "github.com/ServiceWeaver/weaver/Quota",
+		Iface:   reflect.TypeOf((*Quota)(nil)).Elem(),
+		Impl:    reflect.TypeOf(quota{}),
+		NoRetry: []int{0},
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return quota_local_stub{impl: impl.(Quota), tracer: tracer, caller: codegen.Caller{Component: caller}, acquireMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Quota", Method: "Acquire", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return quota_client_stub{stub: stub, acquireMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/Quota", Method: "Acquire", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return quota_server_stub{impl: impl.(Quota), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return quota_reflect_stub{caller: caller}
+		},
+		RefData:          "⟦cce5a473:wEaVeReDgE:github.com/ServiceWeaver/weaver/Quota→github.com/ServiceWeaver/weaver/quotaServer⟧\n⟦4550ff10:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Quota.Acquire→github.com/ServiceWeaver/weaver/quotaServer.Grant@quota.go:176:23⟧\n⟦19bad06a:wEaVeRcAlL:github.com/ServiceWeaver/weaver/Quota.refill→github.com/ServiceWeaver/weaver/quotaServer.Grant@quota.go:195:23⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "10f1cfec224c00fb",
+	})
+	codegen.Register(codegen.Registration{
+		Name:    "github.com/ServiceWeaver/weaver/ReplicatedStateMachine",
+		Iface:   reflect.TypeOf((*ReplicatedStateMachine)(nil)).Elem(),
+		Impl:    reflect.TypeOf(replicatedStateMachine{}),
+		Routed:  true,
+		NoRetry: []int{0},
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return replicatedStateMachine_local_stub{impl: impl.(ReplicatedStateMachine), tracer: tracer, caller: codegen.Caller{Component: caller}, applyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Apply", Remote: false, Generated: true}), queryMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Query", Remote: false, Generated: true}), raftMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Raft", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return replicatedStateMachine_client_stub{stub: stub, applyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Apply", Remote: true, Generated: true}), queryMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Query", Remote: true, Generated: true}), raftMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicatedStateMachine", Method: "Raft", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return replicatedStateMachine_server_stub{impl: impl.(ReplicatedStateMachine), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return replicatedStateMachine_reflect_stub{caller: caller}
+		},
+		RefData:          "⟦7f682d3e:wEaVeReDgE:github.com/ServiceWeaver/weaver/ReplicatedStateMachine→github.com/ServiceWeaver/weaver/ReplicatedStateMachine⟧\n⟦9f7d6ba4:wEaVeRcAlL:github.com/ServiceWeaver/weaver/ReplicatedStateMachine.Apply→github.com/ServiceWeaver/weaver/ReplicatedStateMachine.Apply@statemachine.go:365:11⟧\n⟦908141d0:wEaVeRcAlL:github.com/ServiceWeaver/weaver/ReplicatedStateMachine.Query→github.com/ServiceWeaver/weaver/ReplicatedStateMachine.Query@statemachine.go:397:11⟧\n⟦f7e2b8a9:wEaVeRcAlL:github.com/ServiceWeaver/weaver/ReplicatedStateMachine.discover→github.com/ServiceWeaver/weaver/ReplicatedStateMachine.Raft@statemachine.go:516:15⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "4dac160da861b782",
+	})
+	codegen.Register(codegen.Registration{
+		Name:    "github.com/ServiceWeaver/weaver/ReplicationLog",
+		Iface:   reflect.TypeOf((*ReplicationLog)(nil)).Elem(),
+		Impl:    reflect.TypeOf(replicationLog{}),
+		Routed:  true,
+		NoRetry: []int{0},
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return replicationLog_local_stub{impl: impl.(ReplicationLog), tracer: tracer, caller: codegen.Caller{Component: caller}, appendMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Append", Remote: false, Generated: true}), checkpointMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Checkpoint", Remote: false, Generated: true}), fetchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Fetch", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return replicationLog_client_stub{stub: stub, appendMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Append", Remote: true, Generated: true}), checkpointMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Checkpoint", Remote: true, Generated: true}), fetchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/ReplicationLog", Method: "Fetch", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return replicationLog_server_stub{impl: impl.(ReplicationLog), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return replicationLog_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "fbb31f844b992303",
+	})
+	codegen.Register(codegen.Registration{
+		Name:   "github.com/ServiceWeaver/weaver/cacheShard",
+		Iface:  reflect.TypeOf((*cacheShard)(nil)).Elem(),
+		Impl:   reflect.TypeOf(cacheStore{}),
+		Routed: true,
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return cacheShard_local_stub{impl: impl.(cacheShard), tracer: tracer, caller: codegen.Caller{Component: caller}, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Delete", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Get", Remote: false, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Put", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return cacheShard_client_stub{stub: stub, deleteMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Delete", Remote: true, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Get", Remote: true, Generated: true}), putMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/cacheShard", Method: "Put", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return cacheShard_server_stub{impl: impl.(cacheShard), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return cacheShard_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "f02ecc9611c5f455",
+	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/deployerControl",
 		Iface: reflect.TypeOf((*deployerControl)(nil)).Elem(),
 		Impl:  reflect.TypeOf(localDeployerControl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return deployerControl_local_stub{impl: impl.(deployerControl), tracer: tracer, activateComponentMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ActivateComponent", Remote: false, Generated: true}), exportListenerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ExportListener", Remote: false, Generated: true}), getListenerAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetListenerAddress", Remote: false, Generated: true}), getSelfCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetSelfCertificate", Remote: false, Generated: true}), handleTraceSpansMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "HandleTraceSpans", Remote: false, Generated: true}), logBatchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "LogBatch", Remote: false, Generated: true}), verifyClientCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyClientCertificate", Remote: false, Generated: true}), verifyServerCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyServerCertificate", Remote: false, Generated: true})}
+			return deployerControl_local_stub{impl: impl.(deployerControl), tracer: tracer, caller: codegen.Caller{Component: caller}, activateComponentMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ActivateComponent", Remote: false, Generated: true}), exportListenerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ExportListener", Remote: false, Generated: true}), getListenerAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetListenerAddress", Remote: false, Generated: true}), getSelfCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetSelfCertificate", Remote: false, Generated: true}), getTopologyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetTopology", Remote: false, Generated: true}), handleTraceSpansMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "HandleTraceSpans", Remote: false, Generated: true}), logBatchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "LogBatch", Remote: false, Generated: true}), verifyClientCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyClientCertificate", Remote: false, Generated: true}), verifyServerCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyServerCertificate", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return deployerControl_client_stub{stub: stub, activateComponentMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ActivateComponent", Remote: true, Generated: true}), exportListenerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ExportListener", Remote: true, Generated: true}), getListenerAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetListenerAddress", Remote: true, Generated: true}), getSelfCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetSelfCertificate", Remote: true, Generated: true}), handleTraceSpansMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "HandleTraceSpans", Remote: true, Generated: true}), logBatchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "LogBatch", Remote: true, Generated: true}), verifyClientCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyClientCertificate", Remote: true, Generated: true}), verifyServerCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyServerCertificate", Remote: true, Generated: true})}
+			return deployerControl_client_stub{stub: stub, activateComponentMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ActivateComponent", Remote: true, Generated: true}), exportListenerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "ExportListener", Remote: true, Generated: true}), getListenerAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetListenerAddress", Remote: true, Generated: true}), getSelfCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetSelfCertificate", Remote: true, Generated: true}), getTopologyMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "GetTopology", Remote: true, Generated: true}), handleTraceSpansMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "HandleTraceSpans", Remote: true, Generated: true}), logBatchMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "LogBatch", Remote: true, Generated: true}), verifyClientCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyClientCertificate", Remote: true, Generated: true}), verifyServerCertificateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/deployerControl", Method: "VerifyServerCertificate", Remote: true, Generated: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return deployerControl_server_stub{impl: impl.(deployerControl), addLoad: addLoad}
@@ -30,17 +185,43 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return deployerControl_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "092ee0600e6b089e",
+	})
+	codegen.Register(codegen.Registration{
+		Name:    "github.com/ServiceWeaver/weaver/quotaServer",
+		Iface:   reflect.TypeOf((*quotaServer)(nil)).Elem(),
+		Impl:    reflect.TypeOf(quotaCounter{}),
+		Routed:  true,
+		NoRetry: []int{0},
+		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
+			return quotaServer_local_stub{impl: impl.(quotaServer), tracer: tracer, caller: codegen.Caller{Component: caller}, grantMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/quotaServer", Method: "Grant", Remote: false, Generated: true})}
+		},
+		ClientStubFn: func(stub codegen.Stub, caller string) any {
+			return quotaServer_client_stub{stub: stub, grantMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/quotaServer", Method: "Grant", Remote: true, Generated: true})}
+		},
+		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
+			return quotaServer_server_stub{impl: impl.(quotaServer), addLoad: addLoad}
+		},
+		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
+			return quotaServer_reflect_stub{caller: caller}
+		},
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "591a52af2a50224b",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/weaveletControl",
 		Iface: reflect.TypeOf((*weaveletControl)(nil)).Elem(),
 		Impl:  reflect.TypeOf(noopWeaveletControl{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return weaveletControl_local_stub{impl: impl.(weaveletControl), tracer: tracer, getHealthMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetHealth", Remote: false, Generated: true}), getLoadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetLoad", Remote: false, Generated: true}), getMetricsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetMetrics", Remote: false, Generated: true}), getProfileMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetProfile", Remote: false, Generated: true}), initWeaveletMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "InitWeavelet", Remote: false, Generated: true}), updateComponentsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateComponents", Remote: false, Generated: true}), updateRoutingInfoMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateRoutingInfo", Remote: false, Generated: true})}
+			return weaveletControl_local_stub{impl: impl.(weaveletControl), tracer: tracer, caller: codegen.Caller{Component: caller}, getFlightRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetFlightRecord", Remote: false, Generated: true}), getHealthMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetHealth", Remote: false, Generated: true}), getLoadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetLoad", Remote: false, Generated: true}), getMetricsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetMetrics", Remote: false, Generated: true}), getProfileMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetProfile", Remote: false, Generated: true}), initWeaveletMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "InitWeavelet", Remote: false, Generated: true}), notifyDeploymentEventMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "NotifyDeploymentEvent", Remote: false, Generated: true}), updateComponentsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateComponents", Remote: false, Generated: true}), updateRoutingInfoMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateRoutingInfo", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return weaveletControl_client_stub{stub: stub, getHealthMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetHealth", Remote: true, Generated: true}), getLoadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetLoad", Remote: true, Generated: true}), getMetricsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetMetrics", Remote: true, Generated: true}), getProfileMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetProfile", Remote: true, Generated: true}), initWeaveletMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "InitWeavelet", Remote: true, Generated: true}), updateComponentsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateComponents", Remote: true, Generated: true}), updateRoutingInfoMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateRoutingInfo", Remote: true, Generated: true})}
+			return weaveletControl_client_stub{stub: stub, getFlightRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetFlightRecord", Remote: true, Generated: true}), getHealthMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetHealth", Remote: true, Generated: true}), getLoadMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetLoad", Remote: true, Generated: true}), getMetricsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetMetrics", Remote: true, Generated: true}), getProfileMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "GetProfile", Remote: true, Generated: true}), initWeaveletMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "InitWeavelet", Remote: true, Generated: true}), notifyDeploymentEventMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "NotifyDeploymentEvent", Remote: true, Generated: true}), updateComponentsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateComponents", Remote: true, Generated: true}), updateRoutingInfoMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weaveletControl", Method: "UpdateRoutingInfo", Remote: true, Generated: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return weaveletControl_server_stub{impl: impl.(weaveletControl), addLoad: addLoad}
@@ -48,44 +229,88 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return weaveletControl_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "49ff17a10e0bb22c",
 	})
 }
 
 // weaver.InstanceOf checks.
+var _ InstanceOf[BlobStore] = (*blobStore)(nil)
+var _ InstanceOf[Cache] = (*cache)(nil)
+var _ InstanceOf[Notifier] = (*notifier)(nil)
+var _ InstanceOf[Quota] = (*quota)(nil)
+var _ InstanceOf[ReplicatedStateMachine] = (*replicatedStateMachine)(nil)
+var _ InstanceOf[ReplicationLog] = (*replicationLog)(nil)
+var _ InstanceOf[cacheShard] = (*cacheStore)(nil)
 var _ InstanceOf[deployerControl] = (*localDeployerControl)(nil)
+var _ InstanceOf[quotaServer] = (*quotaCounter)(nil)
 var _ InstanceOf[weaveletControl] = (*noopWeaveletControl)(nil)
 
 // weaver.Router checks.
+var _ Unrouted = (*blobStore)(nil)
+var _ Unrouted = (*cache)(nil)
+var _ Unrouted = (*notifier)(nil)
+var _ Unrouted = (*quota)(nil)
+var _ RoutedBy[rsmRouter] = (*replicatedStateMachine)(nil)
+var _ RoutedBy[replicationRouter] = (*replicationLog)(nil)
+var _ RoutedBy[cacheRouter] = (*cacheStore)(nil)
 var _ Unrouted = (*localDeployerControl)(nil)
+var _ RoutedBy[quotaRouter] = (*quotaCounter)(nil)
 var _ Unrouted = (*noopWeaveletControl)(nil)
 
+// Component "replicatedStateMachine", router "rsmRouter" checks.
+type __replicatedStateMachine_rsmRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate struct {
+	rsmRouter
+	__replicatedStateMachine_rsmRouter_embedding
+}
+
+type __replicatedStateMachine_rsmRouter_embedding struct{}
+
+func (__replicatedStateMachine_rsmRouter_embedding) Raft() {}
+
+var _ func(_ context.Context, machine string, _ []byte) string = (&rsmRouter{}).Apply                                // routed
+var _ func(_ context.Context, machine string, _ []byte) string = (&rsmRouter{}).Query                                // routed
+var _ = (&__replicatedStateMachine_rsmRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Raft // unrouted
+// Component "replicationLog", router "replicationRouter" checks.
+var _ func(_ context.Context, name string, _ ReplicationEntry) string = (&replicationRouter{}).Append               // routed
+var _ func(_ context.Context, name string, _ string, _ uint64) string = (&replicationRouter{}).Fetch                // routed
+var _ func(_ context.Context, name string, _ string, _ uint64, _ []byte) string = (&replicationRouter{}).Checkpoint // routed
+// Component "cacheStore", router "cacheRouter" checks.
+var _ func(_ context.Context, key string) string = (&cacheRouter{}).Delete                        // routed
+var _ func(_ context.Context, key string) string = (&cacheRouter{}).Get                           // routed
+var _ func(_ context.Context, key string, _ []byte, _ int64, _ int) string = (&cacheRouter{}).Put // routed
+// Component "quotaCounter", router "quotaRouter" checks.
+var _ func(_ context.Context, name string, key string, _ int, _ int64, _ int) string = (&quotaRouter{}).Grant // routed
+
 // Local stub implementations.
 
-type deployerControl_local_stub struct {
-	impl                           deployerControl
-	tracer                         trace.Tracer
-	activateComponentMetrics       *codegen.MethodMetrics
-	exportListenerMetrics          *codegen.MethodMetrics
-	getListenerAddressMetrics      *codegen.MethodMetrics
-	getSelfCertificateMetrics      *codegen.MethodMetrics
-	handleTraceSpansMetrics        *codegen.MethodMetrics
-	logBatchMetrics                *codegen.MethodMetrics
-	verifyClientCertificateMetrics *codegen.MethodMetrics
-	verifyServerCertificateMetrics *codegen.MethodMetrics
+type blobStore_local_stub struct {
+	impl          BlobStore
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	deleteMetrics *codegen.MethodMetrics
+	getMetrics    *codegen.MethodMetrics
+	listMetrics   *codegen.MethodMetrics
+	putMetrics    *codegen.MethodMetrics
 }
 
-// Check that deployerControl_local_stub implements the deployerControl interface.
-var _ deployerControl = (*deployerControl_local_stub)(nil)
+// Check that blobStore_local_stub implements the BlobStore interface.
+var _ BlobStore = (*blobStore_local_stub)(nil)
 
-func (s deployerControl_local_stub) ActivateComponent(ctx context.Context, a0 *protos.ActivateComponentRequest) (r0 *protos.ActivateComponentReply, err error) {
+func (s blobStore_local_stub) Delete(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
-	begin := s.activateComponentMetrics.Begin()
-	defer func() { s.activateComponentMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.ActivateComponent", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.BlobStore.Delete", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -95,17 +320,21 @@ func (s deployerControl_local_stub) ActivateComponent(ctx context.Context, a0 *p
 		}()
 	}
 
-	return s.impl.ActivateComponent(ctx, a0)
+	return s.impl.Delete(ctx, a0)
 }
 
-func (s deployerControl_local_stub) ExportListener(ctx context.Context, a0 *protos.ExportListenerRequest) (r0 *protos.ExportListenerReply, err error) {
+func (s blobStore_local_stub) Get(ctx context.Context, a0 string) (r0 []byte, err error) {
 	// Update metrics.
-	begin := s.exportListenerMetrics.Begin()
-	defer func() { s.exportListenerMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.ExportListener", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.BlobStore.Get", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -115,17 +344,21 @@ func (s deployerControl_local_stub) ExportListener(ctx context.Context, a0 *prot
 		}()
 	}
 
-	return s.impl.ExportListener(ctx, a0)
+	return s.impl.Get(ctx, a0)
 }
 
-func (s deployerControl_local_stub) GetListenerAddress(ctx context.Context, a0 *protos.GetListenerAddressRequest) (r0 *protos.GetListenerAddressReply, err error) {
+func (s blobStore_local_stub) List(ctx context.Context, a0 string) (r0 []string, err error) {
 	// Update metrics.
-	begin := s.getListenerAddressMetrics.Begin()
-	defer func() { s.getListenerAddressMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.listMetrics.Begin()
+	defer func() { s.listMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.GetListenerAddress", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.BlobStore.List", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -135,17 +368,21 @@ func (s deployerControl_local_stub) GetListenerAddress(ctx context.Context, a0 *
 		}()
 	}
 
-	return s.impl.GetListenerAddress(ctx, a0)
+	return s.impl.List(ctx, a0)
 }
 
-func (s deployerControl_local_stub) GetSelfCertificate(ctx context.Context, a0 *protos.GetSelfCertificateRequest) (r0 *protos.GetSelfCertificateReply, err error) {
+func (s blobStore_local_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
 	// Update metrics.
-	begin := s.getSelfCertificateMetrics.Begin()
-	defer func() { s.getSelfCertificateMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.GetSelfCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.BlobStore.Put", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -155,17 +392,33 @@ func (s deployerControl_local_stub) GetSelfCertificate(ctx context.Context, a0 *
 		}()
 	}
 
-	return s.impl.GetSelfCertificate(ctx, a0)
+	return s.impl.Put(ctx, a0, a1)
 }
 
-func (s deployerControl_local_stub) HandleTraceSpans(ctx context.Context, a0 *protos.TraceSpans) (err error) {
+type cache_local_stub struct {
+	impl              Cache
+	tracer            trace.Tracer
+	caller            codegen.Caller
+	getMetrics        *codegen.MethodMetrics
+	invalidateMetrics *codegen.MethodMetrics
+	putMetrics        *codegen.MethodMetrics
+}
+
+// Check that cache_local_stub implements the Cache interface.
+var _ Cache = (*cache_local_stub)(nil)
+
+func (s cache_local_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
 	// Update metrics.
-	begin := s.handleTraceSpansMetrics.Begin()
-	defer func() { s.handleTraceSpansMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.HandleTraceSpans", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.Cache.Get", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -175,17 +428,21 @@ func (s deployerControl_local_stub) HandleTraceSpans(ctx context.Context, a0 *pr
 		}()
 	}
 
-	return s.impl.HandleTraceSpans(ctx, a0)
+	return s.impl.Get(ctx, a0)
 }
 
-func (s deployerControl_local_stub) LogBatch(ctx context.Context, a0 *protos.LogEntryBatch) (err error) {
+func (s cache_local_stub) Invalidate(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
-	begin := s.logBatchMetrics.Begin()
-	defer func() { s.logBatchMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.invalidateMetrics.Begin()
+	defer func() { s.invalidateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.LogBatch", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.Cache.Invalidate", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -195,17 +452,21 @@ func (s deployerControl_local_stub) LogBatch(ctx context.Context, a0 *protos.Log
 		}()
 	}
 
-	return s.impl.LogBatch(ctx, a0)
+	return s.impl.Invalidate(ctx, a0)
 }
 
-func (s deployerControl_local_stub) VerifyClientCertificate(ctx context.Context, a0 *protos.VerifyClientCertificateRequest) (r0 *protos.VerifyClientCertificateReply, err error) {
+func (s cache_local_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
 	// Update metrics.
-	begin := s.verifyClientCertificateMetrics.Begin()
-	defer func() { s.verifyClientCertificateMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.VerifyClientCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.Cache.Put", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -215,17 +476,31 @@ func (s deployerControl_local_stub) VerifyClientCertificate(ctx context.Context,
 		}()
 	}
 
-	return s.impl.VerifyClientCertificate(ctx, a0)
+	return s.impl.Put(ctx, a0, a1)
 }
 
-func (s deployerControl_local_stub) VerifyServerCertificate(ctx context.Context, a0 *protos.VerifyServerCertificateRequest) (r0 *protos.VerifyServerCertificateReply, err error) {
+type notifier_local_stub struct {
+	impl          Notifier
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	notifyMetrics *codegen.MethodMetrics
+}
+
+// Check that notifier_local_stub implements the Notifier interface.
+var _ Notifier = (*notifier_local_stub)(nil)
+
+func (s notifier_local_stub) Notify(ctx context.Context, a0 Notification) (err error) {
 	// Update metrics.
-	begin := s.verifyServerCertificateMetrics.Begin()
-	defer func() { s.verifyServerCertificateMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.notifyMetrics.Begin()
+	defer func() { s.notifyMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.VerifyServerCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.Notifier.Notify", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -235,32 +510,31 @@ func (s deployerControl_local_stub) VerifyServerCertificate(ctx context.Context,
 		}()
 	}
 
-	return s.impl.VerifyServerCertificate(ctx, a0)
+	return s.impl.Notify(ctx, a0)
 }
 
-type weaveletControl_local_stub struct {
-	impl                     weaveletControl
-	tracer                   trace.Tracer
-	getHealthMetrics         *codegen.MethodMetrics
-	getLoadMetrics           *codegen.MethodMetrics
-	getMetricsMetrics        *codegen.MethodMetrics
-	getProfileMetrics        *codegen.MethodMetrics
-	initWeaveletMetrics      *codegen.MethodMetrics
-	updateComponentsMetrics  *codegen.MethodMetrics
-	updateRoutingInfoMetrics *codegen.MethodMetrics
+type quota_local_stub struct {
+	impl           Quota
+	tracer         trace.Tracer
+	caller         codegen.Caller
+	acquireMetrics *codegen.MethodMetrics
 }
 
-// Check that weaveletControl_local_stub implements the weaveletControl interface.
-var _ weaveletControl = (*weaveletControl_local_stub)(nil)
+// Check that quota_local_stub implements the Quota interface.
+var _ Quota = (*quota_local_stub)(nil)
 
-func (s weaveletControl_local_stub) GetHealth(ctx context.Context, a0 *protos.GetHealthRequest) (r0 *protos.GetHealthReply, err error) {
+func (s quota_local_stub) Acquire(ctx context.Context, a0 string, a1 string, a2 int) (r0 bool, err error) {
 	// Update metrics.
-	begin := s.getHealthMetrics.Begin()
-	defer func() { s.getHealthMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.acquireMetrics.Begin()
+	defer func() { s.acquireMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetHealth", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.Quota.Acquire", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -270,17 +544,33 @@ func (s weaveletControl_local_stub) GetHealth(ctx context.Context, a0 *protos.Ge
 		}()
 	}
 
-	return s.impl.GetHealth(ctx, a0)
+	return s.impl.Acquire(ctx, a0, a1, a2)
 }
 
-func (s weaveletControl_local_stub) GetLoad(ctx context.Context, a0 *protos.GetLoadRequest) (r0 *protos.GetLoadReply, err error) {
+type replicatedStateMachine_local_stub struct {
+	impl         ReplicatedStateMachine
+	tracer       trace.Tracer
+	caller       codegen.Caller
+	applyMetrics *codegen.MethodMetrics
+	queryMetrics *codegen.MethodMetrics
+	raftMetrics  *codegen.MethodMetrics
+}
+
+// Check that replicatedStateMachine_local_stub implements the ReplicatedStateMachine interface.
+var _ ReplicatedStateMachine = (*replicatedStateMachine_local_stub)(nil)
+
+func (s replicatedStateMachine_local_stub) Apply(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
 	// Update metrics.
-	begin := s.getLoadMetrics.Begin()
-	defer func() { s.getLoadMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.applyMetrics.Begin()
+	defer func() { s.applyMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetLoad", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicatedStateMachine.Apply", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -290,17 +580,21 @@ func (s weaveletControl_local_stub) GetLoad(ctx context.Context, a0 *protos.GetL
 		}()
 	}
 
-	return s.impl.GetLoad(ctx, a0)
+	return s.impl.Apply(ctx, a0, a1)
 }
 
-func (s weaveletControl_local_stub) GetMetrics(ctx context.Context, a0 *protos.GetMetricsRequest) (r0 *protos.GetMetricsReply, err error) {
+func (s replicatedStateMachine_local_stub) Query(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
 	// Update metrics.
-	begin := s.getMetricsMetrics.Begin()
-	defer func() { s.getMetricsMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.queryMetrics.Begin()
+	defer func() { s.queryMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetMetrics", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicatedStateMachine.Query", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -310,17 +604,21 @@ func (s weaveletControl_local_stub) GetMetrics(ctx context.Context, a0 *protos.G
 		}()
 	}
 
-	return s.impl.GetMetrics(ctx, a0)
+	return s.impl.Query(ctx, a0, a1)
 }
 
-func (s weaveletControl_local_stub) GetProfile(ctx context.Context, a0 *protos.GetProfileRequest) (r0 *protos.GetProfileReply, err error) {
+func (s replicatedStateMachine_local_stub) Raft(ctx context.Context, a0 string, a1 []byte) (r0 string, err error) {
 	// Update metrics.
-	begin := s.getProfileMetrics.Begin()
-	defer func() { s.getProfileMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.raftMetrics.Begin()
+	defer func() { s.raftMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetProfile", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicatedStateMachine.Raft", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -330,17 +628,33 @@ func (s weaveletControl_local_stub) GetProfile(ctx context.Context, a0 *protos.G
 		}()
 	}
 
-	return s.impl.GetProfile(ctx, a0)
+	return s.impl.Raft(ctx, a0, a1)
 }
 
-func (s weaveletControl_local_stub) InitWeavelet(ctx context.Context, a0 *protos.InitWeaveletRequest) (r0 *protos.InitWeaveletReply, err error) {
+type replicationLog_local_stub struct {
+	impl              ReplicationLog
+	tracer            trace.Tracer
+	caller            codegen.Caller
+	appendMetrics     *codegen.MethodMetrics
+	checkpointMetrics *codegen.MethodMetrics
+	fetchMetrics      *codegen.MethodMetrics
+}
+
+// Check that replicationLog_local_stub implements the ReplicationLog interface.
+var _ ReplicationLog = (*replicationLog_local_stub)(nil)
+
+func (s replicationLog_local_stub) Append(ctx context.Context, a0 string, a1 ReplicationEntry) (r0 string, r1 uint64, err error) {
 	// Update metrics.
-	begin := s.initWeaveletMetrics.Begin()
-	defer func() { s.initWeaveletMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.appendMetrics.Begin()
+	defer func() { s.appendMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.InitWeavelet", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicationLog.Append", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -350,17 +664,21 @@ func (s weaveletControl_local_stub) InitWeavelet(ctx context.Context, a0 *protos
 		}()
 	}
 
-	return s.impl.InitWeavelet(ctx, a0)
+	return s.impl.Append(ctx, a0, a1)
 }
 
-func (s weaveletControl_local_stub) UpdateComponents(ctx context.Context, a0 *protos.UpdateComponentsRequest) (r0 *protos.UpdateComponentsReply, err error) {
+func (s replicationLog_local_stub) Checkpoint(ctx context.Context, a0 string, a1 string, a2 uint64, a3 []byte) (err error) {
 	// Update metrics.
-	begin := s.updateComponentsMetrics.Begin()
-	defer func() { s.updateComponentsMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.checkpointMetrics.Begin()
+	defer func() { s.checkpointMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.UpdateComponents", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicationLog.Checkpoint", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -370,17 +688,21 @@ func (s weaveletControl_local_stub) UpdateComponents(ctx context.Context, a0 *pr
 		}()
 	}
 
-	return s.impl.UpdateComponents(ctx, a0)
+	return s.impl.Checkpoint(ctx, a0, a1, a2, a3)
 }
 
-func (s weaveletControl_local_stub) UpdateRoutingInfo(ctx context.Context, a0 *protos.UpdateRoutingInfoRequest) (r0 *protos.UpdateRoutingInfoReply, err error) {
+func (s replicationLog_local_stub) Fetch(ctx context.Context, a0 string, a1 string, a2 uint64) (r0 ReplicationBatch, err error) {
 	// Update metrics.
-	begin := s.updateRoutingInfoMetrics.Begin()
-	defer func() { s.updateRoutingInfoMetrics.End(begin, err != nil, 0, 0) }()
+	begin := s.fetchMetrics.Begin()
+	defer func() { s.fetchMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.UpdateRoutingInfo", trace.WithSpanKind(trace.SpanKindInternal))
+		ctx, span = s.tracer.Start(ctx, "weaver.ReplicationLog.Fetch", trace.WithSpanKind(trace.SpanKindInternal))
 		defer func() {
 			if err != nil {
 				span.RecordError(err)
@@ -390,508 +712,619 @@ func (s weaveletControl_local_stub) UpdateRoutingInfo(ctx context.Context, a0 *p
 		}()
 	}
 
-	return s.impl.UpdateRoutingInfo(ctx, a0)
+	return s.impl.Fetch(ctx, a0, a1, a2)
 }
 
-// Client stub implementations.
-
-type deployerControl_client_stub struct {
-	stub                           codegen.Stub
-	activateComponentMetrics       *codegen.MethodMetrics
-	exportListenerMetrics          *codegen.MethodMetrics
-	getListenerAddressMetrics      *codegen.MethodMetrics
-	getSelfCertificateMetrics      *codegen.MethodMetrics
-	handleTraceSpansMetrics        *codegen.MethodMetrics
-	logBatchMetrics                *codegen.MethodMetrics
-	verifyClientCertificateMetrics *codegen.MethodMetrics
-	verifyServerCertificateMetrics *codegen.MethodMetrics
+type cacheShard_local_stub struct {
+	impl          cacheShard
+	tracer        trace.Tracer
+	caller        codegen.Caller
+	deleteMetrics *codegen.MethodMetrics
+	getMetrics    *codegen.MethodMetrics
+	putMetrics    *codegen.MethodMetrics
 }
 
-// Check that deployerControl_client_stub implements the deployerControl interface.
-var _ deployerControl = (*deployerControl_client_stub)(nil)
+// Check that cacheShard_local_stub implements the cacheShard interface.
+var _ cacheShard = (*cacheShard_local_stub)(nil)
 
-func (s deployerControl_client_stub) ActivateComponent(ctx context.Context, a0 *protos.ActivateComponentRequest) (r0 *protos.ActivateComponentReply, err error) {
+func (s cacheShard_local_stub) Delete(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
-	begin := s.activateComponentMetrics.Begin()
-	defer func() { s.activateComponentMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.ActivateComponent", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.cacheShard.Delete", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.Delete(ctx, a0)
+}
 
-	}()
+func (s cacheShard_local_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
+	// Update metrics.
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_ActivateComponentRequest_73adf343(enc, a0)
-	var shardKey uint64
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.cacheShard.Get", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_ActivateComponentReply_5e57d605(dec)
-	err = dec.Error()
-	return
+	return s.impl.Get(ctx, a0)
 }
 
-func (s deployerControl_client_stub) ExportListener(ctx context.Context, a0 *protos.ExportListenerRequest) (r0 *protos.ExportListenerReply, err error) {
+func (s cacheShard_local_stub) Put(ctx context.Context, a0 string, a1 []byte, a2 int64, a3 int) (err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
-	begin := s.exportListenerMetrics.Begin()
-	defer func() { s.exportListenerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.ExportListener", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.cacheShard.Put", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.Put(ctx, a0, a1, a2, a3)
+}
 
-	}()
+type deployerControl_local_stub struct {
+	impl                           deployerControl
+	tracer                         trace.Tracer
+	caller                         codegen.Caller
+	activateComponentMetrics       *codegen.MethodMetrics
+	exportListenerMetrics          *codegen.MethodMetrics
+	getListenerAddressMetrics      *codegen.MethodMetrics
+	getSelfCertificateMetrics      *codegen.MethodMetrics
+	getTopologyMetrics             *codegen.MethodMetrics
+	handleTraceSpansMetrics        *codegen.MethodMetrics
+	logBatchMetrics                *codegen.MethodMetrics
+	verifyClientCertificateMetrics *codegen.MethodMetrics
+	verifyServerCertificateMetrics *codegen.MethodMetrics
+}
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_ExportListenerRequest_b494514e(enc, a0)
-	var shardKey uint64
+// Check that deployerControl_local_stub implements the deployerControl interface.
+var _ deployerControl = (*deployerControl_local_stub)(nil)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+func (s deployerControl_local_stub) ActivateComponent(ctx context.Context, a0 *protos.ActivateComponentRequest) (r0 *protos.ActivateComponentReply, err error) {
+	// Update metrics.
+	begin := s.activateComponentMetrics.Begin()
+	defer func() { s.activateComponentMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.ActivateComponent", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_ExportListenerReply_b0fc34d0(dec)
-	err = dec.Error()
-	return
+	return s.impl.ActivateComponent(ctx, a0)
 }
 
-func (s deployerControl_client_stub) GetListenerAddress(ctx context.Context, a0 *protos.GetListenerAddressRequest) (r0 *protos.GetListenerAddressReply, err error) {
+func (s deployerControl_local_stub) ExportListener(ctx context.Context, a0 *protos.ExportListenerRequest) (r0 *protos.ExportListenerReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
-	begin := s.getListenerAddressMetrics.Begin()
-	defer func() { s.getListenerAddressMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.exportListenerMetrics.Begin()
+	defer func() { s.exportListenerMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.GetListenerAddress", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.ExportListener", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.ExportListener(ctx, a0)
+}
 
-	}()
+func (s deployerControl_local_stub) GetListenerAddress(ctx context.Context, a0 *protos.GetListenerAddressRequest) (r0 *protos.GetListenerAddressReply, err error) {
+	// Update metrics.
+	begin := s.getListenerAddressMetrics.Begin()
+	defer func() { s.getListenerAddressMetrics.End(begin, err != nil, 0, 0) }()
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetListenerAddressRequest_5a58feb0(enc, a0)
-	var shardKey uint64
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.GetListenerAddress", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetListenerAddressReply_8bfe2caa(dec)
-	err = dec.Error()
-	return
+	return s.impl.GetListenerAddress(ctx, a0)
 }
 
-func (s deployerControl_client_stub) GetSelfCertificate(ctx context.Context, a0 *protos.GetSelfCertificateRequest) (r0 *protos.GetSelfCertificateReply, err error) {
+func (s deployerControl_local_stub) GetSelfCertificate(ctx context.Context, a0 *protos.GetSelfCertificateRequest) (r0 *protos.GetSelfCertificateReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.getSelfCertificateMetrics.Begin()
-	defer func() { s.getSelfCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.getSelfCertificateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.GetSelfCertificate", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.GetSelfCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.GetSelfCertificate(ctx, a0)
+}
 
-	}()
+func (s deployerControl_local_stub) GetTopology(ctx context.Context, a0 *protos.GetTopologyRequest) (r0 *protos.GetTopologyReply, err error) {
+	// Update metrics.
+	begin := s.getTopologyMetrics.Begin()
+	defer func() { s.getTopologyMetrics.End(begin, err != nil, 0, 0) }()
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetSelfCertificateRequest_0de4e3b4(enc, a0)
-	var shardKey uint64
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.GetTopology", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetSelfCertificateReply_12277ec8(dec)
-	err = dec.Error()
-	return
+	return s.impl.GetTopology(ctx, a0)
 }
 
-func (s deployerControl_client_stub) HandleTraceSpans(ctx context.Context, a0 *protos.TraceSpans) (err error) {
+func (s deployerControl_local_stub) HandleTraceSpans(ctx context.Context, a0 *protos.TraceSpans) (err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.handleTraceSpansMetrics.Begin()
-	defer func() { s.handleTraceSpansMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.handleTraceSpansMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.HandleTraceSpans", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.HandleTraceSpans", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
-
-	}()
-
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_TraceSpans_af16efd0(enc, a0)
-	var shardKey uint64
-
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
-	}
-
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	err = dec.Error()
-	return
+	return s.impl.HandleTraceSpans(ctx, a0)
 }
 
-func (s deployerControl_client_stub) LogBatch(ctx context.Context, a0 *protos.LogEntryBatch) (err error) {
+func (s deployerControl_local_stub) LogBatch(ctx context.Context, a0 *protos.LogEntryBatch) (err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.logBatchMetrics.Begin()
-	defer func() { s.logBatchMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.logBatchMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.LogBatch", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.LogBatch", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
-
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
-
-	}()
-
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_LogEntryBatch_fec9a5d4(enc, a0)
-	var shardKey uint64
-
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	err = dec.Error()
-	return
+	return s.impl.LogBatch(ctx, a0)
 }
 
-func (s deployerControl_client_stub) VerifyClientCertificate(ctx context.Context, a0 *protos.VerifyClientCertificateRequest) (r0 *protos.VerifyClientCertificateReply, err error) {
+func (s deployerControl_local_stub) VerifyClientCertificate(ctx context.Context, a0 *protos.VerifyClientCertificateRequest) (r0 *protos.VerifyClientCertificateReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.verifyClientCertificateMetrics.Begin()
-	defer func() { s.verifyClientCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.verifyClientCertificateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.VerifyClientCertificate", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.VerifyClientCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
-
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
-
-	}()
-
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_VerifyClientCertificateRequest_f8d21781(enc, a0)
-	var shardKey uint64
-
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_VerifyClientCertificateReply_c76e39ec(dec)
-	err = dec.Error()
-	return
+	return s.impl.VerifyClientCertificate(ctx, a0)
 }
 
-func (s deployerControl_client_stub) VerifyServerCertificate(ctx context.Context, a0 *protos.VerifyServerCertificateRequest) (r0 *protos.VerifyServerCertificateReply, err error) {
+func (s deployerControl_local_stub) VerifyServerCertificate(ctx context.Context, a0 *protos.VerifyServerCertificateRequest) (r0 *protos.VerifyServerCertificateReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.verifyServerCertificateMetrics.Begin()
-	defer func() { s.verifyServerCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.verifyServerCertificateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.VerifyServerCertificate", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.deployerControl.VerifyServerCertificate", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.VerifyServerCertificate(ctx, a0)
+}
 
-	}()
+type quotaServer_local_stub struct {
+	impl         quotaServer
+	tracer       trace.Tracer
+	caller       codegen.Caller
+	grantMetrics *codegen.MethodMetrics
+}
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_VerifyServerCertificateRequest_9c56ee67(enc, a0)
-	var shardKey uint64
+// Check that quotaServer_local_stub implements the quotaServer interface.
+var _ quotaServer = (*quotaServer_local_stub)(nil)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+func (s quotaServer_local_stub) Grant(ctx context.Context, a0 string, a1 string, a2 int, a3 int64, a4 int) (r0 int, r1 int64, err error) {
+	// Update metrics.
+	begin := s.grantMetrics.Begin()
+	defer func() { s.grantMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.quotaServer.Grant", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_VerifyServerCertificateReply_c0d4bd3b(dec)
-	err = dec.Error()
-	return
+	return s.impl.Grant(ctx, a0, a1, a2, a3, a4)
 }
 
-type weaveletControl_client_stub struct {
-	stub                     codegen.Stub
-	getHealthMetrics         *codegen.MethodMetrics
-	getLoadMetrics           *codegen.MethodMetrics
-	getMetricsMetrics        *codegen.MethodMetrics
-	getProfileMetrics        *codegen.MethodMetrics
-	initWeaveletMetrics      *codegen.MethodMetrics
-	updateComponentsMetrics  *codegen.MethodMetrics
-	updateRoutingInfoMetrics *codegen.MethodMetrics
+type weaveletControl_local_stub struct {
+	impl                         weaveletControl
+	tracer                       trace.Tracer
+	caller                       codegen.Caller
+	getFlightRecordMetrics       *codegen.MethodMetrics
+	getHealthMetrics             *codegen.MethodMetrics
+	getLoadMetrics               *codegen.MethodMetrics
+	getMetricsMetrics            *codegen.MethodMetrics
+	getProfileMetrics            *codegen.MethodMetrics
+	initWeaveletMetrics          *codegen.MethodMetrics
+	notifyDeploymentEventMetrics *codegen.MethodMetrics
+	updateComponentsMetrics      *codegen.MethodMetrics
+	updateRoutingInfoMetrics     *codegen.MethodMetrics
 }
 
-// Check that weaveletControl_client_stub implements the weaveletControl interface.
-var _ weaveletControl = (*weaveletControl_client_stub)(nil)
+// Check that weaveletControl_local_stub implements the weaveletControl interface.
+var _ weaveletControl = (*weaveletControl_local_stub)(nil)
 
-func (s weaveletControl_client_stub) GetHealth(ctx context.Context, a0 *protos.GetHealthRequest) (r0 *protos.GetHealthReply, err error) {
+func (s weaveletControl_local_stub) GetFlightRecord(ctx context.Context, a0 *protos.GetFlightRecordRequest) (r0 *protos.GetFlightRecordReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
-	begin := s.getHealthMetrics.Begin()
-	defer func() { s.getHealthMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.getFlightRecordMetrics.Begin()
+	defer func() { s.getFlightRecordMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetHealth", trace.WithSpanKind(trace.SpanKindClient))
-	}
-
-	defer func() {
-		// Catch and return any panics detected during encoding/decoding/rpc.
-		if err == nil {
-			err = codegen.CatchPanics(recover())
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetFlightRecord", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
 			if err != nil {
-				err = errors.Join(RemoteCallError, err)
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
 			}
-		}
+			span.End()
+		}()
+	}
 
-		if err != nil {
-			span.RecordError(err)
-			span.SetStatus(codes.Error, err.Error())
-		}
-		span.End()
+	return s.impl.GetFlightRecord(ctx, a0)
+}
 
-	}()
+func (s weaveletControl_local_stub) GetHealth(ctx context.Context, a0 *protos.GetHealthRequest) (r0 *protos.GetHealthReply, err error) {
+	// Update metrics.
+	begin := s.getHealthMetrics.Begin()
+	defer func() { s.getHealthMetrics.End(begin, err != nil, 0, 0) }()
 
-	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetHealthRequest_fd6083fb(enc, a0)
-	var shardKey uint64
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
-	// Call the remote method.
-	requestBytes = len(enc.Data())
-	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
-	replyBytes = len(results)
-	if err != nil {
-		err = errors.Join(RemoteCallError, err)
-		return
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetHealth", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetHealthReply_b2d11423(dec)
-	err = dec.Error()
-	return
+	return s.impl.GetHealth(ctx, a0)
 }
 
-func (s weaveletControl_client_stub) GetLoad(ctx context.Context, a0 *protos.GetLoadRequest) (r0 *protos.GetLoadReply, err error) {
+func (s weaveletControl_local_stub) GetLoad(ctx context.Context, a0 *protos.GetLoadRequest) (r0 *protos.GetLoadReply, err error) {
 	// Update metrics.
-	var requestBytes, replyBytes int
 	begin := s.getLoadMetrics.Begin()
-	defer func() { s.getLoadMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	defer func() { s.getLoadMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetLoad", trace.WithSpanKind(trace.SpanKindClient))
-	}
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetLoad", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetLoad(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) GetMetrics(ctx context.Context, a0 *protos.GetMetricsRequest) (r0 *protos.GetMetricsReply, err error) {
+	// Update metrics.
+	begin := s.getMetricsMetrics.Begin()
+	defer func() { s.getMetricsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetMetrics", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetMetrics(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) GetProfile(ctx context.Context, a0 *protos.GetProfileRequest) (r0 *protos.GetProfileReply, err error) {
+	// Update metrics.
+	begin := s.getProfileMetrics.Begin()
+	defer func() { s.getProfileMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.GetProfile", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.GetProfile(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) InitWeavelet(ctx context.Context, a0 *protos.InitWeaveletRequest) (r0 *protos.InitWeaveletReply, err error) {
+	// Update metrics.
+	begin := s.initWeaveletMetrics.Begin()
+	defer func() { s.initWeaveletMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.InitWeavelet", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.InitWeavelet(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) NotifyDeploymentEvent(ctx context.Context, a0 *protos.NotifyDeploymentEventRequest) (r0 *protos.NotifyDeploymentEventReply, err error) {
+	// Update metrics.
+	begin := s.notifyDeploymentEventMetrics.Begin()
+	defer func() { s.notifyDeploymentEventMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.NotifyDeploymentEvent", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.NotifyDeploymentEvent(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) UpdateComponents(ctx context.Context, a0 *protos.UpdateComponentsRequest) (r0 *protos.UpdateComponentsReply, err error) {
+	// Update metrics.
+	begin := s.updateComponentsMetrics.Begin()
+	defer func() { s.updateComponentsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.UpdateComponents", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.UpdateComponents(ctx, a0)
+}
+
+func (s weaveletControl_local_stub) UpdateRoutingInfo(ctx context.Context, a0 *protos.UpdateRoutingInfoRequest) (r0 *protos.UpdateRoutingInfoReply, err error) {
+	// Update metrics.
+	begin := s.updateRoutingInfoMetrics.Begin()
+	defer func() { s.updateRoutingInfoMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "weaver.weaveletControl.UpdateRoutingInfo", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.UpdateRoutingInfo(ctx, a0)
+}
+
+// Client stub implementations.
+
+type blobStore_client_stub struct {
+	stub          codegen.Stub
+	deleteMetrics *codegen.MethodMetrics
+	getMetrics    *codegen.MethodMetrics
+	listMetrics   *codegen.MethodMetrics
+	putMetrics    *codegen.MethodMetrics
+}
+
+// Check that blobStore_client_stub implements the BlobStore interface.
+var _ BlobStore = (*blobStore_client_stub)(nil)
+
+func (s blobStore_client_stub) Delete(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.BlobStore.Delete", trace.WithSpanKind(trace.SpanKindClient))
+	}
 
 	defer func() {
 		// Catch and return any panics detected during encoding/decoding/rpc.
@@ -910,15 +1343,77 @@ func (s weaveletControl_client_stub) GetLoad(ctx context.Context, a0 *protos.Get
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetLoadRequest_d733b2cf(enc, a0)
+	enc.String(a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s blobStore_client_stub) Get(ctx context.Context, a0 string) (r0 []byte, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.BlobStore.Get", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
@@ -927,21 +1422,22 @@ func (s weaveletControl_client_stub) GetLoad(ctx context.Context, a0 *protos.Get
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetLoadReply_cf8279ad(dec)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
 	err = dec.Error()
 	return
 }
 
-func (s weaveletControl_client_stub) GetMetrics(ctx context.Context, a0 *protos.GetMetricsRequest) (r0 *protos.GetMetricsReply, err error) {
+func (s blobStore_client_stub) List(ctx context.Context, a0 string) (r0 []string, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.getMetricsMetrics.Begin()
-	defer func() { s.getMetricsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.listMetrics.Begin()
+	defer func() { s.listMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetMetrics", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.BlobStore.List", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -961,15 +1457,21 @@ func (s weaveletControl_client_stub) GetMetrics(ctx context.Context, a0 *protos.
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetMetricsRequest_010b3cd9(enc, a0)
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
@@ -978,21 +1480,22 @@ func (s weaveletControl_client_stub) GetMetrics(ctx context.Context, a0 *protos.
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetMetricsReply_3c7180e4(dec)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_string_4af10117(dec)
 	err = dec.Error()
 	return
 }
 
-func (s weaveletControl_client_stub) GetProfile(ctx context.Context, a0 *protos.GetProfileRequest) (r0 *protos.GetProfileReply, err error) {
+func (s blobStore_client_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.getProfileMetrics.Begin()
-	defer func() { s.getProfileMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetProfile", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.BlobStore.Put", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -1012,15 +1515,24 @@ func (s weaveletControl_client_stub) GetProfile(ctx context.Context, a0 *protos.
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_GetProfileRequest_d1544fcf(enc, a0)
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
@@ -1029,21 +1541,30 @@ func (s weaveletControl_client_stub) GetProfile(ctx context.Context, a0 *protos.
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_GetProfileReply_10a79dcc(dec)
 	err = dec.Error()
 	return
 }
 
-func (s weaveletControl_client_stub) InitWeavelet(ctx context.Context, a0 *protos.InitWeaveletRequest) (r0 *protos.InitWeaveletReply, err error) {
+type cache_client_stub struct {
+	stub              codegen.Stub
+	getMetrics        *codegen.MethodMetrics
+	invalidateMetrics *codegen.MethodMetrics
+	putMetrics        *codegen.MethodMetrics
+}
+
+// Check that cache_client_stub implements the Cache interface.
+var _ Cache = (*cache_client_stub)(nil)
+
+func (s cache_client_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.initWeaveletMetrics.Begin()
-	defer func() { s.initWeaveletMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.InitWeavelet", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.Cache.Get", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -1063,15 +1584,21 @@ func (s weaveletControl_client_stub) InitWeavelet(ctx context.Context, a0 *proto
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_InitWeaveletRequest_d1f5204c(enc, a0)
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
@@ -1080,21 +1607,23 @@ func (s weaveletControl_client_stub) InitWeavelet(ctx context.Context, a0 *proto
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_InitWeaveletReply_565d8c96(dec)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
+	r1 = dec.Bool()
 	err = dec.Error()
 	return
 }
 
-func (s weaveletControl_client_stub) UpdateComponents(ctx context.Context, a0 *protos.UpdateComponentsRequest) (r0 *protos.UpdateComponentsReply, err error) {
+func (s cache_client_stub) Invalidate(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.updateComponentsMetrics.Begin()
-	defer func() { s.updateComponentsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.invalidateMetrics.Begin()
+	defer func() { s.invalidateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.UpdateComponents", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.Cache.Invalidate", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -1114,15 +1643,21 @@ func (s weaveletControl_client_stub) UpdateComponents(ctx context.Context, a0 *p
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_UpdateComponentsRequest_d1b56e1f(enc, a0)
+	enc.String(a0)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
@@ -1131,21 +1666,20 @@ func (s weaveletControl_client_stub) UpdateComponents(ctx context.Context, a0 *p
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_UpdateComponentsReply_93bebb77(dec)
 	err = dec.Error()
 	return
 }
 
-func (s weaveletControl_client_stub) UpdateRoutingInfo(ctx context.Context, a0 *protos.UpdateRoutingInfoRequest) (r0 *protos.UpdateRoutingInfoReply, err error) {
+func (s cache_client_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
-	begin := s.updateRoutingInfoMetrics.Begin()
-	defer func() { s.updateRoutingInfoMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
 
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
-		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.UpdateRoutingInfo", trace.WithSpanKind(trace.SpanKindClient))
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.Cache.Put", trace.WithSpanKind(trace.SpanKindClient))
 	}
 
 	defer func() {
@@ -1165,52 +1699,2473 @@ func (s weaveletControl_client_stub) UpdateRoutingInfo(ctx context.Context, a0 *
 
 	}()
 
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
 	// Encode arguments.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_UpdateRoutingInfoRequest_e752cfad(enc, a0)
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(RemoteCallError, err)
 		return
 	}
 
-	// Decode the results.
-	dec := codegen.NewDecoder(results)
-	r0 = serviceweaver_dec_ptr_UpdateRoutingInfoReply_d1854fd5(dec)
-	err = dec.Error()
-	return
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+type notifier_client_stub struct {
+	stub          codegen.Stub
+	notifyMetrics *codegen.MethodMetrics
+}
+
+// Check that notifier_client_stub implements the Notifier interface.
+var _ Notifier = (*notifier_client_stub)(nil)
+
+func (s notifier_client_stub) Notify(ctx context.Context, a0 Notification) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.notifyMetrics.Begin()
+	defer func() { s.notifyMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.Notifier.Notify", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	(a0).WeaverMarshal(enc)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+type quota_client_stub struct {
+	stub           codegen.Stub
+	acquireMetrics *codegen.MethodMetrics
+}
+
+// Check that quota_client_stub implements the Quota interface.
+var _ Quota = (*quota_client_stub)(nil)
+
+func (s quota_client_stub) Acquire(ctx context.Context, a0 string, a1 string, a2 int) (r0 bool, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.acquireMetrics.Begin()
+	defer func() { s.acquireMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.Quota.Acquire", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	enc.Int(a2)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Bool()
+	err = dec.Error()
+	return
+}
+
+type replicatedStateMachine_client_stub struct {
+	stub         codegen.Stub
+	applyMetrics *codegen.MethodMetrics
+	queryMetrics *codegen.MethodMetrics
+	raftMetrics  *codegen.MethodMetrics
+}
+
+// Check that replicatedStateMachine_client_stub implements the ReplicatedStateMachine interface.
+var _ ReplicatedStateMachine = (*replicatedStateMachine_client_stub)(nil)
+
+func (s replicatedStateMachine_client_stub) Apply(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.applyMetrics.Begin()
+	defer func() { s.applyMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicatedStateMachine.Apply", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+
+	// Set the shardKey.
+	var r rsmRouter
+	shardKey := _hashReplicatedStateMachine(r.Apply(ctx, a0, a1))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
+	err = dec.Error()
+	return
+}
+
+func (s replicatedStateMachine_client_stub) Query(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.queryMetrics.Begin()
+	defer func() { s.queryMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicatedStateMachine.Query", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+
+	// Set the shardKey.
+	var r rsmRouter
+	shardKey := _hashReplicatedStateMachine(r.Query(ctx, a0, a1))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
+	err = dec.Error()
+	return
+}
+
+func (s replicatedStateMachine_client_stub) Raft(ctx context.Context, a0 string, a1 []byte) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.raftMetrics.Begin()
+	defer func() { s.raftMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicatedStateMachine.Raft", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+type replicationLog_client_stub struct {
+	stub              codegen.Stub
+	appendMetrics     *codegen.MethodMetrics
+	checkpointMetrics *codegen.MethodMetrics
+	fetchMetrics      *codegen.MethodMetrics
+}
+
+// Check that replicationLog_client_stub implements the ReplicationLog interface.
+var _ ReplicationLog = (*replicationLog_client_stub)(nil)
+
+func (s replicationLog_client_stub) Append(ctx context.Context, a0 string, a1 ReplicationEntry) (r0 string, r1 uint64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.appendMetrics.Begin()
+	defer func() { s.appendMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicationLog.Append", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += serviceweaver_size_ReplicationEntry_adfd1bd2(&a1)
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	(a1).WeaverMarshal(enc)
+
+	// Set the shardKey.
+	var r replicationRouter
+	shardKey := _hashReplicationLog(r.Append(ctx, a0, a1))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	r1 = dec.Uint64()
+	err = dec.Error()
+	return
+}
+
+func (s replicationLog_client_stub) Checkpoint(ctx context.Context, a0 string, a1 string, a2 uint64, a3 []byte) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.checkpointMetrics.Begin()
+	defer func() { s.checkpointMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicationLog.Checkpoint", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += 8
+	size += (4 + (len(a3) * 1))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	enc.String(a1)
+	enc.Uint64(a2)
+	serviceweaver_enc_slice_byte_87461245(enc, a3)
+
+	// Set the shardKey.
+	var r replicationRouter
+	shardKey := _hashReplicationLog(r.Checkpoint(ctx, a0, a1, a2, a3))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s replicationLog_client_stub) Fetch(ctx context.Context, a0 string, a1 string, a2 uint64) (r0 ReplicationBatch, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.fetchMetrics.Begin()
+	defer func() { s.fetchMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.ReplicationLog.Fetch", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	enc.Uint64(a2)
+
+	// Set the shardKey.
+	var r replicationRouter
+	shardKey := _hashReplicationLog(r.Fetch(ctx, a0, a1, a2))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	(&r0).WeaverUnmarshal(dec)
+	err = dec.Error()
+	return
+}
+
+type cacheShard_client_stub struct {
+	stub          codegen.Stub
+	deleteMetrics *codegen.MethodMetrics
+	getMetrics    *codegen.MethodMetrics
+	putMetrics    *codegen.MethodMetrics
+}
+
+// Check that cacheShard_client_stub implements the cacheShard interface.
+var _ cacheShard = (*cacheShard_client_stub)(nil)
+
+func (s cacheShard_client_stub) Delete(ctx context.Context, a0 string) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.deleteMetrics.Begin()
+	defer func() { s.deleteMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.cacheShard.Delete", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+
+	// Set the shardKey.
+	var r cacheRouter
+	shardKey := _hashCacheShard(r.Delete(ctx, a0))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s cacheShard_client_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getMetrics.Begin()
+	defer func() { s.getMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.cacheShard.Get", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+
+	// Set the shardKey.
+	var r cacheRouter
+	shardKey := _hashCacheShard(r.Get(ctx, a0))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_byte_87461245(dec)
+	r1 = dec.Bool()
+	err = dec.Error()
+	return
+}
+
+func (s cacheShard_client_stub) Put(ctx context.Context, a0 string, a1 []byte, a2 int64, a3 int) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.putMetrics.Begin()
+	defer func() { s.putMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.cacheShard.Put", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + (len(a1) * 1))
+	size += 8
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.String(a0)
+	serviceweaver_enc_slice_byte_87461245(enc, a1)
+	enc.Int64(a2)
+	enc.Int(a3)
+
+	// Set the shardKey.
+	var r cacheRouter
+	shardKey := _hashCacheShard(r.Put(ctx, a0, a1, a2, a3))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+type deployerControl_client_stub struct {
+	stub                           codegen.Stub
+	activateComponentMetrics       *codegen.MethodMetrics
+	exportListenerMetrics          *codegen.MethodMetrics
+	getListenerAddressMetrics      *codegen.MethodMetrics
+	getSelfCertificateMetrics      *codegen.MethodMetrics
+	getTopologyMetrics             *codegen.MethodMetrics
+	handleTraceSpansMetrics        *codegen.MethodMetrics
+	logBatchMetrics                *codegen.MethodMetrics
+	verifyClientCertificateMetrics *codegen.MethodMetrics
+	verifyServerCertificateMetrics *codegen.MethodMetrics
+}
+
+// Check that deployerControl_client_stub implements the deployerControl interface.
+var _ deployerControl = (*deployerControl_client_stub)(nil)
+
+func (s deployerControl_client_stub) ActivateComponent(ctx context.Context, a0 *protos.ActivateComponentRequest) (r0 *protos.ActivateComponentReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.activateComponentMetrics.Begin()
+	defer func() { s.activateComponentMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.ActivateComponent", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_ActivateComponentRequest_73adf343(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_ActivateComponentReply_5e57d605(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) ExportListener(ctx context.Context, a0 *protos.ExportListenerRequest) (r0 *protos.ExportListenerReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.exportListenerMetrics.Begin()
+	defer func() { s.exportListenerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.ExportListener", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_ExportListenerRequest_b494514e(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_ExportListenerReply_b0fc34d0(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) GetListenerAddress(ctx context.Context, a0 *protos.GetListenerAddressRequest) (r0 *protos.GetListenerAddressReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getListenerAddressMetrics.Begin()
+	defer func() { s.getListenerAddressMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.GetListenerAddress", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetListenerAddressRequest_5a58feb0(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetListenerAddressReply_8bfe2caa(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) GetSelfCertificate(ctx context.Context, a0 *protos.GetSelfCertificateRequest) (r0 *protos.GetSelfCertificateReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getSelfCertificateMetrics.Begin()
+	defer func() { s.getSelfCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.GetSelfCertificate", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetSelfCertificateRequest_0de4e3b4(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetSelfCertificateReply_12277ec8(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) GetTopology(ctx context.Context, a0 *protos.GetTopologyRequest) (r0 *protos.GetTopologyReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getTopologyMetrics.Begin()
+	defer func() { s.getTopologyMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.GetTopology", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetTopologyRequest_fdeb70d4(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetTopologyReply_36d124ea(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) HandleTraceSpans(ctx context.Context, a0 *protos.TraceSpans) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.handleTraceSpansMetrics.Begin()
+	defer func() { s.handleTraceSpansMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.HandleTraceSpans", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_TraceSpans_af16efd0(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) LogBatch(ctx context.Context, a0 *protos.LogEntryBatch) (err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.logBatchMetrics.Begin()
+	defer func() { s.logBatchMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.LogBatch", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_LogEntryBatch_fec9a5d4(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) VerifyClientCertificate(ctx context.Context, a0 *protos.VerifyClientCertificateRequest) (r0 *protos.VerifyClientCertificateReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.verifyClientCertificateMetrics.Begin()
+	defer func() { s.verifyClientCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.VerifyClientCertificate", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_VerifyClientCertificateRequest_f8d21781(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_VerifyClientCertificateReply_c76e39ec(dec)
+	err = dec.Error()
+	return
+}
+
+func (s deployerControl_client_stub) VerifyServerCertificate(ctx context.Context, a0 *protos.VerifyServerCertificateRequest) (r0 *protos.VerifyServerCertificateReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.verifyServerCertificateMetrics.Begin()
+	defer func() { s.verifyServerCertificateMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.deployerControl.VerifyServerCertificate", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_VerifyServerCertificateRequest_9c56ee67(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 8, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_VerifyServerCertificateReply_c0d4bd3b(dec)
+	err = dec.Error()
+	return
+}
+
+type quotaServer_client_stub struct {
+	stub         codegen.Stub
+	grantMetrics *codegen.MethodMetrics
+}
+
+// Check that quotaServer_client_stub implements the quotaServer interface.
+var _ quotaServer = (*quotaServer_client_stub)(nil)
+
+func (s quotaServer_client_stub) Grant(ctx context.Context, a0 string, a1 string, a2 int, a3 int64, a4 int) (r0 int, r1 int64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.grantMetrics.Begin()
+	defer func() { s.grantMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.quotaServer.Grant", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += (4 + len(a1))
+	size += 8
+	size += 8
+	size += 8
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	enc.String(a1)
+	enc.Int(a2)
+	enc.Int64(a3)
+	enc.Int(a4)
+
+	// Set the shardKey.
+	var r quotaRouter
+	shardKey := _hashQuotaServer(r.Grant(ctx, a0, a1, a2, a3, a4))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.Int()
+	r1 = dec.Int64()
+	err = dec.Error()
+	return
+}
+
+type weaveletControl_client_stub struct {
+	stub                         codegen.Stub
+	getFlightRecordMetrics       *codegen.MethodMetrics
+	getHealthMetrics             *codegen.MethodMetrics
+	getLoadMetrics               *codegen.MethodMetrics
+	getMetricsMetrics            *codegen.MethodMetrics
+	getProfileMetrics            *codegen.MethodMetrics
+	initWeaveletMetrics          *codegen.MethodMetrics
+	notifyDeploymentEventMetrics *codegen.MethodMetrics
+	updateComponentsMetrics      *codegen.MethodMetrics
+	updateRoutingInfoMetrics     *codegen.MethodMetrics
+}
+
+// Check that weaveletControl_client_stub implements the weaveletControl interface.
+var _ weaveletControl = (*weaveletControl_client_stub)(nil)
+
+func (s weaveletControl_client_stub) GetFlightRecord(ctx context.Context, a0 *protos.GetFlightRecordRequest) (r0 *protos.GetFlightRecordReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getFlightRecordMetrics.Begin()
+	defer func() { s.getFlightRecordMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetFlightRecord", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetFlightRecordRequest_4f7c6ec7(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetFlightRecordReply_38faceaf(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) GetHealth(ctx context.Context, a0 *protos.GetHealthRequest) (r0 *protos.GetHealthReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getHealthMetrics.Begin()
+	defer func() { s.getHealthMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetHealth", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetHealthRequest_fd6083fb(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetHealthReply_b2d11423(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) GetLoad(ctx context.Context, a0 *protos.GetLoadRequest) (r0 *protos.GetLoadReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getLoadMetrics.Begin()
+	defer func() { s.getLoadMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetLoad", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetLoadRequest_d733b2cf(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetLoadReply_cf8279ad(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) GetMetrics(ctx context.Context, a0 *protos.GetMetricsRequest) (r0 *protos.GetMetricsReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getMetricsMetrics.Begin()
+	defer func() { s.getMetricsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetMetrics", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetMetricsRequest_010b3cd9(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetMetricsReply_3c7180e4(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) GetProfile(ctx context.Context, a0 *protos.GetProfileRequest) (r0 *protos.GetProfileReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.getProfileMetrics.Begin()
+	defer func() { s.getProfileMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.GetProfile", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_GetProfileRequest_d1544fcf(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_GetProfileReply_10a79dcc(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) InitWeavelet(ctx context.Context, a0 *protos.InitWeaveletRequest) (r0 *protos.InitWeaveletReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.initWeaveletMetrics.Begin()
+	defer func() { s.initWeaveletMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.InitWeavelet", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_InitWeaveletRequest_d1f5204c(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_InitWeaveletReply_565d8c96(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) NotifyDeploymentEvent(ctx context.Context, a0 *protos.NotifyDeploymentEventRequest) (r0 *protos.NotifyDeploymentEventReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.notifyDeploymentEventMetrics.Begin()
+	defer func() { s.notifyDeploymentEventMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.NotifyDeploymentEvent", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_NotifyDeploymentEventRequest_b43ef911(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_NotifyDeploymentEventReply_06bba459(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) UpdateComponents(ctx context.Context, a0 *protos.UpdateComponentsRequest) (r0 *protos.UpdateComponentsReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.updateComponentsMetrics.Begin()
+	defer func() { s.updateComponentsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.UpdateComponents", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_UpdateComponentsRequest_d1b56e1f(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_UpdateComponentsReply_93bebb77(dec)
+	err = dec.Error()
+	return
+}
+
+func (s weaveletControl_client_stub) UpdateRoutingInfo(ctx context.Context, a0 *protos.UpdateRoutingInfoRequest) (r0 *protos.UpdateRoutingInfoReply, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.updateRoutingInfoMetrics.Begin()
+	defer func() { s.updateRoutingInfoMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "weaver.weaveletControl.UpdateRoutingInfo", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	serviceweaver_enc_ptr_UpdateRoutingInfoRequest_e752cfad(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 8, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = serviceweaver_dec_ptr_UpdateRoutingInfoReply_d1854fd5(dec)
+	err = dec.Error()
+	return
+}
+
+// Note that "weaver generate" will always generate the error message below.
+// Everything is okay. The error message is only relevant if you see it when
+// you run "go build" or "go run".
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
+
+ERROR: You generated this file with 'weaver generate' (devel) (codegen
+version v0.27.0). The generated code is incompatible with the version of the
+github.com/ServiceWeaver/weaver module that you're using. The weaver module
+version can be found in your go.mod file or by running the following command.
+
+    go list -m github.com/ServiceWeaver/weaver
+
+We recommend updating the weaver module and the 'weaver generate' command by
+running the following.
+
+    go get github.com/ServiceWeaver/weaver@latest
+    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+
+Then, re-run 'weaver generate' and re-build your code. If the problem persists,
+please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+
+`)
+
+// Server stub implementations.
+
+type blobStore_server_stub struct {
+	impl    BlobStore
+	addLoad func(key uint64, load float64)
+}
+
+// Check that blobStore_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*blobStore_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s blobStore_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Delete":
+		return s.delete
+	case "Get":
+		return s.get
+	case "List":
+		return s.list
+	case "Put":
+		return s.put
+	default:
+		return nil
+	}
+}
+
+func (s blobStore_server_stub) delete(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Delete(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s blobStore_server_stub) get(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Get(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s blobStore_server_stub) list(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.List(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_string_4af10117(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s blobStore_server_stub) put(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Put(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type cache_server_stub struct {
+	impl    Cache
+	addLoad func(key uint64, load float64)
+}
+
+// Check that cache_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*cache_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s cache_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Get":
+		return s.get
+	case "Invalidate":
+		return s.invalidate
+	case "Put":
+		return s.put
+	default:
+		return nil
+	}
+}
+
+func (s cache_server_stub) get(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Get(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Bool(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s cache_server_stub) invalidate(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Invalidate(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s cache_server_stub) put(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Put(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type notifier_server_stub struct {
+	impl    Notifier
+	addLoad func(key uint64, load float64)
+}
+
+// Check that notifier_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*notifier_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s notifier_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Notify":
+		return s.notify
+	default:
+		return nil
+	}
+}
+
+func (s notifier_server_stub) notify(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 Notification
+	(&a0).WeaverUnmarshal(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Notify(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type quota_server_stub struct {
+	impl    Quota
+	addLoad func(key uint64, load float64)
+}
+
+// Check that quota_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*quota_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s quota_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Acquire":
+		return s.acquire
+	default:
+		return nil
+	}
+}
+
+func (s quota_server_stub) acquire(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+	var a2 int
+	a2 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Acquire(ctx, a0, a1, a2)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Bool(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type replicatedStateMachine_server_stub struct {
+	impl    ReplicatedStateMachine
+	addLoad func(key uint64, load float64)
+}
+
+// Check that replicatedStateMachine_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*replicatedStateMachine_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s replicatedStateMachine_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Apply":
+		return s.apply
+	case "Query":
+		return s.query
+	case "Raft":
+		return s.raft
+	default:
+		return nil
+	}
+}
+
+func (s replicatedStateMachine_server_stub) apply(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+	var r rsmRouter
+	s.addLoad(_hashReplicatedStateMachine(r.Apply(ctx, a0, a1)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Apply(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s replicatedStateMachine_server_stub) query(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+	var r rsmRouter
+	s.addLoad(_hashReplicatedStateMachine(r.Query(ctx, a0, a1)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Query(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s replicatedStateMachine_server_stub) raft(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Raft(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type replicationLog_server_stub struct {
+	impl    ReplicationLog
+	addLoad func(key uint64, load float64)
+}
+
+// Check that replicationLog_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*replicationLog_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s replicationLog_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Append":
+		return s.append
+	case "Checkpoint":
+		return s.checkpoint
+	case "Fetch":
+		return s.fetch
+	default:
+		return nil
+	}
+}
+
+func (s replicationLog_server_stub) append(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 ReplicationEntry
+	(&a1).WeaverUnmarshal(dec)
+	var r replicationRouter
+	s.addLoad(_hashReplicationLog(r.Append(ctx, a0, a1)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Append(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Uint64(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s replicationLog_server_stub) checkpoint(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+	var a2 uint64
+	a2 = dec.Uint64()
+	var a3 []byte
+	a3 = serviceweaver_dec_slice_byte_87461245(dec)
+	var r replicationRouter
+	s.addLoad(_hashReplicationLog(r.Checkpoint(ctx, a0, a1, a2, a3)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Checkpoint(ctx, a0, a1, a2, a3)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s replicationLog_server_stub) fetch(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+	var a2 uint64
+	a2 = dec.Uint64()
+	var r replicationRouter
+	s.addLoad(_hashReplicationLog(r.Fetch(ctx, a0, a1, a2)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Fetch(ctx, a0, a1, a2)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	(r0).WeaverMarshal(enc)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+type cacheShard_server_stub struct {
+	impl    cacheShard
+	addLoad func(key uint64, load float64)
+}
+
+// Check that cacheShard_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*cacheShard_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s cacheShard_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Delete":
+		return s.delete
+	case "Get":
+		return s.get
+	case "Put":
+		return s.put
+	default:
+		return nil
+	}
+}
+
+func (s cacheShard_server_stub) delete(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var r cacheRouter
+	s.addLoad(_hashCacheShard(r.Delete(ctx, a0)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Delete(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
 }
 
-// Note that "weaver generate" will always generate the error message below.
-// Everything is okay. The error message is only relevant if you see it when
-// you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+func (s cacheShard_server_stub) get(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
-github.com/ServiceWeaver/weaver module that you're using. The weaver module
-version can be found in your go.mod file or by running the following command.
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var r cacheRouter
+	s.addLoad(_hashCacheShard(r.Get(ctx, a0)), 1.0)
 
-    go list -m github.com/ServiceWeaver/weaver
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Get(ctx, a0)
 
-We recommend updating the weaver module and the 'weaver generate' command by
-running the following.
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_byte_87461245(enc, r0)
+	enc.Bool(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
 
-    go get github.com/ServiceWeaver/weaver@latest
-    go install github.com/ServiceWeaver/weaver/cmd/weaver@latest
+func (s cacheShard_server_stub) put(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
 
-Then, re-run 'weaver generate' and re-build your code. If the problem persists,
-please file an issue at https://github.com/ServiceWeaver/weaver/issues.
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 []byte
+	a1 = serviceweaver_dec_slice_byte_87461245(dec)
+	var a2 int64
+	a2 = dec.Int64()
+	var a3 int
+	a3 = dec.Int()
+	var r cacheRouter
+	s.addLoad(_hashCacheShard(r.Put(ctx, a0, a1, a2, a3)), 1.0)
 
-`)
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	appErr := s.impl.Put(ctx, a0, a1, a2, a3)
 
-// Server stub implementations.
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
 
 type deployerControl_server_stub struct {
 	impl    deployerControl
@@ -1231,6 +4186,8 @@ func (s deployerControl_server_stub) GetStubFn(method string) func(ctx context.C
 		return s.getListenerAddress
 	case "GetSelfCertificate":
 		return s.getSelfCertificate
+	case "GetTopology":
+		return s.getTopology
 	case "HandleTraceSpans":
 		return s.handleTraceSpans
 	case "LogBatch":
@@ -1344,6 +4301,31 @@ func (s deployerControl_server_stub) getSelfCertificate(ctx context.Context, arg
 	return enc.Data(), nil
 }
 
+func (s deployerControl_server_stub) getTopology(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 *protos.GetTopologyRequest
+	a0 = serviceweaver_dec_ptr_GetTopologyRequest_fdeb70d4(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.GetTopology(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_ptr_GetTopologyReply_36d124ea(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s deployerControl_server_stub) handleTraceSpans(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1442,6 +4424,60 @@ func (s deployerControl_server_stub) verifyServerCertificate(ctx context.Context
 	return enc.Data(), nil
 }
 
+type quotaServer_server_stub struct {
+	impl    quotaServer
+	addLoad func(key uint64, load float64)
+}
+
+// Check that quotaServer_server_stub implements the codegen.Server interface.
+var _ codegen.Server = (*quotaServer_server_stub)(nil)
+
+// GetStubFn implements the codegen.Server interface.
+func (s quotaServer_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
+	switch method {
+	case "Grant":
+		return s.grant
+	default:
+		return nil
+	}
+}
+
+func (s quotaServer_server_stub) grant(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 string
+	a1 = dec.String()
+	var a2 int
+	a2 = dec.Int()
+	var a3 int64
+	a3 = dec.Int64()
+	var a4 int
+	a4 = dec.Int()
+	var r quotaRouter
+	s.addLoad(_hashQuotaServer(r.Grant(ctx, a0, a1, a2, a3, a4)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Grant(ctx, a0, a1, a2, a3, a4)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Int(r0)
+	enc.Int64(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 type weaveletControl_server_stub struct {
 	impl    weaveletControl
 	addLoad func(key uint64, load float64)
@@ -1453,6 +4489,8 @@ var _ codegen.Server = (*weaveletControl_server_stub)(nil)
 // GetStubFn implements the codegen.Server interface.
 func (s weaveletControl_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
+	case "GetFlightRecord":
+		return s.getFlightRecord
 	case "GetHealth":
 		return s.getHealth
 	case "GetLoad":
@@ -1463,6 +4501,8 @@ func (s weaveletControl_server_stub) GetStubFn(method string) func(ctx context.C
 		return s.getProfile
 	case "InitWeavelet":
 		return s.initWeavelet
+	case "NotifyDeploymentEvent":
+		return s.notifyDeploymentEvent
 	case "UpdateComponents":
 		return s.updateComponents
 	case "UpdateRoutingInfo":
@@ -1472,6 +4512,31 @@ func (s weaveletControl_server_stub) GetStubFn(method string) func(ctx context.C
 	}
 }
 
+func (s weaveletControl_server_stub) getFlightRecord(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 *protos.GetFlightRecordRequest
+	a0 = serviceweaver_dec_ptr_GetFlightRecordRequest_4f7c6ec7(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.GetFlightRecord(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_ptr_GetFlightRecordReply_38faceaf(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s weaveletControl_server_stub) getHealth(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1597,6 +4662,31 @@ func (s weaveletControl_server_stub) initWeavelet(ctx context.Context, args []by
 	return enc.Data(), nil
 }
 
+func (s weaveletControl_server_stub) notifyDeploymentEvent(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 *protos.NotifyDeploymentEventRequest
+	a0 = serviceweaver_dec_ptr_NotifyDeploymentEventRequest_b43ef911(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.NotifyDeploymentEvent(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_ptr_NotifyDeploymentEventReply_06bba459(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s weaveletControl_server_stub) updateComponents(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1605,49 +4695,188 @@ func (s weaveletControl_server_stub) updateComponents(ctx context.Context, args
 		}
 	}()
 
-	// Decode arguments.
-	dec := codegen.NewDecoder(args)
-	var a0 *protos.UpdateComponentsRequest
-	a0 = serviceweaver_dec_ptr_UpdateComponentsRequest_d1b56e1f(dec)
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 *protos.UpdateComponentsRequest
+	a0 = serviceweaver_dec_ptr_UpdateComponentsRequest_d1b56e1f(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.UpdateComponents(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_ptr_UpdateComponentsReply_93bebb77(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s weaveletControl_server_stub) updateRoutingInfo(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 *protos.UpdateRoutingInfoRequest
+	a0 = serviceweaver_dec_ptr_UpdateRoutingInfoRequest_e752cfad(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.UpdateRoutingInfo(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_ptr_UpdateRoutingInfoReply_d1854fd5(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+// Reflect stub implementations.
+
+type blobStore_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that blobStore_reflect_stub implements the BlobStore interface.
+var _ BlobStore = (*blobStore_reflect_stub)(nil)
+
+func (s blobStore_reflect_stub) Delete(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Delete", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s blobStore_reflect_stub) Get(ctx context.Context, a0 string) (r0 []byte, err error) {
+	err = s.caller("Get", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s blobStore_reflect_stub) List(ctx context.Context, a0 string) (r0 []string, err error) {
+	err = s.caller("List", ctx, []any{a0}, []any{&r0})
+	return
+}
+
+func (s blobStore_reflect_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
+	err = s.caller("Put", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+type cache_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that cache_reflect_stub implements the Cache interface.
+var _ Cache = (*cache_reflect_stub)(nil)
+
+func (s cache_reflect_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
+	err = s.caller("Get", ctx, []any{a0}, []any{&r0, &r1})
+	return
+}
+
+func (s cache_reflect_stub) Invalidate(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Invalidate", ctx, []any{a0}, []any{})
+	return
+}
+
+func (s cache_reflect_stub) Put(ctx context.Context, a0 string, a1 []byte) (err error) {
+	err = s.caller("Put", ctx, []any{a0, a1}, []any{})
+	return
+}
+
+type notifier_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that notifier_reflect_stub implements the Notifier interface.
+var _ Notifier = (*notifier_reflect_stub)(nil)
+
+func (s notifier_reflect_stub) Notify(ctx context.Context, a0 Notification) (err error) {
+	err = s.caller("Notify", ctx, []any{a0}, []any{})
+	return
+}
+
+type quota_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that quota_reflect_stub implements the Quota interface.
+var _ Quota = (*quota_reflect_stub)(nil)
+
+func (s quota_reflect_stub) Acquire(ctx context.Context, a0 string, a1 string, a2 int) (r0 bool, err error) {
+	err = s.caller("Acquire", ctx, []any{a0, a1, a2}, []any{&r0})
+	return
+}
+
+type replicatedStateMachine_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that replicatedStateMachine_reflect_stub implements the ReplicatedStateMachine interface.
+var _ ReplicatedStateMachine = (*replicatedStateMachine_reflect_stub)(nil)
+
+func (s replicatedStateMachine_reflect_stub) Apply(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
+	err = s.caller("Apply", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
+func (s replicatedStateMachine_reflect_stub) Query(ctx context.Context, a0 string, a1 []byte) (r0 []byte, err error) {
+	err = s.caller("Query", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
+func (s replicatedStateMachine_reflect_stub) Raft(ctx context.Context, a0 string, a1 []byte) (r0 string, err error) {
+	err = s.caller("Raft", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
+type replicationLog_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
 
-	// TODO(rgrandl): The deferred function above will recover from panics in the
-	// user code: fix this.
-	// Call the local method.
-	r0, appErr := s.impl.UpdateComponents(ctx, a0)
+// Check that replicationLog_reflect_stub implements the ReplicationLog interface.
+var _ ReplicationLog = (*replicationLog_reflect_stub)(nil)
 
-	// Encode the results.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_UpdateComponentsReply_93bebb77(enc, r0)
-	enc.Error(appErr)
-	return enc.Data(), nil
+func (s replicationLog_reflect_stub) Append(ctx context.Context, a0 string, a1 ReplicationEntry) (r0 string, r1 uint64, err error) {
+	err = s.caller("Append", ctx, []any{a0, a1}, []any{&r0, &r1})
+	return
 }
 
-func (s weaveletControl_server_stub) updateRoutingInfo(ctx context.Context, args []byte) (res []byte, err error) {
-	// Catch and return any panics detected during encoding/decoding/rpc.
-	defer func() {
-		if err == nil {
-			err = codegen.CatchPanics(recover())
-		}
-	}()
+func (s replicationLog_reflect_stub) Checkpoint(ctx context.Context, a0 string, a1 string, a2 uint64, a3 []byte) (err error) {
+	err = s.caller("Checkpoint", ctx, []any{a0, a1, a2, a3}, []any{})
+	return
+}
 
-	// Decode arguments.
-	dec := codegen.NewDecoder(args)
-	var a0 *protos.UpdateRoutingInfoRequest
-	a0 = serviceweaver_dec_ptr_UpdateRoutingInfoRequest_e752cfad(dec)
+func (s replicationLog_reflect_stub) Fetch(ctx context.Context, a0 string, a1 string, a2 uint64) (r0 ReplicationBatch, err error) {
+	err = s.caller("Fetch", ctx, []any{a0, a1, a2}, []any{&r0})
+	return
+}
 
-	// TODO(rgrandl): The deferred function above will recover from panics in the
-	// user code: fix this.
-	// Call the local method.
-	r0, appErr := s.impl.UpdateRoutingInfo(ctx, a0)
+type cacheShard_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
 
-	// Encode the results.
-	enc := codegen.NewEncoder()
-	serviceweaver_enc_ptr_UpdateRoutingInfoReply_d1854fd5(enc, r0)
-	enc.Error(appErr)
-	return enc.Data(), nil
+// Check that cacheShard_reflect_stub implements the cacheShard interface.
+var _ cacheShard = (*cacheShard_reflect_stub)(nil)
+
+func (s cacheShard_reflect_stub) Delete(ctx context.Context, a0 string) (err error) {
+	err = s.caller("Delete", ctx, []any{a0}, []any{})
+	return
 }
 
-// Reflect stub implementations.
+func (s cacheShard_reflect_stub) Get(ctx context.Context, a0 string) (r0 []byte, r1 bool, err error) {
+	err = s.caller("Get", ctx, []any{a0}, []any{&r0, &r1})
+	return
+}
+
+func (s cacheShard_reflect_stub) Put(ctx context.Context, a0 string, a1 []byte, a2 int64, a3 int) (err error) {
+	err = s.caller("Put", ctx, []any{a0, a1, a2, a3}, []any{})
+	return
+}
 
 type deployerControl_reflect_stub struct {
 	caller func(string, context.Context, []any, []any) error
@@ -1676,6 +4905,11 @@ func (s deployerControl_reflect_stub) GetSelfCertificate(ctx context.Context, a0
 	return
 }
 
+func (s deployerControl_reflect_stub) GetTopology(ctx context.Context, a0 *protos.GetTopologyRequest) (r0 *protos.GetTopologyReply, err error) {
+	err = s.caller("GetTopology", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s deployerControl_reflect_stub) HandleTraceSpans(ctx context.Context, a0 *protos.TraceSpans) (err error) {
 	err = s.caller("HandleTraceSpans", ctx, []any{a0}, []any{})
 	return
@@ -1696,6 +4930,18 @@ func (s deployerControl_reflect_stub) VerifyServerCertificate(ctx context.Contex
 	return
 }
 
+type quotaServer_reflect_stub struct {
+	caller func(string, context.Context, []any, []any) error
+}
+
+// Check that quotaServer_reflect_stub implements the quotaServer interface.
+var _ quotaServer = (*quotaServer_reflect_stub)(nil)
+
+func (s quotaServer_reflect_stub) Grant(ctx context.Context, a0 string, a1 string, a2 int, a3 int64, a4 int) (r0 int, r1 int64, err error) {
+	err = s.caller("Grant", ctx, []any{a0, a1, a2, a3, a4}, []any{&r0, &r1})
+	return
+}
+
 type weaveletControl_reflect_stub struct {
 	caller func(string, context.Context, []any, []any) error
 }
@@ -1703,6 +4949,11 @@ type weaveletControl_reflect_stub struct {
 // Check that weaveletControl_reflect_stub implements the weaveletControl interface.
 var _ weaveletControl = (*weaveletControl_reflect_stub)(nil)
 
+func (s weaveletControl_reflect_stub) GetFlightRecord(ctx context.Context, a0 *protos.GetFlightRecordRequest) (r0 *protos.GetFlightRecordReply, err error) {
+	err = s.caller("GetFlightRecord", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s weaveletControl_reflect_stub) GetHealth(ctx context.Context, a0 *protos.GetHealthRequest) (r0 *protos.GetHealthReply, err error) {
 	err = s.caller("GetHealth", ctx, []any{a0}, []any{&r0})
 	return
@@ -1728,6 +4979,11 @@ func (s weaveletControl_reflect_stub) InitWeavelet(ctx context.Context, a0 *prot
 	return
 }
 
+func (s weaveletControl_reflect_stub) NotifyDeploymentEvent(ctx context.Context, a0 *protos.NotifyDeploymentEventRequest) (r0 *protos.NotifyDeploymentEventReply, err error) {
+	err = s.caller("NotifyDeploymentEvent", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s weaveletControl_reflect_stub) UpdateComponents(ctx context.Context, a0 *protos.UpdateComponentsRequest) (r0 *protos.UpdateComponentsReply, err error) {
 	err = s.caller("UpdateComponents", ctx, []any{a0}, []any{&r0})
 	return
@@ -1738,6 +4994,299 @@ func (s weaveletControl_reflect_stub) UpdateRoutingInfo(ctx context.Context, a0
 	return
 }
 
+// AutoMarshal implementations.
+
+var _ codegen.AutoMarshal = (*Notification)(nil)
+
+type __is_Notification[T ~struct {
+	AutoMarshal
+	Channel  string
+	To       []string
+	Template string
+	Data     map[string]string
+	Subject  string
+	Body     string
+}] struct{}
+
+var _ __is_Notification[Notification]
+
+func (x *Notification) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("Notification.WeaverMarshal: nil receiver"))
+	}
+	enc.String(x.Channel)
+	serviceweaver_enc_slice_string_4af10117(enc, x.To)
+	enc.String(x.Template)
+	serviceweaver_enc_map_string_string_219dd46d(enc, x.Data)
+	enc.String(x.Subject)
+	enc.String(x.Body)
+}
+
+func (x *Notification) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("Notification.WeaverUnmarshal: nil receiver"))
+	}
+	x.Channel = dec.String()
+	x.To = serviceweaver_dec_slice_string_4af10117(dec)
+	x.Template = dec.String()
+	x.Data = serviceweaver_dec_map_string_string_219dd46d(dec)
+	x.Subject = dec.String()
+	x.Body = dec.String()
+}
+
+func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 4)
+	res := make([]string, n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_map_string_string_219dd46d(enc *codegen.Encoder, arg map[string]string) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	m := enc.Map()
+	for k, v := range arg {
+		m.Key()
+		enc.String(k)
+		m.Value()
+		enc.String(v)
+	}
+	m.End()
+}
+
+func serviceweaver_dec_map_string_string_219dd46d(dec *codegen.Decoder) map[string]string {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 8)
+	res := make(map[string]string, n)
+	var k string
+	var v string
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k = dec.String()
+			v = dec.String()
+			res[k] = v
+		}
+	})
+	return res
+}
+
+var _ codegen.AutoMarshal = (*ReplicationBatch)(nil)
+
+type __is_ReplicationBatch[T ~struct {
+	AutoMarshal
+	Epoch      string
+	Recovering bool
+	Reset      bool
+	Snapshot   []byte
+	Base       uint64
+	Entries    []ReplicationEntry
+	Last       uint64
+	Compact    bool
+}] struct{}
+
+var _ __is_ReplicationBatch[ReplicationBatch]
+
+func (x *ReplicationBatch) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("ReplicationBatch.WeaverMarshal: nil receiver"))
+	}
+	enc.String(x.Epoch)
+	enc.Bool(x.Recovering)
+	enc.Bool(x.Reset)
+	serviceweaver_enc_slice_byte_87461245(enc, x.Snapshot)
+	enc.Uint64(x.Base)
+	serviceweaver_enc_slice_ReplicationEntry_5d2b1b51(enc, x.Entries)
+	enc.Uint64(x.Last)
+	enc.Bool(x.Compact)
+}
+
+func (x *ReplicationBatch) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("ReplicationBatch.WeaverUnmarshal: nil receiver"))
+	}
+	x.Epoch = dec.String()
+	x.Recovering = dec.Bool()
+	x.Reset = dec.Bool()
+	x.Snapshot = serviceweaver_dec_slice_byte_87461245(dec)
+	x.Base = dec.Uint64()
+	x.Entries = serviceweaver_dec_slice_ReplicationEntry_5d2b1b51(dec)
+	x.Last = dec.Uint64()
+	x.Compact = dec.Bool()
+}
+
+func serviceweaver_enc_slice_byte_87461245(enc *codegen.Encoder, arg []byte) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	if codegen.EncodeFixed(enc, arg) {
+		return
+	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Byte(arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_byte_87461245(dec *codegen.Decoder) []byte {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 1)
+	res := make([]byte, n)
+	if codegen.DecodeFixed[byte](dec, res) {
+		return res
+	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Byte()
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_slice_ReplicationEntry_5d2b1b51(enc *codegen.Encoder, arg []ReplicationEntry) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(arg[i]).WeaverMarshal(enc)
+		}
+	})
+}
+
+func serviceweaver_dec_slice_ReplicationEntry_5d2b1b51(dec *codegen.Decoder) []ReplicationEntry {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 12)
+	res := make([]ReplicationEntry, n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			(&res[i]).WeaverUnmarshal(dec)
+		}
+	})
+	return res
+}
+
+var _ codegen.AutoMarshal = (*ReplicationEntry)(nil)
+
+type __is_ReplicationEntry[T ~struct {
+	AutoMarshal
+	ID uint64
+	Op []byte
+}] struct{}
+
+var _ __is_ReplicationEntry[ReplicationEntry]
+
+func (x *ReplicationEntry) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("ReplicationEntry.WeaverMarshal: nil receiver"))
+	}
+	enc.Uint64(x.ID)
+	serviceweaver_enc_slice_byte_87461245(enc, x.Op)
+}
+
+func (x *ReplicationEntry) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("ReplicationEntry.WeaverUnmarshal: nil receiver"))
+	}
+	x.ID = dec.Uint64()
+	x.Op = serviceweaver_dec_slice_byte_87461245(dec)
+}
+
+// Router methods.
+
+// _hashReplicatedStateMachine returns a 64 bit hash of the provided value.
+func _hashReplicatedStateMachine(r string) uint64 {
+	var h codegen.Hasher
+	h.WriteString(string(r))
+	return h.Sum64()
+}
+
+// _orderedCodeReplicatedStateMachine returns an order-preserving serialization of the provided value.
+func _orderedCodeReplicatedStateMachine(r string) codegen.OrderedCode {
+	var enc codegen.OrderedEncoder
+	enc.WriteString(string(r))
+	return enc.Encode()
+}
+
+// _hashReplicationLog returns a 64 bit hash of the provided value.
+func _hashReplicationLog(r string) uint64 {
+	var h codegen.Hasher
+	h.WriteString(string(r))
+	return h.Sum64()
+}
+
+// _orderedCodeReplicationLog returns an order-preserving serialization of the provided value.
+func _orderedCodeReplicationLog(r string) codegen.OrderedCode {
+	var enc codegen.OrderedEncoder
+	enc.WriteString(string(r))
+	return enc.Encode()
+}
+
+// _hashCacheShard returns a 64 bit hash of the provided value.
+func _hashCacheShard(r string) uint64 {
+	var h codegen.Hasher
+	h.WriteString(string(r))
+	return h.Sum64()
+}
+
+// _orderedCodeCacheShard returns an order-preserving serialization of the provided value.
+func _orderedCodeCacheShard(r string) codegen.OrderedCode {
+	var enc codegen.OrderedEncoder
+	enc.WriteString(string(r))
+	return enc.Encode()
+}
+
+// _hashQuotaServer returns a 64 bit hash of the provided value.
+func _hashQuotaServer(r string) uint64 {
+	var h codegen.Hasher
+	h.WriteString(string(r))
+	return h.Sum64()
+}
+
+// _orderedCodeQuotaServer returns an order-preserving serialization of the provided value.
+func _orderedCodeQuotaServer(r string) codegen.OrderedCode {
+	var enc codegen.OrderedEncoder
+	enc.WriteString(string(r))
+	return enc.Encode()
+}
+
 // Encoding/decoding implementations.
 
 func serviceweaver_enc_ptr_ActivateComponentRequest_73adf343(enc *codegen.Encoder, arg *protos.ActivateComponentRequest) {
@@ -1884,6 +5433,42 @@ func serviceweaver_dec_ptr_GetSelfCertificateReply_12277ec8(dec *codegen.Decoder
 	return &res
 }
 
+func serviceweaver_enc_ptr_GetTopologyRequest_fdeb70d4(enc *codegen.Encoder, arg *protos.GetTopologyRequest) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_GetTopologyRequest_fdeb70d4(dec *codegen.Decoder) *protos.GetTopologyRequest {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.GetTopologyRequest
+	dec.DecodeProto(&res)
+	return &res
+}
+
+func serviceweaver_enc_ptr_GetTopologyReply_36d124ea(enc *codegen.Encoder, arg *protos.GetTopologyReply) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_GetTopologyReply_36d124ea(dec *codegen.Decoder) *protos.GetTopologyReply {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.GetTopologyReply
+	dec.DecodeProto(&res)
+	return &res
+}
+
 func serviceweaver_enc_ptr_TraceSpans_af16efd0(enc *codegen.Encoder, arg *protos.TraceSpans) {
 	if arg == nil {
 		enc.Bool(false)
@@ -1992,6 +5577,42 @@ func serviceweaver_dec_ptr_VerifyServerCertificateReply_c0d4bd3b(dec *codegen.De
 	return &res
 }
 
+func serviceweaver_enc_ptr_GetFlightRecordRequest_4f7c6ec7(enc *codegen.Encoder, arg *protos.GetFlightRecordRequest) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_GetFlightRecordRequest_4f7c6ec7(dec *codegen.Decoder) *protos.GetFlightRecordRequest {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.GetFlightRecordRequest
+	dec.DecodeProto(&res)
+	return &res
+}
+
+func serviceweaver_enc_ptr_GetFlightRecordReply_38faceaf(enc *codegen.Encoder, arg *protos.GetFlightRecordReply) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_GetFlightRecordReply_38faceaf(dec *codegen.Decoder) *protos.GetFlightRecordReply {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.GetFlightRecordReply
+	dec.DecodeProto(&res)
+	return &res
+}
+
 func serviceweaver_enc_ptr_GetHealthRequest_fd6083fb(enc *codegen.Encoder, arg *protos.GetHealthRequest) {
 	if arg == nil {
 		enc.Bool(false)
@@ -2172,6 +5793,42 @@ func serviceweaver_dec_ptr_InitWeaveletReply_565d8c96(dec *codegen.Decoder) *pro
 	return &res
 }
 
+func serviceweaver_enc_ptr_NotifyDeploymentEventRequest_b43ef911(enc *codegen.Encoder, arg *protos.NotifyDeploymentEventRequest) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_NotifyDeploymentEventRequest_b43ef911(dec *codegen.Decoder) *protos.NotifyDeploymentEventRequest {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.NotifyDeploymentEventRequest
+	dec.DecodeProto(&res)
+	return &res
+}
+
+func serviceweaver_enc_ptr_NotifyDeploymentEventReply_06bba459(enc *codegen.Encoder, arg *protos.NotifyDeploymentEventReply) {
+	if arg == nil {
+		enc.Bool(false)
+	} else {
+		enc.Bool(true)
+		enc.EncodeProto(arg)
+	}
+}
+
+func serviceweaver_dec_ptr_NotifyDeploymentEventReply_06bba459(dec *codegen.Decoder) *protos.NotifyDeploymentEventReply {
+	if !dec.Bool() {
+		return nil
+	}
+	var res protos.NotifyDeploymentEventReply
+	dec.DecodeProto(&res)
+	return &res
+}
+
 func serviceweaver_enc_ptr_UpdateComponentsRequest_d1b56e1f(enc *codegen.Encoder, arg *protos.UpdateComponentsRequest) {
 	if arg == nil {
 		enc.Bool(false)
@@ -2243,3 +5900,15 @@ func serviceweaver_dec_ptr_UpdateRoutingInfoReply_d1854fd5(dec *codegen.Decoder)
 	dec.DecodeProto(&res)
 	return &res
 }
+
+// Size implementations.
+
+// serviceweaver_size_ReplicationEntry_adfd1bd2 returns the size (in bytes) of the serialization
+// of the provided type.
+func serviceweaver_size_ReplicationEntry_adfd1bd2(x *ReplicationEntry) int {
+	size := 0
+	size += 0
+	size += 8
+	size += (4 + (len(x.Op) * 1))
+	return size
+}
@@ -0,0 +1,122 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weavertest
+
+import (
+	"context"
+	"testing"
+
+	imetrics "github.com/ServiceWeaver/weaver/internal/metrics"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
+)
+
+// WireBytesUnit is the unit of the bytes on the wire metric reported by
+// Benchmark.
+const WireBytesUnit = "wire-B/op"
+
+// Benchmark runs a sub-benchmark of b for every provided runner that
+// repeatedly calls call with a component of type T. For example:
+//
+//	func BenchmarkReverse(b *testing.B) {
+//		weavertest.Benchmark(b, weavertest.AllRunners(), func(ctx context.Context, r Reverser) error {
+//			_, err := r.Reverse(ctx, "diaper drawer")
+//			return err
+//		})
+//	}
+//
+// Every sub-benchmark reports the time and allocations per call, like
+// "go test -bench" does with -benchmem, and the number of bytes that the
+// calls send and receive over the wire per call, in WireBytesUnit. Calls made
+// with the Local runner are local procedure calls that don't send bytes over
+// the wire; calls made with the RPC and Multi runners go through the real
+// serialization and transport code.
+//
+// Bytes on the wire are the sizes of the serialized requests and replies of
+// the remote calls made by the benchmarking process, including nested calls
+// between components in the same process, but not calls made by other
+// processes. They are measured using the process's method metrics, so
+// benchmarks that measure them should not run concurrently with other
+// benchmarks or tests that call components.
+//
+// T can either be a component interface type (e.g., Reverser) or a component
+// implementation pointer type (e.g., *reverser). call is called once before
+// the benchmark starts to create the components and establish connections.
+func Benchmark[T any](b *testing.B, runners []Runner, call func(context.Context, T) error) {
+	b.Helper()
+	for _, r := range runners {
+		r.Bench(b, benchmarkCalls(call, false))
+	}
+}
+
+// BenchmarkParallel is like Benchmark, but calls call from multiple
+// goroutines in parallel, like testing.B.RunParallel.
+func BenchmarkParallel[T any](b *testing.B, runners []Runner, call func(context.Context, T) error) {
+	b.Helper()
+	for _, r := range runners {
+		r.Bench(b, benchmarkCalls(call, true))
+	}
+}
+
+// benchmarkCalls returns the body of a benchmark that calls call b.N times,
+// in parallel if parallel is true, and reports the bytes on the wire per call.
+func benchmarkCalls[T any](call func(context.Context, T) error, parallel bool) func(*testing.B, T) {
+	return func(b *testing.B, c T) {
+		ctx := context.Background()
+		if err := call(ctx, c); err != nil {
+			b.Fatal(err)
+		}
+
+		before := wireBytes()
+		b.ReportAllocs()
+		b.ResetTimer()
+		if parallel {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if err := call(ctx, c); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		} else {
+			for i := 0; i < b.N; i++ {
+				if err := call(ctx, c); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+		b.StopTimer()
+		b.ReportMetric((wireBytes()-before)/float64(b.N), WireBytesUnit)
+	}
+}
+
+// wireBytes returns the total number of bytes in the requests and replies of
+// the remote calls to application components made by this process so far.
+func wireBytes() float64 {
+	var total float64
+	for _, m := range metrics.Snapshot() {
+		if m.Name != imetrics.MethodBytesRequestName && m.Name != imetrics.MethodBytesReplyName {
+			continue
+		}
+		if m.Labels["remote"] != "true" {
+			continue
+		}
+		if imetrics.IsSystem(m.Labels["component"]) {
+			continue
+		}
+		total += m.Value
+	}
+	return total
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weavertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver/weavertest/internal/simple"
+)
+
+func TestBenchmarkCalls(t *testing.T) {
+	caller := func(ctx context.Context, dst simple.Destination) error {
+		_, err := dst.Caller(ctx)
+		return err
+	}
+	for _, parallel := range []bool{false, true} {
+		// testing.Benchmark doesn't name its benchmark, which the RPC and
+		// Multi runners require, so we only benchmark with the Local runner.
+		result := testing.Benchmark(func(b *testing.B) {
+			Local.sub(b, true, benchmarkCalls(caller, parallel))
+		})
+		if result.N == 0 {
+			t.Fatalf("parallel=%v: benchmark failed", parallel)
+		}
+		if result.MemAllocs == 0 {
+			t.Errorf("parallel=%v: no allocations reported", parallel)
+		}
+		if got, ok := result.Extra[WireBytesUnit]; !ok || got != 0 {
+			t.Errorf("parallel=%v: got %v %s (reported: %v), want 0", parallel, got, WireBytesUnit, ok)
+		}
+	}
+}
+
+func TestWireBytes(t *testing.T) {
+	RPC.Test(t, func(t *testing.T, dst simple.Destination) {
+		ctx := context.Background()
+		before := wireBytes()
+		caller, err := dst.Caller(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Caller has no arguments and replies with the caller's name and a
+		// nil error, which are encoded in at least len(caller) bytes.
+		if got, want := wireBytes()-before, float64(len(caller)); got < want {
+			t.Errorf("wire bytes: got %v, want >= %v", got, want)
+		}
+	})
+}
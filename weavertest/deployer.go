@@ -21,14 +21,16 @@ import (
 	"log/slog"
 	"reflect"
 	"sync"
+	"time"
 
 	"github.com/ServiceWeaver/weaver/internal/control"
 	"github.com/ServiceWeaver/weaver/internal/weaver"
 	"github.com/ServiceWeaver/weaver/runtime"
-	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/ServiceWeaver/weaver/runtime/envelope"
 	"github.com/ServiceWeaver/weaver/runtime/logging"
+	"github.com/ServiceWeaver/weaver/runtime/metrics"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
+	"github.com/ServiceWeaver/weaver/runtime/topology"
 	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
 	"golang.org/x/sync/errgroup"
@@ -64,6 +66,7 @@ type deployer struct {
 	local      map[string]bool        // Components that should run locally
 	log        func(*protos.LogEntry) // logs the passed in string
 	sysLogger  *slog.Logger           // system message logger
+	topology   *topology.Tracker      // computes the live component graph
 
 	mu     sync.Mutex        // guards fields below
 	groups map[string]*group // groups, by group name
@@ -111,6 +114,7 @@ func newDeployer(ctx context.Context, wlet *protos.WeaveletArgs, config *protos.
 		groups:     map[string]*group{},
 		local:      map[string]bool{},
 		log:        logWriter,
+		topology:   topology.NewTracker(topology.DefaultWindow),
 	}
 	d.sysLogger = slog.New(&logging.LogHandler{
 		Opts: logging.Options{
@@ -142,6 +146,7 @@ func (d *deployer) start(opts weaver.RemoteWeaveletOptions) (*weaver.RemoteWeave
 		DeploymentId:    d.wlet.DeploymentId,
 		Id:              uuid.New().String(),
 		InternalAddress: "localhost:0",
+		Group:           "main",
 	}
 
 	child := envelope.NewInProcessChild()
@@ -157,7 +162,7 @@ func (d *deployer) start(opts weaver.RemoteWeaveletOptions) (*weaver.RemoteWeave
 		bootstrap := runtime.Bootstrap{
 			Args: child.Args(), // Will block
 		}
-		weavelet, err := weaver.NewRemoteWeavelet(d.ctx, codegen.Registered(), bootstrap, opts)
+		weavelet, err := weaver.NewRemoteWeavelet(d.ctx, d.runner.registry().Registered(), bootstrap, opts)
 		wchan <- weaveletResult{weavelet, err} // Give weavelet to caller
 		wchan <- weaveletResult{weavelet, err} // Give weavelet to NewEnvelope goroutine
 	}()
@@ -247,6 +252,13 @@ func (d *deployer) HandleTraceSpans(context.Context, *protos.TraceSpans) error {
 	return nil
 }
 
+// GetTopology implements the envelope.EnvelopeHandler interface.
+func (d *deployer) GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	// All weavelets run in the test process, so the metrics of the process
+	// include all the calls between components.
+	return &protos.GetTopologyReply{Topology: d.topology.Record(time.Now(), metrics.Snapshot())}, nil
+}
+
 // GetListenerAddress implements the envelope.EnvelopeHandler interface.
 func (d *deployer) GetListenerAddress(_ context.Context, req *protos.GetListenerAddressRequest) (*protos.GetListenerAddressReply, error) {
 	return &protos.GetListenerAddressReply{Address: "localhost:0"}, nil
@@ -325,8 +337,10 @@ func (h *handler) ActivateComponent(ctx context.Context, req *protos.ActivateCom
 	if !h.subscribed[req.Component] {
 		h.subscribed[req.Component] = true
 
-		if !h.runner.forceRPC && h.group.name == target.name {
-			// Route locally.
+		if !h.runner.forceRPC && !req.Routed && h.group.name == target.name {
+			// Route locally. Like the multiprocess deployer, we route calls
+			// to routed components remotely, even within a group, so that
+			// calls are routed to the right replica.
 			routing := &protos.UpdateRoutingInfoRequest{RoutingInfo: &protos.RoutingInfo{Component: req.Component, Local: true}}
 			if _, err := h.controller.UpdateRoutingInfo(ctx, routing); err != nil {
 				return nil, err
@@ -362,6 +376,8 @@ func (d *deployer) startGroup(g *group) error {
 			DeploymentId:    d.wlet.DeploymentId,
 			Id:              uuid.New().String(),
 			InternalAddress: "localhost:0",
+			Group:           g.name,
+			ReplicaIndex:    int32(r),
 		}
 		handler := &handler{
 			deployer:   d,
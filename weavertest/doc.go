@@ -55,4 +55,14 @@
 //	    // ...
 //	  })
 //	}
+//
+// Use [Benchmark] to benchmark a component method under several runners. It
+// reports the time, allocations, and bytes on the wire per call:
+//
+//	func BenchmarkReverse(b *testing.B) {
+//	  weavertest.Benchmark(b, weavertest.AllRunners(), func(ctx context.Context, reverser Reverser) error {
+//	    _, err := reverser.Reverse(ctx, "diaper drawer")
+//	    return err
+//	  })
+//	}
 package weavertest
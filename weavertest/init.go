@@ -53,6 +53,21 @@ type Runner struct {
 	// The typical use is to override some subset of the application
 	// code being tested with test-specific component implementations.
 	Fakes []FakeComponent
+
+	// Registry holds the components available to the test. If nil, the
+	// global registry, which holds every component linked into the test
+	// binary, is used. A test can use a registry with a subset of the
+	// components (see codegen.Registry.Subset) to run independently of
+	// other tests in the same binary, possibly in parallel.
+	Registry *codegen.Registry
+}
+
+// registry returns the registry of components available to the test.
+func (r Runner) registry() *codegen.Registry {
+	if r.Registry == nil {
+		return codegen.Global()
+	}
+	return r.Registry
 }
 
 var (
@@ -208,7 +223,7 @@ func (r Runner) sub(t testing.TB, isBench bool, testBody any) {
 			Quiet:  !testing.Verbose(),
 		}
 		var err error
-		runner, err = weaver.NewSingleWeavelet(ctx, codegen.Registered(), opts)
+		runner, err = weaver.NewSingleWeavelet(ctx, r.registry().Registered(), opts)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*A)(nil)).Elem(),
 		Impl:  reflect.TypeOf(a{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return a_local_stub{impl: impl.(A), tracer: tracer, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/A", Method: "Propagate", Remote: false, Generated: true})}
+			return a_local_stub{impl: impl.(A), tracer: tracer, caller: codegen.Caller{Component: caller}, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/A", Method: "Propagate", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return a_client_stub{stub: stub, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/A", Method: "Propagate", Remote: true, Generated: true})}
@@ -30,14 +30,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return a_reflect_stub{caller: caller}
 		},
-		RefData: "⟦d3d93f6e:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/chain/A→github.com/ServiceWeaver/weaver/weavertest/internal/chain/B⟧\n",
+		RefData:          "⟦d3d93f6e:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/chain/A→github.com/ServiceWeaver/weaver/weavertest/internal/chain/B⟧\n⟦60a884ff:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/chain/A.Propagate→github.com/ServiceWeaver/weaver/weavertest/internal/chain/B.Propagate@chain.go:64:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "b7d95a0ceae2508a",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/weavertest/internal/chain/B",
 		Iface: reflect.TypeOf((*B)(nil)).Elem(),
 		Impl:  reflect.TypeOf(b{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return b_local_stub{impl: impl.(B), tracer: tracer, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/B", Method: "Propagate", Remote: false, Generated: true})}
+			return b_local_stub{impl: impl.(B), tracer: tracer, caller: codegen.Caller{Component: caller}, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/B", Method: "Propagate", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return b_client_stub{stub: stub, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/B", Method: "Propagate", Remote: true, Generated: true})}
@@ -48,14 +51,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return b_reflect_stub{caller: caller}
 		},
-		RefData: "⟦08d612ad:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/chain/B→github.com/ServiceWeaver/weaver/weavertest/internal/chain/C⟧\n",
+		RefData:          "⟦08d612ad:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/chain/B→github.com/ServiceWeaver/weaver/weavertest/internal/chain/C⟧\n⟦4829ead5:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/chain/B.Propagate→github.com/ServiceWeaver/weaver/weavertest/internal/chain/C.Propagate@chain.go:71:9⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "b7d95a0ceae2508a",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/weavertest/internal/chain/C",
 		Iface: reflect.TypeOf((*C)(nil)).Elem(),
 		Impl:  reflect.TypeOf(c{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return c_local_stub{impl: impl.(C), tracer: tracer, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/C", Method: "Propagate", Remote: false, Generated: true})}
+			return c_local_stub{impl: impl.(C), tracer: tracer, caller: codegen.Caller{Component: caller}, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/C", Method: "Propagate", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return c_client_stub{stub: stub, propagateMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/chain/C", Method: "Propagate", Remote: true, Generated: true})}
@@ -66,7 +72,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return c_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "b7d95a0ceae2508a",
 	})
 }
 
@@ -85,6 +94,7 @@ var _ weaver.Unrouted = (*c)(nil)
 type a_local_stub struct {
 	impl             A
 	tracer           trace.Tracer
+	caller           codegen.Caller
 	propagateMetrics *codegen.MethodMetrics
 }
 
@@ -95,6 +105,10 @@ func (s a_local_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Update metrics.
 	begin := s.propagateMetrics.Begin()
 	defer func() { s.propagateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -114,6 +128,7 @@ func (s a_local_stub) Propagate(ctx context.Context, a0 int) (err error) {
 type b_local_stub struct {
 	impl             B
 	tracer           trace.Tracer
+	caller           codegen.Caller
 	propagateMetrics *codegen.MethodMetrics
 }
 
@@ -124,6 +139,10 @@ func (s b_local_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Update metrics.
 	begin := s.propagateMetrics.Begin()
 	defer func() { s.propagateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -143,6 +162,7 @@ func (s b_local_stub) Propagate(ctx context.Context, a0 int) (err error) {
 type c_local_stub struct {
 	impl             C
 	tracer           trace.Tracer
+	caller           codegen.Caller
 	propagateMetrics *codegen.MethodMetrics
 }
 
@@ -153,6 +173,10 @@ func (s c_local_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Update metrics.
 	begin := s.propagateMetrics.Begin()
 	defer func() { s.propagateMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -211,7 +235,7 @@ func (s a_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -222,6 +246,7 @@ func (s a_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -274,7 +299,7 @@ func (s b_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -285,6 +310,7 @@ func (s b_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -337,7 +363,7 @@ func (s c_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -348,6 +374,7 @@ func (s c_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -363,10 +390,10 @@ func (s c_client_stub) Propagate(ctx context.Context, a0 int) (err error) {
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
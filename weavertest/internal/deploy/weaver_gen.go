@@ -19,7 +19,7 @@ func init() {
 		Iface: reflect.TypeOf((*Started)(nil)).Elem(),
 		Impl:  reflect.TypeOf(started{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return started_local_stub{impl: impl.(Started), tracer: tracer, markStartedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started", Method: "MarkStarted", Remote: false, Generated: true})}
+			return started_local_stub{impl: impl.(Started), tracer: tracer, caller: codegen.Caller{Component: caller}, markStartedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started", Method: "MarkStarted", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return started_client_stub{stub: stub, markStartedMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started", Method: "MarkStarted", Remote: true, Generated: true})}
@@ -30,14 +30,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return started_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "b8396d1910444004",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget",
 		Iface: reflect.TypeOf((*Widget)(nil)).Elem(),
 		Impl:  reflect.TypeOf(widget{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return widget_local_stub{impl: impl.(Widget), tracer: tracer, useMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget", Method: "Use", Remote: false, Generated: true})}
+			return widget_local_stub{impl: impl.(Widget), tracer: tracer, caller: codegen.Caller{Component: caller}, useMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget", Method: "Use", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return widget_client_stub{stub: stub, useMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget", Method: "Use", Remote: true, Generated: true})}
@@ -48,7 +51,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return widget_reflect_stub{caller: caller}
 		},
-		RefData: "⟦f3fa3c18:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget→github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started⟧\n",
+		RefData:          "⟦f3fa3c18:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget→github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started⟧\n⟦ad0aa0da:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Widget.Use→github.com/ServiceWeaver/weaver/weavertest/internal/deploy/Started.MarkStarted@deploy.go:72:13⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "71bb88be5cfd57da",
 	})
 }
 
@@ -65,6 +71,7 @@ var _ weaver.Unrouted = (*widget)(nil)
 type started_local_stub struct {
 	impl               Started
 	tracer             trace.Tracer
+	caller             codegen.Caller
 	markStartedMetrics *codegen.MethodMetrics
 }
 
@@ -75,6 +82,10 @@ func (s started_local_stub) MarkStarted(ctx context.Context, a0 string) (err err
 	// Update metrics.
 	begin := s.markStartedMetrics.Begin()
 	defer func() { s.markStartedMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -94,6 +105,7 @@ func (s started_local_stub) MarkStarted(ctx context.Context, a0 string) (err err
 type widget_local_stub struct {
 	impl       Widget
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	useMetrics *codegen.MethodMetrics
 }
 
@@ -104,6 +116,10 @@ func (s widget_local_stub) Use(ctx context.Context, a0 string) (err error) {
 	// Update metrics.
 	begin := s.useMetrics.Begin()
 	defer func() { s.useMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -162,7 +178,7 @@ func (s started_client_stub) MarkStarted(ctx context.Context, a0 string) (err er
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -173,6 +189,7 @@ func (s started_client_stub) MarkStarted(ctx context.Context, a0 string) (err er
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -225,7 +242,7 @@ func (s widget_client_stub) Use(ctx context.Context, a0 string) (err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -236,6 +253,7 @@ func (s widget_client_stub) Use(ctx context.Context, a0 string) (err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -251,10 +269,10 @@ func (s widget_client_stub) Use(ctx context.Context, a0 string) (err error) {
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
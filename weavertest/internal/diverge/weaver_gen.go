@@ -20,7 +20,7 @@ func init() {
 		Iface: reflect.TypeOf((*Errer)(nil)).Elem(),
 		Impl:  reflect.TypeOf(errer{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return errer_local_stub{impl: impl.(Errer), tracer: tracer, errMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Errer", Method: "Err", Remote: false, Generated: true})}
+			return errer_local_stub{impl: impl.(Errer), tracer: tracer, caller: codegen.Caller{Component: caller}, errMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Errer", Method: "Err", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return errer_client_stub{stub: stub, errMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Errer", Method: "Err", Remote: true, Generated: true})}
@@ -31,14 +31,17 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return errer_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "423bca9bc06adf0f",
 	})
 	codegen.Register(codegen.Registration{
 		Name:  "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Pointer",
 		Iface: reflect.TypeOf((*Pointer)(nil)).Elem(),
 		Impl:  reflect.TypeOf(pointer{}),
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return pointer_local_stub{impl: impl.(Pointer), tracer: tracer, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Pointer", Method: "Get", Remote: false, Generated: true})}
+			return pointer_local_stub{impl: impl.(Pointer), tracer: tracer, caller: codegen.Caller{Component: caller}, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Pointer", Method: "Get", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return pointer_client_stub{stub: stub, getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/diverge/Pointer", Method: "Get", Remote: true, Generated: true})}
@@ -49,7 +52,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return pointer_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "45ab6fcf4ec4138a",
 	})
 }
 
@@ -66,6 +72,7 @@ var _ weaver.Unrouted = (*pointer)(nil)
 type errer_local_stub struct {
 	impl       Errer
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	errMetrics *codegen.MethodMetrics
 }
 
@@ -76,6 +83,10 @@ func (s errer_local_stub) Err(ctx context.Context, a0 int) (err error) {
 	// Update metrics.
 	begin := s.errMetrics.Begin()
 	defer func() { s.errMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -95,6 +106,7 @@ func (s errer_local_stub) Err(ctx context.Context, a0 int) (err error) {
 type pointer_local_stub struct {
 	impl       Pointer
 	tracer     trace.Tracer
+	caller     codegen.Caller
 	getMetrics *codegen.MethodMetrics
 }
 
@@ -105,6 +117,10 @@ func (s pointer_local_stub) Get(ctx context.Context) (r0 Pair, err error) {
 	// Update metrics.
 	begin := s.getMetrics.Begin()
 	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -163,7 +179,7 @@ func (s errer_client_stub) Err(ctx context.Context, a0 int) (err error) {
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -174,6 +190,7 @@ func (s errer_client_stub) Err(ctx context.Context, a0 int) (err error) {
 	requestBytes = len(enc.Data())
 	var results []byte
 	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -244,10 +261,10 @@ func (s pointer_client_stub) Get(ctx context.Context) (r0 Pair, err error) {
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -22,10 +22,12 @@ import (
 	"fmt"
 
 	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
 )
 
 //go:generate ../../../cmd/weaver/weaver generate
 
+//weaver:enum
 type behaviorType int
 
 const (
@@ -42,14 +44,48 @@ type customErrorValue struct {
 
 func (c customErrorValue) Error() string { return fmt.Sprintf("customError(%s)", c.key) }
 
+// shape is an interface with registered concrete types rect and *square.
+type shape interface {
+	area() int
+}
+
+type rect struct {
+	weaver.AutoMarshal
+	w, h int
+}
+
+type square struct {
+	weaver.AutoMarshal
+	s int
+}
+
+func (r rect) area() int    { return r.w * r.h }
+func (s *square) area() int { return s.s * s.s }
+
+func init() {
+	codegen.RegisterConcrete[rect]()
+	codegen.RegisterConcrete[square]()
+}
+
 type testApp interface {
 	Get(_ context.Context, key string, behavior behaviorType) (int, error)
 	IncPointer(_ context.Context, arg *int) (*int, error)
 	DivMod(_ context.Context, numerator int, denominator int) (int, int, error)
+	Scale(_ context.Context, s shape, factor int) (shape, error)
+	Count(_ context.Context, words []string) (map[string]int, error)
+	Publish(_ context.Context, key string, r rect) (weaver.WatchToken, error)
+	WatchChanges(_ context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[rect], error)
 }
 
 type impl struct {
 	weaver.Implements[testApp]
+	weaver.WithRouter[watchRouter]
+	publisher *weaver.Publisher[rect]
+}
+
+func (p *impl) Init(context.Context) error {
+	p.publisher = weaver.NewPublisher[rect]()
+	return nil
 }
 
 // Get returns an error or a value based on the expected behavior.
@@ -84,3 +120,50 @@ func (p *impl) DivMod(_ context.Context, n, d int) (int, int, error) {
 	}
 	return n / d, n % d, nil
 }
+
+// Scale returns s scaled by factor.
+func (p *impl) Scale(_ context.Context, s shape, factor int) (shape, error) {
+	switch x := s.(type) {
+	case nil:
+		return nil, nil
+	case rect:
+		return rect{w: x.w * factor, h: x.h * factor}, nil
+	case *square:
+		return &square{s: x.s * factor}, nil
+	default:
+		return nil, fmt.Errorf("unexpected shape %T", s)
+	}
+}
+
+// Count returns the number of occurrences of every word.
+func (p *impl) Count(_ context.Context, words []string) (map[string]int, error) {
+	counts := map[string]int{}
+	for _, word := range words {
+		counts[word]++
+	}
+	return counts, nil
+}
+
+// Publish publishes r, and returns the token of the position before it.
+func (p *impl) Publish(_ context.Context, key string, r rect) (weaver.WatchToken, error) {
+	token := p.publisher.Token()
+	p.publisher.Publish(key, r)
+	return token, nil
+}
+
+// WatchChanges returns the rects published after token.
+func (p *impl) WatchChanges(ctx context.Context, prefix string, token weaver.WatchToken) (weaver.WatchBatch[rect], error) {
+	return p.publisher.WatchChanges(ctx, prefix, token)
+}
+
+// watchRouter routes the calls that publish and watch rects by their key, so
+// that a watch reaches the replica that publishes the rects it watches.
+type watchRouter struct{}
+
+func (watchRouter) Publish(_ context.Context, key string, _ rect) string {
+	return key
+}
+
+func (watchRouter) WatchChanges(_ context.Context, prefix string, _ weaver.WatchToken) string {
+	return prefix
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -74,6 +76,34 @@ func TestCustomError(t *testing.T) {
 	})
 }
 
+func TestUnknownEnum(t *testing.T) {
+	// Encode a behaviorType that isn't one of its constants.
+	enc := codegen.NewEncoder()
+	unknown := behaviorType(42)
+	unknown.WeaverMarshal(enc)
+
+	// Check that decoding fails.
+	decode := func() (err error) {
+		defer func() { err = codegen.CatchPanics(recover()) }()
+		var got behaviorType
+		got.WeaverUnmarshal(codegen.NewDecoder(enc.Data()))
+		return nil
+	}
+	err := decode()
+	if err == nil || !strings.Contains(err.Error(), "unknown value 42") {
+		t.Fatalf("decode unknown behaviorType: got %v, want unknown value error", err)
+	}
+
+	// Check that a remote call with an unknown enum value fails.
+	ctx := context.Background()
+	weavertest.Multi.Test(t, func(t *testing.T, client testApp) {
+		_, err := client.Get(ctx, "foo", unknown)
+		if err == nil || !strings.Contains(err.Error(), "unknown value 42") {
+			t.Fatalf("client.Get: got %v, want unknown value error", err)
+		}
+	})
+}
+
 func TestPanic(t *testing.T) {
 	t.Skip("weavertest crashes if any component panics, even in another process")
 	ctx := context.Background()
@@ -115,6 +145,96 @@ func TestPointers(t *testing.T) {
 	}
 }
 
+func TestInterfaces(t *testing.T) {
+	for _, runner := range weavertest.AllRunners() {
+		ctx := context.Background()
+		runner.Test(t, func(t *testing.T, client testApp) {
+			for _, test := range []struct{ in, want shape }{
+				{nil, nil},
+				{rect{w: 2, h: 3}, rect{w: 4, h: 6}},
+				{&square{s: 2}, &square{s: 4}},
+			} {
+				got, err := client.Scale(ctx, test.in, 2)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Fatalf("Scale(%#v, 2): got %#v, want %#v", test.in, got, test.want)
+				}
+			}
+		})
+	}
+}
+
+func TestLargeCollections(t *testing.T) {
+	// Slices and maps this large are serialized in chunks.
+	const n = 20000
+	words := make([]string, n)
+	want := map[string]int{}
+	for i := range words {
+		words[i] = strconv.Itoa(i % (n / 2))
+		want[words[i]]++
+	}
+
+	for _, runner := range weavertest.AllRunners() {
+		runner.Test(t, func(t *testing.T, client testApp) {
+			var reports int
+			ctx := weaver.WithProgress(context.Background(), func(done, total int) {
+				reports++
+			})
+			got, err := client.Count(ctx, words)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("Count: got %d words, want %d", len(got), len(want))
+			}
+			if runner.Name == weavertest.Local.Name {
+				// Colocated calls don't serialize arguments and results.
+				return
+			}
+			// 5 chunks of arguments and 3 chunks of results.
+			if reports != 8 {
+				t.Fatalf("got %d progress reports, want 8", reports)
+			}
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	// The Multi runner replicates testApp, but doesn't assign routing keys
+	// to replicas, so a watch may not reach the replica that publishes the
+	// changes it watches.
+	for _, runner := range []weavertest.Runner{weavertest.Local, weavertest.RPC} {
+		runner.Test(t, func(t *testing.T, client testApp) {
+			ctx := context.Background()
+			token, err := client.Publish(ctx, "a", rect{w: 1, h: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := client.Publish(ctx, "a", rect{w: 3, h: 4}); err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			errDone := errors.New("done")
+			err = weaver.Watch(ctx, client, "a", token, func(c weaver.WatchChange[rect]) error {
+				got = append(got, c.Value.area())
+				if len(got) == 2 {
+					return errDone
+				}
+				return nil
+			})
+			if !errors.Is(err, errDone) {
+				t.Fatalf("Watch: got %v, want %v", err, errDone)
+			}
+			if want := []int{2, 12}; !reflect.DeepEqual(got, want) {
+				t.Fatalf("Watch: got areas %v, want %v", got, want)
+			}
+		})
+	}
+}
+
 func TestReflectStubs(t *testing.T) {
 	fakeErr := fmt.Errorf("fake error")
 	call := func(method string, _ context.Context, args, returns []any) error {
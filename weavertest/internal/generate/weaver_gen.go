@@ -16,14 +16,15 @@ import (
 
 func init() {
 	codegen.Register(codegen.Registration{
-		Name:  "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp",
-		Iface: reflect.TypeOf((*testApp)(nil)).Elem(),
-		Impl:  reflect.TypeOf(impl{}),
+		Name:   "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp",
+		Iface:  reflect.TypeOf((*testApp)(nil)).Elem(),
+		Impl:   reflect.TypeOf(impl{}),
+		Routed: true,
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return testApp_local_stub{impl: impl.(testApp), tracer: tracer, divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "DivMod", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Get", Remote: false, Generated: true}), incPointerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "IncPointer", Remote: false, Generated: true})}
+			return testApp_local_stub{impl: impl.(testApp), tracer: tracer, caller: codegen.Caller{Component: caller}, countMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Count", Remote: false, Generated: true}), divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "DivMod", Remote: false, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Get", Remote: false, Generated: true}), incPointerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "IncPointer", Remote: false, Generated: true}), publishMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Publish", Remote: false, Generated: true}), scaleMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Scale", Remote: false, Generated: true}), watchChangesMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "WatchChanges", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return testApp_client_stub{stub: stub, divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "DivMod", Remote: true, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Get", Remote: true, Generated: true}), incPointerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "IncPointer", Remote: true, Generated: true})}
+			return testApp_client_stub{stub: stub, countMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Count", Remote: true, Generated: true}), divModMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "DivMod", Remote: true, Generated: true}), getMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Get", Remote: true, Generated: true}), incPointerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "IncPointer", Remote: true, Generated: true}), publishMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Publish", Remote: true, Generated: true}), scaleMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "Scale", Remote: true, Generated: true}), watchChangesMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/generate/testApp", Method: "WatchChanges", Remote: true, Generated: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return testApp_server_stub{impl: impl.(testApp), addLoad: addLoad}
@@ -31,7 +32,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return testApp_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "84d9644c7958a9ab",
 	})
 }
 
@@ -39,25 +43,80 @@ func init() {
 var _ weaver.InstanceOf[testApp] = (*impl)(nil)
 
 // weaver.Router checks.
-var _ weaver.Unrouted = (*impl)(nil)
+var _ weaver.RoutedBy[watchRouter] = (*impl)(nil)
+
+// Component "impl", router "watchRouter" checks.
+type __impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate struct {
+	watchRouter
+	__impl_watchRouter_embedding
+}
+
+type __impl_watchRouter_embedding struct{}
+
+func (__impl_watchRouter_embedding) Count()      {}
+func (__impl_watchRouter_embedding) DivMod()     {}
+func (__impl_watchRouter_embedding) Get()        {}
+func (__impl_watchRouter_embedding) IncPointer() {}
+func (__impl_watchRouter_embedding) Scale()      {}
+
+var _ func(_ context.Context, key string, _ rect) string = (&watchRouter{}).Publish                        // routed
+var _ func(_ context.Context, prefix string, _ weaver.WatchToken) string = (&watchRouter{}).WatchChanges   // routed
+var _ = (&__impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Count      // unrouted
+var _ = (&__impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).DivMod     // unrouted
+var _ = (&__impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Get        // unrouted
+var _ = (&__impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).IncPointer // unrouted
+var _ = (&__impl_watchRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Scale      // unrouted
 
 // Local stub implementations.
 
 type testApp_local_stub struct {
-	impl              testApp
-	tracer            trace.Tracer
-	divModMetrics     *codegen.MethodMetrics
-	getMetrics        *codegen.MethodMetrics
-	incPointerMetrics *codegen.MethodMetrics
+	impl                testApp
+	tracer              trace.Tracer
+	caller              codegen.Caller
+	countMetrics        *codegen.MethodMetrics
+	divModMetrics       *codegen.MethodMetrics
+	getMetrics          *codegen.MethodMetrics
+	incPointerMetrics   *codegen.MethodMetrics
+	publishMetrics      *codegen.MethodMetrics
+	scaleMetrics        *codegen.MethodMetrics
+	watchChangesMetrics *codegen.MethodMetrics
 }
 
 // Check that testApp_local_stub implements the testApp interface.
 var _ testApp = (*testApp_local_stub)(nil)
 
+func (s testApp_local_stub) Count(ctx context.Context, a0 []string) (r0 map[string]int, err error) {
+	// Update metrics.
+	begin := s.countMetrics.Begin()
+	defer func() { s.countMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "generate.testApp.Count", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Count(ctx, a0)
+}
+
 func (s testApp_local_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int, r1 int, err error) {
 	// Update metrics.
 	begin := s.divModMetrics.Begin()
 	defer func() { s.divModMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -78,6 +137,10 @@ func (s testApp_local_stub) Get(ctx context.Context, a0 string, a1 behaviorType)
 	// Update metrics.
 	begin := s.getMetrics.Begin()
 	defer func() { s.getMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -98,6 +161,10 @@ func (s testApp_local_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int, e
 	// Update metrics.
 	begin := s.incPointerMetrics.Begin()
 	defer func() { s.incPointerMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -114,18 +181,148 @@ func (s testApp_local_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int, e
 	return s.impl.IncPointer(ctx, a0)
 }
 
+func (s testApp_local_stub) Publish(ctx context.Context, a0 string, a1 rect) (r0 weaver.WatchToken, err error) {
+	// Update metrics.
+	begin := s.publishMetrics.Begin()
+	defer func() { s.publishMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "generate.testApp.Publish", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Publish(ctx, a0, a1)
+}
+
+func (s testApp_local_stub) Scale(ctx context.Context, a0 shape, a1 int) (r0 shape, err error) {
+	// Update metrics.
+	begin := s.scaleMetrics.Begin()
+	defer func() { s.scaleMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "generate.testApp.Scale", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Scale(ctx, a0, a1)
+}
+
+func (s testApp_local_stub) WatchChanges(ctx context.Context, a0 string, a1 weaver.WatchToken) (r0 weaver.WatchBatch[rect], err error) {
+	// Update metrics.
+	begin := s.watchChangesMetrics.Begin()
+	defer func() { s.watchChangesMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "generate.testApp.WatchChanges", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.WatchChanges(ctx, a0, a1)
+}
+
 // Client stub implementations.
 
 type testApp_client_stub struct {
-	stub              codegen.Stub
-	divModMetrics     *codegen.MethodMetrics
-	getMetrics        *codegen.MethodMetrics
-	incPointerMetrics *codegen.MethodMetrics
+	stub                codegen.Stub
+	countMetrics        *codegen.MethodMetrics
+	divModMetrics       *codegen.MethodMetrics
+	getMetrics          *codegen.MethodMetrics
+	incPointerMetrics   *codegen.MethodMetrics
+	publishMetrics      *codegen.MethodMetrics
+	scaleMetrics        *codegen.MethodMetrics
+	watchChangesMetrics *codegen.MethodMetrics
 }
 
 // Check that testApp_client_stub implements the testApp interface.
 var _ testApp = (*testApp_client_stub)(nil)
 
+func (s testApp_client_stub) Count(ctx context.Context, a0 []string) (r0 map[string]int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.countMetrics.Begin()
+	defer func() { s.countMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "generate.testApp.Count", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	serviceweaver_enc_slice_string_4af10117(enc, a0)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_map_string_int_c20ee031(dec)
+	err = dec.Error()
+	return
+}
+
 func (s testApp_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int, r1 int, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -159,7 +356,7 @@ func (s testApp_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int
 	size := 0
 	size += 8
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -170,7 +367,8 @@ func (s testApp_client_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -218,18 +416,19 @@ func (s testApp_client_stub) Get(ctx context.Context, a0 string, a1 behaviorType
 	size := 0
 	size += (4 + len(a0))
 	size += 8
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
 	enc.String(a0)
-	enc.Int((int)(a1))
+	(a1).WeaverMarshal(enc)
 	var shardKey uint64
 
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 1, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -275,7 +474,7 @@ func (s testApp_client_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int,
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += serviceweaver_size_ptr_int_98a2a745(a0)
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -285,7 +484,8 @@ func (s testApp_client_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int,
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -299,13 +499,187 @@ func (s testApp_client_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int,
 	return
 }
 
+func (s testApp_client_stub) Publish(ctx context.Context, a0 string, a1 rect) (r0 weaver.WatchToken, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.publishMetrics.Begin()
+	defer func() { s.publishMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "generate.testApp.Publish", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Preallocate a buffer of the right size.
+	size := 0
+	size += (4 + len(a0))
+	size += serviceweaver_size_rect_bf1eb8c9(&a1)
+	enc := codegen.NewPooledEncoder()
+	enc.Reset(size)
+
+	// Encode arguments.
+	enc.String(a0)
+	(a1).WeaverMarshal(enc)
+
+	// Set the shardKey.
+	var r watchRouter
+	shardKey := _hashTestApp(r.Publish(ctx, a0, a1))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	*(*string)(&r0) = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s testApp_client_stub) Scale(ctx context.Context, a0 shape, a1 int) (r0 shape, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.scaleMetrics.Begin()
+	defer func() { s.scaleMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "generate.testApp.Scale", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	enc.SetProgress(codegen.ProgressFromContext(ctx))
+	enc.Concrete(a0)
+	enc.Int(a1)
+	var shardKey uint64
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = codegen.DecodeConcrete[shape](dec)
+	err = dec.Error()
+	return
+}
+
+func (s testApp_client_stub) WatchChanges(ctx context.Context, a0 string, a1 weaver.WatchToken) (r0 weaver.WatchBatch[rect], err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.watchChangesMetrics.Begin()
+	defer func() { s.watchChangesMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "generate.testApp.WatchChanges", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	// Encode arguments.
+	enc := codegen.NewPooledEncoder()
+	enc.String(a0)
+	enc.String((string)(a1))
+
+	// Set the shardKey.
+	var r watchRouter
+	shardKey := _hashTestApp(r.WatchChanges(ctx, a0, a1))
+
+	// Call the remote method.
+	requestBytes = len(enc.Data())
+	var results []byte
+	results, err = s.stub.Run(ctx, 6, enc.Data(), shardKey)
+	enc.Release()
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	serviceweaver_dec_WatchBatch_rect_d0928abb(dec, &r0)
+	err = dec.Error()
+	return
+}
+
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -335,17 +709,50 @@ var _ codegen.Server = (*testApp_server_stub)(nil)
 // GetStubFn implements the codegen.Server interface.
 func (s testApp_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
+	case "Count":
+		return s.count
 	case "DivMod":
 		return s.divMod
 	case "Get":
 		return s.get
 	case "IncPointer":
 		return s.incPointer
+	case "Publish":
+		return s.publish
+	case "Scale":
+		return s.scale
+	case "WatchChanges":
+		return s.watchChanges
 	default:
 		return nil
 	}
 }
 
+func (s testApp_server_stub) count(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 []string
+	a0 = serviceweaver_dec_slice_string_4af10117(dec)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Count(ctx, a0)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_map_string_int_c20ee031(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s testApp_server_stub) divMod(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -387,7 +794,7 @@ func (s testApp_server_stub) get(ctx context.Context, args []byte) (res []byte,
 	var a0 string
 	a0 = dec.String()
 	var a1 behaviorType
-	*(*int)(&a1) = dec.Int()
+	(&a1).WeaverUnmarshal(dec)
 
 	// TODO(rgrandl): The deferred function above will recover from panics in the
 	// user code: fix this.
@@ -426,6 +833,91 @@ func (s testApp_server_stub) incPointer(ctx context.Context, args []byte) (res [
 	return enc.Data(), nil
 }
 
+func (s testApp_server_stub) publish(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 rect
+	(&a1).WeaverUnmarshal(dec)
+	var r watchRouter
+	s.addLoad(_hashTestApp(r.Publish(ctx, a0, a1)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Publish(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String((string)(r0))
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s testApp_server_stub) scale(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 shape
+	a0 = codegen.DecodeConcrete[shape](dec)
+	var a1 int
+	a1 = dec.Int()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Scale(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.Concrete(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s testApp_server_stub) watchChanges(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// Decode arguments.
+	dec := codegen.NewDecoder(args)
+	var a0 string
+	a0 = dec.String()
+	var a1 weaver.WatchToken
+	*(*string)(&a1) = dec.String()
+	var r watchRouter
+	s.addLoad(_hashTestApp(r.WatchChanges(ctx, a0, a1)), 1.0)
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.WatchChanges(ctx, a0, a1)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_WatchBatch_rect_d0928abb(enc, &r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 // Reflect stub implementations.
 
 type testApp_reflect_stub struct {
@@ -435,6 +927,11 @@ type testApp_reflect_stub struct {
 // Check that testApp_reflect_stub implements the testApp interface.
 var _ testApp = (*testApp_reflect_stub)(nil)
 
+func (s testApp_reflect_stub) Count(ctx context.Context, a0 []string) (r0 map[string]int, err error) {
+	err = s.caller("Count", ctx, []any{a0}, []any{&r0})
+	return
+}
+
 func (s testApp_reflect_stub) DivMod(ctx context.Context, a0 int, a1 int) (r0 int, r1 int, err error) {
 	err = s.caller("DivMod", ctx, []any{a0, a1}, []any{&r0, &r1})
 	return
@@ -450,6 +947,21 @@ func (s testApp_reflect_stub) IncPointer(ctx context.Context, a0 *int) (r0 *int,
 	return
 }
 
+func (s testApp_reflect_stub) Publish(ctx context.Context, a0 string, a1 rect) (r0 weaver.WatchToken, err error) {
+	err = s.caller("Publish", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
+func (s testApp_reflect_stub) Scale(ctx context.Context, a0 shape, a1 int) (r0 shape, err error) {
+	err = s.caller("Scale", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
+func (s testApp_reflect_stub) WatchChanges(ctx context.Context, a0 string, a1 weaver.WatchToken) (r0 weaver.WatchBatch[rect], err error) {
+	err = s.caller("WatchChanges", ctx, []any{a0, a1}, []any{&r0})
+	return
+}
+
 // AutoMarshal implementations.
 
 var _ codegen.AutoMarshal = (*customErrorValue)(nil)
@@ -476,8 +988,163 @@ func (x *customErrorValue) WeaverUnmarshal(dec *codegen.Decoder) {
 }
 func init() { codegen.RegisterSerializable[*customErrorValue]() }
 
+var _ codegen.AutoMarshal = (*rect)(nil)
+
+type __is_rect[T ~struct {
+	weaver.AutoMarshal
+	w int
+	h int
+}] struct{}
+
+var _ __is_rect[rect]
+
+func (x *rect) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("rect.WeaverMarshal: nil receiver"))
+	}
+	enc.Int(x.w)
+	enc.Int(x.h)
+}
+
+func (x *rect) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("rect.WeaverUnmarshal: nil receiver"))
+	}
+	x.w = dec.Int()
+	x.h = dec.Int()
+}
+
+var _ codegen.AutoMarshal = (*square)(nil)
+
+type __is_square[T ~struct {
+	weaver.AutoMarshal
+	s int
+}] struct{}
+
+var _ __is_square[square]
+
+func (x *square) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("square.WeaverMarshal: nil receiver"))
+	}
+	enc.Int(x.s)
+}
+
+func (x *square) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("square.WeaverUnmarshal: nil receiver"))
+	}
+	x.s = dec.Int()
+}
+
+// Enum implementations.
+
+var _ codegen.AutoMarshal = (*behaviorType)(nil)
+
+type __is_behaviorType[T ~int] struct{}
+
+var _ __is_behaviorType[behaviorType]
+
+func (x *behaviorType) WeaverMarshal(enc *codegen.Encoder) {
+	if x == nil {
+		panic(fmt.Errorf("behaviorType.WeaverMarshal: nil receiver"))
+	}
+	enc.Int((int)(*x))
+}
+
+func (x *behaviorType) WeaverUnmarshal(dec *codegen.Decoder) {
+	if x == nil {
+		panic(fmt.Errorf("behaviorType.WeaverUnmarshal: nil receiver"))
+	}
+	*(*int)(x) = dec.Int()
+	switch *x {
+	case appError, panicError, customError, noError:
+	default:
+		dec.UnknownEnum("behaviorType", *x)
+	}
+}
+
+// Router methods.
+
+// _hashTestApp returns a 64 bit hash of the provided value.
+func _hashTestApp(r string) uint64 {
+	var h codegen.Hasher
+	h.WriteString(string(r))
+	return h.Sum64()
+}
+
+// _orderedCodeTestApp returns an order-preserving serialization of the provided value.
+func _orderedCodeTestApp(r string) codegen.OrderedCode {
+	var enc codegen.OrderedEncoder
+	enc.WriteString(string(r))
+	return enc.Encode()
+}
+
 // Encoding/decoding implementations.
 
+func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 4)
+	res := make([]string, n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_map_string_int_c20ee031(enc *codegen.Encoder, arg map[string]int) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	m := enc.Map()
+	for k, v := range arg {
+		m.Key()
+		enc.String(k)
+		m.Value()
+		enc.Int(v)
+	}
+	m.End()
+}
+
+func serviceweaver_dec_map_string_int_c20ee031(dec *codegen.Decoder) map[string]int {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 12)
+	res := make(map[string]int, n)
+	var k string
+	var v int
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k = dec.String()
+			v = dec.Int()
+			res[k] = v
+		}
+	})
+	return res
+}
+
 func serviceweaver_enc_ptr_int_98a2a745(enc *codegen.Encoder, arg *int) {
 	if arg == nil {
 		enc.Bool(false)
@@ -496,6 +1163,56 @@ func serviceweaver_dec_ptr_int_98a2a745(dec *codegen.Decoder) *int {
 	return &res
 }
 
+func serviceweaver_enc_WatchChange_rect_8783642a(enc *codegen.Encoder, arg *weaver.WatchChange[rect]) {
+	enc.String(arg.Key)
+	(arg.Value).WeaverMarshal(enc)
+	enc.String((string)(arg.Token))
+}
+
+func serviceweaver_dec_WatchChange_rect_8783642a(dec *codegen.Decoder, res *weaver.WatchChange[rect]) {
+	res.Key = dec.String()
+	(&res.Value).WeaverUnmarshal(dec)
+	*(*string)(&res.Token) = dec.String()
+}
+
+func serviceweaver_enc_slice_WatchChange_rect_2d92059f(enc *codegen.Encoder, arg []weaver.WatchChange[rect]) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			serviceweaver_enc_WatchChange_rect_8783642a(enc, &arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_WatchChange_rect_2d92059f(dec *codegen.Decoder) []weaver.WatchChange[rect] {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 24)
+	res := make([]weaver.WatchChange[rect], n)
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			serviceweaver_dec_WatchChange_rect_8783642a(dec, &res[i])
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_WatchBatch_rect_d0928abb(enc *codegen.Encoder, arg *weaver.WatchBatch[rect]) {
+	serviceweaver_enc_slice_WatchChange_rect_2d92059f(enc, arg.Changes)
+	enc.String((string)(arg.Token))
+}
+
+func serviceweaver_dec_WatchBatch_rect_d0928abb(dec *codegen.Decoder, res *weaver.WatchBatch[rect]) {
+	res.Changes = serviceweaver_dec_slice_WatchChange_rect_2d92059f(dec)
+	*(*string)(&res.Token) = dec.String()
+}
+
 // Size implementations.
 
 // serviceweaver_size_ptr_int_98a2a745 returns the size (in bytes) of the serialization
@@ -507,3 +1224,13 @@ func serviceweaver_size_ptr_int_98a2a745(x *int) int {
 		return 1 + 8
 	}
 }
+
+// serviceweaver_size_rect_bf1eb8c9 returns the size (in bytes) of the serialization
+// of the provided type.
+func serviceweaver_size_rect_bf1eb8c9(x *rect) int {
+	size := 0
+	size += 0
+	size += 8
+	size += 8
+	return size
+}
@@ -36,6 +36,8 @@ import (
 
 type Source interface {
 	Emit(ctx context.Context, file, msg string) error
+	DestinationCaller(ctx context.Context) (string, error)
+	DestinationPids(ctx context.Context) ([]int, error)
 }
 
 type source struct {
@@ -47,6 +49,24 @@ func (s *source) Emit(ctx context.Context, file, msg string) error {
 	return s.dst.Get().Record(ctx, file, msg)
 }
 
+// DestinationCaller returns the caller identity observed by the destination
+// when called by the source.
+func (s *source) DestinationCaller(ctx context.Context) (string, error) {
+	return s.dst.Get().Caller(ctx)
+}
+
+// DestinationPids returns the PIDs of every replica of the destination,
+// in no particular order.
+func (s *source) DestinationPids(ctx context.Context) ([]int, error) {
+	return weaver.Broadcast(ctx, s.dst,
+		func(ctx context.Context, dst Destination) ([]int, error) {
+			pid, err := dst.Getpid(ctx)
+			return []int{pid}, err
+		},
+		func(x, y []int) []int { return append(x, y...) },
+	)
+}
+
 type Destination interface {
 	Getpid(_ context.Context) (int, error)
 	Record(_ context.Context, file, msg string) error
@@ -54,6 +74,9 @@ type Destination interface {
 	RoutedRecord(_ context.Context, file, msg string) error
 	UpdateMetadata(_ context.Context) error
 	GetMetadata(_ context.Context) (map[string]string, error)
+	Caller(_ context.Context) (string, error)
+	Replica(_ context.Context) (string, int, error)
+	Callers(_ context.Context) (map[string]int64, error)
 }
 
 var (
@@ -133,6 +156,41 @@ func (d *destination) GetMetadata(_ context.Context) (map[string]string, error)
 	return d.metadata, nil
 }
 
+// Caller returns the calling component.
+func (d *destination) Caller(ctx context.Context) (string, error) {
+	id, ok := weaver.CallerIdentity(ctx)
+	if !ok {
+		return "", fmt.Errorf("missing caller identity")
+	}
+	return id.Component, nil
+}
+
+// Replica returns the colocation group and the replica index of the
+// destination replica that handles the call.
+func (d *destination) Replica(ctx context.Context) (string, int, error) {
+	r, ok := weaver.ReplicaInfo(ctx)
+	if !ok {
+		return "", 0, fmt.Errorf("missing replica info")
+	}
+	return r.Group, r.Index, nil
+}
+
+// Callers returns the number of calls made to the destination by every
+// calling component, as reported by weaver.Topology.
+func (d *destination) Callers(ctx context.Context) (map[string]int64, error) {
+	graph, err := weaver.Topology(ctx)
+	if err != nil {
+		return nil, err
+	}
+	callers := map[string]int64{}
+	for _, e := range graph.Edges {
+		if e.Component == "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination" {
+			callers[e.Caller] += e.Calls
+		}
+	}
+	return callers, nil
+}
+
 // Server is a component used to test Service Weaver listener handling.
 // An HTTP server is started when this component is initialized.
 // simple_test.go checks the functionality of the HTTP server by fetching
@@ -22,12 +22,15 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ServiceWeaver/weaver"
 	"github.com/ServiceWeaver/weaver/internal/traceio"
 	"github.com/ServiceWeaver/weaver/metadata"
+	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/ServiceWeaver/weaver/weavertest"
 	"github.com/ServiceWeaver/weaver/weavertest/internal/simple"
 	"github.com/google/uuid"
@@ -109,6 +112,20 @@ func TestContextWithMetadata(t *testing.T) {
 	}
 }
 
+func TestCallerIdentity(t *testing.T) {
+	for _, runner := range weavertest.AllRunners() {
+		runner.Test(t, func(t *testing.T, src simple.Source) {
+			got, err := src.DestinationCaller(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source"; got != want {
+				t.Fatalf("caller: got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
 type fakeDest struct{ file, msg string }
 
 func (f *fakeDest) Getpid(context.Context) (int, error)                    { return 100, nil }
@@ -116,6 +133,9 @@ func (f *fakeDest) GetAll(context.Context, string) ([]string, error)       { ret
 func (f *fakeDest) RoutedRecord(context.Context, string, string) error     { return nil }
 func (f *fakeDest) UpdateMetadata(context.Context) error                   { return nil }
 func (f *fakeDest) GetMetadata(context.Context) (map[string]string, error) { return nil, nil }
+func (f *fakeDest) Caller(context.Context) (string, error)                 { return "", nil }
+func (f *fakeDest) Replica(context.Context) (string, int, error)           { return "", 0, nil }
+func (f *fakeDest) Callers(context.Context) (map[string]int64, error)      { return nil, nil }
 func (f *fakeDest) Record(ctx context.Context, file, msg string) error {
 	f.file = file
 	f.msg = msg
@@ -135,6 +155,28 @@ func TestFake(t *testing.T) {
 	}
 }
 
+func TestRegistry(t *testing.T) {
+	// Run Source against a registry that holds only Source and the
+	// Destination it references.
+	const src = "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source"
+	reg, err := codegen.Global().Subset([]string{src}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(reg.Registered()); got != 2 {
+		t.Fatalf("len(Registered()) = %d; expecting 2", got)
+	}
+	for _, runner := range weavertest.AllRunners() {
+		runner.Registry = reg
+		runner.Test(t, func(t *testing.T, src simple.Source) {
+			file := filepath.Join(t.TempDir(), "registry")
+			if err := src.Emit(context.Background(), file, "msg"); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
 func TestTwoComponents(t *testing.T) {
 	// Add a list of items to a component (dst) from another component (src). Verify that
 	// dst updates the state accordingly.
@@ -173,6 +215,46 @@ func TestTwoComponents(t *testing.T) {
 	}
 }
 
+func TestLazy(t *testing.T) {
+	// Construct Destination lazily, on its first method call.
+	ctx := context.Background()
+	const lazy = `
+		[serviceweaver]
+		lazy = ["github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination"]
+	`
+	colocate := weavertest.Multi
+	colocate.Name = "Colocate"
+	colocate.Config = lazy + `
+		colocate = [
+		  [
+		    "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source",
+		    "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination",
+		  ]
+		]
+	`
+	var runners []weavertest.Runner
+	for _, runner := range weavertest.AllRunners() {
+		runner.Config = lazy
+		runners = append(runners, runner)
+	}
+
+	for _, runner := range append(runners, colocate) {
+		runner.Test(t, func(t *testing.T, src simple.Source, dst simple.Destination) {
+			file := filepath.Join(t.TempDir(), fmt.Sprintf("simple_%s", uuid.New().String()))
+			if err := src.Emit(ctx, file, "a"); err != nil {
+				t.Fatal(err)
+			}
+			got, err := dst.GetAll(ctx, file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := []string{"a"}; !reflect.DeepEqual(want, got) {
+				t.Fatalf("GetAll() = %v; expecting %v", got, want)
+			}
+		})
+	}
+}
+
 func TestServer(t *testing.T) {
 	for _, runner := range weavertest.AllRunners() {
 		runner.Test(t, func(t *testing.T, srv simple.Server) {
@@ -243,6 +325,121 @@ func TestRoutedCall(t *testing.T) {
 	}
 }
 
+func TestIdempotencyKey(t *testing.T) {
+	// Make duplicate calls to a method that is not retriable. The RPC runner
+	// also injects a retry into every retriable call, and calls to routed
+	// methods with an idempotency key are retriable. Calls are deduplicated by
+	// the replica that handles them, so we use the RPC runner, which doesn't
+	// replicate components. The Local runner doesn't make remote calls, and
+	// the Multi runner replicates Destination.
+	ctx := context.Background()
+	weavertest.RPC.Test(t, func(t *testing.T, dst simple.Destination) {
+		file := filepath.Join(t.TempDir(), fmt.Sprintf("simple_%s", uuid.New().String()))
+		for _, key := range []string{"a", "a", "b", "a"} {
+			ctx := weaver.WithIdempotencyKey(ctx, key)
+			if err := dst.RoutedRecord(ctx, file, key); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		want := []string{"routed: a", "routed: b"}
+		got, err := dst.GetAll(ctx, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(want, got) {
+			t.Fatalf("GetAll() = %v; expecting %v", got, want)
+		}
+	})
+}
+
+func TestBroadcast(t *testing.T) {
+	for _, runner := range weavertest.AllRunners() {
+		runner.Test(t, func(t *testing.T, src simple.Source) {
+			// The Multi runner runs two replicas of Destination, in two
+			// different processes. The source may not know of both replicas
+			// right away, so we retry until it does.
+			want := 1
+			if runner.Name == weavertest.Multi.Name {
+				want = 2
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			for {
+				pids, err := src.DestinationPids(ctx)
+				if err != nil {
+					t.Fatal(err)
+				}
+				slices.Sort(pids)
+				if got := len(slices.Compact(pids)); got == want {
+					return
+				} else if got > want {
+					t.Fatalf("DestinationPids() = %v; expecting %d distinct pids", pids, want)
+				}
+				select {
+				case <-ctx.Done():
+					t.Fatalf("DestinationPids() = %v; expecting %d distinct pids", pids, want)
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+		})
+	}
+}
+
+func TestReplicaInfo(t *testing.T) {
+	// The Local runner makes local calls, which carry the caller's context,
+	// and the test's context doesn't come from Service Weaver. The RPC runner
+	// runs everything in the main group, with a single replica. The Multi
+	// runner runs two replicas of Destination in a group of its own.
+	for _, runner := range []weavertest.Runner{weavertest.RPC, weavertest.Multi} {
+		runner.Test(t, func(t *testing.T, dst simple.Destination) {
+			want, n := "main", 1
+			if runner.Name == weavertest.Multi.Name {
+				want, n = "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", 2
+			}
+			group, index, err := dst.Replica(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if group != want {
+				t.Errorf("group: got %q, want %q", group, want)
+			}
+			if index < 0 || index >= n {
+				t.Errorf("index: got %d, want [0, %d)", index, n)
+			}
+		})
+	}
+}
+
+func TestTopology(t *testing.T) {
+	// The Local runner makes local calls, which carry the caller's context,
+	// and the test's context doesn't come from Service Weaver.
+	for _, runner := range []weavertest.Runner{weavertest.RPC, weavertest.Multi} {
+		runner.Test(t, func(t *testing.T, dst simple.Destination) {
+			ctx := context.Background()
+			const n = 5
+			for i := 0; i < n; i++ {
+				if _, err := dst.Getpid(ctx); err != nil {
+					t.Fatal(err)
+				}
+			}
+			callers, err := dst.Callers(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Metrics are shared by all the tests in the process, so there
+			// may be more calls than the ones made here.
+			var got int64
+			for _, calls := range callers {
+				got += calls
+			}
+			if got < n {
+				t.Fatalf("calls: got %d, want >= %d (callers: %v)", got, n, callers)
+			}
+		})
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	for _, runner := range weavertest.AllRunners() {
 		runner.Bench(b, func(b *testing.B, dst simple.Destination) {
@@ -257,6 +454,13 @@ func BenchmarkCall(b *testing.B) {
 	}
 }
 
+func BenchmarkCaller(b *testing.B) {
+	weavertest.Benchmark(b, weavertest.AllRunners(), func(ctx context.Context, dst simple.Destination) error {
+		_, err := dst.Caller(ctx)
+		return err
+	})
+}
+
 func BenchmarkTracedCall(b *testing.B) {
 	weavertest.Local.Bench(b, func(b *testing.B, dst simple.Destination) {
 		ctx, span := traceio.TestTracer().Start(context.Background(), "foo")
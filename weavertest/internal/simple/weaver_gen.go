@@ -19,12 +19,12 @@ func init() {
 		Iface:   reflect.TypeOf((*Destination)(nil)).Elem(),
 		Impl:    reflect.TypeOf(destination{}),
 		Routed:  true,
-		NoRetry: []int{3, 4},
+		NoRetry: []int{5, 7},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return destination_local_stub{impl: impl.(Destination), tracer: tracer, getAllMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetAll", Remote: false, Generated: true}), getMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetMetadata", Remote: false, Generated: true}), getpidMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Getpid", Remote: false, Generated: true}), recordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Record", Remote: false, Generated: true}), routedRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "RoutedRecord", Remote: false, Generated: true}), updateMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "UpdateMetadata", Remote: false, Generated: true})}
+			return destination_local_stub{impl: impl.(Destination), tracer: tracer, caller: codegen.Caller{Component: caller}, callerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Caller", Remote: false, Generated: true}), callersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Callers", Remote: false, Generated: true}), getAllMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetAll", Remote: false, Generated: true}), getMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetMetadata", Remote: false, Generated: true}), getpidMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Getpid", Remote: false, Generated: true}), recordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Record", Remote: false, Generated: true}), replicaMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Replica", Remote: false, Generated: true}), routedRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "RoutedRecord", Remote: false, Generated: true}), updateMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "UpdateMetadata", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return destination_client_stub{stub: stub, getAllMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetAll", Remote: true, Generated: true}), getMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetMetadata", Remote: true, Generated: true}), getpidMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Getpid", Remote: true, Generated: true}), recordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Record", Remote: true, Generated: true}), routedRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "RoutedRecord", Remote: true, Generated: true}), updateMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "UpdateMetadata", Remote: true, Generated: true})}
+			return destination_client_stub{stub: stub, callerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Caller", Remote: true, Generated: true}), callersMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Callers", Remote: true, Generated: true}), getAllMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetAll", Remote: true, Generated: true}), getMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "GetMetadata", Remote: true, Generated: true}), getpidMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Getpid", Remote: true, Generated: true}), recordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Record", Remote: true, Generated: true}), replicaMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "Replica", Remote: true, Generated: true}), routedRecordMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "RoutedRecord", Remote: true, Generated: true}), updateMetadataMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination", Method: "UpdateMetadata", Remote: true, Generated: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return destination_server_stub{impl: impl.(Destination), addLoad: addLoad}
@@ -32,7 +32,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return destination_reflect_stub{caller: caller}
 		},
-		RefData: "",
+		RefData:          "",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "a92e322c88074df1",
 	})
 	codegen.Register(codegen.Registration{
 		Name:      "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server",
@@ -40,7 +43,7 @@ func init() {
 		Impl:      reflect.TypeOf(server{}),
 		Listeners: []string{"hello"},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return server_local_stub{impl: impl.(Server), tracer: tracer, addressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Address", Remote: false, Generated: true}), proxyAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "ProxyAddress", Remote: false, Generated: true}), shutdownMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Shutdown", Remote: false, Generated: true})}
+			return server_local_stub{impl: impl.(Server), tracer: tracer, caller: codegen.Caller{Component: caller}, addressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Address", Remote: false, Generated: true}), proxyAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "ProxyAddress", Remote: false, Generated: true}), shutdownMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Shutdown", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
 			return server_client_stub{stub: stub, addressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Address", Remote: true, Generated: true}), proxyAddressMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "ProxyAddress", Remote: true, Generated: true}), shutdownMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server", Method: "Shutdown", Remote: true, Generated: true})}
@@ -51,18 +54,21 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return server_reflect_stub{caller: caller}
 		},
-		RefData: "⟦1e2dce71:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server→hello⟧\n",
+		RefData:          "⟦1e2dce71:wEaVeRlIsTeNeRs:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Server→hello⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "9fbcdedf9d50de33",
 	})
 	codegen.Register(codegen.Registration{
 		Name:    "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source",
 		Iface:   reflect.TypeOf((*Source)(nil)).Elem(),
 		Impl:    reflect.TypeOf(source{}),
-		NoRetry: []int{0},
+		NoRetry: []int{2},
 		LocalStubFn: func(impl any, caller string, tracer trace.Tracer) any {
-			return source_local_stub{impl: impl.(Source), tracer: tracer, emitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "Emit", Remote: false, Generated: true})}
+			return source_local_stub{impl: impl.(Source), tracer: tracer, caller: codegen.Caller{Component: caller}, destinationCallerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "DestinationCaller", Remote: false, Generated: true}), destinationPidsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "DestinationPids", Remote: false, Generated: true}), emitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "Emit", Remote: false, Generated: true})}
 		},
 		ClientStubFn: func(stub codegen.Stub, caller string) any {
-			return source_client_stub{stub: stub, emitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "Emit", Remote: true, Generated: true})}
+			return source_client_stub{stub: stub, destinationCallerMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "DestinationCaller", Remote: true, Generated: true}), destinationPidsMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "DestinationPids", Remote: true, Generated: true}), emitMetrics: codegen.MethodMetricsFor(codegen.MethodLabels{Caller: caller, Component: "github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source", Method: "Emit", Remote: true, Generated: true})}
 		},
 		ServerStubFn: func(impl any, addLoad func(uint64, float64)) codegen.Server {
 			return source_server_stub{impl: impl.(Source), addLoad: addLoad}
@@ -70,7 +76,10 @@ func init() {
 		ReflectStubFn: func(caller func(string, context.Context, []any, []any) error) any {
 			return source_reflect_stub{caller: caller}
 		},
-		RefData: "⟦bf914175:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source→github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination⟧\n",
+		RefData:          "⟦bf914175:wEaVeReDgE:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source→github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination⟧\n⟦a45b0eea:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source.Emit→github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination.Record@simple.go:49:9⟧\n⟦1d1efb62:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source.DestinationCaller→github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination.Caller@simple.go:55:9⟧\n⟦47b56b06:wEaVeRcAlL:github.com/ServiceWeaver/weaver/weavertest/internal/simple/Source.DestinationPids→github.com/ServiceWeaver/weaver/weavertest/internal/simple/Destination.Getpid@simple.go:63:16⟧\n",
+		GeneratorVersion: "(devel)",
+		CodegenVersion:   "v0.27.0",
+		IfaceHash:        "b3794776b37ddb42",
 	})
 }
 
@@ -92,17 +101,23 @@ type __destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_we
 
 type __destination_destRouter_embedding struct{}
 
+func (__destination_destRouter_embedding) Caller()         {}
+func (__destination_destRouter_embedding) Callers()        {}
 func (__destination_destRouter_embedding) GetAll()         {}
 func (__destination_destRouter_embedding) GetMetadata()    {}
 func (__destination_destRouter_embedding) Getpid()         {}
 func (__destination_destRouter_embedding) Record()         {}
+func (__destination_destRouter_embedding) Replica()        {}
 func (__destination_destRouter_embedding) UpdateMetadata() {}
 
 var _ func(_ context.Context, file string, msg string) string = (&destRouter{}).RoutedRecord                         // routed
+var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Caller         // unrouted
+var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Callers        // unrouted
 var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).GetAll         // unrouted
 var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).GetMetadata    // unrouted
 var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Getpid         // unrouted
 var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Record         // unrouted
+var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).Replica        // unrouted
 var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_run_weaver_generate{}).UpdateMetadata // unrouted
 
 // Local stub implementations.
@@ -110,10 +125,14 @@ var _ = (&__destination_destRouter_if_youre_seeing_this_you_probably_forgot_to_r
 type destination_local_stub struct {
 	impl                  Destination
 	tracer                trace.Tracer
+	caller                codegen.Caller
+	callerMetrics         *codegen.MethodMetrics
+	callersMetrics        *codegen.MethodMetrics
 	getAllMetrics         *codegen.MethodMetrics
 	getMetadataMetrics    *codegen.MethodMetrics
 	getpidMetrics         *codegen.MethodMetrics
 	recordMetrics         *codegen.MethodMetrics
+	replicaMetrics        *codegen.MethodMetrics
 	routedRecordMetrics   *codegen.MethodMetrics
 	updateMetadataMetrics *codegen.MethodMetrics
 }
@@ -121,10 +140,62 @@ type destination_local_stub struct {
 // Check that destination_local_stub implements the Destination interface.
 var _ Destination = (*destination_local_stub)(nil)
 
+func (s destination_local_stub) Caller(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	begin := s.callerMetrics.Begin()
+	defer func() { s.callerMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "simple.Destination.Caller", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Caller(ctx)
+}
+
+func (s destination_local_stub) Callers(ctx context.Context) (r0 map[string]int64, err error) {
+	// Update metrics.
+	begin := s.callersMetrics.Begin()
+	defer func() { s.callersMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "simple.Destination.Callers", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Callers(ctx)
+}
+
 func (s destination_local_stub) GetAll(ctx context.Context, a0 string) (r0 []string, err error) {
 	// Update metrics.
 	begin := s.getAllMetrics.Begin()
 	defer func() { s.getAllMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -145,6 +216,10 @@ func (s destination_local_stub) GetMetadata(ctx context.Context) (r0 map[string]
 	// Update metrics.
 	begin := s.getMetadataMetrics.Begin()
 	defer func() { s.getMetadataMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -165,6 +240,10 @@ func (s destination_local_stub) Getpid(ctx context.Context) (r0 int, err error)
 	// Update metrics.
 	begin := s.getpidMetrics.Begin()
 	defer func() { s.getpidMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -185,6 +264,10 @@ func (s destination_local_stub) Record(ctx context.Context, a0 string, a1 string
 	// Update metrics.
 	begin := s.recordMetrics.Begin()
 	defer func() { s.recordMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -201,10 +284,38 @@ func (s destination_local_stub) Record(ctx context.Context, a0 string, a1 string
 	return s.impl.Record(ctx, a0, a1)
 }
 
+func (s destination_local_stub) Replica(ctx context.Context) (r0 string, r1 int, err error) {
+	// Update metrics.
+	begin := s.replicaMetrics.Begin()
+	defer func() { s.replicaMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "simple.Destination.Replica", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.Replica(ctx)
+}
+
 func (s destination_local_stub) RoutedRecord(ctx context.Context, a0 string, a1 string) (err error) {
 	// Update metrics.
 	begin := s.routedRecordMetrics.Begin()
 	defer func() { s.routedRecordMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -225,6 +336,10 @@ func (s destination_local_stub) UpdateMetadata(ctx context.Context) (err error)
 	// Update metrics.
 	begin := s.updateMetadataMetrics.Begin()
 	defer func() { s.updateMetadataMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -244,6 +359,7 @@ func (s destination_local_stub) UpdateMetadata(ctx context.Context) (err error)
 type server_local_stub struct {
 	impl                Server
 	tracer              trace.Tracer
+	caller              codegen.Caller
 	addressMetrics      *codegen.MethodMetrics
 	proxyAddressMetrics *codegen.MethodMetrics
 	shutdownMetrics     *codegen.MethodMetrics
@@ -256,6 +372,10 @@ func (s server_local_stub) Address(ctx context.Context) (r0 string, err error) {
 	// Update metrics.
 	begin := s.addressMetrics.Begin()
 	defer func() { s.addressMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -276,6 +396,10 @@ func (s server_local_stub) ProxyAddress(ctx context.Context) (r0 string, err err
 	// Update metrics.
 	begin := s.proxyAddressMetrics.Begin()
 	defer func() { s.proxyAddressMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -296,6 +420,10 @@ func (s server_local_stub) Shutdown(ctx context.Context) (err error) {
 	// Update metrics.
 	begin := s.shutdownMetrics.Begin()
 	defer func() { s.shutdownMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -313,18 +441,73 @@ func (s server_local_stub) Shutdown(ctx context.Context) (err error) {
 }
 
 type source_local_stub struct {
-	impl        Source
-	tracer      trace.Tracer
-	emitMetrics *codegen.MethodMetrics
+	impl                     Source
+	tracer                   trace.Tracer
+	caller                   codegen.Caller
+	destinationCallerMetrics *codegen.MethodMetrics
+	destinationPidsMetrics   *codegen.MethodMetrics
+	emitMetrics              *codegen.MethodMetrics
 }
 
 // Check that source_local_stub implements the Source interface.
 var _ Source = (*source_local_stub)(nil)
 
+func (s source_local_stub) DestinationCaller(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	begin := s.destinationCallerMetrics.Begin()
+	defer func() { s.destinationCallerMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "simple.Source.DestinationCaller", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.DestinationCaller(ctx)
+}
+
+func (s source_local_stub) DestinationPids(ctx context.Context) (r0 []int, err error) {
+	// Update metrics.
+	begin := s.destinationPidsMetrics.Begin()
+	defer func() { s.destinationPidsMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.tracer.Start(ctx, "simple.Source.DestinationPids", trace.WithSpanKind(trace.SpanKindInternal))
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			}
+			span.End()
+		}()
+	}
+
+	return s.impl.DestinationPids(ctx)
+}
+
 func (s source_local_stub) Emit(ctx context.Context, a0 string, a1 string) (err error) {
 	// Update metrics.
 	begin := s.emitMetrics.Begin()
 	defer func() { s.emitMetrics.End(begin, err != nil, 0, 0) }()
+
+	// Record the caller.
+	ctx = codegen.WithCaller(ctx, s.caller)
+
 	span := trace.SpanFromContext(ctx)
 	if span.SpanContext().IsValid() {
 		// Create a child span for this method.
@@ -345,10 +528,13 @@ func (s source_local_stub) Emit(ctx context.Context, a0 string, a1 string) (err
 
 type destination_client_stub struct {
 	stub                  codegen.Stub
+	callerMetrics         *codegen.MethodMetrics
+	callersMetrics        *codegen.MethodMetrics
 	getAllMetrics         *codegen.MethodMetrics
 	getMetadataMetrics    *codegen.MethodMetrics
 	getpidMetrics         *codegen.MethodMetrics
 	recordMetrics         *codegen.MethodMetrics
+	replicaMetrics        *codegen.MethodMetrics
 	routedRecordMetrics   *codegen.MethodMetrics
 	updateMetadataMetrics *codegen.MethodMetrics
 }
@@ -356,6 +542,101 @@ type destination_client_stub struct {
 // Check that destination_client_stub implements the Destination interface.
 var _ Destination = (*destination_client_stub)(nil)
 
+func (s destination_client_stub) Caller(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.callerMetrics.Begin()
+	defer func() { s.callerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "simple.Destination.Caller", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s destination_client_stub) Callers(ctx context.Context) (r0 map[string]int64, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.callersMetrics.Begin()
+	defer func() { s.callersMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "simple.Destination.Callers", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_map_string_int64_048c612c(dec)
+	err = dec.Error()
+	return
+}
+
 func (s destination_client_stub) GetAll(ctx context.Context, a0 string) (r0 []string, err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -388,7 +669,7 @@ func (s destination_client_stub) GetAll(ctx context.Context, a0 string) (r0 []st
 	// Preallocate a buffer of the right size.
 	size := 0
 	size += (4 + len(a0))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -398,7 +679,8 @@ func (s destination_client_stub) GetAll(ctx context.Context, a0 string) (r0 []st
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -407,6 +689,7 @@ func (s destination_client_stub) GetAll(ctx context.Context, a0 string) (r0 []st
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_slice_string_4af10117(dec)
 	err = dec.Error()
 	return
@@ -445,7 +728,7 @@ func (s destination_client_stub) GetMetadata(ctx context.Context) (r0 map[string
 
 	// Call the remote method.
 	var results []byte
-	results, err = s.stub.Run(ctx, 1, nil, shardKey)
+	results, err = s.stub.Run(ctx, 3, nil, shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -454,6 +737,7 @@ func (s destination_client_stub) GetMetadata(ctx context.Context) (r0 map[string
 
 	// Decode the results.
 	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
 	r0 = serviceweaver_dec_map_string_string_219dd46d(dec)
 	err = dec.Error()
 	return
@@ -492,7 +776,7 @@ func (s destination_client_stub) Getpid(ctx context.Context) (r0 int, err error)
 
 	// Call the remote method.
 	var results []byte
-	results, err = s.stub.Run(ctx, 2, nil, shardKey)
+	results, err = s.stub.Run(ctx, 4, nil, shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -539,7 +823,7 @@ func (s destination_client_stub) Record(ctx context.Context, a0 string, a1 strin
 	size := 0
 	size += (4 + len(a0))
 	size += (4 + len(a1))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -550,7 +834,8 @@ func (s destination_client_stub) Record(ctx context.Context, a0 string, a1 strin
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 3, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 5, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -563,6 +848,54 @@ func (s destination_client_stub) Record(ctx context.Context, a0 string, a1 strin
 	return
 }
 
+func (s destination_client_stub) Replica(ctx context.Context) (r0 string, r1 int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.replicaMetrics.Begin()
+	defer func() { s.replicaMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "simple.Destination.Replica", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 6, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	r1 = dec.Int()
+	err = dec.Error()
+	return
+}
+
 func (s destination_client_stub) RoutedRecord(ctx context.Context, a0 string, a1 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -596,7 +929,7 @@ func (s destination_client_stub) RoutedRecord(ctx context.Context, a0 string, a1
 	size := 0
 	size += (4 + len(a0))
 	size += (4 + len(a1))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -610,7 +943,8 @@ func (s destination_client_stub) RoutedRecord(ctx context.Context, a0 string, a1
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 4, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 7, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -656,7 +990,7 @@ func (s destination_client_stub) UpdateMetadata(ctx context.Context) (err error)
 
 	// Call the remote method.
 	var results []byte
-	results, err = s.stub.Run(ctx, 5, nil, shardKey)
+	results, err = s.stub.Run(ctx, 8, nil, shardKey)
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -820,13 +1154,110 @@ func (s server_client_stub) Shutdown(ctx context.Context) (err error) {
 }
 
 type source_client_stub struct {
-	stub        codegen.Stub
-	emitMetrics *codegen.MethodMetrics
+	stub                     codegen.Stub
+	destinationCallerMetrics *codegen.MethodMetrics
+	destinationPidsMetrics   *codegen.MethodMetrics
+	emitMetrics              *codegen.MethodMetrics
 }
 
 // Check that source_client_stub implements the Source interface.
 var _ Source = (*source_client_stub)(nil)
 
+func (s source_client_stub) DestinationCaller(ctx context.Context) (r0 string, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.destinationCallerMetrics.Begin()
+	defer func() { s.destinationCallerMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "simple.Source.DestinationCaller", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 0, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	r0 = dec.String()
+	err = dec.Error()
+	return
+}
+
+func (s source_client_stub) DestinationPids(ctx context.Context) (r0 []int, err error) {
+	// Update metrics.
+	var requestBytes, replyBytes int
+	begin := s.destinationPidsMetrics.Begin()
+	defer func() { s.destinationPidsMetrics.End(begin, err != nil, requestBytes, replyBytes) }()
+
+	span := trace.SpanFromContext(ctx)
+	if span.SpanContext().IsValid() {
+		// Create a child span for this method.
+		ctx, span = s.stub.Tracer().Start(ctx, "simple.Source.DestinationPids", trace.WithSpanKind(trace.SpanKindClient))
+	}
+
+	defer func() {
+		// Catch and return any panics detected during encoding/decoding/rpc.
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+			if err != nil {
+				err = errors.Join(weaver.RemoteCallError, err)
+			}
+		}
+
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+	}()
+
+	var shardKey uint64
+
+	// Call the remote method.
+	var results []byte
+	results, err = s.stub.Run(ctx, 1, nil, shardKey)
+	replyBytes = len(results)
+	if err != nil {
+		err = errors.Join(weaver.RemoteCallError, err)
+		return
+	}
+
+	// Decode the results.
+	dec := codegen.NewDecoder(results)
+	dec.SetProgress(codegen.ProgressFromContext(ctx))
+	r0 = serviceweaver_dec_slice_int_7c8c8866(dec)
+	err = dec.Error()
+	return
+}
+
 func (s source_client_stub) Emit(ctx context.Context, a0 string, a1 string) (err error) {
 	// Update metrics.
 	var requestBytes, replyBytes int
@@ -860,7 +1291,7 @@ func (s source_client_stub) Emit(ctx context.Context, a0 string, a1 string) (err
 	size := 0
 	size += (4 + len(a0))
 	size += (4 + len(a1))
-	enc := codegen.NewEncoder()
+	enc := codegen.NewPooledEncoder()
 	enc.Reset(size)
 
 	// Encode arguments.
@@ -871,7 +1302,8 @@ func (s source_client_stub) Emit(ctx context.Context, a0 string, a1 string) (err
 	// Call the remote method.
 	requestBytes = len(enc.Data())
 	var results []byte
-	results, err = s.stub.Run(ctx, 0, enc.Data(), shardKey)
+	results, err = s.stub.Run(ctx, 2, enc.Data(), shardKey)
+	enc.Release()
 	replyBytes = len(results)
 	if err != nil {
 		err = errors.Join(weaver.RemoteCallError, err)
@@ -887,10 +1319,10 @@ func (s source_client_stub) Emit(ctx context.Context, a0 string, a1 string) (err
 // Note that "weaver generate" will always generate the error message below.
 // Everything is okay. The error message is only relevant if you see it when
 // you run "go build" or "go run".
-var _ codegen.LatestVersion = codegen.Version[[0][24]struct{}](`
+var _ codegen.LatestVersion = codegen.Version[[0][27]struct{}](`
 
 ERROR: You generated this file with 'weaver generate' (devel) (codegen
-version v0.24.0). The generated code is incompatible with the version of the
+version v0.27.0). The generated code is incompatible with the version of the
 github.com/ServiceWeaver/weaver module that you're using. The weaver module
 version can be found in your go.mod file or by running the following command.
 
@@ -920,6 +1352,10 @@ var _ codegen.Server = (*destination_server_stub)(nil)
 // GetStubFn implements the codegen.Server interface.
 func (s destination_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
+	case "Caller":
+		return s.caller
+	case "Callers":
+		return s.callers
 	case "GetAll":
 		return s.getAll
 	case "GetMetadata":
@@ -928,6 +1364,8 @@ func (s destination_server_stub) GetStubFn(method string) func(ctx context.Conte
 		return s.getpid
 	case "Record":
 		return s.record
+	case "Replica":
+		return s.replica
 	case "RoutedRecord":
 		return s.routedRecord
 	case "UpdateMetadata":
@@ -937,6 +1375,46 @@ func (s destination_server_stub) GetStubFn(method string) func(ctx context.Conte
 	}
 }
 
+func (s destination_server_stub) caller(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Caller(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s destination_server_stub) callers(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.Callers(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_map_string_int64_048c612c(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s destination_server_stub) getAll(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1028,6 +1506,27 @@ func (s destination_server_stub) record(ctx context.Context, args []byte) (res [
 	return enc.Data(), nil
 }
 
+func (s destination_server_stub) replica(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, r1, appErr := s.impl.Replica(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Int(r1)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s destination_server_stub) routedRecord(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1167,6 +1666,10 @@ var _ codegen.Server = (*source_server_stub)(nil)
 // GetStubFn implements the codegen.Server interface.
 func (s source_server_stub) GetStubFn(method string) func(ctx context.Context, args []byte) ([]byte, error) {
 	switch method {
+	case "DestinationCaller":
+		return s.destinationCaller
+	case "DestinationPids":
+		return s.destinationPids
 	case "Emit":
 		return s.emit
 	default:
@@ -1174,6 +1677,46 @@ func (s source_server_stub) GetStubFn(method string) func(ctx context.Context, a
 	}
 }
 
+func (s source_server_stub) destinationCaller(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.DestinationCaller(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	enc.String(r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
+func (s source_server_stub) destinationPids(ctx context.Context, args []byte) (res []byte, err error) {
+	// Catch and return any panics detected during encoding/decoding/rpc.
+	defer func() {
+		if err == nil {
+			err = codegen.CatchPanics(recover())
+		}
+	}()
+
+	// TODO(rgrandl): The deferred function above will recover from panics in the
+	// user code: fix this.
+	// Call the local method.
+	r0, appErr := s.impl.DestinationPids(ctx)
+
+	// Encode the results.
+	enc := codegen.NewEncoder()
+	serviceweaver_enc_slice_int_7c8c8866(enc, r0)
+	enc.Error(appErr)
+	return enc.Data(), nil
+}
+
 func (s source_server_stub) emit(ctx context.Context, args []byte) (res []byte, err error) {
 	// Catch and return any panics detected during encoding/decoding/rpc.
 	defer func() {
@@ -1209,6 +1752,16 @@ type destination_reflect_stub struct {
 // Check that destination_reflect_stub implements the Destination interface.
 var _ Destination = (*destination_reflect_stub)(nil)
 
+func (s destination_reflect_stub) Caller(ctx context.Context) (r0 string, err error) {
+	err = s.caller("Caller", ctx, []any{}, []any{&r0})
+	return
+}
+
+func (s destination_reflect_stub) Callers(ctx context.Context) (r0 map[string]int64, err error) {
+	err = s.caller("Callers", ctx, []any{}, []any{&r0})
+	return
+}
+
 func (s destination_reflect_stub) GetAll(ctx context.Context, a0 string) (r0 []string, err error) {
 	err = s.caller("GetAll", ctx, []any{a0}, []any{&r0})
 	return
@@ -1229,6 +1782,11 @@ func (s destination_reflect_stub) Record(ctx context.Context, a0 string, a1 stri
 	return
 }
 
+func (s destination_reflect_stub) Replica(ctx context.Context) (r0 string, r1 int, err error) {
+	err = s.caller("Replica", ctx, []any{}, []any{&r0, &r1})
+	return
+}
+
 func (s destination_reflect_stub) RoutedRecord(ctx context.Context, a0 string, a1 string) (err error) {
 	err = s.caller("RoutedRecord", ctx, []any{a0, a1}, []any{})
 	return
@@ -1268,6 +1826,16 @@ type source_reflect_stub struct {
 // Check that source_reflect_stub implements the Source interface.
 var _ Source = (*source_reflect_stub)(nil)
 
+func (s source_reflect_stub) DestinationCaller(ctx context.Context) (r0 string, err error) {
+	err = s.caller("DestinationCaller", ctx, []any{}, []any{&r0})
+	return
+}
+
+func (s source_reflect_stub) DestinationPids(ctx context.Context) (r0 []int, err error) {
+	err = s.caller("DestinationPids", ctx, []any{}, []any{&r0})
+	return
+}
+
 func (s source_reflect_stub) Emit(ctx context.Context, a0 string, a1 string) (err error) {
 	err = s.caller("Emit", ctx, []any{a0, a1}, []any{})
 	return
@@ -1291,15 +1859,52 @@ func _orderedCodeDestination(r string) codegen.OrderedCode {
 
 // Encoding/decoding implementations.
 
-func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
+func serviceweaver_enc_map_string_int64_048c612c(enc *codegen.Encoder, arg map[string]int64) {
 	if arg == nil {
 		enc.Len(-1)
 		return
 	}
 	enc.Len(len(arg))
-	for i := 0; i < len(arg); i++ {
-		enc.String(arg[i])
+	m := enc.Map()
+	for k, v := range arg {
+		m.Key()
+		enc.String(k)
+		m.Value()
+		enc.Int64(v)
+	}
+	m.End()
+}
+
+func serviceweaver_dec_map_string_int64_048c612c(dec *codegen.Decoder) map[string]int64 {
+	n := dec.Len()
+	if n == -1 {
+		return nil
 	}
+	dec.CheckLen(n, 12)
+	res := make(map[string]int64, n)
+	var k string
+	var v int64
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k = dec.String()
+			v = dec.Int64()
+			res[k] = v
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_slice_string_4af10117(enc *codegen.Encoder, arg []string) {
+	if arg == nil {
+		enc.Len(-1)
+		return
+	}
+	enc.Len(len(arg))
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.String(arg[i])
+		}
+	})
 }
 
 func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
@@ -1307,10 +1912,13 @@ func serviceweaver_dec_slice_string_4af10117(dec *codegen.Decoder) []string {
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 4)
 	res := make([]string, n)
-	for i := 0; i < n; i++ {
-		res[i] = dec.String()
-	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.String()
+		}
+	})
 	return res
 }
 
@@ -1320,10 +1928,14 @@ func serviceweaver_enc_map_string_string_219dd46d(enc *codegen.Encoder, arg map[
 		return
 	}
 	enc.Len(len(arg))
+	m := enc.Map()
 	for k, v := range arg {
+		m.Key()
 		enc.String(k)
+		m.Value()
 		enc.String(v)
 	}
+	m.End()
 }
 
 func serviceweaver_dec_map_string_string_219dd46d(dec *codegen.Decoder) map[string]string {
@@ -1331,13 +1943,50 @@ func serviceweaver_dec_map_string_string_219dd46d(dec *codegen.Decoder) map[stri
 	if n == -1 {
 		return nil
 	}
+	dec.CheckLen(n, 8)
 	res := make(map[string]string, n)
 	var k string
 	var v string
-	for i := 0; i < n; i++ {
-		k = dec.String()
-		v = dec.String()
-		res[k] = v
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			k = dec.String()
+			v = dec.String()
+			res[k] = v
+		}
+	})
+	return res
+}
+
+func serviceweaver_enc_slice_int_7c8c8866(enc *codegen.Encoder, arg []int) {
+	if arg == nil {
+		enc.Len(-1)
+		return
 	}
+	enc.Len(len(arg))
+	if codegen.EncodeFixed(enc, arg) {
+		return
+	}
+	enc.Chunks(len(arg), func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			enc.Int(arg[i])
+		}
+	})
+}
+
+func serviceweaver_dec_slice_int_7c8c8866(dec *codegen.Decoder) []int {
+	n := dec.Len()
+	if n == -1 {
+		return nil
+	}
+	dec.CheckLen(n, 8)
+	res := make([]int, n)
+	if codegen.DecodeFixed[int](dec, res) {
+		return res
+	}
+	dec.Chunks(n, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			res[i] = dec.Int()
+		}
+	})
 	return res
 }
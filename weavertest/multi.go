@@ -25,7 +25,6 @@ import (
 
 	"github.com/ServiceWeaver/weaver/internal/weaver"
 	"github.com/ServiceWeaver/weaver/runtime"
-	"github.com/ServiceWeaver/weaver/runtime/codegen"
 	"github.com/ServiceWeaver/weaver/runtime/protos"
 	"github.com/google/uuid"
 )
@@ -60,7 +59,7 @@ func initMultiProcess(ctx context.Context, t testing.TB, isBench bool, runner Ru
 		}()
 
 		opts := weaver.RemoteWeaveletOptions{}
-		wlet, err := weaver.NewRemoteWeavelet(ctx, codegen.Registered(), bootstrap, opts)
+		wlet, err := weaver.NewRemoteWeavelet(ctx, runner.registry().Registered(), bootstrap, opts)
 		if err != nil {
 			panic(err)
 		}
@@ -71,7 +70,7 @@ func initMultiProcess(ctx context.Context, t testing.TB, isBench bool, runner Ru
 	appConfig := &protos.AppConfig{}
 	if runner.Config != "" {
 		var err error
-		appConfig, err = runtime.ParseConfig("[testconfig]", runner.Config, codegen.ComponentConfigValidator)
+		appConfig, err = runtime.ParseConfig("[testconfig]", runner.Config, runner.registry().ConfigValidator)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weavertest
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/ServiceWeaver/weaver"
+)
+
+// FakeNotifier is a fake implementation of the weaver.Notifier component that
+// captures notifications instead of sending them. The zero value is ready to
+// use. For example:
+//
+//	notifier := &weavertest.FakeNotifier{}
+//	runner := weavertest.Local
+//	runner.Fakes = append(runner.Fakes, weavertest.Fake[weaver.Notifier](notifier))
+//	runner.Test(t, func(t *testing.T, signup Signup) {
+//	    ...
+//	    if got := notifier.Notifications(); len(got) != 1 {
+//	        t.Fatalf("got %d notifications, want 1", len(got))
+//	    }
+//	})
+//
+// A FakeNotifier can also be used as a fake in the simulator.
+type FakeNotifier struct {
+	mu            sync.Mutex
+	notifications []weaver.Notification
+}
+
+var _ weaver.Notifier = &FakeNotifier{}
+
+// Notify implements the weaver.Notifier interface.
+func (f *FakeNotifier) Notify(_ context.Context, n weaver.Notification) error {
+	n.To = slices.Clone(n.To)
+	n.Data = maps.Clone(n.Data)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = append(f.notifications, n)
+	return nil
+}
+
+// Notifications returns the captured notifications, in the order they were
+// sent.
+func (f *FakeNotifier) Notifications() []weaver.Notification {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.notifications)
+}
+
+// Reset discards the captured notifications.
+func (f *FakeNotifier) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.notifications = nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package weavertest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ServiceWeaver/weaver"
+	"github.com/ServiceWeaver/weaver/weavertest"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestFakeNotifier(t *testing.T) {
+	for _, runner := range weavertest.AllRunners() {
+		fake := &weavertest.FakeNotifier{}
+		runner.Fakes = append(runner.Fakes, weavertest.Fake[weaver.Notifier](fake))
+		runner.Test(t, func(t *testing.T, notifier weaver.Notifier) {
+			ctx := context.Background()
+			data := map[string]string{"name": "Ada"}
+			n := weaver.Notification{Channel: "email", To: []string{"ada@example.com"}, Template: "welcome", Data: data}
+			if err := notifier.Notify(ctx, n); err != nil {
+				t.Fatal(err)
+			}
+			data["name"] = "Eve"
+
+			want := []weaver.Notification{{
+				Channel:  "email",
+				To:       []string{"ada@example.com"},
+				Template: "welcome",
+				Data:     map[string]string{"name": "Ada"},
+			}}
+			if diff := cmp.Diff(want, fake.Notifications(), cmpopts.IgnoreUnexported(weaver.Notification{})); diff != "" {
+				t.Fatalf("Notifications (-want +got):\n%s", diff)
+			}
+			fake.Reset()
+			if got := fake.Notifications(); len(got) != 0 {
+				t.Fatalf("Notifications after Reset: got %v, want none", got)
+			}
+		})
+	}
+}
@@ -158,6 +158,11 @@ func (h *handler) HandleTraceSpans(context.Context, *protos.TraceSpans) error {
 	return nil
 }
 
+func (*handler) GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	// This simplified deployer doesn't collect metrics.
+	return nil, fmt.Errorf("topology not supported")
+}
+
 // Responsibility 4: Security.
 func (*handler) GetSelfCertificate(context.Context, *protos.GetSelfCertificateRequest) (*protos.GetSelfCertificateReply, error) {
 	// This deployer doesn't enable mTLS.
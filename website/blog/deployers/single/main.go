@@ -112,6 +112,11 @@ func (d *deployer) HandleTraceSpans(context.Context, *protos.TraceSpans) error {
 	return nil
 }
 
+func (*deployer) GetTopology(context.Context, *protos.GetTopologyRequest) (*protos.GetTopologyReply, error) {
+	// This simplified deployer doesn't collect metrics.
+	return nil, fmt.Errorf("topology not supported")
+}
+
 // Responsibility 4: Security.
 func (*deployer) GetSelfCertificate(context.Context, *protos.GetSelfCertificateRequest) (*protos.GetSelfCertificateReply, error) {
 	// This deployer doesn't enable mTLS.